@@ -18,7 +18,7 @@ func (c *TokenAuth) Create(opts *TokenCreateRequest) (*Secret, error) {
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -35,7 +35,27 @@ func (c *TokenAuth) CreateOrphan(opts *TokenCreateRequest) (*Secret, error) {
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+// CreateRestricted creates a token whose policies must be a subset of those
+// of the calling token, further restricted to the rules of the inline policy
+// of the request
+func (c *TokenAuth) CreateRestricted(opts *TokenCreateRequest) (*Secret, error) {
+	r := c.c.NewRequest("POST", "/v1/auth/token/create-restricted")
+	if err := r.SetJSONBody(opts); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -52,7 +72,7 @@ func (c *TokenAuth) CreateWithRole(opts *TokenCreateRequest, roleName string) (*
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -71,7 +91,7 @@ func (c *TokenAuth) Lookup(token string) (*Secret, error) {
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -82,6 +102,39 @@ func (c *TokenAuth) Lookup(token string) (*Secret, error) {
 	return ParseSecret(resp.Body)
 }
 
+// ListAccessors lists the accessors of all outstanding tokens. This requires
+// sudo capability on auth/token/accessors.
+func (c *TokenAuth) ListAccessors() ([]string, error) {
+	r := c.c.NewRequest("LIST", "/v1/auth/token/accessors")
+	r.Method = "GET"
+	r.Params.Set("list", "true")
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var result []string
+	keys, _ := secret.Data["keys"].([]interface{})
+	for _, k := range keys {
+		if accessor, ok := k.(string); ok {
+			result = append(result, accessor)
+		}
+	}
+	return result, nil
+}
+
 func (c *TokenAuth) LookupAccessor(accessor string) (*Secret, error) {
 	r := c.c.NewRequest("POST", "/v1/auth/token/lookup-accessor")
 	if err := r.SetJSONBody(map[string]interface{}{
@@ -90,7 +143,7 @@ func (c *TokenAuth) LookupAccessor(accessor string) (*Secret, error) {
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -104,7 +157,7 @@ func (c *TokenAuth) LookupAccessor(accessor string) (*Secret, error) {
 func (c *TokenAuth) LookupSelf() (*Secret, error) {
 	r := c.c.NewRequest("GET", "/v1/auth/token/lookup-self")
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -124,7 +177,7 @@ func (c *TokenAuth) Renew(token string, increment int) (*Secret, error) {
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -143,7 +196,7 @@ func (c *TokenAuth) RenewSelf(increment int) (*Secret, error) {
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -165,7 +218,7 @@ func (c *TokenAuth) RenewTokenAsSelf(token string, increment int) (*Secret, erro
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -186,7 +239,7 @@ func (c *TokenAuth) RevokeAccessor(accessor string) error {
 		return err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -207,7 +260,7 @@ func (c *TokenAuth) RevokeOrphan(token string) error {
 		return err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -224,7 +277,7 @@ func (c *TokenAuth) RevokeOrphan(token string) error {
 func (c *TokenAuth) RevokeSelf(token string) error {
 	r := c.c.NewRequest("PUT", "/v1/auth/token/revoke-self")
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -246,7 +299,7 @@ func (c *TokenAuth) RevokeTree(token string) error {
 		return err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -259,16 +312,18 @@ func (c *TokenAuth) RevokeTree(token string) error {
 
 // TokenCreateRequest is the options structure for creating a token.
 type TokenCreateRequest struct {
-	ID              string            `json:"id,omitempty"`
-	Policies        []string          `json:"policies,omitempty"`
-	Metadata        map[string]string `json:"meta,omitempty"`
-	Lease           string            `json:"lease,omitempty"`
-	TTL             string            `json:"ttl,omitempty"`
-	ExplicitMaxTTL  string            `json:"explicit_max_ttl,omitempty"`
-	Period          string            `json:"period,omitempty"`
-	NoParent        bool              `json:"no_parent,omitempty"`
-	NoDefaultPolicy bool              `json:"no_default_policy,omitempty"`
-	DisplayName     string            `json:"display_name"`
-	NumUses         int               `json:"num_uses"`
-	Renewable       *bool             `json:"renewable,omitempty"`
+	ID                     string            `json:"id,omitempty"`
+	Policies               []string          `json:"policies,omitempty"`
+	Metadata               map[string]string `json:"meta,omitempty"`
+	Lease                  string            `json:"lease,omitempty"`
+	TTL                    string            `json:"ttl,omitempty"`
+	ExplicitMaxTTL         string            `json:"explicit_max_ttl,omitempty"`
+	Period                 string            `json:"period,omitempty"`
+	NoParent               bool              `json:"no_parent,omitempty"`
+	NoDefaultPolicy        bool              `json:"no_default_policy,omitempty"`
+	OrphanChildrenOnRevoke bool              `json:"orphan_children_on_revoke,omitempty"`
+	DisplayName            string            `json:"display_name"`
+	NumUses                int               `json:"num_uses"`
+	Renewable              *bool             `json:"renewable,omitempty"`
+	InlinePolicy           string            `json:"inline_policy,omitempty"`
 }
@@ -0,0 +1,51 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/strutil"
+)
+
+func TestTokenAuth_Accessors(t *testing.T) {
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+		Policies: []string{"default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessor := secret.Auth.Accessor
+
+	accessors, err := client.Auth().Token().ListAccessors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strutil.StrListContains(accessors, accessor) {
+		t.Fatalf("accessor %q not listed in %v", accessor, accessors)
+	}
+
+	lookup, err := client.Auth().Token().LookupAccessor(accessor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookup.Data["id"] != "" || lookup.Data["accessor"] != accessor {
+		t.Fatalf("bad: %#v", lookup.Data)
+	}
+
+	if err := client.Auth().Token().RevokeAccessor(accessor); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Auth().Token().LookupAccessor(accessor); err == nil {
+		t.Fatal("expected lookup of revoked accessor to fail")
+	}
+	accessors, err = client.Auth().Token().ListAccessors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strutil.StrListContains(accessors, accessor) {
+		t.Fatalf("revoked accessor %q still listed in %v", accessor, accessors)
+	}
+}
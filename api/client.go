@@ -35,6 +35,7 @@ const EnvVaultTLSServerName = "VAULT_TLS_SERVER_NAME"
 const EnvVaultWrapTTL = "VAULT_WRAP_TTL"
 const EnvVaultMaxRetries = "VAULT_MAX_RETRIES"
 const EnvVaultToken = "VAULT_TOKEN"
+const EnvVaultNamespace = "VAULT_NAMESPACE"
 const EnvVaultMFA = "VAULT_MFA"
 const EnvRateLimit = "VAULT_RATE_LIMIT"
 
@@ -77,6 +78,17 @@ type Config struct {
 	// The Backoff function to use; a default is used if not provided
 	Backoff retryablehttp.Backoff
 
+	// MinRetryWait and MaxRetryWait are passed to the Backoff function as the
+	// bounds of the wait between retries. They default to 1s and 1.5s.
+	MinRetryWait time.Duration
+	MaxRetryWait time.Duration
+
+	// CheckRetry decides whether a request is retried; a default is used if
+	// not provided. The default retries connection errors and 5xx responses
+	// other than 501, and 429 responses telling when to retry with a
+	// Retry-After header.
+	CheckRetry retryablehttp.CheckRetry
+
 	// Limiter is the rate limiter used by the client.
 	// If this pointer is nil, then there will be no limit set.
 	// In contrast, if this pointer is set, even to an empty struct,
@@ -153,6 +165,8 @@ func DefaultConfig() *Config {
 
 	config.Backoff = retryablehttp.LinearJitterBackoff
 	config.MaxRetries = 2
+	config.MinRetryWait = 1000 * time.Millisecond
+	config.MaxRetryWait = 1500 * time.Millisecond
 
 	return config
 }
@@ -331,6 +345,8 @@ type Client struct {
 	wrappingLookupFunc WrappingLookupFunc
 	mfaCreds           []string
 	policyOverride     bool
+	namespace          string
+	ctx                context.Context
 }
 
 // NewClient returns a new client for the given configuration.
@@ -369,6 +385,24 @@ func NewClient(c *Config) (*Client, error) {
 		c.HttpClient.Transport = def.HttpClient.Transport
 	}
 
+	// Addresses of the form unix:///path/to/socket dial the Unix domain
+	// socket; requests themselves are still plain HTTP
+	if u.Scheme == "unix" {
+		transport, ok := c.HttpClient.Transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("unix socket addresses require an *http.Transport")
+		}
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		u = &url.URL{
+			Scheme: "http",
+			Host:   "localhost",
+		}
+	}
+
 	client := &Client{
 		addr:   u,
 		config: c,
@@ -378,6 +412,10 @@ func NewClient(c *Config) (*Client, error) {
 		client.token = token
 	}
 
+	if namespace := os.Getenv(EnvVaultNamespace); namespace != "" {
+		client.namespace = namespace
+	}
+
 	return client, nil
 }
 
@@ -490,6 +528,25 @@ func (c *Client) ClearToken() {
 	c.token = ""
 }
 
+// Namespace returns the namespace requests are made within. It will return
+// the empty string for the root namespace.
+func (c *Client) Namespace() string {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+
+	return c.namespace
+}
+
+// SetNamespace sets the namespace future requests are made within. Setting
+// this on a client will override the value of the VAULT_NAMESPACE
+// environment variable.
+func (c *Client) SetNamespace(namespace string) {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	c.namespace = namespace
+}
+
 // SetHeaders sets the headers to be used for future requests.
 func (c *Client) SetHeaders(headers http.Header) {
 	c.modifyLock.Lock()
@@ -508,6 +565,108 @@ func (c *Client) SetBackoff(backoff retryablehttp.Backoff) {
 	c.config.Backoff = backoff
 }
 
+// SetRetryWait sets the bounds of the wait between retries of future
+// requests.
+func (c *Client) SetRetryWait(min, max time.Duration) {
+	c.modifyLock.RLock()
+	c.config.modifyLock.Lock()
+	defer c.config.modifyLock.Unlock()
+	c.modifyLock.RUnlock()
+
+	c.config.MinRetryWait = min
+	c.config.MaxRetryWait = max
+}
+
+// SetCheckRetry sets the function deciding whether future requests are
+// retried.
+func (c *Client) SetCheckRetry(checkRetry retryablehttp.CheckRetry) {
+	c.modifyLock.RLock()
+	c.config.modifyLock.Lock()
+	defer c.config.modifyLock.Unlock()
+	c.modifyLock.RUnlock()
+
+	c.config.CheckRetry = checkRetry
+}
+
+// WithContext returns a copy of the client whose requests are made with the
+// given context, so that they are canceled along with it. The copy shares the
+// configuration of the client, while its token, headers and namespace can be
+// changed independently.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	r := c.shallowCopy()
+	r.ctx = ctx
+	return r
+}
+
+// WithWrappingTTL returns a copy of the client whose responses are all
+// wrapped with the given TTL, such as "5m". The copy shares the configuration
+// of the client, while its token, headers and namespace can be changed
+// independently.
+func (c *Client) WithWrappingTTL(ttl string) *Client {
+	r := c.shallowCopy()
+	r.wrappingLookupFunc = func(operation, path string) string {
+		return ttl
+	}
+	return r
+}
+
+// WithReason returns a copy of the client whose requests carry the given
+// justification, which is recorded in the audit log
+func (c *Client) WithReason(reason string) *Client {
+	r := c.shallowCopy()
+	headers := make(http.Header, len(r.headers)+1)
+	for k, vals := range r.headers {
+		headers[k] = append([]string(nil), vals...)
+	}
+	headers.Set("X-Vault-Reason", reason)
+	r.headers = headers
+	return r
+}
+
+// WithIdempotencyKey returns a copy of the client whose requests carry the
+// given idempotency key, so that retrying a request for credentials returns
+// the lease issued the first time rather than a new one
+func (c *Client) WithIdempotencyKey(key string) *Client {
+	r := c.shallowCopy()
+	headers := make(http.Header, len(r.headers)+1)
+	for k, vals := range r.headers {
+		headers[k] = append([]string(nil), vals...)
+	}
+	headers.Set("X-Vault-Idempotency-Key", key)
+	r.headers = headers
+	return r
+}
+
+// shallowCopy returns a copy of the client sharing its configuration
+func (c *Client) shallowCopy() *Client {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+
+	return &Client{
+		addr:               c.addr,
+		config:             c.config,
+		token:              c.token,
+		headers:            c.headers,
+		wrappingLookupFunc: c.wrappingLookupFunc,
+		mfaCreds:           c.mfaCreds,
+		policyOverride:     c.policyOverride,
+		namespace:          c.namespace,
+		ctx:                c.ctx,
+	}
+}
+
+// baseContext returns the context given to WithContext, which requests are
+// made with
+func (c *Client) baseContext() context.Context {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 // Clone creates a new client with the same configuration. Note that the same
 // underlying http.Client is used; modifying the client from more than one
 // goroutine at once may not be safe, so modify the client as needed and then
@@ -519,12 +678,15 @@ func (c *Client) Clone() (*Client, error) {
 	c.modifyLock.RUnlock()
 
 	newConfig := &Config{
-		Address:    config.Address,
-		HttpClient: config.HttpClient,
-		MaxRetries: config.MaxRetries,
-		Timeout:    config.Timeout,
-		Backoff:    config.Backoff,
-		Limiter:    config.Limiter,
+		Address:      config.Address,
+		HttpClient:   config.HttpClient,
+		MaxRetries:   config.MaxRetries,
+		Timeout:      config.Timeout,
+		Backoff:      config.Backoff,
+		MinRetryWait: config.MinRetryWait,
+		MaxRetryWait: config.MaxRetryWait,
+		CheckRetry:   config.CheckRetry,
+		Limiter:      config.Limiter,
 	}
 	config.modifyLock.RUnlock()
 
@@ -552,6 +714,7 @@ func (c *Client) NewRequest(method, requestPath string) *Request {
 	wrappingLookupFunc := c.wrappingLookupFunc
 	headers := c.headers
 	policyOverride := c.policyOverride
+	namespace := c.namespace
 	c.modifyLock.RUnlock()
 
 	// if SRV records exist (see https://tools.ietf.org/html/draft-andrews-http-srv-02), lookup the SRV
@@ -600,6 +763,7 @@ func (c *Client) NewRequest(method, requestPath string) *Request {
 	}
 
 	req.PolicyOverride = policyOverride
+	req.Namespace = namespace
 
 	return req
 }
@@ -608,7 +772,7 @@ func (c *Client) NewRequest(method, requestPath string) *Request {
 // a Vault server not configured with this client. This is an advanced operation
 // that generally won't need to be called externally.
 func (c *Client) RawRequest(r *Request) (*Response, error) {
-	return c.RawRequestWithContext(context.Background(), r)
+	return c.RawRequestWithContext(c.baseContext(), r)
 }
 
 // RawRequestWithContext performs the raw request given. This request may be against
@@ -622,6 +786,9 @@ func (c *Client) RawRequestWithContext(ctx context.Context, r *Request) (*Respon
 	limiter := c.config.Limiter
 	maxRetries := c.config.MaxRetries
 	backoff := c.config.Backoff
+	minRetryWait := c.config.MinRetryWait
+	maxRetryWait := c.config.MaxRetryWait
+	checkRetry := c.config.CheckRetry
 	httpClient := c.config.HttpClient
 	timeout := c.config.Timeout
 	c.config.modifyLock.RUnlock()
@@ -658,14 +825,21 @@ START:
 	if backoff == nil {
 		backoff = retryablehttp.LinearJitterBackoff
 	}
+	if minRetryWait == 0 && maxRetryWait == 0 {
+		minRetryWait = 1000 * time.Millisecond
+		maxRetryWait = 1500 * time.Millisecond
+	}
+	if checkRetry == nil {
+		checkRetry = DefaultRetryPolicy
+	}
 
 	client := &retryablehttp.Client{
 		HTTPClient:   httpClient,
-		RetryWaitMin: 1000 * time.Millisecond,
-		RetryWaitMax: 1500 * time.Millisecond,
+		RetryWaitMin: minRetryWait,
+		RetryWaitMax: maxRetryWait,
 		RetryMax:     maxRetries,
-		CheckRetry:   retryablehttp.DefaultRetryPolicy,
-		Backoff:      backoff,
+		CheckRetry:   checkRetry,
+		Backoff:      retryAfterBackoff(ctx, backoff),
 		ErrorHandler: retryablehttp.PassthroughErrorHandler,
 	}
 
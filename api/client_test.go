@@ -3,8 +3,11 @@ package api
 import (
 	"bytes"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -69,6 +72,44 @@ func TestClientSetAddress(t *testing.T) {
 	}
 }
 
+func TestClientUnixSocket(t *testing.T) {
+	td, err := ioutil.TempDir("", "vault-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	socket := filepath.Join(td, "vault.sock")
+
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.URL.Path))
+	}))
+
+	config := DefaultConfig()
+	config.Address = "unix://" + socket
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.RawRequest(client.NewRequest("GET", "/v1/sys/health"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "/v1/sys/health" {
+		t.Fatalf("bad: %s", body)
+	}
+}
+
 func TestClientToken(t *testing.T) {
 	tokenValue := "foo"
 	handler := func(w http.ResponseWriter, req *http.Request) {}
@@ -119,6 +160,30 @@ func TestClientBadToken(t *testing.T) {
 	}
 }
 
+func TestClientErrorCode(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(403)
+		w.Write([]byte(`{"errors":["permission denied"],"error_code":"permission_denied"}`))
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	_, err = client.RawRequest(client.NewRequest("GET", "/v1/secret/foo"))
+	respErr, ok := err.(*ResponseError)
+	if !ok {
+		t.Fatalf("expected a response error, got %#v", err)
+	}
+	if respErr.StatusCode != 403 || respErr.ErrorCode != "permission_denied" || !strings.Contains(respErr.Error(), "* permission denied") {
+		t.Fatalf("bad: %#v", respErr)
+	}
+}
+
 func TestClientRedirect(t *testing.T) {
 	primary := func(w http.ResponseWriter, req *http.Request) {
 		w.Write([]byte("test"))
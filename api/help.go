@@ -10,7 +10,7 @@ func (c *Client) Help(path string) (*Help, error) {
 	r := c.NewRequest("GET", fmt.Sprintf("/v1/%s", path))
 	r.Params.Add("help", "1")
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.baseContext())
 	defer cancelFunc()
 	resp, err := c.RawRequestWithContext(ctx, r)
 	if err != nil {
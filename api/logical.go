@@ -3,9 +3,13 @@ package api
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/jsonutil"
@@ -48,7 +52,7 @@ func (c *Client) Logical() *Logical {
 func (c *Logical) Read(path string) (*Secret, error) {
 	r := c.c.NewRequest("GET", "/v1/"+path)
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
@@ -76,13 +80,31 @@ func (c *Logical) Read(path string) (*Secret, error) {
 }
 
 func (c *Logical) List(path string) (*Secret, error) {
+	return c.list(path, 0, "")
+}
+
+// ListPage lists at most limit keys at the given path, those after the
+// continuation token if one is given. The continuation token to list the next
+// page with is in the "continuation" field of the returned data, if there may
+// be more keys to list.
+func (c *Logical) ListPage(path string, limit int, continuation string) (*Secret, error) {
+	return c.list(path, limit, continuation)
+}
+
+func (c *Logical) list(path string, limit int, continuation string) (*Secret, error) {
 	r := c.c.NewRequest("LIST", "/v1/"+path)
 	// Set this for broader compatibility, but we use LIST above to be able to
 	// handle the wrapping lookup function
 	r.Method = "GET"
 	r.Params.Set("list", "true")
+	if limit > 0 {
+		r.Params.Set("limit", strconv.Itoa(limit))
+	}
+	if continuation != "" {
+		r.Params.Set("continuation", continuation)
+	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
@@ -115,7 +137,7 @@ func (c *Logical) Write(path string, data map[string]interface{}) (*Secret, erro
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
@@ -144,7 +166,7 @@ func (c *Logical) Write(path string, data map[string]interface{}) (*Secret, erro
 func (c *Logical) Delete(path string) (*Secret, error) {
 	r := c.c.NewRequest("DELETE", "/v1/"+path)
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
@@ -187,7 +209,7 @@ func (c *Logical) Unwrap(wrappingToken string) (*Secret, error) {
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
@@ -246,3 +268,72 @@ func (c *Logical) Unwrap(wrappingToken string) (*Secret, error) {
 
 	return wrappedSecret, nil
 }
+
+// LookupWrapping returns the information of a wrapping token without
+// unwrapping it: its creation time, TTL and the path of the wrapped response.
+func (c *Logical) LookupWrapping(wrappingToken string) (*SecretWrapInfo, error) {
+	secret, err := c.Write("sys/wrapping/lookup", map[string]interface{}{
+		"token": wrappingToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no data returned when looking up the wrapping token")
+	}
+
+	info := &SecretWrapInfo{
+		Token: wrappingToken,
+	}
+	info.CreationPath, _ = secret.Data["creation_path"].(string)
+	if ttl, ok := secret.Data["creation_ttl"].(json.Number); ok {
+		v, err := ttl.Float64()
+		if err != nil {
+			return nil, errwrap.Wrapf("error parsing the TTL of the wrapping token: {{err}}", err)
+		}
+		info.TTL = int(v)
+	}
+	if creationTime, ok := secret.Data["creation_time"].(string); ok {
+		t, err := time.Parse(time.RFC3339Nano, creationTime)
+		if err != nil {
+			return nil, errwrap.Wrapf("error parsing the creation time of the wrapping token: {{err}}", err)
+		}
+		info.CreationTime = t
+	}
+
+	return info, nil
+}
+
+// UnwrapFromPath unwraps the response wrapped by the token, after checking
+// that it was created by one of the expected paths. A token created elsewhere
+// may have been substituted by an attacker, so it is left wrapped.
+func (c *Logical) UnwrapFromPath(wrappingToken string, expectedPaths ...string) (*Secret, error) {
+	info, err := c.LookupWrapping(wrappingToken)
+	if err != nil {
+		return nil, errwrap.Wrapf("error looking up the wrapping token: {{err}}", err)
+	}
+	if err := ValidateWrappingPath(info.CreationPath, expectedPaths...); err != nil {
+		return nil, err
+	}
+
+	return c.Unwrap(wrappingToken)
+}
+
+// ValidateWrappingPath returns an error unless the creation path of a
+// wrapping token matches one of the expected paths. An expected path ending
+// in "*" matches any creation path starting with the rest of it.
+func ValidateWrappingPath(creationPath string, expectedPaths ...string) error {
+	for _, expected := range expectedPaths {
+		if strings.HasSuffix(expected, "*") {
+			if strings.HasPrefix(creationPath, strings.TrimSuffix(expected, "*")) {
+				return nil
+			}
+			continue
+		}
+		if creationPath == expected {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("wrapping token was created by %q rather than one of the expected paths %q", creationPath, expectedPaths)
+}
@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestLogical_UnwrapFromPath(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	wrapped, err := client.WithWrappingTTL("5m").Logical().Write("auth/token/create", map[string]interface{}{
+		"policies": []string{"default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrapped == nil || wrapped.WrapInfo == nil {
+		t.Fatalf("expected a wrapped response: %#v", wrapped)
+	}
+	if wrapped.WrapInfo.CreationPath != "auth/token/create" {
+		t.Fatalf("bad creation path: %q", wrapped.WrapInfo.CreationPath)
+	}
+
+	// The copy wraps responses while the client does not
+	if secret, err := client.Logical().Write("auth/token/create", nil); err != nil || secret.WrapInfo != nil {
+		t.Fatalf("expected an unwrapped response: %#v, %v", secret, err)
+	}
+
+	info, err := client.Logical().LookupWrapping(wrapped.WrapInfo.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.CreationPath != "auth/token/create" || info.TTL != 300 || info.CreationTime.IsZero() {
+		t.Fatalf("bad wrapping info: %#v", info)
+	}
+
+	// A token created elsewhere is left wrapped
+	_, err = client.Logical().UnwrapFromPath(wrapped.WrapInfo.Token, "auth/approle/role/foo/secret-id")
+	if err == nil || !strings.Contains(err.Error(), "rather than one of the expected paths") {
+		t.Fatalf("expected a path mismatch, got %v", err)
+	}
+
+	secret, err := client.Logical().UnwrapFromPath(wrapped.WrapInfo.Token, "auth/token/create*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		t.Fatalf("expected a token: %#v", secret)
+	}
+
+	// The token can't be unwrapped twice
+	if _, err := client.Logical().UnwrapFromPath(wrapped.WrapInfo.Token, "auth/token/create"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestValidateWrappingPath(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected []string
+		ok       bool
+	}{
+		{"auth/token/create", []string{"auth/token/create"}, true},
+		{"auth/token/create", []string{"sys/wrapping/wrap", "auth/token/create"}, true},
+		{"auth/token/create-orphan", []string{"auth/token/create"}, false},
+		{"auth/approle/role/foo/secret-id", []string{"auth/approle/role/*"}, true},
+		{"auth/token/create", []string{"auth/approle/role/*"}, false},
+		{"auth/token/create", nil, false},
+	}
+
+	for _, tc := range cases {
+		err := api.ValidateWrappingPath(tc.path, tc.expected...)
+		if (err == nil) != tc.ok {
+			t.Fatalf("%q %q: expected %t, got %v", tc.path, tc.expected, tc.ok, err)
+		}
+	}
+}
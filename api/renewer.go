@@ -18,6 +18,27 @@ var (
 	DefaultRenewerRenewBuffer = 5
 )
 
+// RenewBehavior controls what the renewer does when a renewal fails.
+type RenewBehavior uint
+
+const (
+	// RenewBehaviorErrorOnErrors stops the renewer with the error of the
+	// first failed renewal. This is the default.
+	RenewBehaviorErrorOnErrors RenewBehavior = iota
+
+	// RenewBehaviorIgnoreErrors keeps renewing after failed renewals, until
+	// the lease runs into the grace period without a successful renewal. The
+	// renewer then stops with the error of the last renewal.
+	RenewBehaviorIgnoreErrors
+)
+
+// LifetimeWatcher is another name for Renewer, watching the lifetime of a
+// secret or token and renewing it in the background.
+type LifetimeWatcher = Renewer
+
+// LifetimeWatcherInput is another name for RenewerInput.
+type LifetimeWatcherInput = RenewerInput
+
 // Renewer is a process for renewing a secret.
 //
 // 	renewer, err := client.NewRenewer(&RenewerInput{
@@ -52,6 +73,7 @@ type Renewer struct {
 	grace     time.Duration
 	random    *rand.Rand
 	increment int
+	behavior  RenewBehavior
 	doneCh    chan error
 	renewCh   chan *RenewOutput
 
@@ -80,6 +102,10 @@ type RenewerInput struct {
 	// here may or may not be honored by the vault server, based on Vault
 	// configuration or any associated max TTL values.
 	Increment int
+
+	// RenewBehavior controls what happens when a renewal fails. The default
+	// is RenewBehaviorErrorOnErrors.
+	RenewBehavior RenewBehavior
 }
 
 // RenewOutput is the metadata returned to the client (if it's listening) to
@@ -119,6 +145,7 @@ func (c *Client) NewRenewer(i *RenewerInput) (*Renewer, error) {
 		client:    c,
 		secret:    secret,
 		increment: i.Increment,
+		behavior:  i.RenewBehavior,
 		random:    random,
 		doneCh:    make(chan error, 1),
 		renewCh:   make(chan *RenewOutput, renewBuffer),
@@ -128,6 +155,12 @@ func (c *Client) NewRenewer(i *RenewerInput) (*Renewer, error) {
 	}, nil
 }
 
+// NewLifetimeWatcher creates a new lifetime watcher from the given input. It
+// is the same as NewRenewer.
+func (c *Client) NewLifetimeWatcher(i *LifetimeWatcherInput) (*LifetimeWatcher, error) {
+	return c.NewRenewer(i)
+}
+
 // DoneCh returns the channel where the renewer will publish when renewal stops.
 // If there is an error, this will be an error.
 func (r *Renewer) DoneCh() <-chan error {
@@ -164,79 +197,29 @@ func (r *Renewer) Renew() {
 	r.doneCh <- result
 }
 
+// Start is the same as Renew, starting the renewal of the secret. It is meant
+// to be run in a goroutine.
+func (r *Renewer) Start() {
+	r.Renew()
+}
+
 // renewAuth is a helper for renewing authentication.
 func (r *Renewer) renewAuth() error {
 	if !r.secret.Auth.Renewable || r.secret.Auth.ClientToken == "" {
 		return ErrRenewerNotRenewable
 	}
 
-	priorDuration := time.Duration(r.secret.Auth.LeaseDuration) * time.Second
-	r.calculateGrace(priorDuration)
-
 	client, token := r.client, r.secret.Auth.ClientToken
+	priorDuration := time.Duration(r.secret.Auth.LeaseDuration) * time.Second
 
-	for {
-		// Check if we are stopped.
-		select {
-		case <-r.stopCh:
-			return nil
-		default:
-		}
-
-		// Renew the auth.
-		renewal, err := client.Auth().Token().RenewTokenAsSelf(token, r.increment)
-		if err != nil {
-			return err
-		}
-
-		// Push a message that a renewal took place.
-		select {
-		case r.renewCh <- &RenewOutput{time.Now().UTC(), renewal}:
-		default:
-		}
-
-		// Somehow, sometimes, this happens.
+	return r.doRenew(priorDuration, func() (*Secret, error) {
+		return client.Auth().Token().RenewTokenAsSelf(token, r.increment)
+	}, func(renewal *Secret) (bool, time.Duration, bool) {
 		if renewal == nil || renewal.Auth == nil {
-			return ErrRenewerNoSecretData
-		}
-
-		// Do nothing if we are not renewable
-		if !renewal.Auth.Renewable {
-			return ErrRenewerNotRenewable
-		}
-
-		// Grab the lease duration
-		leaseDuration := time.Duration(renewal.Auth.LeaseDuration) * time.Second
-
-		// We keep evaluating a new grace period so long as the lease is
-		// extending. Once it stops extending, we've hit the max and need to
-		// rely on the grace duration.
-		if leaseDuration > priorDuration {
-			r.calculateGrace(leaseDuration)
-		}
-		priorDuration = leaseDuration
-
-		// The sleep duration is set to 2/3 of the current lease duration plus
-		// 1/3 of the current grace period, which adds jitter.
-		sleepDuration := time.Duration(float64(leaseDuration.Nanoseconds())*2/3 + float64(r.grace.Nanoseconds())/3)
-
-		// If we are within grace, return now; or, if the amount of time we
-		// would sleep would land us in the grace period. This helps with short
-		// tokens; for example, you don't want a current lease duration of 4
-		// seconds, a grace period of 3 seconds, and end up sleeping for more
-		// than three of those seconds and having a very small budget of time
-		// to renew.
-		if leaseDuration <= r.grace || leaseDuration-sleepDuration <= r.grace {
-			return nil
+			return false, 0, false
 		}
-
-		select {
-		case <-r.stopCh:
-			return nil
-		case <-time.After(sleepDuration):
-			continue
-		}
-	}
+		return renewal.Auth.Renewable, time.Duration(renewal.Auth.LeaseDuration) * time.Second, true
+	})
 }
 
 // renewLease is a helper for renewing a lease.
@@ -245,10 +228,29 @@ func (r *Renewer) renewLease() error {
 		return ErrRenewerNotRenewable
 	}
 
+	client, leaseID := r.client, r.secret.LeaseID
 	priorDuration := time.Duration(r.secret.LeaseDuration) * time.Second
+
+	return r.doRenew(priorDuration, func() (*Secret, error) {
+		return client.Sys().Renew(leaseID, r.increment)
+	}, func(renewal *Secret) (bool, time.Duration, bool) {
+		if renewal == nil {
+			return false, 0, false
+		}
+		return renewal.Renewable, time.Duration(renewal.LeaseDuration) * time.Second, true
+	})
+}
+
+// doRenew renews until the renewer is stopped, the secret is no longer
+// renewable or its lease runs into the grace period, which it waits for
+// without renewing once Vault reports a renewal as final. The lease function
+// returns whether the renewal is renewable and its lease duration, or false
+// if the renewal has no data.
+func (r *Renewer) doRenew(priorDuration time.Duration, renew func() (*Secret, error), lease func(*Secret) (bool, time.Duration, bool)) error {
 	r.calculateGrace(priorDuration)
 
-	client, leaseID := r.client, r.secret.LeaseID
+	// The expiration of the lease, which failed renewals are retried up to
+	leaseEnd := time.Now().Add(priorDuration)
 
 	for {
 		// Check if we are stopped.
@@ -258,38 +260,57 @@ func (r *Renewer) renewLease() error {
 		default:
 		}
 
-		// Renew the lease.
-		renewal, err := client.Sys().Renew(leaseID, r.increment)
-		if err != nil {
+		// Renew the secret.
+		renewal, err := renew()
+		if err != nil && r.behavior == RenewBehaviorErrorOnErrors {
 			return err
 		}
 
-		// Push a message that a renewal took place.
-		select {
-		case r.renewCh <- &RenewOutput{time.Now().UTC(), renewal}:
-		default:
-		}
-
-		// Somehow, sometimes, this happens.
-		if renewal == nil {
-			return ErrRenewerNoSecretData
-		}
-
-		// Do nothing if we are not renewable
-		if !renewal.Renewable {
-			return ErrRenewerNotRenewable
-		}
-
-		// Grab the lease duration
-		leaseDuration := time.Duration(renewal.LeaseDuration) * time.Second
-
-		// We keep evaluating a new grace period so long as the lease is
-		// extending. Once it stops extending, we've hit the max and need to
-		// rely on the grace duration.
-		if leaseDuration > priorDuration {
-			r.calculateGrace(leaseDuration)
+		var leaseDuration time.Duration
+		if err != nil {
+			// The lease is not extended, so try again before it runs out
+			leaseDuration = time.Until(leaseEnd)
+		} else {
+			// Push a message that a renewal took place.
+			select {
+			case r.renewCh <- &RenewOutput{time.Now().UTC(), renewal}:
+			default:
+			}
+
+			renewable, duration, ok := lease(renewal)
+
+			// Somehow, sometimes, this happens.
+			if !ok {
+				return ErrRenewerNoSecretData
+			}
+
+			// Do nothing if we are not renewable
+			if !renewable {
+				return ErrRenewerNotRenewable
+			}
+
+			// Grab the lease duration
+			leaseDuration = duration
+			leaseEnd = time.Now().Add(leaseDuration)
+
+			// We keep evaluating a new grace period so long as the lease is
+			// extending. Once it stops extending, we've hit the max and need
+			// to rely on the grace duration.
+			if leaseDuration > priorDuration {
+				r.calculateGrace(leaseDuration)
+			}
+			priorDuration = leaseDuration
+
+			// Vault reports the lease now runs until its max TTL, so rather
+			// than renewing it again, wait for it to run into the grace period
+			if renewal.Renewal != nil && renewal.Renewal.Final {
+				select {
+				case <-r.stopCh:
+				case <-time.After(leaseDuration - r.grace):
+				}
+				return nil
+			}
 		}
-		priorDuration = leaseDuration
 
 		// The sleep duration is set to 2/3 of the current lease duration plus
 		// 1/3 of the current grace period, which adds jitter.
@@ -300,9 +321,10 @@ func (r *Renewer) renewLease() error {
 		// tokens; for example, you don't want a current lease duration of 4
 		// seconds, a grace period of 3 seconds, and end up sleeping for more
 		// than three of those seconds and having a very small budget of time
-		// to renew.
+		// to renew. A lease ending without a successful renewal returns the
+		// error of the last one.
 		if leaseDuration <= r.grace || leaseDuration-sleepDuration <= r.grace {
-			return nil
+			return err
 		}
 
 		select {
@@ -1,8 +1,12 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
 	"reflect"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestRenewer_NewRenewer(t *testing.T) {
@@ -69,3 +73,139 @@ func TestRenewer_NewRenewer(t *testing.T) {
 		})
 	}
 }
+
+func TestRenewer_RenewBehavior(t *testing.T) {
+	t.Parallel()
+
+	// The token can't be renewed
+	var attempts int32
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &Secret{
+		Auth: &SecretAuth{
+			ClientToken:   "foo",
+			Renewable:     true,
+			LeaseDuration: 2,
+		},
+	}
+
+	t.Run("error_on_errors", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+
+		renewer, err := client.NewRenewer(&RenewerInput{
+			Secret: secret,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		go renewer.Renew()
+		defer renewer.Stop()
+
+		select {
+		case err := <-renewer.DoneCh():
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+		if actual := atomic.LoadInt32(&attempts); actual != 1 {
+			t.Fatalf("expected 1 attempt, got %d", actual)
+		}
+	})
+
+	t.Run("ignore_errors", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+
+		watcher, err := client.NewLifetimeWatcher(&LifetimeWatcherInput{
+			Secret:        secret,
+			RenewBehavior: RenewBehaviorIgnoreErrors,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		go watcher.Start()
+		defer watcher.Stop()
+
+		// The renewal is retried until the lease runs into the grace period
+		select {
+		case err := <-watcher.DoneCh():
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+		if actual := atomic.LoadInt32(&attempts); actual < 2 {
+			t.Fatalf("expected more than 1 attempt, got %d", actual)
+		}
+	})
+}
+
+func TestRenewer_IgnoreErrorsRecovers(t *testing.T) {
+	t.Parallel()
+
+	// The first renewal fails, the second one ends the renewable period
+	var attempts int32
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"auth":{"client_token":"foo","renewable":false,"lease_duration":2}}`)
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := client.NewLifetimeWatcher(&LifetimeWatcherInput{
+		Secret: &Secret{
+			Auth: &SecretAuth{
+				ClientToken:   "foo",
+				Renewable:     true,
+				LeaseDuration: 2,
+			},
+		},
+		RenewBehavior: RenewBehaviorIgnoreErrors,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	select {
+	case renewal := <-watcher.RenewCh():
+		if renewal.Secret == nil || renewal.Secret.Auth == nil {
+			t.Fatalf("bad renewal: %#v", renewal)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	select {
+	case err := <-watcher.DoneCh():
+		if err != ErrRenewerNotRenewable {
+			t.Fatalf("expected %v, got %v", ErrRenewerNotRenewable, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -35,6 +35,9 @@ type Request struct {
 	// EGPs). If set, the override flag will take effect for all policies
 	// evaluated during the request.
 	PolicyOverride bool
+
+	// The namespace the request is made within; empty for the root namespace
+	Namespace string
 }
 
 // SetJSONBody is used to set a request body that is a JSON-encoded value.
@@ -123,6 +126,9 @@ func (r *Request) toRetryableHTTP() (*retryablehttp.Request, error) {
 		}
 	}
 
+	// Listeners may require this header, which a forged request cannot set
+	req.Header.Set("X-Vault-Request", "true")
+
 	if len(r.ClientToken) != 0 {
 		req.Header.Set("X-Vault-Token", r.ClientToken)
 	}
@@ -141,5 +147,9 @@ func (r *Request) toRetryableHTTP() (*retryablehttp.Request, error) {
 		req.Header.Set("X-Vault-Policy-Override", "true")
 	}
 
+	if len(r.Namespace) != 0 {
+		req.Header.Set("X-Vault-Namespace", r.Namespace)
+	}
+
 	return req, nil
 }
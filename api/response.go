@@ -67,11 +67,37 @@ func (r *Response) Error() error {
 		errBody.WriteString(fmt.Sprintf("* %s", err))
 	}
 
-	return fmt.Errorf(errBody.String())
+	return &ResponseError{
+		StatusCode: r.StatusCode,
+		ErrorCode:  resp.ErrorCode,
+		Errors:     resp.Errors,
+		message:    errBody.String(),
+	}
 }
 
 // ErrorResponse is the raw structure of errors when they're returned by the
 // HTTP API.
 type ErrorResponse struct {
+	Errors    []string
+	ErrorCode string `json:"error_code"`
+}
+
+// ResponseError is the error returned for an error response of the HTTP API.
+type ResponseError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// ErrorCode is the stable code of the error, such as "permission_denied",
+	// "sealed" or "unsupported_path". It is empty if the server did not send
+	// one.
+	ErrorCode string
+
+	// Errors are the error strings of the response
 	Errors []string
+
+	message string
+}
+
+func (e *ResponseError) Error() string {
+	return e.message
 }
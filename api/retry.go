@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+)
+
+// DefaultRetryPolicy is the default CheckRetry of the client. Connection
+// errors and 5xx responses other than 501 are retried, as are 429 responses
+// which tell when to retry with a Retry-After header.
+func DefaultRetryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	if err == nil && resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		_, ok := retryAfter(resp)
+		return ok, nil
+	}
+
+	return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+}
+
+// retryAfterBackoff wraps the backoff so that the wait asked for by the
+// Retry-After header of a response is honored, up to the deadline of the
+// context.
+func retryAfterBackoff(ctx context.Context, backoff retryablehttp.Backoff) retryablehttp.Backoff {
+	return func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		wait, ok := retryAfter(resp)
+		if !ok {
+			return backoff(min, max, attemptNum, resp)
+		}
+
+		if deadline, ok := ctx.Deadline(); ok {
+			if remaining := time.Until(deadline); wait > remaining {
+				wait = remaining
+			}
+		}
+		return wait
+	}
+}
+
+// retryAfter returns the wait asked for by the Retry-After header of a 429 or
+// 503 response, which is either a number of seconds or a date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	wait := time.Until(date)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetry(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		header   string
+		attempts int32
+		err      bool
+	}{
+		{"server_error", http.StatusServiceUnavailable, "", 3, false},
+		{"not_implemented", http.StatusNotImplemented, "", 1, true},
+		// 429 without Retry-After is the health status of standbys
+		{"too_many_requests", http.StatusTooManyRequests, "", 1, false},
+		{"too_many_requests_retry_after", http.StatusTooManyRequests, "0", 3, false},
+		{"bad_request", http.StatusBadRequest, "0", 1, true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Fail the first two attempts
+			var attempts int32
+			handler := func(w http.ResponseWriter, req *http.Request) {
+				if atomic.AddInt32(&attempts, 1) <= 2 {
+					if tc.header != "" {
+						w.Header().Set("Retry-After", tc.header)
+					}
+					w.WriteHeader(tc.status)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}
+
+			config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+			defer ln.Close()
+
+			client, err := NewClient(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.SetRetryWait(time.Millisecond, 2*time.Millisecond)
+
+			_, err = client.RawRequest(client.NewRequest("GET", "/"))
+			if (err != nil) != tc.err {
+				t.Fatalf("bad: err: %v", err)
+			}
+			if actual := atomic.LoadInt32(&attempts); actual != tc.attempts {
+				t.Fatalf("expected %d attempts, got %d", tc.attempts, actual)
+			}
+		})
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	var attempts int32
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetRetryWait(time.Millisecond, 2*time.Millisecond)
+
+	start := time.Now()
+	if _, err := client.RawRequest(client.NewRequest("GET", "/")); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected the Retry-After wait to be honored, took %s", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+
+	cases := []struct {
+		status int
+		header string
+		wait   time.Duration
+		ok     bool
+	}{
+		{http.StatusTooManyRequests, "", 0, false},
+		{http.StatusTooManyRequests, "5", 5 * time.Second, true},
+		{http.StatusServiceUnavailable, "5", 5 * time.Second, true},
+		{http.StatusInternalServerError, "5", 0, false},
+		{http.StatusTooManyRequests, "-5", 0, false},
+		{http.StatusTooManyRequests, "soon", 0, false},
+		{http.StatusTooManyRequests, past, 0, true},
+		{http.StatusTooManyRequests, date, time.Hour, true},
+	}
+
+	for _, tc := range cases {
+		resp := &http.Response{
+			StatusCode: tc.status,
+			Header:     http.Header{},
+		}
+		if tc.header != "" {
+			resp.Header.Set("Retry-After", tc.header)
+		}
+
+		wait, ok := retryAfter(resp)
+		if ok != tc.ok {
+			t.Fatalf("%d %q: expected %t, got %t", tc.status, tc.header, tc.ok, ok)
+		}
+		// Dates are only precise to the second
+		if wait > tc.wait || wait < tc.wait-2*time.Second {
+			t.Fatalf("%d %q: expected %s, got %s", tc.status, tc.header, tc.wait, wait)
+		}
+	}
+
+	if _, ok := retryAfter(nil); ok {
+		t.Fatal("expected no wait without a response")
+	}
+}
+
+func TestClientWithContext(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-done:
+		case <-req.Context().Done():
+		}
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("foo")
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelFunc()
+
+	ctxClient := client.WithContext(ctx)
+	if ctxClient.Token() != "foo" {
+		t.Fatalf("expected the token to be kept, got %q", ctxClient.Token())
+	}
+
+	// The token of the copy is independent of the client
+	ctxClient.SetToken("bar")
+	if client.Token() != "foo" {
+		t.Fatalf("expected the token of the client to be unchanged, got %q", client.Token())
+	}
+
+	start := time.Now()
+	if _, err := ctxClient.Logical().Read("secret/foo"); err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the request to be canceled with the context, took %s", elapsed)
+	}
+}
@@ -37,6 +37,10 @@ type Secret struct {
 	// cubbyhole of the given token (which has a TTL of the given number of
 	// seconds)
 	WrapInfo *SecretWrapInfo `json:"wrap_info,omitempty"`
+
+	// Renewal, if non-nil, describes how Vault decided the lease duration
+	// granted by a renewal
+	Renewal *SecretRenewal `json:"renewal,omitempty"`
 }
 
 // TokenID returns the standardized token ID (token) for the given secret.
@@ -284,6 +288,17 @@ type SecretWrapInfo struct {
 	WrappedAccessor string    `json:"wrapped_accessor"`
 }
 
+// SecretRenewal describes how the lease duration granted by a renewal was
+// decided. Decision is one of "requested", "default_increment",
+// "max_increment", "max_ttl" or "grace_period". Final is true if the lease
+// now expires at its max TTL, so renewing it again cannot extend it.
+type SecretRenewal struct {
+	RequestedIncrement int    `json:"requested_increment"`
+	TTL                int    `json:"ttl"`
+	Decision           string `json:"decision"`
+	Final              bool   `json:"final"`
+}
+
 // SecretAuth is the structure containing auth information if we have it.
 type SecretAuth struct {
 	ClientToken      string            `json:"client_token"`
@@ -17,7 +17,7 @@ func (c *Sys) AuditHash(path string, input string) (string, error) {
 		return "", err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -41,7 +41,7 @@ func (c *Sys) AuditHash(path string, input string) (string, error) {
 func (c *Sys) ListAudit() (map[string]*Audit, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/audit")
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 
@@ -94,7 +94,7 @@ func (c *Sys) EnableAuditWithOptions(path string, options *EnableAuditOptions) e
 		return err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 
@@ -109,7 +109,7 @@ func (c *Sys) EnableAuditWithOptions(path string, options *EnableAuditOptions) e
 func (c *Sys) DisableAudit(path string) error {
 	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/audit/%s", path))
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 
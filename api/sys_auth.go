@@ -10,7 +10,7 @@ import (
 func (c *Sys) ListAuth() (map[string]*AuthMount, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/auth")
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -60,7 +60,7 @@ func (c *Sys) EnableAuthWithOptions(path string, options *EnableAuthOptions) err
 		return err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -74,7 +74,7 @@ func (c *Sys) EnableAuthWithOptions(path string, options *EnableAuthOptions) err
 func (c *Sys) DisableAuth(path string) error {
 	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/auth/%s", path))
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
@@ -105,16 +105,28 @@ type AuthConfigInput struct {
 	AuditNonHMACResponseKeys  []string `json:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
 	ListingVisibility         string   `json:"listing_visibility,omitempty" mapstructure:"listing_visibility"`
 	PassthroughRequestHeaders []string `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
+	TokenType                 string   `json:"token_type,omitempty" mapstructure:"token_type"`
+	MaxInFlightRequests       int      `json:"max_in_flight_requests,omitempty" mapstructure:"max_in_flight_requests"`
+	MaxQueuedRequests         int      `json:"max_queued_requests,omitempty" mapstructure:"max_queued_requests"`
+	DefaultRenewalIncrement   string   `json:"default_renewal_increment,omitempty" mapstructure:"default_renewal_increment"`
+	MaxRenewalIncrement       string   `json:"max_renewal_increment,omitempty" mapstructure:"max_renewal_increment"`
+	RenewalGracePeriod        string   `json:"renewal_grace_period,omitempty" mapstructure:"renewal_grace_period"`
+	OutboundProxyURL          string   `json:"outbound_proxy_url,omitempty" mapstructure:"outbound_proxy_url"`
+	OutboundCACert            string   `json:"outbound_ca_cert,omitempty" mapstructure:"outbound_ca_cert"`
+	LockoutThreshold          int      `json:"lockout_threshold,omitempty" mapstructure:"lockout_threshold"`
+	LockoutIPThreshold        int      `json:"lockout_ip_threshold,omitempty" mapstructure:"lockout_ip_threshold"`
+	LockoutDuration           string   `json:"lockout_duration,omitempty" mapstructure:"lockout_duration"`
 }
 
 type AuthMount struct {
-	Type        string            `json:"type" mapstructure:"type"`
-	Description string            `json:"description" mapstructure:"description"`
-	Accessor    string            `json:"accessor" mapstructure:"accessor"`
-	Config      AuthConfigOutput  `json:"config" mapstructure:"config"`
-	Local       bool              `json:"local" mapstructure:"local"`
-	SealWrap    bool              `json:"seal_wrap" mapstructure:"seal_wrap"`
-	Options     map[string]string `json:"options" mapstructure:"options"`
+	Type              string            `json:"type" mapstructure:"type"`
+	Description       string            `json:"description" mapstructure:"description"`
+	Accessor          string            `json:"accessor" mapstructure:"accessor"`
+	Config            AuthConfigOutput  `json:"config" mapstructure:"config"`
+	Local             bool              `json:"local" mapstructure:"local"`
+	SealWrap          bool              `json:"seal_wrap" mapstructure:"seal_wrap"`
+	Options           map[string]string `json:"options" mapstructure:"options"`
+	DeprecationStatus string            `json:"deprecation_status,omitempty" mapstructure:"deprecation_status"`
 }
 
 type AuthConfigOutput struct {
@@ -125,4 +137,15 @@ type AuthConfigOutput struct {
 	AuditNonHMACResponseKeys  []string `json:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
 	ListingVisibility         string   `json:"listing_visibility,omitempty" mapstructure:"listing_visibility"`
 	PassthroughRequestHeaders []string `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
+	TokenType                 string   `json:"token_type,omitempty" mapstructure:"token_type"`
+	MaxInFlightRequests       int      `json:"max_in_flight_requests,omitempty" mapstructure:"max_in_flight_requests"`
+	MaxQueuedRequests         int      `json:"max_queued_requests,omitempty" mapstructure:"max_queued_requests"`
+	DefaultRenewalIncrement   int      `json:"default_renewal_increment,omitempty" mapstructure:"default_renewal_increment"`
+	MaxRenewalIncrement       int      `json:"max_renewal_increment,omitempty" mapstructure:"max_renewal_increment"`
+	RenewalGracePeriod        int      `json:"renewal_grace_period,omitempty" mapstructure:"renewal_grace_period"`
+	OutboundProxyURL          string   `json:"outbound_proxy_url,omitempty" mapstructure:"outbound_proxy_url"`
+	OutboundCACert            string   `json:"outbound_ca_cert,omitempty" mapstructure:"outbound_ca_cert"`
+	LockoutThreshold          int      `json:"lockout_threshold,omitempty" mapstructure:"lockout_threshold"`
+	LockoutIPThreshold        int      `json:"lockout_ip_threshold,omitempty" mapstructure:"lockout_ip_threshold"`
+	LockoutDuration           int      `json:"lockout_duration,omitempty" mapstructure:"lockout_duration"`
 }
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AuthLockouts returns the users and client addresses the auth method at the
+// given path locked out after too many failed logins, as counted by the node
+// handling the request
+func (c *Sys) AuthLockouts(path string) (*AuthLockoutsOutput, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/auth/%s/lockouts", path))
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data *AuthLockoutsOutput `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+	return result.Data, nil
+}
+
+// UnlockAuth clears the lockout of a user or a client address, either of
+// which may be empty, of the auth method at the given path
+func (c *Sys) UnlockAuth(path, user, remoteAddress string) error {
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/auth/%s/unlock", path))
+	if err := r.SetJSONBody(map[string]interface{}{
+		"user":           user,
+		"remote_address": remoteAddress,
+	}); err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+type AuthLockoutsOutput struct {
+	Users           map[string]AuthLockout `json:"users"`
+	RemoteAddresses map[string]AuthLockout `json:"remote_addresses"`
+}
+
+type AuthLockout struct {
+	LockedUntil time.Time `json:"locked_until"`
+}
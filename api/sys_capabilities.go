@@ -25,7 +25,7 @@ func (c *Sys) Capabilities(token, path string) ([]string, error) {
 		return nil, err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -52,3 +52,56 @@ func (c *Sys) Capabilities(token, path string) ([]string, error) {
 	}
 	return capabilities, nil
 }
+
+// CapabilitiesSelfPaths returns the capabilities of the client token on each
+// of the given paths
+func (c *Sys) CapabilitiesSelfPaths(paths []string) (map[string][]string, error) {
+	return c.capabilitiesPaths("/v1/sys/capabilities-self", "", paths)
+}
+
+// CapabilitiesPaths returns the capabilities of the given token on each of
+// the given paths
+func (c *Sys) CapabilitiesPaths(token string, paths []string) (map[string][]string, error) {
+	return c.capabilitiesPaths("/v1/sys/capabilities", token, paths)
+}
+
+func (c *Sys) capabilitiesPaths(reqPath, token string, paths []string) (map[string][]string, error) {
+	body := map[string]interface{}{
+		"paths": paths,
+	}
+	if token != "" {
+		body["token"] = token
+	}
+
+	r := c.c.NewRequest("POST", reqPath)
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		capabilitiesRaw, ok := result[path].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("error interpreting returned capabilities for %q", path)
+		}
+		capabilities := make([]string, 0, len(capabilitiesRaw))
+		for _, capability := range capabilitiesRaw {
+			capabilities = append(capabilities, capability.(string))
+		}
+		ret[path] = capabilities
+	}
+	return ret, nil
+}
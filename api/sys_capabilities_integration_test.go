@@ -0,0 +1,58 @@
+package api_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestSys_CapabilitiesPaths(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().PutPolicy("foo", `
+path "secret/foo" {
+	capabilities = ["read", "list"]
+}
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+		Policies: []string{"foo"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := secret.Auth.ClientToken
+
+	expected := map[string][]string{
+		"secret/foo": []string{"list", "read"},
+		"secret/bar": []string{"deny"},
+	}
+	paths := []string{"secret/foo", "secret/bar"}
+
+	actual, err := client.Sys().CapabilitiesPaths(token, paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: expected %v, got %v", expected, actual)
+	}
+
+	tokenClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenClient.SetToken(token)
+	actual, err = tokenClient.Sys().CapabilitiesSelfPaths(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+func (c *Sys) HAStatus() (*HAStatusResponse, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/ha-status")
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data *HAStatusResponse `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+	return result.Data, nil
+}
+
+type HAStatusResponse struct {
+	Nodes []HANode `json:"nodes"`
+}
+
+type HANode struct {
+	Hostname           string     `json:"hostname"`
+	APIAddress         string     `json:"api_address"`
+	ClusterAddress     string     `json:"cluster_address"`
+	Version            string     `json:"version"`
+	ActiveNode         bool       `json:"active_node"`
+	PerformanceStandby bool       `json:"performance_standby"`
+	LastEcho           *time.Time `json:"last_echo"`
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+)
+
+// Monitor streams the lines logged by the server at or above the given log
+// level. The returned channel is closed when the context is canceled or the
+// server ends the stream. Unlike other requests, the stream is not bound by
+// the timeout of the client.
+func (c *Sys) Monitor(ctx context.Context, logLevel string) (chan string, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/monitor")
+	if logLevel != "" {
+		r.Params.Set("log_level", logLevel)
+	}
+
+	c.c.config.modifyLock.RLock()
+	httpClient := *c.c.config.HttpClient
+	c.c.config.modifyLock.RUnlock()
+	httpClient.Timeout = 0
+
+	redirected := false
+START:
+	req, err := r.toRetryableHTTP()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req.Request.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// Standbys redirect to the active node, only a single redirect is
+	// followed
+	if resp.StatusCode == 307 && !redirected {
+		resp.Body.Close()
+		respLoc, err := resp.Location()
+		if err != nil {
+			return nil, err
+		}
+		if req.URL.Scheme == "https" && respLoc.Scheme != "https" {
+			return nil, fmt.Errorf("redirect would cause protocol downgrade")
+		}
+		r.URL = respLoc
+		redirected = true
+		goto START
+	}
+
+	result := &Response{Response: resp}
+	if err := result.Error(); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return lines, nil
+}
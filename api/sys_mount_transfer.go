@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+)
+
+// ExportMount exports the storage of the secrets engine or auth method at
+// the given path, prefixed with auth/ for auth methods, along with the
+// configuration of its mount
+func (c *Sys) ExportMount(path string, input *MountExportInput) (*MountExportOutput, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/storage/mount-export")
+	body := map[string]string{
+		"path":       path,
+		"key":        input.Key,
+		"public_key": input.PublicKey,
+	}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data *MountExportOutput `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+	return result.Data, nil
+}
+
+// ImportMount creates a secrets engine or auth method at the given path with
+// the configuration and the storage of an export
+func (c *Sys) ImportMount(path string, input *MountImportInput) (*MountImportOutput, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/storage/mount-import")
+	body := map[string]string{
+		"path":   path,
+		"export": input.Export,
+		"key":    input.Key,
+	}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data *MountImportOutput `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+	return result.Data, nil
+}
+
+// MountImportWrappingKey returns the PEM-encoded RSA public key exports can
+// be encrypted for, to be imported into the cluster of the client
+func (c *Sys) MountImportWrappingKey() (string, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/storage/mount-import/wrapping-key")
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data struct {
+			PublicKey string `json:"public_key"`
+		} `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return "", err
+	}
+	return result.Data.PublicKey, nil
+}
+
+// MountExportInput gives either the base64-encoded AES-256 key to encrypt
+// an export with, or the PEM-encoded RSA public key to encrypt it for
+type MountExportInput struct {
+	Key       string `json:"key"`
+	PublicKey string `json:"public_key"`
+}
+
+type MountExportOutput struct {
+	Export  string `json:"export"`
+	Type    string `json:"type"`
+	Entries int    `json:"entries"`
+}
+
+// MountImportInput gives the export, and the key it was encrypted with
+// unless it was encrypted for the wrapping key of the cluster
+type MountImportInput struct {
+	Export string `json:"export"`
+	Key    string `json:"key"`
+}
+
+type MountImportOutput struct {
+	Type     string `json:"type"`
+	Accessor string `json:"accessor"`
+	Entries  int    `json:"entries"`
+}
@@ -10,7 +10,7 @@ import (
 func (c *Sys) ListMounts() (map[string]*MountOutput, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/mounts")
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -52,7 +52,7 @@ func (c *Sys) Mount(path string, mountInfo *MountInput) error {
 		return err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -66,7 +66,47 @@ func (c *Sys) Mount(path string, mountInfo *MountInput) error {
 func (c *Sys) Unmount(path string) error {
 	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/mounts/%s", path))
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// UnmountOptions are the options for disabling a mount
+type UnmountOptions struct {
+	// Force deletes the mount right away, even if the server keeps disabled
+	// mounts around for a grace period
+	Force bool
+}
+
+// UnmountWithOptions disables the mount at the path. When the server keeps
+// disabled mounts around for a grace period, the returned secret carries a
+// warning saying when the mount is deleted.
+func (c *Sys) UnmountWithOptions(path string, options *UnmountOptions) (*Secret, error) {
+	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/mounts/%s", path))
+	if options != nil && options.Force {
+		r.Params.Set("force", "true")
+	}
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+// RestoreMount cancels the pending deletion of the mount at the path
+func (c *Sys) RestoreMount(path string) error {
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/mounts/%s/restore", path))
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
@@ -86,7 +126,7 @@ func (c *Sys) Remount(from, to string) error {
 		return err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
@@ -101,7 +141,7 @@ func (c *Sys) TuneMount(path string, config MountConfigInput) error {
 		return err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
@@ -113,7 +153,7 @@ func (c *Sys) TuneMount(path string, config MountConfigInput) error {
 func (c *Sys) MountConfig(path string) (*MountConfigOutput, error) {
 	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/mounts/%s/tune", path))
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -151,16 +191,29 @@ type MountConfigInput struct {
 	AuditNonHMACResponseKeys  []string          `json:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
 	ListingVisibility         string            `json:"listing_visibility,omitempty" mapstructure:"listing_visibility"`
 	PassthroughRequestHeaders []string          `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
+	TokenType                 string            `json:"token_type,omitempty" mapstructure:"token_type"`
+	MaxInFlightRequests       *int              `json:"max_in_flight_requests,omitempty" mapstructure:"max_in_flight_requests"`
+	MaxQueuedRequests         *int              `json:"max_queued_requests,omitempty" mapstructure:"max_queued_requests"`
+	DefaultRenewalIncrement   string            `json:"default_renewal_increment,omitempty" mapstructure:"default_renewal_increment"`
+	MaxRenewalIncrement       string            `json:"max_renewal_increment,omitempty" mapstructure:"max_renewal_increment"`
+	RenewalGracePeriod        string            `json:"renewal_grace_period,omitempty" mapstructure:"renewal_grace_period"`
+	OutboundProxyURL          string            `json:"outbound_proxy_url,omitempty" mapstructure:"outbound_proxy_url"`
+	OutboundCACert            string            `json:"outbound_ca_cert,omitempty" mapstructure:"outbound_ca_cert"`
+	LockoutThreshold          *int              `json:"lockout_threshold,omitempty" mapstructure:"lockout_threshold"`
+	LockoutIPThreshold        *int              `json:"lockout_ip_threshold,omitempty" mapstructure:"lockout_ip_threshold"`
+	LockoutDuration           string            `json:"lockout_duration,omitempty" mapstructure:"lockout_duration"`
 }
 
 type MountOutput struct {
-	Type        string            `json:"type"`
-	Description string            `json:"description"`
-	Accessor    string            `json:"accessor"`
-	Config      MountConfigOutput `json:"config"`
-	Options     map[string]string `json:"options"`
-	Local       bool              `json:"local"`
-	SealWrap    bool              `json:"seal_wrap" mapstructure:"seal_wrap"`
+	Type              string            `json:"type"`
+	Description       string            `json:"description"`
+	Accessor          string            `json:"accessor"`
+	Config            MountConfigOutput `json:"config"`
+	Options           map[string]string `json:"options"`
+	Local             bool              `json:"local"`
+	SealWrap          bool              `json:"seal_wrap" mapstructure:"seal_wrap"`
+	DeletionTime      string            `json:"deletion_time,omitempty" mapstructure:"deletion_time"`
+	DeprecationStatus string            `json:"deprecation_status,omitempty" mapstructure:"deprecation_status"`
 }
 
 type MountConfigOutput struct {
@@ -172,4 +225,15 @@ type MountConfigOutput struct {
 	AuditNonHMACResponseKeys  []string `json:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
 	ListingVisibility         string   `json:"listing_visibility,omitempty" mapstructure:"listing_visibility"`
 	PassthroughRequestHeaders []string `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
+	TokenType                 string   `json:"token_type,omitempty" mapstructure:"token_type"`
+	MaxInFlightRequests       int      `json:"max_in_flight_requests,omitempty" mapstructure:"max_in_flight_requests"`
+	MaxQueuedRequests         int      `json:"max_queued_requests,omitempty" mapstructure:"max_queued_requests"`
+	DefaultRenewalIncrement   int      `json:"default_renewal_increment,omitempty" mapstructure:"default_renewal_increment"`
+	MaxRenewalIncrement       int      `json:"max_renewal_increment,omitempty" mapstructure:"max_renewal_increment"`
+	RenewalGracePeriod        int      `json:"renewal_grace_period,omitempty" mapstructure:"renewal_grace_period"`
+	OutboundProxyURL          string   `json:"outbound_proxy_url,omitempty" mapstructure:"outbound_proxy_url"`
+	OutboundCACert            string   `json:"outbound_ca_cert,omitempty" mapstructure:"outbound_ca_cert"`
+	LockoutThreshold          int      `json:"lockout_threshold,omitempty" mapstructure:"lockout_threshold"`
+	LockoutIPThreshold        int      `json:"lockout_ip_threshold,omitempty" mapstructure:"lockout_ip_threshold"`
+	LockoutDuration           int      `json:"lockout_duration,omitempty" mapstructure:"lockout_duration"`
 }
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ListLeaseCountQuotas returns the names of the lease count quotas
+func (c *Sys) ListLeaseCountQuotas() ([]string, error) {
+	r := c.c.NewRequest("LIST", "/v1/sys/quotas/lease-count")
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return result.Data.Keys, nil
+}
+
+// GetLeaseCountQuota returns the lease count quota of the given name, or nil
+// if there is none
+func (c *Sys) GetLeaseCountQuota(name string) (*LeaseCountQuota, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/quotas/lease-count/%s", name))
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data *LeaseCountQuota `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+	return result.Data, nil
+}
+
+// PutLeaseCountQuota creates or updates the lease count quota of the given
+// name
+func (c *Sys) PutLeaseCountQuota(name string, quota *LeaseCountQuotaInput) error {
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/quotas/lease-count/%s", name))
+	if err := r.SetJSONBody(quota); err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DeleteLeaseCountQuota deletes the lease count quota of the given name
+func (c *Sys) DeleteLeaseCountQuota(name string) error {
+	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/quotas/lease-count/%s", name))
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+type LeaseCountQuotaInput struct {
+	Path      *string `json:"path,omitempty"`
+	MaxLeases *int    `json:"max_leases,omitempty"`
+}
+
+type LeaseCountQuota struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	MaxLeases int    `json:"max_leases"`
+	Leases    int    `json:"leases"`
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+)
+
+// StorageCleanupReport reports the storage entries left behind by leases
+// and tokens: the leases whose token no longer exists, the leases and tokens
+// expired long ago which were never revoked, and the cubbyholes whose token
+// no longer exists
+func (c *Sys) StorageCleanupReport() (*StorageCleanupOutput, error) {
+	return c.storageCleanup("GET")
+}
+
+// StorageCleanup deletes the storage entries StorageCleanupReport reports,
+// returning those it found
+func (c *Sys) StorageCleanup() (*StorageCleanupOutput, error) {
+	return c.storageCleanup("PUT")
+}
+
+func (c *Sys) storageCleanup(method string) (*StorageCleanupOutput, error) {
+	r := c.c.NewRequest(method, "/v1/sys/storage/cleanup")
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data     *StorageCleanupOutput `json:"data"`
+		Warnings []string              `json:"warnings"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		result.Data = new(StorageCleanupOutput)
+	}
+	result.Data.Errors = result.Warnings
+	return result.Data, nil
+}
+
+type StorageCleanupOutput struct {
+	OrphanedLeases     StorageCleanupLeases     `json:"orphaned_leases"`
+	ExpiredLeases      StorageCleanupLeases     `json:"expired_leases"`
+	UnreapedTokens     StorageCleanupTokens     `json:"unreaped_tokens"`
+	OrphanedCubbyholes StorageCleanupCubbyholes `json:"orphaned_cubbyholes"`
+	Deleted            bool                     `json:"deleted"`
+
+	// Errors are the failures to delete individual entries
+	Errors []string `json:"-"`
+}
+
+type StorageCleanupLeases struct {
+	Count    int      `json:"count"`
+	LeaseIDs []string `json:"lease_ids"`
+}
+
+type StorageCleanupTokens struct {
+	Count     int      `json:"count"`
+	Accessors []string `json:"accessors"`
+}
+
+type StorageCleanupCubbyholes struct {
+	Count int `json:"count"`
+}
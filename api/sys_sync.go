@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ListSyncDestinations returns the types and names of the sync destinations,
+// as "<type>/<name>"
+func (c *Sys) ListSyncDestinations() ([]string, error) {
+	r := c.c.NewRequest("LIST", "/v1/sys/sync/destinations")
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return result.Data.Keys, nil
+}
+
+// GetSyncDestination returns the configuration of a sync destination,
+// without its credentials, or nil if there is none
+func (c *Sys) GetSyncDestination(destType, name string) (map[string]interface{}, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/sync/destinations/%s/%s", destType, name))
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+	return secret.Data, nil
+}
+
+// PutSyncDestination creates or updates a sync destination. The parameters
+// depend on the type of the destination.
+func (c *Sys) PutSyncDestination(destType, name string, config map[string]interface{}) error {
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/sync/destinations/%s/%s", destType, name))
+	if err := r.SetJSONBody(config); err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DeleteSyncDestination deletes a sync destination, which must have no
+// associations left
+func (c *Sys) DeleteSyncDestination(destType, name string) error {
+	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/sync/destinations/%s/%s", destType, name))
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// GetSyncAssociations returns the secrets pushed to a sync destination and
+// the status of their synchronization, keyed by their path
+func (c *Sys) GetSyncAssociations(destType, name string) (map[string]*SyncAssociation, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/sync/destinations/%s/%s/associations", destType, name))
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Associations map[string]*SyncAssociation `json:"associations"`
+		} `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return result.Data.Associations, nil
+}
+
+// SetSyncAssociation associates a KV secret with a sync destination, which
+// pushes it right away, and returns the status of the synchronization
+func (c *Sys) SetSyncAssociation(destType, name, mount, secretName string) (*SyncAssociation, error) {
+	return c.syncAssociation("set", destType, name, mount, secretName)
+}
+
+// RemoveSyncAssociation stops pushing a KV secret to a sync destination,
+// which deletes it there
+func (c *Sys) RemoveSyncAssociation(destType, name, mount, secretName string) error {
+	_, err := c.syncAssociation("remove", destType, name, mount, secretName)
+	return err
+}
+
+func (c *Sys) syncAssociation(action, destType, name, mount, secretName string) (*SyncAssociation, error) {
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/sync/destinations/%s/%s/associations/%s", destType, name, action))
+	body := map[string]string{
+		"mount":       mount,
+		"secret_name": secretName,
+	}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 204 {
+		return nil, nil
+	}
+
+	var result struct {
+		Data *SyncAssociation `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+type SyncAssociation struct {
+	Mount             string `json:"mount"`
+	SecretName        string `json:"secret_name"`
+	RemoteName        string `json:"remote_name"`
+	SyncStatus        string `json:"sync_status"`
+	LastSynced        string `json:"last_synced"`
+	LastChecked       string `json:"last_checked"`
+	LastDriftDetected string `json:"last_drift_detected"`
+	Error             string `json:"error"`
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// VersionHistory returns the versions of Vault the cluster has run, in the
+// order they were first run
+func (c *Sys) VersionHistory() ([]*VersionHistoryEntry, error) {
+	r := c.c.NewRequest("LIST", "/v1/sys/version-history")
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data *struct {
+			Keys    []string                        `json:"keys"`
+			KeyInfo map[string]*VersionHistoryEntry `json:"key_info"`
+		} `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	history := make([]*VersionHistoryEntry, 0, len(result.Data.Keys))
+	for _, key := range result.Data.Keys {
+		entry := result.Data.KeyInfo[key]
+		if entry == nil {
+			entry = new(VersionHistoryEntry)
+		}
+		entry.Version = key
+		history = append(history, entry)
+	}
+	return history, nil
+}
+
+// Deprecations returns the deprecated builtin backends, and the mounts of
+// the namespace using them
+func (c *Sys) Deprecations() (*DeprecationsOutput, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/deprecations")
+
+	ctx, cancelFunc := context.WithCancel(c.c.baseContext())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data *DeprecationsOutput `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+	return result.Data, nil
+}
+
+type VersionHistoryEntry struct {
+	Version            string    `json:"-"`
+	Revision           string    `json:"revision"`
+	PreviousVersion    string    `json:"previous_version"`
+	TimestampInstalled time.Time `json:"timestamp_installed"`
+}
+
+type DeprecationsOutput struct {
+	Builtins struct {
+		Auth    map[string]*DeprecatedBuiltin `json:"auth"`
+		Secrets map[string]*DeprecatedBuiltin `json:"secrets"`
+	} `json:"builtins"`
+	Mounts map[string]*DeprecatedMount `json:"mounts"`
+}
+
+type DeprecatedBuiltin struct {
+	DeprecationStatus string `json:"deprecation_status"`
+	Replacement       string `json:"replacement"`
+}
+
+type DeprecatedMount struct {
+	Type              string `json:"type"`
+	DeprecationStatus string `json:"deprecation_status"`
+	Replacement       string `json:"replacement"`
+}
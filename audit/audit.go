@@ -2,6 +2,7 @@ package audit
 
 import (
 	"context"
+	"io"
 
 	"github.com/hashicorp/vault/helper/salt"
 	"github.com/hashicorp/vault/logical"
@@ -36,6 +37,22 @@ type Backend interface {
 	Invalidate(context.Context)
 }
 
+// EntryBackend is implemented by the audit backends which format their
+// entries before writing them, so that the entries they fail to write can be
+// buffered and written again later
+type EntryBackend interface {
+	Backend
+
+	// FormatRequest and FormatResponse format the entry LogRequest and
+	// LogResponse would write, without writing it
+	FormatRequest(context.Context, io.Writer, *LogInput) error
+	FormatResponse(context.Context, io.Writer, *LogInput) error
+
+	// WriteEntry writes an entry formatted by FormatRequest or
+	// FormatResponse
+	WriteEntry(context.Context, []byte) error
+}
+
 // LogInput contains the input parameters passed into LogRequest and LogResponse
 type LogInput struct {
 	Auth                *logical.Auth
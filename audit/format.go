@@ -137,6 +137,7 @@ func (f *AuditFormatter) FormatRequest(ctx context.Context, w io.Writer, config
 			Path:                req.Path,
 			Data:                req.Data,
 			PolicyOverride:      req.PolicyOverride,
+			Reason:              req.Reason,
 			RemoteAddr:          getRemoteAddr(req),
 			ReplicationCluster:  req.ReplicationCluster,
 			Headers:             req.Headers,
@@ -336,6 +337,7 @@ func (f *AuditFormatter) FormatResponse(ctx context.Context, w io.Writer, config
 			Path:                req.Path,
 			Data:                req.Data,
 			PolicyOverride:      req.PolicyOverride,
+			Reason:              req.Reason,
 			RemoteAddr:          getRemoteAddr(req),
 			ReplicationCluster:  req.ReplicationCluster,
 			Headers:             req.Headers,
@@ -389,6 +391,7 @@ type AuditRequest struct {
 	Path                string                 `json:"path"`
 	Data                map[string]interface{} `json:"data"`
 	PolicyOverride      bool                   `json:"policy_override"`
+	Reason              string                 `json:"reason,omitempty"`
 	RemoteAddr          string                 `json:"remote_address"`
 	WrapTTL             int                    `json:"wrap_ttl"`
 	Headers             map[string][]string    `json:"headers"`
@@ -49,6 +49,7 @@ func TestFormatJSON_formatRequest(t *testing.T) {
 				Headers: map[string][]string{
 					"foo": []string{"bar"},
 				},
+				Reason: "CHG-1234",
 			},
 			errors.New("this is an error"),
 			"",
@@ -68,6 +69,7 @@ func TestFormatJSON_formatRequest(t *testing.T) {
 				Headers: map[string][]string{
 					"foo": []string{"bar"},
 				},
+				Reason: "CHG-1234",
 			},
 			errors.New("this is an error"),
 			"@cee: ",
@@ -125,5 +127,5 @@ func TestFormatJSON_formatRequest(t *testing.T) {
 	}
 }
 
-const testFormatJSONReqBasicStrFmt = `{"time":"2015-08-05T13:45:46Z","type":"request","auth":{"client_token":"%s","accessor":"bar","display_name":"testtoken","policies":["root"],"metadata":null},"request":{"operation":"update","path":"/foo","data":null,"wrap_ttl":60,"remote_address":"127.0.0.1","headers":{"foo":["bar"]}},"error":"this is an error"}
+const testFormatJSONReqBasicStrFmt = `{"time":"2015-08-05T13:45:46Z","type":"request","auth":{"client_token":"%s","accessor":"bar","display_name":"testtoken","policies":["root"],"metadata":null},"request":{"operation":"update","path":"/foo","data":null,"wrap_ttl":60,"remote_address":"127.0.0.1","headers":{"foo":["bar"]},"reason":"CHG-1234"},"error":"this is an error"}
 `
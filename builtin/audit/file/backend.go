@@ -1,9 +1,10 @@
 package file
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -142,7 +143,7 @@ type Backend struct {
 	saltView   logical.Storage
 }
 
-var _ audit.Backend = (*Backend)(nil)
+var _ audit.EntryBackend = (*Backend)(nil)
 
 func (b *Backend) Salt(ctx context.Context) (*salt.Salt, error) {
 	b.saltMutex.RLock()
@@ -173,52 +174,46 @@ func (b *Backend) GetHash(ctx context.Context, data string) (string, error) {
 }
 
 func (b *Backend) LogRequest(ctx context.Context, in *audit.LogInput) error {
-	b.fileLock.Lock()
-	defer b.fileLock.Unlock()
-
-	switch b.path {
-	case "stdout":
-		return b.formatter.FormatRequest(ctx, os.Stdout, b.formatConfig, in)
-	case "discard":
-		return b.formatter.FormatRequest(ctx, ioutil.Discard, b.formatConfig, in)
-	}
-
-	if err := b.open(); err != nil {
+	var buf bytes.Buffer
+	if err := b.FormatRequest(ctx, &buf, in); err != nil {
 		return err
 	}
+	return b.WriteEntry(ctx, buf.Bytes())
+}
 
-	if err := b.formatter.FormatRequest(ctx, b.f, b.formatConfig, in); err == nil {
-		return nil
-	}
-
-	// Opportunistically try to re-open the FD, once per call
-	b.f.Close()
-	b.f = nil
-
-	if err := b.open(); err != nil {
+func (b *Backend) LogResponse(ctx context.Context, in *audit.LogInput) error {
+	var buf bytes.Buffer
+	if err := b.FormatResponse(ctx, &buf, in); err != nil {
 		return err
 	}
+	return b.WriteEntry(ctx, buf.Bytes())
+}
 
-	return b.formatter.FormatRequest(ctx, b.f, b.formatConfig, in)
+func (b *Backend) FormatRequest(ctx context.Context, w io.Writer, in *audit.LogInput) error {
+	return b.formatter.FormatRequest(ctx, w, b.formatConfig, in)
 }
 
-func (b *Backend) LogResponse(ctx context.Context, in *audit.LogInput) error {
+func (b *Backend) FormatResponse(ctx context.Context, w io.Writer, in *audit.LogInput) error {
+	return b.formatter.FormatResponse(ctx, w, b.formatConfig, in)
+}
 
+func (b *Backend) WriteEntry(_ context.Context, entry []byte) error {
 	b.fileLock.Lock()
 	defer b.fileLock.Unlock()
 
 	switch b.path {
 	case "stdout":
-		return b.formatter.FormatResponse(ctx, os.Stdout, b.formatConfig, in)
+		_, err := os.Stdout.Write(entry)
+		return err
 	case "discard":
-		return b.formatter.FormatResponse(ctx, ioutil.Discard, b.formatConfig, in)
+		return nil
 	}
 
 	if err := b.open(); err != nil {
 		return err
 	}
 
-	if err := b.formatter.FormatResponse(ctx, b.f, b.formatConfig, in); err == nil {
+	if _, err := b.f.Write(entry); err == nil {
 		return nil
 	}
 
@@ -230,7 +225,8 @@ func (b *Backend) LogResponse(ctx context.Context, in *audit.LogInput) error {
 		return err
 	}
 
-	return b.formatter.FormatResponse(ctx, b.f, b.formatConfig, in)
+	_, err := b.f.Write(entry)
+	return err
 }
 
 // The file lock must be held before calling this
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"strconv"
 	"sync"
@@ -121,7 +122,7 @@ type Backend struct {
 	saltView   logical.Storage
 }
 
-var _ audit.Backend = (*Backend)(nil)
+var _ audit.EntryBackend = (*Backend)(nil)
 
 func (b *Backend) GetHash(ctx context.Context, data string) (string, error) {
 	salt, err := b.Salt(ctx)
@@ -133,44 +134,40 @@ func (b *Backend) GetHash(ctx context.Context, data string) (string, error) {
 
 func (b *Backend) LogRequest(ctx context.Context, in *audit.LogInput) error {
 	var buf bytes.Buffer
-	if err := b.formatter.FormatRequest(ctx, &buf, b.formatConfig, in); err != nil {
+	if err := b.FormatRequest(ctx, &buf, in); err != nil {
 		return err
 	}
-
-	b.Lock()
-	defer b.Unlock()
-
-	err := b.write(ctx, buf.Bytes())
-	if err != nil {
-		rErr := b.reconnect(ctx)
-		if rErr != nil {
-			err = multierror.Append(err, rErr)
-		} else {
-			// Try once more after reconnecting
-			err = b.write(ctx, buf.Bytes())
-		}
-	}
-
-	return err
+	return b.WriteEntry(ctx, buf.Bytes())
 }
 
 func (b *Backend) LogResponse(ctx context.Context, in *audit.LogInput) error {
 	var buf bytes.Buffer
-	if err := b.formatter.FormatResponse(ctx, &buf, b.formatConfig, in); err != nil {
+	if err := b.FormatResponse(ctx, &buf, in); err != nil {
 		return err
 	}
+	return b.WriteEntry(ctx, buf.Bytes())
+}
+
+func (b *Backend) FormatRequest(ctx context.Context, w io.Writer, in *audit.LogInput) error {
+	return b.formatter.FormatRequest(ctx, w, b.formatConfig, in)
+}
+
+func (b *Backend) FormatResponse(ctx context.Context, w io.Writer, in *audit.LogInput) error {
+	return b.formatter.FormatResponse(ctx, w, b.formatConfig, in)
+}
 
+func (b *Backend) WriteEntry(ctx context.Context, entry []byte) error {
 	b.Lock()
 	defer b.Unlock()
 
-	err := b.write(ctx, buf.Bytes())
+	err := b.write(ctx, entry)
 	if err != nil {
 		rErr := b.reconnect(ctx)
 		if rErr != nil {
 			err = multierror.Append(err, rErr)
 		} else {
 			// Try once more after reconnecting
-			err = b.write(ctx, buf.Bytes())
+			err = b.write(ctx, entry)
 		}
 	}
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"strconv"
 	"sync"
 
@@ -108,7 +109,7 @@ type Backend struct {
 	saltView   logical.Storage
 }
 
-var _ audit.Backend = (*Backend)(nil)
+var _ audit.EntryBackend = (*Backend)(nil)
 
 func (b *Backend) GetHash(ctx context.Context, data string) (string, error) {
 	salt, err := b.Salt(ctx)
@@ -120,23 +121,31 @@ func (b *Backend) GetHash(ctx context.Context, data string) (string, error) {
 
 func (b *Backend) LogRequest(ctx context.Context, in *audit.LogInput) error {
 	var buf bytes.Buffer
-	if err := b.formatter.FormatRequest(ctx, &buf, b.formatConfig, in); err != nil {
+	if err := b.FormatRequest(ctx, &buf, in); err != nil {
 		return err
 	}
-
-	// Write out to syslog
-	_, err := b.logger.Write(buf.Bytes())
-	return err
+	return b.WriteEntry(ctx, buf.Bytes())
 }
 
 func (b *Backend) LogResponse(ctx context.Context, in *audit.LogInput) error {
 	var buf bytes.Buffer
-	if err := b.formatter.FormatResponse(ctx, &buf, b.formatConfig, in); err != nil {
+	if err := b.FormatResponse(ctx, &buf, in); err != nil {
 		return err
 	}
+	return b.WriteEntry(ctx, buf.Bytes())
+}
+
+func (b *Backend) FormatRequest(ctx context.Context, w io.Writer, in *audit.LogInput) error {
+	return b.formatter.FormatRequest(ctx, w, b.formatConfig, in)
+}
+
+func (b *Backend) FormatResponse(ctx context.Context, w io.Writer, in *audit.LogInput) error {
+	return b.formatter.FormatResponse(ctx, w, b.formatConfig, in)
+}
 
-	// Write out to syslog
-	_, err := b.logger.Write(buf.Bytes())
+// WriteEntry writes out an entry to syslog
+func (b *Backend) WriteEntry(_ context.Context, entry []byte) error {
+	_, err := b.logger.Write(entry)
 	return err
 }
 
@@ -112,6 +112,18 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 	return secret, nil
 }
 
+// AuthKeys returns the keys of the configuration accepted by Auth
+func (h *CLIHandler) AuthKeys() []string {
+	return []string{
+		"aws_access_key_id",
+		"aws_secret_access_key",
+		"aws_security_token",
+		"header_value",
+		"mount",
+		"role",
+	}
+}
+
 func (h *CLIHandler) Help() string {
 	help := `
 Usage: vault login -method=aws [CONFIG K=V...]
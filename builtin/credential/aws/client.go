@@ -52,6 +52,8 @@ func (b *backend) getRawClientConfig(ctx context.Context, s logical.Storage, reg
 		maxRetries = config.MaxRetries
 	}
 
+	// The credential providers reach the instance metadata, the egress
+	// configuration only applies to the AWS APIs
 	credsConfig.HTTPClient = cleanhttp.DefaultClient()
 
 	creds, err := credsConfig.GenerateCredentialChain()
@@ -62,11 +64,16 @@ func (b *backend) getRawClientConfig(ctx context.Context, s logical.Storage, reg
 		return nil, fmt.Errorf("could not compile valid credential providers from static config, environment, shared, or instance metadata")
 	}
 
+	httpClient, err := logical.OutboundHTTPClient(b.System())
+	if err != nil {
+		return nil, errwrap.Wrapf("error building the HTTP client: {{err}}", err)
+	}
+
 	// Create a config that can be used to make the API calls.
 	return &aws.Config{
 		Credentials: creds,
 		Region:      aws.String(region),
-		HTTPClient:  cleanhttp.DefaultClient(),
+		HTTPClient:  httpClient,
 		Endpoint:    endpoint,
 		MaxRetries:  aws.Int(maxRetries),
 	}, nil
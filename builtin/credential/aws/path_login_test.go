@@ -208,3 +208,47 @@ func TestBackend_pathLogin_parseIamRequestHeaders(t *testing.T) {
 		t.Errorf("error parsing mixed-style headers: %v", err)
 	}
 }
+
+func TestBackend_pathLogin_validateMetadata(t *testing.T) {
+	stored := &whitelistIdentity{
+		ClientNonce: "nonce",
+		PendingTime: "2018-06-01T10:00:00Z",
+	}
+	role := &awsRoleEntry{}
+	migrationRole := &awsRoleEntry{AllowInstanceMigration: true}
+
+	testCases := []struct {
+		name        string
+		nonce       string
+		pendingTime string
+		identity    *whitelistIdentity
+		role        *awsRoleEntry
+		wantErr     bool
+	}{
+		{"same nonce", "nonce", "2018-06-01T10:00:00Z", stored, role, false},
+		{"newer pending time", "nonce", "2018-06-02T10:00:00Z", stored, role, false},
+		{"older pending time", "nonce", "2018-05-31T10:00:00Z", stored, role, true},
+		{"nonce mismatch", "other", "2018-06-02T10:00:00Z", stored, role, true},
+		{"migration with newer pending time", "other", "2018-06-02T10:00:00Z", stored, migrationRole, false},
+		{"migration with same pending time", "other", "2018-06-01T10:00:00Z", stored, migrationRole, true},
+		{"reauthentication disabled nonce", reauthenticationDisabledNonce, "2018-06-01T10:00:00Z", stored, role, true},
+		{"reauthentication disabled", "nonce", "2018-06-01T10:00:00Z", &whitelistIdentity{
+			ClientNonce:              "nonce",
+			PendingTime:              "2018-06-01T10:00:00Z",
+			DisallowReauthentication: true,
+		}, role, true},
+		{"missing stored nonce", "", "2018-06-01T10:00:00Z", &whitelistIdentity{
+			PendingTime: "2018-06-01T10:00:00Z",
+		}, role, true},
+	}
+
+	for _, tc := range testCases {
+		err := validateMetadata(tc.nonce, tc.pendingTime, tc.identity, tc.role)
+		if tc.wantErr && err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+		if !tc.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
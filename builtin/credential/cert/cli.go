@@ -38,6 +38,13 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 	return secret, nil
 }
 
+// AuthKeys returns the keys of the configuration accepted by Auth
+func (h *CLIHandler) AuthKeys() []string {
+	return []string{
+		"name",
+	}
+}
+
 func (h *CLIHandler) Help() string {
 	help := `
 Usage: vault login -method=cert [CONFIG K=V...]
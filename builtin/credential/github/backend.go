@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/google/go-github/github"
-	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/helper/mfa"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -67,7 +66,10 @@ type backend struct {
 // Client returns the GitHub client to communicate to GitHub via the
 // configured settings.
 func (b *backend) Client(token string) (*github.Client, error) {
-	tc := cleanhttp.DefaultClient()
+	tc, err := logical.OutboundHTTPClient(b.System())
+	if err != nil {
+		return nil, err
+	}
 	if token != "" {
 		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, tc)
 		tc = oauth2.NewClient(ctx, &tokenSource{Value: token})
@@ -60,6 +60,16 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 	return secret, nil
 }
 
+// AuthKeys returns the keys of the configuration accepted by Auth
+func (h *CLIHandler) AuthKeys() []string {
+	return []string{
+		"method",
+		"passcode",
+		"password",
+		"username",
+	}
+}
+
 func (h *CLIHandler) Help() string {
 	help := `
 Usage: vault login -method=ldap [CONFIG K=V...]
@@ -65,7 +65,11 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 		return nil, logical.ErrorResponse("Okta auth method not configured"), nil, nil
 	}
 
-	client := cfg.OktaClient()
+	httpClient, err := logical.OutboundHTTPClient(b.System())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	client := cfg.OktaClient(httpClient)
 
 	type mfaFactor struct {
 		Id       string `json:"id"`
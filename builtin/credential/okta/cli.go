@@ -60,6 +60,14 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 }
 
 // Help method for okta cli
+// AuthKeys returns the keys of the configuration accepted by Auth
+func (h *CLIHandler) AuthKeys() []string {
+	return []string{
+		"password",
+		"username",
+	}
+}
+
 func (h *CLIHandler) Help() string {
 	help := `
 Usage: vault login -method=okta [CONFIG K=V...]
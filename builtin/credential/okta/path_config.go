@@ -3,12 +3,12 @@ package okta
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 
 	"time"
 
 	"github.com/chrismalek/oktasdk-go/okta"
-	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -229,8 +229,9 @@ func (b *backend) pathConfigExistenceCheck(ctx context.Context, req *logical.Req
 	return cfg != nil, nil
 }
 
-// OktaClient creates a basic okta client connection
-func (c *ConfigEntry) OktaClient() *okta.Client {
+// OktaClient creates a basic okta client connection over the given HTTP
+// client
+func (c *ConfigEntry) OktaClient(httpClient *http.Client) *okta.Client {
 	baseURL := defaultBaseURL
 	if c.Production != nil {
 		if !*c.Production {
@@ -242,7 +243,7 @@ func (c *ConfigEntry) OktaClient() *okta.Client {
 	}
 
 	// We validate config on input and errors are only returned when parsing URLs
-	client, _ := okta.NewClientWithDomain(cleanhttp.DefaultClient(), c.Org, baseURL, c.Token)
+	client, _ := okta.NewClientWithDomain(httpClient, c.Org, baseURL, c.Token)
 	return client
 }
 
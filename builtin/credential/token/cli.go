@@ -137,6 +137,14 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 
 }
 
+// AuthKeys returns the keys of the configuration accepted by Auth
+func (h *CLIHandler) AuthKeys() []string {
+	return []string{
+		"lookup",
+		"token",
+	}
+}
+
 func (h *CLIHandler) Help() string {
 	help := `
 Usage: vault login TOKEN [CONFIG K=V...]
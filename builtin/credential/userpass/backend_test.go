@@ -318,3 +318,117 @@ func testAccStepReadUser(t *testing.T, name string, policies string) logicaltest
 		},
 	}
 }
+
+// testPasswordSystemView stands in for the password policies of Vault with a
+// "long" policy requiring 12 characters
+type testPasswordSystemView struct {
+	*logical.StaticSystemView
+}
+
+func (v testPasswordSystemView) CheckPasswordAgainstPolicy(ctx context.Context, policyName, password string) error {
+	if policyName != "long" {
+		return fmt.Errorf("password policy %q not found", policyName)
+	}
+	if len(password) < 12 {
+		return fmt.Errorf("password must be at least 12 characters long")
+	}
+	return nil
+}
+
+func TestBackend_passwordChange(t *testing.T) {
+	sysView := testPasswordSystemView{
+		StaticSystemView: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: testSysTTL,
+			MaxLeaseTTLVal:     testSysMaxTTL,
+			EntityVal: &logical.Entity{
+				ID: "entity",
+				Aliases: []*logical.Alias{
+					{MountAccessor: "userpass_accessor", Name: "web"},
+				},
+			},
+		},
+	}
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = sysView
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+
+	request := func(path string, data map[string]interface{}, self bool) (*logical.Response, error) {
+		req := &logical.Request{
+			Operation:     logical.UpdateOperation,
+			Path:          path,
+			Data:          data,
+			Storage:       config.StorageView,
+			MountAccessor: "userpass_accessor",
+		}
+		if self {
+			req.EntityID = "entity"
+		}
+		return b.HandleRequest(context.Background(), req)
+	}
+	login := func(password string) bool {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation:  logical.UpdateOperation,
+			Path:       "login/web",
+			Data:       map[string]interface{}{"password": password},
+			Storage:    config.StorageView,
+			Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+		})
+		return err == nil && resp != nil && resp.Auth != nil
+	}
+
+	if resp, err := request("users/web", map[string]interface{}{"password": "password"}, false); err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	// Users must give their current password to change it
+	if resp, err := request("users/web/password", map[string]interface{}{"password": "newpassword"}, true); err != logical.ErrInvalidRequest || !resp.IsError() {
+		t.Fatalf("expected a missing old_password error, got resp: %#v, err: %v", resp, err)
+	}
+	if resp, err := request("users/web/password", map[string]interface{}{"password": "newpassword", "old_password": "wrong"}, true); err != logical.ErrPermissionDenied || !resp.IsError() {
+		t.Fatalf("expected an invalid old_password error, got resp: %#v, err: %v", resp, err)
+	}
+	if resp, err := request("users/web/password", map[string]interface{}{"password": "newpassword", "old_password": "password"}, true); err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if login("password") || !login("newpassword") {
+		t.Fatal("expected the password to be changed")
+	}
+
+	// Others may reset it without the current password
+	if resp, err := request("users/web/password", map[string]interface{}{"password": "resetpassword"}, false); err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if !login("resetpassword") {
+		t.Fatal("expected the password to be reset")
+	}
+
+	// New passwords must follow the password policy
+	if resp, err := request("config", map[string]interface{}{"password_policy": "long"}, false); err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if resp, err := request("users/web/password", map[string]interface{}{"password": "short", "old_password": "resetpassword"}, true); err != logical.ErrInvalidRequest || !resp.IsError() {
+		t.Fatalf("expected the password to be rejected, got resp: %#v, err: %v", resp, err)
+	}
+	if resp, err := request("users/web2", map[string]interface{}{"password": "short"}, false); err != logical.ErrInvalidRequest || !resp.IsError() {
+		t.Fatalf("expected the password to be rejected, got resp: %#v, err: %v", resp, err)
+	}
+	if resp, err := request("users/web/password", map[string]interface{}{"password": "a-long-password", "old_password": "resetpassword"}, true); err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if !login("a-long-password") {
+		t.Fatal("expected the password to be changed")
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config",
+		Storage:   config.StorageView,
+	})
+	if err != nil || resp.Data["password_policy"] != "long" {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+}
@@ -75,17 +75,8 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 		return logical.ErrorResponse("login request originated from invalid CIDR"), nil
 	}
 
-	// Check for a password match. Check for a hash collision for Vault 0.2+,
-	// but handle the older legacy passwords with a constant time comparison.
-	passwordBytes := []byte(password)
-	if user.PasswordHash != nil {
-		if err := bcrypt.CompareHashAndPassword(user.PasswordHash, passwordBytes); err != nil {
-			return logical.ErrorResponse("invalid username or password"), nil
-		}
-	} else {
-		if subtle.ConstantTimeCompare([]byte(user.Password), passwordBytes) != 1 {
-			return logical.ErrorResponse("invalid username or password"), nil
-		}
+	if !passwordMatches(user, password) {
+		return logical.ErrorResponse("invalid username or password"), nil
 	}
 
 	return &logical.Response{
@@ -108,6 +99,17 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 	}, nil
 }
 
+// passwordMatches returns whether the password is that of the user. It checks
+// for a hash collision for Vault 0.2+, but handles the older legacy passwords
+// with a constant time comparison.
+func passwordMatches(user *UserEntry, password string) bool {
+	passwordBytes := []byte(password)
+	if user.PasswordHash != nil {
+		return bcrypt.CompareHashAndPassword(user.PasswordHash, passwordBytes) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(user.Password), passwordBytes) == 1
+}
+
 func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	// Get the user
 	user, err := b.user(ctx, req.Storage, req.Auth.Metadata["username"])
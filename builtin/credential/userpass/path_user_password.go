@@ -3,9 +3,11 @@ package userpass
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -23,6 +25,12 @@ func pathUserPassword(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "Password for this user.",
 			},
+
+			"old_password": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Current password of this user. Required when users change
+their own password; if given, it must match.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -35,7 +43,7 @@ func pathUserPassword(b *backend) *framework.Path {
 }
 
 func (b *backend) pathUserPasswordUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	username := d.Get("username").(string)
+	username := strings.ToLower(d.Get("username").(string))
 
 	userEntry, err := b.user(ctx, req.Storage, username)
 	if err != nil {
@@ -45,9 +53,22 @@ func (b *backend) pathUserPasswordUpdate(ctx context.Context, req *logical.Reque
 		return nil, fmt.Errorf("username does not exist")
 	}
 
-	userErr, intErr := b.updateUserPassword(req, d, userEntry)
+	oldPassword := d.Get("old_password").(string)
+	if oldPassword == "" {
+		self, err := b.isUser(req, username)
+		if err != nil {
+			return nil, err
+		}
+		if self {
+			return logical.ErrorResponse("missing old_password"), logical.ErrInvalidRequest
+		}
+	} else if !passwordMatches(userEntry, oldPassword) {
+		return logical.ErrorResponse("invalid old_password"), logical.ErrPermissionDenied
+	}
+
+	userErr, intErr := b.updateUserPassword(ctx, req, d, userEntry)
 	if intErr != nil {
-		return nil, err
+		return nil, intErr
 	}
 	if userErr != nil {
 		return logical.ErrorResponse(userErr.Error()), logical.ErrInvalidRequest
@@ -56,11 +77,26 @@ func (b *backend) pathUserPasswordUpdate(ctx context.Context, req *logical.Reque
 	return nil, b.setUser(ctx, req.Storage, username, userEntry)
 }
 
-func (b *backend) updateUserPassword(req *logical.Request, d *framework.FieldData, userEntry *UserEntry) (error, error) {
+func (b *backend) updateUserPassword(ctx context.Context, req *logical.Request, d *framework.FieldData, userEntry *UserEntry) (error, error) {
 	password := d.Get("password").(string)
 	if password == "" {
 		return fmt.Errorf("missing password"), nil
 	}
+
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config.PasswordPolicy != "" {
+		checker, ok := b.System().(logical.PasswordChecker)
+		if !ok {
+			return nil, logical.ErrPasswordPoliciesUnsupported
+		}
+		if err := checker.CheckPasswordAgainstPolicy(ctx, config.PasswordPolicy, password); err != nil {
+			return errwrap.Wrapf("password rejected by the password policy: {{err}}", err), nil
+		}
+	}
+
 	// Generate a hash of the password
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -70,10 +106,34 @@ func (b *backend) updateUserPassword(req *logical.Request, d *framework.FieldDat
 	return nil, nil
 }
 
+// isUser returns whether the request is made with a token the auth method
+// issued to the user
+func (b *backend) isUser(req *logical.Request, username string) (bool, error) {
+	if req.EntityID == "" {
+		return false, nil
+	}
+	entity, err := b.System().EntityInfo(req.EntityID)
+	if err != nil {
+		return false, err
+	}
+	if entity == nil {
+		return false, nil
+	}
+	for _, alias := range entity.Aliases {
+		if alias.MountAccessor == req.MountAccessor && strings.ToLower(alias.Name) == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 const pathUserPasswordHelpSyn = `
 Reset user's password.
 `
 
 const pathUserPasswordHelpDesc = `
-This endpoint allows resetting the user's password.
+This endpoint allows resetting the user's password. Users changing their own
+password must also give their current password as "old_password"; if given by
+anyone else, it must match as well. If the auth method is configured with a
+password policy, the new password must follow it.
 `
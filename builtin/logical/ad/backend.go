@@ -1,4 +1,4 @@
-package plugin
+package ad
 
 import (
 	"context"
@@ -14,7 +14,9 @@ import (
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
 	backend := newBackend(util.NewSecretsClient(conf.Logger))
-	backend.Setup(ctx, conf)
+	if err := backend.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
 	return backend, nil
 }
 
@@ -33,6 +35,19 @@ func newBackend(client secretsClient) *backend {
 			adBackend.pathListRoles(),
 			adBackend.pathCreds(),
 			adBackend.pathRotateCredentials(),
+			adBackend.pathRotateRole(),
+
+			// The check-out paths come before the set paths they share a
+			// prefix with.
+			adBackend.pathManageCheckIn(),
+			adBackend.pathCheckOut(),
+			adBackend.pathCheckIn(),
+			adBackend.pathLibraryStatus(),
+			adBackend.pathLibrarySets(),
+			adBackend.pathListLibrarySets(),
+		},
+		Secrets: []*framework.Secret{
+			adBackend.checkOutSecret(),
 		},
 		PathsSpecial: &logical.Paths{
 			SealWrapStorage: []string{
@@ -55,6 +70,9 @@ type backend struct {
 	credCache      *cache.Cache
 	credLock       sync.Mutex
 	rotateRootLock *int32
+
+	// checkOutLock serializes the check-outs and check-ins of the library
+	checkOutLock sync.Mutex
 }
 
 func (b *backend) Invalidate(ctx context.Context, key string) {
@@ -79,5 +97,9 @@ As long as access has been granted to the creds path via a method like
 AppRole, they're available.
 
 Passwords are lazily rotated based on preset TTLs and can have a length configured to meet 
-your needs.
+your needs. They can also be rotated on demand.
+
+Service accounts that shouldn't be shared can be placed in a library set instead, and
+checked out by one caller at a time. Their passwords are rotated on every check-out and
+check-in.
 `
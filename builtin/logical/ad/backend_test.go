@@ -0,0 +1,553 @@
+package ad
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault-plugin-secrets-ad/plugin/client"
+	"github.com/hashicorp/vault/logical"
+)
+
+// fakeSecretsClient stands in for Active Directory, keeping the passwords of
+// a fixed set of service accounts in memory
+type fakeSecretsClient struct {
+	l         sync.Mutex
+	passwords map[string]string
+	lastSet   map[string]time.Time
+}
+
+func newFakeSecretsClient(serviceAccountNames ...string) *fakeSecretsClient {
+	c := &fakeSecretsClient{
+		passwords: make(map[string]string),
+		lastSet:   make(map[string]time.Time),
+	}
+	for _, name := range serviceAccountNames {
+		c.passwords[name] = ""
+	}
+	return c
+}
+
+func (c *fakeSecretsClient) Get(conf *client.ADConf, serviceAccountName string) (*client.Entry, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if _, ok := c.passwords[serviceAccountName]; !ok {
+		return nil, fmt.Errorf("unable to find service account named %s in active directory", serviceAccountName)
+	}
+	return &client.Entry{}, nil
+}
+
+func (c *fakeSecretsClient) GetPasswordLastSet(conf *client.ADConf, serviceAccountName string) (time.Time, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.lastSet[serviceAccountName], nil
+}
+
+func (c *fakeSecretsClient) UpdatePassword(conf *client.ADConf, serviceAccountName string, newPassword string) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if _, ok := c.passwords[serviceAccountName]; !ok {
+		return fmt.Errorf("unable to find service account named %s in active directory", serviceAccountName)
+	}
+	c.passwords[serviceAccountName] = newPassword
+	c.lastSet[serviceAccountName] = time.Now().UTC()
+	return nil
+}
+
+func (c *fakeSecretsClient) UpdateRootPassword(conf *client.ADConf, bindDN string, newPassword string) error {
+	return nil
+}
+
+func (c *fakeSecretsClient) password(serviceAccountName string) string {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.passwords[serviceAccountName]
+}
+
+type testBackend struct {
+	*backend
+	t       *testing.T
+	storage logical.Storage
+}
+
+func newTestBackend(t *testing.T, fake *fakeSecretsClient) *testBackend {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := newBackend(fake)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	tb := &testBackend{backend: b, t: t, storage: config.StorageView}
+	tb.mustHandle(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      configPath,
+		Data: map[string]interface{}{
+			"url":      "ldap://127.0.0.1",
+			"binddn":   "cn=vault,dc=example,dc=com",
+			"bindpass": "password",
+			"ttl":      600,
+			"max_ttl":  3600,
+			"length":   20,
+		},
+	})
+	return tb
+}
+
+func (tb *testBackend) handle(req *logical.Request) (*logical.Response, error) {
+	req.Storage = tb.storage
+	return tb.HandleRequest(context.Background(), req)
+}
+
+func (tb *testBackend) mustHandle(req *logical.Request) *logical.Response {
+	tb.t.Helper()
+	resp, err := tb.handle(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		tb.t.Fatalf("%s %s: resp: %#v, err: %v", req.Operation, req.Path, resp, err)
+	}
+	return resp
+}
+
+func (tb *testBackend) mustFail(req *logical.Request, contains string) {
+	tb.t.Helper()
+	resp, err := tb.handle(req)
+	if err == nil && (resp == nil || !resp.IsError()) {
+		tb.t.Fatalf("%s %s: expected an error, resp: %#v", req.Operation, req.Path, resp)
+	}
+	msg := fmt.Sprintf("%v", err)
+	if resp != nil && resp.IsError() {
+		msg = resp.Error().Error()
+	}
+	if !strings.Contains(msg, contains) {
+		tb.t.Fatalf("%s %s: expected error containing %q, got %q", req.Operation, req.Path, contains, msg)
+	}
+}
+
+func TestBackend_LibraryCRUD(t *testing.T) {
+	fake := newFakeSecretsClient("tester1@example.com", "tester2@example.com", "tester3@example.com")
+	b := newTestBackend(t, fake)
+
+	b.mustHandle(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/test-set",
+		Data: map[string]interface{}{
+			"service_account_names": "tester1@example.com,tester2@example.com",
+			"ttl":                   "10m",
+			"max_ttl":               "20m",
+		},
+	})
+
+	resp := b.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "library/test-set",
+	})
+	expected := map[string]interface{}{
+		"service_account_names":        []string{"tester1@example.com", "tester2@example.com"},
+		"ttl":                          int64(600),
+		"max_ttl":                      int64(1200),
+		"disable_check_in_enforcement": false,
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, resp.Data)
+	}
+
+	// The ttls default to those of the config
+	b.mustHandle(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/other-set",
+		Data: map[string]interface{}{
+			"service_account_names": "tester3@example.com",
+		},
+	})
+	resp = b.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "library/other-set",
+	})
+	if resp.Data["ttl"] != int64(600) || resp.Data["max_ttl"] != int64(3600) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = b.mustHandle(&logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "library/",
+	})
+	keys := resp.Data["keys"].([]string)
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"other-set", "test-set"}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+
+	// Accounts can only be in one set, must exist, and the ttl can't exceed
+	// the max ttl
+	b.mustFail(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/third-set",
+		Data: map[string]interface{}{
+			"service_account_names": "tester1@example.com",
+		},
+	}, `"tester1@example.com" is already in the "test-set" set`)
+	b.mustFail(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/third-set",
+		Data: map[string]interface{}{
+			"service_account_names": "missing@example.com",
+		},
+	}, "unable to find service account")
+	b.mustFail(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/third-set",
+		Data: map[string]interface{}{
+			"ttl": "2h",
+		},
+	}, "service_account_names")
+	b.mustFail(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/test-set",
+		Data: map[string]interface{}{
+			"service_account_names": "tester1@example.com",
+			"ttl":                   "2h",
+			"max_ttl":               "1h",
+		},
+	}, "over the max ttl")
+
+	b.mustHandle(&logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "library/other-set",
+	})
+	resp = b.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "library/other-set",
+	})
+	if resp != nil {
+		t.Fatalf("expected the set to be deleted, got %#v", resp)
+	}
+
+	// Sets can't lose or be deleted with accounts that are checked out
+	resp = b.mustHandle(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-out",
+		ClientToken: "token1",
+	})
+	checkedOut := resp.Data["service_account_name"].(string)
+	other := "tester1@example.com"
+	if checkedOut == other {
+		other = "tester2@example.com"
+	}
+	b.mustFail(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/test-set",
+		Data: map[string]interface{}{
+			"service_account_names": other,
+		},
+	}, "is checked out and can't be removed")
+	b.mustFail(&logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "library/test-set",
+	}, "can't be deleted while accounts are checked out")
+
+	b.mustHandle(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-in",
+		ClientToken: "token1",
+	})
+	b.mustHandle(&logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "library/test-set",
+	})
+}
+
+func TestBackend_CheckOutCheckIn(t *testing.T) {
+	fake := newFakeSecretsClient("tester1@example.com", "tester2@example.com")
+	b := newTestBackend(t, fake)
+
+	b.mustHandle(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/test-set",
+		Data: map[string]interface{}{
+			"service_account_names": "tester1@example.com,tester2@example.com",
+			"ttl":                   "10m",
+			"max_ttl":               "20m",
+		},
+	})
+
+	// Each check-out gets an account of its own with a fresh password, which
+	// is the account's password in AD
+	resp := b.mustHandle(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-out",
+		ClientToken: "token1",
+		Data: map[string]interface{}{
+			"ttl": "5m",
+		},
+	})
+	first := resp.Data["service_account_name"].(string)
+	firstPassword := resp.Data["password"].(string)
+	if firstPassword == "" || fake.password(first) != firstPassword {
+		t.Fatalf("expected the password of %s to be rotated to %q, got %q", first, firstPassword, fake.password(first))
+	}
+	if resp.Secret == nil || resp.Secret.TTL != 5*time.Minute || resp.Secret.MaxTTL != 20*time.Minute {
+		t.Fatalf("bad: %#v", resp.Secret)
+	}
+	firstSecret := resp.Secret
+
+	resp = b.mustHandle(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-out",
+		ClientToken: "token2",
+	})
+	second := resp.Data["service_account_name"].(string)
+	if second == first {
+		t.Fatalf("expected a different account than %s", first)
+	}
+	if resp.Secret.TTL != 10*time.Minute {
+		t.Fatalf("bad: %#v", resp.Secret)
+	}
+	secondSecret := resp.Secret
+
+	b.mustFail(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-out",
+		ClientToken: "token3",
+	}, "no service accounts are available")
+
+	resp = b.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "library/test-set/status",
+	})
+	for _, name := range []string{first, second} {
+		status := resp.Data[name].(map[string]interface{})
+		if status["available"] != false {
+			t.Fatalf("expected %s to be checked out: %#v", name, status)
+		}
+	}
+
+	// Only the borrower can check an account in, unless it's done through
+	// the manage endpoint
+	b.mustFail(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-in",
+		ClientToken: "token2",
+		Data: map[string]interface{}{
+			"service_account_names": first,
+		},
+	}, "is checked out by someone else")
+	resp = b.mustHandle(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-in",
+		ClientToken: "token1",
+	})
+	if !reflect.DeepEqual(resp.Data["check_ins"], []string{first}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if fake.password(first) == firstPassword {
+		t.Fatalf("expected the password of %s to be rotated on check-in", first)
+	}
+
+	resp = b.mustHandle(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/manage/test-set/check-in",
+		Data: map[string]interface{}{
+			"service_account_names": second,
+		},
+	})
+	if !reflect.DeepEqual(resp.Data["check_ins"], []string{second}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = b.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "library/test-set/status",
+	})
+	for _, name := range []string{first, second} {
+		status := resp.Data[name].(map[string]interface{})
+		if status["available"] != true {
+			t.Fatalf("expected %s to be available: %#v", name, status)
+		}
+	}
+
+	// The leases of check-outs that were checked in can't be renewed, and
+	// revoking them doesn't check in a newer check-out of the account
+	b.mustFail(&logical.Request{
+		Operation: logical.RenewOperation,
+		Secret:    secondSecret,
+	}, "has already been checked in")
+
+	resp = b.mustHandle(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-out",
+		ClientToken: "token3",
+	})
+	third := resp.Data["service_account_name"].(string)
+	thirdPassword := resp.Data["password"].(string)
+	thirdSecret := resp.Secret
+	for _, secret := range []*logical.Secret{firstSecret, secondSecret} {
+		b.mustHandle(&logical.Request{
+			Operation: logical.RevokeOperation,
+			Secret:    secret,
+		})
+	}
+	if fake.password(third) != thirdPassword {
+		t.Fatalf("expected the password of %s to be left alone", third)
+	}
+
+	// Revoking the lease of the current check-out checks the account in
+	b.mustHandle(&logical.Request{
+		Operation: logical.RevokeOperation,
+		Secret:    thirdSecret,
+	})
+	if fake.password(third) == thirdPassword {
+		t.Fatalf("expected the password of %s to be rotated on revocation", third)
+	}
+	resp = b.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "library/test-set/status",
+	})
+	if status := resp.Data[third].(map[string]interface{}); status["available"] != true {
+		t.Fatalf("expected %s to be available: %#v", third, status)
+	}
+}
+
+func TestBackend_CheckInEnforcementDisabled(t *testing.T) {
+	fake := newFakeSecretsClient("tester1@example.com")
+	b := newTestBackend(t, fake)
+
+	b.mustHandle(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "library/test-set",
+		Data: map[string]interface{}{
+			"service_account_names":        "tester1@example.com",
+			"disable_check_in_enforcement": true,
+		},
+	})
+	b.mustHandle(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-out",
+		ClientToken: "token1",
+	})
+
+	resp := b.mustHandle(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "library/test-set/check-in",
+		ClientToken: "token2",
+	})
+	if !reflect.DeepEqual(resp.Data["check_ins"], []string{"tester1@example.com"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestBackend_RotateRole(t *testing.T) {
+	fake := newFakeSecretsClient("tester1@example.com")
+	b := newTestBackend(t, fake)
+
+	b.mustHandle(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/test-role",
+		Data: map[string]interface{}{
+			"service_account_name": "tester1@example.com",
+		},
+	})
+	resp := b.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/test-role",
+	})
+	password := resp.Data["current_password"].(string)
+	if password == "" || fake.password("tester1@example.com") != password {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	b.mustHandle(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "rotate-role/test-role",
+	})
+	newPassword := fake.password("tester1@example.com")
+	if newPassword == password {
+		t.Fatalf("expected the password to be rotated")
+	}
+
+	// The creds return the new password, and the old one as the last password
+	resp = b.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/test-role",
+	})
+	if resp.Data["current_password"] != newPassword || resp.Data["last_password"] != password {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	b.mustFail(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "rotate-role/missing",
+	}, "unknown role")
+}
+
+// passwordPolicySystemView generates the passwords of a single password
+// policy
+type passwordPolicySystemView struct {
+	logical.StaticSystemView
+	policy, password string
+}
+
+func (s *passwordPolicySystemView) GeneratePasswordFromPolicy(_ context.Context, policyName string) (string, error) {
+	if policyName != s.policy {
+		return "", fmt.Errorf("password policy %q not found", policyName)
+	}
+	return s.password, nil
+}
+
+func TestBackend_PasswordPolicy(t *testing.T) {
+	fake := newFakeSecretsClient("tester1@example.com")
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = &passwordPolicySystemView{
+		StaticSystemView: *config.System.(*logical.StaticSystemView),
+		policy:           "ad",
+		password:         "generated-from-policy",
+	}
+	b := newBackend(fake)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	tb := &testBackend{backend: b, t: t, storage: config.StorageView}
+
+	configReq := func(data map[string]interface{}) *logical.Request {
+		data["url"] = "ldap://127.0.0.1"
+		data["binddn"] = "cn=vault,dc=example,dc=com"
+		data["bindpass"] = "password"
+		return &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      configPath,
+			Data:      data,
+		}
+	}
+	tb.mustFail(configReq(map[string]interface{}{"password_policy": "missing"}), `"missing" not found`)
+	tb.mustFail(configReq(map[string]interface{}{"password_policy": "ad", "length": 20}), "cannot be set with length or formatter")
+	tb.mustHandle(configReq(map[string]interface{}{"password_policy": "ad"}))
+
+	resp := tb.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      configPath,
+	})
+	if resp.Data["password_policy"] != "ad" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The passwords of the service accounts follow the policy
+	tb.mustHandle(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/test-role",
+		Data: map[string]interface{}{
+			"service_account_name": "tester1@example.com",
+		},
+	})
+	resp = tb.mustHandle(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/test-role",
+	})
+	if resp.Data["current_password"] != "generated-from-policy" || fake.password("tester1@example.com") != "generated-from-policy" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
@@ -1,4 +1,4 @@
-package plugin
+package ad
 
 import (
 	"github.com/hashicorp/vault-plugin-secrets-ad/plugin/client"
@@ -0,0 +1,109 @@
+package ad
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	libraryPrefix     = "library/"
+	libraryStorageKey = "library"
+
+	checkOutStorageKey = "checkout"
+)
+
+// librarySet is a set of service accounts that are checked out by one caller
+// at a time, rather than shared like the account of a role.
+type librarySet struct {
+	ServiceAccountNames       []string      `json:"service_account_names"`
+	TTL                       time.Duration `json:"ttl"`
+	MaxTTL                    time.Duration `json:"max_ttl"`
+	DisableCheckInEnforcement bool          `json:"disable_check_in_enforcement"`
+}
+
+func (s *librarySet) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"service_account_names":        s.ServiceAccountNames,
+		"ttl":                          int64(s.TTL.Seconds()),
+		"max_ttl":                      int64(s.MaxTTL.Seconds()),
+		"disable_check_in_enforcement": s.DisableCheckInEnforcement,
+	}
+}
+
+func (s *librarySet) has(serviceAccountName string) bool {
+	for _, name := range s.ServiceAccountNames {
+		if name == serviceAccountName {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOut records who a service account is checked out to. Accounts without
+// a check-out are available.
+type checkOut struct {
+	// ID tells check-outs of the same account apart, so the expiry of the
+	// lease of an old check-out doesn't check in a newer one.
+	ID                  string    `json:"id"`
+	LibrarySetName      string    `json:"library_set_name"`
+	BorrowerEntityID    string    `json:"borrower_entity_id"`
+	BorrowerClientToken string    `json:"borrower_client_token"`
+	CheckedOutAt        time.Time `json:"checked_out_at"`
+}
+
+// borrowedBy tells whether the check-out belongs to the caller of the request,
+// by entity when both have one and by token otherwise.
+func (c *checkOut) borrowedBy(req *logical.Request) bool {
+	if c.BorrowerEntityID != "" && req.EntityID != "" {
+		return c.BorrowerEntityID == req.EntityID
+	}
+	return c.BorrowerClientToken == req.ClientToken
+}
+
+func readLibrarySet(ctx context.Context, storage logical.Storage, setName string) (*librarySet, error) {
+	entry, err := storage.Get(ctx, libraryStorageKey+"/"+setName)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	set := &librarySet{}
+	if err := entry.DecodeJSON(set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func writeLibrarySet(ctx context.Context, storage logical.Storage, setName string, set *librarySet) error {
+	entry, err := logical.StorageEntryJSON(libraryStorageKey+"/"+setName, set)
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, entry)
+}
+
+func readCheckOut(ctx context.Context, storage logical.Storage, serviceAccountName string) (*checkOut, error) {
+	entry, err := storage.Get(ctx, checkOutStorageKey+"/"+serviceAccountName)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	c := &checkOut{}
+	if err := entry.DecodeJSON(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func writeCheckOut(ctx context.Context, storage logical.Storage, serviceAccountName string, c *checkOut) error {
+	entry, err := logical.StorageEntryJSON(checkOutStorageKey+"/"+serviceAccountName, c)
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, entry)
+}
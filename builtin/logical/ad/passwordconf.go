@@ -0,0 +1,43 @@
+package ad
+
+import (
+	"context"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault-plugin-secrets-ad/plugin/util"
+	"github.com/hashicorp/vault/logical"
+)
+
+type passwordConf struct {
+	TTL       int    `json:"ttl"`
+	MaxTTL    int    `json:"max_ttl"`
+	Length    int    `json:"length"`
+	Formatter string `json:"formatter"`
+
+	// PasswordPolicy is the name of the password policy the passwords are
+	// generated with instead of Length and Formatter when set.
+	PasswordPolicy string `json:"password_policy"`
+}
+
+func (c *passwordConf) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"ttl":             c.TTL,
+		"max_ttl":         c.MaxTTL,
+		"length":          c.Length,
+		"formatter":       c.Formatter,
+		"password_policy": c.PasswordPolicy,
+	}
+}
+
+// generatePassword returns a new password following the password policy of
+// the configuration if one is set, and its length and formatter otherwise.
+func (b *backend) generatePassword(ctx context.Context, c *passwordConf) (string, error) {
+	if c.PasswordPolicy == "" {
+		return util.GeneratePassword(c.Formatter, c.Length)
+	}
+	password, err := logical.GeneratePasswordFromPolicy(ctx, b.System(), c.PasswordPolicy)
+	if err != nil {
+		return "", errwrap.Wrapf("failed to generate password: {{err}}", err)
+	}
+	return password, nil
+}
@@ -0,0 +1,376 @@
+package ad
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const secretCheckOutType = "ad_library_check_out"
+
+func (b *backend) checkOutSecret() *framework.Secret {
+	return &framework.Secret{
+		Type: secretCheckOutType,
+		Fields: map[string]*framework.FieldSchema{
+			"service_account_name": {
+				Type:        framework.TypeString,
+				Description: "The username/logon name of the service account that was checked out.",
+			},
+			"password": {
+				Type:        framework.TypeString,
+				Description: "The password of the service account, until it's checked in.",
+			},
+		},
+		Renew:  b.checkOutRenew,
+		Revoke: b.checkOutRevoke,
+	}
+}
+
+func (b *backend) pathCheckOut() *framework.Path {
+	return &framework.Path{
+		Pattern: libraryPrefix + framework.GenericNameRegex("name") + "/check-out$",
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the set",
+			},
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "In seconds, the length of the check-out. Defaults to the ttl of the set.",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.checkOutOperation,
+		},
+		HelpSynopsis:    checkOutHelpSynopsis,
+		HelpDescription: checkOutHelpDescription,
+	}
+}
+
+func (b *backend) pathCheckIn() *framework.Path {
+	return &framework.Path{
+		Pattern: libraryPrefix + framework.GenericNameRegex("name") + "/check-in$",
+		Fields:  checkInFields(),
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.checkInOperation(false),
+		},
+		HelpSynopsis:    checkInHelpSynopsis,
+		HelpDescription: checkInHelpDescription,
+	}
+}
+
+func (b *backend) pathManageCheckIn() *framework.Path {
+	return &framework.Path{
+		Pattern: libraryPrefix + "manage/" + framework.GenericNameRegex("name") + "/check-in$",
+		Fields:  checkInFields(),
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.checkInOperation(true),
+		},
+		HelpSynopsis:    manageCheckInHelpSynopsis,
+		HelpDescription: manageCheckInHelpDescription,
+	}
+}
+
+func (b *backend) pathLibraryStatus() *framework.Path {
+	return &framework.Path{
+		Pattern: libraryPrefix + framework.GenericNameRegex("name") + "/status$",
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the set",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.libraryStatusOperation,
+		},
+		HelpSynopsis:    libraryStatusHelpSynopsis,
+		HelpDescription: libraryStatusHelpDescription,
+	}
+}
+
+func checkInFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeLowerCaseString,
+			Description: "Name of the set",
+		},
+		"service_account_names": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "The username/logon names of the service accounts to check in. Defaults to the accounts checked out by the caller.",
+		},
+	}
+}
+
+func (b *backend) checkOutOperation(ctx context.Context, req *logical.Request, fieldData *framework.FieldData) (*logical.Response, error) {
+	setName := fieldData.Get("name").(string)
+
+	b.checkOutLock.Lock()
+	defer b.checkOutLock.Unlock()
+
+	set, err := readLibrarySet(ctx, req.Storage, setName)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown set: %s", setName)), nil
+	}
+
+	ttl := set.TTL
+	if requested := time.Duration(fieldData.Get("ttl").(int)) * time.Second; requested > 0 && requested < ttl {
+		ttl = requested
+	}
+
+	for _, serviceAccountName := range set.ServiceAccountNames {
+		existing, err := readCheckOut(ctx, req.Storage, serviceAccountName)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			continue
+		}
+
+		// The account is available, the password is rotated so only the
+		// borrower knows it.
+		password, err := b.rotateServiceAccountPassword(ctx, req.Storage, serviceAccountName)
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		c := &checkOut{
+			ID:                  id,
+			LibrarySetName:      setName,
+			BorrowerEntityID:    req.EntityID,
+			BorrowerClientToken: req.ClientToken,
+			CheckedOutAt:        time.Now().UTC(),
+		}
+		if err := writeCheckOut(ctx, req.Storage, serviceAccountName, c); err != nil {
+			return nil, err
+		}
+
+		resp := b.checkOutSecret().Response(map[string]interface{}{
+			"service_account_name": serviceAccountName,
+			"password":             password,
+		}, map[string]interface{}{
+			"service_account_name": serviceAccountName,
+			"set_name":             setName,
+			"check_out_id":         id,
+		})
+		resp.Secret.TTL = ttl
+		resp.Secret.MaxTTL = set.MaxTTL
+		return resp, nil
+	}
+
+	return logical.ErrorResponse("no service accounts are available for check-out"), nil
+}
+
+func (b *backend) checkInOperation(overrideEnforcement bool) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, fieldData *framework.FieldData) (*logical.Response, error) {
+		setName := fieldData.Get("name").(string)
+
+		b.checkOutLock.Lock()
+		defer b.checkOutLock.Unlock()
+
+		set, err := readLibrarySet(ctx, req.Storage, setName)
+		if err != nil {
+			return nil, err
+		}
+		if set == nil {
+			return logical.ErrorResponse(fmt.Sprintf("unknown set: %s", setName)), nil
+		}
+		enforce := !overrideEnforcement && !set.DisableCheckInEnforcement
+
+		serviceAccountNames := fieldData.Get("service_account_names").([]string)
+		explicit := len(serviceAccountNames) > 0
+		if !explicit {
+			serviceAccountNames = set.ServiceAccountNames
+		}
+
+		checkIns := []string{}
+		for _, serviceAccountName := range serviceAccountNames {
+			if !set.has(serviceAccountName) {
+				return logical.ErrorResponse(fmt.Sprintf("%q is not in the %q set", serviceAccountName, setName)), nil
+			}
+			c, err := readCheckOut(ctx, req.Storage, serviceAccountName)
+			if err != nil {
+				return nil, err
+			}
+			if c == nil {
+				// Already available.
+				continue
+			}
+			if enforce && !c.borrowedBy(req) {
+				if explicit {
+					return logical.ErrorResponse(fmt.Sprintf("%q is checked out by someone else", serviceAccountName)), nil
+				}
+				continue
+			}
+			if err := b.checkIn(ctx, req.Storage, serviceAccountName); err != nil {
+				return nil, err
+			}
+			checkIns = append(checkIns, serviceAccountName)
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"check_ins": checkIns,
+			},
+		}, nil
+	}
+}
+
+func (b *backend) libraryStatusOperation(ctx context.Context, req *logical.Request, fieldData *framework.FieldData) (*logical.Response, error) {
+	setName := fieldData.Get("name").(string)
+
+	set, err := readLibrarySet(ctx, req.Storage, setName)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	status := make(map[string]interface{}, len(set.ServiceAccountNames))
+	for _, serviceAccountName := range set.ServiceAccountNames {
+		c, err := readCheckOut(ctx, req.Storage, serviceAccountName)
+		if err != nil {
+			return nil, err
+		}
+		accountStatus := map[string]interface{}{
+			"available": c == nil,
+		}
+		if c != nil {
+			accountStatus["checked_out_at"] = c.CheckedOutAt
+			if c.BorrowerEntityID != "" {
+				accountStatus["borrower_entity_id"] = c.BorrowerEntityID
+			}
+		}
+		status[serviceAccountName] = accountStatus
+	}
+
+	return &logical.Response{
+		Data: status,
+	}, nil
+}
+
+func (b *backend) checkOutRenew(ctx context.Context, req *logical.Request, fieldData *framework.FieldData) (*logical.Response, error) {
+	setName, _ := req.Secret.InternalData["set_name"].(string)
+	serviceAccountName, _ := req.Secret.InternalData["service_account_name"].(string)
+	id, _ := req.Secret.InternalData["check_out_id"].(string)
+
+	set, err := readLibrarySet(ctx, req.Storage, setName)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, fmt.Errorf("set %q no longer exists", setName)
+	}
+	c, err := readCheckOut(ctx, req.Storage, serviceAccountName)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.ID != id {
+		return nil, fmt.Errorf("%q has already been checked in", serviceAccountName)
+	}
+
+	return framework.LeaseExtend(set.TTL, set.MaxTTL, b.System())(ctx, req, fieldData)
+}
+
+func (b *backend) checkOutRevoke(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	serviceAccountName, _ := req.Secret.InternalData["service_account_name"].(string)
+	id, _ := req.Secret.InternalData["check_out_id"].(string)
+
+	b.checkOutLock.Lock()
+	defer b.checkOutLock.Unlock()
+
+	// The account may have been checked in already, and checked out again by
+	// someone else since.
+	c, err := readCheckOut(ctx, req.Storage, serviceAccountName)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.ID != id {
+		return nil, nil
+	}
+	if err := b.checkIn(ctx, req.Storage, serviceAccountName); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// checkIn rotates the password of the account, so the borrower can no longer
+// use it, and makes the account available again.
+func (b *backend) checkIn(ctx context.Context, storage logical.Storage, serviceAccountName string) error {
+	if _, err := b.rotateServiceAccountPassword(ctx, storage, serviceAccountName); err != nil {
+		return err
+	}
+	return storage.Delete(ctx, checkOutStorageKey+"/"+serviceAccountName)
+}
+
+// rotateServiceAccountPassword sets a new password on the account and returns
+// it.
+func (b *backend) rotateServiceAccountPassword(ctx context.Context, storage logical.Storage, serviceAccountName string) (string, error) {
+	engineConf, err := b.readConfig(ctx, storage)
+	if err != nil {
+		return "", err
+	}
+	if engineConf == nil {
+		return "", errors.New("the config is currently unset")
+	}
+
+	newPassword, err := b.generatePassword(ctx, engineConf.PasswordConf)
+	if err != nil {
+		return "", err
+	}
+	if err := b.client.UpdatePassword(engineConf.ADConf, serviceAccountName, newPassword); err != nil {
+		return "", err
+	}
+	return newPassword, nil
+}
+
+const (
+	checkOutHelpSynopsis = `
+Check a service account out of a set.
+`
+	checkOutHelpDescription = `
+Check out the first available service account of the set. Its password is
+rotated and returned, along with a lease. The account is checked in when the
+lease is revoked or expires, and the lease can be renewed up to the max ttl of
+the set.
+`
+
+	checkInHelpSynopsis = `
+Check service accounts back into a set.
+`
+	checkInHelpDescription = `
+Check in service accounts that were checked out of the set, rotating their
+passwords. By default, the accounts checked out by the caller are checked in;
+only the caller who checked an account out can check it in, unless the
+"disable_check_in_enforcement" setting of the set is enabled.
+`
+
+	manageCheckInHelpSynopsis = `
+Check service accounts back into a set, regardless of who checked them out.
+`
+	manageCheckInHelpDescription = `
+Check in service accounts that were checked out of the set, rotating their
+passwords, regardless of who checked them out. This is meant for operators,
+for instance to recover an account whose borrower is unavailable.
+`
+
+	libraryStatusHelpSynopsis = `
+Show which service accounts of a set are available.
+`
+	libraryStatusHelpDescription = `
+Read the availability of every service account of the set, along with when
+checked out accounts were checked out and the entity of their borrower.
+`
+)
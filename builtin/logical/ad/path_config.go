@@ -1,10 +1,11 @@
-package plugin
+package ad
 
 import (
 	"context"
 	"errors"
 	"time"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault-plugin-secrets-ad/plugin/client"
 	"github.com/hashicorp/vault-plugin-secrets-ad/plugin/util"
 	"github.com/hashicorp/vault/helper/ldaputil"
@@ -72,6 +73,10 @@ func (b *backend) configFields() map[string]*framework.FieldSchema {
 		Type:        framework.TypeString,
 		Description: `Text to insert the password into, ex. "customPrefix{{PASSWORD}}customSuffix".`,
 	}
+	fields["password_policy"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: "The name of the password policy to generate passwords with, instead of length and formatter.",
+	}
 	return fields
 }
 
@@ -90,6 +95,7 @@ func (b *backend) configUpdateOperation(ctx context.Context, req *logical.Reques
 	maxTTL := fieldData.Get("max_ttl").(int)
 	length := fieldData.Get("length").(int)
 	formatter := fieldData.Get("formatter").(string)
+	passwordPolicy := fieldData.Get("password_policy").(string)
 
 	if ttl == 0 {
 		ttl = int(b.System().DefaultLeaseTTL().Seconds())
@@ -106,15 +112,23 @@ func (b *backend) configUpdateOperation(ctx context.Context, req *logical.Reques
 	if maxTTL < 1 {
 		return nil, errors.New("max_ttl must be positive")
 	}
-	if err := util.ValidatePwdSettings(formatter, length); err != nil {
+	if passwordPolicy != "" {
+		if _, ok := fieldData.GetOk("length"); ok || formatter != "" {
+			return nil, errors.New("password_policy cannot be set with length or formatter")
+		}
+		if _, err := logical.GeneratePasswordFromPolicy(ctx, b.System(), passwordPolicy); err != nil {
+			return nil, errwrap.Wrapf("invalid password_policy: {{err}}", err)
+		}
+	} else if err := util.ValidatePwdSettings(formatter, length); err != nil {
 		return nil, err
 	}
 
 	passwordConf := &passwordConf{
-		TTL:       ttl,
-		MaxTTL:    maxTTL,
-		Length:    length,
-		Formatter: formatter,
+		TTL:            ttl,
+		MaxTTL:         maxTTL,
+		Length:         length,
+		Formatter:      formatter,
+		PasswordPolicy: passwordPolicy,
 	}
 
 	config := &configuration{passwordConf, &client.ADConf{ConfigEntry: activeDirectoryConf}}
@@ -0,0 +1,242 @@
+package ad
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func (b *backend) pathListLibrarySets() *framework.Path {
+	return &framework.Path{
+		Pattern: libraryPrefix + "?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.librarySetListOperation,
+		},
+
+		HelpSynopsis:    pathListLibrarySetsHelpSyn,
+		HelpDescription: pathListLibrarySetsHelpDesc,
+	}
+}
+
+func (b *backend) pathLibrarySets() *framework.Path {
+	return &framework.Path{
+		Pattern: libraryPrefix + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the set",
+			},
+			"service_account_names": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The username/logon names of the service accounts that can be checked out.",
+			},
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "In seconds, the default length of a check-out.",
+			},
+			"max_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "In seconds, the maximum length of a check-out, including renewals.",
+			},
+			"disable_check_in_enforcement": {
+				Type:        framework.TypeBool,
+				Description: "Allow any caller of the check-in endpoint to check in accounts checked out by someone else.",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.librarySetUpdateOperation,
+			logical.ReadOperation:   b.librarySetReadOperation,
+			logical.DeleteOperation: b.librarySetDeleteOperation,
+		},
+		HelpSynopsis:    librarySetHelpSynopsis,
+		HelpDescription: librarySetHelpDescription,
+	}
+}
+
+func (b *backend) librarySetUpdateOperation(ctx context.Context, req *logical.Request, fieldData *framework.FieldData) (*logical.Response, error) {
+	setName := fieldData.Get("name").(string)
+
+	engineConf, err := b.readConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if engineConf == nil {
+		return nil, errors.New("the config is currently unset")
+	}
+
+	serviceAccountNames := fieldData.Get("service_account_names").([]string)
+	if len(serviceAccountNames) == 0 {
+		return logical.ErrorResponse("\"service_account_names\" is required"), nil
+	}
+
+	ttl := time.Duration(fieldData.Get("ttl").(int)) * time.Second
+	if ttl == 0 {
+		ttl = time.Duration(engineConf.PasswordConf.TTL) * time.Second
+	}
+	maxTTL := time.Duration(fieldData.Get("max_ttl").(int)) * time.Second
+	if maxTTL == 0 {
+		maxTTL = time.Duration(engineConf.PasswordConf.MaxTTL) * time.Second
+	}
+	if ttl < 0 || maxTTL < 0 {
+		return logical.ErrorResponse("ttl and max_ttl can't be negative"), nil
+	}
+	if ttl > maxTTL {
+		return logical.ErrorResponse(fmt.Sprintf("ttl of %d seconds is over the max ttl of %d seconds", int64(ttl.Seconds()), int64(maxTTL.Seconds()))), nil
+	}
+
+	b.checkOutLock.Lock()
+	defer b.checkOutLock.Unlock()
+
+	oldSet, err := readLibrarySet(ctx, req.Storage, setName)
+	if err != nil {
+		return nil, err
+	}
+
+	// An account can only be in one set, since its check-out is tracked by
+	// name.
+	setNames, err := req.Storage.List(ctx, libraryStorageKey+"/")
+	if err != nil {
+		return nil, err
+	}
+	for _, otherName := range setNames {
+		if otherName == setName {
+			continue
+		}
+		otherSet, err := readLibrarySet(ctx, req.Storage, otherName)
+		if err != nil {
+			return nil, err
+		}
+		if otherSet == nil {
+			continue
+		}
+		for _, serviceAccountName := range serviceAccountNames {
+			if otherSet.has(serviceAccountName) {
+				return logical.ErrorResponse(fmt.Sprintf("%q is already in the %q set", serviceAccountName, otherName)), nil
+			}
+		}
+	}
+
+	// Accounts that are checked out can't be removed, they would never be
+	// checked in again.
+	set := &librarySet{
+		ServiceAccountNames:       serviceAccountNames,
+		TTL:                       ttl,
+		MaxTTL:                    maxTTL,
+		DisableCheckInEnforcement: fieldData.Get("disable_check_in_enforcement").(bool),
+	}
+	if oldSet != nil {
+		for _, serviceAccountName := range oldSet.ServiceAccountNames {
+			if set.has(serviceAccountName) {
+				continue
+			}
+			c, err := readCheckOut(ctx, req.Storage, serviceAccountName)
+			if err != nil {
+				return nil, err
+			}
+			if c != nil {
+				return logical.ErrorResponse(fmt.Sprintf("%q is checked out and can't be removed from the set until it's checked in", serviceAccountName)), nil
+			}
+		}
+	}
+
+	// verify service accounts exist
+	for _, serviceAccountName := range serviceAccountNames {
+		if _, err := b.client.Get(engineConf.ADConf, serviceAccountName); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeLibrarySet(ctx, req.Storage, setName, set); err != nil {
+		return nil, err
+	}
+
+	// Return a 204.
+	return nil, nil
+}
+
+func (b *backend) librarySetReadOperation(ctx context.Context, req *logical.Request, fieldData *framework.FieldData) (*logical.Response, error) {
+	setName := fieldData.Get("name").(string)
+
+	set, err := readLibrarySet(ctx, req.Storage, setName)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: set.Map(),
+	}, nil
+}
+
+func (b *backend) librarySetListOperation(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	keys, err := req.Storage.List(ctx, libraryStorageKey+"/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(keys), nil
+}
+
+func (b *backend) librarySetDeleteOperation(ctx context.Context, req *logical.Request, fieldData *framework.FieldData) (*logical.Response, error) {
+	setName := fieldData.Get("name").(string)
+
+	b.checkOutLock.Lock()
+	defer b.checkOutLock.Unlock()
+
+	set, err := readLibrarySet(ctx, req.Storage, setName)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	var checkedOut []string
+	for _, serviceAccountName := range set.ServiceAccountNames {
+		c, err := readCheckOut(ctx, req.Storage, serviceAccountName)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			checkedOut = append(checkedOut, serviceAccountName)
+		}
+	}
+	if len(checkedOut) > 0 {
+		return logical.ErrorResponse(fmt.Sprintf("the set can't be deleted while accounts are checked out: %s", strings.Join(checkedOut, ", "))), nil
+	}
+
+	if err := req.Storage.Delete(ctx, libraryStorageKey+"/"+setName); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+const (
+	librarySetHelpSynopsis = `
+Manage sets of service accounts that can be checked out.
+`
+	librarySetHelpDescription = `
+This endpoint allows you to read, write, and delete sets of service accounts
+that are checked out by one caller at a time, rather than shared.
+
+Each check-out rotates the password of the account, and so does each check-in,
+so a password stops working once the account is returned. Accounts are checked
+in when the lease of their check-out expires. An account can only be in one set.
+`
+
+	pathListLibrarySetsHelpSyn = `
+List the name of each set of service accounts currently stored.
+`
+	pathListLibrarySetsHelpDesc = `
+To learn which service accounts can be checked out, list the set names using
+this endpoint. Then read any individual set by name to learn more, or read its
+status to see which accounts are available.
+`
+)
@@ -0,0 +1,69 @@
+package ad
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func (b *backend) pathRotateRole() *framework.Path {
+	return &framework.Path{
+		Pattern: "rotate-role/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the role",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathRotateRoleUpdate,
+		},
+
+		HelpSynopsis:    pathRotateRoleHelpSyn,
+		HelpDescription: pathRotateRoleHelpDesc,
+	}
+}
+
+func (b *backend) pathRotateRoleUpdate(ctx context.Context, req *logical.Request, fieldData *framework.FieldData) (*logical.Response, error) {
+	roleName := fieldData.Get("name").(string)
+
+	b.credLock.Lock()
+	defer b.credLock.Unlock()
+
+	role, err := b.readRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("unknown role: " + roleName), nil
+	}
+
+	// Carry the current password forward as the last password.
+	cred := make(map[string]interface{})
+	entry, err := req.Storage.Get(ctx, storageKey+"/"+roleName)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(&cred); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := b.generateAndReturnCreds(ctx, req.Storage, roleName, role, cred); err != nil {
+		return nil, err
+	}
+	// Respond with a 204.
+	return nil, nil
+}
+
+const pathRotateRoleHelpSyn = `
+Request to rotate the password of a role's service account.
+`
+
+const pathRotateRoleHelpDesc = `
+This path rotates the password of the service account of a role right away,
+rather than waiting for its ttl to pass. The previous password is returned as
+the last password by the creds endpoint.
+`
@@ -0,0 +1,74 @@
+package apitoken
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				configPath,
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfig(&b),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathCreds(&b),
+		},
+
+		Secrets: []*framework.Secret{
+			secretAPIToken(&b),
+		},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+}
+
+// client returns a client of the configured API
+func (b *backend) client(ctx context.Context, s logical.Storage) (*apiClient, error) {
+	conf, err := b.config(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, errNotConfigured
+	}
+	transport, err := logical.OutboundTransport(b.System())
+	if err != nil {
+		return nil, err
+	}
+	return newAPIClient(conf, transport)
+}
+
+const backendHelp = `
+The apitoken backend creates short-lived tokens of HTTP APIs, such as those of
+SaaS products, without a backend dedicated to each API.
+
+Each role describes the request creating a token, where the token and its ID
+are found in the response, and the request revoking it. The requests are sent
+to the configured API with its credentials, and the tokens are revoked when
+their lease is.
+`
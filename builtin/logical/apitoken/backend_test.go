@@ -0,0 +1,500 @@
+package apitoken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	logicaltest "github.com/hashicorp/vault/logical/testing"
+)
+
+// fakeAPIServer is an API managing authentication tokens like Terraform
+// Cloud does
+type fakeAPIServer struct {
+	*httptest.Server
+
+	l      sync.Mutex
+	tokens map[string]map[string]interface{}
+
+	// omitToken makes the created tokens missing from the responses
+	omitToken bool
+}
+
+func newFakeAPIServer(t *testing.T) *fakeAPIServer {
+	s := &fakeAPIServer{
+		tokens: make(map[string]map[string]interface{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer admin" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"status":"401","title":"unauthorized"}]}`))
+			return
+		}
+
+		s.l.Lock()
+		defer s.l.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/users/user-1/authentication-tokens":
+			if r.Header.Get("Content-Type") != "application/vnd.api+json" {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			var in struct {
+				Data struct {
+					Attributes map[string]interface{} `json:"attributes"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			id := fmt.Sprintf("at-%d", len(s.tokens)+1)
+			s.tokens[id] = in.Data.Attributes
+			attributes := map[string]interface{}{
+				"description": in.Data.Attributes["description"],
+			}
+			if !s.omitToken {
+				attributes["token"] = "secret-" + id
+			}
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"id":         id,
+					"type":       "authentication-tokens",
+					"attributes": attributes,
+				},
+			})
+
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v2/authentication-tokens/"):
+			id := strings.TrimPrefix(r.URL.Path, "/api/v2/authentication-tokens/")
+			if _, ok := s.tokens[id]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errors":[{"status":"404","title":"not found"}]}`))
+				return
+			}
+			delete(s.tokens, id)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s
+}
+
+func (s *fakeAPIServer) token(id string) map[string]interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.tokens[id]
+}
+
+func (s *fakeAPIServer) tokenCount() int {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return len(s.tokens)
+}
+
+// testTokenRole creates Terraform Cloud user tokens
+var testTokenRole = map[string]interface{}{
+	"creation_path":   "/users/user-1/authentication-tokens",
+	"creation_body":   `{"data":{"type":"authentication-tokens","attributes":{"description":{{json .Name}},"expired-at":{{json .Expiration}}}}}`,
+	"content_type":    "application/vnd.api+json",
+	"token_field":     "data.attributes.token",
+	"id_field":        "data.id",
+	"revocation_path": "/authentication-tokens/{{pathescape .ID}}",
+	"ttl":             "30m",
+	"max_ttl":         "2h",
+}
+
+func getBackend(t *testing.T) (*backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func TestBackend_config(t *testing.T) {
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepConfig(t, map[string]interface{}{
+				"headers": "Authorization=Bearer admin",
+			}, true),
+			testAccStepConfig(t, map[string]interface{}{
+				"base_url": "app.terraform.io/api/v2",
+			}, true),
+			testAccStepConfig(t, map[string]interface{}{
+				"base_url": "https://app.terraform.io/api/v2",
+				"ca_cert":  "not a certificate",
+			}, true),
+			testAccStepConfig(t, map[string]interface{}{
+				"base_url": "https://app.terraform.io/api/v2",
+				"headers":  []string{"Authorization=Bearer admin", "X-Org=acme"},
+			}, false),
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "config",
+				Check: func(resp *logical.Response) error {
+					if resp == nil || resp.Data["base_url"] != "https://app.terraform.io/api/v2" {
+						return fmt.Errorf("bad: %#v", resp)
+					}
+					if !reflect.DeepEqual(resp.Data["headers"], []string{"Authorization", "X-Org"}) {
+						return fmt.Errorf("bad: %#v", resp.Data)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestBackend_roles(t *testing.T) {
+	steps := []logicaltest.TestStep{}
+	for _, data := range []map[string]interface{}{
+		// No creation path
+		{
+			"token_field": "token",
+		},
+		// No token field
+		{
+			"creation_path": "/tokens",
+		},
+		// Invalid method
+		{
+			"creation_path":   "/tokens",
+			"creation_method": "CONNECT",
+			"token_field":     "token",
+		},
+		// Invalid template
+		{
+			"creation_path": "/tokens",
+			"creation_body": `{"name":{{.Name}`,
+			"token_field":   "token",
+		},
+		// Unknown value
+		{
+			"creation_path": "/tokens",
+			"creation_body": `{"name":{{json .Username}}}`,
+			"token_field":   "token",
+		},
+		// Relative path
+		{
+			"creation_path": "tokens",
+			"token_field":   "token",
+		},
+		// Absolute revocation URL
+		{
+			"creation_path":   "/tokens",
+			"token_field":     "token",
+			"revocation_path": "https://example.com/tokens/{{.ID}}",
+		},
+		// Revocation body without path
+		{
+			"creation_path":   "/tokens",
+			"token_field":     "token",
+			"revocation_body": `{"id":{{json .ID}}}`,
+		},
+		// ttl over max_ttl
+		{
+			"creation_path": "/tokens",
+			"token_field":   "token",
+			"ttl":           "2h",
+			"max_ttl":       "1h",
+		},
+	} {
+		steps = append(steps, testAccStepWriteRole(t, "bad", data, true))
+	}
+
+	steps = append(steps,
+		// Roles whose tokens can't be revoked are allowed, with a warning
+		logicaltest.TestStep{
+			Operation: logical.UpdateOperation,
+			Path:      "roles/irrevocable",
+			Data: map[string]interface{}{
+				"creation_path": "/tokens",
+				"token_field":   "token",
+			},
+			Check: func(resp *logical.Response) error {
+				if resp == nil || len(resp.Warnings) != 1 {
+					return fmt.Errorf("expected a warning, got: %#v", resp)
+				}
+				return nil
+			},
+		},
+		testAccStepWriteRole(t, "tfc", testTokenRole, false),
+		logicaltest.TestStep{
+			Operation: logical.ReadOperation,
+			Path:      "roles/tfc",
+			Check: func(resp *logical.Response) error {
+				if resp == nil || resp.Data["creation_method"] != "POST" || resp.Data["revocation_method"] != "DELETE" || resp.Data["ttl"] != int64(1800) {
+					return fmt.Errorf("bad: %#v", resp)
+				}
+				return nil
+			},
+		},
+		logicaltest.TestStep{
+			Operation: logical.ListOperation,
+			Path:      "roles/",
+			Check: func(resp *logical.Response) error {
+				if resp == nil || !reflect.DeepEqual(resp.Data["keys"], []string{"irrevocable", "tfc"}) {
+					return fmt.Errorf("bad: %#v", resp)
+				}
+				return nil
+			},
+		},
+		logicaltest.TestStep{
+			Operation: logical.DeleteOperation,
+			Path:      "roles/tfc",
+		},
+		logicaltest.TestStep{
+			Operation: logical.ReadOperation,
+			Path:      "roles/tfc",
+			Check: func(resp *logical.Response) error {
+				if resp != nil {
+					return fmt.Errorf("bad: %#v", resp)
+				}
+				return nil
+			},
+		},
+	)
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps:   steps,
+	})
+}
+
+func TestBackend_creds(t *testing.T) {
+	server := newFakeAPIServer(t)
+	defer server.Close()
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepWriteRole(t, "tfc", testTokenRole, false),
+			testAccStepReadCreds(t, "tfc", true, nil),
+
+			testAccStepConfig(t, map[string]interface{}{
+				"base_url": server.URL + "/api/v2/",
+				"headers":  "Authorization=Bearer admin",
+			}, false),
+			testAccStepReadCreds(t, "missing", true, nil),
+			testAccStepReadCreds(t, "tfc", false, func(resp *logical.Response) error {
+				if resp.Data["token"] != "secret-at-1" || resp.Data["id"] != "at-1" {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				if resp.Secret.TTL != 30*time.Minute || resp.Secret.MaxTTL != 2*time.Hour {
+					return fmt.Errorf("bad: %#v", resp.Secret)
+				}
+				token := server.token("at-1")
+				if !strings.HasPrefix(token["description"].(string), "vault-tfc-root-") || token["description"] != resp.Data["name"] {
+					return fmt.Errorf("bad: %#v", token)
+				}
+				expiration, err := time.Parse(time.RFC3339, token["expired-at"].(string))
+				if err != nil || expiration.Sub(time.Now()) > 2*time.Hour || expiration.Sub(time.Now()) < time.Hour {
+					return fmt.Errorf("bad expiration: %v err: %v", token["expired-at"], err)
+				}
+				return nil
+			}),
+
+			// A token missing from the response is revoked
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "creds/tfc",
+				PreFlight: func(req *logical.Request) error {
+					server.l.Lock()
+					defer server.l.Unlock()
+					server.omitToken = true
+					return nil
+				},
+				ErrorOk: true,
+				Check: func(resp *logical.Response) error {
+					server.l.Lock()
+					server.omitToken = false
+					server.l.Unlock()
+					if resp != nil && resp.Secret != nil {
+						return fmt.Errorf("expected an error, got %#v", resp)
+					}
+					if n := server.tokenCount(); n != 1 {
+						return fmt.Errorf("the token of the failed lease was not revoked: %d tokens", n)
+					}
+					return nil
+				},
+			},
+
+			// Tokens are revoked with the request rendered when they were
+			// created
+			testAccStepWriteRole(t, "tfc", map[string]interface{}{
+				"revocation_path": "/tokens/{{.ID}}",
+			}, false),
+		},
+	})
+
+	// The lease was revoked at the end of the test case
+	if n := server.tokenCount(); n != 0 {
+		t.Fatalf("the token of the lease was not revoked: %d tokens", n)
+	}
+}
+
+func TestBackend_renewRevoke(t *testing.T) {
+	server := newFakeAPIServer(t)
+	defer server.Close()
+
+	b, storage := getBackend(t)
+
+	configReq := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"base_url": server.URL + "/api/v2/",
+			"headers":  "Authorization=Bearer admin",
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), configReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/tfc",
+		Storage:   storage,
+		Data:      testTokenRole,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	credsReq := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/tfc",
+		Storage:   storage,
+	}
+	resp, err = b.HandleRequest(context.Background(), credsReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	secret := resp.Secret
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RenewOperation,
+		Storage:   storage,
+		Secret:    secret,
+	})
+	if err != nil || resp.Secret.TTL != 30*time.Minute || resp.Secret.MaxTTL != 2*time.Hour {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	revokeReq := &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    secret,
+	}
+	if _, err := b.HandleRequest(context.Background(), revokeReq); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.tokenCount(); n != 0 {
+		t.Fatalf("the token of the lease was not revoked: %d tokens", n)
+	}
+
+	// A token revoked out of band doesn't fail the revocation
+	if _, err := b.HandleRequest(context.Background(), revokeReq); err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests are rejected with the wrong headers
+	configReq.Data = map[string]interface{}{
+		"headers": "Authorization=Bearer wrong",
+	}
+	resp, err = b.HandleRequest(context.Background(), configReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	if _, err := b.HandleRequest(context.Background(), credsReq); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected an authentication error, got: %v", err)
+	}
+}
+
+func testAccStepConfig(t *testing.T, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepWriteRole(t *testing.T, name string, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/" + name,
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepReadCreds(t *testing.T, role string, expectFail bool, check logicaltest.TestCheckFunc) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "creds/" + role,
+		ErrorOk:   expectFail,
+		Check:     check,
+	}
+	if expectFail {
+		// Errors of the backend itself don't come with a response
+		step.Check = func(resp *logical.Response) error {
+			if resp != nil && !resp.IsError() {
+				return fmt.Errorf("expected an error, got %#v", resp)
+			}
+			return nil
+		}
+	}
+	return step
+}
+
+func TestExtractField(t *testing.T) {
+	doc := []byte(`{"data":{"id":42,"keys":[{"secret":"s1"},{"secret":""}],"token":"t"}}`)
+	for path, expected := range map[string]string{
+		"data.token":          "t",
+		"data.id":             "42",
+		"data.keys.0.secret":  "s1",
+		"data.keys.1.secret":  "",
+		"data.keys.2.secret":  "",
+		"data.keys.x":         "",
+		"data.token.value":    "",
+		"data.missing.secret": "",
+	} {
+		value, err := extractField(doc, path)
+		if expected == "" {
+			if err == nil {
+				t.Fatalf("expected an error for %s, got %q", path, value)
+			}
+			continue
+		}
+		if err != nil || value != expected {
+			t.Fatalf("bad %s: %q err: %v", path, value, err)
+		}
+	}
+}
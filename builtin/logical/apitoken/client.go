@@ -0,0 +1,118 @@
+package apitoken
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxResponseSize is the largest response body read from the API
+const maxResponseSize = 1 << 20
+
+// apiRequest is a rendered request of a role
+type apiRequest struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// apiError is a response of the API with an unexpected status
+type apiError struct {
+	Code int
+	Body string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("API returned %d: %s", e.Code, e.Body)
+}
+
+// isNotFound returns whether err is the API reporting that an object does
+// not exist
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.Code == http.StatusNotFound
+}
+
+// apiClient sends requests to the configured API with its headers
+type apiClient struct {
+	baseURL string
+	headers map[string]string
+	client  *http.Client
+}
+
+// newAPIClient returns a client of the API over the transport, which trusts
+// the ca_cert of the configuration in place of its CA certificates if set
+func newAPIClient(conf *apiConfig, transport *http.Transport) (*apiClient, error) {
+	u, err := url.Parse(conf.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("base_url must be an http or https URL")
+	}
+
+	if conf.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(conf.CACert)) {
+			return nil, errors.New("could not parse ca_cert")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs: pool,
+		}
+	}
+
+	return &apiClient{
+		baseURL: strings.TrimSuffix(conf.BaseURL, "/"),
+		headers: conf.Headers,
+		client: &http.Client{
+			Transport: transport,
+		},
+	}, nil
+}
+
+// do sends a request and returns the body of its response. Paths are
+// relative to the base URL, so requests can't be sent, with the headers of
+// the API, anywhere else.
+func (c *apiClient) do(ctx context.Context, r *apiRequest, contentType string) ([]byte, error) {
+	if !strings.HasPrefix(r.Path, "/") {
+		return nil, fmt.Errorf("path %q must start with /", r.Path)
+	}
+
+	var body io.Reader
+	if r.Body != "" {
+		body = strings.NewReader(r.Body)
+	}
+	req, err := http.NewRequest(r.Method, c.baseURL+r.Path, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	if r.Body != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &apiError{
+			Code: resp.StatusCode,
+			Body: strings.TrimSpace(string(respBody)),
+		}
+	}
+	return respBody, nil
+}
@@ -0,0 +1,162 @@
+package apitoken
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const configPath = "config"
+
+var errNotConfigured = errors.New("the apitoken backend is not configured")
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: configPath,
+		Fields: map[string]*framework.FieldSchema{
+			"base_url": {
+				Type:        framework.TypeString,
+				Description: "The base URL of the API, such as https://app.terraform.io/api/v2. The paths of the roles are relative to it.",
+			},
+
+			"headers": {
+				Type:        framework.TypeKVPairs,
+				Description: `The headers sent with every request, such as "Authorization=Bearer ...". Their values are never returned.`,
+			},
+
+			"ca_cert": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded CA certificate the TLS certificate of the API is verified with. The system roots are used if unset.",
+			},
+		},
+
+		ExistenceCheck: b.pathConfigExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.CreateOperation: b.pathConfigWrite,
+			logical.UpdateOperation: b.pathConfigWrite,
+			logical.DeleteOperation: b.pathConfigDelete,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+// config returns the configuration, or nil if the backend is not configured
+func (b *backend) config(ctx context.Context, s logical.Storage) (*apiConfig, error) {
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	conf := &apiConfig{}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, errwrap.Wrapf("error reading apitoken configuration: {{err}}", err)
+	}
+	return conf, nil
+}
+
+func (b *backend) pathConfigExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return false, err
+	}
+	return conf != nil, nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, nil
+	}
+
+	// Headers hold the credentials of the API, so only their names are
+	// returned
+	headers := make([]string, 0, len(conf.Headers))
+	for name := range conf.Headers {
+		headers = append(headers, name)
+	}
+	sort.Strings(headers)
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"base_url": conf.BaseURL,
+			"headers":  headers,
+			"ca_cert":  conf.CACert,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &apiConfig{}
+	}
+
+	if v, ok := data.GetOk("base_url"); ok {
+		conf.BaseURL = v.(string)
+	}
+	if v, ok := data.GetOk("headers"); ok {
+		conf.Headers = v.(map[string]string)
+	}
+	if v, ok := data.GetOk("ca_cert"); ok {
+		conf.CACert = v.(string)
+	}
+
+	if conf.BaseURL == "" {
+		return logical.ErrorResponse("base_url is required"), nil
+	}
+	transport, err := logical.OutboundTransport(b.System())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := newAPIClient(conf, transport); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type apiConfig struct {
+	BaseURL string            `json:"base_url"`
+	Headers map[string]string `json:"headers"`
+	CACert  string            `json:"ca_cert"`
+}
+
+const pathConfigHelpSyn = `
+Configure the API tokens are created with.
+`
+
+const pathConfigHelpDesc = `
+This path configures the base URL of the API and the headers sent with every
+request, which usually hold the credentials Vault creates and revokes tokens
+with. Writing headers replaces all of them.
+`
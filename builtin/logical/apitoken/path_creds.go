@@ -0,0 +1,123 @@
+package apitoken
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCredsRead,
+		},
+
+		HelpSynopsis:    pathCredsHelpSyn,
+		HelpDescription: pathCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", roleName)), nil
+	}
+
+	ttl, maxTTL, warnings := framework.LeaseTTLs(b.System(), role.TTL, role.MaxTTL)
+
+	client, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tmplData := &templateData{
+		Name:        fmt.Sprintf("vault-%s-%s-%d", roleName, req.DisplayName, now.Unix()),
+		RoleName:    roleName,
+		DisplayName: req.DisplayName,
+		Expiration:  now.Add(maxTTL).UTC().Format(time.RFC3339),
+	}
+	creation, err := renderRequest("creation", role.CreationMethod, role.CreationPath, role.CreationBody, tmplData)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := client.do(ctx, creation, role.ContentType)
+	if err != nil {
+		return nil, errwrap.Wrapf("error creating the token: {{err}}", err)
+	}
+
+	// The ID is read first, so that a token created without the expected
+	// fields can still be revoked
+	if role.IDField != "" {
+		if tmplData.ID, err = extractField(respBody, role.IDField); err != nil {
+			return nil, err
+		}
+	}
+	var revocation *apiRequest
+	if role.RevocationPath != "" {
+		if revocation, err = renderRequest("revocation", role.RevocationMethod, role.RevocationPath, role.RevocationBody, tmplData); err != nil {
+			return nil, err
+		}
+	}
+	token, err := extractField(respBody, role.TokenField)
+	if err != nil {
+		if revocation != nil {
+			if revokeErr := b.revoke(ctx, client, revocation, role.ContentType); revokeErr != nil {
+				b.Logger().Error("error revoking the token of a failed lease", "name", tmplData.Name, "error", revokeErr)
+			}
+		}
+		return nil, err
+	}
+
+	respData := map[string]interface{}{
+		"token": token,
+		"name":  tmplData.Name,
+	}
+	if tmplData.ID != "" {
+		respData["id"] = tmplData.ID
+	}
+	internalData := map[string]interface{}{
+		"role":         roleName,
+		"content_type": role.ContentType,
+	}
+	if revocation != nil {
+		internalData["revocation_method"] = revocation.Method
+		internalData["revocation_path"] = revocation.Path
+		internalData["revocation_body"] = revocation.Body
+	}
+
+	resp := b.Secret(SecretAPITokenType).Response(respData, internalData)
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = maxTTL
+	resp.Warnings = warnings
+
+	return resp, nil
+}
+
+const pathCredsHelpSyn = `
+Create a token for a role.
+`
+
+const pathCredsHelpDesc = `
+This path sends the creation request of the role to the API and returns the
+token of the response. The revocation request is rendered along with it and
+sent when the lease is revoked, so changing the role doesn't change how the
+tokens already created are revoked.
+`
@@ -0,0 +1,330 @@
+package apitoken
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	defaultCreationMethod   = "POST"
+	defaultRevocationMethod = "DELETE"
+	defaultContentType      = "application/json"
+)
+
+// methods are the HTTP methods of the requests of roles
+var methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"creation_method": {
+				Type:        framework.TypeString,
+				Description: "The HTTP method of the request creating a token. Defaults to POST.",
+			},
+
+			"creation_path": {
+				Type:        framework.TypeString,
+				Description: "The template of the path of the request creating a token, relative to the base URL of the API.",
+			},
+
+			"creation_body": {
+				Type:        framework.TypeString,
+				Description: "The template of the body of the request creating a token.",
+			},
+
+			"content_type": {
+				Type:        framework.TypeString,
+				Description: "The content type of the bodies of the requests. Defaults to application/json.",
+			},
+
+			"token_field": {
+				Type:        framework.TypeString,
+				Description: "The dot separated path of the token in the JSON response of the creation request, such as data.attributes.token.",
+			},
+
+			"id_field": {
+				Type:        framework.TypeString,
+				Description: "The dot separated path of the ID of the token in the JSON response of the creation request, such as data.id. The ID is available to the revocation templates.",
+			},
+
+			"revocation_method": {
+				Type:        framework.TypeString,
+				Description: "The HTTP method of the request revoking a token. Defaults to DELETE.",
+			},
+
+			"revocation_path": {
+				Type:        framework.TypeString,
+				Description: "The template of the path of the request revoking a token, relative to the base URL of the API. Tokens are not revoked if unset.",
+			},
+
+			"revocation_body": {
+				Type:        framework.TypeString,
+				Description: "The template of the body of the request revoking a token.",
+			},
+
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The default TTL of the tokens. Defaults to the default lease TTL of the mount.",
+			},
+
+			"max_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The maximum TTL of the tokens. Defaults to the maximum lease TTL of the mount.",
+			},
+		},
+
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.CreateOperation: b.pathRoleWrite,
+			logical.UpdateOperation: b.pathRoleWrite,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+// role returns a role, or nil if it doesn't exist
+func (b *backend) role(ctx context.Context, s logical.Storage, name string) (*roleEntry, error) {
+	entry, err := s.Get(ctx, "role/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role roleEntry
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, errwrap.Wrapf("error reading role: {{err}}", err)
+	}
+	return &role, nil
+}
+
+func (b *backend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"creation_method":   role.CreationMethod,
+			"creation_path":     role.CreationPath,
+			"creation_body":     role.CreationBody,
+			"content_type":      role.ContentType,
+			"token_field":       role.TokenField,
+			"id_field":          role.IDField,
+			"revocation_method": role.RevocationMethod,
+			"revocation_path":   role.RevocationPath,
+			"revocation_body":   role.RevocationBody,
+			"ttl":               int64(role.TTL.Seconds()),
+			"max_ttl":           int64(role.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleEntry{
+			CreationMethod:   defaultCreationMethod,
+			RevocationMethod: defaultRevocationMethod,
+			ContentType:      defaultContentType,
+		}
+	}
+
+	if v, ok := data.GetOk("creation_method"); ok {
+		role.CreationMethod = strings.ToUpper(v.(string))
+	}
+	if v, ok := data.GetOk("creation_path"); ok {
+		role.CreationPath = v.(string)
+	}
+	if v, ok := data.GetOk("creation_body"); ok {
+		role.CreationBody = v.(string)
+	}
+	if v, ok := data.GetOk("content_type"); ok {
+		role.ContentType = v.(string)
+	}
+	if v, ok := data.GetOk("token_field"); ok {
+		role.TokenField = v.(string)
+	}
+	if v, ok := data.GetOk("id_field"); ok {
+		role.IDField = v.(string)
+	}
+	if v, ok := data.GetOk("revocation_method"); ok {
+		role.RevocationMethod = strings.ToUpper(v.(string))
+	}
+	if v, ok := data.GetOk("revocation_path"); ok {
+		role.RevocationPath = v.(string)
+	}
+	if v, ok := data.GetOk("revocation_body"); ok {
+		role.RevocationBody = v.(string)
+	}
+	if v, ok := data.GetOk("ttl"); ok {
+		role.TTL = time.Duration(v.(int)) * time.Second
+	}
+	if v, ok := data.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Duration(v.(int)) * time.Second
+	}
+
+	switch {
+	case role.CreationPath == "":
+		return logical.ErrorResponse("creation_path is required"), nil
+	case role.TokenField == "":
+		return logical.ErrorResponse("token_field is required"), nil
+	case role.RevocationPath == "" && role.RevocationBody != "":
+		return logical.ErrorResponse("revocation_body requires revocation_path"), nil
+	case role.ContentType == "":
+		role.ContentType = defaultContentType
+	}
+	for _, method := range []string{role.CreationMethod, role.RevocationMethod} {
+		if !strutil.StrListContains(methods, method) {
+			return logical.ErrorResponse(fmt.Sprintf("invalid method %q", method)), nil
+		}
+	}
+
+	// The templates are rendered with sample values, so that errors show
+	// when the role is written rather than when a token is requested
+	sample := &templateData{
+		Name:        "vault-role-token-1500000000",
+		RoleName:    name,
+		DisplayName: "token",
+		Expiration:  time.Now().UTC().Format(time.RFC3339),
+		ID:          "id",
+	}
+	creation, err := renderRequest("creation", role.CreationMethod, role.CreationPath, role.CreationBody, sample)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if !strings.HasPrefix(creation.Path, "/") {
+		return logical.ErrorResponse("creation_path must start with /"), nil
+	}
+	if role.RevocationPath != "" {
+		revocation, err := renderRequest("revocation", role.RevocationMethod, role.RevocationPath, role.RevocationBody, sample)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if !strings.HasPrefix(revocation.Path, "/") {
+			return logical.ErrorResponse("revocation_path must start with /"), nil
+		}
+	}
+
+	if role.TTL < 0 || role.MaxTTL < 0 {
+		return logical.ErrorResponse("ttl and max_ttl can't be negative"), nil
+	}
+	if role.MaxTTL > 0 && role.TTL > role.MaxTTL {
+		return logical.ErrorResponse("ttl can't be greater than max_ttl"), nil
+	}
+
+	var resp *logical.Response
+	if role.RevocationPath == "" {
+		resp = &logical.Response{}
+		resp.AddWarning("revocation_path is not set, so tokens will not be revoked when their lease is")
+	}
+
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "role/"+data.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type roleEntry struct {
+	CreationMethod   string        `json:"creation_method"`
+	CreationPath     string        `json:"creation_path"`
+	CreationBody     string        `json:"creation_body"`
+	ContentType      string        `json:"content_type"`
+	TokenField       string        `json:"token_field"`
+	IDField          string        `json:"id_field"`
+	RevocationMethod string        `json:"revocation_method"`
+	RevocationPath   string        `json:"revocation_path"`
+	RevocationBody   string        `json:"revocation_body"`
+	TTL              time.Duration `json:"ttl"`
+	MaxTTL           time.Duration `json:"max_ttl"`
+}
+
+const pathRoleHelpSyn = `
+Manage the roles tokens are created for.
+`
+
+const pathRoleHelpDesc = `
+This path manages the roles of the backend. A role describes the request
+creating a token and the request revoking it, with templates of their paths
+and bodies, and where the token and its ID are in the JSON response of the
+creation request.
+
+The templates use the Go template syntax, with these values:
+
+  {{.Name}}         A unique name for the token, such as a description
+  {{.RoleName}}     The name of the role
+  {{.DisplayName}}  The display name of the Vault token requesting the token
+  {{.Expiration}}   The time the lease expires at the latest, in RFC 3339
+  {{.ID}}           The ID of the token, in the revocation templates only
+
+The "json" function quotes a value for a JSON body, and "pathescape" escapes
+it for a path.
+`
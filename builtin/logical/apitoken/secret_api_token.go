@@ -0,0 +1,92 @@
+package apitoken
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const SecretAPITokenType = "api_token"
+
+func secretAPIToken(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretAPITokenType,
+		Fields: map[string]*framework.FieldSchema{
+			"token": {
+				Type:        framework.TypeString,
+				Description: "API token",
+			},
+			"id": {
+				Type:        framework.TypeString,
+				Description: "ID of the API token",
+			},
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name the API token was created with",
+			},
+		},
+
+		Renew:  b.secretAPITokenRenew,
+		Revoke: b.secretAPITokenRevoke,
+	}
+}
+
+// secretAPITokenRenew extends the lease with the TTLs of its role. Nothing
+// changes on the API, so tokens created with an expiration still expire then.
+func (b *backend) secretAPITokenRenew(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName, _ := req.Secret.InternalData["role"].(string)
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, fmt.Errorf("role %q of the lease not found", roleName)
+	}
+
+	ttl, maxTTL, warnings := framework.LeaseTTLs(b.System(), role.TTL, role.MaxTTL)
+	resp := &logical.Response{Secret: req.Secret}
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = maxTTL
+	resp.Warnings = warnings
+	return resp, nil
+}
+
+// secretAPITokenRevoke sends the revocation request rendered when the token
+// was created. Leases of roles without a revocation request are only
+// forgotten.
+func (b *backend) secretAPITokenRevoke(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path, _ := req.Secret.InternalData["revocation_path"].(string)
+	if path == "" {
+		return nil, nil
+	}
+	method, _ := req.Secret.InternalData["revocation_method"].(string)
+	body, _ := req.Secret.InternalData["revocation_body"].(string)
+	contentType, _ := req.Secret.InternalData["content_type"].(string)
+
+	client, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	revocation := &apiRequest{
+		Method: method,
+		Path:   path,
+		Body:   body,
+	}
+	if err := b.revoke(ctx, client, revocation, contentType); err != nil {
+		return nil, errwrap.Wrapf("could not revoke token: {{err}}", err)
+	}
+	return nil, nil
+}
+
+// revoke sends a revocation request; a token that doesn't exist anymore is
+// not an error
+func (b *backend) revoke(ctx context.Context, client *apiClient, revocation *apiRequest, contentType string) error {
+	_, err := client.do(ctx, revocation, contentType)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package apitoken
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// templateData is what the request templates of roles are rendered with. ID
+// is only set for the revocation request.
+type templateData struct {
+	Name        string
+	RoleName    string
+	DisplayName string
+	Expiration  string
+	ID          string
+}
+
+// templateFuncs are the functions available to templates, to build JSON
+// bodies and paths from the values
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		buf, err := json.Marshal(v)
+		return string(buf), err
+	},
+	"pathescape": url.PathEscape,
+}
+
+// renderTemplate renders a request template
+func renderTemplate(name, tmpl string, data *templateData) (string, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return "", errwrap.Wrapf(fmt.Sprintf("invalid %s template: {{err}}", name), err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", errwrap.Wrapf(fmt.Sprintf("error rendering %s template: {{err}}", name), err)
+	}
+	return buf.String(), nil
+}
+
+// renderRequest renders the path and body templates of a request
+func renderRequest(name, method, pathTmpl, bodyTmpl string, data *templateData) (*apiRequest, error) {
+	path, err := renderTemplate(name+"_path", pathTmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := renderTemplate(name+"_body", bodyTmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	return &apiRequest{
+		Method: method,
+		Path:   path,
+		Body:   body,
+	}, nil
+}
+
+// extractField returns the value at a dot separated path of a JSON document,
+// such as data.attributes.token. Numeric components index arrays.
+func extractField(doc []byte, path string) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return "", errwrap.Wrapf("error decoding the API response: {{err}}", err)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", fmt.Errorf("no %s in the API response", path)
+			}
+			v = node[i]
+		default:
+			return "", fmt.Errorf("no %s in the API response", path)
+		}
+	}
+
+	switch value := v.(type) {
+	case string:
+		if value != "" {
+			return value, nil
+		}
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("no %s in the API response", path)
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/hashicorp/vault/logical"
 )
 
-func getRootConfig(ctx context.Context, s logical.Storage, clientType string) (*aws.Config, error) {
+func getRootConfig(ctx context.Context, s logical.Storage, clientType string, sys logical.SystemView) (*aws.Config, error) {
 	credsConfig := &awsutil.CredentialsConfig{}
 	var endpoint string
 	var maxRetries int = aws.UseServiceDefaultRetries
@@ -52,6 +52,8 @@ func getRootConfig(ctx context.Context, s logical.Storage, clientType string) (*
 		}
 	}
 
+	// The credential providers reach the instance metadata, the egress
+	// configuration only applies to the AWS APIs
 	credsConfig.HTTPClient = cleanhttp.DefaultClient()
 
 	creds, err := credsConfig.GenerateCredentialChain()
@@ -59,17 +61,22 @@ func getRootConfig(ctx context.Context, s logical.Storage, clientType string) (*
 		return nil, err
 	}
 
+	httpClient, err := logical.OutboundHTTPClient(sys)
+	if err != nil {
+		return nil, errwrap.Wrapf("error building the HTTP client: {{err}}", err)
+	}
+
 	return &aws.Config{
 		Credentials: creds,
 		Region:      aws.String(credsConfig.Region),
 		Endpoint:    &endpoint,
-		HTTPClient:  cleanhttp.DefaultClient(),
+		HTTPClient:  httpClient,
 		MaxRetries:  aws.Int(maxRetries),
 	}, nil
 }
 
-func clientIAM(ctx context.Context, s logical.Storage) (*iam.IAM, error) {
-	awsConfig, err := getRootConfig(ctx, s, "iam")
+func (b *backend) clientIAM(ctx context.Context, s logical.Storage) (*iam.IAM, error) {
+	awsConfig, err := getRootConfig(ctx, s, "iam", b.System())
 	if err != nil {
 		return nil, err
 	}
@@ -82,8 +89,8 @@ func clientIAM(ctx context.Context, s logical.Storage) (*iam.IAM, error) {
 	return client, nil
 }
 
-func clientSTS(ctx context.Context, s logical.Storage) (*sts.STS, error) {
-	awsConfig, err := getRootConfig(ctx, s, "sts")
+func (b *backend) clientSTS(ctx context.Context, s logical.Storage) (*sts.STS, error) {
+	awsConfig, err := getRootConfig(ctx, s, "sts", b.System())
 	if err != nil {
 		return nil, err
 	}
@@ -314,6 +314,59 @@ func TestBackend_role_lease(t *testing.T) {
 	})
 }
 
+func TestBackend_role_ttl(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(data map[string]interface{}) *logical.Response {
+		t.Helper()
+		data["policy"] = base64.StdEncoding.EncodeToString([]byte(testPolicy))
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: logical.UpdateOperation,
+			Path:      "roles/test",
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	read := func() map[string]interface{} {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: logical.ReadOperation,
+			Path:      "roles/test",
+		})
+		if err != nil || resp == nil {
+			t.Fatalf("resp: %#v, err: %v", resp, err)
+		}
+		return resp.Data
+	}
+
+	write(map[string]interface{}{"ttl": "1h", "max_ttl": "6h"})
+	data := read()
+	if data["ttl"] != int64(3600) || data["max_ttl"] != int64(21600) || data["lease"] != int64(3600) {
+		t.Fatalf("bad: %#v", data)
+	}
+
+	// The deprecated lease parameter still sets the TTL
+	write(map[string]interface{}{"lease": "2h"})
+	data = read()
+	if data["ttl"] != int64(7200) || data["max_ttl"] != int64(0) {
+		t.Fatalf("bad: %#v", data)
+	}
+
+	if resp := write(map[string]interface{}{"ttl": "6h", "max_ttl": "1h"}); resp == nil || !resp.IsError() {
+		t.Fatalf("expected ttl greater than max_ttl to be rejected: %#v", resp)
+	}
+}
+
 func testAccStepConfig(
 	t *testing.T, config map[string]interface{}) logicaltest.TestStep {
 	return logicaltest.TestStep{
@@ -44,9 +44,19 @@ the "policy" parameter is not required.
 Defaults to 'client'.`,
 			},
 
+			"ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "TTL of tokens issued for the role. Defaults to the mount's default lease TTL.",
+			},
+
+			"max_ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Maximum TTL tokens issued for the role may be renewed to. Defaults to the mount's max lease TTL.",
+			},
+
 			"lease": &framework.FieldSchema{
 				Type:        framework.TypeDurationSecond,
-				Description: "Lease time of the role.",
+				Description: "Deprecated, use \"ttl\" instead.",
 			},
 		},
 
@@ -90,7 +100,9 @@ func pathRolesRead(ctx context.Context, req *logical.Request, d *framework.Field
 	// Generate the response
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"lease":      int64(result.Lease.Seconds()),
+			"ttl":        int64(result.TTL.Seconds()),
+			"max_ttl":    int64(result.MaxTTL.Seconds()),
+			"lease":      int64(result.TTL.Seconds()),
 			"token_type": result.TokenType,
 		},
 	}
@@ -127,15 +139,23 @@ func pathRolesWrite(ctx context.Context, req *logical.Request, d *framework.Fiel
 		}
 	}
 
-	var lease time.Duration
-	leaseParamRaw, ok := d.GetOk("lease")
+	var ttl time.Duration
+	ttlRaw, ok := d.GetOk("ttl")
+	if !ok {
+		ttlRaw, ok = d.GetOk("lease")
+	}
 	if ok {
-		lease = time.Second * time.Duration(leaseParamRaw.(int))
+		ttl = time.Second * time.Duration(ttlRaw.(int))
+	}
+	maxTTL := time.Second * time.Duration(d.Get("max_ttl").(int))
+	if maxTTL > 0 && ttl > maxTTL {
+		return logical.ErrorResponse("ttl cannot be greater than max_ttl"), nil
 	}
 
 	entry, err := logical.StorageEntryJSON("policy/"+name, roleConfig{
 		Policy:    string(policyRaw),
-		Lease:     lease,
+		TTL:       ttl,
+		MaxTTL:    maxTTL,
 		TokenType: tokenType,
 	})
 	if err != nil {
@@ -158,7 +178,9 @@ func pathRolesDelete(ctx context.Context, req *logical.Request, d *framework.Fie
 }
 
 type roleConfig struct {
-	Policy    string        `json:"policy"`
-	Lease     time.Duration `json:"lease"`
+	Policy string `json:"policy"`
+	// TTL is stored as "lease" to remain compatible with existing roles
+	TTL       time.Duration `json:"lease"`
+	MaxTTL    time.Duration `json:"max_ttl"`
 	TokenType string        `json:"token_type"`
 }
@@ -79,7 +79,8 @@ func (b *backend) pathTokenRead(ctx context.Context, req *logical.Request, d *fr
 		"token": token,
 		"role":  role,
 	})
-	s.Secret.TTL = result.Lease
+	s.Secret.TTL = result.TTL
+	s.Secret.MaxTTL = result.MaxTTL
 
 	return s, nil
 }
@@ -26,6 +26,11 @@ type dbPluginInstance struct {
 	id     string
 	name   string
 	closed bool
+
+	// health is the outcome of the health checks, which stop when stopCh is
+	// closed
+	health connectionHealth
+	stopCh chan struct{}
 }
 
 func (dbi *dbPluginInstance) Close() error {
@@ -36,6 +41,7 @@ func (dbi *dbPluginInstance) Close() error {
 		return nil
 	}
 	dbi.closed = true
+	close(dbi.stopCh)
 
 	return dbi.Database.Close()
 }
@@ -57,6 +63,9 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 			SealWrapStorage: []string{
 				"config/*",
 			},
+			Idempotent: []string{
+				"creds/*",
+			},
 		},
 
 		Paths: []*framework.Path{
@@ -66,6 +75,7 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 			pathRoles(&b),
 			pathCredsCreate(&b),
 			pathResetConnection(&b),
+			pathConnectionStatus(&b),
 			pathRotateCredentials(&b),
 		},
 
@@ -210,18 +220,33 @@ func (b *databaseBackend) GetConnection(ctx context.Context, s logical.Storage,
 		return nil, err
 	}
 
+	db, err = b.newPluginInstance(name, dbp, config)
+	if err != nil {
+		dbp.Close()
+		return nil, err
+	}
+
+	b.connections[name] = db
+	return db, nil
+}
+
+// newPluginInstance wraps an initialized plugin for the connection with the
+// given name, starting its health checks if they are enabled
+func (b *databaseBackend) newPluginInstance(name string, dbp dbplugin.Database, config *DatabaseConfig) (*dbPluginInstance, error) {
 	id, err := uuid.GenerateUUID()
 	if err != nil {
 		return nil, err
 	}
 
-	db = &dbPluginInstance{
+	db := &dbPluginInstance{
 		Database: dbp,
 		name:     name,
 		id:       id,
+		stopCh:   make(chan struct{}),
+	}
+	if config.HealthCheckInterval > 0 {
+		go b.runHealthChecks(db, config)
 	}
-
-	b.connections[name] = db
 	return db, nil
 }
 
@@ -239,6 +239,9 @@ func TestBackend_config_connection(t *testing.T) {
 			},
 			"allowed_roles":                      []string{"*"},
 			"root_credentials_rotate_statements": []string{},
+			"password_policy":                    "",
+			"health_check_interval":              int64(0),
+			"health_check_failure_threshold":     3,
 		}
 		configReq.Operation = logical.ReadOperation
 		resp, err = b.HandleRequest(context.Background(), configReq)
@@ -291,6 +294,9 @@ func TestBackend_config_connection(t *testing.T) {
 			},
 			"allowed_roles":                      []string{"*"},
 			"root_credentials_rotate_statements": []string{},
+			"password_policy":                    "",
+			"health_check_interval":              int64(0),
+			"health_check_failure_threshold":     3,
 		}
 		configReq.Operation = logical.ReadOperation
 		resp, err = b.HandleRequest(context.Background(), configReq)
@@ -332,6 +338,9 @@ func TestBackend_config_connection(t *testing.T) {
 			},
 			"allowed_roles":                      []string{"flu", "barre"},
 			"root_credentials_rotate_statements": []string{},
+			"password_policy":                    "",
+			"health_check_interval":              int64(0),
+			"health_check_failure_threshold":     3,
 		}
 		configReq.Operation = logical.ReadOperation
 		resp, err = b.HandleRequest(context.Background(), configReq)
@@ -719,6 +728,9 @@ func TestBackend_connectionCrud(t *testing.T) {
 		},
 		"allowed_roles":                      []string{"plugin-role-test"},
 		"root_credentials_rotate_statements": []string(nil),
+		"password_policy":                    "",
+		"health_check_interval":              int64(0),
+		"health_check_failure_threshold":     3,
 	}
 	req.Operation = logical.ReadOperation
 	resp, err = b.HandleRequest(context.Background(), req)
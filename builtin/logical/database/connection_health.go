@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+)
+
+const (
+	defaultHealthCheckFailureThreshold = 3
+
+	// healthCheckTimeout bounds a health check, including the restart of
+	// the plugin
+	healthCheckTimeout = 30 * time.Second
+)
+
+// connectionHealth is the outcome of the health checks of a connection
+type connectionHealth struct {
+	sync.RWMutex
+
+	lastCheck           time.Time
+	lastError           string
+	consecutiveFailures int
+	reconnections       int
+}
+
+// runHealthChecks verifies the connection of the plugin instance every
+// health check interval of its configuration, until it is closed
+func (b *databaseBackend) runHealthChecks(db *dbPluginInstance, config *DatabaseConfig) {
+	ticker := time.NewTicker(config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		if !b.checkHealth(db, config) {
+			return
+		}
+	}
+}
+
+// checkHealth verifies the connection of the plugin instance by initializing
+// it again, which re-establishes the broken connections of the plugins. After
+// failure_threshold consecutive failures, the plugin is restarted. It returns
+// false once the instance is closed.
+func (b *databaseBackend) checkHealth(db *dbPluginInstance, config *DatabaseConfig) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	db.RLock()
+	if db.closed {
+		db.RUnlock()
+		return false
+	}
+	_, err := db.Init(ctx, config.ConnectionDetails, true)
+	db.RUnlock()
+
+	db.health.Lock()
+	db.health.lastCheck = time.Now()
+	if err == nil {
+		if db.health.consecutiveFailures > 0 {
+			b.logger.Info("database connection is healthy again", "name", db.name, "failures", db.health.consecutiveFailures)
+		}
+		db.health.consecutiveFailures = 0
+		db.health.lastError = ""
+		db.health.Unlock()
+		return true
+	}
+	db.health.consecutiveFailures++
+	db.health.lastError = err.Error()
+	failures := db.health.consecutiveFailures
+	db.health.Unlock()
+
+	b.logger.Warn("database connection health check failed", "name", db.name, "failures", failures, "error", err)
+	if err != rpc.ErrShutdown && err != dbplugin.ErrPluginShutdown && failures%config.healthCheckFailureThreshold() != 0 {
+		return true
+	}
+	return b.reconnect(ctx, db, config)
+}
+
+// reconnect replaces the plugin of the instance with a new one, keeping the
+// instance so that requests wait for it. It returns false if the instance is
+// closed.
+func (b *databaseBackend) reconnect(ctx context.Context, db *dbPluginInstance, config *DatabaseConfig) bool {
+	db.Lock()
+	defer db.Unlock()
+	if db.closed {
+		return false
+	}
+
+	// The previous plugin is closed even if the new one fails, so that
+	// requests fail until the next attempt
+	if err := db.Database.Close(); err != nil {
+		b.logger.Debug("error closing the unhealthy database plugin", "name", db.name, "error", err)
+	}
+
+	dbp, err := dbplugin.PluginFactory(ctx, config.PluginName, b.System(), b.logger)
+	if err == nil {
+		if _, err = dbp.Init(ctx, config.ConnectionDetails, true); err != nil {
+			dbp.Close()
+		}
+	}
+
+	db.health.Lock()
+	defer db.health.Unlock()
+	db.health.reconnections++
+	if err != nil {
+		db.health.lastError = err.Error()
+		b.logger.Error("failed to re-establish database connection", "name", db.name, "error", err)
+		return true
+	}
+
+	db.Database = dbp
+	db.health.consecutiveFailures = 0
+	db.health.lastError = ""
+	b.logger.Info("re-established database connection", "name", db.name)
+	return true
+}
+
+// healthCheckFailureThreshold returns the number of consecutive failed health
+// checks after which the plugin is restarted
+func (c *DatabaseConfig) healthCheckFailureThreshold() int {
+	if c.HealthCheckFailureThreshold < 1 {
+		return defaultHealthCheckFailureThreshold
+	}
+	return c.HealthCheckFailureThreshold
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/logical"
+)
+
+// healthDatabase is a database whose connection fails while its err is set
+type healthDatabase struct {
+	dbplugin.Database
+
+	l      sync.Mutex
+	err    error
+	inits  int
+	closed bool
+}
+
+func (d *healthDatabase) Init(_ context.Context, conf map[string]interface{}, _ bool) (map[string]interface{}, error) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	d.inits++
+	return conf, d.err
+}
+
+func (d *healthDatabase) Close() error {
+	d.l.Lock()
+	defer d.l.Unlock()
+	d.closed = true
+	return nil
+}
+
+func (d *healthDatabase) setErr(err error) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	d.err = err
+}
+
+func TestBackend_ConnectionHealth(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	lb, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := lb.(*databaseBackend)
+	defer b.Cleanup(context.Background())
+
+	dbConfig := &DatabaseConfig{
+		PluginName:                  "unknown-database-plugin",
+		HealthCheckInterval:         time.Hour,
+		HealthCheckFailureThreshold: 2,
+	}
+	entry, err := logical.StorageEntryJSON("config/test", dbConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.StorageView.Put(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	readStatus := func() map[string]interface{} {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "status/test",
+			Storage:   config.StorageView,
+		})
+		if err != nil || resp == nil || resp.IsError() {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp.Data
+	}
+
+	if status := readStatus(); status["connected"] != false || status["status"] != "unknown" || status["health_check_interval"] != int64(3600) {
+		t.Fatalf("bad status: %#v", status)
+	}
+
+	fake := &healthDatabase{}
+	db, err := b.newPluginInstance("test", fake, dbConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.connections["test"] = db
+	if status := readStatus(); status["connected"] != true || status["status"] != "unknown" {
+		t.Fatalf("bad status: %#v", status)
+	}
+
+	if !b.checkHealth(db, dbConfig) {
+		t.Fatal("expected the health checks to continue")
+	}
+	if status := readStatus(); status["status"] != "healthy" || status["last_check"] == nil {
+		t.Fatalf("bad status: %#v", status)
+	}
+
+	// Failures below the threshold only re-establish the connection of the
+	// plugin
+	fake.setErr(errors.New("connection refused"))
+	b.checkHealth(db, dbConfig)
+	if status := readStatus(); status["status"] != "degraded" || status["consecutive_failures"] != 1 || status["last_error"] != "connection refused" {
+		t.Fatalf("bad status: %#v", status)
+	}
+	if fake.closed {
+		t.Fatal("expected the plugin not to be restarted")
+	}
+
+	// At the threshold, the plugin is restarted, failing here since it does
+	// not exist
+	if !b.checkHealth(db, dbConfig) {
+		t.Fatal("expected the health checks to continue")
+	}
+	status := readStatus()
+	if status["status"] != "unhealthy" || status["consecutive_failures"] != 2 || status["reconnections"] != 1 || status["last_error"] == "connection refused" {
+		t.Fatalf("bad status: %#v", status)
+	}
+	if !fake.closed {
+		t.Fatal("expected the unhealthy plugin to be closed")
+	}
+
+	// The connection recovers once its plugin does
+	fake.setErr(nil)
+	b.checkHealth(db, dbConfig)
+	if status := readStatus(); status["status"] != "healthy" || status["consecutive_failures"] != 0 || status["last_error"] != "" {
+		t.Fatalf("bad status: %#v", status)
+	}
+
+	// The health checks stop with the instance
+	if err := b.ClearConnection("test"); err != nil {
+		t.Fatal(err)
+	}
+	if b.checkHealth(db, dbConfig) {
+		t.Fatal("expected the health checks to stop")
+	}
+	if status := readStatus(); status["connected"] != false {
+		t.Fatalf("bad status: %#v", status)
+	}
+}
+
+func TestBackend_RunHealthChecks(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	lb, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := lb.(*databaseBackend)
+	defer b.Cleanup(context.Background())
+
+	// Health checks are disabled by default
+	fake := &healthDatabase{}
+	db, err := b.newPluginInstance("test", fake, &DatabaseConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	db.Close()
+	if fake.inits != 0 {
+		t.Fatalf("expected no health checks, got %d", fake.inits)
+	}
+
+	fake = &healthDatabase{}
+	db, err = b.newPluginInstance("test", fake, &DatabaseConfig{HealthCheckInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		fake.l.Lock()
+		inits := fake.inits
+		fake.l.Unlock()
+		if inits >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the connection to be checked periodically")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	db.Close()
+}
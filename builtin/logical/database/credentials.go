@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/helper/strutil"
+)
+
+const defaultCredentialKeyBits = 2048
+
+// validateCredentialConfig checks the credential type of a role and its
+// configuration, returning the configuration to store.
+func validateCredentialConfig(credentialType string, config map[string]string) (map[string]string, error) {
+	switch credentialType {
+	case dbplugin.CredentialTypePassword:
+		if len(config) > 0 {
+			return nil, errors.New("credential_config cannot be set with the password credential type")
+		}
+		return nil, nil
+
+	case dbplugin.CredentialTypeRSAPrivateKey, dbplugin.CredentialTypeClientCertificate:
+	default:
+		return nil, fmt.Errorf("invalid credential_type %q, must be one of %q, %q or %q", credentialType,
+			dbplugin.CredentialTypePassword, dbplugin.CredentialTypeRSAPrivateKey, dbplugin.CredentialTypeClientCertificate)
+	}
+
+	allowed := []string{"key_bits"}
+	if credentialType == dbplugin.CredentialTypeClientCertificate {
+		allowed = append(allowed, "ca_cert", "ca_private_key")
+	}
+	result := make(map[string]string, len(config))
+	for k, v := range config {
+		if !strutil.StrListContains(allowed, k) {
+			return nil, fmt.Errorf("invalid credential_config key %q for the %s credential type, must be one of %q", k, credentialType, allowed)
+		}
+		result[k] = v
+	}
+
+	if _, err := credentialKeyBits(result); err != nil {
+		return nil, err
+	}
+	if credentialType == dbplugin.CredentialTypeClientCertificate {
+		if _, err := credentialCA(result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// credentialKeyBits returns the size of the RSA keys generated for a role
+func credentialKeyBits(config map[string]string) (int, error) {
+	raw, ok := config["key_bits"]
+	if !ok {
+		return defaultCredentialKeyBits, nil
+	}
+	bits, err := strconv.Atoi(raw)
+	if err != nil || (bits != 2048 && bits != 3072 && bits != 4096) {
+		return 0, fmt.Errorf("invalid key_bits %q, must be 2048, 3072 or 4096", raw)
+	}
+	return bits, nil
+}
+
+// credentialCA returns the CA client certificates are signed with
+func credentialCA(config map[string]string) (*certutil.ParsedCertBundle, error) {
+	if config["ca_cert"] == "" || config["ca_private_key"] == "" {
+		return nil, errors.New("ca_cert and ca_private_key must be set in credential_config with the client_certificate credential type")
+	}
+	bundle, err := certutil.ParsePEMBundle(strings.TrimSpace(config["ca_cert"]) + "\n" + strings.TrimSpace(config["ca_private_key"]))
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to parse the CA of credential_config: {{err}}", err)
+	}
+	if bundle.Certificate == nil || bundle.PrivateKey == nil {
+		return nil, errors.New("ca_cert must hold a certificate and ca_private_key its private key")
+	}
+	if !bundle.Certificate.IsCA {
+		return nil, errors.New("ca_cert is not a CA certificate")
+	}
+	return bundle, nil
+}
+
+// createUserWithCredential generates a key pair for a new user of a role
+// which does not use passwords, and creates the user with it. Client
+// certificates are issued for the username until certExpiration. It returns
+// the username and the data of the credentials.
+func createUserWithCredential(ctx context.Context, db dbplugin.Database, role *roleEntry, usernameConfig dbplugin.UsernameConfig, expiration, certExpiration time.Time) (string, map[string]interface{}, error) {
+	bits, err := credentialKeyBits(role.CredentialConfig)
+	if err != nil {
+		return "", nil, err
+	}
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", nil, errwrap.Wrapf("failed to generate key: {{err}}", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", nil, err
+	}
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	username, err := dbplugin.CreateUserWithCredential(ctx, db, role.Statements, usernameConfig, expiration, dbplugin.Credential{
+		Type:      role.CredentialType,
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	data := map[string]interface{}{
+		"username":         username,
+		"private_key":      string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKey})),
+		"private_key_type": string(certutil.RSAPrivateKey),
+	}
+	if role.CredentialType != dbplugin.CredentialTypeClientCertificate {
+		return username, data, nil
+	}
+
+	certificate, ca, err := issueClientCertificate(role.CredentialConfig, username, key, certExpiration)
+	if err != nil {
+		// Do not leave behind a user nobody can log in as
+		if revokeErr := db.RevokeUser(ctx, role.Statements, username); revokeErr != nil {
+			err = fmt.Errorf("%v; failed to revoke user %q: %v", err, username, revokeErr)
+		}
+		return "", nil, err
+	}
+	data["certificate"] = certificate
+	data["issuing_ca"] = ca
+	return username, data, nil
+}
+
+// issueClientCertificate signs a client certificate for the username with
+// the CA of the role, returning it and the CA PEM-encoded
+func issueClientCertificate(config map[string]string, username string, key *rsa.PrivateKey, notAfter time.Time) (string, string, error) {
+	ca, err := credentialCA(config)
+	if err != nil {
+		return "", "", err
+	}
+	serial, err := certutil.GenerateSerialNumber()
+	if err != nil {
+		return "", "", err
+	}
+	subjKeyID, err := certutil.GetSubjKeyID(key)
+	if err != nil {
+		return "", "", err
+	}
+	if notAfter.After(ca.Certificate.NotAfter) {
+		notAfter = ca.Certificate.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: username},
+		NotBefore:    time.Now().Add(-30 * time.Second),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		SubjectKeyId: subjKeyID,
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, &key.PublicKey, ca.PrivateKey)
+	if err != nil {
+		return "", "", errwrap.Wrapf("failed to sign client certificate: {{err}}", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.CertificateBytes})), nil
+}
@@ -0,0 +1,325 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/logical"
+)
+
+// credentialDatabase is a database recording the users created with a
+// credential
+type credentialDatabase struct {
+	dbplugin.Database
+
+	credentials map[string]dbplugin.Credential
+	revoked     []string
+}
+
+func (d *credentialDatabase) CreateUserWithCredential(_ context.Context, _ dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, _ time.Time, credential dbplugin.Credential) (string, error) {
+	username := "v-" + usernameConfig.RoleName
+	d.credentials[username] = credential
+	return username, nil
+}
+
+func (d *credentialDatabase) RevokeUser(_ context.Context, _ dbplugin.Statements, username string) error {
+	d.revoked = append(d.revoked, username)
+	return nil
+}
+
+func testCredentialCA(t *testing.T) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "database-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestBackend_RoleCredentialType(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Cleanup(context.Background())
+
+	caCert, caKey := testCredentialCA(t)
+	writeRole := func(data map[string]interface{}) (*logical.Response, error) {
+		data["db_name"] = "test"
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "roles/test",
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+	}
+
+	cases := map[string]map[string]interface{}{
+		"invalid type":           {"credential_type": "token"},
+		"config with password":   {"credential_config": "key_bits=2048"},
+		"invalid key bits":       {"credential_type": "rsa_private_key", "credential_config": "key_bits=1024"},
+		"invalid key":            {"credential_type": "rsa_private_key", "credential_config": "ca_cert=foo"},
+		"missing CA":             {"credential_type": "client_certificate"},
+		"CA without private key": {"credential_type": "client_certificate", "credential_config": map[string]interface{}{"ca_cert": caCert}},
+	}
+	for name, data := range cases {
+		resp, err := writeRole(data)
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("%s: expected an error response, got resp: %#v, err: %v", name, resp, err)
+		}
+	}
+
+	resp, err := writeRole(map[string]interface{}{
+		"credential_type": "client_certificate",
+		"credential_config": map[string]interface{}{
+			"ca_cert":        caCert,
+			"ca_private_key": caKey,
+			"key_bits":       "3072",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/test",
+		Storage:   config.StorageView,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["credential_type"] != "client_certificate" {
+		t.Fatalf("bad credential_type: %#v", resp.Data)
+	}
+	credentialConfig := resp.Data["credential_config"].(map[string]string)
+	if credentialConfig["ca_cert"] != caCert || credentialConfig["key_bits"] != "3072" {
+		t.Fatalf("bad credential_config: %#v", credentialConfig)
+	}
+	if _, ok := credentialConfig["ca_private_key"]; ok {
+		t.Fatal("expected the CA private key not to be returned")
+	}
+
+	// Changing the type drops the configuration of the previous one
+	resp, err = writeRole(map[string]interface{}{"credential_type": "password"})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	role, err := b.(*databaseBackend).Role(context.Background(), config.StorageView, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.credentialType() != dbplugin.CredentialTypePassword || len(role.CredentialConfig) != 0 {
+		t.Fatalf("bad role: %#v", role)
+	}
+}
+
+func TestBackend_CreateUserWithCredential(t *testing.T) {
+	caCert, caKey := testCredentialCA(t)
+	db := &credentialDatabase{credentials: map[string]dbplugin.Credential{}}
+	usernameConfig := dbplugin.UsernameConfig{DisplayName: "token", RoleName: "test"}
+	expiration := time.Now().Add(time.Minute)
+
+	// RSA key pairs register the public key of the returned private key
+	role := &roleEntry{CredentialType: dbplugin.CredentialTypeRSAPrivateKey}
+	username, data, err := createUserWithCredential(context.Background(), db, role, usernameConfig, expiration, expiration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "v-test" || data["username"] != username || data["private_key_type"] != "rsa" {
+		t.Fatalf("bad credentials: %#v", data)
+	}
+	block, _ := pem.Decode([]byte(data["private_key"].(string)))
+	if block == nil || block.Type != "PRIVATE KEY" {
+		t.Fatalf("bad private key: %q", data["private_key"])
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.(*rsa.PrivateKey).PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential := db.credentials[username]
+	if credential.Type != dbplugin.CredentialTypeRSAPrivateKey || credential.PublicKey != string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})) {
+		t.Fatalf("bad credential: %#v", credential)
+	}
+	if _, ok := data["certificate"]; ok {
+		t.Fatal("expected no certificate")
+	}
+
+	// Client certificates are issued for the username by the CA of the role
+	role = &roleEntry{
+		CredentialType:   dbplugin.CredentialTypeClientCertificate,
+		CredentialConfig: map[string]string{"ca_cert": caCert, "ca_private_key": caKey},
+	}
+	certExpiration := time.Now().Add(10 * time.Minute)
+	username, data, err = createUserWithCredential(context.Background(), db, role, usernameConfig, expiration, certExpiration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["issuing_ca"] != caCert {
+		t.Fatalf("bad issuing CA: %q", data["issuing_ca"])
+	}
+	block, _ = pem.Decode([]byte(data["certificate"].(string)))
+	if block == nil {
+		t.Fatalf("bad certificate: %q", data["certificate"])
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM([]byte(caCert))
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != username || cert.NotAfter.Unix() != certExpiration.Unix() {
+		t.Fatalf("bad certificate: %v, %v", cert.Subject, cert.NotAfter)
+	}
+
+	// The user is revoked if its certificate cannot be issued
+	role.CredentialConfig = map[string]string{"ca_cert": caCert}
+	if _, _, err := createUserWithCredential(context.Background(), db, role, usernameConfig, expiration, certExpiration); err == nil || !strings.Contains(err.Error(), "ca_private_key") {
+		t.Fatalf("expected an error, got %v", err)
+	}
+	if len(db.revoked) != 1 || db.revoked[0] != username {
+		t.Fatalf("bad revoked users: %#v", db.revoked)
+	}
+
+	// Databases without credential support are rejected
+	if _, _, err := createUserWithCredential(context.Background(), &mockDatabase{}, role, usernameConfig, expiration, certExpiration); err != dbplugin.ErrCredentialTypeUnsupported {
+		t.Fatalf("expected the credential type to be unsupported, got %v", err)
+	}
+}
+
+type mockDatabase struct {
+	dbplugin.Database
+}
+
+// passwordPolicySystemView generates the passwords of a single password
+// policy
+type passwordPolicySystemView struct {
+	logical.StaticSystemView
+	policy, password string
+}
+
+func (s *passwordPolicySystemView) GeneratePasswordFromPolicy(_ context.Context, policyName string) (string, error) {
+	if policyName != s.policy {
+		return "", fmt.Errorf("password policy %q not found", policyName)
+	}
+	return s.password, nil
+}
+
+func TestBackend_PasswordPolicy(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = &passwordPolicySystemView{
+		StaticSystemView: *config.System.(*logical.StaticSystemView),
+		policy:           "database",
+		password:         "generated-from-policy",
+	}
+	lb, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := lb.(*databaseBackend)
+
+	// A policy that does not exist is rejected
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/test",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"plugin_name":     "unknown-database-plugin",
+			"password_policy": "missing",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), `"missing" not found`) {
+		t.Fatalf("expected the unknown policy to be rejected, got resp: %#v, err: %v", resp, err)
+	}
+
+	entry, err := logical.StorageEntryJSON("config/test", &DatabaseConfig{
+		PluginName:     "unknown-database-plugin",
+		AllowedRoles:   []string{"*"},
+		PasswordPolicy: "database",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.StorageView.Put(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/test",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"db_name":             "test",
+			"creation_statements": "CREATE USER",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	readCreds := func(dbp dbplugin.Database) (*logical.Response, error) {
+		db, err := b.newPluginInstance("test", dbp, &DatabaseConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Lock()
+		b.connections["test"] = db
+		b.Unlock()
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "creds/test",
+			Storage:   config.StorageView,
+		})
+	}
+
+	// The user is created with the password of the policy
+	fake := &credentialDatabase{credentials: map[string]dbplugin.Credential{}}
+	resp, err = readCreds(fake)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["username"] != "v-test" || resp.Data["password"] != "generated-from-policy" {
+		t.Fatalf("bad credentials: %#v", resp.Data)
+	}
+	credential := fake.credentials["v-test"]
+	if credential.Type != dbplugin.CredentialTypePassword || credential.Password != "generated-from-policy" {
+		t.Fatalf("bad credential: %#v", credential)
+	}
+
+	// Plugins which cannot be given the password are rejected
+	resp, err = readCreds(&mockDatabase{})
+	if err != nil || resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), "does not support password policies") {
+		t.Fatalf("expected the plugin to be rejected, got resp: %#v, err: %v", resp, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
@@ -28,6 +29,11 @@ func (dc *DatabasePluginClient) Close() error {
 	return err
 }
 
+// CreateUserWithCredential calls the plugin if its client supports it.
+func (dc *DatabasePluginClient) CreateUserWithCredential(ctx context.Context, statements Statements, usernameConfig UsernameConfig, expiration time.Time, credential Credential) (string, error) {
+	return CreateUserWithCredential(ctx, dc.Database, statements, usernameConfig, expiration, credential)
+}
+
 // newPluginClient returns a databaseRPCClient with a connection to a running
 // plugin. The client is wrapped in a DatabasePluginClient object to ensure the
 // plugin is killed on call of Close().
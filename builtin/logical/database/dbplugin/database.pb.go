@@ -37,7 +37,7 @@ func (m *InitializeRequest) Reset()         { *m = InitializeRequest{} }
 func (m *InitializeRequest) String() string { return proto.CompactTextString(m) }
 func (*InitializeRequest) ProtoMessage()    {}
 func (*InitializeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{0}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{0}
 }
 func (m *InitializeRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_InitializeRequest.Unmarshal(m, b)
@@ -83,7 +83,7 @@ func (m *InitRequest) Reset()         { *m = InitRequest{} }
 func (m *InitRequest) String() string { return proto.CompactTextString(m) }
 func (*InitRequest) ProtoMessage()    {}
 func (*InitRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{1}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{1}
 }
 func (m *InitRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_InitRequest.Unmarshal(m, b)
@@ -130,7 +130,7 @@ func (m *CreateUserRequest) Reset()         { *m = CreateUserRequest{} }
 func (m *CreateUserRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateUserRequest) ProtoMessage()    {}
 func (*CreateUserRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{2}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{2}
 }
 func (m *CreateUserRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateUserRequest.Unmarshal(m, b)
@@ -184,7 +184,7 @@ func (m *RenewUserRequest) Reset()         { *m = RenewUserRequest{} }
 func (m *RenewUserRequest) String() string { return proto.CompactTextString(m) }
 func (*RenewUserRequest) ProtoMessage()    {}
 func (*RenewUserRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{3}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{3}
 }
 func (m *RenewUserRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RenewUserRequest.Unmarshal(m, b)
@@ -237,7 +237,7 @@ func (m *RevokeUserRequest) Reset()         { *m = RevokeUserRequest{} }
 func (m *RevokeUserRequest) String() string { return proto.CompactTextString(m) }
 func (*RevokeUserRequest) ProtoMessage()    {}
 func (*RevokeUserRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{4}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{4}
 }
 func (m *RevokeUserRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RevokeUserRequest.Unmarshal(m, b)
@@ -282,7 +282,7 @@ func (m *RotateRootCredentialsRequest) Reset()         { *m = RotateRootCredenti
 func (m *RotateRootCredentialsRequest) String() string { return proto.CompactTextString(m) }
 func (*RotateRootCredentialsRequest) ProtoMessage()    {}
 func (*RotateRootCredentialsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{5}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{5}
 }
 func (m *RotateRootCredentialsRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RotateRootCredentialsRequest.Unmarshal(m, b)
@@ -331,7 +331,7 @@ func (m *Statements) Reset()         { *m = Statements{} }
 func (m *Statements) String() string { return proto.CompactTextString(m) }
 func (*Statements) ProtoMessage()    {}
 func (*Statements) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{6}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{6}
 }
 func (m *Statements) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Statements.Unmarshal(m, b)
@@ -423,7 +423,7 @@ func (m *UsernameConfig) Reset()         { *m = UsernameConfig{} }
 func (m *UsernameConfig) String() string { return proto.CompactTextString(m) }
 func (*UsernameConfig) ProtoMessage()    {}
 func (*UsernameConfig) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{7}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{7}
 }
 func (m *UsernameConfig) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UsernameConfig.Unmarshal(m, b)
@@ -468,7 +468,7 @@ func (m *InitResponse) Reset()         { *m = InitResponse{} }
 func (m *InitResponse) String() string { return proto.CompactTextString(m) }
 func (*InitResponse) ProtoMessage()    {}
 func (*InitResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{8}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{8}
 }
 func (m *InitResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_InitResponse.Unmarshal(m, b)
@@ -507,7 +507,7 @@ func (m *CreateUserResponse) Reset()         { *m = CreateUserResponse{} }
 func (m *CreateUserResponse) String() string { return proto.CompactTextString(m) }
 func (*CreateUserResponse) ProtoMessage()    {}
 func (*CreateUserResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{9}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{9}
 }
 func (m *CreateUserResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateUserResponse.Unmarshal(m, b)
@@ -552,7 +552,7 @@ func (m *TypeResponse) Reset()         { *m = TypeResponse{} }
 func (m *TypeResponse) String() string { return proto.CompactTextString(m) }
 func (*TypeResponse) ProtoMessage()    {}
 func (*TypeResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{10}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{10}
 }
 func (m *TypeResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TypeResponse.Unmarshal(m, b)
@@ -590,7 +590,7 @@ func (m *RotateRootCredentialsResponse) Reset()         { *m = RotateRootCredent
 func (m *RotateRootCredentialsResponse) String() string { return proto.CompactTextString(m) }
 func (*RotateRootCredentialsResponse) ProtoMessage()    {}
 func (*RotateRootCredentialsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{11}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{11}
 }
 func (m *RotateRootCredentialsResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RotateRootCredentialsResponse.Unmarshal(m, b)
@@ -627,7 +627,7 @@ func (m *Empty) Reset()         { *m = Empty{} }
 func (m *Empty) String() string { return proto.CompactTextString(m) }
 func (*Empty) ProtoMessage()    {}
 func (*Empty) Descriptor() ([]byte, []int) {
-	return fileDescriptor_database_4b48b7d6479beb92, []int{12}
+	return fileDescriptor_database_3f194b8e45fe0339, []int{12}
 }
 func (m *Empty) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Empty.Unmarshal(m, b)
@@ -647,6 +647,122 @@ func (m *Empty) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Empty proto.InternalMessageInfo
 
+type Credential struct {
+	Type                 string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	PublicKey            string   `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Password             string   `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Credential) Reset()         { *m = Credential{} }
+func (m *Credential) String() string { return proto.CompactTextString(m) }
+func (*Credential) ProtoMessage()    {}
+func (*Credential) Descriptor() ([]byte, []int) {
+	return fileDescriptor_database_3f194b8e45fe0339, []int{13}
+}
+func (m *Credential) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Credential.Unmarshal(m, b)
+}
+func (m *Credential) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Credential.Marshal(b, m, deterministic)
+}
+func (dst *Credential) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Credential.Merge(dst, src)
+}
+func (m *Credential) XXX_Size() int {
+	return xxx_messageInfo_Credential.Size(m)
+}
+func (m *Credential) XXX_DiscardUnknown() {
+	xxx_messageInfo_Credential.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Credential proto.InternalMessageInfo
+
+func (m *Credential) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Credential) GetPublicKey() string {
+	if m != nil {
+		return m.PublicKey
+	}
+	return ""
+}
+
+func (m *Credential) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+type CreateUserWithCredentialRequest struct {
+	Statements           *Statements          `protobuf:"bytes,1,opt,name=statements,proto3" json:"statements,omitempty"`
+	UsernameConfig       *UsernameConfig      `protobuf:"bytes,2,opt,name=username_config,json=usernameConfig,proto3" json:"username_config,omitempty"`
+	Expiration           *timestamp.Timestamp `protobuf:"bytes,3,opt,name=expiration,proto3" json:"expiration,omitempty"`
+	Credential           *Credential          `protobuf:"bytes,4,opt,name=credential,proto3" json:"credential,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *CreateUserWithCredentialRequest) Reset()         { *m = CreateUserWithCredentialRequest{} }
+func (m *CreateUserWithCredentialRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateUserWithCredentialRequest) ProtoMessage()    {}
+func (*CreateUserWithCredentialRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_database_3f194b8e45fe0339, []int{14}
+}
+func (m *CreateUserWithCredentialRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateUserWithCredentialRequest.Unmarshal(m, b)
+}
+func (m *CreateUserWithCredentialRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateUserWithCredentialRequest.Marshal(b, m, deterministic)
+}
+func (dst *CreateUserWithCredentialRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateUserWithCredentialRequest.Merge(dst, src)
+}
+func (m *CreateUserWithCredentialRequest) XXX_Size() int {
+	return xxx_messageInfo_CreateUserWithCredentialRequest.Size(m)
+}
+func (m *CreateUserWithCredentialRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateUserWithCredentialRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateUserWithCredentialRequest proto.InternalMessageInfo
+
+func (m *CreateUserWithCredentialRequest) GetStatements() *Statements {
+	if m != nil {
+		return m.Statements
+	}
+	return nil
+}
+
+func (m *CreateUserWithCredentialRequest) GetUsernameConfig() *UsernameConfig {
+	if m != nil {
+		return m.UsernameConfig
+	}
+	return nil
+}
+
+func (m *CreateUserWithCredentialRequest) GetExpiration() *timestamp.Timestamp {
+	if m != nil {
+		return m.Expiration
+	}
+	return nil
+}
+
+func (m *CreateUserWithCredentialRequest) GetCredential() *Credential {
+	if m != nil {
+		return m.Credential
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*InitializeRequest)(nil), "dbplugin.InitializeRequest")
 	proto.RegisterType((*InitRequest)(nil), "dbplugin.InitRequest")
@@ -661,6 +777,8 @@ func init() {
 	proto.RegisterType((*TypeResponse)(nil), "dbplugin.TypeResponse")
 	proto.RegisterType((*RotateRootCredentialsResponse)(nil), "dbplugin.RotateRootCredentialsResponse")
 	proto.RegisterType((*Empty)(nil), "dbplugin.Empty")
+	proto.RegisterType((*Credential)(nil), "dbplugin.Credential")
+	proto.RegisterType((*CreateUserWithCredentialRequest)(nil), "dbplugin.CreateUserWithCredentialRequest")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -677,6 +795,7 @@ const _ = grpc.SupportPackageIsVersion4
 type DatabaseClient interface {
 	Type(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*TypeResponse, error)
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*CreateUserResponse, error)
+	CreateUserWithCredential(ctx context.Context, in *CreateUserWithCredentialRequest, opts ...grpc.CallOption) (*CreateUserResponse, error)
 	RenewUser(ctx context.Context, in *RenewUserRequest, opts ...grpc.CallOption) (*Empty, error)
 	RevokeUser(ctx context.Context, in *RevokeUserRequest, opts ...grpc.CallOption) (*Empty, error)
 	RotateRootCredentials(ctx context.Context, in *RotateRootCredentialsRequest, opts ...grpc.CallOption) (*RotateRootCredentialsResponse, error)
@@ -711,6 +830,15 @@ func (c *databaseClient) CreateUser(ctx context.Context, in *CreateUserRequest,
 	return out, nil
 }
 
+func (c *databaseClient) CreateUserWithCredential(ctx context.Context, in *CreateUserWithCredentialRequest, opts ...grpc.CallOption) (*CreateUserResponse, error) {
+	out := new(CreateUserResponse)
+	err := c.cc.Invoke(ctx, "/dbplugin.Database/CreateUserWithCredential", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *databaseClient) RenewUser(ctx context.Context, in *RenewUserRequest, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
 	err := c.cc.Invoke(ctx, "/dbplugin.Database/RenewUser", in, out, opts...)
@@ -770,6 +898,7 @@ func (c *databaseClient) Initialize(ctx context.Context, in *InitializeRequest,
 type DatabaseServer interface {
 	Type(context.Context, *Empty) (*TypeResponse, error)
 	CreateUser(context.Context, *CreateUserRequest) (*CreateUserResponse, error)
+	CreateUserWithCredential(context.Context, *CreateUserWithCredentialRequest) (*CreateUserResponse, error)
 	RenewUser(context.Context, *RenewUserRequest) (*Empty, error)
 	RevokeUser(context.Context, *RevokeUserRequest) (*Empty, error)
 	RotateRootCredentials(context.Context, *RotateRootCredentialsRequest) (*RotateRootCredentialsResponse, error)
@@ -818,6 +947,24 @@ func _Database_CreateUser_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Database_CreateUserWithCredential_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUserWithCredentialRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServer).CreateUserWithCredential(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dbplugin.Database/CreateUserWithCredential",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServer).CreateUserWithCredential(ctx, req.(*CreateUserWithCredentialRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Database_RenewUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RenewUserRequest)
 	if err := dec(in); err != nil {
@@ -938,6 +1085,10 @@ var _Database_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateUser",
 			Handler:    _Database_CreateUser_Handler,
 		},
+		{
+			MethodName: "CreateUserWithCredential",
+			Handler:    _Database_CreateUserWithCredential_Handler,
+		},
 		{
 			MethodName: "RenewUser",
 			Handler:    _Database_RenewUser_Handler,
@@ -968,55 +1119,60 @@ var _Database_serviceDesc = grpc.ServiceDesc{
 }
 
 func init() {
-	proto.RegisterFile("builtin/logical/database/dbplugin/database.proto", fileDescriptor_database_4b48b7d6479beb92)
-}
-
-var fileDescriptor_database_4b48b7d6479beb92 = []byte{
-	// 724 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xd1, 0x4e, 0xdb, 0x4a,
-	0x10, 0x95, 0x93, 0x00, 0xc9, 0x80, 0x80, 0xec, 0x05, 0x64, 0xf9, 0x72, 0x6f, 0x91, 0x1f, 0x28,
-	0x55, 0xd5, 0xb8, 0x82, 0x56, 0x54, 0xa8, 0xa2, 0x2a, 0xa1, 0xaa, 0x2a, 0x55, 0x3c, 0x2c, 0xf0,
-	0x52, 0x55, 0x42, 0x1b, 0x67, 0x49, 0x56, 0x38, 0x5e, 0xd7, 0xbb, 0x0e, 0x4d, 0x7f, 0xa0, 0xfd,
-	0x8c, 0x7e, 0x4e, 0x1f, 0xfb, 0x49, 0x95, 0x37, 0x59, 0xef, 0x26, 0x86, 0xf2, 0x40, 0xfb, 0xe6,
-	0xd9, 0x99, 0x33, 0x73, 0xe6, 0x78, 0x76, 0x16, 0x9e, 0x76, 0x32, 0x16, 0x49, 0x16, 0x07, 0x11,
-	0xef, 0xb1, 0x90, 0x44, 0x41, 0x97, 0x48, 0xd2, 0x21, 0x82, 0x06, 0xdd, 0x4e, 0x12, 0x65, 0x3d,
-	0x16, 0x17, 0x27, 0xad, 0x24, 0xe5, 0x92, 0xa3, 0xba, 0x76, 0x78, 0x0f, 0x7a, 0x9c, 0xf7, 0x22,
-	0x1a, 0xa8, 0xf3, 0x4e, 0x76, 0x19, 0x48, 0x36, 0xa0, 0x42, 0x92, 0x41, 0x32, 0x0e, 0xf5, 0x3f,
-	0x42, 0xf3, 0x5d, 0xcc, 0x24, 0x23, 0x11, 0xfb, 0x42, 0x31, 0xfd, 0x94, 0x51, 0x21, 0xd1, 0x06,
-	0xcc, 0x87, 0x3c, 0xbe, 0x64, 0x3d, 0xd7, 0xd9, 0x72, 0x76, 0x96, 0xf0, 0xc4, 0x42, 0x8f, 0xa1,
-	0x39, 0xa4, 0x29, 0xbb, 0x1c, 0x5d, 0x84, 0x3c, 0x8e, 0x69, 0x28, 0x19, 0x8f, 0xdd, 0xca, 0x96,
-	0xb3, 0x53, 0xc7, 0xab, 0x63, 0x47, 0xbb, 0x38, 0x3f, 0xa8, 0xb8, 0x8e, 0x8f, 0x61, 0x31, 0xcf,
-	0xfe, 0x27, 0xf3, 0xfa, 0x3f, 0x1c, 0x68, 0xb6, 0x53, 0x4a, 0x24, 0x3d, 0x17, 0x34, 0xd5, 0xa9,
-	0x9f, 0x01, 0x08, 0x49, 0x24, 0x1d, 0xd0, 0x58, 0x0a, 0x95, 0x7e, 0x71, 0x77, 0xad, 0xa5, 0x75,
-	0x68, 0x9d, 0x16, 0x3e, 0x6c, 0xc5, 0xa1, 0xd7, 0xb0, 0x92, 0x09, 0x9a, 0xc6, 0x64, 0x40, 0x2f,
-	0x26, 0xcc, 0x2a, 0x0a, 0xea, 0x1a, 0xe8, 0xf9, 0x24, 0xa0, 0xad, 0xfc, 0x78, 0x39, 0x9b, 0xb2,
-	0xd1, 0x01, 0x00, 0xfd, 0x9c, 0xb0, 0x94, 0x28, 0xd2, 0x55, 0x85, 0xf6, 0x5a, 0x63, 0xd9, 0x5b,
-	0x5a, 0xf6, 0xd6, 0x99, 0x96, 0x1d, 0x5b, 0xd1, 0xfe, 0x77, 0x07, 0x56, 0x31, 0x8d, 0xe9, 0xf5,
-	0xfd, 0x3b, 0xf1, 0xa0, 0xae, 0x89, 0xa9, 0x16, 0x1a, 0xb8, 0xb0, 0xef, 0x45, 0x91, 0x42, 0x13,
-	0xd3, 0x21, 0xbf, 0xa2, 0x7f, 0x95, 0xa2, 0x7f, 0x08, 0x9b, 0x98, 0xe7, 0xa1, 0x98, 0x73, 0xd9,
-	0x4e, 0x69, 0x97, 0xc6, 0xf9, 0x4c, 0x0a, 0x5d, 0xf1, 0xff, 0x99, 0x8a, 0xd5, 0x9d, 0x86, 0x9d,
-	0xdb, 0xff, 0x59, 0x01, 0x30, 0x65, 0xd1, 0x1e, 0xfc, 0x13, 0xe6, 0x23, 0xc2, 0x78, 0x7c, 0x31,
-	0xc3, 0xb4, 0x71, 0x54, 0x71, 0x1d, 0x8c, 0xb4, 0xdb, 0x02, 0xed, 0xc3, 0x7a, 0x4a, 0x87, 0x3c,
-	0x2c, 0xc1, 0x2a, 0x05, 0x6c, 0xcd, 0x04, 0x4c, 0x57, 0x4b, 0x79, 0x14, 0x75, 0x48, 0x78, 0x65,
-	0xc3, 0xaa, 0xa6, 0x9a, 0x76, 0x5b, 0xa0, 0x27, 0xb0, 0x9a, 0xe6, 0xbf, 0xde, 0x46, 0xd4, 0x0a,
-	0xc4, 0x8a, 0xf2, 0x9d, 0x4e, 0x89, 0xa7, 0x29, 0xbb, 0x73, 0xaa, 0xfd, 0xc2, 0xce, 0xc5, 0x31,
-	0xbc, 0xdc, 0xf9, 0xb1, 0x38, 0xe6, 0x24, 0xc7, 0x6a, 0x02, 0xee, 0xc2, 0x18, 0xab, 0x6d, 0xe4,
-	0xc2, 0x82, 0x2a, 0x45, 0x22, 0xb7, 0xae, 0x5c, 0xda, 0xf4, 0x4f, 0x60, 0x79, 0x7a, 0xf4, 0xd1,
-	0x16, 0x2c, 0x1e, 0x33, 0x91, 0x44, 0x64, 0x74, 0x92, 0xff, 0x43, 0xa5, 0x26, 0xb6, 0x8f, 0xf2,
-	0x4a, 0x98, 0x47, 0xf4, 0xc4, 0xfa, 0xc5, 0xda, 0xf6, 0xb7, 0x61, 0x69, 0xbc, 0x0b, 0x44, 0xc2,
-	0x63, 0x41, 0x6f, 0x5b, 0x06, 0xfe, 0x7b, 0x40, 0xf6, 0xf5, 0x9e, 0x44, 0xdb, 0xc3, 0xe3, 0xcc,
-	0xcc, 0xb7, 0x07, 0xf5, 0x84, 0x08, 0x71, 0xcd, 0xd3, 0xae, 0xae, 0xaa, 0x6d, 0xdf, 0x87, 0xa5,
-	0xb3, 0x51, 0x42, 0x8b, 0x3c, 0x08, 0x6a, 0x72, 0x94, 0xe8, 0x1c, 0xea, 0xdb, 0xdf, 0x87, 0xff,
-	0x6e, 0x19, 0xbe, 0x3b, 0xa8, 0x2e, 0xc0, 0xdc, 0x9b, 0x41, 0x22, 0x47, 0xbb, 0x5f, 0x6b, 0x50,
-	0x3f, 0x9e, 0xec, 0x60, 0x14, 0x40, 0x2d, 0x2f, 0x89, 0x56, 0xcc, 0x8d, 0x50, 0x51, 0xde, 0x86,
-	0x39, 0x98, 0xe2, 0xf4, 0x16, 0xc0, 0x74, 0x8c, 0xfe, 0x35, 0x51, 0xa5, 0x35, 0xe7, 0x6d, 0xde,
-	0xec, 0x9c, 0x24, 0x7a, 0x01, 0x8d, 0x62, 0x9d, 0x20, 0xcf, 0x84, 0xce, 0xee, 0x18, 0x6f, 0x96,
-	0x5a, 0xbe, 0x22, 0xcc, 0x35, 0xb7, 0x29, 0x94, 0x2e, 0x7f, 0x19, 0xdb, 0x87, 0xf5, 0x1b, 0xe5,
-	0x43, 0xdb, 0x56, 0x9a, 0xdf, 0x5c, 0x6e, 0xef, 0xe1, 0x9d, 0x71, 0x93, 0xfe, 0x9e, 0x43, 0x2d,
-	0x1f, 0x21, 0xb4, 0x6e, 0x00, 0xd6, 0xf3, 0x62, 0xeb, 0x3b, 0x35, 0x69, 0x8f, 0x60, 0xae, 0x1d,
-	0x71, 0x71, 0xc3, 0x1f, 0x29, 0xf5, 0xf2, 0x0a, 0xc0, 0x3c, 0x87, 0xb6, 0x0e, 0xa5, 0x47, 0xb2,
-	0x84, 0xf5, 0xab, 0xdf, 0x2a, 0xce, 0xd1, 0xe1, 0x87, 0x97, 0x3d, 0x26, 0xfb, 0x59, 0xa7, 0x15,
-	0xf2, 0x41, 0xd0, 0x27, 0xa2, 0xcf, 0x42, 0x9e, 0x26, 0xc1, 0x90, 0x64, 0x91, 0x0c, 0xee, 0x7c,
-	0xc9, 0x3b, 0xf3, 0x6a, 0x1f, 0xef, 0xfd, 0x0a, 0x00, 0x00, 0xff, 0xff, 0x9c, 0x49, 0x0b, 0x5b,
-	0xf5, 0x07, 0x00, 0x00,
+	proto.RegisterFile("builtin/logical/database/dbplugin/database.proto", fileDescriptor_database_3f194b8e45fe0339)
+}
+
+var fileDescriptor_database_3f194b8e45fe0339 = []byte{
+	// 803 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x56, 0xdd, 0x4e, 0xdb, 0x48,
+	0x14, 0x5e, 0x3b, 0x01, 0x92, 0x03, 0x02, 0x32, 0x0b, 0xc8, 0xf2, 0xc2, 0x82, 0x7c, 0xc1, 0x82,
+	0x56, 0x1b, 0xaf, 0x60, 0x57, 0xac, 0xd0, 0x8a, 0xd5, 0x12, 0x56, 0xab, 0xaa, 0x15, 0x17, 0x06,
+	0x54, 0xa9, 0xad, 0x14, 0x8d, 0x9d, 0x21, 0x19, 0xe1, 0x78, 0x5c, 0x7b, 0x1c, 0x9a, 0x3e, 0x41,
+	0x2f, 0xfa, 0x10, 0x7d, 0x9c, 0x5e, 0xf6, 0x49, 0xfa, 0x0c, 0x95, 0x1d, 0x8f, 0x67, 0xec, 0x04,
+	0xb8, 0xa0, 0xbd, 0xe8, 0x9d, 0xcf, 0xcf, 0x77, 0x7e, 0xbe, 0x33, 0x3e, 0x33, 0xf0, 0xbb, 0x9b,
+	0x50, 0x9f, 0xd3, 0xc0, 0xf6, 0x59, 0x9f, 0x7a, 0xd8, 0xb7, 0x7b, 0x98, 0x63, 0x17, 0xc7, 0xc4,
+	0xee, 0xb9, 0xa1, 0x9f, 0xf4, 0x69, 0x50, 0x68, 0xda, 0x61, 0xc4, 0x38, 0x43, 0x0d, 0x61, 0x30,
+	0xb7, 0xfb, 0x8c, 0xf5, 0x7d, 0x62, 0x67, 0x7a, 0x37, 0xb9, 0xb6, 0x39, 0x1d, 0x92, 0x98, 0xe3,
+	0x61, 0x38, 0x71, 0xb5, 0x5e, 0x41, 0xeb, 0x49, 0x40, 0x39, 0xc5, 0x3e, 0x7d, 0x4b, 0x1c, 0xf2,
+	0x3a, 0x21, 0x31, 0x47, 0x1b, 0x30, 0xef, 0xb1, 0xe0, 0x9a, 0xf6, 0x0d, 0x6d, 0x47, 0xdb, 0x5b,
+	0x72, 0x72, 0x09, 0xfd, 0x0a, 0xad, 0x11, 0x89, 0xe8, 0xf5, 0xb8, 0xeb, 0xb1, 0x20, 0x20, 0x1e,
+	0xa7, 0x2c, 0x30, 0xf4, 0x1d, 0x6d, 0xaf, 0xe1, 0xac, 0x4e, 0x0c, 0x9d, 0x42, 0x7f, 0xac, 0x1b,
+	0x9a, 0xe5, 0xc0, 0x62, 0x1a, 0xfd, 0x6b, 0xc6, 0xb5, 0x3e, 0x6a, 0xd0, 0xea, 0x44, 0x04, 0x73,
+	0x72, 0x15, 0x93, 0x48, 0x84, 0xfe, 0x03, 0x20, 0xe6, 0x98, 0x93, 0x21, 0x09, 0x78, 0x9c, 0x85,
+	0x5f, 0x3c, 0x58, 0x6b, 0x0b, 0x1e, 0xda, 0x17, 0x85, 0xcd, 0x51, 0xfc, 0xd0, 0xbf, 0xb0, 0x92,
+	0xc4, 0x24, 0x0a, 0xf0, 0x90, 0x74, 0xf3, 0xca, 0xf4, 0x0c, 0x6a, 0x48, 0xe8, 0x55, 0xee, 0xd0,
+	0xc9, 0xec, 0xce, 0x72, 0x52, 0x92, 0xd1, 0x31, 0x00, 0x79, 0x13, 0xd2, 0x08, 0x67, 0x45, 0xd7,
+	0x32, 0xb4, 0xd9, 0x9e, 0xd0, 0xde, 0x16, 0xb4, 0xb7, 0x2f, 0x05, 0xed, 0x8e, 0xe2, 0x6d, 0x7d,
+	0xd0, 0x60, 0xd5, 0x21, 0x01, 0xb9, 0x7d, 0x7c, 0x27, 0x26, 0x34, 0x44, 0x61, 0x59, 0x0b, 0x4d,
+	0xa7, 0x90, 0x1f, 0x55, 0x22, 0x81, 0x96, 0x43, 0x46, 0xec, 0x86, 0x7c, 0xd3, 0x12, 0xad, 0x13,
+	0xd8, 0x74, 0x58, 0xea, 0xea, 0x30, 0xc6, 0x3b, 0x11, 0xe9, 0x91, 0x20, 0x3d, 0x93, 0xb1, 0xc8,
+	0xf8, 0x73, 0x25, 0x63, 0x6d, 0xaf, 0xa9, 0xc6, 0xb6, 0x3e, 0xe9, 0x00, 0x32, 0x2d, 0x3a, 0x84,
+	0x1f, 0xbd, 0xf4, 0x88, 0x50, 0x16, 0x74, 0x2b, 0x95, 0x36, 0x4f, 0x75, 0x43, 0x73, 0x90, 0x30,
+	0x2b, 0xa0, 0x23, 0x58, 0x8f, 0xc8, 0x88, 0x79, 0x53, 0x30, 0xbd, 0x80, 0xad, 0x49, 0x87, 0x72,
+	0xb6, 0x88, 0xf9, 0xbe, 0x8b, 0xbd, 0x1b, 0x15, 0x56, 0x93, 0xd9, 0x84, 0x59, 0x01, 0xfd, 0x06,
+	0xab, 0x51, 0x3a, 0x7a, 0x15, 0x51, 0x2f, 0x10, 0x2b, 0x99, 0xed, 0xa2, 0x44, 0x9e, 0x28, 0xd9,
+	0x98, 0xcb, 0xda, 0x2f, 0xe4, 0x94, 0x1c, 0x59, 0x97, 0x31, 0x3f, 0x21, 0x47, 0x6a, 0x52, 0xac,
+	0x28, 0xc0, 0x58, 0x98, 0x60, 0x85, 0x8c, 0x0c, 0x58, 0xc8, 0x52, 0x61, 0xdf, 0x68, 0x64, 0x26,
+	0x21, 0x5a, 0xe7, 0xb0, 0x5c, 0x3e, 0xfa, 0x68, 0x07, 0x16, 0xcf, 0x68, 0x1c, 0xfa, 0x78, 0x7c,
+	0x9e, 0xce, 0x30, 0x63, 0xd3, 0x51, 0x55, 0x69, 0x26, 0x87, 0xf9, 0xe4, 0x5c, 0x19, 0xb1, 0x90,
+	0xad, 0x5d, 0x58, 0x9a, 0xec, 0x82, 0x38, 0x64, 0x41, 0x4c, 0xee, 0x5a, 0x06, 0xd6, 0x33, 0x40,
+	0xea, 0xef, 0x9d, 0x7b, 0xab, 0x87, 0x47, 0xab, 0x9c, 0x6f, 0x13, 0x1a, 0x21, 0x8e, 0xe3, 0x5b,
+	0x16, 0xf5, 0x44, 0x56, 0x21, 0x5b, 0x16, 0x2c, 0x5d, 0x8e, 0x43, 0x52, 0xc4, 0x41, 0x50, 0xe7,
+	0xe3, 0x50, 0xc4, 0xc8, 0xbe, 0xad, 0x23, 0xd8, 0xba, 0xe3, 0xf0, 0x3d, 0x50, 0xea, 0x02, 0xcc,
+	0xfd, 0x37, 0x0c, 0xf9, 0xd8, 0x7a, 0x09, 0x20, 0x71, 0xb3, 0x72, 0xa0, 0x2d, 0x80, 0x30, 0x71,
+	0x7d, 0xea, 0x75, 0x6f, 0xc8, 0x38, 0xaf, 0xb2, 0x39, 0xd1, 0x3c, 0x25, 0xe3, 0x52, 0x0b, 0xb5,
+	0x4a, 0x0b, 0xef, 0x75, 0xd8, 0x96, 0x8c, 0x3c, 0xa7, 0x7c, 0x20, 0x73, 0x7d, 0xcf, 0xeb, 0x2f,
+	0x2d, 0xda, 0x2b, 0x3a, 0x31, 0xea, 0xd5, 0xa2, 0x95, 0x2e, 0x15, 0xbf, 0x83, 0xcf, 0x75, 0x68,
+	0x9c, 0xe5, 0xf7, 0x1d, 0xb2, 0xa1, 0x9e, 0x8e, 0x17, 0xad, 0x48, 0x58, 0x36, 0x11, 0x73, 0x43,
+	0x2a, 0x4a, 0xf3, 0xff, 0x1f, 0x40, 0x72, 0x89, 0x7e, 0x2a, 0x65, 0x2b, 0x5f, 0x29, 0xe6, 0xe6,
+	0x6c, 0x63, 0x1e, 0x88, 0x80, 0x71, 0xd7, 0x50, 0xd0, 0xfe, 0x2c, 0xe4, 0xcc, 0xc1, 0xdd, 0x9f,
+	0xc4, 0xfa, 0x01, 0xfd, 0x05, 0xcd, 0xe2, 0x86, 0x40, 0xa6, 0x74, 0xae, 0x5e, 0x1b, 0x66, 0x95,
+	0x81, 0x74, 0x32, 0x72, 0x73, 0xab, 0x9d, 0x4e, 0xed, 0xf3, 0x69, 0xec, 0x00, 0xd6, 0x67, 0xfe,
+	0x11, 0x68, 0x57, 0x09, 0x73, 0xcf, 0xbe, 0x36, 0x7f, 0x79, 0xd0, 0x2f, 0xa7, 0xf1, 0x4f, 0xa8,
+	0xa7, 0x5b, 0x01, 0xad, 0x4b, 0x80, 0xf2, 0x62, 0x30, 0x37, 0xaa, 0xea, 0x1c, 0xb6, 0x0f, 0x73,
+	0x1d, 0x9f, 0xc5, 0x33, 0x06, 0x3f, 0xd5, 0xcb, 0x3f, 0x00, 0xf2, 0x85, 0xa3, 0xf2, 0x30, 0xf5,
+	0xee, 0x99, 0xc2, 0x5a, 0xb5, 0x77, 0xba, 0x76, 0x7a, 0xf2, 0xe2, 0xef, 0x3e, 0xe5, 0x83, 0xc4,
+	0x6d, 0x7b, 0x6c, 0x68, 0x0f, 0x70, 0x3c, 0xa0, 0x1e, 0x8b, 0x42, 0x7b, 0x84, 0x13, 0x9f, 0xdb,
+	0x0f, 0x3e, 0xce, 0xdc, 0xf9, 0xec, 0x37, 0x38, 0xfc, 0x32, 0x00, 0xd8, 0x17, 0x50, 0x2d, 0xc8,
+	0x09, 0x00, 0x00,
 }
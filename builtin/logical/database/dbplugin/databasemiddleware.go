@@ -36,6 +36,15 @@ func (mw *databaseTracingMiddleware) CreateUser(ctx context.Context, statements
 	return mw.next.CreateUser(ctx, statements, usernameConfig, expiration)
 }
 
+func (mw *databaseTracingMiddleware) CreateUserWithCredential(ctx context.Context, statements Statements, usernameConfig UsernameConfig, expiration time.Time, credential Credential) (username string, err error) {
+	defer func(then time.Time) {
+		mw.logger.Trace("create user with credential", "status", "finished", "type", credential.Type, "err", err, "took", time.Since(then))
+	}(time.Now())
+
+	mw.logger.Trace("create user with credential", "status", "started", "type", credential.Type)
+	return CreateUserWithCredential(ctx, mw.next, statements, usernameConfig, expiration, credential)
+}
+
 func (mw *databaseTracingMiddleware) RenewUser(ctx context.Context, statements Statements, username string, expiration time.Time) (err error) {
 	defer func(then time.Time) {
 		mw.logger.Trace("renew user", "status", "finished", "err", err, "took", time.Since(then))
@@ -116,6 +125,22 @@ func (mw *databaseMetricsMiddleware) CreateUser(ctx context.Context, statements
 	return mw.next.CreateUser(ctx, statements, usernameConfig, expiration)
 }
 
+func (mw *databaseMetricsMiddleware) CreateUserWithCredential(ctx context.Context, statements Statements, usernameConfig UsernameConfig, expiration time.Time, credential Credential) (username string, err error) {
+	defer func(now time.Time) {
+		metrics.MeasureSince([]string{"database", "CreateUser"}, now)
+		metrics.MeasureSince([]string{"database", mw.typeStr, "CreateUser"}, now)
+
+		if err != nil {
+			metrics.IncrCounter([]string{"database", "CreateUser", "error"}, 1)
+			metrics.IncrCounter([]string{"database", mw.typeStr, "CreateUser", "error"}, 1)
+		}
+	}(time.Now())
+
+	metrics.IncrCounter([]string{"database", "CreateUser"}, 1)
+	metrics.IncrCounter([]string{"database", mw.typeStr, "CreateUser"}, 1)
+	return CreateUserWithCredential(ctx, mw.next, statements, usernameConfig, expiration, credential)
+}
+
 func (mw *databaseMetricsMiddleware) RenewUser(ctx context.Context, statements Statements, username string, expiration time.Time) (err error) {
 	defer func(now time.Time) {
 		metrics.MeasureSince([]string{"database", "RenewUser"}, now)
@@ -228,6 +253,11 @@ func (mw *DatabaseErrorSanitizerMiddleware) CreateUser(ctx context.Context, stat
 	return username, password, mw.sanitize(err)
 }
 
+func (mw *DatabaseErrorSanitizerMiddleware) CreateUserWithCredential(ctx context.Context, statements Statements, usernameConfig UsernameConfig, expiration time.Time, credential Credential) (username string, err error) {
+	username, err = CreateUserWithCredential(ctx, mw.next, statements, usernameConfig, expiration, credential)
+	return username, mw.sanitize(err)
+}
+
 func (mw *DatabaseErrorSanitizerMiddleware) RenewUser(ctx context.Context, statements Statements, username string, expiration time.Time) (err error) {
 	return mw.sanitize(mw.next.RenewUser(ctx, statements, username, expiration))
 }
@@ -49,6 +49,19 @@ func (s *gRPCServer) CreateUser(ctx context.Context, req *CreateUserRequest) (*C
 	}, err
 }
 
+func (s *gRPCServer) CreateUserWithCredential(ctx context.Context, req *CreateUserWithCredentialRequest) (*CreateUserResponse, error) {
+	e, err := ptypes.Timestamp(req.Expiration)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := CreateUserWithCredential(ctx, s.impl, *req.Statements, *req.UsernameConfig, e, *req.Credential)
+
+	return &CreateUserResponse{
+		Username: u,
+	}, err
+}
+
 func (s *gRPCServer) RenewUser(ctx context.Context, req *RenewUserRequest) (*Empty, error) {
 	e, err := ptypes.Timestamp(req.Expiration)
 	if err != nil {
@@ -160,6 +173,40 @@ func (c *gRPCClient) CreateUser(ctx context.Context, statements Statements, user
 	return resp.Username, resp.Password, err
 }
 
+func (c *gRPCClient) CreateUserWithCredential(ctx context.Context, statements Statements, usernameConfig UsernameConfig, expiration time.Time, credential Credential) (username string, err error) {
+	t, err := ptypes.TimestampProto(expiration)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	quitCh := pluginutil.CtxCancelIfCanceled(cancel, c.doneCtx)
+	defer close(quitCh)
+	defer cancel()
+
+	resp, err := c.client.CreateUserWithCredential(ctx, &CreateUserWithCredentialRequest{
+		Statements:     &statements,
+		UsernameConfig: &usernameConfig,
+		Expiration:     t,
+		Credential:     &credential,
+	})
+	if err != nil {
+		// Plugins built before credential types cannot create such users
+		grpcStatus, ok := status.FromError(err)
+		if ok && (grpcStatus.Code() == codes.Unimplemented || grpcStatus.Message() == ErrCredentialTypeUnsupported.Error()) {
+			return "", ErrCredentialTypeUnsupported
+		}
+
+		if c.doneCtx.Err() != nil {
+			return "", ErrPluginShutdown
+		}
+
+		return "", err
+	}
+
+	return resp.Username, nil
+}
+
 func (c *gRPCClient) RenewUser(ctx context.Context, statements Statements, username string, expiration time.Time) error {
 	t, err := ptypes.TimestampProto(expiration)
 	if err != nil {
@@ -29,6 +29,12 @@ func (ds *databasePluginRPCServer) CreateUser(args *CreateUserRequestRPC, resp *
 	return err
 }
 
+func (ds *databasePluginRPCServer) CreateUserWithCredential(args *CreateUserWithCredentialRequestRPC, resp *CreateUserResponse) error {
+	var err error
+	resp.Username, err = CreateUserWithCredential(context.Background(), ds.impl, args.Statements, args.UsernameConfig, args.Expiration, args.Credential)
+	return err
+}
+
 func (ds *databasePluginRPCServer) RenewUser(args *RenewUserRequestRPC, _ *struct{}) error {
 	err := ds.impl.RenewUser(context.Background(), args.Statements, args.Username, args.Expiration)
 	return err
@@ -96,6 +102,23 @@ func (dr *databasePluginRPCClient) CreateUser(_ context.Context, statements Stat
 	return resp.Username, resp.Password, err
 }
 
+func (dr *databasePluginRPCClient) CreateUserWithCredential(_ context.Context, statements Statements, usernameConfig UsernameConfig, expiration time.Time, credential Credential) (username string, err error) {
+	req := CreateUserWithCredentialRequestRPC{
+		Statements:     statements,
+		UsernameConfig: usernameConfig,
+		Expiration:     expiration,
+		Credential:     credential,
+	}
+
+	var resp CreateUserResponse
+	err = dr.client.Call("Plugin.CreateUserWithCredential", req, &resp)
+	if err != nil && (err.Error() == ErrCredentialTypeUnsupported.Error() || strings.Contains(err.Error(), "can't find method Plugin.CreateUserWithCredential")) {
+		return "", ErrCredentialTypeUnsupported
+	}
+
+	return resp.Username, err
+}
+
 func (dr *databasePluginRPCClient) RenewUser(_ context.Context, statements Statements, username string, expiration time.Time) error {
 	req := RenewUserRequestRPC{
 		Statements: statements,
@@ -181,6 +204,13 @@ type CreateUserRequestRPC struct {
 	Expiration     time.Time
 }
 
+type CreateUserWithCredentialRequestRPC struct {
+	Statements     Statements
+	UsernameConfig UsernameConfig
+	Expiration     time.Time
+	Credential     Credential
+}
+
 type RenewUserRequestRPC struct {
 	Statements Statements
 	Username   string
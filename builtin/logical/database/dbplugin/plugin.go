@@ -2,6 +2,7 @@ package dbplugin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/rpc"
 	"time"
@@ -30,6 +31,38 @@ type Database interface {
 	Initialize(ctx context.Context, config map[string]interface{}, verifyConnection bool) (err error)
 }
 
+// The types of credentials database users can be created with. Users are
+// created with a password by default.
+const (
+	CredentialTypePassword          = "password"
+	CredentialTypeRSAPrivateKey     = "rsa_private_key"
+	CredentialTypeClientCertificate = "client_certificate"
+)
+
+// ErrCredentialTypeUnsupported is returned when creating a user with a
+// credential type the database does not support.
+var ErrCredentialTypeUnsupported = errors.New("database does not support this credential type")
+
+// CredentialDatabase is implemented by the databases which can create users
+// authenticating with another credential than a password, such as a key pair
+// or a client certificate, or with a password generated by Vault. The
+// credential holds the public key or the password the user must be registered
+// with.
+type CredentialDatabase interface {
+	CreateUserWithCredential(ctx context.Context, statements Statements, usernameConfig UsernameConfig, expiration time.Time, credential Credential) (username string, err error)
+}
+
+// CreateUserWithCredential creates a user of the database authenticating with
+// the given credential, returning ErrCredentialTypeUnsupported if the database
+// cannot.
+func CreateUserWithCredential(ctx context.Context, db Database, statements Statements, usernameConfig UsernameConfig, expiration time.Time, credential Credential) (string, error) {
+	credDB, ok := db.(CredentialDatabase)
+	if !ok {
+		return "", ErrCredentialTypeUnsupported
+	}
+	return credDB.CreateUserWithCredential(ctx, statements, usernameConfig, expiration, credential)
+}
+
 // PluginFactory is used to build plugin database types. It wraps the database
 // object in a logging and metrics middleware.
 func PluginFactory(ctx context.Context, pluginName string, sys pluginutil.LookRunnerUtil, logger log.Logger) (Database, error) {
@@ -36,6 +36,18 @@ func (m *mockPlugin) CreateUser(_ context.Context, statements dbplugin.Statement
 
 	return usernameConf.DisplayName, "test", nil
 }
+func (m *mockPlugin) CreateUserWithCredential(_ context.Context, statements dbplugin.Statements, usernameConf dbplugin.UsernameConfig, expiration time.Time, credential dbplugin.Credential) (username string, err error) {
+	if credential.Type != dbplugin.CredentialTypeRSAPrivateKey {
+		return "", dbplugin.ErrCredentialTypeUnsupported
+	}
+	if usernameConf.DisplayName == "" || expiration.IsZero() || credential.PublicKey == "" {
+		return "", errors.New("err")
+	}
+
+	m.users[usernameConf.DisplayName] = []string{credential.PublicKey}
+
+	return usernameConf.DisplayName, nil
+}
 func (m *mockPlugin) RenewUser(_ context.Context, statements dbplugin.Statements, username string, expiration time.Time) error {
 	err := errors.New("err")
 	if username == "" || expiration.IsZero() {
@@ -207,6 +219,49 @@ func TestPlugin_CreateUser(t *testing.T) {
 	}
 }
 
+func TestPlugin_CreateUserWithCredential(t *testing.T) {
+	cluster, sys := getCluster(t)
+	defer cluster.Cleanup()
+
+	db, err := dbplugin.PluginFactory(context.Background(), "test-plugin", sys, log.NewNullLogger())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer db.Close()
+
+	connectionDetails := map[string]interface{}{
+		"test": 1,
+	}
+
+	_, err = db.Init(context.Background(), connectionDetails, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	usernameConf := dbplugin.UsernameConfig{
+		DisplayName: "test",
+		RoleName:    "test",
+	}
+
+	us, err := dbplugin.CreateUserWithCredential(context.Background(), db, dbplugin.Statements{}, usernameConf, time.Now().Add(time.Minute), dbplugin.Credential{
+		Type:      dbplugin.CredentialTypeRSAPrivateKey,
+		PublicKey: "public key",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if us != "test" {
+		t.Fatal("expected username to be 'test'")
+	}
+
+	_, err = dbplugin.CreateUserWithCredential(context.Background(), db, dbplugin.Statements{}, usernameConf, time.Now().Add(time.Minute), dbplugin.Credential{
+		Type: dbplugin.CredentialTypeClientCertificate,
+	})
+	if err != dbplugin.ErrCredentialTypeUnsupported {
+		t.Fatalf("expected the credential type to be unsupported, got: %v", err)
+	}
+}
+
 func TestPlugin_RenewUser(t *testing.T) {
 	cluster, sys := getCluster(t)
 	defer cluster.Cleanup()
@@ -347,6 +402,49 @@ func TestPlugin_NetRPC_CreateUser(t *testing.T) {
 	}
 }
 
+func TestPlugin_NetRPC_CreateUserWithCredential(t *testing.T) {
+	cluster, sys := getCluster(t)
+	defer cluster.Cleanup()
+
+	db, err := dbplugin.PluginFactory(context.Background(), "test-plugin-netRPC", sys, log.NewNullLogger())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer db.Close()
+
+	connectionDetails := map[string]interface{}{
+		"test": 1,
+	}
+
+	_, err = db.Init(context.Background(), connectionDetails, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	usernameConf := dbplugin.UsernameConfig{
+		DisplayName: "test",
+		RoleName:    "test",
+	}
+
+	us, err := dbplugin.CreateUserWithCredential(context.Background(), db, dbplugin.Statements{}, usernameConf, time.Now().Add(time.Minute), dbplugin.Credential{
+		Type:      dbplugin.CredentialTypeRSAPrivateKey,
+		PublicKey: "public key",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if us != "test" {
+		t.Fatal("expected username to be 'test'")
+	}
+
+	_, err = dbplugin.CreateUserWithCredential(context.Background(), db, dbplugin.Statements{}, usernameConf, time.Now().Add(time.Minute), dbplugin.Credential{
+		Type: dbplugin.CredentialTypeClientCertificate,
+	})
+	if err != dbplugin.ErrCredentialTypeUnsupported {
+		t.Fatalf("expected the credential type to be unsupported, got: %v", err)
+	}
+}
+
 func TestPlugin_NetRPC_RenewUser(t *testing.T) {
 	cluster, sys := getCluster(t)
 	defer cluster.Cleanup()
@@ -6,9 +6,9 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/fatih/structs"
-	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -29,6 +29,16 @@ type DatabaseConfig struct {
 	AllowedRoles      []string               `json:"allowed_roles" structs:"allowed_roles" mapstructure:"allowed_roles"`
 
 	RootCredentialsRotateStatements []string `json:"root_credentials_rotate_statements" structs:"root_credentials_rotate_statements" mapstructure:"root_credentials_rotate_statements"`
+
+	// PasswordPolicy is the name of the password policy Vault generates the
+	// passwords of the users with. If empty, the plugin generates them.
+	PasswordPolicy string `json:"password_policy" structs:"password_policy" mapstructure:"password_policy"`
+
+	// HealthCheckInterval is how often the connection is verified, never if
+	// zero. The plugin is restarted after HealthCheckFailureThreshold
+	// consecutive failures.
+	HealthCheckInterval         time.Duration `json:"health_check_interval" structs:"-" mapstructure:"health_check_interval"`
+	HealthCheckFailureThreshold int           `json:"health_check_failure_threshold" structs:"-" mapstructure:"health_check_failure_threshold"`
 }
 
 // pathResetConnection configures a path to reset a plugin.
@@ -113,6 +123,27 @@ func pathConfigurePluginConnection(b *databaseBackend) *framework.Path {
 				page for more information on support and formatting for this 
 				parameter.`,
 			},
+
+			"password_policy": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The name of the password policy the passwords of
+				the users are generated with. If not set, the plugin generates
+				them.`,
+			},
+
+			"health_check_interval": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `How often the connection to the database is
+				verified in the background, re-establishing it if broken.
+				Disabled if zero, the default.`,
+			},
+
+			"health_check_failure_threshold": &framework.FieldSchema{
+				Type:    framework.TypeInt,
+				Default: defaultHealthCheckFailureThreshold,
+				Description: `The number of consecutive failed health checks
+				after which the plugin is restarted. Defaults to 3.`,
+			},
 		},
 
 		ExistenceCheck: b.connectionExistenceCheck(),
@@ -201,9 +232,12 @@ func (b *databaseBackend) connectionReadHandler() framework.OperationFunc {
 
 		delete(config.ConnectionDetails, "password")
 
-		return &logical.Response{
+		resp := &logical.Response{
 			Data: structs.New(config).Map(),
-		}, nil
+		}
+		resp.Data["health_check_interval"] = int64(config.HealthCheckInterval.Seconds())
+		resp.Data["health_check_failure_threshold"] = config.healthCheckFailureThreshold()
+		return resp, nil
 	}
 }
 
@@ -273,6 +307,30 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 			config.RootCredentialsRotateStatements = data.Get("root_rotation_statements").([]string)
 		}
 
+		if passwordPolicyRaw, ok := data.GetOk("password_policy"); ok {
+			config.PasswordPolicy = passwordPolicyRaw.(string)
+		}
+		if config.PasswordPolicy != "" {
+			if _, err := logical.GeneratePasswordFromPolicy(ctx, b.System(), config.PasswordPolicy); err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid password_policy: %s", err)), nil
+			}
+		}
+
+		if healthCheckIntervalRaw, ok := data.GetOk("health_check_interval"); ok {
+			config.HealthCheckInterval = time.Duration(healthCheckIntervalRaw.(int)) * time.Second
+		}
+		if healthCheckFailureThresholdRaw, ok := data.GetOk("health_check_failure_threshold"); ok {
+			config.HealthCheckFailureThreshold = healthCheckFailureThresholdRaw.(int)
+		} else if req.Operation == logical.CreateOperation || config.HealthCheckFailureThreshold == 0 {
+			config.HealthCheckFailureThreshold = data.Get("health_check_failure_threshold").(int)
+		}
+		if config.HealthCheckInterval < 0 {
+			return logical.ErrorResponse("health_check_interval cannot be negative"), nil
+		}
+		if config.HealthCheckFailureThreshold < 1 {
+			return logical.ErrorResponse("health_check_failure_threshold must be at least 1"), nil
+		}
+
 		// Remove these entries from the data before we store it keyed under
 		// ConnectionDetails.
 		delete(data.Raw, "name")
@@ -280,6 +338,9 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		delete(data.Raw, "allowed_roles")
 		delete(data.Raw, "verify_connection")
 		delete(data.Raw, "root_rotation_statements")
+		delete(data.Raw, "password_policy")
+		delete(data.Raw, "health_check_interval")
+		delete(data.Raw, "health_check_failure_threshold")
 
 		// Create a database plugin and initialize it.
 		db, err := dbplugin.PluginFactory(ctx, config.PluginName, b.System(), b.logger)
@@ -313,16 +374,12 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		// Close and remove the old connection
 		b.clearConnection(name)
 
-		id, err := uuid.GenerateUUID()
+		instance, err := b.newPluginInstance(name, db, config)
 		if err != nil {
+			db.Close()
 			return nil, err
 		}
-
-		b.connections[name] = &dbPluginInstance{
-			Database: db,
-			name:     name,
-			id:       id,
-		}
+		b.connections[name] = instance
 
 		// Store it
 		entry, err = logical.StorageEntryJSON(fmt.Sprintf("config/%s", name), config)
@@ -369,6 +426,18 @@ accepts:
 	* "verify_connection" (default: true) - A boolean value denoting if the plugin should verify
 	   it is able to connect to the database using the provided connection
        details.
+
+	* "password_policy" (default: "") - The name of the password policy the
+	   passwords of the users are generated with instead of by the plugin.
+	   The plugin must support creating users with a given password.
+
+	* "health_check_interval" (default: 0) - How often the connection is
+	   verified in the background, re-establishing it if broken, so that a
+	   dead connection is not first discovered by a credential request. The
+	   health of the connection is read from "status/<name>".
+
+	* "health_check_failure_threshold" (default: 3) - The number of
+	   consecutive failed health checks after which the plugin is restarted.
 `
 
 const pathResetConnectionHelpSyn = `
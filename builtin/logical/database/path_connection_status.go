@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathConnectionStatus(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: fmt.Sprintf("status/%s", framework.GenericNameRegex("name")),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of this database connection",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathConnectionStatusRead(),
+		},
+
+		HelpSynopsis:    pathConnectionStatusHelpSyn,
+		HelpDescription: pathConnectionStatusHelpDesc,
+	}
+}
+
+// pathConnectionStatusRead returns the outcome of the health checks of the
+// connection
+func (b *databaseBackend) pathConnectionStatusRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+		if name == "" {
+			return logical.ErrorResponse(respErrEmptyName), nil
+		}
+
+		config, err := b.DatabaseConfig(ctx, req.Storage, name)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
+		respData := map[string]interface{}{
+			"health_check_interval":          int64(config.HealthCheckInterval.Seconds()),
+			"health_check_failure_threshold": config.healthCheckFailureThreshold(),
+			"connected":                      false,
+			"status":                         "unknown",
+		}
+
+		b.RLock()
+		db, ok := b.connections[name]
+		b.RUnlock()
+		if !ok {
+			return &logical.Response{
+				Data: respData,
+			}, nil
+		}
+		respData["connected"] = true
+
+		db.health.RLock()
+		defer db.health.RUnlock()
+		respData["consecutive_failures"] = db.health.consecutiveFailures
+		respData["reconnections"] = db.health.reconnections
+		respData["last_error"] = db.health.lastError
+		if !db.health.lastCheck.IsZero() {
+			respData["last_check"] = db.health.lastCheck.Format(time.RFC3339Nano)
+			switch {
+			case db.health.consecutiveFailures == 0:
+				respData["status"] = "healthy"
+			case db.health.consecutiveFailures < config.healthCheckFailureThreshold():
+				respData["status"] = "degraded"
+			default:
+				respData["status"] = "unhealthy"
+			}
+		}
+
+		return &logical.Response{
+			Data: respData,
+		}, nil
+	}
+}
+
+const pathConnectionStatusHelpSyn = `
+Read the health of a database connection.
+`
+
+const pathConnectionStatusHelpDesc = `
+This path returns the outcome of the background health checks of the database
+connection, enabled with "health_check_interval". The status is "healthy" if
+the last check succeeded, "degraded" after failed checks, and "unhealthy" once
+"health_check_failure_threshold" consecutive checks failed, after which the
+plugin is restarted. It is "unknown" until the connection is checked, and
+"connected" is false while no plugin runs for the connection, such as before
+its first use.
+`
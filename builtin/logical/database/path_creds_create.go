@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
@@ -76,18 +77,53 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 			DisplayName: req.DisplayName,
 			RoleName:    name,
 		}
+		if role.UsernameEntityName && req.EntityName != "" {
+			usernameConfig.DisplayName = req.EntityName
+		}
 
 		// Create the user
-		username, password, err := db.CreateUser(ctx, role.Statements, usernameConfig, expiration)
+		var username string
+		var respData map[string]interface{}
+		switch role.credentialType() {
+		case dbplugin.CredentialTypePassword:
+			var password string
+			if dbConfig.PasswordPolicy == "" {
+				username, password, err = db.CreateUser(ctx, role.Statements, usernameConfig, expiration)
+			} else {
+				password, err = logical.GeneratePasswordFromPolicy(ctx, b.System(), dbConfig.PasswordPolicy)
+				if err != nil {
+					return nil, errwrap.Wrapf("failed to generate password: {{err}}", err)
+				}
+				username, err = dbplugin.CreateUserWithCredential(ctx, db.Database, role.Statements, usernameConfig, expiration, dbplugin.Credential{
+					Type:     dbplugin.CredentialTypePassword,
+					Password: password,
+				})
+				if err == dbplugin.ErrCredentialTypeUnsupported {
+					return logical.ErrorResponse(fmt.Sprintf("the plugin of database %q does not support password policies", role.DBName)), nil
+				}
+			}
+			respData = map[string]interface{}{
+				"username": username,
+				"password": password,
+			}
+		default:
+			// Client certificates stay valid as long as the lease can be
+			// renewed
+			maxTTL := role.MaxTTL
+			if maxTTL == 0 {
+				maxTTL = b.System().MaxLeaseTTL()
+			}
+			username, respData, err = createUserWithCredential(ctx, db.Database, role, usernameConfig, expiration, time.Now().Add(maxTTL))
+		}
+		if err == dbplugin.ErrCredentialTypeUnsupported {
+			return logical.ErrorResponse(fmt.Sprintf("the plugin of database %q does not support the %s credential type", role.DBName, role.CredentialType)), nil
+		}
 		if err != nil {
 			b.CloseIfShutdown(db, err)
 			return nil, err
 		}
 
-		resp := b.Secret(SecretCredsType).Response(map[string]interface{}{
-			"username": username,
-			"password": password,
-		}, map[string]interface{}{
+		resp := b.Secret(SecretCredsType).Response(respData, map[string]interface{}{
 			"username":              username,
 			"role":                  name,
 			"db_name":               role.DBName,
@@ -72,6 +72,32 @@ func pathRoles(b *databaseBackend) *framework.Path {
 				Type:        framework.TypeDurationSecond,
 				Description: "Maximum time a credential is valid for",
 			},
+
+			"username_entity_name": {
+				Type: framework.TypeBool,
+				Description: `If set, the name of the identity entity
+				of the requesting token is used in generated usernames
+				instead of the display name of the token. Tokens without
+				an entity keep using their display name.`,
+			},
+
+			"credential_type": {
+				Type:    framework.TypeString,
+				Default: dbplugin.CredentialTypePassword,
+				Description: `The type of credential the users are created
+				with: "password", "rsa_private_key" or "client_certificate".
+				The database plugin must support the types other than
+				"password".`,
+			},
+
+			"credential_config": {
+				Type: framework.TypeKVPairs,
+				Description: `The configuration of the credential type.
+				"key_bits" sets the size of the RSA keys generated, 2048 by
+				default. With "client_certificate", "ca_cert" and
+				"ca_private_key" set the PEM-encoded CA signing the client
+				certificates.`,
+			},
 		},
 
 		ExistenceCheck: b.pathRoleExistenceCheck(),
@@ -128,6 +154,9 @@ func (b *databaseBackend) pathRoleRead() framework.OperationFunc {
 				"renew_statements":      role.Statements.Renewal,
 				"default_ttl":           role.DefaultTTL.Seconds(),
 				"max_ttl":               role.MaxTTL.Seconds(),
+				"username_entity_name":  role.UsernameEntityName,
+				"credential_type":       role.credentialType(),
+				"credential_config":     role.publicCredentialConfig(),
 			},
 		}, nil
 	}
@@ -185,6 +214,31 @@ func (b *databaseBackend) pathRoleCreateUpdate() framework.OperationFunc {
 			}
 		}
 
+		if usernameEntityNameRaw, ok := data.GetOk("username_entity_name"); ok {
+			role.UsernameEntityName = usernameEntityNameRaw.(bool)
+		}
+
+		// Credentials
+		{
+			previousType := role.credentialType()
+			if credentialTypeRaw, ok := data.GetOk("credential_type"); ok {
+				role.CredentialType = credentialTypeRaw.(string)
+			} else if req.Operation == logical.CreateOperation {
+				role.CredentialType = data.Get("credential_type").(string)
+			}
+			if credentialConfigRaw, ok := data.GetOk("credential_config"); ok {
+				role.CredentialConfig = credentialConfigRaw.(map[string]string)
+			} else if role.credentialType() != previousType {
+				// The configuration of another type does not carry over
+				role.CredentialConfig = nil
+			}
+			credentialConfig, err := validateCredentialConfig(role.credentialType(), role.CredentialConfig)
+			if err != nil {
+				return logical.ErrorResponse(err.Error()), nil
+			}
+			role.CredentialConfig = credentialConfig
+		}
+
 		// Statements
 		{
 			if creationStmtsRaw, ok := data.GetOk("creation_statements"); ok {
@@ -230,6 +284,33 @@ type roleEntry struct {
 	Statements dbplugin.Statements `json:"statements"`
 	DefaultTTL time.Duration       `json:"default_ttl"`
 	MaxTTL     time.Duration       `json:"max_ttl"`
+
+	// UsernameEntityName uses the entity name of the requester in usernames
+	UsernameEntityName bool `json:"username_entity_name"`
+
+	// CredentialType is the type of credential users are created with, a
+	// password if empty
+	CredentialType   string            `json:"credential_type"`
+	CredentialConfig map[string]string `json:"credential_config"`
+}
+
+func (r *roleEntry) credentialType() string {
+	if r.CredentialType == "" {
+		return dbplugin.CredentialTypePassword
+	}
+	return r.CredentialType
+}
+
+// publicCredentialConfig returns the credential configuration without the
+// private key of its CA
+func (r *roleEntry) publicCredentialConfig() map[string]string {
+	config := make(map[string]string, len(r.CredentialConfig))
+	for k, v := range r.CredentialConfig {
+		if k != "ca_private_key" {
+			config[k] = v
+		}
+	}
+	return config
 }
 
 const pathRoleHelpSyn = `
@@ -271,6 +352,14 @@ database plugin:
 	REVOKE USAGE ON SCHEMA public FROM {{name}};
 	DROP ROLE IF EXISTS {{name}};
 
+The "credential_type" parameter sets what users authenticate with. By default,
+they are created with a random password. With "rsa_private_key", an RSA key
+pair is generated for each user instead, and the creation statements register
+its public key, substituted for "{{public_key}}" as PEM. With
+"client_certificate", a client certificate is also issued for the username
+with the CA set in "credential_config". The private key is returned with the
+credentials. Only some database plugins support these types.
+
 The "renew_statements" parameter customizes the statement string used to renew a
 user.
 The "rollback_statements' parameter customizes the statement string used to
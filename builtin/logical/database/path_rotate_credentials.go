@@ -68,6 +68,7 @@ func (b *databaseBackend) pathRotateCredentialsUpdate() framework.OperationFunc
 
 		// Close the plugin
 		db.closed = true
+		close(db.stopCh)
 		if err := db.Database.Close(); err != nil {
 			b.Logger().Error("error closing the database plugin connection", "err", err)
 		}
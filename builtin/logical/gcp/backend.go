@@ -1,4 +1,4 @@
-package gcpsecrets
+package gcp
 
 import (
 	"context"
@@ -21,7 +21,12 @@ type backend struct {
 
 	iamResources iamutil.IamResourceParser
 
-	rolesetLock sync.Mutex
+	// iamAdminClient creates the IAM admin client used by static accounts,
+	// so that tests can point it at a fake IAM API
+	iamAdminClient func(context.Context, logical.Storage) (*iam.Service, error)
+
+	rolesetLock       sync.Mutex
+	staticAccountLock sync.Mutex
 }
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -34,7 +39,8 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 
 func Backend() *backend {
 	var b = backend{
-		iamResources: iamutil.GetEnabledIamResources(),
+		iamResources:   iamutil.GetEnabledIamResources(),
+		iamAdminClient: newIamAdmin,
 	}
 
 	b.Backend = &framework.Backend{
@@ -45,11 +51,13 @@ func Backend() *backend {
 			},
 			SealWrapStorage: []string{
 				"config",
+				"static-account/",
 			},
 		},
 
 		Paths: framework.PathAppend(
 			pathsRoleSet(&b),
+			pathsStaticAccount(&b),
 			[]*framework.Path{
 				pathConfig(&b),
 				pathSecretAccessToken(&b),
@@ -112,4 +120,9 @@ revoked at the end of the lease.
 After mounting this backend, credentials to generate IAM keys must
 be configured with the "config/" endpoints and policies must be
 written using the "roles/" endpoints before any keys can be generated.
+
+Existing service accounts can also be registered with the
+"static-account/" endpoints, in which case Vault rotates their keys
+and generates access tokens or keys for them without managing the
+accounts or their IAM policies.
 `
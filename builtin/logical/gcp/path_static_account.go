@@ -0,0 +1,507 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-gcp-common/gcputil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathsStaticAccount(b *backend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: fmt.Sprintf("static-account/%s", framework.GenericNameRegex("name")),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Required. Name of the static account.",
+				},
+				"service_account_email": {
+					Type:        framework.TypeString,
+					Description: "Required. Email of the existing service account bound to this static account.",
+				},
+				"secret_type": {
+					Type:        framework.TypeString,
+					Description: fmt.Sprintf("Type of secret generated for this static account. Defaults to '%s'", SecretTypeAccessToken),
+					Default:     SecretTypeAccessToken,
+				},
+				"token_scopes": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: `List of OAuth scopes to assign to access tokens generated under this static account`,
+				},
+				"key_retention": {
+					Type:        framework.TypeInt,
+					Description: fmt.Sprintf("Number of keys created by Vault to keep for the service account on rotation, between 1 and %d. Defaults to %d", maxStaticAccountKeyRetention, defaultStaticAccountKeyRetention),
+					Default:     defaultStaticAccountKeyRetention,
+				},
+			},
+			ExistenceCheck: b.pathStaticAccountExistenceCheck,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.DeleteOperation: b.pathStaticAccountDelete,
+				logical.ReadOperation:   b.pathStaticAccountRead,
+				logical.CreateOperation: b.pathStaticAccountCreateUpdate,
+				logical.UpdateOperation: b.pathStaticAccountCreateUpdate,
+			},
+			HelpSynopsis:    pathStaticAccountHelpSyn,
+			HelpDescription: pathStaticAccountHelpDesc,
+		},
+		// Path to rotate the key of a static account
+		{
+			Pattern: fmt.Sprintf("static-account/%s/rotate-key", framework.GenericNameRegex("name")),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the static account.",
+				},
+			},
+			ExistenceCheck: b.pathStaticAccountExistenceCheck,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.pathStaticAccountRotateKey,
+			},
+			HelpSynopsis:    pathStaticAccountRotateKeyHelpSyn,
+			HelpDescription: pathStaticAccountRotateKeyHelpDesc,
+		},
+		// Paths to generate secrets under a static account
+		{
+			Pattern: fmt.Sprintf("static-account/%s/key", framework.GenericNameRegex("name")),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Required. Name of the static account.",
+				},
+			},
+			ExistenceCheck: b.pathStaticAccountExistenceCheck,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.pathStaticAccountKey,
+				logical.UpdateOperation: b.pathStaticAccountKey,
+			},
+			HelpSynopsis:    pathStaticAccountKeyHelpSyn,
+			HelpDescription: pathStaticAccountKeyHelpDesc,
+		},
+		{
+			Pattern: fmt.Sprintf("static-account/%s/token", framework.GenericNameRegex("name")),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Required. Name of the static account.",
+				},
+			},
+			ExistenceCheck: b.pathStaticAccountExistenceCheck,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.pathStaticAccountToken,
+				logical.UpdateOperation: b.pathStaticAccountToken,
+			},
+			HelpSynopsis:    pathStaticAccountTokenHelpSyn,
+			HelpDescription: pathStaticAccountTokenHelpDesc,
+		},
+		// Paths for listing static accounts
+		{
+			Pattern: "static-accounts/?",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.pathStaticAccountList,
+			},
+
+			HelpSynopsis:    pathListStaticAccountHelpSyn,
+			HelpDescription: pathListStaticAccountHelpDesc,
+		},
+		{
+			Pattern: "static-account/?",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.pathStaticAccountList,
+			},
+
+			HelpSynopsis:    pathListStaticAccountHelpSyn,
+			HelpDescription: pathListStaticAccountHelpDesc,
+		},
+	}
+}
+
+func (b *backend) pathStaticAccountExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	nameRaw, ok := d.GetOk("name")
+	if !ok {
+		return false, errors.New("static account name is required")
+	}
+
+	sa, err := getStaticAccount(nameRaw.(string), ctx, req.Storage)
+	if err != nil {
+		return false, err
+	}
+
+	return sa != nil, nil
+}
+
+func (b *backend) pathStaticAccountRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	nameRaw, ok := d.GetOk("name")
+	if !ok {
+		return logical.ErrorResponse("name is required"), nil
+	}
+
+	sa, err := getStaticAccount(nameRaw.(string), ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if sa == nil {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(sa.Keys))
+	for _, k := range sa.Keys {
+		keys = append(keys, k.Name)
+	}
+
+	data := map[string]interface{}{
+		"secret_type":   sa.SecretType,
+		"key_retention": sa.KeyRetention,
+		"keys":          keys,
+	}
+
+	if sa.AccountId != nil {
+		data["service_account_email"] = sa.AccountId.EmailOrId
+		data["service_account_project"] = sa.AccountId.Project
+	}
+
+	if sa.SecretType == SecretTypeAccessToken {
+		data["token_scopes"] = sa.TokenScopes
+	}
+
+	if k := sa.currentKey(); k != nil {
+		data["last_rotated"] = k.CreatedTime.Format(time.RFC3339)
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+func (b *backend) pathStaticAccountDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	nameRaw, ok := d.GetOk("name")
+	if !ok {
+		return logical.ErrorResponse("name is required"), nil
+	}
+	name := nameRaw.(string)
+
+	b.staticAccountLock.Lock()
+	defer b.staticAccountLock.Unlock()
+
+	sa, err := getStaticAccount(name, ctx, req.Storage)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("unable to get static account %s: {{err}}", name), err)
+	}
+	if sa == nil {
+		return nil, nil
+	}
+
+	walIds := make([]string, 0, len(sa.Keys))
+	for _, k := range sa.Keys {
+		walId, err := framework.PutWAL(ctx, req.Storage, walTypeStaticAccountKey, &walStaticAccountKey{
+			StaticAccount: name,
+			KeyName:       k.Name,
+		})
+		if err != nil {
+			tryDeleteWALs(ctx, req.Storage, walIds...)
+			return nil, errwrap.Wrapf("unable to create WAL entry to clean up service account key: {{err}}", err)
+		}
+		walIds = append(walIds, walId)
+	}
+
+	if err := req.Storage.Delete(ctx, fmt.Sprintf("%s/%s", staticAccountStoragePrefix, name)); err != nil {
+		tryDeleteWALs(ctx, req.Storage, walIds...)
+		return nil, err
+	}
+
+	// Clean up the keys created by Vault, the service account is left as is.
+	iamAdmin, err := b.iamAdminClient(ctx, req.Storage)
+	if err != nil {
+		return &logical.Response{
+			Warnings: []string{fmt.Sprintf("unable to delete keys of service account (WAL entries to clean-up later have been added): %v", err)},
+		}, nil
+	}
+
+	var warnings []string
+	for i, k := range sa.Keys {
+		if err := deleteServiceAccountKey(iamAdmin, k.Name); err != nil {
+			warnings = append(warnings, fmt.Sprintf("unable to delete key '%s' (WAL entry to clean-up later has been added): %v", k.Name, err))
+			continue
+		}
+		tryDeleteWALs(ctx, req.Storage, walIds[i])
+	}
+
+	if len(warnings) > 0 {
+		return &logical.Response{Warnings: warnings}, nil
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathStaticAccountCreateUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var warnings []string
+	nameRaw, ok := d.GetOk("name")
+	if !ok {
+		return logical.ErrorResponse("name is required"), nil
+	}
+	name := nameRaw.(string)
+
+	b.staticAccountLock.Lock()
+	defer b.staticAccountLock.Unlock()
+
+	sa, err := getStaticAccount(name, ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	isCreate := sa == nil
+	if isCreate {
+		sa = &StaticAccount{
+			Name: name,
+		}
+	}
+
+	// Secret type
+	if isCreate {
+		secretType := d.Get("secret_type").(string)
+		switch secretType {
+		case SecretTypeKey, SecretTypeAccessToken:
+			sa.SecretType = secretType
+		default:
+			return logical.ErrorResponse(fmt.Sprintf(`invalid "secret_type" value: "%s"`, secretType)), nil
+		}
+	} else {
+		secretTypeRaw, ok := d.GetOk("secret_type")
+		if ok && sa.SecretType != secretTypeRaw.(string) {
+			return logical.ErrorResponse("cannot change secret_type after static account creation"), nil
+		}
+	}
+
+	// Service account
+	emailRaw, ok := d.GetOk("service_account_email")
+	if ok {
+		if len(emailRaw.(string)) == 0 {
+			return logical.ErrorResponse("given empty service_account_email"), nil
+		}
+		if !isCreate && sa.AccountId.EmailOrId != emailRaw.(string) {
+			return logical.ErrorResponse(fmt.Sprintf("cannot change service account for existing static account (old: %s, new: %s)", sa.AccountId.EmailOrId, emailRaw)), nil
+		}
+	} else if isCreate {
+		return logical.ErrorResponse("service_account_email argument is required for new static account"), nil
+	}
+
+	// Scopes
+	scopesRaw, ok := d.GetOk("token_scopes")
+	if ok {
+		if sa.SecretType != SecretTypeAccessToken {
+			warnings = append(warnings, fmt.Sprintf("ignoring token_scopes, only valid for '%s' secret type static account", SecretTypeAccessToken))
+		} else {
+			sa.TokenScopes = scopesRaw.([]string)
+			if len(sa.TokenScopes) == 0 {
+				return logical.ErrorResponse("cannot provide empty token_scopes"), nil
+			}
+		}
+	} else if isCreate && sa.SecretType == SecretTypeAccessToken {
+		return logical.ErrorResponse("token_scopes must be provided for creating access token static account"), nil
+	}
+
+	// Key retention
+	_, ok = d.GetOk("key_retention")
+	if ok || isCreate {
+		retention := d.Get("key_retention").(int)
+		if retention < 1 || retention > maxStaticAccountKeyRetention {
+			return logical.ErrorResponse(fmt.Sprintf("key_retention must be between 1 and %d", maxStaticAccountKeyRetention)), nil
+		}
+		if !isCreate && retention < len(sa.Keys) {
+			warnings = append(warnings, "keys beyond the new key_retention will be deleted on the next key rotation")
+		}
+		sa.KeyRetention = retention
+	}
+
+	if !isCreate {
+		// Just save static account with updated metadata:
+		if err := sa.save(ctx, req.Storage); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if len(warnings) > 0 {
+			return &logical.Response{Warnings: warnings}, nil
+		}
+		return nil, nil
+	}
+
+	// Verify the service account exists, resolving its project.
+	iamAdmin, err := b.iamAdminClient(ctx, req.Storage)
+	if err != nil {
+		return nil, errwrap.Wrapf("could not create IAM Admin client: {{err}}", err)
+	}
+	sa.AccountId = &gcputil.ServiceAccountId{
+		Project:   "-",
+		EmailOrId: emailRaw.(string),
+	}
+	account, err := sa.getServiceAccount(iamAdmin)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	sa.AccountId = &gcputil.ServiceAccountId{
+		Project:   account.ProjectId,
+		EmailOrId: account.Email,
+	}
+
+	// The static account is saved once its first key has been created.
+	keyWarns, err := b.saveStaticAccountWithNewKey(ctx, req.Storage, sa)
+	warnings = append(warnings, keyWarns...)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	} else if len(warnings) > 0 {
+		return &logical.Response{Warnings: warnings}, nil
+	}
+	return nil, nil
+}
+
+func (b *backend) pathStaticAccountList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	accounts, err := req.Storage.List(ctx, staticAccountStoragePrefix+"/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(accounts), nil
+}
+
+func (b *backend) pathStaticAccountRotateKey(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	nameRaw, ok := d.GetOk("name")
+	if !ok {
+		return logical.ErrorResponse("name is required"), nil
+	}
+	name := nameRaw.(string)
+
+	b.staticAccountLock.Lock()
+	defer b.staticAccountLock.Unlock()
+
+	sa, err := getStaticAccount(name, ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if sa == nil {
+		return logical.ErrorResponse(fmt.Sprintf("static account '%s' not found", name)), nil
+	}
+
+	warnings, err := b.saveStaticAccountWithNewKey(ctx, req.Storage, sa)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	} else if len(warnings) > 0 {
+		return &logical.Response{Warnings: warnings}, nil
+	}
+	return nil, nil
+}
+
+func (b *backend) pathStaticAccountKey(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	sa, err := getStaticAccount(name, ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if sa == nil {
+		return logical.ErrorResponse(fmt.Sprintf("static account '%s' does not exists", name)), nil
+	}
+
+	if sa.SecretType != SecretTypeKey {
+		return logical.ErrorResponse(fmt.Sprintf("static account '%s' cannot return service account keys (has secret type %s)", name, sa.SecretType)), nil
+	}
+
+	key := sa.currentKey()
+	if key == nil {
+		return logical.ErrorResponse(fmt.Sprintf("static account has no service account key, must be rotated (path static-account/%s/rotate-key) before returning secrets", name)), nil
+	}
+
+	// The key is shared by all callers until the next rotation, so it is
+	// returned without a lease.
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"private_key_data": key.PrivateKeyData,
+			"key_algorithm":    key.KeyAlgorithm,
+			"key_type":         key.PrivateKeyType,
+			"key_name":         key.Name,
+			"valid_after_time": key.ValidAfterTime,
+		},
+	}, nil
+}
+
+func (b *backend) pathStaticAccountToken(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	sa, err := getStaticAccount(name, ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if sa == nil {
+		return logical.ErrorResponse(fmt.Sprintf("static account '%s' does not exists", name)), nil
+	}
+
+	if sa.SecretType != SecretTypeAccessToken {
+		return logical.ErrorResponse(fmt.Sprintf("static account '%s' cannot generate access tokens (has secret type %s)", name, sa.SecretType)), nil
+	}
+
+	key := sa.currentKey()
+	if key == nil {
+		return logical.ErrorResponse(fmt.Sprintf("static account has no service account key, must be rotated (path static-account/%s/rotate-key) before generating new secrets", name)), nil
+	}
+
+	iamC, err := b.iamAdminClient(ctx, req.Storage)
+	if err != nil {
+		return nil, errwrap.Wrapf("could not create IAM Admin client: {{err}}", err)
+	}
+
+	tokenGen := &TokenGenerator{
+		KeyName:    key.Name,
+		B64KeyJSON: key.PrivateKeyData,
+		Scopes:     sa.TokenScopes,
+	}
+	token, err := tokenGen.getAccessToken(ctx, iamC)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("could not generate token: %v", err)), nil
+	}
+
+	secretD := map[string]interface{}{
+		"token": token.AccessToken,
+	}
+	internalD := map[string]interface{}{
+		"access_token":   token.AccessToken,
+		"key_name":       key.Name,
+		"static_account": sa.Name,
+	}
+	resp := b.Secret(SecretTypeAccessToken).Response(secretD, internalD)
+	resp.Secret.TTL = token.Expiry.Sub(time.Now())
+	resp.Secret.Renewable = false
+
+	return resp, nil
+}
+
+const pathStaticAccountHelpSyn = `Read/write static accounts, which bind existing service accounts to generate credentials.`
+const pathListStaticAccountHelpSyn = `List existing static accounts.`
+const pathStaticAccountRotateKeyHelpSyn = `Rotate the key of the service account bound to a static account`
+const pathStaticAccountKeyHelpSyn = `Return the current service account key of a static account`
+const pathStaticAccountTokenHelpSyn = `Generate an OAuth2 access token under a static account`
+
+const pathStaticAccountHelpDesc = `
+This path allows you to create static accounts, which bind an existing
+service account to a name. Vault does not create, delete or change the
+IAM policies of the service account: it only creates keys for it, and
+deletes the keys it created.
+
+A key is created when the static account is created. Secrets (either
+access tokens or the current key, depending on "secret_type") are then
+generated from the current key until it is rotated.`
+const pathListStaticAccountHelpDesc = `List static accounts by name.`
+const pathStaticAccountRotateKeyHelpDesc = `
+This path allows you to rotate the key of the service account bound to
+a static account. A new key is created, and the oldest keys created by
+Vault beyond "key_retention" are deleted. Keys that were not created by
+Vault are never deleted.`
+const pathStaticAccountKeyHelpDesc = `
+This path returns the current key of a "service_account_key" static
+account. The key is not leased: it remains valid until it is deleted by
+a later rotation.`
+const pathStaticAccountTokenHelpDesc = `
+This path generates an OAuth2 access token from the current key of an
+"access_token" static account, with the scopes of the static account.
+Access tokens are not renewable.`
@@ -0,0 +1,418 @@
+package gcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"google.golang.org/api/iam/v1"
+)
+
+// fakeIam serves the parts of the IAM API used by static accounts, along
+// with an OAuth2 token endpoint for the keys it creates
+type fakeIam struct {
+	*httptest.Server
+
+	l           sync.Mutex
+	accounts    map[string]string
+	keys        map[string]bool
+	nextKey     int
+	failDeletes bool
+	privateKey  string
+}
+
+func newFakeIam(t *testing.T) *fakeIam {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIam{
+		accounts: map[string]string{
+			"sa1@test-project.iam.gserviceaccount.com": "test-project",
+		},
+		keys: make(map[string]bool),
+		privateKey: string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+		})),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+func (f *fakeIam) iamAdmin(context.Context, logical.Storage) (*iam.Service, error) {
+	svc, err := iam.New(f.Client())
+	if err != nil {
+		return nil, err
+	}
+	svc.BasePath = f.URL + "/"
+	return svc, nil
+}
+
+func (f *fakeIam) hasKey(name string) bool {
+	f.l.Lock()
+	defer f.l.Unlock()
+	return f.keys[name]
+}
+
+func (f *fakeIam) setFailDeletes(fail bool) {
+	f.l.Lock()
+	defer f.l.Unlock()
+	f.failDeletes = fail
+}
+
+// addKey creates a key for the account outside of Vault
+func (f *fakeIam) addKey(email string) string {
+	f.l.Lock()
+	defer f.l.Unlock()
+	f.nextKey++
+	name := fmt.Sprintf("projects/%s/serviceAccounts/%s/keys/key%d", f.accounts[email], email, f.nextKey)
+	f.keys[name] = true
+	return name
+}
+
+func (f *fakeIam) handle(w http.ResponseWriter, r *http.Request) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	respond := func(v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	fail := func(code int) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    code,
+				"message": http.StatusText(code),
+			},
+		})
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case r.URL.Path == "/token":
+		// The ID of the signing key is in the header of the JWT assertion
+		parts := strings.Split(r.FormValue("assertion"), ".")
+		header, err := base64.RawURLEncoding.DecodeString(parts[0])
+		if err != nil {
+			fail(http.StatusBadRequest)
+			return
+		}
+		var jwtHeader struct {
+			KeyID string `json:"kid"`
+		}
+		json.Unmarshal(header, &jwtHeader)
+		respond(map[string]interface{}{
+			"access_token": "token-" + jwtHeader.KeyID,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "projects/-/serviceAccounts/"):
+		email := strings.TrimPrefix(path, "projects/-/serviceAccounts/")
+		project, ok := f.accounts[email]
+		if !ok {
+			fail(http.StatusNotFound)
+			return
+		}
+		respond(&iam.ServiceAccount{
+			Name:      fmt.Sprintf("projects/%s/serviceAccounts/%s", project, email),
+			Email:     email,
+			ProjectId: project,
+		})
+
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/keys"):
+		f.nextKey++
+		name := fmt.Sprintf("%s/key%d", path, f.nextKey)
+		email := strings.Split(path, "/")[3]
+		keyJSON, _ := json.Marshal(map[string]string{
+			"type":           "service_account",
+			"client_email":   email,
+			"private_key_id": fmt.Sprintf("key%d", f.nextKey),
+			"private_key":    f.privateKey,
+			"token_uri":      f.URL + "/token",
+		})
+		f.keys[name] = true
+		respond(&iam.ServiceAccountKey{
+			Name:           name,
+			PrivateKeyData: base64.StdEncoding.EncodeToString(keyJSON),
+			KeyAlgorithm:   keyAlgorithmRSA2k,
+			PrivateKeyType: privateKeyTypeJson,
+		})
+
+	case r.Method == http.MethodGet && strings.Contains(path, "/keys/"):
+		if !f.keys[path] {
+			fail(http.StatusNotFound)
+			return
+		}
+		respond(&iam.ServiceAccountKey{Name: path})
+
+	case r.Method == http.MethodDelete && strings.Contains(path, "/keys/"):
+		if f.failDeletes {
+			fail(http.StatusInternalServerError)
+			return
+		}
+		if !f.keys[path] {
+			fail(http.StatusNotFound)
+			return
+		}
+		delete(f.keys, path)
+		respond(map[string]interface{}{})
+
+	default:
+		fail(http.StatusNotFound)
+	}
+}
+
+func getTestStaticAccountBackend(t *testing.T) (*backend, logical.Storage, *fakeIam) {
+	f := newFakeIam(t)
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	b.iamAdminClient = f.iamAdmin
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView, f
+}
+
+func handleStaticAccountRequest(t *testing.T, b *backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: op,
+		Path:      path,
+		Storage:   s,
+		Data:      data,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("%s %s: resp: %#v, err: %v", op, path, resp, err)
+	}
+	return resp
+}
+
+func staticAccountKeys(t *testing.T, b *backend, s logical.Storage, name string) []string {
+	t.Helper()
+	resp := handleStaticAccountRequest(t, b, s, logical.ReadOperation, "static-account/"+name, nil)
+	return resp.Data["keys"].([]string)
+}
+
+func TestStaticAccount_KeyRotation(t *testing.T) {
+	b, s, f := getTestStaticAccountBackend(t)
+	defer f.Close()
+
+	email := "sa1@test-project.iam.gserviceaccount.com"
+	userKey := f.addKey(email)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "static-account/missing",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"service_account_email": "missing@test-project.iam.gserviceaccount.com",
+			"secret_type":           SecretTypeKey,
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an unknown service account to be rejected: resp: %#v, err: %v", resp, err)
+	}
+
+	// A key is created along with the static account
+	handleStaticAccountRequest(t, b, s, logical.CreateOperation, "static-account/sa1", map[string]interface{}{
+		"service_account_email": email,
+		"secret_type":           SecretTypeKey,
+		"key_retention":         2,
+	})
+	resp = handleStaticAccountRequest(t, b, s, logical.ReadOperation, "static-account/sa1", nil)
+	if resp.Data["service_account_project"] != "test-project" || resp.Data["key_retention"] != 2 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	keys := resp.Data["keys"].([]string)
+	if len(keys) != 1 || !f.hasKey(keys[0]) {
+		t.Fatalf("bad: %#v", keys)
+	}
+	first := keys[0]
+
+	resp = handleStaticAccountRequest(t, b, s, logical.ReadOperation, "static-account/sa1/key", nil)
+	if resp.Data["key_name"] != first || resp.Secret != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Rotations keep the newest keys up to the retention
+	handleStaticAccountRequest(t, b, s, logical.UpdateOperation, "static-account/sa1/rotate-key", nil)
+	keys = staticAccountKeys(t, b, s, "sa1")
+	if len(keys) != 2 || keys[0] != first {
+		t.Fatalf("bad: %#v", keys)
+	}
+	second := keys[1]
+
+	handleStaticAccountRequest(t, b, s, logical.UpdateOperation, "static-account/sa1/rotate-key", nil)
+	keys = staticAccountKeys(t, b, s, "sa1")
+	if len(keys) != 2 || keys[0] != second {
+		t.Fatalf("bad: %#v", keys)
+	}
+	third := keys[1]
+	if f.hasKey(first) || !f.hasKey(second) || !f.hasKey(third) {
+		t.Fatalf("expected only the oldest key to be deleted")
+	}
+	resp = handleStaticAccountRequest(t, b, s, logical.ReadOperation, "static-account/sa1/key", nil)
+	if resp.Data["key_name"] != third {
+		t.Fatalf("expected the newest key, got %#v", resp.Data)
+	}
+
+	// Lowering the retention deletes the extra keys on the next rotation
+	resp = handleStaticAccountRequest(t, b, s, logical.UpdateOperation, "static-account/sa1", map[string]interface{}{
+		"key_retention": 1,
+	})
+	if resp == nil || len(resp.Warnings) != 1 {
+		t.Fatalf("expected a warning, got %#v", resp)
+	}
+	handleStaticAccountRequest(t, b, s, logical.UpdateOperation, "static-account/sa1/rotate-key", nil)
+	keys = staticAccountKeys(t, b, s, "sa1")
+	if len(keys) != 1 || f.hasKey(second) || f.hasKey(third) || !f.hasKey(keys[0]) {
+		t.Fatalf("bad: %#v", keys)
+	}
+
+	resp = handleStaticAccountRequest(t, b, s, logical.ListOperation, "static-accounts/", nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"sa1"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Deleting the static account deletes the keys Vault created, but not
+	// the keys created outside of Vault
+	handleStaticAccountRequest(t, b, s, logical.DeleteOperation, "static-account/sa1", nil)
+	if f.hasKey(keys[0]) {
+		t.Fatalf("expected the key of the static account to be deleted")
+	}
+	if !f.hasKey(userKey) {
+		t.Fatalf("expected the key created outside of Vault to remain")
+	}
+	resp = handleStaticAccountRequest(t, b, s, logical.ReadOperation, "static-account/sa1", nil)
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestStaticAccount_AccessToken(t *testing.T) {
+	b, s, f := getTestStaticAccountBackend(t)
+	defer f.Close()
+
+	handleStaticAccountRequest(t, b, s, logical.CreateOperation, "static-account/sa1", map[string]interface{}{
+		"service_account_email": "sa1@test-project.iam.gserviceaccount.com",
+		"token_scopes":          "https://www.googleapis.com/auth/cloud-platform",
+	})
+
+	// Tokens are generated with the current key
+	keys := staticAccountKeys(t, b, s, "sa1")
+	resp := handleStaticAccountRequest(t, b, s, logical.ReadOperation, "static-account/sa1/token", nil)
+	keyID := keys[0][strings.LastIndex(keys[0], "/")+1:]
+	if resp.Data["token"] != "token-"+keyID {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if resp.Secret == nil || resp.Secret.TTL <= 0 || resp.Secret.Renewable {
+		t.Fatalf("bad: %#v", resp.Secret)
+	}
+
+	handleStaticAccountRequest(t, b, s, logical.UpdateOperation, "static-account/sa1/rotate-key", nil)
+	keys = staticAccountKeys(t, b, s, "sa1")
+	resp = handleStaticAccountRequest(t, b, s, logical.ReadOperation, "static-account/sa1/token", nil)
+	keyID = keys[0][strings.LastIndex(keys[0], "/")+1:]
+	if resp.Data["token"] != "token-"+keyID {
+		t.Fatalf("expected a token of the rotated key, got %#v", resp.Data)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "static-account/sa1/key",
+		Storage:   s,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected access token static account to refuse keys: resp: %#v, err: %v", resp, err)
+	}
+}
+
+func TestStaticAccount_Rollback(t *testing.T) {
+	b, s, f := getTestStaticAccountBackend(t)
+	defer f.Close()
+	ctx := context.Background()
+
+	email := "sa1@test-project.iam.gserviceaccount.com"
+	handleStaticAccountRequest(t, b, s, logical.CreateOperation, "static-account/sa1", map[string]interface{}{
+		"service_account_email": email,
+		"secret_type":           SecretTypeKey,
+	})
+	old := staticAccountKeys(t, b, s, "sa1")[0]
+
+	// A key that can't be deleted on rotation is left to the rollback
+	f.setFailDeletes(true)
+	resp := handleStaticAccountRequest(t, b, s, logical.UpdateOperation, "static-account/sa1/rotate-key", nil)
+	if resp == nil || len(resp.Warnings) != 1 {
+		t.Fatalf("expected a warning, got %#v", resp)
+	}
+	f.setFailDeletes(false)
+	current := staticAccountKeys(t, b, s, "sa1")[0]
+	if !f.hasKey(old) || current == old {
+		t.Fatalf("expected the old key to remain until the rollback")
+	}
+
+	// So is a key that was created but never saved with the account, while
+	// the WAL entry of a key that was saved is stale
+	orphan := f.addKey(email)
+	for _, keyName := range []string{orphan, current} {
+		if _, err := framework.PutWAL(ctx, s, walTypeStaticAccountKey, &walStaticAccountKey{
+			StaticAccount: "sa1",
+			KeyName:       keyName,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := framework.ListWAL(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("expected 3 WAL entries, got %d", len(ids))
+	}
+	for _, id := range ids {
+		entry, err := framework.GetWAL(ctx, s, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Kind != walTypeStaticAccountKey {
+			t.Fatalf("bad: %#v", entry)
+		}
+		if err := b.walRollback(ctx, &logical.Request{Storage: s}, entry.Kind, entry.Data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if f.hasKey(old) || f.hasKey(orphan) {
+		t.Fatalf("expected the rollback to delete the keys no longer tracked")
+	}
+	if !f.hasKey(current) {
+		t.Fatalf("expected the rollback to leave the current key")
+	}
+
+	// Rolling back a key that is already gone succeeds
+	if err := b.walRollback(ctx, &logical.Request{Storage: s}, walTypeStaticAccountKey, map[string]interface{}{
+		"StaticAccount": "sa1",
+		"KeyName":       old,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
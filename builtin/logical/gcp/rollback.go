@@ -1,4 +1,4 @@
-package gcpsecrets
+package gcp
 
 import (
 	"context"
@@ -21,6 +21,8 @@ const (
 	walTypeAccount    = "account"
 	walTypeAccountKey = "account_key"
 	walTypeIamPolicy  = "iam_policy"
+
+	walTypeStaticAccountKey = "static_account_key"
 )
 
 func (b *backend) walRollback(ctx context.Context, req *logical.Request, kind string, data interface{}) error {
@@ -31,6 +33,8 @@ func (b *backend) walRollback(ctx context.Context, req *logical.Request, kind st
 		return b.serviceAccountKeyRollback(ctx, req, data)
 	case walTypeIamPolicy:
 		return b.serviceAccountPolicyRollback(ctx, req, data)
+	case walTypeStaticAccountKey:
+		return b.staticAccountKeyRollback(ctx, req, data)
 	default:
 		return fmt.Errorf("unknown type to rollback")
 	}
@@ -47,6 +51,11 @@ type walAccountKey struct {
 	KeyName            string
 }
 
+type walStaticAccountKey struct {
+	StaticAccount string
+	KeyName       string
+}
+
 type walIamPolicy struct {
 	RoleSet   string
 	AccountId gcputil.ServiceAccountId
@@ -200,6 +209,36 @@ func (b *backend) serviceAccountPolicyRollback(ctx context.Context, req *logical
 	return err
 }
 
+func (b *backend) staticAccountKeyRollback(ctx context.Context, req *logical.Request, data interface{}) error {
+	b.staticAccountLock.Lock()
+	defer b.staticAccountLock.Unlock()
+
+	var entry walStaticAccountKey
+	if err := mapstructure.Decode(data, &entry); err != nil {
+		return err
+	}
+	if entry.KeyName == "" {
+		return nil
+	}
+
+	// If key is still tracked by the static account, WAL entry was
+	// not deleted properly after a successful operation.
+	sa, err := getStaticAccount(entry.StaticAccount, ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	if sa != nil && sa.hasKey(entry.KeyName) {
+		return nil
+	}
+
+	iamC, err := b.iamAdminClient(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+	return deleteServiceAccountKey(iamC, entry.KeyName)
+}
+
 func (b *backend) deleteServiceAccount(ctx context.Context, iamAdmin *iam.Service, account *gcputil.ServiceAccountId) error {
 	if account == nil || account.EmailOrId == "" {
 		return nil
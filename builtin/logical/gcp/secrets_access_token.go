@@ -1,4 +1,4 @@
-package gcpsecrets
+package gcp
 
 import (
 	"context"
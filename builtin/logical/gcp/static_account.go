@@ -0,0 +1,220 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-gcp-common/gcputil"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"google.golang.org/api/iam/v1"
+)
+
+const (
+	staticAccountStoragePrefix = "static-account"
+
+	defaultStaticAccountKeyRetention = 1
+
+	// GCP allows at most 10 keys per service account
+	maxStaticAccountKeyRetention = 10
+)
+
+// StaticAccount binds an existing service account, which Vault does not
+// manage, to a name. Vault only creates and deletes keys for the account.
+type StaticAccount struct {
+	Name       string
+	SecretType string
+
+	AccountId    *gcputil.ServiceAccountId
+	TokenScopes  []string
+	KeyRetention int
+
+	// Keys created by Vault for the account, newest last
+	Keys []*StaticAccountKey
+}
+
+type StaticAccountKey struct {
+	Name           string
+	PrivateKeyData string
+	KeyAlgorithm   string
+	PrivateKeyType string
+	ValidAfterTime string
+	CreatedTime    time.Time
+}
+
+func (sa *StaticAccount) validate() error {
+	var err *multierror.Error
+	if sa.Name == "" {
+		err = multierror.Append(err, errors.New("static account name is empty"))
+	}
+
+	if sa.AccountId == nil {
+		err = multierror.Append(err, fmt.Errorf("static account should have service account associated"))
+	}
+
+	if sa.KeyRetention < 1 || sa.KeyRetention > maxStaticAccountKeyRetention {
+		err = multierror.Append(err, fmt.Errorf("static account key retention must be between 1 and %d", maxStaticAccountKeyRetention))
+	}
+
+	switch sa.SecretType {
+	case SecretTypeAccessToken:
+		if len(sa.TokenScopes) == 0 {
+			err = multierror.Append(err, fmt.Errorf("access token static account should have defined scopes"))
+		}
+	case SecretTypeKey:
+		break
+	default:
+		err = multierror.Append(err, fmt.Errorf("unknown secret type: %s", sa.SecretType))
+	}
+	return err.ErrorOrNil()
+}
+
+func (sa *StaticAccount) save(ctx context.Context, s logical.Storage) error {
+	if err := sa.validate(); err != nil {
+		return err
+	}
+
+	entry, err := logical.StorageEntryJSON(fmt.Sprintf("%s/%s", staticAccountStoragePrefix, sa.Name), sa)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
+
+// currentKey returns the newest key created for the account, or nil.
+func (sa *StaticAccount) currentKey() *StaticAccountKey {
+	if len(sa.Keys) == 0 {
+		return nil
+	}
+	return sa.Keys[len(sa.Keys)-1]
+}
+
+func (sa *StaticAccount) hasKey(keyName string) bool {
+	for _, k := range sa.Keys {
+		if k.Name == keyName {
+			return true
+		}
+	}
+	return false
+}
+
+func (sa *StaticAccount) getServiceAccount(iamAdmin *iam.Service) (*iam.ServiceAccount, error) {
+	if sa.AccountId == nil {
+		return nil, fmt.Errorf("static account '%s' is invalid, has no associated service account", sa.Name)
+	}
+
+	account, err := iamAdmin.Projects.ServiceAccounts.Get(sa.AccountId.ResourceName()).Do()
+	if err != nil {
+		return nil, fmt.Errorf("could not find service account: %v", err)
+	} else if account == nil {
+		return nil, fmt.Errorf("service account '%s' for static account '%s' was removed", sa.AccountId.EmailOrId, sa.Name)
+	}
+
+	return account, nil
+}
+
+// saveStaticAccountWithNewKey creates a new key for the service account of
+// the static account, then deletes the oldest keys Vault created beyond the
+// key retention of the account. Keys that were not created by Vault are never
+// deleted. The caller must hold the static account lock.
+func (b *backend) saveStaticAccountWithNewKey(ctx context.Context, s logical.Storage, sa *StaticAccount) (warnings []string, err error) {
+	iamAdmin, err := b.iamAdminClient(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := iamAdmin.Projects.ServiceAccounts.Keys.Create(sa.AccountId.ResourceName(),
+		&iam.CreateServiceAccountKeyRequest{
+			KeyAlgorithm:   keyAlgorithmRSA2k,
+			PrivateKeyType: privateKeyTypeJson,
+		}).Do()
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("unable to create key for service account '%s': {{err}}", sa.AccountId.ResourceName()), err)
+	}
+
+	// The key is cleaned up by rollback unless the account is saved with it.
+	newKeyWalId, err := framework.PutWAL(ctx, s, walTypeStaticAccountKey, &walStaticAccountKey{
+		StaticAccount: sa.Name,
+		KeyName:       key.Name,
+	})
+	if err != nil {
+		if delErr := deleteServiceAccountKey(iamAdmin, key.Name); delErr != nil {
+			err = multierror.Append(err, delErr)
+		}
+		return nil, errwrap.Wrapf("unable to create WAL entry for new service account key: {{err}}", err)
+	}
+
+	sa.Keys = append(sa.Keys, &StaticAccountKey{
+		Name:           key.Name,
+		PrivateKeyData: key.PrivateKeyData,
+		KeyAlgorithm:   key.KeyAlgorithm,
+		PrivateKeyType: key.PrivateKeyType,
+		ValidAfterTime: key.ValidAfterTime,
+		CreatedTime:    time.Now().UTC(),
+	})
+
+	var oldKeys []*StaticAccountKey
+	if len(sa.Keys) > sa.KeyRetention {
+		oldKeys = sa.Keys[:len(sa.Keys)-sa.KeyRetention]
+		sa.Keys = sa.Keys[len(sa.Keys)-sa.KeyRetention:]
+	}
+
+	oldKeyWalIds := make([]string, 0, len(oldKeys))
+	for _, k := range oldKeys {
+		walId, err := framework.PutWAL(ctx, s, walTypeStaticAccountKey, &walStaticAccountKey{
+			StaticAccount: sa.Name,
+			KeyName:       k.Name,
+		})
+		if err != nil {
+			tryDeleteWALs(ctx, s, oldKeyWalIds...)
+			return nil, errwrap.Wrapf("unable to create WAL entry for deleting old key: {{err}}", err)
+		}
+		oldKeyWalIds = append(oldKeyWalIds, walId)
+	}
+
+	if err := sa.save(ctx, s); err != nil {
+		tryDeleteWALs(ctx, s, oldKeyWalIds...)
+		return nil, err
+	}
+
+	// Delete WAL for cleaning up new key now that it's been saved.
+	tryDeleteWALs(ctx, s, newKeyWalId)
+	for i, k := range oldKeys {
+		if err := deleteServiceAccountKey(iamAdmin, k.Name); err != nil {
+			warnings = append(warnings, fmt.Sprintf("unable to delete old key '%s' (WAL entry to clean-up later has been added): %v", k.Name, err))
+			continue
+		}
+		tryDeleteWALs(ctx, s, oldKeyWalIds[i])
+	}
+
+	return warnings, nil
+}
+
+func deleteServiceAccountKey(iamAdmin *iam.Service, keyName string) error {
+	_, err := iamAdmin.Projects.ServiceAccounts.Keys.Delete(keyName).Do()
+	if err != nil && !isGoogleApi404Error(err) {
+		return errwrap.Wrapf("unable to delete service account key: {{err}}", err)
+	}
+	return nil
+}
+
+func getStaticAccount(name string, ctx context.Context, s logical.Storage) (*StaticAccount, error) {
+	entry, err := s.Get(ctx, fmt.Sprintf("%s/%s", staticAccountStoragePrefix, name))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	sa := &StaticAccount{}
+	if err := entry.DecodeJSON(sa); err != nil {
+		return nil, err
+	}
+	return sa, nil
+}
@@ -0,0 +1,70 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				configPath,
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfig(&b),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathCreds(&b),
+		},
+
+		Secrets: []*framework.Secret{
+			secretServiceAccountToken(&b),
+		},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+}
+
+// client returns a client of the API server of the configured cluster
+func (b *backend) client(ctx context.Context, s logical.Storage) (*kubeClient, error) {
+	conf, err := b.config(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, errNotConfigured
+	}
+	return newKubeClient(conf)
+}
+
+const backendHelp = `
+The kubernetes backend creates short-lived service account tokens for a
+Kubernetes cluster.
+
+Each role either issues tokens for an existing service account, or creates a
+service account for every lease, bound to an existing Role or ClusterRole or to
+one generated from the rules of the role. The objects a lease creates are
+deleted when it is revoked, and the tokens expire with their lease.
+`
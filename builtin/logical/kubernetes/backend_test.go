@@ -0,0 +1,454 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	logicaltest "github.com/hashicorp/vault/logical/testing"
+)
+
+// fakeAPIServer is an API server keeping the objects created through it
+type fakeAPIServer struct {
+	*httptest.Server
+
+	l       sync.Mutex
+	objects map[string]map[string]interface{}
+
+	// failPath makes the creation of objects under a path fail
+	failPath string
+}
+
+func newFakeAPIServer(t *testing.T) *fakeAPIServer {
+	s := &fakeAPIServer{
+		objects: make(map[string]map[string]interface{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer jwt" {
+			writeStatus(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		s.l.Lock()
+		defer s.l.Unlock()
+
+		switch r.Method {
+		case http.MethodPost:
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var obj map[string]interface{}
+			if err := json.Unmarshal(body, &obj); err != nil {
+				writeStatus(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			if strings.HasSuffix(r.URL.Path, "/token") {
+				if _, ok := s.objects[strings.TrimSuffix(r.URL.Path, "/token")]; !ok {
+					writeStatus(w, http.StatusNotFound, "serviceaccount not found")
+					return
+				}
+				spec := obj["spec"].(map[string]interface{})
+				expiration := time.Duration(spec["expirationSeconds"].(float64)) * time.Second
+				obj["status"] = map[string]interface{}{
+					"token":               "token-of-" + r.URL.Path,
+					"expirationTimestamp": time.Now().Add(expiration).Format(time.RFC3339),
+				}
+				json.NewEncoder(w).Encode(obj)
+				return
+			}
+
+			if s.failPath != "" && r.URL.Path == s.failPath {
+				writeStatus(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			name := obj["metadata"].(map[string]interface{})["name"].(string)
+			path := r.URL.Path + "/" + name
+			if _, ok := s.objects[path]; ok {
+				writeStatus(w, http.StatusConflict, "already exists")
+				return
+			}
+			s.objects[path] = obj
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+
+		case http.MethodDelete:
+			if _, ok := s.objects[r.URL.Path]; !ok {
+				writeStatus(w, http.StatusNotFound, "not found")
+				return
+			}
+			delete(s.objects, r.URL.Path)
+			writeStatus(w, http.StatusOK, "")
+
+		default:
+			writeStatus(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}))
+	return s
+}
+
+func writeStatus(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"kind":    "Status",
+		"code":    code,
+		"message": message,
+	})
+}
+
+// paths returns the sorted paths of the objects of the server
+func (s *fakeAPIServer) paths() []string {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	var paths []string
+	for path := range s.objects {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (s *fakeAPIServer) object(path string) map[string]interface{} {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.objects[path]
+}
+
+func TestBackend_config(t *testing.T) {
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepConfig(t, map[string]interface{}{
+				"service_account_jwt": "jwt",
+			}, true),
+			testAccStepConfig(t, map[string]interface{}{
+				"kubernetes_host":     "https://kubernetes",
+				"kubernetes_ca_cert":  "not a certificate",
+				"service_account_jwt": "jwt",
+			}, true),
+			testAccStepConfig(t, map[string]interface{}{
+				"kubernetes_host":     "https://kubernetes",
+				"service_account_jwt": "jwt",
+			}, false),
+			testAccStepReadConfig(t, "https://kubernetes"),
+		},
+	})
+}
+
+func TestBackend_roles(t *testing.T) {
+	steps := []logicaltest.TestStep{}
+	for _, data := range []map[string]interface{}{
+		// No Kubernetes role
+		{
+			"allowed_kubernetes_namespaces": "*",
+		},
+		// Two Kubernetes roles
+		{
+			"allowed_kubernetes_namespaces": "*",
+			"service_account_name":          "sa",
+			"kubernetes_role_name":          "role",
+		},
+		// No namespace
+		{
+			"service_account_name": "sa",
+		},
+		// Invalid role type
+		{
+			"allowed_kubernetes_namespaces": "*",
+			"kubernetes_role_name":          "role",
+			"kubernetes_role_type":          "Group",
+		},
+		// Invalid rules
+		{
+			"allowed_kubernetes_namespaces": "*",
+			"generated_role_rules":          "rules: [{resources: [pods]}]",
+		},
+		// ttl over max_ttl
+		{
+			"allowed_kubernetes_namespaces": "*",
+			"service_account_name":          "sa",
+			"ttl":                           "2h",
+			"max_ttl":                       "1h",
+		},
+	} {
+		steps = append(steps, testAccStepWriteRole(t, "bad", data, true))
+	}
+
+	steps = append(steps,
+		testAccStepWriteRole(t, "ci", map[string]interface{}{
+			"allowed_kubernetes_namespaces": "ci,staging",
+			"kubernetes_role_name":          "edit",
+			"kubernetes_role_type":          "clusterrole",
+			"ttl":                           "30m",
+		}, false),
+		testAccStepReadRole(t, "ci", map[string]interface{}{
+			"kubernetes_role_type": kindClusterRole,
+			"ttl":                  int64(1800),
+		}),
+		testAccStepListRoles(t, []string{"ci"}),
+		testAccStepDeleteRole(t, "ci"),
+		testAccStepReadRole(t, "ci", nil),
+	)
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps:   steps,
+	})
+}
+
+func TestBackend_creds(t *testing.T) {
+	server := newFakeAPIServer(t)
+	defer server.Close()
+
+	// An existing service account
+	server.objects["/api/v1/namespaces/ci/serviceaccounts/deployer"] = map[string]interface{}{}
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepConfig(t, map[string]interface{}{
+				"kubernetes_host":     server.URL,
+				"service_account_jwt": "jwt",
+			}, false),
+
+			testAccStepWriteRole(t, "existing", map[string]interface{}{
+				"allowed_kubernetes_namespaces": "ci",
+				"service_account_name":          "deployer",
+				"ttl":                           "1h",
+			}, false),
+			testAccStepCreds(t, "existing", nil, false, func(resp *logical.Response) error {
+				if resp.Data["service_account_token"] != "token-of-/api/v1/namespaces/ci/serviceaccounts/deployer/token" ||
+					resp.Data["service_account_namespace"] != "ci" {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				if resp.Secret.TTL > time.Hour || resp.Secret.TTL < 59*time.Minute || resp.Secret.Renewable {
+					return fmt.Errorf("bad: %#v", resp.Secret)
+				}
+				if paths := server.paths(); len(paths) != 1 {
+					return fmt.Errorf("bad: %v", paths)
+				}
+				return nil
+			}),
+			testAccStepCreds(t, "existing", map[string]interface{}{
+				"kubernetes_namespace": "default",
+			}, true, nil),
+			testAccStepCreds(t, "existing", map[string]interface{}{
+				"ttl": "5m",
+			}, true, nil),
+			testAccStepCreds(t, "existing", map[string]interface{}{
+				"cluster_role_binding": true,
+			}, true, nil),
+
+			// A generated Role
+			testAccStepWriteRole(t, "generated", map[string]interface{}{
+				"allowed_kubernetes_namespaces": "*",
+				"generated_role_rules": `
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+`,
+			}, false),
+			testAccStepCreds(t, "generated", nil, true, nil),
+			testAccStepCreds(t, "generated", map[string]interface{}{
+				"kubernetes_namespace": "app",
+				"ttl":                  "20m",
+			}, false, func(resp *logical.Response) error {
+				name := resp.Data["service_account_name"].(string)
+				if !strings.HasPrefix(name, "v-generated-") {
+					return fmt.Errorf("bad: %q", name)
+				}
+				if resp.Secret.TTL > 20*time.Minute || resp.Secret.TTL < 19*time.Minute {
+					return fmt.Errorf("bad: %v", resp.Secret.TTL)
+				}
+				sa := "/api/v1/namespaces/app/serviceaccounts/" + name
+				role := "/apis/rbac.authorization.k8s.io/v1/namespaces/app/roles/" + name
+				binding := "/apis/rbac.authorization.k8s.io/v1/namespaces/app/rolebindings/" + name
+				for _, path := range []string{sa, role, binding} {
+					if server.object(path) == nil {
+						return fmt.Errorf("%s was not created: %v", path, server.paths())
+					}
+				}
+				rules := server.object(role)["rules"].([]interface{})
+				if verbs := rules[0].(map[string]interface{})["verbs"].([]interface{}); len(verbs) != 2 {
+					return fmt.Errorf("bad: %#v", rules)
+				}
+				roleRef := server.object(binding)["roleRef"].(map[string]interface{})
+				if roleRef["kind"] != kindRole || roleRef["name"] != name {
+					return fmt.Errorf("bad: %#v", roleRef)
+				}
+				return nil
+			}),
+
+			// An existing ClusterRole, bound cluster-wide
+			testAccStepWriteRole(t, "cluster", map[string]interface{}{
+				"allowed_kubernetes_namespaces": "ci",
+				"kubernetes_role_name":          "view",
+				"kubernetes_role_type":          "ClusterRole",
+			}, false),
+			testAccStepCreds(t, "cluster", map[string]interface{}{
+				"cluster_role_binding": true,
+			}, false, func(resp *logical.Response) error {
+				name := resp.Data["service_account_name"].(string)
+				binding := "/apis/rbac.authorization.k8s.io/v1/clusterrolebindings/" + name
+				if server.object(binding) == nil {
+					return fmt.Errorf("%s was not created: %v", binding, server.paths())
+				}
+				roleRef := server.object(binding)["roleRef"].(map[string]interface{})
+				if roleRef["kind"] != kindClusterRole || roleRef["name"] != "view" {
+					return fmt.Errorf("bad: %#v", roleRef)
+				}
+
+				// Objects already deleted out of band don't fail the
+				// revocation
+				server.l.Lock()
+				delete(server.objects, binding)
+				server.l.Unlock()
+				return nil
+			}),
+
+			// The objects created before a failure are deleted
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "creds/generated",
+				Data: map[string]interface{}{
+					"kubernetes_namespace": "app",
+				},
+				PreFlight: func(req *logical.Request) error {
+					server.l.Lock()
+					defer server.l.Unlock()
+					server.failPath = "/apis/rbac.authorization.k8s.io/v1/namespaces/app/rolebindings"
+					return nil
+				},
+				ErrorOk: true,
+				Check: func(resp *logical.Response) error {
+					if resp != nil && resp.Secret != nil {
+						return fmt.Errorf("expected an error, got %#v", resp)
+					}
+					if paths := server.paths(); len(paths) != 5 {
+						return fmt.Errorf("the objects of the failed lease were not deleted: %v", paths)
+					}
+					return nil
+				},
+			},
+		},
+	})
+
+	// The leases were revoked at the end of the test case
+	if paths := server.paths(); len(paths) != 1 {
+		t.Fatalf("the objects of the leases were not deleted: %v", paths)
+	}
+}
+
+func testAccStepConfig(t *testing.T, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepReadConfig(t *testing.T, host string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "config",
+		Check: func(resp *logical.Response) error {
+			if resp == nil || resp.Data["kubernetes_host"] != host {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+			if _, ok := resp.Data["service_account_jwt"]; ok {
+				return fmt.Errorf("the JWT was returned")
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepWriteRole(t *testing.T, name string, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/" + name,
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+// testAccStepReadRole checks the given fields of a role, or that it doesn't
+// exist if expected is nil
+func testAccStepReadRole(t *testing.T, name string, expected map[string]interface{}) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "roles/" + name,
+		Check: func(resp *logical.Response) error {
+			if expected == nil {
+				if resp != nil {
+					return fmt.Errorf("bad: %#v", resp)
+				}
+				return nil
+			}
+			if resp == nil {
+				return fmt.Errorf("role %q not found", name)
+			}
+			for k, v := range expected {
+				if resp.Data[k] != v {
+					return fmt.Errorf("bad %s: %#v", k, resp.Data[k])
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepListRoles(t *testing.T, names []string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ListOperation,
+		Path:      "roles/",
+		Check: func(resp *logical.Response) error {
+			if resp == nil || !reflect.DeepEqual(resp.Data["keys"], names) {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepDeleteRole(t *testing.T, name string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.DeleteOperation,
+		Path:      "roles/" + name,
+	}
+}
+
+func testAccStepCreds(t *testing.T, role string, data map[string]interface{}, expectFail bool, check logicaltest.TestCheckFunc) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "creds/" + role,
+		Data:      data,
+		ErrorOk:   expectFail,
+		Check:     check,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
@@ -0,0 +1,302 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+)
+
+const (
+	kindRole               = "Role"
+	kindClusterRole        = "ClusterRole"
+	kindRoleBinding        = "RoleBinding"
+	kindClusterRoleBinding = "ClusterRoleBinding"
+
+	rbacGroup      = "rbac.authorization.k8s.io"
+	rbacAPIVersion = rbacGroup + "/v1"
+)
+
+// objectMeta is the metadata of the Kubernetes objects Vault creates
+type objectMeta struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type serviceAccount struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Metadata   objectMeta `json:"metadata"`
+}
+
+// policyRule is a rule of a Role or ClusterRole
+type policyRule struct {
+	APIGroups       []string `json:"apiGroups,omitempty"`
+	Resources       []string `json:"resources,omitempty"`
+	ResourceNames   []string `json:"resourceNames,omitempty"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+	Verbs           []string `json:"verbs"`
+}
+
+type rbacRole struct {
+	APIVersion string       `json:"apiVersion"`
+	Kind       string       `json:"kind"`
+	Metadata   objectMeta   `json:"metadata"`
+	Rules      []policyRule `json:"rules"`
+}
+
+type subject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type roleRef struct {
+	APIGroup string `json:"apiGroup"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+}
+
+type roleBinding struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Metadata   objectMeta `json:"metadata"`
+	Subjects   []subject  `json:"subjects"`
+	RoleRef    roleRef    `json:"roleRef"`
+}
+
+type tokenRequest struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Spec       tokenRequestSpec   `json:"spec"`
+	Status     tokenRequestStatus `json:"status"`
+}
+
+type tokenRequestSpec struct {
+	Audiences         []string `json:"audiences,omitempty"`
+	ExpirationSeconds int64    `json:"expirationSeconds"`
+}
+
+type tokenRequestStatus struct {
+	Token               string    `json:"token"`
+	ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+}
+
+// apiError is an error returned by the API server
+type apiError struct {
+	Code    int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("kubernetes API returned %d: %s", e.Code, e.Message)
+}
+
+// isNotFound returns whether err is the API server reporting that an object
+// does not exist
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.Code == http.StatusNotFound
+}
+
+// kubeClient calls the API server of a cluster with the token of the service
+// account Vault is configured with
+type kubeClient struct {
+	host   string
+	token  string
+	client *http.Client
+}
+
+func newKubeClient(conf *kubeConfig) (*kubeClient, error) {
+	client := cleanhttp.DefaultClient()
+
+	// If we have a CA cert build the TLSConfig
+	if len(conf.CACert) > 0 {
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM([]byte(conf.CACert)) {
+			return nil, errors.New("no certificate found in the configured CA certificate")
+		}
+		client.Transport.(*http.Transport).TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    certPool,
+		}
+	}
+
+	return &kubeClient{
+		host:   strings.TrimSuffix(conf.Host, "/"),
+		token:  conf.ServiceAccountJWT,
+		client: client,
+	}, nil
+}
+
+func serviceAccountPath(namespace, name string) string {
+	return fmt.Sprintf("/api/v1/namespaces/%s/%s", url.PathEscape(namespace), resourcePath("serviceaccounts", name))
+}
+
+// rbacPath returns the path of an RBAC object; the namespace is ignored for
+// the kinds that are cluster-wide
+func rbacPath(kind, namespace, name string) string {
+	var resource string
+	switch kind {
+	case kindRole:
+		resource = "roles"
+	case kindClusterRole:
+		resource = "clusterroles"
+	case kindRoleBinding:
+		resource = "rolebindings"
+	case kindClusterRoleBinding:
+		resource = "clusterrolebindings"
+	}
+
+	if kind == kindClusterRole || kind == kindClusterRoleBinding {
+		return fmt.Sprintf("/apis/%s/%s", rbacAPIVersion, resourcePath(resource, name))
+	}
+	return fmt.Sprintf("/apis/%s/namespaces/%s/%s", rbacAPIVersion, url.PathEscape(namespace), resourcePath(resource, name))
+}
+
+func resourcePath(resource, name string) string {
+	if name == "" {
+		return resource
+	}
+	return resource + "/" + url.PathEscape(name)
+}
+
+// createServiceAccount creates a service account and returns its path
+func (c *kubeClient) createServiceAccount(ctx context.Context, meta objectMeta) (string, error) {
+	sa := &serviceAccount{
+		APIVersion: "v1",
+		Kind:       "ServiceAccount",
+		Metadata:   meta,
+	}
+	if err := c.do(ctx, http.MethodPost, serviceAccountPath(meta.Namespace, ""), sa, nil); err != nil {
+		return "", err
+	}
+	return serviceAccountPath(meta.Namespace, meta.Name), nil
+}
+
+// createRole creates a Role or ClusterRole and returns its path
+func (c *kubeClient) createRole(ctx context.Context, kind string, meta objectMeta, rules []policyRule) (string, error) {
+	role := &rbacRole{
+		APIVersion: rbacAPIVersion,
+		Kind:       kind,
+		Metadata:   meta,
+		Rules:      rules,
+	}
+	if err := c.do(ctx, http.MethodPost, rbacPath(kind, meta.Namespace, ""), role, nil); err != nil {
+		return "", err
+	}
+	return rbacPath(kind, meta.Namespace, meta.Name), nil
+}
+
+// createBinding creates a RoleBinding or ClusterRoleBinding of a service
+// account to a role and returns its path
+func (c *kubeClient) createBinding(ctx context.Context, kind string, meta objectMeta, sa subject, ref roleRef) (string, error) {
+	binding := &roleBinding{
+		APIVersion: rbacAPIVersion,
+		Kind:       kind,
+		Metadata:   meta,
+		Subjects:   []subject{sa},
+		RoleRef:    ref,
+	}
+	if err := c.do(ctx, http.MethodPost, rbacPath(kind, meta.Namespace, ""), binding, nil); err != nil {
+		return "", err
+	}
+	return rbacPath(kind, meta.Namespace, meta.Name), nil
+}
+
+// createToken requests a token of a service account which expires after ttl
+func (c *kubeClient) createToken(ctx context.Context, namespace, name string, ttl time.Duration, audiences []string) (*tokenRequestStatus, error) {
+	in := &tokenRequest{
+		APIVersion: "authentication.k8s.io/v1",
+		Kind:       "TokenRequest",
+		Spec: tokenRequestSpec{
+			Audiences:         audiences,
+			ExpirationSeconds: int64(ttl.Seconds()),
+		},
+	}
+	var out tokenRequest
+	if err := c.do(ctx, http.MethodPost, serviceAccountPath(namespace, name)+"/token", in, &out); err != nil {
+		return nil, err
+	}
+	if out.Status.Token == "" {
+		return nil, errors.New("kubernetes API returned no token")
+	}
+	return &out.Status, nil
+}
+
+// delete deletes the object at a path; an object that doesn't exist is
+// not an error
+func (c *kubeClient) delete(ctx context.Context, path string) error {
+	err := c.do(ctx, http.MethodDelete, path, nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *kubeClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.host+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(c.token))
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Errors are returned as a Status object, whose message is more
+		// useful than the body
+		var status struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(respBody, &status); err != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(respBody))
+		}
+		return &apiError{
+			Code:    resp.StatusCode,
+			Message: status.Message,
+		}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return errwrap.Wrapf("error decoding kubernetes API response: {{err}}", err)
+		}
+	}
+	return nil
+}
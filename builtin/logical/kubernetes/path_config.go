@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const configPath = "config"
+
+var errNotConfigured = errors.New("the kubernetes backend is not configured")
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: configPath,
+		Fields: map[string]*framework.FieldSchema{
+			"kubernetes_host": {
+				Type:        framework.TypeString,
+				Description: "Host of the Kubernetes API server, such as https://192.168.99.100:8443.",
+			},
+
+			"kubernetes_ca_cert": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded CA certificate the TLS certificate of the API server is verified with. The system roots are used if unset.",
+			},
+
+			"service_account_jwt": {
+				Type:        framework.TypeString,
+				Description: "The JWT of the service account Vault calls the API server with. It must be allowed to manage service accounts, their tokens and the RBAC objects of the roles.",
+			},
+		},
+
+		ExistenceCheck: b.pathConfigExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.CreateOperation: b.pathConfigWrite,
+			logical.UpdateOperation: b.pathConfigWrite,
+			logical.DeleteOperation: b.pathConfigDelete,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+// config returns the configuration, or nil if the backend is not configured
+func (b *backend) config(ctx context.Context, s logical.Storage) (*kubeConfig, error) {
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	conf := &kubeConfig{}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, errwrap.Wrapf("error reading kubernetes configuration: {{err}}", err)
+	}
+	return conf, nil
+}
+
+func (b *backend) pathConfigExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return false, err
+	}
+	return conf != nil, nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, nil
+	}
+
+	// The JWT is never returned
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"kubernetes_host":    conf.Host,
+			"kubernetes_ca_cert": conf.CACert,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &kubeConfig{}
+	}
+
+	if v, ok := data.GetOk("kubernetes_host"); ok {
+		conf.Host = v.(string)
+	}
+	if v, ok := data.GetOk("kubernetes_ca_cert"); ok {
+		conf.CACert = v.(string)
+	}
+	if v, ok := data.GetOk("service_account_jwt"); ok {
+		conf.ServiceAccountJWT = v.(string)
+	}
+
+	switch {
+	case conf.Host == "":
+		return logical.ErrorResponse("kubernetes_host is required"), nil
+	case conf.ServiceAccountJWT == "":
+		return logical.ErrorResponse("service_account_jwt is required"), nil
+	}
+	if _, err := newKubeClient(conf); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type kubeConfig struct {
+	Host              string `json:"kubernetes_host"`
+	CACert            string `json:"kubernetes_ca_cert"`
+	ServiceAccountJWT string `json:"service_account_jwt"`
+}
+
+const pathConfigHelpSyn = `
+Configure the Kubernetes cluster tokens are created for.
+`
+
+const pathConfigHelpDesc = `
+This path configures the API server of the cluster and the JWT of the service
+account Vault calls it with. The service account must be allowed to create and
+delete service accounts, to create their tokens, and to create and delete the
+Roles, ClusterRoles and bindings the roles of this backend use.
+`
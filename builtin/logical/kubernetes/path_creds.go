@@ -0,0 +1,224 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// minTokenTTL is the shortest expiration the API server accepts for tokens
+const minTokenTTL = 10 * time.Minute
+
+// invalidNameChars matches what can't appear in the names of the objects
+// created for leases
+var invalidNameChars = regexp.MustCompile("[^a-z0-9-]+")
+
+func pathCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"kubernetes_namespace": {
+				Type:        framework.TypeString,
+				Description: "The namespace of the token. Can be omitted if the role allows a single namespace.",
+			},
+
+			"cluster_role_binding": {
+				Type:        framework.TypeBool,
+				Description: "Bind the service account with a ClusterRoleBinding rather than a RoleBinding, giving it the permissions of the ClusterRole of the role in all namespaces.",
+			},
+
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The TTL of the token. Defaults to the TTL of the role. Can't be less than 10 minutes.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCredsWrite,
+		},
+
+		HelpSynopsis:    pathCredsHelpSyn,
+		HelpDescription: pathCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathCredsWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", roleName)), nil
+	}
+
+	namespace := data.Get("kubernetes_namespace").(string)
+	if namespace == "" && len(role.AllowedNamespaces) == 1 && role.AllowedNamespaces[0] != "*" {
+		namespace = role.AllowedNamespaces[0]
+	}
+	switch {
+	case namespace == "":
+		return logical.ErrorResponse("kubernetes_namespace is required"), nil
+	case !role.allowsNamespace(namespace):
+		return logical.ErrorResponse(fmt.Sprintf("namespace %q is not allowed by role %q", namespace, roleName)), nil
+	}
+
+	clusterRoleBinding := data.Get("cluster_role_binding").(bool)
+	if clusterRoleBinding && (role.ServiceAccountName != "" || role.KubernetesRoleType != kindClusterRole) {
+		return logical.ErrorResponse("cluster_role_binding requires a role binding service accounts to a ClusterRole"), nil
+	}
+
+	ttl := role.TTL
+	if v, ok := data.GetOk("ttl"); ok {
+		ttl = time.Duration(v.(int)) * time.Second
+	}
+	ttl, _, warnings := framework.LeaseTTLs(b.System(), ttl, role.MaxTTL)
+	if ttl < minTokenTTL {
+		return logical.ErrorResponse(fmt.Sprintf("ttl can't be less than %s", minTokenTTL)), nil
+	}
+
+	client, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Objects created for the lease, deleted in reverse order when it is
+	// revoked or if a later step fails
+	var objects []string
+	cleanup := func() {
+		if err := b.deleteObjects(ctx, client, objects); err != nil {
+			b.Logger().Error("error deleting the objects of a failed lease", "error", err)
+		}
+	}
+
+	serviceAccountName := role.ServiceAccountName
+	if serviceAccountName == "" {
+		meta := objectMeta{
+			Name:      generateName(roleName),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "vault",
+			},
+			Annotations: map[string]string{
+				"vault.hashicorp.com/role":         roleName,
+				"vault.hashicorp.com/display-name": req.DisplayName,
+			},
+		}
+		serviceAccountName = meta.Name
+
+		path, err := client.createServiceAccount(ctx, meta)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, path)
+
+		ref := roleRef{
+			APIGroup: rbacGroup,
+			Kind:     role.KubernetesRoleType,
+			Name:     role.KubernetesRoleName,
+		}
+		if role.GeneratedRoleRules != "" {
+			rules, err := parseRules(role.GeneratedRoleRules)
+			if err != nil {
+				cleanup()
+				return nil, err
+			}
+			path, err := client.createRole(ctx, role.KubernetesRoleType, scopedMeta(meta, role.KubernetesRoleType), rules)
+			if err != nil {
+				cleanup()
+				return nil, err
+			}
+			objects = append(objects, path)
+			ref.Name = meta.Name
+		}
+
+		bindingKind := kindRoleBinding
+		if clusterRoleBinding {
+			bindingKind = kindClusterRoleBinding
+		}
+		sa := subject{
+			Kind:      "ServiceAccount",
+			Name:      meta.Name,
+			Namespace: namespace,
+		}
+		path, err = client.createBinding(ctx, bindingKind, scopedMeta(meta, bindingKind), sa, ref)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		objects = append(objects, path)
+	}
+
+	token, err := client.createToken(ctx, namespace, serviceAccountName, ttl, role.Audiences)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	// The API server may shorten the expiration of tokens
+	if !token.ExpirationTimestamp.IsZero() {
+		if remaining := time.Until(token.ExpirationTimestamp); remaining < ttl {
+			ttl = remaining
+		}
+	}
+
+	resp := b.Secret(SecretServiceAccountTokenType).Response(map[string]interface{}{
+		"service_account_token":     token.Token,
+		"service_account_name":      serviceAccountName,
+		"service_account_namespace": namespace,
+	}, map[string]interface{}{
+		"role":    roleName,
+		"objects": objects,
+	})
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = ttl
+	resp.Secret.Renewable = false
+	resp.Warnings = warnings
+
+	return resp, nil
+}
+
+// generateName returns a unique name for the objects of a lease of a role
+func generateName(roleName string) string {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		id = fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	prefix := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(roleName), "-"), "-")
+	if max := 63 - len("v--") - 8; len(prefix) > max {
+		prefix = strings.TrimRight(prefix[:max], "-")
+	}
+	return fmt.Sprintf("v-%s-%s", prefix, id[:8])
+}
+
+// scopedMeta returns the metadata of an object of a kind, which has no
+// namespace if the kind is cluster-wide
+func scopedMeta(meta objectMeta, kind string) objectMeta {
+	if kind == kindClusterRole || kind == kindClusterRoleBinding {
+		meta.Namespace = ""
+	}
+	return meta
+}
+
+const pathCredsHelpSyn = `
+Create a service account token for a role.
+`
+
+const pathCredsHelpDesc = `
+This path creates a token of the service account of the role, or of a service
+account created for the lease and bound to the Kubernetes role of the role. The
+token expires with the lease, which can't be renewed, and the objects created
+for the lease are deleted when it is revoked.
+`
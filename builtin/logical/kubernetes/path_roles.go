@@ -0,0 +1,300 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"allowed_kubernetes_namespaces": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `The namespaces tokens can be created in. "*" allows all namespaces.`,
+			},
+
+			"service_account_name": {
+				Type:        framework.TypeString,
+				Description: "The existing service account tokens are created for.",
+			},
+
+			"kubernetes_role_name": {
+				Type:        framework.TypeString,
+				Description: "The existing Role or ClusterRole a service account created for each lease is bound to.",
+			},
+
+			"kubernetes_role_type": {
+				Type:        framework.TypeString,
+				Default:     kindRole,
+				Description: `The kind of the role the service accounts are bound to, "Role" or "ClusterRole".`,
+			},
+
+			"generated_role_rules": {
+				Type:        framework.TypeString,
+				Description: "The rules, in JSON or YAML, of a Role or ClusterRole created along with the service account of each lease.",
+			},
+
+			"token_default_audiences": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The audiences of the tokens. Defaults to the audience of the API server.",
+			},
+
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The default TTL of the tokens. Defaults to the default lease TTL of the mount.",
+			},
+
+			"max_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The maximum TTL of the tokens. Defaults to the maximum lease TTL of the mount.",
+			},
+		},
+
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.CreateOperation: b.pathRoleWrite,
+			logical.UpdateOperation: b.pathRoleWrite,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+// role returns a role, or nil if it doesn't exist
+func (b *backend) role(ctx context.Context, s logical.Storage, name string) (*roleEntry, error) {
+	entry, err := s.Get(ctx, "role/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role roleEntry
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, errwrap.Wrapf("error reading role: {{err}}", err)
+	}
+	return &role, nil
+}
+
+func (b *backend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"allowed_kubernetes_namespaces": role.AllowedNamespaces,
+			"service_account_name":          role.ServiceAccountName,
+			"kubernetes_role_name":          role.KubernetesRoleName,
+			"kubernetes_role_type":          role.KubernetesRoleType,
+			"generated_role_rules":          role.GeneratedRoleRules,
+			"token_default_audiences":       role.Audiences,
+			"ttl":                           int64(role.TTL.Seconds()),
+			"max_ttl":                       int64(role.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleEntry{
+			KubernetesRoleType: kindRole,
+		}
+	}
+
+	if v, ok := data.GetOk("allowed_kubernetes_namespaces"); ok {
+		role.AllowedNamespaces = strutil.RemoveDuplicates(v.([]string), false)
+	}
+	if v, ok := data.GetOk("service_account_name"); ok {
+		role.ServiceAccountName = v.(string)
+	}
+	if v, ok := data.GetOk("kubernetes_role_name"); ok {
+		role.KubernetesRoleName = v.(string)
+	}
+	if v, ok := data.GetOk("kubernetes_role_type"); ok {
+		role.KubernetesRoleType = v.(string)
+	}
+	if v, ok := data.GetOk("generated_role_rules"); ok {
+		role.GeneratedRoleRules = v.(string)
+	}
+	if v, ok := data.GetOk("token_default_audiences"); ok {
+		role.Audiences = v.([]string)
+	}
+	if v, ok := data.GetOk("ttl"); ok {
+		role.TTL = time.Duration(v.(int)) * time.Second
+	}
+	if v, ok := data.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Duration(v.(int)) * time.Second
+	}
+
+	switch strings.ToLower(role.KubernetesRoleType) {
+	case strings.ToLower(kindRole):
+		role.KubernetesRoleType = kindRole
+	case strings.ToLower(kindClusterRole):
+		role.KubernetesRoleType = kindClusterRole
+	default:
+		return logical.ErrorResponse(`kubernetes_role_type must be "Role" or "ClusterRole"`), nil
+	}
+
+	set := 0
+	for _, v := range []string{role.ServiceAccountName, role.KubernetesRoleName, role.GeneratedRoleRules} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return logical.ErrorResponse("exactly one of service_account_name, kubernetes_role_name and generated_role_rules must be set"), nil
+	}
+	if role.GeneratedRoleRules != "" {
+		if _, err := parseRules(role.GeneratedRoleRules); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if len(role.AllowedNamespaces) == 0 {
+		return logical.ErrorResponse("allowed_kubernetes_namespaces must be set"), nil
+	}
+	if role.TTL < 0 || role.MaxTTL < 0 {
+		return logical.ErrorResponse("ttl and max_ttl can't be negative"), nil
+	}
+	if role.MaxTTL > 0 && role.TTL > role.MaxTTL {
+		return logical.ErrorResponse("ttl can't be greater than max_ttl"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "role/"+data.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// parseRules parses the rules of a generated role, given in JSON or YAML as
+// an object with a "rules" list
+func parseRules(in string) ([]policyRule, error) {
+	var out struct {
+		Rules []policyRule `json:"rules"`
+	}
+	if err := yaml.Unmarshal([]byte(in), &out); err != nil {
+		return nil, errwrap.Wrapf("invalid generated_role_rules: {{err}}", err)
+	}
+	if len(out.Rules) == 0 {
+		return nil, errors.New("generated_role_rules has no rules")
+	}
+	for i, rule := range out.Rules {
+		if len(rule.Verbs) == 0 {
+			return nil, fmt.Errorf("rule %d of generated_role_rules has no verbs", i)
+		}
+	}
+	return out.Rules, nil
+}
+
+// allowsNamespace returns whether the role allows tokens in a namespace
+func (r *roleEntry) allowsNamespace(namespace string) bool {
+	return strutil.StrListContains(r.AllowedNamespaces, "*") || strutil.StrListContains(r.AllowedNamespaces, namespace)
+}
+
+type roleEntry struct {
+	AllowedNamespaces []string `json:"allowed_kubernetes_namespaces"`
+
+	// ServiceAccountName is the existing service account of the tokens. If
+	// unset, a service account is created for each lease and bound to the
+	// existing role KubernetesRoleName or to a role generated with
+	// GeneratedRoleRules.
+	ServiceAccountName string `json:"service_account_name"`
+	KubernetesRoleName string `json:"kubernetes_role_name"`
+	KubernetesRoleType string `json:"kubernetes_role_type"`
+	GeneratedRoleRules string `json:"generated_role_rules"`
+
+	Audiences []string      `json:"token_default_audiences"`
+	TTL       time.Duration `json:"ttl"`
+	MaxTTL    time.Duration `json:"max_ttl"`
+}
+
+const pathRoleHelpSyn = `
+Manage the roles tokens are created for.
+`
+
+const pathRoleHelpDesc = `
+This path lets you manage the roles of this backend. Exactly one of the
+following describes what the tokens of a role can do:
+
+  - "service_account_name" creates tokens of an existing service account.
+
+  - "kubernetes_role_name" creates a service account for each lease, bound to
+    an existing Role or ClusterRole, as set by "kubernetes_role_type".
+
+  - "generated_role_rules" creates a service account for each lease, along
+    with a Role or ClusterRole with the rules and its binding.
+
+The objects created for a lease are deleted when it is revoked. Tokens can be
+created in the namespaces listed in "allowed_kubernetes_namespaces".
+`
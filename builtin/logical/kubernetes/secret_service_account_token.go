@@ -0,0 +1,66 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const SecretServiceAccountTokenType = "service_account_token"
+
+func secretServiceAccountToken(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretServiceAccountTokenType,
+		Fields: map[string]*framework.FieldSchema{
+			"service_account_token": {
+				Type:        framework.TypeString,
+				Description: "Service account token",
+			},
+		},
+
+		Revoke: b.secretServiceAccountTokenRevoke,
+	}
+}
+
+// secretServiceAccountTokenRevoke deletes the objects created for the lease.
+// The token itself can't be revoked; tokens of a created service account stop
+// working once it is deleted, and the others when they expire with the lease.
+func (b *backend) secretServiceAccountTokenRevoke(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var objects []string
+	switch raw := req.Secret.InternalData["objects"].(type) {
+	case []string:
+		objects = raw
+	case []interface{}:
+		for _, v := range raw {
+			path, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid object %v on the lease", v)
+			}
+			objects = append(objects, path)
+		}
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	client, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	return nil, b.deleteObjects(ctx, client, objects)
+}
+
+// deleteObjects deletes the objects at paths in reverse order, so that
+// bindings go before what they refer to
+func (b *backend) deleteObjects(ctx context.Context, client *kubeClient, paths []string) error {
+	var result error
+	for i := len(paths) - 1; i >= 0; i-- {
+		if err := client.delete(ctx, paths[i]); err != nil {
+			result = multierror.Append(result, fmt.Errorf("error deleting %s: %v", paths[i], err))
+		}
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package kv
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func getBackend(t *testing.T) (*versionedKVBackend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.BackendUUID = "kv-test"
+
+	raw, err := VersionedKVFactory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := raw.(*versionedKVBackend)
+
+	// Wait for the upgrade of the empty storage to finish
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadUint32(b.upgrading) == 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the upgrade to finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return b, config.StorageView
+}
+
+func mustHandle(t *testing.T, b logical.Backend, req *logical.Request) *logical.Response {
+	t.Helper()
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: %s %s: resp: %#v, err: %v", req.Operation, req.Path, resp, err)
+	}
+	return resp
+}
+
+func mustFail(t *testing.T, b logical.Backend, req *logical.Request) *logical.Response {
+	t.Helper()
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatalf("expected %s %s to fail: resp: %#v", req.Operation, req.Path, resp)
+	}
+	return resp
+}
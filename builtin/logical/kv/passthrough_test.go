@@ -0,0 +1,84 @@
+package kv
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestPassthroughBackend_ListPage(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := PassthroughBackendFactory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"foo/a", "foo/b", "foo/c", "foo/d/e"} {
+		mustHandle(t, b, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      key,
+			Storage:   config.StorageView,
+			Data:      map[string]interface{}{"value": "bar"},
+		})
+	}
+
+	list := func(data map[string]interface{}) *logical.Response {
+		return mustHandle(t, b, &logical.Request{
+			Operation: logical.ListOperation,
+			Path:      "foo",
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+	}
+
+	// Without a limit all the keys are listed
+	resp := list(nil)
+	if actual := resp.Data["keys"]; !reflect.DeepEqual(actual, []string{"a", "b", "c", "d/"}) {
+		t.Fatalf("bad keys: %#v", actual)
+	}
+	if _, ok := resp.Data["continuation"]; ok {
+		t.Fatalf("unexpected continuation: %#v", resp.Data)
+	}
+
+	// Page through the keys
+	var pages [][]string
+	continuation := ""
+	for i := 0; i < 5; i++ {
+		data := map[string]interface{}{"limit": "3"}
+		if continuation != "" {
+			data["continuation"] = continuation
+		}
+		resp = list(data)
+		pages = append(pages, resp.Data["keys"].([]string))
+
+		raw, ok := resp.Data["continuation"]
+		if !ok {
+			break
+		}
+		continuation = raw.(string)
+	}
+	expected := [][]string{{"a", "b", "c"}, {"d/"}}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Fatalf("bad pages: %#v", pages)
+	}
+
+	// A page holding the last keys has no continuation
+	resp = list(map[string]interface{}{"limit": 4})
+	if actual := resp.Data["keys"]; !reflect.DeepEqual(actual, []string{"a", "b", "c", "d/"}) {
+		t.Fatalf("bad keys: %#v", actual)
+	}
+	if _, ok := resp.Data["continuation"]; ok {
+		t.Fatalf("unexpected continuation: %#v", resp.Data)
+	}
+
+	// An invalid limit is rejected
+	mustFail(t, b, &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "foo",
+		Storage:   config.StorageView,
+		Data:      map[string]interface{}{"limit": "-1"},
+	})
+}
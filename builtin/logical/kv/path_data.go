@@ -13,6 +13,7 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/helper/templateutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 	"github.com/mitchellh/mapstructure"
@@ -102,10 +103,12 @@ func (b *versionedKVBackend) pathDataRead() framework.OperationFunc {
 			Data: map[string]interface{}{
 				"data": nil,
 				"metadata": map[string]interface{}{
-					"version":       verNum,
-					"created_time":  ptypesTimestampToString(vm.CreatedTime),
-					"deletion_time": ptypesTimestampToString(vm.DeletionTime),
-					"destroyed":     vm.Destroyed,
+					"version":         verNum,
+					"created_time":    ptypesTimestampToString(vm.CreatedTime),
+					"deletion_time":   ptypesTimestampToString(vm.DeletionTime),
+					"destroyed":       vm.Destroyed,
+					"created_by":      versionCreatedBy(vm),
+					"custom_metadata": meta.CustomMetadata,
 				},
 			},
 		}
@@ -154,6 +157,15 @@ func (b *versionedKVBackend) pathDataRead() framework.OperationFunc {
 
 		resp.Data["data"] = vData
 
+		// Render the fields computed from the data
+		if len(meta.Templates) > 0 {
+			computed, warnings := templateutil.RenderAll(meta.Templates, vData)
+			resp.Data["computed"] = computed
+			for _, warning := range warnings {
+				resp.AddWarning(warning)
+			}
+		}
+
 		return resp, nil
 	}
 }
@@ -247,6 +259,9 @@ func (b *versionedKVBackend) pathDataWrite() framework.OperationFunc {
 		}
 
 		vm, versionToDelete := meta.AddVersion(version.CreatedTime, nil, config.MaxVersions)
+		vm.EntityId = req.EntityID
+		vm.DisplayName = req.DisplayName
+		vm.RequestId = req.ID
 		err = b.writeKeyMetadata(ctx, req.Storage, meta)
 		if err != nil {
 			return nil, err
@@ -259,6 +274,7 @@ func (b *versionedKVBackend) pathDataWrite() framework.OperationFunc {
 				"created_time":  ptypesTimestampToString(vm.CreatedTime),
 				"deletion_time": ptypesTimestampToString(vm.DeletionTime),
 				"destroyed":     vm.Destroyed,
+				"created_by":    versionCreatedBy(vm),
 			},
 		}
 
@@ -421,6 +437,8 @@ data.
 
 A read operation will return the latest version for a key unless the "version"
 parameter is set, then it returns the version at that number.
+If templates are set in the metadata of the key, the fields they compute from
+the data of the version are returned in "computed".
 
 Delete operations are a soft delete. They will mark the latest version as
 deleted, but the underlying data will not be fully removed. Delete operations
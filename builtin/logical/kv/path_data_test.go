@@ -0,0 +1,198 @@
+package kv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestVersionedKV_Data_CreatedBy(t *testing.T) {
+	b, storage := getBackend(t)
+
+	write := func(entityID, displayName, requestID string) *logical.Response {
+		return mustHandle(t, b, &logical.Request{
+			ID:          requestID,
+			Operation:   logical.UpdateOperation,
+			Path:        "data/foo",
+			Storage:     storage,
+			EntityID:    entityID,
+			DisplayName: displayName,
+			Data: map[string]interface{}{
+				"data": map[string]interface{}{"bar": "baz"},
+			},
+		})
+	}
+
+	first := map[string]interface{}{
+		"entity_id":    "entity-1",
+		"display_name": "userpass-alice",
+		"request_id":   "request-1",
+	}
+	second := map[string]interface{}{
+		"entity_id":    "",
+		"display_name": "token",
+		"request_id":   "request-2",
+	}
+
+	// The write returns who created the version
+	resp := write("entity-1", "userpass-alice", "request-1")
+	if actual := resp.Data["created_by"]; !reflect.DeepEqual(actual, first) {
+		t.Fatalf("bad created_by: %#v", actual)
+	}
+	resp = write("", "token", "request-2")
+	if actual := resp.Data["created_by"]; !reflect.DeepEqual(actual, second) {
+		t.Fatalf("bad created_by: %#v", actual)
+	}
+
+	// Each version keeps its own creator
+	for version, expected := range map[int]map[string]interface{}{1: first, 2: second} {
+		resp = mustHandle(t, b, &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "data/foo",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"version": version,
+			},
+		})
+		metadata := resp.Data["metadata"].(map[string]interface{})
+		if actual := metadata["created_by"]; !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("version %d: bad created_by: %#v", version, actual)
+		}
+	}
+
+	resp = mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+	})
+	versions := resp.Data["versions"].(map[string]interface{})
+	for version, expected := range map[string]map[string]interface{}{"1": first, "2": second} {
+		v := versions[version].(map[string]interface{})
+		if actual := v["created_by"]; !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("version %s: bad created_by: %#v", version, actual)
+		}
+	}
+}
+
+func TestVersionedKV_Data_Templates(t *testing.T) {
+	b, storage := getBackend(t)
+
+	mustHandle(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "data/db",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"data": map[string]interface{}{
+				"host": "db.example.com",
+				"db":   "app",
+				"user": "alice",
+			},
+		},
+	})
+
+	// An invalid template is rejected and nothing is written
+	mustFail(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "metadata/db",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"templates": map[string]interface{}{"url": "{{.host"},
+		},
+	})
+	resp := mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "data/db",
+		Storage:   storage,
+	})
+	if _, ok := resp.Data["computed"]; ok {
+		t.Fatalf("unexpected computed: %#v", resp.Data)
+	}
+
+	mustHandle(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "metadata/db",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"templates": map[string]interface{}{
+				"url":      "jdbc:postgresql://{{.host}}/{{.db}}?user={{.user}}",
+				"password": "{{.password}}",
+			},
+		},
+	})
+
+	resp = mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "metadata/db",
+		Storage:   storage,
+	})
+	if actual := resp.Data["templates"].(map[string]string); len(actual) != 2 {
+		t.Fatalf("bad templates: %#v", actual)
+	}
+
+	// The fields are computed from the data when it is read, and the fields
+	// which fail to render are returned as warnings
+	resp = mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "data/db",
+		Storage:   storage,
+	})
+	expected := map[string]interface{}{
+		"url": "jdbc:postgresql://db.example.com/app?user=alice",
+	}
+	if actual := resp.Data["computed"]; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad computed: %#v", actual)
+	}
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], `"password"`) {
+		t.Fatalf("bad warnings: %#v", resp.Warnings)
+	}
+
+	// The data itself is returned as written
+	if actual := resp.Data["data"].(map[string]interface{})["host"]; actual != "db.example.com" {
+		t.Fatalf("bad data: %#v", resp.Data["data"])
+	}
+
+	// A new version is rendered with its own data
+	mustHandle(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "data/db",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"data": map[string]interface{}{
+				"host":     "db2.example.com",
+				"db":       "app",
+				"user":     "bob",
+				"password": "secret",
+			},
+		},
+	})
+	resp = mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "data/db",
+		Storage:   storage,
+	})
+	expected = map[string]interface{}{
+		"url":      "jdbc:postgresql://db2.example.com/app?user=bob",
+		"password": "secret",
+	}
+	if actual := resp.Data["computed"]; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad computed: %#v", actual)
+	}
+	if len(resp.Warnings) != 0 {
+		t.Fatalf("unexpected warnings: %#v", resp.Warnings)
+	}
+
+	// Older versions are rendered with their data
+	resp = mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "data/db",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"version": 1,
+		},
+	})
+	if actual := resp.Data["computed"].(map[string]interface{})["url"]; actual != "jdbc:postgresql://db.example.com/app?user=alice" {
+		t.Fatalf("bad computed url: %#v", actual)
+	}
+}
@@ -7,10 +7,17 @@ import (
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/helper/templateutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
 
+const (
+	maxCustomMetadataKeys        = 64
+	maxCustomMetadataKeyLength   = 128
+	maxCustomMetadataValueLength = 512
+)
+
 // pathMetadata returns the path configuration for CRUD operations on the
 // metadata endpoint
 func pathMetadata(b *versionedKVBackend) *framework.Path {
@@ -29,6 +36,32 @@ If false, the backend’s configuration will be used.`,
 The number of versions to keep. If not set, the backend’s configured max
 version is used.`,
 			},
+			"templates": {
+				Type: framework.TypeKVPairs,
+				Description: `
+Templates of fields computed from the data of the key when it is read, as a map
+of field names to templates. The fields of the data are referenced as
+{{.name}}, such as "jdbc:postgresql://{{.host}}/{{.db}}". The computed fields
+are returned in "computed" next to the data. Setting it replaces the existing
+templates.`,
+			},
+			"custom_metadata": {
+				Type: framework.TypeKVPairs,
+				Description: `
+User-defined metadata of the key, as a map of strings. Setting it replaces the
+existing custom metadata; an empty map removes it.`,
+			},
+			"include_metadata": {
+				Type: framework.TypeBool,
+				Description: `
+If true, the listed keys are returned with their metadata in "key_info".`,
+			},
+			"match_custom_metadata": {
+				Type: framework.TypeKVPairs,
+				Description: `
+If set, only the keys whose custom metadata contains each of the given pairs are
+listed. Folders are always listed.`,
+			},
 		},
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation: b.upgradeCheck(b.pathMetadataWrite()),
@@ -72,8 +105,91 @@ func (b *versionedKVBackend) pathMetadataList() framework.OperationFunc {
 
 		// Use encrypted key storage to list the keys
 		keys, err := es.List(ctx, key)
-		return logical.ListResponse(keys), err
+		if err != nil {
+			return nil, err
+		}
+
+		includeMetadata := data.Get("include_metadata").(bool)
+		match := data.Get("match_custom_metadata").(map[string]string)
+		if !includeMetadata && len(match) == 0 {
+			return logical.ListResponse(keys), nil
+		}
+
+		filtered := make([]string, 0, len(keys))
+		keyInfo := make(map[string]interface{}, len(keys))
+		for _, k := range keys {
+			if strings.HasSuffix(k, "/") {
+				filtered = append(filtered, k)
+				continue
+			}
+
+			meta, err := b.getKeyMetadata(ctx, req.Storage, key+k)
+			if err != nil {
+				return nil, err
+			}
+			if meta == nil || !matchCustomMetadata(meta.CustomMetadata, match) {
+				continue
+			}
+
+			filtered = append(filtered, k)
+			info := map[string]interface{}{
+				"custom_metadata": meta.CustomMetadata,
+				"current_version": meta.CurrentVersion,
+				"created_time":    ptypesTimestampToString(meta.CreatedTime),
+				"updated_time":    ptypesTimestampToString(meta.UpdatedTime),
+			}
+			if vm, ok := meta.Versions[meta.CurrentVersion]; ok {
+				info["created_by"] = versionCreatedBy(vm)
+			}
+			keyInfo[k] = info
+		}
+
+		if !includeMetadata {
+			return logical.ListResponse(filtered), nil
+		}
+		return logical.ListResponseWithInfo(filtered, keyInfo), nil
+	}
+}
+
+// matchCustomMetadata returns true if the custom metadata contains each of
+// the pairs of match
+func matchCustomMetadata(customMetadata, match map[string]string) bool {
+	for k, v := range match {
+		if actual, ok := customMetadata[k]; !ok || actual != v {
+			return false
+		}
 	}
+	return true
+}
+
+// versionCreatedBy returns the identity of the request which wrote the
+// version
+func versionCreatedBy(vm *VersionMetadata) map[string]interface{} {
+	return map[string]interface{}{
+		"entity_id":    vm.EntityId,
+		"display_name": vm.DisplayName,
+		"request_id":   vm.RequestId,
+	}
+}
+
+// validateCustomMetadata checks the custom metadata against the limits of the
+// number of keys and the length of the keys and values
+func validateCustomMetadata(customMetadata map[string]string) error {
+	if len(customMetadata) > maxCustomMetadataKeys {
+		return fmt.Errorf("custom_metadata may not have more than %d keys", maxCustomMetadataKeys)
+	}
+	for k, v := range customMetadata {
+		if k == "" {
+			return fmt.Errorf("custom_metadata keys may not be empty")
+		}
+		if len(k) > maxCustomMetadataKeyLength {
+			return fmt.Errorf("custom_metadata key %q is longer than %d characters", k, maxCustomMetadataKeyLength)
+		}
+		if len(v) > maxCustomMetadataValueLength {
+			return fmt.Errorf("custom_metadata value of %q is longer than %d characters", k, maxCustomMetadataValueLength)
+		}
+	}
+	return nil
 }
 
 func (b *versionedKVBackend) pathMetadataRead() framework.OperationFunc {
@@ -94,6 +210,7 @@ func (b *versionedKVBackend) pathMetadataRead() framework.OperationFunc {
 				"created_time":  ptypesTimestampToString(v.CreatedTime),
 				"deletion_time": ptypesTimestampToString(v.DeletionTime),
 				"destroyed":     v.Destroyed,
+				"created_by":    versionCreatedBy(v),
 			}
 		}
 
@@ -106,6 +223,8 @@ func (b *versionedKVBackend) pathMetadataRead() framework.OperationFunc {
 				"updated_time":    ptypesTimestampToString(meta.UpdatedTime),
 				"max_versions":    meta.MaxVersions,
 				"cas_required":    meta.CasRequired,
+				"templates":       meta.Templates,
+				"custom_metadata": meta.CustomMetadata,
 			},
 		}, nil
 	}
@@ -117,12 +236,28 @@ func (b *versionedKVBackend) pathMetadataWrite() framework.OperationFunc {
 
 		maxRaw, mOk := data.GetOk("max_versions")
 		casRaw, cOk := data.GetOk("cas_required")
+		templatesRaw, tOk := data.GetOk("templates")
+		customMetadataRaw, cmOk := data.GetOk("custom_metadata")
 
 		// Fast path validation
-		if !mOk && !cOk {
+		if !mOk && !cOk && !tOk && !cmOk {
 			return nil, nil
 		}
 
+		if cmOk {
+			if err := validateCustomMetadata(customMetadataRaw.(map[string]string)); err != nil {
+				return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+			}
+		}
+
+		if tOk {
+			for name, tpl := range templatesRaw.(map[string]string) {
+				if _, err := templateutil.Parse(name, tpl); err != nil {
+					return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+				}
+			}
+		}
+
 		config, err := b.config(ctx, req.Storage)
 		if err != nil {
 			return nil, err
@@ -158,6 +293,12 @@ func (b *versionedKVBackend) pathMetadataWrite() framework.OperationFunc {
 		if cOk {
 			meta.CasRequired = casRaw.(bool)
 		}
+		if tOk {
+			meta.Templates = templatesRaw.(map[string]string)
+		}
+		if cmOk {
+			meta.CustomMetadata = customMetadataRaw.(map[string]string)
+		}
 
 		err = b.writeKeyMetadata(ctx, req.Storage, meta)
 		return resp, err
@@ -0,0 +1,238 @@
+package kv
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestVersionedKV_Metadata_CustomMetadata(t *testing.T) {
+	b, storage := getBackend(t)
+
+	mustHandle(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "data/foo",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"data": map[string]interface{}{"bar": "baz"},
+		},
+	})
+
+	customMetadata := map[string]string{"owner": "team-a", "env": "prod"}
+	mustHandle(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"custom_metadata": map[string]interface{}{"owner": "team-a", "env": "prod"},
+		},
+	})
+
+	// The custom metadata is returned by the metadata and the data reads
+	resp := mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+	})
+	if actual := resp.Data["custom_metadata"]; !reflect.DeepEqual(actual, customMetadata) {
+		t.Fatalf("bad custom_metadata: %#v", actual)
+	}
+
+	resp = mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "data/foo",
+		Storage:   storage,
+	})
+	metadata := resp.Data["metadata"].(map[string]interface{})
+	if actual := metadata["custom_metadata"]; !reflect.DeepEqual(actual, customMetadata) {
+		t.Fatalf("bad custom_metadata: %#v", actual)
+	}
+
+	// Writing other metadata leaves the custom metadata as it is
+	mustHandle(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"max_versions": 5,
+		},
+	})
+	resp = mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+	})
+	if actual := resp.Data["custom_metadata"]; !reflect.DeepEqual(actual, customMetadata) {
+		t.Fatalf("bad custom_metadata: %#v", actual)
+	}
+
+	// Setting it replaces the existing custom metadata
+	mustHandle(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"custom_metadata": map[string]interface{}{"owner": "team-b"},
+		},
+	})
+	resp = mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+	})
+	if actual := resp.Data["custom_metadata"]; !reflect.DeepEqual(actual, map[string]string{"owner": "team-b"}) {
+		t.Fatalf("bad custom_metadata: %#v", actual)
+	}
+
+	// An empty map removes it
+	mustHandle(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"custom_metadata": map[string]interface{}{},
+		},
+	})
+	resp = mustHandle(t, b, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+	})
+	if actual := resp.Data["custom_metadata"].(map[string]string); len(actual) != 0 {
+		t.Fatalf("bad custom_metadata: %#v", actual)
+	}
+}
+
+func TestVersionedKV_Metadata_CustomMetadataLimits(t *testing.T) {
+	b, storage := getBackend(t)
+
+	tooManyKeys := make(map[string]interface{}, maxCustomMetadataKeys+1)
+	for i := 0; i <= maxCustomMetadataKeys; i++ {
+		tooManyKeys[strings.Repeat("k", i+1)] = "v"
+	}
+
+	cases := map[string]map[string]interface{}{
+		"too many keys":  tooManyKeys,
+		"empty key":      {"": "v"},
+		"key too long":   {strings.Repeat("k", maxCustomMetadataKeyLength+1): "v"},
+		"value too long": {"k": strings.Repeat("v", maxCustomMetadataValueLength+1)},
+	}
+	for name, customMetadata := range cases {
+		mustFail(t, b, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "metadata/foo",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"custom_metadata": customMetadata,
+			},
+		})
+
+		// Nothing has been written
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "metadata/foo",
+			Storage:   storage,
+		})
+		if err != nil || resp != nil {
+			t.Fatalf("%s: expected no metadata: resp: %#v, err: %v", name, resp, err)
+		}
+	}
+
+	// The limits themselves are allowed
+	mustHandle(t, b, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "metadata/foo",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"custom_metadata": map[string]interface{}{
+				strings.Repeat("k", maxCustomMetadataKeyLength): strings.Repeat("v", maxCustomMetadataValueLength),
+			},
+		},
+	})
+}
+
+func TestVersionedKV_Metadata_ListCustomMetadata(t *testing.T) {
+	b, storage := getBackend(t)
+
+	for _, key := range []string{"app/a", "app/b", "app/c", "app/sub/d"} {
+		mustHandle(t, b, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "data/" + key,
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"data": map[string]interface{}{"bar": "baz"},
+			},
+		})
+	}
+	for key, owner := range map[string]string{"app/a": "team-a", "app/b": "team-b"} {
+		mustHandle(t, b, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "metadata/" + key,
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"custom_metadata": map[string]interface{}{"owner": owner, "env": "prod"},
+			},
+		})
+	}
+
+	list := func(data map[string]interface{}) *logical.Response {
+		return mustHandle(t, b, &logical.Request{
+			Operation: logical.ListOperation,
+			Path:      "metadata/app/",
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	keys := func(resp *logical.Response) []string {
+		keys := resp.Data["keys"].([]string)
+		sort.Strings(keys)
+		return keys
+	}
+
+	// A plain list has no key info
+	resp := list(nil)
+	if actual := keys(resp); !reflect.DeepEqual(actual, []string{"a", "b", "c", "sub/"}) {
+		t.Fatalf("bad keys: %#v", actual)
+	}
+	if _, ok := resp.Data["key_info"]; ok {
+		t.Fatalf("unexpected key_info: %#v", resp.Data)
+	}
+
+	// Matching only lists the matching keys and the folders
+	resp = list(map[string]interface{}{
+		"match_custom_metadata": map[string]interface{}{"env": "prod", "owner": "team-a"},
+	})
+	if actual := keys(resp); !reflect.DeepEqual(actual, []string{"a", "sub/"}) {
+		t.Fatalf("bad keys: %#v", actual)
+	}
+	if _, ok := resp.Data["key_info"]; ok {
+		t.Fatalf("unexpected key_info: %#v", resp.Data)
+	}
+
+	// Including the metadata returns it in the key info
+	resp = list(map[string]interface{}{
+		"include_metadata":      true,
+		"match_custom_metadata": map[string]interface{}{"env": "prod"},
+	})
+	if actual := keys(resp); !reflect.DeepEqual(actual, []string{"a", "b", "sub/"}) {
+		t.Fatalf("bad keys: %#v", actual)
+	}
+	keyInfo := resp.Data["key_info"].(map[string]interface{})
+	if len(keyInfo) != 2 {
+		t.Fatalf("bad key_info: %#v", keyInfo)
+	}
+	info := keyInfo["b"].(map[string]interface{})
+	if actual := info["custom_metadata"]; !reflect.DeepEqual(actual, map[string]string{"owner": "team-b", "env": "prod"}) {
+		t.Fatalf("bad custom_metadata: %#v", actual)
+	}
+	if actual := info["current_version"]; actual != uint64(1) {
+		t.Fatalf("bad current_version: %#v", actual)
+	}
+	if _, ok := info["created_by"]; !ok {
+		t.Fatalf("expected created_by: %#v", info)
+	}
+}
@@ -0,0 +1,449 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: builtin/logical/kv/types.proto
+
+package kv // import "github.com/hashicorp/vault/builtin/logical/kv"
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import timestamp "github.com/golang/protobuf/ptypes/timestamp"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// If values are added to this, be sure to update the config() function
+type Configuration struct {
+	MaxVersions          uint32   `protobuf:"varint,1,opt,name=max_versions,json=maxVersions,proto3" json:"max_versions,omitempty"`
+	CasRequired          bool     `protobuf:"varint,2,opt,name=cas_required,json=casRequired,proto3" json:"cas_required,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Configuration) Reset()         { *m = Configuration{} }
+func (m *Configuration) String() string { return proto.CompactTextString(m) }
+func (*Configuration) ProtoMessage()    {}
+func (*Configuration) Descriptor() ([]byte, []int) {
+	return fileDescriptor_types_2573a88e1fb43030, []int{0}
+}
+func (m *Configuration) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Configuration.Unmarshal(m, b)
+}
+func (m *Configuration) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Configuration.Marshal(b, m, deterministic)
+}
+func (dst *Configuration) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Configuration.Merge(dst, src)
+}
+func (m *Configuration) XXX_Size() int {
+	return xxx_messageInfo_Configuration.Size(m)
+}
+func (m *Configuration) XXX_DiscardUnknown() {
+	xxx_messageInfo_Configuration.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Configuration proto.InternalMessageInfo
+
+func (m *Configuration) GetMaxVersions() uint32 {
+	if m != nil {
+		return m.MaxVersions
+	}
+	return 0
+}
+
+func (m *Configuration) GetCasRequired() bool {
+	if m != nil {
+		return m.CasRequired
+	}
+	return false
+}
+
+type VersionMetadata struct {
+	// CreatedTime is when the version was created.
+	CreatedTime *timestamp.Timestamp `protobuf:"bytes,1,opt,name=created_time,json=createdTime,proto3" json:"created_time,omitempty"`
+	// DeletionTime is the time this version becomes invalid.
+	// Set to Now() to delete the version before the configured
+	// delete time.
+	DeletionTime *timestamp.Timestamp `protobuf:"bytes,2,opt,name=deletion_time,json=deletionTime,proto3" json:"deletion_time,omitempty"`
+	// Destroyed is used to specify this version is
+	// a has been removed and the underlying data deleted.
+	Destroyed bool `protobuf:"varint,3,opt,name=destroyed,proto3" json:"destroyed,omitempty"`
+	// EntityId is the ID of the entity of the token which created the
+	// version, if any.
+	EntityId string `protobuf:"bytes,4,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	// DisplayName is the display name of the token which created the
+	// version.
+	DisplayName string `protobuf:"bytes,5,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	// RequestId is the ID of the request which created the version, as
+	// logged by the audit devices.
+	RequestId            string   `protobuf:"bytes,6,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VersionMetadata) Reset()         { *m = VersionMetadata{} }
+func (m *VersionMetadata) String() string { return proto.CompactTextString(m) }
+func (*VersionMetadata) ProtoMessage()    {}
+func (*VersionMetadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_types_2573a88e1fb43030, []int{1}
+}
+func (m *VersionMetadata) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VersionMetadata.Unmarshal(m, b)
+}
+func (m *VersionMetadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VersionMetadata.Marshal(b, m, deterministic)
+}
+func (dst *VersionMetadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VersionMetadata.Merge(dst, src)
+}
+func (m *VersionMetadata) XXX_Size() int {
+	return xxx_messageInfo_VersionMetadata.Size(m)
+}
+func (m *VersionMetadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_VersionMetadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VersionMetadata proto.InternalMessageInfo
+
+func (m *VersionMetadata) GetCreatedTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.CreatedTime
+	}
+	return nil
+}
+
+func (m *VersionMetadata) GetDeletionTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.DeletionTime
+	}
+	return nil
+}
+
+func (m *VersionMetadata) GetDestroyed() bool {
+	if m != nil {
+		return m.Destroyed
+	}
+	return false
+}
+
+func (m *VersionMetadata) GetEntityId() string {
+	if m != nil {
+		return m.EntityId
+	}
+	return ""
+}
+
+func (m *VersionMetadata) GetDisplayName() string {
+	if m != nil {
+		return m.DisplayName
+	}
+	return ""
+}
+
+func (m *VersionMetadata) GetRequestId() string {
+	if m != nil {
+		return m.RequestId
+	}
+	return ""
+}
+
+type KeyMetadata struct {
+	// Key is the key for this entry
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Versions is the map of versionID -> VersionMetadata.
+	// Useful when listing all versions.
+	Versions map[uint64]*VersionMetadata `protobuf:"bytes,2,rep,name=versions,proto3" json:"versions,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// CurrentVersion is the latest version of the value
+	CurrentVersion uint64 `protobuf:"varint,3,opt,name=current_version,json=currentVersion,proto3" json:"current_version,omitempty"`
+	// OldestVersion is the oldest version of the value.
+	OldestVersion uint64 `protobuf:"varint,4,opt,name=oldest_version,json=oldestVersion,proto3" json:"oldest_version,omitempty"`
+	// Created time is when the metadata was created.
+	CreatedTime *timestamp.Timestamp `protobuf:"bytes,5,opt,name=created_time,json=createdTime,proto3" json:"created_time,omitempty"`
+	// Updated time was the last time the metadata version
+	// was updated.
+	UpdatedTime *timestamp.Timestamp `protobuf:"bytes,6,opt,name=updated_time,json=updatedTime,proto3" json:"updated_time,omitempty"`
+	// MaxVersions specifies how many versions to keep around.
+	// If empty value, defaults to the configured Max
+	// for the mount.
+	MaxVersions uint32 `protobuf:"varint,7,opt,name=max_versions,json=maxVersions,proto3" json:"max_versions,omitempty"`
+	// CasRequired specifies if the cas parameter is
+	// required for this key
+	CasRequired bool `protobuf:"varint,8,opt,name=cas_required,json=casRequired,proto3" json:"cas_required,omitempty"`
+	// Templates is the map of field name -> template of the fields
+	// computed from the data of the key when it is read
+	Templates map[string]string `protobuf:"bytes,9,rep,name=templates,proto3" json:"templates,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// CustomMetadata is the map of user-provided metadata of the key
+	CustomMetadata       map[string]string `protobuf:"bytes,10,rep,name=custom_metadata,json=customMetadata,proto3" json:"custom_metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *KeyMetadata) Reset()         { *m = KeyMetadata{} }
+func (m *KeyMetadata) String() string { return proto.CompactTextString(m) }
+func (*KeyMetadata) ProtoMessage()    {}
+func (*KeyMetadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_types_2573a88e1fb43030, []int{2}
+}
+func (m *KeyMetadata) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyMetadata.Unmarshal(m, b)
+}
+func (m *KeyMetadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyMetadata.Marshal(b, m, deterministic)
+}
+func (dst *KeyMetadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyMetadata.Merge(dst, src)
+}
+func (m *KeyMetadata) XXX_Size() int {
+	return xxx_messageInfo_KeyMetadata.Size(m)
+}
+func (m *KeyMetadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyMetadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyMetadata proto.InternalMessageInfo
+
+func (m *KeyMetadata) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KeyMetadata) GetVersions() map[uint64]*VersionMetadata {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+func (m *KeyMetadata) GetCurrentVersion() uint64 {
+	if m != nil {
+		return m.CurrentVersion
+	}
+	return 0
+}
+
+func (m *KeyMetadata) GetOldestVersion() uint64 {
+	if m != nil {
+		return m.OldestVersion
+	}
+	return 0
+}
+
+func (m *KeyMetadata) GetCreatedTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.CreatedTime
+	}
+	return nil
+}
+
+func (m *KeyMetadata) GetUpdatedTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.UpdatedTime
+	}
+	return nil
+}
+
+func (m *KeyMetadata) GetMaxVersions() uint32 {
+	if m != nil {
+		return m.MaxVersions
+	}
+	return 0
+}
+
+func (m *KeyMetadata) GetCasRequired() bool {
+	if m != nil {
+		return m.CasRequired
+	}
+	return false
+}
+
+func (m *KeyMetadata) GetTemplates() map[string]string {
+	if m != nil {
+		return m.Templates
+	}
+	return nil
+}
+
+func (m *KeyMetadata) GetCustomMetadata() map[string]string {
+	if m != nil {
+		return m.CustomMetadata
+	}
+	return nil
+}
+
+type Version struct {
+	// Data is a JSON object with string keys that
+	// represents the user supplied data.
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// CreatedTime is when the version was created.
+	CreatedTime *timestamp.Timestamp `protobuf:"bytes,2,opt,name=created_time,json=createdTime,proto3" json:"created_time,omitempty"`
+	// DeletionTime is the time this version becomes invalid.
+	// Set to Now() to delete the version before the configured
+	// deletion time.
+	DeletionTime         *timestamp.Timestamp `protobuf:"bytes,3,opt,name=deletion_time,json=deletionTime,proto3" json:"deletion_time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Version) Reset()         { *m = Version{} }
+func (m *Version) String() string { return proto.CompactTextString(m) }
+func (*Version) ProtoMessage()    {}
+func (*Version) Descriptor() ([]byte, []int) {
+	return fileDescriptor_types_2573a88e1fb43030, []int{3}
+}
+func (m *Version) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Version.Unmarshal(m, b)
+}
+func (m *Version) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Version.Marshal(b, m, deterministic)
+}
+func (dst *Version) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Version.Merge(dst, src)
+}
+func (m *Version) XXX_Size() int {
+	return xxx_messageInfo_Version.Size(m)
+}
+func (m *Version) XXX_DiscardUnknown() {
+	xxx_messageInfo_Version.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Version proto.InternalMessageInfo
+
+func (m *Version) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Version) GetCreatedTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.CreatedTime
+	}
+	return nil
+}
+
+func (m *Version) GetDeletionTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.DeletionTime
+	}
+	return nil
+}
+
+type UpgradeInfo struct {
+	// Started time is when the upgrade was started.
+	StartedTime *timestamp.Timestamp `protobuf:"bytes,1,opt,name=started_time,json=startedTime,proto3" json:"started_time,omitempty"`
+	// done is set to true once the backend has been successfully
+	// upgraded.
+	Done                 bool     `protobuf:"varint,2,opt,name=done,proto3" json:"done,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpgradeInfo) Reset()         { *m = UpgradeInfo{} }
+func (m *UpgradeInfo) String() string { return proto.CompactTextString(m) }
+func (*UpgradeInfo) ProtoMessage()    {}
+func (*UpgradeInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_types_2573a88e1fb43030, []int{4}
+}
+func (m *UpgradeInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpgradeInfo.Unmarshal(m, b)
+}
+func (m *UpgradeInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpgradeInfo.Marshal(b, m, deterministic)
+}
+func (dst *UpgradeInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpgradeInfo.Merge(dst, src)
+}
+func (m *UpgradeInfo) XXX_Size() int {
+	return xxx_messageInfo_UpgradeInfo.Size(m)
+}
+func (m *UpgradeInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpgradeInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpgradeInfo proto.InternalMessageInfo
+
+func (m *UpgradeInfo) GetStartedTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartedTime
+	}
+	return nil
+}
+
+func (m *UpgradeInfo) GetDone() bool {
+	if m != nil {
+		return m.Done
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterType((*Configuration)(nil), "kv.Configuration")
+	proto.RegisterType((*VersionMetadata)(nil), "kv.VersionMetadata")
+	proto.RegisterType((*KeyMetadata)(nil), "kv.KeyMetadata")
+	proto.RegisterMapType((map[string]string)(nil), "kv.KeyMetadata.CustomMetadataEntry")
+	proto.RegisterMapType((map[string]string)(nil), "kv.KeyMetadata.TemplatesEntry")
+	proto.RegisterMapType((map[uint64]*VersionMetadata)(nil), "kv.KeyMetadata.VersionsEntry")
+	proto.RegisterType((*Version)(nil), "kv.Version")
+	proto.RegisterType((*UpgradeInfo)(nil), "kv.UpgradeInfo")
+}
+
+func init() {
+	proto.RegisterFile("builtin/logical/kv/types.proto", fileDescriptor_types_2573a88e1fb43030)
+}
+
+var fileDescriptor_types_2573a88e1fb43030 = []byte{
+	// 608 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xcd, 0x6e, 0xd3, 0x4c,
+	0x14, 0x55, 0x9c, 0xa4, 0x8d, 0xaf, 0x93, 0xf4, 0x93, 0xfb, 0x2d, 0xac, 0x40, 0x4b, 0x08, 0x42,
+	0x84, 0x05, 0xb6, 0x54, 0x36, 0x80, 0x5a, 0x21, 0xa8, 0x58, 0x54, 0xfc, 0x08, 0x59, 0x2d, 0x0b,
+	0x36, 0x61, 0x62, 0xdf, 0xa6, 0xa3, 0xd8, 0x1e, 0x33, 0x33, 0x8e, 0xea, 0x97, 0xe0, 0x0d, 0x78,
+	0x24, 0xde, 0x09, 0xcd, 0x78, 0x9c, 0xa6, 0x69, 0xa5, 0x28, 0x62, 0xe7, 0x9c, 0x9c, 0x73, 0xe7,
+	0xcc, 0xb9, 0xf7, 0x0e, 0x1c, 0x4e, 0x0b, 0x9a, 0x48, 0x9a, 0x05, 0x09, 0x9b, 0xd1, 0x88, 0x24,
+	0xc1, 0x7c, 0x11, 0xc8, 0x32, 0x47, 0xe1, 0xe7, 0x9c, 0x49, 0xe6, 0x5a, 0xf3, 0xc5, 0xe0, 0xd1,
+	0x8c, 0xb1, 0x59, 0x82, 0x81, 0x46, 0xa6, 0xc5, 0x65, 0x20, 0x69, 0x8a, 0x42, 0x92, 0x34, 0xaf,
+	0x48, 0xa3, 0x0b, 0xe8, 0x9d, 0xb2, 0xec, 0x92, 0xce, 0x0a, 0x4e, 0x24, 0x65, 0x99, 0xfb, 0x18,
+	0xba, 0x29, 0xb9, 0x9e, 0x2c, 0x90, 0x0b, 0xca, 0x32, 0xe1, 0x35, 0x86, 0x8d, 0x71, 0x2f, 0x74,
+	0x52, 0x72, 0xfd, 0xcd, 0x40, 0x8a, 0x12, 0x11, 0x31, 0xe1, 0xf8, 0xb3, 0xa0, 0x1c, 0x63, 0xcf,
+	0x1a, 0x36, 0xc6, 0x9d, 0xd0, 0x89, 0x88, 0x08, 0x0d, 0x34, 0xfa, 0x65, 0xc1, 0x9e, 0xe1, 0x7f,
+	0x46, 0x49, 0x62, 0x22, 0x89, 0x7b, 0x02, 0xdd, 0x88, 0x23, 0x91, 0x18, 0x4f, 0x94, 0x0b, 0x5d,
+	0xd9, 0x39, 0x1a, 0xf8, 0x95, 0x45, 0xbf, 0xb6, 0xe8, 0x9f, 0xd7, 0x16, 0x43, 0xc7, 0xf0, 0x15,
+	0xe2, 0xbe, 0x85, 0x5e, 0x8c, 0x09, 0x2a, 0x93, 0x95, 0xde, 0xda, 0xa8, 0xef, 0xd6, 0x02, 0x5d,
+	0xe0, 0x21, 0xd8, 0x31, 0x0a, 0xc9, 0x59, 0x89, 0xb1, 0xd7, 0xd4, 0x9e, 0x6f, 0x00, 0xf7, 0x01,
+	0xd8, 0x98, 0x49, 0x2a, 0xcb, 0x09, 0x8d, 0xbd, 0xd6, 0xb0, 0x31, 0xb6, 0xc3, 0x4e, 0x05, 0x9c,
+	0xc5, 0xea, 0xc6, 0x31, 0x15, 0x79, 0x42, 0xca, 0x49, 0x46, 0x52, 0xf4, 0xda, 0xfa, 0x7f, 0xc7,
+	0x60, 0x5f, 0x48, 0x8a, 0xee, 0x01, 0x80, 0x0a, 0x04, 0x85, 0x54, 0x05, 0x76, 0x34, 0xc1, 0x36,
+	0xc8, 0x59, 0x3c, 0xfa, 0xd3, 0x06, 0xe7, 0x23, 0x96, 0xcb, 0x30, 0xfe, 0x83, 0xe6, 0x1c, 0x4b,
+	0x9d, 0x81, 0x1d, 0xaa, 0x4f, 0xf7, 0x35, 0x74, 0x96, 0xa1, 0x5b, 0xc3, 0xe6, 0xd8, 0x39, 0x3a,
+	0xf0, 0xe7, 0x0b, 0x7f, 0x45, 0xe4, 0xd7, 0x1d, 0xf8, 0x90, 0x49, 0x5e, 0x86, 0x4b, 0xba, 0xfb,
+	0x0c, 0xf6, 0xa2, 0x82, 0x73, 0xcc, 0x64, 0xdd, 0x37, 0x7d, 0xbf, 0x56, 0xd8, 0x37, 0xb0, 0x11,
+	0xba, 0x4f, 0xa1, 0xcf, 0x12, 0x75, 0xe7, 0x25, 0xaf, 0xa5, 0x79, 0xbd, 0x0a, 0xad, 0x69, 0xeb,
+	0x9d, 0x6a, 0x6f, 0xd7, 0xa9, 0x13, 0xe8, 0x16, 0x79, 0x7c, 0x23, 0xdf, 0xd9, 0x2c, 0x37, 0x7c,
+	0x2d, 0x5f, 0x9f, 0xc0, 0xdd, 0xcd, 0x13, 0xd8, 0xb9, 0x33, 0x81, 0xee, 0x31, 0xd8, 0x12, 0xd3,
+	0x3c, 0x21, 0x12, 0x85, 0x67, 0xeb, 0x3c, 0x0f, 0xd7, 0xf3, 0x3c, 0xaf, 0x09, 0x55, 0xa0, 0x37,
+	0x02, 0xf7, 0x93, 0x4a, 0x54, 0x48, 0x96, 0x4e, 0x52, 0x43, 0xf6, 0x40, 0xd7, 0x78, 0xb2, 0x5e,
+	0xe3, 0x54, 0xd3, 0xea, 0x9f, 0x55, 0xa1, 0x7e, 0x74, 0x0b, 0x1c, 0x7c, 0x85, 0xde, 0xad, 0xd6,
+	0xad, 0x76, 0xbf, 0x55, 0x75, 0xff, 0x39, 0xb4, 0x17, 0x24, 0x29, 0xea, 0xa9, 0xde, 0x57, 0xc7,
+	0xac, 0x2d, 0x50, 0x58, 0x31, 0xde, 0x58, 0xaf, 0x1a, 0x83, 0x63, 0xe8, 0xdf, 0x36, 0x7f, 0xcf,
+	0x40, 0xfd, 0xbf, 0x5a, 0xd2, 0x5e, 0x55, 0xbf, 0x83, 0xfd, 0x7b, 0x6c, 0x6f, 0x53, 0x62, 0xf4,
+	0xbb, 0x01, 0xbb, 0xf5, 0xb8, 0xb8, 0xd0, 0xd2, 0x09, 0x29, 0x61, 0x37, 0x6c, 0xdd, 0xbb, 0xec,
+	0xd6, 0x3f, 0x2e, 0x7b, 0x73, 0xbb, 0x65, 0x1f, 0xfd, 0x00, 0xe7, 0x22, 0x9f, 0x71, 0x12, 0xe3,
+	0x59, 0x76, 0xc9, 0x94, 0x1d, 0x21, 0x09, 0xdf, 0xe6, 0xed, 0x31, 0x7c, 0x6d, 0x47, 0xdd, 0x90,
+	0x65, 0x68, 0x5e, 0x3a, 0xfd, 0xfd, 0x3e, 0xf8, 0xfe, 0x62, 0x46, 0xe5, 0x55, 0x31, 0xf5, 0x23,
+	0x96, 0x06, 0x57, 0x44, 0x5c, 0xd1, 0x88, 0xf1, 0x3c, 0x58, 0x90, 0x22, 0x91, 0xc1, 0xdd, 0xb7,
+	0x79, 0xba, 0xa3, 0x4f, 0x79, 0xf9, 0x77, 0x00, 0x62, 0x25, 0x23, 0xc1, 0xb8, 0x05, 0x00, 0x00,
+}
@@ -0,0 +1,111 @@
+package ldap
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/ldaputil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	b := &backend{
+		ldap: ldaputil.NewLDAP(),
+	}
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				configPath,
+				staticRolePrefix,
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfig(b),
+			pathListRoles(b),
+			pathRoles(b),
+			pathCreds(b),
+			pathListStaticRoles(b),
+			pathStaticRoles(b),
+			pathStaticCreds(b),
+			pathRotateRole(b),
+		},
+
+		Secrets: []*framework.Secret{
+			secretDynamicCreds(b),
+		},
+
+		// Rotate the passwords of the static roles whose period elapsed
+		PeriodicFunc: b.periodicFunc,
+		BackendType:  logical.TypeLogical,
+	}
+
+	return b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// ldap dials the directory; tests replace it
+	ldap ldaputil.LDAP
+
+	// rotationLock serializes the rotations of static roles
+	rotationLock sync.Mutex
+}
+
+// conn returns a connection to the directory bound with the configured DN,
+// which the caller closes
+func (b *backend) conn(ctx context.Context, s logical.Storage) (ldaputil.Connection, *ldapConfig, error) {
+	conf, err := b.config(ctx, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conf == nil {
+		return nil, nil, errNotConfigured
+	}
+
+	client := &ldaputil.Client{
+		Logger: b.Logger(),
+		LDAP:   b.ldap,
+	}
+	conn, err := client.DialLDAP(conf.ConfigEntry)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := conn.Bind(conf.BindDN, conf.BindPassword); err != nil {
+		conn.Close()
+		return nil, nil, errwrap.Wrapf("error binding to the directory: {{err}}", err)
+	}
+	return conn, conf, nil
+}
+
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	// Static roles are replicated, so only the primary rotates them
+	if b.System().LocalMount() || !b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		b.rotateExpiredStaticRoles(ctx, req.Storage)
+	}
+	return nil
+}
+
+const backendHelp = `
+The ldap backend manages credentials of users of an LDAP directory, such as
+OpenLDAP or Active Directory.
+
+Dynamic roles create a user for every lease from LDIF templates, and delete it
+when the lease is revoked. Static roles manage the password of an existing
+entry, which is rotated periodically and can be read by authorized clients.
+`
@@ -0,0 +1,616 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	goldap "github.com/go-ldap/ldap"
+	"github.com/hashicorp/vault/helper/ldaputil"
+	"github.com/hashicorp/vault/logical"
+	logicaltest "github.com/hashicorp/vault/logical/testing"
+)
+
+// fakeDirectory is a directory keeping its entries in memory
+type fakeDirectory struct {
+	l       sync.Mutex
+	entries map[string]map[string][]string
+
+	// failDN makes the changes of an entry fail
+	failDN string
+}
+
+func newFakeDirectory() *fakeDirectory {
+	return &fakeDirectory{
+		entries: map[string]map[string][]string{
+			"cn=admin,dc=example,dc=org": {"userPassword": {"admin"}},
+		},
+	}
+}
+
+func (d *fakeDirectory) Dial(network, addr string) (ldaputil.Connection, error) {
+	return &fakeConn{d: d}, nil
+}
+
+func (d *fakeDirectory) DialTLS(network, addr string, config *tls.Config) (ldaputil.Connection, error) {
+	return &fakeConn{d: d}, nil
+}
+
+func (d *fakeDirectory) entry(dn string) map[string][]string {
+	d.l.Lock()
+	defer d.l.Unlock()
+	return d.entries[dn]
+}
+
+type fakeConn struct {
+	d     *fakeDirectory
+	bound bool
+}
+
+func (c *fakeConn) Bind(username, password string) error {
+	c.d.l.Lock()
+	defer c.d.l.Unlock()
+
+	entry, ok := c.d.entries[username]
+	if !ok || len(entry["userPassword"]) == 0 || entry["userPassword"][0] != password {
+		return goldap.NewError(goldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	c.bound = true
+	return nil
+}
+
+func (c *fakeConn) Add(req *goldap.AddRequest) error {
+	c.d.l.Lock()
+	defer c.d.l.Unlock()
+
+	if err := c.check(req.DN); err != nil {
+		return err
+	}
+	if _, ok := c.d.entries[req.DN]; ok {
+		return goldap.NewError(goldap.LDAPResultEntryAlreadyExists, errors.New("already exists"))
+	}
+	entry := make(map[string][]string)
+	for _, attr := range req.Attributes {
+		entry[attr.Type] = attr.Vals
+	}
+	c.d.entries[req.DN] = entry
+	return nil
+}
+
+func (c *fakeConn) Del(req *goldap.DelRequest) error {
+	c.d.l.Lock()
+	defer c.d.l.Unlock()
+
+	if err := c.check(req.DN); err != nil {
+		return err
+	}
+	if _, ok := c.d.entries[req.DN]; !ok {
+		return goldap.NewError(goldap.LDAPResultNoSuchObject, errors.New("no such object"))
+	}
+	delete(c.d.entries, req.DN)
+	return nil
+}
+
+func (c *fakeConn) Modify(req *goldap.ModifyRequest) error {
+	c.d.l.Lock()
+	defer c.d.l.Unlock()
+
+	if err := c.check(req.DN); err != nil {
+		return err
+	}
+	entry, ok := c.d.entries[req.DN]
+	if !ok {
+		return goldap.NewError(goldap.LDAPResultNoSuchObject, errors.New("no such object"))
+	}
+	for _, change := range req.Changes {
+		attr := change.Modification
+		switch change.Operation {
+		case goldap.AddAttribute:
+			entry[attr.Type] = append(entry[attr.Type], attr.Vals...)
+		case goldap.ReplaceAttribute:
+			entry[attr.Type] = attr.Vals
+		case goldap.DeleteAttribute:
+			if len(attr.Vals) == 0 {
+				delete(entry, attr.Type)
+				continue
+			}
+			var vals []string
+			for _, v := range entry[attr.Type] {
+				keep := true
+				for _, del := range attr.Vals {
+					if v == del {
+						keep = false
+					}
+				}
+				if keep {
+					vals = append(vals, v)
+				}
+			}
+			entry[attr.Type] = vals
+		}
+	}
+	return nil
+}
+
+func (c *fakeConn) check(dn string) error {
+	if !c.bound {
+		return goldap.NewError(goldap.LDAPResultInsufficientAccessRights, errors.New("not bound"))
+	}
+	if dn == c.d.failDN {
+		return goldap.NewError(goldap.LDAPResultUnwillingToPerform, errors.New("unwilling to perform"))
+	}
+	return nil
+}
+
+func (c *fakeConn) Close() {}
+
+func (c *fakeConn) Search(req *goldap.SearchRequest) (*goldap.SearchResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeConn) StartTLS(config *tls.Config) error {
+	return nil
+}
+
+func (c *fakeConn) UnauthenticatedBind(username string) error {
+	return errors.New("not implemented")
+}
+
+const testCreationLDIF = `
+dn: cn={{.Username}},ou=users,dc=example,dc=org
+objectClass: inetOrgPerson
+cn: {{.Username}}
+sn: {{.RoleName}}
+userPassword: {{.Password}}
+
+dn: cn=readers,ou=groups,dc=example,dc=org
+changetype: modify
+add: member
+member: cn={{.Username}},ou=users,dc=example,dc=org
+-
+`
+
+const testDeletionLDIF = `
+dn: cn=readers,ou=groups,dc=example,dc=org
+changetype: modify
+delete: member
+member: cn={{.Username}},ou=users,dc=example,dc=org
+-
+
+dn: cn={{.Username}},ou=users,dc=example,dc=org
+changetype: delete
+`
+
+func getBackend(t *testing.T) (*backend, logical.Storage, *fakeDirectory) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	d := newFakeDirectory()
+	b.ldap = d
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView, d
+}
+
+var testConfigData = map[string]interface{}{
+	"url":      "ldap://localhost",
+	"binddn":   "cn=admin,dc=example,dc=org",
+	"bindpass": "admin",
+}
+
+func TestBackend_config(t *testing.T) {
+	b, _, _ := getBackend(t)
+
+	steps := []logicaltest.TestStep{}
+	for _, data := range []map[string]interface{}{
+		// No bind DN
+		{
+			"url":      "ldap://localhost",
+			"bindpass": "admin",
+		},
+		// Invalid schema
+		{
+			"url":      "ldap://localhost",
+			"binddn":   "cn=admin,dc=example,dc=org",
+			"bindpass": "admin",
+			"schema":   "novell",
+		},
+		// Short passwords
+		{
+			"url":             "ldap://localhost",
+			"binddn":          "cn=admin,dc=example,dc=org",
+			"bindpass":        "admin",
+			"password_length": 8,
+		},
+	} {
+		steps = append(steps, testAccStepConfig(t, data, true))
+	}
+
+	steps = append(steps,
+		testAccStepConfig(t, testConfigData, false),
+		logicaltest.TestStep{
+			Operation: logical.ReadOperation,
+			Path:      "config",
+			Check: func(resp *logical.Response) error {
+				if resp == nil || resp.Data["binddn"] != "cn=admin,dc=example,dc=org" || resp.Data["schema"] != schemaOpenLDAP || resp.Data["password_length"] != defaultPasswordLength {
+					return fmt.Errorf("bad: %#v", resp)
+				}
+				if _, ok := resp.Data["bindpass"]; ok {
+					return fmt.Errorf("the bind password was returned")
+				}
+				return nil
+			},
+		},
+	)
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Backend: b,
+		Steps:   steps,
+	})
+}
+
+func TestBackend_dynamicCreds(t *testing.T) {
+	b, _, d := getBackend(t)
+	d.entries["cn=readers,ou=groups,dc=example,dc=org"] = map[string][]string{}
+
+	var dn string
+	logicaltest.Test(t, logicaltest.TestCase{
+		Backend: b,
+		Steps: []logicaltest.TestStep{
+			testAccStepConfig(t, testConfigData, false),
+			testAccStepWriteRole(t, "bad", map[string]interface{}{
+				"creation_ldif": testCreationLDIF,
+			}, true),
+			testAccStepWriteRole(t, "bad", map[string]interface{}{
+				"creation_ldif": "cn: {{.Username}}",
+				"deletion_ldif": testDeletionLDIF,
+			}, true),
+			testAccStepWriteRole(t, "bad", map[string]interface{}{
+				"creation_ldif": testCreationLDIF,
+				"deletion_ldif": "dn: cn={{.Username}}\nchangetype: rename",
+			}, true),
+			testAccStepWriteRole(t, "readers", map[string]interface{}{
+				"creation_ldif": testCreationLDIF,
+				"deletion_ldif": testDeletionLDIF,
+				"ttl":           "30m",
+				"max_ttl":       "2h",
+			}, false),
+			logicaltest.TestStep{
+				Operation: logical.ListOperation,
+				Path:      "role/",
+				Check: func(resp *logical.Response) error {
+					if resp == nil || !reflect.DeepEqual(resp.Data["keys"], []string{"readers"}) {
+						return fmt.Errorf("bad: %#v", resp)
+					}
+					return nil
+				},
+			},
+			testAccStepReadCreds(t, "readers", func(resp *logical.Response) error {
+				username := resp.Data["username"].(string)
+				password := resp.Data["password"].(string)
+				if !strings.HasPrefix(username, "v_root_readers_") || len(password) != defaultPasswordLength {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				if resp.Secret.TTL != 30*time.Minute || resp.Secret.MaxTTL != 2*time.Hour {
+					return fmt.Errorf("bad: %#v", resp.Secret)
+				}
+				dn = "cn=" + username + ",ou=users,dc=example,dc=org"
+				if dns := resp.Data["distinguished_names"].([]string); len(dns) != 1 || dns[0] != dn {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				if entry := d.entry(dn); entry == nil || entry["userPassword"][0] != password || entry["sn"][0] != "readers" {
+					return fmt.Errorf("bad entry: %#v", entry)
+				}
+				if members := d.entry("cn=readers,ou=groups,dc=example,dc=org")["member"]; len(members) != 1 || members[0] != dn {
+					return fmt.Errorf("bad members: %v", members)
+				}
+				return nil
+			}),
+
+			// A failed creation is rolled back
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "creds/readers",
+				PreFlight: func(req *logical.Request) error {
+					d.l.Lock()
+					defer d.l.Unlock()
+					d.failDN = "cn=readers,ou=groups,dc=example,dc=org"
+					return nil
+				},
+				ErrorOk: true,
+				Check: func(resp *logical.Response) error {
+					d.l.Lock()
+					defer d.l.Unlock()
+					d.failDN = ""
+					if resp != nil && resp.Secret != nil {
+						return fmt.Errorf("expected an error, got %#v", resp)
+					}
+					if len(d.entries) != 3 {
+						return fmt.Errorf("the failed creation was not rolled back: %#v", d.entries)
+					}
+					return nil
+				},
+			},
+
+			// The lease is revoked with the deletion LDIF of its creation
+			// time, even if the role is deleted
+			testAccStepDeleteRole(t, "readers"),
+		},
+	})
+
+	// The lease was revoked at the end of the test case
+	if entry := d.entry(dn); entry != nil {
+		t.Fatalf("the entry was not deleted: %#v", entry)
+	}
+	if members := d.entry("cn=readers,ou=groups,dc=example,dc=org")["member"]; len(members) != 0 {
+		t.Fatalf("bad members: %v", members)
+	}
+}
+
+func TestBackend_dynamicCredsRenewRevoke(t *testing.T) {
+	b, storage, d := getBackend(t)
+	d.entries["cn=readers,ou=groups,dc=example,dc=org"] = map[string][]string{}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data:      testConfigData,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	roleReq := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "role/readers",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"creation_ldif": testCreationLDIF,
+			"deletion_ldif": testDeletionLDIF,
+			"ttl":           "30m",
+		},
+	}
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "creds/readers",
+		Storage:     storage,
+		DisplayName: "token",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	secret := resp.Secret
+	dn := "cn=" + resp.Data["username"].(string) + ",ou=users,dc=example,dc=org"
+	if !strings.HasPrefix(dn, "cn=v_token_readers_") {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RenewOperation,
+		Storage:   storage,
+		Secret:    secret,
+	})
+	if err != nil || resp.Secret.TTL != 30*time.Minute || resp.Secret.MaxTTL != 48*time.Hour {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	// Revoking a lease twice is not an error
+	roleReq.Operation = logical.DeleteOperation
+	roleReq.Data = nil
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.RevokeOperation,
+			Storage:   storage,
+			Secret:    secret,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entry := d.entry(dn); entry != nil {
+		t.Fatalf("the entry was not deleted: %#v", entry)
+	}
+}
+
+func TestBackend_staticRoles(t *testing.T) {
+	b, storage, d := getBackend(t)
+
+	dn := "cn=app,ou=services,dc=example,dc=org"
+	d.entries[dn] = map[string][]string{"userPassword": {"initial"}}
+
+	// The password is rotated when the role is created, which needs the
+	// directory
+	roleReq := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "static-role/app",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"dn":              dn,
+			"rotation_period": "1h",
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), roleReq)
+	if err == nil && !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data:      testConfigData,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	for _, data := range []map[string]interface{}{
+		{
+			"dn":              dn,
+			"rotation_period": "10s",
+		},
+		{
+			"dn":              "cn=missing,dc=example,dc=org",
+			"rotation_period": "1h",
+		},
+	} {
+		roleReq.Data = data
+		resp, err = b.HandleRequest(context.Background(), roleReq)
+		if err == nil && !resp.IsError() {
+			t.Fatalf("expected an error for %#v, got: %#v", data, resp)
+		}
+	}
+
+	roleReq.Data = map[string]interface{}{
+		"dn":              dn,
+		"username":        "app",
+		"rotation_period": "1h",
+	}
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	roleReq.Operation = logical.ReadOperation
+	roleReq.Data = nil
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	if _, ok := resp.Data["password"]; ok || resp.Data["rotation_period"] != int64(3600) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	credReq := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "static-cred/app",
+		Storage:   storage,
+	}
+	resp, err = b.HandleRequest(context.Background(), credReq)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	password := resp.Data["password"].(string)
+	if password == "" || d.entry(dn)["userPassword"][0] != password || resp.Data["username"] != "app" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if ttl := resp.Data["ttl"].(int64); ttl > 3600 || ttl < 3590 {
+		t.Fatalf("bad ttl: %d", ttl)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "rotate-role/app",
+		Storage:   storage,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	resp, err = b.HandleRequest(context.Background(), credReq)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	if resp.Data["last_password"] != password || resp.Data["password"] == password || d.entry(dn)["userPassword"][0] != resp.Data["password"] {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	password = resp.Data["password"].(string)
+
+	// The periodic function only rotates the roles whose period elapsed
+	b.rotateExpiredStaticRoles(context.Background(), storage)
+	if d.entry(dn)["userPassword"][0] != password {
+		t.Fatal("the password was rotated before the end of the period")
+	}
+	role, err := b.staticRole(context.Background(), storage, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	role.LastVaultRotation = time.Now().Add(-2 * time.Hour)
+	if err := b.putStaticRole(context.Background(), storage, "app", role); err != nil {
+		t.Fatal(err)
+	}
+	b.rotateExpiredStaticRoles(context.Background(), storage)
+	resp, err = b.HandleRequest(context.Background(), credReq)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	if resp.Data["password"] == password || d.entry(dn)["userPassword"][0] != resp.Data["password"] {
+		t.Fatalf("the password was not rotated: %#v", resp.Data)
+	}
+
+	roleReq.Operation = logical.DeleteOperation
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	resp, err = b.HandleRequest(context.Background(), credReq)
+	if err == nil && !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+}
+
+func testAccStepConfig(t *testing.T, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepWriteRole(t *testing.T, name string, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "role/" + name,
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepDeleteRole(t *testing.T, name string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.DeleteOperation,
+		Path:      "role/" + name,
+	}
+}
+
+func testAccStepReadCreds(t *testing.T, role string, check logicaltest.TestCheckFunc) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "creds/" + role,
+		Check:     check,
+	}
+}
+func TestPasswordAttribute(t *testing.T) {
+	attr, value, err := passwordAttribute(schemaAD, "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attr != "unicodePwd" || value != "\"\x00p\x00w\x00\"\x00" {
+		t.Fatalf("bad: %q %q", attr, value)
+	}
+}
@@ -0,0 +1,310 @@
+package ldap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"text/template"
+
+	goldap "github.com/go-ldap/ldap"
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/ldaputil"
+)
+
+const (
+	changeTypeAdd    = "add"
+	changeTypeModify = "modify"
+	changeTypeDelete = "delete"
+)
+
+// ldifEntry is a change record of an LDIF document
+type ldifEntry struct {
+	DN         string
+	ChangeType string
+
+	// Attributes are the attributes of an added entry
+	Attributes []goldap.Attribute
+
+	// Changes are the modifications of a modified entry
+	Changes []ldifChange
+}
+
+type ldifChange struct {
+	Operation string
+	Attribute goldap.PartialAttribute
+}
+
+// ldifTemplateData is what the LDIF templates of roles are rendered with
+type ldifTemplateData struct {
+	Username    string
+	Password    string
+	DisplayName string
+	RoleName    string
+}
+
+// renderLDIF renders an LDIF template and parses the result
+func renderLDIF(tmpl string, data ldifTemplateData) ([]*ldifEntry, error) {
+	ldif, err := renderLDIFString(tmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	return parseLDIF(ldif)
+}
+
+// renderLDIFString renders an LDIF template
+func renderLDIFString(tmpl string, data ldifTemplateData) (string, error) {
+	t, err := template.New("ldif").Parse(tmpl)
+	if err != nil {
+		return "", errwrap.Wrapf("invalid LDIF template: {{err}}", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", errwrap.Wrapf("error rendering LDIF template: {{err}}", err)
+	}
+	return buf.String(), nil
+}
+
+// validateLDIF checks that an LDIF template renders to valid change records
+func validateLDIF(tmpl string) error {
+	entries, err := renderLDIF(tmpl, ldifTemplateData{
+		Username:    "username",
+		Password:    "password",
+		DisplayName: "display-name",
+		RoleName:    "role",
+	})
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("LDIF has no entries")
+	}
+	return nil
+}
+
+// parseLDIF parses the change records of an LDIF document, as described in
+// RFC 2849. Records without a changetype add an entry. Values can be base64
+// encoded, but can't be read from URLs.
+func parseLDIF(in string) ([]*ldifEntry, error) {
+	// Unfold the lines continued on the next one, dropping comments
+	var lines []string
+	comment := false
+	for _, line := range strings.Split(strings.Replace(in, "\r\n", "\n", -1), "\n") {
+		switch {
+		case strings.HasPrefix(line, " "):
+			if comment {
+				continue
+			}
+			if len(lines) == 0 || lines[len(lines)-1] == "" {
+				return nil, fmt.Errorf("unexpected continuation line %q", line)
+			}
+			lines[len(lines)-1] += line[1:]
+		case strings.HasPrefix(line, "#"):
+			comment = true
+		default:
+			comment = false
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+
+	// Drop the version line, which can only come first
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(line), "version:") {
+			lines = lines[i+1:]
+		}
+		break
+	}
+
+	var entries []*ldifEntry
+	var record []string
+	flush := func() error {
+		if len(record) == 0 {
+			return nil
+		}
+		entry, err := parseLDIFRecord(record)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		record = nil
+		return nil
+	}
+	for _, line := range lines {
+		if line != "" {
+			record = append(record, line)
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func parseLDIFRecord(lines []string) (*ldifEntry, error) {
+	key, value, err := parseLDIFLine(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	if key != "dn" {
+		return nil, fmt.Errorf("LDIF record must start with a dn, got %q", key)
+	}
+	if value == "" {
+		return nil, fmt.Errorf("LDIF record has an empty dn")
+	}
+
+	entry := &ldifEntry{
+		DN:         value,
+		ChangeType: changeTypeAdd,
+	}
+	lines = lines[1:]
+	if len(lines) > 0 {
+		if key, value, err := parseLDIFLine(lines[0]); err == nil && strings.EqualFold(key, "changetype") {
+			entry.ChangeType = strings.ToLower(value)
+			lines = lines[1:]
+		}
+	}
+
+	switch entry.ChangeType {
+	case changeTypeAdd:
+		index := make(map[string]int)
+		for _, line := range lines {
+			key, value, err := parseLDIFLine(line)
+			if err != nil {
+				return nil, err
+			}
+			if i, ok := index[strings.ToLower(key)]; ok {
+				entry.Attributes[i].Vals = append(entry.Attributes[i].Vals, value)
+				continue
+			}
+			index[strings.ToLower(key)] = len(entry.Attributes)
+			entry.Attributes = append(entry.Attributes, goldap.Attribute{Type: key, Vals: []string{value}})
+		}
+		if len(entry.Attributes) == 0 {
+			return nil, fmt.Errorf("entry %q has no attributes", entry.DN)
+		}
+
+	case changeTypeModify:
+		var change *ldifChange
+		for _, line := range lines {
+			if line == "-" {
+				if change == nil {
+					return nil, fmt.Errorf("unexpected separator in the changes of %q", entry.DN)
+				}
+				entry.Changes = append(entry.Changes, *change)
+				change = nil
+				continue
+			}
+			key, value, err := parseLDIFLine(line)
+			if err != nil {
+				return nil, err
+			}
+			if change == nil {
+				op := strings.ToLower(key)
+				if op != "add" && op != "delete" && op != "replace" {
+					return nil, fmt.Errorf("invalid modification %q of %q", key, entry.DN)
+				}
+				change = &ldifChange{
+					Operation: op,
+					Attribute: goldap.PartialAttribute{Type: value},
+				}
+				continue
+			}
+			if !strings.EqualFold(key, change.Attribute.Type) {
+				return nil, fmt.Errorf("attribute %q in the %s of %q of %q", key, change.Operation, change.Attribute.Type, entry.DN)
+			}
+			change.Attribute.Vals = append(change.Attribute.Vals, value)
+		}
+		if change != nil {
+			entry.Changes = append(entry.Changes, *change)
+		}
+		if len(entry.Changes) == 0 {
+			return nil, fmt.Errorf("modification of %q has no changes", entry.DN)
+		}
+
+	case changeTypeDelete:
+		if len(lines) > 0 {
+			return nil, fmt.Errorf("deletion of %q can't have attributes", entry.DN)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported changetype %q of %q", entry.ChangeType, entry.DN)
+	}
+
+	return entry, nil
+}
+
+// parseLDIFLine parses an "attr: value" or base64 encoded "attr:: value" line
+func parseLDIFLine(line string) (string, string, error) {
+	i := strings.IndexByte(line, ':')
+	if i <= 0 {
+		return "", "", fmt.Errorf("invalid LDIF line %q", line)
+	}
+	key, value := line[:i], line[i+1:]
+	switch {
+	case strings.HasPrefix(value, ":"):
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value[1:]))
+		if err != nil {
+			return "", "", fmt.Errorf("invalid base64 value of %q", key)
+		}
+		return key, string(decoded), nil
+	case strings.HasPrefix(value, "<"):
+		return "", "", fmt.Errorf("values read from URLs are not supported, for %q", key)
+	}
+	return key, strings.TrimLeft(value, " "), nil
+}
+
+// applyLDIF applies change records in order. With ignoreMissing, records
+// whose entry doesn't exist are skipped, so that deletions can be retried.
+func applyLDIF(conn ldaputil.Connection, entries []*ldifEntry, ignoreMissing bool) error {
+	for _, entry := range entries {
+		var err error
+		switch entry.ChangeType {
+		case changeTypeAdd:
+			req := goldap.NewAddRequest(entry.DN, nil)
+			req.Attributes = entry.Attributes
+			err = conn.Add(req)
+		case changeTypeModify:
+			req := goldap.NewModifyRequest(entry.DN, nil)
+			for _, change := range entry.Changes {
+				switch change.Operation {
+				case "add":
+					req.Add(change.Attribute.Type, change.Attribute.Vals)
+				case "delete":
+					req.Delete(change.Attribute.Type, change.Attribute.Vals)
+				case "replace":
+					req.Replace(change.Attribute.Type, change.Attribute.Vals)
+				}
+			}
+			err = conn.Modify(req)
+		case changeTypeDelete:
+			err = conn.Del(goldap.NewDelRequest(entry.DN, nil))
+		}
+		if ignoreMissing && goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject) {
+			continue
+		}
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error applying the %s of %q: {{err}}", entry.ChangeType, entry.DN), err)
+		}
+	}
+	return nil
+}
+
+// applyAll applies every change record, ignoring missing entries, and returns
+// the errors of all of them; it is used to clean up after a failure
+func applyAll(conn ldaputil.Connection, entries []*ldifEntry) error {
+	var result error
+	for _, entry := range entries {
+		if err := applyLDIF(conn, []*ldifEntry{entry}, true); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result
+}
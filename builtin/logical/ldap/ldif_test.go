@@ -0,0 +1,77 @@
+package ldap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLDIF(t *testing.T) {
+	entries, err := parseLDIF(`version: 1
+
+# A user
+dn: cn=alice,ou=users,
+ dc=example,dc=org
+objectClass: top
+objectClass: inetOrgPerson
+description:: aGVsbG8gd29ybGQ=
+cn: alice
+
+dn: cn=admins,dc=example,dc=org
+changetype: modify
+add: member
+member: cn=alice,ou=users,dc=example,dc=org
+-
+delete: description
+-
+
+dn: cn=bob,dc=example,dc=org
+changetype: delete
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("bad: %#v", entries)
+	}
+
+	add := entries[0]
+	if add.DN != "cn=alice,ou=users,dc=example,dc=org" || add.ChangeType != changeTypeAdd {
+		t.Fatalf("bad: %#v", add)
+	}
+	attrs := map[string][]string{}
+	for _, attr := range add.Attributes {
+		attrs[attr.Type] = attr.Vals
+	}
+	expected := map[string][]string{
+		"objectClass": {"top", "inetOrgPerson"},
+		"description": {"hello world"},
+		"cn":          {"alice"},
+	}
+	if !reflect.DeepEqual(attrs, expected) {
+		t.Fatalf("bad: %#v", attrs)
+	}
+
+	modify := entries[1]
+	if modify.ChangeType != changeTypeModify || len(modify.Changes) != 2 ||
+		modify.Changes[0].Operation != "add" || modify.Changes[0].Attribute.Vals[0] != "cn=alice,ou=users,dc=example,dc=org" ||
+		modify.Changes[1].Operation != "delete" || len(modify.Changes[1].Attribute.Vals) != 0 {
+		t.Fatalf("bad: %#v", modify)
+	}
+
+	if entries[2].ChangeType != changeTypeDelete || entries[2].DN != "cn=bob,dc=example,dc=org" {
+		t.Fatalf("bad: %#v", entries[2])
+	}
+
+	for _, ldif := range []string{
+		"cn: alice",
+		"dn: cn=alice\nchangetype: rename",
+		"dn: cn=alice\nchangetype: delete\ncn: alice",
+		"dn: cn=alice\nchangetype: modify\nreplace: cn\nsn: alice\n-",
+		"dn: cn=alice\njpegPhoto:< file:///photo.jpg",
+		"dn: cn=alice",
+	} {
+		if _, err := parseLDIF(ldif); err == nil {
+			t.Fatalf("expected an error parsing %q", ldif)
+		}
+	}
+}
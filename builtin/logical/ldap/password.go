@@ -0,0 +1,50 @@
+package ldap
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+// passwordChars are the characters of generated passwords, which are safe in
+// LDIF values and DNs
+const passwordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// generatePassword returns a random password of a length. It starts with a
+// lowercase and uppercase letter and a digit for the sake of complexity
+// policies.
+func generatePassword(length int) (string, error) {
+	password := make([]byte, length)
+	for i := range password {
+		chars := passwordChars
+		switch i {
+		case 0:
+			chars = passwordChars[:26]
+		case 1:
+			chars = passwordChars[26:52]
+		case 2:
+			chars = passwordChars[52:]
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = chars[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// passwordAttribute returns the attribute and value a password is set with in
+// a schema. Active Directory takes the password quoted and encoded in
+// UTF-16LE.
+func passwordAttribute(schema, password string) (string, string, error) {
+	if schema != schemaAD {
+		return "userPassword", password, nil
+	}
+	encoded, err := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().String(`"` + password + `"`)
+	if err != nil {
+		return "", "", err
+	}
+	return "unicodePwd", encoded, nil
+}
@@ -0,0 +1,152 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/ldaputil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const configPath = "config"
+
+const (
+	schemaOpenLDAP = "openldap"
+	schemaAD       = "ad"
+
+	// defaultPasswordLength is long enough for most password policies
+	defaultPasswordLength = 64
+
+	// minPasswordLength is the shortest password considered strong enough
+	minPasswordLength = 14
+)
+
+var errNotConfigured = errors.New("the ldap backend is not configured")
+
+func pathConfig(b *backend) *framework.Path {
+	fields := ldaputil.ConfigFields()
+	fields["schema"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Default:     schemaOpenLDAP,
+		Description: `The schema of the directory, which decides how passwords are set: "openldap" sets userPassword, "ad" sets unicodePwd.`,
+	}
+	fields["password_length"] = &framework.FieldSchema{
+		Type:        framework.TypeInt,
+		Default:     defaultPasswordLength,
+		Description: "The length of the passwords Vault generates.",
+	}
+
+	return &framework.Path{
+		Pattern: configPath,
+		Fields:  fields,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.UpdateOperation: b.pathConfigWrite,
+			logical.DeleteOperation: b.pathConfigDelete,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+// config returns the configuration, or nil if the backend is not configured
+func (b *backend) config(ctx context.Context, s logical.Storage) (*ldapConfig, error) {
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	conf := &ldapConfig{
+		ConfigEntry: &ldaputil.ConfigEntry{},
+	}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, errwrap.Wrapf("error reading ldap configuration: {{err}}", err)
+	}
+	return conf, nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, nil
+	}
+
+	// The bind password is never returned
+	respData := conf.PasswordlessMap()
+	respData["schema"] = conf.Schema
+	respData["password_length"] = conf.PasswordLength
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entry, err := ldaputil.NewConfigEntry(data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	conf := &ldapConfig{
+		ConfigEntry:    entry,
+		Schema:         data.Get("schema").(string),
+		PasswordLength: data.Get("password_length").(int),
+	}
+
+	switch {
+	case conf.BindDN == "":
+		return logical.ErrorResponse("binddn is required"), nil
+	case conf.BindPassword == "":
+		return logical.ErrorResponse("bindpass is required"), nil
+	case conf.Schema != schemaOpenLDAP && conf.Schema != schemaAD:
+		return logical.ErrorResponse(fmt.Sprintf("schema must be %q or %q", schemaOpenLDAP, schemaAD)), nil
+	case conf.PasswordLength < minPasswordLength:
+		return logical.ErrorResponse(fmt.Sprintf("password_length can't be less than %d", minPasswordLength)), nil
+	}
+	if err := conf.Validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	storageEntry, err := logical.StorageEntryJSON(configPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, storageEntry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type ldapConfig struct {
+	*ldaputil.ConfigEntry
+
+	Schema         string `json:"schema"`
+	PasswordLength int    `json:"password_length"`
+}
+
+const pathConfigHelpSyn = `
+Configure the LDAP directory credentials are managed in.
+`
+
+const pathConfigHelpDesc = `
+This path configures the connection to the directory and the DN Vault binds
+with, which must be allowed to add and delete the entries of the dynamic
+roles and to set the passwords of the entries of the static roles. The schema
+decides which attribute passwords are set in.
+`
@@ -0,0 +1,144 @@
+package ldap
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// maxUsernameLen is the length usernames are truncated to
+const maxUsernameLen = 64
+
+// invalidUsernameChars matches what can't appear in generated usernames,
+// which are used in DNs and LDIF values
+var invalidUsernameChars = regexp.MustCompile("[^a-zA-Z0-9_-]+")
+
+func pathCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the dynamic role.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCredsRead,
+		},
+
+		HelpSynopsis:    pathCredsHelpSyn,
+		HelpDescription: pathCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", roleName)), nil
+	}
+
+	conn, conf, err := b.conn(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	password, err := generatePassword(conf.PasswordLength)
+	if err != nil {
+		return nil, err
+	}
+	tmplData := ldifTemplateData{
+		Username:    generateUsername(req.DisplayName, roleName),
+		Password:    password,
+		DisplayName: req.DisplayName,
+		RoleName:    roleName,
+	}
+
+	creation, err := renderLDIF(role.CreationLDIF, tmplData)
+	if err != nil {
+		return nil, err
+	}
+
+	// The deletion is rendered now, so that the lease can be revoked even if
+	// the role changes or is deleted
+	tmplData.Password = ""
+	deletion, err := renderLDIFString(role.DeletionLDIF, tmplData)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseLDIF(deletion); err != nil {
+		return nil, err
+	}
+
+	if err := applyLDIF(conn, creation, false); err != nil {
+		rollbackTmpl := role.RollbackLDIF
+		if rollbackTmpl == "" {
+			rollbackTmpl = role.DeletionLDIF
+		}
+		rollback, rbErr := renderLDIF(rollbackTmpl, tmplData)
+		if rbErr == nil {
+			rbErr = applyAll(conn, rollback)
+		}
+		if rbErr != nil {
+			b.Logger().Error("error rolling back the creation of a user", "username", tmplData.Username, "error", rbErr)
+		}
+		return nil, err
+	}
+
+	var dns []string
+	for _, entry := range creation {
+		if entry.ChangeType == changeTypeAdd {
+			dns = append(dns, entry.DN)
+		}
+	}
+
+	ttl, maxTTL, warnings := framework.LeaseTTLs(b.System(), role.TTL, role.MaxTTL)
+	resp := b.Secret(SecretDynamicCredsType).Response(map[string]interface{}{
+		"username":            tmplData.Username,
+		"password":            password,
+		"distinguished_names": dns,
+	}, map[string]interface{}{
+		"role":          roleName,
+		"deletion_ldif": deletion,
+	})
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = maxTTL
+	resp.Warnings = warnings
+
+	return resp, nil
+}
+
+// generateUsername returns a unique username for a lease of a role
+func generateUsername(displayName, roleName string) string {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		id = fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	username := fmt.Sprintf("v_%s_%s_%s_%d", displayName, roleName, id[:8], time.Now().Unix())
+	username = invalidUsernameChars.ReplaceAllString(username, "_")
+	if len(username) > maxUsernameLen {
+		username = username[:maxUsernameLen]
+	}
+	return username
+}
+
+const pathCredsHelpSyn = `
+Create a user for a dynamic role.
+`
+
+const pathCredsHelpDesc = `
+This path creates a user with a generated username and password by applying
+the creation LDIF of the role. The changes of the deletion LDIF are applied
+when the lease is revoked or expires.
+`
@@ -0,0 +1,222 @@
+package ldap
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const rolePrefix = "role/"
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "role/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "role/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"creation_ldif": {
+				Type:        framework.TypeString,
+				Description: "The LDIF template of the changes creating the user of a lease. It can use {{.Username}}, {{.Password}}, {{.DisplayName}} and {{.RoleName}}.",
+			},
+
+			"deletion_ldif": {
+				Type:        framework.TypeString,
+				Description: "The LDIF template of the changes deleting the user of a lease when it is revoked. It can use {{.Username}}, {{.DisplayName}} and {{.RoleName}}.",
+			},
+
+			"rollback_ldif": {
+				Type:        framework.TypeString,
+				Description: "The LDIF template of the changes undoing a creation that failed. Defaults to the deletion LDIF.",
+			},
+
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The default TTL of the users. Defaults to the default lease TTL of the mount.",
+			},
+
+			"max_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The maximum TTL of the users. Defaults to the maximum lease TTL of the mount.",
+			},
+		},
+
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.CreateOperation: b.pathRoleWrite,
+			logical.UpdateOperation: b.pathRoleWrite,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+// role returns a dynamic role, or nil if it doesn't exist
+func (b *backend) role(ctx context.Context, s logical.Storage, name string) (*roleEntry, error) {
+	entry, err := s.Get(ctx, rolePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role roleEntry
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, errwrap.Wrapf("error reading role: {{err}}", err)
+	}
+	return &role, nil
+}
+
+func (b *backend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, rolePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"creation_ldif": role.CreationLDIF,
+			"deletion_ldif": role.DeletionLDIF,
+			"rollback_ldif": role.RollbackLDIF,
+			"ttl":           int64(role.TTL.Seconds()),
+			"max_ttl":       int64(role.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleEntry{}
+	}
+
+	if v, ok := data.GetOk("creation_ldif"); ok {
+		role.CreationLDIF = v.(string)
+	}
+	if v, ok := data.GetOk("deletion_ldif"); ok {
+		role.DeletionLDIF = v.(string)
+	}
+	if v, ok := data.GetOk("rollback_ldif"); ok {
+		role.RollbackLDIF = v.(string)
+	}
+	if v, ok := data.GetOk("ttl"); ok {
+		role.TTL = time.Duration(v.(int)) * time.Second
+	}
+	if v, ok := data.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Duration(v.(int)) * time.Second
+	}
+
+	switch {
+	case role.CreationLDIF == "":
+		return logical.ErrorResponse("creation_ldif is required"), nil
+	case role.DeletionLDIF == "":
+		return logical.ErrorResponse("deletion_ldif is required"), nil
+	}
+	for field, tmpl := range map[string]string{
+		"creation_ldif": role.CreationLDIF,
+		"deletion_ldif": role.DeletionLDIF,
+		"rollback_ldif": role.RollbackLDIF,
+	} {
+		if tmpl == "" {
+			continue
+		}
+		if err := validateLDIF(tmpl); err != nil {
+			return logical.ErrorResponse(field + ": " + err.Error()), nil
+		}
+	}
+
+	if role.TTL < 0 || role.MaxTTL < 0 {
+		return logical.ErrorResponse("ttl and max_ttl can't be negative"), nil
+	}
+	if role.MaxTTL > 0 && role.TTL > role.MaxTTL {
+		return logical.ErrorResponse("ttl can't be greater than max_ttl"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(rolePrefix+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, rolePrefix+data.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type roleEntry struct {
+	CreationLDIF string        `json:"creation_ldif"`
+	DeletionLDIF string        `json:"deletion_ldif"`
+	RollbackLDIF string        `json:"rollback_ldif"`
+	TTL          time.Duration `json:"ttl"`
+	MaxTTL       time.Duration `json:"max_ttl"`
+}
+
+const pathRoleHelpSyn = `
+Manage the dynamic roles users are created for.
+`
+
+const pathRoleHelpDesc = `
+This path manages the dynamic roles of the backend. Each lease of a role
+applies the changes of its creation LDIF, which typically add a user entry
+with the generated username and password and add it to groups, and the
+changes of its deletion LDIF are applied when the lease is revoked. If the
+creation fails, the changes of the rollback LDIF, or of the deletion LDIF if
+unset, are applied to clean up what was created.
+
+The templates are Go templates rendered with {{.Username}}, {{.Password}},
+{{.DisplayName}} and {{.RoleName}}, and are given as LDIF change records.
+Records without a changetype add an entry.
+`
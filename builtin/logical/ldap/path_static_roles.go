@@ -0,0 +1,384 @@
+package ldap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goldap "github.com/go-ldap/ldap"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const staticRolePrefix = "static-role/"
+
+// minRotationPeriod is the shortest rotation period of static roles, as
+// rotations are checked about every minute
+const minRotationPeriod = time.Minute
+
+func pathListStaticRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-role/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathStaticRoleList,
+		},
+
+		HelpSynopsis:    pathStaticRoleHelpSyn,
+		HelpDescription: pathStaticRoleHelpDesc,
+	}
+}
+
+func pathStaticRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-role/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the static role.",
+			},
+
+			"dn": {
+				Type:        framework.TypeString,
+				Description: "The DN of the existing entry whose password is managed.",
+			},
+
+			"username": {
+				Type:        framework.TypeString,
+				Description: "The username of the entry, returned with its password.",
+			},
+
+			"rotation_period": {
+				Type:        framework.TypeDurationSecond,
+				Description: "How often the password is rotated. Can't be less than a minute.",
+			},
+		},
+
+		ExistenceCheck: b.pathStaticRoleExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathStaticRoleRead,
+			logical.CreateOperation: b.pathStaticRoleWrite,
+			logical.UpdateOperation: b.pathStaticRoleWrite,
+			logical.DeleteOperation: b.pathStaticRoleDelete,
+		},
+
+		HelpSynopsis:    pathStaticRoleHelpSyn,
+		HelpDescription: pathStaticRoleHelpDesc,
+	}
+}
+
+func pathStaticCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-cred/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the static role.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathStaticCredsRead,
+		},
+
+		HelpSynopsis:    pathStaticCredsHelpSyn,
+		HelpDescription: pathStaticCredsHelpDesc,
+	}
+}
+
+func pathRotateRole(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "rotate-role/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the static role.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathRotateRoleWrite,
+		},
+
+		HelpSynopsis:    pathRotateRoleHelpSyn,
+		HelpDescription: pathRotateRoleHelpDesc,
+	}
+}
+
+// staticRole returns a static role, or nil if it doesn't exist
+func (b *backend) staticRole(ctx context.Context, s logical.Storage, name string) (*staticRoleEntry, error) {
+	entry, err := s.Get(ctx, staticRolePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role staticRoleEntry
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, errwrap.Wrapf("error reading static role: {{err}}", err)
+	}
+	return &role, nil
+}
+
+func (b *backend) putStaticRole(ctx context.Context, s logical.Storage, name string, role *staticRoleEntry) error {
+	entry, err := logical.StorageEntryJSON(staticRolePrefix+name, role)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+func (b *backend) pathStaticRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.staticRole(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) pathStaticRoleList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, staticRolePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathStaticRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	role, err := b.staticRole(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	// The password is only returned by static-cred
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"dn":                  role.DN,
+			"username":            role.Username,
+			"rotation_period":     int64(role.RotationPeriod.Seconds()),
+			"last_vault_rotation": role.LastVaultRotation,
+		},
+	}, nil
+}
+
+func (b *backend) pathStaticRoleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	b.rotationLock.Lock()
+	defer b.rotationLock.Unlock()
+
+	role, err := b.staticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	created := role == nil
+	if created {
+		role = &staticRoleEntry{}
+	}
+	previousDN := role.DN
+
+	if v, ok := data.GetOk("dn"); ok {
+		role.DN = v.(string)
+	}
+	if v, ok := data.GetOk("username"); ok {
+		role.Username = v.(string)
+	}
+	if v, ok := data.GetOk("rotation_period"); ok {
+		role.RotationPeriod = time.Duration(v.(int)) * time.Second
+	}
+
+	switch {
+	case role.DN == "":
+		return logical.ErrorResponse("dn is required"), nil
+	case role.RotationPeriod < minRotationPeriod:
+		return logical.ErrorResponse(fmt.Sprintf("rotation_period can't be less than %s", minRotationPeriod)), nil
+	}
+
+	// The password of a new entry is rotated right away, so that Vault knows
+	// it
+	if created || role.DN != previousDN {
+		if err := b.rotateStaticRole(ctx, req.Storage, role); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.putStaticRole(ctx, req.Storage, name, role); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathStaticRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.rotationLock.Lock()
+	defer b.rotationLock.Unlock()
+
+	if err := req.Storage.Delete(ctx, staticRolePrefix+data.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathStaticCredsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.staticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("static role %q not found", name)), nil
+	}
+
+	ttl := time.Until(role.LastVaultRotation.Add(role.RotationPeriod))
+	if ttl < 0 {
+		ttl = 0
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"dn":                  role.DN,
+			"username":            role.Username,
+			"password":            role.Password,
+			"last_password":       role.LastPassword,
+			"last_vault_rotation": role.LastVaultRotation,
+			"rotation_period":     int64(role.RotationPeriod.Seconds()),
+			"ttl":                 int64(ttl.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRotateRoleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	b.rotationLock.Lock()
+	defer b.rotationLock.Unlock()
+
+	role, err := b.staticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("static role %q not found", name)), nil
+	}
+
+	if err := b.rotateStaticRole(ctx, req.Storage, role); err != nil {
+		return nil, err
+	}
+	if err := b.putStaticRole(ctx, req.Storage, name, role); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// rotateStaticRole sets a new password on the entry of a static role, and
+// updates the role, which the caller stores. The rotation lock must be held.
+func (b *backend) rotateStaticRole(ctx context.Context, s logical.Storage, role *staticRoleEntry) error {
+	conn, conf, err := b.conn(ctx, s)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	password, err := generatePassword(conf.PasswordLength)
+	if err != nil {
+		return err
+	}
+	attr, value, err := passwordAttribute(conf.Schema, password)
+	if err != nil {
+		return err
+	}
+
+	entry := &ldifEntry{
+		DN:         role.DN,
+		ChangeType: changeTypeModify,
+		Changes: []ldifChange{
+			{Operation: "replace", Attribute: goldap.PartialAttribute{Type: attr, Vals: []string{value}}},
+		},
+	}
+	if err := applyLDIF(conn, []*ldifEntry{entry}, false); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("error rotating the password of %q: {{err}}", role.DN), err)
+	}
+
+	role.LastPassword = role.Password
+	role.Password = password
+	role.LastVaultRotation = time.Now().UTC()
+	return nil
+}
+
+// rotateExpiredStaticRoles rotates the passwords of the static roles whose
+// rotation period elapsed. Failures are logged and retried on the next run.
+func (b *backend) rotateExpiredStaticRoles(ctx context.Context, s logical.Storage) {
+	names, err := s.List(ctx, staticRolePrefix)
+	if err != nil {
+		b.Logger().Error("error listing static roles", "error", err)
+		return
+	}
+
+	b.rotationLock.Lock()
+	defer b.rotationLock.Unlock()
+
+	for _, name := range names {
+		role, err := b.staticRole(ctx, s, name)
+		if err != nil {
+			b.Logger().Error("error reading static role", "name", name, "error", err)
+			continue
+		}
+		if role == nil || time.Now().Before(role.LastVaultRotation.Add(role.RotationPeriod)) {
+			continue
+		}
+
+		if err := b.rotateStaticRole(ctx, s, role); err != nil {
+			b.Logger().Error("error rotating the password of a static role", "name", name, "error", err)
+			continue
+		}
+		if err := b.putStaticRole(ctx, s, name, role); err != nil {
+			// The directory has a password Vault lost; the next run rotates it
+			// again
+			b.Logger().Error("error storing the rotated password of a static role", "name", name, "error", err)
+		}
+	}
+}
+
+type staticRoleEntry struct {
+	DN                string        `json:"dn"`
+	Username          string        `json:"username"`
+	RotationPeriod    time.Duration `json:"rotation_period"`
+	Password          string        `json:"password"`
+	LastPassword      string        `json:"last_password"`
+	LastVaultRotation time.Time     `json:"last_vault_rotation"`
+}
+
+const pathStaticRoleHelpSyn = `
+Manage the static roles whose passwords are rotated.
+`
+
+const pathStaticRoleHelpDesc = `
+This path manages the static roles of the backend. Each static role manages
+the password of an existing entry of the directory, which Vault sets when the
+role is created and rotates every rotation period. The current password is
+read from the static-cred path.
+`
+
+const pathStaticCredsHelpSyn = `
+Read the current password of a static role.
+`
+
+const pathStaticCredsHelpDesc = `
+This path returns the current and previous passwords of the entry of a static
+role, along with the time left until the next rotation.
+`
+
+const pathRotateRoleHelpSyn = `
+Rotate the password of a static role.
+`
+
+const pathRotateRoleHelpDesc = `
+This path sets a new password on the entry of a static role right away, and
+restarts its rotation period.
+`
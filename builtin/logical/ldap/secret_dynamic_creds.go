@@ -0,0 +1,74 @@
+package ldap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const SecretDynamicCredsType = "dynamic_creds"
+
+func secretDynamicCreds(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretDynamicCredsType,
+		Fields: map[string]*framework.FieldSchema{
+			"username": {
+				Type:        framework.TypeString,
+				Description: "Username of the user",
+			},
+			"password": {
+				Type:        framework.TypeString,
+				Description: "Password of the user",
+			},
+		},
+
+		Renew:  b.secretDynamicCredsRenew,
+		Revoke: b.secretDynamicCredsRevoke,
+	}
+}
+
+// secretDynamicCredsRenew extends the lease with the TTLs of its role
+func (b *backend) secretDynamicCredsRenew(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName, _ := req.Secret.InternalData["role"].(string)
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, fmt.Errorf("role %q of the lease not found", roleName)
+	}
+
+	ttl, maxTTL, warnings := framework.LeaseTTLs(b.System(), role.TTL, role.MaxTTL)
+	resp := &logical.Response{Secret: req.Secret}
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = maxTTL
+	resp.Warnings = warnings
+	return resp, nil
+}
+
+// secretDynamicCredsRevoke applies the deletion LDIF rendered when the user
+// was created. Entries deleted out of band are skipped.
+func (b *backend) secretDynamicCredsRevoke(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	deletion, ok := req.Secret.InternalData["deletion_ldif"].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret is missing the deletion_ldif internal data")
+	}
+	entries, err := parseLDIF(deletion)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, _, err := b.conn(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := applyLDIF(conn, entries, true); err != nil {
+		return nil, errwrap.Wrapf("could not delete user: {{err}}", err)
+	}
+	return nil, nil
+}
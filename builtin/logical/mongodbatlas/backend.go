@@ -0,0 +1,74 @@
+package mongodbatlas
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				configPath,
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfig(&b),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathCreds(&b),
+		},
+
+		Secrets: []*framework.Secret{
+			secretProgrammaticAPIKey(&b),
+		},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+}
+
+// client returns a client of the Atlas API authenticated with the configured
+// API key
+func (b *backend) client(ctx context.Context, s logical.Storage) (*atlasClient, error) {
+	conf, err := b.config(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, errNotConfigured
+	}
+	httpClient, err := logical.OutboundHTTPClient(b.System())
+	if err != nil {
+		return nil, err
+	}
+	return newAtlasClient(conf, httpClient), nil
+}
+
+const backendHelp = `
+The mongodbatlas backend creates short-lived programmatic API keys for MongoDB
+Atlas.
+
+Each role grants the keys it creates roles in an organization or in a project,
+and restricts the addresses they can be used from. The keys are deleted when
+their lease is revoked.
+`
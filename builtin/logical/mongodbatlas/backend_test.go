@@ -0,0 +1,522 @@
+package mongodbatlas
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	logicaltest "github.com/hashicorp/vault/logical/testing"
+)
+
+// fakeAtlasServer is an Atlas API keeping the API keys created through it,
+// which authenticates requests with digest authentication
+type fakeAtlasServer struct {
+	*httptest.Server
+
+	l          sync.Mutex
+	keys       map[string]map[string]interface{}
+	accessList map[string][]accessListEntry
+
+	// failAccessList makes the creation of access lists fail
+	failAccessList bool
+}
+
+func newFakeAtlasServer(t *testing.T) *fakeAtlasServer {
+	s := &fakeAtlasServer{
+		keys:       make(map[string]map[string]interface{}),
+		accessList: make(map[string][]accessListEntry),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !checkDigest(r, "public", "private") {
+			w.Header().Set("WWW-Authenticate", `Digest realm="MMS Public API", domain="", nonce="n0nce", algorithm=MD5, qop="auth", stale=false`)
+			writeError(w, http.StatusUnauthorized, "You are not authorized for this resource.")
+			return
+		}
+
+		s.l.Lock()
+		defer s.l.Unlock()
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "apiKeys":
+			var in map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			id := fmt.Sprintf("key%d", len(s.keys)+1)
+			in["parent"] = parts[0] + "/" + parts[1]
+			s.keys[id] = in
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":         id,
+				"desc":       in["desc"],
+				"publicKey":  "pub-" + id,
+				"privateKey": "priv-" + id,
+			})
+
+		case r.Method == http.MethodPost && len(parts) == 5 && parts[4] == "whitelist":
+			if s.failAccessList {
+				writeError(w, http.StatusBadRequest, "Invalid IP address.")
+				return
+			}
+			if _, ok := s.keys[parts[3]]; !ok {
+				writeError(w, http.StatusNotFound, "API key not found.")
+				return
+			}
+			var entries []accessListEntry
+			if err := json.NewDecoder(r.Body).Decode(&entries); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.accessList[parts[3]] = append(s.accessList[parts[3]], entries...)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"results":[]}`))
+
+		case r.Method == http.MethodDelete && len(parts) == 4 && parts[0] == "orgs":
+			if _, ok := s.keys[parts[3]]; !ok {
+				writeError(w, http.StatusNotFound, "API key not found.")
+				return
+			}
+			delete(s.keys, parts[3])
+			delete(s.accessList, parts[3])
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	}))
+	return s
+}
+
+// checkDigest verifies the digest authorization of a request
+func checkDigest(r *http.Request, username, password string) bool {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Digest ") {
+		return false
+	}
+	params := parseChallenge(strings.TrimPrefix(authorization, "Digest "))
+	if params["username"] != username || params["nonce"] != "n0nce" || params["uri"] != r.URL.RequestURI() {
+		return false
+	}
+	sum := func(s string) string {
+		h := md5.Sum([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+	ha1 := sum(username + ":" + params["realm"] + ":" + password)
+	ha2 := sum(r.Method + ":" + params["uri"])
+	return params["response"] == sum(strings.Join([]string{ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2}, ":"))
+}
+
+func writeError(w http.ResponseWriter, code int, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"detail": detail,
+		"error":  code,
+		"reason": http.StatusText(code),
+	})
+}
+
+func (s *fakeAtlasServer) key(id string) (map[string]interface{}, []accessListEntry) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.keys[id], s.accessList[id]
+}
+
+func (s *fakeAtlasServer) keyCount() int {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return len(s.keys)
+}
+
+func getBackend(t *testing.T) (*backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func TestBackend_config(t *testing.T) {
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepConfig(t, map[string]interface{}{
+				"private_key": "private",
+			}, true),
+			testAccStepConfig(t, map[string]interface{}{
+				"public_key":  "public",
+				"private_key": "private",
+				"base_url":    "cloud.mongodb.com",
+			}, true),
+			testAccStepConfig(t, map[string]interface{}{
+				"public_key":  "public",
+				"private_key": "private",
+			}, false),
+			testAccStepReadConfig(t, "public", defaultBaseURL),
+		},
+	})
+}
+
+func TestBackend_roles(t *testing.T) {
+	steps := []logicaltest.TestStep{}
+	for _, data := range []map[string]interface{}{
+		// No organization
+		{
+			"roles": "ORG_READ_ONLY",
+		},
+		// No roles
+		{
+			"organization_id": "org",
+		},
+		// Project role without project
+		{
+			"organization_id": "org",
+			"roles":           "GROUP_READ_ONLY",
+		},
+		// Organization role with project
+		{
+			"organization_id": "org",
+			"project_id":      "project",
+			"roles":           "ORG_READ_ONLY",
+		},
+		// Invalid IP address
+		{
+			"organization_id": "org",
+			"roles":           "ORG_READ_ONLY",
+			"ip_addresses":    "10.0.0.300",
+		},
+		// Invalid CIDR block
+		{
+			"organization_id": "org",
+			"roles":           "ORG_READ_ONLY",
+			"cidr_blocks":     "10.0.0.0",
+		},
+		// ttl over max_ttl
+		{
+			"organization_id": "org",
+			"roles":           "ORG_READ_ONLY",
+			"ttl":             "2h",
+			"max_ttl":         "1h",
+		},
+	} {
+		steps = append(steps, testAccStepWriteRole(t, "bad", data, true))
+	}
+
+	steps = append(steps,
+		testAccStepWriteRole(t, "readonly", map[string]interface{}{
+			"organization_id": "org",
+			"project_id":      "project",
+			"roles":           "group_read_only,GROUP_DATA_ACCESS_READ_ONLY",
+			"cidr_blocks":     "10.0.0.0/8",
+			"ttl":             "30m",
+		}, false),
+		testAccStepReadRole(t, "readonly", map[string]interface{}{
+			"roles": []string{"GROUP_DATA_ACCESS_READ_ONLY", "GROUP_READ_ONLY"},
+			"ttl":   int64(1800),
+		}),
+		testAccStepListRoles(t, []string{"readonly"}),
+		testAccStepDeleteRole(t, "readonly"),
+		testAccStepReadRole(t, "readonly", nil),
+	)
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps:   steps,
+	})
+}
+
+func TestBackend_creds(t *testing.T) {
+	server := newFakeAtlasServer(t)
+	defer server.Close()
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepWriteRole(t, "project", map[string]interface{}{
+				"organization_id": "org",
+				"project_id":      "project",
+				"roles":           "GROUP_READ_ONLY",
+				"ip_addresses":    "192.0.2.10",
+				"cidr_blocks":     "10.0.0.0/8",
+				"ttl":             "30m",
+				"max_ttl":         "2h",
+			}, false),
+			testAccStepReadCreds(t, "project", true, nil),
+
+			testAccStepConfig(t, map[string]interface{}{
+				"public_key":  "public",
+				"private_key": "private",
+				"base_url":    server.URL,
+			}, false),
+			testAccStepReadCreds(t, "missing", true, nil),
+			testAccStepReadCreds(t, "project", false, func(resp *logical.Response) error {
+				if resp.Data["public_key"] != "pub-key1" || resp.Data["private_key"] != "priv-key1" {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				if resp.Secret.TTL != 30*time.Minute || resp.Secret.MaxTTL != 2*time.Hour {
+					return fmt.Errorf("bad: %#v", resp.Secret)
+				}
+				key, accessList := server.key("key1")
+				if key["parent"] != "groups/project" || !strings.HasPrefix(key["desc"].(string), "vault-project-") {
+					return fmt.Errorf("bad: %#v", key)
+				}
+				if roles := key["roles"].([]interface{}); len(roles) != 1 || roles[0] != "GROUP_READ_ONLY" {
+					return fmt.Errorf("bad: %#v", key)
+				}
+				if len(accessList) != 2 || accessList[0].IPAddress != "192.0.2.10" || accessList[1].CIDRBlock != "10.0.0.0/8" {
+					return fmt.Errorf("bad: %#v", accessList)
+				}
+				return nil
+			}),
+
+			// The key is deleted if its access list can't be created
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "creds/project",
+				PreFlight: func(req *logical.Request) error {
+					server.l.Lock()
+					defer server.l.Unlock()
+					server.failAccessList = true
+					return nil
+				},
+				ErrorOk: true,
+				Check: func(resp *logical.Response) error {
+					if resp != nil && resp.Secret != nil {
+						return fmt.Errorf("expected an error, got %#v", resp)
+					}
+					if n := server.keyCount(); n != 1 {
+						return fmt.Errorf("the API key of the failed lease was not deleted: %d keys", n)
+					}
+					return nil
+				},
+			},
+		},
+	})
+
+	// The lease was revoked at the end of the test case
+	if n := server.keyCount(); n != 0 {
+		t.Fatalf("the API key of the lease was not deleted: %d keys", n)
+	}
+}
+
+func TestBackend_renewRevoke(t *testing.T) {
+	server := newFakeAtlasServer(t)
+	defer server.Close()
+
+	b, storage := getBackend(t)
+
+	configReq := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"public_key":  "public",
+			"private_key": "private",
+			"base_url":    server.URL,
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), configReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/org",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"organization_id": "org",
+			"roles":           "ORG_READ_ONLY",
+			"ttl":             "30m",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	credsReq := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/org",
+		Storage:   storage,
+	}
+	resp, err = b.HandleRequest(context.Background(), credsReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	secret := resp.Secret
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RenewOperation,
+		Storage:   storage,
+		Secret:    secret,
+	})
+	if err != nil || resp.Secret.TTL != 30*time.Minute || resp.Secret.MaxTTL != 48*time.Hour {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	revokeReq := &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    secret,
+	}
+	if _, err := b.HandleRequest(context.Background(), revokeReq); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.keyCount(); n != 0 {
+		t.Fatalf("the API key of the lease was not deleted: %d keys", n)
+	}
+
+	// A key deleted out of band doesn't fail the revocation
+	if _, err := b.HandleRequest(context.Background(), revokeReq); err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests are rejected with the wrong key
+	configReq.Data = map[string]interface{}{
+		"private_key": "wrong",
+	}
+	resp, err = b.HandleRequest(context.Background(), configReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	if _, err := b.HandleRequest(context.Background(), credsReq); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected an authentication error, got: %v", err)
+	}
+}
+
+func testAccStepConfig(t *testing.T, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepReadConfig(t *testing.T, publicKey, baseURL string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "config",
+		Check: func(resp *logical.Response) error {
+			if resp == nil || resp.Data["public_key"] != publicKey || resp.Data["base_url"] != baseURL {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+			if _, ok := resp.Data["private_key"]; ok {
+				return fmt.Errorf("the private key was returned")
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepWriteRole(t *testing.T, name string, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/" + name,
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+// testAccStepReadRole checks the given fields of a role, or that it doesn't
+// exist if expected is nil
+func testAccStepReadRole(t *testing.T, name string, expected map[string]interface{}) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "roles/" + name,
+		Check: func(resp *logical.Response) error {
+			if expected == nil {
+				if resp != nil {
+					return fmt.Errorf("bad: %#v", resp)
+				}
+				return nil
+			}
+			if resp == nil {
+				return fmt.Errorf("role %q not found", name)
+			}
+			for k, v := range expected {
+				if !reflect.DeepEqual(resp.Data[k], v) {
+					return fmt.Errorf("bad %s: %#v", k, resp.Data[k])
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepListRoles(t *testing.T, names []string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ListOperation,
+		Path:      "roles/",
+		Check: func(resp *logical.Response) error {
+			if resp == nil || !reflect.DeepEqual(resp.Data["keys"], names) {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepDeleteRole(t *testing.T, name string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.DeleteOperation,
+		Path:      "roles/" + name,
+	}
+}
+
+func testAccStepReadCreds(t *testing.T, role string, expectFail bool, check logicaltest.TestCheckFunc) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "creds/" + role,
+		ErrorOk:   expectFail,
+		Check:     check,
+	}
+	if expectFail {
+		// Errors of the backend itself don't come with a response
+		step.Check = func(resp *logical.Response) error {
+			if resp != nil && !resp.IsError() {
+				return fmt.Errorf("expected an error, got %#v", resp)
+			}
+			return nil
+		}
+	}
+	return step
+}
+
+func TestParseChallenge(t *testing.T) {
+	params := parseChallenge(`realm="MMS Public API", domain="", nonce="a,b", algorithm=MD5, qop="auth", stale=false`)
+	for k, v := range map[string]string{
+		"realm":     "MMS Public API",
+		"domain":    "",
+		"nonce":     "a,b",
+		"algorithm": "MD5",
+		"qop":       "auth",
+		"stale":     "false",
+	} {
+		if got, ok := params[k]; !ok || got != v {
+			t.Fatalf("bad %s: %q", k, got)
+		}
+	}
+}
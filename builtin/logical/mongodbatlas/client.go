@@ -0,0 +1,276 @@
+package mongodbatlas
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// apiKey is a programmatic API key; the private key is only returned when
+// the key is created
+type apiKey struct {
+	ID         string `json:"id"`
+	PublicKey  string `json:"publicKey"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// apiKeyRequest is the body creating a key. The roles of keys are given by
+// name, but returned as objects.
+type apiKeyRequest struct {
+	Description string   `json:"desc"`
+	Roles       []string `json:"roles"`
+}
+
+type accessListEntry struct {
+	IPAddress string `json:"ipAddress,omitempty"`
+	CIDRBlock string `json:"cidrBlock,omitempty"`
+}
+
+// apiError is an error returned by the Atlas API
+type apiError struct {
+	Code      int    `json:"error"`
+	ErrorCode string `json:"errorCode"`
+	Detail    string `json:"detail"`
+	Reason    string `json:"reason"`
+}
+
+func (e *apiError) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Reason
+	}
+	if e.ErrorCode != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.ErrorCode)
+	}
+	return fmt.Sprintf("atlas API returned %d: %s", e.Code, msg)
+}
+
+// isNotFound returns whether err is the API reporting that an object does
+// not exist
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.Code == http.StatusNotFound
+}
+
+// atlasClient calls the Atlas API with a programmatic API key, which
+// authenticates with HTTP digest authentication
+type atlasClient struct {
+	baseURL    string
+	publicKey  string
+	privateKey string
+	client     *http.Client
+}
+
+func newAtlasClient(conf *atlasConfig, client *http.Client) *atlasClient {
+	return &atlasClient{
+		baseURL:    strings.TrimSuffix(conf.BaseURL, "/"),
+		publicKey:  conf.PublicKey,
+		privateKey: conf.PrivateKey,
+		client:     client,
+	}
+}
+
+// createAPIKey creates a key in an organization, or assigns it to a project
+// if projectID is set
+func (c *atlasClient) createAPIKey(ctx context.Context, orgID, projectID, description string, roles []string) (*apiKey, error) {
+	path := fmt.Sprintf("/orgs/%s/apiKeys", url.PathEscape(orgID))
+	if projectID != "" {
+		path = fmt.Sprintf("/groups/%s/apiKeys", url.PathEscape(projectID))
+	}
+	in := &apiKeyRequest{
+		Description: description,
+		Roles:       roles,
+	}
+	var out apiKey
+	if err := c.do(ctx, http.MethodPost, path, in, &out); err != nil {
+		return nil, err
+	}
+	if out.ID == "" || out.PublicKey == "" || out.PrivateKey == "" {
+		return nil, errors.New("atlas API returned an incomplete API key")
+	}
+	return &out, nil
+}
+
+// addAccessList adds entries to the access list of a key
+func (c *atlasClient) addAccessList(ctx context.Context, orgID, keyID string, entries []accessListEntry) error {
+	path := fmt.Sprintf("/orgs/%s/apiKeys/%s/whitelist", url.PathEscape(orgID), url.PathEscape(keyID))
+	return c.do(ctx, http.MethodPost, path, entries, nil)
+}
+
+// deleteAPIKey deletes a key; a key that doesn't exist is not an error
+func (c *atlasClient) deleteAPIKey(ctx context.Context, orgID, keyID string) error {
+	path := fmt.Sprintf("/orgs/%s/apiKeys/%s", url.PathEscape(orgID), url.PathEscape(keyID))
+	err := c.do(ctx, http.MethodDelete, path, nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *atlasClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Accept", "application/json")
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, nil
+	}
+
+	// The first request is answered with the digest challenge the request is
+	// then sent again with
+	req, err := newRequest()
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+		authorization, err := c.digestAuthorization(req, challenge)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", authorization)
+		if resp, err = c.client.Do(req); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &apiError{}
+		if err := json.Unmarshal(respBody, apiErr); err != nil || (apiErr.Detail == "" && apiErr.Reason == "") {
+			apiErr.Detail = strings.TrimSpace(string(respBody))
+		}
+		apiErr.Code = resp.StatusCode
+		return apiErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return errwrap.Wrapf("error decoding atlas API response: {{err}}", err)
+		}
+	}
+	return nil
+}
+
+// digestAuthorization answers an MD5 digest challenge for a request, as
+// described in RFC 2617
+func (c *atlasClient) digestAuthorization(req *http.Request, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Digest ") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+	params := parseChallenge(strings.TrimPrefix(challenge, "Digest "))
+	if alg := params["algorithm"]; alg != "" && !strings.EqualFold(alg, "MD5") {
+		return "", fmt.Errorf("unsupported digest algorithm %q", alg)
+	}
+	qop := ""
+	for _, q := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+	if params["qop"] != "" && qop == "" {
+		return "", fmt.Errorf("unsupported digest qop %q", params["qop"])
+	}
+
+	cnonceBytes := make([]byte, 16)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", err
+	}
+	cnonce := hex.EncodeToString(cnonceBytes)
+	const nc = "00000001"
+
+	uri := req.URL.RequestURI()
+	ha1 := md5Hex(c.publicKey + ":" + params["realm"] + ":" + c.privateKey)
+	ha2 := md5Hex(req.Method + ":" + uri)
+	var response string
+	if qop == "" {
+		response = md5Hex(ha1 + ":" + params["nonce"] + ":" + ha2)
+	} else {
+		response = md5Hex(strings.Join([]string{ha1, params["nonce"], nc, cnonce, qop, ha2}, ":"))
+	}
+
+	authorization := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s", algorithm=MD5`,
+		c.publicKey, params["realm"], params["nonce"], uri, response)
+	if qop != "" {
+		authorization += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s"`, qop, nc, cnonce)
+	}
+	if opaque, ok := params["opaque"]; ok {
+		authorization += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+	return authorization, nil
+}
+
+// parseChallenge parses the comma-separated key=value parameters of a
+// challenge, whose values may be quoted
+func parseChallenge(in string) map[string]string {
+	params := make(map[string]string)
+	for len(in) > 0 {
+		in = strings.TrimLeft(in, " ,")
+		eq := strings.IndexByte(in, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(in[:eq]))
+		in = in[eq+1:]
+
+		var value string
+		if strings.HasPrefix(in, `"`) {
+			end := strings.IndexByte(in[1:], '"')
+			if end < 0 {
+				value, in = in[1:], ""
+			} else {
+				value, in = in[1:end+1], in[end+2:]
+			}
+		} else if comma := strings.IndexByte(in, ','); comma >= 0 {
+			value, in = strings.TrimSpace(in[:comma]), in[comma:]
+		} else {
+			value, in = strings.TrimSpace(in), ""
+		}
+		params[key] = value
+	}
+	return params
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,162 @@
+package mongodbatlas
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const configPath = "config"
+
+const defaultBaseURL = "https://cloud.mongodb.com/api/atlas/v1.0"
+
+var errNotConfigured = errors.New("the mongodbatlas backend is not configured")
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: configPath,
+		Fields: map[string]*framework.FieldSchema{
+			"public_key": {
+				Type:        framework.TypeString,
+				Description: "The public key of the programmatic API key Vault calls Atlas with.",
+			},
+
+			"private_key": {
+				Type:        framework.TypeString,
+				Description: "The private key of the programmatic API key Vault calls Atlas with.",
+			},
+
+			"base_url": {
+				Type:        framework.TypeString,
+				Description: "The base URL of the Atlas API. Defaults to " + defaultBaseURL + ".",
+			},
+		},
+
+		ExistenceCheck: b.pathConfigExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.CreateOperation: b.pathConfigWrite,
+			logical.UpdateOperation: b.pathConfigWrite,
+			logical.DeleteOperation: b.pathConfigDelete,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+// config returns the configuration, or nil if the backend is not configured
+func (b *backend) config(ctx context.Context, s logical.Storage) (*atlasConfig, error) {
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	conf := &atlasConfig{}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, errwrap.Wrapf("error reading mongodbatlas configuration: {{err}}", err)
+	}
+	return conf, nil
+}
+
+func (b *backend) pathConfigExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return false, err
+	}
+	return conf != nil, nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, nil
+	}
+
+	// The private key is never returned
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": conf.PublicKey,
+			"base_url":   conf.BaseURL,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &atlasConfig{
+			BaseURL: defaultBaseURL,
+		}
+	}
+
+	if v, ok := data.GetOk("public_key"); ok {
+		conf.PublicKey = v.(string)
+	}
+	if v, ok := data.GetOk("private_key"); ok {
+		conf.PrivateKey = v.(string)
+	}
+	if v, ok := data.GetOk("base_url"); ok {
+		conf.BaseURL = v.(string)
+	}
+	if conf.BaseURL == "" {
+		conf.BaseURL = defaultBaseURL
+	}
+
+	switch {
+	case conf.PublicKey == "":
+		return logical.ErrorResponse("public_key is required"), nil
+	case conf.PrivateKey == "":
+		return logical.ErrorResponse("private_key is required"), nil
+	}
+	if u, err := url.Parse(conf.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return logical.ErrorResponse("base_url must be an http or https URL"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type atlasConfig struct {
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"`
+	BaseURL    string `json:"base_url"`
+}
+
+const pathConfigHelpSyn = `
+Configure the API key Vault manages Atlas programmatic API keys with.
+`
+
+const pathConfigHelpDesc = `
+This path configures the programmatic API key Vault calls the Atlas API with.
+It must have the Organization Owner role in the organizations of the roles of
+this backend, so that it can create and delete API keys and their access
+lists, and the address of Vault must be in its own access list.
+`
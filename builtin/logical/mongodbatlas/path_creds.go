@@ -0,0 +1,100 @@
+package mongodbatlas
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// maxDescriptionLen is the longest description Atlas accepts for API keys
+const maxDescriptionLen = 250
+
+func pathCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCredsRead,
+		},
+
+		HelpSynopsis:    pathCredsHelpSyn,
+		HelpDescription: pathCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", roleName)), nil
+	}
+
+	ttl, maxTTL, warnings := framework.LeaseTTLs(b.System(), role.TTL, role.MaxTTL)
+
+	client, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("vault-%s-%s-%d", roleName, req.DisplayName, time.Now().Unix())
+	if len(description) > maxDescriptionLen {
+		description = description[:maxDescriptionLen]
+	}
+	key, err := client.createAPIKey(ctx, role.OrganizationID, role.ProjectID, description, role.Roles)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []accessListEntry
+	for _, ip := range role.IPAddresses {
+		entries = append(entries, accessListEntry{IPAddress: ip})
+	}
+	for _, cidr := range role.CIDRBlocks {
+		entries = append(entries, accessListEntry{CIDRBlock: cidr})
+	}
+	if len(entries) > 0 {
+		if err := client.addAccessList(ctx, role.OrganizationID, key.ID, entries); err != nil {
+			// A key without its access list would be usable from anywhere
+			if delErr := client.deleteAPIKey(ctx, role.OrganizationID, key.ID); delErr != nil {
+				b.Logger().Error("error deleting the API key of a failed lease", "api_key_id", key.ID, "error", delErr)
+			}
+			return nil, err
+		}
+	}
+
+	resp := b.Secret(SecretProgrammaticAPIKeyType).Response(map[string]interface{}{
+		"public_key":  key.PublicKey,
+		"private_key": key.PrivateKey,
+	}, map[string]interface{}{
+		"role":            roleName,
+		"organization_id": role.OrganizationID,
+		"api_key_id":      key.ID,
+	})
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = maxTTL
+	resp.Warnings = warnings
+
+	return resp, nil
+}
+
+const pathCredsHelpSyn = `
+Create a programmatic API key for a role.
+`
+
+const pathCredsHelpDesc = `
+This path creates an Atlas programmatic API key with the roles and the access
+list of the role. The key is deleted when its lease is revoked.
+`
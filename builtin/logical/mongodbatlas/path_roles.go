@@ -0,0 +1,254 @@
+package mongodbatlas
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"organization_id": {
+				Type:        framework.TypeString,
+				Description: "The ID of the organization the API keys are created in.",
+			},
+
+			"project_id": {
+				Type:        framework.TypeString,
+				Description: "The ID of a project of the organization the API keys are assigned to. The keys have organization roles if unset.",
+			},
+
+			"roles": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The Atlas roles of the API keys, such as ORG_READ_ONLY, or GROUP_READ_ONLY for the keys of a project.",
+			},
+
+			"ip_addresses": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The IP addresses the API keys can be used from.",
+			},
+
+			"cidr_blocks": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The CIDR blocks the API keys can be used from.",
+			},
+
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The default TTL of the API keys. Defaults to the default lease TTL of the mount.",
+			},
+
+			"max_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The maximum TTL of the API keys. Defaults to the maximum lease TTL of the mount.",
+			},
+		},
+
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.CreateOperation: b.pathRoleWrite,
+			logical.UpdateOperation: b.pathRoleWrite,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+// role returns a role, or nil if it doesn't exist
+func (b *backend) role(ctx context.Context, s logical.Storage, name string) (*roleEntry, error) {
+	entry, err := s.Get(ctx, "role/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role roleEntry
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, errwrap.Wrapf("error reading role: {{err}}", err)
+	}
+	return &role, nil
+}
+
+func (b *backend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"organization_id": role.OrganizationID,
+			"project_id":      role.ProjectID,
+			"roles":           role.Roles,
+			"ip_addresses":    role.IPAddresses,
+			"cidr_blocks":     role.CIDRBlocks,
+			"ttl":             int64(role.TTL.Seconds()),
+			"max_ttl":         int64(role.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleEntry{}
+	}
+
+	if v, ok := data.GetOk("organization_id"); ok {
+		role.OrganizationID = v.(string)
+	}
+	if v, ok := data.GetOk("project_id"); ok {
+		role.ProjectID = v.(string)
+	}
+	if v, ok := data.GetOk("roles"); ok {
+		roles := v.([]string)
+		for i, r := range roles {
+			roles[i] = strings.ToUpper(r)
+		}
+		role.Roles = strutil.RemoveDuplicates(roles, false)
+	}
+	if v, ok := data.GetOk("ip_addresses"); ok {
+		role.IPAddresses = strutil.RemoveDuplicates(v.([]string), false)
+	}
+	if v, ok := data.GetOk("cidr_blocks"); ok {
+		role.CIDRBlocks = strutil.RemoveDuplicates(v.([]string), false)
+	}
+	if v, ok := data.GetOk("ttl"); ok {
+		role.TTL = time.Duration(v.(int)) * time.Second
+	}
+	if v, ok := data.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Duration(v.(int)) * time.Second
+	}
+
+	if role.OrganizationID == "" {
+		return logical.ErrorResponse("organization_id is required"), nil
+	}
+	if len(role.Roles) == 0 {
+		return logical.ErrorResponse("roles must be set"), nil
+	}
+
+	// Keys of a project can only have project roles, and the others only
+	// organization roles
+	prefix := "ORG_"
+	if role.ProjectID != "" {
+		prefix = "GROUP_"
+	}
+	for _, r := range role.Roles {
+		if !strings.HasPrefix(r, prefix) {
+			return logical.ErrorResponse(fmt.Sprintf("role %q is not a %s role", r, strings.TrimSuffix(prefix, "_"))), nil
+		}
+	}
+
+	for _, ip := range role.IPAddresses {
+		if net.ParseIP(ip) == nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid IP address %q", ip)), nil
+		}
+	}
+	for _, cidr := range role.CIDRBlocks {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid CIDR block %q", cidr)), nil
+		}
+	}
+
+	if role.TTL < 0 || role.MaxTTL < 0 {
+		return logical.ErrorResponse("ttl and max_ttl can't be negative"), nil
+	}
+	if role.MaxTTL > 0 && role.TTL > role.MaxTTL {
+		return logical.ErrorResponse("ttl can't be greater than max_ttl"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "role/"+data.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type roleEntry struct {
+	OrganizationID string        `json:"organization_id"`
+	ProjectID      string        `json:"project_id"`
+	Roles          []string      `json:"roles"`
+	IPAddresses    []string      `json:"ip_addresses"`
+	CIDRBlocks     []string      `json:"cidr_blocks"`
+	TTL            time.Duration `json:"ttl"`
+	MaxTTL         time.Duration `json:"max_ttl"`
+}
+
+const pathRoleHelpSyn = `
+Manage the roles API keys are created for.
+`
+
+const pathRoleHelpDesc = `
+This path manages the roles of the backend. The API keys of a role are created
+in its organization, with the organization roles of the role, or assigned to
+the project of the role with its project roles. The access list of each key
+holds the IP addresses and CIDR blocks of its role; keys with an empty access
+list can be used from anywhere unless the organization requires access lists.
+`
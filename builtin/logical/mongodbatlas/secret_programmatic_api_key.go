@@ -0,0 +1,70 @@
+package mongodbatlas
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const SecretProgrammaticAPIKeyType = "programmatic_api_key"
+
+func secretProgrammaticAPIKey(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretProgrammaticAPIKeyType,
+		Fields: map[string]*framework.FieldSchema{
+			"public_key": {
+				Type:        framework.TypeString,
+				Description: "Public key of the programmatic API key",
+			},
+			"private_key": {
+				Type:        framework.TypeString,
+				Description: "Private key of the programmatic API key",
+			},
+		},
+
+		Renew:  b.secretProgrammaticAPIKeyRenew,
+		Revoke: b.secretProgrammaticAPIKeyRevoke,
+	}
+}
+
+// secretProgrammaticAPIKeyRenew extends the lease with the TTLs of its role.
+// Atlas keys don't expire, so nothing changes on Atlas.
+func (b *backend) secretProgrammaticAPIKeyRenew(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName, _ := req.Secret.InternalData["role"].(string)
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, fmt.Errorf("role %q of the lease not found", roleName)
+	}
+
+	ttl, maxTTL, warnings := framework.LeaseTTLs(b.System(), role.TTL, role.MaxTTL)
+	resp := &logical.Response{Secret: req.Secret}
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = maxTTL
+	resp.Warnings = warnings
+	return resp, nil
+}
+
+// secretProgrammaticAPIKeyRevoke deletes the key of the lease. A key deleted
+// out of band is not an error.
+func (b *backend) secretProgrammaticAPIKeyRevoke(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	orgID, _ := req.Secret.InternalData["organization_id"].(string)
+	keyID, _ := req.Secret.InternalData["api_key_id"].(string)
+	if orgID == "" || keyID == "" {
+		return nil, fmt.Errorf("secret is missing the API key internal data")
+	}
+
+	client, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.deleteAPIKey(ctx, orgID, keyID); err != nil {
+		return nil, errwrap.Wrapf("could not delete API key: {{err}}", err)
+	}
+	return nil, nil
+}
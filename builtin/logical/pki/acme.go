@@ -0,0 +1,461 @@
+package pki
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// acmeNonceLifetime is how long a nonce handed out by the ACME server
+	// can be used for
+	acmeNonceLifetime = 30 * time.Minute
+
+	// acmeOrderLifetime is how long an order and its authorizations can be
+	// completed in
+	acmeOrderLifetime = 24 * time.Hour
+
+	acmeStatusPending     = "pending"
+	acmeStatusReady       = "ready"
+	acmeStatusValid       = "valid"
+	acmeStatusInvalid     = "invalid"
+	acmeStatusExpired     = "expired"
+	acmeStatusDeactivated = "deactivated"
+
+	acmeIdentifierDNS = "dns"
+	acmeIdentifierIP  = "ip"
+
+	acmeChallengeHTTP01 = "http-01"
+	acmeChallengeDNS01  = "dns-01"
+)
+
+// acmeSignatureAlgorithms are the algorithms requests to the ACME server may
+// be signed with; MAC algorithms are only allowed for external account
+// bindings
+var acmeSignatureAlgorithms = map[string]bool{
+	string(jose.RS256): true,
+	string(jose.RS384): true,
+	string(jose.RS512): true,
+	string(jose.PS256): true,
+	string(jose.PS384): true,
+	string(jose.PS512): true,
+	string(jose.ES256): true,
+	string(jose.ES384): true,
+	string(jose.ES512): true,
+	string(jose.EdDSA): true,
+}
+
+// acmeProblem is an ACME error, returned to clients as a problem document
+// (RFC 7807)
+type acmeProblem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+	Status int    `json:"status"`
+}
+
+func (p *acmeProblem) Error() string {
+	return p.Detail
+}
+
+// newACMEProblem returns an ACME error of the given type, such as
+// "malformed", from the ACME error namespace
+func newACMEProblem(status int, typ, format string, args ...interface{}) *acmeProblem {
+	return &acmeProblem{
+		Type:   "urn:ietf:params:acme:error:" + typ,
+		Detail: fmt.Sprintf(format, args...),
+		Status: status,
+	}
+}
+
+type acmeIdentifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type acmeAccount struct {
+	ID        string           `json:"id"`
+	Key       *jose.JSONWebKey `json:"key"`
+	Status    string           `json:"status"`
+	Contact   []string         `json:"contact"`
+	Role      string           `json:"role"`
+	EABKeyID  string           `json:"eab_key_id"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type acmeOrder struct {
+	ID                string            `json:"id"`
+	AccountID         string            `json:"account_id"`
+	Status            string            `json:"status"`
+	Expires           time.Time         `json:"expires"`
+	Identifiers       []*acmeIdentifier `json:"identifiers"`
+	AuthorizationIDs  []string          `json:"authorization_ids"`
+	Role              string            `json:"role"`
+	CertificateSerial string            `json:"certificate_serial"`
+	Certificate       string            `json:"certificate"`
+	Error             *acmeProblem      `json:"error"`
+}
+
+type acmeAuthorization struct {
+	ID         string           `json:"id"`
+	AccountID  string           `json:"account_id"`
+	Identifier *acmeIdentifier  `json:"identifier"`
+	Status     string           `json:"status"`
+	Expires    time.Time        `json:"expires"`
+	Wildcard   bool             `json:"wildcard"`
+	Challenges []*acmeChallenge `json:"challenges"`
+}
+
+type acmeChallenge struct {
+	Type      string       `json:"type"`
+	Token     string       `json:"token"`
+	Status    string       `json:"status"`
+	Validated *time.Time   `json:"validated"`
+	Error     *acmeProblem `json:"error"`
+}
+
+type acmeEABKey struct {
+	KeyID     string    `json:"key_id"`
+	Key       []byte    `json:"key"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// acmeNonces are the nonces handed out by the ACME server that have not been
+// used yet. They are only kept in memory, so a restart or a change of the
+// active node makes clients retry with a new one.
+type acmeNonces struct {
+	sync.Mutex
+	nonces map[string]time.Time
+}
+
+func (n *acmeNonces) issue() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+
+	n.Lock()
+	defer n.Unlock()
+	if n.nonces == nil {
+		n.nonces = make(map[string]time.Time)
+	}
+	now := time.Now()
+	for k, expires := range n.nonces {
+		if now.After(expires) {
+			delete(n.nonces, k)
+		}
+	}
+	n.nonces[nonce] = now.Add(acmeNonceLifetime)
+
+	return nonce, nil
+}
+
+// redeem uses up the nonce, returning whether it was valid
+func (n *acmeNonces) redeem(nonce string) bool {
+	n.Lock()
+	defer n.Unlock()
+	expires, ok := n.nonces[nonce]
+	if !ok {
+		return false
+	}
+	delete(n.nonces, nonce)
+	return time.Now().Before(expires)
+}
+
+// acmeRequest is a request to the ACME server whose signature was verified
+type acmeRequest struct {
+	config  *acmeConfig
+	url     string
+	payload []byte
+
+	// jwk is the key embedded in requests not signed by an account, and
+	// account the account that signed the request otherwise
+	jwk     *jose.JSONWebKey
+	account *acmeAccount
+}
+
+// acmeResult is what an ACME operation returns on success
+type acmeResult struct {
+	status int
+
+	// body is encoded as JSON unless it is raw
+	body        interface{}
+	raw         []byte
+	contentType string
+
+	location string
+	links    []string
+}
+
+type acmeOperation func(context.Context, *logical.Request, *framework.FieldData, *acmeRequest) (*acmeResult, error)
+
+// acmeFields are the fields of the flattened JWS requests to the ACME
+// server are sent as
+func acmeFields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	for _, name := range []string{"protected", "payload", "signature"} {
+		fields[name] = &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: fmt.Sprintf("The %s of the JWS the request is signed with.", name),
+		}
+	}
+	return fields
+}
+
+// acmeURL returns the URL of a path of the ACME server
+func acmeURL(config *acmeConfig, path string) string {
+	return config.BaseURL + "/acme/" + path
+}
+
+// acmeHandler verifies the signature of a request to the ACME server before
+// handing it to the operation. Requests must be signed by an account unless
+// jwkAllowed is set, in which case they may instead embed their key.
+func (b *backend) acmeHandler(op acmeOperation, jwkAllowed bool) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		config, err := b.acmeConfig(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+		if config == nil || !config.Enabled {
+			return b.acmeProblemResponse(nil, newACMEProblem(http.StatusForbidden, "unauthorized", "ACME is not enabled on this mount"))
+		}
+
+		var r *acmeRequest
+		if req.Operation == logical.UpdateOperation {
+			r, err = b.acmeVerify(ctx, req, d, config, jwkAllowed)
+		} else {
+			r = &acmeRequest{config: config}
+		}
+		if err != nil {
+			if problem, ok := err.(*acmeProblem); ok {
+				return b.acmeProblemResponse(config, problem)
+			}
+			return nil, err
+		}
+
+		result, err := op(ctx, req, d, r)
+		if err != nil {
+			if problem, ok := err.(*acmeProblem); ok {
+				return b.acmeProblemResponse(config, problem)
+			}
+			return nil, err
+		}
+
+		return b.acmeResponse(config, result)
+	}
+}
+
+// acmeVerify parses the JWS a request is sent as and verifies its nonce, URL
+// and signature
+func (b *backend) acmeVerify(ctx context.Context, req *logical.Request, d *framework.FieldData, config *acmeConfig, jwkAllowed bool) (*acmeRequest, error) {
+	encoded, err := json.Marshal(map[string]string{
+		"protected": d.Get("protected").(string),
+		"payload":   d.Get("payload").(string),
+		"signature": d.Get("signature").(string),
+	})
+	if err != nil {
+		return nil, err
+	}
+	jws, err := jose.ParseSigned(string(encoded))
+	if err != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "error parsing JWS: %s", err)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "the JWS must have exactly one signature")
+	}
+	header := jws.Signatures[0].Protected
+
+	if !acmeSignatureAlgorithms[header.Algorithm] {
+		return nil, newACMEProblem(http.StatusBadRequest, "badSignatureAlgorithm", "unsupported signature algorithm %q", header.Algorithm)
+	}
+
+	if !b.acmeNonces.redeem(header.Nonce) {
+		return nil, newACMEProblem(http.StatusBadRequest, "badNonce", "invalid or expired nonce")
+	}
+
+	r := &acmeRequest{
+		config: config,
+		url:    config.BaseURL + "/" + req.Path,
+	}
+	if url, _ := header.ExtraHeaders["url"].(string); url != r.url {
+		return nil, newACMEProblem(http.StatusUnauthorized, "unauthorized", "the url of the JWS does not match the request")
+	}
+
+	var key interface{}
+	switch {
+	case header.JSONWebKey != nil && header.KeyID != "":
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "only one of jwk and kid may be given")
+
+	case header.JSONWebKey != nil:
+		if !jwkAllowed {
+			return nil, newACMEProblem(http.StatusBadRequest, "malformed", "the request must be signed by an account")
+		}
+		r.jwk = header.JSONWebKey
+		key = header.JSONWebKey
+
+	case header.KeyID != "":
+		accountPrefix := acmeURL(config, "account/")
+		if !strings.HasPrefix(header.KeyID, accountPrefix) {
+			return nil, newACMEProblem(http.StatusBadRequest, "accountDoesNotExist", "unknown account %q", header.KeyID)
+		}
+		account, err := b.acmeAccount(ctx, req.Storage, strings.TrimPrefix(header.KeyID, accountPrefix))
+		if err != nil {
+			return nil, err
+		}
+		if account == nil {
+			return nil, newACMEProblem(http.StatusBadRequest, "accountDoesNotExist", "unknown account %q", header.KeyID)
+		}
+		if account.Status != acmeStatusValid {
+			return nil, newACMEProblem(http.StatusUnauthorized, "unauthorized", "the account is %s", account.Status)
+		}
+		r.account = account
+		key = account.Key
+
+	default:
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "one of jwk and kid must be given")
+	}
+
+	r.payload, err = jws.Verify(key)
+	if err != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "invalid signature")
+	}
+
+	return r, nil
+}
+
+// acmeResponse returns the result of an ACME operation as a raw HTTP
+// response, along with a new nonce
+func (b *backend) acmeResponse(config *acmeConfig, result *acmeResult) (*logical.Response, error) {
+	nonce, err := b.acmeNonces.issue()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string][]string{
+		"Replay-Nonce":  []string{nonce},
+		"Cache-Control": []string{"no-store"},
+	}
+	if config != nil {
+		headers["Link"] = []string{fmt.Sprintf("<%s>;rel=\"index\"", acmeURL(config, "directory"))}
+	}
+	headers["Link"] = append(headers["Link"], result.links...)
+	if result.location != "" {
+		headers["Location"] = []string{result.location}
+	}
+
+	data := map[string]interface{}{
+		logical.HTTPStatusCode: result.status,
+		logical.HTTPRawHeaders: headers,
+	}
+
+	switch {
+	case result.raw != nil:
+		data[logical.HTTPContentType] = result.contentType
+		data[logical.HTTPRawBody] = result.raw
+	case result.body != nil:
+		body, err := json.Marshal(result.body)
+		if err != nil {
+			return nil, err
+		}
+		contentType := result.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		data[logical.HTTPContentType] = contentType
+		data[logical.HTTPRawBody] = body
+	case result.status != http.StatusNoContent:
+		data[logical.HTTPContentType] = "application/json"
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+func (b *backend) acmeProblemResponse(config *acmeConfig, problem *acmeProblem) (*logical.Response, error) {
+	return b.acmeResponse(config, &acmeResult{
+		status:      problem.Status,
+		body:        problem,
+		contentType: "application/problem+json",
+	})
+}
+
+// acmeThumbprint returns the thumbprint of a key (RFC 7638), which key
+// authorizations are made of
+func acmeThumbprint(key *jose.JSONWebKey) (string, error) {
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", errwrap.Wrapf("error computing key thumbprint: {{err}}", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), nil
+}
+
+// acmeToken returns a random token for a challenge or an identifier
+func acmeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (b *backend) acmeAccount(ctx context.Context, s logical.Storage, id string) (*acmeAccount, error) {
+	var account acmeAccount
+	ok, err := acmeGet(ctx, s, "acme/accounts/"+id, &account)
+	if !ok || err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (b *backend) acmeOrder(ctx context.Context, s logical.Storage, id string) (*acmeOrder, error) {
+	var order acmeOrder
+	ok, err := acmeGet(ctx, s, "acme/orders/"+id, &order)
+	if !ok || err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (b *backend) acmeAuthorization(ctx context.Context, s logical.Storage, id string) (*acmeAuthorization, error) {
+	var authz acmeAuthorization
+	ok, err := acmeGet(ctx, s, "acme/authorizations/"+id, &authz)
+	if !ok || err != nil {
+		return nil, err
+	}
+	return &authz, nil
+}
+
+func acmeGet(ctx context.Context, s logical.Storage, key string, out interface{}) (bool, error) {
+	entry, err := s.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if entry == nil {
+		return false, nil
+	}
+	if err := entry.DecodeJSON(out); err != nil {
+		return false, errwrap.Wrapf(fmt.Sprintf("error decoding %q: {{err}}", key), err)
+	}
+	return true, nil
+}
+
+func acmePut(ctx context.Context, s logical.Storage, key string, v interface{}) error {
+	entry, err := logical.StorageEntryJSON(key, v)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
@@ -0,0 +1,105 @@
+package pki
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+// acmeValidationTimeout is how long validating a challenge may take
+const acmeValidationTimeout = 10 * time.Second
+
+// acmeTXTLookup looks up the TXT records of a name, with the DNS server at
+// the given address if it is not empty
+type acmeTXTLookup func(ctx context.Context, server, name string) ([]string, error)
+
+func defaultACMEHTTPClient() *http.Client {
+	client := cleanhttp.DefaultClient()
+	client.Timeout = acmeValidationTimeout
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after %d redirects", len(via))
+		}
+		return nil
+	}
+	return client
+}
+
+func defaultACMETXTLookup(ctx context.Context, server, name string) ([]string, error) {
+	resolver := net.DefaultResolver
+	if server != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return resolver.LookupTXT(ctx, name)
+}
+
+// acmeValidate checks that the client controls the identifier of the
+// authorization, as shown by the response to the challenge
+func (b *backend) acmeValidate(ctx context.Context, config *acmeConfig, authz *acmeAuthorization, challenge *acmeChallenge, thumbprint string) error {
+	ctx, cancel := context.WithTimeout(ctx, acmeValidationTimeout)
+	defer cancel()
+
+	keyAuthorization := challenge.Token + "." + thumbprint
+
+	switch challenge.Type {
+	case acmeChallengeHTTP01:
+		host := authz.Identifier.Value
+		if authz.Identifier.Type == acmeIdentifierIP && strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		req, err := http.NewRequest("GET", fmt.Sprintf("http://%s/.well-known/acme-challenge/%s", host, challenge.Token), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := b.acmeHTTPClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status %d fetching the challenge response", resp.StatusCode)
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(body)) != keyAuthorization {
+			return fmt.Errorf("the challenge response does not match the key authorization")
+		}
+		return nil
+
+	case acmeChallengeDNS01:
+		digest := sha256.Sum256([]byte(keyAuthorization))
+		expected := base64.RawURLEncoding.EncodeToString(digest[:])
+
+		name := "_acme-challenge." + authz.Identifier.Value
+		records, err := b.acmeLookupTXT(ctx, config.DNSResolver, name)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if record == expected {
+				return nil
+			}
+		}
+		return fmt.Errorf("no TXT record of %s matches the key authorization", name)
+
+	default:
+		return fmt.Errorf("unsupported challenge type %q", challenge.Type)
+	}
+}
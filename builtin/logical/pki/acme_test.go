@@ -0,0 +1,454 @@
+package pki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const testACMEBaseURL = "https://vault.example.com/v1/pki"
+
+// testACMEClient speaks the ACME protocol to a backend
+type testACMEClient struct {
+	t       *testing.T
+	b       *backend
+	storage logical.Storage
+	key     *ecdsa.PrivateKey
+	kid     string
+	nonce   string
+}
+
+type testACMEResponse struct {
+	status  int
+	headers map[string][]string
+	body    []byte
+}
+
+func (r *testACMEResponse) decode(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var out map[string]interface{}
+	if err := json.Unmarshal(r.body, &out); err != nil {
+		t.Fatalf("error decoding %q: %v", r.body, err)
+	}
+	return out
+}
+
+func (r *testACMEResponse) problem(t *testing.T) string {
+	t.Helper()
+	return strings.TrimPrefix(r.decode(t)["type"].(string), "urn:ietf:params:acme:error:")
+}
+
+func newTestACMEClient(t *testing.T, b *backend, storage logical.Storage) *testACMEClient {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testACMEClient{
+		t:       t,
+		b:       b,
+		storage: storage,
+		key:     key,
+	}
+}
+
+func (c *testACMEClient) request(op logical.Operation, path string, data map[string]interface{}) *testACMEResponse {
+	c.t.Helper()
+	resp, err := c.b.HandleRequest(context.Background(), &logical.Request{
+		Operation: op,
+		Path:      path,
+		Storage:   c.storage,
+		Data:      data,
+	})
+	if err != nil {
+		c.t.Fatalf("error requesting %s: %v", path, err)
+	}
+	result := &testACMEResponse{
+		status:  resp.Data[logical.HTTPStatusCode].(int),
+		headers: resp.Data[logical.HTTPRawHeaders].(map[string][]string),
+	}
+	if body, ok := resp.Data[logical.HTTPRawBody]; ok {
+		result.body = body.([]byte)
+	}
+	if nonces := result.headers["Replay-Nonce"]; len(nonces) > 0 {
+		c.nonce = nonces[0]
+	}
+	return result
+}
+
+// Nonce implements jose.NonceSource
+func (c *testACMEClient) Nonce() (string, error) {
+	if c.nonce == "" {
+		c.request(logical.ReadOperation, "acme/new-nonce", nil)
+	}
+	nonce := c.nonce
+	c.nonce = ""
+	return nonce, nil
+}
+
+func (c *testACMEClient) url(path string) string {
+	return testACMEBaseURL + "/acme/" + path
+}
+
+// post signs the payload, sending a POST-as-GET request if it is nil
+func (c *testACMEClient) post(path string, payload interface{}) *testACMEResponse {
+	c.t.Helper()
+
+	var encoded []byte
+	if payload != nil {
+		var err error
+		if encoded, err = json.Marshal(payload); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+
+	opts := &jose.SignerOptions{NonceSource: c}
+	opts.WithHeader("url", c.url(path))
+	if c.kid == "" {
+		opts.EmbedJWK = true
+	} else {
+		opts.WithHeader("kid", c.kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: c.key}, opts)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	jws, err := signer.Sign(encoded)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(jws.FullSerialize()), &data); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.request(logical.UpdateOperation, "acme/"+path, data)
+}
+
+func (c *testACMEClient) thumbprint() string {
+	thumbprint, err := acmeThumbprint(&jose.JSONWebKey{Key: c.key.Public()})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return thumbprint
+}
+
+func (c *testACMEClient) newAccount(payload map[string]interface{}) *testACMEResponse {
+	c.t.Helper()
+	resp := c.post("new-account", payload)
+	if resp.status == http.StatusCreated || resp.status == http.StatusOK {
+		c.kid = resp.headers["Location"][0]
+	}
+	return resp
+}
+
+func (c *testACMEClient) csr(names ...string) string {
+	c.t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: names[0]},
+		DNSNames: names,
+	}, key)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(der)
+}
+
+func testACMEBackend(t *testing.T, config map[string]interface{}) (*backend, logical.Storage) {
+	b, storage := createBackendWithStorage(t)
+
+	for _, req := range []struct {
+		path string
+		data map[string]interface{}
+	}{
+		{"root/generate/internal", map[string]interface{}{"common_name": "example.com", "ttl": "48h"}},
+		{"roles/web", map[string]interface{}{"allowed_domains": "example.com", "allow_subdomains": true, "key_type": "ec", "key_bits": 256, "ttl": "1h"}},
+		{"config/acme", config},
+	} {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      req.path,
+			Storage:   storage,
+			Data:      req.data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("error writing %s: resp: %#v, err: %v", req.path, resp, err)
+		}
+	}
+
+	return b, storage
+}
+
+func TestBackend_ACME(t *testing.T) {
+	b, storage := testACMEBackend(t, map[string]interface{}{
+		"enabled":      true,
+		"base_url":     testACMEBaseURL + "/",
+		"default_role": "web",
+	})
+	c := newTestACMEClient(t, b, storage)
+
+	resp := c.request(logical.ReadOperation, "acme/directory", nil)
+	if resp.status != http.StatusOK || resp.decode(t)["newAccount"] != c.url("new-account") {
+		t.Fatalf("bad directory: %d %s", resp.status, resp.body)
+	}
+
+	// Accounts are created once per key
+	if resp := c.newAccount(map[string]interface{}{"contact": []string{"mailto:admin@example.com"}}); resp.status != http.StatusCreated {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+	kid := c.kid
+	c.kid = ""
+	if resp := c.newAccount(map[string]interface{}{"onlyReturnExisting": true}); resp.status != http.StatusOK || c.kid != kid {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+
+	// Nonces can only be used once
+	c.nonce = "bogus"
+	if resp := c.post("new-order", nil); resp.status != http.StatusBadRequest || resp.problem(t) != "badNonce" {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+
+	// Names the role does not allow are rejected
+	resp = c.post("new-order", map[string]interface{}{
+		"identifiers": []map[string]string{{"type": "dns", "value": "www.example.org"}},
+	})
+	if resp.status != http.StatusBadRequest || resp.problem(t) != "rejectedIdentifier" {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+
+	resp = c.post("new-order", map[string]interface{}{
+		"identifiers": []map[string]string{
+			{"type": "dns", "value": "www.example.com"},
+			{"type": "dns", "value": "*.example.com"},
+		},
+	})
+	if resp.status != http.StatusCreated {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+	orderURL := resp.headers["Location"][0]
+	order := resp.decode(t)
+	if order["status"] != acmeStatusPending {
+		t.Fatalf("bad: %#v", order)
+	}
+
+	// Serve the http-01 responses and the dns-01 records
+	keyAuthorizations := make(map[string]string)
+	txtRecords := make(map[string][]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, keyAuthorizations[r.Host+r.URL.Path])
+	}))
+	defer srv.Close()
+	b.acmeHTTPClient = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("tcp", srv.Listener.Addr().String())
+			},
+		},
+	}
+	b.acmeLookupTXT = func(ctx context.Context, server, name string) ([]string, error) {
+		return txtRecords[name], nil
+	}
+
+	for _, authzURL := range order["authorizations"].([]interface{}) {
+		authz := c.post(strings.TrimPrefix(authzURL.(string), testACMEBaseURL+"/acme/"), nil).decode(t)
+		identifier := authz["identifier"].(map[string]interface{})["value"].(string)
+
+		// Wildcards can only be validated with dns-01
+		var challenge map[string]interface{}
+		for _, ch := range authz["challenges"].([]interface{}) {
+			ch := ch.(map[string]interface{})
+			if authz["wildcard"] == true && ch["type"] == acmeChallengeHTTP01 {
+				t.Fatal("http-01 offered for a wildcard")
+			}
+			if challenge == nil || ch["type"] == acmeChallengeDNS01 {
+				challenge = ch
+			}
+		}
+
+		keyAuthorization := challenge["token"].(string) + "." + c.thumbprint()
+		switch challenge["type"] {
+		case acmeChallengeHTTP01:
+			keyAuthorizations[identifier+"/.well-known/acme-challenge/"+challenge["token"].(string)] = keyAuthorization
+		case acmeChallengeDNS01:
+			digest := sha256.Sum256([]byte(keyAuthorization))
+			name := "_acme-challenge." + identifier
+			txtRecords[name] = append(txtRecords[name], base64.RawURLEncoding.EncodeToString(digest[:]))
+		}
+
+		resp := c.post(strings.TrimPrefix(challenge["url"].(string), testACMEBaseURL+"/acme/"), map[string]interface{}{})
+		if resp.status != http.StatusOK || resp.decode(t)["status"] != acmeStatusValid {
+			t.Fatalf("bad: %d %s", resp.status, resp.body)
+		}
+	}
+
+	orderPath := strings.TrimPrefix(orderURL, testACMEBaseURL+"/acme/")
+	if order := c.post(orderPath, nil).decode(t); order["status"] != acmeStatusReady {
+		t.Fatalf("bad: %#v", order)
+	}
+
+	// The CSR must ask for exactly the identifiers of the order
+	resp = c.post(orderPath+"/finalize", map[string]interface{}{"csr": c.csr("www.example.com")})
+	if resp.status != http.StatusBadRequest || resp.problem(t) != "badCSR" {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+
+	resp = c.post(orderPath+"/finalize", map[string]interface{}{"csr": c.csr("www.example.com", "*.example.com")})
+	if resp.status != http.StatusOK {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+	order = resp.decode(t)
+	if order["status"] != acmeStatusValid {
+		t.Fatalf("bad: %#v", order)
+	}
+
+	resp = c.post(strings.TrimPrefix(order["certificate"].(string), testACMEBaseURL+"/acme/"), nil)
+	if resp.status != http.StatusOK {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+	block, _ := pem.Decode(resp.body)
+	if block == nil {
+		t.Fatalf("bad certificate: %s", resp.body)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "www.example.com" || len(cert.DNSNames) != 2 {
+		t.Fatalf("bad certificate names: %s %v", cert.Subject.CommonName, cert.DNSNames)
+	}
+
+	// The account can revoke the certificates it ordered
+	revoke := map[string]interface{}{"certificate": base64.RawURLEncoding.EncodeToString(cert.Raw)}
+	if resp := c.post("revoke-cert", revoke); resp.status != http.StatusOK {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+	if resp := c.post("revoke-cert", revoke); resp.status != http.StatusBadRequest || resp.problem(t) != "alreadyRevoked" {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+
+	// Other accounts can't see the order
+	other := newTestACMEClient(t, b, storage)
+	other.newAccount(map[string]interface{}{})
+	if resp := other.post(orderPath, nil); resp.status != http.StatusNotFound {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+}
+
+func TestBackend_ACME_EAB(t *testing.T) {
+	b, storage := testACMEBackend(t, map[string]interface{}{
+		"enabled":             true,
+		"base_url":            testACMEBaseURL,
+		"eab_required":        true,
+		"eab_skip_challenges": true,
+	})
+	c := newTestACMEClient(t, b, storage)
+
+	if resp := c.newAccount(map[string]interface{}{}); resp.status != http.StatusBadRequest || resp.problem(t) != "externalAccountRequired" {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "acme/new-eab",
+		Storage:   storage,
+		Data:      map[string]interface{}{"role": "web"},
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	keyID := resp.Data["key_id"].(string)
+	hmacKey, err := base64.RawURLEncoding.DecodeString(resp.Data["key"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eab := func(key []byte) json.RawMessage {
+		opts := &jose.SignerOptions{}
+		opts.WithHeader("url", c.url("new-account"))
+		opts.WithHeader("kid", keyID)
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: key}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jwk, err := json.Marshal(&jose.JSONWebKey{Key: c.key.Public()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		jws, err := signer.Sign(jwk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return json.RawMessage(jws.FullSerialize())
+	}
+
+	if resp := c.newAccount(map[string]interface{}{"externalAccountBinding": eab([]byte("bogus"))}); resp.status != http.StatusUnauthorized {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+	if resp := c.newAccount(map[string]interface{}{"externalAccountBinding": eab(hmacKey)}); resp.status != http.StatusCreated {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+
+	// The key is used up
+	keys, err := storage.List(context.Background(), "acme/eab/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("bad: %v", keys)
+	}
+
+	// The role of the account authorizes its names without challenges
+	resp2 := c.post("new-order", map[string]interface{}{
+		"identifiers": []map[string]string{{"type": "dns", "value": "api.example.com"}},
+	})
+	if resp2.status != http.StatusCreated || resp2.decode(t)["status"] != acmeStatusReady {
+		t.Fatalf("bad: %d %s", resp2.status, resp2.body)
+	}
+}
+
+func TestBackend_ACME_Disabled(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+	c := newTestACMEClient(t, b, storage)
+
+	resp := c.request(logical.ReadOperation, "acme/directory", nil)
+	if resp.status != http.StatusForbidden {
+		t.Fatalf("bad: %d %s", resp.status, resp.body)
+	}
+
+	// Enabling requires a base URL and a role for accounts without EAB
+	for _, data := range []map[string]interface{}{
+		{"enabled": true, "default_role": "web"},
+		{"enabled": true, "base_url": testACMEBaseURL},
+		{"base_url": "ftp://vault.example.com"},
+		{"challenge_types": "tls-alpn-01"},
+	} {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "config/acme",
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || !resp.IsError() {
+			t.Fatalf("expected error for %v: resp: %#v, err: %v", data, resp, err)
+		}
+	}
+}
@@ -2,10 +2,12 @@ package pki
 
 import (
 	"context"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/hashicorp/vault/helper/locksutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -33,11 +35,29 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"ca",
 				"crl/pem",
 				"crl",
+				"crl/delta",
+				"crl/delta/pem",
+				"ca/issuer/*",
+				"crl/issuer/*",
+				"acme/directory",
+				"acme/new-nonce",
+				"acme/new-account",
+				"acme/new-order",
+				"acme/revoke-cert",
+				"acme/account/*",
+				"acme/order/*",
+				"acme/authorization/*",
+				"acme/challenge/*",
+				"acme/cert/*",
 			},
 
 			LocalStorage: []string{
 				"revoked/",
 				"crl",
+				"crls/",
+				"delta-crl",
+				"delta-crls/",
+				"crl-state",
 				"certs/",
 			},
 
@@ -48,6 +68,8 @@ func Backend(conf *logical.BackendConfig) *backend {
 
 			SealWrapStorage: []string{
 				"config/ca_bundle",
+				"issuer/",
+				"acme/eab/",
 			},
 		},
 
@@ -63,6 +85,14 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathConfigCA(&b),
 			pathConfigCRL(&b),
 			pathConfigURLs(&b),
+			pathConfigIssuers(&b),
+			pathListIssuers(&b),
+			pathGenerateIssuerRoot(&b),
+			pathImportIssuer(&b),
+			pathIssuer(&b),
+			pathCrossSignIssuer(&b),
+			pathFetchIssuerCA(&b),
+			pathFetchIssuerCRL(&b),
 			pathSignVerbatim(&b),
 			pathSign(&b),
 			pathIssue(&b),
@@ -70,23 +100,45 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathFetchCA(&b),
 			pathFetchCAChain(&b),
 			pathFetchCRL(&b),
+			pathFetchDeltaCRL(&b),
 			pathFetchCRLViaCertPath(&b),
 			pathFetchValid(&b),
 			pathFetchListCerts(&b),
 			pathRevoke(&b),
 			pathTidy(&b),
+			pathConfigACME(&b),
+			pathACMENewEAB(&b),
+			pathListACMEEAB(&b),
+			pathACMEEAB(&b),
+			pathACMEDirectory(&b),
+			pathACMENewNonce(&b),
+			pathACMENewAccount(&b),
+			pathACMEAccount(&b),
+			pathACMEAccountOrders(&b),
+			pathACMENewOrder(&b),
+			pathACMEOrder(&b),
+			pathACMEOrderFinalize(&b),
+			pathACMEAuthorization(&b),
+			pathACMEChallenge(&b),
+			pathACMECert(&b),
+			pathACMERevokeCert(&b),
 		},
 
 		Secrets: []*framework.Secret{
 			secretCerts(&b),
 		},
 
-		BackendType: logical.TypeLogical,
+		// Rebuild the full CRLs whose delta CRLs are due for a new base
+		PeriodicFunc: b.periodicFunc,
+		BackendType:  logical.TypeLogical,
 	}
 
 	b.crlLifetime = time.Hour * 72
 	b.tidyCASGuard = new(uint32)
 	b.storage = conf.StorageView
+	b.acmeLocks = locksutil.CreateLocks()
+	b.acmeHTTPClient = defaultACMEHTTPClient()
+	b.acmeLookupTXT = defaultACMETXTLookup
 
 	return &b
 }
@@ -97,7 +149,40 @@ type backend struct {
 	storage           logical.Storage
 	crlLifetime       time.Duration
 	revokeStorageLock sync.RWMutex
+	crlBuildLock      sync.Mutex
+	issuersLock       sync.Mutex
 	tidyCASGuard      *uint32
+
+	acmeNonces      acmeNonces
+	acmeAccountLock sync.Mutex
+	acmeLocks       []*locksutil.LockEntry
+	acmeHTTPClient  *http.Client
+	acmeLookupTXT   acmeTXTLookup
+}
+
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	config, err := b.CRL(ctx, req.Storage)
+	if err != nil || config == nil || !config.EnableDelta {
+		return err
+	}
+
+	// Only the CRLs built since the delta CRLs were enabled are rebuilt, so
+	// that a mount without a CA is left alone
+	state, err := getCRLState(ctx, req.Storage)
+	if err != nil || len(state.Bases) == 0 {
+		return err
+	}
+	signers, err := fetchCRLSigners(ctx, req)
+	if err != nil {
+		return err
+	}
+	if !fullCRLDue(state, config, signers) {
+		return nil
+	}
+
+	b.revokeStorageLock.RLock()
+	defer b.revokeStorageLock.RUnlock()
+	return buildCRL(ctx, b, req)
 }
 
 const backendHelp = `
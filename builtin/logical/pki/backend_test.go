@@ -449,6 +449,7 @@ func generateURLSteps(t *testing.T, caCert, caKey string, intdata, reqdata map[s
 			"http://example.com/crl1",
 			"http://example.com/crl2",
 		},
+		DeltaCRLDistributionPoints: []string{},
 		OCSPServers: []string{
 			"http://example.com/ocsp1",
 			"http://example.com/ocsp2",
@@ -1708,6 +1709,116 @@ func TestBackend_SignVerbatim(t *testing.T) {
 	if math.Abs(float64(resp.Secret.TTL-(5*time.Hour))) > float64(5*time.Hour) {
 		t.Fatalf("ttl not default; wanted %v, got %v", b.System().DefaultLeaseTTL(), resp.Secret.TTL)
 	}
+
+	// A role that does not exist is not treated as no role
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign-verbatim/missing",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"csr": pemCSR,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error signing with an unknown role: %#v", resp)
+	}
+}
+
+func TestBackend_RevokeNoStore(t *testing.T) {
+	config := logical.TestBackendConfig()
+	storage := &logical.InmemStorage{}
+	config.StorageView = storage
+
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := request("root/generate/internal", map[string]interface{}{
+		"common_name": "test.com",
+		"ttl":         "172800",
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("failed to generate root: %#v", resp)
+	}
+	caPEM := resp.Data["certificate"].(string)
+
+	resp = request("roles/test", map[string]interface{}{
+		"allow_any_name": true,
+		"no_store":       true,
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("failed to create a role: %#v", resp)
+	}
+
+	resp = request("issue/test", map[string]interface{}{
+		"common_name": "foo.test.com",
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("failed to issue: %#v", resp)
+	}
+	certPEM := resp.Data["certificate"].(string)
+	serial := resp.Data["serial_number"].(string)
+
+	// The certificate was not stored, so its serial number is not enough
+	resp = request("revoke", map[string]interface{}{
+		"serial_number": serial,
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error revoking by serial number: %#v", resp)
+	}
+
+	resp = request("revoke", map[string]interface{}{
+		"certificate": caPEM,
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error revoking the CA: %#v", resp)
+	}
+
+	resp = request("revoke", map[string]interface{}{
+		"certificate": certPEM,
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("failed to revoke by certificate: %#v", resp)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "cert/crl",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("failed to read the CRL: %#v, %v", resp, err)
+	}
+	block, _ := pem.Decode([]byte(resp.Data["certificate"].(string)))
+	if block == nil {
+		t.Fatal("nil pem block")
+	}
+	crl, err := x509.ParseCRL(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked := crl.TBSCertList.RevokedCertificates
+	if len(revoked) != 1 || certutil.GetHexFormatted(revoked[0].SerialNumber.Bytes(), ":") != serial {
+		t.Fatalf("expected %s to be revoked: %#v", serial, revoked)
+	}
 }
 
 func TestBackend_Root_Idempotency(t *testing.T) {
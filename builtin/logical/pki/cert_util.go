@@ -14,6 +14,7 @@ import (
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 	"regexp"
@@ -59,6 +60,7 @@ type dataBundle struct {
 	role          *roleEntry
 	req           *logical.Request
 	apiData       *framework.FieldData
+	randomSource  io.Reader
 }
 
 type creationParameters struct {
@@ -175,22 +177,14 @@ func validateKeyTypeLength(keyType string, keyBits int) *logical.Response {
 	return nil
 }
 
-// Fetches the CA info. Unlike other certificates, the CA info is stored
-// in the backend as a CertBundle, because we are storing its private key
+// Fetches the CA info of the default issuer. Unlike other certificates, the
+// CA info is stored in the backend as a CertBundle, because we are storing
+// its private key
 func fetchCAInfo(ctx context.Context, req *logical.Request) (*caInfoBundle, error) {
-	bundleEntry, err := req.Storage.Get(ctx, "config/ca_bundle")
-	if err != nil {
-		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch local CA certificate/key: %v", err)}
-	}
-	if bundleEntry == nil {
-		return nil, errutil.UserError{Err: "backend must be configured with a CA certificate/key"}
-	}
-
-	var bundle certutil.CertBundle
-	if err := bundleEntry.DecodeJSON(&bundle); err != nil {
-		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode local CA certificate/key: %v", err)}
-	}
+	return fetchCAInfoByIssuer(ctx, req, defaultIssuerRef)
+}
 
+func caInfoFromBundle(ctx context.Context, req *logical.Request, bundle *certutil.CertBundle) (*caInfoBundle, error) {
 	parsedBundle, err := bundle.ToParsedCertBundle()
 	if err != nil {
 		return nil, errutil.InternalError{Err: err.Error()}
@@ -218,6 +212,17 @@ func fetchCAInfo(ctx context.Context, req *logical.Request) (*caInfoBundle, erro
 	return caInfo, nil
 }
 
+// caInfoFromIssuer returns the CA info of the issuer, with the URLs it
+// overrides
+func caInfoFromIssuer(ctx context.Context, req *logical.Request, issuer *issuerEntry) (*caInfoBundle, error) {
+	caInfo, err := caInfoFromBundle(ctx, req, issuer.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	caInfo.URLs = overrideURLs(caInfo.URLs, issuer.URLs)
+	return caInfo, nil
+}
+
 // Allows fetching certificates from the backend; it handles the slightly
 // separate pathing for CA, CRL, and revoked certificates.
 func fetchCertBySerial(ctx context.Context, req *logical.Request, prefix, serial string) (*logical.StorageEntry, error) {
@@ -238,6 +243,8 @@ func fetchCertBySerial(ctx context.Context, req *logical.Request, prefix, serial
 		path = "ca"
 	case serial == "crl":
 		path = "crl"
+	case serial == deltaCRLPath:
+		path = deltaCRLPath
 	default:
 		legacyPath = "certs/" + colonSerial
 		path = "certs/" + hyphenSerial
@@ -581,6 +588,8 @@ func generateCert(ctx context.Context,
 		}
 	}
 
+	data.randomSource = b.GetRandomReader()
+
 	parsedBundle, err := createCertificate(data)
 	if err != nil {
 		return nil, err
@@ -600,6 +609,8 @@ func generateIntermediateCSR(b *backend, data *dataBundle) (*certutil.ParsedCSRB
 		return nil, errutil.InternalError{Err: "nil parameters received from parameter bundle generation"}
 	}
 
+	data.randomSource = b.GetRandomReader()
+
 	parsedBundle, err := createCSR(data)
 	if err != nil {
 		return nil, err
@@ -1143,6 +1154,17 @@ func addExtKeyUsageOids(data *dataBundle, certTemplate *x509.Certificate) {
 
 // Performs the heavy lifting of creating a certificate. Returns
 // a fully-filled-in ParsedCertBundle.
+// addFreshestCRL adds the delta CRL distribution points, if any, to the
+// certificate
+func addFreshestCRL(urls *urlEntries, certTemplate *x509.Certificate) error {
+	extensions, err := freshestCRLExtensions(urls)
+	if err != nil {
+		return errwrap.Wrapf("error encoding delta CRL distribution points: {{err}}", err)
+	}
+	certTemplate.ExtraExtensions = append(certTemplate.ExtraExtensions, extensions...)
+	return nil
+}
+
 func createCertificate(data *dataBundle) (*certutil.ParsedCertBundle, error) {
 	var err error
 	result := &certutil.ParsedCertBundle{}
@@ -1152,9 +1174,15 @@ func createCertificate(data *dataBundle) (*certutil.ParsedCertBundle, error) {
 		return nil, err
 	}
 
-	if err := certutil.GeneratePrivateKey(data.params.KeyType,
+	randomSource := data.randomSource
+	if randomSource == nil {
+		randomSource = rand.Reader
+	}
+
+	if err := certutil.GeneratePrivateKeyWithRandomSource(data.params.KeyType,
 		data.params.KeyBits,
-		result); err != nil {
+		result,
+		randomSource); err != nil {
 		return nil, err
 	}
 
@@ -1203,6 +1231,9 @@ func createCertificate(data *dataBundle) (*certutil.ParsedCertBundle, error) {
 	certTemplate.IssuingCertificateURL = data.params.URLs.IssuingCertificates
 	certTemplate.CRLDistributionPoints = data.params.URLs.CRLDistributionPoints
 	certTemplate.OCSPServer = data.params.URLs.OCSPServers
+	if err := addFreshestCRL(data.params.URLs, certTemplate); err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
 
 	var certBytes []byte
 	if data.signingBundle != nil {
@@ -1271,9 +1302,15 @@ func createCSR(data *dataBundle) (*certutil.ParsedCSRBundle, error) {
 	var err error
 	result := &certutil.ParsedCSRBundle{}
 
-	if err := certutil.GeneratePrivateKey(data.params.KeyType,
+	randomSource := data.randomSource
+	if randomSource == nil {
+		randomSource = rand.Reader
+	}
+
+	if err := certutil.GeneratePrivateKeyWithRandomSource(data.params.KeyType,
 		data.params.KeyBits,
-		result); err != nil {
+		result,
+		randomSource); err != nil {
 		return nil, err
 	}
 
@@ -1414,6 +1451,9 @@ func signCertificate(data *dataBundle) (*certutil.ParsedCertBundle, error) {
 	certTemplate.IssuingCertificateURL = data.params.URLs.IssuingCertificates
 	certTemplate.CRLDistributionPoints = data.params.URLs.CRLDistributionPoints
 	certTemplate.OCSPServer = data.signingBundle.URLs.OCSPServers
+	if err := addFreshestCRL(data.params.URLs, certTemplate); err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
 
 	if data.params.IsCA {
 		certTemplate.BasicConstraintsValid = true
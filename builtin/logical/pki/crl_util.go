@@ -5,22 +5,40 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 )
 
+const (
+	// deltaCRLPath is where the delta CRL of the default issuer is stored,
+	// alongside its full CRL at crl
+	deltaCRLPath         = "delta-crl"
+	issuerDeltaCRLPrefix = "delta-crls/"
+	crlStatePath         = "crl-state"
+)
+
+var (
+	oidExtensionDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+	oidExtensionFreshestCRL       = asn1.ObjectIdentifier{2, 5, 29, 46}
+)
+
 type revocationInfo struct {
 	CertificateBytes  []byte    `json:"certificate_bytes"`
 	RevocationTime    int64     `json:"revocation_time"`
 	RevocationTimeUTC time.Time `json:"revocation_time_utc"`
 }
 
-// Revokes a cert, and tries to be smart about error recovery
-func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial string, fromLease bool) (*logical.Response, error) {
+// Revokes a cert, and tries to be smart about error recovery. The
+// certificate bytes are only needed for certificates that were not stored,
+// and must have been verified as issued by the CA by the caller.
+func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial string, certBytes []byte, fromLease bool) (*logical.Response, error) {
 	// As this backend is self-contained and this function does not hook into
 	// third parties to manage users or resources, if the mount is tainted,
 	// revocation doesn't matter anyways -- the CRL that would be written will
@@ -61,6 +79,12 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 				return nil, err
 			}
 		}
+		if certEntry == nil && certBytes != nil {
+			certEntry = &logical.StorageEntry{
+				Key:   "certs/" + normalizeSerial(serial),
+				Value: certBytes,
+			}
+		}
 		if certEntry == nil {
 			return logical.ErrorResponse(fmt.Sprintf("certificate with serial %s not found", serial)), nil
 		}
@@ -100,7 +124,7 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 
 	}
 
-	crlErr := buildCRL(ctx, b, req)
+	crlErr := updateCRL(ctx, b, req)
 	switch crlErr.(type) {
 	case errutil.UserError:
 		return logical.ErrorResponse(fmt.Sprintf("Error during CRL building: %s", crlErr)), nil
@@ -119,39 +143,146 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 	return resp, nil
 }
 
-// Builds a CRL by going through the list of revoked certificates and building
-// a new CRL with the stored revocation times and serial numbers.
-func buildCRL(ctx context.Context, b *backend, req *logical.Request) error {
+// revokedCertInfo is a revoked certificate and its entry in the CRLs
+type revokedCertInfo struct {
+	cert  *x509.Certificate
+	entry pkix.RevokedCertificate
+}
+
+// crlSigner is an issuer signing a CRL, along with the keys its full and
+// delta CRLs are stored at
+type crlSigner struct {
+	// id is empty for the one CA of a mount predating multiple issuers
+	id        string
+	bundle    *caInfoBundle
+	keys      []string
+	deltaKeys []string
+}
+
+// crlState is the numbering of the CRLs of the mount. A single counter
+// numbers all of the CRLs, full and delta, so that the numbers of the CRLs of
+// each issuer increase even as issuers are added or replaced.
+type crlState struct {
+	Number int64                   `json:"number"`
+	Bases  map[string]crlBaseState `json:"bases"`
+}
+
+// crlBaseState is the last full CRL of an issuer, the base of its delta CRL
+type crlBaseState struct {
+	Number int64     `json:"number"`
+	Time   time.Time `json:"time"`
+}
+
+func getCRLState(ctx context.Context, s logical.Storage) (*crlState, error) {
+	entry, err := s.Get(ctx, crlStatePath)
+	if err != nil {
+		return nil, err
+	}
+	state := &crlState{}
+	if entry != nil {
+		if err := entry.DecodeJSON(state); err != nil {
+			return nil, err
+		}
+	}
+	if state.Bases == nil {
+		state.Bases = map[string]crlBaseState{}
+	}
+	return state, nil
+}
+
+func storeCRLState(ctx context.Context, s logical.Storage, state *crlState) error {
+	entry, err := logical.StorageEntryJSON(crlStatePath, state)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// fullCRLDue returns whether the delta CRLs cannot be rebuilt without
+// rebuilding the full CRLs, as their base is missing or too old
+func fullCRLDue(state *crlState, config *crlConfig, signers []*crlSigner) bool {
+	rawInterval := config.FullRebuildInterval
+	if rawInterval == "" {
+		rawInterval = defaultCRLFullRebuildInterval
+	}
+	interval, err := time.ParseDuration(rawInterval)
+	if err != nil {
+		return true
+	}
+	for _, signer := range signers {
+		if !crlNumbersSupported(signer.bundle) {
+			continue
+		}
+		base, ok := state.Bases[signer.id]
+		if !ok || time.Since(base.Time) >= interval {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCRL updates the CRLs following a revocation. With delta CRLs
+// enabled, only the delta CRLs are rebuilt until the full CRLs are due.
+func updateCRL(ctx context.Context, b *backend, req *logical.Request) error {
+	config, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
+	}
+	b.crlBuildLock.Lock()
+	defer b.crlBuildLock.Unlock()
+
+	if config == nil || !config.EnableDelta {
+		return buildFullCRL(ctx, b, req)
+	}
+
+	signers, err := fetchCRLSigners(ctx, req)
+	if err != nil {
+		return err
+	}
+	state, err := getCRLState(ctx, req.Storage)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL state: %s", err)}
+	}
+	if fullCRLDue(state, config, signers) {
+		return buildFullCRL(ctx, b, req)
+	}
+	return buildDeltaCRL(ctx, b, req, config, state, signers)
+}
+
+// fetchRevokedCerts returns the revoked certificates of the mount
+func fetchRevokedCerts(ctx context.Context, req *logical.Request) ([]revokedCertInfo, error) {
 	revokedSerials, err := req.Storage.List(ctx, "revoked/")
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error fetching list of revoked certs: %s", err)}
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching list of revoked certs: %s", err)}
 	}
 
-	revokedCerts := []pkix.RevokedCertificate{}
-	var revInfo revocationInfo
+	revokedCerts := []revokedCertInfo{}
 	for _, serial := range revokedSerials {
+		// The parsed certificates refer to the bytes they are parsed from, so
+		// each is decoded into its own revocation info
+		var revInfo revocationInfo
 		revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("unable to fetch revoked cert with serial %s: %s", serial, err)}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch revoked cert with serial %s: %s", serial, err)}
 		}
 		if revokedEntry == nil {
-			return errutil.InternalError{Err: fmt.Sprintf("revoked certificate entry for serial %s is nil", serial)}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("revoked certificate entry for serial %s is nil", serial)}
 		}
 		if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
 			// TODO: In this case, remove it and continue? How likely is this to
 			// happen? Alternately, could skip it entirely, or could implement a
 			// delete function so that there is a way to remove these
-			return errutil.InternalError{Err: fmt.Sprintf("found revoked serial but actual certificate is empty")}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("found revoked serial but actual certificate is empty")}
 		}
 
 		err = revokedEntry.DecodeJSON(&revInfo)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("error decoding revocation entry for serial %s: %s", serial, err)}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error decoding revocation entry for serial %s: %s", serial, err)}
 		}
 
 		revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("unable to parse stored revoked certificate with serial %s: %s", serial, err)}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse stored revoked certificate with serial %s: %s", serial, err)}
 		}
 
 		// NOTE: We have to change this to UTC time because the CRL standard
@@ -164,41 +295,338 @@ func buildCRL(ctx context.Context, b *backend, req *logical.Request) error {
 		} else {
 			newRevCert.RevocationTime = time.Unix(revInfo.RevocationTime, 0).UTC()
 		}
-		revokedCerts = append(revokedCerts, newRevCert)
+		revokedCerts = append(revokedCerts, revokedCertInfo{
+			cert:  revokedCert,
+			entry: newRevCert,
+		})
 	}
 
-	signingBundle, caErr := fetchCAInfo(ctx, req)
-	switch caErr.(type) {
-	case errutil.UserError:
-		return errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
-	case errutil.InternalError:
-		return errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+	return revokedCerts, nil
+}
+
+// fetchCRLSigners returns the issuers signing the CRLs of the mount
+func fetchCRLSigners(ctx context.Context, req *logical.Request) ([]*crlSigner, error) {
+	issuers, err := listIssuers(ctx, req.Storage)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching list of issuers: %s", err)}
+	}
+
+	// The one CA of a mount predating multiple issuers signs every revocation
+	if len(issuers) == 0 {
+		signingBundle, caErr := fetchCAInfo(ctx, req)
+		switch caErr.(type) {
+		case errutil.UserError:
+			return nil, errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
+		case errutil.InternalError:
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+		}
+		return []*crlSigner{{
+			bundle:    signingBundle,
+			keys:      []string{"crl"},
+			deltaKeys: []string{deltaCRLPath},
+		}}, nil
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching issuers config: %s", err)}
+	}
+
+	signers := make([]*crlSigner, len(issuers))
+	for i, issuer := range issuers {
+		signingBundle, err := caInfoFromIssuer(ctx, req, issuer)
+		if err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate of issuer %s: %s", issuer.ID, err)}
+		}
+		signers[i] = &crlSigner{
+			id:        issuer.ID,
+			bundle:    signingBundle,
+			keys:      []string{issuerCRLPrefix + issuer.ID},
+			deltaKeys: []string{issuerDeltaCRLPrefix + issuer.ID},
+		}
+		if config != nil && issuer.ID == config.Default {
+			signers[i].keys = append(signers[i].keys, "crl")
+			signers[i].deltaKeys = append(signers[i].deltaKeys, deltaCRLPath)
+		}
+	}
+	return signers, nil
+}
+
+// assignRevokedCerts returns the entries of the revoked certificates each
+// signer lists. Each certificate is listed by the issuers sharing the key it
+// was signed with. Those issued by none of them, as by a CA since replaced
+// over config/ca, are listed by the default issuer.
+func assignRevokedCerts(signers []*crlSigner, revokedCerts []revokedCertInfo) [][]pkix.RevokedCertificate {
+	entries := make([][]pkix.RevokedCertificate, len(signers))
+	if len(signers) == 1 && signers[0].id == "" {
+		for _, revoked := range revokedCerts {
+			entries[0] = append(entries[0], revoked.entry)
+		}
+		return entries
+	}
+
+	for _, revoked := range revokedCerts {
+		found := false
+		for i, signer := range signers {
+			if issuedBy(revoked.cert, signer.bundle.Certificate) {
+				entries[i] = append(entries[i], revoked.entry)
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		for i, signer := range signers {
+			if strutil.StrListContains(signer.keys, "crl") {
+				entries[i] = append(entries[i], revoked.entry)
+			}
+		}
+	}
+	return entries
+}
+
+// Builds the CRLs by going through the list of revoked certificates and
+// building a new CRL for each issuer with the stored revocation times and
+// serial numbers of the certificates it issued. With delta CRLs enabled, each
+// issuer's delta CRL is rebuilt empty, with the new CRL as its base.
+func buildCRL(ctx context.Context, b *backend, req *logical.Request) error {
+	b.crlBuildLock.Lock()
+	defer b.crlBuildLock.Unlock()
+
+	return buildFullCRL(ctx, b, req)
+}
+
+func buildFullCRL(ctx context.Context, b *backend, req *logical.Request) error {
+	// The certificates revoked from now on are listed by the delta CRLs
+	baseTime := time.Now()
+
+	revokedCerts, err := fetchRevokedCerts(ctx, req)
+	if err != nil {
+		return err
 	}
 
-	crlLifetime := b.crlLifetime
 	crlInfo, err := b.CRL(ctx, req.Storage)
 	if err != nil {
 		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
 	}
-	if crlInfo != nil {
-		crlDur, err := time.ParseDuration(crlInfo.Expiry)
+	crlLifetime, err := b.crlLifetimeFromConfig(crlInfo)
+	if err != nil {
+		return err
+	}
+	enableDelta := crlInfo != nil && crlInfo.EnableDelta
+
+	signers, err := fetchCRLSigners(ctx, req)
+	if err != nil {
+		return err
+	}
+	entries := assignRevokedCerts(signers, revokedCerts)
+
+	// The numbers are stored before being used, so that they are never
+	// reused if storing the CRLs fails
+	state, err := getCRLState(ctx, req.Storage)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL state: %s", err)}
+	}
+	numbers := make([]int64, len(signers))
+	bases := make(map[string]crlBaseState, len(signers))
+	for i, signer := range signers {
+		state.Number++
+		numbers[i] = state.Number
+		if enableDelta && crlNumbersSupported(signer.bundle) {
+			bases[signer.id] = crlBaseState{
+				Number: numbers[i],
+				Time:   baseTime,
+			}
+			state.Number++
+		}
+	}
+	state.Bases = bases
+	if err := storeCRLState(ctx, req.Storage, state); err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL state: %s", err)}
+	}
+
+	for i, signer := range signers {
+		base, ok := bases[signer.id]
+		var extensions []pkix.Extension
+		if ok {
+			extensions, err = freshestCRLExtensions(signer.bundle.URLs)
+			if err != nil {
+				return errutil.InternalError{Err: fmt.Sprintf("error encoding delta CRL distribution points: %s", err)}
+			}
+		}
+		if err := storeCRL(ctx, req, signer.keys, signer.bundle, entries[i], crlLifetime, numbers[i], extensions); err != nil {
+			return err
+		}
+
+		if !ok {
+			for _, key := range signer.deltaKeys {
+				if err := req.Storage.Delete(ctx, key); err != nil {
+					return errutil.InternalError{Err: fmt.Sprintf("error deleting delta CRL: %s", err)}
+				}
+			}
+			continue
+		}
+		extensions, err = deltaCRLExtensions(base.Number)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("error parsing CRL duration of %s", crlInfo.Expiry)}
+			return errutil.InternalError{Err: fmt.Sprintf("error encoding delta CRL indicator: %s", err)}
+		}
+		if err := storeCRL(ctx, req, signer.deltaKeys, signer.bundle, nil, crlLifetime, base.Number+1, extensions); err != nil {
+			return err
 		}
-		crlLifetime = crlDur
 	}
 
-	crlBytes, err := signingBundle.Certificate.CreateCRL(rand.Reader, signingBundle.PrivateKey, revokedCerts, time.Now(), time.Now().Add(crlLifetime))
+	return nil
+}
+
+// buildDeltaCRL builds the delta CRL of each issuer, listing the
+// certificates revoked since its last full CRL
+func buildDeltaCRL(ctx context.Context, b *backend, req *logical.Request, config *crlConfig, state *crlState, signers []*crlSigner) error {
+	crlLifetime, err := b.crlLifetimeFromConfig(config)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
+		return err
 	}
 
-	err = req.Storage.Put(ctx, &logical.StorageEntry{
-		Key:   "crl",
-		Value: crlBytes,
-	})
+	revokedCerts, err := fetchRevokedCerts(ctx, req)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL: %s", err)}
+		return err
+	}
+	entries := assignRevokedCerts(signers, revokedCerts)
+
+	numbers := make([]int64, len(signers))
+	for i := range signers {
+		state.Number++
+		numbers[i] = state.Number
+	}
+	if err := storeCRLState(ctx, req.Storage, state); err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL state: %s", err)}
+	}
+
+	for i, signer := range signers {
+		base, ok := state.Bases[signer.id]
+		if !ok {
+			continue
+		}
+		var delta []pkix.RevokedCertificate
+		for _, entry := range entries[i] {
+			if !entry.RevocationTime.Before(base.Time) {
+				delta = append(delta, entry)
+			}
+		}
+
+		extensions, err := deltaCRLExtensions(base.Number)
+		if err != nil {
+			return errutil.InternalError{Err: fmt.Sprintf("error encoding delta CRL indicator: %s", err)}
+		}
+		if err := storeCRL(ctx, req, signer.deltaKeys, signer.bundle, delta, crlLifetime, numbers[i], extensions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// crlLifetimeFromConfig returns the lifetime of the CRLs as configured
+func (b *backend) crlLifetimeFromConfig(config *crlConfig) (time.Duration, error) {
+	if config == nil {
+		return b.crlLifetime, nil
+	}
+	crlDur, err := time.ParseDuration(config.Expiry)
+	if err != nil {
+		return 0, errutil.InternalError{Err: fmt.Sprintf("error parsing CRL duration of %s", config.Expiry)}
+	}
+	return crlDur, nil
+}
+
+// crlNumbersSupported returns whether the CRLs of the CA can be numbered,
+// which requires its certificate to allow signing CRLs and to have a subject
+// key identifier. The CRLs of other CAs are not numbered, and they have no
+// delta CRL.
+func crlNumbersSupported(signingBundle *caInfoBundle) bool {
+	return signingBundle.Certificate.KeyUsage&x509.KeyUsageCRLSign != 0 &&
+		len(signingBundle.Certificate.SubjectKeyId) > 0
+}
+
+// deltaCRLExtensions returns the extensions of a delta CRL of the base CRL
+// with the given number
+func deltaCRLExtensions(baseNumber int64) ([]pkix.Extension, error) {
+	value, err := asn1.Marshal(big.NewInt(baseNumber))
+	if err != nil {
+		return nil, err
+	}
+	return []pkix.Extension{{
+		Id:       oidExtensionDeltaCRLIndicator,
+		Critical: true,
+		Value:    value,
+	}}, nil
+}
+
+// freshestCRLExtensions returns the extensions pointing to the delta CRL
+// distribution points, if any, of the certificates and full CRLs of an
+// issuer
+func freshestCRLExtensions(urls *urlEntries) ([]pkix.Extension, error) {
+	if urls == nil || len(urls.DeltaCRLDistributionPoints) == 0 {
+		return nil, nil
+	}
+
+	// The distribution points are encoded as the CRL distribution points of
+	// certificates are
+	type distributionPointName struct {
+		FullName []asn1.RawValue `asn1:"optional,tag:0"`
+	}
+	type distributionPoint struct {
+		DistributionPoint distributionPointName `asn1:"optional,tag:0"`
+	}
+	var points []distributionPoint
+	for _, url := range urls.DeltaCRLDistributionPoints {
+		points = append(points, distributionPoint{
+			DistributionPoint: distributionPointName{
+				FullName: []asn1.RawValue{{Tag: 6, Class: 2, Bytes: []byte(url)}},
+			},
+		})
+	}
+	value, err := asn1.Marshal(points)
+	if err != nil {
+		return nil, err
+	}
+	return []pkix.Extension{{
+		Id:    oidExtensionFreshestCRL,
+		Value: value,
+	}}, nil
+}
+
+// storeCRL signs the CRL of the revoked certificates and stores it at each
+// of the keys
+func storeCRL(ctx context.Context, req *logical.Request, keys []string, signingBundle *caInfoBundle, revokedCerts []pkix.RevokedCertificate, crlLifetime time.Duration, number int64, extensions []pkix.Extension) error {
+	if revokedCerts == nil {
+		revokedCerts = []pkix.RevokedCertificate{}
+	}
+
+	var crlBytes []byte
+	var err error
+	now := time.Now()
+	if crlNumbersSupported(signingBundle) {
+		crlBytes, err = x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			RevokedCertificates: revokedCerts,
+			Number:              big.NewInt(number),
+			ThisUpdate:          now,
+			NextUpdate:          now.Add(crlLifetime),
+			ExtraExtensions:     extensions,
+		}, signingBundle.Certificate, signingBundle.PrivateKey)
+	} else {
+		crlBytes, err = signingBundle.Certificate.CreateCRL(rand.Reader, signingBundle.PrivateKey, revokedCerts, now, now.Add(crlLifetime))
+	}
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
+	}
+
+	for _, key := range keys {
+		err = req.Storage.Put(ctx, &logical.StorageEntry{
+			Key:   key,
+			Value: crlBytes,
+		})
+		if err != nil {
+			return errutil.InternalError{Err: fmt.Sprintf("error storing CRL: %s", err)}
+		}
 	}
 
 	return nil
@@ -0,0 +1,153 @@
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func deltaCRLTestParse(t *testing.T, b *backend, storage logical.Storage, path string) *x509.RevocationList {
+	t.Helper()
+	resp := issuersTestRequest(t, b, storage, logical.ReadOperation, path, nil)
+	der := resp.Data[logical.HTTPRawBody].([]byte)
+	if block, _ := pem.Decode(der); block != nil {
+		der = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crl
+}
+
+// deltaCRLTestBase returns the number of the base CRL of a delta CRL
+func deltaCRLTestBase(t *testing.T, crl *x509.RevocationList) *big.Int {
+	t.Helper()
+	for _, ext := range crl.Extensions {
+		if ext.Id.Equal(oidExtensionDeltaCRLIndicator) {
+			var base *big.Int
+			if _, err := asn1.Unmarshal(ext.Value, &base); err != nil {
+				t.Fatal(err)
+			}
+			return base
+		}
+	}
+	t.Fatal("missing delta CRL indicator")
+	return nil
+}
+
+func TestPki_DeltaCRL(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	resp := issuersTestRequest(t, b, storage, logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"key_type":    "ec",
+		"key_bits":    256,
+		"ttl":         "8760h",
+	})
+	issuerID := resp.Data["issuer_id"].(string)
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "roles/example", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"key_bits":         256,
+	})
+	issue := func(name string) string {
+		resp := issuersTestRequest(t, b, storage, logical.UpdateOperation, "issue/example", map[string]interface{}{
+			"common_name": name,
+		})
+		return resp.Data["serial_number"].(string)
+	}
+	revoke := func(serial string) {
+		issuersTestRequest(t, b, storage, logical.UpdateOperation, "revoke", map[string]interface{}{
+			"serial_number": serial,
+		})
+	}
+
+	// The rebuild interval must leave time to rebuild the full CRLs
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/crl",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"enable_delta":          true,
+			"full_rebuild_interval": "96h",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected the interval to be refused: err: %v resp: %#v", err, resp)
+	}
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"enable_delta": true,
+	})
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "config/crl", nil)
+	if resp.Data["expiry"] != "72h" || resp.Data["enable_delta"] != true || resp.Data["full_rebuild_interval"] != "24h" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The first revocation builds the full CRLs, which the following ones
+	// leave alone
+	revoke(issue("a.example.com"))
+	full := deltaCRLTestParse(t, b, storage, "crl")
+	if len(full.RevokedCertificateEntries) != 1 {
+		t.Fatalf("bad full CRL: %#v", full.RevokedCertificateEntries)
+	}
+	delta := deltaCRLTestParse(t, b, storage, "crl/delta")
+	if len(delta.RevokedCertificateEntries) != 0 || deltaCRLTestBase(t, delta).Cmp(full.Number) != 0 {
+		t.Fatalf("bad delta CRL of base %v: %#v", deltaCRLTestBase(t, delta), delta.RevokedCertificateEntries)
+	}
+
+	revoke(issue("b.example.com"))
+	revoke(issue("c.example.com"))
+	if next := deltaCRLTestParse(t, b, storage, "crl"); next.Number.Cmp(full.Number) != 0 {
+		t.Fatalf("expected the full CRL not to be rebuilt")
+	}
+	next := deltaCRLTestParse(t, b, storage, "crl/issuer/"+issuerID+"/delta")
+	if len(next.RevokedCertificateEntries) != 2 || next.Number.Cmp(delta.Number) <= 0 || deltaCRLTestBase(t, next).Cmp(full.Number) != 0 {
+		t.Fatalf("bad delta CRL %v: %#v", next.Number, next.RevokedCertificateEntries)
+	}
+	delta = next
+
+	// The full CRLs are rebuilt once due, with an empty delta CRL
+	state, err := getCRLState(context.Background(), storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := state.Bases[issuerID]
+	base.Time = base.Time.Add(-25 * time.Hour)
+	state.Bases[issuerID] = base
+	if err := storeCRLState(context.Background(), storage, state); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	next = deltaCRLTestParse(t, b, storage, "crl/pem")
+	if len(next.RevokedCertificateEntries) != 3 || next.Number.Cmp(delta.Number) <= 0 {
+		t.Fatalf("bad full CRL %v: %#v", next.Number, next.RevokedCertificateEntries)
+	}
+	full = next
+	delta = deltaCRLTestParse(t, b, storage, "crl/delta/pem")
+	if len(delta.RevokedCertificateEntries) != 0 || deltaCRLTestBase(t, delta).Cmp(full.Number) != 0 {
+		t.Fatalf("bad delta CRL: %#v", delta.RevokedCertificateEntries)
+	}
+
+	// Disabling the delta CRLs removes them on the next build
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"enable_delta": false,
+	})
+	revoke(issue("d.example.com"))
+	if full := deltaCRLTestParse(t, b, storage, "crl"); len(full.RevokedCertificateEntries) != 4 {
+		t.Fatalf("bad full CRL: %#v", full.RevokedCertificateEntries)
+	}
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "crl/delta", nil)
+	if resp.Data[logical.HTTPStatusCode] != 204 {
+		t.Fatalf("expected no delta CRL: %#v", resp.Data)
+	}
+}
@@ -0,0 +1,357 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+// Each CA certificate and key a mount can issue from is an issuer stored at
+// issuer/<id>. The default issuer, used by the paths and roles not naming
+// one, is also stored at config/ca_bundle and ca, as the one CA of mounts
+// predating multiple issuers was, so that those entries stay current.
+const (
+	issuerPrefix      = "issuer/"
+	issuerCRLPrefix   = "crls/"
+	issuersConfigPath = "config/issuers"
+
+	// defaultIssuerRef refers to the default issuer of the mount
+	defaultIssuerRef = "default"
+)
+
+// issuerNameRegex matches the names that can be used in paths to refer to
+// an issuer
+var issuerNameRegex = regexp.MustCompile(`^\w(([\w-.]+)?\w)?$`)
+
+type issuerEntry struct {
+	ID     string               `json:"id"`
+	Name   string               `json:"name"`
+	Bundle *certutil.CertBundle `json:"bundle"`
+
+	// URLs replace those of config/urls in the certificates the issuer
+	// signs, for each kind of URL set
+	URLs *urlEntries `json:"urls,omitempty"`
+}
+
+type issuersConfigEntry struct {
+	Default string `json:"default"`
+}
+
+func isDefaultIssuerRef(ref string) bool {
+	return ref == "" || ref == defaultIssuerRef
+}
+
+func validateIssuerName(name string) error {
+	switch {
+	case name == "":
+		return nil
+	case name == defaultIssuerRef:
+		return fmt.Errorf("%q is reserved to refer to the default issuer", defaultIssuerRef)
+	case !issuerNameRegex.MatchString(name):
+		return fmt.Errorf("issuer name %q may only contain alphanumeric characters, dashes, underscores and periods", name)
+	}
+	return nil
+}
+
+func getIssuersConfig(ctx context.Context, s logical.Storage) (*issuersConfigEntry, error) {
+	entry, err := s.Get(ctx, issuersConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var config issuersConfigEntry
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func fetchIssuer(ctx context.Context, s logical.Storage, id string) (*issuerEntry, error) {
+	entry, err := s.Get(ctx, issuerPrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var issuer issuerEntry
+	if err := entry.DecodeJSON(&issuer); err != nil {
+		return nil, err
+	}
+	return &issuer, nil
+}
+
+func storeIssuer(ctx context.Context, s logical.Storage, issuer *issuerEntry) error {
+	entry, err := logical.StorageEntryJSON(issuerPrefix+issuer.ID, issuer)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// listIssuers returns the issuers of the mount, which a mount predating
+// multiple issuers has none of until one is added
+func listIssuers(ctx context.Context, s logical.Storage) ([]*issuerEntry, error) {
+	ids, err := s.List(ctx, issuerPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	issuers := make([]*issuerEntry, 0, len(ids))
+	for _, id := range ids {
+		issuer, err := fetchIssuer(ctx, s, id)
+		if err != nil {
+			return nil, err
+		}
+		if issuer != nil {
+			issuers = append(issuers, issuer)
+		}
+	}
+	return issuers, nil
+}
+
+// resolveIssuerRef returns the issuer referred to by its ID or name, or by
+// "default", or nil if there is none
+func resolveIssuerRef(ctx context.Context, s logical.Storage, ref string) (*issuerEntry, error) {
+	if isDefaultIssuerRef(ref) {
+		config, err := getIssuersConfig(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		if config == nil || config.Default == "" {
+			return nil, nil
+		}
+		return fetchIssuer(ctx, s, config.Default)
+	}
+
+	issuer, err := fetchIssuer(ctx, s, ref)
+	if err != nil || issuer != nil {
+		return issuer, err
+	}
+
+	issuers, err := listIssuers(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	for _, issuer := range issuers {
+		if issuer.Name == ref {
+			return issuer, nil
+		}
+	}
+	return nil, nil
+}
+
+// fetchCAInfoByIssuer fetches the CA info of the issuer referred to. The
+// default issuer of a mount predating multiple issuers is its CA bundle.
+func fetchCAInfoByIssuer(ctx context.Context, req *logical.Request, ref string) (*caInfoBundle, error) {
+	issuer, err := resolveIssuerRef(ctx, req.Storage, ref)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch issuer %q: %v", ref, err)}
+	}
+	if issuer != nil {
+		return caInfoFromIssuer(ctx, req, issuer)
+	}
+	if !isDefaultIssuerRef(ref) {
+		return nil, errutil.UserError{Err: fmt.Sprintf("unknown issuer %q", ref)}
+	}
+
+	bundleEntry, err := req.Storage.Get(ctx, "config/ca_bundle")
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch local CA certificate/key: %v", err)}
+	}
+	if bundleEntry == nil {
+		return nil, errutil.UserError{Err: "backend must be configured with a CA certificate/key"}
+	}
+
+	var bundle certutil.CertBundle
+	if err := bundleEntry.DecodeJSON(&bundle); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode local CA certificate/key: %v", err)}
+	}
+	return caInfoFromBundle(ctx, req, &bundle)
+}
+
+// fetchAllCAInfo fetches the CA info of every issuer of the mount
+func fetchAllCAInfo(ctx context.Context, req *logical.Request) ([]*caInfoBundle, error) {
+	issuers, err := listIssuers(ctx, req.Storage)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to list issuers: %v", err)}
+	}
+	if len(issuers) == 0 {
+		caInfo, err := fetchCAInfo(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return []*caInfoBundle{caInfo}, nil
+	}
+
+	caInfos := make([]*caInfoBundle, 0, len(issuers))
+	for _, issuer := range issuers {
+		caInfo, err := caInfoFromIssuer(ctx, req, issuer)
+		if err != nil {
+			return nil, err
+		}
+		caInfos = append(caInfos, caInfo)
+	}
+	return caInfos, nil
+}
+
+// deleteIssuer deletes the issuer and its CRLs
+func deleteIssuer(ctx context.Context, s logical.Storage, id string) error {
+	if err := s.Delete(ctx, issuerPrefix+id); err != nil {
+		return err
+	}
+	if err := s.Delete(ctx, issuerCRLPrefix+id); err != nil {
+		return err
+	}
+	return s.Delete(ctx, issuerDeltaCRLPrefix+id)
+}
+
+// issuedBy returns whether the certificate was signed by the key of the
+// issuer certificate
+func issuedBy(cert, issuer *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	return cert.CheckSignatureFrom(issuer) == nil
+}
+
+// migrateLegacyCABundle adds the CA bundle of a mount predating multiple
+// issuers as its default issuer, the first time the issuers are modified
+func migrateLegacyCABundle(ctx context.Context, s logical.Storage) (*issuersConfigEntry, error) {
+	config, err := getIssuersConfig(ctx, s)
+	if err != nil || config != nil {
+		return config, err
+	}
+	config = &issuersConfigEntry{}
+
+	entry, err := s.Get(ctx, "config/ca_bundle")
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		var bundle certutil.CertBundle
+		if err := entry.DecodeJSON(&bundle); err != nil {
+			return nil, err
+		}
+
+		// A bundle without a certificate is the key of an intermediate CA
+		// waiting for its certificate to be signed
+		if bundle.Certificate != "" {
+			id, err := uuid.GenerateUUID()
+			if err != nil {
+				return nil, err
+			}
+			if err := storeIssuer(ctx, s, &issuerEntry{ID: id, Bundle: &bundle}); err != nil {
+				return nil, err
+			}
+			config.Default = id
+		}
+	}
+
+	configEntry, err := logical.StorageEntryJSON(issuersConfigPath, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Put(ctx, configEntry); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// importIssuer adds the CA bundle as an issuer of the mount, or returns the
+// existing issuer of its certificate. The issuer is made the default if
+// requested or if the mount has no default issuer yet.
+func (b *backend) importIssuer(ctx context.Context, s logical.Storage, bundle *certutil.CertBundle, name string, makeDefault bool) (*issuerEntry, error) {
+	if err := validateIssuerName(name); err != nil {
+		return nil, errutil.UserError{Err: err.Error()}
+	}
+
+	b.issuersLock.Lock()
+	defer b.issuersLock.Unlock()
+
+	config, err := migrateLegacyCABundle(ctx, s)
+	if err != nil {
+		return nil, errwrap.Wrapf("error migrating the CA bundle to an issuer: {{err}}", err)
+	}
+
+	issuers, err := listIssuers(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var issuer *issuerEntry
+	for _, existing := range issuers {
+		switch {
+		case existing.Bundle.Certificate == bundle.Certificate:
+			issuer = existing
+		case name != "" && existing.Name == name:
+			return nil, errutil.UserError{Err: fmt.Sprintf("issuer name %q is already in use", name)}
+		}
+	}
+
+	if issuer == nil {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		issuer = &issuerEntry{
+			ID:     id,
+			Name:   name,
+			Bundle: bundle,
+		}
+		if err := storeIssuer(ctx, s, issuer); err != nil {
+			return nil, err
+		}
+	}
+
+	if makeDefault || config.Default == "" {
+		if err := setDefaultIssuer(ctx, s, config, issuer); err != nil {
+			return nil, err
+		}
+	}
+
+	return issuer, nil
+}
+
+// setDefaultIssuer makes the issuer the default of the mount, storing its
+// bundle where mounts predating multiple issuers have their CA
+func setDefaultIssuer(ctx context.Context, s logical.Storage, config *issuersConfigEntry, issuer *issuerEntry) error {
+	parsedBundle, err := issuer.Bundle.ToParsedCertBundle()
+	if err != nil {
+		return err
+	}
+
+	config.Default = issuer.ID
+	entry, err := logical.StorageEntryJSON(issuersConfigPath, config)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	entry, err = logical.StorageEntryJSON("config/ca_bundle", issuer.Bundle)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	return s.Put(ctx, &logical.StorageEntry{
+		Key:   "ca",
+		Value: parsedBundle.CertificateBytes,
+	})
+}
@@ -0,0 +1,1196 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func pathACMEDirectory(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/directory",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.acmeHandler(b.acmeDirectory, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMENewNonce(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/new-nonce",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.acmeHandler(b.acmeNewNonce, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMENewAccount(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/new-account",
+		Fields:  acmeFields(map[string]*framework.FieldSchema{}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeNewAccount, true),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMEAccount(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/account/" + framework.GenericNameRegex("account_id"),
+		Fields: acmeFields(map[string]*framework.FieldSchema{
+			"account_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The ID of the account.",
+			},
+		}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeAccountUpdate, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMEAccountOrders(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/account/" + framework.GenericNameRegex("account_id") + "/orders",
+		Fields: acmeFields(map[string]*framework.FieldSchema{
+			"account_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The ID of the account.",
+			},
+		}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeAccountOrders, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMENewOrder(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/new-order",
+		Fields:  acmeFields(map[string]*framework.FieldSchema{}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeNewOrder, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMEOrder(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/order/" + framework.GenericNameRegex("order_id"),
+		Fields: acmeFields(map[string]*framework.FieldSchema{
+			"order_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The ID of the order.",
+			},
+		}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeOrderRead, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMEOrderFinalize(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/order/" + framework.GenericNameRegex("order_id") + "/finalize",
+		Fields: acmeFields(map[string]*framework.FieldSchema{
+			"order_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The ID of the order.",
+			},
+		}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeOrderFinalize, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMEAuthorization(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/authorization/" + framework.GenericNameRegex("authorization_id"),
+		Fields: acmeFields(map[string]*framework.FieldSchema{
+			"authorization_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The ID of the authorization.",
+			},
+		}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeAuthorizationUpdate, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMEChallenge(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/challenge/" + framework.GenericNameRegex("authorization_id") + "/" + framework.GenericNameRegex("challenge_type"),
+		Fields: acmeFields(map[string]*framework.FieldSchema{
+			"authorization_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The ID of the authorization.",
+			},
+			"challenge_type": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The type of the challenge.",
+			},
+		}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeChallengeRespond, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMECert(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/cert/" + framework.GenericNameRegex("order_id"),
+		Fields: acmeFields(map[string]*framework.FieldSchema{
+			"order_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The ID of the order.",
+			},
+		}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeCertRead, false),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathACMERevokeCert(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/revoke-cert",
+		Fields:  acmeFields(map[string]*framework.FieldSchema{}),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(b.acmeRevokeCert, true),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func (b *backend) acmeDirectory(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	return &acmeResult{
+		status: http.StatusOK,
+		body: map[string]interface{}{
+			"newNonce":   acmeURL(r.config, "new-nonce"),
+			"newAccount": acmeURL(r.config, "new-account"),
+			"newOrder":   acmeURL(r.config, "new-order"),
+			"revokeCert": acmeURL(r.config, "revoke-cert"),
+			"meta": map[string]interface{}{
+				"externalAccountRequired": r.config.EABRequired,
+			},
+		},
+	}, nil
+}
+
+func (b *backend) acmeNewNonce(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	return &acmeResult{
+		status: http.StatusNoContent,
+	}, nil
+}
+
+func (b *backend) acmeNewAccount(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	var payload struct {
+		Contact                []string        `json:"contact"`
+		OnlyReturnExisting     bool            `json:"onlyReturnExisting"`
+		ExternalAccountBinding json.RawMessage `json:"externalAccountBinding"`
+	}
+	if err := acmeDecodePayload(r, &payload); err != nil {
+		return nil, err
+	}
+	if r.jwk == nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "new accounts must be signed with the key in jwk")
+	}
+
+	thumbprint, err := acmeThumbprint(r.jwk)
+	if err != nil {
+		return nil, err
+	}
+
+	b.acmeAccountLock.Lock()
+	defer b.acmeAccountLock.Unlock()
+
+	// Accounts are identified by their key, so creating an account with the
+	// key of an existing one returns it
+	var existing struct {
+		ID string `json:"id"`
+	}
+	ok, err := acmeGet(ctx, req.Storage, "acme/account-keys/"+thumbprint, &existing)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		account, err := b.acmeAccount(ctx, req.Storage, existing.ID)
+		if err != nil {
+			return nil, err
+		}
+		if account != nil {
+			return &acmeResult{
+				status:   http.StatusOK,
+				body:     acmeAccountObject(r.config, account),
+				location: acmeURL(r.config, "account/"+account.ID),
+			}, nil
+		}
+	}
+
+	if payload.OnlyReturnExisting {
+		return nil, newACMEProblem(http.StatusBadRequest, "accountDoesNotExist", "no account exists with the key")
+	}
+
+	if err := acmeValidateContact(payload.Contact); err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	account := &acmeAccount{
+		ID:        id,
+		Key:       r.jwk,
+		Status:    acmeStatusValid,
+		Contact:   payload.Contact,
+		CreatedAt: time.Now(),
+	}
+
+	switch {
+	case len(payload.ExternalAccountBinding) > 0:
+		eab, err := b.acmeVerifyEAB(ctx, req.Storage, r, thumbprint, payload.ExternalAccountBinding)
+		if err != nil {
+			return nil, err
+		}
+		account.Role = eab.Role
+		account.EABKeyID = eab.KeyID
+
+	case r.config.EABRequired:
+		return nil, newACMEProblem(http.StatusBadRequest, "externalAccountRequired", "an external account binding is required")
+	}
+
+	if err := acmePut(ctx, req.Storage, "acme/accounts/"+account.ID, account); err != nil {
+		return nil, err
+	}
+	if err := acmePut(ctx, req.Storage, "acme/account-keys/"+thumbprint, map[string]string{"id": account.ID}); err != nil {
+		return nil, err
+	}
+
+	// External account binding keys can only be used once
+	if account.EABKeyID != "" {
+		if err := req.Storage.Delete(ctx, "acme/eab/"+account.EABKeyID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &acmeResult{
+		status:   http.StatusCreated,
+		body:     acmeAccountObject(r.config, account),
+		location: acmeURL(r.config, "account/"+account.ID),
+	}, nil
+}
+
+// acmeVerifyEAB checks that the external account binding of a new account
+// signs its key with an external account binding key
+func (b *backend) acmeVerifyEAB(ctx context.Context, s logical.Storage, r *acmeRequest, thumbprint string, raw json.RawMessage) (*acmeEABKey, error) {
+	jws, err := jose.ParseSigned(string(raw))
+	if err != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "error parsing the external account binding: %s", err)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "the external account binding must have exactly one signature")
+	}
+	header := jws.Signatures[0].Protected
+
+	switch header.Algorithm {
+	case string(jose.HS256), string(jose.HS384), string(jose.HS512):
+	default:
+		return nil, newACMEProblem(http.StatusBadRequest, "badSignatureAlgorithm", "unsupported external account binding algorithm %q", header.Algorithm)
+	}
+	if header.Nonce != "" {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "the external account binding must not have a nonce")
+	}
+	if url, _ := header.ExtraHeaders["url"].(string); url != r.url {
+		return nil, newACMEProblem(http.StatusUnauthorized, "unauthorized", "the url of the external account binding does not match the request")
+	}
+
+	var eab acmeEABKey
+	ok, err := acmeGet(ctx, s, "acme/eab/"+header.KeyID, &eab)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, newACMEProblem(http.StatusUnauthorized, "unauthorized", "unknown external account binding key %q", header.KeyID)
+	}
+
+	payload, err := jws.Verify(eab.Key)
+	if err != nil {
+		return nil, newACMEProblem(http.StatusUnauthorized, "unauthorized", "invalid external account binding signature")
+	}
+	var key jose.JSONWebKey
+	if err := json.Unmarshal(payload, &key); err != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "error parsing the key of the external account binding: %s", err)
+	}
+	if eabThumbprint, err := acmeThumbprint(&key); err != nil || eabThumbprint != thumbprint {
+		return nil, newACMEProblem(http.StatusUnauthorized, "unauthorized", "the external account binding does not sign the key of the account")
+	}
+
+	return &eab, nil
+}
+
+func (b *backend) acmeAccountUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	if r.account.ID != d.Get("account_id").(string) {
+		return nil, newACMEProblem(http.StatusUnauthorized, "unauthorized", "the request is not signed by the account")
+	}
+
+	var payload struct {
+		Contact *[]string `json:"contact"`
+		Status  string    `json:"status"`
+	}
+	if err := acmeDecodePayload(r, &payload); err != nil {
+		return nil, err
+	}
+
+	b.acmeAccountLock.Lock()
+	defer b.acmeAccountLock.Unlock()
+
+	account := r.account
+	changed := false
+	switch payload.Status {
+	case "":
+	case acmeStatusDeactivated:
+		account.Status = acmeStatusDeactivated
+		changed = true
+	default:
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "an account can only be deactivated")
+	}
+	if payload.Contact != nil {
+		if err := acmeValidateContact(*payload.Contact); err != nil {
+			return nil, err
+		}
+		account.Contact = *payload.Contact
+		changed = true
+	}
+
+	if changed {
+		if err := acmePut(ctx, req.Storage, "acme/accounts/"+account.ID, account); err != nil {
+			return nil, err
+		}
+	}
+
+	return &acmeResult{
+		status: http.StatusOK,
+		body:   acmeAccountObject(r.config, account),
+	}, nil
+}
+
+func (b *backend) acmeAccountOrders(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	if r.account.ID != d.Get("account_id").(string) {
+		return nil, newACMEProblem(http.StatusUnauthorized, "unauthorized", "the request is not signed by the account")
+	}
+
+	ids, err := req.Storage.List(ctx, "acme/account-orders/"+r.account.ID+"/")
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]string, 0, len(ids))
+	for _, id := range ids {
+		orders = append(orders, acmeURL(r.config, "order/"+id))
+	}
+
+	return &acmeResult{
+		status: http.StatusOK,
+		body: map[string]interface{}{
+			"orders": orders,
+		},
+	}, nil
+}
+
+func (b *backend) acmeNewOrder(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	var payload struct {
+		Identifiers []*acmeIdentifier `json:"identifiers"`
+		NotBefore   string            `json:"notBefore"`
+		NotAfter    string            `json:"notAfter"`
+	}
+	if err := acmeDecodePayload(r, &payload); err != nil {
+		return nil, err
+	}
+	if payload.NotBefore != "" || payload.NotAfter != "" {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "notBefore and notAfter are not supported, the validity of certificates is set by the role")
+	}
+
+	identifiers, err := acmeNormalizeIdentifiers(payload.Identifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	roleName := r.account.Role
+	if roleName == "" {
+		roleName = r.config.DefaultRole
+	}
+	if roleName == "" {
+		return nil, newACMEProblem(http.StatusUnauthorized, "unauthorized", "the account is not bound to a role")
+	}
+	role, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, fmt.Errorf("unknown role %q", roleName)
+	}
+
+	// Identifiers the role can't issue certificates for are rejected before
+	// any challenge is solved for them
+	var dnsNames []string
+	for _, identifier := range identifiers {
+		switch identifier.Type {
+		case acmeIdentifierDNS:
+			dnsNames = append(dnsNames, identifier.Value)
+		case acmeIdentifierIP:
+			if !role.AllowIPSANs {
+				return nil, newACMEProblem(http.StatusBadRequest, "rejectedIdentifier", "IP addresses are not allowed")
+			}
+		}
+	}
+	if badName := validateNames(&dataBundle{role: role, req: req}, dnsNames); badName != "" {
+		return nil, newACMEProblem(http.StatusBadRequest, "rejectedIdentifier", "name %q is not allowed", badName)
+	}
+
+	orderID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	order := &acmeOrder{
+		ID:          orderID,
+		AccountID:   r.account.ID,
+		Status:      acmeStatusPending,
+		Expires:     time.Now().Add(acmeOrderLifetime).UTC().Truncate(time.Second),
+		Identifiers: identifiers,
+		Role:        roleName,
+	}
+
+	// The role of an account bound with an external account binding key may
+	// be trusted to authorize its identifiers, as it is for tokens
+	preauthorized := r.account.Role != "" && r.config.EABSkipChallenges
+
+	for _, identifier := range identifiers {
+		authz, err := acmeNewAuthorization(r.config, order, identifier, preauthorized)
+		if err != nil {
+			return nil, err
+		}
+		if err := acmePut(ctx, req.Storage, "acme/authorizations/"+authz.ID, authz); err != nil {
+			return nil, err
+		}
+		order.AuthorizationIDs = append(order.AuthorizationIDs, authz.ID)
+	}
+
+	if err := acmePut(ctx, req.Storage, "acme/orders/"+order.ID, order); err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, &logical.StorageEntry{Key: "acme/account-orders/" + r.account.ID + "/" + order.ID}); err != nil {
+		return nil, err
+	}
+
+	status, err := b.acmeOrderStatus(ctx, req.Storage, order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &acmeResult{
+		status:   http.StatusCreated,
+		body:     acmeOrderObject(r.config, order, status),
+		location: acmeURL(r.config, "order/"+order.ID),
+	}, nil
+}
+
+// acmeNewAuthorization returns a pending authorization for an identifier of
+// an order, with the challenges that can prove control of it
+func acmeNewAuthorization(config *acmeConfig, order *acmeOrder, identifier *acmeIdentifier, preauthorized bool) (*acmeAuthorization, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	authz := &acmeAuthorization{
+		ID:        id,
+		AccountID: order.AccountID,
+		Identifier: &acmeIdentifier{
+			Type:  identifier.Type,
+			Value: strings.TrimPrefix(identifier.Value, "*."),
+		},
+		Status:     acmeStatusPending,
+		Expires:    order.Expires,
+		Wildcard:   strings.HasPrefix(identifier.Value, "*."),
+		Challenges: []*acmeChallenge{},
+	}
+
+	if preauthorized {
+		authz.Status = acmeStatusValid
+		return authz, nil
+	}
+
+	for _, typ := range config.ChallengeTypes {
+		// Wildcards can only be proven with DNS, and IP addresses without
+		// (RFC 8738)
+		if typ == acmeChallengeHTTP01 && authz.Wildcard || typ == acmeChallengeDNS01 && identifier.Type == acmeIdentifierIP {
+			continue
+		}
+		token, err := acmeToken()
+		if err != nil {
+			return nil, err
+		}
+		authz.Challenges = append(authz.Challenges, &acmeChallenge{
+			Type:   typ,
+			Token:  token,
+			Status: acmeStatusPending,
+		})
+	}
+	if len(authz.Challenges) == 0 {
+		return nil, newACMEProblem(http.StatusBadRequest, "rejectedIdentifier", "no challenge can authorize %q", identifier.Value)
+	}
+
+	return authz, nil
+}
+
+func (b *backend) acmeOrderRead(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	order, err := b.acmeAccountOrder(ctx, req.Storage, r, d.Get("order_id").(string))
+	if err != nil {
+		return nil, err
+	}
+	status, err := b.acmeOrderStatus(ctx, req.Storage, order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &acmeResult{
+		status: http.StatusOK,
+		body:   acmeOrderObject(r.config, order, status),
+	}, nil
+}
+
+func (b *backend) acmeOrderFinalize(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	orderID := d.Get("order_id").(string)
+	lock := locksutil.LockForKey(b.acmeLocks, orderID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	order, err := b.acmeAccountOrder(ctx, req.Storage, r, orderID)
+	if err != nil {
+		return nil, err
+	}
+	status, err := b.acmeOrderStatus(ctx, req.Storage, order)
+	if err != nil {
+		return nil, err
+	}
+	if status != acmeStatusReady {
+		return nil, newACMEProblem(http.StatusForbidden, "orderNotReady", "the order is %s", status)
+	}
+
+	var payload struct {
+		CSR string `json:"csr"`
+	}
+	if err := acmeDecodePayload(r, &payload); err != nil {
+		return nil, err
+	}
+	der, err := base64.RawURLEncoding.DecodeString(payload.CSR)
+	if err != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "badCSR", "error decoding the CSR: %s", err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "badCSR", "error parsing the CSR: %s", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "badCSR", "invalid CSR signature: %s", err)
+	}
+	if err := acmeCheckCSRNames(csr, order.Identifiers); err != nil {
+		return nil, err
+	}
+
+	role, err := b.getRole(ctx, req.Storage, order.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, fmt.Errorf("unknown role %q", order.Role)
+	}
+
+	var dnsNames, ipAddresses []string
+	for _, identifier := range order.Identifiers {
+		switch identifier.Type {
+		case acmeIdentifierDNS:
+			dnsNames = append(dnsNames, identifier.Value)
+		case acmeIdentifierIP:
+			ipAddresses = append(ipAddresses, identifier.Value)
+		}
+	}
+	commonName := strings.ToLower(csr.Subject.CommonName)
+	if commonName == "" && len(dnsNames) > 0 {
+		commonName = dnsNames[0]
+	}
+
+	// The common name is added to the DNS names of the certificate
+	var altNames []string
+	for _, name := range dnsNames {
+		if name != commonName {
+			altNames = append(altNames, name)
+		}
+	}
+
+	signingBundle, err := fetchCAInfoByIssuer(ctx, req, role.IssuerRef)
+	if err != nil {
+		return nil, err
+	}
+
+	// The certificate is signed as by the sign endpoint of the role, with the
+	// names of the order rather than those of the CSR
+	orderRole := *role
+	orderRole.UseCSRCommonName = false
+	orderRole.UseCSRSANs = false
+	input := &dataBundle{
+		req: req,
+		apiData: &framework.FieldData{
+			Raw: map[string]interface{}{
+				"csr":         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})),
+				"common_name": commonName,
+				"alt_names":   strings.Join(altNames, ","),
+				"ip_sans":     strings.Join(ipAddresses, ","),
+			},
+			Schema: pathSign(b).Fields,
+		},
+		role:          &orderRole,
+		signingBundle: signingBundle,
+	}
+	parsedBundle, err := signCert(b, input, false, false)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return nil, newACMEProblem(http.StatusBadRequest, "badCSR", "%s", err)
+		}
+		return nil, err
+	}
+
+	cb, err := parsedBundle.ToCertBundle()
+	if err != nil {
+		return nil, err
+	}
+	serial := normalizeSerial(cb.SerialNumber)
+
+	if !role.NoStore {
+		err = req.Storage.Put(ctx, &logical.StorageEntry{
+			Key:   "certs/" + serial,
+			Value: parsedBundle.CertificateBytes,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = acmePut(ctx, req.Storage, "acme/certs/"+serial, map[string]string{
+		"account_id": order.AccountID,
+		"order_id":   order.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []string{cb.Certificate}
+	chain = append(chain, cb.CAChain...)
+
+	order.Status = acmeStatusValid
+	order.CertificateSerial = cb.SerialNumber
+	order.Certificate = strings.Join(chain, "\n") + "\n"
+	if err := acmePut(ctx, req.Storage, "acme/orders/"+order.ID, order); err != nil {
+		return nil, err
+	}
+
+	return &acmeResult{
+		status:   http.StatusOK,
+		body:     acmeOrderObject(r.config, order, order.Status),
+		location: acmeURL(r.config, "order/"+order.ID),
+	}, nil
+}
+
+func (b *backend) acmeAuthorizationUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	authzID := d.Get("authorization_id").(string)
+	lock := locksutil.LockForKey(b.acmeLocks, authzID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	authz, err := b.acmeAccountAuthorization(ctx, req.Storage, r, authzID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		Status string `json:"status"`
+	}
+	if err := acmeDecodePayload(r, &payload); err != nil {
+		return nil, err
+	}
+	switch payload.Status {
+	case "":
+	case acmeStatusDeactivated:
+		authz.Status = acmeStatusDeactivated
+		if err := acmePut(ctx, req.Storage, "acme/authorizations/"+authz.ID, authz); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "an authorization can only be deactivated")
+	}
+
+	return &acmeResult{
+		status: http.StatusOK,
+		body:   acmeAuthorizationObject(r.config, authz),
+	}, nil
+}
+
+func (b *backend) acmeChallengeRespond(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	authzID := d.Get("authorization_id").(string)
+	lock := locksutil.LockForKey(b.acmeLocks, authzID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	authz, err := b.acmeAccountAuthorization(ctx, req.Storage, r, authzID)
+	if err != nil {
+		return nil, err
+	}
+
+	var challenge *acmeChallenge
+	for _, c := range authz.Challenges {
+		if c.Type == d.Get("challenge_type").(string) {
+			challenge = c
+		}
+	}
+	if challenge == nil {
+		return nil, newACMEProblem(http.StatusNotFound, "malformed", "unknown challenge")
+	}
+
+	// Challenges are validated as soon as the client is ready, and only once
+	if acmeAuthorizationStatus(authz) == acmeStatusPending && challenge.Status == acmeStatusPending {
+		thumbprint, err := acmeThumbprint(r.account.Key)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.acmeValidate(ctx, r.config, authz, challenge, thumbprint); err != nil {
+			b.Logger().Debug("acme challenge failed", "type", challenge.Type, "identifier", authz.Identifier.Value, "error", err)
+			challenge.Status = acmeStatusInvalid
+			challenge.Error = newACMEProblem(http.StatusForbidden, "incorrectResponse", "%s", err)
+			authz.Status = acmeStatusInvalid
+		} else {
+			now := time.Now().UTC().Truncate(time.Second)
+			challenge.Status = acmeStatusValid
+			challenge.Validated = &now
+			authz.Status = acmeStatusValid
+		}
+		if err := acmePut(ctx, req.Storage, "acme/authorizations/"+authz.ID, authz); err != nil {
+			return nil, err
+		}
+	}
+
+	return &acmeResult{
+		status: http.StatusOK,
+		body:   acmeChallengeObject(r.config, authz, challenge),
+		links:  []string{fmt.Sprintf("<%s>;rel=\"up\"", acmeURL(r.config, "authorization/"+authz.ID))},
+	}, nil
+}
+
+func (b *backend) acmeCertRead(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	order, err := b.acmeAccountOrder(ctx, req.Storage, r, d.Get("order_id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != acmeStatusValid {
+		return nil, newACMEProblem(http.StatusNotFound, "malformed", "the order has no certificate")
+	}
+
+	return &acmeResult{
+		status:      http.StatusOK,
+		raw:         []byte(order.Certificate),
+		contentType: "application/pem-certificate-chain",
+	}, nil
+}
+
+func (b *backend) acmeRevokeCert(ctx context.Context, req *logical.Request, d *framework.FieldData, r *acmeRequest) (*acmeResult, error) {
+	var payload struct {
+		Certificate string `json:"certificate"`
+	}
+	if err := acmeDecodePayload(r, &payload); err != nil {
+		return nil, err
+	}
+	der, err := base64.RawURLEncoding.DecodeString(payload.Certificate)
+	if err != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "error decoding the certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "error parsing the certificate: %s", err)
+	}
+
+	caInfos, err := fetchAllCAInfo(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	issued := false
+	for _, caInfo := range caInfos {
+		if bytes.Equal(cert.Raw, caInfo.Certificate.Raw) {
+			issued = false
+			break
+		}
+		if cert.CheckSignatureFrom(caInfo.Certificate) == nil {
+			issued = true
+		}
+	}
+	if !issued {
+		return nil, newACMEProblem(http.StatusForbidden, "unauthorized", "the certificate was not issued by this CA")
+	}
+
+	serial := certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":")
+
+	// Certificates can be revoked by the account that ordered them, or with
+	// their own key
+	if r.account != nil {
+		var issued struct {
+			AccountID string `json:"account_id"`
+		}
+		ok, err := acmeGet(ctx, req.Storage, "acme/certs/"+normalizeSerial(serial), &issued)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || issued.AccountID != r.account.ID {
+			return nil, newACMEProblem(http.StatusForbidden, "unauthorized", "the certificate was not ordered by the account")
+		}
+	} else {
+		requestKey, err := x509.MarshalPKIXPublicKey(r.jwk.Key)
+		if err != nil {
+			return nil, newACMEProblem(http.StatusBadRequest, "malformed", "unsupported key: %s", err)
+		}
+		certKey, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
+		if err != nil || !bytes.Equal(requestKey, certKey) {
+			return nil, newACMEProblem(http.StatusForbidden, "unauthorized", "the request is not signed by the key of the certificate")
+		}
+	}
+
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	revoked, err := fetchCertBySerial(ctx, req, "revoked/", serial)
+	if err != nil {
+		return nil, err
+	}
+	if revoked != nil {
+		return nil, newACMEProblem(http.StatusBadRequest, "alreadyRevoked", "the certificate is already revoked")
+	}
+
+	resp, err := revokeCert(ctx, b, req, serial, der, false)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil && resp.IsError() {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "%s", resp.Error())
+	}
+
+	return &acmeResult{
+		status: http.StatusOK,
+	}, nil
+}
+
+// acmeAccountOrder returns an order of the account that signed the request
+func (b *backend) acmeAccountOrder(ctx context.Context, s logical.Storage, r *acmeRequest, id string) (*acmeOrder, error) {
+	order, err := b.acmeOrder(ctx, s, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.AccountID != r.account.ID {
+		return nil, newACMEProblem(http.StatusNotFound, "malformed", "unknown order")
+	}
+	return order, nil
+}
+
+// acmeAccountAuthorization returns an authorization of the account that
+// signed the request
+func (b *backend) acmeAccountAuthorization(ctx context.Context, s logical.Storage, r *acmeRequest, id string) (*acmeAuthorization, error) {
+	authz, err := b.acmeAuthorization(ctx, s, id)
+	if err != nil {
+		return nil, err
+	}
+	if authz == nil || authz.AccountID != r.account.ID {
+		return nil, newACMEProblem(http.StatusNotFound, "malformed", "unknown authorization")
+	}
+	return authz, nil
+}
+
+// acmeOrderStatus returns the status of an order, which is ready once all of
+// its authorizations are valid
+func (b *backend) acmeOrderStatus(ctx context.Context, s logical.Storage, order *acmeOrder) (string, error) {
+	switch {
+	case order.Status == acmeStatusValid || order.Status == acmeStatusInvalid:
+		return order.Status, nil
+	case time.Now().After(order.Expires):
+		return acmeStatusInvalid, nil
+	}
+
+	status := acmeStatusReady
+	for _, id := range order.AuthorizationIDs {
+		authz, err := b.acmeAuthorization(ctx, s, id)
+		if err != nil {
+			return "", err
+		}
+		if authz == nil {
+			return acmeStatusInvalid, nil
+		}
+		switch acmeAuthorizationStatus(authz) {
+		case acmeStatusValid:
+		case acmeStatusPending:
+			status = acmeStatusPending
+		default:
+			return acmeStatusInvalid, nil
+		}
+	}
+	return status, nil
+}
+
+func acmeAuthorizationStatus(authz *acmeAuthorization) string {
+	if (authz.Status == acmeStatusPending || authz.Status == acmeStatusValid) && time.Now().After(authz.Expires) {
+		return acmeStatusExpired
+	}
+	return authz.Status
+}
+
+func acmeDecodePayload(r *acmeRequest, out interface{}) error {
+	// POST-as-GET requests have an empty payload
+	if len(r.payload) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.payload, out); err != nil {
+		return newACMEProblem(http.StatusBadRequest, "malformed", "error parsing the payload: %s", err)
+	}
+	return nil
+}
+
+func acmeValidateContact(contact []string) error {
+	for _, c := range contact {
+		if !strings.HasPrefix(c, "mailto:") {
+			return newACMEProblem(http.StatusBadRequest, "unsupportedContact", "unsupported contact %q, only mailto: is supported", c)
+		}
+	}
+	return nil
+}
+
+// acmeNormalizeIdentifiers checks the identifiers of an order, returning
+// them in canonical form and without duplicates
+func acmeNormalizeIdentifiers(identifiers []*acmeIdentifier) ([]*acmeIdentifier, error) {
+	if len(identifiers) == 0 {
+		return nil, newACMEProblem(http.StatusBadRequest, "malformed", "at least one identifier is required")
+	}
+
+	var result []*acmeIdentifier
+	seen := make(map[string]bool)
+	for _, identifier := range identifiers {
+		if identifier == nil {
+			return nil, newACMEProblem(http.StatusBadRequest, "malformed", "invalid identifier")
+		}
+		value := identifier.Value
+		switch identifier.Type {
+		case acmeIdentifierDNS:
+			value = strings.TrimSuffix(strings.ToLower(value), ".")
+			if value == "" || strings.Contains(strings.TrimPrefix(value, "*."), "*") {
+				return nil, newACMEProblem(http.StatusBadRequest, "rejectedIdentifier", "invalid DNS name %q", identifier.Value)
+			}
+		case acmeIdentifierIP:
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, newACMEProblem(http.StatusBadRequest, "rejectedIdentifier", "invalid IP address %q", identifier.Value)
+			}
+			value = ip.String()
+		default:
+			return nil, newACMEProblem(http.StatusBadRequest, "unsupportedIdentifier", "unsupported identifier type %q", identifier.Type)
+		}
+
+		key := identifier.Type + ":" + value
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, &acmeIdentifier{
+			Type:  identifier.Type,
+			Value: value,
+		})
+	}
+
+	return result, nil
+}
+
+// acmeCheckCSRNames checks that a CSR asks for exactly the identifiers of an
+// order
+func acmeCheckCSRNames(csr *x509.CertificateRequest, identifiers []*acmeIdentifier) error {
+	if len(csr.EmailAddresses) > 0 || len(csr.URIs) > 0 {
+		return newACMEProblem(http.StatusBadRequest, "badCSR", "the CSR may only ask for DNS names and IP addresses")
+	}
+
+	var expected []string
+	for _, identifier := range identifiers {
+		expected = append(expected, identifier.Type+":"+identifier.Value)
+	}
+
+	requested := make(map[string]bool)
+	if cn := csr.Subject.CommonName; cn != "" {
+		if ip := net.ParseIP(cn); ip != nil {
+			requested[acmeIdentifierIP+":"+ip.String()] = true
+		} else {
+			requested[acmeIdentifierDNS+":"+strings.ToLower(cn)] = true
+		}
+	}
+	for _, name := range csr.DNSNames {
+		requested[acmeIdentifierDNS+":"+strings.ToLower(name)] = true
+	}
+	for _, ip := range csr.IPAddresses {
+		requested[acmeIdentifierIP+":"+ip.String()] = true
+	}
+
+	var actual []string
+	for name := range requested {
+		actual = append(actual, name)
+	}
+	sort.Strings(expected)
+	sort.Strings(actual)
+	if !strutil.EquivalentSlices(expected, actual) {
+		return newACMEProblem(http.StatusBadRequest, "badCSR", "the names of the CSR do not match the identifiers of the order")
+	}
+	return nil
+}
+
+func acmeAccountObject(config *acmeConfig, account *acmeAccount) map[string]interface{} {
+	contact := account.Contact
+	if contact == nil {
+		contact = []string{}
+	}
+	return map[string]interface{}{
+		"status":  account.Status,
+		"contact": contact,
+		"orders":  acmeURL(config, "account/"+account.ID+"/orders"),
+	}
+}
+
+func acmeOrderObject(config *acmeConfig, order *acmeOrder, status string) map[string]interface{} {
+	authorizations := make([]string, 0, len(order.AuthorizationIDs))
+	for _, id := range order.AuthorizationIDs {
+		authorizations = append(authorizations, acmeURL(config, "authorization/"+id))
+	}
+
+	result := map[string]interface{}{
+		"status":         status,
+		"expires":        order.Expires.Format(time.RFC3339),
+		"identifiers":    order.Identifiers,
+		"authorizations": authorizations,
+		"finalize":       acmeURL(config, "order/"+order.ID+"/finalize"),
+	}
+	if status == acmeStatusValid {
+		result["certificate"] = acmeURL(config, "cert/"+order.ID)
+	}
+	if order.Error != nil {
+		result["error"] = order.Error
+	}
+	return result
+}
+
+func acmeAuthorizationObject(config *acmeConfig, authz *acmeAuthorization) map[string]interface{} {
+	challenges := make([]map[string]interface{}, 0, len(authz.Challenges))
+	for _, challenge := range authz.Challenges {
+		challenges = append(challenges, acmeChallengeObject(config, authz, challenge))
+	}
+
+	result := map[string]interface{}{
+		"identifier": authz.Identifier,
+		"status":     acmeAuthorizationStatus(authz),
+		"expires":    authz.Expires.Format(time.RFC3339),
+		"challenges": challenges,
+	}
+	if authz.Wildcard {
+		result["wildcard"] = true
+	}
+	return result
+}
+
+func acmeChallengeObject(config *acmeConfig, authz *acmeAuthorization, challenge *acmeChallenge) map[string]interface{} {
+	result := map[string]interface{}{
+		"type":   challenge.Type,
+		"url":    acmeURL(config, "challenge/"+authz.ID+"/"+challenge.Type),
+		"token":  challenge.Token,
+		"status": challenge.Status,
+	}
+	if challenge.Validated != nil {
+		result["validated"] = challenge.Validated.Format(time.RFC3339)
+	}
+	if challenge.Error != nil {
+		result["error"] = challenge.Error
+	}
+	return result
+}
+
+const pathACMEHelpSyn = `
+The ACME server of the mount.
+`
+
+const pathACMEHelpDesc = `
+These endpoints implement the ACME protocol (RFC 8555), through which clients
+such as cert-manager or certbot obtain certificates for the identifiers they
+prove control of, with http-01 or dns-01 challenges. They need no Vault token,
+and must be enabled with the "config/acme" endpoint. The directory of the
+server is at "acme/directory".
+`
@@ -0,0 +1,354 @@
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// acmeConfig configures the ACME server of the mount
+type acmeConfig struct {
+	Enabled           bool     `json:"enabled"`
+	BaseURL           string   `json:"base_url"`
+	DefaultRole       string   `json:"default_role"`
+	EABRequired       bool     `json:"eab_required"`
+	EABSkipChallenges bool     `json:"eab_skip_challenges"`
+	ChallengeTypes    []string `json:"challenge_types"`
+	DNSResolver       string   `json:"dns_resolver"`
+}
+
+var acmeChallengeTypes = []string{acmeChallengeHTTP01, acmeChallengeDNS01}
+
+func pathConfigACME(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/acme",
+		Fields: map[string]*framework.FieldSchema{
+			"enabled": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `Whether the ACME server of the mount is enabled.`,
+			},
+
+			"base_url": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The URL of the mount as ACME clients reach it,
+such as "https://vault.example.com:8200/v1/pki". Required to enable ACME.`,
+			},
+
+			"default_role": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The role certificates are issued with for
+accounts not bound to a role by an external account binding.`,
+			},
+
+			"eab_required": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Whether new accounts must be bound to a role with
+an external account binding key.`,
+			},
+
+			"eab_skip_challenges": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, the identifiers the role of an account
+bound with an external account binding key allows are authorized without
+solving challenges.`,
+			},
+
+			"challenge_types": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `The challenges offered to authorize identifiers,
+among "http-01" and "dns-01". Defaults to both.`,
+			},
+
+			"dns_resolver": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The address of the DNS server dns-01 challenges
+are looked up with. Defaults to the resolver of the system.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathACMEConfigRead,
+			logical.UpdateOperation: b.pathACMEConfigWrite,
+		},
+
+		HelpSynopsis:    pathConfigACMEHelpSyn,
+		HelpDescription: pathConfigACMEHelpDesc,
+	}
+}
+
+func pathACMENewEAB(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "acme/new-eab",
+		Fields: map[string]*framework.FieldSchema{
+			"role": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The role the account created with the key is bound to.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathACMENewEAB,
+		},
+
+		HelpSynopsis:    pathACMENewEABHelpSyn,
+		HelpDescription: pathACMENewEABHelpDesc,
+	}
+}
+
+func pathListACMEEAB(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "eab/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathACMEEABList,
+		},
+
+		HelpSynopsis:    pathListACMEEABHelpSyn,
+		HelpDescription: pathListACMEEABHelpDesc,
+	}
+}
+
+func pathACMEEAB(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "eab/" + framework.GenericNameRegex("key_id"),
+		Fields: map[string]*framework.FieldSchema{
+			"key_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The ID of the external account binding key.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathACMEEABRead,
+			logical.DeleteOperation: b.pathACMEEABDelete,
+		},
+
+		HelpSynopsis:    pathACMEEABHelpSyn,
+		HelpDescription: pathACMEEABHelpDesc,
+	}
+}
+
+func (b *backend) acmeConfig(ctx context.Context, s logical.Storage) (*acmeConfig, error) {
+	var config acmeConfig
+	ok, err := acmeGet(ctx, s, "config/acme", &config)
+	if !ok || err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (b *backend) pathACMEConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.acmeConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":             config.Enabled,
+			"base_url":            config.BaseURL,
+			"default_role":        config.DefaultRole,
+			"eab_required":        config.EABRequired,
+			"eab_skip_challenges": config.EABSkipChallenges,
+			"challenge_types":     config.ChallengeTypes,
+			"dns_resolver":        config.DNSResolver,
+		},
+	}, nil
+}
+
+func (b *backend) pathACMEConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.acmeConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &acmeConfig{
+			ChallengeTypes: acmeChallengeTypes,
+		}
+	}
+
+	if v, ok := data.GetOk("enabled"); ok {
+		config.Enabled = v.(bool)
+	}
+	if v, ok := data.GetOk("base_url"); ok {
+		config.BaseURL = strings.TrimSuffix(v.(string), "/")
+	}
+	if v, ok := data.GetOk("default_role"); ok {
+		config.DefaultRole = v.(string)
+	}
+	if v, ok := data.GetOk("eab_required"); ok {
+		config.EABRequired = v.(bool)
+	}
+	if v, ok := data.GetOk("eab_skip_challenges"); ok {
+		config.EABSkipChallenges = v.(bool)
+	}
+	if v, ok := data.GetOk("challenge_types"); ok {
+		config.ChallengeTypes = v.([]string)
+	}
+	if v, ok := data.GetOk("dns_resolver"); ok {
+		config.DNSResolver = v.(string)
+	}
+
+	if config.BaseURL != "" {
+		u, err := url.Parse(config.BaseURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return logical.ErrorResponse(fmt.Sprintf("invalid base_url %q", config.BaseURL)), nil
+		}
+	}
+	if config.Enabled && config.BaseURL == "" {
+		return logical.ErrorResponse("base_url is required to enable ACME"), nil
+	}
+
+	if config.DefaultRole != "" {
+		role, err := b.getRole(ctx, req.Storage, config.DefaultRole)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", config.DefaultRole)), nil
+		}
+	}
+	if config.Enabled && config.DefaultRole == "" && !config.EABRequired {
+		return logical.ErrorResponse("default_role is required unless eab_required is set"), nil
+	}
+
+	for _, typ := range config.ChallengeTypes {
+		if !strutil.StrListContains(acmeChallengeTypes, typ) {
+			return logical.ErrorResponse(fmt.Sprintf("unsupported challenge type %q", typ)), nil
+		}
+	}
+
+	if config.DNSResolver != "" {
+		if _, _, err := net.SplitHostPort(config.DNSResolver); err != nil {
+			config.DNSResolver = net.JoinHostPort(config.DNSResolver, "53")
+		}
+	}
+
+	if err := acmePut(ctx, req.Storage, "config/acme", config); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathACMENewEAB(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role"), nil
+	}
+	role, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", roleName)), nil
+	}
+
+	keyID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	eab := &acmeEABKey{
+		KeyID:     keyID,
+		Key:       key,
+		Role:      roleName,
+		CreatedAt: time.Now(),
+	}
+	if err := acmePut(ctx, req.Storage, "acme/eab/"+keyID, eab); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"key_id":     keyID,
+			"key":        base64.RawURLEncoding.EncodeToString(key),
+			"role":       roleName,
+			"created_at": eab.CreatedAt,
+		},
+	}, nil
+}
+
+func (b *backend) pathACMEEABList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	keys, err := req.Storage.List(ctx, "acme/eab/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(keys), nil
+}
+
+func (b *backend) pathACMEEABRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var eab acmeEABKey
+	ok, err := acmeGet(ctx, req.Storage, "acme/eab/"+data.Get("key_id").(string), &eab)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"key_id":     eab.KeyID,
+			"role":       eab.Role,
+			"created_at": eab.CreatedAt,
+		},
+	}, nil
+}
+
+func (b *backend) pathACMEEABDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return nil, req.Storage.Delete(ctx, "acme/eab/"+data.Get("key_id").(string))
+}
+
+const pathConfigACMEHelpSyn = `
+Configure the ACME server of the mount.
+`
+
+const pathConfigACMEHelpDesc = `
+This endpoint enables the ACME server of the mount, through which ACME clients
+such as cert-manager or certbot obtain certificates for the identifiers they
+prove control of. Certificates are issued with the default role, or the role
+the account is bound to with an external account binding key.
+`
+
+const pathACMENewEABHelpSyn = `
+Create an external account binding key for the ACME server.
+`
+
+const pathACMENewEABHelpDesc = `
+This endpoint creates a key an ACME client binds its account to when creating
+it, so that certificates are issued to the account with the given role. A key
+can only be used to create a single account.
+`
+
+const pathListACMEEABHelpSyn = `
+List the unused external account binding keys.
+`
+
+const pathListACMEEABHelpDesc = `
+This endpoint lists the IDs of the external account binding keys that have not
+been used to create an account yet.
+`
+
+const pathACMEEABHelpSyn = `
+Read or delete an unused external account binding key.
+`
+
+const pathACMEEABHelpDesc = `
+This endpoint returns the role of an external account binding key, without the
+key itself, or deletes the key so that it can't be used.
+`
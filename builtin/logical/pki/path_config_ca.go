@@ -31,61 +31,57 @@ secret key and certificate.`,
 }
 
 func (b *backend) pathCAWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	pemBundle := data.Get("pem_bundle").(string)
+	cb, errResp, err := parseCAPEMBundle(data.Get("pem_bundle").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	// Store it as the default issuer, plus a fresh CRL
+	_, err = b.importIssuer(ctx, req.Storage, cb, "", true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = buildCRL(ctx, b, req)
+
+	return nil, err
+}
 
+// parseCAPEMBundle parses a PEM bundle of a CA certificate and its key
+func parseCAPEMBundle(pemBundle string) (*certutil.CertBundle, *logical.Response, error) {
 	if pemBundle == "" {
-		return logical.ErrorResponse("'pem_bundle' was empty"), nil
+		return nil, logical.ErrorResponse("'pem_bundle' was empty"), nil
 	}
 
 	parsedBundle, err := certutil.ParsePEMBundle(pemBundle)
 	if err != nil {
 		switch err.(type) {
 		case errutil.InternalError:
-			return nil, err
+			return nil, nil, err
 		default:
-			return logical.ErrorResponse(err.Error()), nil
+			return nil, logical.ErrorResponse(err.Error()), nil
 		}
 	}
 
 	if parsedBundle.PrivateKey == nil ||
 		parsedBundle.PrivateKeyType == certutil.UnknownPrivateKey {
-		return logical.ErrorResponse("private key not found in the PEM bundle"), nil
+		return nil, logical.ErrorResponse("private key not found in the PEM bundle"), nil
 	}
 
 	if parsedBundle.Certificate == nil {
-		return logical.ErrorResponse("no certificate found in the PEM bundle"), nil
+		return nil, logical.ErrorResponse("no certificate found in the PEM bundle"), nil
 	}
 
 	if !parsedBundle.Certificate.IsCA {
-		return logical.ErrorResponse("the given certificate is not marked for CA use and cannot be used with this backend"), nil
+		return nil, logical.ErrorResponse("the given certificate is not marked for CA use and cannot be used with this backend"), nil
 	}
 
 	cb, err := parsedBundle.ToCertBundle()
 	if err != nil {
-		return nil, errwrap.Wrapf("error converting raw values into cert bundle: {{err}}", err)
-	}
-
-	entry, err := logical.StorageEntryJSON("config/ca_bundle", cb)
-	if err != nil {
-		return nil, err
-	}
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		return nil, err
-	}
-
-	// For ease of later use, also store just the certificate at a known
-	// location, plus a fresh CRL
-	entry.Key = "ca"
-	entry.Value = parsedBundle.CertificateBytes
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		return nil, err
+		return nil, nil, errwrap.Wrapf("error converting raw values into cert bundle: {{err}}", err)
 	}
 
-	err = buildCRL(ctx, b, req)
-
-	return nil, err
+	return cb, nil, nil
 }
 
 const pathConfigCAHelpSyn = `
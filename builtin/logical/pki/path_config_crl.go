@@ -11,9 +11,15 @@ import (
 
 // CRLConfig holds basic CRL configuration information
 type crlConfig struct {
-	Expiry string `json:"expiry" mapstructure:"expiry" structs:"expiry"`
+	Expiry              string `json:"expiry" mapstructure:"expiry" structs:"expiry"`
+	EnableDelta         bool   `json:"enable_delta" mapstructure:"enable_delta" structs:"enable_delta"`
+	FullRebuildInterval string `json:"full_rebuild_interval" mapstructure:"full_rebuild_interval" structs:"full_rebuild_interval"`
 }
 
+// defaultCRLFullRebuildInterval is how often the full CRLs are rebuilt when
+// delta CRLs are enabled, unless configured otherwise
+const defaultCRLFullRebuildInterval = "24h"
+
 func pathConfigCRL(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: "config/crl",
@@ -24,6 +30,19 @@ func pathConfigCRL(b *backend) *framework.Path {
 valid; defaults to 72 hours`,
 				Default: "72h",
 			},
+			"enable_delta": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Whether to build delta CRLs, listing the
+certificates revoked since the last full CRL. When enabled, revocations
+only rebuild the delta CRLs, and the full CRLs are rebuilt every
+full_rebuild_interval; defaults to false`,
+			},
+			"full_rebuild_interval": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `How often the full CRLs are rebuilt when delta
+CRLs are enabled. Must be shorter than the expiry; defaults to 24 hours`,
+				Default: defaultCRLFullRebuildInterval,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -62,23 +81,53 @@ func (b *backend) pathCRLRead(ctx context.Context, req *logical.Request, data *f
 		return nil, nil
 	}
 
+	if config.FullRebuildInterval == "" {
+		config.FullRebuildInterval = defaultCRLFullRebuildInterval
+	}
+
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"expiry": config.Expiry,
+			"expiry":                config.Expiry,
+			"enable_delta":          config.EnableDelta,
+			"full_rebuild_interval": config.FullRebuildInterval,
 		},
 	}, nil
 }
 
 func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	expiry := d.Get("expiry").(string)
+	config, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &crlConfig{
+			Expiry: d.Get("expiry").(string),
+		}
+	}
+	if config.FullRebuildInterval == "" {
+		config.FullRebuildInterval = defaultCRLFullRebuildInterval
+	}
+
+	if expiryRaw, ok := d.GetOk("expiry"); ok {
+		config.Expiry = expiryRaw.(string)
+	}
+	if enableDeltaRaw, ok := d.GetOk("enable_delta"); ok {
+		config.EnableDelta = enableDeltaRaw.(bool)
+	}
+	if intervalRaw, ok := d.GetOk("full_rebuild_interval"); ok {
+		config.FullRebuildInterval = intervalRaw.(string)
+	}
 
-	_, err := time.ParseDuration(expiry)
+	expiry, err := time.ParseDuration(config.Expiry)
 	if err != nil {
 		return logical.ErrorResponse(fmt.Sprintf("Given expiry could not be decoded: %s", err)), nil
 	}
-
-	config := &crlConfig{
-		Expiry: expiry,
+	interval, err := time.ParseDuration(config.FullRebuildInterval)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("Given full_rebuild_interval could not be decoded: %s", err)), nil
+	}
+	if config.EnableDelta && (interval <= 0 || interval >= expiry) {
+		return logical.ErrorResponse("full_rebuild_interval must be positive and shorter than the expiry, so that the full CRLs are rebuilt before they expire"), nil
 	}
 
 	entry, err := logical.StorageEntryJSON("config/crl", config)
@@ -94,9 +143,13 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 }
 
 const pathConfigCRLHelpSyn = `
-Configure the CRL expiration.
+Configure the CRL expiration and delta CRLs.
 `
 
 const pathConfigCRLHelpDesc = `
-This endpoint allows configuration of the CRL lifetime.
+This endpoint allows configuration of the CRL lifetime, and of delta CRLs.
+With delta CRLs enabled, each issuer has a delta CRL listing the certificates
+revoked since its last full CRL, served at "crl/delta" and
+"crl/issuer/:issuer_ref/delta". Revocations then only rebuild the delta CRLs,
+which stay small, while the full CRLs are rebuilt periodically.
 `
@@ -26,6 +26,12 @@ for the issuing certificate attribute`,
 for the CRL distribution points attribute`,
 			},
 
+			"delta_crl_distribution_points": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of URLs to be used
+for the freshest CRL attribute, pointing to the delta CRLs`,
+			},
+
 			"ocsp_servers": &framework.FieldSchema{
 				Type: framework.TypeCommaStringSlice,
 				Description: `Comma-separated list of URLs to be used
@@ -110,41 +116,81 @@ func (b *backend) pathWriteURL(ctx context.Context, req *logical.Request, data *
 	}
 	if entries == nil {
 		entries = &urlEntries{
-			IssuingCertificates:   []string{},
-			CRLDistributionPoints: []string{},
-			OCSPServers:           []string{},
+			IssuingCertificates:        []string{},
+			CRLDistributionPoints:      []string{},
+			DeltaCRLDistributionPoints: []string{},
+			OCSPServers:                []string{},
 		}
 	}
 
+	if errResp := updateURLs(entries, data); errResp != nil {
+		return errResp, nil
+	}
+
+	return nil, writeURLs(ctx, req, entries)
+}
+
+// updateURLs sets the URLs given in the request, returning an error response
+// if any is invalid
+func updateURLs(entries *urlEntries, data *framework.FieldData) *logical.Response {
 	if urlsInt, ok := data.GetOk("issuing_certificates"); ok {
 		entries.IssuingCertificates = urlsInt.([]string)
 		if badURL := validateURLs(entries.IssuingCertificates); badURL != "" {
 			return logical.ErrorResponse(fmt.Sprintf(
-				"invalid URL found in issuing certificates: %s", badURL)), nil
+				"invalid URL found in issuing certificates: %s", badURL))
 		}
 	}
 	if urlsInt, ok := data.GetOk("crl_distribution_points"); ok {
 		entries.CRLDistributionPoints = urlsInt.([]string)
 		if badURL := validateURLs(entries.CRLDistributionPoints); badURL != "" {
 			return logical.ErrorResponse(fmt.Sprintf(
-				"invalid URL found in CRL distribution points: %s", badURL)), nil
+				"invalid URL found in CRL distribution points: %s", badURL))
+		}
+	}
+	if urlsInt, ok := data.GetOk("delta_crl_distribution_points"); ok {
+		entries.DeltaCRLDistributionPoints = urlsInt.([]string)
+		if badURL := validateURLs(entries.DeltaCRLDistributionPoints); badURL != "" {
+			return logical.ErrorResponse(fmt.Sprintf(
+				"invalid URL found in delta CRL distribution points: %s", badURL))
 		}
 	}
 	if urlsInt, ok := data.GetOk("ocsp_servers"); ok {
 		entries.OCSPServers = urlsInt.([]string)
 		if badURL := validateURLs(entries.OCSPServers); badURL != "" {
 			return logical.ErrorResponse(fmt.Sprintf(
-				"invalid URL found in OCSP servers: %s", badURL)), nil
+				"invalid URL found in OCSP servers: %s", badURL))
 		}
 	}
-
-	return nil, writeURLs(ctx, req, entries)
+	return nil
 }
 
 type urlEntries struct {
-	IssuingCertificates   []string `json:"issuing_certificates" structs:"issuing_certificates" mapstructure:"issuing_certificates"`
-	CRLDistributionPoints []string `json:"crl_distribution_points" structs:"crl_distribution_points" mapstructure:"crl_distribution_points"`
-	OCSPServers           []string `json:"ocsp_servers" structs:"ocsp_servers" mapstructure:"ocsp_servers"`
+	IssuingCertificates        []string `json:"issuing_certificates" structs:"issuing_certificates" mapstructure:"issuing_certificates"`
+	CRLDistributionPoints      []string `json:"crl_distribution_points" structs:"crl_distribution_points" mapstructure:"crl_distribution_points"`
+	DeltaCRLDistributionPoints []string `json:"delta_crl_distribution_points" structs:"delta_crl_distribution_points" mapstructure:"delta_crl_distribution_points"`
+	OCSPServers                []string `json:"ocsp_servers" structs:"ocsp_servers" mapstructure:"ocsp_servers"`
+}
+
+// overrideURLs returns the URLs with those set on an issuer replacing the
+// ones of the mount
+func overrideURLs(mount, issuer *urlEntries) *urlEntries {
+	urls := *mount
+	if issuer == nil {
+		return &urls
+	}
+	if len(issuer.IssuingCertificates) > 0 {
+		urls.IssuingCertificates = issuer.IssuingCertificates
+	}
+	if len(issuer.CRLDistributionPoints) > 0 {
+		urls.CRLDistributionPoints = issuer.CRLDistributionPoints
+	}
+	if len(issuer.DeltaCRLDistributionPoints) > 0 {
+		urls.DeltaCRLDistributionPoints = issuer.DeltaCRLDistributionPoints
+	}
+	if len(issuer.OCSPServers) > 0 {
+		urls.OCSPServers = issuer.OCSPServers
+	}
+	return &urls
 }
 
 const pathConfigURLsHelpSyn = `
@@ -152,11 +198,12 @@ Set the URLs for the issuing CA, CRL distribution points, and OCSP servers.
 `
 
 const pathConfigURLsHelpDesc = `
-This path allows you to set the issuing CA, CRL distribution points, and
-OCSP server URLs that will be encoded into issued certificates. If these
-values are not set, no such information will be encoded in the issued
-certificates. To delete URLs, simply re-set the appropriate value with an
-empty string.
+This path allows you to set the issuing CA, CRL distribution points, delta CRL
+distribution points, and OCSP server URLs that will be encoded into issued
+certificates. If these values are not set, no such information will be encoded
+in the issued certificates. To delete URLs, simply re-set the appropriate value
+with an empty string. Each issuer can override these URLs for the certificates
+it signs, on the "issuer/:issuer_ref" path.
 
 Multiple URLs can be specified for each type; use commas to separate them.
 `
@@ -147,10 +147,19 @@ func (b *backend) pathSignVerbatim(ctx context.Context, req *logical.Request, da
 
 	roleName := data.Get("role").(string)
 
-	// Get the role if one was specified
-	role, err := b.getRole(ctx, req.Storage, roleName)
-	if err != nil {
-		return nil, err
+	// Get the role if one was specified. Naming a role that does not exist
+	// is an error rather than a request to sign without one, so that
+	// policies granting access to sign-verbatim/<role> cannot be sidestepped.
+	var role *roleEntry
+	if roleName != "" {
+		var err error
+		role, err = b.getRole(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", roleName)), nil
+		}
 	}
 
 	entry := &roleEntry{
@@ -184,6 +193,7 @@ func (b *backend) pathSignVerbatim(ctx context.Context, req *logical.Request, da
 			*entry.GenerateLease = *role.GenerateLease
 		}
 		entry.NoStore = role.NoStore
+		entry.IssuerRef = role.IssuerRef
 	}
 
 	if entry.MaxTTL > 0 && entry.TTL > entry.MaxTTL {
@@ -201,7 +211,7 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(ctx, req)
+	signingBundle, caErr := fetchCAInfoByIssuer(ctx, req, role.IssuerRef)
 	switch caErr.(type) {
 	case errutil.UserError:
 		return nil, errutil.UserError{Err: fmt.Sprintf(
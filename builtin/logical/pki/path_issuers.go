@@ -0,0 +1,679 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathConfigIssuers(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/issuers",
+		Fields: map[string]*framework.FieldSchema{
+			"default": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The ID or name of the issuer used by the paths and
+roles not referring to one.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigIssuersRead,
+			logical.UpdateOperation: b.pathConfigIssuersWrite,
+		},
+
+		HelpSynopsis:    pathConfigIssuersHelpSyn,
+		HelpDescription: pathConfigIssuersHelpDesc,
+	}
+}
+
+func pathListIssuers(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuers/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathIssuersList,
+		},
+
+		HelpSynopsis:    pathListIssuersHelpSyn,
+		HelpDescription: pathListIssuersHelpDesc,
+	}
+}
+
+func pathGenerateIssuerRoot(b *backend) *framework.Path {
+	ret := &framework.Path{
+		Pattern: "issuers/generate/root/" + framework.GenericNameRegex("exported"),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathIssuerGenerateRoot,
+		},
+
+		HelpSynopsis:    pathGenerateIssuerRootHelpSyn,
+		HelpDescription: pathGenerateIssuerRootHelpDesc,
+	}
+
+	ret.Fields = addCACommonFields(map[string]*framework.FieldSchema{})
+	ret.Fields = addCAKeyGenerationFields(ret.Fields)
+	ret.Fields = addCAIssueFields(ret.Fields)
+	ret.Fields["issuer_name"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `An optional name of the issuer, unique within the mount.`,
+	}
+
+	return ret
+}
+
+func pathImportIssuer(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuers/import",
+		Fields: map[string]*framework.FieldSchema{
+			"pem_bundle": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `PEM-format, concatenated unencrypted
+secret key and certificate.`,
+			},
+			"issuer_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `An optional name of the issuer, unique within the mount.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathIssuerImport,
+		},
+
+		HelpSynopsis:    pathImportIssuerHelpSyn,
+		HelpDescription: pathImportIssuerHelpDesc,
+	}
+}
+
+func pathIssuer(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuer/" + framework.GenericNameRegex("issuer_ref"),
+		Fields: map[string]*framework.FieldSchema{
+			"issuer_ref": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The ID or name of the issuer, or "default".`,
+			},
+			"issuer_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The name of the issuer, unique within the mount.`,
+			},
+			"issuing_certificates": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of URLs to be used
+for the issuing certificate attribute of the certificates signed by the
+issuer, instead of those of config/urls`,
+			},
+			"crl_distribution_points": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of URLs to be used
+for the CRL distribution points attribute of the certificates signed by the
+issuer, instead of those of config/urls`,
+			},
+			"delta_crl_distribution_points": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of URLs to be used
+for the freshest CRL attribute of the certificates and CRLs signed by the
+issuer, instead of those of config/urls`,
+			},
+			"ocsp_servers": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of URLs to be used
+for the OCSP servers attribute of the certificates signed by the issuer,
+instead of those of config/urls`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathIssuerRead,
+			logical.UpdateOperation: b.pathIssuerWrite,
+			logical.DeleteOperation: b.pathIssuerDelete,
+		},
+
+		HelpSynopsis:    pathIssuerHelpSyn,
+		HelpDescription: pathIssuerHelpDesc,
+	}
+}
+
+func pathCrossSignIssuer(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuer/" + framework.GenericNameRegex("issuer_ref") + "/cross-sign",
+		Fields: map[string]*framework.FieldSchema{
+			"issuer_ref": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The ID or name of the issuer to cross-sign, or "default".`,
+			},
+			"signer_ref": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     defaultIssuerRef,
+				Description: `The ID or name of the issuer to sign with. Defaults to the default issuer of the mount.`,
+			},
+			"ttl": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The requested Time To Live for the certificate.
+Defaults to the remaining lifetime of the cross-signed issuer. The
+certificate does not outlive the signing issuer.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathIssuerCrossSign,
+		},
+
+		HelpSynopsis:    pathCrossSignIssuerHelpSyn,
+		HelpDescription: pathCrossSignIssuerHelpDesc,
+	}
+}
+
+// Returns the certificate of an issuer in raw format
+func pathFetchIssuerCA(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "ca/issuer/" + framework.GenericNameRegex("issuer_ref") + "(/pem)?",
+		Fields: map[string]*framework.FieldSchema{
+			"issuer_ref": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The ID or name of the issuer, or "default".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchIssuerRead,
+		},
+
+		HelpSynopsis:    pathFetchIssuerHelpSyn,
+		HelpDescription: pathFetchIssuerHelpDesc,
+	}
+}
+
+// Returns the CRL or delta CRL of an issuer in raw format
+func pathFetchIssuerCRL(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "crl/issuer/" + framework.GenericNameRegex("issuer_ref") + "(/delta)?(/pem)?",
+		Fields: map[string]*framework.FieldSchema{
+			"issuer_ref": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The ID or name of the issuer, or "default".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchIssuerRead,
+		},
+
+		HelpSynopsis:    pathFetchIssuerHelpSyn,
+		HelpDescription: pathFetchIssuerHelpDesc,
+	}
+}
+
+func (b *backend) pathConfigIssuersRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"default": config.Default,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigIssuersWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ref := data.Get("default").(string)
+	if isDefaultIssuerRef(ref) {
+		return logical.ErrorResponse("the ID or name of the default issuer must be provided"), nil
+	}
+
+	b.issuersLock.Lock()
+	defer b.issuersLock.Unlock()
+
+	config, err := migrateLegacyCABundle(ctx, req.Storage)
+	if err != nil {
+		return nil, errwrap.Wrapf("error migrating the CA bundle to an issuer: {{err}}", err)
+	}
+
+	issuer, err := resolveIssuerRef(ctx, req.Storage, ref)
+	if err != nil {
+		return nil, err
+	}
+	if issuer == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown issuer %q", ref)), nil
+	}
+
+	if err := setDefaultIssuer(ctx, req.Storage, config, issuer); err != nil {
+		return nil, err
+	}
+
+	// The CRL at the legacy path is the one of the default issuer
+	return nil, buildCRL(ctx, b, req)
+}
+
+func (b *backend) pathIssuersList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuers, err := listIssuers(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(issuers))
+	keyInfo := make(map[string]interface{}, len(issuers))
+	for _, issuer := range issuers {
+		keys = append(keys, issuer.ID)
+		keyInfo[issuer.ID] = map[string]interface{}{
+			"issuer_name":   issuer.Name,
+			"serial_number": issuer.Bundle.SerialNumber,
+			"is_default":    config != nil && issuer.ID == config.Default,
+		}
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *backend) pathIssuerGenerateRoot(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return b.generateRoot(ctx, req, data, data.Get("issuer_name").(string), false)
+}
+
+func (b *backend) pathIssuerImport(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	cb, errResp, err := parseCAPEMBundle(data.Get("pem_bundle").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	issuer, err := b.importIssuer(ctx, req.Storage, cb, data.Get("issuer_name").(string), false)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
+	}
+
+	if err := buildCRL(ctx, b, req); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id": issuer.ID,
+		},
+	}, nil
+}
+
+func (b *backend) pathIssuerRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuer, err := resolveIssuerRef(ctx, req.Storage, data.Get("issuer_ref").(string))
+	if err != nil {
+		return nil, err
+	}
+	if issuer == nil {
+		return nil, nil
+	}
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedBundle, err := issuer.Bundle.ToParsedCertBundle()
+	if err != nil {
+		return nil, err
+	}
+
+	caChain := issuer.Bundle.CAChain
+	if caChain == nil {
+		caChain = []string{}
+	}
+
+	urls := &urlEntries{}
+	if issuer.URLs != nil {
+		urls = issuer.URLs
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id":                     issuer.ID,
+			"issuer_name":                   issuer.Name,
+			"certificate":                   issuer.Bundle.Certificate,
+			"ca_chain":                      caChain,
+			"serial_number":                 issuer.Bundle.SerialNumber,
+			"expiration":                    parsedBundle.Certificate.NotAfter.Unix(),
+			"is_default":                    config != nil && issuer.ID == config.Default,
+			"issuing_certificates":          nonNilStrings(urls.IssuingCertificates),
+			"crl_distribution_points":       nonNilStrings(urls.CRLDistributionPoints),
+			"delta_crl_distribution_points": nonNilStrings(urls.DeltaCRLDistributionPoints),
+			"ocsp_servers":                  nonNilStrings(urls.OCSPServers),
+		},
+	}, nil
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func (b *backend) pathIssuerWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.issuersLock.Lock()
+	defer b.issuersLock.Unlock()
+
+	issuer, err := resolveIssuerRef(ctx, req.Storage, data.Get("issuer_ref").(string))
+	if err != nil {
+		return nil, err
+	}
+	if issuer == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown issuer %q", data.Get("issuer_ref").(string))), nil
+	}
+
+	if nameRaw, ok := data.GetOk("issuer_name"); ok {
+		name := nameRaw.(string)
+		if err := validateIssuerName(name); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if name != "" && name != issuer.Name {
+			existing, err := resolveIssuerRef(ctx, req.Storage, name)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil && existing.ID != issuer.ID {
+				return logical.ErrorResponse(fmt.Sprintf("issuer name %q is already in use", name)), nil
+			}
+		}
+		issuer.Name = name
+	}
+
+	if issuer.URLs == nil {
+		issuer.URLs = &urlEntries{}
+	}
+	if errResp := updateURLs(issuer.URLs, data); errResp != nil {
+		return errResp, nil
+	}
+	if err := storeIssuer(ctx, req.Storage, issuer); err != nil {
+		return nil, err
+	}
+
+	// The delta CRL distribution points are listed in the full CRL
+	return nil, buildCRL(ctx, b, req)
+}
+
+func (b *backend) pathIssuerDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.issuersLock.Lock()
+	defer b.issuersLock.Unlock()
+
+	issuer, err := resolveIssuerRef(ctx, req.Storage, data.Get("issuer_ref").(string))
+	if err != nil {
+		return nil, err
+	}
+	if issuer == nil {
+		return nil, nil
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config != nil && issuer.ID == config.Default {
+		return logical.ErrorResponse("the default issuer cannot be deleted; make another issuer the default first, or delete the root to remove all issuers"), nil
+	}
+
+	return nil, deleteIssuer(ctx, req.Storage, issuer.ID)
+}
+
+func (b *backend) pathIssuerCrossSign(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuerRef := data.Get("issuer_ref").(string)
+	signerRef := data.Get("signer_ref").(string)
+
+	target, caErr := fetchCAInfoByIssuer(ctx, req, issuerRef)
+	switch caErr.(type) {
+	case errutil.UserError:
+		return logical.ErrorResponse(caErr.Error()), nil
+	case errutil.InternalError:
+		return nil, caErr
+	}
+	signingBundle, caErr := fetchCAInfoByIssuer(ctx, req, signerRef)
+	switch caErr.(type) {
+	case errutil.UserError:
+		return logical.ErrorResponse(caErr.Error()), nil
+	case errutil.InternalError:
+		return nil, caErr
+	}
+
+	if bytes.Equal(target.Certificate.Raw, signingBundle.Certificate.Raw) {
+		return logical.ErrorResponse("an issuer cannot cross-sign itself"), nil
+	}
+
+	serialNumber, err := certutil.GenerateSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	// The cross-signed certificate keeps the subject, key and extensions of
+	// the issuer, so that it validates the certificates the issuer signs
+	template := *target.Certificate
+	template.SerialNumber = serialNumber
+	template.SignatureAlgorithm = x509.UnknownSignatureAlgorithm
+	template.AuthorityKeyId = signingBundle.Certificate.SubjectKeyId
+	template.NotBefore = time.Now().Add(-30 * time.Second)
+	if ttl := time.Duration(data.Get("ttl").(int)) * time.Second; ttl > 0 {
+		template.NotAfter = time.Now().Add(ttl)
+	}
+
+	resp := &logical.Response{}
+	if template.NotAfter.After(signingBundle.Certificate.NotAfter) {
+		template.NotAfter = signingBundle.Certificate.NotAfter
+		resp.AddWarning("The expiration time of the cross-signed certificate was capped to the one of the signing issuer.")
+	}
+
+	urls := &urlEntries{}
+	if signingBundle.URLs != nil {
+		urls = signingBundle.URLs
+	}
+	template.IssuingCertificateURL = urls.IssuingCertificates
+	template.CRLDistributionPoints = urls.CRLDistributionPoints
+	template.OCSPServer = urls.OCSPServers
+	if err := addFreshestCRL(urls, &template); err != nil {
+		return nil, err
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, signingBundle.Certificate, target.Certificate.PublicKey, signingBundle.PrivateKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("error cross-signing the issuer: {{err}}", err)
+	}
+	if len(certBytes) == 0 {
+		return nil, fmt.Errorf("nil cert was created when cross-signing the issuer")
+	}
+
+	serial := certutil.GetHexFormatted(serialNumber.Bytes(), ":")
+	err = req.Storage.Put(ctx, &logical.StorageEntry{
+		Key:   "certs/" + normalizeSerial(serial),
+		Value: certBytes,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("unable to store certificate locally: {{err}}", err)
+	}
+
+	signingCB, err := signingBundle.ToCertBundle()
+	if err != nil {
+		return nil, errwrap.Wrapf("error converting raw signing bundle to cert bundle: {{err}}", err)
+	}
+
+	pemCert := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: certBytes,
+	})
+	resp.Data = map[string]interface{}{
+		"certificate":   strings.TrimSpace(string(pemCert)),
+		"issuing_ca":    signingCB.Certificate,
+		"serial_number": serial,
+		"expiration":    template.NotAfter.Unix(),
+	}
+	return resp, nil
+}
+
+func (b *backend) pathFetchIssuerRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuerRef := data.Get("issuer_ref").(string)
+	isCRL := strings.HasPrefix(req.Path, "crl/")
+	isDelta := isCRL && strings.HasSuffix(strings.TrimSuffix(req.Path, "/pem"), "/delta")
+
+	contentType := "application/pkix-cert"
+	pemType := "CERTIFICATE"
+	if isCRL {
+		contentType = "application/pkix-crl"
+		pemType = "X509 CRL"
+	}
+
+	issuer, err := resolveIssuerRef(ctx, req.Storage, issuerRef)
+	if err != nil {
+		return nil, err
+	}
+
+	// The default issuer of a mount predating multiple issuers is its CA
+	var key string
+	switch {
+	case issuer == nil && !isDefaultIssuerRef(issuerRef):
+		return logical.ErrorResponse(fmt.Sprintf("unknown issuer %q", issuerRef)), nil
+	case issuer == nil && isDelta:
+		key = deltaCRLPath
+	case issuer == nil && isCRL:
+		key = "crl"
+	case issuer == nil:
+		key = "ca"
+	case isDelta:
+		key = issuerDeltaCRLPrefix + issuer.ID
+	case isCRL:
+		key = issuerCRLPrefix + issuer.ID
+	}
+
+	var certificate []byte
+	if key != "" {
+		entry, err := req.Storage.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			certificate = entry.Value
+		}
+	} else {
+		block, _ := pem.Decode([]byte(issuer.Bundle.Certificate))
+		if block == nil {
+			return nil, fmt.Errorf("unable to decode the certificate of issuer %s", issuer.ID)
+		}
+		certificate = block.Bytes
+	}
+
+	if len(certificate) > 0 && strings.HasSuffix(req.Path, "/pem") {
+		certificate = []byte(strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+			Type:  pemType,
+			Bytes: certificate,
+		}))))
+	}
+
+	statusCode := 200
+	if len(certificate) == 0 {
+		statusCode = 204
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: contentType,
+			logical.HTTPRawBody:     certificate,
+			logical.HTTPStatusCode:  statusCode,
+		},
+	}, nil
+}
+
+const pathConfigIssuersHelpSyn = `
+Configure the default issuer of the mount.
+`
+
+const pathConfigIssuersHelpDesc = `
+The default issuer signs the certificates of the paths and roles not referring
+to an issuer, and its certificate and CRL are the ones served at the "ca" and
+"crl" paths. Changing it does not affect the certificates already issued, which
+remain covered by the CRL of the issuer that signed them.
+`
+
+const pathListIssuersHelpSyn = `
+List the issuers of the mount.
+`
+
+const pathListIssuersHelpDesc = `
+Issuers are listed by ID, along with their names and serial numbers.
+`
+
+const pathGenerateIssuerRootHelpSyn = `
+Generate a new root CA certificate and private key as an additional issuer.
+`
+
+const pathGenerateIssuerRootHelpDesc = `
+Unlike the "root/generate" path, this adds an issuer alongside the existing
+ones rather than refusing to replace the root. The new issuer only becomes the
+default if the mount has none; use the "config/issuers" path to switch to it
+once it is trusted.
+
+See the API documentation for more information.
+`
+
+const pathImportIssuerHelpSyn = `
+Import a CA certificate and private key as an additional issuer.
+`
+
+const pathImportIssuerHelpDesc = `
+This takes a PEM-format, concatenated unencrypted secret key and certificate.
+The new issuer only becomes the default if the mount has none; use the
+"config/issuers" path to switch to it.
+
+For security reasons, the secret key cannot be retrieved later.
+`
+
+const pathIssuerHelpSyn = `
+Read, rename, configure the URLs of or delete an issuer.
+`
+
+const pathIssuerHelpDesc = `
+Issuers can be referred to by ID or name, and the default issuer as "default".
+The default issuer cannot be deleted. Certificates signed by a deleted issuer
+are no longer listed in any CRL of the mount.
+
+The URLs set on an issuer replace those of "config/urls" in the certificates
+it signs, for each kind of URL set, so that each issuer can point to its own
+CRLs and certificate.
+`
+
+const pathCrossSignIssuerHelpSyn = `
+Cross-sign an issuer with another issuer of the mount.
+`
+
+const pathCrossSignIssuerHelpDesc = `
+This returns a certificate with the subject and key of the issuer, signed by
+the issuer given as "signer_ref". Distributing it alongside the certificates
+signed by the issuer lets the clients trusting only the signer validate them,
+which allows rotating to a new root without downtime.
+`
+
+const pathFetchIssuerHelpSyn = `
+Fetch the CA certificate or CRL of an issuer.
+`
+
+const pathFetchIssuerHelpDesc = `
+This fetches the CA certificate or CRL of an issuer in DER encoding. Add
+"/delta" to the CRL path to get the delta CRL of the issuer, and "/pem" to get
+PEM encoding.
+`
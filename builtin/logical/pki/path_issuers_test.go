@@ -0,0 +1,378 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func issuersTestRequest(t *testing.T, b *backend, storage logical.Storage, operation logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: operation,
+		Path:      path,
+		Storage:   storage,
+		Data:      data,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("%s %s: err: %v resp: %#v", operation, path, err, resp)
+	}
+	return resp
+}
+
+func issuersTestParseCert(t *testing.T, pemCert string) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode([]byte(pemCert))
+	if block == nil {
+		t.Fatalf("unable to decode %q", pemCert)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func issuersTestCRLSerials(t *testing.T, b *backend, storage logical.Storage, path string) map[string]bool {
+	t.Helper()
+	resp := issuersTestRequest(t, b, storage, logical.ReadOperation, path, nil)
+	crl, err := x509.ParseCRL(resp.Data[logical.HTTPRawBody].([]byte))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serials := map[string]bool{}
+	for _, revoked := range crl.TBSCertList.RevokedCertificates {
+		serials[revoked.SerialNumber.String()] = true
+	}
+	return serials
+}
+
+func TestPki_MultipleIssuers(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	resp := issuersTestRequest(t, b, storage, logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "old.example.com",
+		"key_type":    "ec",
+		"key_bits":    256,
+		"ttl":         "8760h",
+	})
+	oldID := resp.Data["issuer_id"].(string)
+	oldCA := issuersTestParseCert(t, resp.Data["certificate"].(string))
+
+	resp = issuersTestRequest(t, b, storage, logical.UpdateOperation, "issuers/generate/root/internal", map[string]interface{}{
+		"common_name": "new.example.com",
+		"issuer_name": "new",
+		"key_type":    "ec",
+		"key_bits":    256,
+		"ttl":         "4380h",
+	})
+	newID := resp.Data["issuer_id"].(string)
+	newCA := issuersTestParseCert(t, resp.Data["certificate"].(string))
+
+	// Adding an issuer leaves the default issuer alone
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "config/issuers", nil)
+	if resp.Data["default"] != oldID {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = issuersTestRequest(t, b, storage, logical.ListOperation, "issuers/", nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 2 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "issuer/new", nil)
+	if resp.Data["issuer_id"] != newID || resp.Data["is_default"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The name of an issuer is unique and cannot be "default"
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "issuer/" + oldID,
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"issuer_name": "new",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected the name to be refused: err: %v resp: %#v", err, resp)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "issuer/" + oldID,
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"issuer_name": "default",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected the name to be refused: err: %v resp: %#v", err, resp)
+	}
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "issuer/"+oldID, map[string]interface{}{
+		"issuer_name": "old",
+	})
+
+	// Roles issue from the default issuer unless they refer to another
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "roles/current", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"key_bits":         256,
+	})
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "roles/next", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"key_bits":         256,
+		"issuer_ref":       "new",
+	})
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/unknown",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"allow_any_name": true,
+			"issuer_ref":     "unknown",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an unknown issuer to be refused: err: %v resp: %#v", err, resp)
+	}
+
+	resp = issuersTestRequest(t, b, storage, logical.UpdateOperation, "issue/current", map[string]interface{}{
+		"common_name": "a.example.com",
+	})
+	oldCert := issuersTestParseCert(t, resp.Data["certificate"].(string))
+	if err := oldCert.CheckSignatureFrom(oldCA); err != nil {
+		t.Fatal(err)
+	}
+	oldSerial := resp.Data["serial_number"].(string)
+
+	resp = issuersTestRequest(t, b, storage, logical.UpdateOperation, "issue/next", map[string]interface{}{
+		"common_name": "b.example.com",
+	})
+	newCert := issuersTestParseCert(t, resp.Data["certificate"].(string))
+	if err := newCert.CheckSignatureFrom(newCA); err != nil {
+		t.Fatal(err)
+	}
+	newSerial := resp.Data["serial_number"].(string)
+
+	// The cross-signed certificate of the new issuer chains its
+	// certificates to the old one
+	resp = issuersTestRequest(t, b, storage, logical.UpdateOperation, "issuer/new/cross-sign", map[string]interface{}{
+		"signer_ref": "old",
+	})
+	crossCert := issuersTestParseCert(t, resp.Data["certificate"].(string))
+	if err := crossCert.CheckSignatureFrom(oldCA); err != nil {
+		t.Fatal(err)
+	}
+	if err := newCert.CheckSignatureFrom(crossCert); err != nil {
+		t.Fatal(err)
+	}
+	if crossCert.NotAfter.After(oldCA.NotAfter) {
+		t.Fatalf("the cross-signed certificate outlives its signer")
+	}
+
+	// Each issuer lists the certificates it issued
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": oldSerial,
+	})
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": newSerial,
+	})
+	oldCRL := issuersTestCRLSerials(t, b, storage, "crl/issuer/old")
+	newCRL := issuersTestCRLSerials(t, b, storage, "crl/issuer/new")
+	defaultCRL := issuersTestCRLSerials(t, b, storage, "crl")
+	switch {
+	case len(oldCRL) != 1 || !oldCRL[oldCert.SerialNumber.String()]:
+		t.Fatalf("bad CRL of the old issuer: %v", oldCRL)
+	case len(newCRL) != 1 || !newCRL[newCert.SerialNumber.String()]:
+		t.Fatalf("bad CRL of the new issuer: %v", newCRL)
+	case len(defaultCRL) != 1 || !defaultCRL[oldCert.SerialNumber.String()]:
+		t.Fatalf("bad CRL of the default issuer: %v", defaultCRL)
+	}
+
+	// The default issuer cannot be deleted until another replaces it
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "issuer/old",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected the default issuer not to be deleted: err: %v resp: %#v", err, resp)
+	}
+
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "config/issuers", map[string]interface{}{
+		"default": "new",
+	})
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "ca", nil)
+	if resp.Data[logical.HTTPStatusCode] != 200 || string(resp.Data[logical.HTTPRawBody].([]byte)) != string(newCA.Raw) {
+		t.Fatalf("expected the CA of the new default issuer")
+	}
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "ca/issuer/old", nil)
+	if string(resp.Data[logical.HTTPRawBody].([]byte)) != string(oldCA.Raw) {
+		t.Fatalf("expected the CA of the old issuer")
+	}
+	if defaultCRL := issuersTestCRLSerials(t, b, storage, "crl"); len(defaultCRL) != 1 || !defaultCRL[newCert.SerialNumber.String()] {
+		t.Fatalf("bad CRL of the default issuer: %v", defaultCRL)
+	}
+
+	issuersTestRequest(t, b, storage, logical.DeleteOperation, "issuer/old", nil)
+	resp = issuersTestRequest(t, b, storage, logical.ListOperation, "issuers/", nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != newID {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestPki_MultipleIssuers_LegacyCABundle(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	resp := issuersTestRequest(t, b, storage, logical.UpdateOperation, "root/generate/exported", map[string]interface{}{
+		"common_name": "legacy.example.com",
+		"key_type":    "ec",
+		"key_bits":    256,
+	})
+	legacyCA := issuersTestParseCert(t, resp.Data["certificate"].(string))
+	pemBundle := resp.Data["private_key"].(string) + "\n" + resp.Data["certificate"].(string)
+
+	// Mounts predating multiple issuers only have the CA bundle
+	for _, key := range []string{issuerPrefix + resp.Data["issuer_id"].(string), issuersConfigPath} {
+		if err := storage.Delete(context.Background(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "ca/issuer/default", nil)
+	if string(resp.Data[logical.HTTPRawBody].([]byte)) != string(legacyCA.Raw) {
+		t.Fatalf("expected the CA bundle to be the default issuer")
+	}
+
+	// Importing the CA bundle again finds it migrated as the default issuer
+	resp = issuersTestRequest(t, b, storage, logical.UpdateOperation, "issuers/import", map[string]interface{}{
+		"pem_bundle": pemBundle,
+	})
+	importedID := resp.Data["issuer_id"].(string)
+
+	resp = issuersTestRequest(t, b, storage, logical.ListOperation, "issuers/", nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != importedID {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "config/issuers", nil)
+	if resp.Data["default"] != importedID {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestPki_IssuerURLs(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "old.example.com",
+		"key_type":    "ec",
+		"key_bits":    256,
+		"ttl":         "8760h",
+	})
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "issuers/generate/root/internal", map[string]interface{}{
+		"common_name": "new.example.com",
+		"issuer_name": "new",
+		"key_type":    "ec",
+		"key_bits":    256,
+		"ttl":         "8760h",
+	})
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "config/urls", map[string]interface{}{
+		"issuing_certificates":    "http://example.com/ca",
+		"crl_distribution_points": "http://example.com/crl",
+		"ocsp_servers":            "http://example.com/ocsp",
+	})
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"enable_delta": true,
+	})
+
+	// Invalid URLs are refused
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "issuer/new",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"crl_distribution_points": "not a url",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected the URL to be refused: err: %v resp: %#v", err, resp)
+	}
+
+	issuersTestRequest(t, b, storage, logical.UpdateOperation, "issuer/new", map[string]interface{}{
+		"issuing_certificates":          "http://example.com/new/ca",
+		"crl_distribution_points":       "http://example.com/new/crl",
+		"delta_crl_distribution_points": "http://example.com/new/crl/delta",
+	})
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "issuer/new", nil)
+	if crls := resp.Data["crl_distribution_points"].([]string); len(crls) != 1 || crls[0] != "http://example.com/new/crl" || resp.Data["issuer_name"] != "new" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	for _, issuerRef := range []string{"default", "new"} {
+		issuersTestRequest(t, b, storage, logical.UpdateOperation, "roles/"+issuerRef, map[string]interface{}{
+			"allowed_domains":  "example.com",
+			"allow_subdomains": true,
+			"key_type":         "ec",
+			"key_bits":         256,
+			"issuer_ref":       issuerRef,
+		})
+	}
+
+	// The default issuer uses the URLs of the mount
+	resp = issuersTestRequest(t, b, storage, logical.UpdateOperation, "issue/default", map[string]interface{}{
+		"common_name": "a.example.com",
+	})
+	cert := issuersTestParseCert(t, resp.Data["certificate"].(string))
+	if len(cert.CRLDistributionPoints) != 1 || cert.CRLDistributionPoints[0] != "http://example.com/crl" {
+		t.Fatalf("bad CRL distribution points: %v", cert.CRLDistributionPoints)
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidExtensionFreshestCRL) {
+			t.Fatal("unexpected delta CRL distribution points")
+		}
+	}
+
+	// The new issuer overrides those it sets
+	resp = issuersTestRequest(t, b, storage, logical.UpdateOperation, "issue/new", map[string]interface{}{
+		"common_name": "b.example.com",
+	})
+	cert = issuersTestParseCert(t, resp.Data["certificate"].(string))
+	switch {
+	case len(cert.IssuingCertificateURL) != 1 || cert.IssuingCertificateURL[0] != "http://example.com/new/ca":
+		t.Fatalf("bad issuing certificates: %v", cert.IssuingCertificateURL)
+	case len(cert.CRLDistributionPoints) != 1 || cert.CRLDistributionPoints[0] != "http://example.com/new/crl":
+		t.Fatalf("bad CRL distribution points: %v", cert.CRLDistributionPoints)
+	case len(cert.OCSPServer) != 1 || cert.OCSPServer[0] != "http://example.com/ocsp":
+		t.Fatalf("bad OCSP servers: %v", cert.OCSPServer)
+	}
+	found := false
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidExtensionFreshestCRL) {
+			found = bytes.Contains(ext.Value, []byte("http://example.com/new/crl/delta"))
+		}
+	}
+	if !found {
+		t.Fatal("missing delta CRL distribution points")
+	}
+
+	// So does its full CRL
+	resp = issuersTestRequest(t, b, storage, logical.ReadOperation, "crl/issuer/new", nil)
+	crl, err := x509.ParseRevocationList(resp.Data[logical.HTTPRawBody].([]byte))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = false
+	for _, ext := range crl.Extensions {
+		if ext.Id.Equal(oidExtensionFreshestCRL) {
+			found = bytes.Contains(ext.Value, []byte("http://example.com/new/crl/delta"))
+		}
+	}
+	if !found {
+		t.Fatal("missing delta CRL distribution points in the CRL")
+	}
+}
@@ -1,11 +1,15 @@
 package pki
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"strings"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/certutil"
 	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -20,6 +24,12 @@ func pathRevoke(b *backend) *framework.Path {
 				Description: `Certificate serial number, in colon- or
 hyphen-separated octal`,
 			},
+			"certificate": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `PEM-format certificate to revoke, in place of
+its serial number. Certificates issued by roles with
+no_store set can only be revoked this way.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -46,8 +56,49 @@ func pathRotateCRL(b *backend) *framework.Path {
 
 func (b *backend) pathRevokeWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	serial := data.Get("serial_number").(string)
-	if len(serial) == 0 {
-		return logical.ErrorResponse("The serial number must be provided"), nil
+	certPEM := data.Get("certificate").(string)
+	switch {
+	case len(serial) == 0 && len(certPEM) == 0:
+		return logical.ErrorResponse("The serial number or certificate must be provided"), nil
+	case len(serial) != 0 && len(certPEM) != 0:
+		return logical.ErrorResponse("Only one of the serial number and certificate may be provided"), nil
+	}
+
+	// The certificate may not have been stored, so it has to be checked that
+	// it was issued by this CA before it is added to the CRL
+	var certBytes []byte
+	if len(certPEM) != 0 {
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil {
+			return logical.ErrorResponse("certificate could not be PEM-decoded"), nil
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("error parsing certificate: %s", err)), nil
+		}
+
+		caInfos, err := fetchAllCAInfo(ctx, req)
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		case errutil.InternalError:
+			return nil, err
+		}
+		issued := false
+		for _, caInfo := range caInfos {
+			if bytes.Equal(cert.Raw, caInfo.Certificate.Raw) {
+				return logical.ErrorResponse("the CA certificate cannot be revoked"), nil
+			}
+			if err := cert.CheckSignatureFrom(caInfo.Certificate); err == nil {
+				issued = true
+			}
+		}
+		if !issued {
+			return logical.ErrorResponse("certificate was not issued by this CA"), nil
+		}
+
+		serial = certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":")
+		certBytes = block.Bytes
 	}
 
 	// We store and identify by lowercase colon-separated hex, but other
@@ -57,7 +108,7 @@ func (b *backend) pathRevokeWrite(ctx context.Context, req *logical.Request, dat
 	b.revokeStorageLock.Lock()
 	defer b.revokeStorageLock.Unlock()
 
-	return revokeCert(ctx, b, req, serial, false)
+	return revokeCert(ctx, b, req, serial, certBytes, false)
 }
 
 func (b *backend) pathRotateCRLRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -85,6 +136,8 @@ Revoke a certificate by serial number.
 
 const pathRevokeHelpDesc = `
 This allows certificates to be revoked using its serial number. A root token is required.
+Certificates that were not stored, because they were issued by a role with
+no_store set, can be revoked by submitting the certificate itself.
 `
 
 const pathRotateCRLHelpSyn = `
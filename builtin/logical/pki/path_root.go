@@ -72,6 +72,12 @@ func pathSignIntermediate(b *backend) *framework.Path {
 		Description: `PEM-format CSR to be signed.`,
 	}
 
+	ret.Fields["issuer_ref"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Default:     defaultIssuerRef,
+		Description: `The ID or name of the issuer to sign with. Defaults to the default issuer of the mount.`,
+	}
+
 	ret.Fields["use_csr_values"] = &framework.FieldSchema{
 		Type:    framework.TypeBool,
 		Default: false,
@@ -102,6 +108,11 @@ func pathSignSelfIssued(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: `PEM-format self-issued certificate to be signed.`,
 			},
+			"issuer_ref": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     defaultIssuerRef,
+				Description: `The ID or name of the issuer to sign with. Defaults to the default issuer of the mount.`,
+			},
 		},
 
 		HelpSynopsis:    pathSignSelfIssuedHelpSyn,
@@ -112,6 +123,22 @@ func pathSignSelfIssued(b *backend) *framework.Path {
 }
 
 func (b *backend) pathCADeleteRoot(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.issuersLock.Lock()
+	defer b.issuersLock.Unlock()
+
+	issuers, err := req.Storage.List(ctx, issuerPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range issuers {
+		if err := deleteIssuer(ctx, req.Storage, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := req.Storage.Delete(ctx, issuersConfigPath); err != nil {
+		return nil, err
+	}
+
 	return nil, req.Storage.Delete(ctx, "config/ca_bundle")
 }
 
@@ -128,6 +155,16 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 		return resp, nil
 	}
 
+	return b.generateRoot(ctx, req, data, "", true)
+}
+
+// generateRoot generates a root CA certificate and key and adds them as an
+// issuer, made the default if requested or if there is none
+func (b *backend) generateRoot(ctx context.Context, req *logical.Request, data *framework.FieldData, issuerName string, makeDefault bool) (*logical.Response, error) {
+	if err := validateIssuerName(issuerName); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	exported, format, role, errorResp := b.getGenerationParams(data)
 	if errorResp != nil {
 		return errorResp, nil
@@ -202,15 +239,17 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 		}
 	}
 
-	// Store it as the CA bundle
-	entry, err = logical.StorageEntryJSON("config/ca_bundle", cb)
-	if err != nil {
-		return nil, err
-	}
-	err = req.Storage.Put(ctx, entry)
+	// Store it as an issuer
+	issuer, err := b.importIssuer(ctx, req.Storage, cb, issuerName, makeDefault)
 	if err != nil {
-		return nil, err
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
 	}
+	resp.Data["issuer_id"] = issuer.ID
 
 	// Also store it as just the certificate identified by serial number, so it
 	// can be revoked
@@ -222,15 +261,6 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 		return nil, errwrap.Wrapf("unable to store certificate locally: {{err}}", err)
 	}
 
-	// For ease of later use, also store just the certificate at a known
-	// location
-	entry.Key = "ca"
-	entry.Value = parsedBundle.CertificateBytes
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		return nil, err
-	}
-
 	// Build a fresh CRL
 	err = buildCRL(ctx, b, req)
 	if err != nil {
@@ -278,7 +308,7 @@ func (b *backend) pathCASignIntermediate(ctx context.Context, req *logical.Reque
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(ctx, req)
+	signingBundle, caErr := fetchCAInfoByIssuer(ctx, req, data.Get("issuer_ref").(string))
 	switch caErr.(type) {
 	case errutil.UserError:
 		return nil, errutil.UserError{Err: fmt.Sprintf(
@@ -405,7 +435,7 @@ func (b *backend) pathCASignSelfIssued(ctx context.Context, req *logical.Request
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(ctx, req)
+	signingBundle, caErr := fetchCAInfoByIssuer(ctx, req, data.Get("issuer_ref").(string))
 	switch caErr.(type) {
 	case errutil.UserError:
 		return nil, errutil.UserError{Err: fmt.Sprintf(
@@ -427,6 +457,9 @@ func (b *backend) pathCASignSelfIssued(ctx context.Context, req *logical.Request
 	cert.IssuingCertificateURL = urls.IssuingCertificates
 	cert.CRLDistributionPoints = urls.CRLDistributionPoints
 	cert.OCSPServer = urls.OCSPServers
+	if err := addFreshestCRL(urls, cert); err != nil {
+		return nil, err
+	}
 
 	newCert, err := x509.CreateCertificate(rand.Reader, cert, signingBundle.Certificate, cert.PublicKey, signingBundle.PrivateKey)
 	if err != nil {
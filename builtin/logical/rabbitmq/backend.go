@@ -3,6 +3,9 @@ package rabbitmq
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"strings"
 	"sync"
 
@@ -119,6 +122,22 @@ func (b *backend) invalidate(ctx context.Context, key string) {
 	}
 }
 
+// checkResponse returns an error if the RabbitMQ management API rejected a
+// request. The API reports failures with the status code only, so the
+// responses of the user and permission calls have to be checked as well.
+func checkResponse(res *http.Response) error {
+	if res == nil {
+		return nil
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("unexpected status %d from RabbitMQ: %s", res.StatusCode, strings.TrimSpace(string(body)))
+}
+
 // Lease returns the lease information
 func (b *backend) Lease(ctx context.Context, s logical.Storage) (*configLease, error) {
 	entry, err := s.Get(ctx, "config/lease")
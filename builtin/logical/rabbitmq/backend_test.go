@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/hashicorp/vault/helper/jsonutil"
@@ -116,6 +120,201 @@ func TestBackend_roleCrud(t *testing.T) {
 	})
 }
 
+func TestBackend_responseStatus(t *testing.T) {
+	var lock sync.Mutex
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch {
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/users/"):
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/permissions/"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"bad_request","reason":"vhost_not_found"}`))
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/api/users/"):
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/api/users/"))
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, req := range []*logical.Request{
+		{
+			Operation: logical.UpdateOperation,
+			Path:      "config/connection",
+			Data: map[string]interface{}{
+				"connection_uri":    ts.URL,
+				"username":          "guest",
+				"password":          "guest",
+				"verify_connection": false,
+			},
+		},
+		{
+			Operation: logical.UpdateOperation,
+			Path:      "roles/web",
+			Data: map[string]interface{}{
+				"vhosts": `{"missing": {"configure": ".*", "write": ".*", "read": ".*"}}`,
+			},
+		},
+	} {
+		req.Storage = config.StorageView
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+		}
+	}
+
+	// A rejected permission update fails the request and deletes the user
+	_, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "creds/web",
+		Storage:     config.StorageView,
+		DisplayName: "test",
+	})
+	if err == nil || !strings.Contains(err.Error(), "vhost_not_found") {
+		t.Fatalf("expected the permission update to fail, got: %v", err)
+	}
+	lock.Lock()
+	if len(deleted) != 1 || !strings.HasPrefix(deleted[0], "test-") {
+		t.Fatalf("expected the user to be deleted, got: %v", deleted)
+	}
+	lock.Unlock()
+
+	// Revoking a user that no longer exists succeeds
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   config.StorageView,
+		Secret: &logical.Secret{
+			InternalData: map[string]interface{}{
+				"secret_type": "creds",
+				"username":    "gone",
+			},
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+}
+
+// passwordPolicySystemView generates the passwords of a single password
+// policy
+type passwordPolicySystemView struct {
+	logical.StaticSystemView
+	policy, password string
+}
+
+func (s *passwordPolicySystemView) GeneratePasswordFromPolicy(_ context.Context, policyName string) (string, error) {
+	if policyName != s.policy {
+		return "", fmt.Errorf("password policy %q not found", policyName)
+	}
+	return s.password, nil
+}
+
+func TestBackend_passwordPolicy(t *testing.T) {
+	var lock sync.Mutex
+	var passwords []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch {
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/users/"):
+			var user rabbithole.UserSettings
+			if err := jsonutil.DecodeJSONFromReader(r.Body, &user); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			passwords = append(passwords, user.Password)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/permissions/"):
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = &passwordPolicySystemView{
+		StaticSystemView: *config.System.(*logical.StaticSystemView),
+		policy:           "rabbitmq",
+		password:         "generated-from-policy",
+	}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	configReq := func(policy string) *logical.Request {
+		return &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "config/connection",
+			Storage:   config.StorageView,
+			Data: map[string]interface{}{
+				"connection_uri":    ts.URL,
+				"username":          "guest",
+				"password":          "guest",
+				"verify_connection": false,
+				"password_policy":   policy,
+			},
+		}
+	}
+
+	// A policy that does not exist is rejected
+	resp, err := b.HandleRequest(context.Background(), configReq("missing"))
+	if err != nil || resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), `"missing" not found`) {
+		t.Fatalf("expected the unknown policy to be rejected, got resp: %#v, err: %v", resp, err)
+	}
+
+	for _, req := range []*logical.Request{
+		configReq("rabbitmq"),
+		{
+			Operation: logical.UpdateOperation,
+			Path:      "roles/web",
+			Storage:   config.StorageView,
+			Data: map[string]interface{}{
+				"vhosts": `{"/": {"configure": ".*", "write": ".*", "read": ".*"}}`,
+			},
+		},
+	} {
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+		}
+	}
+
+	// The user is created with the password of the policy
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "creds/web",
+		Storage:     config.StorageView,
+		DisplayName: "test",
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if resp.Data["password"] != "generated-from-policy" {
+		t.Fatalf("expected the password of the policy, got: %v", resp.Data["password"])
+	}
+	lock.Lock()
+	if len(passwords) != 1 || passwords[0] != "generated-from-policy" {
+		t.Fatalf("expected the user to be created with the password of the policy, got: %v", passwords)
+	}
+	lock.Unlock()
+}
+
 func testAccPreCheckFunc(t *testing.T, uri string) func() {
 	return func() {
 		if uri == "" {
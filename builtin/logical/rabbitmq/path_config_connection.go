@@ -2,6 +2,7 @@ package rabbitmq
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
@@ -30,6 +31,10 @@ func pathConfigConnection(b *backend) *framework.Path {
 				Default:     true,
 				Description: `If set, connection_uri is verified by actually connecting to the RabbitMQ management API`,
 			},
+			"password_policy": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the password policy used to generate the passwords of the users",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -72,11 +77,20 @@ func (b *backend) pathConnectionUpdate(ctx context.Context, req *logical.Request
 		}
 	}
 
+	// Make sure the passwords of the users can be generated with the policy
+	passwordPolicy := data.Get("password_policy").(string)
+	if passwordPolicy != "" {
+		if _, err := logical.GeneratePasswordFromPolicy(ctx, b.System(), passwordPolicy); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid password_policy: %s", err)), nil
+		}
+	}
+
 	// Store it
 	entry, err := logical.StorageEntryJSON("config/connection", connectionConfig{
-		URI:      uri,
-		Username: username,
-		Password: password,
+		URI:            uri,
+		Username:       username,
+		Password:       password,
+		PasswordPolicy: passwordPolicy,
 	})
 	if err != nil {
 		return nil, err
@@ -101,6 +115,10 @@ type connectionConfig struct {
 
 	// Password for the Username
 	Password string `json:"password"`
+
+	// PasswordPolicy is the name of the password policy the passwords of the
+	// users are generated with. If empty, they are random UUIDs.
+	PasswordPolicy string `json:"password_policy"`
 }
 
 const pathConfigConnectionHelpSyn = `
@@ -112,7 +130,8 @@ This path configures the connection properties used to connect to RabbitMQ manag
 The "connection_uri" parameter is a string that is used to connect to the API. The "username"
 and "password" parameters are strings that are used as credentials to the API. The "verify_connection"
 parameter is a boolean that is used to verify whether the provided connection URI, username, and password
-are valid.
+are valid. The "password_policy" parameter is the name of a Vault password policy the passwords of
+the generated users follow.
 
 The URI looks like:
 "http://localhost:15672"
@@ -54,7 +54,7 @@ func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *fr
 	}
 	username := fmt.Sprintf("%s-%s", req.DisplayName, uuidVal)
 
-	password, err := uuid.GenerateUUID()
+	password, err := b.generatePassword(ctx, req.Storage)
 	if err != nil {
 		return nil, err
 	}
@@ -69,24 +69,32 @@ func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *fr
 	}
 
 	// Register the generated credentials in the backend, with the RabbitMQ server
-	if _, err = client.PutUser(username, rabbithole.UserSettings{
+	res, err := client.PutUser(username, rabbithole.UserSettings{
 		Password: password,
 		Tags:     role.Tags,
-	}); err != nil {
-		return nil, fmt.Errorf("failed to create a new user with the generated credentials")
+	})
+	if err == nil {
+		err = checkResponse(res)
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to create a new user with the generated credentials: {{err}}", err)
 	}
 
 	// If the role had vhost permissions specified, assign those permissions
 	// to the created username for respective vhosts.
 	for vhost, permission := range role.VHosts {
-		if _, err := client.UpdatePermissionsIn(vhost, username, rabbithole.Permissions{
+		res, err := client.UpdatePermissionsIn(vhost, username, rabbithole.Permissions{
 			Configure: permission.Configure,
 			Write:     permission.Write,
 			Read:      permission.Read,
-		}); err != nil {
+		})
+		if err == nil {
+			err = checkResponse(res)
+		}
+		if err != nil {
 			outerErr := errwrap.Wrapf(fmt.Sprintf("failed to update permissions to the %q user: {{err}}", username), err)
 			// Delete the user because it's in an unknown state
-			if _, rmErr := client.DeleteUser(username); rmErr != nil {
+			if rmErr := deleteUser(client, username); rmErr != nil {
 				return nil, multierror.Append(errwrap.Wrapf("failed to delete user: {{err}}", rmErr), outerErr)
 			}
 			return nil, outerErr
@@ -115,6 +123,31 @@ func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *fr
 	return resp, nil
 }
 
+// generatePassword returns a password for a new user, following the password
+// policy of the connection if one is set
+func (b *backend) generatePassword(ctx context.Context, s logical.Storage) (string, error) {
+	entry, err := s.Get(ctx, "config/connection")
+	if err != nil {
+		return "", err
+	}
+	var connConfig connectionConfig
+	if entry != nil {
+		if err := entry.DecodeJSON(&connConfig); err != nil {
+			return "", err
+		}
+	}
+
+	if connConfig.PasswordPolicy == "" {
+		return uuid.GenerateUUID()
+	}
+
+	password, err := logical.GeneratePasswordFromPolicy(ctx, b.System(), connConfig.PasswordPolicy)
+	if err != nil {
+		return "", errwrap.Wrapf("failed to generate password: {{err}}", err)
+	}
+	return password, nil
+}
+
 const pathRoleCreateReadHelpSyn = `
 Request RabbitMQ credentials for a certain role.
 `
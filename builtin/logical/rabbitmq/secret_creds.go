@@ -3,10 +3,12 @@ package rabbitmq
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/michaelklishin/rabbit-hole"
 )
 
 // SecretCredsType is the key for this backend's secrets.
@@ -62,9 +64,23 @@ func (b *backend) secretCredsRevoke(ctx context.Context, req *logical.Request, d
 		return nil, err
 	}
 
-	if _, err = client.DeleteUser(username); err != nil {
+	if err := deleteUser(client, username); err != nil {
 		return nil, errwrap.Wrapf("could not delete user: {{err}}", err)
 	}
 
 	return nil, nil
 }
+
+// deleteUser deletes a user from RabbitMQ. A user that no longer exists is
+// not an error, so that the lease of a user deleted out of band is revoked.
+func deleteUser(client *rabbithole.Client, username string) error {
+	res, err := client.DeleteUser(username)
+	if err != nil {
+		return err
+	}
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil
+	}
+	return checkResponse(res)
+}
@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// artifactoryToken is an access token created through the token API
+type artifactoryToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+	TokenType   string `json:"token_type"`
+}
+
+// artifactoryError is an error returned by Artifactory. The token API and the
+// rest of the REST API report errors differently.
+type artifactoryError struct {
+	Code             int    `json:"-"`
+	ErrorCode        string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	Errors           []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (e *artifactoryError) Error() string {
+	var msg string
+	switch {
+	case e.ErrorDescription != "":
+		msg = e.ErrorDescription
+	case len(e.Errors) > 0:
+		msg = e.Errors[0].Message
+	default:
+		msg = e.ErrorCode
+	}
+	return fmt.Sprintf("artifactory returned %d: %s", e.Code, msg)
+}
+
+// isNotFound returns whether err is Artifactory reporting that an object does
+// not exist
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*artifactoryError)
+	return ok && apiErr.Code == http.StatusNotFound
+}
+
+// artifactoryClient calls the REST API of Artifactory with an admin access
+// token
+type artifactoryClient struct {
+	url         string
+	accessToken string
+	client      *http.Client
+}
+
+func newArtifactoryClient(conf *registryConfig, client *http.Client) *artifactoryClient {
+	return &artifactoryClient{
+		url:         strings.TrimSuffix(conf.URL, "/"),
+		accessToken: conf.AccessToken,
+		client:      client,
+	}
+}
+
+// createToken creates a token for username, with the permissions of groups,
+// expiring after ttl. The user doesn't have to exist.
+func (c *artifactoryClient) createToken(ctx context.Context, username string, groups []string, ttl time.Duration) (*artifactoryToken, error) {
+	form := url.Values{}
+	form.Set("username", username)
+	form.Set("scope", "member-of-groups:"+strings.Join(groups, ","))
+	form.Set("expires_in", strconv.FormatInt(int64(ttl.Seconds()), 10))
+
+	var out artifactoryToken
+	if err := c.post(ctx, "/api/security/token", form, &out); err != nil {
+		return nil, err
+	}
+	if out.AccessToken == "" {
+		return nil, errors.New("artifactory returned an empty access token")
+	}
+	return &out, nil
+}
+
+// revokeToken revokes a token by ID; a token that doesn't exist is not an
+// error
+func (c *artifactoryClient) revokeToken(ctx context.Context, tokenID string) error {
+	form := url.Values{}
+	form.Set("token_id", tokenID)
+	err := c.post(ctx, "/api/security/token/revoke", form, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *artifactoryClient) post(ctx context.Context, path string, form url.Values, out interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, c.url+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &artifactoryError{}
+		if err := json.Unmarshal(body, apiErr); err != nil || (apiErr.ErrorCode == "" && apiErr.ErrorDescription == "" && len(apiErr.Errors) == 0) {
+			apiErr.ErrorDescription = strings.TrimSpace(string(body))
+		}
+		apiErr.Code = resp.StatusCode
+		return apiErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return errwrap.Wrapf("error decoding artifactory response: {{err}}", err)
+		}
+	}
+	return nil
+}
+
+// artifactoryTokenID returns the ID of an access token, which is the jti
+// claim of the JWT. The token is issued by Artifactory, so its signature
+// isn't verified.
+func artifactoryTokenID(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("the access token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", errwrap.Wrapf("error decoding the access token: {{err}}", err)
+	}
+	var claims struct {
+		ID string `json:"jti"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", errwrap.Wrapf("error decoding the access token: {{err}}", err)
+	}
+	if claims.ID == "" {
+		return "", errors.New("the access token has no ID")
+	}
+	return claims.ID, nil
+}
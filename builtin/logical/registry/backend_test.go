@@ -0,0 +1,519 @@
+package registry
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	logicaltest "github.com/hashicorp/vault/logical/testing"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// fakeArtifactoryServer is an Artifactory token API keeping the tokens
+// created through it
+type fakeArtifactoryServer struct {
+	*httptest.Server
+
+	l      sync.Mutex
+	tokens map[string]map[string]string
+}
+
+func newFakeArtifactoryServer(t *testing.T) *fakeArtifactoryServer {
+	s := &fakeArtifactoryServer{
+		tokens: make(map[string]map[string]string),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer admin-token" {
+			writeError(w, http.StatusUnauthorized, "Bad credentials")
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.l.Lock()
+		defer s.l.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/artifactory/api/security/token":
+			id := fmt.Sprintf("token%d", len(s.tokens)+1)
+			s.tokens[id] = map[string]string{
+				"username":   r.PostForm.Get("username"),
+				"scope":      r.PostForm.Get("scope"),
+				"expires_in": r.PostForm.Get("expires_in"),
+			}
+			claims, _ := json.Marshal(map[string]interface{}{
+				"sub": "jfrt@01/users/" + r.PostForm.Get("username"),
+				"jti": id,
+			})
+			expiresIn, _ := strconv.Atoi(r.PostForm.Get("expires_in"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(claims) + ".c2ln",
+				"expires_in":   expiresIn,
+				"scope":        r.PostForm.Get("scope"),
+				"token_type":   "Bearer",
+			})
+
+		case r.Method == http.MethodPost && r.URL.Path == "/artifactory/api/security/token/revoke":
+			id := r.PostForm.Get("token_id")
+			if _, ok := s.tokens[id]; !ok {
+				writeError(w, http.StatusNotFound, "Token not found")
+				return
+			}
+			delete(s.tokens, id)
+			w.Write([]byte("Token revoked"))
+
+		default:
+			writeError(w, http.StatusNotFound, "Not found")
+		}
+	}))
+	return s
+}
+
+func writeError(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":             http.StatusText(code),
+		"error_description": description,
+	})
+}
+
+func (s *fakeArtifactoryServer) token(id string) map[string]string {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.tokens[id]
+}
+
+func (s *fakeArtifactoryServer) tokenCount() int {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return len(s.tokens)
+}
+
+func getBackend(t *testing.T) (*backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+var testDockerConfigData = map[string]interface{}{
+	"type":    "docker",
+	"issuer":  "vault",
+	"service": "registry.example.com",
+}
+
+func TestBackend_config(t *testing.T) {
+	steps := []logicaltest.TestStep{}
+	for _, data := range []map[string]interface{}{
+		// No type
+		{
+			"issuer":  "vault",
+			"service": "registry.example.com",
+		},
+		// Unknown type
+		{
+			"type": "quay",
+		},
+		// Docker without issuer
+		{
+			"type":    "docker",
+			"service": "registry.example.com",
+		},
+		// Docker without service
+		{
+			"type":   "docker",
+			"issuer": "vault",
+		},
+		// Artifactory without access token
+		{
+			"type": "artifactory",
+			"url":  "https://example.jfrog.io/artifactory",
+		},
+		// Artifactory with invalid url
+		{
+			"type":         "artifactory",
+			"url":          "example.jfrog.io",
+			"access_token": "admin-token",
+		},
+	} {
+		steps = append(steps, testAccStepConfig(t, data, true))
+	}
+
+	signingKey := make(map[string]interface{})
+	steps = append(steps,
+		testAccStepConfig(t, testDockerConfigData, false),
+		testAccStepReadConfig(t, func(resp *logical.Response) error {
+			if resp.Data["type"] != "docker" || resp.Data["issuer"] != "vault" || resp.Data["service"] != "registry.example.com" {
+				return fmt.Errorf("bad: %#v", resp.Data)
+			}
+			if _, ok := resp.Data["signing_key"]; ok {
+				return fmt.Errorf("the signing key was returned")
+			}
+			keyID := resp.Data["key_id"].(string)
+			if !regexp.MustCompile(`^([A-Z2-7]{4}:){11}[A-Z2-7]{4}$`).MatchString(keyID) {
+				return fmt.Errorf("bad key ID: %q", keyID)
+			}
+			signingKey["certificate"] = resp.Data["certificate"]
+			signingKey["key_id"] = keyID
+			return nil
+		}),
+
+		// The signing key is kept when the configuration changes
+		testAccStepConfig(t, map[string]interface{}{
+			"service": "registry2.example.com",
+		}, false),
+		testAccStepReadConfig(t, func(resp *logical.Response) error {
+			if resp.Data["certificate"] != signingKey["certificate"] || resp.Data["key_id"] != signingKey["key_id"] {
+				return fmt.Errorf("the signing key changed: %#v", resp.Data)
+			}
+			return nil
+		}),
+
+		testAccStepConfig(t, map[string]interface{}{
+			"type":         "artifactory",
+			"url":          "https://example.jfrog.io/artifactory",
+			"access_token": "admin-token",
+		}, false),
+		testAccStepReadConfig(t, func(resp *logical.Response) error {
+			if resp.Data["type"] != "artifactory" || resp.Data["url"] != "https://example.jfrog.io/artifactory" {
+				return fmt.Errorf("bad: %#v", resp.Data)
+			}
+			if _, ok := resp.Data["access_token"]; ok {
+				return fmt.Errorf("the access token was returned")
+			}
+			return nil
+		}),
+	)
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps:   steps,
+	})
+}
+
+func TestBackend_roles(t *testing.T) {
+	steps := []logicaltest.TestStep{}
+	for _, data := range []map[string]interface{}{
+		// No repositories or groups
+		{
+			"actions": "pull",
+		},
+		// Invalid repository
+		{
+			"repositories": "Library/Alpine",
+		},
+		// Invalid action
+		{
+			"repositories": "library/alpine",
+			"actions":      "pull,write",
+		},
+		// ttl over max_ttl
+		{
+			"groups":  "readers",
+			"ttl":     "2h",
+			"max_ttl": "1h",
+		},
+	} {
+		steps = append(steps, testAccStepWriteRole(t, "bad", data, true))
+	}
+
+	steps = append(steps,
+		testAccStepWriteRole(t, "build", map[string]interface{}{
+			"repositories": "library/alpine,team/app",
+			"groups":       "readers",
+			"ttl":          "30m",
+		}, false),
+		logicaltest.TestStep{
+			Operation: logical.ReadOperation,
+			Path:      "roles/build",
+			Check: func(resp *logical.Response) error {
+				if resp == nil {
+					return fmt.Errorf("role not found")
+				}
+				if actions := resp.Data["actions"].([]string); len(actions) != 1 || actions[0] != "pull" {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				if repositories := resp.Data["repositories"].([]string); len(repositories) != 2 || resp.Data["ttl"] != int64(1800) {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				return nil
+			},
+		},
+		logicaltest.TestStep{
+			Operation: logical.ListOperation,
+			Path:      "roles/",
+			Check: func(resp *logical.Response) error {
+				if resp == nil || !reflect.DeepEqual(resp.Data["keys"], []string{"build"}) {
+					return fmt.Errorf("bad: %#v", resp)
+				}
+				return nil
+			},
+		},
+		logicaltest.TestStep{
+			Operation: logical.DeleteOperation,
+			Path:      "roles/build",
+		},
+		logicaltest.TestStep{
+			Operation: logical.ReadOperation,
+			Path:      "roles/build",
+			Check: func(resp *logical.Response) error {
+				if resp != nil {
+					return fmt.Errorf("bad: %#v", resp)
+				}
+				return nil
+			},
+		},
+	)
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps:   steps,
+	})
+}
+
+func TestBackend_dockerCreds(t *testing.T) {
+	signingKey := make(map[string]interface{})
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepWriteRole(t, "push", map[string]interface{}{
+				"repositories": "team/app",
+				"actions":      "pull,push",
+				"ttl":          "10m",
+			}, false),
+			testAccStepWriteRole(t, "artifactory", map[string]interface{}{
+				"groups": "readers",
+			}, false),
+			testAccStepReadCreds(t, "push", true, nil),
+
+			testAccStepConfig(t, testDockerConfigData, false),
+			testAccStepReadCreds(t, "missing", true, nil),
+			testAccStepReadCreds(t, "artifactory", true, nil),
+			testAccStepReadConfig(t, func(resp *logical.Response) error {
+				signingKey["certificate"] = resp.Data["certificate"]
+				signingKey["key_id"] = resp.Data["key_id"]
+				return nil
+			}),
+
+			// The token verifies with the certificate registries trust, which
+			// they find by the key ID of the token
+			testAccStepReadCreds(t, "push", false, func(resp *logical.Response) error {
+				if resp.Secret.TTL != 10*time.Minute || resp.Secret.Renewable {
+					return fmt.Errorf("bad: %#v", resp.Secret)
+				}
+				username := resp.Data["username"].(string)
+				if !strings.HasPrefix(username, "vault-push-root-") {
+					return fmt.Errorf("bad username: %q", username)
+				}
+
+				block, _ := pem.Decode([]byte(signingKey["certificate"].(string)))
+				cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					return err
+				}
+				jws, err := jose.ParseSigned(resp.Data["token"].(string))
+				if err != nil {
+					return err
+				}
+				if kid := jws.Signatures[0].Header.KeyID; kid != signingKey["key_id"] {
+					return fmt.Errorf("bad key ID: %q", kid)
+				}
+				payload, err := jws.Verify(cert.PublicKey)
+				if err != nil {
+					return err
+				}
+
+				var claims dockerClaims
+				if err := json.Unmarshal(payload, &claims); err != nil {
+					return err
+				}
+				if claims.Issuer != "vault" || claims.Audience != "registry.example.com" || claims.Subject != username || claims.ID == "" {
+					return fmt.Errorf("bad: %#v", claims)
+				}
+				if ttl := claims.Expiry.Time().Sub(claims.IssuedAt.Time()); ttl != 10*time.Minute {
+					return fmt.Errorf("bad expiry: %s", ttl)
+				}
+				if len(claims.Access) != 1 || claims.Access[0].Type != "repository" || claims.Access[0].Name != "team/app" || strings.Join(claims.Access[0].Actions, ",") != "pull,push" {
+					return fmt.Errorf("bad: %#v", claims.Access)
+				}
+				return nil
+			}),
+		},
+	})
+}
+
+func TestBackend_artifactoryCreds(t *testing.T) {
+	server := newFakeArtifactoryServer(t)
+	defer server.Close()
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepConfig(t, map[string]interface{}{
+				"type":         "artifactory",
+				"url":          server.URL + "/artifactory/",
+				"access_token": "admin-token",
+			}, false),
+			testAccStepWriteRole(t, "deploy", map[string]interface{}{
+				"groups": "readers,deployers",
+				"ttl":    "30m",
+			}, false),
+			testAccStepWriteRole(t, "docker", map[string]interface{}{
+				"repositories": "team/app",
+			}, false),
+			testAccStepReadCreds(t, "docker", true, nil),
+
+			testAccStepReadCreds(t, "deploy", false, func(resp *logical.Response) error {
+				if resp.Secret.TTL != 30*time.Minute || resp.Secret.Renewable {
+					return fmt.Errorf("bad: %#v", resp.Secret)
+				}
+				if id, err := artifactoryTokenID(resp.Data["token"].(string)); err != nil || id != "token1" {
+					return fmt.Errorf("bad token: id %q err: %v", id, err)
+				}
+				token := server.token("token1")
+				if token["username"] != resp.Data["username"] || token["scope"] != "member-of-groups:deployers,readers" || token["expires_in"] != strconv.Itoa(1800) {
+					return fmt.Errorf("bad: %#v", token)
+				}
+				return nil
+			}),
+
+			// A token revoked out of band doesn't fail the revocation
+			testAccStepReadCreds(t, "deploy", false, func(resp *logical.Response) error {
+				id, err := artifactoryTokenID(resp.Data["token"].(string))
+				if err != nil {
+					return err
+				}
+				server.l.Lock()
+				defer server.l.Unlock()
+				delete(server.tokens, id)
+				return nil
+			}),
+		},
+	})
+
+	// The leases were revoked at the end of the test case
+	if n := server.tokenCount(); n != 0 {
+		t.Fatalf("the token of the lease was not revoked: %d tokens", n)
+	}
+}
+
+func TestBackend_artifactoryCredsBadToken(t *testing.T) {
+	server := newFakeArtifactoryServer(t)
+	defer server.Close()
+
+	b, storage := getBackend(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"type":         "artifactory",
+			"url":          server.URL + "/artifactory/",
+			"access_token": "wrong",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/deploy",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"groups": "deployers",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	// Requests are rejected with the wrong admin token
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/deploy",
+		Storage:   storage,
+	})
+	if err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Fatalf("expected an authentication error, got: %v", err)
+	}
+}
+
+func testAccStepConfig(t *testing.T, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepReadConfig(t *testing.T, check logicaltest.TestCheckFunc) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "config",
+		Check: func(resp *logical.Response) error {
+			if resp == nil {
+				return fmt.Errorf("missing configuration")
+			}
+			return check(resp)
+		},
+	}
+}
+
+func testAccStepWriteRole(t *testing.T, name string, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/" + name,
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepReadCreds(t *testing.T, role string, expectFail bool, check logicaltest.TestCheckFunc) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "creds/" + role,
+		ErrorOk:   expectFail,
+		Check:     check,
+	}
+	if expectFail {
+		// Errors of the backend itself don't come with a response
+		step.Check = func(resp *logical.Response) error {
+			if resp != nil && !resp.IsError() {
+				return fmt.Errorf("expected an error, got %#v", resp)
+			}
+			return nil
+		}
+	}
+	return step
+}
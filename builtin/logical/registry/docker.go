@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base32"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// signingKeyValidity is how long the certificate of the signing key is valid
+const signingKeyValidity = 10 * 365 * 24 * time.Hour
+
+type signingKey struct {
+	keyPEM  string
+	certPEM string
+	keyID   string
+}
+
+// generateSigningKey generates the ECDSA key Docker registry tokens are signed
+// with, and the self-signed certificate registries trust it through
+func generateSigningKey(issuer string) (*signingKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: issuer},
+		NotBefore:             now.Add(-30 * time.Second),
+		NotAfter:              now.Add(signingKeyValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, err := dockerKeyID(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})),
+		keyID:   keyID,
+	}, nil
+}
+
+// dockerKeyID returns the ID registries find a trusted key by: the base32
+// encoding of the first 240 bits of the SHA-256 of the DER public key, in
+// groups of four characters separated by colons
+func dockerKeyID(pub interface{}) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	s := strings.TrimRight(base32.StdEncoding.EncodeToString(sum[:30]), "=")
+
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i += 4 {
+		if i > 0 {
+			buf.WriteByte(':')
+		}
+		buf.WriteString(s[i : i+4])
+	}
+	return buf.String(), nil
+}
+
+// dockerAccess is an access entry of a registry token
+type dockerAccess struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Actions []string `json:"actions"`
+}
+
+// dockerClaims are the claims of a registry token. The audience is a string,
+// as registries don't accept a list.
+type dockerClaims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  string          `json:"aud"`
+	Expiry    jwt.NumericDate `json:"exp"`
+	NotBefore jwt.NumericDate `json:"nbf"`
+	IssuedAt  jwt.NumericDate `json:"iat"`
+	ID        string          `json:"jti"`
+	Access    []dockerAccess  `json:"access"`
+}
+
+// signDockerToken creates a token granting actions on repositories, signed
+// with the configured key
+func signDockerToken(conf *registryConfig, subject string, repositories, actions []string, ttl time.Duration) (string, error) {
+	block, _ := pem.Decode([]byte(conf.SigningKey))
+	if block == nil {
+		return "", errors.New("invalid signing key")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.ES256,
+		Key: jose.JSONWebKey{
+			Key:   key,
+			KeyID: conf.KeyID,
+		},
+	}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := &dockerClaims{
+		Issuer:    conf.Issuer,
+		Subject:   subject,
+		Audience:  conf.Service,
+		Expiry:    jwt.NewNumericDate(now.Add(ttl)),
+		NotBefore: jwt.NewNumericDate(now.Add(-30 * time.Second)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        id,
+	}
+	for _, repository := range repositories {
+		claims.Access = append(claims.Access, dockerAccess{
+			Type:    "repository",
+			Name:    repository,
+			Actions: actions,
+		})
+	}
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
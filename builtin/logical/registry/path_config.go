@@ -0,0 +1,220 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const configPath = "config"
+
+const (
+	registryTypeDocker      = "docker"
+	registryTypeArtifactory = "artifactory"
+)
+
+var errNotConfigured = errors.New("the registry backend is not configured")
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: configPath,
+		Fields: map[string]*framework.FieldSchema{
+			"type": {
+				Type:        framework.TypeString,
+				Description: `The type of the registry, "docker" or "artifactory".`,
+			},
+
+			"issuer": {
+				Type:        framework.TypeString,
+				Description: "The issuer of the tokens of a Docker registry, which must match the issuer the registry is configured with.",
+			},
+
+			"service": {
+				Type:        framework.TypeString,
+				Description: "The name of the service of a Docker registry, which the tokens are issued for.",
+			},
+
+			"url": {
+				Type:        framework.TypeString,
+				Description: "The URL of Artifactory, such as https://example.jfrog.io/artifactory.",
+			},
+
+			"access_token": {
+				Type:        framework.TypeString,
+				Description: "The admin access token Vault creates and revokes Artifactory access tokens with.",
+			},
+		},
+
+		ExistenceCheck: b.pathConfigExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.CreateOperation: b.pathConfigWrite,
+			logical.UpdateOperation: b.pathConfigWrite,
+			logical.DeleteOperation: b.pathConfigDelete,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+// config returns the configuration, or nil if the backend is not configured
+func (b *backend) config(ctx context.Context, s logical.Storage) (*registryConfig, error) {
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	conf := &registryConfig{}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, errwrap.Wrapf("error reading registry configuration: {{err}}", err)
+	}
+	return conf, nil
+}
+
+func (b *backend) pathConfigExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return false, err
+	}
+	return conf != nil, nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, nil
+	}
+
+	// Neither the signing key nor the access token is ever returned
+	respData := map[string]interface{}{
+		"type": conf.Type,
+	}
+	switch conf.Type {
+	case registryTypeDocker:
+		respData["issuer"] = conf.Issuer
+		respData["service"] = conf.Service
+		respData["certificate"] = conf.Certificate
+		respData["key_id"] = conf.KeyID
+	case registryTypeArtifactory:
+		respData["url"] = conf.URL
+	}
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &registryConfig{}
+	}
+
+	if v, ok := data.GetOk("type"); ok {
+		conf.Type = v.(string)
+	}
+	if v, ok := data.GetOk("issuer"); ok {
+		conf.Issuer = v.(string)
+	}
+	if v, ok := data.GetOk("service"); ok {
+		conf.Service = v.(string)
+	}
+	if v, ok := data.GetOk("url"); ok {
+		conf.URL = v.(string)
+	}
+	if v, ok := data.GetOk("access_token"); ok {
+		conf.AccessToken = v.(string)
+	}
+
+	switch conf.Type {
+	case registryTypeDocker:
+		switch {
+		case conf.Issuer == "":
+			return logical.ErrorResponse("issuer is required"), nil
+		case conf.Service == "":
+			return logical.ErrorResponse("service is required"), nil
+		}
+
+		// The key is generated once, as the registry trusts its certificate
+		if conf.SigningKey == "" {
+			key, err := generateSigningKey(conf.Issuer)
+			if err != nil {
+				return nil, errwrap.Wrapf("error generating the signing key: {{err}}", err)
+			}
+			conf.SigningKey = key.keyPEM
+			conf.Certificate = key.certPEM
+			conf.KeyID = key.keyID
+		}
+
+	case registryTypeArtifactory:
+		if conf.AccessToken == "" {
+			return logical.ErrorResponse("access_token is required"), nil
+		}
+		if u, err := url.Parse(conf.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return logical.ErrorResponse("url must be an http or https URL"), nil
+		}
+
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("type must be %q or %q", registryTypeDocker, registryTypeArtifactory)), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type registryConfig struct {
+	Type string `json:"type"`
+
+	Issuer      string `json:"issuer"`
+	Service     string `json:"service"`
+	SigningKey  string `json:"signing_key"`
+	Certificate string `json:"certificate"`
+	KeyID       string `json:"key_id"`
+
+	URL         string `json:"url"`
+	AccessToken string `json:"access_token"`
+}
+
+const pathConfigHelpSyn = `
+Configure the registry tokens are created for.
+`
+
+const pathConfigHelpDesc = `
+This path configures the registry of the backend.
+
+For a Docker registry, it sets the issuer and service of the tokens. An ECDSA
+signing key is generated on the first write; the registry must be configured
+to trust the certificate returned when reading this path, with the same issuer
+and service.
+
+For Artifactory, it sets the URL and the admin access token Vault creates and
+revokes access tokens with.
+`
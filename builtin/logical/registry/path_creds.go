@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// maxUsernameLen is the longest username the tokens are created for
+const maxUsernameLen = 64
+
+func pathCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCredsRead,
+		},
+
+		HelpSynopsis:    pathCredsHelpSyn,
+		HelpDescription: pathCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", roleName)), nil
+	}
+
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, errNotConfigured
+	}
+
+	ttl, _, warnings := framework.LeaseTTLs(b.System(), role.TTL, role.MaxTTL)
+
+	username := fmt.Sprintf("vault-%s-%s-%d", roleName, req.DisplayName, time.Now().Unix())
+	if len(username) > maxUsernameLen {
+		username = username[:maxUsernameLen]
+	}
+
+	var token string
+	internalData := map[string]interface{}{
+		"role": roleName,
+	}
+	switch conf.Type {
+	case registryTypeDocker:
+		if len(role.Repositories) == 0 {
+			return logical.ErrorResponse(fmt.Sprintf("role %q has no repositories", roleName)), nil
+		}
+		token, err = signDockerToken(conf, username, role.Repositories, role.Actions, ttl)
+		if err != nil {
+			return nil, errwrap.Wrapf("error signing the registry token: {{err}}", err)
+		}
+
+	case registryTypeArtifactory:
+		if len(role.Groups) == 0 {
+			return logical.ErrorResponse(fmt.Sprintf("role %q has no groups", roleName)), nil
+		}
+		httpClient, err := logical.OutboundHTTPClient(b.System())
+		if err != nil {
+			return nil, err
+		}
+		created, err := newArtifactoryClient(conf, httpClient).createToken(ctx, username, role.Groups, ttl)
+		if err != nil {
+			return nil, err
+		}
+		tokenID, err := artifactoryTokenID(created.AccessToken)
+		if err != nil {
+			// The token can't be revoked without its ID, but still expires
+			return nil, errwrap.Wrapf("the access token could not be leased: {{err}}", err)
+		}
+		token = created.AccessToken
+		internalData["token_id"] = tokenID
+
+	default:
+		return nil, fmt.Errorf("unsupported registry type %q", conf.Type)
+	}
+
+	resp := b.Secret(SecretRegistryTokenType).Response(map[string]interface{}{
+		"username": username,
+		"token":    token,
+	}, internalData)
+
+	// The tokens expire with their lease
+	resp.Secret.TTL = ttl
+	resp.Secret.Renewable = false
+	resp.Warnings = warnings
+
+	return resp, nil
+}
+
+const pathCredsHelpSyn = `
+Create a registry token for a role.
+`
+
+const pathCredsHelpDesc = `
+This path creates a token with the permissions of the role, which expires with
+its lease. Tokens for a Docker registry are signed by the backend and can't be
+revoked before they expire; Artifactory access tokens are revoked with their
+lease.
+`
@@ -0,0 +1,222 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// repositoryNameRegex matches the names of Docker repositories, path
+// components of lowercase alphanumerics and separators
+var repositoryNameRegex = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+
+// dockerActions are the actions a registry token can grant on a repository
+var dockerActions = []string{"pull", "push", "delete", "*"}
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"repositories": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The Docker repositories the tokens grant access to, such as library/alpine.",
+			},
+
+			"actions": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `The actions the tokens grant on the Docker repositories: "pull", "push", "delete" or "*". Defaults to "pull".`,
+			},
+
+			"groups": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The Artifactory groups whose permissions the tokens have.",
+			},
+
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The default TTL of the tokens. Defaults to the default lease TTL of the mount.",
+			},
+
+			"max_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The maximum TTL of the tokens. Defaults to the maximum lease TTL of the mount.",
+			},
+		},
+
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.CreateOperation: b.pathRoleWrite,
+			logical.UpdateOperation: b.pathRoleWrite,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+// role returns a role, or nil if it doesn't exist
+func (b *backend) role(ctx context.Context, s logical.Storage, name string) (*roleEntry, error) {
+	entry, err := s.Get(ctx, "role/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role roleEntry
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, errwrap.Wrapf("error reading role: {{err}}", err)
+	}
+	return &role, nil
+}
+
+func (b *backend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"repositories": role.Repositories,
+			"actions":      role.Actions,
+			"groups":       role.Groups,
+			"ttl":          int64(role.TTL.Seconds()),
+			"max_ttl":      int64(role.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleEntry{}
+	}
+
+	if v, ok := data.GetOk("repositories"); ok {
+		role.Repositories = strutil.RemoveDuplicates(v.([]string), false)
+	}
+	if v, ok := data.GetOk("actions"); ok {
+		role.Actions = strutil.RemoveDuplicates(v.([]string), true)
+	}
+	if v, ok := data.GetOk("groups"); ok {
+		role.Groups = strutil.RemoveDuplicates(v.([]string), false)
+	}
+	if v, ok := data.GetOk("ttl"); ok {
+		role.TTL = time.Duration(v.(int)) * time.Second
+	}
+	if v, ok := data.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Duration(v.(int)) * time.Second
+	}
+
+	if len(role.Repositories) == 0 && len(role.Groups) == 0 {
+		return logical.ErrorResponse("repositories or groups must be set"), nil
+	}
+	for _, repository := range role.Repositories {
+		if !repositoryNameRegex.MatchString(repository) {
+			return logical.ErrorResponse(fmt.Sprintf("invalid repository name %q", repository)), nil
+		}
+	}
+	if len(role.Repositories) > 0 && len(role.Actions) == 0 {
+		role.Actions = []string{"pull"}
+	}
+	for _, action := range role.Actions {
+		if !strutil.StrListContains(dockerActions, action) {
+			return logical.ErrorResponse(fmt.Sprintf("invalid action %q", action)), nil
+		}
+	}
+
+	if role.TTL < 0 || role.MaxTTL < 0 {
+		return logical.ErrorResponse("ttl and max_ttl can't be negative"), nil
+	}
+	if role.MaxTTL > 0 && role.TTL > role.MaxTTL {
+		return logical.ErrorResponse("ttl can't be greater than max_ttl"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "role/"+data.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type roleEntry struct {
+	Repositories []string      `json:"repositories"`
+	Actions      []string      `json:"actions"`
+	Groups       []string      `json:"groups"`
+	TTL          time.Duration `json:"ttl"`
+	MaxTTL       time.Duration `json:"max_ttl"`
+}
+
+const pathRoleHelpSyn = `
+Manage the roles tokens are created for.
+`
+
+const pathRoleHelpDesc = `
+This path manages the roles of the backend. The tokens of a role for a Docker
+registry grant its actions on each of its repositories, and the tokens for
+Artifactory have the permissions of its groups. A role can set both, so that
+it doesn't have to change when the mount is configured for another registry.
+`
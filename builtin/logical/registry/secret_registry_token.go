@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const SecretRegistryTokenType = "registry_token"
+
+func secretRegistryToken(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretRegistryTokenType,
+		Fields: map[string]*framework.FieldSchema{
+			"username": {
+				Type:        framework.TypeString,
+				Description: "Username the token is issued for",
+			},
+			"token": {
+				Type:        framework.TypeString,
+				Description: "Registry token",
+			},
+		},
+
+		Revoke: b.secretRegistryTokenRevoke,
+	}
+}
+
+// secretRegistryTokenRevoke revokes the Artifactory access token of the
+// lease. Docker registry tokens can't be revoked and expire with the lease.
+func (b *backend) secretRegistryTokenRevoke(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	tokenID, _ := req.Secret.InternalData["token_id"].(string)
+	if tokenID == "" {
+		return nil, nil
+	}
+
+	conf, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil || conf.Type != registryTypeArtifactory {
+		return nil, errNotConfigured
+	}
+	httpClient, err := logical.OutboundHTTPClient(b.System())
+	if err != nil {
+		return nil, err
+	}
+	if err := newArtifactoryClient(conf, httpClient).revokeToken(ctx, tokenID); err != nil {
+		return nil, errwrap.Wrapf("could not revoke access token: {{err}}", err)
+	}
+	return nil, nil
+}
@@ -0,0 +1,79 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				"role/",
+				"token/",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathListAlphabets(&b),
+			pathAlphabets(&b),
+			pathListTemplates(&b),
+			pathTemplates(&b),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathEncode(&b),
+			pathDecode(&b),
+			pathMetadata(&b),
+		},
+
+		Secrets:     []*framework.Secret{},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// configLock serializes changes to alphabets, templates and roles, so that
+	// none is deleted while another that refers to it is written
+	configLock sync.RWMutex
+}
+
+// builtinPrefix is the prefix of the names of the alphabets and templates
+// every mount has, which can't be changed
+const builtinPrefix = "builtin/"
+
+// nameRegex matches the names of alphabets and templates, which may be
+// built in
+func nameRegex(name string) string {
+	return fmt.Sprintf("(?P<%s>(%s)?\\w(([\\w-.]+)?\\w)?)", name, builtinPrefix)
+}
+
+const backendHelp = `
+The transform backend performs format-preserving encryption and tokenization
+of values such as credit card numbers.
+
+Templates describe the format of the values a role transforms, and alphabets
+the characters of the parts of the values that are transformed. Roles using
+format-preserving encryption return values of the same format; roles using
+tokenization return tokens, and store the values with optional metadata so
+that the tokens can be decoded.
+`
@@ -0,0 +1,404 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+	logicaltest "github.com/hashicorp/vault/logical/testing"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	testCardNumber = "1111-2222-3333-4444"
+	testTweak      = "dHdlYWs="
+)
+
+func createBackendWithStorage(t *testing.T) (*backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func TestBackend_FPE(t *testing.T) {
+	decodeData := make(map[string]interface{})
+	tweakedData := map[string]interface{}{
+		"tweak": testTweak,
+	}
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepWriteRole(t, "payments", map[string]interface{}{
+				"template": "builtin/creditcardnumber",
+			}, false),
+			testAccStepEncode(t, "payments", map[string]interface{}{
+				"value": testCardNumber,
+			}, `^\d{4}-\d{4}-\d{4}-\d{4}$`, decodeData),
+
+			// Encoding is deterministic, and the tweak changes it
+			testAccStepEncodeCompare(t, "payments", map[string]interface{}{
+				"value": testCardNumber,
+			}, decodeData, true),
+			testAccStepEncode(t, "payments", map[string]interface{}{
+				"value": testCardNumber,
+				"tweak": testTweak,
+			}, `^\d{4}-\d{4}-\d{4}-\d{4}$`, tweakedData),
+			testAccStepEncodeCompare(t, "payments", map[string]interface{}{
+				"value": testCardNumber,
+				"tweak": testTweak,
+			}, decodeData, false),
+			testAccStepDecode(t, "payments", decodeData, testCardNumber),
+			testAccStepDecode(t, "payments", tweakedData, testCardNumber),
+
+			// Values must match the template
+			testAccStepEncodeInvalid(t, "payments", "1111-2222-3333"),
+		},
+	})
+}
+
+func TestBackend_FPECustomTemplate(t *testing.T) {
+	decodeData := make(map[string]interface{})
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepWriteAlphabet(t, "hex", "0123456789abcdef", false),
+			testAccStepWriteTemplate(t, "serial", `SN-([0-9a-f]{8})`, "hex", false),
+			testAccStepWriteRole(t, "serials", map[string]interface{}{
+				"template": "serial",
+			}, false),
+			testAccStepEncode(t, "serials", map[string]interface{}{
+				"value": "SN-deadbeef",
+			}, `^SN-[0-9a-f]{8}$`, decodeData),
+			testAccStepDecode(t, "serials", decodeData, "SN-deadbeef"),
+			testAccStepEncodeInvalid(t, "serials", "SN-DEADBEEF"),
+
+			// Alphabets and templates in use can't be deleted
+			testAccStepDeleteAlphabet(t, "hex", true),
+			testAccStepDeleteTemplate(t, "serial", true),
+			testAccStepDeleteRole(t, "serials"),
+			testAccStepDeleteTemplate(t, "serial", false),
+			testAccStepDeleteAlphabet(t, "hex", false),
+		},
+	})
+}
+
+func TestBackend_builtins(t *testing.T) {
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepWriteAlphabet(t, "builtin/numeric", "01", true),
+			testAccStepWriteTemplate(t, "builtin/creditcardnumber", `(\d{4})`, "builtin/numeric", true),
+			testAccStepDeleteAlphabet(t, "builtin/numeric", true),
+			testAccStepDeleteTemplate(t, "builtin/creditcardnumber", true),
+			testAccStepReadTemplate(t, "builtin/creditcardnumber", "builtin/numeric"),
+		},
+	})
+}
+
+func TestBackend_Tokenization(t *testing.T) {
+	decodeData := make(map[string]interface{})
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepWriteRole(t, "cards", map[string]interface{}{
+				"type":     "tokenization",
+				"template": "builtin/creditcardnumber",
+			}, false),
+			testAccStepEncode(t, "cards", map[string]interface{}{
+				"value":    "1111222233334444",
+				"metadata": "merchant=acme",
+			}, "", decodeData),
+
+			// Roles that are not convergent return a new token every time
+			testAccStepEncodeCompare(t, "cards", map[string]interface{}{
+				"value": "1111222233334444",
+			}, decodeData, false),
+			testAccStepDecode(t, "cards", decodeData, "1111222233334444"),
+			testAccStepReadMetadata(t, "cards", decodeData, "merchant", "acme"),
+
+			// Values must match the template, and tokens must exist
+			testAccStepEncodeInvalid(t, "cards", "not a card"),
+			testAccStepDecodeInvalid(t, "cards", "bogus"),
+
+			// The type of a role can't be changed
+			testAccStepWriteRole(t, "cards", map[string]interface{}{
+				"type": "fpe",
+			}, true),
+		},
+	})
+}
+
+func TestBackend_TokenizationConvergent(t *testing.T) {
+	decodeData := make(map[string]interface{})
+	logicaltest.Test(t, logicaltest.TestCase{
+		Factory: Factory,
+		Steps: []logicaltest.TestStep{
+			testAccStepWriteRole(t, "convergent", map[string]interface{}{
+				"type":       "tokenization",
+				"convergent": true,
+			}, false),
+			testAccStepEncode(t, "convergent", map[string]interface{}{
+				"value": "123-45-6789",
+			}, "", decodeData),
+			testAccStepEncodeCompare(t, "convergent", map[string]interface{}{
+				"value": "123-45-6789",
+			}, decodeData, true),
+			testAccStepDecode(t, "convergent", decodeData, "123-45-6789"),
+
+			// The convergence of a role can't be changed
+			testAccStepWriteRole(t, "convergent", map[string]interface{}{
+				"convergent": false,
+			}, true),
+		},
+	})
+}
+
+func TestBackend_TokenizationDeleteRole(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	roleReq := &logical.Request{
+		Path:      "roles/convergent",
+		Operation: logical.CreateOperation,
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"type":       "tokenization",
+			"convergent": true,
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	encodeReq := &logical.Request{
+		Path:      "encode/convergent",
+		Operation: logical.UpdateOperation,
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"value": "123-45-6789",
+		},
+	}
+	resp, err = b.HandleRequest(context.Background(), encodeReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	keys, err := storage.List(context.Background(), "token/convergent/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("expected a stored token, got %v", keys)
+	}
+
+	// Deleting a role deletes its tokens
+	roleReq.Operation = logical.DeleteOperation
+	roleReq.Data = nil
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	keys, err = storage.List(context.Background(), "token/convergent/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected tokens to be deleted, got %v", keys)
+	}
+}
+
+func testAccStepWriteAlphabet(t *testing.T, name, alphabet string, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      path.Join("alphabets", name),
+		Data: map[string]interface{}{
+			"alphabet": alphabet,
+		},
+		ErrorOk: expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepDeleteAlphabet(t *testing.T, name string, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.DeleteOperation,
+		Path:      path.Join("alphabets", name),
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepWriteTemplate(t *testing.T, name, pattern, alphabet string, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      path.Join("templates", name),
+		Data: map[string]interface{}{
+			"pattern":  pattern,
+			"alphabet": alphabet,
+		},
+		ErrorOk: expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepReadTemplate(t *testing.T, name, alphabet string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      path.Join("templates", name),
+		Check: func(resp *logical.Response) error {
+			if resp == nil {
+				return fmt.Errorf("missing template %q", name)
+			}
+			if resp.Data["alphabet"] != alphabet {
+				return fmt.Errorf("bad template: %#v", resp.Data)
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepDeleteTemplate(t *testing.T, name string, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.DeleteOperation,
+		Path:      path.Join("templates", name),
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepWriteRole(t *testing.T, name string, data map[string]interface{}, expectFail bool) logicaltest.TestStep {
+	step := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      path.Join("roles", name),
+		Data:      data,
+		ErrorOk:   expectFail,
+	}
+	if expectFail {
+		step.Check = logicaltest.TestCheckError()
+	}
+	return step
+}
+
+func testAccStepDeleteRole(t *testing.T, name string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.DeleteOperation,
+		Path:      path.Join("roles", name),
+	}
+}
+
+// testAccStepEncode encodes a value with a role and stores the encoded value
+// in decodeData, checking it against pattern if set
+func testAccStepEncode(t *testing.T, role string, data map[string]interface{}, pattern string, decodeData map[string]interface{}) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      path.Join("encode", role),
+		Data:      data,
+		Check: func(resp *logical.Response) error {
+			var d struct {
+				EncodedValue string `mapstructure:"encoded_value"`
+			}
+			if err := mapstructure.Decode(resp.Data, &d); err != nil {
+				return err
+			}
+			if d.EncodedValue == "" || d.EncodedValue == data["value"] {
+				return fmt.Errorf("bad encoded value: %q", d.EncodedValue)
+			}
+			if pattern != "" && !regexp.MustCompile(pattern).MatchString(d.EncodedValue) {
+				return fmt.Errorf("encoded value %q does not match %q", d.EncodedValue, pattern)
+			}
+			decodeData["value"] = d.EncodedValue
+			return nil
+		},
+	}
+}
+
+// testAccStepEncodeCompare encodes a value with a role and compares the
+// encoded value with the one stored in decodeData
+func testAccStepEncodeCompare(t *testing.T, role string, data map[string]interface{}, decodeData map[string]interface{}, same bool) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      path.Join("encode", role),
+		Data:      data,
+		Check: func(resp *logical.Response) error {
+			encoded := resp.Data["encoded_value"]
+			if (encoded == decodeData["value"]) != same {
+				return fmt.Errorf("encoded value %q compared with %q, expected same: %t", encoded, decodeData["value"], same)
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepEncodeInvalid(t *testing.T, role, value string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      path.Join("encode", role),
+		Data: map[string]interface{}{
+			"value": value,
+		},
+		ErrorOk: true,
+		Check:   logicaltest.TestCheckError(),
+	}
+}
+
+func testAccStepDecode(t *testing.T, role string, decodeData map[string]interface{}, value string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      path.Join("decode", role),
+		Data:      decodeData,
+		Check: func(resp *logical.Response) error {
+			if decoded := resp.Data["decoded_value"]; decoded != value {
+				return fmt.Errorf("decoded value mismatch: %q expect: %q, decodeData was %#v", decoded, value, decodeData)
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepDecodeInvalid(t *testing.T, role, value string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      path.Join("decode", role),
+		Data: map[string]interface{}{
+			"value": value,
+		},
+		ErrorOk: true,
+		Check:   logicaltest.TestCheckError(),
+	}
+}
+
+func testAccStepReadMetadata(t *testing.T, role string, decodeData map[string]interface{}, key, value string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      path.Join("metadata", role),
+		Data:      decodeData,
+		Check: func(resp *logical.Response) error {
+			metadata, ok := resp.Data["metadata"].(map[string]string)
+			if !ok || metadata[key] != value {
+				return fmt.Errorf("bad metadata: %#v", resp.Data["metadata"])
+			}
+			return nil
+		},
+	}
+}
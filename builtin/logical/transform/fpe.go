@@ -0,0 +1,185 @@
+package transform
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+const (
+	// ff1Rounds is the number of Feistel rounds of FF1
+	ff1Rounds = 10
+
+	// ff1MaxRadix is the largest radix FF1 supports
+	ff1MaxRadix = 1 << 16
+
+	// ff1MinDomain is the smallest number of possible values of a numeral
+	// string FF1 may encrypt, per NIST SP 800-38G Revision 1
+	ff1MinDomain = 1000000
+
+	// ff1MaxLength is the longest numeral string encrypted; much shorter
+	// than FF1 allows, but far longer than any field this engine protects
+	ff1MaxLength = 4096
+)
+
+// ff1 implements the FF1 format-preserving encryption mode of NIST SP
+// 800-38G with AES. Inputs and outputs are numeral strings, slices of digits
+// in the given radix, most significant first.
+type ff1 struct {
+	block cipher.Block
+	radix int
+}
+
+func newFF1(key []byte, radix int) (*ff1, error) {
+	if radix < 2 || radix > ff1MaxRadix {
+		return nil, fmt.Errorf("radix %d is not between 2 and %d", radix, ff1MaxRadix)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ff1{block: block, radix: radix}, nil
+}
+
+// minLength returns the shortest numeral string that may be encrypted
+func (f *ff1) minLength() int {
+	return int(math.Ceil(math.Log(ff1MinDomain) / math.Log(float64(f.radix))))
+}
+
+func (f *ff1) Encrypt(x []uint16, tweak []byte) ([]uint16, error) {
+	return f.cipher(x, tweak, true)
+}
+
+func (f *ff1) Decrypt(x []uint16, tweak []byte) ([]uint16, error) {
+	return f.cipher(x, tweak, false)
+}
+
+func (f *ff1) cipher(x []uint16, tweak []byte, encrypt bool) ([]uint16, error) {
+	n := len(x)
+	if n < f.minLength() || n > ff1MaxLength {
+		return nil, fmt.Errorf("value must be between %d and %d characters long", f.minLength(), ff1MaxLength)
+	}
+	for _, digit := range x {
+		if int(digit) >= f.radix {
+			return nil, errors.New("digit out of range of the radix")
+		}
+	}
+
+	u := n / 2
+	v := n - u
+	radix := big.NewInt(int64(f.radix))
+
+	// b is the number of bytes of the largest number of v digits, d the
+	// number of bytes of each round function output
+	b := (new(big.Int).Sub(pow(radix, v), big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((b+3)/4) + 4
+
+	p := make([]byte, 16)
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3], p[4], p[5] = byte(f.radix>>16), byte(f.radix>>8), byte(f.radix)
+	p[6] = 10
+	p[7] = byte(u)
+	binary.BigEndian.PutUint32(p[8:12], uint32(n))
+	binary.BigEndian.PutUint32(p[12:16], uint32(len(tweak)))
+
+	// Q is the tweak, zero padding, the round number and b bytes of the
+	// numeral of one half, padded to a multiple of the block size
+	pad := (16 - (len(tweak)+b+1)%16) % 16
+	q := make([]byte, len(tweak)+pad+1+b)
+	copy(q, tweak)
+	roundIndex := len(tweak) + pad
+
+	modU, modV := pow(radix, u), pow(radix, v)
+	a, bb := num(x[:u], radix), num(x[u:], radix)
+
+	// round returns the round function output y for round i
+	round := func(i int, half *big.Int) *big.Int {
+		q[roundIndex] = byte(i)
+		numBytes := half.Bytes()
+		tail := q[roundIndex+1:]
+		for j := range tail {
+			tail[j] = 0
+		}
+		copy(tail[b-len(numBytes):], numBytes)
+
+		r := f.prf(append(append([]byte{}, p...), q...))
+		s := make([]byte, 0, ((d+15)/16)*16)
+		s = append(s, r...)
+		for j := 1; len(s) < d; j++ {
+			block := make([]byte, 16)
+			binary.BigEndian.PutUint64(block[8:], uint64(j))
+			for k := range block {
+				block[k] ^= r[k]
+			}
+			f.block.Encrypt(block, block)
+			s = append(s, block...)
+		}
+		return new(big.Int).SetBytes(s[:d])
+	}
+
+	if encrypt {
+		for i := 0; i < ff1Rounds; i++ {
+			m := modU
+			if i%2 == 1 {
+				m = modV
+			}
+			c := new(big.Int).Add(a, round(i, bb))
+			c.Mod(c, m)
+			a, bb = bb, c
+		}
+	} else {
+		for i := ff1Rounds - 1; i >= 0; i-- {
+			m := modU
+			if i%2 == 1 {
+				m = modV
+			}
+			c := new(big.Int).Sub(bb, round(i, a))
+			c.Mod(c, m)
+			bb, a = a, c
+		}
+	}
+
+	return append(str(a, radix, u), str(bb, radix, v)...), nil
+}
+
+// prf is the CBC-MAC of the input, which is a multiple of the block size
+func (f *ff1) prf(input []byte) []byte {
+	y := make([]byte, 16)
+	for i := 0; i < len(input); i += 16 {
+		for j := 0; j < 16; j++ {
+			y[j] ^= input[i+j]
+		}
+		f.block.Encrypt(y, y)
+	}
+	return y
+}
+
+func pow(radix *big.Int, m int) *big.Int {
+	return new(big.Int).Exp(radix, big.NewInt(int64(m)), nil)
+}
+
+// num returns the number a numeral string represents
+func num(x []uint16, radix *big.Int) *big.Int {
+	ret := new(big.Int)
+	for _, digit := range x {
+		ret.Mul(ret, radix)
+		ret.Add(ret, big.NewInt(int64(digit)))
+	}
+	return ret
+}
+
+// str returns the numeral string of m digits that represents the number
+func str(x *big.Int, radix *big.Int, m int) []uint16 {
+	ret := make([]uint16, m)
+	x = new(big.Int).Set(x)
+	digit := new(big.Int)
+	for i := m - 1; i >= 0; i-- {
+		x.DivMod(x, radix, digit)
+		ret[i] = uint16(digit.Int64())
+	}
+	return ret
+}
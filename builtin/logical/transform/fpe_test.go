@@ -0,0 +1,90 @@
+package transform
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestFF1_NISTSamples(t *testing.T) {
+	const (
+		key128 = "2B7E151628AED2A6ABF7158809CF4F3C"
+		key192 = "2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F"
+		key256 = "2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94"
+	)
+	const alphabet36 = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+	// Samples from the examples of NIST SP 800-38G
+	cases := []struct {
+		key        string
+		radix      int
+		tweak      string
+		plaintext  string
+		ciphertext string
+	}{
+		{key128, 10, "", "0123456789", "2433477484"},
+		{key128, 10, "39383736353433323130", "0123456789", "6124200773"},
+		{key128, 36, "3737373770717273373737", "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{key192, 10, "", "0123456789", "2830668132"},
+		{key192, 10, "39383736353433323130", "0123456789", "2496655549"},
+		{key192, 36, "3737373770717273373737", "0123456789abcdefghi", "xbj3kv35jrawxv32ysr"},
+		{key256, 10, "", "0123456789", "6657667009"},
+		{key256, 10, "39383736353433323130", "0123456789", "1001623463"},
+		{key256, 36, "3737373770717273373737", "0123456789abcdefghi", "xs8a0azh2avyalyzuwd"},
+	}
+
+	toNumerals := func(s string) []uint16 {
+		ret := make([]uint16, len(s))
+		for i, c := range s {
+			for j, a := range alphabet36 {
+				if a == c {
+					ret[i] = uint16(j)
+				}
+			}
+		}
+		return ret
+	}
+
+	for i, tc := range cases {
+		key, _ := hex.DecodeString(tc.key)
+		tweak, _ := hex.DecodeString(tc.tweak)
+		f, err := newFF1(key, tc.radix)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ct, err := f.Encrypt(toNumerals(tc.plaintext), tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ct, toNumerals(tc.ciphertext)) {
+			t.Fatalf("sample %d: bad ciphertext %v", i+1, ct)
+		}
+
+		pt, err := f.Decrypt(ct, tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(pt, toNumerals(tc.plaintext)) {
+			t.Fatalf("sample %d: bad plaintext %v", i+1, pt)
+		}
+	}
+}
+
+func TestFF1_Invalid(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := newFF1(key, 1); err == nil {
+		t.Fatal("expected error with a radix of 1")
+	}
+
+	f, err := newFF1(key, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Encrypt([]uint16{1, 2, 3, 4, 5}, nil); err == nil {
+		t.Fatal("expected error encrypting a value below the minimum domain size")
+	}
+	if _, err := f.Encrypt([]uint16{1, 2, 3, 4, 5, 10}, nil); err == nil {
+		t.Fatal("expected error encrypting a digit out of range")
+	}
+}
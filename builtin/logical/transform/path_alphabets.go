@@ -0,0 +1,212 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// builtinAlphabets are the alphabets every mount has
+var builtinAlphabets = map[string]string{
+	"builtin/numeric":               "0123456789",
+	"builtin/alphalower":            "abcdefghijklmnopqrstuvwxyz",
+	"builtin/alphaupper":            "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"builtin/alphanumericlower":     "0123456789abcdefghijklmnopqrstuvwxyz",
+	"builtin/alphanumericupper":     "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"builtin/alphanumeric":          "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"builtin/printableasciinospace": printableASCII(),
+}
+
+func printableASCII() string {
+	var sb strings.Builder
+	for c := '!'; c <= '~'; c++ {
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+func pathListAlphabets(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "alphabets/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathAlphabetList,
+		},
+
+		HelpSynopsis:    pathAlphabetHelpSyn,
+		HelpDescription: pathAlphabetHelpDesc,
+	}
+}
+
+func pathAlphabets(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "alphabets/" + nameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the alphabet.",
+			},
+
+			"alphabet": {
+				Type:        framework.TypeString,
+				Description: "The characters of the alphabet, each used once.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathAlphabetRead,
+			logical.UpdateOperation: b.pathAlphabetUpdate,
+			logical.DeleteOperation: b.pathAlphabetDelete,
+		},
+
+		HelpSynopsis:    pathAlphabetHelpSyn,
+		HelpDescription: pathAlphabetHelpDesc,
+	}
+}
+
+// alphabet returns the characters of an alphabet, or nil if it doesn't exist
+func (b *backend) alphabet(ctx context.Context, s logical.Storage, name string) ([]rune, error) {
+	if alphabet, ok := builtinAlphabets[name]; ok {
+		return []rune(alphabet), nil
+	}
+
+	entry, err := s.Get(ctx, "alphabet/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result alphabetEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return []rune(result.Alphabet), nil
+}
+
+func (b *backend) pathAlphabetList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	alphabets, err := req.Storage.List(ctx, "alphabet/")
+	if err != nil {
+		return nil, err
+	}
+	for name := range builtinAlphabets {
+		alphabets = append(alphabets, name)
+	}
+	sort.Strings(alphabets)
+
+	return logical.ListResponse(alphabets), nil
+}
+
+func (b *backend) pathAlphabetRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.configLock.RLock()
+	defer b.configLock.RUnlock()
+
+	alphabet, err := b.alphabet(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if alphabet == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"alphabet": string(alphabet),
+		},
+	}, nil
+}
+
+func (b *backend) pathAlphabetUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if strings.HasPrefix(name, builtinPrefix) {
+		return logical.ErrorResponse("built-in alphabets can't be changed"), logical.ErrInvalidRequest
+	}
+
+	alphabet := d.Get("alphabet").(string)
+	if err := validateAlphabet([]rune(alphabet)); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	b.configLock.Lock()
+	defer b.configLock.Unlock()
+
+	entry, err := logical.StorageEntryJSON("alphabet/"+name, &alphabetEntry{
+		Alphabet: alphabet,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathAlphabetDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if strings.HasPrefix(name, builtinPrefix) {
+		return logical.ErrorResponse("built-in alphabets can't be deleted"), logical.ErrInvalidRequest
+	}
+
+	b.configLock.Lock()
+	defer b.configLock.Unlock()
+
+	// Changing the alphabet of a template would make the values it encoded
+	// impossible to decode, so alphabets in use can't be deleted
+	templates, err := req.Storage.List(ctx, "template/")
+	if err != nil {
+		return nil, err
+	}
+	for _, templateName := range templates {
+		template, err := b.template(ctx, req.Storage, templateName)
+		if err != nil {
+			return nil, err
+		}
+		if template != nil && template.Alphabet == name {
+			return logical.ErrorResponse(fmt.Sprintf("alphabet is in use by template %q", templateName)), logical.ErrInvalidRequest
+		}
+	}
+
+	return nil, req.Storage.Delete(ctx, "alphabet/"+name)
+}
+
+func validateAlphabet(alphabet []rune) error {
+	if len(alphabet) < 2 {
+		return fmt.Errorf("alphabet must have at least 2 characters")
+	}
+	if len(alphabet) > ff1MaxRadix {
+		return fmt.Errorf("alphabet must have at most %d characters", ff1MaxRadix)
+	}
+	seen := make(map[rune]bool, len(alphabet))
+	for _, c := range alphabet {
+		if seen[c] {
+			return fmt.Errorf("alphabet has character %q more than once", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+type alphabetEntry struct {
+	Alphabet string `json:"alphabet"`
+}
+
+const pathAlphabetHelpSyn = `
+Manage the alphabets of the characters that are transformed.
+`
+
+const pathAlphabetHelpDesc = `
+This path lets you manage the alphabets templates use. An alphabet is the set
+of characters the transformed parts of a value are made of, and the set of
+characters their format-preserving encryption produces.
+
+The "alphabet" parameter is the characters of the alphabet, each used once.
+Alphabets whose names start with "builtin/" are provided by the backend and
+can't be changed. Alphabets in use by templates can't be deleted.
+`
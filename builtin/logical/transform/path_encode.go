@@ -0,0 +1,445 @@
+package transform
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathEncode(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "encode/" + framework.GenericNameRegex("role"),
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"value": {
+				Type:        framework.TypeString,
+				Description: "The value to encode.",
+			},
+
+			"tweak": {
+				Type:        framework.TypeString,
+				Description: "For fpe roles, a base64-encoded tweak that must also be given to decode the value.",
+			},
+
+			"metadata": {
+				Type:        framework.TypeKVPairs,
+				Description: "For tokenization roles, metadata stored with the value, which can be read with the token.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathEncodeWrite,
+		},
+
+		HelpSynopsis:    pathEncodeHelpSyn,
+		HelpDescription: pathEncodeHelpDesc,
+	}
+}
+
+func pathDecode(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "decode/" + framework.GenericNameRegex("role"),
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"value": {
+				Type:        framework.TypeString,
+				Description: "The value to decode.",
+			},
+
+			"tweak": {
+				Type:        framework.TypeString,
+				Description: "For fpe roles, the base64-encoded tweak the value was encoded with.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathDecodeWrite,
+		},
+
+		HelpSynopsis:    pathDecodeHelpSyn,
+		HelpDescription: pathDecodeHelpDesc,
+	}
+}
+
+func (b *backend) pathEncodeWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.configLock.RLock()
+	defer b.configLock.RUnlock()
+
+	roleName := d.Get("role").(string)
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", roleName)), logical.ErrInvalidRequest
+	}
+
+	value := d.Get("value").(string)
+	if value == "" {
+		return logical.ErrorResponse("missing value"), logical.ErrInvalidRequest
+	}
+	metadata := d.Get("metadata").(map[string]string)
+
+	var encoded string
+	switch role.Type {
+	case roleTypeFPE:
+		if len(metadata) > 0 {
+			return logical.ErrorResponse("metadata is only supported by tokenization roles"), logical.ErrInvalidRequest
+		}
+		tweak, err := base64.StdEncoding.DecodeString(d.Get("tweak").(string))
+		if err != nil {
+			return logical.ErrorResponse("tweak must be base64-encoded"), logical.ErrInvalidRequest
+		}
+		encoded, err = b.transformFPE(ctx, req.Storage, role, value, tweak, true)
+		if err != nil {
+			return errorResponse(err)
+		}
+	case roleTypeTokenization:
+		if role.Template != "" {
+			if _, err := b.templateParts(ctx, req.Storage, role.Template, value); err != nil {
+				return errorResponse(err)
+			}
+		}
+		encoded, err = b.tokenize(ctx, req.Storage, roleName, role, value, metadata)
+		if err != nil {
+			return errorResponse(err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown role type %q", role.Type)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"encoded_value": encoded,
+		},
+	}, nil
+}
+
+func (b *backend) pathDecodeWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.configLock.RLock()
+	defer b.configLock.RUnlock()
+
+	roleName := d.Get("role").(string)
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", roleName)), logical.ErrInvalidRequest
+	}
+
+	value := d.Get("value").(string)
+	if value == "" {
+		return logical.ErrorResponse("missing value"), logical.ErrInvalidRequest
+	}
+
+	var decoded string
+	switch role.Type {
+	case roleTypeFPE:
+		tweak, err := base64.StdEncoding.DecodeString(d.Get("tweak").(string))
+		if err != nil {
+			return logical.ErrorResponse("tweak must be base64-encoded"), logical.ErrInvalidRequest
+		}
+		decoded, err = b.transformFPE(ctx, req.Storage, role, value, tweak, false)
+		if err != nil {
+			return errorResponse(err)
+		}
+	case roleTypeTokenization:
+		entry, err := b.tokenEntry(ctx, req.Storage, roleName, role, value)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
+		}
+		decoded = entry.value
+	default:
+		return nil, fmt.Errorf("unknown role type %q", role.Type)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"decoded_value": decoded,
+		},
+	}, nil
+}
+
+// userError is an error of the request rather than of the backend
+type userError struct {
+	error
+}
+
+// errorResponse returns an error response for user errors, and the error
+// itself otherwise
+func errorResponse(err error) (*logical.Response, error) {
+	if uerr, ok := err.(userError); ok {
+		return logical.ErrorResponse(uerr.Error()), logical.ErrInvalidRequest
+	}
+	return nil, err
+}
+
+// valueParts are the positions of the characters of the capture groups of a
+// value, along with the alphabet they must come from
+type valueParts struct {
+	groups   [][2]int
+	alphabet []rune
+}
+
+// templateParts matches a value against a template and returns its parts
+func (b *backend) templateParts(ctx context.Context, s logical.Storage, templateName, value string) (*valueParts, error) {
+	template, err := b.template(ctx, s, templateName)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, fmt.Errorf("template %q not found", templateName)
+	}
+	re, err := template.regexp()
+	if err != nil {
+		return nil, err
+	}
+	alphabet, err := b.alphabet(ctx, s, template.Alphabet)
+	if err != nil {
+		return nil, err
+	}
+	if alphabet == nil {
+		return nil, fmt.Errorf("alphabet %q not found", template.Alphabet)
+	}
+
+	match := re.FindStringSubmatchIndex(value)
+	if match == nil {
+		return nil, userError{fmt.Errorf("value does not match template %q", templateName)}
+	}
+
+	parts := &valueParts{alphabet: alphabet}
+	end := 0
+	for i := 2; i < len(match); i += 2 {
+		// Skip groups that didn't match, and nested groups, which are part
+		// of the group around them
+		if match[i] < 0 || match[i] < end {
+			continue
+		}
+		parts.groups = append(parts.groups, [2]int{match[i], match[i+1]})
+		end = match[i+1]
+	}
+	return parts, nil
+}
+
+// transformFPE encrypts or decrypts the characters of the capture groups of
+// the template of the role, leaving the rest of the value as it is
+func (b *backend) transformFPE(ctx context.Context, s logical.Storage, role *roleEntry, value string, tweak []byte, encrypt bool) (string, error) {
+	parts, err := b.templateParts(ctx, s, role.Template, value)
+	if err != nil {
+		return "", err
+	}
+
+	index := make(map[rune]uint16, len(parts.alphabet))
+	for i, c := range parts.alphabet {
+		index[c] = uint16(i)
+	}
+	var numerals []uint16
+	for _, group := range parts.groups {
+		for _, c := range value[group[0]:group[1]] {
+			n, ok := index[c]
+			if !ok {
+				return "", userError{fmt.Errorf("value has character %q, which is not in the alphabet of the template", c)}
+			}
+			numerals = append(numerals, n)
+		}
+	}
+
+	f, err := newFF1(role.Key, len(parts.alphabet))
+	if err != nil {
+		return "", err
+	}
+	var transformed []uint16
+	if encrypt {
+		transformed, err = f.Encrypt(numerals, tweak)
+	} else {
+		transformed, err = f.Decrypt(numerals, tweak)
+	}
+	if err != nil {
+		return "", userError{err}
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, group := range parts.groups {
+		sb.WriteString(value[last:group[0]])
+		for range value[group[0]:group[1]] {
+			sb.WriteRune(parts.alphabet[transformed[0]])
+			transformed = transformed[1:]
+		}
+		last = group[1]
+	}
+	sb.WriteString(value[last:])
+	return sb.String(), nil
+}
+
+// tokenPrefix is the storage prefix of the values of the tokens of a role
+func tokenPrefix(roleName string) string {
+	return "token/" + roleName + "/"
+}
+
+// tokenStorageKey returns the storage key of the value of a token, which
+// doesn't reveal the token
+func tokenStorageKey(roleName string, role *roleEntry, token string) string {
+	mac := hmac.New(sha256.New, role.HMACKey)
+	mac.Write([]byte("token:" + token))
+	return tokenPrefix(roleName) + hex.EncodeToString(mac.Sum(nil))
+}
+
+// tokenize returns a token for the value, storing the value and metadata
+// encrypted with the key of the role
+func (b *backend) tokenize(ctx context.Context, s logical.Storage, roleName string, role *roleEntry, value string, metadata map[string]string) (string, error) {
+	var tokenBytes []byte
+	if role.Convergent {
+		mac := hmac.New(sha256.New, role.HMACKey)
+		mac.Write([]byte("value:" + value))
+		tokenBytes = mac.Sum(nil)
+	} else {
+		var err error
+		if tokenBytes, err = uuid.GenerateRandomBytes(32); err != nil {
+			return "", err
+		}
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	key := tokenStorageKey(roleName, role, token)
+
+	if role.Convergent {
+		// The value is already stored; its metadata is the one given when
+		// it was first encoded
+		existing, err := s.Get(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		if existing != nil {
+			return token, nil
+		}
+	}
+
+	ciphertext, err := seal(role.Key, []byte(value), []byte(key))
+	if err != nil {
+		return "", err
+	}
+	entry, err := logical.StorageEntryJSON(key, &tokenEntry{
+		Ciphertext: ciphertext,
+		Metadata:   metadata,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// tokenEntry returns the stored value of a token, or nil if it isn't found
+func (b *backend) tokenEntry(ctx context.Context, s logical.Storage, roleName string, role *roleEntry, token string) (*tokenEntry, error) {
+	key := tokenStorageKey(roleName, role, token)
+	entry, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result tokenEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	plaintext, err := open(role.Key, result.Ciphertext, []byte(key))
+	if err != nil {
+		return nil, err
+	}
+	result.value = string(plaintext)
+	return &result, nil
+}
+
+type tokenEntry struct {
+	Ciphertext []byte            `json:"ciphertext"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+
+	value string
+}
+
+// seal encrypts the plaintext with AES-GCM, prefixing the nonce
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := uuid.GenerateRandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+const pathEncodeHelpSyn = `
+Encode a value with a role.
+`
+
+const pathEncodeHelpDesc = `
+This path encodes a value with the transformation of a role, returning the
+encoded value.
+
+For fpe roles, the value must match the template of the role, and the
+characters its capture groups match are encrypted with format-preserving
+encryption. An optional "tweak" changes the encoding, so that the same value
+encoded with different tweaks gives different encoded values; the same tweak
+must be given to decode it.
+
+For tokenization roles, the encoded value is a token, and the value is stored
+along with the optional "metadata". For convergent roles, encoding a value
+again returns the same token and keeps the metadata it was first encoded with.
+`
+
+const pathDecodeHelpSyn = `
+Decode a value encoded with a role.
+`
+
+const pathDecodeHelpDesc = `
+This path decodes a value that was encoded with a role, returning the original
+value. For fpe roles, the "tweak" the value was encoded with must be given.
+`
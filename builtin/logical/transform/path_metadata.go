@@ -0,0 +1,83 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathMetadata(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "metadata/" + framework.GenericNameRegex("role"),
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"value": {
+				Type:        framework.TypeString,
+				Description: "The token whose metadata to read.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathMetadataWrite,
+		},
+
+		HelpSynopsis:    pathMetadataHelpSyn,
+		HelpDescription: pathMetadataHelpDesc,
+	}
+}
+
+func (b *backend) pathMetadataWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.configLock.RLock()
+	defer b.configLock.RUnlock()
+
+	roleName := d.Get("role").(string)
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", roleName)), logical.ErrInvalidRequest
+	}
+	if role.Type != roleTypeTokenization {
+		return logical.ErrorResponse("metadata is only supported by tokenization roles"), logical.ErrInvalidRequest
+	}
+
+	value := d.Get("value").(string)
+	if value == "" {
+		return logical.ErrorResponse("missing value"), logical.ErrInvalidRequest
+	}
+
+	entry, err := b.tokenEntry(ctx, req.Storage, roleName, role, value)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
+	}
+
+	metadata := entry.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"metadata": metadata,
+		},
+	}, nil
+}
+
+const pathMetadataHelpSyn = `
+Read the metadata of a token.
+`
+
+const pathMetadataHelpDesc = `
+This path returns the metadata stored along with the value of a token of a
+tokenization role, without decoding the token. The token is given as "value",
+so that it isn't part of the request path.
+`
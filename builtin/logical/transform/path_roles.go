@@ -0,0 +1,256 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// roleTypeFPE roles encrypt values with format-preserving encryption
+	roleTypeFPE = "fpe"
+
+	// roleTypeTokenization roles replace values with tokens
+	roleTypeTokenization = "tokenization"
+)
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"type": {
+				Type:        framework.TypeLowerCaseString,
+				Default:     roleTypeFPE,
+				Description: `The transformation of the role, "fpe" or "tokenization". Can't be changed once the role exists.`,
+			},
+
+			"template": {
+				Type:        framework.TypeString,
+				Description: "The name of the template describing the values of the role. Required for fpe roles; for tokenization roles, values are only checked against it.",
+			},
+
+			"convergent": {
+				Type:        framework.TypeBool,
+				Description: "For tokenization roles, whether a value is always replaced with the same token. Can't be changed once the role exists.",
+			},
+		},
+
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.CreateOperation: b.pathRoleWrite,
+			logical.UpdateOperation: b.pathRoleWrite,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+// role returns a role, or nil if it doesn't exist
+func (b *backend) role(ctx context.Context, s logical.Storage, name string) (*roleEntry, error) {
+	entry, err := s.Get(ctx, "role/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result roleEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roles, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(roles), nil
+}
+
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.configLock.RLock()
+	defer b.configLock.RUnlock()
+
+	role, err := b.role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"type":       role.Type,
+			"template":   role.Template,
+			"convergent": role.Convergent,
+		},
+	}, nil
+}
+
+func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.configLock.Lock()
+	defer b.configLock.Unlock()
+
+	role, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == nil {
+		role = &roleEntry{
+			Type:       d.Get("type").(string),
+			Convergent: d.Get("convergent").(bool),
+		}
+		switch role.Type {
+		case roleTypeFPE:
+			if role.Convergent {
+				return logical.ErrorResponse("convergent is only supported by tokenization roles"), logical.ErrInvalidRequest
+			}
+		case roleTypeTokenization:
+		default:
+			return logical.ErrorResponse(fmt.Sprintf("unsupported role type %q", role.Type)), logical.ErrInvalidRequest
+		}
+
+		if role.Key, err = uuid.GenerateRandomBytes(32); err != nil {
+			return nil, err
+		}
+		if role.Type == roleTypeTokenization {
+			if role.HMACKey, err = uuid.GenerateRandomBytes(32); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// The key and the tokens of the role depend on these
+		if typeRaw, ok := d.GetOk("type"); ok && typeRaw.(string) != role.Type {
+			return logical.ErrorResponse("the type of a role can't be changed"), logical.ErrInvalidRequest
+		}
+		if convergentRaw, ok := d.GetOk("convergent"); ok && convergentRaw.(bool) != role.Convergent {
+			return logical.ErrorResponse("the convergence of a role can't be changed"), logical.ErrInvalidRequest
+		}
+	}
+
+	if templateRaw, ok := d.GetOk("template"); ok {
+		role.Template = templateRaw.(string)
+	}
+	if role.Template == "" && role.Type == roleTypeFPE {
+		return logical.ErrorResponse("missing template"), logical.ErrInvalidRequest
+	}
+	if role.Template != "" {
+		template, err := b.template(ctx, req.Storage, role.Template)
+		if err != nil {
+			return nil, err
+		}
+		if template == nil {
+			return logical.ErrorResponse(fmt.Sprintf("template %q not found", role.Template)), logical.ErrInvalidRequest
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// pathRoleDelete deletes a role along with the tokens it issued, which could
+// no longer be decoded
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.configLock.Lock()
+	defer b.configLock.Unlock()
+
+	if err := req.Storage.Delete(ctx, "role/"+name); err != nil {
+		return nil, err
+	}
+
+	tokens, err := req.Storage.List(ctx, tokenPrefix(name))
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		if err := req.Storage.Delete(ctx, tokenPrefix(name)+token); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+type roleEntry struct {
+	Type       string `json:"type"`
+	Template   string `json:"template"`
+	Convergent bool   `json:"convergent"`
+
+	// Key is the key of the format-preserving encryption of fpe roles, and of
+	// the encryption of the stored values of tokenization roles
+	Key []byte `json:"key"`
+
+	// HMACKey derives the tokens of convergent tokenization roles and the
+	// storage keys of the values of all tokenization roles
+	HMACKey []byte `json:"hmac_key,omitempty"`
+}
+
+const pathRoleHelpSyn = `
+Manage the roles that transform values.
+`
+
+const pathRoleHelpDesc = `
+This path lets you manage the roles values are encoded and decoded with.
+
+The "type" parameter is the transformation of the role. Roles of type "fpe"
+encrypt the characters the capture groups of their template match with
+format-preserving encryption, so encoded values keep the format of the
+template. Roles of type "tokenization" replace values with random tokens, and
+store the values so that the tokens can be decoded; with "convergent" set, a
+value is always replaced with the same token.
+
+Each role has its own key, generated when it is created. Deleting a role
+deletes its key and its tokens, so the values it encoded can no longer be
+decoded. Changing the template of an fpe role has the same effect on the values
+it already encoded.
+`
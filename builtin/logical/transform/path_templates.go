@@ -0,0 +1,244 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// templateTypeRegex is the type of templates that describe values with a
+// regular expression
+const templateTypeRegex = "regex"
+
+// builtinTemplates are the templates every mount has
+var builtinTemplates = map[string]*templateEntry{
+	"builtin/creditcardnumber": {
+		Type:     templateTypeRegex,
+		Pattern:  `(\d{4})[- ]?(\d{4})[- ]?(\d{4})[- ]?(\d{4})`,
+		Alphabet: "builtin/numeric",
+	},
+	"builtin/socialsecuritynumber": {
+		Type:     templateTypeRegex,
+		Pattern:  `(\d{3})[- ]?(\d{2})[- ]?(\d{4})`,
+		Alphabet: "builtin/numeric",
+	},
+}
+
+func pathListTemplates(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "templates/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathTemplateList,
+		},
+
+		HelpSynopsis:    pathTemplateHelpSyn,
+		HelpDescription: pathTemplateHelpDesc,
+	}
+}
+
+func pathTemplates(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "templates/" + nameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the template.",
+			},
+
+			"type": {
+				Type:        framework.TypeLowerCaseString,
+				Default:     templateTypeRegex,
+				Description: `The type of the template. Only "regex" is supported.`,
+			},
+
+			"pattern": {
+				Type:        framework.TypeString,
+				Description: "The regular expression values must match. The characters its capture groups match are transformed.",
+			},
+
+			"alphabet": {
+				Type:        framework.TypeString,
+				Description: "The name of the alphabet of the transformed characters.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathTemplateRead,
+			logical.UpdateOperation: b.pathTemplateUpdate,
+			logical.DeleteOperation: b.pathTemplateDelete,
+		},
+
+		HelpSynopsis:    pathTemplateHelpSyn,
+		HelpDescription: pathTemplateHelpDesc,
+	}
+}
+
+// template returns a template, or nil if it doesn't exist
+func (b *backend) template(ctx context.Context, s logical.Storage, name string) (*templateEntry, error) {
+	if template, ok := builtinTemplates[name]; ok {
+		return template, nil
+	}
+
+	entry, err := s.Get(ctx, "template/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result templateEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathTemplateList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	templates, err := req.Storage.List(ctx, "template/")
+	if err != nil {
+		return nil, err
+	}
+	for name := range builtinTemplates {
+		templates = append(templates, name)
+	}
+	sort.Strings(templates)
+
+	return logical.ListResponse(templates), nil
+}
+
+func (b *backend) pathTemplateRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.configLock.RLock()
+	defer b.configLock.RUnlock()
+
+	template, err := b.template(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"type":     template.Type,
+			"pattern":  template.Pattern,
+			"alphabet": template.Alphabet,
+		},
+	}, nil
+}
+
+func (b *backend) pathTemplateUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if strings.HasPrefix(name, builtinPrefix) {
+		return logical.ErrorResponse("built-in templates can't be changed"), logical.ErrInvalidRequest
+	}
+
+	template := &templateEntry{
+		Type:     d.Get("type").(string),
+		Pattern:  d.Get("pattern").(string),
+		Alphabet: d.Get("alphabet").(string),
+	}
+	if template.Type != templateTypeRegex {
+		return logical.ErrorResponse(fmt.Sprintf("unsupported template type %q", template.Type)), logical.ErrInvalidRequest
+	}
+	if template.Alphabet == "" {
+		return logical.ErrorResponse("missing alphabet"), logical.ErrInvalidRequest
+	}
+	if _, err := template.regexp(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	b.configLock.Lock()
+	defer b.configLock.Unlock()
+
+	alphabet, err := b.alphabet(ctx, req.Storage, template.Alphabet)
+	if err != nil {
+		return nil, err
+	}
+	if alphabet == nil {
+		return logical.ErrorResponse(fmt.Sprintf("alphabet %q not found", template.Alphabet)), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON("template/"+name, template)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathTemplateDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if strings.HasPrefix(name, builtinPrefix) {
+		return logical.ErrorResponse("built-in templates can't be deleted"), logical.ErrInvalidRequest
+	}
+
+	b.configLock.Lock()
+	defer b.configLock.Unlock()
+
+	roles, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+	for _, roleName := range roles {
+		role, err := b.role(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		if role != nil && role.Template == name {
+			return logical.ErrorResponse(fmt.Sprintf("template is in use by role %q", roleName)), logical.ErrInvalidRequest
+		}
+	}
+
+	return nil, req.Storage.Delete(ctx, "template/"+name)
+}
+
+type templateEntry struct {
+	Type     string `json:"type"`
+	Pattern  string `json:"pattern"`
+	Alphabet string `json:"alphabet"`
+}
+
+// regexp returns the regular expression of the template, anchored so that
+// it matches whole values
+func (t *templateEntry) regexp() (*regexp.Regexp, error) {
+	if t.Pattern == "" {
+		return nil, fmt.Errorf("missing pattern")
+	}
+	re, err := regexp.Compile(`^(?:` + t.Pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %s", err)
+	}
+	if re.NumSubexp() == 0 {
+		return nil, fmt.Errorf("pattern must have at least one capture group")
+	}
+	return re, nil
+}
+
+const pathTemplateHelpSyn = `
+Manage the templates that describe the format of values.
+`
+
+const pathTemplateHelpDesc = `
+This path lets you manage the templates roles use. A template describes the
+format of the values a role transforms with a regular expression; the
+characters its capture groups match are transformed, and the rest of the value
+is left as it is.
+
+The "pattern" parameter is the regular expression, which must match whole
+values and have at least one capture group. Capture groups shouldn't be nested.
+The "alphabet" parameter is the name of the alphabet of the characters the
+capture groups match. Templates whose names start with "builtin/" are provided
+by the backend and can't be changed. Templates in use by roles can't be
+deleted.
+`
@@ -3,7 +3,9 @@ package transit
 import (
 	"context"
 	"strings"
+	"sync"
 
+	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -24,6 +26,10 @@ func Backend(conf *logical.BackendConfig) *backend {
 			SealWrapStorage: []string{
 				"archive/",
 				"policy/",
+				wrappingKeyPath,
+			},
+			LocalStorage: []string{
+				keyUsagePath,
 			},
 		},
 
@@ -32,6 +38,7 @@ func Backend(conf *logical.BackendConfig) *backend {
 			// as the handler is greedy
 			b.pathConfig(),
 			b.pathRotate(),
+			b.pathImport(),
 			b.pathRewrap(),
 			b.pathKeys(),
 			b.pathListKeys(),
@@ -46,14 +53,20 @@ func Backend(conf *logical.BackendConfig) *backend {
 			b.pathVerify(),
 			b.pathBackup(),
 			b.pathRestore(),
+			b.pathWrappingKey(),
 		},
 
-		Secrets:     []*framework.Secret{},
-		Invalidate:  b.invalidate,
-		BackendType: logical.TypeLogical,
+		Secrets:    []*framework.Secret{},
+		Invalidate: b.invalidate,
+
+		// Persist the usage of the keys and rotate the keys whose
+		// auto-rotate period elapsed
+		PeriodicFunc: b.periodicFunc,
+		BackendType:  logical.TypeLogical,
 	}
 
 	b.lm = keysutil.NewLockManager(conf.System.CachingDisabled())
+	b.usage = newUsageTracker()
 
 	return &b
 }
@@ -61,6 +74,22 @@ func Backend(conf *logical.BackendConfig) *backend {
 type backend struct {
 	*framework.Backend
 	lm *keysutil.LockManager
+
+	// usage counts the operations performed with the keys
+	usage *usageTracker
+
+	// wrappingKeyLock prevents generating the wrapping key more than once
+	wrappingKeyLock sync.Mutex
+}
+
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	b.persistKeyUsage(ctx, req.Storage)
+
+	// Keys are replicated, so only the primary rotates them
+	if b.System().LocalMount() || !b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		b.autoRotateKeys(ctx, req.Storage)
+	}
+	return nil
 }
 
 func (b *backend) invalidate(_ context.Context, key string) {
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/fips"
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -290,6 +291,55 @@ func TestBackend_upsert(t *testing.T) {
 	})
 }
 
+func TestBackend_FIPSMode(t *testing.T) {
+	defer fips.SetEnabled(fips.Enabled())
+	fips.SetEnabled(true)
+
+	b, storage := createBackendWithStorage(t)
+
+	for _, keyType := range []string{"aes256-gcm96", "ecdsa-p256", "rsa-2048"} {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "keys/" + keyType,
+			Data: map[string]interface{}{
+				"type": keyType,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: resp: %#v, err: %v", keyType, resp, err)
+		}
+	}
+
+	for _, keyType := range []string{"chacha20-poly1305", "ed25519"} {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "keys/" + keyType,
+			Data: map[string]interface{}{
+				"type": keyType,
+			},
+		})
+		if err != logical.ErrInvalidRequest || resp == nil || !strings.Contains(resp.Error().Error(), "not FIPS-approved") {
+			t.Fatalf("%s: expected the key type to be refused, resp: %#v, err: %v", keyType, resp, err)
+		}
+	}
+
+	// Keys created by encrypting are refused too
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.CreateOperation,
+		Path:      "encrypt/upserted",
+		Data: map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString([]byte(testPlaintext)),
+			"type":      "chacha20-poly1305",
+		},
+	})
+	if err != logical.ErrInvalidRequest || resp == nil || !strings.Contains(resp.Error().Error(), "not FIPS-approved") {
+		t.Fatalf("expected the key type to be refused, resp: %#v, err: %v", resp, err)
+	}
+}
+
 func TestBackend_datakey(t *testing.T) {
 	dataKeyInfo := make(map[string]interface{})
 	logicaltest.Test(t, logicaltest.TestCase{
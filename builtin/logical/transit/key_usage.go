@@ -0,0 +1,213 @@
+package transit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+)
+
+const keyUsagePath = "usage/"
+
+type keyOperation int
+
+const (
+	keyOperationEncrypt keyOperation = iota
+	keyOperationDecrypt
+	keyOperationRewrap
+	keyOperationSign
+	keyOperationVerify
+	keyOperationHMAC
+)
+
+// keyUsage counts the cryptographic operations performed with a key
+type keyUsage struct {
+	Encrypt  uint64    `json:"encrypt"`
+	Decrypt  uint64    `json:"decrypt"`
+	Rewrap   uint64    `json:"rewrap"`
+	Sign     uint64    `json:"sign"`
+	Verify   uint64    `json:"verify"`
+	HMAC     uint64    `json:"hmac"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+func (u *keyUsage) record(op keyOperation, n uint64, now time.Time) {
+	switch op {
+	case keyOperationEncrypt:
+		u.Encrypt += n
+	case keyOperationDecrypt:
+		u.Decrypt += n
+	case keyOperationRewrap:
+		u.Rewrap += n
+	case keyOperationSign:
+		u.Sign += n
+	case keyOperationVerify:
+		u.Verify += n
+	case keyOperationHMAC:
+		u.HMAC += n
+	}
+	if now.After(u.LastUsed) {
+		u.LastUsed = now
+	}
+}
+
+func (u *keyUsage) add(other *keyUsage) {
+	u.Encrypt += other.Encrypt
+	u.Decrypt += other.Decrypt
+	u.Rewrap += other.Rewrap
+	u.Sign += other.Sign
+	u.Verify += other.Verify
+	u.HMAC += other.HMAC
+	if other.LastUsed.After(u.LastUsed) {
+		u.LastUsed = other.LastUsed
+	}
+}
+
+func (u *keyUsage) toMap() map[string]interface{} {
+	ret := map[string]interface{}{
+		"encrypt": u.Encrypt,
+		"decrypt": u.Decrypt,
+		"rewrap":  u.Rewrap,
+		"sign":    u.Sign,
+		"verify":  u.Verify,
+		"hmac":    u.HMAC,
+	}
+	if !u.LastUsed.IsZero() {
+		ret["last_used"] = u.LastUsed
+	}
+	return ret
+}
+
+// usageTracker accumulates the usage of the keys in memory until the periodic
+// function persists it, so that the operations do not write to storage
+type usageTracker struct {
+	// persistLock is held while the usage is persisted, so that reads and
+	// deletions see the usage of a key either in memory or in storage
+	persistLock sync.RWMutex
+
+	l       sync.Mutex
+	pending map[string]*keyUsage
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{
+		pending: make(map[string]*keyUsage),
+	}
+}
+
+// record counts n operations of the given type performed with the named key
+func (t *usageTracker) record(name string, op keyOperation, n int) {
+	if n <= 0 {
+		return
+	}
+
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	u, ok := t.pending[name]
+	if !ok {
+		u = &keyUsage{}
+		t.pending[name] = u
+	}
+	u.record(op, uint64(n), time.Now().UTC())
+}
+
+// keyUsage returns the persisted usage of the named key along with the usage
+// which is not yet persisted
+func (b *backend) keyUsage(ctx context.Context, s logical.Storage, name string) (*keyUsage, error) {
+	b.usage.persistLock.RLock()
+	defer b.usage.persistLock.RUnlock()
+
+	u, err := readKeyUsage(ctx, s, name)
+	if err != nil {
+		return nil, err
+	}
+
+	b.usage.l.Lock()
+	defer b.usage.l.Unlock()
+	if pending, ok := b.usage.pending[name]; ok {
+		u.add(pending)
+	}
+	return u, nil
+}
+
+// persistKeyUsage adds the usage accumulated in memory to the usage of the
+// keys in storage. The usage failing to be persisted is kept in memory until
+// the next attempt.
+func (b *backend) persistKeyUsage(ctx context.Context, s logical.Storage) {
+	b.usage.persistLock.Lock()
+	defer b.usage.persistLock.Unlock()
+
+	b.usage.l.Lock()
+	pending := b.usage.pending
+	b.usage.pending = make(map[string]*keyUsage, len(pending))
+	b.usage.l.Unlock()
+
+	for name, u := range pending {
+		if err := writeKeyUsage(ctx, s, name, u); err != nil {
+			b.Logger().Error("error persisting key usage", "name", name, "error", err)
+
+			b.usage.l.Lock()
+			if current, ok := b.usage.pending[name]; ok {
+				u.add(current)
+			}
+			b.usage.pending[name] = u
+			b.usage.l.Unlock()
+		}
+	}
+}
+
+// deleteKeyUsage removes the usage of the named key, in memory and in storage
+func (b *backend) deleteKeyUsage(ctx context.Context, s logical.Storage, name string) error {
+	b.usage.persistLock.Lock()
+	defer b.usage.persistLock.Unlock()
+
+	b.usage.l.Lock()
+	delete(b.usage.pending, name)
+	b.usage.l.Unlock()
+
+	return s.Delete(ctx, keyUsagePath+name)
+}
+
+func readKeyUsage(ctx context.Context, s logical.Storage, name string) (*keyUsage, error) {
+	entry, err := s.Get(ctx, keyUsagePath+name)
+	if err != nil {
+		return nil, errwrap.Wrapf("error reading key usage: {{err}}", err)
+	}
+
+	u := &keyUsage{}
+	if entry == nil {
+		return u, nil
+	}
+	if err := entry.DecodeJSON(u); err != nil {
+		return nil, errwrap.Wrapf("error decoding key usage: {{err}}", err)
+	}
+	return u, nil
+}
+
+func writeKeyUsage(ctx context.Context, s logical.Storage, name string, pending *keyUsage) error {
+	u, err := readKeyUsage(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	u.add(pending)
+
+	entry, err := logical.StorageEntryJSON(keyUsagePath+name, u)
+	if err != nil {
+		return errwrap.Wrapf("error encoding key usage: {{err}}", err)
+	}
+	return s.Put(ctx, entry)
+}
+
+// successfulItems returns the number of batch items processed without error
+func successfulItems(items []BatchResponseItem) int {
+	var n int
+	for _, item := range items {
+		if item.Error == "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,98 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestTransit_KeyUsage(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	doReq := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	readUsage := func() map[string]interface{} {
+		t.Helper()
+		return doReq(logical.ReadOperation, "keys/foo", nil).Data["usage"].(map[string]interface{})
+	}
+
+	doReq(logical.UpdateOperation, "keys/foo", nil)
+	if usage := readUsage(); usage["encrypt"] != uint64(0) || usage["last_used"] != nil {
+		t.Fatalf("bad usage: %#v", usage)
+	}
+
+	plaintext := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+	resp := doReq(logical.UpdateOperation, "encrypt/foo", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+	doReq(logical.UpdateOperation, "decrypt/foo", map[string]interface{}{
+		"ciphertext": resp.Data["ciphertext"],
+	})
+
+	// Only the successful items of batches are counted
+	doReq(logical.UpdateOperation, "encrypt/foo", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"plaintext": plaintext},
+			map[string]interface{}{"plaintext": plaintext},
+			map[string]interface{}{"plaintext": "not base64"},
+		},
+	})
+	doReq(logical.UpdateOperation, "hmac/foo", map[string]interface{}{
+		"input": plaintext,
+	})
+
+	usage := readUsage()
+	if usage["encrypt"] != uint64(3) || usage["decrypt"] != uint64(1) || usage["hmac"] != uint64(1) || usage["sign"] != uint64(0) {
+		t.Fatalf("bad usage: %#v", usage)
+	}
+	lastUsed, ok := usage["last_used"].(time.Time)
+	if !ok || time.Since(lastUsed) > time.Minute {
+		t.Fatalf("bad last_used: %#v", usage["last_used"])
+	}
+
+	// The periodic function persists the usage, which is not counted twice
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := storage.Get(context.Background(), keyUsagePath+"foo")
+	if err != nil || entry == nil {
+		t.Fatalf("expected the usage to be persisted, err: %v", err)
+	}
+	if usage := readUsage(); usage["encrypt"] != uint64(3) || usage["decrypt"] != uint64(1) {
+		t.Fatalf("bad usage: %#v", usage)
+	}
+
+	doReq(logical.UpdateOperation, "encrypt/foo", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if usage := readUsage(); usage["encrypt"] != uint64(4) || usage["decrypt"] != uint64(1) || usage["last_used"].(time.Time).Before(lastUsed) {
+		t.Fatalf("bad usage: %#v", usage)
+	}
+
+	// Deleting the key deletes its usage
+	doReq(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"deletion_allowed": true,
+	})
+	doReq(logical.DeleteOperation, "keys/foo", nil)
+	entry, err = storage.Get(context.Background(), keyUsagePath+"foo")
+	if err != nil || entry != nil {
+		t.Fatalf("expected the usage to be deleted, err: %v entry: %#v", err, entry)
+	}
+}
@@ -0,0 +1,66 @@
+package transit
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// kwpIV is the high half of the alternative initial value of AES key wrap
+// with padding, as defined in RFC 5649
+var kwpIV = []byte{0xa6, 0x59, 0x59, 0xa6}
+
+var errKWPUnwrap = errors.New("failed to unwrap key")
+
+// unwrapKWP unwraps a key wrapped with AES key wrap with padding (RFC 5649)
+// under the given key encryption key
+func unwrapKWP(kek, wrapped []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < 16 || len(wrapped)%8 != 0 {
+		return nil, errKWPUnwrap
+	}
+
+	n := len(wrapped)/8 - 1
+	a := make([]byte, 8)
+	r := make([]byte, len(wrapped)-8)
+	b := make([]byte, 16)
+
+	if n == 1 {
+		// A single block is encrypted directly with the initial value
+		block.Decrypt(b, wrapped)
+		copy(a, b[:8])
+		copy(r, b[8:])
+	} else {
+		copy(a, wrapped[:8])
+		copy(r, wrapped[8:])
+		for j := 5; j >= 0; j-- {
+			for i := n; i >= 1; i-- {
+				t := uint64(n*j + i)
+				binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a)^t)
+				copy(b[8:], r[(i-1)*8:i*8])
+				block.Decrypt(b, b)
+				copy(a, b[:8])
+				copy(r[(i-1)*8:i*8], b[8:])
+			}
+		}
+	}
+
+	if subtle.ConstantTimeCompare(a[:4], kwpIV) != 1 {
+		return nil, errKWPUnwrap
+	}
+	length := int(binary.BigEndian.Uint32(a[4:]))
+	if length <= 8*(n-1) || length > 8*n {
+		return nil, errKWPUnwrap
+	}
+	for _, c := range r[length:] {
+		if c != 0 {
+			return nil, errKWPUnwrap
+		}
+	}
+
+	return r[:length], nil
+}
@@ -2,9 +2,9 @@ package transit
 
 import (
 	"context"
-	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"io"
 
 	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/keysutil"
@@ -125,7 +125,7 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 	default:
 		return logical.ErrorResponse("invalid bit length"), logical.ErrInvalidRequest
 	}
-	_, err = rand.Read(newKey)
+	_, err = io.ReadFull(b.GetRandomReader(), newKey)
 	if err != nil {
 		return nil, err
 	}
@@ -157,6 +157,7 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 		resp.Data["plaintext"] = base64.StdEncoding.EncodeToString(newKey)
 	}
 
+	b.usage.record(p.Name, keyOperationEncrypt, 1)
 	return resp, nil
 }
 
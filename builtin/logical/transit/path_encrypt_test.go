@@ -2,6 +2,7 @@ package transit
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/vault/logical"
@@ -546,3 +547,141 @@ func TestTransit_BatchEncryptionCase12(t *testing.T) {
 		t.Fatalf("expected an error")
 	}
 }
+
+func TestTransit_AssociatedData(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   s,
+			Data:      data,
+		})
+	}
+	plaintext := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	recordA := "cmVjb3JkLWE="
+	recordB := "cmVjb3JkLWI="
+
+	resp, err := request(logical.CreateOperation, "encrypt/aead", map[string]interface{}{
+		"plaintext":       plaintext,
+		"associated_data": recordA,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	ciphertext := resp.Data["ciphertext"].(string)
+
+	// The ciphertext only decrypts with the same associated data
+	resp, err = request(logical.UpdateOperation, "decrypt/aead", map[string]interface{}{
+		"ciphertext":      ciphertext,
+		"associated_data": recordA,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Data["plaintext"] != plaintext {
+		t.Fatalf("bad plaintext: %#v", resp.Data)
+	}
+	for _, associatedData := range []string{"", recordB} {
+		resp, err = request(logical.UpdateOperation, "decrypt/aead", map[string]interface{}{
+			"ciphertext":      ciphertext,
+			"associated_data": associatedData,
+		})
+		if err == nil {
+			t.Fatalf("expected an error decrypting with associated data %q, resp:%#v", associatedData, resp)
+		}
+	}
+
+	// Rewrapping keeps the ciphertext bound to the associated data
+	if _, err := request(logical.UpdateOperation, "keys/aead/rotate", nil); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = request(logical.UpdateOperation, "rewrap/aead", map[string]interface{}{
+		"ciphertext":      ciphertext,
+		"associated_data": recordA,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	rewrapped := resp.Data["ciphertext"].(string)
+	if !strings.HasPrefix(rewrapped, "vault:v2:") {
+		t.Fatalf("bad rewrapped ciphertext: %q", rewrapped)
+	}
+	if _, err := request(logical.UpdateOperation, "decrypt/aead", map[string]interface{}{
+		"ciphertext":      rewrapped,
+		"associated_data": recordB,
+	}); err == nil {
+		t.Fatal("expected an error decrypting the rewrapped ciphertext with other associated data")
+	}
+
+	// Batch items each have their own associated data
+	resp, err = request(logical.UpdateOperation, "encrypt/aead", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"plaintext": plaintext, "associated_data": recordA},
+			map[string]interface{}{"plaintext": plaintext, "associated_data": recordB},
+			map[string]interface{}{"plaintext": plaintext, "associated_data": "not-encoded"},
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	batchResults := resp.Data["batch_results"].([]BatchResponseItem)
+	if batchResults[2].Error == "" {
+		t.Fatalf("expected an error for the invalid associated data: %#v", batchResults)
+	}
+	resp, err = request(logical.UpdateOperation, "decrypt/aead", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"ciphertext": batchResults[0].Ciphertext, "associated_data": recordA},
+			map[string]interface{}{"ciphertext": batchResults[1].Ciphertext, "associated_data": recordA},
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	batchResults = resp.Data["batch_results"].([]BatchResponseItem)
+	if batchResults[0].Plaintext != plaintext || batchResults[1].Error == "" {
+		t.Fatalf("bad batch results: %#v", batchResults)
+	}
+
+	// Convergent encryption is bound to the associated data as well
+	if _, err := request(logical.UpdateOperation, "keys/convergent", map[string]interface{}{
+		"derived":               true,
+		"convergent_encryption": true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ciphertexts := map[string]string{}
+	for _, associatedData := range []string{"", recordA, recordA, recordB} {
+		resp, err = request(logical.UpdateOperation, "encrypt/convergent", map[string]interface{}{
+			"plaintext":       plaintext,
+			"context":         "dmlzaGFsCg==",
+			"associated_data": associatedData,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		ciphertext := resp.Data["ciphertext"].(string)
+		if existing, ok := ciphertexts[associatedData]; ok && existing != ciphertext {
+			t.Fatalf("expected the same ciphertext for associated data %q", associatedData)
+		}
+		ciphertexts[associatedData] = ciphertext
+	}
+	if ciphertexts[""] == ciphertexts[recordA] || ciphertexts[recordA] == ciphertexts[recordB] {
+		t.Fatalf("expected different ciphertexts for different associated data: %#v", ciphertexts)
+	}
+
+	// RSA keys do not support associated data
+	if _, err := request(logical.UpdateOperation, "keys/rsa", map[string]interface{}{
+		"type": "rsa-2048",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = request(logical.UpdateOperation, "encrypt/rsa", map[string]interface{}{
+		"plaintext":       plaintext,
+		"associated_data": recordA,
+	})
+	if err == nil || resp == nil || !strings.Contains(resp.Error().Error(), "associated data not supported") {
+		t.Fatalf("expected an error, err:%v resp:%#v", err, resp)
+	}
+}
@@ -143,6 +143,7 @@ func (b *backend) pathHMACWrite(ctx context.Context, req *logical.Request, d *fr
 		},
 	}
 
+	b.usage.record(p.Name, keyOperationHMAC, 1)
 	p.Unlock()
 	return resp, nil
 }
@@ -232,6 +233,7 @@ func (b *backend) pathHMACVerify(ctx context.Context, req *logical.Request, d *f
 	hf.Write(input)
 	retBytes := hf.Sum(nil)
 
+	b.usage.record(p.Name, keyOperationVerify, 1)
 	p.Unlock()
 	return &logical.Response{
 		Data: map[string]interface{}{
@@ -0,0 +1,298 @@
+package transit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"hash"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/helper/keysutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// wrappingKeyPath is where the key wrapping the imported keys is stored
+	wrappingKeyPath = "wrapping_key"
+
+	// wrappingKeyBits is the size of the wrapping key
+	wrappingKeyBits = 4096
+)
+
+func (b *backend) pathWrappingKey() *framework.Path {
+	return &framework.Path{
+		Pattern: "wrapping_key$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathWrappingKeyRead,
+		},
+
+		HelpSynopsis:    pathWrappingKeyHelpSyn,
+		HelpDescription: pathWrappingKeyHelpDesc,
+	}
+}
+
+func (b *backend) pathImport() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/import",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"ciphertext": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The base64-encoded key material to import,
+wrapped as the concatenation of a 256-bit AES key
+encrypted with RSA-OAEP under the wrapping key, and
+of the key material wrapped with AES key wrap with
+padding (RFC 5649) under the AES key.`,
+			},
+
+			"hash_function": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "SHA256",
+				Description: `The hash function of the RSA-OAEP encryption of
+the AES key. Can be "SHA1", "SHA224", "SHA256",
+"SHA384" or "SHA512". Defaults to "SHA256".`,
+			},
+
+			"type": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "aes256-gcm96",
+				Description: `The type of the imported key. The same types as
+keys created by transit are supported. Defaults to
+"aes256-gcm96".`,
+			},
+
+			"derived": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `Enables key derivation mode.`,
+			},
+
+			"exportable": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `Enables the key to be exportable.`,
+			},
+
+			"allow_plaintext_backup": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables taking a backup of the named
+key in plaintext format. Once set,
+this cannot be disabled.`,
+			},
+
+			"allow_rotation": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Allows the key to be rotated, which generates
+its new versions in Vault.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathImportWrite,
+		},
+
+		HelpSynopsis:    pathImportHelpSyn,
+		HelpDescription: pathImportHelpDesc,
+	}
+}
+
+// wrappingKey returns the key wrapping the imported keys, generating it if
+// it doesn't exist yet
+func (b *backend) wrappingKey(ctx context.Context, s logical.Storage) (*rsa.PrivateKey, error) {
+	b.wrappingKeyLock.Lock()
+	defer b.wrappingKeyLock.Unlock()
+
+	entry, err := s.Get(ctx, wrappingKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		var key rsa.PrivateKey
+		if err := entry.DecodeJSON(&key); err != nil {
+			return nil, errwrap.Wrapf("failed to decode wrapping key: {{err}}", err)
+		}
+		key.Precompute()
+		return &key, nil
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, wrappingKeyBits)
+	if err != nil {
+		return nil, err
+	}
+	entry, err = logical.StorageEntryJSON(wrappingKeyPath, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (b *backend) pathWrappingKeyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key, err := b.wrappingKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	derBytes, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, errwrap.Wrapf("error marshaling public key: {{err}}", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": string(pem.EncodeToMemory(&pem.Block{
+				Type:  "PUBLIC KEY",
+				Bytes: derBytes,
+			})),
+		},
+	}, nil
+}
+
+func (b *backend) pathImportWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	ciphertext, err := base64.StdEncoding.DecodeString(d.Get("ciphertext").(string))
+	if err != nil {
+		return logical.ErrorResponse("failed to base64-decode ciphertext"), logical.ErrInvalidRequest
+	}
+
+	var hashFunc func() hash.Hash
+	switch d.Get("hash_function").(string) {
+	case "SHA1":
+		hashFunc = sha1.New
+	case "SHA224":
+		hashFunc = sha256.New224
+	case "SHA256":
+		hashFunc = sha256.New
+	case "SHA384":
+		hashFunc = sha512.New384
+	case "SHA512":
+		hashFunc = sha512.New
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unsupported hash function %q", d.Get("hash_function").(string))), logical.ErrInvalidRequest
+	}
+
+	polReq := keysutil.PolicyRequest{
+		Upsert:                   true,
+		Storage:                  req.Storage,
+		Name:                     name,
+		Derived:                  d.Get("derived").(bool),
+		Exportable:               d.Get("exportable").(bool),
+		AllowPlaintextBackup:     d.Get("allow_plaintext_backup").(bool),
+		AllowImportedKeyRotation: d.Get("allow_rotation").(bool),
+	}
+	keyType := d.Get("type").(string)
+	switch keyType {
+	case "aes256-gcm96":
+		polReq.KeyType = keysutil.KeyType_AES256_GCM96
+	case "chacha20-poly1305":
+		polReq.KeyType = keysutil.KeyType_ChaCha20_Poly1305
+	case "ecdsa-p256":
+		polReq.KeyType = keysutil.KeyType_ECDSA_P256
+	case "ed25519":
+		polReq.KeyType = keysutil.KeyType_ED25519
+	case "rsa-2048":
+		polReq.KeyType = keysutil.KeyType_RSA2048
+	case "rsa-4096":
+		polReq.KeyType = keysutil.KeyType_RSA4096
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
+	}
+
+	// Keys can only be imported as new keys, which is checked before
+	// unwrapping the key material
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		if b.System().CachingDisabled() {
+			p.Unlock()
+		}
+		return logical.ErrorResponse(fmt.Sprintf("key %q already exists", name)), logical.ErrInvalidRequest
+	}
+
+	wrappingKey, err := b.wrappingKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	wrappedAESKeySize := wrappingKey.Size()
+	if len(ciphertext) <= wrappedAESKeySize {
+		return logical.ErrorResponse("ciphertext is too short"), logical.ErrInvalidRequest
+	}
+
+	aesKey, err := rsa.DecryptOAEP(hashFunc(), rand.Reader, wrappingKey, ciphertext[:wrappedAESKeySize], nil)
+	if err != nil {
+		return logical.ErrorResponse("failed to decrypt the AES key"), logical.ErrInvalidRequest
+	}
+	if len(aesKey) != 32 {
+		return logical.ErrorResponse("the AES key must be 256 bits"), logical.ErrInvalidRequest
+	}
+	polReq.KeyMaterial, err = unwrapKWP(aesKey, ciphertext[wrappedAESKeySize:])
+	if err != nil {
+		return logical.ErrorResponse("failed to unwrap the key material"), logical.ErrInvalidRequest
+	}
+
+	p, upserted, err := b.lm.GetPolicy(ctx, polReq)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
+	}
+	if p == nil {
+		return nil, fmt.Errorf("error importing key: returned policy was nil")
+	}
+	if b.System().CachingDisabled() {
+		p.Unlock()
+	}
+	if !upserted {
+		return logical.ErrorResponse(fmt.Sprintf("key %q already exists", name)), logical.ErrInvalidRequest
+	}
+
+	return nil, nil
+}
+
+const pathWrappingKeyHelpSyn = `Returns the public key to wrap imported keys with`
+
+const pathWrappingKeyHelpDesc = `
+This path returns the public part of the 4096-bit RSA key that the key
+material imported with the keys/<name>/import path is wrapped with. The
+wrapping key is generated on first use, and its private part never leaves
+Vault.
+`
+
+const pathImportHelpSyn = `Imports an existing key as a new named key`
+
+const pathImportHelpDesc = `
+This path imports key material from outside of Vault, such as from an HSM or
+another KMS, as the first version of a new named key, so that it is never
+sent in plaintext. The key material is wrapped by generating a 256-bit AES
+key, encrypting it with RSA-OAEP under the public key returned by the
+wrapping_key path, and wrapping the key material under the AES key with AES
+key wrap with padding (RFC 5649). The "ciphertext" parameter is the base64
+encoding of the encrypted AES key followed by the wrapped key material.
+
+Symmetric key material is the raw key bytes; asymmetric key material is the
+private key in PKCS #8 DER format. Imported keys can only be rotated, which
+generates their new versions in Vault, if "allow_rotation" is set.
+`
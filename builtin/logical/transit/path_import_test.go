@@ -0,0 +1,253 @@
+package transit
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+	"golang.org/x/crypto/ed25519"
+)
+
+// wrapKWP wraps a key with AES key wrap with padding (RFC 5649)
+func wrapKWP(t *testing.T, kek, key []byte) []byte {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := make([]byte, 8)
+	copy(a, kwpIV)
+	binary.BigEndian.PutUint32(a[4:], uint32(len(key)))
+	r := make([]byte, (len(key)+7)/8*8)
+	copy(r, key)
+	n := len(r) / 8
+
+	b := make([]byte, 16)
+	if n == 1 {
+		copy(b, a)
+		copy(b[8:], r)
+		block.Encrypt(b, b)
+		return b
+	}
+	for j := 0; j <= 5; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b, a)
+			copy(b[8:], r[(i-1)*8:i*8])
+			block.Encrypt(b, b)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(b[:8])^uint64(n*j+i))
+			copy(r[(i-1)*8:i*8], b[8:])
+		}
+	}
+	return append(a, r...)
+}
+
+func TestTransit_KWP(t *testing.T) {
+	// The test vectors of RFC 5649
+	kek, _ := hex.DecodeString("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	for _, tc := range []struct {
+		key     string
+		wrapped string
+	}{
+		{"c37b7e6492584340bed12207808941155068f738", "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a"},
+		{"466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f"},
+	} {
+		key, _ := hex.DecodeString(tc.key)
+		wrapped, _ := hex.DecodeString(tc.wrapped)
+
+		if actual := wrapKWP(t, kek, key); !bytes.Equal(actual, wrapped) {
+			t.Fatalf("bad wrapped key: %x", actual)
+		}
+		actual, err := unwrapKWP(kek, wrapped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(actual, key) {
+			t.Fatalf("bad unwrapped key: %x", actual)
+		}
+
+		wrapped[len(wrapped)-1] ^= 1
+		if _, err := unwrapKWP(kek, wrapped); err == nil {
+			t.Fatal("expected an error unwrapping a modified key")
+		}
+	}
+}
+
+func TestTransit_Import(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+
+	resp, err := request(logical.ReadOperation, "wrapping_key", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode([]byte(resp.Data["public_key"].(string)))
+	if block == nil {
+		t.Fatalf("bad public key: %#v", resp.Data)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrappingKey := parsed.(*rsa.PublicKey)
+
+	wrap := func(key []byte) string {
+		aesKey := make([]byte, 32)
+		if _, err := rand.Read(aesKey); err != nil {
+			t.Fatal(err)
+		}
+		wrappedAESKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, wrappingKey, aesKey, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(append(wrappedAESKey, wrapKWP(t, aesKey, key)...))
+	}
+
+	importKey := func(name, keyType string, key []byte, data map[string]interface{}) (*logical.Response, error) {
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		data["type"] = keyType
+		data["ciphertext"] = wrap(key)
+		return request(logical.UpdateOperation, "keys/"+name+"/import", data)
+	}
+
+	// Symmetric keys are imported as they are
+	aesKey := make([]byte, 32)
+	if _, err := rand.Read(aesKey); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = importKey("aes", "aes256-gcm96", aesKey, map[string]interface{}{
+		"exportable": true,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp, err = request(logical.ReadOperation, "export/encryption-key/aes/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported := resp.Data["keys"].(map[string]string)["1"]; exported != base64.StdEncoding.EncodeToString(aesKey) {
+		t.Fatalf("bad exported key: %q", exported)
+	}
+	resp, err = request(logical.ReadOperation, "keys/aes", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["imported_key"] != true || resp.Data["allow_rotation"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Imported keys exist only once, and can't be rotated unless allowed
+	resp, err = importKey("aes", "aes256-gcm96", aesKey, nil)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error importing an existing key, got %#v", resp)
+	}
+	resp, err = request(logical.UpdateOperation, "keys/aes/rotate", nil)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error rotating an imported key, got %#v", resp)
+	}
+	resp, err = importKey("rotated", "aes256-gcm96", aesKey, map[string]interface{}{
+		"allow_rotation": true,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if _, err := request(logical.UpdateOperation, "keys/rotated/rotate", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Asymmetric keys are imported in PKCS #8 format
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = importKey("ecdsa", "ecdsa-p256", der, nil)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(ecKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = request(logical.ReadOperation, "keys/ecdsa", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	if actual := resp.Data["keys"].(map[string]map[string]interface{})["1"]["public_key"]; actual != expected {
+		t.Fatalf("bad public key: %q", actual)
+	}
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, err := asn1.Marshal(edKey.Seed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err = asn1.Marshal(struct {
+		Version    int
+		Algo       pkix.AlgorithmIdentifier
+		PrivateKey []byte
+	}{
+		Algo:       pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 101, 112}},
+		PrivateKey: seed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = importKey("ed25519", "ed25519", der, nil)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp, err = request(logical.UpdateOperation, "sign/ed25519", map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString([]byte("hello")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(resp.Data["signature"].(string)[len("vault:v1:"):])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(edPub, []byte("hello"), sig) {
+		t.Fatal("expected the signature to verify with the imported key")
+	}
+
+	// Key material must match the type
+	resp, err = importKey("mismatch", "rsa-2048", der, nil)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error importing a key of another type, got %#v", resp)
+	}
+	resp, err = importKey("mismatch", "aes256-gcm96", aesKey[:16], nil)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error importing a short key, got %#v", resp)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -88,6 +89,13 @@ key in plaintext format. Once set,
 this cannot be disabled.`,
 			},
 
+			"auto_rotate_period": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `If set, the key is rotated automatically once
+its latest version is this old. It must be at
+least an hour. 0 disables automatic rotation.`,
+			},
+
 			"context": &framework.FieldSchema{
 				Type: framework.TypeString,
 				Description: `Base64 encoded context for key derivation.
@@ -124,10 +132,14 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 	keyType := d.Get("type").(string)
 	exportable := d.Get("exportable").(bool)
 	allowPlaintextBackup := d.Get("allow_plaintext_backup").(bool)
+	autoRotatePeriod := time.Duration(d.Get("auto_rotate_period").(int)) * time.Second
 
 	if !derived && convergent {
 		return logical.ErrorResponse("convergent encryption requires derivation to be enabled"), nil
 	}
+	if err := validateAutoRotatePeriod(autoRotatePeriod); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
 
 	polReq := keysutil.PolicyRequest{
 		Upsert:               true,
@@ -137,6 +149,8 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 		Convergent:           convergent,
 		Exportable:           exportable,
 		AllowPlaintextBackup: allowPlaintextBackup,
+		AutoRotatePeriod:     autoRotatePeriod,
+		RandReader:           b.GetRandomReader(),
 	}
 	switch keyType {
 	case "aes256-gcm96":
@@ -157,7 +171,12 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 
 	p, upserted, err := b.lm.GetPolicy(ctx, polReq)
 	if err != nil {
-		return nil, err
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
 	}
 	if p == nil {
 		return nil, fmt.Errorf("error generating key: returned policy was nil")
@@ -215,9 +234,20 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 			"supports_decryption":    p.Type.DecryptionSupported(),
 			"supports_signing":       p.Type.SigningSupported(),
 			"supports_derivation":    p.Type.DerivationSupported(),
+			"imported_key":           p.Imported,
+			"auto_rotate_period":     int64(p.AutoRotatePeriod.Seconds()),
 		},
 	}
 
+	usage, err := b.keyUsage(ctx, req.Storage, p.Name)
+	if err != nil {
+		return nil, err
+	}
+	resp.Data["usage"] = usage.toMap()
+	if p.Imported {
+		resp.Data["allow_rotation"] = p.AllowImportedKeyRotation
+	}
+
 	if p.BackupInfo != nil {
 		resp.Data["backup_info"] = map[string]interface{}{
 			"time":    p.BackupInfo.Time,
@@ -334,6 +364,10 @@ func (b *backend) pathPolicyDelete(ctx context.Context, req *logical.Request, d
 		return logical.ErrorResponse(fmt.Sprintf("error deleting policy %s: %s", name, err)), err
 	}
 
+	if err := b.deleteKeyUsage(ctx, req.Storage, name); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error deleting the usage of key %s: {{err}}", name), err)
+	}
+
 	return nil, nil
 }
 
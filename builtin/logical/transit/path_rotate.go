@@ -2,7 +2,11 @@ package transit
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -45,13 +49,91 @@ func (b *backend) pathRotateWrite(ctx context.Context, req *logical.Request, d *
 		p.Lock(true)
 	}
 
+	// The new versions of imported keys would be generated in Vault
+	if p.Imported && !p.AllowImportedKeyRotation {
+		p.Unlock()
+		return logical.ErrorResponse("imported keys can't be rotated unless allow_rotation was set when importing them"), logical.ErrInvalidRequest
+	}
+
 	// Rotate the policy
-	err = p.Rotate(ctx, req.Storage)
+	err = p.RotateWithReader(ctx, req.Storage, b.GetRandomReader())
 
 	p.Unlock()
 	return nil, err
 }
 
+// minAutoRotatePeriod is the shortest auto-rotate period of a key, which
+// keeps the number of versions of a key in check
+const minAutoRotatePeriod = time.Hour
+
+func validateAutoRotatePeriod(period time.Duration) error {
+	if period != 0 && period < minAutoRotatePeriod {
+		return fmt.Errorf("auto_rotate_period must be 0 to disable automatic rotation, or at least %s", minAutoRotatePeriod)
+	}
+	return nil
+}
+
+// autoRotateKeys rotates the keys whose latest version is older than their
+// auto-rotate period
+func (b *backend) autoRotateKeys(ctx context.Context, s logical.Storage) {
+	names, err := s.List(ctx, "policy/")
+	if err != nil {
+		b.Logger().Error("error listing keys", "error", err)
+		return
+	}
+
+	for _, name := range names {
+		if err := b.autoRotateKey(ctx, s, name); err != nil {
+			metrics.IncrCounter([]string{"transit", "auto_rotate", "error"}, 1)
+			b.Logger().Error("error rotating key automatically", "name", name, "error", err)
+		}
+	}
+}
+
+func (b *backend) autoRotateKey(ctx context.Context, s logical.Storage, name string) error {
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: s,
+		Name:    name,
+	})
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(true)
+	}
+	defer p.Unlock()
+
+	if !p.AutoRotateDue(time.Now()) || (p.Imported && !p.AllowImportedKeyRotation) {
+		return nil
+	}
+
+	// The rotation is audited as a request to the rotate path, so that it
+	// can be told from the rotations clients request
+	if auditor, ok := b.System().(logical.Auditor); ok {
+		req := &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "keys/" + name + "/rotate",
+			Data: map[string]interface{}{
+				"auto_rotate_period": int64(p.AutoRotatePeriod.Seconds()),
+			},
+		}
+		if err := auditor.AuditRequest(ctx, req); err != nil {
+			return errwrap.Wrapf("failed to audit the rotation: {{err}}", err)
+		}
+	}
+
+	if err := p.RotateWithReader(ctx, s, b.GetRandomReader()); err != nil {
+		return err
+	}
+
+	metrics.IncrCounter([]string{"transit", "auto_rotate"}, 1)
+	b.Logger().Info("rotated key automatically", "name", name, "version", p.LatestVersion)
+	return nil
+}
+
 const pathRotateHelpSyn = `Rotate named encryption key`
 
 const pathRotateHelpDesc = `
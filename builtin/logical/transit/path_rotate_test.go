@@ -0,0 +1,114 @@
+package transit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/keysutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+// auditingSystemView records the requests the backend audits
+type auditingSystemView struct {
+	logical.StaticSystemView
+	requests []*logical.Request
+}
+
+func (a *auditingSystemView) AuditRequest(_ context.Context, req *logical.Request) error {
+	a.requests = append(a.requests, req)
+	return nil
+}
+
+func TestTransit_AutoRotate(t *testing.T) {
+	sysView := &auditingSystemView{
+		StaticSystemView: logical.StaticSystemView{
+			DefaultLeaseTTLVal: 24 * time.Hour,
+			MaxLeaseTTLVal:     2 * 24 * time.Hour,
+		},
+	}
+	storage := &logical.InmemStorage{}
+	conf := &logical.BackendConfig{
+		StorageView: storage,
+		System:      sysView,
+	}
+	b := Backend(conf)
+	if err := b.Backend.Setup(context.Background(), conf); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+		Data: map[string]interface{}{
+			"auto_rotate_period": "30m",
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected a period under an hour to be rejected: %v %#v", err, resp)
+	}
+
+	req.Data["auto_rotate_period"] = "24h"
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	latestVersion := func() int {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.ReadOperation,
+			Path:      "keys/foo",
+		})
+		if err != nil || resp == nil {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		if resp.Data["auto_rotate_period"] != int64(86400) {
+			t.Fatalf("bad: %#v", resp.Data)
+		}
+		return resp.Data["latest_version"].(int)
+	}
+
+	// The key is not due yet
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if version := latestVersion(); version != 1 || len(sysView.requests) != 0 {
+		t.Fatalf("expected no rotation, got version %d and %d audited requests", version, len(sysView.requests))
+	}
+
+	// Age the latest version past the period
+	p, _, err := b.lm.GetPolicy(context.Background(), keysutil.PolicyRequest{
+		Storage: storage,
+		Name:    "foo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := p.Keys["1"]
+	entry.CreationTime = time.Now().Add(-25 * time.Hour)
+	p.Keys["1"] = entry
+	if err := p.Persist(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if version := latestVersion(); version != 2 {
+		t.Fatalf("expected the key to be rotated, got version %d", version)
+	}
+	if len(sysView.requests) != 1 || sysView.requests[0].Path != "keys/foo/rotate" {
+		t.Fatalf("bad: %#v", sysView.requests)
+	}
+
+	// The new version is not due
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if version := latestVersion(); version != 2 {
+		t.Fatalf("expected no rotation, got version %d", version)
+	}
+}
@@ -248,6 +248,7 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 		resp.Data["public_key"] = sig.PublicKey
 	}
 
+	b.usage.record(p.Name, keyOperationSign, 1)
 	p.Unlock()
 	return resp, nil
 }
@@ -355,6 +356,7 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 		},
 	}
 
+	b.usage.record(p.Name, keyOperationVerify, 1)
 	p.Unlock()
 	return resp, nil
 }
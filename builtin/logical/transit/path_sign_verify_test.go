@@ -323,6 +323,7 @@ func TestTransit_SignVerify_ED25519(t *testing.T) {
 			}
 
 			keyReadReq := &logical.Request{
+				Storage:   storage,
 				Operation: logical.ReadOperation,
 				Path:      "keys/" + postpath,
 			}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/kr/pretty"
 	"github.com/mitchellh/cli"
@@ -16,16 +18,23 @@ import (
 	"github.com/hashicorp/errwrap"
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/command/agent/auth"
+	"github.com/hashicorp/vault/command/agent/auth/approle"
 	"github.com/hashicorp/vault/command/agent/auth/aws"
 	"github.com/hashicorp/vault/command/agent/auth/azure"
 	"github.com/hashicorp/vault/command/agent/auth/gcp"
 	"github.com/hashicorp/vault/command/agent/auth/jwt"
 	"github.com/hashicorp/vault/command/agent/auth/kubernetes"
-	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/command/agent/cache"
+	agentConfig "github.com/hashicorp/vault/command/agent/config"
 	"github.com/hashicorp/vault/command/agent/sink"
 	"github.com/hashicorp/vault/command/agent/sink/file"
+	"github.com/hashicorp/vault/command/agent/sink/inmem"
+	"github.com/hashicorp/vault/command/agent/template"
+	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/helper/gated-writer"
 	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/helper/parseutil"
+	vaulthttp "github.com/hashicorp/vault/http"
 	"github.com/hashicorp/vault/version"
 )
 
@@ -46,8 +55,10 @@ type AgentCommand struct {
 
 	startedCh chan (struct{}) // for tests
 
-	flagConfigs  []string
-	flagLogLevel string
+	flagConfigs         []string
+	flagAnnotations     string
+	flagLogLevel        string
+	flagExitAfterRender bool
 
 	flagTestVerifyOnly bool
 	flagCombineLogs    bool
@@ -68,6 +79,11 @@ Usage: vault agent [options]
 
       $ vault agent -config=/etc/vault/config.hcl
 
+  Start an agent in a Kubernetes pod, configured by the annotations of the pod
+  as projected by the downward API:
+
+      $ vault agent -annotations=/etc/podinfo/annotations
+
   For a full list of examples, please see the documentation.
 
 ` + c.Flags().Help()
@@ -90,6 +106,24 @@ func (c *AgentCommand) Flags() *FlagSets {
 			"contain only agent directives.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "annotations",
+		Target:     &c.flagAnnotations,
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to a file holding the annotations of the Kubernetes pod " +
+			"the agent runs in, as projected by the downward API. The agent " +
+			"authenticates with the role of the pod and renders its secrets " +
+			"into the secret volume. Cannot be used with -config.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "exit-after-render",
+		Target:  &c.flagExitAfterRender,
+		Default: false,
+		Usage: "Exit once the templates have been rendered, as when running " +
+			"as an init container. This overrides the configuration.",
+	})
+
 	f.StringVar(&StringVar{
 		Name:       "log-level",
 		Target:     &c.flagLogLevel,
@@ -174,16 +208,30 @@ func (c *AgentCommand) Run(args []string) int {
 	}
 
 	// Validation
-	if len(c.flagConfigs) != 1 {
+	switch {
+	case c.flagAnnotations != "" && len(c.flagConfigs) > 0:
+		c.UI.Error("Cannot specify both -config and -annotations")
+		return 1
+	case c.flagAnnotations == "" && len(c.flagConfigs) != 1:
 		c.UI.Error("Must specify exactly one config path using -config")
 		return 1
 	}
 
 	// Load the configuration
-	config, err := config.LoadConfig(c.flagConfigs[0], c.logger)
-	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfigs[0], err))
-		return 1
+	var config *agentConfig.Config
+	var err error
+	if c.flagAnnotations != "" {
+		config, err = agentConfig.LoadAnnotations(c.flagAnnotations, c.logger)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error loading configuration from the annotations in %s: %s", c.flagAnnotations, err))
+			return 1
+		}
+	} else {
+		config, err = agentConfig.LoadConfig(c.flagConfigs[0], c.logger)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfigs[0], err))
+			return 1
+		}
 	}
 
 	// Ensure at least one config was found.
@@ -193,8 +241,19 @@ func (c *AgentCommand) Run(args []string) int {
 				"-config flag."))
 		return 1
 	}
-	if config.AutoAuth == nil {
-		c.UI.Error("No auto_auth block found in config file")
+	if c.flagExitAfterRender {
+		if len(config.Templates) == 0 {
+			c.UI.Error("-exit-after-render requires templates")
+			return 1
+		}
+		if config.Cache != nil {
+			c.UI.Error("-exit-after-render cannot be used with a cache block")
+			return 1
+		}
+		config.ExitAfterRender = true
+	}
+	if config.AutoAuth == nil && config.Cache == nil {
+		c.UI.Error("No auto_auth or cache block found in config file")
 		return 1
 	}
 
@@ -249,58 +308,158 @@ func (c *AgentCommand) Run(args []string) int {
 	}
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
 
 	var sinks []*sink.SinkConfig
-	for _, sc := range config.AutoAuth.Sinks {
-		switch sc.Type {
-		case "file":
-			config := &sink.SinkConfig{
-				Logger:  c.logger.Named("sink.file"),
-				Config:  sc.Config,
-				Client:  client,
-				WrapTTL: sc.WrapTTL,
-				DHType:  sc.DHType,
-				DHPath:  sc.DHPath,
-				AAD:     sc.AAD,
-			}
-			s, err := file.NewFileSink(config)
-			if err != nil {
-				c.UI.Error(errwrap.Wrapf("Error creating file sink: {{err}}", err).Error())
+	var method auth.AuthMethod
+	if config.AutoAuth != nil {
+		for _, sc := range config.AutoAuth.Sinks {
+			switch sc.Type {
+			case "file":
+				config := &sink.SinkConfig{
+					Logger:  c.logger.Named("sink.file"),
+					Config:  sc.Config,
+					Client:  client,
+					WrapTTL: sc.WrapTTL,
+					DHType:  sc.DHType,
+					DHPath:  sc.DHPath,
+					AAD:     sc.AAD,
+				}
+				s, err := file.NewFileSink(config)
+				if err != nil {
+					c.UI.Error(errwrap.Wrapf("Error creating file sink: {{err}}", err).Error())
+					return 1
+				}
+				config.Sink = s
+				sinks = append(sinks, config)
+			default:
+				c.UI.Error(fmt.Sprintf("Unknown sink type %q", sc.Type))
 				return 1
 			}
-			config.Sink = s
-			sinks = append(sinks, config)
+		}
+
+		authConfig := &auth.AuthConfig{
+			Logger:    c.logger.Named(fmt.Sprintf("auth.%s", config.AutoAuth.Method.Type)),
+			MountPath: config.AutoAuth.Method.MountPath,
+			WrapTTL:   config.AutoAuth.Method.WrapTTL,
+			Config:    config.AutoAuth.Method.Config,
+		}
+		switch config.AutoAuth.Method.Type {
+		case "approle":
+			method, err = approle.NewApproleAuthMethod(authConfig)
+		case "aws":
+			method, err = aws.NewAWSAuthMethod(authConfig)
+		case "azure":
+			method, err = azure.NewAzureAuthMethod(authConfig)
+		case "gcp":
+			method, err = gcp.NewGCPAuthMethod(authConfig)
+		case "jwt":
+			method, err = jwt.NewJWTAuthMethod(authConfig)
+		case "kubernetes":
+			method, err = kubernetes.NewKubernetesAuthMethod(authConfig)
 		default:
-			c.UI.Error(fmt.Sprintf("Unknown sink type %q", sc.Type))
+			c.UI.Error(fmt.Sprintf("Unknown auth method %q", config.AutoAuth.Method.Type))
+			return 1
+		}
+		if err != nil {
+			c.UI.Error(errwrap.Wrapf(fmt.Sprintf("Error creating %s auth method: {{err}}", config.AutoAuth.Method.Type), err).Error())
 			return 1
 		}
 	}
 
-	var method auth.AuthMethod
-	authConfig := &auth.AuthConfig{
-		Logger:    c.logger.Named(fmt.Sprintf("auth.%s", config.AutoAuth.Method.Type)),
-		MountPath: config.AutoAuth.Method.MountPath,
-		WrapTTL:   config.AutoAuth.Method.WrapTTL,
-		Config:    config.AutoAuth.Method.Config,
-	}
-	switch config.AutoAuth.Method.Type {
-	case "aws":
-		method, err = aws.NewAWSAuthMethod(authConfig)
-	case "azure":
-		method, err = azure.NewAzureAuthMethod(authConfig)
-	case "gcp":
-		method, err = gcp.NewGCPAuthMethod(authConfig)
-	case "jwt":
-		method, err = jwt.NewJWTAuthMethod(authConfig)
-	case "kubernetes":
-		method, err = kubernetes.NewKubernetesAuthMethod(authConfig)
-	default:
-		c.UI.Error(fmt.Sprintf("Unknown auth method %q", config.AutoAuth.Method.Type))
-		return 1
+	// Start the caching proxy on the configured listeners
+	if config.Cache != nil {
+		cacheLogger := c.logger.Named("cache")
+
+		apiProxy, err := cache.NewAPIProxy(&cache.APIProxyConfig{
+			Client: client,
+			Logger: cacheLogger.Named("apiproxy"),
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating API proxy: %v", err))
+			return 1
+		}
+
+		leaseCache, err := cache.NewLeaseCache(&cache.LeaseCacheConfig{
+			Proxier: apiProxy,
+			Client:  client,
+			Logger:  cacheLogger.Named("leasecache"),
+			BaseCtx: ctx,
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating lease cache: %v", err))
+			return 1
+		}
+
+		// Requests without a token are made with the auto-auth token, which
+		// is kept in an in-memory sink
+		var tokenReader sink.SinkReader
+		if config.Cache.UseAutoAuthToken {
+			inmemConfig := &sink.SinkConfig{
+				Logger: cacheLogger.Named("sink.inmem"),
+			}
+			s, err := inmem.New(inmemConfig)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error creating in-memory sink: %v", err))
+				return 1
+			}
+			inmemConfig.Sink = s
+			sinks = append(sinks, inmemConfig)
+			tokenReader = s.(sink.SinkReader)
+		}
+
+		handler := cache.Handler(cacheLogger, leaseCache, tokenReader)
+		for _, lnConfig := range config.Listeners {
+			ln, _, _, err := server.NewListener(lnConfig.Type, lnConfig.Config, c.logWriter, c.UI)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error starting listener of type %s: %v", lnConfig.Type, err))
+				return 1
+			}
+			defer ln.Close()
+
+			var lnHandler http.Handler = handler
+			if valRaw, ok := lnConfig.Config["require_request_header"]; ok {
+				requireRequestHeader, err := parseutil.ParseBool(valRaw)
+				if err != nil {
+					c.UI.Error(fmt.Sprintf("Could not parse require_request_header value %v", valRaw))
+					return 1
+				}
+				if requireRequestHeader {
+					lnHandler = vaulthttp.WrapRequireRequestHeader(handler)
+				}
+			}
+
+			srv := &http.Server{
+				Handler:           lnHandler,
+				ReadHeaderTimeout: 10 * time.Second,
+				ReadTimeout:       30 * time.Second,
+				IdleTimeout:       5 * time.Minute,
+			}
+			go srv.Serve(ln)
+
+			c.logger.Info("serving the cache", "type", lnConfig.Type, "address", ln.Addr().String())
+		}
 	}
-	if err != nil {
-		c.UI.Error(errwrap.Wrapf(fmt.Sprintf("Error creating %s auth method: {{err}}", config.AutoAuth.Method.Type), err).Error())
-		return 1
+
+	// Render the templates with the auto-auth token, which the template
+	// server is handed as a sink
+	var ts *template.Server
+	if len(config.Templates) > 0 {
+		ts, err = template.NewServer(&template.ServerConfig{
+			Logger:          c.logger.Named("template.server"),
+			Client:          client,
+			Templates:       config.Templates,
+			ExitAfterRender: config.ExitAfterRender,
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating template server: %v", err))
+			return 1
+		}
+		sinks = append(sinks, &sink.SinkConfig{
+			Logger: c.logger.Named("sink.template"),
+			Sink:   ts,
+		})
+		go ts.Run(ctx)
 	}
 
 	// Output the header that the server has started
@@ -314,20 +473,27 @@ func (c *AgentCommand) Run(args []string) int {
 	default:
 	}
 
-	ss := sink.NewSinkServer(&sink.SinkServerConfig{
-		Logger:        c.logger.Named("sink.server"),
-		Client:        client,
-		ExitAfterAuth: config.ExitAfterAuth,
-	})
-
-	ah := auth.NewAuthHandler(&auth.AuthHandlerConfig{
-		Logger: c.logger.Named("auth.handler"),
-		Client: c.client,
-	})
-
-	// Start things running
-	go ah.Run(ctx, method)
-	go ss.Run(ctx, ah.OutputCh, sinks)
+	var ah *auth.AuthHandler
+	var ss *sink.SinkServer
+	var sinksDoneCh chan struct{}
+	if method != nil {
+		ss = sink.NewSinkServer(&sink.SinkServerConfig{
+			Logger:        c.logger.Named("sink.server"),
+			Client:        client,
+			ExitAfterAuth: config.ExitAfterAuth,
+		})
+
+		ah = auth.NewAuthHandler(&auth.AuthHandlerConfig{
+			Logger:  c.logger.Named("auth.handler"),
+			Client:  c.client,
+			WrapTTL: config.AutoAuth.Method.WrapTTL,
+		})
+
+		// Start things running
+		go ah.Run(ctx, method)
+		go ss.Run(ctx, ah.OutputCh, sinks)
+		sinksDoneCh = ss.DoneCh
+	}
 
 	// Release the log gate.
 	c.logGate.Flush()
@@ -344,15 +510,28 @@ func (c *AgentCommand) Run(args []string) int {
 		}
 	}()
 
+	var templatesDoneCh chan struct{}
+	if ts != nil && config.ExitAfterRender {
+		templatesDoneCh = ts.DoneCh
+	}
+
 	select {
-	case <-ss.DoneCh:
+	case <-sinksDoneCh:
 		// This will happen if we exit-on-auth
 		c.logger.Info("sinks finished, exiting")
+	case <-templatesDoneCh:
+		// This will happen if we exit once the templates are rendered
+		c.logger.Info("templates rendered, exiting")
 	case <-c.ShutdownCh:
 		c.UI.Output("==> Vault agent shutdown triggered")
 		cancelFunc()
-		<-ah.DoneCh
-		<-ss.DoneCh
+		if ah != nil {
+			<-ah.DoneCh
+			<-ss.DoneCh
+		}
+		if ts != nil {
+			<-ts.DoneCh
+		}
 	}
 
 	return 0
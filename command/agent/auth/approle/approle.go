@@ -0,0 +1,144 @@
+package approle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/auth"
+	"github.com/hashicorp/vault/helper/parseutil"
+)
+
+type approleMethod struct {
+	logger    hclog.Logger
+	mountPath string
+
+	roleIDFilePath                 string
+	secretIDFilePath               string
+	removeSecretIDFileAfterReading bool
+
+	// The secret ID is kept once read since its file may be removed
+	l        sync.Mutex
+	secretID string
+}
+
+func NewApproleAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
+	if conf == nil {
+		return nil, errors.New("empty config")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("empty config data")
+	}
+
+	a := &approleMethod{
+		logger:                         conf.Logger,
+		mountPath:                      conf.MountPath,
+		removeSecretIDFileAfterReading: true,
+	}
+
+	roleIDFilePathRaw, ok := conf.Config["role_id_file_path"]
+	if !ok {
+		return nil, errors.New("missing 'role_id_file_path' value")
+	}
+	a.roleIDFilePath, ok = roleIDFilePathRaw.(string)
+	if !ok {
+		return nil, errors.New("could not convert 'role_id_file_path' config value to string")
+	}
+	if a.roleIDFilePath == "" {
+		return nil, errors.New("'role_id_file_path' value is empty")
+	}
+
+	secretIDFilePathRaw, ok := conf.Config["secret_id_file_path"]
+	if !ok {
+		return nil, errors.New("missing 'secret_id_file_path' value")
+	}
+	a.secretIDFilePath, ok = secretIDFilePathRaw.(string)
+	if !ok {
+		return nil, errors.New("could not convert 'secret_id_file_path' config value to string")
+	}
+	if a.secretIDFilePath == "" {
+		return nil, errors.New("'secret_id_file_path' value is empty")
+	}
+
+	if removeRaw, ok := conf.Config["remove_secret_id_file_after_reading"]; ok {
+		remove, err := parseutil.ParseBool(removeRaw)
+		if err != nil {
+			return nil, errwrap.Wrapf("error parsing 'remove_secret_id_file_after_reading' value: {{err}}", err)
+		}
+		a.removeSecretIDFileAfterReading = remove
+	}
+
+	return a, nil
+}
+
+func (a *approleMethod) Authenticate(ctx context.Context, client *api.Client) (string, map[string]interface{}, error) {
+	a.logger.Trace("beginning authentication")
+
+	roleID, err := ioutil.ReadFile(a.roleIDFilePath)
+	if err != nil {
+		return "", nil, errwrap.Wrapf("error reading role ID file: {{err}}", err)
+	}
+	if len(strings.TrimSpace(string(roleID))) == 0 {
+		return "", nil, errors.New("role ID file is empty")
+	}
+
+	secretID, err := a.readSecretID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("%s/login", a.mountPath), map[string]interface{}{
+		"role_id":   strings.TrimSpace(string(roleID)),
+		"secret_id": secretID,
+	}, nil
+}
+
+// readSecretID returns the secret ID, preferring a new one written to the
+// secret ID file over the one read previously
+func (a *approleMethod) readSecretID() (string, error) {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	content, err := ioutil.ReadFile(a.secretIDFilePath)
+	switch {
+	case err == nil:
+	case os.IsNotExist(err) && a.secretID != "":
+		return a.secretID, nil
+	default:
+		return "", errwrap.Wrapf("error reading secret ID file: {{err}}", err)
+	}
+
+	secretID := strings.TrimSpace(string(content))
+	if secretID == "" {
+		if a.secretID != "" {
+			return a.secretID, nil
+		}
+		return "", errors.New("secret ID file is empty")
+	}
+	a.secretID = secretID
+
+	if a.removeSecretIDFileAfterReading {
+		if err := os.Remove(a.secretIDFilePath); err != nil {
+			a.logger.Error("error removing secret ID file after reading", "error", err)
+		}
+	}
+
+	return secretID, nil
+}
+
+func (a *approleMethod) NewCreds() chan struct{} {
+	return nil
+}
+
+func (a *approleMethod) CredSuccess() {
+}
+
+func (a *approleMethod) Shutdown() {
+}
@@ -5,9 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"strings"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/command/agent/auth"
@@ -56,7 +56,7 @@ func (k *kubernetesMethod) Authenticate(ctx context.Context, client *api.Client)
 	k.logger.Trace("beginning authentication")
 	content, err := ioutil.ReadFile(serviceAccountFile)
 	if err != nil {
-		log.Fatal(err)
+		return "", nil, errwrap.Wrapf("error reading service account token: {{err}}", err)
 	}
 
 	return fmt.Sprintf("%s/login", k.mountPath), map[string]interface{}{
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+)
+
+// APIProxy is a Proxier sending requests on to Vault
+type APIProxy struct {
+	client *api.Client
+	logger hclog.Logger
+}
+
+type APIProxyConfig struct {
+	Client *api.Client
+	Logger hclog.Logger
+}
+
+func NewAPIProxy(config *APIProxyConfig) (Proxier, error) {
+	if config.Client == nil {
+		return nil, errors.New("nil API client")
+	}
+	return &APIProxy{
+		client: config.Client,
+		logger: config.Logger,
+	}, nil
+}
+
+func (ap *APIProxy) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	client, err := ap.client.Clone()
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(req.Token)
+
+	fwReq := client.NewRequest(req.Request.Method, req.Request.URL.Path)
+	fwReq.BodyBytes = req.RequestBody
+	fwReq.Params = req.Request.URL.Query()
+
+	// Pass on the headers of the client, such as those asking for the
+	// response to be wrapped or naming the namespace, rather than those the
+	// agent would set itself
+	fwReq.Headers = make(http.Header, len(req.Request.Header))
+	for k, vals := range req.Request.Header {
+		fwReq.Headers[k] = append([]string(nil), vals...)
+	}
+	fwReq.Headers.Del("X-Vault-Token")
+	fwReq.WrapTTL = ""
+	if req.Request.Header.Get("X-Vault-Namespace") != "" {
+		fwReq.Namespace = ""
+	}
+
+	// Error responses are passed on to the client as they are
+	resp, err := client.RawRequestWithContext(ctx, fwReq)
+	if resp == nil && err != nil {
+		return nil, err
+	}
+
+	return NewSendResponse(resp, nil)
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hashicorp/errwrap"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/sink"
+)
+
+// maxRequestSize is the maximum accepted size of a proxied request body
+const maxRequestSize = 32 * 1024 * 1024
+
+// Handler returns an http.Handler passing the requests of local clients to
+// the proxier. If a token reader is given, its token is used for requests
+// that are made without one.
+func Handler(logger hclog.Logger, proxier Proxier, tokenReader sink.SinkReader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Trace("received request", "method", r.Method, "path", r.URL.Path)
+
+		token := r.Header.Get("X-Vault-Token")
+		if token == "" && tokenReader != nil {
+			token = tokenReader.Token()
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, errwrap.Wrapf("failed to read request body: {{err}}", err))
+			return
+		}
+		if len(body) == 0 {
+			body = nil
+		}
+
+		resp, err := proxier.Send(r.Context(), &SendRequest{
+			Token:       token,
+			Request:     r,
+			RequestBody: body,
+		})
+		if err != nil {
+			logger.Error("failed to proxy request", "method", r.Method, "path", r.URL.Path, "error", err)
+			respondError(w, http.StatusBadGateway, errwrap.Wrapf("failed to get the response: {{err}}", err))
+			return
+		}
+
+		for k, vals := range resp.Response.Header {
+			for _, v := range vals {
+				w.Header().Add(k, v)
+			}
+		}
+		w.WriteHeader(resp.Response.StatusCode)
+		w.Write(resp.ResponseBody)
+	})
+}
+
+func respondError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	resp := &api.ErrorResponse{Errors: []string{err.Error()}}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,366 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/jsonutil"
+)
+
+// LeaseCache is a Proxier caching the responses to reads and logins that
+// carry a lease or a new token, so that clients repeating a request are given
+// the same credentials rather than new ones. Renewable leases and tokens are
+// renewed by the cache. Cached responses are dropped once their lease expires, or
+// when the lease or the token they were requested with is revoked through
+// the agent.
+type LeaseCache struct {
+	proxier Proxier
+	client  *api.Client
+	logger  hclog.Logger
+	baseCtx context.Context
+
+	l       sync.RWMutex
+	entries map[string]*cacheEntry
+}
+
+type LeaseCacheConfig struct {
+	Proxier Proxier
+	Client  *api.Client
+	Logger  hclog.Logger
+	BaseCtx context.Context
+}
+
+// cacheEntry is a cached response along with what is needed to find it when
+// its lease or token goes away
+type cacheEntry struct {
+	// token is the token the request was made with
+	token string
+
+	// leaseID is the lease of the response, and issuedToken the token it
+	// carries, if any
+	leaseID     string
+	issuedToken string
+
+	statusCode int
+	header     http.Header
+	body       []byte
+
+	l       sync.RWMutex
+	expires time.Time
+
+	cancel context.CancelFunc
+}
+
+func NewLeaseCache(conf *LeaseCacheConfig) (*LeaseCache, error) {
+	switch {
+	case conf.Proxier == nil:
+		return nil, errors.New("nil proxier")
+	case conf.Client == nil:
+		return nil, errors.New("nil API client")
+	case conf.Logger == nil:
+		return nil, errors.New("nil logger")
+	}
+
+	baseCtx := conf.BaseCtx
+	if baseCtx == nil {
+		baseCtx = context.Background()
+	}
+
+	return &LeaseCache{
+		proxier: conf.Proxier,
+		client:  conf.Client,
+		logger:  conf.Logger,
+		baseCtx: baseCtx,
+		entries: make(map[string]*cacheEntry),
+	}, nil
+}
+
+func (c *LeaseCache) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	key := computeCacheKey(req)
+
+	c.l.RLock()
+	entry, ok := c.entries[key]
+	c.l.RUnlock()
+	if ok && !entry.expired() {
+		c.logger.Debug("returning cached response", "path", req.Request.URL.Path)
+		return entry.response()
+	}
+
+	resp, err := c.proxier.Send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Response.StatusCode < 200 || resp.Response.StatusCode >= 300 {
+		return resp, nil
+	}
+
+	c.handleRevocation(req)
+	if !cacheable(req) {
+		return resp, nil
+	}
+
+	secret, err := api.ParseSecret(bytes.NewReader(resp.ResponseBody))
+	if err != nil || secret == nil || secret.WrapInfo != nil {
+		// Not a secret, or a wrapped response that must only be used once
+		return resp, nil
+	}
+
+	entry = &cacheEntry{
+		token:      req.Token,
+		statusCode: resp.Response.StatusCode,
+		header:     resp.Response.Header,
+		body:       resp.ResponseBody,
+	}
+	var ttl time.Duration
+	switch {
+	case secret.Auth != nil && secret.Auth.ClientToken != "":
+		entry.issuedToken = secret.Auth.ClientToken
+		ttl = time.Duration(secret.Auth.LeaseDuration) * time.Second
+	case secret.LeaseID != "":
+		entry.leaseID = secret.LeaseID
+		ttl = time.Duration(secret.LeaseDuration) * time.Second
+	}
+	if ttl <= 0 {
+		return resp, nil
+	}
+	entry.expires = time.Now().Add(ttl)
+
+	watchCtx, cancel := context.WithCancel(c.baseCtx)
+	entry.cancel = cancel
+
+	c.l.Lock()
+	if old, ok := c.entries[key]; ok {
+		old.cancel()
+	}
+	c.entries[key] = entry
+	c.l.Unlock()
+
+	c.logger.Debug("caching response", "path", req.Request.URL.Path)
+	go c.watch(watchCtx, key, entry, secret)
+
+	return resp, nil
+}
+
+// watch renews the lease or token of a cached response while possible and
+// removes the response from the cache once it expires
+func (c *LeaseCache) watch(ctx context.Context, key string, entry *cacheEntry, secret *api.Secret) {
+	defer func() {
+		c.evict(key, entry)
+		if entry.issuedToken != "" {
+			// Anything requested with the token is gone along with it
+			c.evictMatching(func(e *cacheEntry) bool {
+				return e.token == entry.issuedToken
+			})
+		}
+	}()
+
+	client, err := c.client.Clone()
+	if err != nil {
+		c.logger.Error("error creating client for renewal", "error", err)
+		return
+	}
+	client.SetToken(entry.token)
+
+	renewer, err := client.NewRenewer(&api.RenewerInput{
+		Secret: secret,
+	})
+	if err != nil {
+		c.logger.Error("error creating renewer", "error", err)
+		return
+	}
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case err := <-renewer.DoneCh():
+			if err != nil && err != api.ErrRenewerNotRenewable {
+				c.logger.Debug("renewal of cached response failed", "error", err)
+				return
+			}
+
+			// Renewals have stopped, keep the response until it expires
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Until(entry.expiry())):
+			}
+			return
+
+		case renewal := <-renewer.RenewCh():
+			var ttl int
+			switch {
+			case renewal.Secret == nil:
+				continue
+			case renewal.Secret.Auth != nil:
+				ttl = renewal.Secret.Auth.LeaseDuration
+			default:
+				ttl = renewal.Secret.LeaseDuration
+			}
+			entry.l.Lock()
+			entry.expires = renewal.RenewedAt.Add(time.Duration(ttl) * time.Second)
+			entry.l.Unlock()
+		}
+	}
+}
+
+// handleRevocation removes the cached responses made obsolete by a
+// successful revocation request
+func (c *LeaseCache) handleRevocation(req *SendRequest) {
+	path := strings.TrimPrefix(req.Request.URL.Path, "/v1/")
+	if ns := strings.Trim(req.Request.Header.Get("X-Vault-Namespace"), "/"); ns != "" {
+		path = ns + "/" + path
+	}
+
+	var body map[string]interface{}
+	if len(req.RequestBody) > 0 {
+		jsonutil.DecodeJSON(req.RequestBody, &body)
+	}
+	bodyValue := func(key string) string {
+		v, _ := body[key].(string)
+		return v
+	}
+
+	for _, prefix := range []string{"sys/leases/revoke-prefix/", "sys/revoke-prefix/", "sys/leases/revoke-force/", "sys/revoke-force/"} {
+		if strings.HasPrefix(path, prefix) {
+			leasePrefix := strings.TrimPrefix(path, prefix)
+			c.evictMatching(func(e *cacheEntry) bool {
+				return e.leaseID != "" && strings.HasPrefix(e.leaseID, leasePrefix)
+			})
+			return
+		}
+	}
+
+	for _, prefix := range []string{"sys/leases/revoke", "sys/revoke"} {
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		leaseID := strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/")
+		if leaseID == "" {
+			leaseID = bodyValue("lease_id")
+		}
+		if leaseID != "" {
+			c.evictMatching(func(e *cacheEntry) bool {
+				return e.leaseID == leaseID
+			})
+		}
+		return
+	}
+
+	var token string
+	switch {
+	case strings.HasSuffix(path, "auth/token/revoke-self"):
+		token = req.Token
+	case strings.HasSuffix(path, "auth/token/revoke"), strings.HasSuffix(path, "auth/token/revoke-orphan"):
+		token = bodyValue("token")
+	}
+	if token != "" {
+		c.evictMatching(func(e *cacheEntry) bool {
+			return e.token == token || e.issuedToken == token
+		})
+	}
+}
+
+// cacheable reports whether the response to a request may be cached. Only
+// reads and logins issue the leases and tokens worth handing out again.
+// Renewals, revocations and lookups must always reach Vault, since a cached
+// renewal would let the lease it was meant to extend expire.
+func cacheable(req *SendRequest) bool {
+	path := "/" + strings.TrimPrefix(req.Request.URL.Path, "/v1/")
+	if strings.Contains(path, "/sys/") {
+		return false
+	}
+
+	switch req.Request.Method {
+	case http.MethodGet:
+		return !strings.Contains(path, "/auth/token/")
+	case http.MethodPost, http.MethodPut:
+		if i := strings.Index(path, "/auth/token/"); i != -1 {
+			return strings.HasPrefix(path[i+len("/auth/token/"):], "create")
+		}
+		if i := strings.Index(path, "/auth/"); i != -1 {
+			for _, segment := range strings.Split(path[i+len("/auth/"):], "/") {
+				if segment == "login" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// evict removes the entry stored under the key, unless it has been replaced
+func (c *LeaseCache) evict(key string, entry *cacheEntry) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if c.entries[key] == entry {
+		delete(c.entries, key)
+		entry.cancel()
+	}
+}
+
+// evictMatching removes all entries the function returns true for
+func (c *LeaseCache) evictMatching(match func(*cacheEntry) bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	for key, entry := range c.entries {
+		if match(entry) {
+			delete(c.entries, key)
+			entry.cancel()
+		}
+	}
+}
+
+func (e *cacheEntry) expiry() time.Time {
+	e.l.RLock()
+	defer e.l.RUnlock()
+	return e.expires
+}
+
+func (e *cacheEntry) expired() bool {
+	return !time.Now().Before(e.expiry())
+}
+
+// response returns a copy of the cached response
+func (e *cacheEntry) response() (*SendResponse, error) {
+	return NewSendResponse(&api.Response{
+		Response: &http.Response{
+			StatusCode: e.statusCode,
+			Header:     e.header,
+			Body:       ioutil.NopCloser(bytes.NewReader(e.body)),
+		},
+	}, e.body)
+}
+
+// computeCacheKey returns the key under which the response to the request is
+// cached. Requests only share a response if they are made with the same
+// token.
+func computeCacheKey(req *SendRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.Request.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.URL.Query().Encode()))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.Header.Get("X-Vault-Namespace")))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Token))
+	h.Write([]byte{0})
+	h.Write(req.RequestBody)
+	return hex.EncodeToString(h.Sum(nil))
+}
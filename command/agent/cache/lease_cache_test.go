@@ -0,0 +1,252 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/logging"
+)
+
+// mockProxier answers requests with a response of its own, counting the
+// requests it received for each path
+type mockProxier struct {
+	l        sync.Mutex
+	requests map[string]int
+	respond  func(req *SendRequest, n int) (int, string)
+}
+
+func (p *mockProxier) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	p.l.Lock()
+	p.requests[req.Request.URL.Path]++
+	n := p.requests[req.Request.URL.Path]
+	p.l.Unlock()
+
+	status, body := p.respond(req, n)
+	return NewSendResponse(&api.Response{
+		Response: &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		},
+	}, nil)
+}
+
+func testLeaseCache(t *testing.T) (*LeaseCache, *mockProxier) {
+	t.Helper()
+
+	p := &mockProxier{
+		requests: make(map[string]int),
+		respond: func(req *SendRequest, n int) (int, string) {
+			switch {
+			case strings.HasPrefix(req.Request.URL.Path, "/v1/database/creds/"):
+				return 200, fmt.Sprintf(`{"lease_id": "database/creds/foo/%d", "lease_duration": 3600, "data": {"username": "user-%d"}}`, n, n)
+			case req.Request.URL.Path == "/v1/auth/token/create":
+				return 200, fmt.Sprintf(`{"auth": {"client_token": "token-%d", "lease_duration": 3600}}`, n)
+			case req.Request.URL.Path == "/v1/secret/foo":
+				return 200, fmt.Sprintf(`{"data": {"value": "%d"}}`, n)
+			case req.Request.URL.Path == "/v1/pki/issue/foo":
+				return 200, fmt.Sprintf(`{"lease_id": "pki/issue/foo/%d", "lease_duration": 3600, "wrap_info": {"token": "wrapped-%d"}}`, n, n)
+			case req.Request.URL.Path == "/v1/auth/approle/login":
+				return 200, fmt.Sprintf(`{"auth": {"client_token": "login-%d", "lease_duration": 3600}}`, n)
+			case req.Request.URL.Path == "/v1/sys/leases/renew":
+				return 200, fmt.Sprintf(`{"lease_id": "database/creds/foo/1", "lease_duration": %d, "renewable": true}`, 3600*n)
+			case req.Request.URL.Path == "/v1/auth/token/renew-self":
+				return 200, fmt.Sprintf(`{"auth": {"client_token": "foo", "lease_duration": %d, "renewable": true}}`, 3600*n)
+			case strings.Contains(req.Request.URL.Path, "revoke"):
+				return 204, ""
+			default:
+				return 404, `{"errors": []}`
+			}
+		},
+	}
+
+	client, err := api.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewLeaseCache(&LeaseCacheConfig{
+		Proxier: p,
+		Client:  client,
+		Logger:  logging.NewVaultLogger(hclog.Trace),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, p
+}
+
+func testSend(t *testing.T, c *LeaseCache, method, path, token string, body string) string {
+	t.Helper()
+
+	req := &SendRequest{
+		Token:   token,
+		Request: httptest.NewRequest(method, path, bytes.NewReader([]byte(body))),
+	}
+	if body != "" {
+		req.RequestBody = []byte(body)
+	}
+	resp, err := c.Send(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(resp.ResponseBody)
+}
+
+func TestLeaseCache_Send(t *testing.T) {
+	c, p := testLeaseCache(t)
+
+	// Responses with a lease or a token are cached per token
+	first := testSend(t, c, "GET", "/v1/database/creds/foo", "foo", "")
+	if second := testSend(t, c, "GET", "/v1/database/creds/foo", "foo", ""); second != first {
+		t.Fatalf("expected cached response, got %q and %q", first, second)
+	}
+	if other := testSend(t, c, "GET", "/v1/database/creds/foo", "bar", ""); other == first {
+		t.Fatalf("expected response of other token not to be shared")
+	}
+	if p.requests["/v1/database/creds/foo"] != 2 {
+		t.Fatalf("bad: %#v", p.requests)
+	}
+
+	first = testSend(t, c, "POST", "/v1/auth/token/create", "foo", `{"policies": ["default"]}`)
+	if second := testSend(t, c, "POST", "/v1/auth/token/create", "foo", `{"policies": ["default"]}`); second != first {
+		t.Fatalf("expected cached response, got %q and %q", first, second)
+	}
+	if other := testSend(t, c, "POST", "/v1/auth/token/create", "foo", `{"policies": ["other"]}`); other == first {
+		t.Fatalf("expected response to other request not to be shared")
+	}
+
+	// Others are not
+	for i := 0; i < 2; i++ {
+		testSend(t, c, "GET", "/v1/secret/foo", "foo", "")
+		testSend(t, c, "GET", "/v1/pki/issue/foo", "foo", "")
+		testSend(t, c, "GET", "/v1/nonexistent", "foo", "")
+	}
+	if p.requests["/v1/secret/foo"] != 2 || p.requests["/v1/pki/issue/foo"] != 2 || p.requests["/v1/nonexistent"] != 2 {
+		t.Fatalf("bad: %#v", p.requests)
+	}
+}
+
+func TestLeaseCache_Login(t *testing.T) {
+	c, p := testLeaseCache(t)
+
+	first := testSend(t, c, "PUT", "/v1/auth/approle/login", "", `{"role_id": "foo"}`)
+	if second := testSend(t, c, "PUT", "/v1/auth/approle/login", "", `{"role_id": "foo"}`); second != first {
+		t.Fatalf("expected cached response, got %q and %q", first, second)
+	}
+	if p.requests["/v1/auth/approle/login"] != 1 {
+		t.Fatalf("bad: %#v", p.requests)
+	}
+}
+
+func TestLeaseCache_Renewal(t *testing.T) {
+	c, p := testLeaseCache(t)
+
+	// Renewing the same lease or token twice reaches Vault both times
+	for i := 0; i < 2; i++ {
+		testSend(t, c, "PUT", "/v1/sys/leases/renew", "foo", `{"lease_id": "database/creds/foo/1"}`)
+		testSend(t, c, "PUT", "/v1/auth/token/renew-self", "foo", "")
+	}
+	if p.requests["/v1/sys/leases/renew"] != 2 || p.requests["/v1/auth/token/renew-self"] != 2 {
+		t.Fatalf("bad: %#v", p.requests)
+	}
+	if third := testSend(t, c, "PUT", "/v1/sys/leases/renew", "foo", `{"lease_id": "database/creds/foo/1"}`); !strings.Contains(third, `"lease_duration": 10800`) {
+		t.Fatalf("expected the third renewal, got %s", third)
+	}
+}
+
+func TestLeaseCache_Revocation(t *testing.T) {
+	c, p := testLeaseCache(t)
+
+	count := func(path string) int {
+		p.l.Lock()
+		defer p.l.Unlock()
+		return p.requests[path]
+	}
+
+	// Revoking the lease of a cached response drops it
+	testSend(t, c, "GET", "/v1/database/creds/foo", "foo", "")
+	testSend(t, c, "PUT", "/v1/sys/leases/revoke", "foo", `{"lease_id": "database/creds/foo/1"}`)
+	testSend(t, c, "GET", "/v1/database/creds/foo", "foo", "")
+	if n := count("/v1/database/creds/foo"); n != 2 {
+		t.Fatalf("expected response to be requested again, got %d requests", n)
+	}
+
+	// As does revoking by prefix
+	testSend(t, c, "PUT", "/v1/sys/leases/revoke-prefix/database/creds", "foo", "")
+	testSend(t, c, "GET", "/v1/database/creds/foo", "foo", "")
+	if n := count("/v1/database/creds/foo"); n != 3 {
+		t.Fatalf("expected response to be requested again, got %d requests", n)
+	}
+
+	// Revoking a token drops the responses requested with it, and those
+	// requested with tokens it issued
+	resp := testSend(t, c, "POST", "/v1/auth/token/create", "foo", "")
+	if !strings.Contains(resp, "token-1") {
+		t.Fatalf("bad: %s", resp)
+	}
+	testSend(t, c, "GET", "/v1/database/creds/bar", "token-1", "")
+	testSend(t, c, "PUT", "/v1/auth/token/revoke", "foo", `{"token": "token-1"}`)
+	testSend(t, c, "POST", "/v1/auth/token/create", "foo", "")
+	testSend(t, c, "GET", "/v1/database/creds/bar", "token-1", "")
+	if count("/v1/auth/token/create") != 2 || count("/v1/database/creds/bar") != 2 {
+		t.Fatalf("bad: %#v", p.requests)
+	}
+
+	testSend(t, c, "PUT", "/v1/auth/token/revoke-self", "foo", "")
+	testSend(t, c, "POST", "/v1/auth/token/create", "foo", "")
+	testSend(t, c, "GET", "/v1/database/creds/foo", "foo", "")
+	if count("/v1/auth/token/create") != 3 || count("/v1/database/creds/foo") != 4 {
+		t.Fatalf("bad: %#v", p.requests)
+	}
+}
+
+func TestLeaseCache_Handler(t *testing.T) {
+	c, p := testLeaseCache(t)
+
+	var tokens []string
+	p.respond = func(req *SendRequest, n int) (int, string) {
+		tokens = append(tokens, req.Token)
+		return 200, `{"data": {}}`
+	}
+
+	server := httptest.NewServer(Handler(logging.NewVaultLogger(hclog.Trace), c, staticToken("auto")))
+	defer server.Close()
+
+	// Requests without a token are made with the auto-auth token
+	for _, token := range []string{"", "foo"} {
+		req, err := http.NewRequest("GET", server.URL+"/v1/secret/foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("X-Vault-Token", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatalf("bad: %d", resp.StatusCode)
+		}
+	}
+	if len(tokens) != 2 || tokens[0] != "auto" || tokens[1] != "foo" {
+		t.Fatalf("bad: %v", tokens)
+	}
+}
+
+type staticToken string
+
+func (s staticToken) Token() string {
+	return string(s)
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// SendRequest is a request received by the agent, to be sent on to Vault or
+// answered from the cache
+type SendRequest struct {
+	Token       string
+	Request     *http.Request
+	RequestBody []byte
+}
+
+// SendResponse is the response to a SendRequest. The body is read in full so
+// that it can be inspected and served more than once.
+type SendResponse struct {
+	Response     *api.Response
+	ResponseBody []byte
+}
+
+// NewSendResponse reads the body of the given response
+func NewSendResponse(resp *api.Response, body []byte) (*SendResponse, error) {
+	if body == nil && resp.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return &SendResponse{
+		Response:     resp,
+		ResponseBody: body,
+	}, nil
+}
+
+// Proxier is implemented by the layers requests pass through on their way to
+// Vault
+type Proxier interface {
+	Send(ctx context.Context, req *SendRequest) (*SendResponse, error)
+}
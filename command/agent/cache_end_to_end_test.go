@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
+	"github.com/hashicorp/vault/command/agent/auth"
+	agentapprole "github.com/hashicorp/vault/command/agent/auth/approle"
+	"github.com/hashicorp/vault/command/agent/cache"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/command/agent/sink/inmem"
+	"github.com/hashicorp/vault/helper/logging"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestCacheEndToEnd(t *testing.T) {
+	logger := logging.NewVaultLogger(hclog.Trace)
+	coreConfig := &vault.CoreConfig{
+		Logger: logger,
+		CredentialBackends: map[string]logical.Factory{
+			"approle": credAppRole.Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	vault.TestWaitActive(t, cluster.Cores[0].Core)
+	client := cluster.Cores[0].Client
+
+	// Setup Vault
+	if err := client.Sys().EnableAuthWithOptions("approle", &api.EnableAuthOptions{
+		Type: "approle",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Sys().PutPolicy("test", `path "auth/token/create" { capabilities = ["update"] }`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("auth/approle/role/test", map[string]interface{}{
+		"policies":  "test",
+		"token_ttl": "1h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Logical().Read("auth/approle/role/test/role-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roleID := resp.Data["role_id"].(string)
+	resp, err = client.Logical().Write("auth/approle/role/test/secret-id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretID := resp.Data["secret_id"].(string)
+
+	dir, err := ioutil.TempDir("", "agent.cache.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	roleIDPath := filepath.Join(dir, "role-id")
+	secretIDPath := filepath.Join(dir, "secret-id")
+	if err := ioutil.WriteFile(roleIDPath, []byte(roleID), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(secretIDPath, []byte(secretID), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	timer := time.AfterFunc(30*time.Second, func() {
+		cancelFunc()
+	})
+	defer timer.Stop()
+
+	am, err := agentapprole.NewApproleAuthMethod(&auth.AuthConfig{
+		Logger:    logger.Named("auth.approle"),
+		MountPath: "auth/approle",
+		Config: map[string]interface{}{
+			"role_id_file_path":   roleIDPath,
+			"secret_id_file_path": secretIDPath,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ah := auth.NewAuthHandler(&auth.AuthHandlerConfig{
+		Logger: logger.Named("auth.handler"),
+		Client: client,
+	})
+	go ah.Run(ctx, am)
+	defer func() {
+		<-ah.DoneCh
+	}()
+
+	config := &sink.SinkConfig{
+		Logger: logger.Named("sink.inmem"),
+	}
+	inmemSink, err := inmem.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Sink = inmemSink
+
+	ss := sink.NewSinkServer(&sink.SinkServerConfig{
+		Logger: logger.Named("sink.server"),
+		Client: client,
+	})
+	go ss.Run(ctx, ah.OutputCh, []*sink.SinkConfig{config})
+	defer func() {
+		<-ss.DoneCh
+	}()
+
+	// This has to be after the other defers so it happens first
+	defer cancelFunc()
+
+	apiProxy, err := cache.NewAPIProxy(&cache.APIProxyConfig{
+		Client: client,
+		Logger: logger.Named("cache.apiproxy"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaseCache, err := cache.NewLeaseCache(&cache.LeaseCacheConfig{
+		Proxier: apiProxy,
+		Client:  client,
+		Logger:  logger.Named("cache.leasecache"),
+		BaseCtx: ctx,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(cache.Handler(logger.Named("cache"), leaseCache, inmemSink.(sink.SinkReader)))
+	defer server.Close()
+
+	// Wait for the agent to authenticate
+	reader := inmemSink.(sink.SinkReader)
+	for reader.Token() == "" {
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for auto-auth token")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// The secret ID file is removed once read
+	if _, err := os.Stat(secretIDPath); !os.IsNotExist(err) {
+		t.Fatalf("expected secret ID file to be removed, got %v", err)
+	}
+
+	agentClient, err := api.NewClient(&api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agentClient.SetToken("")
+
+	// Requests without a token use the auto-auth token
+	secret, err := agentClient.Auth().Token().LookupSelf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["id"] != reader.Token() {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+
+	// A token created through the agent is handed out again from the cache
+	first, err := agentClient.Auth().Token().Create(&api.TokenCreateRequest{
+		Policies: []string{"default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := agentClient.Auth().Token().Create(&api.TokenCreateRequest{
+		Policies: []string{"default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Auth.ClientToken != second.Auth.ClientToken {
+		t.Fatalf("expected cached token, got %q and %q", first.Auth.ClientToken, second.Auth.ClientToken)
+	}
+
+	// Once revoked through the agent a new one is created
+	childClient, err := agentClient.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	childClient.SetToken(first.Auth.ClientToken)
+	if err := childClient.Auth().Token().RevokeSelf(""); err != nil {
+		t.Fatal(err)
+	}
+	third, err := agentClient.Auth().Token().Create(&api.TokenCreateRequest{
+		Policies: []string{"default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Auth.ClientToken == first.Auth.ClientToken {
+		t.Fatal("expected a new token after revocation")
+	}
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/parseutil"
+)
+
+// The pod annotations the agent is configured with when it runs as an init
+// or sidecar container. The names of the secrets to render are given by the
+// suffix of AnnotationSecretPrefix, and the other per-secret annotations use
+// the same suffix.
+const (
+	AnnotationPrefix = "vault.hashicorp.com/"
+
+	AnnotationInject           = AnnotationPrefix + "agent-inject"
+	AnnotationRole             = AnnotationPrefix + "role"
+	AnnotationAuthPath         = AnnotationPrefix + "auth-path"
+	AnnotationSecretVolumePath = AnnotationPrefix + "secret-volume-path"
+	AnnotationPrePopulateOnly  = AnnotationPrefix + "agent-pre-populate-only"
+	AnnotationInjectToken      = AnnotationPrefix + "agent-inject-token"
+
+	AnnotationSecretPrefix   = AnnotationPrefix + "agent-inject-secret-"
+	AnnotationTemplatePrefix = AnnotationPrefix + "agent-inject-template-"
+	AnnotationFilePrefix     = AnnotationPrefix + "agent-inject-file-"
+	AnnotationCommandPrefix  = AnnotationPrefix + "agent-inject-command-"
+)
+
+// DefaultSecretVolumePath is where secrets are rendered unless the pod says
+// otherwise; it is expected to be a volume shared with the other containers
+const DefaultSecretVolumePath = "/vault/secrets"
+
+// defaultSecretTemplate renders each key of the data of a secret on its own
+// line
+const defaultSecretTemplate = `{{ with secret %s }}{{ range $k, $v := .Data }}{{ $k }}: {{ $v }}
+{{ end }}{{ end }}`
+
+// LoadAnnotations loads the configuration from the annotations of the pod
+// the agent runs in, as projected in a file by the downward API
+func LoadAnnotations(path string, logger log.Logger) (*Config, error) {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations, err := parseAnnotations(d)
+	if err != nil {
+		return nil, errwrap.Wrapf("error parsing annotations: {{err}}", err)
+	}
+
+	return ConfigFromAnnotations(annotations)
+}
+
+// parseAnnotations parses the lines of a downward API file, of the form
+// key="value" with the value quoted as a Go string
+func parseAnnotations(d []byte) (map[string]string, error) {
+	annotations := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(d))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		value, err := strconv.Unquote(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %q", parts[0])
+		}
+		annotations[parts[0]] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return annotations, nil
+}
+
+// ConfigFromAnnotations returns the configuration of an agent authenticating
+// with the Kubernetes auth method and rendering the secrets the annotations
+// list into the secret volume
+func ConfigFromAnnotations(annotations map[string]string) (*Config, error) {
+	inject, err := parseutil.ParseBool(annotations[AnnotationInject])
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("invalid value for %q: {{err}}", AnnotationInject), err)
+	}
+	if !inject {
+		return nil, fmt.Errorf("the pod is not annotated with %s: \"true\"", AnnotationInject)
+	}
+
+	role := annotations[AnnotationRole]
+	if role == "" {
+		return nil, fmt.Errorf("%q must be set", AnnotationRole)
+	}
+
+	mountPath := strings.TrimSuffix(annotations[AnnotationAuthPath], "/")
+	if mountPath == "" {
+		mountPath = "auth/kubernetes"
+	}
+
+	volumePath := annotations[AnnotationSecretVolumePath]
+	if volumePath == "" {
+		volumePath = DefaultSecretVolumePath
+	}
+
+	result := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "kubernetes",
+				MountPath: mountPath,
+				Config: map[string]interface{}{
+					"role": role,
+				},
+			},
+		},
+	}
+
+	if v, ok := annotations[AnnotationPrePopulateOnly]; ok {
+		if result.ExitAfterRender, err = parseutil.ParseBool(v); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("invalid value for %q: {{err}}", AnnotationPrePopulateOnly), err)
+		}
+	}
+
+	if v, ok := annotations[AnnotationInjectToken]; ok {
+		injectToken, err := parseutil.ParseBool(v)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("invalid value for %q: {{err}}", AnnotationInjectToken), err)
+		}
+		if injectToken {
+			result.AutoAuth.Sinks = []*Sink{
+				&Sink{
+					Type: "file",
+					Config: map[string]interface{}{
+						"path": filepath.Join(volumePath, "token"),
+					},
+				},
+			}
+		}
+	}
+
+	var names []string
+	for k := range annotations {
+		if strings.HasPrefix(k, AnnotationSecretPrefix) {
+			names = append(names, strings.TrimPrefix(k, AnnotationSecretPrefix))
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := annotations[AnnotationSecretPrefix+name]
+		if path == "" {
+			return nil, fmt.Errorf("%q must not be empty", AnnotationSecretPrefix+name)
+		}
+
+		file := name
+		if v := annotations[AnnotationFilePrefix+name]; v != "" {
+			file = v
+		}
+		if err := validateFileName(file); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("invalid file name for secret %q: {{err}}", name), err)
+		}
+
+		contents := annotations[AnnotationTemplatePrefix+name]
+		if contents == "" {
+			contents = fmt.Sprintf(defaultSecretTemplate, strconv.Quote(path))
+		}
+
+		result.Templates = append(result.Templates, &Template{
+			Contents:    contents,
+			Destination: filepath.Join(volumePath, file),
+			Perms:       0644,
+			Command:     annotations[AnnotationCommandPrefix+name],
+		})
+	}
+
+	if len(result.Templates) == 0 && len(result.AutoAuth.Sinks) == 0 {
+		return nil, fmt.Errorf("no secrets to render: at least one %q annotation is required", AnnotationSecretPrefix+"<name>")
+	}
+
+	// Without templates, only the token is written before exiting
+	if result.ExitAfterRender && len(result.Templates) == 0 {
+		result.ExitAfterRender = false
+		result.ExitAfterAuth = true
+	}
+
+	if err := validateConfig(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validateFileName checks that a secret is rendered directly into the
+// secret volume
+func validateFileName(name string) error {
+	switch {
+	case name == "", name == ".", name == "..":
+		return errors.New("the name must not be empty, \".\" or \"..\"")
+	case strings.ContainsAny(name, `/\`):
+		return errors.New("the name must not contain a path separator")
+	case name == "token":
+		return errors.New(`"token" is reserved for the token`)
+	}
+	return nil
+}
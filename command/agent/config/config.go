@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,9 +20,53 @@ import (
 
 // Config is the configuration for the vault server.
 type Config struct {
-	AutoAuth      *AutoAuth `hcl:"auto_auth"`
-	ExitAfterAuth bool      `hcl:"exit_after_auth"`
-	PidFile       string    `hcl:"pid_file"`
+	AutoAuth        *AutoAuth   `hcl:"auto_auth"`
+	ExitAfterAuth   bool        `hcl:"exit_after_auth"`
+	ExitAfterRender bool        `hcl:"exit_after_render"`
+	PidFile         string      `hcl:"pid_file"`
+	Cache           *Cache      `hcl:"cache"`
+	Listeners       []*Listener `hcl:"-"`
+	Templates       []*Template `hcl:"-"`
+}
+
+// Cache configures the agent to proxy the requests of local clients to
+// Vault, caching the responses that carry leases or tokens
+type Cache struct {
+	UseAutoAuthToken bool `hcl:"use_auto_auth_token"`
+}
+
+// Listener is a listener the agent serves proxied requests on
+type Listener struct {
+	Type   string
+	Config map[string]interface{}
+}
+
+// Template renders secrets into a file using the auto-auth token
+type Template struct {
+	Source      string `hcl:"source"`
+	Contents    string `hcl:"contents"`
+	Destination string `hcl:"destination"`
+
+	// DestinationDir is the directory of the files the template writes with
+	// the file function, one per secret
+	DestinationDir string `hcl:"destination_dir"`
+
+	PermsRaw interface{} `hcl:"perms"`
+	Perms    os.FileMode `hcl:"-"`
+
+	// UID and GID own the rendered files if set
+	UIDRaw interface{} `hcl:"uid"`
+	UID    *int        `hcl:"-"`
+	GIDRaw interface{} `hcl:"gid"`
+	GID    *int        `hcl:"-"`
+
+	// Command is run and Signal sent to the process in SignalPIDFile
+	// whenever the destination changes
+	Command           string        `hcl:"command"`
+	CommandTimeoutRaw interface{}   `hcl:"command_timeout"`
+	CommandTimeout    time.Duration `hcl:"-"`
+	Signal            string        `hcl:"signal"`
+	SignalPIDFile     string        `hcl:"signal_pid_file"`
 }
 
 type AutoAuth struct {
@@ -87,15 +132,69 @@ func LoadConfig(path string, logger log.Logger) (*Config, error) {
 		return nil, errwrap.Wrapf("error parsing 'auto_auth': {{err}}", err)
 	}
 
+	if err := parseCache(&result, list); err != nil {
+		return nil, errwrap.Wrapf("error parsing 'cache': {{err}}", err)
+	}
+
+	if err := parseListeners(&result, list); err != nil {
+		return nil, errwrap.Wrapf("error parsing 'listener' stanzas: {{err}}", err)
+	}
+
+	if err := parseTemplates(&result, list); err != nil {
+		return nil, errwrap.Wrapf("error parsing 'template' stanzas: {{err}}", err)
+	}
+
+	if err := validateConfig(&result); err != nil {
+		return nil, err
+	}
+
 	return &result, nil
 }
 
+// validateConfig checks that the blocks of a configuration can be used
+// together
+func validateConfig(result *Config) error {
+	switch {
+	case result.AutoAuth == nil && result.Cache == nil:
+		return fmt.Errorf("an 'auto_auth' or 'cache' block is required")
+	case result.AutoAuth != nil && len(result.AutoAuth.Sinks) == 0 && len(result.Templates) == 0 &&
+		(result.Cache == nil || !result.Cache.UseAutoAuthToken):
+		return fmt.Errorf("at least one 'sink' block must be provided, unless the token is used by 'template' blocks or the cache")
+	case len(result.Templates) > 0 && result.AutoAuth == nil:
+		return fmt.Errorf("'template' blocks require an 'auto_auth' block")
+	case len(result.Templates) > 0 && result.AutoAuth.Method.WrapTTL > 0:
+		return fmt.Errorf("'template' blocks cannot be used with a response-wrapping auth method")
+	case len(result.Templates) > 0 && result.ExitAfterAuth:
+		return fmt.Errorf("'exit_after_auth' cannot be used with 'template' blocks")
+	case result.Cache == nil && len(result.Listeners) > 0:
+		return fmt.Errorf("'listener' blocks require a 'cache' block")
+	case result.Cache != nil && result.ExitAfterAuth:
+		return fmt.Errorf("'exit_after_auth' cannot be used with a 'cache' block")
+	case result.Cache != nil && len(result.Listeners) == 0:
+		return fmt.Errorf("a 'cache' block requires at least one 'listener' block")
+	case result.Cache != nil && result.Cache.UseAutoAuthToken && result.AutoAuth == nil:
+		return fmt.Errorf("'use_auto_auth_token' requires an 'auto_auth' block")
+	case result.Cache != nil && result.Cache.UseAutoAuthToken && result.AutoAuth.Method.WrapTTL > 0:
+		return fmt.Errorf("'use_auto_auth_token' cannot be used with a response-wrapping auth method")
+	case result.ExitAfterRender && len(result.Templates) == 0:
+		return fmt.Errorf("'exit_after_render' requires 'template' blocks")
+	case result.ExitAfterRender && result.Cache != nil:
+		return fmt.Errorf("'exit_after_render' cannot be used with a 'cache' block")
+	}
+
+	return nil
+}
+
 func parseAutoAuth(result *Config, list *ast.ObjectList) error {
 	name := "auto_auth"
 
 	autoAuthList := list.Filter(name)
-	if len(autoAuthList.Items) != 1 {
-		return fmt.Errorf("one and only one %q block is required", name)
+	switch len(autoAuthList.Items) {
+	case 0:
+		return nil
+	case 1:
+	default:
+		return fmt.Errorf("only one %q block is permitted", name)
 	}
 
 	// Get our item
@@ -122,11 +221,8 @@ func parseAutoAuth(result *Config, list *ast.ObjectList) error {
 		return errwrap.Wrapf("error parsing 'sink' stanzas: {{err}}", err)
 	}
 
-	switch {
-	case a.Method == nil:
+	if a.Method == nil {
 		return fmt.Errorf("no 'method' block found")
-	case len(a.Sinks) == 0:
-		return fmt.Errorf("at least one 'sink' block must be provided")
 	}
 
 	return nil
@@ -181,7 +277,7 @@ func parseSinks(result *Config, list *ast.ObjectList) error {
 
 	sinkList := list.Filter(name)
 	if len(sinkList.Items) < 1 {
-		return fmt.Errorf("at least one %q block is required", name)
+		return nil
 	}
 
 	var ts []*Sink
@@ -237,3 +333,140 @@ func parseSinks(result *Config, list *ast.ObjectList) error {
 	result.AutoAuth.Sinks = ts
 	return nil
 }
+
+func parseCache(result *Config, list *ast.ObjectList) error {
+	name := "cache"
+
+	cacheList := list.Filter(name)
+	switch len(cacheList.Items) {
+	case 0:
+		result.Cache = nil
+		return nil
+	case 1:
+	default:
+		return fmt.Errorf("only one %q block is permitted", name)
+	}
+
+	var c Cache
+	if err := hcl.DecodeObject(&c, cacheList.Items[0].Val); err != nil {
+		return err
+	}
+
+	result.Cache = &c
+	return nil
+}
+
+func parseListeners(result *Config, list *ast.ObjectList) error {
+	name := "listener"
+
+	listenerList := list.Filter(name)
+
+	var listeners []*Listener
+	for _, item := range listenerList.Items {
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		var lnType string
+		if len(item.Keys) == 1 {
+			lnType = strings.ToLower(item.Keys[0].Token.Value().(string))
+		}
+		if t, ok := m["type"].(string); ok && t != "" {
+			lnType = strings.ToLower(t)
+			delete(m, "type")
+		}
+		switch lnType {
+		case "tcp", "unix":
+		case "":
+			return errors.New("listener type must be specified")
+		default:
+			return fmt.Errorf("invalid listener type %q", lnType)
+		}
+
+		listeners = append(listeners, &Listener{
+			Type:   lnType,
+			Config: m,
+		})
+	}
+
+	result.Listeners = listeners
+	return nil
+}
+
+func parseTemplates(result *Config, list *ast.ObjectList) error {
+	name := "template"
+
+	templateList := list.Filter(name)
+
+	var templates []*Template
+	for i, item := range templateList.Items {
+		prefix := fmt.Sprintf("template.%d", i)
+
+		var t Template
+		if err := hcl.DecodeObject(&t, item.Val); err != nil {
+			return multierror.Prefix(err, prefix)
+		}
+
+		switch {
+		case t.Source == "" && t.Contents == "":
+			return multierror.Prefix(errors.New("one of 'source' and 'contents' must be specified"), prefix)
+		case t.Source != "" && t.Contents != "":
+			return multierror.Prefix(errors.New("only one of 'source' and 'contents' may be specified"), prefix)
+		case t.Destination == "" && t.DestinationDir == "":
+			return multierror.Prefix(errors.New("one of 'destination' and 'destination_dir' must be specified"), prefix)
+		case t.Signal != "" && t.SignalPIDFile == "":
+			return multierror.Prefix(errors.New("'signal' requires 'signal_pid_file'"), prefix)
+		}
+
+		t.Perms = 0644
+		if t.PermsRaw != nil {
+			perms, err := strconv.ParseUint(fmt.Sprintf("%v", t.PermsRaw), 8, 32)
+			if err != nil {
+				return multierror.Prefix(errwrap.Wrapf("invalid value for 'perms': {{err}}", err), prefix)
+			}
+			t.Perms = os.FileMode(perms)
+			t.PermsRaw = nil
+		}
+
+		var err error
+		if t.UID, err = parseID(t.UIDRaw); err != nil {
+			return multierror.Prefix(errwrap.Wrapf("invalid value for 'uid': {{err}}", err), prefix)
+		}
+		t.UIDRaw = nil
+		if t.GID, err = parseID(t.GIDRaw); err != nil {
+			return multierror.Prefix(errwrap.Wrapf("invalid value for 'gid': {{err}}", err), prefix)
+		}
+		t.GIDRaw = nil
+
+		if t.CommandTimeoutRaw != nil {
+			if t.CommandTimeout, err = parseutil.ParseDurationSecond(t.CommandTimeoutRaw); err != nil {
+				return multierror.Prefix(errwrap.Wrapf("invalid value for 'command_timeout': {{err}}", err), prefix)
+			}
+			t.CommandTimeoutRaw = nil
+		}
+
+		t.Signal = strings.ToUpper(t.Signal)
+
+		templates = append(templates, &t)
+	}
+
+	result.Templates = templates
+	return nil
+}
+
+// parseID parses a user or group ID, returning nil if it is not set
+func parseID(raw interface{}) (*int, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	id, err := parseutil.ParseInt(raw)
+	if err != nil {
+		return nil, err
+	}
+	if id < 0 {
+		return nil, errors.New("must not be negative")
+	}
+	result := int(id)
+	return &result, nil
+}
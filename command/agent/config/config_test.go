@@ -69,3 +69,207 @@ func TestLoadConfigFile(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+func TestLoadConfigFile_Cache(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	config, err := LoadConfig("./test-fixtures/config-cache.hcl", logger)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "approle",
+				MountPath: "auth/approle",
+				Config: map[string]interface{}{
+					"role_id_file_path":   "/tmp/role-id",
+					"secret_id_file_path": "/tmp/secret-id",
+				},
+			},
+			Sinks: []*Sink{
+				&Sink{
+					Type: "file",
+					Config: map[string]interface{}{
+						"path": "/tmp/file-foo",
+					},
+				},
+			},
+		},
+		Cache: &Cache{
+			UseAutoAuthToken: true,
+		},
+		Listeners: []*Listener{
+			&Listener{
+				Type: "unix",
+				Config: map[string]interface{}{
+					"address":     "/tmp/agent.sock",
+					"tls_disable": true,
+				},
+			},
+			&Listener{
+				Type: "tcp",
+				Config: map[string]interface{}{
+					"address":     "127.0.0.1:8300",
+					"tls_disable": true,
+				},
+			},
+		},
+		PidFile: "./pidfile",
+	}
+
+	if diff := deep.Equal(config, expected); diff != nil {
+		t.Fatal(diff)
+	}
+
+	for _, path := range []string{
+		"./test-fixtures/config-cache-no-listeners.hcl",
+		"./test-fixtures/config-cache-wrapped-auth.hcl",
+	} {
+		if _, err := LoadConfig(path, logger); err == nil {
+			t.Fatalf("%s: expected error", path)
+		}
+	}
+}
+
+func TestLoadConfigFile_Template(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	config, err := LoadConfig("./test-fixtures/config-template.hcl", logger)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	id := 1000
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "approle",
+				MountPath: "auth/approle",
+				Config: map[string]interface{}{
+					"role_id_file_path":   "/tmp/role-id",
+					"secret_id_file_path": "/tmp/secret-id",
+				},
+			},
+		},
+		Templates: []*Template{
+			&Template{
+				Source:         "/tmp/app.conf.tpl",
+				Destination:    "/tmp/app.conf",
+				Perms:          0600,
+				Command:        "systemctl reload app",
+				CommandTimeout: 10 * time.Second,
+			},
+			&Template{
+				Contents:      `{{ with secret "secret/foo" }}{{ .Data.password }}{{ end }}`,
+				Destination:   "/tmp/password",
+				Perms:         0644,
+				Signal:        "SIGHUP",
+				SignalPIDFile: "/tmp/app.pid",
+			},
+			&Template{
+				Contents:       `{{ with secret "pki/issue/app" "common_name=app" }}{{ file "tls.crt" .Data.certificate }}{{ end }}`,
+				DestinationDir: "/tmp/tls",
+				Perms:          0644,
+				UID:            &id,
+				GID:            &id,
+			},
+		},
+		PidFile: "./pidfile",
+	}
+
+	if diff := deep.Equal(config, expected); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if _, err := LoadConfig("./test-fixtures/config-template-no-destination.hcl", logger); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestLoadAnnotations(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	config, err := LoadAnnotations("./test-fixtures/annotations", logger)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "kubernetes",
+				MountPath: "auth/k8s",
+				Config: map[string]interface{}{
+					"role": "app",
+				},
+			},
+			Sinks: []*Sink{
+				&Sink{
+					Type: "file",
+					Config: map[string]interface{}{
+						"path": "/secrets/token",
+					},
+				},
+			},
+		},
+		Templates: []*Template{
+			&Template{
+				Contents:    "{{ with secret \"database/creds/app\" }}\nusername={{ .Data.username }}\npassword={{ .Data.password }}\n{{ end }}",
+				Destination: "/secrets/db.conf",
+				Perms:       0644,
+				Command:     "kill -HUP 1",
+			},
+			&Template{
+				Contents:    "{{ with secret \"secret/app\" }}{{ range $k, $v := .Data }}{{ $k }}: {{ $v }}\n{{ end }}{{ end }}",
+				Destination: "/secrets/password",
+				Perms:       0644,
+			},
+		},
+	}
+
+	if diff := deep.Equal(config, expected); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// Only the token is written before exiting without secrets
+	config, err = ConfigFromAnnotations(map[string]string{
+		AnnotationInject:          "true",
+		AnnotationRole:            "app",
+		AnnotationInjectToken:     "true",
+		AnnotationPrePopulateOnly: "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.ExitAfterAuth || config.ExitAfterRender || config.AutoAuth.Sinks[0].Config["path"] != "/vault/secrets/token" {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	for name, annotations := range map[string]map[string]string{
+		"not injected": {
+			AnnotationRole:                "app",
+			AnnotationSecretPrefix + "db": "database/creds/app",
+		},
+		"no role": {
+			AnnotationInject:              "true",
+			AnnotationSecretPrefix + "db": "database/creds/app",
+		},
+		"no secrets": {
+			AnnotationInject: "true",
+			AnnotationRole:   "app",
+		},
+		"file outside the volume": {
+			AnnotationInject:              "true",
+			AnnotationRole:                "app",
+			AnnotationSecretPrefix + "db": "database/creds/app",
+			AnnotationFilePrefix + "db":   "../db",
+		},
+	} {
+		if _, err := ConfigFromAnnotations(annotations); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
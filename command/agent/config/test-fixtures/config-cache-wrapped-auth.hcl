@@ -0,0 +1,24 @@
+auto_auth {
+	method "approle" {
+		wrap_ttl = 300
+		config = {
+			role_id_file_path = "/tmp/role-id"
+			secret_id_file_path = "/tmp/secret-id"
+		}
+	}
+
+	sink "file" {
+		config = {
+			path = "/tmp/file-foo"
+		}
+	}
+}
+
+cache {
+	use_auto_auth_token = true
+}
+
+listener "unix" {
+	address = "/tmp/agent.sock"
+	tls_disable = true
+}
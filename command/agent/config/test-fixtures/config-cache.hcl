@@ -0,0 +1,30 @@
+pid_file = "./pidfile"
+
+auto_auth {
+	method "approle" {
+		config = {
+			role_id_file_path = "/tmp/role-id"
+			secret_id_file_path = "/tmp/secret-id"
+		}
+	}
+
+	sink "file" {
+		config = {
+			path = "/tmp/file-foo"
+		}
+	}
+}
+
+cache {
+	use_auto_auth_token = true
+}
+
+listener "unix" {
+	address = "/tmp/agent.sock"
+	tls_disable = true
+}
+
+listener "tcp" {
+	address = "127.0.0.1:8300"
+	tls_disable = true
+}
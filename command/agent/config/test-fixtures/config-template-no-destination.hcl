@@ -0,0 +1,12 @@
+auto_auth {
+	method "approle" {
+		config = {
+			role_id_file_path = "/tmp/role-id"
+			secret_id_file_path = "/tmp/secret-id"
+		}
+	}
+}
+
+template {
+	contents = "foo"
+}
@@ -0,0 +1,32 @@
+pid_file = "./pidfile"
+
+auto_auth {
+	method "approle" {
+		config = {
+			role_id_file_path = "/tmp/role-id"
+			secret_id_file_path = "/tmp/secret-id"
+		}
+	}
+}
+
+template {
+	source = "/tmp/app.conf.tpl"
+	destination = "/tmp/app.conf"
+	perms = "0600"
+	command = "systemctl reload app"
+	command_timeout = "10s"
+}
+
+template {
+	contents = "{{ with secret \"secret/foo\" }}{{ .Data.password }}{{ end }}"
+	destination = "/tmp/password"
+	signal = "sighup"
+	signal_pid_file = "/tmp/app.pid"
+}
+
+template {
+	contents = "{{ with secret \"pki/issue/app\" \"common_name=app\" }}{{ file \"tls.crt\" .Data.certificate }}{{ end }}"
+	destination_dir = "/tmp/tls"
+	uid = 1000
+	gid = 1000
+}
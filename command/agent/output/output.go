@@ -0,0 +1,134 @@
+// Package output writes the files the agent renders tokens and secrets into,
+// and notifies their consumers when they change.
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// DefaultCommandTimeout is how long a command run after a change may take if
+// not otherwise configured
+const DefaultCommandTimeout = 30 * time.Second
+
+// Owner is the owner of written files; a negative ID is left unchanged
+type Owner struct {
+	UID int
+	GID int
+}
+
+// NoOwner leaves the owner of written files unchanged
+var NoOwner = Owner{UID: -1, GID: -1}
+
+// WriteFile replaces the file atomically, so that readers never see it
+// partially written, and returns whether it changed. The file is left alone
+// if it already has the contents, permissions and owner.
+func WriteFile(path string, contents []byte, perms os.FileMode, owner Owner) (bool, error) {
+	if existing, err := ioutil.ReadFile(path); err == nil && bytes.Equal(existing, contents) {
+		info, err := os.Stat(path)
+		if err == nil && info.Mode().Perm() == perms && ownedBy(info, owner) {
+			return false, nil
+		}
+	}
+
+	dir := filepath.Dir(path)
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".")
+	if err != nil {
+		return false, err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		return false, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return false, err
+	}
+	if err := f.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tmp, perms); err != nil {
+		return false, err
+	}
+	if owner.UID >= 0 || owner.GID >= 0 {
+		if err := os.Chown(tmp, owner.UID, owner.GID); err != nil {
+			return false, err
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return false, err
+	}
+
+	// Persist the rename; not all platforms can sync a directory
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return true, nil
+}
+
+// Notification is how the consumer of a file is told that it changed
+type Notification struct {
+	// Command is run with sh -c, and killed after CommandTimeout
+	Command        string
+	CommandTimeout time.Duration
+
+	// Signal is sent to the process whose ID is in SignalPIDFile
+	Signal        string
+	SignalPIDFile string
+}
+
+// Run runs the command and sends the signal of the notification
+func (n *Notification) Run(ctx context.Context) error {
+	if n.Command != "" {
+		timeout := n.CommandTimeout
+		if timeout == 0 {
+			timeout = DefaultCommandTimeout
+		}
+		cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		out, err := exec.CommandContext(cmdCtx, "sh", "-c", n.Command).CombinedOutput()
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error running command, output %q: {{err}}", out), err)
+		}
+	}
+
+	if n.Signal != "" {
+		sig, ok := Signals[n.Signal]
+		if !ok {
+			return fmt.Errorf("unknown signal %q", n.Signal)
+		}
+		b, err := ioutil.ReadFile(n.SignalPIDFile)
+		if err != nil {
+			return errwrap.Wrapf("error reading signal PID file: {{err}}", err)
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+		if err != nil {
+			return errwrap.Wrapf("error parsing signal PID file: {{err}}", err)
+		}
+		proc, err := os.FindProcess(pid)
+		if err != nil {
+			return err
+		}
+		if err := proc.Signal(sig); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error sending %s to process %d: {{err}}", n.Signal, pid), err)
+		}
+	}
+
+	return nil
+}
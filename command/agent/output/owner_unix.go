@@ -0,0 +1,16 @@
+// +build !windows
+
+package output
+
+import (
+	"os"
+	"syscall"
+)
+
+func ownedBy(info os.FileInfo, owner Owner) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+	return (owner.UID < 0 || int(stat.Uid) == owner.UID) && (owner.GID < 0 || int(stat.Gid) == owner.GID)
+}
@@ -0,0 +1,15 @@
+package output
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals are the signals that may be sent once a file has changed
+var Signals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGKILL": syscall.SIGKILL,
+}
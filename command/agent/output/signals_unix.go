@@ -0,0 +1,10 @@
+// +build !windows
+
+package output
+
+import "syscall"
+
+func init() {
+	Signals["SIGUSR1"] = syscall.SIGUSR1
+	Signals["SIGUSR2"] = syscall.SIGUSR2
+}
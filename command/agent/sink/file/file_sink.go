@@ -1,22 +1,36 @@
 package file
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/errwrap"
 	hclog "github.com/hashicorp/go-hclog"
-	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/command/agent/output"
 	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/helper/parseutil"
 )
 
+// defaultMode is the mode tokens are written with unless configured
+const defaultMode = 0640
+
 // fileSink is a Sink implementation that writes a token to a file
 type fileSink struct {
 	path   string
+	mode   os.FileMode
+	owner  output.Owner
 	logger hclog.Logger
+
+	// notification is run whenever the token changes; notifyPending is set
+	// if it failed, so that it is run again on the next write
+	notification  *output.Notification
+	notifyPending bool
 }
 
 // NewFileSink creates a new file sink with the given configuration
@@ -28,7 +42,10 @@ func NewFileSink(conf *sink.SinkConfig) (sink.Sink, error) {
 	conf.Logger.Info("creating file sink")
 
 	f := &fileSink{
-		logger: conf.Logger,
+		mode:         defaultMode,
+		owner:        output.NoOwner,
+		logger:       conf.Logger,
+		notification: &output.Notification{},
 	}
 
 	pathRaw, ok := conf.Config["path"]
@@ -42,71 +59,138 @@ func NewFileSink(conf *sink.SinkConfig) (sink.Sink, error) {
 
 	f.path = path
 
+	if modeRaw, ok := conf.Config["mode"]; ok {
+		mode, err := strconv.ParseUint(fmt.Sprintf("%v", modeRaw), 8, 32)
+		if err != nil {
+			return nil, errwrap.Wrapf("could not parse 'mode' as an octal file mode: {{err}}", err)
+		}
+		f.mode = os.FileMode(mode)
+	}
+
+	for _, id := range []struct {
+		name string
+		dest *int
+	}{
+		{"uid", &f.owner.UID},
+		{"gid", &f.owner.GID},
+	} {
+		raw, ok := conf.Config[id.name]
+		if !ok {
+			continue
+		}
+		v, err := parseutil.ParseInt(raw)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("could not parse '%s' as an integer: {{err}}", id.name), err)
+		}
+		if v < 0 {
+			return nil, fmt.Errorf("'%s' must not be negative", id.name)
+		}
+		*id.dest = int(v)
+	}
+
+	if commandRaw, ok := conf.Config["command"]; ok {
+		command, ok := commandRaw.(string)
+		if !ok {
+			return nil, errors.New("could not parse 'command' as string")
+		}
+		f.notification.Command = command
+	}
+
+	if timeoutRaw, ok := conf.Config["command_timeout"]; ok {
+		timeout, err := parseutil.ParseDurationSecond(timeoutRaw)
+		if err != nil {
+			return nil, errwrap.Wrapf("could not parse 'command_timeout' as a duration: {{err}}", err)
+		}
+		f.notification.CommandTimeout = timeout
+	}
+
+	if signalRaw, ok := conf.Config["signal"]; ok {
+		signal, ok := signalRaw.(string)
+		if !ok {
+			return nil, errors.New("could not parse 'signal' as string")
+		}
+		signal = strings.ToUpper(signal)
+		if _, ok := output.Signals[signal]; !ok {
+			return nil, fmt.Errorf("unknown signal %q", signal)
+		}
+		f.notification.Signal = signal
+
+		pidFileRaw, ok := conf.Config["signal_pid_file"]
+		if !ok {
+			return nil, errors.New("'signal' requires 'signal_pid_file'")
+		}
+		pidFile, ok := pidFileRaw.(string)
+		if !ok {
+			return nil, errors.New("could not parse 'signal_pid_file' as string")
+		}
+		f.notification.SignalPIDFile = pidFile
+	}
+
 	if err := f.WriteToken(""); err != nil {
 		return nil, errwrap.Wrapf("error during write check: {{err}}", err)
 	}
 
-	f.logger.Info("file sink configured", "path", f.path)
+	f.logger.Info("file sink configured", "path", f.path, "mode", f.mode)
 
 	return f, nil
 }
 
 // WriteToken implements the Server interface and writes the token to a path on
 // disk. It writes into the path's directory into a temp file and does an
-// atomic rename to ensure consistency. If a blank token is passed in, it
-// performs a write check but does not write a blank value to the final
-// location.
+// atomic rename to ensure consistency, then runs the configured command and
+// sends the configured signal if the token changed. If a blank token is
+// passed in, it performs a write check but does not write a blank value to
+// the final location.
 func (f *fileSink) WriteToken(token string) error {
 	f.logger.Trace("enter write_token", "path", f.path)
 	defer f.logger.Trace("exit write_token", "path", f.path)
 
-	u, err := uuid.GenerateUUID()
-	if err != nil {
-		return errwrap.Wrapf("error generating a uuid during write check: {{err}}", err)
+	if token == "" {
+		return f.writeCheck()
 	}
 
-	targetDir := filepath.Dir(f.path)
-	fileName := filepath.Base(f.path)
-	tmpSuffix := strings.Split(u, "-")[0]
-
-	tmpFile, err := os.OpenFile(filepath.Join(targetDir, fmt.Sprintf("%s.tmp.%s", fileName, tmpSuffix)), os.O_WRONLY|os.O_CREATE, 0640)
+	written, err := output.WriteFile(f.path, []byte(token), f.mode, f.owner)
 	if err != nil {
-		return errwrap.Wrapf(fmt.Sprintf("error opening temp file in dir %s for writing: {{err}}", targetDir), err)
+		return errwrap.Wrapf(fmt.Sprintf("error writing token to %s: {{err}}", f.path), err)
+	}
+	if written {
+		f.logger.Info("token written", "path", f.path)
+		f.notifyPending = true
 	}
 
-	valToWrite := token
-	if token == "" {
-		valToWrite = u
+	if !f.notifyPending {
+		return nil
+	}
+	if err := f.notification.Run(context.Background()); err != nil {
+		return errwrap.Wrapf("error notifying of the new token: {{err}}", err)
 	}
+	f.notifyPending = false
+
+	return nil
+}
 
-	_, err = tmpFile.WriteString(valToWrite)
+// writeCheck checks that a token can be written with the configured mode and
+// owner, without touching the final location
+func (f *fileSink) writeCheck() error {
+	targetDir := filepath.Dir(f.path)
+
+	tmpFile, err := ioutil.TempFile(targetDir, "."+filepath.Base(f.path)+".")
 	if err != nil {
-		// Attempt closing and deleting but ignore any error
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-		return errwrap.Wrapf(fmt.Sprintf("error writing to %s: {{err}}", tmpFile.Name()), err)
+		return errwrap.Wrapf(fmt.Sprintf("error opening temp file in dir %s for writing: {{err}}", targetDir), err)
 	}
+	defer os.Remove(tmpFile.Name())
 
-	err = tmpFile.Close()
-	if err != nil {
+	if err := tmpFile.Close(); err != nil {
 		return errwrap.Wrapf(fmt.Sprintf("error closing %s: {{err}}", tmpFile.Name()), err)
 	}
-
-	// Now, if we were just doing a write check (blank token), remove the file
-	// and exit; otherwise, atomically rename it
-	if token == "" {
-		err = os.Remove(tmpFile.Name())
-		if err != nil {
-			return errwrap.Wrapf(fmt.Sprintf("error removing temp file %s during write check: {{err}}", tmpFile.Name()), err)
-		}
-		return nil
+	if err := os.Chmod(tmpFile.Name(), f.mode); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("error setting the mode of %s: {{err}}", tmpFile.Name()), err)
 	}
-
-	err = os.Rename(tmpFile.Name(), f.path)
-	if err != nil {
-		return errwrap.Wrapf(fmt.Sprintf("error renaming temp file %s to target file %s: {{err}}", tmpFile.Name(), f.path), err)
+	if f.owner.UID >= 0 || f.owner.GID >= 0 {
+		if err := os.Chown(tmpFile.Name(), f.owner.UID, f.owner.GID); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error setting the owner of %s: {{err}}", tmpFile.Name()), err)
+		}
 	}
 
-	f.logger.Info("token written", "path", f.path)
 	return nil
 }
@@ -80,3 +80,83 @@ func TestFileSink(t *testing.T) {
 		t.Fatalf("expected %s, got %s", uuidStr, string(fileBytes))
 	}
 }
+
+func TestFileSink_ModeAndCommand(t *testing.T) {
+	log := logging.NewVaultLogger(hclog.Trace)
+
+	tmpDir, err := ioutil.TempDir("", fmt.Sprintf("%s.", fileServerTestDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "token")
+	counter := filepath.Join(tmpDir, "counter")
+
+	fs, err := NewFileSink(&sink.SinkConfig{
+		Logger: log.Named("sink.file"),
+		Config: map[string]interface{}{
+			"path":    path,
+			"mode":    "0600",
+			"command": "echo written >> " + counter,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The command is only run when the token changes
+	for _, token := range []string{"foo", "foo", "bar"} {
+		if err := fs.WriteToken(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("bad mode: %v", fi.Mode().Perm())
+	}
+
+	fileBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fileBytes) != "bar" {
+		t.Fatalf("expected bar, got %s", string(fileBytes))
+	}
+
+	counterBytes, err := ioutil.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(counterBytes) != "written\nwritten\n" {
+		t.Fatalf("bad: %q", counterBytes)
+	}
+
+	// No temp files are left behind
+	files, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected only the token and counter, got %d files", len(files))
+	}
+}
+
+func TestNewFileSink_Invalid(t *testing.T) {
+	log := logging.NewVaultLogger(hclog.Trace)
+
+	for _, conf := range []map[string]interface{}{
+		{"path": "/tmp/token", "mode": "rw"},
+		{"path": "/tmp/token", "uid": -1},
+		{"path": "/tmp/token", "signal": "SIGFOO", "signal_pid_file": "/tmp/pid"},
+		{"path": "/tmp/token", "signal": "SIGHUP"},
+	} {
+		if _, err := NewFileSink(&sink.SinkConfig{Logger: log, Config: conf}); err == nil {
+			t.Fatalf("expected error for %v", conf)
+		}
+	}
+}
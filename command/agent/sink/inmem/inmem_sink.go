@@ -0,0 +1,42 @@
+package inmem
+
+import (
+	"errors"
+	"sync/atomic"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agent/sink"
+)
+
+// inmemSink is a Sink implementation that keeps the latest token in memory,
+// where the cache can use it for requests that do not carry a token
+type inmemSink struct {
+	logger hclog.Logger
+	token  *atomic.Value
+}
+
+// New creates a new in-memory sink with the given configuration
+func New(conf *sink.SinkConfig) (sink.Sink, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+
+	conf.Logger.Info("creating in-memory sink")
+
+	s := &inmemSink{
+		logger: conf.Logger,
+		token:  new(atomic.Value),
+	}
+	s.token.Store("")
+
+	return s, nil
+}
+
+func (s *inmemSink) WriteToken(token string) error {
+	s.token.Store(token)
+	return nil
+}
+
+func (s *inmemSink) Token() string {
+	return s.token.Load().(string)
+}
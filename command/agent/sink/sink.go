@@ -20,6 +20,11 @@ type Sink interface {
 	WriteToken(string) error
 }
 
+// SinkReader is implemented by sinks the latest token can be read back from
+type SinkReader interface {
+	Token() string
+}
+
 type SinkConfig struct {
 	Sink
 	Logger             hclog.Logger
@@ -109,13 +114,13 @@ func (ss *SinkServer) Run(ctx context.Context, incoming chan string, sinks []*Si
 							var err error
 
 							if currSink.WrapTTL != 0 {
-								if currToken, err = s.wrapToken(ss.client, currSink.WrapTTL, currToken); err != nil {
+								if currToken, err = currSink.wrapToken(ss.client, currSink.WrapTTL, currToken); err != nil {
 									return err
 								}
 							}
 
-							if s.DHType != "" {
-								if currToken, err = s.encryptToken(currToken); err != nil {
+							if currSink.DHType != "" {
+								if currToken, err = currSink.encryptToken(currToken); err != nil {
 									return err
 								}
 							}
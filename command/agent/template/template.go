@@ -0,0 +1,502 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	hclog "github.com/hashicorp/go-hclog"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/command/agent/output"
+)
+
+const (
+	// defaultRefreshInterval is how often secrets without a lease are read
+	// again
+	defaultRefreshInterval = 5 * time.Minute
+)
+
+// Server renders templates with secrets read using the latest auto-auth
+// token. It is a sink, so that it is given the token along with the other
+// sinks. Renewable leases are renewed, and secrets are read again once their
+// renewal stops, when two thirds of a lease that can't be renewed has passed,
+// or periodically if they have none. The lease of the secret read before is
+// then revoked. The destination files and the commands and signals configured
+// for them are only updated and triggered when their contents change.
+type Server struct {
+	DoneCh chan struct{}
+
+	logger    hclog.Logger
+	client    *api.Client
+	templates []*config.Template
+	random    *rand.Rand
+
+	// exitAfterRender stops the server once every template is rendered
+	exitAfterRender bool
+
+	tokenCh chan string
+
+	// refreshCh wakes the server when the renewal of a secret stops
+	refreshCh chan struct{}
+
+	// files are the names of the files each template wrote with the file
+	// function when last rendered
+	files map[*config.Template]map[string][]byte
+
+	// secrets are the secrets read with the current token, keyed by the
+	// arguments of the template function reading them
+	l       sync.Mutex
+	secrets map[string]*cachedSecret
+}
+
+type ServerConfig struct {
+	Logger    hclog.Logger
+	Client    *api.Client
+	Templates []*config.Template
+
+	// ExitAfterRender stops the server, closing DoneCh, once the templates
+	// have been rendered successfully
+	ExitAfterRender bool
+}
+
+type cachedSecret struct {
+	secret    *api.Secret
+	refreshAt time.Time
+
+	// renewer renews the lease of the secret, if it is renewable, until
+	// stopCh is closed
+	renewer *api.Renewer
+	stopCh  chan struct{}
+}
+
+// NewServer returns a template server, validating that the templates parse
+func NewServer(conf *ServerConfig) (*Server, error) {
+	switch {
+	case conf.Logger == nil:
+		return nil, errors.New("nil logger provided")
+	case conf.Client == nil:
+		return nil, errors.New("nil API client")
+	}
+
+	ts := &Server{
+		DoneCh:    make(chan struct{}),
+		logger:    conf.Logger,
+		client:    conf.Client,
+		templates: conf.Templates,
+		random:    rand.New(rand.NewSource(int64(time.Now().Nanosecond()))),
+		tokenCh:   make(chan string, 1),
+		refreshCh: make(chan struct{}, 1),
+		secrets:   make(map[string]*cachedSecret),
+		files:     make(map[*config.Template]map[string][]byte),
+
+		exitAfterRender: conf.ExitAfterRender,
+	}
+
+	for _, t := range ts.templates {
+		if _, err := ts.parse(t, nil, nil); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error parsing template for %q: {{err}}", describe(t)), err)
+		}
+	}
+
+	return ts, nil
+}
+
+// WriteToken implements sink.Sink, handing the server a new token. Secrets
+// read with the previous token are read again.
+func (ts *Server) WriteToken(token string) error {
+	if token == "" {
+		return nil
+	}
+
+	// Only the latest token matters
+	select {
+	case <-ts.tokenCh:
+	default:
+	}
+	ts.tokenCh <- token
+	return nil
+}
+
+// Run renders the templates until the context is canceled
+func (ts *Server) Run(ctx context.Context) {
+	ts.logger.Info("starting template server")
+	defer func() {
+		ts.resetSecrets()
+		ts.logger.Info("template server stopped")
+		close(ts.DoneCh)
+	}()
+
+	var client *api.Client
+	var next <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case token := <-ts.tokenCh:
+			var err error
+			client, err = ts.client.Clone()
+			if err != nil {
+				ts.logger.Error("error creating client for templates", "error", err)
+				continue
+			}
+			client.SetToken(token)
+			ts.resetSecrets()
+
+		case <-ts.refreshCh:
+		case <-next:
+		}
+
+		if client == nil {
+			continue
+		}
+
+		refreshAt, err := ts.renderAll(ctx, client)
+		if err != nil {
+			backoff := 2*time.Second + time.Duration(ts.random.Int63()%int64(time.Second*2))
+			ts.logger.Error("error rendering templates, retrying", "error", err, "backoff", backoff.String())
+			next = time.After(backoff)
+			continue
+		}
+		if ts.exitAfterRender {
+			ts.logger.Info("templates rendered")
+			return
+		}
+		next = time.After(time.Until(refreshAt))
+	}
+}
+
+// renderAll renders every template, returning when the secrets they use
+// must next be read again
+func (ts *Server) renderAll(ctx context.Context, client *api.Client) (time.Time, error) {
+	refreshAt := time.Now().Add(defaultRefreshInterval)
+
+	var result error
+	for _, t := range ts.templates {
+		used, err := ts.render(ctx, client, t)
+		if err != nil {
+			result = multierror.Append(result, errwrap.Wrapf(fmt.Sprintf("error rendering %q: {{err}}", describe(t)), err))
+			continue
+		}
+		ts.l.Lock()
+		for _, s := range used {
+			if s.refreshAt.Before(refreshAt) {
+				refreshAt = s.refreshAt
+			}
+		}
+		ts.l.Unlock()
+	}
+
+	return refreshAt, result
+}
+
+// render renders a template, updating its destination and the files it
+// writes, and running its command if any of them changed
+func (ts *Server) render(ctx context.Context, client *api.Client, t *config.Template) ([]*cachedSecret, error) {
+	var used []*cachedSecret
+	files := make(map[string][]byte)
+	tmpl, err := ts.parse(t, func(path string, args ...string) (*api.Secret, error) {
+		s, err := ts.secret(client, path, args)
+		if err != nil {
+			return nil, err
+		}
+		used = append(used, s)
+		return s.secret, nil
+	}, func(name string, contents interface{}) (string, error) {
+		if t.DestinationDir == "" {
+			return "", errors.New("the file function requires 'destination_dir'")
+		}
+		if err := validateFileName(name); err != nil {
+			return "", err
+		}
+		files[name] = []byte(fmt.Sprint(contents))
+		return "", nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, err
+	}
+
+	owner := output.NoOwner
+	if t.UID != nil {
+		owner.UID = *t.UID
+	}
+	if t.GID != nil {
+		owner.GID = *t.GID
+	}
+
+	changed := false
+	write := func(path string, contents []byte) error {
+		written, err := output.WriteFile(path, contents, t.Perms, owner)
+		if err != nil {
+			return err
+		}
+		if written {
+			ts.logger.Info("rendered template", "destination", path)
+			changed = true
+		}
+		return nil
+	}
+
+	if t.Destination != "" {
+		if err := write(t.Destination, buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := write(filepath.Join(t.DestinationDir, name), files[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	// Remove the files the template no longer writes
+	for name := range ts.files[t] {
+		if _, ok := files[name]; ok {
+			continue
+		}
+		path := filepath.Join(t.DestinationDir, name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		ts.logger.Info("removed file no longer rendered", "destination", path)
+		changed = true
+	}
+	ts.files[t] = files
+
+	if !changed {
+		return used, nil
+	}
+
+	notification := &output.Notification{
+		Command:        t.Command,
+		CommandTimeout: t.CommandTimeout,
+		Signal:         t.Signal,
+		SignalPIDFile:  t.SignalPIDFile,
+	}
+	if err := notification.Run(ctx); err != nil {
+		return nil, err
+	}
+
+	return used, nil
+}
+
+// validateFileName checks that a file written with the file function is
+// directly in the destination directory
+func validateFileName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("file name must not be empty")
+	case strings.HasPrefix(name, "."):
+		return fmt.Errorf("file name %q must not start with a dot", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("file name %q must not contain a path separator", name)
+	}
+	return nil
+}
+
+// describe names a template by where it renders
+func describe(t *config.Template) string {
+	if t.Destination != "" {
+		return t.Destination
+	}
+	return t.DestinationDir
+}
+
+// parse parses the template, reading secrets and writing files with the
+// given functions
+func (ts *Server) parse(t *config.Template, secretFunc func(string, ...string) (*api.Secret, error), fileFunc func(string, interface{}) (string, error)) (*template.Template, error) {
+	contents := t.Contents
+	if t.Source != "" {
+		b, err := ioutil.ReadFile(t.Source)
+		if err != nil {
+			return nil, err
+		}
+		contents = string(b)
+	}
+
+	if secretFunc == nil {
+		secretFunc = func(string, ...string) (*api.Secret, error) {
+			return nil, nil
+		}
+	}
+	if fileFunc == nil {
+		fileFunc = func(string, interface{}) (string, error) {
+			return "", nil
+		}
+	}
+
+	return template.New(describe(t)).Funcs(template.FuncMap{
+		"secret": secretFunc,
+		"file":   fileFunc,
+		"env":    os.Getenv,
+		"toJSON": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(contents)
+}
+
+// secret reads a secret, or writes the given key=value arguments and returns
+// the response, reusing the result until it must be read again
+func (ts *Server) secret(client *api.Client, path string, args []string) (*cachedSecret, error) {
+	key := strings.Join(append([]string{path}, args...), "\x00")
+
+	ts.l.Lock()
+	defer ts.l.Unlock()
+
+	old, ok := ts.secrets[key]
+	if ok && time.Now().Before(old.refreshAt) {
+		return old, nil
+	}
+
+	var secret *api.Secret
+	var err error
+	if len(args) == 0 {
+		secret, err = client.Logical().Read(path)
+	} else {
+		data := make(map[string]interface{}, len(args))
+		for _, arg := range args {
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid argument %q to secret %q, expected key=value", arg, path)
+			}
+			data[parts[0]] = parts[1]
+		}
+		secret, err = client.Logical().Write(path, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("no secret found at %q", path)
+	}
+
+	refreshIn := defaultRefreshInterval
+	renewable := false
+	switch {
+	case secret.LeaseDuration > 0:
+		refreshIn = time.Duration(secret.LeaseDuration) * time.Second * 2 / 3
+		renewable = secret.Renewable && secret.LeaseID != ""
+	case secret.Auth != nil && secret.Auth.LeaseDuration > 0:
+		refreshIn = time.Duration(secret.Auth.LeaseDuration) * time.Second * 2 / 3
+		renewable = secret.Auth.Renewable
+	}
+
+	s := &cachedSecret{
+		secret:    secret,
+		refreshAt: time.Now().Add(refreshIn),
+	}
+	if renewable {
+		renewer, err := client.NewRenewer(&api.RenewerInput{
+			Secret: secret,
+		})
+		if err != nil {
+			ts.logger.Error("error creating renewer, reading secret again instead", "path", path, "error", err)
+		} else {
+			s.renewer = renewer
+			s.stopCh = make(chan struct{})
+			go renewer.Renew()
+			go ts.watch(path, s)
+		}
+	}
+	ts.secrets[key] = s
+
+	// The secret read before is superseded, so its lease is of no more use
+	if old != nil {
+		old.stop()
+		if old.secret.LeaseID != "" {
+			if err := client.Sys().Revoke(old.secret.LeaseID); err != nil {
+				ts.logger.Warn("error revoking lease of superseded secret", "path", path, "error", err)
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// watch keeps a secret until its lease is due to be read again, pushing that
+// time back whenever the lease is renewed, and wakes the server once the
+// renewal stops
+func (ts *Server) watch(path string, s *cachedSecret) {
+	for {
+		select {
+		case <-s.stopCh:
+			return
+
+		case err := <-s.renewer.DoneCh():
+			select {
+			case <-s.stopCh:
+				return
+			default:
+			}
+			if err != nil {
+				ts.logger.Warn("renewal of secret stopped, reading it again", "path", path, "error", err)
+			}
+			ts.l.Lock()
+			s.refreshAt = time.Now()
+			ts.l.Unlock()
+
+			select {
+			case ts.refreshCh <- struct{}{}:
+			default:
+			}
+			return
+
+		case renewal := <-s.renewer.RenewCh():
+			if renewal.Secret == nil {
+				continue
+			}
+			ttl := renewal.Secret.LeaseDuration
+			if renewal.Secret.Auth != nil {
+				ttl = renewal.Secret.Auth.LeaseDuration
+			}
+			ts.l.Lock()
+			s.refreshAt = renewal.RenewedAt.Add(time.Duration(ttl) * time.Second)
+			ts.l.Unlock()
+		}
+	}
+}
+
+// stop stops renewing the secret
+func (s *cachedSecret) stop() {
+	if s.renewer != nil {
+		close(s.stopCh)
+		s.renewer.Stop()
+	}
+}
+
+// resetSecrets forgets the secrets read, so that they are read again. Their
+// leases belong to the token they were read with and go away along with it.
+func (ts *Server) resetSecrets() {
+	ts.l.Lock()
+	defer ts.l.Unlock()
+
+	for _, s := range ts.secrets {
+		s.stop()
+	}
+	ts.secrets = make(map[string]*cachedSecret)
+}
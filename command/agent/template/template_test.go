@@ -0,0 +1,348 @@
+package template
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/helper/logging"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestServer_Render(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	client, err := api.NewClient(&api.Config{Address: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	if err := client.Sys().Mount("kv", &api.MountInput{Type: "kv"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("kv/foo", map[string]interface{}{"password": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "agent.template.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dest := filepath.Join(dir, "password")
+	counter := filepath.Join(dir, "counter")
+
+	// The command is only run when the destination changes
+	ts, err := NewServer(&ServerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		Client: client,
+		Templates: []*config.Template{
+			&config.Template{
+				Contents:    `{{ with secret "kv/foo" }}{{ .Data.password }}{{ end }}`,
+				Destination: dest,
+				Perms:       0600,
+				Command:     "echo rendered >> " + counter,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	go ts.Run(ctx)
+	defer func() {
+		cancelFunc()
+		<-ts.DoneCh
+	}()
+
+	waitFor := func(path, expected string) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			b, _ := ioutil.ReadFile(path)
+			if string(b) == expected {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %q in %s, got %q", expected, path, b)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	if err := ts.WriteToken(token); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(dest, "bar")
+	waitFor(counter, "rendered\n")
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("bad perms: %v", info.Mode().Perm())
+	}
+
+	// Rendering the same contents with a new token does not rewrite the
+	// destination, while changed contents are
+	if err := ts.WriteToken(token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("kv/foo", map[string]interface{}{"password": "baz"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.WriteToken(token); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(dest, "baz")
+	waitFor(counter, "rendered\nrendered\n")
+}
+
+func TestServer_ExitAfterRender(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	client, err := api.NewClient(&api.Config{Address: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	if err := client.Sys().Mount("kv", &api.MountInput{Type: "kv"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("kv/foo", map[string]interface{}{"password": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "agent.template.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dest := filepath.Join(dir, "password")
+
+	ts, err := NewServer(&ServerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		Client: client,
+		Templates: []*config.Template{
+			&config.Template{
+				Contents:    `{{ with secret "kv/foo" }}{{ .Data.password }}{{ end }}`,
+				Destination: dest,
+				Perms:       0600,
+			},
+		},
+		ExitAfterRender: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	go ts.Run(ctx)
+
+	if err := ts.WriteToken(token); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ts.DoneCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the server did not stop after rendering")
+	}
+
+	b, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "bar" {
+		t.Fatalf("bad: %q", b)
+	}
+}
+
+func TestServer_RenderFiles(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	client, err := api.NewClient(&api.Config{Address: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	if err := client.Sys().Mount("kv", &api.MountInput{Type: "kv"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("kv/foo", map[string]interface{}{"cert": "foo", "key": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "agent.template.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Each key of the secret is written to its own file
+	ts, err := NewServer(&ServerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		Client: client,
+		Templates: []*config.Template{
+			&config.Template{
+				Contents:       `{{ with secret "kv/foo" }}{{ range $k, $v := .Data }}{{ file $k $v }}{{ end }}{{ end }}`,
+				DestinationDir: dir,
+				Perms:          0600,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	go ts.Run(ctx)
+	defer func() {
+		cancelFunc()
+		<-ts.DoneCh
+	}()
+
+	waitFor := func(path, expected string, exists bool) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			b, err := ioutil.ReadFile(path)
+			if exists && string(b) == expected || !exists && os.IsNotExist(err) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %q in %s, got %q (%v)", expected, path, b, err)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	if err := ts.WriteToken(token); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(filepath.Join(dir, "cert"), "foo", true)
+	waitFor(filepath.Join(dir, "key"), "bar", true)
+
+	info, err := os.Stat(filepath.Join(dir, "key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("bad perms: %v", info.Mode().Perm())
+	}
+
+	// Files the template no longer writes are removed
+	if _, err := client.Logical().Write("kv/foo", map[string]interface{}{"cert": "baz"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.WriteToken(token); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(filepath.Join(dir, "cert"), "baz", true)
+	waitFor(filepath.Join(dir, "key"), "", false)
+}
+
+func TestServer_SecretLease(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	client, err := api.NewClient(&api.Config{Address: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	if _, err := client.Logical().Write("secret/foo", map[string]interface{}{"password": "bar", "ttl": "1h"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := NewServer(&ServerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		Client: client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.resetSecrets()
+
+	// A renewable lease is renewed rather than read again
+	first, err := ts.secret(client, "secret/foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.secret.LeaseID == "" || first.renewer == nil {
+		t.Fatalf("expected the lease to be renewed: %#v", first)
+	}
+	if again, err := ts.secret(client, "secret/foo", nil); err != nil || again != first {
+		t.Fatalf("expected the same secret: %v %#v", err, again)
+	}
+
+	// Once its renewal stops the secret is read again, and the lease it
+	// replaces is revoked
+	ts.l.Lock()
+	first.refreshAt = time.Now()
+	ts.l.Unlock()
+	second, err := ts.secret(client, "secret/foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.secret.LeaseID == first.secret.LeaseID {
+		t.Fatalf("expected a new lease: %#v", second.secret)
+	}
+	if _, err := client.Logical().Write("sys/leases/lookup", map[string]interface{}{"lease_id": first.secret.LeaseID}); err == nil {
+		t.Fatal("expected the superseded lease to be revoked")
+	}
+	if _, err := client.Logical().Write("sys/leases/lookup", map[string]interface{}{"lease_id": second.secret.LeaseID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateFileName(t *testing.T) {
+	for _, name := range []string{"", ".hidden", "../foo", "foo/bar", `foo\bar`} {
+		if err := validateFileName(name); err == nil {
+			t.Fatalf("expected error for %q", name)
+		}
+	}
+	if err := validateFileName("tls.crt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewServer_InvalidTemplate(t *testing.T) {
+	client, err := api.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewServer(&ServerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		Client: client,
+		Templates: []*config.Template{
+			&config.Template{
+				Contents:    `{{ with secret "kv/foo" }}`,
+				Destination: "/tmp/foo",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
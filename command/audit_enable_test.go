@@ -46,6 +46,12 @@ func TestAuditEnableCommand_Run(t *testing.T) {
 			"Success! Enabled the file audit device at: file/",
 			0,
 		},
+		{
+			"enable_format",
+			[]string{"-format", "json", "file", "file_path=discard"},
+			"Success! Enabled the file audit device at: file/",
+			0,
+		},
 		{
 			"enable_path",
 			[]string{
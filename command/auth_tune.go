@@ -18,14 +18,24 @@ var _ cli.CommandAutocomplete = (*AuthTuneCommand)(nil)
 type AuthTuneCommand struct {
 	*BaseCommand
 
-	flagAuditNonHMACRequestKeys  []string
-	flagAuditNonHMACResponseKeys []string
-	flagDefaultLeaseTTL          time.Duration
-	flagDescription              string
-	flagListingVisibility        string
-	flagMaxLeaseTTL              time.Duration
-	flagOptions                  map[string]string
-	flagVersion                  int
+	flagAuditNonHMACRequestKeys   []string
+	flagAuditNonHMACResponseKeys  []string
+	flagDefaultLeaseTTL           time.Duration
+	flagDefaultRenewalIncrement   time.Duration
+	flagDescription               string
+	flagListingVisibility         string
+	flagLockoutDuration           time.Duration
+	flagLockoutIPThreshold        int
+	flagLockoutThreshold          int
+	flagMaxInFlightRequests       int
+	flagMaxLeaseTTL               time.Duration
+	flagMaxQueuedRequests         int
+	flagMaxRenewalIncrement       time.Duration
+	flagOptions                   map[string]string
+	flagPassthroughRequestHeaders []string
+	flagRenewalGracePeriod        time.Duration
+	flagTokenType                 string
+	flagVersion                   int
 }
 
 func (c *AuthTuneCommand) Synopsis() string {
@@ -44,6 +54,10 @@ Usage: vault auth tune [options] PATH
 
       $ vault auth tune -default-lease-ttl=72h github/
 
+  Make logins to the approle auth method issue batch tokens:
+
+      $ vault auth tune -token-type=batch approle/
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -79,6 +93,15 @@ func (c *AuthTuneCommand) Flags() *FlagSets {
 			"or a previously configured value for the auth method.",
 	})
 
+	f.DurationVar(&DurationVar{
+		Name:       flagNameDefaultRenewalIncrement,
+		Target:     &c.flagDefaultRenewalIncrement,
+		Completion: complete.PredictAnything,
+		Usage: "The increment of the renewals of the leases of this auth method " +
+			"requesting none, in place of the TTL of the backend. 0 leaves the " +
+			"Vault server's globally configured value, if any.",
+	})
+
 	f.StringVar(&StringVar{
 		Name:   flagNameDescription,
 		Target: &c.flagDescription,
@@ -93,6 +116,55 @@ func (c *AuthTuneCommand) Flags() *FlagSets {
 			"endpoint.",
 	})
 
+	f.DurationVar(&DurationVar{
+		Name:       flagNameLockoutDuration,
+		Target:     &c.flagLockoutDuration,
+		Completion: complete.PredictAnything,
+		Usage: "How long a user or client address stays locked out of this auth " +
+			"method, and how long its failed logins are counted. 0 uses the " +
+			"default of 15 minutes.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:   flagNameLockoutIPThreshold,
+		Target: &c.flagLockoutIPThreshold,
+		Usage: "The number of consecutive failed logins from a client address, " +
+			"for any user, after which the address is locked out of this auth " +
+			"method. 0 never locks addresses out.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:   flagNameLockoutThreshold,
+		Target: &c.flagLockoutThreshold,
+		Usage: "The number of consecutive failed logins after which a user is " +
+			"locked out of this auth method. 0 never locks users out.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:   flagNameMaxInFlightRequests,
+		Target: &c.flagMaxInFlightRequests,
+		Usage: "The maximum number of requests the auth method handles " +
+			"concurrently. Further requests wait in a queue, or are rejected " +
+			"once the queue is full. 0 leaves the auth method unlimited.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:   flagNameMaxQueuedRequests,
+		Target: &c.flagMaxQueuedRequests,
+		Usage: "The maximum number of requests that wait for the auth method once " +
+			"it handles -max-in-flight-requests. 0 rejects the requests " +
+			"right away.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       flagNameMaxRenewalIncrement,
+		Target:     &c.flagMaxRenewalIncrement,
+		Completion: complete.PredictAnything,
+		Usage: "The most a renewal can extend a lease of this auth method by. Larger " +
+			"increments are capped. 0 leaves the Vault server's globally " +
+			"configured value, if any.",
+	})
+
 	f.DurationVar(&DurationVar{
 		Name:       "max-lease-ttl",
 		Target:     &c.flagMaxLeaseTTL,
@@ -112,6 +184,30 @@ func (c *AuthTuneCommand) Flags() *FlagSets {
 			"This can be specified multiple times.",
 	})
 
+	f.StringSliceVar(&StringSliceVar{
+		Name:   flagNamePassthroughRequestHeaders,
+		Target: &c.flagPassthroughRequestHeaders,
+		Usage: "Comma-separated string or list of request header values that " +
+			"will be sent to the backend",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       flagNameRenewalGracePeriod,
+		Target:     &c.flagRenewalGracePeriod,
+		Completion: complete.PredictAnything,
+		Usage: "How close to its max TTL a renewal extends a lease of this " +
+			"auth method all the way to it, rather than leaving a shorter " +
+			"remainder. 0 leaves the Vault server's globally configured value, " +
+			"if any.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:   flagNameTokenType,
+		Target: &c.flagTokenType,
+		Usage: "The type of tokens logins to the auth method issue, \"service\" " +
+			"or \"batch\". \"default\" issues service tokens.",
+	})
+
 	f.IntVar(&IntVar{
 		Name:    "version",
 		Target:  &c.flagVersion,
@@ -184,6 +280,46 @@ func (c *AuthTuneCommand) Run(args []string) int {
 		if fl.Name == flagNameListingVisibility {
 			mountConfigInput.ListingVisibility = c.flagListingVisibility
 		}
+
+		if fl.Name == flagNamePassthroughRequestHeaders {
+			mountConfigInput.PassthroughRequestHeaders = c.flagPassthroughRequestHeaders
+		}
+
+		if fl.Name == flagNameTokenType {
+			mountConfigInput.TokenType = c.flagTokenType
+		}
+
+		if fl.Name == flagNameMaxInFlightRequests {
+			mountConfigInput.MaxInFlightRequests = &c.flagMaxInFlightRequests
+		}
+
+		if fl.Name == flagNameMaxQueuedRequests {
+			mountConfigInput.MaxQueuedRequests = &c.flagMaxQueuedRequests
+		}
+
+		if fl.Name == flagNameDefaultRenewalIncrement {
+			mountConfigInput.DefaultRenewalIncrement = c.flagDefaultRenewalIncrement.String()
+		}
+
+		if fl.Name == flagNameMaxRenewalIncrement {
+			mountConfigInput.MaxRenewalIncrement = c.flagMaxRenewalIncrement.String()
+		}
+
+		if fl.Name == flagNameRenewalGracePeriod {
+			mountConfigInput.RenewalGracePeriod = c.flagRenewalGracePeriod.String()
+		}
+
+		if fl.Name == flagNameLockoutThreshold {
+			mountConfigInput.LockoutThreshold = &c.flagLockoutThreshold
+		}
+
+		if fl.Name == flagNameLockoutIPThreshold {
+			mountConfigInput.LockoutIPThreshold = &c.flagLockoutIPThreshold
+		}
+
+		if fl.Name == flagNameLockoutDuration {
+			mountConfigInput.LockoutDuration = c.flagLockoutDuration.String()
+		}
 	})
 
 	// Append /auth (since that's where auths live) and a trailing slash to
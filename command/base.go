@@ -44,7 +44,8 @@ type BaseCommand struct {
 	flagFormat string
 	flagField  string
 
-	flagMFA []string
+	flagMFA    []string
+	flagReason string
 
 	tokenHelper token.TokenHelper
 
@@ -119,6 +120,10 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 
 	client.SetMFACreds(c.flagMFA)
 
+	if c.flagReason != "" {
+		client = client.WithReason(c.flagReason)
+	}
+
 	c.client = client
 
 	return client, nil
@@ -276,6 +281,16 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 				Completion: complete.PredictAnything,
 				Usage:      "Supply MFA credentials as part of X-Vault-MFA header.",
 			})
+
+			f.StringVar(&StringVar{
+				Name:       "reason",
+				Target:     &c.flagReason,
+				Default:    "",
+				EnvVar:     "VAULT_REASON",
+				Completion: complete.PredictAnything,
+				Usage: "Justification for the request, which is sent in the " +
+					"X-Vault-Reason header and recorded in the audit log.",
+			})
 		}
 
 		if bit&(FlagSetOutputField|FlagSetOutputFormat) != 0 {
@@ -307,6 +322,20 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 			}
 		}
 
+		// The format is read from the arguments of every command before it
+		// runs, so accept it on the commands without structured output too
+		// rather than failing on an unknown flag.
+		if bit&FlagSetOutputFormat == 0 {
+			f := set.NewFlagSet("Output Options")
+			f.StringVar(&StringVar{
+				Name:    "format",
+				Target:  &c.flagFormat,
+				Default: "table",
+				EnvVar:  EnvVaultFormat,
+				Hidden:  true,
+			})
+		}
+
 		c.flags = set
 	})
 
@@ -377,14 +406,24 @@ func (fs *FlagSets) Help() string {
 	var out bytes.Buffer
 
 	for _, set := range fs.flagSets {
-		printFlagTitle(&out, set.name+":")
+		var visible []*flag.Flag
 		set.VisitAll(func(f *flag.Flag) {
 			// Skip any hidden flags
 			if v, ok := f.Value.(FlagVisibility); ok && v.Hidden() {
 				return
 			}
-			printFlagDetail(&out, f)
+			visible = append(visible, f)
 		})
+
+		// Skip sets that only have hidden flags
+		if len(visible) == 0 {
+			continue
+		}
+
+		printFlagTitle(&out, set.name+":")
+		for _, f := range visible {
+			printFlagDetail(&out, f)
+		}
 	}
 
 	return strings.TrimRight(out.String(), "\n")
@@ -80,15 +80,21 @@ func (b *BaseCommand) PredictVaultAvailableMounts() complete.Predictor {
 	// This list does not contain deprecated backends. At present, there is no
 	// API that lists all available secret backends, so this is hard-coded :(.
 	return complete.PredictSet(
+		"apitoken",
 		"aws",
 		"consul",
 		"database",
 		"generic",
+		"kubernetes",
+		"ldap",
+		"mongodbatlas",
 		"pki",
 		"plugin",
 		"rabbitmq",
+		"registry",
 		"ssh",
 		"totp",
+		"transform",
 		"transit",
 	)
 }
@@ -323,7 +323,9 @@ func TestPredict_Plugins(t *testing.T) {
 			client,
 			[]string{
 				"cassandra-database-plugin",
+				"clickhouse-database-plugin",
 				"hana-database-plugin",
+				"influxdb-database-plugin",
 				"mongodb-database-plugin",
 				"mssql-database-plugin",
 				"mysql-aurora-database-plugin",
@@ -331,6 +333,7 @@ func TestPredict_Plugins(t *testing.T) {
 				"mysql-legacy-database-plugin",
 				"mysql-rds-database-plugin",
 				"postgresql-database-plugin",
+				"redis-database-plugin",
 			},
 		},
 	}
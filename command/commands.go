@@ -6,28 +6,34 @@ import (
 	"os/signal"
 	"syscall"
 
-	ad "github.com/hashicorp/vault-plugin-secrets-ad/plugin"
-	gcp "github.com/hashicorp/vault-plugin-secrets-gcp/plugin"
-	kv "github.com/hashicorp/vault-plugin-secrets-kv"
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/physical"
 	"github.com/hashicorp/vault/version"
 	"github.com/mitchellh/cli"
 
+	"github.com/hashicorp/vault/builtin/logical/ad"
+	"github.com/hashicorp/vault/builtin/logical/apitoken"
 	"github.com/hashicorp/vault/builtin/logical/aws"
 	"github.com/hashicorp/vault/builtin/logical/cassandra"
 	"github.com/hashicorp/vault/builtin/logical/consul"
 	"github.com/hashicorp/vault/builtin/logical/database"
+	"github.com/hashicorp/vault/builtin/logical/gcp"
+	"github.com/hashicorp/vault/builtin/logical/kubernetes"
+	"github.com/hashicorp/vault/builtin/logical/kv"
+	"github.com/hashicorp/vault/builtin/logical/ldap"
 	"github.com/hashicorp/vault/builtin/logical/mongodb"
+	"github.com/hashicorp/vault/builtin/logical/mongodbatlas"
 	"github.com/hashicorp/vault/builtin/logical/mssql"
 	"github.com/hashicorp/vault/builtin/logical/mysql"
 	"github.com/hashicorp/vault/builtin/logical/nomad"
 	"github.com/hashicorp/vault/builtin/logical/pki"
 	"github.com/hashicorp/vault/builtin/logical/postgresql"
 	"github.com/hashicorp/vault/builtin/logical/rabbitmq"
+	"github.com/hashicorp/vault/builtin/logical/registry"
 	"github.com/hashicorp/vault/builtin/logical/ssh"
 	"github.com/hashicorp/vault/builtin/logical/totp"
+	"github.com/hashicorp/vault/builtin/logical/transform"
 	"github.com/hashicorp/vault/builtin/logical/transit"
 	"github.com/hashicorp/vault/builtin/plugin"
 
@@ -88,6 +94,24 @@ const (
 	flagNameListingVisibility = "listing-visibility"
 	// flagNamePassthroughRequestHeaders is the flag name used to set passthrough request headers to the backend
 	flagNamePassthroughRequestHeaders = "passthrough-request-headers"
+	// flagNameTokenType is the flag name used to set the type of tokens an auth method issues
+	flagNameTokenType = "token-type"
+	// flagNameMaxInFlightRequests is the flag name used to limit the requests a mount handles concurrently
+	flagNameMaxInFlightRequests = "max-in-flight-requests"
+	// flagNameMaxQueuedRequests is the flag name used to limit the requests waiting for a mount
+	flagNameMaxQueuedRequests = "max-queued-requests"
+	// flagNameDefaultRenewalIncrement is the flag name used to set the increment of renewals requesting none
+	flagNameDefaultRenewalIncrement = "default-renewal-increment"
+	// flagNameMaxRenewalIncrement is the flag name used to cap the increment of renewals
+	flagNameMaxRenewalIncrement = "max-renewal-increment"
+	// flagNameRenewalGracePeriod is the flag name used to set how close to their max TTL renewals extend leases to it
+	flagNameRenewalGracePeriod = "renewal-grace-period"
+	// flagNameLockoutThreshold is the flag name used to set the failed logins after which a user is locked out
+	flagNameLockoutThreshold = "lockout-threshold"
+	// flagNameLockoutIPThreshold is the flag name used to set the failed logins after which a client address is locked out
+	flagNameLockoutIPThreshold = "lockout-ip-threshold"
+	// flagNameLockoutDuration is the flag name used to set how long lockouts last
+	flagNameLockoutDuration = "lockout-duration"
 )
 
 var (
@@ -116,24 +140,30 @@ var (
 	}
 
 	logicalBackends = map[string]logical.Factory{
-		"ad":         ad.Factory,
-		"aws":        aws.Factory,
-		"cassandra":  cassandra.Factory,
-		"consul":     consul.Factory,
-		"database":   database.Factory,
-		"gcp":        gcp.Factory,
-		"kv":         kv.Factory,
-		"mongodb":    mongodb.Factory,
-		"mssql":      mssql.Factory,
-		"mysql":      mysql.Factory,
-		"nomad":      nomad.Factory,
-		"pki":        pki.Factory,
-		"plugin":     plugin.Factory,
-		"postgresql": postgresql.Factory,
-		"rabbitmq":   rabbitmq.Factory,
-		"ssh":        ssh.Factory,
-		"totp":       totp.Factory,
-		"transit":    transit.Factory,
+		"ad":           ad.Factory,
+		"apitoken":     apitoken.Factory,
+		"aws":          aws.Factory,
+		"cassandra":    cassandra.Factory,
+		"consul":       consul.Factory,
+		"database":     database.Factory,
+		"gcp":          gcp.Factory,
+		"kubernetes":   kubernetes.Factory,
+		"kv":           kv.Factory,
+		"ldap":         ldap.Factory,
+		"mongodb":      mongodb.Factory,
+		"mongodbatlas": mongodbatlas.Factory,
+		"mssql":        mssql.Factory,
+		"mysql":        mysql.Factory,
+		"nomad":        nomad.Factory,
+		"pki":          pki.Factory,
+		"plugin":       plugin.Factory,
+		"postgresql":   postgresql.Factory,
+		"rabbitmq":     rabbitmq.Factory,
+		"registry":     registry.Factory,
+		"ssh":          ssh.Factory,
+		"totp":         totp.Factory,
+		"transform":    transform.Factory,
+		"transit":      transit.Factory,
 	}
 
 	physicalBackends = map[string]physical.Factory{
@@ -321,11 +351,34 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				Handlers:    loginHandlers,
 			}, nil
 		},
+		"monitor": func() (cli.Command, error) {
+			return &MonitorCommand{
+				BaseCommand: getBaseCommand(),
+				ShutdownCh:  MakeShutdownCh(),
+			}, nil
+		},
 		"operator": func() (cli.Command, error) {
 			return &OperatorCommand{
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator cleanup": func() (cli.Command, error) {
+			return &OperatorCleanupCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"operator debug": func() (cli.Command, error) {
+			return &OperatorDebugCommand{
+				BaseCommand: getBaseCommand(),
+				ShutdownCh:  MakeShutdownCh(),
+			}, nil
+		},
+		"operator diagnose": func() (cli.Command, error) {
+			return &OperatorDiagnoseCommand{
+				BaseCommand:      getBaseCommand(),
+				PhysicalBackends: physicalBackends,
+			}, nil
+		},
 		"operator generate-root": func() (cli.Command, error) {
 			return &OperatorGenerateRootCommand{
 				BaseCommand: getBaseCommand(),
@@ -341,6 +394,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator mount-export": func() (cli.Command, error) {
+			return &OperatorMountExportCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"operator mount-import": func() (cli.Command, error) {
+			return &OperatorMountImportCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator rekey": func() (cli.Command, error) {
 			return &OperatorRekeyCommand{
 				BaseCommand: getBaseCommand(),
@@ -356,6 +419,12 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator seal-migrate": func() (cli.Command, error) {
+			return &OperatorSealMigrateCommand{
+				BaseCommand:      getBaseCommand(),
+				PhysicalBackends: physicalBackends,
+			}, nil
+		},
 		"operator step-down": func() (cli.Command, error) {
 			return &OperatorStepDownCommand{
 				BaseCommand: getBaseCommand(),
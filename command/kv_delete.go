@@ -101,6 +101,11 @@ func (c *KVDeleteCommand) Run(args []string) int {
 		return 2
 	}
 
+	if !v2 && len(c.flagVersions) > 0 {
+		c.UI.Error("Versions not supported on KV Version 1")
+		return 1
+	}
+
 	var secret *api.Secret
 	if v2 {
 		secret, err = c.deleteV2(path, mountPath, client)
@@ -22,11 +22,11 @@ func (c *KVEnableVersioningCommand) Synopsis() string {
 
 func (c *KVEnableVersioningCommand) Help() string {
 	helpText := `
-Usage: vault kv enable-versions [options] KEY
+Usage: vault kv enable-versioning [options] KEY
 
   This command turns on versioning for the backend at the provided path.
 
-      $ vault kv enable-versions secret
+      $ vault kv enable-versioning secret
 
   Additional flags and more advanced use cases are detailed below.
 
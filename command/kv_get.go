@@ -97,6 +97,11 @@ func (c *KVGetCommand) Run(args []string) int {
 		return 2
 	}
 
+	if !v2 && c.flagVersion > 0 {
+		c.UI.Error("Versions not supported on KV Version 1")
+		return 1
+	}
+
 	var versionParam map[string]string
 
 	if v2 {
@@ -382,6 +382,12 @@ func TestKVGetCommand(t *testing.T) {
 			"foo",
 			0,
 		},
+		{
+			"v1_read_version",
+			[]string{"--version", "1", "secret/read/foo"},
+			"Versions not supported on KV Version 1",
+			1,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -439,6 +445,203 @@ func TestKVGetCommand(t *testing.T) {
 	})
 }
 
+func testKVDeleteCommand(tb testing.TB) (*cli.MockUi, *KVDeleteCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVDeleteCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestKVDeleteCommand(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"v1_versions",
+			[]string{"-versions", "1", "secret/delete/foo"},
+			"Versions not supported on KV Version 1",
+			1,
+		},
+		{
+			"v1_delete",
+			[]string{"secret/delete/foo"},
+			"Success!",
+			0,
+		},
+		{
+			"v2_delete",
+			[]string{"kv/delete/foo"},
+			"Success!",
+			0,
+		},
+		{
+			"v2_delete_versions",
+			[]string{"-versions", "1", "kv/delete/foo"},
+			"Success!",
+			0,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+				if err := client.Sys().Mount("kv/", &api.MountInput{
+					Type: "kv-v2",
+				}); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := client.Logical().Write("secret/delete/foo", map[string]interface{}{
+					"foo": "bar",
+				}); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := client.Logical().Write("kv/data/delete/foo", map[string]interface{}{
+					"data": map[string]interface{}{
+						"foo": "bar",
+					},
+				}); err != nil {
+					t.Fatal(err)
+				}
+
+				ui, cmd := testKVDeleteCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+		if err := client.Sys().Mount("kv/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range []string{"one", "two"} {
+			if _, err := client.Logical().Write("kv/data/versions/foo", map[string]interface{}{
+				"data": map[string]interface{}{
+					"foo": v,
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		readVersion := func(version string) *api.Secret {
+			t.Helper()
+			secret, err := kvReadRequest(client, "kv/data/versions/foo", map[string]string{
+				"version": version,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return secret
+		}
+
+		// Deleting a version only marks it as deleted, so that it can be
+		// undeleted until it is destroyed
+		_, cmd := testKVDeleteCommand(t)
+		cmd.client = client
+		if code := cmd.Run([]string{"-versions", "1", "kv/versions/foo"}); code != 0 {
+			t.Fatalf("expected 0 to be %d", code)
+		}
+		if secret := readVersion("1"); secret != nil && secret.Data["data"] != nil {
+			t.Fatalf("expected version 1 to be deleted, got %#v", secret.Data)
+		}
+		if secret := readVersion("2"); secret == nil || secret.Data["data"] == nil {
+			t.Fatal("expected version 2 to be kept")
+		}
+
+		_, undeleteCmd := testKVUndeleteCommand(t)
+		undeleteCmd.client = client
+		if code := undeleteCmd.Run([]string{"-versions", "1", "kv/versions/foo"}); code != 0 {
+			t.Fatalf("expected 0 to be %d", code)
+		}
+		if secret := readVersion("1"); secret == nil || secret.Data["data"] == nil {
+			t.Fatal("expected version 1 to be undeleted")
+		}
+
+		_, destroyCmd := testKVDestroyCommand(t)
+		destroyCmd.client = client
+		if code := destroyCmd.Run([]string{"-versions", "1", "kv/versions/foo"}); code != 0 {
+			t.Fatalf("expected 0 to be %d", code)
+		}
+		_, undeleteCmd = testKVUndeleteCommand(t)
+		undeleteCmd.client = client
+		undeleteCmd.Run([]string{"-versions", "1", "kv/versions/foo"})
+		if secret := readVersion("1"); secret != nil && secret.Data["data"] != nil {
+			t.Fatalf("expected version 1 to be destroyed, got %#v", secret.Data)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testKVDeleteCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func testKVUndeleteCommand(tb testing.TB) (*cli.MockUi, *KVUndeleteCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVUndeleteCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func testKVDestroyCommand(tb testing.TB) (*cli.MockUi, *KVDestroyCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVDestroyCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
 func testKVMetadataGetCommand(tb testing.TB) (*cli.MockUi, *KVMetadataGetCommand) {
 	tb.Helper()
 
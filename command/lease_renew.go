@@ -49,7 +49,7 @@ Usage: vault lease renew [options] ID
 }
 
 func (c *LeaseRenewCommand) Flags() *FlagSets {
-	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
 	f := set.NewFlagSet("Command Options")
 
 	f.DurationVar(&DurationVar{
@@ -124,5 +124,9 @@ func (c *LeaseRenewCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagField != "" {
+		return PrintRawField(c.UI, secret, c.flagField)
+	}
+
 	return OutputSecret(c.UI, secret)
 }
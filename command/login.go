@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/vault/api"
@@ -17,6 +18,12 @@ type LoginHandler interface {
 	Help() string
 }
 
+// LoginHandlerKeys is optionally implemented by auth handlers, returning the
+// keys of the K=V configuration they accept so that they can be completed.
+type LoginHandlerKeys interface {
+	AuthKeys() []string
+}
+
 type LoginCommand struct {
 	*BaseCommand
 
@@ -87,11 +94,18 @@ func (c *LoginCommand) Flags() *FlagSets {
 
 	f := set.NewFlagSet("Command Options")
 
+	// Only the auth methods with a handler can be used to log in
+	methods := make([]string, 0, len(c.Handlers))
+	for method := range c.Handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
 	f.StringVar(&StringVar{
 		Name:       "method",
 		Target:     &c.flagMethod,
 		Default:    "token",
-		Completion: c.PredictVaultAvailableAuths(),
+		Completion: complete.PredictSet(methods...),
 		Usage: "Type of authentication to use such as \"userpass\" or " +
 			"\"ldap\". Note this corresponds to the TYPE, not the enabled path. " +
 			"Use -path to specify the path where the authentication is enabled.",
@@ -146,7 +160,41 @@ func (c *LoginCommand) Flags() *FlagSets {
 }
 
 func (c *LoginCommand) AutocompleteArgs() complete.Predictor {
-	return nil
+	return complete.PredictFunc(func(args complete.Args) []string {
+		// Find the auth method being used, which is the token auth method
+		// unless given
+		authMethod := "token"
+		for i, arg := range args.Completed {
+			arg = strings.TrimLeft(arg, "-")
+			switch {
+			case strings.HasPrefix(arg, "method="):
+				authMethod = strings.TrimPrefix(arg, "method=")
+			case arg == "method" && i+1 < len(args.Completed):
+				authMethod = args.Completed[i+1]
+			}
+		}
+
+		handler, ok := c.Handlers[sanitizePath(authMethod)].(LoginHandlerKeys)
+		if !ok {
+			return nil
+		}
+
+		// Only suggest the keys which have not been given yet
+		given := make(map[string]bool)
+		for _, arg := range args.Completed {
+			if i := strings.Index(arg, "="); i > 0 && !strings.HasPrefix(arg, "-") {
+				given[arg[:i]] = true
+			}
+		}
+
+		var keys []string
+		for _, key := range handler.AuthKeys() {
+			if !given[key] {
+				keys = append(keys, key+"=")
+			}
+		}
+		return keys
+	})
 }
 
 func (c *LoginCommand) AutocompleteFlags() complete.Flags {
@@ -1,10 +1,13 @@
 package command
 
 import (
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
 	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
 
 	"github.com/hashicorp/vault/api"
 	credToken "github.com/hashicorp/vault/builtin/credential/token"
@@ -494,3 +497,65 @@ func TestLoginCommand_Run(t *testing.T) {
 		assertNoTabs(t, cmd)
 	})
 }
+
+func TestLoginCommand_AutocompleteArgs(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		completed []string
+		exp       []string
+	}{
+		{
+			"default_token",
+			nil,
+			[]string{"lookup=", "token="},
+		},
+		{
+			"method_flag",
+			[]string{"-method=userpass"},
+			[]string{"method=", "passcode=", "password=", "username="},
+		},
+		{
+			"method_flag_separate",
+			[]string{"-method", "userpass", "username=foo"},
+			[]string{"method=", "passcode=", "password="},
+		},
+		{
+			"unknown_method",
+			[]string{"-method=foo"},
+			nil,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, cmd := testLoginCommand(t)
+
+			act := cmd.AutocompleteArgs().Predict(complete.Args{
+				All:       tc.completed,
+				Completed: tc.completed,
+			})
+			if !reflect.DeepEqual(act, tc.exp) {
+				t.Errorf("expected %q to be %q", act, tc.exp)
+			}
+		})
+	}
+
+	t.Run("method_flag_completion", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testLoginCommand(t)
+
+		act := cmd.AutocompleteFlags()["-method"].Predict(complete.Args{})
+		sort.Strings(act)
+		exp := []string{"token", "userpass"}
+		if !reflect.DeepEqual(act, exp) {
+			t.Errorf("expected %q to be %q", act, exp)
+		}
+	})
+}
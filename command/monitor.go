@@ -0,0 +1,124 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*MonitorCommand)(nil)
+var _ cli.CommandAutocomplete = (*MonitorCommand)(nil)
+
+type MonitorCommand struct {
+	*BaseCommand
+
+	flagLogLevel string
+
+	// ShutdownCh, if set, ends the stream
+	ShutdownCh chan struct{}
+}
+
+func (c *MonitorCommand) Synopsis() string {
+	return "Stream the log lines of a Vault server"
+}
+
+func (c *MonitorCommand) Help() string {
+	helpText := `
+Usage: vault monitor [options]
+
+  Streams the lines logged by a Vault server from now on, until interrupted
+  with Ctrl-C. Standby nodes redirect to the active node, so this shows what
+  the active node is doing. Only levels the server logs at can be streamed.
+  This requires a token with sudo capability on sys/monitor.
+
+  Stream the log lines of the active node:
+
+      $ vault monitor
+
+  Stream only warnings and errors:
+
+      $ vault monitor -log-level=warn
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *MonitorCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "log-level",
+		Target:     &c.flagLogLevel,
+		Default:    "info",
+		Completion: complete.PredictSet("trace", "debug", "info", "warn", "error"),
+		Usage: "Lowest level of the lines to stream. This is one of \"trace\", " +
+			"\"debug\", \"info\", \"warn\" or \"error\".",
+	})
+
+	return set
+}
+
+func (c *MonitorCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *MonitorCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *MonitorCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	if log.LevelFromString(c.flagLogLevel) == log.NoLevel {
+		c.UI.Error(fmt.Sprintf("Unknown log level %q", c.flagLogLevel))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	if c.ShutdownCh != nil {
+		go func() {
+			select {
+			case <-c.ShutdownCh:
+				cancelFunc()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	lines, err := client.Sys().Monitor(ctx, c.flagLogLevel)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error starting the stream: %s", err))
+		return 2
+	}
+
+	for line := range lines {
+		c.UI.Output(line)
+	}
+
+	return 0
+}
@@ -0,0 +1,123 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func testMonitorCommand(tb testing.TB) (*cli.MockUi, *MonitorCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &MonitorCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+}
+
+func TestMonitorCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name string
+			args []string
+			out  string
+			code int
+		}{
+			{
+				"too_many_args",
+				[]string{"foo"},
+				"Too many arguments",
+				1,
+			},
+			{
+				"unknown_level",
+				[]string{"-log-level", "verbose"},
+				"Unknown log level",
+				1,
+			},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testMonitorCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		logLines := logging.NewLineBuffer(10)
+
+		client, _, closer := testVaultServerCoreConfig(t, &vault.CoreConfig{
+			DisableMlock:       true,
+			DisableCache:       true,
+			Logger:             defaultVaultLogger,
+			CredentialBackends: defaultVaultCredentialBackends,
+			AuditBackends:      defaultVaultAuditBackends,
+			LogicalBackends:    defaultVaultLogicalBackends,
+			LogLines:           logLines,
+		})
+		defer closer()
+
+		ui, cmd := testMonitorCommand(t)
+		cmd.client = client
+
+		codeCh := make(chan int)
+		go func() {
+			codeCh <- cmd.Run([]string{"-log-level", "warn"})
+		}()
+
+		// Lines are only streamed once the stream is started, so keep
+		// logging for a while
+		for i := 0; i < 10; i++ {
+			fmt.Fprintf(logLines, "2018-08-01T12:00:00.000Z [DEBUG] test: debug line\n")
+			fmt.Fprintf(logLines, "2018-08-01T12:00:00.000Z [WARN ] test: warn line\n")
+			time.Sleep(100 * time.Millisecond)
+		}
+		close(cmd.ShutdownCh)
+
+		select {
+		case code := <-codeCh:
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the stream to end")
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, "test: warn line") {
+			t.Errorf("expected %q to contain the warn line", combined)
+		}
+		if strings.Contains(combined, "test: debug line") {
+			t.Errorf("expected %q not to contain the debug line", combined)
+		}
+	})
+}
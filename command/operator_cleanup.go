@@ -0,0 +1,146 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*OperatorCleanupCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorCleanupCommand)(nil)
+
+type OperatorCleanupCommand struct {
+	*BaseCommand
+
+	flagDelete bool
+}
+
+func (c *OperatorCleanupCommand) Synopsis() string {
+	return "Reports or deletes storage entries left behind by leases and tokens"
+}
+
+func (c *OperatorCleanupCommand) Help() string {
+	helpText := `
+Usage: vault operator cleanup [options]
+
+  Scans the storage of the Vault server for the entries which are no longer
+  used but were never deleted:
+
+    - the leases whose token no longer exists
+    - the leases and tokens which expired over an hour ago but were never
+      revoked
+    - the cubbyholes, including those of response-wrapping tokens, whose
+      token no longer exists
+
+  By default, the entries are only reported. With -delete, the leases and
+  tokens are revoked and the cubbyholes destroyed; the expired leases are
+  revoked with force, ignoring the errors of their backend. This requires a
+  token with sudo capability on sys/storage/cleanup.
+
+  Report the entries left behind:
+
+      $ vault operator cleanup
+
+  Delete them:
+
+      $ vault operator cleanup -delete
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorCleanupCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "delete",
+		Target:  &c.flagDelete,
+		Default: false,
+		Usage: "Delete the entries found rather than only report them. The " +
+			"leases and tokens are revoked and the cubbyholes destroyed.",
+	})
+
+	return set
+}
+
+func (c *OperatorCleanupCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *OperatorCleanupCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorCleanupCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	var result *api.StorageCleanupOutput
+	if c.flagDelete {
+		result, err = client.Sys().StorageCleanup()
+	} else {
+		result, err = client.Sys().StorageCleanupReport()
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error cleaning up storage: %s", err))
+		return 2
+	}
+
+	if c.flagField != "" {
+		return PrintRawField(c.UI, result, c.flagField)
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, result)
+	}
+
+	c.UI.Output(columnOutput([]string{
+		fmt.Sprintf("Orphaned Leases | %d", result.OrphanedLeases.Count),
+		fmt.Sprintf("Expired Leases | %d", result.ExpiredLeases.Count),
+		fmt.Sprintf("Unreaped Tokens | %d", result.UnreapedTokens.Count),
+		fmt.Sprintf("Orphaned Cubbyholes | %d", result.OrphanedCubbyholes.Count),
+	}, nil))
+	c.UI.Output("")
+
+	for _, err := range result.Errors {
+		c.UI.Warn(err)
+	}
+	if len(result.Errors) > 0 {
+		c.UI.Error("Error cleaning up storage: some entries could not be deleted")
+		return 2
+	}
+
+	total := result.OrphanedLeases.Count + result.ExpiredLeases.Count +
+		result.UnreapedTokens.Count + result.OrphanedCubbyholes.Count
+	switch {
+	case result.Deleted:
+		c.UI.Output(fmt.Sprintf("Success! Deleted %d entries left behind in storage", total))
+	case total > 0:
+		c.UI.Output(fmt.Sprintf("Found %d entries left behind in storage; delete them with -delete", total))
+	default:
+		c.UI.Output("No entries left behind in storage")
+	}
+	return 0
+}
@@ -0,0 +1,586 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+const (
+	// debugIndexVersion is the version of the layout of the debug bundle
+	debugIndexVersion = 1
+
+	// debugMinInterval is the smallest interval at which data is captured
+	debugMinInterval = 5 * time.Second
+
+	// debugMaxProfileDuration bounds each CPU profile, so that it fits within
+	// the request timeout of the client
+	debugMaxProfileDuration = 30 * time.Second
+)
+
+// debugTargets are the kinds of data the debug command is able to capture
+var debugTargets = []string{
+	"host",
+	"log",
+	"metrics",
+	"pprof",
+	"replication-status",
+	"server-status",
+}
+
+var _ cli.Command = (*OperatorDebugCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorDebugCommand)(nil)
+
+type OperatorDebugCommand struct {
+	*BaseCommand
+
+	flagDuration        time.Duration
+	flagInterval        time.Duration
+	flagMetricsInterval time.Duration
+	flagOutput          string
+	flagTargets         []string
+
+	// ShutdownCh, if set, ends the capture early. The data captured so far is
+	// still written to the bundle.
+	ShutdownCh chan struct{}
+
+	// minInterval overrides debugMinInterval, for testing
+	minInterval time.Duration
+}
+
+// debugIndex is written to the root of the bundle, describing its contents
+type debugIndex struct {
+	Version         int       `json:"version"`
+	VaultAddress    string    `json:"vault_address"`
+	Timestamp       time.Time `json:"timestamp"`
+	Duration        string    `json:"duration"`
+	Interval        string    `json:"interval"`
+	MetricsInterval string    `json:"metrics_interval"`
+	Targets         []string  `json:"targets"`
+	Files           []string  `json:"files"`
+	Errors          []string  `json:"errors"`
+}
+
+// debugCapture holds the data captured by the collectors
+type debugCapture struct {
+	l sync.Mutex
+
+	dir   string
+	files []string
+	errs  []string
+
+	serverStatus      []map[string]interface{}
+	replicationStatus []map[string]interface{}
+	metrics           []map[string]interface{}
+}
+
+func (c *OperatorDebugCommand) Synopsis() string {
+	return "Captures debugging information from a Vault server"
+}
+
+func (c *OperatorDebugCommand) Help() string {
+	helpText := `
+Usage: vault operator debug [options]
+
+  Captures information useful for debugging a Vault server over a window of
+  time, and writes it into a single gzipped tarball. The captured data may be
+  any of:
+
+    - host: information about the host and process of the server
+    - log: the recent log lines of the server
+    - metrics: the in-memory telemetry of the server
+    - pprof: CPU, goroutine and heap profiles
+    - replication-status: the performance and DR replication status
+    - server-status: the seal, HA and health status
+
+  All data is captured by default. Except for the replication status, this
+  requires a token with sudo capability on the sys/ paths used.
+
+  Capture two minutes of data into the default bundle:
+
+      $ vault operator debug
+
+  Capture ten minutes of metrics and profiles into a named bundle:
+
+      $ vault operator debug -duration=10m -target=metrics -target=pprof \
+          -output=vault-incident.tar.gz
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorDebugCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.DurationVar(&DurationVar{
+		Name:       "duration",
+		Target:     &c.flagDuration,
+		Default:    2 * time.Minute,
+		Completion: complete.PredictAnything,
+		Usage:      "Duration of the capture.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "interval",
+		Target:     &c.flagInterval,
+		Default:    30 * time.Second,
+		Completion: complete.PredictAnything,
+		Usage: "Interval at which the profiles, the replication status and " +
+			"the server status are captured. Each CPU profile lasts for the " +
+			"interval, up to 30s.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "metrics-interval",
+		Target:     &c.flagMetricsInterval,
+		Default:    10 * time.Second,
+		Completion: complete.PredictAnything,
+		Usage:      "Interval at which the metrics are captured.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "output",
+		Target:     &c.flagOutput,
+		Completion: complete.PredictFiles("*.tar.gz"),
+		Usage: "Path of the bundle to write. This defaults to " +
+			"\"vault-debug-<timestamp>.tar.gz\" in the current directory.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "target",
+		Target:     &c.flagTargets,
+		Completion: complete.PredictSet(debugTargets...),
+		Usage: "Kind of data to capture. This can be specified multiple times " +
+			"to capture multiple kinds of data. The default is to capture all " +
+			"of them.",
+	})
+
+	return set
+}
+
+func (c *OperatorDebugCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *OperatorDebugCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorDebugCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	minInterval := debugMinInterval
+	if c.minInterval != 0 {
+		minInterval = c.minInterval
+	}
+	switch {
+	case c.flagInterval < minInterval:
+		c.UI.Error(fmt.Sprintf("Interval must be at least %s", minInterval))
+		return 1
+	case c.flagMetricsInterval < minInterval:
+		c.UI.Error(fmt.Sprintf("Metrics interval must be at least %s", minInterval))
+		return 1
+	case c.flagDuration < c.flagInterval:
+		c.UI.Error("Duration must be at least the interval")
+		return 1
+	}
+
+	targets := strutil.RemoveDuplicates(c.flagTargets, true)
+	if len(targets) == 0 {
+		targets = debugTargets
+	}
+	for _, target := range targets {
+		if !strutil.StrListContains(debugTargets, target) {
+			c.UI.Error(fmt.Sprintf("Unknown target %q, expected one of: %s",
+				target, strings.Join(debugTargets, ", ")))
+			return 1
+		}
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	now := time.Now().UTC()
+	output := c.flagOutput
+	if output == "" {
+		output = fmt.Sprintf("vault-debug-%s.tar.gz", now.Format("2006-01-02T15-04-05Z"))
+	}
+	if _, err := os.Stat(output); err == nil {
+		c.UI.Error(fmt.Sprintf("Output file %q already exists", output))
+		return 1
+	}
+	bundleName := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(output), ".gz"), ".tar")
+
+	dir, err := ioutil.TempDir("", "vault-debug")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating temporary directory: %s", err))
+		return 2
+	}
+	defer os.RemoveAll(dir)
+
+	capture := &debugCapture{
+		dir: dir,
+	}
+
+	c.UI.Output(fmt.Sprintf("Capturing %s for %s, this can be stopped early "+
+		"with Ctrl-C...", strings.Join(targets, ", "), c.flagDuration))
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), c.flagDuration)
+	defer cancelFunc()
+	if c.ShutdownCh != nil {
+		go func() {
+			select {
+			case <-c.ShutdownCh:
+				c.UI.Warn("Stopping the capture early")
+				cancelFunc()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	c.collect(ctx, client, targets, capture)
+
+	index := &debugIndex{
+		Version:         debugIndexVersion,
+		VaultAddress:    client.Address(),
+		Timestamp:       now,
+		Duration:        c.flagDuration.String(),
+		Interval:        c.flagInterval.String(),
+		MetricsInterval: c.flagMetricsInterval.String(),
+		Targets:         targets,
+		Errors:          capture.errs,
+	}
+	if index.Errors == nil {
+		index.Errors = []string{}
+	}
+	index.Files = append(capture.files, "index.json")
+	if err := capture.writeJSON("index.json", index); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing index: %s", err))
+		return 2
+	}
+
+	if err := writeDebugBundle(output, bundleName, dir, index.Files); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing bundle: %s", err))
+		return 2
+	}
+
+	if len(capture.errs) > 0 {
+		c.UI.Warn(fmt.Sprintf("%d errors occurred during the capture, which are "+
+			"listed in the index of the bundle", len(capture.errs)))
+	}
+	c.UI.Output(fmt.Sprintf("Success! Wrote debug bundle to: %s", output))
+	return 0
+}
+
+// collect runs the collectors of the targets until the context is done
+func (c *OperatorDebugCommand) collect(ctx context.Context, client *api.Client, targets []string, capture *debugCapture) {
+	var wg sync.WaitGroup
+	run := func(interval time.Duration, f func(now time.Time)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				f(time.Now().UTC())
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+
+	for _, target := range targets {
+		switch target {
+		case "host":
+			capture.readJSON(client, "host_info.json", "sys/host-info")
+
+		case "metrics":
+			run(c.flagMetricsInterval, func(now time.Time) {
+				if data := capture.read(client, "metrics", "sys/metrics"); data != nil {
+					capture.l.Lock()
+					capture.metrics = append(capture.metrics, data)
+					capture.l.Unlock()
+				}
+			})
+
+		case "pprof":
+			run(c.flagInterval, func(now time.Time) {
+				c.collectPprof(ctx, client, capture, now)
+			})
+
+		case "replication-status":
+			run(c.flagInterval, func(now time.Time) {
+				entry := map[string]interface{}{
+					"timestamp": now,
+				}
+				if data := capture.read(client, "replication status", "sys/replication/status"); data != nil {
+					entry["performance"] = data["performance"]
+					entry["dr"] = data["dr"]
+				}
+				capture.l.Lock()
+				capture.replicationStatus = append(capture.replicationStatus, entry)
+				capture.l.Unlock()
+			})
+
+		case "server-status":
+			run(c.flagInterval, func(now time.Time) {
+				entry := map[string]interface{}{
+					"timestamp": now,
+				}
+				if status, err := client.Sys().SealStatus(); err != nil {
+					capture.addError("seal status", err)
+				} else {
+					entry["seal_status"] = status
+				}
+				if leader, err := client.Sys().Leader(); err != nil {
+					capture.addError("leader", err)
+				} else {
+					entry["leader"] = leader
+				}
+				if health, err := client.Sys().Health(); err != nil {
+					capture.addError("health", err)
+				} else {
+					entry["health"] = health
+				}
+				capture.l.Lock()
+				capture.serverStatus = append(capture.serverStatus, entry)
+				capture.l.Unlock()
+			})
+		}
+	}
+
+	wg.Wait()
+
+	// The log lines are read last so that they cover the captured window
+	if strutil.StrListContains(targets, "log") {
+		if data := capture.read(client, "logs", "sys/logs"); data != nil {
+			var lines []string
+			if raw, ok := data["lines"].([]interface{}); ok {
+				for _, line := range raw {
+					lines = append(lines, fmt.Sprintf("%v", line))
+				}
+			}
+			contents := strings.Join(lines, "\n")
+			if contents != "" {
+				contents += "\n"
+			}
+			if err := capture.writeFile("vault.log", []byte(contents)); err != nil {
+				capture.addError("logs", err)
+			}
+		}
+	}
+
+	for name, data := range map[string][]map[string]interface{}{
+		"metrics.json":            capture.metrics,
+		"replication_status.json": capture.replicationStatus,
+		"server_status.json":      capture.serverStatus,
+	} {
+		if len(data) == 0 {
+			continue
+		}
+		if err := capture.writeJSON(name, data); err != nil {
+			capture.addError(name, err)
+		}
+	}
+}
+
+// collectPprof captures the profiles of an interval into their own
+// directory: a goroutine and heap profile, and a CPU profile lasting for the
+// interval, up to debugMaxProfileDuration.
+func (c *OperatorDebugCommand) collectPprof(ctx context.Context, client *api.Client, capture *debugCapture, now time.Time) {
+	prefix := now.Format("2006-01-02T15-04-05Z")
+
+	for _, name := range []string{"goroutine", "heap"} {
+		capture.readPprof(ctx, client, prefix, name, nil)
+	}
+
+	duration := c.flagInterval
+	if duration > debugMaxProfileDuration {
+		duration = debugMaxProfileDuration
+	}
+	deadline, _ := ctx.Deadline()
+	if remaining := deadline.Sub(time.Now()); remaining < duration {
+		duration = remaining
+	}
+	if duration < time.Second {
+		return
+	}
+	capture.readPprof(ctx, client, prefix, "profile", map[string]string{
+		"seconds": strconv.Itoa(int(duration.Seconds())),
+	})
+}
+
+// read reads the path and returns the data of the response, recording an
+// error if there is none
+func (d *debugCapture) read(client *api.Client, desc, path string) map[string]interface{} {
+	secret, err := client.Logical().Read(path)
+	if err != nil {
+		d.addError(desc, err)
+		return nil
+	}
+	if secret == nil || secret.Data == nil {
+		d.addError(desc, fmt.Errorf("no data returned from %s", path))
+		return nil
+	}
+	return secret.Data
+}
+
+// readJSON reads the path and writes the data of the response to the file
+func (d *debugCapture) readJSON(client *api.Client, name, path string) {
+	data := d.read(client, name, path)
+	if data == nil {
+		return
+	}
+	if err := d.writeJSON(name, data); err != nil {
+		d.addError(name, err)
+	}
+}
+
+// readPprof writes the named profile to a file in the directory given by
+// prefix
+func (d *debugCapture) readPprof(ctx context.Context, client *api.Client, prefix, name string, params map[string]string) {
+	r := client.NewRequest("GET", "/v1/sys/pprof/"+name)
+	for k, v := range params {
+		r.Params.Set(k, v)
+	}
+
+	resp, err := client.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		// Profiles cut short by the end of the capture are not errors
+		if ctx.Err() == nil {
+			d.addError(fmt.Sprintf("pprof %s", name), err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		d.addError(fmt.Sprintf("pprof %s", name), err)
+		return
+	}
+	if err := d.writeFile(filepath.Join(prefix, name+".prof"), data); err != nil {
+		d.addError(fmt.Sprintf("pprof %s", name), err)
+	}
+}
+
+func (d *debugCapture) addError(desc string, err error) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	d.errs = append(d.errs, fmt.Sprintf("%s: %s: %s", time.Now().UTC().Format(time.RFC3339), desc, err))
+}
+
+func (d *debugCapture) writeJSON(name string, data interface{}) error {
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return d.writeFile(name, b)
+}
+
+// writeFile writes the file relative to the capture directory and records
+// it for the bundle
+func (d *debugCapture) writeFile(name string, data []byte) error {
+	path := filepath.Join(d.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	d.l.Lock()
+	defer d.l.Unlock()
+	d.files = append(d.files, filepath.ToSlash(name))
+	return nil
+}
+
+// writeDebugBundle writes the files of the directory into a gzipped tarball
+// at output, under a directory named by bundleName
+func writeDebugBundle(output, bundleName, dir string, files []string) (retErr error) {
+	out, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := out.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+		if retErr != nil {
+			os.Remove(output)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, name := range files {
+		if err := addDebugBundleFile(tw, filepath.Join(dir, filepath.FromSlash(name)), bundleName+"/"+name); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addDebugBundleFile(tw *tar.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,296 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorDebugCommand(tb testing.TB) (*cli.MockUi, *OperatorDebugCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorDebugCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		minInterval: time.Second,
+	}
+}
+
+// testDebugBundle returns the contents of the files of the bundle, keyed by
+// their name
+func testDebugBundle(tb testing.TB, path string) map[string][]byte {
+	tb.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			tb.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		files[hdr.Name] = b
+	}
+	return files
+}
+
+func TestOperatorDebugCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name string
+			args []string
+			out  string
+			code int
+		}{
+			{
+				"too_many_args",
+				[]string{"foo"},
+				"Too many arguments",
+				1,
+			},
+			{
+				"short_interval",
+				[]string{"-interval", "1ms"},
+				"Interval must be at least",
+				1,
+			},
+			{
+				"short_duration",
+				[]string{"-duration", "1s", "-interval", "2s"},
+				"Duration must be at least the interval",
+				1,
+			},
+			{
+				"unknown_target",
+				[]string{"-duration", "1s", "-interval", "1s", "-metrics-interval", "1s", "-target", "foo"},
+				"Unknown target",
+				1,
+			},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testOperatorDebugCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		logLines := logging.NewLineBuffer(10)
+		logLines.Write([]byte("test log line\n"))
+
+		client, _, closer := testVaultServerCoreConfig(t, &vault.CoreConfig{
+			DisableMlock:       true,
+			DisableCache:       true,
+			Logger:             defaultVaultLogger,
+			CredentialBackends: defaultVaultCredentialBackends,
+			AuditBackends:      defaultVaultAuditBackends,
+			LogicalBackends:    defaultVaultLogicalBackends,
+			MetricsSink:        metrics.NewInmemSink(time.Second, time.Minute),
+			LogLines:           logLines,
+		})
+		defer closer()
+
+		dir, err := ioutil.TempDir("", "vault-debug-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		output := filepath.Join(dir, "bundle.tar.gz")
+
+		ui, cmd := testOperatorDebugCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-duration", "2s",
+			"-interval", "1s",
+			"-metrics-interval", "1s",
+			"-output", output,
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "Success! Wrote debug bundle to: " + output
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		files := testDebugBundle(t, output)
+
+		var index debugIndex
+		if err := json.Unmarshal(files["bundle/index.json"], &index); err != nil {
+			t.Fatal(err)
+		}
+		if len(index.Targets) != len(debugTargets) {
+			t.Errorf("bad targets: %#v", index.Targets)
+		}
+		if len(index.Errors) != 0 {
+			t.Errorf("bad errors: %#v", index.Errors)
+		}
+		for _, name := range index.Files {
+			if _, ok := files["bundle/"+name]; !ok {
+				t.Errorf("missing file %q", name)
+			}
+		}
+
+		for _, name := range []string{"host_info.json", "metrics.json", "replication_status.json", "server_status.json"} {
+			if len(files["bundle/"+name]) == 0 {
+				t.Errorf("missing file %q", name)
+			}
+		}
+		if log := string(files["bundle/vault.log"]); log != "test log line\n" {
+			t.Errorf("bad log: %q", log)
+		}
+
+		var profiles int
+		for name := range files {
+			if strings.HasSuffix(name, "/profile.prof") {
+				profiles++
+			}
+		}
+		if profiles == 0 {
+			t.Errorf("missing CPU profile: %#v", index.Files)
+		}
+	})
+
+	t.Run("targets", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		dir, err := ioutil.TempDir("", "vault-debug-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		output := filepath.Join(dir, "bundle.tar.gz")
+
+		ui, cmd := testOperatorDebugCommand(t)
+		cmd.client = client
+
+		// The server has no in-memory metrics, which is reported in the
+		// index rather than failing the capture
+		code := cmd.Run([]string{
+			"-duration", "1s",
+			"-interval", "1s",
+			"-metrics-interval", "1s",
+			"-output", output,
+			"-target", "host",
+			"-target", "metrics",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		files := testDebugBundle(t, output)
+
+		var index debugIndex
+		if err := json.Unmarshal(files["bundle/index.json"], &index); err != nil {
+			t.Fatal(err)
+		}
+		if len(index.Errors) == 0 || !strings.Contains(index.Errors[0], "in-memory metrics are not available") {
+			t.Errorf("bad errors: %#v", index.Errors)
+		}
+		if _, ok := files["bundle/host_info.json"]; !ok {
+			t.Errorf("missing host info: %#v", index.Files)
+		}
+		if _, ok := files["bundle/server_status.json"]; ok {
+			t.Errorf("unexpected server status: %#v", index.Files)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		dir, err := ioutil.TempDir("", "vault-debug-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		output := filepath.Join(dir, "bundle.tar.gz")
+
+		ui, cmd := testOperatorDebugCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-duration", "1s",
+			"-interval", "1s",
+			"-metrics-interval", "1s",
+			"-output", output,
+			"-target", "host",
+		})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "errors occurred during the capture"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorDebugCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -0,0 +1,477 @@
+package command
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/helper/pkcs11"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+const (
+	// diagnoseTimeout bounds each check that reaches out over the network
+	diagnoseTimeout = 10 * time.Second
+
+	// diagnoseCertExpiryWarning is how close to its expiry a certificate is
+	// reported
+	diagnoseCertExpiryWarning = 30 * 24 * time.Hour
+
+	// diagnoseSealConfigPath is where the barrier seal configuration is kept
+	// in storage, in the clear
+	diagnoseSealConfigPath = "core/seal-config"
+)
+
+// The outcomes of a diagnose check
+const (
+	diagnosePass = "pass"
+	diagnoseWarn = "warn"
+	diagnoseFail = "fail"
+)
+
+var _ cli.Command = (*OperatorDiagnoseCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorDiagnoseCommand)(nil)
+
+type OperatorDiagnoseCommand struct {
+	*BaseCommand
+
+	PhysicalBackends map[string]physical.Factory
+
+	flagConfigs       []string
+	flagSkewThreshold time.Duration
+
+	results []diagnoseResult
+}
+
+// diagnoseResult is the outcome of one check
+type diagnoseResult struct {
+	Status  string `json:"status"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+func (c *OperatorDiagnoseCommand) Synopsis() string {
+	return "Checks a server configuration before it is started"
+}
+
+func (c *OperatorDiagnoseCommand) Help() string {
+	helpText := `
+Usage: vault operator diagnose [options]
+
+  Checks that a Vault server could be started with the given configuration,
+  without starting it. The command parses the configuration and then checks:
+
+    - storage: the storage backends can be reached, and whether Vault has
+      been initialized in them
+    - listeners: the listener addresses can be bound, and the TLS
+      certificates of the listeners can be loaded, are valid and chain to a
+      trusted root
+    - seal: the configured seal can be used by this build of Vault, and the
+      HSM of a pkcs11 seal can encrypt and decrypt with its key
+    - clock: the clock of this host agrees with the one of the api_addr
+
+  Each check reports pass, warn or fail. The command exits with 1 if any
+  check fails, since the server would not start, and 0 otherwise. As it binds
+  the listener addresses, it should be run while the server is stopped.
+
+  Diagnose a configuration file:
+
+      $ vault operator diagnose -config=/etc/vault/config.hcl
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorDiagnoseCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "config",
+		Target: &c.flagConfigs,
+		Completion: complete.PredictOr(
+			complete.PredictFiles("*.hcl"),
+			complete.PredictFiles("*.json"),
+			complete.PredictDirs("*"),
+		),
+		Usage: "Path to a configuration file or directory of configuration " +
+			"files. This flag can be specified multiple times to load multiple " +
+			"configurations. If the path is a directory, all files which end in " +
+			".hcl or .json are loaded.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "skew-threshold",
+		Target:     &c.flagSkewThreshold,
+		Default:    5 * time.Second,
+		Completion: complete.PredictAnything,
+		Usage:      "Clock skew from the api_addr above which a warning is reported.",
+	})
+
+	return set
+}
+
+func (c *OperatorDiagnoseCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *OperatorDiagnoseCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorDiagnoseCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	if len(c.flagConfigs) == 0 {
+		c.UI.Error("Must specify at least one config path using -config")
+		return 1
+	}
+
+	c.results = nil
+	logger := log.NewNullLogger()
+
+	config := c.checkConfig(logger)
+	if config != nil {
+		c.checkStorage(config, logger)
+		c.checkListeners(config)
+		c.checkSeal(config)
+		c.checkClock(config)
+	}
+
+	failed := false
+	for _, result := range c.results {
+		if result.Status == diagnoseFail {
+			failed = true
+		}
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		for _, result := range c.results {
+			c.UI.Output(fmt.Sprintf("[%s] %s: %s", result.Status, result.Check, result.Message))
+		}
+	default:
+		if code := OutputData(c.UI, c.results); code != 0 {
+			return code
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// report records the outcome of a check
+func (c *OperatorDiagnoseCommand) report(status, check, format string, a ...interface{}) {
+	c.results = append(c.results, diagnoseResult{
+		Status:  status,
+		Check:   check,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// checkConfig loads and merges the configuration files the same way the
+// server does
+func (c *OperatorDiagnoseCommand) checkConfig(logger log.Logger) *server.Config {
+	var config *server.Config
+	for _, path := range c.flagConfigs {
+		current, err := server.LoadConfig(path, logger)
+		if err != nil {
+			c.report(diagnoseFail, "config", "error loading %s: %s", path, err)
+			return nil
+		}
+
+		if config == nil {
+			config = current
+		} else {
+			config = config.Merge(current)
+		}
+	}
+
+	if config.Storage == nil {
+		c.report(diagnoseFail, "config", "a storage backend must be specified")
+		return nil
+	}
+	if len(config.Listeners) == 0 {
+		c.report(diagnoseWarn, "config", "no listeners are configured, the server will not serve the API")
+	}
+
+	c.report(diagnosePass, "config", "loaded %s", strings.Join(c.flagConfigs, ", "))
+	return config
+}
+
+// checkStorage reaches the storage backends, and reports whether Vault is
+// initialized in the storage
+func (c *OperatorDiagnoseCommand) checkStorage(config *server.Config, logger log.Logger) {
+	backend := c.checkBackend("storage", config.Storage, logger)
+	if backend != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), diagnoseTimeout)
+		defer cancel()
+
+		entry, err := backend.Get(ctx, diagnoseSealConfigPath)
+		switch {
+		case err != nil:
+			c.report(diagnoseFail, "storage", "error reading the seal configuration: %s", err)
+		case entry == nil:
+			c.report(diagnoseWarn, "storage", "Vault is not initialized in the storage yet")
+		default:
+			var sealConfig struct {
+				SecretShares    int `json:"secret_shares"`
+				SecretThreshold int `json:"secret_threshold"`
+			}
+			if err := json.Unmarshal(entry.Value, &sealConfig); err != nil {
+				c.report(diagnoseFail, "storage", "error decoding the seal configuration: %s", err)
+			} else {
+				c.report(diagnosePass, "storage", "Vault is initialized with %d key shares and a threshold of %d", sealConfig.SecretShares, sealConfig.SecretThreshold)
+			}
+		}
+	}
+
+	if config.HAStorage != nil {
+		c.checkBackend("ha_storage", config.HAStorage, logger)
+	}
+}
+
+// checkBackend creates a storage backend from its configuration and lists its
+// root, returning the backend if that worked
+func (c *OperatorDiagnoseCommand) checkBackend(check string, storage *server.Storage, logger log.Logger) physical.Backend {
+	factory, ok := c.PhysicalBackends[storage.Type]
+	if !ok {
+		c.report(diagnoseFail, check, "unknown storage type %s", storage.Type)
+		return nil
+	}
+	backend, err := factory(storage.Config, logger)
+	if err != nil {
+		c.report(diagnoseFail, check, "error initializing storage of type %s: %s", storage.Type, err)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), diagnoseTimeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := backend.List(ctx, "")
+		errCh <- err
+	}()
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		c.report(diagnoseFail, check, "error reaching storage of type %s: %s", storage.Type, err)
+		return nil
+	}
+
+	c.report(diagnosePass, check, "reached storage of type %s", storage.Type)
+	return backend
+}
+
+// checkListeners binds the listener addresses and checks their TLS
+// certificates
+func (c *OperatorDiagnoseCommand) checkListeners(config *server.Config) {
+	for _, lnConfig := range config.Listeners {
+		address, _ := lnConfig.Config["address"].(string)
+		check := fmt.Sprintf("listener %s", lnConfig.Type)
+
+		switch lnConfig.Type {
+		case "tcp":
+			if address == "" {
+				address = "127.0.0.1:8200"
+			}
+			check = fmt.Sprintf("listener %s", address)
+			ln, err := net.Listen("tcp", address)
+			if err != nil {
+				c.report(diagnoseFail, check, "cannot bind the address: %s", err)
+			} else {
+				ln.Close()
+				c.report(diagnosePass, check, "the address can be bound")
+			}
+			c.checkTLS(check, lnConfig.Config)
+
+		case "unix":
+			check = fmt.Sprintf("listener %s", address)
+			if _, err := os.Stat(address); err == nil {
+				c.report(diagnoseWarn, check, "the socket file exists, and is replaced when the server starts")
+				continue
+			}
+			ln, err := net.Listen("unix", address)
+			if err != nil {
+				c.report(diagnoseFail, check, "cannot bind the socket: %s", err)
+				continue
+			}
+			ln.Close()
+			c.report(diagnosePass, check, "the socket can be bound")
+
+		default:
+			c.report(diagnoseWarn, check, "listeners of type %s are not checked", lnConfig.Type)
+		}
+	}
+}
+
+// checkTLS loads the certificate of a listener and checks that it is valid
+// now, not about to expire, and chains to a trusted root
+func (c *OperatorDiagnoseCommand) checkTLS(check string, config map[string]interface{}) {
+	if v, ok := config["tls_disable"]; ok {
+		disabled, err := parseutil.ParseBool(v)
+		if err != nil {
+			c.report(diagnoseFail, check, "invalid value for 'tls_disable': %s", err)
+			return
+		}
+		if disabled {
+			c.report(diagnoseWarn, check, "TLS is disabled")
+			return
+		}
+	}
+
+	certFile, _ := config["tls_cert_file"].(string)
+	keyFile, _ := config["tls_key_file"].(string)
+	if certFile == "" || keyFile == "" {
+		c.report(diagnoseFail, check, "'tls_cert_file' and 'tls_key_file' must be set")
+		return
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		c.report(diagnoseFail, check, "error loading the TLS certificate: %s", err)
+		return
+	}
+
+	chain := make([]*x509.Certificate, 0, len(cert.Certificate))
+	for _, der := range cert.Certificate {
+		parsed, err := x509.ParseCertificate(der)
+		if err != nil {
+			c.report(diagnoseFail, check, "error parsing the TLS certificate: %s", err)
+			return
+		}
+		chain = append(chain, parsed)
+	}
+	leaf := chain[0]
+
+	now := time.Now()
+	switch {
+	case now.Before(leaf.NotBefore):
+		c.report(diagnoseFail, check, "the TLS certificate is not valid until %s", leaf.NotBefore.Format(time.RFC3339))
+		return
+	case now.After(leaf.NotAfter):
+		c.report(diagnoseFail, check, "the TLS certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+		return
+	case leaf.NotAfter.Sub(now) < diagnoseCertExpiryWarning:
+		c.report(diagnoseWarn, check, "the TLS certificate expires soon, at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Intermediates: intermediates}); err != nil {
+		c.report(diagnoseWarn, check, "the TLS certificate does not chain to a root trusted by this host: %s", err)
+		return
+	}
+
+	c.report(diagnosePass, check, "the TLS certificate is valid until %s", leaf.NotAfter.Format(time.RFC3339))
+}
+
+// checkSeal reports whether the configured seal can be used
+func (c *OperatorDiagnoseCommand) checkSeal(config *server.Config) {
+	if config.DisabledSeal != nil {
+		c.report(diagnoseFail, "seal", "the %s seal is disabled, the server only starts once the seal is migrated with \"vault operator seal-migrate\" and the disabled seal is removed", config.DisabledSeal.Type)
+	}
+	if config.Seal == nil || config.Seal.Type == "shamir" {
+		c.report(diagnosePass, "seal", "the Shamir seal is used")
+		return
+	}
+	if config.Seal.Type != vault.SealTypePKCS11 {
+		c.report(diagnoseFail, "seal", "seals of type %s are not supported by this build", config.Seal.Type)
+		return
+	}
+
+	hsmConfig, err := pkcs11.ParseConfig(config.Seal.Config)
+	if err != nil {
+		c.report(diagnoseFail, "seal", "invalid configuration of the %s seal: %s", config.Seal.Type, err)
+		return
+	}
+	hsm, err := pkcs11.Open(hsmConfig)
+	if err != nil {
+		c.report(diagnoseFail, "seal", "error opening the HSM: %s", err)
+		return
+	}
+	defer hsm.Close()
+
+	if err := hsm.Check(); err != nil {
+		c.report(diagnoseFail, "seal", "the HSM failed its health check: %s", err)
+		return
+	}
+	c.report(diagnosePass, "seal", "the HSM can encrypt and decrypt with the key labeled %s (version %d)", hsm.KeyLabel(), hsm.KeyVersion())
+}
+
+// checkClock compares the clock of this host with the Date header sent by
+// the api_addr, which is usually the load balancer of the cluster
+func (c *OperatorDiagnoseCommand) checkClock(config *server.Config) {
+	if config.APIAddr == "" {
+		c.report(diagnoseWarn, "clock", "no api_addr is configured to compare the clock against")
+		return
+	}
+
+	client := &http.Client{
+		Timeout: diagnoseTimeout,
+		Transport: &http.Transport{
+			// Only the Date header matters here, not who sent it
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	start := time.Now()
+	resp, err := client.Get(strings.TrimSuffix(config.APIAddr, "/") + "/v1/sys/health")
+	if err != nil {
+		c.report(diagnoseWarn, "clock", "cannot reach the api_addr: %s", err)
+		return
+	}
+	resp.Body.Close()
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		c.report(diagnoseWarn, "clock", "the api_addr did not send a valid Date header")
+		return
+	}
+
+	// The Date header has a resolution of a second, and was set somewhere
+	// between sending the request and getting the response
+	local := start.Add(time.Since(start) / 2)
+	skew := local.Sub(date)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > c.flagSkewThreshold+time.Second {
+		c.report(diagnoseWarn, "clock", "the clock is %s off from the one of %s", skew.Round(time.Second), config.APIAddr)
+		return
+	}
+	c.report(diagnosePass, "clock", "the clock agrees with the one of %s", config.APIAddr)
+}
@@ -0,0 +1,253 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/physical"
+	physInmem "github.com/hashicorp/vault/physical/inmem"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorDiagnoseCommand(tb testing.TB) (*cli.MockUi, *OperatorDiagnoseCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorDiagnoseCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		PhysicalBackends: map[string]physical.Factory{
+			"inmem": physInmem.NewInmem,
+		},
+	}
+}
+
+func testOperatorDiagnoseConfig(tb testing.TB, config string) (string, func()) {
+	tb.Helper()
+
+	dir, err := ioutil.TempDir("", "vault-diagnose")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.hcl")
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		os.RemoveAll(dir)
+		tb.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestOperatorDiagnoseCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		config string
+		out    []string
+		code   int
+	}{
+		{
+			"default",
+			`
+storage "inmem" {}
+
+listener "tcp" {
+  address     = "127.0.0.1:0"
+  tls_disable = true
+}
+`,
+			[]string{
+				"[pass] config: loaded",
+				"[pass] storage: reached storage of type inmem",
+				"[warn] storage: Vault is not initialized",
+				"[pass] listener 127.0.0.1:0: the address can be bound",
+				"[warn] listener 127.0.0.1:0: TLS is disabled",
+				"[pass] seal: the Shamir seal is used",
+				"[warn] clock: no api_addr is configured",
+			},
+			0,
+		},
+		{
+			"unknown_storage",
+			`storage "bogus" {}`,
+			[]string{"[fail] storage: unknown storage type bogus"},
+			1,
+		},
+		{
+			"no_storage",
+			`disable_mlock = true`,
+			[]string{"[fail] config: a storage backend must be specified"},
+			1,
+		},
+		{
+			"missing_certificate",
+			`
+storage "inmem" {}
+
+listener "tcp" {
+  address       = "127.0.0.1:0"
+  tls_cert_file = "/nonexistent/cert.pem"
+  tls_key_file  = "/nonexistent/key.pem"
+}
+`,
+			[]string{"[fail] listener 127.0.0.1:0: error loading the TLS certificate"},
+			1,
+		},
+		{
+			"unsupported_seal",
+			`
+storage "inmem" {}
+
+seal "awskms" {}
+`,
+			[]string{"[fail] seal: seals of type awskms are not supported"},
+			1,
+		},
+		{
+			"invalid_pkcs11_seal",
+			`
+storage "inmem" {}
+
+seal "pkcs11" {
+  lib  = "/usr/lib/libhsm.so"
+  slot = "0"
+}
+`,
+			[]string{"[fail] seal: invalid configuration of the pkcs11 seal: 'pin' must be set"},
+			1,
+		},
+		{
+			"disabled_seal",
+			`
+storage "inmem" {}
+
+seal "pkcs11" {
+  disabled = "true"
+}
+`,
+			[]string{
+				"[fail] seal: the pkcs11 seal is disabled",
+				"[pass] seal: the Shamir seal is used",
+			},
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path, cleanup := testOperatorDiagnoseConfig(t, tc.config)
+			defer cleanup()
+
+			ui, cmd := testOperatorDiagnoseCommand(t)
+
+			code := cmd.Run([]string{"-config", path})
+			if code != tc.code {
+				t.Errorf("expected %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			for _, out := range tc.out {
+				if !strings.Contains(combined, out) {
+					t.Errorf("expected %q to contain %q", combined, out)
+				}
+			}
+		})
+	}
+
+	t.Run("no_config", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testOperatorDiagnoseCommand(t)
+
+		code := cmd.Run(nil)
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Must specify at least one config path"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("address_in_use", func(t *testing.T) {
+		t.Parallel()
+
+		// Hold the address so that binding it fails
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+
+		path, cleanup := testOperatorDiagnoseConfig(t, fmt.Sprintf(`
+storage "inmem" {}
+
+listener "tcp" {
+  address     = "%s"
+  tls_disable = true
+}
+`, ln.Addr().String()))
+		defer cleanup()
+
+		ui, cmd := testOperatorDiagnoseCommand(t)
+
+		code := cmd.Run([]string{"-config", path})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := fmt.Sprintf("[fail] listener %s: cannot bind the address", ln.Addr().String())
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("initialized", func(t *testing.T) {
+		t.Parallel()
+
+		inm, err := physInmem.NewInmem(nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := inm.Put(context.Background(), &physical.Entry{
+			Key:   diagnoseSealConfigPath,
+			Value: []byte(`{"type":"shamir","secret_shares":5,"secret_threshold":3}`),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		path, cleanup := testOperatorDiagnoseConfig(t, `storage "inmem" {}`)
+		defer cleanup()
+
+		ui, cmd := testOperatorDiagnoseCommand(t)
+		cmd.PhysicalBackends["inmem"] = func(map[string]string, log.Logger) (physical.Backend, error) {
+			return inm, nil
+		}
+
+		code := cmd.Run([]string{"-config", path})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "[pass] storage: Vault is initialized with 5 key shares and a threshold of 3"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+}
@@ -83,7 +83,7 @@ Usage: vault operator init [options]
 }
 
 func (c *OperatorInitCommand) Flags() *FlagSets {
-	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
 
 	// Common Options
 	f := set.NewFlagSet("Common Options")
@@ -442,6 +442,10 @@ func (c *OperatorInitCommand) init(client *api.Client, req *api.InitRequest) int
 		return 2
 	}
 
+	if c.flagField != "" {
+		return PrintRawField(c.UI, newMachineInit(req, resp), c.flagField)
+	}
+
 	switch Format(c.UI) {
 	case "table":
 	default:
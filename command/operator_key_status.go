@@ -32,7 +32,7 @@ Usage: vault operator key-status [options]
 }
 
 func (c *OperatorKeyStatusCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	return c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
 }
 
 func (c *OperatorKeyStatusCommand) AutocompleteArgs() complete.Predictor {
@@ -69,6 +69,10 @@ func (c *OperatorKeyStatusCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagField != "" {
+		return PrintRawField(c.UI, status, c.flagField)
+	}
+
 	switch Format(c.UI) {
 	case "table":
 		c.UI.Output(printKeyStatus(status))
@@ -84,6 +84,26 @@ func TestOperatorKeyStatusCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("field", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testOperatorKeyStatusCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-field", "term"})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "1"
+		if combined := ui.OutputWriter.String(); strings.TrimSpace(combined) != expected {
+			t.Errorf("expected %q to be %q", combined, expected)
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
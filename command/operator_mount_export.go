@@ -0,0 +1,167 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*OperatorMountExportCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorMountExportCommand)(nil)
+
+type OperatorMountExportCommand struct {
+	*BaseCommand
+
+	flagKey           string
+	flagPublicKeyFile string
+	flagOutput        string
+}
+
+func (c *OperatorMountExportCommand) Synopsis() string {
+	return "Exports the storage of a secrets engine or auth method"
+}
+
+func (c *OperatorMountExportCommand) Help() string {
+	helpText := `
+Usage: vault operator mount-export [options] PATH
+
+  Exports all of the storage of the secrets engine or auth method at the
+  given path, along with the configuration of its mount, so that it can be
+  imported into another cluster with "vault operator mount-import". Auth
+  methods are given with their auth/ prefix. The mount is read-only during
+  the export, so that the export is consistent.
+
+  The export is encrypted either with a base64-encoded AES-256 key which must
+  be given to the import, or for the wrapping key of the target cluster,
+  which only that cluster can decrypt. The leases and tokens issued by the
+  mount are not exported.
+
+  Export the kv secrets engine at secret/ for the target cluster:
+
+      $ VAULT_ADDR=https://target:8200 vault operator mount-import -wrapping-key > target.pem
+      $ vault operator mount-export -public-key-file=target.pem -output=secret.export secret/
+
+  Export the userpass auth method with a key:
+
+      $ vault operator mount-export -key=$(openssl rand -base64 32) -output=userpass.export auth/userpass/
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorMountExportCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "key",
+		Target:     &c.flagKey,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage:      "Base64-encoded AES-256 key to encrypt the export with.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "public-key-file",
+		Target:     &c.flagPublicKeyFile,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to the PEM-encoded RSA public key to encrypt the export for, " +
+			"such as the wrapping key of the target cluster.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "output",
+		Target:     &c.flagOutput,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictFiles("*"),
+		Usage:      "Path to write the export to. The export is printed if empty.",
+	})
+
+	return set
+}
+
+func (c *OperatorMountExportCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultMounts()
+}
+
+func (c *OperatorMountExportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorMountExportCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	input := &api.MountExportInput{
+		Key: c.flagKey,
+	}
+	if c.flagPublicKeyFile != "" {
+		publicKey, err := ioutil.ReadFile(c.flagPublicKeyFile)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading public key: %s", err))
+			return 1
+		}
+		input.PublicKey = string(publicKey)
+	}
+	if input.Key == "" && input.PublicKey == "" {
+		c.UI.Error("One of -key or -public-key-file must be given")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	path := ensureTrailingSlash(sanitizePath(args[0]))
+	result, err := client.Sys().ExportMount(path, input)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error exporting %s: %s", path, err))
+		return 2
+	}
+
+	if c.flagField != "" {
+		return PrintRawField(c.UI, result, c.flagField)
+	}
+
+	if c.flagOutput == "" {
+		if Format(c.UI) != "table" {
+			return OutputData(c.UI, result)
+		}
+		c.UI.Output(result.Export)
+		return 0
+	}
+
+	if err := ioutil.WriteFile(c.flagOutput, []byte(result.Export), 0600); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing export: %s", err))
+		return 2
+	}
+	c.UI.Output(fmt.Sprintf("Success! Exported %d entries of the %s mount at %s to %s",
+		result.Entries, result.Type, path, c.flagOutput))
+	return 0
+}
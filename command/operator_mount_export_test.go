@@ -0,0 +1,164 @@
+package command
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorMountExportCommand(tb testing.TB) (*cli.MockUi, *OperatorMountExportCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorMountExportCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorMountExportCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			nil,
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"no_key",
+			[]string{"secret/"},
+			"One of -key or -public-key-file must be given",
+			1,
+		},
+		{
+			"not_a_mount",
+			[]string{"-key=" + base64.StdEncoding.EncodeToString(make([]byte, 32)), "nope/"},
+			"no mount at",
+			2,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+
+				ui, cmd := testOperatorMountExportCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		dir, err := ioutil.TempDir("", "vault-mount-export")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		publicKey, err := client.Sys().MountImportWrappingKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKeyFile := filepath.Join(dir, "target.pem")
+		if err := ioutil.WriteFile(publicKeyFile, []byte(publicKey), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorMountExportCommand(t)
+		cmd.client = client
+
+		output := filepath.Join(dir, "secret.export")
+		code := cmd.Run([]string{
+			"-public-key-file", publicKeyFile,
+			"-output", output,
+			"secret/",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "Success! Exported 0 entries of the kv mount at secret/ to " + output
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		export, err := ioutil.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := base64.StdEncoding.DecodeString(string(export)); err != nil || len(export) == 0 {
+			t.Errorf("bad export: %q", export)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testOperatorMountExportCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-key=foo", "secret/"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error exporting secret/: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorMountExportCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -0,0 +1,154 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*OperatorMountImportCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorMountImportCommand)(nil)
+
+type OperatorMountImportCommand struct {
+	*BaseCommand
+
+	flagKey         string
+	flagWrappingKey bool
+}
+
+func (c *OperatorMountImportCommand) Synopsis() string {
+	return "Imports the storage of a secrets engine or auth method"
+}
+
+func (c *OperatorMountImportCommand) Help() string {
+	helpText := `
+Usage: vault operator mount-import [options] PATH FILE
+
+  Creates a secrets engine or auth method at the given path with the
+  configuration and the storage of an export made by "vault operator
+  mount-export" on another cluster. Auth methods are imported under auth/.
+  The path must not be in use.
+
+  With -wrapping-key, prints the public key of this cluster which exports
+  can be encrypted for instead, so that they can only be imported into this
+  cluster.
+
+  Print the wrapping key of this cluster:
+
+      $ vault operator mount-import -wrapping-key > target.pem
+
+  Import an export encrypted for the wrapping key at kv/:
+
+      $ vault operator mount-import kv/ secret.export
+
+  Import an export encrypted with a key at auth/userpass/:
+
+      $ vault operator mount-import -key=... auth/userpass/ userpass.export
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorMountImportCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "key",
+		Target:     &c.flagKey,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage: "Base64-encoded AES-256 key the export was encrypted with. Not " +
+			"needed for the exports encrypted for the wrapping key.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "wrapping-key",
+		Target:  &c.flagWrappingKey,
+		Default: false,
+		Usage:   "Print the public key exports can be encrypted for.",
+	})
+
+	return set
+}
+
+func (c *OperatorMountImportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *OperatorMountImportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorMountImportCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	expected := 2
+	if c.flagWrappingKey {
+		expected = 0
+	}
+	switch {
+	case len(args) < expected:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected %d, got %d)", expected, len(args)))
+		return 1
+	case len(args) > expected:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected %d, got %d)", expected, len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	if c.flagWrappingKey {
+		publicKey, err := client.Sys().MountImportWrappingKey()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading wrapping key: %s", err))
+			return 2
+		}
+		c.UI.Output(strings.TrimSpace(publicKey))
+		return 0
+	}
+
+	export, err := ioutil.ReadFile(args[1])
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading export: %s", err))
+		return 1
+	}
+
+	path := ensureTrailingSlash(sanitizePath(args[0]))
+	result, err := client.Sys().ImportMount(path, &api.MountImportInput{
+		Export: strings.TrimSpace(string(export)),
+		Key:    c.flagKey,
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error importing %s: %s", path, err))
+		return 2
+	}
+
+	if c.flagField != "" {
+		return PrintRawField(c.UI, result, c.flagField)
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, result)
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Imported %d entries into the %s mount at %s", result.Entries, result.Type, path))
+	return 0
+}
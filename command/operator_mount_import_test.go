@@ -0,0 +1,177 @@
+package command
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorMountImportCommand(tb testing.TB) (*cli.MockUi, *OperatorMountImportCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorMountImportCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorMountImportCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"kv/"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"kv/", "foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"wrapping_key_args",
+			[]string{"-wrapping-key", "kv/"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"no_file",
+			[]string{"kv/", "/nope/secret.export"},
+			"Error reading export",
+			1,
+		},
+		{
+			"wrapping_key",
+			[]string{"-wrapping-key"},
+			"-----BEGIN PUBLIC KEY-----",
+			0,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+
+				ui, cmd := testOperatorMountImportCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if _, err := client.Logical().Write("secret/foo", map[string]interface{}{
+			"password": "hunter2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+		result, err := client.Sys().ExportMount("secret/", &api.MountExportInput{
+			Key: key,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dir, err := ioutil.TempDir("", "vault-mount-import")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "secret.export")
+		if err := ioutil.WriteFile(file, []byte(result.Export), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorMountImportCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-key", key,
+			"imported/", file,
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "Success! Imported 1 entries into the kv mount at imported/"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		secret, err := client.Logical().Read("imported/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret == nil || secret.Data["password"] != "hunter2" {
+			t.Errorf("bad secret: %#v", secret)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testOperatorMountImportCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-wrapping-key"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error reading wrapping key: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorMountImportCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
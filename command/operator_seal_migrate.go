@@ -0,0 +1,269 @@
+package command
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/password"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*OperatorSealMigrateCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorSealMigrateCommand)(nil)
+
+type OperatorSealMigrateCommand struct {
+	*BaseCommand
+
+	PhysicalBackends map[string]physical.Factory
+
+	flagConfigs []string
+
+	testOutput      io.Writer                              // for tests
+	testSealFactory func(*server.Seal) (vault.Seal, error) // for tests
+}
+
+func (c *OperatorSealMigrateCommand) Synopsis() string {
+	return "Migrates the seal of a stopped Vault to another seal"
+}
+
+func (c *OperatorSealMigrateCommand) Help() string {
+	helpText := `
+Usage: vault operator seal-migrate [options] [KEY...]
+
+  Migrates Vault from one seal to another: from the Shamir seal to an auto
+  seal such as pkcs11, from an auto seal to the Shamir seal, or between two
+  auto seals. The command works on the storage directly, so every Vault server
+  using the storage must be stopped, and the storage should be backed up
+  first.
+
+  The seal migrated to is the "seal" block of the configuration, or the
+  Shamir seal if there is none. The seal migrated from is the "seal" block
+  with disabled = "true", or the Shamir seal if there is none:
+
+      seal "pkcs11" {
+        lib       = "/usr/lib/libhsm.so"
+        slot      = "0"
+        pin       = "..."
+        key_label = "vault"
+        disabled  = "true"
+      }
+
+  The command asks for the unseal keys of the Shamir seal, or the recovery
+  keys of an auto seal, up to the threshold. They can also be given as
+  arguments, but they would then be kept in the shell history.
+
+  The old seal decrypts the master key, which is checked against the barrier.
+  The new seal then encrypts it, and what it wrote is read back and verified
+  before anything is written to the storage. The operators keep their key
+  shares: migrating to an auto seal turns the unseal keys into recovery keys,
+  and migrating to the Shamir seal turns the recovery keys into unseal keys.
+
+  Once migrated, remove the disabled seal from the configuration and start
+  the servers.
+
+  Migrate the seal of the storage of a configuration file:
+
+      $ vault operator seal-migrate -config=/etc/vault/config.hcl
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSealMigrateCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetNone)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "config",
+		Target: &c.flagConfigs,
+		Completion: complete.PredictOr(
+			complete.PredictFiles("*.hcl"),
+			complete.PredictFiles("*.json"),
+			complete.PredictDirs("*"),
+		),
+		Usage: "Path to a configuration file or directory of configuration " +
+			"files. This flag can be specified multiple times to load multiple " +
+			"configurations. If the path is a directory, all files which end in " +
+			".hcl or .json are loaded.",
+	})
+
+	return set
+}
+
+func (c *OperatorSealMigrateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *OperatorSealMigrateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorSealMigrateCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	keys := f.Args()
+
+	if len(c.flagConfigs) == 0 {
+		c.UI.Error("Must specify at least one config path using -config")
+		return 1
+	}
+
+	logger := log.NewNullLogger()
+
+	var config *server.Config
+	for _, path := range c.flagConfigs {
+		current, err := server.LoadConfig(path, logger)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error loading configuration from %s: %s", path, err))
+			return 1
+		}
+
+		if config == nil {
+			config = current
+		} else {
+			config = config.Merge(current)
+		}
+	}
+
+	if config.Storage == nil {
+		c.UI.Error("A storage backend must be specified")
+		return 1
+	}
+	if config.Seal == nil && config.DisabledSeal == nil {
+		c.UI.Error(wrapAtLength("The configuration has no seal to migrate to nor " +
+			"disabled seal to migrate from. Add the seal to migrate to, and mark " +
+			"the seal to migrate from with disabled = \"true\"."))
+		return 1
+	}
+
+	factory, ok := c.PhysicalBackends[config.Storage.Type]
+	if !ok {
+		c.UI.Error(fmt.Sprintf("Unknown storage type %s", config.Storage.Type))
+		return 1
+	}
+	backend, err := factory(config.Storage.Config, logger)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing storage of type %s: %s", config.Storage.Type, err))
+		return 1
+	}
+
+	ctx := context.Background()
+
+	oldSeal, err := c.seal(config.DisabledSeal)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing the seal to migrate from: %s", err))
+		return 1
+	}
+	defer oldSeal.Finalize(ctx)
+	newSeal, err := c.seal(config.Seal)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing the seal to migrate to: %s", err))
+		return 1
+	}
+	defer newSeal.Finalize(ctx)
+
+	core, err := vault.NewCore(&vault.CoreConfig{
+		Physical:     backend,
+		Seal:         oldSeal,
+		Logger:       logger,
+		DisableMlock: true,
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing core: %s", err))
+		return 1
+	}
+
+	sealAccess := core.SealAccess()
+	keyName := "Unseal Key"
+	keyConfig, err := sealAccess.BarrierConfig(ctx)
+	if err == nil && sealAccess.RecoveryKeySupported() {
+		keyName = "Recovery Key"
+		keyConfig, err = sealAccess.RecoveryConfig(ctx)
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading the configuration of the seal to migrate from: %s", err))
+		return 2
+	}
+	if keyConfig == nil {
+		c.UI.Error("Vault is not initialized")
+		return 2
+	}
+
+	writer := (io.Writer)(os.Stdout)
+	if c.testOutput != nil {
+		writer = c.testOutput
+	}
+	for len(keys) < keyConfig.SecretThreshold {
+		fmt.Fprintf(writer, "%s %d of %d (will be hidden): ", keyName, len(keys)+1, keyConfig.SecretThreshold)
+		value, err := password.Read(os.Stdin)
+		fmt.Fprintf(writer, "\n")
+		if err != nil {
+			c.UI.Error(wrapAtLength(fmt.Sprintf("An error occurred attempting to "+
+				"ask for a key. The raw error message is shown below, but usually "+
+				"this is because you attempted to pipe a value into the command or "+
+				"you are executing outside of a terminal (tty). If this is not an "+
+				"option, the keys can be provided as arguments to the command. The "+
+				"raw error was:\n\n%s", err)))
+			return 1
+		}
+		keys = append(keys, strings.TrimSpace(value))
+	}
+
+	min, max := core.BarrierKeyLength()
+	shares := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		// Decode the key, which is base64 or hex encoded, the way the unseal
+		// endpoint does
+		share, err := hex.DecodeString(key)
+		if err != nil || len(share) < min || len(share) > max {
+			share, err = base64.StdEncoding.DecodeString(key)
+			if err != nil {
+				c.UI.Error("Keys must be valid hex or base64 strings")
+				return 1
+			}
+		}
+		shares = append(shares, share)
+	}
+
+	result, err := core.MigrateSeal(ctx, newSeal, shares)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error migrating the seal: %s", err))
+		return 2
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Migrated the seal from %s to %s.", result.OldType, result.NewType))
+	c.UI.Output("")
+	keysNow := "unseal keys"
+	if result.RecoveryKeys {
+		keysNow = "recovery keys"
+	}
+	c.UI.Output(wrapAtLength(fmt.Sprintf("The %d key shares the operators hold are "+
+		"now %s, with a threshold of %d. Remove the disabled seal from the "+
+		"configuration before starting the servers.", result.SecretShares, keysNow, result.SecretThreshold)))
+	return 0
+}
+
+// seal returns the seal of the configuration, or the Shamir seal
+func (c *OperatorSealMigrateCommand) seal(sealConfig *server.Seal) (vault.Seal, error) {
+	if c.testSealFactory != nil && sealConfig != nil {
+		return c.testSealFactory(sealConfig)
+	}
+	seal, _, err := configureSeal(sealConfig)
+	return seal, err
+}
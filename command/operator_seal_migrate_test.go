@@ -0,0 +1,187 @@
+package command
+
+import (
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/pkcs11"
+	"github.com/hashicorp/vault/physical"
+	physInmem "github.com/hashicorp/vault/physical/inmem"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorSealMigrateCommand(tb testing.TB, backend physical.Backend, token *pkcs11.InmemToken) (*cli.MockUi, *OperatorSealMigrateCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorSealMigrateCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		PhysicalBackends: map[string]physical.Factory{
+			"inmem": func(map[string]string, log.Logger) (physical.Backend, error) {
+				return backend, nil
+			},
+		},
+		testOutput: ioutil.Discard,
+		testSealFactory: func(sealConfig *server.Seal) (vault.Seal, error) {
+			hsm, err := pkcs11.New(&pkcs11.Config{
+				KeyLabel:    sealConfig.Config["key_label"],
+				Mechanism:   pkcs11.MechanismAESGCM,
+				GenerateKey: true,
+			}, token)
+			if err != nil {
+				return nil, err
+			}
+			return vault.NewPKCS11Seal(hsm, time.Minute), nil
+		},
+	}
+}
+
+func TestOperatorSealMigrateCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		backend, err := physInmem.NewInmem(nil, log.NewNullLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cases := []struct {
+			name   string
+			config string
+			out    string
+			code   int
+		}{
+			{
+				"no_seal",
+				`storage "inmem" {}`,
+				"no seal to migrate to",
+				1,
+			},
+			{
+				"no_storage",
+				`seal "pkcs11" { key_label = "vault" }`,
+				"A storage backend must be specified",
+				1,
+			},
+			{
+				"not_initialized",
+				`
+storage "inmem" {}
+
+seal "pkcs11" {
+  key_label = "vault"
+}
+`,
+				"Vault is not initialized",
+				2,
+			},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				path, closer := testOperatorDiagnoseConfig(t, tc.config)
+				defer closer()
+
+				ui, cmd := testOperatorSealMigrateCommand(t, backend, pkcs11.NewInmemToken())
+				code := cmd.Run([]string{"-config", path})
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		t.Parallel()
+
+		backend, err := physInmem.NewInmem(nil, log.NewNullLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		core, err := vault.NewCore(&vault.CoreConfig{
+			Physical:     backend,
+			Logger:       log.NewNullLogger(),
+			DisableMlock: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, _ := vault.TestCoreInit(t, core)
+		args := make([]string, 0, len(keys))
+		for _, key := range keys {
+			args = append(args, hex.EncodeToString(key))
+		}
+
+		path, closer := testOperatorDiagnoseConfig(t, `
+storage "inmem" {}
+
+seal "pkcs11" {
+  key_label = "vault"
+}
+`)
+		defer closer()
+
+		token := pkcs11.NewInmemToken()
+
+		ui, cmd := testOperatorSealMigrateCommand(t, backend, token)
+		if code := cmd.Run([]string{"-config", path, args[0], args[1], args[0]}); code != 2 {
+			t.Fatalf("expected %d to be %d: %s", code, 2, ui.ErrorWriter.String())
+		}
+
+		ui, cmd = testOperatorSealMigrateCommand(t, backend, token)
+		if code := cmd.Run(append([]string{"-config", path}, args...)); code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
+		expected := "Success! Migrated the seal from shamir to pkcs11"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+		if !strings.Contains(combined, "now recovery keys") {
+			t.Errorf("expected %q to mention the recovery keys", combined)
+		}
+
+		hsm, err := pkcs11.New(&pkcs11.Config{
+			KeyLabel:  "vault",
+			Mechanism: pkcs11.MechanismAESGCM,
+		}, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core, err = vault.NewCore(&vault.CoreConfig{
+			Physical:     backend,
+			Seal:         vault.NewPKCS11Seal(hsm, time.Minute),
+			Logger:       log.NewNullLogger(),
+			DisableMlock: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := core.UnsealWithStoredKeys(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if core.Sealed() {
+			t.Fatal("should be unsealed with the stored keys")
+		}
+	})
+}
@@ -50,7 +50,7 @@ Usage: vault operator unseal [options] [KEY]
 }
 
 func (c *OperatorUnsealCommand) Flags() *FlagSets {
-	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
 
 	f := set.NewFlagSet("Command Options")
 
@@ -141,5 +141,9 @@ func (c *OperatorUnsealCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagField != "" {
+		return PrintRawField(c.UI, status, c.flagField)
+	}
+
 	return OutputSealStatus(c.UI, client, status)
 }
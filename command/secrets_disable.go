@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -13,6 +14,8 @@ var _ cli.CommandAutocomplete = (*SecretsDisableCommand)(nil)
 
 type SecretsDisableCommand struct {
 	*BaseCommand
+
+	flagForce bool
 }
 
 func (c *SecretsDisableCommand) Synopsis() string {
@@ -27,17 +30,37 @@ Usage: vault secrets disable [options] PATH
   the enabled PATH of the engine, not the TYPE! All secrets created by this
   engine are revoked and its Vault data is removed.
 
+  If the server has a mount deletion grace period, the secrets engine only
+  stops serving requests, and is deleted once the grace period has elapsed.
+  Until then, it can be restored by writing to sys/mounts/PATH/restore.
+
   Disable the secrets engine enabled at aws/:
 
       $ vault secrets disable aws/
 
+  Delete the secrets engine enabled at aws/ right away:
+
+      $ vault secrets disable -force aws/
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *SecretsDisableCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP)
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "force",
+		Target:  &c.flagForce,
+		Default: false,
+		Usage: "Delete the secrets engine right away, even if the server has a " +
+			"mount deletion grace period.",
+	})
+
+	return set
 }
 
 func (c *SecretsDisableCommand) AutocompleteArgs() complete.Predictor {
@@ -74,11 +97,19 @@ func (c *SecretsDisableCommand) Run(args []string) int {
 
 	path := ensureTrailingSlash(sanitizePath(args[0]))
 
-	if err := client.Sys().Unmount(path); err != nil {
+	secret, err := client.Sys().UnmountWithOptions(path, &api.UnmountOptions{
+		Force: c.flagForce,
+	})
+	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error disabling secrets engine at %s: %s", path, err))
 		return 2
 	}
 
+	if secret != nil && len(secret.Warnings) > 0 {
+		tf := TableFormatter{}
+		tf.printWarnings(c.UI, secret)
+	}
+
 	c.UI.Output(fmt.Sprintf("Success! Disabled the secrets engine (if it existed) at: %s", path))
 	return 0
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/mitchellh/cli"
 )
 
@@ -197,7 +198,12 @@ func TestSecretsEnableCommand_Run(t *testing.T) {
 		}
 		for _, p := range plugins {
 			if p.IsDir() && strings.HasPrefix(p.Name(), "vault-plugin-secrets-") {
-				backends = append(backends, strings.TrimPrefix(p.Name(), "vault-plugin-secrets-"))
+				// Plugins built in-tree may still vendor their helper packages
+				name := strings.TrimPrefix(p.Name(), "vault-plugin-secrets-")
+				if strutil.StrListContains(backends, name) {
+					continue
+				}
+				backends = append(backends, name)
 			}
 		}
 
@@ -23,12 +23,12 @@ func (c *SecretsMoveCommand) Help() string {
 	helpText := `
 Usage: vault secrets move [options] SOURCE DESTINATION
 
-  Moves an existing secrets engine to a new path. Any leases from the old
-  secrets engine are revoked, but all configuration associated with the engine
-  is preserved.
+  Moves an existing secrets engine to a new path. The leases of the secrets
+  engine are moved along with it, and all configuration associated with the
+  engine is preserved.
 
-  WARNING! Moving an existing secrets engine will revoke any leases from the
-  old engine.
+  Policies are not rewritten. The policies that still have rules for the old
+  path are listed after the move, and may need to be updated.
 
   Move the existing secrets engine at secret/ to generic/:
 
@@ -79,11 +79,20 @@ func (c *SecretsMoveCommand) Run(args []string) int {
 		return 2
 	}
 
-	if err := client.Sys().Remount(source, destination); err != nil {
+	secret, err := client.Logical().Write("sys/remount", map[string]interface{}{
+		"from": source,
+		"to":   destination,
+	})
+	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error moving secrets engine %s to %s: %s", source, destination, err))
 		return 2
 	}
 
+	if secret != nil && len(secret.Warnings) > 0 {
+		tf := TableFormatter{}
+		tf.printWarnings(c.UI, secret)
+	}
+
 	c.UI.Output(fmt.Sprintf("Success! Moved secrets engine %s to: %s", source, destination))
 	return 0
 }
@@ -21,10 +21,15 @@ type SecretsTuneCommand struct {
 	flagAuditNonHMACRequestKeys  []string
 	flagAuditNonHMACResponseKeys []string
 	flagDefaultLeaseTTL          time.Duration
+	flagDefaultRenewalIncrement  time.Duration
 	flagDescription              string
 	flagListingVisibility        string
+	flagMaxInFlightRequests      int
 	flagMaxLeaseTTL              time.Duration
+	flagMaxQueuedRequests        int
+	flagMaxRenewalIncrement      time.Duration
 	flagOptions                  map[string]string
+	flagRenewalGracePeriod       time.Duration
 	flagVersion                  int
 }
 
@@ -79,6 +84,15 @@ func (c *SecretsTuneCommand) Flags() *FlagSets {
 			"TTL, or a previously configured value for the secrets engine.",
 	})
 
+	f.DurationVar(&DurationVar{
+		Name:       flagNameDefaultRenewalIncrement,
+		Target:     &c.flagDefaultRenewalIncrement,
+		Completion: complete.PredictAnything,
+		Usage: "The increment of the renewals of the leases of this secrets engine " +
+			"requesting none, in place of the TTL of the backend. 0 leaves the " +
+			"Vault server's globally configured value, if any.",
+	})
+
 	f.StringVar(&StringVar{
 		Name:   flagNameDescription,
 		Target: &c.flagDescription,
@@ -93,6 +107,31 @@ func (c *SecretsTuneCommand) Flags() *FlagSets {
 			"endpoint.",
 	})
 
+	f.IntVar(&IntVar{
+		Name:   flagNameMaxInFlightRequests,
+		Target: &c.flagMaxInFlightRequests,
+		Usage: "The maximum number of requests the secrets engine handles " +
+			"concurrently. Further requests wait in a queue, or are rejected " +
+			"once the queue is full. 0 leaves the secrets engine unlimited.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:   flagNameMaxQueuedRequests,
+		Target: &c.flagMaxQueuedRequests,
+		Usage: "The maximum number of requests that wait for the secrets engine once " +
+			"it handles -max-in-flight-requests. 0 rejects the requests " +
+			"right away.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       flagNameMaxRenewalIncrement,
+		Target:     &c.flagMaxRenewalIncrement,
+		Completion: complete.PredictAnything,
+		Usage: "The most a renewal can extend a lease of this secrets engine by. Larger " +
+			"increments are capped. 0 leaves the Vault server's globally " +
+			"configured value, if any.",
+	})
+
 	f.DurationVar(&DurationVar{
 		Name:       "max-lease-ttl",
 		Target:     &c.flagMaxLeaseTTL,
@@ -112,6 +151,16 @@ func (c *SecretsTuneCommand) Flags() *FlagSets {
 			"This can be specified multiple times.",
 	})
 
+	f.DurationVar(&DurationVar{
+		Name:       flagNameRenewalGracePeriod,
+		Target:     &c.flagRenewalGracePeriod,
+		Completion: complete.PredictAnything,
+		Usage: "How close to its max TTL a renewal extends a lease of this " +
+			"secrets engine all the way to it, rather than leaving a shorter " +
+			"remainder. 0 leaves the Vault server's globally configured value, " +
+			"if any.",
+	})
+
 	f.IntVar(&IntVar{
 		Name:    "version",
 		Target:  &c.flagVersion,
@@ -187,6 +236,26 @@ func (c *SecretsTuneCommand) Run(args []string) int {
 		if fl.Name == flagNameListingVisibility {
 			mountConfigInput.ListingVisibility = c.flagListingVisibility
 		}
+
+		if fl.Name == flagNameMaxInFlightRequests {
+			mountConfigInput.MaxInFlightRequests = &c.flagMaxInFlightRequests
+		}
+
+		if fl.Name == flagNameMaxQueuedRequests {
+			mountConfigInput.MaxQueuedRequests = &c.flagMaxQueuedRequests
+		}
+
+		if fl.Name == flagNameDefaultRenewalIncrement {
+			mountConfigInput.DefaultRenewalIncrement = c.flagDefaultRenewalIncrement.String()
+		}
+
+		if fl.Name == flagNameMaxRenewalIncrement {
+			mountConfigInput.MaxRenewalIncrement = c.flagMaxRenewalIncrement.String()
+		}
+
+		if fl.Name == flagNameRenewalGracePeriod {
+			mountConfigInput.RenewalGracePeriod = c.flagRenewalGracePeriod.String()
+		}
 	})
 
 	if err := client.Sys().TuneMount(mountPath, mountConfigInput); err != nil {
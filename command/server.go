@@ -35,12 +35,18 @@ import (
 	sockaddr "github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/entropy"
+	"github.com/hashicorp/vault/helper/fips"
 	"github.com/hashicorp/vault/helper/gated-writer"
 	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/mlock"
 	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/helper/pkcs11"
 	"github.com/hashicorp/vault/helper/reload"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/helper/tracing"
 	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/kmip"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/physical"
 	"github.com/hashicorp/vault/vault"
@@ -65,6 +71,8 @@ type ServerCommand struct {
 
 	logWriter io.Writer
 	logGate   *gatedwriter.Writer
+	logLines  *logging.LineBuffer
+	logLevels *logging.SubsystemLevels
 	logger    log.Logger
 
 	cleanupGuard sync.Once
@@ -74,12 +82,19 @@ type ServerCommand struct {
 	startedCh       chan (struct{}) // for tests
 	reloadedCh      chan (struct{}) // for tests
 
+	// logLevelSet is whether the log level was given on the command line, in
+	// which case the one of the configuration is ignored, even on reload
+	logLevelSet bool
+
 	// new stuff
 	flagConfigs        []string
 	flagLogLevel       string
+	flagLogFormat      string
+	flagRecovery       bool
 	flagDev            bool
 	flagDevRootTokenID string
 	flagDevListenAddr  string
+	flagDevTransit     bool
 
 	flagDevPluginDir     string
 	flagDevPluginInit    bool
@@ -100,6 +115,20 @@ type ServerListener struct {
 	config             map[string]interface{}
 	maxRequestSize     int64
 	maxRequestDuration time.Duration
+	disableUI          bool
+
+	hideUnauthenticatedDetails bool
+	requireAuthForSysInternal  bool
+	requireRequestHeader       bool
+	requestQueueTimeout        time.Duration
+	maxConcurrentRequests      int
+	reservedConcurrentRequests int
+
+	// kmip listeners serve transit keys to KMIP clients rather than the API
+	kmip               bool
+	kmipMount          string
+	kmipAuthMount      string
+	kmipMaxConnections int
 }
 
 func (c *ServerCommand) Synopsis() string {
@@ -152,11 +181,34 @@ func (c *ServerCommand) Flags() *FlagSets {
 	f.StringVar(&StringVar{
 		Name:       "log-level",
 		Target:     &c.flagLogLevel,
-		Default:    "info",
+		Default:    "",
 		EnvVar:     "VAULT_LOG_LEVEL",
 		Completion: complete.PredictSet("trace", "debug", "info", "warn", "err"),
 		Usage: "Log verbosity level. Supported values (in order of detail) are " +
-			"\"trace\", \"debug\", \"info\", \"warn\", and \"err\".",
+			"\"trace\", \"debug\", \"info\", \"warn\", and \"err\". This " +
+			"takes precedence over the log_level set in the configuration, " +
+			"both at startup and when the configuration is reloaded on SIGHUP. " +
+			"The default is \"info\".",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "log-format",
+		Target:     &c.flagLogFormat,
+		Default:    "",
+		EnvVar:     "VAULT_LOG_FORMAT",
+		Completion: complete.PredictSet("standard", "json"),
+		Usage: "Log format. Supported values are \"standard\" and \"json\". " +
+			"This takes precedence over the log_format set in the " +
+			"configuration. The default is \"standard\".",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:   "recovery",
+		Target: &c.flagRecovery,
+		Usage: "Start in recovery mode. In this mode, Vault only unseals the " +
+			"barrier and serves the sys/raw endpoint to the holder of a " +
+			"recovery token, so that corrupted storage entries can be " +
+			"repaired. HA and clustering are disabled.",
 	})
 
 	f = set.NewFlagSet("Dev Options")
@@ -186,6 +238,15 @@ func (c *ServerCommand) Flags() *FlagSets {
 		Usage:   "Address to bind to in \"dev\" mode.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "dev-transit",
+		Target:  &c.flagDevTransit,
+		Default: false,
+		EnvVar:  "VAULT_DEV_TRANSIT",
+		Usage: "Mount the transit secrets engine at \"transit/\". This only " +
+			"applies when running in \"dev\" mode.",
+	})
+
 	// Internal-only flags to follow.
 	//
 	// Why hello there little source code reader! Welcome to the Vault source
@@ -306,21 +367,20 @@ func (c *ServerCommand) Run(args []string) int {
 	if c.flagCombineLogs {
 		c.logWriter = os.Stdout
 	}
-	var level log.Level
+
+	// Keep the recent log lines, which are returned by sys/logs
+	c.logLines = logging.NewLineBuffer(1000)
+	c.logWriter = io.MultiWriter(c.logWriter, c.logLines)
 	c.flagLogLevel = strings.ToLower(strings.TrimSpace(c.flagLogLevel))
-	switch c.flagLogLevel {
-	case "trace":
-		level = log.Trace
-	case "debug":
-		level = log.Debug
-	case "notice", "info", "":
-		level = log.Info
-	case "warn", "warning":
-		level = log.Warn
-	case "err", "error":
-		level = log.Error
-	default:
-		c.UI.Error(fmt.Sprintf("Unknown log level: %s", c.flagLogLevel))
+	c.logLevelSet = c.flagLogLevel != ""
+	level, err := parseLogLevel(c.flagLogLevel)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	format, err := logging.ParseLogFormat(c.flagLogFormat)
+	if err != nil {
+		c.UI.Error(err.Error())
 		return 1
 	}
 
@@ -331,20 +391,19 @@ func (c *ServerCommand) Run(args []string) int {
 			Level:  log.Trace,
 		})
 	} else {
-		c.logger = logging.NewVaultLoggerWithWriter(c.logWriter, level)
+		c.logger = logging.NewVaultLoggerWithFormat(c.logWriter, level, format)
 	}
 
-	grpclog.SetLogger(&grpclogFaker{
-		logger: c.logger.Named("grpclogfaker"),
-		log:    os.Getenv("VAULT_GRPC_LOGGING") != "",
-	})
-
 	// Automatically enable dev mode if other dev flags are provided.
 	if c.flagDevHA || c.flagDevTransactional || c.flagDevLeasedKV || c.flagDevThreeNode || c.flagDevFourCluster {
 		c.flagDev = true
 	}
 
 	// Validation
+	if c.flagRecovery && c.flagDev {
+		c.UI.Error("Recovery mode cannot be used with dev mode")
+		return 1
+	}
 	if !c.flagDev {
 		switch {
 		case len(c.flagConfigs) == 0:
@@ -396,10 +455,55 @@ func (c *ServerCommand) Run(args []string) int {
 		return 1
 	}
 
+	// The log level from the configuration only applies if one was not
+	// given on the command line
+	if c.flagLogLevel == "" {
+		c.flagLogLevel = "info"
+		if config.LogLevel != "" && !c.flagDevThreeNode && !c.flagDevFourCluster {
+			level, err = parseLogLevel(config.LogLevel)
+			if err != nil {
+				c.UI.Error(err.Error())
+				return 1
+			}
+			c.flagLogLevel = config.LogLevel
+		}
+	}
+	if !c.flagDevThreeNode && !c.flagDevFourCluster {
+		if err := c.setupLogger(config, level, format); err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+	}
+
+	grpclog.SetLogger(&grpclogFaker{
+		logger: c.logger.Named("grpclogfaker"),
+		log:    os.Getenv("VAULT_GRPC_LOGGING") != "",
+	})
+
 	if config.DefaultMaxRequestDuration != 0 {
 		vault.DefaultMaxRequestDuration = config.DefaultMaxRequestDuration
 	}
 
+	// FIPS mode restricts the cryptography of the listeners, the cluster and
+	// transit, which validate their configuration against it as they start
+	if config.FIPSMode {
+		fips.SetEnabled(true)
+	}
+	if fips.Enabled() {
+		for _, lnConfig := range config.Listeners {
+			if lnConfig.Type == "tcp" || lnConfig.Type == "unix" {
+				if v, ok := lnConfig.Config["tls_disable"]; ok {
+					if disabled, err := parseutil.ParseBool(v); err == nil && disabled {
+						c.UI.Warn(wrapAtLength(fmt.Sprintf(
+							"WARNING! FIPS mode is enabled but TLS is disabled on the %s "+
+								"listener at %v, so its traffic is not protected by "+
+								"FIPS-approved cryptography.", lnConfig.Type, lnConfig.Config["address"])))
+					}
+				}
+			}
+		}
+	}
+
 	// If mlockall(2) isn't supported, show a warning. We disable this in dev
 	// because it is quite scary to see when first using Vault. We also disable
 	// this if the user has explicitly disabled mlock in configuration.
@@ -412,11 +516,19 @@ func (c *ServerCommand) Run(args []string) int {
 				"in a Docker container, provide the IPC_LOCK cap to the container."))
 	}
 
-	if err := c.setupTelemetry(config); err != nil {
+	inmemSink, err := c.setupTelemetry(config)
+	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error initializing telemetry: %s", err))
 		return 1
 	}
 
+	if tracer := c.setupTracing(config); tracer != nil {
+		defer func() {
+			tracing.SetTracer(nil)
+			tracer.Close()
+		}()
+	}
+
 	// Initialize the backend
 	factory, exists := c.PhysicalBackends[config.Storage.Type]
 	if !exists {
@@ -434,7 +546,19 @@ func (c *ServerCommand) Run(args []string) int {
 	info["log level"] = c.flagLogLevel
 	infoKeys = append(infoKeys, "log level")
 
-	var seal vault.Seal = vault.NewDefaultSeal()
+	if config.DisabledSeal != nil {
+		c.UI.Error(fmt.Sprintf("The %s seal is disabled, which is only supported by \"vault operator seal-migrate\": remove it from the configuration once the seal is migrated", config.DisabledSeal.Type))
+		return 1
+	}
+	seal, sealInfo, err := configureSeal(config.Seal)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if sealInfo != "" {
+		info["seal"] = sealInfo
+		infoKeys = append(infoKeys, "seal")
+	}
 
 	// Ensure that the seal finalizer is called, even if using verify-only
 	defer func() {
@@ -469,6 +593,34 @@ func (c *ServerCommand) Run(args []string) int {
 		PluginDirectory:    config.PluginDirectory,
 		EnableUI:           config.EnableUI,
 		EnableRaw:          config.EnableRawEndpoint,
+		DisablePprof:       config.DisablePprof,
+		PerformanceStandby: config.PerformanceStandby,
+		RootTokenMaxTTL:    config.RootTokenMaxTTL,
+		LogRootTokens:      config.LogRootTokens,
+
+		MountDeletionGracePeriod: config.MountDeletionGracePeriod,
+
+		LeaseRevocationWorkers: config.LeaseRevocationWorkers,
+
+		DefaultRenewalIncrement: config.DefaultRenewalIncrement,
+		MaxRenewalIncrement:     config.MaxRenewalIncrement,
+		RenewalGracePeriod:      config.RenewalGracePeriod,
+
+		OutboundProxyURL: config.OutboundProxyURL,
+		OutboundNoProxy:  config.OutboundNoProxy,
+
+		RecoveryMode: c.flagRecovery,
+
+		MetricsSink: inmemSink,
+		LogLines:    c.logLines,
+	}
+	if config.OutboundCACertFile != "" {
+		caCert, err := ioutil.ReadFile(config.OutboundCACertFile)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading outbound_ca_cert_file: %s", err))
+			return 1
+		}
+		coreConfig.OutboundCACert = string(caCert)
 	}
 	if c.flagDev {
 		coreConfig.DevToken = c.flagDevRootTokenID
@@ -488,15 +640,34 @@ func (c *ServerCommand) Run(args []string) int {
 		}
 	}
 
+	// Mix an external entropy source into key generation, if configured
+	if config.Entropy != nil {
+		source, err := entropy.NewFileSource(config.Entropy.Config["path"])
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error initializing entropy source of type %s: %s", config.Entropy.Type, err))
+			return 1
+		}
+		defer source.Close()
+
+		coreConfig.EntropySource = source
+		coreConfig.EntropyOperations = strutil.ParseStringSlice(config.Entropy.Config["operations"], ",")
+
+		info["entropy source"] = config.Entropy.Type
+		infoKeys = append(infoKeys, "entropy source")
+	}
+
 	if c.flagDevThreeNode {
 		return c.enableThreeNodeDevCluster(coreConfig, info, infoKeys, c.flagDevListenAddr, os.Getenv("VAULT_DEV_TEMP_DIR"))
 	}
 
 	var disableClustering bool
 
-	// Initialize the separate HA storage backend, if it exists
+	// Initialize the separate HA storage backend, if it exists. Recovery
+	// mode runs a single node, without HA.
 	var ok bool
-	if config.HAStorage != nil {
+	if c.flagRecovery {
+		disableClustering = true
+	} else if config.HAStorage != nil {
 		factory, exists := c.PhysicalBackends[config.HAStorage.Type]
 		if !exists {
 			c.UI.Error(fmt.Sprintf("Unknown HA storage type %s", config.HAStorage.Type))
@@ -565,6 +736,16 @@ func (c *ServerCommand) Run(args []string) int {
 		coreConfig.RedirectAddr = fmt.Sprintf("http://%s", config.Listeners[0].Config["address"])
 	}
 
+	if coreConfig.RedirectAddr != "" {
+		// The address is given to clients as is in standby redirects, so it
+		// has to be a full URL
+		u, err := url.ParseRequestURI(coreConfig.RedirectAddr)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			c.UI.Error(fmt.Sprintf("Error parsing api address %s: must be a full URL, like https://vault.example.com:8200", coreConfig.RedirectAddr))
+			return 1
+		}
+	}
+
 	// After the redirect bits are sorted out, if no cluster address was
 	// explicitly given, derive one from the redirect addr
 	if disableClustering {
@@ -574,7 +755,10 @@ func (c *ServerCommand) Run(args []string) int {
 	} else {
 		var addrToUse string
 		switch {
-		case coreConfig.ClusterAddr == "" && coreConfig.RedirectAddr != "":
+		case coreConfig.ClusterAddr != "":
+			// An explicitly given cluster address is used as is
+			goto CLUSTER_SYNTHESIS_COMPLETE
+		case coreConfig.RedirectAddr != "":
 			addrToUse = coreConfig.RedirectAddr
 		case c.flagDev:
 			addrToUse = fmt.Sprintf("http://%s", config.Listeners[0].Config["address"])
@@ -623,7 +807,7 @@ CLUSTER_SYNTHESIS_COMPLETE:
 		u, err := url.ParseRequestURI(coreConfig.ClusterAddr)
 		if err != nil {
 			c.UI.Error(fmt.Sprintf("Error parsing cluster address %s: %v", coreConfig.ClusterAddr, err))
-			return 11
+			return 1
 		}
 		u.Scheme = "https"
 		coreConfig.ClusterAddr = u.String()
@@ -660,6 +844,11 @@ CLUSTER_SYNTHESIS_COMPLETE:
 		mlock.Supported(), !config.DisableMlock && mlock.Supported())
 	infoKeys = append(infoKeys, "mlock", "storage")
 
+	if fips.Enabled() {
+		info["fips mode"] = "enabled"
+		infoKeys = append(infoKeys, "fips mode")
+	}
+
 	if coreConfig.ClusterAddr != "" {
 		info["cluster address"] = coreConfig.ClusterAddr
 		infoKeys = append(infoKeys, "cluster address")
@@ -757,11 +946,140 @@ CLUSTER_SYNTHESIS_COMPLETE:
 		}
 		props["max_request_duration"] = fmt.Sprintf("%s", maxRequestDuration.String())
 
+		// Hardening turns on all of the toggles below, which can otherwise
+		// be set individually
+		var harden bool
+		if valRaw, ok := lnConfig.Config["harden_unauthenticated_endpoints"]; ok {
+			harden, err = parseutil.ParseBool(valRaw)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Could not parse harden_unauthenticated_endpoints value %v", valRaw))
+				return 1
+			}
+		}
+
+		disableUI := harden
+		if valRaw, ok := lnConfig.Config["disable_ui"]; ok && !harden {
+			disableUI, err = parseutil.ParseBool(valRaw)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Could not parse disable_ui value %v", valRaw))
+				return 1
+			}
+		}
+		if config.EnableUI {
+			props["ui"] = strconv.FormatBool(!disableUI)
+		}
+
+		hideUnauthenticatedDetails := harden
+		if valRaw, ok := lnConfig.Config["hide_unauthenticated_details"]; ok && !harden {
+			hideUnauthenticatedDetails, err = parseutil.ParseBool(valRaw)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Could not parse hide_unauthenticated_details value %v", valRaw))
+				return 1
+			}
+		}
+		if hideUnauthenticatedDetails {
+			props["hide_unauthenticated_details"] = "true"
+		}
+
+		requireAuthForSysInternal := harden
+		if valRaw, ok := lnConfig.Config["require_auth_for_sys_internal"]; ok && !harden {
+			requireAuthForSysInternal, err = parseutil.ParseBool(valRaw)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Could not parse require_auth_for_sys_internal value %v", valRaw))
+				return 1
+			}
+		}
+		if requireAuthForSysInternal {
+			props["require_auth_for_sys_internal"] = "true"
+		}
+
+		var requireRequestHeader bool
+		if valRaw, ok := lnConfig.Config["require_request_header"]; ok {
+			requireRequestHeader, err = parseutil.ParseBool(valRaw)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Could not parse require_request_header value %v", valRaw))
+				return 1
+			}
+		}
+		if requireRequestHeader {
+			props["require_request_header"] = "true"
+		}
+
+		var requestQueueTimeout time.Duration
+		if valRaw, ok := lnConfig.Config["request_queue_timeout"]; ok {
+			requestQueueTimeout, err = parseutil.ParseDurationSecond(valRaw)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Could not parse request_queue_timeout value %v", valRaw))
+				return 1
+			}
+		}
+		if requestQueueTimeout > 0 {
+			props["request_queue_timeout"] = requestQueueTimeout.String()
+		}
+
+		var maxConcurrentRequests int64
+		if valRaw, ok := lnConfig.Config["max_concurrent_requests"]; ok {
+			maxConcurrentRequests, err = parseutil.ParseInt(valRaw)
+			if err != nil || maxConcurrentRequests < 0 {
+				c.UI.Error(fmt.Sprintf("Could not parse max_concurrent_requests value %v", valRaw))
+				return 1
+			}
+		}
+		var reservedConcurrentRequests int64 = vaulthttp.DefaultReservedConcurrentRequests
+		if valRaw, ok := lnConfig.Config["reserved_concurrent_requests"]; ok {
+			reservedConcurrentRequests, err = parseutil.ParseInt(valRaw)
+			if err != nil || reservedConcurrentRequests < 0 {
+				c.UI.Error(fmt.Sprintf("Could not parse reserved_concurrent_requests value %v", valRaw))
+				return 1
+			}
+		}
+		if maxConcurrentRequests > 0 {
+			props["max_concurrent_requests"] = fmt.Sprintf("%d", maxConcurrentRequests)
+			props["reserved_concurrent_requests"] = fmt.Sprintf("%d", reservedConcurrentRequests)
+		}
+
+		var kmipMount, kmipAuthMount string
+		var kmipMaxConnections int64
+		if lnConfig.Type == "kmip" {
+			kmipMount = kmip.DefaultMount
+			if valRaw, ok := lnConfig.Config["mount"]; ok {
+				kmipMount = fmt.Sprintf("%v", valRaw)
+			}
+			kmipAuthMount = kmip.DefaultAuthMount
+			if valRaw, ok := lnConfig.Config["auth_mount"]; ok {
+				kmipAuthMount = fmt.Sprintf("%v", valRaw)
+			}
+			props["mount"] = kmipMount
+			props["auth_mount"] = kmipAuthMount
+			kmipMaxConnections = kmip.DefaultMaxConnections
+			if valRaw, ok := lnConfig.Config["max_connections"]; ok {
+				kmipMaxConnections, err = parseutil.ParseInt(valRaw)
+				if err != nil || kmipMaxConnections < 0 {
+					c.UI.Error(fmt.Sprintf("Could not parse max_connections value %v", valRaw))
+					return 1
+				}
+			}
+			props["max_connections"] = fmt.Sprintf("%d", kmipMaxConnections)
+		}
+
 		lns = append(lns, ServerListener{
 			Listener:           ln,
 			config:             lnConfig.Config,
 			maxRequestSize:     maxRequestSize,
 			maxRequestDuration: maxRequestDuration,
+			disableUI:          disableUI,
+
+			hideUnauthenticatedDetails: hideUnauthenticatedDetails,
+			requireAuthForSysInternal:  requireAuthForSysInternal,
+			requireRequestHeader:       requireRequestHeader,
+			requestQueueTimeout:        requestQueueTimeout,
+			maxConcurrentRequests:      int(maxConcurrentRequests),
+			reservedConcurrentRequests: int(reservedConcurrentRequests),
+
+			kmip:               lnConfig.Type == "kmip",
+			kmipMount:          kmipMount,
+			kmipAuthMount:      kmipAuthMount,
+			kmipMaxConnections: int(kmipMaxConnections),
 		})
 
 		// Store the listener props for output later
@@ -954,13 +1272,47 @@ CLUSTER_SYNTHESIS_COMPLETE:
 		c.UI.Warn("")
 	}
 
+	if c.flagRecovery {
+		c.UI.Warn(wrapAtLength(
+			"WARNING! Vault is in recovery mode. Once unsealed, only the " +
+				"sys/raw endpoint is available, with a recovery token generated " +
+				"through sys/generate-recovery-token. Restart Vault without " +
+				"-recovery once storage is repaired."))
+		c.UI.Warn("")
+	}
+
 	// Initialize the HTTP servers
 	for _, ln := range lns {
+		if ln.kmip {
+			// The transit keys aren't available in recovery mode
+			if c.flagRecovery {
+				ln.Listener.Close()
+				continue
+			}
+			kmipServer := &kmip.Server{
+				Core:           core,
+				Mount:          ln.kmipMount,
+				AuthMount:      ln.kmipAuthMount,
+				MaxConnections: ln.kmipMaxConnections,
+				Logger:         c.logger.Named("kmip"),
+			}
+			go kmipServer.Serve(ln.Listener)
+			continue
+		}
+
 		handler := vaulthttp.Handler(&vault.HandlerProperties{
 			Core:                  core,
 			MaxRequestSize:        ln.maxRequestSize,
 			MaxRequestDuration:    ln.maxRequestDuration,
 			DisablePrintableCheck: config.DisablePrintableCheck,
+			DisableUI:             ln.disableUI,
+
+			HideUnauthenticatedDetails: ln.hideUnauthenticatedDetails,
+			RequireAuthForSysInternal:  ln.requireAuthForSysInternal,
+			RequireRequestHeader:       ln.requireRequestHeader,
+			RequestQueueTimeout:        ln.requestQueueTimeout,
+			MaxConcurrentRequests:      ln.maxConcurrentRequests,
+			ReservedConcurrentRequests: ln.reservedConcurrentRequests,
 		})
 
 		// We perform validation on the config earlier, we can just cast here
@@ -1049,6 +1401,29 @@ CLUSTER_SYNTHESIS_COMPLETE:
 	return 0
 }
 
+// configureSeal returns the seal of the configuration, or the Shamir seal if
+// there is none, with a description of it for the server output
+func configureSeal(sealConfig *server.Seal) (vault.Seal, string, error) {
+	if sealConfig == nil {
+		return vault.NewDefaultSeal(), "", nil
+	}
+
+	switch sealConfig.Type {
+	case vault.SealTypePKCS11:
+		hsmConfig, err := pkcs11.ParseConfig(sealConfig.Config)
+		if err != nil {
+			return nil, "", fmt.Errorf("Error parsing the configuration of the %s seal: %s", sealConfig.Type, err)
+		}
+		hsm, err := pkcs11.Open(hsmConfig)
+		if err != nil {
+			return nil, "", fmt.Errorf("Error initializing the %s seal: %s", sealConfig.Type, err)
+		}
+		return vault.NewPKCS11Seal(hsm, hsmConfig.HealthCheckInterval), fmt.Sprintf("%s (key label: %s)", sealConfig.Type, hsm.KeyLabel()), nil
+	default:
+		return nil, "", fmt.Errorf("Seals of type %s are not supported by this build of Vault", sealConfig.Type)
+	}
+}
+
 func (c *ServerCommand) enableDev(core *vault.Core, coreConfig *vault.CoreConfig) (*vault.InitResult, error) {
 	var recoveryConfig *vault.SealConfig
 	barrierConfig := &vault.SealConfig{
@@ -1186,6 +1561,25 @@ func (c *ServerCommand) enableDev(core *vault.Core, coreConfig *vault.CoreConfig
 		}
 	}
 
+	// Mount transit if requested
+	if c.flagDevTransit {
+		req := &logical.Request{
+			Operation:   logical.UpdateOperation,
+			ClientToken: init.RootToken,
+			Path:        "sys/mounts/transit",
+			Data: map[string]interface{}{
+				"type": "transit",
+			},
+		}
+		resp, err := core.HandleRequest(context.Background(), req)
+		if err != nil {
+			return nil, errwrap.Wrapf("error mounting transit: {{err}}", err)
+		}
+		if resp.IsError() {
+			return nil, errwrap.Wrapf("failed to mount transit: {{err}}", resp.Error())
+		}
+	}
+
 	return init, nil
 }
 
@@ -1487,7 +1881,7 @@ func (c *ServerCommand) detectRedirect(detect physical.RedirectDetect,
 }
 
 // setupTelemetry is used to setup the telemetry sub-systems
-func (c *ServerCommand) setupTelemetry(config *server.Config) error {
+func (c *ServerCommand) setupTelemetry(config *server.Config) (*metrics.InmemSink, error) {
 	/* Setup telemetry
 	Aggregate on 10 second intervals for 1 minute. Expose the
 	metrics over stderr when there is a SIGUSR1 received.
@@ -1504,13 +1898,14 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 
 	metricsConf := metrics.DefaultConfig("vault")
 	metricsConf.EnableHostname = !telConfig.DisableHostname
+	metricsConf.EnableHostnameLabel = telConfig.EnableHostnameLabel
 
 	// Configure the statsite sink
 	var fanout metrics.FanoutSink
 	if telConfig.StatsiteAddr != "" {
 		sink, err := metrics.NewStatsiteSink(telConfig.StatsiteAddr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fanout = append(fanout, sink)
 	}
@@ -1519,7 +1914,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 	if telConfig.StatsdAddr != "" {
 		sink, err := metrics.NewStatsdSink(telConfig.StatsdAddr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fanout = append(fanout, sink)
 	}
@@ -1555,7 +1950,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 
 		sink, err := circonus.NewCirconusSink(cfg)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		sink.Start()
 		fanout = append(fanout, sink)
@@ -1570,7 +1965,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 
 		sink, err := datadog.NewDogStatsdSink(telConfig.DogStatsDAddr, metricsConf.HostName)
 		if err != nil {
-			return errwrap.Wrapf("failed to start DogStatsD sink: {{err}}", err)
+			return nil, errwrap.Wrapf("failed to start DogStatsD sink: {{err}}", err)
 		}
 		sink.SetTags(tags)
 		fanout = append(fanout, sink)
@@ -1582,9 +1977,28 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 		metrics.NewGlobal(metricsConf, fanout)
 	} else {
 		metricsConf.EnableHostname = false
+		metricsConf.EnableHostnameLabel = false
 		metrics.NewGlobal(metricsConf, inm)
 	}
-	return nil
+	return inm, nil
+}
+
+// setupTracing sets the tracer of the requests if the telemetry configuration
+// has a Zipkin endpoint, and returns it
+func (c *ServerCommand) setupTracing(config *server.Config) *tracing.Tracer {
+	if config.Telemetry == nil || config.Telemetry.ZipkinEndpoint == "" {
+		return nil
+	}
+
+	sampleRate := config.Telemetry.TracingSampleRate
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+
+	reporter := tracing.NewZipkinReporter(config.Telemetry.ZipkinEndpoint, "vault", c.logger.Named("tracing"))
+	tracer := tracing.NewTracer(reporter, sampleRate)
+	tracing.SetTracer(tracer)
+	return tracer
 }
 
 func (c *ServerCommand) Reload(lock *sync.RWMutex, reloadFuncs *map[string][]reload.ReloadFunc, configPath []string) error {
@@ -1615,6 +2029,46 @@ func (c *ServerCommand) Reload(lock *sync.RWMutex, reloadFuncs *map[string][]rel
 		}
 	}
 
+	// Update the log level if one is set in the configuration, and none was
+	// given on the command line
+	if len(configPath) > 0 {
+		var config *server.Config
+		for _, path := range configPath {
+			current, err := server.LoadConfig(path, c.logger)
+			if err != nil {
+				reloadErrors = multierror.Append(reloadErrors, errwrap.Wrapf(fmt.Sprintf("error loading configuration from %s: {{err}}", path), err))
+				config = nil
+				break
+			}
+
+			if config == nil {
+				config = current
+			} else {
+				config = config.Merge(current)
+			}
+		}
+		if config != nil && config.LogLevel != "" && !c.logLevelSet {
+			level, err := parseLogLevel(strings.ToLower(strings.TrimSpace(config.LogLevel)))
+			if err != nil {
+				reloadErrors = multierror.Append(reloadErrors, err)
+			} else {
+				c.logger.SetLevel(level)
+				c.logger.Info("log level updated", "level", config.LogLevel)
+			}
+		}
+		if config != nil && c.logLevels != nil {
+			levels, err := parseSubsystemLogLevels(config.LogLevels)
+			if err != nil {
+				reloadErrors = multierror.Append(reloadErrors, err)
+			} else {
+				c.logLevels.SetSubsystemLevels(levels)
+				if len(levels) > 0 {
+					c.logger.Info("subsystem log levels updated", "levels", config.LogLevels)
+				}
+			}
+		}
+	}
+
 	// Send a message that we reloaded. This prevents "guessing" sleep times
 	// in tests.
 	select {
@@ -1625,6 +2079,68 @@ func (c *ServerCommand) Reload(lock *sync.RWMutex, reloadFuncs *map[string][]rel
 	return reloadErrors.ErrorOrNil()
 }
 
+// parseLogLevel converts a log level name from the command line or the
+// configuration into a log.Level
+func parseLogLevel(logLevel string) (log.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(logLevel)) {
+	case "trace":
+		return log.Trace, nil
+	case "debug":
+		return log.Debug, nil
+	case "notice", "info", "":
+		return log.Info, nil
+	case "warn", "warning":
+		return log.Warn, nil
+	case "err", "error":
+		return log.Error, nil
+	}
+	return log.NoLevel, fmt.Errorf("Unknown log level: %s", logLevel)
+}
+
+// setupLogger replaces the logger of the server with one in the format of the
+// configuration, unless one was given on the command line, and logging at
+// its subsystem levels. The logs are also written to the log file of the
+// configuration, if it has one.
+func (c *ServerCommand) setupLogger(config *server.Config, level log.Level, format logging.LogFormat) error {
+	if format == logging.UnspecifiedFormat {
+		var err error
+		if format, err = logging.ParseLogFormat(config.LogFormat); err != nil {
+			return err
+		}
+	}
+
+	levels, err := parseSubsystemLogLevels(config.LogLevels)
+	if err != nil {
+		return err
+	}
+
+	if config.LogFile != "" {
+		logFile, err := logging.NewLogFile(config.LogFile, int64(config.LogRotateBytes), config.LogRotateDuration, config.LogRotateMaxFiles)
+		if err != nil {
+			return errwrap.Wrapf("error opening the log file: {{err}}", err)
+		}
+		c.logWriter = io.MultiWriter(c.logWriter, logFile)
+	}
+
+	c.logLevels = logging.NewSubsystemLevels(level, levels)
+	c.logger = logging.NewSubsystemLogger(logging.NewVaultLoggerWithFormat(c.logWriter, level, format), "", c.logLevels)
+	return nil
+}
+
+// parseSubsystemLogLevels converts the log levels of the subsystems from the
+// configuration into log.Levels
+func parseSubsystemLogLevels(raw map[string]string) (map[string]log.Level, error) {
+	levels := make(map[string]log.Level, len(raw))
+	for subsystem, rawLevel := range raw {
+		level, err := parseLogLevel(rawLevel)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("invalid level for the %q subsystem: {{err}}", subsystem), err)
+		}
+		levels[subsystem] = level
+	}
+	return levels, nil
+}
+
 // storePidFile is used to write out our PID to a file if necessary
 func (c *ServerCommand) storePidFile(pidPath string) error {
 	// Quit fast if no pidfile
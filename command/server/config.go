@@ -27,6 +27,11 @@ type Config struct {
 
 	Seal *Seal `hcl:"-"`
 
+	// DisabledSeal is the seal migrated from by "vault operator seal-migrate"
+	DisabledSeal *Seal `hcl:"-"`
+
+	Entropy *Entropy `hcl:"-"`
+
 	CacheSize                int         `hcl:"cache_size"`
 	DisableCache             bool        `hcl:"-"`
 	DisableCacheRaw          interface{} `hcl:"disable_cache"`
@@ -35,6 +40,9 @@ type Config struct {
 	DisablePrintableCheck    bool        `hcl:"-"`
 	DisablePrintableCheckRaw interface{} `hcl:"disable_printable_check"`
 
+	FIPSMode    bool        `hcl:"-"`
+	FIPSModeRaw interface{} `hcl:"fips_mode"`
+
 	EnableUI    bool        `hcl:"-"`
 	EnableUIRaw interface{} `hcl:"ui"`
 
@@ -54,6 +62,8 @@ type Config struct {
 	PluginDirectory string `hcl:"plugin_directory"`
 
 	PidFile              string      `hcl:"pid_file"`
+	LogLevel             string      `hcl:"log_level"`
+	LogFormat            string      `hcl:"log_format"`
 	EnableRawEndpoint    bool        `hcl:"-"`
 	EnableRawEndpointRaw interface{} `hcl:"raw_storage_endpoint"`
 
@@ -64,6 +74,46 @@ type Config struct {
 
 	DisableSealWrap    bool        `hcl:"-"`
 	DisableSealWrapRaw interface{} `hcl:"disable_sealwrap"`
+
+	DisablePprof    bool        `hcl:"-"`
+	DisablePprofRaw interface{} `hcl:"disable_pprof"`
+
+	PerformanceStandby    bool        `hcl:"-"`
+	PerformanceStandbyRaw interface{} `hcl:"performance_standby"`
+
+	RootTokenMaxTTL    time.Duration `hcl:"-"`
+	RootTokenMaxTTLRaw interface{}   `hcl:"root_token_max_ttl"`
+	LogRootTokens      bool          `hcl:"-"`
+	LogRootTokensRaw   interface{}   `hcl:"log_root_tokens"`
+
+	MountDeletionGracePeriod    time.Duration `hcl:"-"`
+	MountDeletionGracePeriodRaw interface{}   `hcl:"mount_deletion_grace_period"`
+
+	LeaseRevocationWorkers int `hcl:"lease_revocation_workers"`
+
+	DefaultRenewalIncrement    time.Duration `hcl:"-"`
+	DefaultRenewalIncrementRaw interface{}   `hcl:"default_renewal_increment"`
+	MaxRenewalIncrement        time.Duration `hcl:"-"`
+	MaxRenewalIncrementRaw     interface{}   `hcl:"max_renewal_increment"`
+	RenewalGracePeriod         time.Duration `hcl:"-"`
+	RenewalGracePeriodRaw      interface{}   `hcl:"renewal_grace_period"`
+
+	// The proxy the backends reach external APIs through, the hosts they
+	// reach directly, and the CA certificates they trust in addition to
+	// those of the system
+	OutboundProxyURL   string `hcl:"outbound_proxy_url"`
+	OutboundNoProxy    string `hcl:"outbound_no_proxy"`
+	OutboundCACertFile string `hcl:"outbound_ca_cert_file"`
+
+	// LogLevels overrides the log level for subsystems such as "core" or
+	// "storage", from the log_levels block
+	LogLevels map[string]string `hcl:"-"`
+
+	LogFile              string        `hcl:"log_file"`
+	LogRotateBytes       int           `hcl:"log_rotate_bytes"`
+	LogRotateDuration    time.Duration `hcl:"-"`
+	LogRotateDurationRaw interface{}   `hcl:"log_rotate_duration"`
+	LogRotateMaxFiles    int           `hcl:"log_rotate_max_files"`
 }
 
 // DevConfig is a Config that is used for dev mode of Vault.
@@ -138,6 +188,17 @@ func (h *Seal) GoString() string {
 	return fmt.Sprintf("*%#v", *h)
 }
 
+// Entropy contains the configuration of an external entropy source for the
+// server
+type Entropy struct {
+	Type   string
+	Config map[string]string
+}
+
+func (e *Entropy) GoString() string {
+	return fmt.Sprintf("*%#v", *e)
+}
+
 // Telemetry is the telemetry configuration for the server
 type Telemetry struct {
 	StatsiteAddr string `hcl:"statsite_address"`
@@ -145,6 +206,11 @@ type Telemetry struct {
 
 	DisableHostname bool `hcl:"disable_hostname"`
 
+	// EnableHostnameLabel adds the hostname as a "host" label of the
+	// metrics, rather than prefixing their names with it, so the metrics of
+	// the nodes of a cluster share their names in sinks supporting labels
+	EnableHostnameLabel bool `hcl:"enable_hostname_label"`
+
 	// Circonus: see https://github.com/circonus-labs/circonus-gometrics
 	// for more details on the various configuration options.
 	// Valid configuration combinations:
@@ -224,6 +290,18 @@ type Telemetry struct {
 	// DogStatsdTags are the global tags that should be sent with each packet to dogstatsd
 	// It is a list of strings, where each string looks like "my_tag_name:my_tag_value"
 	DogStatsDTags []string `hcl:"dogstatsd_tags"`
+
+	// Tracing:
+	// ZipkinEndpoint is the URL of the span endpoint of the v2 API of
+	// Zipkin, such as http://zipkin:9411/api/v2/spans. If provided, the
+	// traces of the requests are sent to it
+	ZipkinEndpoint string `hcl:"zipkin_endpoint"`
+
+	// TracingSampleRate is the share of the requests which are traced when
+	// they don't carry the sampling decision of a trace, between 0 and 1.
+	// Zero leaves the default.
+	// Default: 1
+	TracingSampleRate float64 `hcl:"tracing_sample_rate"`
 }
 
 func (s *Telemetry) GoString() string {
@@ -259,6 +337,16 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.Seal = c2.Seal
 	}
 
+	result.DisabledSeal = c.DisabledSeal
+	if c2.DisabledSeal != nil {
+		result.DisabledSeal = c2.DisabledSeal
+	}
+
+	result.Entropy = c.Entropy
+	if c2.Entropy != nil {
+		result.Entropy = c2.Entropy
+	}
+
 	result.Telemetry = c.Telemetry
 	if c2.Telemetry != nil {
 		result.Telemetry = c2.Telemetry
@@ -280,6 +368,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.DisableMlock = c2.DisableMlock
 	}
 
+	result.FIPSMode = c.FIPSMode
+	if c2.FIPSMode {
+		result.FIPSMode = c2.FIPSMode
+	}
+
 	// merge these integers via a MAX operation
 	result.MaxLeaseTTL = c.MaxLeaseTTL
 	if c2.MaxLeaseTTL > result.MaxLeaseTTL {
@@ -326,11 +419,106 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.PidFile = c2.PidFile
 	}
 
+	result.LogLevel = c.LogLevel
+	if c2.LogLevel != "" {
+		result.LogLevel = c2.LogLevel
+	}
+
+	result.LogFormat = c.LogFormat
+	if c2.LogFormat != "" {
+		result.LogFormat = c2.LogFormat
+	}
+
+	result.LogLevels = c.LogLevels
+	if c2.LogLevels != nil {
+		result.LogLevels = c2.LogLevels
+	}
+
+	result.LogFile = c.LogFile
+	if c2.LogFile != "" {
+		result.LogFile = c2.LogFile
+	}
+
+	result.LogRotateBytes = c.LogRotateBytes
+	if c2.LogRotateBytes != 0 {
+		result.LogRotateBytes = c2.LogRotateBytes
+	}
+
+	result.LogRotateDuration = c.LogRotateDuration
+	if c2.LogRotateDuration != 0 {
+		result.LogRotateDuration = c2.LogRotateDuration
+	}
+
+	result.LogRotateMaxFiles = c.LogRotateMaxFiles
+	if c2.LogRotateMaxFiles != 0 {
+		result.LogRotateMaxFiles = c2.LogRotateMaxFiles
+	}
+
 	result.DisableSealWrap = c.DisableSealWrap
 	if c2.DisableSealWrap {
 		result.DisableSealWrap = c2.DisableSealWrap
 	}
 
+	result.DisablePprof = c.DisablePprof
+	if c2.DisablePprof {
+		result.DisablePprof = c2.DisablePprof
+	}
+
+	result.PerformanceStandby = c.PerformanceStandby
+	if c2.PerformanceStandby {
+		result.PerformanceStandby = c2.PerformanceStandby
+	}
+
+	result.RootTokenMaxTTL = c.RootTokenMaxTTL
+	if c2.RootTokenMaxTTL != 0 {
+		result.RootTokenMaxTTL = c2.RootTokenMaxTTL
+	}
+
+	result.LogRootTokens = c.LogRootTokens
+	if c2.LogRootTokens {
+		result.LogRootTokens = c2.LogRootTokens
+	}
+
+	result.MountDeletionGracePeriod = c.MountDeletionGracePeriod
+	if c2.MountDeletionGracePeriod != 0 {
+		result.MountDeletionGracePeriod = c2.MountDeletionGracePeriod
+	}
+
+	result.LeaseRevocationWorkers = c.LeaseRevocationWorkers
+	if c2.LeaseRevocationWorkers != 0 {
+		result.LeaseRevocationWorkers = c2.LeaseRevocationWorkers
+	}
+
+	result.DefaultRenewalIncrement = c.DefaultRenewalIncrement
+	if c2.DefaultRenewalIncrement != 0 {
+		result.DefaultRenewalIncrement = c2.DefaultRenewalIncrement
+	}
+
+	result.MaxRenewalIncrement = c.MaxRenewalIncrement
+	if c2.MaxRenewalIncrement != 0 {
+		result.MaxRenewalIncrement = c2.MaxRenewalIncrement
+	}
+
+	result.RenewalGracePeriod = c.RenewalGracePeriod
+	if c2.RenewalGracePeriod != 0 {
+		result.RenewalGracePeriod = c2.RenewalGracePeriod
+	}
+
+	result.OutboundProxyURL = c.OutboundProxyURL
+	if c2.OutboundProxyURL != "" {
+		result.OutboundProxyURL = c2.OutboundProxyURL
+	}
+
+	result.OutboundNoProxy = c.OutboundNoProxy
+	if c2.OutboundNoProxy != "" {
+		result.OutboundNoProxy = c2.OutboundNoProxy
+	}
+
+	result.OutboundCACertFile = c.OutboundCACertFile
+	if c2.OutboundCACertFile != "" {
+		result.OutboundCACertFile = c2.OutboundCACertFile
+	}
+
 	return result
 }
 
@@ -412,6 +600,12 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		}
 	}
 
+	if result.FIPSModeRaw != nil {
+		if result.FIPSMode, err = parseutil.ParseBool(result.FIPSModeRaw); err != nil {
+			return nil, err
+		}
+	}
+
 	if result.EnableRawEndpointRaw != nil {
 		if result.EnableRawEndpoint, err = parseutil.ParseBool(result.EnableRawEndpointRaw); err != nil {
 			return nil, err
@@ -430,6 +624,61 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		}
 	}
 
+	if result.DisablePprofRaw != nil {
+		if result.DisablePprof, err = parseutil.ParseBool(result.DisablePprofRaw); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.PerformanceStandbyRaw != nil {
+		if result.PerformanceStandby, err = parseutil.ParseBool(result.PerformanceStandbyRaw); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.RootTokenMaxTTLRaw != nil {
+		if result.RootTokenMaxTTL, err = parseutil.ParseDurationSecond(result.RootTokenMaxTTLRaw); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.LogRootTokensRaw != nil {
+		if result.LogRootTokens, err = parseutil.ParseBool(result.LogRootTokensRaw); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.MountDeletionGracePeriodRaw != nil {
+		if result.MountDeletionGracePeriod, err = parseutil.ParseDurationSecond(result.MountDeletionGracePeriodRaw); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.DefaultRenewalIncrementRaw != nil {
+		if result.DefaultRenewalIncrement, err = parseutil.ParseDurationSecond(result.DefaultRenewalIncrementRaw); err != nil {
+			return nil, err
+		}
+	}
+	if result.MaxRenewalIncrementRaw != nil {
+		if result.MaxRenewalIncrement, err = parseutil.ParseDurationSecond(result.MaxRenewalIncrementRaw); err != nil {
+			return nil, err
+		}
+	}
+	if result.RenewalGracePeriodRaw != nil {
+		if result.RenewalGracePeriod, err = parseutil.ParseDurationSecond(result.RenewalGracePeriodRaw); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.LogRotateDurationRaw != nil {
+		if result.LogRotateDuration, err = parseutil.ParseDurationSecond(result.LogRotateDurationRaw); err != nil {
+			return nil, err
+		}
+	}
+	if result.LogRotateBytes < 0 || result.LogRotateDuration < 0 || result.LogRotateMaxFiles < 0 {
+		return nil, fmt.Errorf("log_rotate_bytes, log_rotate_duration and log_rotate_max_files cannot be negative")
+	}
+
 	list, ok := obj.Node.(*ast.ObjectList)
 	if !ok {
 		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
@@ -472,6 +721,12 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		}
 	}
 
+	if o := list.Filter("entropy"); len(o.Items) > 0 {
+		if err := parseEntropy(&result, o); err != nil {
+			return nil, errwrap.Wrapf("error parsing 'entropy': {{err}}", err)
+		}
+	}
+
 	if o := list.Filter("listener"); len(o.Items) > 0 {
 		if err := parseListeners(&result, o); err != nil {
 			return nil, errwrap.Wrapf("error parsing 'listener': {{err}}", err)
@@ -484,6 +739,12 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		}
 	}
 
+	if o := list.Filter("log_levels"); len(o.Items) > 0 {
+		if err := parseLogLevels(&result, o); err != nil {
+			return nil, errwrap.Wrapf("error parsing 'log_levels': {{err}}", err)
+		}
+	}
+
 	return &result, nil
 }
 
@@ -699,34 +960,98 @@ func parseHAStorage(result *Config, list *ast.ObjectList, name string) error {
 }
 
 func parseSeal(result *Config, list *ast.ObjectList, blockName string) error {
+	// A second block is permitted when one of them is disabled, to migrate
+	// from the disabled seal to the other one
+	if len(list.Items) > 2 {
+		return fmt.Errorf("only one %q block is permitted, or two when one is disabled", blockName)
+	}
+
+	var enabled, disabled *Seal
+	for _, item := range list.Items {
+		key := blockName
+		if len(item.Keys) > 0 {
+			key = item.Keys[0].Token.Value().(string)
+		}
+
+		// Valid parameter for the Seal types
+		switch key {
+		case "pkcs11":
+		case "awskms":
+		case "gcpckms":
+		case "azurekeyvault":
+		default:
+			return fmt.Errorf("invalid seal type %q", key)
+		}
+
+		var m map[string]string
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("%s.%s:", blockName, key))
+		}
+
+		isDisabled := false
+		if raw, ok := m["disabled"]; ok {
+			var err error
+			if isDisabled, err = strconv.ParseBool(raw); err != nil {
+				return multierror.Prefix(fmt.Errorf("invalid value for disabled: %s", err), fmt.Sprintf("%s.%s:", blockName, key))
+			}
+			delete(m, "disabled")
+		}
+
+		seal := &Seal{
+			Type:   strings.ToLower(key),
+			Config: m,
+		}
+		switch {
+		case isDisabled && disabled != nil:
+			return fmt.Errorf("only one %q block can be disabled", blockName)
+		case isDisabled:
+			disabled = seal
+		case enabled != nil:
+			return fmt.Errorf("only one %q block is permitted, or two when one is disabled", blockName)
+		default:
+			enabled = seal
+		}
+	}
+
+	if enabled != nil {
+		result.Seal = enabled
+	}
+	if disabled != nil {
+		result.DisabledSeal = disabled
+	}
+	return nil
+}
+
+func parseEntropy(result *Config, list *ast.ObjectList) error {
 	if len(list.Items) > 1 {
-		return fmt.Errorf("only one %q block is permitted", blockName)
+		return fmt.Errorf("only one %q block is permitted", "entropy")
 	}
 
 	// Get our item
 	item := list.Items[0]
 
-	key := blockName
+	key := "entropy"
 	if len(item.Keys) > 0 {
 		key = item.Keys[0].Token.Value().(string)
 	}
 
-	// Valid parameter for the Seal types
+	// Valid entropy source types
 	switch key {
-	case "pkcs11":
-	case "awskms":
-	case "gcpckms":
-	case "azurekeyvault":
+	case "file":
 	default:
-		return fmt.Errorf("invalid seal type %q", key)
+		return fmt.Errorf("invalid entropy source type %q", key)
 	}
 
 	var m map[string]string
 	if err := hcl.DecodeObject(&m, item.Val); err != nil {
-		return multierror.Prefix(err, fmt.Sprintf("%s.%s:", blockName, key))
+		return multierror.Prefix(err, fmt.Sprintf("entropy.%s:", key))
 	}
 
-	result.Seal = &Seal{
+	if m["path"] == "" {
+		return fmt.Errorf("entropy source of type %q requires a path", key)
+	}
+
+	result.Entropy = &Entropy{
 		Type:   strings.ToLower(key),
 		Config: m,
 	}
@@ -779,5 +1104,23 @@ func parseTelemetry(result *Config, list *ast.ObjectList) error {
 	if err := hcl.DecodeObject(&result.Telemetry, item.Val); err != nil {
 		return multierror.Prefix(err, "telemetry:")
 	}
+
+	if result.Telemetry.TracingSampleRate < 0 || result.Telemetry.TracingSampleRate > 1 {
+		return fmt.Errorf("telemetry: tracing_sample_rate must be between 0 and 1")
+	}
+	return nil
+}
+
+func parseLogLevels(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'log_levels' block is permitted")
+	}
+
+	var m map[string]string
+	if err := hcl.DecodeObject(&m, list.Items[0].Val); err != nil {
+		return multierror.Prefix(err, "log_levels:")
+	}
+
+	result.LogLevels = m
 	return nil
 }
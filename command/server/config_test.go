@@ -48,11 +48,12 @@ func TestLoadConfigFile(t *testing.T) {
 		},
 
 		Telemetry: &Telemetry{
-			StatsdAddr:      "bar",
-			StatsiteAddr:    "foo",
-			DisableHostname: false,
-			DogStatsDAddr:   "127.0.0.1:7254",
-			DogStatsDTags:   []string{"tag_1:val_1", "tag_2:val_2"},
+			StatsdAddr:          "bar",
+			StatsiteAddr:        "foo",
+			DisableHostname:     false,
+			EnableHostnameLabel: true,
+			DogStatsDAddr:       "127.0.0.1:7254",
+			DogStatsDTags:       []string{"tag_1:val_1", "tag_2:val_2"},
 		},
 
 		DisableCache:             true,
@@ -67,6 +68,9 @@ func TestLoadConfigFile(t *testing.T) {
 		EnableRawEndpoint:    true,
 		EnableRawEndpointRaw: true,
 
+		DisablePprof:    true,
+		DisablePprofRaw: true,
+
 		MaxLeaseTTL:        10 * time.Hour,
 		MaxLeaseTTLRaw:     "10h",
 		DefaultLeaseTTL:    10 * time.Hour,
@@ -145,6 +149,14 @@ func TestLoadConfigFile_topLevel(t *testing.T) {
 
 		APIAddr:     "top_level_api_addr",
 		ClusterAddr: "top_level_cluster_addr",
+
+		RootTokenMaxTTL:    time.Hour,
+		RootTokenMaxTTLRaw: "1h",
+		LogRootTokens:      true,
+		LogRootTokensRaw:   true,
+
+		MountDeletionGracePeriod:    24 * time.Hour,
+		MountDeletionGracePeriodRaw: "24h",
 	}
 	if !reflect.DeepEqual(config, expected) {
 		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config, expected)
@@ -383,3 +395,200 @@ listener "tcp" {
 	}
 
 }
+
+func TestParseEntropy(t *testing.T) {
+	obj, _ := hcl.Parse(strings.TrimSpace(`
+entropy "file" {
+	path = "/dev/hwrng"
+	operations = "barrier,transit"
+}`))
+
+	var config Config
+	list, _ := obj.Node.(*ast.ObjectList)
+	objList := list.Filter("entropy")
+	if err := parseEntropy(&config, objList); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Entropy{
+		Type: "file",
+		Config: map[string]string{
+			"path":       "/dev/hwrng",
+			"operations": "barrier,transit",
+		},
+	}
+	if !reflect.DeepEqual(config.Entropy, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config.Entropy, expected)
+	}
+
+	for _, input := range []string{
+		`entropy "bogus" { path = "/dev/hwrng" }`,
+		`entropy "file" {}`,
+	} {
+		obj, _ := hcl.Parse(input)
+		list, _ := obj.Node.(*ast.ObjectList)
+		if err := parseEntropy(&Config{}, list.Filter("entropy")); err == nil {
+			t.Fatalf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestParseSeal(t *testing.T) {
+	obj, _ := hcl.Parse(strings.TrimSpace(`
+seal "pkcs11" {
+	lib = "/usr/lib/softhsm/libsofthsm2.so"
+	key_label = "vault-new"
+}
+
+seal "pkcs11" {
+	lib = "/usr/lib/softhsm/libsofthsm2.so"
+	key_label = "vault"
+	disabled = "true"
+}`))
+
+	var config Config
+	list, _ := obj.Node.(*ast.ObjectList)
+	if err := parseSeal(&config, list.Filter("seal"), "seal"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Seal{
+		Type: "pkcs11",
+		Config: map[string]string{
+			"lib":       "/usr/lib/softhsm/libsofthsm2.so",
+			"key_label": "vault-new",
+		},
+	}
+	if !reflect.DeepEqual(config.Seal, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config.Seal, expected)
+	}
+	expected.Config["key_label"] = "vault"
+	if !reflect.DeepEqual(config.DisabledSeal, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config.DisabledSeal, expected)
+	}
+
+	for _, input := range []string{
+		`seal "bogus" {}`,
+		`seal "pkcs11" {} seal "pkcs11" {}`,
+		`seal "pkcs11" { disabled = "true" } seal "pkcs11" { disabled = "true" }`,
+		`seal "pkcs11" { disabled = "maybe" }`,
+	} {
+		obj, _ := hcl.Parse(input)
+		list, _ := obj.Node.(*ast.ObjectList)
+		if err := parseSeal(&Config{}, list.Filter("seal"), "seal"); err == nil {
+			t.Fatalf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestParseConfig_logging(t *testing.T) {
+	config, err := ParseConfig(strings.TrimSpace(`
+log_level  = "info"
+log_format = "json"
+
+log_levels {
+	expiration     = "debug"
+	"secrets.kv"   = "trace"
+}
+
+log_file             = "/var/log/vault/vault.log"
+log_rotate_bytes     = 1048576
+log_rotate_duration  = "24h"
+log_rotate_max_files = 7
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.LogFormat != "json" {
+		t.Fatalf("bad: %q", config.LogFormat)
+	}
+	expected := map[string]string{
+		"expiration": "debug",
+		"secrets.kv": "trace",
+	}
+	if !reflect.DeepEqual(config.LogLevels, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config.LogLevels, expected)
+	}
+	if config.LogFile != "/var/log/vault/vault.log" || config.LogRotateBytes != 1048576 ||
+		config.LogRotateDuration != 24*time.Hour || config.LogRotateMaxFiles != 7 {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	merged := config.Merge(&Config{LogFormat: "standard"})
+	if merged.LogFormat != "standard" || !reflect.DeepEqual(merged.LogLevels, expected) || merged.LogRotateDuration != 24*time.Hour {
+		t.Fatalf("bad: %#v", merged)
+	}
+
+	for _, input := range []string{
+		`log_levels { core = "info" } log_levels { storage = "debug" }`,
+		`log_rotate_bytes = -1`,
+		`log_rotate_duration = "bogus"`,
+	} {
+		if _, err := ParseConfig(input, nil); err == nil {
+			t.Fatalf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestParseConfig_tracing(t *testing.T) {
+	config, err := ParseConfig(strings.TrimSpace(`
+telemetry {
+	zipkin_endpoint     = "http://zipkin:9411/api/v2/spans"
+	tracing_sample_rate = 0.25
+}
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Telemetry.ZipkinEndpoint != "http://zipkin:9411/api/v2/spans" || config.Telemetry.TracingSampleRate != 0.25 {
+		t.Fatalf("bad: %#v", config.Telemetry)
+	}
+
+	for _, input := range []string{
+		`telemetry { tracing_sample_rate = 1.5 }`,
+		`telemetry { tracing_sample_rate = -1 }`,
+	} {
+		if _, err := ParseConfig(input, nil); err == nil {
+			t.Fatalf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestParseConfig_renewal(t *testing.T) {
+	config, err := ParseConfig(strings.TrimSpace(`
+default_renewal_increment = "1h"
+max_renewal_increment     = "24h"
+renewal_grace_period      = 300
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.DefaultRenewalIncrement != time.Hour || config.MaxRenewalIncrement != 24*time.Hour || config.RenewalGracePeriod != 5*time.Minute {
+		t.Fatalf("bad: %s %s %s", config.DefaultRenewalIncrement, config.MaxRenewalIncrement, config.RenewalGracePeriod)
+	}
+}
+
+func TestParseConfig_outbound(t *testing.T) {
+	config, err := ParseConfig(strings.TrimSpace(`
+outbound_proxy_url    = "http://proxy.example.com:3128"
+outbound_no_proxy     = "localhost,.internal"
+outbound_ca_cert_file = "/etc/vault/egress-ca.pem"
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.OutboundProxyURL != "http://proxy.example.com:3128" || config.OutboundNoProxy != "localhost,.internal" || config.OutboundCACertFile != "/etc/vault/egress-ca.pem" {
+		t.Fatalf("bad: %q %q %q", config.OutboundProxyURL, config.OutboundNoProxy, config.OutboundCACertFile)
+	}
+
+	merged := config.Merge(&Config{
+		OutboundNoProxy: "*",
+	})
+	if merged.OutboundProxyURL != config.OutboundProxyURL || merged.OutboundNoProxy != "*" {
+		t.Fatalf("bad: %q %q", merged.OutboundProxyURL, merged.OutboundNoProxy)
+	}
+}
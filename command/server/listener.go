@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"net"
 
+	"github.com/hashicorp/vault/helper/fips"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/proxyutil"
 	"github.com/hashicorp/vault/helper/reload"
@@ -24,7 +25,9 @@ type ListenerFactory func(map[string]interface{}, io.Writer, cli.Ui) (net.Listen
 
 // BuiltinListeners is the list of built-in listener types.
 var BuiltinListeners = map[string]ListenerFactory{
-	"tcp": tcpListenerFactory,
+	"kmip": kmipListenerFactory,
+	"tcp":  tcpListenerFactory,
+	"unix": unixListenerFactory,
 }
 
 // NewListener creates a new listener of the given type with the given
@@ -133,6 +136,15 @@ PASSPHRASECORRECT:
 	if !ok {
 		return nil, nil, nil, fmt.Errorf("'tls_min_version' value %q not supported, please specify one of [tls10,tls11,tls12]", tlsvers)
 	}
+	if v, ok := config["tls_max_version"]; ok {
+		tlsConf.MaxVersion, ok = tlsutil.TLSLookup[v.(string)]
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("'tls_max_version' value %q not supported, please specify one of [tls10,tls11,tls12]", v)
+		}
+		if tlsConf.MaxVersion < tlsConf.MinVersion {
+			return nil, nil, nil, fmt.Errorf("'tls_max_version' must not be lower than 'tls_min_version'")
+		}
+	}
 	tlsConf.ClientAuth = tls.RequestClientCert
 
 	if v, ok := config["tls_cipher_suites"]; ok {
@@ -149,6 +161,9 @@ PASSPHRASECORRECT:
 		}
 		tlsConf.PreferServerCipherSuites = preferServer
 	}
+	if err := fips.ConfigureTLS(tlsConf); err != nil {
+		return nil, nil, nil, errwrap.Wrapf("invalid TLS configuration for FIPS mode: {{err}}", err)
+	}
 	var requireVerifyCerts bool
 	var err error
 	if v, ok := config["tls_require_and_verify_client_cert"]; ok {
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/helper/reload"
+	"github.com/mitchellh/cli"
+)
+
+// kmipListenerFactory creates a TCP listener for KMIP clients. KMIP clients
+// authenticate with their TLS certificates, so TLS can't be disabled.
+func kmipListenerFactory(config map[string]interface{}, logger io.Writer, ui cli.Ui) (net.Listener, map[string]string, reload.ReloadFunc, error) {
+	for _, key := range []string{"tls_disable", "tls_disable_client_certs"} {
+		if v, ok := config[key]; ok {
+			disabled, err := parseutil.ParseBool(v)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid value for '%s': %v", key, v)
+			}
+			if disabled {
+				return nil, nil, nil, fmt.Errorf("'%s' is not supported by kmip listeners", key)
+			}
+		}
+	}
+	if _, ok := config["proxy_protocol_behavior"]; ok {
+		return nil, nil, nil, fmt.Errorf("'proxy_protocol_behavior' is not supported by kmip listeners")
+	}
+	if _, ok := config["x_forwarded_for_authorized_addrs"]; ok {
+		return nil, nil, nil, fmt.Errorf("'x_forwarded_for_authorized_addrs' is not supported by kmip listeners")
+	}
+
+	if _, ok := config["address"]; !ok {
+		config["address"] = "127.0.0.1:5696"
+	}
+
+	ln, props, reloadFunc, err := tcpListenerFactory(config, logger, ui)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, key := range []string{"mount", "auth_mount"} {
+		if v, ok := config[key]; ok {
+			props[key] = fmt.Sprintf("%v", v)
+		}
+	}
+	if v, ok := config["max_connections"]; ok {
+		maxConnections, err := parseutil.ParseInt(v)
+		if err != nil || maxConnections < 0 {
+			ln.Close()
+			return nil, nil, nil, fmt.Errorf("invalid value for 'max_connections': %v", v)
+		}
+		props["max_connections"] = fmt.Sprintf("%d", maxConnections)
+	}
+
+	return ln, props, reloadFunc, nil
+}
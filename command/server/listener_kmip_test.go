@@ -0,0 +1,56 @@
+package server
+
+import (
+	"crypto/tls"
+	"os"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestKMIPListener(t *testing.T) {
+	wd, _ := os.Getwd()
+	wd += "/test-fixtures/reload/"
+
+	ln, props, _, err := kmipListenerFactory(map[string]interface{}{
+		"address":       "127.0.0.1:0",
+		"tls_cert_file": wd + "reload_foo.pem",
+		"tls_key_file":  wd + "reload_foo.key",
+		"mount":         "kmip-transit",
+	}, nil, cli.NewMockUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer ln.Close()
+	if props["mount"] != "kmip-transit" {
+		t.Fatalf("bad: %#v", props)
+	}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok {
+			t.Errorf("expected a tls connection, got %T", conn)
+			return
+		}
+		tlsConn.Handshake()
+	}()
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	conn.Close()
+
+	for _, key := range []string{"tls_disable", "tls_disable_client_certs"} {
+		if _, _, _, err := kmipListenerFactory(map[string]interface{}{
+			"address": "127.0.0.1:0",
+			key:       "true",
+		}, nil, cli.NewMockUi()); err == nil {
+			t.Fatalf("expected error with %s", key)
+		}
+	}
+}
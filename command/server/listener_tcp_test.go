@@ -113,3 +113,69 @@ func TestTCPListener_tls(t *testing.T) {
 
 	testListenerImpl(t, ln, connFn(false), "foo.example.com")
 }
+
+func TestTCPListener_tlsMaxVersion(t *testing.T) {
+	wd, _ := os.Getwd()
+	wd += "/test-fixtures/reload/"
+
+	inBytes, _ := ioutil.ReadFile(wd + "reload_ca.pem")
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(inBytes) {
+		t.Fatal("not ok when appending CA cert")
+	}
+
+	_, _, _, err := tcpListenerFactory(map[string]interface{}{
+		"address":         "127.0.0.1:0",
+		"tls_cert_file":   wd + "reload_foo.pem",
+		"tls_key_file":    wd + "reload_foo.key",
+		"tls_min_version": "tls12",
+		"tls_max_version": "tls11",
+	}, nil, cli.NewMockUi())
+	if err == nil {
+		t.Fatal("expected error due to max version lower than min version")
+	}
+
+	ln, _, _, err := tcpListenerFactory(map[string]interface{}{
+		"address":         "127.0.0.1:0",
+		"tls_cert_file":   wd + "reload_foo.pem",
+		"tls_key_file":    wd + "reload_foo.key",
+		"tls_min_version": "tls11",
+		"tls_max_version": "tls11",
+	}, nil, cli.NewMockUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+
+	dial := func(version uint16) error {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			RootCAs:    certPool,
+			ServerName: "foo.example.com",
+			MinVersion: version,
+			MaxVersion: version,
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		return conn.Handshake()
+	}
+	if err := dial(tls.VersionTLS11); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := dial(tls.VersionTLS12); err == nil {
+		t.Fatal("expected handshake to fail above tls_max_version")
+	}
+}
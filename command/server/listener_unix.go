@@ -0,0 +1,111 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/reload"
+	"github.com/mitchellh/cli"
+)
+
+func unixListenerFactory(config map[string]interface{}, _ io.Writer, ui cli.Ui) (net.Listener, map[string]string, reload.ReloadFunc, error) {
+	addrRaw, ok := config["address"]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("'address' must be set to the path of the socket")
+	}
+	addr, ok := addrRaw.(string)
+	if !ok || addr == "" {
+		return nil, nil, nil, fmt.Errorf("'address' must be set to the path of the socket")
+	}
+
+	if _, ok := config["x_forwarded_for_authorized_addrs"]; ok {
+		return nil, nil, nil, fmt.Errorf("'x_forwarded_for_authorized_addrs' is not supported by unix listeners")
+	}
+
+	// Remove a socket left behind by a previous run, but never anything
+	// else that happens to live at the configured path
+	if fi, err := os.Lstat(addr); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, nil, nil, fmt.Errorf("%q exists and is not a socket", addr)
+		}
+		if err := os.Remove(addr); err != nil {
+			return nil, nil, nil, errwrap.Wrapf("failed to remove existing socket: {{err}}", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	props := map[string]string{"addr": addr}
+	if err := setUnixSocketPermissions(addr, config, props); err != nil {
+		ln.Close()
+		return nil, nil, nil, err
+	}
+
+	return listenerWrapTLS(ln, props, config, ui)
+}
+
+// setUnixSocketPermissions applies the socket_mode, socket_user and
+// socket_group settings to the socket file
+func setUnixSocketPermissions(path string, config map[string]interface{}, props map[string]string) error {
+	uid, gid := -1, -1
+
+	if v, ok := config["socket_user"]; ok {
+		name := fmt.Sprintf("%v", v)
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return errwrap.Wrapf("invalid value for 'socket_user': {{err}}", err)
+			}
+			if id, err = strconv.Atoi(u.Uid); err != nil {
+				return errwrap.Wrapf("invalid value for 'socket_user': {{err}}", err)
+			}
+		}
+		uid = id
+		props["socket_user"] = name
+	}
+
+	if v, ok := config["socket_group"]; ok {
+		name := fmt.Sprintf("%v", v)
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return errwrap.Wrapf("invalid value for 'socket_group': {{err}}", err)
+			}
+			if id, err = strconv.Atoi(g.Gid); err != nil {
+				return errwrap.Wrapf("invalid value for 'socket_group': {{err}}", err)
+			}
+		}
+		gid = id
+		props["socket_group"] = name
+	}
+
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(path, uid, gid); err != nil {
+			return errwrap.Wrapf("failed to set socket ownership: {{err}}", err)
+		}
+	}
+
+	if v, ok := config["socket_mode"]; ok {
+		modeStr := fmt.Sprintf("%v", v)
+		mode, err := strconv.ParseUint(modeStr, 8, 32)
+		if err != nil {
+			return errwrap.Wrapf("invalid value for 'socket_mode': {{err}}", err)
+		}
+		if err := os.Chmod(path, os.FileMode(mode)); err != nil {
+			return errwrap.Wrapf("failed to set socket mode: {{err}}", err)
+		}
+		props["socket_mode"] = modeStr
+	}
+
+	return nil
+}
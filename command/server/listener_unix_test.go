@@ -0,0 +1,74 @@
+package server
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestUnixListener(t *testing.T) {
+	td, err := ioutil.TempDir("", "vault-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	path := filepath.Join(td, "vault.sock")
+
+	ln, props, _, err := unixListenerFactory(map[string]interface{}{
+		"address":     path,
+		"socket_mode": "0600",
+		"tls_disable": "1",
+	}, nil, cli.NewMockUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if props["addr"] != path || props["socket_mode"] != "0600" {
+		t.Fatalf("bad: %#v", props)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&os.ModeSocket == 0 || fi.Mode().Perm() != 0600 {
+		t.Fatalf("bad mode: %s", fi.Mode())
+	}
+
+	connFn := func(lnReal net.Listener) (net.Conn, error) {
+		return net.Dial("unix", path)
+	}
+
+	testListenerImpl(t, ln, connFn, "")
+	ln.Close()
+
+	// A socket left behind is replaced, anything else is not
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	ln, _, _, err = unixListenerFactory(map[string]interface{}{
+		"address":     path,
+		"tls_disable": "1",
+	}, nil, cli.NewMockUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ln.Close()
+
+	filePath := filepath.Join(td, "file")
+	if err := ioutil.WriteFile(filePath, []byte("foo"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := unixListenerFactory(map[string]interface{}{
+		"address":     filePath,
+		"tls_disable": "1",
+	}, nil, cli.NewMockUi()); err == nil {
+		t.Fatal("expected error replacing a file that is not a socket")
+	}
+}
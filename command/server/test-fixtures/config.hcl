@@ -23,6 +23,7 @@ telemetry {
     statsite_address = "foo"
     dogstatsd_addr = "127.0.0.1:7254"
     dogstatsd_tags = ["tag_1:val_1", "tag_2:val_2"]
+    enable_hostname_label = true
 }
 
 max_lease_ttl = "10h"
@@ -31,3 +32,4 @@ cluster_name = "testcluster"
 pid_file = "./pidfile"
 raw_storage_endpoint = true
 disable_printable_check = true
+disable_pprof = true
@@ -33,3 +33,6 @@ default_lease_ttl = "10h"
 cluster_name = "testcluster"
 pid_file = "./pidfile"
 raw_storage_endpoint = true
+root_token_max_ttl = "1h"
+log_root_tokens = true
+mount_deletion_grace_period = "24h"
@@ -6,6 +6,7 @@ package command
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -15,11 +16,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/transit"
+	"github.com/hashicorp/vault/command/token"
+	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/physical"
 	"github.com/mitchellh/cli"
 
 	physConsul "github.com/hashicorp/vault/physical/consul"
 	physFile "github.com/hashicorp/vault/physical/file"
+	physInmem "github.com/hashicorp/vault/physical/inmem"
 )
 
 func testRandomPort(tb testing.TB) int {
@@ -207,6 +213,210 @@ func TestServer_ReloadListener(t *testing.T) {
 	wg.Wait()
 }
 
+func TestServer_ReloadLogLevel(t *testing.T) {
+	t.Parallel()
+
+	td, err := ioutil.TempDir("", "vault-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	writeConfig := func(logLevel string) {
+		hcl := testBaseHCL(t) + fmt.Sprintf("\nlog_level = %q\n", logLevel) + `
+backend "file" {
+  path = "/dev/null"
+}
+`
+		if err := ioutil.WriteFile(td+"/config.hcl", []byte(hcl), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("warn")
+
+	ui, cmd := testServerCommand(t)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if code := cmd.Run([]string{"-config", td + "/config.hcl"}); code != 0 {
+			output := ui.ErrorWriter.String() + ui.OutputWriter.String()
+			t.Errorf("got a non-zero exit status: %s", output)
+		}
+	}()
+
+	select {
+	case <-cmd.startedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+	if cmd.logger.IsInfo() || !cmd.logger.IsWarn() {
+		t.Fatal("expected log level to be warn")
+	}
+
+	writeConfig("debug")
+	cmd.SighupCh <- struct{}{}
+	select {
+	case <-cmd.reloadedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+	if !cmd.logger.IsDebug() || cmd.logger.IsTrace() {
+		t.Fatal("expected log level to be debug")
+	}
+
+	cmd.ShutdownCh <- struct{}{}
+	wg.Wait()
+}
+
+func TestServer_ReloadLogLevelFlag(t *testing.T) {
+	t.Parallel()
+
+	td, err := ioutil.TempDir("", "vault-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	writeConfig := func(logLevel string) {
+		hcl := testBaseHCL(t) + fmt.Sprintf("\nlog_level = %q\n", logLevel) + `
+backend "file" {
+  path = "/dev/null"
+}
+`
+		if err := ioutil.WriteFile(td+"/config.hcl", []byte(hcl), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("warn")
+
+	ui, cmd := testServerCommand(t)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if code := cmd.Run([]string{"-config", td + "/config.hcl", "-log-level", "info"}); code != 0 {
+			output := ui.ErrorWriter.String() + ui.OutputWriter.String()
+			t.Errorf("got a non-zero exit status: %s", output)
+		}
+	}()
+
+	select {
+	case <-cmd.startedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+	if !cmd.logger.IsInfo() || cmd.logger.IsDebug() {
+		t.Fatal("expected log level to be info")
+	}
+
+	// The log level of the command line is kept on reload
+	writeConfig("debug")
+	cmd.SighupCh <- struct{}{}
+	select {
+	case <-cmd.reloadedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+	if !cmd.logger.IsInfo() || cmd.logger.IsDebug() {
+		t.Fatal("expected log level to still be info")
+	}
+
+	cmd.ShutdownCh <- struct{}{}
+	wg.Wait()
+}
+
+func TestServer_Logging(t *testing.T) {
+	t.Parallel()
+
+	td, err := ioutil.TempDir("", "vault-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	writeConfig := func(coreLevel string) {
+		hcl := testBaseHCL(t) + fmt.Sprintf(`
+log_level  = "warn"
+log_format = "json"
+log_file   = %q
+
+log_levels {
+  core = %q
+}
+
+backend "file" {
+  path = "/dev/null"
+}
+`, td+"/vault.log", coreLevel)
+		if err := ioutil.WriteFile(td+"/config.hcl", []byte(hcl), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("debug")
+
+	ui, cmd := testServerCommand(t)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if code := cmd.Run([]string{"-config", td + "/config.hcl"}); code != 0 {
+			output := ui.ErrorWriter.String() + ui.OutputWriter.String()
+			t.Errorf("got a non-zero exit status: %s", output)
+		}
+	}()
+
+	select {
+	case <-cmd.startedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+	if cmd.logger.IsInfo() || !cmd.logger.IsWarn() {
+		t.Fatal("expected log level to be warn")
+	}
+	if core := cmd.logger.Named("core"); !core.IsDebug() || core.IsTrace() {
+		t.Fatal("expected the core log level to be debug")
+	}
+
+	// The lines are written in JSON to the log file
+	cmd.logger.Named("core").Debug("core debug")
+	cmd.logger.Named("expiration").Info("expiration info")
+	data, err := ioutil.ReadFile(td + "/vault.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("expected a JSON line: %v: %q", err, line)
+		}
+		if entry["@message"] == "core debug" && entry["@module"] == "core" {
+			found = true
+		}
+		if entry["@message"] == "expiration info" {
+			t.Fatalf("expected the expiration line to be filtered: %s", data)
+		}
+	}
+	if !found {
+		t.Fatalf("expected the core line: %s", data)
+	}
+
+	writeConfig("error")
+	cmd.SighupCh <- struct{}{}
+	select {
+	case <-cmd.reloadedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+	if core := cmd.logger.Named("core"); core.IsWarn() || !core.IsError() {
+		t.Fatal("expected the core log level to be error")
+	}
+
+	cmd.ShutdownCh <- struct{}{}
+	wg.Wait()
+}
+
 func TestServer(t *testing.T) {
 	t.Parallel()
 
@@ -266,3 +476,60 @@ func TestServer(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_DevTransit(t *testing.T) {
+	t.Parallel()
+
+	ui, cmd := testServerCommand(t)
+	cmd.tokenHelper = token.NewTestingTokenHelper()
+	cmd.PhysicalBackends["inmem"] = physInmem.NewInmem
+	cmd.LogicalBackends = map[string]logical.Factory{
+		"transit": transit.Factory,
+	}
+
+	addr := fmt.Sprintf("127.0.0.1:%d", testRandomPort(t))
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		code := cmd.Run([]string{
+			"-dev",
+			"-dev-listen-address", addr,
+			"-dev-root-token-id", "root",
+			"-dev-transit",
+		})
+		if code != 0 {
+			output := ui.ErrorWriter.String() + ui.OutputWriter.String()
+			t.Errorf("got a non-zero exit status: %s", output)
+		}
+	}()
+
+	select {
+	case <-cmd.startedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	client, err := api.NewClient(&api.Config{
+		Address: "http://" + addr,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("root")
+
+	mounts, err := client.Sys().ListMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mount, ok := mounts["transit/"]; !ok || mount.Type != "transit" {
+		t.Errorf("expected transit to be mounted: %#v", mounts)
+	}
+	if mount, ok := mounts["secret/"]; !ok || mount.Options["version"] != "2" {
+		t.Errorf("expected secret/ to be K/V version 2: %#v", mounts)
+	}
+
+	cmd.ShutdownCh <- struct{}{}
+	wg.Wait()
+}
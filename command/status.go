@@ -39,7 +39,7 @@ Usage: vault status [options]
 }
 
 func (c *StatusCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	return c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
 }
 
 func (c *StatusCommand) AutocompleteArgs() complete.Predictor {
@@ -79,7 +79,12 @@ func (c *StatusCommand) Run(args []string) int {
 
 	// Do not return the int here yet, since we may want to return a custom error
 	// code depending on the seal status.
-	code := OutputSealStatus(c.UI, client, status)
+	var code int
+	if c.flagField != "" {
+		code = PrintRawField(c.UI, status, c.flagField)
+	} else {
+		code = OutputSealStatus(c.UI, client, status)
+	}
 
 	if status.Sealed {
 		return 2
@@ -49,6 +49,27 @@ func TestStatusCommand_Run(t *testing.T) {
 			"Too many arguments",
 			1,
 		},
+		{
+			"field",
+			[]string{"-field", "sealed"},
+			false,
+			"false",
+			0,
+		},
+		{
+			"sealed_field",
+			[]string{"-field", "sealed"},
+			true,
+			"true",
+			2,
+		},
+		{
+			"missing_field",
+			[]string{"-field", "nope"},
+			false,
+			"Field \"nope\" not present",
+			1,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -2,6 +2,9 @@ package command
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"strings"
 	"time"
 
@@ -16,18 +19,22 @@ var _ cli.CommandAutocomplete = (*TokenCreateCommand)(nil)
 type TokenCreateCommand struct {
 	*BaseCommand
 
-	flagID              string
-	flagDisplayName     string
-	flagTTL             time.Duration
-	flagExplicitMaxTTL  time.Duration
-	flagPeriod          time.Duration
-	flagRenewable       bool
-	flagOrphan          bool
-	flagNoDefaultPolicy bool
-	flagUseLimit        int
-	flagRole            string
-	flagMetadata        map[string]string
-	flagPolicies        []string
+	flagID                     string
+	flagDisplayName            string
+	flagTTL                    time.Duration
+	flagExplicitMaxTTL         time.Duration
+	flagPeriod                 time.Duration
+	flagRenewable              bool
+	flagOrphan                 bool
+	flagOrphanChildrenOnRevoke bool
+	flagNoDefaultPolicy        bool
+	flagUseLimit               int
+	flagRole                   string
+	flagMetadata               map[string]string
+	flagPolicies               []string
+	flagInlinePolicy           string
+
+	testStdin io.Reader // for tests
 
 	// Deprecated flags
 	flagLease time.Duration
@@ -56,6 +63,11 @@ Usage: vault token create [options]
 
   If a role is specified, the role may override parameters specified here.
 
+  If rules are given with "-inline-policy", the token is further restricted to
+  them, and its policies must be a subset of the policies of the currently
+  authenticated token. The token and its children are only allowed what both
+  their policies and the rules allow.
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -127,6 +139,16 @@ func (c *TokenCreateCommand) Flags() *FlagSets {
 			"value requires sudo permissions.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "orphan-children-on-revoke",
+		Target:  &c.flagOrphanChildrenOnRevoke,
+		Default: false,
+		Usage: "Orphan the children of the token, rather than revoking them, " +
+			"when the token is revoked or expires. This lets long-running " +
+			"workloads outlive the token which created their tokens. Setting " +
+			"this value requires sudo permissions.",
+	})
+
 	f.BoolVar(&BoolVar{
 		Name:    "no-default-policy",
 		Target:  &c.flagNoDefaultPolicy,
@@ -171,6 +193,19 @@ func (c *TokenCreateCommand) Flags() *FlagSets {
 			"specified multiple times to attach multiple policies.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:   "inline-policy",
+		Target: &c.flagInlinePolicy,
+		Completion: complete.PredictOr(
+			complete.PredictFiles("*.hcl"),
+			complete.PredictFiles("*.json"),
+		),
+		Usage: "Path to a file of policy rules restricting the token, or \"-\" " +
+			"to read them from stdin. The token is created against " +
+			"\"auth/token/create-restricted\", which requires its policies to " +
+			"be a subset of the policies of the locally authenticated token.",
+	})
+
 	// Deprecated flags
 	// TODO: remove in 0.9.0
 	f.DurationVar(&DurationVar{
@@ -220,23 +255,38 @@ func (c *TokenCreateCommand) Run(args []string) int {
 	}
 
 	tcr := &api.TokenCreateRequest{
-		ID:              c.flagID,
-		Policies:        c.flagPolicies,
-		Metadata:        c.flagMetadata,
-		TTL:             c.flagTTL.String(),
-		NoParent:        c.flagOrphan,
-		NoDefaultPolicy: c.flagNoDefaultPolicy,
-		DisplayName:     c.flagDisplayName,
-		NumUses:         c.flagUseLimit,
-		Renewable:       &c.flagRenewable,
-		ExplicitMaxTTL:  c.flagExplicitMaxTTL.String(),
-		Period:          c.flagPeriod.String(),
+		ID:                     c.flagID,
+		Policies:               c.flagPolicies,
+		Metadata:               c.flagMetadata,
+		TTL:                    c.flagTTL.String(),
+		NoParent:               c.flagOrphan,
+		OrphanChildrenOnRevoke: c.flagOrphanChildrenOnRevoke,
+		NoDefaultPolicy:        c.flagNoDefaultPolicy,
+		DisplayName:            c.flagDisplayName,
+		NumUses:                c.flagUseLimit,
+		Renewable:              &c.flagRenewable,
+		ExplicitMaxTTL:         c.flagExplicitMaxTTL.String(),
+		Period:                 c.flagPeriod.String(),
 	}
 
 	var secret *api.Secret
-	if c.flagRole != "" {
+	switch {
+	case c.flagInlinePolicy != "":
+		if c.flagRole != "" || c.flagOrphan {
+			c.UI.Error("Cannot use -inline-policy with -role or -orphan")
+			return 1
+		}
+
+		rules, readErr := c.readInlinePolicy(c.flagInlinePolicy)
+		if readErr != nil {
+			c.UI.Error(readErr.Error())
+			return 2
+		}
+		tcr.InlinePolicy = rules
+		secret, err = client.Auth().Token().CreateRestricted(tcr)
+	case c.flagRole != "":
 		secret, err = client.Auth().Token().CreateWithRole(tcr, c.flagRole)
-	} else {
+	default:
 		secret, err = client.Auth().Token().Create(tcr)
 	}
 	if err != nil {
@@ -250,3 +300,28 @@ func (c *TokenCreateCommand) Run(args []string) int {
 
 	return OutputSecret(c.UI, secret)
 }
+
+// readInlinePolicy reads the rules of the inline policy from the given file,
+// or from stdin if the path is "-"
+func (c *TokenCreateCommand) readInlinePolicy(path string) (string, error) {
+	var reader io.Reader
+	if path == "-" {
+		reader = os.Stdin
+		if c.testStdin != nil {
+			reader = c.testStdin
+		}
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("Error opening inline policy file: %s", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	rules, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("Error reading inline policy: %s", err)
+	}
+	return string(rules), nil
+}
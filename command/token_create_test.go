@@ -68,6 +68,12 @@ func TestTokenCreateCommand_Run(t *testing.T) {
 			"not present in secret",
 			1,
 		},
+		{
+			"inline_policy_with_role",
+			[]string{"-inline-policy", "-", "-role", "foo"},
+			"Cannot use -inline-policy",
+			1,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -197,6 +203,45 @@ func TestTokenCreateCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("inline_policy", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testTokenCreateCommand(t)
+		cmd.client = client
+		// The default policy allows the cubbyhole, which the inline policy does
+		// not
+		cmd.testStdin = strings.NewReader(`path "secret/*" { capabilities = ["read"] }`)
+
+		code := cmd.Run([]string{
+			"-policy", "default",
+			"-inline-policy", "-",
+			"-field", "token",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		token := strings.TrimSpace(ui.OutputWriter.String())
+		secret, err := client.Auth().Token().Lookup(token)
+		if secret == nil || err != nil {
+			t.Fatal(err)
+		}
+		if raw, ok := secret.Data["inline_policies"].([]interface{}); !ok || len(raw) != 1 {
+			t.Errorf("bad inline policies: %#v", secret.Data)
+		}
+
+		capabilities, err := client.Sys().Capabilities(token, "cubbyhole/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []string{"deny"}; !reflect.DeepEqual(capabilities, expected) {
+			t.Errorf("expected %q to be %q", capabilities, expected)
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
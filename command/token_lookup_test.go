@@ -100,6 +100,31 @@ func TestTokenLookupCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("field", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		token, _ := testTokenAndAccessor(t, client)
+
+		ui, cmd := testTokenLookupCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-field", "id",
+			token,
+		})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := token
+		if combined := ui.OutputWriter.String(); strings.TrimSpace(combined) != expected {
+			t.Errorf("expected %q to be %q", combined, expected)
+		}
+	})
+
 	t.Run("self", func(t *testing.T) {
 		t.Parallel()
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -15,6 +16,8 @@ var _ cli.CommandAutocomplete = (*UnwrapCommand)(nil)
 // unwrapping cubbyhole-wrapped secrets
 type UnwrapCommand struct {
 	*BaseCommand
+
+	flagCreationPaths []string
 }
 
 func (c *UnwrapCommand) Synopsis() string {
@@ -38,6 +41,11 @@ Usage: vault unwrap [options] [TOKEN]
       $ vault login 848f9ccf-7176-098c-5e2b-75a0689d41cd
       $ vault unwrap # unwraps 848f9ccf...
 
+  Unwrap a secret ID only if the token was created by the expected AppRole
+  endpoint, so that a substituted token is detected and left wrapped:
+
+      $ vault unwrap -creation-path=auth/approle/role/web/secret-id 3de9ece1...
+
   For a full list of examples and paths, please see the online documentation.
 
 ` + c.Flags().Help()
@@ -46,7 +54,21 @@ Usage: vault unwrap [options] [TOKEN]
 }
 
 func (c *UnwrapCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "creation-path",
+		Target:     &c.flagCreationPaths,
+		Completion: complete.PredictAnything,
+		Usage: "Path the wrapping token is expected to have been created by. " +
+			"A path ending in \"*\" matches paths starting with the rest of it. " +
+			"If the token was created elsewhere, it is left wrapped and the " +
+			"command fails. This can be specified multiple times.",
+	})
+
+	return set
 }
 
 func (c *UnwrapCommand) AutocompleteArgs() complete.Predictor {
@@ -83,7 +105,12 @@ func (c *UnwrapCommand) Run(args []string) int {
 		return 2
 	}
 
-	secret, err := client.Logical().Unwrap(token)
+	var secret *api.Secret
+	if len(c.flagCreationPaths) > 0 {
+		secret, err = client.Logical().UnwrapFromPath(token, c.flagCreationPaths...)
+	} else {
+		secret, err = client.Logical().Unwrap(token)
+	}
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error unwrapping: %s", err))
 		return 2
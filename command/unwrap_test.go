@@ -65,6 +65,24 @@ func TestUnwrapCommand_Run(t *testing.T) {
 			"not present in secret",
 			1,
 		},
+		{
+			"creation_path",
+			[]string{"-creation-path", "sys/wrapping/wrap"},
+			"bar",
+			0,
+		},
+		{
+			"creation_path_glob",
+			[]string{"-creation-path", "auth/approle/*", "-creation-path", "sys/wrapping/*"},
+			"bar",
+			0,
+		},
+		{
+			"creation_path_mismatch",
+			[]string{"-creation-path", "auth/approle/role/web/secret-id"},
+			"rather than one of the expected paths",
+			2,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/command/config"
 	"github.com/hashicorp/vault/command/token"
+	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/mitchellh/cli"
 )
 
@@ -95,6 +96,19 @@ func PrintRawField(ui cli.Ui, data interface{}, field string) int {
 		val = RawField(data.(*api.Secret), field)
 	case map[string]interface{}:
 		val = data.(map[string]interface{})[field]
+	default:
+		// Other responses are looked up by the keys of their JSON output, so
+		// that the field names match the output of -format=json
+		var m map[string]interface{}
+		b, err := jsonutil.EncodeJSON(data)
+		if err == nil {
+			err = jsonutil.DecodeJSON(b, &m)
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error extracting field %q: %s", field, err))
+			return 1
+		}
+		val = m[field]
 	}
 
 	if val == nil {
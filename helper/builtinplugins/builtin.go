@@ -2,11 +2,14 @@ package builtinplugins
 
 import (
 	"github.com/hashicorp/vault/plugins/database/cassandra"
+	"github.com/hashicorp/vault/plugins/database/clickhouse"
 	"github.com/hashicorp/vault/plugins/database/hana"
+	"github.com/hashicorp/vault/plugins/database/influxdb"
 	"github.com/hashicorp/vault/plugins/database/mongodb"
 	"github.com/hashicorp/vault/plugins/database/mssql"
 	"github.com/hashicorp/vault/plugins/database/mysql"
 	"github.com/hashicorp/vault/plugins/database/postgresql"
+	"github.com/hashicorp/vault/plugins/database/redis"
 	"github.com/hashicorp/vault/plugins/helper/database/credsutil"
 )
 
@@ -27,6 +30,9 @@ var plugins = map[string]BuiltinFactory{
 	"cassandra-database-plugin":  cassandra.New,
 	"mongodb-database-plugin":    mongodb.New,
 	"hana-database-plugin":       hana.New,
+	"redis-database-plugin":      redis.New,
+	"influxdb-database-plugin":   influxdb.New,
+	"clickhouse-database-plugin": clickhouse.New,
 }
 
 // Get returns the BuiltinFactory func for a particular backend plugin
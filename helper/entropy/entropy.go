@@ -0,0 +1,104 @@
+// Package entropy mixes entropy from external sources, such as an HSM or a
+// hardware random number generator, into the random data used to generate
+// keys.
+package entropy
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// Sourcer is an external source of entropy
+type Sourcer interface {
+	// GetRandom returns the given number of random bytes
+	GetRandom(bytes int) ([]byte, error)
+}
+
+// Reader is an io.Reader that XORs the random data of crypto/rand with the
+// one of an external source, so that the result is at least as
+// unpredictable as the best of the two.
+type Reader struct {
+	source Sourcer
+}
+
+var _ io.Reader = (*Reader)(nil)
+
+// NewReader returns a Reader mixing the entropy of source into crypto/rand
+func NewReader(source Sourcer) *Reader {
+	return &Reader{
+		source: source,
+	}
+}
+
+// Read fills p with random data. It fails rather than falling back to
+// crypto/rand alone if the external source cannot be read.
+func (r *Reader) Read(p []byte) (int, error) {
+	if _, err := io.ReadFull(rand.Reader, p); err != nil {
+		return 0, err
+	}
+
+	external, err := r.source.GetRandom(len(p))
+	if err != nil {
+		return 0, errwrap.Wrapf("error reading from the entropy source: {{err}}", err)
+	}
+	if len(external) != len(p) {
+		return 0, fmt.Errorf("entropy source returned %d bytes instead of %d", len(external), len(p))
+	}
+
+	for i := range p {
+		p[i] ^= external[i]
+	}
+	return len(p), nil
+}
+
+// FileSource reads entropy from a file, usually the character device of a
+// hardware random number generator such as /dev/hwrng
+type FileSource struct {
+	l    sync.Mutex
+	file *os.File
+}
+
+var _ Sourcer = (*FileSource)(nil)
+
+// NewFileSource opens the file at path as an entropy source, and checks that
+// it can be read from
+func NewFileSource(path string) (*FileSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errwrap.Wrapf("error opening the entropy source: {{err}}", err)
+	}
+
+	s := &FileSource{
+		file: file,
+	}
+	if _, err := s.GetRandom(1); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetRandom implements Sourcer
+func (s *FileSource) GetRandom(bytes int) ([]byte, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	buf := make([]byte, bytes)
+	if _, err := io.ReadFull(s.file, buf); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error reading from %s: {{err}}", s.file.Name()), err)
+	}
+	return buf, nil
+}
+
+// Close closes the file of the source
+func (s *FileSource) Close() error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.file.Close()
+}
@@ -0,0 +1,80 @@
+package entropy
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type testSourcer struct {
+	data []byte
+	err  error
+}
+
+func (s *testSourcer) GetRandom(n int) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.data[:n], nil
+}
+
+func TestReader(t *testing.T) {
+	// XORing with zeroes leaves the data of crypto/rand, so the output must
+	// still look random
+	r := NewReader(&testSourcer{data: make([]byte, 64)})
+	a := make([]byte, 32)
+	b := make([]byte, 32)
+	if _, err := r.Read(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Fatal("expected different random data")
+	}
+
+	// A failing source fails the read
+	r = NewReader(&testSourcer{err: errors.New("unavailable")})
+	if _, err := r.Read(a); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	f, err := ioutil.TempFile("", "vault-entropy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write([]byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	s, err := NewFileSource(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// The first byte was read when opening the source
+	buf, err := s.GetRandom(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "1234" {
+		t.Fatalf("bad: %q", buf)
+	}
+
+	// Running out of data is an error
+	if _, err := s.GetRandom(10); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if _, err := NewFileSource(f.Name() + "-missing"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
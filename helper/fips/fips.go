@@ -0,0 +1,89 @@
+// Package fips tracks whether Vault is restricted to FIPS-approved
+// cryptography, and holds the algorithms that this mode allows.
+package fips
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync/atomic"
+)
+
+// enabled is set by builds with the fips tag, or at startup by the server
+// configuration
+var enabled uint32
+
+// CipherSuites are the TLS cipher suites allowed in FIPS mode, in order of
+// preference
+var CipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+}
+
+// Curves are the elliptic curves allowed for TLS key exchanges in FIPS mode
+var Curves = []tls.CurveID{
+	tls.CurveP256,
+	tls.CurveP384,
+	tls.CurveP521,
+}
+
+// Enabled returns whether Vault is restricted to FIPS-approved cryptography.
+func Enabled() bool {
+	return atomic.LoadUint32(&enabled) == 1
+}
+
+// SetEnabled turns FIPS mode on or off. It is meant to be called once at
+// startup, before any key or listener is created.
+func SetEnabled(on bool) {
+	var v uint32
+	if on {
+		v = 1
+	}
+	atomic.StoreUint32(&enabled, v)
+}
+
+// CheckCipherSuites returns an error if any of the cipher suites is not
+// allowed in FIPS mode.
+func CheckCipherSuites(suites []uint16) error {
+	for _, suite := range suites {
+		approved := false
+		for _, allowed := range CipherSuites {
+			if suite == allowed {
+				approved = true
+				break
+			}
+		}
+		if !approved {
+			return fmt.Errorf("cipher suite 0x%04x is not FIPS-approved", suite)
+		}
+	}
+	return nil
+}
+
+// ConfigureTLS restricts a TLS configuration to the versions, cipher suites
+// and curves allowed in FIPS mode. Cipher suites that were set explicitly
+// must all be approved; otherwise the approved ones are used. It does nothing
+// unless FIPS mode is enabled.
+func ConfigureTLS(conf *tls.Config) error {
+	if !Enabled() {
+		return nil
+	}
+
+	if conf.MinVersion < tls.VersionTLS12 {
+		return fmt.Errorf("TLS versions below 1.2 are not allowed in FIPS mode")
+	}
+
+	// The cipher suites of TLS 1.3 cannot be restricted, and include
+	// ChaCha20-Poly1305
+	conf.MaxVersion = tls.VersionTLS12
+
+	if len(conf.CipherSuites) == 0 {
+		conf.CipherSuites = CipherSuites
+	} else if err := CheckCipherSuites(conf.CipherSuites); err != nil {
+		return err
+	}
+
+	conf.CurvePreferences = Curves
+	return nil
+}
@@ -0,0 +1,55 @@
+package fips
+
+import (
+	"crypto/tls"
+	"reflect"
+	"testing"
+)
+
+func TestConfigureTLS(t *testing.T) {
+	defer SetEnabled(Enabled())
+
+	SetEnabled(false)
+	conf := &tls.Config{MinVersion: tls.VersionTLS10}
+	if err := ConfigureTLS(conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.CipherSuites != nil || conf.CurvePreferences != nil {
+		t.Fatal("the configuration should be left alone outside of FIPS mode")
+	}
+
+	SetEnabled(true)
+	if err := ConfigureTLS(conf); err == nil {
+		t.Fatal("expected TLS 1.0 to be refused")
+	}
+
+	conf = &tls.Config{MinVersion: tls.VersionTLS12}
+	if err := ConfigureTLS(conf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(conf.CipherSuites, CipherSuites) {
+		t.Fatalf("expected the approved cipher suites, got %v", conf.CipherSuites)
+	}
+	if !reflect.DeepEqual(conf.CurvePreferences, Curves) {
+		t.Fatalf("expected the approved curves, got %v", conf.CurvePreferences)
+	}
+	if conf.MaxVersion != tls.VersionTLS12 {
+		t.Fatalf("expected TLS 1.3 to be left out, got a maximum version of %x", conf.MaxVersion)
+	}
+
+	conf = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+	}
+	if err := ConfigureTLS(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	conf = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305},
+	}
+	if err := ConfigureTLS(conf); err == nil {
+		t.Fatal("expected ChaCha20-Poly1305 to be refused")
+	}
+}
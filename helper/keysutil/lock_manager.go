@@ -5,11 +5,14 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/helper/fips"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/locksutil"
 	"github.com/hashicorp/vault/logical"
@@ -51,6 +54,21 @@ type PolicyRequest struct {
 
 	// Whether to allow plaintext backup
 	AllowPlaintextBackup bool
+
+	// If set, the key material imported as the first version of the key
+	// during an upsert, instead of generating it
+	KeyMaterial []byte
+
+	// If set, the source of the random data used to generate the key during
+	// an upsert, instead of crypto/rand
+	RandReader io.Reader
+
+	// Whether an imported key can be rotated, generating its new versions
+	AllowImportedKeyRotation bool
+
+	// If set, the age of the latest version of the key past which it is
+	// rotated automatically
+	AutoRotatePeriod time.Duration
 }
 
 type LockManager struct {
@@ -291,6 +309,11 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest) (retP *
 			return nil, false, fmt.Errorf("unsupported key type %v", req.KeyType)
 		}
 
+		if fips.Enabled() && !req.KeyType.FIPSApproved() {
+			cleanup()
+			return nil, false, errutil.UserError{Err: fmt.Sprintf("keys of type %v are not FIPS-approved and cannot be created in FIPS mode", req.KeyType)}
+		}
+
 		p = &Policy{
 			l:                    new(sync.RWMutex),
 			Name:                 req.Name,
@@ -298,6 +321,11 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest) (retP *
 			Derived:              req.Derived,
 			Exportable:           req.Exportable,
 			AllowPlaintextBackup: req.AllowPlaintextBackup,
+			AutoRotatePeriod:     req.AutoRotatePeriod,
+		}
+		if req.KeyMaterial != nil {
+			p.Imported = true
+			p.AllowImportedKeyRotation = req.AllowImportedKeyRotation
 		}
 
 		if req.Derived {
@@ -314,7 +342,13 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest) (retP *
 		}
 
 		// Performs the actual persist and does setup
-		err = p.Rotate(ctx, req.Storage)
+		if req.KeyMaterial != nil {
+			err = p.Import(ctx, req.Storage, req.KeyMaterial)
+		} else if req.RandReader != nil {
+			err = p.RotateWithReader(ctx, req.Storage, req.RandReader)
+		} else {
+			err = p.Rotate(ctx, req.Storage)
+		}
 		if err != nil {
 			cleanup()
 			return nil, false, err
@@ -13,8 +13,10 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
@@ -127,6 +129,15 @@ func (kt KeyType) DerivationSupported() bool {
 	return false
 }
 
+// FIPSApproved returns whether keys of this type may be created in FIPS mode
+func (kt KeyType) FIPSApproved() bool {
+	switch kt {
+	case KeyType_AES256_GCM96, KeyType_ECDSA_P256, KeyType_RSA2048, KeyType_RSA4096:
+		return true
+	}
+	return false
+}
+
 func (kt KeyType) String() string {
 	switch kt {
 	case KeyType_AES256_GCM96:
@@ -346,6 +357,18 @@ type Policy struct {
 	// AllowPlaintextBackup allows taking backup of the policy in plaintext
 	AllowPlaintextBackup bool `json:"allow_plaintext_backup"`
 
+	// Imported indicates that the first version of the key was imported
+	// rather than generated
+	Imported bool `json:"imported"`
+
+	// AllowImportedKeyRotation allows rotating an imported key, which
+	// generates its new versions
+	AllowImportedKeyRotation bool `json:"allow_imported_key_rotation"`
+
+	// AutoRotatePeriod is the age of the latest version of the key past which
+	// the key is rotated automatically. Zero disables automatic rotation.
+	AutoRotatePeriod time.Duration `json:"auto_rotate_period"`
+
 	// VersionTemplate is used to prefix the ciphertext with information about
 	// the key version. It must inclide {{version}} and a delimiter between the
 	// version prefix and the ciphertext.
@@ -757,6 +780,14 @@ func (p *Policy) convergentVersion(ver int) int {
 }
 
 func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string, error) {
+	return p.EncryptWithAssociatedData(ver, context, nonce, value, nil)
+}
+
+// EncryptWithAssociatedData encrypts the value like Encrypt, authenticating
+// the given associated data along with it. The associated data is not part of
+// the ciphertext, and must be given again to decrypt it. It is only supported
+// by the AEAD key types.
+func (p *Policy) EncryptWithAssociatedData(ver int, context, nonce []byte, value string, associatedData []byte) (string, error) {
 	if !p.Type.EncryptionSupported() {
 		return "", errutil.UserError{Err: fmt.Sprintf("message encryption not supported for key type %v", p.Type)}
 	}
@@ -850,6 +881,16 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 					return "", errutil.InternalError{Err: fmt.Sprintf("invalid hmac key length of zero")}
 				}
 				nonceHmac := hmac.New(sha256.New, hmacKey)
+				if len(associatedData) > 0 {
+					// The nonce must differ for the same plaintext with
+					// different associated data, so it is computed with a
+					// key of its own over both
+					nonceHmac = hmac.New(sha256.New, associatedDataNonceKey(hmacKey))
+					var adLen [8]byte
+					binary.BigEndian.PutUint64(adLen[:], uint64(len(associatedData)))
+					nonceHmac.Write(adLen[:])
+					nonceHmac.Write(associatedData)
+				}
 				nonceHmac.Write(plaintext)
 				nonceSum := nonceHmac.Sum(nil)
 				nonce = nonceSum[:aead.NonceSize()]
@@ -865,7 +906,7 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 		}
 
 		// Encrypt and tag with AEAD
-		ciphertext = aead.Seal(nil, nonce, plaintext, nil)
+		ciphertext = aead.Seal(nil, nonce, plaintext, associatedData)
 
 		// Place the encrypted data after the nonce
 		if !p.ConvergentEncryption || p.convergentVersion(ver) > 1 {
@@ -873,6 +914,9 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 		}
 
 	case KeyType_RSA2048, KeyType_RSA4096:
+		if len(associatedData) > 0 {
+			return "", errutil.UserError{Err: fmt.Sprintf("associated data not supported for key type %v", p.Type)}
+		}
 		key := p.Keys[strconv.Itoa(ver)].RSAKey
 		ciphertext, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, plaintext, nil)
 		if err != nil {
@@ -893,6 +937,12 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 }
 
 func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
+	return p.DecryptWithAssociatedData(context, nonce, value, nil)
+}
+
+// DecryptWithAssociatedData decrypts the value like Decrypt, failing unless
+// the given associated data is the one it was encrypted with.
+func (p *Policy) DecryptWithAssociatedData(context, nonce []byte, value string, associatedData []byte) (string, error) {
 	if !p.Type.DecryptionSupported() {
 		return "", errutil.UserError{Err: fmt.Sprintf("message decryption not supported for key type %v", p.Type)}
 	}
@@ -996,12 +1046,15 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 		}
 
 		// Verify and Decrypt
-		plain, err = aead.Open(nil, nonce, ciphertext, nil)
+		plain, err = aead.Open(nil, nonce, ciphertext, associatedData)
 		if err != nil {
 			return "", errutil.UserError{Err: "invalid ciphertext: unable to decrypt"}
 		}
 
 	case KeyType_RSA2048, KeyType_RSA4096:
+		if len(associatedData) > 0 {
+			return "", errutil.UserError{Err: fmt.Sprintf("associated data not supported for key type %v", p.Type)}
+		}
 		key := p.Keys[strconv.Itoa(ver)].RSAKey
 		plain, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, key, decoded, nil)
 		if err != nil {
@@ -1015,6 +1068,15 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 	return base64.StdEncoding.EncodeToString(plain), nil
 }
 
+// associatedDataNonceKey returns the key the convergent nonces of the
+// plaintexts given with associated data are computed with, so that they
+// cannot collide with those of the plaintexts given without
+func associatedDataNonceKey(hmacKey []byte) []byte {
+	keyHmac := hmac.New(sha256.New, hmacKey)
+	keyHmac.Write([]byte("associated-data"))
+	return keyHmac.Sum(nil)
+}
+
 func (p *Policy) HMACKey(version int) ([]byte, error) {
 	switch {
 	case version < 0:
@@ -1256,7 +1318,26 @@ func (p *Policy) VerifySignature(context, input []byte, sig, hashAlgorithm strin
 	}
 }
 
-func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr error) {
+func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) error {
+	return p.RotateWithReader(ctx, storage, rand.Reader)
+}
+
+// AutoRotateDue returns whether the latest version of the key is older than
+// the auto-rotate period of the policy at the given time
+func (p *Policy) AutoRotateDue(now time.Time) bool {
+	if p.AutoRotatePeriod <= 0 {
+		return false
+	}
+	latest, ok := p.Keys[strconv.Itoa(p.LatestVersion)]
+	if !ok {
+		return false
+	}
+	return !now.Before(latest.CreationTime.Add(p.AutoRotatePeriod))
+}
+
+// RotateWithReader is like Rotate, but generates the new key version from
+// the random data of randReader
+func (p *Policy) RotateWithReader(ctx context.Context, storage logical.Storage, randReader io.Reader) (retErr error) {
 	priorLatestVersion := p.LatestVersion
 	priorMinDecryptionVersion := p.MinDecryptionVersion
 	var priorKeys keyEntryMap
@@ -1290,45 +1371,33 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 		DeprecatedCreationTime: now.Unix(),
 	}
 
-	hmacKey, err := uuid.GenerateRandomBytes(32)
-	if err != nil {
+	hmacKey := make([]byte, 32)
+	if _, err := io.ReadFull(randReader, hmacKey); err != nil {
 		return err
 	}
 	entry.HMACKey = hmacKey
 
+	var err error
 	switch p.Type {
 	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
 		// Generate a 256bit key
-		newKey, err := uuid.GenerateRandomBytes(32)
-		if err != nil {
+		newKey := make([]byte, 32)
+		if _, err := io.ReadFull(randReader, newKey); err != nil {
 			return err
 		}
 		entry.Key = newKey
 
 	case KeyType_ECDSA_P256:
-		privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		privKey, err := ecdsa.GenerateKey(elliptic.P256(), randReader)
 		if err != nil {
 			return err
 		}
-		entry.EC_D = privKey.D
-		entry.EC_X = privKey.X
-		entry.EC_Y = privKey.Y
-		derBytes, err := x509.MarshalPKIXPublicKey(privKey.Public())
-		if err != nil {
-			return errwrap.Wrapf("error marshaling public key: {{err}}", err)
-		}
-		pemBlock := &pem.Block{
-			Type:  "PUBLIC KEY",
-			Bytes: derBytes,
-		}
-		pemBytes := pem.EncodeToMemory(pemBlock)
-		if pemBytes == nil || len(pemBytes) == 0 {
-			return fmt.Errorf("error PEM-encoding public key")
+		if err := entry.setECDSAKey(privKey); err != nil {
+			return err
 		}
-		entry.FormattedPublicKey = string(pemBytes)
 
 	case KeyType_ED25519:
-		pub, pri, err := ed25519.GenerateKey(rand.Reader)
+		pub, pri, err := ed25519.GenerateKey(randReader)
 		if err != nil {
 			return err
 		}
@@ -1341,7 +1410,7 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 			bitSize = 4096
 		}
 
-		entry.RSAKey, err = rsa.GenerateKey(rand.Reader, bitSize)
+		entry.RSAKey, err = rsa.GenerateKey(randReader, bitSize)
 		if err != nil {
 			return err
 		}
@@ -1365,6 +1434,125 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 	return p.Persist(ctx, storage)
 }
 
+// setECDSAKey sets the private key and the formatted public key of the entry
+func (entry *KeyEntry) setECDSAKey(privKey *ecdsa.PrivateKey) error {
+	entry.EC_D = privKey.D
+	entry.EC_X = privKey.X
+	entry.EC_Y = privKey.Y
+	derBytes, err := x509.MarshalPKIXPublicKey(privKey.Public())
+	if err != nil {
+		return errwrap.Wrapf("error marshaling public key: {{err}}", err)
+	}
+	pemBlock := &pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	}
+	pemBytes := pem.EncodeToMemory(pemBlock)
+	if pemBytes == nil || len(pemBytes) == 0 {
+		return fmt.Errorf("error PEM-encoding public key")
+	}
+	entry.FormattedPublicKey = string(pemBytes)
+	return nil
+}
+
+// oidEd25519 is the algorithm identifier of ed25519 keys in PKCS #8
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+// pkcs8 is the structure of a PKCS #8 private key
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// Import sets the given key material as the first version of a new policy,
+// instead of generating it. Symmetric keys are raw bytes, and asymmetric keys
+// are PKCS #8 DER.
+func (p *Policy) Import(ctx context.Context, storage logical.Storage, key []byte) error {
+	if p.LatestVersion != 0 {
+		return fmt.Errorf("keys can only be imported as the first version of a policy")
+	}
+
+	now := time.Now()
+	entry := KeyEntry{
+		CreationTime:           now,
+		DeprecatedCreationTime: now.Unix(),
+	}
+
+	hmacKey, err := uuid.GenerateRandomBytes(32)
+	if err != nil {
+		return err
+	}
+	entry.HMACKey = hmacKey
+
+	switch p.Type {
+	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		if len(key) != 32 {
+			return errutil.UserError{Err: fmt.Sprintf("keys of type %v must be 32 bytes", p.Type)}
+		}
+		entry.Key = key
+
+	case KeyType_ED25519:
+		var parsed pkcs8
+		if _, err := asn1.Unmarshal(key, &parsed); err != nil || !parsed.Algo.Algorithm.Equal(oidEd25519) {
+			return errutil.UserError{Err: "key must be an ed25519 private key in PKCS #8 format"}
+		}
+		var seed []byte
+		if _, err := asn1.Unmarshal(parsed.PrivateKey, &seed); err != nil || len(seed) != ed25519.SeedSize {
+			return errutil.UserError{Err: "key must be an ed25519 private key in PKCS #8 format"}
+		}
+		privKey := ed25519.NewKeyFromSeed(seed)
+		entry.Key = privKey
+		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(privKey.Public().(ed25519.PublicKey))
+
+	case KeyType_ECDSA_P256:
+		parsed, err := x509.ParsePKCS8PrivateKey(key)
+		if err != nil {
+			return errutil.UserError{Err: fmt.Sprintf("failed to parse key: %v", err)}
+		}
+		privKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok || privKey.Curve != elliptic.P256() {
+			return errutil.UserError{Err: "key must be an ECDSA P-256 private key"}
+		}
+		if err := entry.setECDSAKey(privKey); err != nil {
+			return err
+		}
+
+	case KeyType_RSA2048, KeyType_RSA4096:
+		bitSize := 2048
+		if p.Type == KeyType_RSA4096 {
+			bitSize = 4096
+		}
+
+		parsed, err := x509.ParsePKCS8PrivateKey(key)
+		if err != nil {
+			return errutil.UserError{Err: fmt.Sprintf("failed to parse key: %v", err)}
+		}
+		privKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok || privKey.N.BitLen() != bitSize {
+			return errutil.UserError{Err: fmt.Sprintf("key must be a %d-bit RSA private key", bitSize)}
+		}
+		entry.RSAKey = privKey
+
+	default:
+		return fmt.Errorf("unsupported key type %v", p.Type)
+	}
+
+	if p.ConvergentEncryption {
+		if p.ConvergentVersion == -1 || p.ConvergentVersion > 1 {
+			entry.ConvergentVersion = currentConvergentVersion
+		}
+	}
+
+	p.Keys = keyEntryMap{
+		"1": entry,
+	}
+	p.LatestVersion = 1
+	p.MinDecryptionVersion = 1
+
+	return p.Persist(ctx, storage)
+}
+
 func (p *Policy) MigrateKeyToKeysMap() {
 	now := time.Now()
 	p.Keys = keyEntryMap{
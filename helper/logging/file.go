@@ -0,0 +1,151 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the time format of the names of rotated log files,
+// which sort in the order the files were rotated
+const rotatedTimeFormat = "20060102T150405.000000000"
+
+// LogFile is a writer to a log file which is rotated once it reaches a
+// size or an age. A rotated file is renamed after the time it was rotated,
+// so that vault.log becomes vault-20190102T150405.000000000.log, and the
+// oldest rotated files are removed.
+type LogFile struct {
+	l        sync.Mutex
+	path     string
+	maxBytes int64
+	duration time.Duration
+	maxFiles int
+	file     *os.File
+	size     int64
+	created  time.Time
+}
+
+// NewLogFile opens the log file at the given path, appending to it. The file
+// is rotated once writing would take it over maxBytes, or once it is older than
+// duration, and maxFiles rotated files are kept. Zero leaves each of them
+// unlimited.
+func NewLogFile(path string, maxBytes int64, duration time.Duration, maxFiles int) (*LogFile, error) {
+	f := &LogFile{
+		path:     path,
+		maxBytes: maxBytes,
+		duration: duration,
+		maxFiles: maxFiles,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write implements io.Writer, rotating the file first if the data would take
+// it over its size or it is older than its age
+func (f *LogFile) Write(p []byte) (int, error) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.size > 0 && ((f.maxBytes > 0 && f.size+int64(len(p)) > f.maxBytes) ||
+		(f.duration > 0 && time.Since(f.created) >= f.duration)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file
+func (f *LogFile) Close() error {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *LogFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.created = time.Now()
+	return nil
+}
+
+func (f *LogFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext)
+	rotated := base + "-" + time.Now().UTC().Format(rotatedTimeFormat) + ext
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+
+	if f.maxFiles > 0 {
+		matches, err := filepath.Glob(globEscape(base) + "-*" + globEscape(ext))
+		if err != nil {
+			return err
+		}
+		var rotatedFiles []string
+		for _, match := range matches {
+			rotatedTime := strings.TrimSuffix(strings.TrimPrefix(match, base+"-"), ext)
+			if _, err := time.Parse(rotatedTimeFormat, rotatedTime); err == nil {
+				rotatedFiles = append(rotatedFiles, match)
+			}
+		}
+		sort.Strings(rotatedFiles)
+		for len(rotatedFiles) > f.maxFiles {
+			if err := os.Remove(rotatedFiles[0]); err != nil {
+				return err
+			}
+			rotatedFiles = rotatedFiles[1:]
+		}
+	}
+
+	return f.open()
+}
+
+// globEscape escapes the characters of a path that filepath.Glob treats as
+// patterns
+func globEscape(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		switch r {
+		case '*', '?', '[', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-log-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// An existing file is appended to
+	path := filepath.Join(dir, "vault.log")
+	if err := ioutil.WriteFile(path, []byte("before\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewLogFile(path, 20, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Each line takes the file over its size
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(f, "line %d of the log\n", i)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "line 4 of the log\n" {
+		t.Fatalf("bad: %q", data)
+	}
+
+	// Only the last rotated files are kept, next to a file which is not one
+	// of them
+	if err := ioutil.WriteFile(filepath.Join(dir, "vault-other.log"), nil, 0640); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(f, "line 5 of the log\n")
+	matches, err := filepath.Glob(filepath.Join(dir, "vault-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("expected 2 rotated files and vault-other.log, got %v", matches)
+	}
+	data, err = ioutil.ReadFile(matches[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "line 4 of the log\n" {
+		t.Fatalf("bad: %q", data)
+	}
+	if !strings.HasSuffix(matches[2], "vault-other.log") {
+		t.Fatalf("bad: %v", matches)
+	}
+}
+
+func TestLogFile_duration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-log-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "vault.log")
+	f, err := NewLogFile(path, 0, 50*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "one\n")
+	fmt.Fprintf(f, "two\n")
+	time.Sleep(100 * time.Millisecond)
+	fmt.Fprintf(f, "three\n")
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "three\n" {
+		t.Fatalf("bad: %q", data)
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "vault-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected a rotated file, got %v", matches)
+	}
+	data, err = ioutil.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "one\ntwo\n" {
+		t.Fatalf("bad: %q", data)
+	}
+}
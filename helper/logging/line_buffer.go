@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	log "github.com/hashicorp/go-hclog"
+)
+
+// LineBuffer is a writer keeping the last lines written to it, so that the
+// recent log output of a server can be returned on request
+type LineBuffer struct {
+	l       sync.Mutex
+	lines   []string
+	next    int
+	full    bool
+	partial []byte
+	subs    map[chan string]struct{}
+}
+
+// NewLineBuffer returns a LineBuffer keeping the given number of lines
+func NewLineBuffer(size int) *LineBuffer {
+	if size < 1 {
+		size = 1
+	}
+	return &LineBuffer{
+		lines: make([]string, size),
+	}
+}
+
+// Write implements io.Writer. A line is kept once its newline is written.
+func (b *LineBuffer) Write(p []byte) (int, error) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			b.partial = append(b.partial, data...)
+			break
+		}
+
+		line := string(append(b.partial, data[:i]...))
+		b.lines[b.next] = line
+		b.partial = nil
+		for ch := range b.subs {
+			// Lines are dropped for subscribers which do not keep up,
+			// rather than blocking the writer
+			select {
+			case ch <- line:
+			default:
+			}
+		}
+		b.next = (b.next + 1) % len(b.lines)
+		if b.next == 0 {
+			b.full = true
+		}
+		data = data[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Lines returns the kept lines, oldest first
+func (b *LineBuffer) Lines() []string {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if !b.full {
+		return append([]string(nil), b.lines[:b.next]...)
+	}
+
+	lines := make([]string, 0, len(b.lines))
+	lines = append(lines, b.lines[b.next:]...)
+	return append(lines, b.lines[:b.next]...)
+}
+
+// Subscribe returns a channel receiving the lines written from now on, which
+// buffers up to size lines, and a function to stop receiving them, which
+// closes the channel
+func (b *LineBuffer) Subscribe(size int) (<-chan string, func()) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	ch := make(chan string, size)
+	if b.subs == nil {
+		b.subs = make(map[chan string]struct{})
+	}
+	b.subs[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.l.Lock()
+			defer b.l.Unlock()
+			delete(b.subs, ch)
+			close(ch)
+		})
+	}
+}
+
+// LineLevel returns the level of a line logged in the standard or JSON
+// format, or NoLevel if it cannot be told
+func LineLevel(line string) log.Level {
+	if strings.HasPrefix(line, "{") {
+		var entry struct {
+			Level string `json:"@level"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return log.NoLevel
+		}
+		return log.LevelFromString(entry.Level)
+	}
+
+	start := strings.Index(line, "[")
+	if start < 0 {
+		return log.NoLevel
+	}
+	end := strings.Index(line[start:], "]")
+	if end < 0 {
+		return log.NoLevel
+	}
+	return log.LevelFromString(line[start+1 : start+end])
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+)
+
+func TestLineBuffer(t *testing.T) {
+	b := NewLineBuffer(3)
+
+	if lines := b.Lines(); len(lines) != 0 {
+		t.Fatalf("bad: %#v", lines)
+	}
+
+	// Lines are only kept once complete
+	fmt.Fprint(b, "one\ntw")
+	if lines := b.Lines(); !reflect.DeepEqual(lines, []string{"one"}) {
+		t.Fatalf("bad: %#v", lines)
+	}
+	fmt.Fprint(b, "o\n")
+	if lines := b.Lines(); !reflect.DeepEqual(lines, []string{"one", "two"}) {
+		t.Fatalf("bad: %#v", lines)
+	}
+
+	// Only the last lines are kept
+	fmt.Fprint(b, "three\nfour\nfive\n")
+	if lines := b.Lines(); !reflect.DeepEqual(lines, []string{"three", "four", "five"}) {
+		t.Fatalf("bad: %#v", lines)
+	}
+}
+
+func TestLineBuffer_Subscribe(t *testing.T) {
+	b := NewLineBuffer(3)
+	fmt.Fprint(b, "before\n")
+
+	ch, stop := b.Subscribe(2)
+	fmt.Fprint(b, "one\ntwo\nthree\n")
+	stop()
+	fmt.Fprint(b, "after\n")
+
+	// Lines beyond the buffer of the subscriber are dropped
+	var lines []string
+	for line := range ch {
+		lines = append(lines, line)
+	}
+	if !reflect.DeepEqual(lines, []string{"one", "two"}) {
+		t.Fatalf("bad: %#v", lines)
+	}
+
+	// Stopping more than once is a no-op
+	stop()
+}
+
+func TestLineLevel(t *testing.T) {
+	cases := map[string]log.Level{
+		"2018-08-01T12:00:00.000Z [DEBUG] core: setting up": log.Debug,
+		"2018-08-01T12:00:00.000Z [WARN ] core: sealed":     log.Warn,
+		`{"@level":"error","@message":"failed"}`:            log.Error,
+		"no level":                                          log.NoLevel,
+		"{":                                                 log.NoLevel,
+	}
+	for line, expected := range cases {
+		if level := LineLevel(line); level != expected {
+			t.Fatalf("%q: expected %v, got %v", line, expected, level)
+		}
+	}
+}
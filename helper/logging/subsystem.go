@@ -0,0 +1,212 @@
+package logging
+
+import (
+	"bytes"
+	stdlog "log"
+	"strings"
+	"sync"
+
+	log "github.com/hashicorp/go-hclog"
+)
+
+// SubsystemLevels holds the level of a logger and the levels overriding it
+// for its subsystems. A subsystem is the name of a logger, such as "core" or
+// "storage", and its level applies to the loggers named after it, such as
+// "storage.cache". The most specific subsystem wins.
+type SubsystemLevels struct {
+	l      sync.RWMutex
+	level  log.Level
+	levels map[string]log.Level
+}
+
+// NewSubsystemLevels returns the given levels
+func NewSubsystemLevels(level log.Level, levels map[string]log.Level) *SubsystemLevels {
+	s := &SubsystemLevels{
+		level: level,
+	}
+	s.SetSubsystemLevels(levels)
+	return s
+}
+
+// SetSubsystemLevels replaces the levels of the subsystems
+func (s *SubsystemLevels) SetSubsystemLevels(levels map[string]log.Level) {
+	copied := make(map[string]log.Level, len(levels))
+	for name, subsystemLevel := range levels {
+		copied[name] = subsystemLevel
+	}
+
+	s.l.Lock()
+	s.levels = copied
+	s.l.Unlock()
+}
+
+// SetLevel replaces the level of the loggers no subsystem level applies to
+func (s *SubsystemLevels) SetLevel(level log.Level) {
+	s.l.Lock()
+	s.level = level
+	s.l.Unlock()
+}
+
+// Level returns the level of the logger with the given name
+func (s *SubsystemLevels) Level(name string) log.Level {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	level := s.level
+	var match string
+	for subsystem, subsystemLevel := range s.levels {
+		if name != subsystem && !strings.HasPrefix(name, subsystem+".") {
+			continue
+		}
+		if len(subsystem) > len(match) {
+			match = subsystem
+			level = subsystemLevel
+		}
+	}
+	return level
+}
+
+// NewSubsystemLogger wraps a logger so that it and the loggers derived from it
+// log at the levels of their subsystem. The level of the wrapped logger is
+// set to trace, as the levels are checked before calling it.
+func NewSubsystemLogger(logger log.Logger, name string, levels *SubsystemLevels) log.Logger {
+	logger.SetLevel(log.Trace)
+	return &subsystemLogger{
+		logger: logger,
+		name:   name,
+		levels: levels,
+	}
+}
+
+type subsystemLogger struct {
+	logger log.Logger
+	name   string
+	levels *SubsystemLevels
+}
+
+var _ log.Logger = (*subsystemLogger)(nil)
+
+func (s *subsystemLogger) enabled(level log.Level) bool {
+	return level >= s.levels.Level(s.name)
+}
+
+func (s *subsystemLogger) Trace(msg string, args ...interface{}) {
+	if s.enabled(log.Trace) {
+		s.logger.Trace(msg, args...)
+	}
+}
+
+func (s *subsystemLogger) Debug(msg string, args ...interface{}) {
+	if s.enabled(log.Debug) {
+		s.logger.Debug(msg, args...)
+	}
+}
+
+func (s *subsystemLogger) Info(msg string, args ...interface{}) {
+	if s.enabled(log.Info) {
+		s.logger.Info(msg, args...)
+	}
+}
+
+func (s *subsystemLogger) Warn(msg string, args ...interface{}) {
+	if s.enabled(log.Warn) {
+		s.logger.Warn(msg, args...)
+	}
+}
+
+func (s *subsystemLogger) Error(msg string, args ...interface{}) {
+	if s.enabled(log.Error) {
+		s.logger.Error(msg, args...)
+	}
+}
+
+func (s *subsystemLogger) IsTrace() bool { return s.enabled(log.Trace) }
+func (s *subsystemLogger) IsDebug() bool { return s.enabled(log.Debug) }
+func (s *subsystemLogger) IsInfo() bool  { return s.enabled(log.Info) }
+func (s *subsystemLogger) IsWarn() bool  { return s.enabled(log.Warn) }
+func (s *subsystemLogger) IsError() bool { return s.enabled(log.Error) }
+
+func (s *subsystemLogger) With(args ...interface{}) log.Logger {
+	return &subsystemLogger{
+		logger: s.logger.With(args...),
+		name:   s.name,
+		levels: s.levels,
+	}
+}
+
+func (s *subsystemLogger) Named(name string) log.Logger {
+	if s.name != "" {
+		name = s.name + "." + name
+	}
+	return s.ResetNamed(name)
+}
+
+func (s *subsystemLogger) ResetNamed(name string) log.Logger {
+	return &subsystemLogger{
+		logger: s.logger.ResetNamed(name),
+		name:   name,
+		levels: s.levels,
+	}
+}
+
+// SetLevel sets the level of the loggers no subsystem level applies to,
+// which is shared by all the loggers derived from the same one.
+func (s *subsystemLogger) SetLevel(level log.Level) {
+	s.levels.SetLevel(level)
+}
+
+func (s *subsystemLogger) StandardLogger(opts *log.StandardLoggerOptions) *stdlog.Logger {
+	if opts == nil {
+		opts = &log.StandardLoggerOptions{}
+	}
+	return stdlog.New(&subsystemStdlogAdapter{s, opts.InferLevels}, "", 0)
+}
+
+// subsystemStdlogAdapter writes the lines of a standard library logger to a
+// subsystem logger, the way go-hclog does for its own loggers
+type subsystemStdlogAdapter struct {
+	logger      *subsystemLogger
+	inferLevels bool
+}
+
+func (a *subsystemStdlogAdapter) Write(data []byte) (int, error) {
+	str := string(bytes.TrimRight(data, " \t\n"))
+
+	if !a.inferLevels {
+		a.logger.Info(str)
+		return len(data), nil
+	}
+
+	level, str := inferLevel(str)
+	switch level {
+	case log.Trace:
+		a.logger.Trace(str)
+	case log.Debug:
+		a.logger.Debug(str)
+	case log.Warn:
+		a.logger.Warn(str)
+	case log.Error:
+		a.logger.Error(str)
+	default:
+		a.logger.Info(str)
+	}
+	return len(data), nil
+}
+
+// inferLevel detects the level of a line of the standard library logger from
+// its prefix, such as "[ERROR]", and strips it
+func inferLevel(str string) (log.Level, string) {
+	for prefix, level := range map[string]log.Level{
+		"[TRACE]": log.Trace,
+		"[DEBUG]": log.Debug,
+		"[INFO]":  log.Info,
+		"[WARN]":  log.Warn,
+		"[ERR]":   log.Error,
+		"[ERROR]": log.Error,
+	} {
+		if strings.HasPrefix(str, prefix) {
+			return level, strings.TrimSpace(str[len(prefix):])
+		}
+	}
+	return log.Info, str
+}
@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+)
+
+func TestSubsystemLogger(t *testing.T) {
+	var buf bytes.Buffer
+	levels := NewSubsystemLevels(log.Info, map[string]log.Level{
+		"storage":       log.Debug,
+		"storage.cache": log.Error,
+		"secrets.kv":    log.Trace,
+	})
+	logger := NewSubsystemLogger(NewVaultLoggerWithFormat(&buf, log.Info, StandardFormat), "", levels)
+
+	cases := []struct {
+		logger  log.Logger
+		level   log.Level
+		logged  bool
+		message string
+	}{
+		{logger.Named("core"), log.Info, true, "core info"},
+		{logger.Named("core"), log.Debug, false, "core debug"},
+		{logger.Named("core").ResetNamed("storage.raft"), log.Debug, true, "raft debug"},
+		{logger.Named("storage").Named("cache"), log.Warn, false, "cache warn"},
+		{logger.ResetNamed("storage.cache"), log.Error, true, "cache error"},
+		{logger.ResetNamed("secrets.kv.kv_1234").With("key", "value"), log.Trace, true, "kv trace"},
+		{logger.ResetNamed("secrets.kvv"), log.Debug, false, "kvv debug"},
+	}
+	for _, tc := range cases {
+		buf.Reset()
+		switch tc.level {
+		case log.Trace:
+			tc.logger.Trace(tc.message)
+		case log.Debug:
+			tc.logger.Debug(tc.message)
+		case log.Info:
+			tc.logger.Info(tc.message)
+		case log.Warn:
+			tc.logger.Warn(tc.message)
+		case log.Error:
+			tc.logger.Error(tc.message)
+		}
+		if logged := strings.Contains(buf.String(), tc.message); logged != tc.logged {
+			t.Fatalf("%s: expected logged to be %t: %q", tc.message, tc.logged, buf.String())
+		}
+	}
+
+	// The level of the loggers without a subsystem level is shared
+	core := logger.Named("core")
+	logger.SetLevel(log.Debug)
+	if !core.IsDebug() || core.IsTrace() {
+		t.Fatal("expected the core logger to log at debug")
+	}
+
+	levels.SetSubsystemLevels(map[string]log.Level{"core": log.Error})
+	if core.IsWarn() || !core.IsError() {
+		t.Fatal("expected the core logger to log at error")
+	}
+
+	// The standard logger filters at the level of its subsystem
+	buf.Reset()
+	std := logger.Named("core").StandardLogger(&log.StandardLoggerOptions{InferLevels: true})
+	std.Print("[WARN] std warn")
+	std.Print("[ERROR] std error")
+	if out := buf.String(); strings.Contains(out, "std warn") || !strings.Contains(out, "std error") {
+		t.Fatalf("bad: %q", out)
+	}
+}
+
+func TestNewVaultLoggerWithFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewVaultLoggerWithFormat(&buf, log.Info, JSONFormat).Named("core")
+	logger.Info("unsealed", "path", "secret/")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected a JSON line: %v: %q", err, buf.String())
+	}
+	if line["@message"] != "unsealed" || line["@module"] != "core" || line["path"] != "secret/" {
+		t.Fatalf("bad: %#v", line)
+	}
+
+	for input, expected := range map[string]LogFormat{
+		"":         UnspecifiedFormat,
+		"standard": StandardFormat,
+		"JSON":     JSONFormat,
+	} {
+		format, err := ParseLogFormat(input)
+		if err != nil || format != expected {
+			t.Fatalf("%q: expected %s, got %s: %v", input, expected, format, err)
+		}
+	}
+	if _, err := ParseLogFormat("xml"); err == nil {
+		t.Fatal("expected an error parsing an unknown format")
+	}
+}
@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"strings"
@@ -14,13 +15,62 @@ func NewVaultLogger(level log.Level) log.Logger {
 	return NewVaultLoggerWithWriter(log.DefaultOutput, level)
 }
 
+// LogFormat is the format of the lines a logger writes
+type LogFormat int
+
+const (
+	// UnspecifiedFormat falls back to the VAULT_LOG_FORMAT environment
+	// variable
+	UnspecifiedFormat LogFormat = iota
+	StandardFormat
+	JSONFormat
+)
+
+func (f LogFormat) String() string {
+	switch f {
+	case StandardFormat:
+		return "standard"
+	case JSONFormat:
+		return "json"
+	default:
+		return "unspecified"
+	}
+}
+
+// ParseLogFormat converts the name of a log format, "standard" or "json",
+// into a LogFormat. An empty name is UnspecifiedFormat.
+func ParseLogFormat(format string) (LogFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "":
+		return UnspecifiedFormat, nil
+	case "standard":
+		return StandardFormat, nil
+	case "json", "vault_json", "vault-json", "vaultjson":
+		return JSONFormat, nil
+	default:
+		return UnspecifiedFormat, fmt.Errorf("unknown log format: %s", format)
+	}
+}
+
 // NewVaultLoggerWithWriter creates a new logger with the specified level and
 // writer and a Vault formatter
 func NewVaultLoggerWithWriter(w io.Writer, level log.Level) log.Logger {
+	return NewVaultLoggerWithFormat(w, level, UnspecifiedFormat)
+}
+
+// NewVaultLoggerWithFormat creates a new logger with the specified level,
+// writer and format
+func NewVaultLoggerWithFormat(w io.Writer, level log.Level, format LogFormat) log.Logger {
+	if format == UnspecifiedFormat {
+		format = StandardFormat
+		if useJson() {
+			format = JSONFormat
+		}
+	}
 	opts := &log.LoggerOptions{
 		Level:      level,
 		Output:     w,
-		JSONFormat: useJson(),
+		JSONFormat: format == JSONFormat,
 	}
 	return log.New(opts)
 }
@@ -0,0 +1,443 @@
+// Package pkcs11 gives access to a key held in an HSM through PKCS#11. The
+// key is an AES-256 key that never leaves the HSM; Vault only asks the HSM to
+// encrypt and decrypt data with it.
+//
+// The versions of the key are distinct objects of the HSM that share the
+// configured label and have the version as their ID, so that rotating the key
+// keeps the previous versions available to decrypt older data.
+package pkcs11
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/parseutil"
+)
+
+// Mechanism is the PKCS#11 mechanism used to encrypt with the key
+type Mechanism uint
+
+const (
+	// MechanismAESCBCPad is CKM_AES_CBC_PAD
+	MechanismAESCBCPad Mechanism = 0x1085
+
+	// MechanismAESGCM is CKM_AES_GCM
+	MechanismAESGCM Mechanism = 0x1087
+)
+
+// DefaultHealthCheckInterval is how often the HSM is checked when no
+// interval is configured
+const DefaultHealthCheckInterval = 10 * time.Minute
+
+// ParseMechanism parses a mechanism given by name, such as CKM_AES_GCM, or by
+// number, such as 0x1087
+func ParseMechanism(in string) (Mechanism, error) {
+	switch strings.ToUpper(strings.TrimSpace(in)) {
+	case "CKM_AES_GCM":
+		return MechanismAESGCM, nil
+	case "CKM_AES_CBC_PAD":
+		return MechanismAESCBCPad, nil
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(in), 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mechanism %q", in)
+	}
+	mechanism := Mechanism(value)
+	if mechanism.ivSize() == 0 {
+		return 0, fmt.Errorf("unsupported mechanism %q", in)
+	}
+	return mechanism, nil
+}
+
+func (m Mechanism) String() string {
+	switch m {
+	case MechanismAESGCM:
+		return "CKM_AES_GCM"
+	case MechanismAESCBCPad:
+		return "CKM_AES_CBC_PAD"
+	default:
+		return fmt.Sprintf("0x%X", uint(m))
+	}
+}
+
+// ivSize returns the size of the IV of the mechanism, or 0 if the mechanism
+// is not supported
+func (m Mechanism) ivSize() int {
+	switch m {
+	case MechanismAESGCM:
+		return 12
+	case MechanismAESCBCPad:
+		return 16
+	default:
+		return 0
+	}
+}
+
+// Config is the configuration of the access to the HSM
+type Config struct {
+	// Lib is the path of the PKCS#11 library of the HSM
+	Lib string
+
+	// Slot is the slot of the token holding the key
+	Slot uint
+
+	// PIN is the PIN of the user of the token
+	PIN string
+
+	// KeyLabel is the label of the key
+	KeyLabel string
+
+	// Mechanism is the mechanism used to encrypt with the key
+	Mechanism Mechanism
+
+	// GenerateKey generates the key if the token does not hold one with the
+	// label
+	GenerateKey bool
+
+	// HealthCheckInterval is how often the HSM is checked
+	HealthCheckInterval time.Duration
+}
+
+// ParseConfig parses the configuration of a pkcs11 seal stanza. Each value
+// can be overridden by an environment variable, such as VAULT_HSM_PIN, so
+// that secrets do not have to be written in the configuration file.
+func ParseConfig(conf map[string]string) (*Config, error) {
+	get := func(key string) string {
+		if v := os.Getenv("VAULT_HSM_" + strings.ToUpper(key)); v != "" {
+			return v
+		}
+		return conf[key]
+	}
+
+	result := &Config{
+		Lib:                 get("lib"),
+		PIN:                 get("pin"),
+		KeyLabel:            get("key_label"),
+		Mechanism:           MechanismAESGCM,
+		HealthCheckInterval: DefaultHealthCheckInterval,
+	}
+	switch {
+	case result.Lib == "":
+		return nil, errors.New("'lib' must be set")
+	case result.PIN == "":
+		return nil, errors.New("'pin' must be set")
+	case result.KeyLabel == "":
+		return nil, errors.New("'key_label' must be set")
+	}
+
+	slot := get("slot")
+	if slot == "" {
+		return nil, errors.New("'slot' must be set")
+	}
+	value, err := strconv.ParseUint(slot, 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid slot %q", slot)
+	}
+	result.Slot = uint(value)
+
+	if v := get("mechanism"); v != "" {
+		if result.Mechanism, err = ParseMechanism(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := get("generate_key"); v != "" {
+		if result.GenerateKey, err = parseutil.ParseBool(v); err != nil {
+			return nil, errwrap.Wrapf("invalid 'generate_key': {{err}}", err)
+		}
+	}
+
+	if v := get("health_check_interval"); v != "" {
+		if result.HealthCheckInterval, err = parseutil.ParseDurationSecond(v); err != nil {
+			return nil, errwrap.Wrapf("invalid 'health_check_interval': {{err}}", err)
+		}
+		if result.HealthCheckInterval <= 0 {
+			return nil, errors.New("'health_check_interval' must be positive")
+		}
+	}
+
+	return result, nil
+}
+
+// Token is the set of operations on the token of the HSM that the key is
+// used through. Keys are identified by their label and ID.
+type Token interface {
+	// FindKeys returns the IDs of the secret keys with the given label
+	FindKeys(label string) ([][]byte, error)
+
+	// GenerateKey generates an AES-256 key with the given label and ID
+	GenerateKey(label string, id []byte) error
+
+	// Encrypt encrypts plaintext with the key and the IV
+	Encrypt(label string, id []byte, mechanism Mechanism, iv, plaintext []byte) ([]byte, error)
+
+	// Decrypt decrypts ciphertext with the key and the IV
+	Decrypt(label string, id []byte, mechanism Mechanism, iv, ciphertext []byte) ([]byte, error)
+
+	// Close releases the token
+	Close() error
+}
+
+// Ciphertext is data encrypted by the HSM, along with what is needed to
+// decrypt it
+type Ciphertext struct {
+	KeyLabel   string    `json:"key_label"`
+	KeyVersion int       `json:"key_version"`
+	Mechanism  Mechanism `json:"mechanism"`
+	IV         []byte    `json:"iv"`
+	Value      []byte    `json:"value"`
+}
+
+// HSM encrypts and decrypts data with the versions of a key of an HSM
+type HSM struct {
+	l sync.RWMutex
+
+	token     Token
+	keyLabel  string
+	mechanism Mechanism
+
+	// keyVersion is the version data is encrypted with, and keyIDs are the
+	// IDs of the known versions of the key
+	keyVersion int
+	keyIDs     map[int][]byte
+}
+
+// Open loads the PKCS#11 library of the configuration, logs into the token of
+// its slot and returns the HSM
+func Open(conf *Config) (*HSM, error) {
+	token, err := OpenModuleToken(conf.Lib, conf.Slot, conf.PIN)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := New(conf, token)
+	if err != nil {
+		token.Close()
+		return nil, err
+	}
+	return h, nil
+}
+
+// New returns the HSM using the key of the configuration on the token,
+// generating the key if it does not exist and the configuration allows it
+func New(conf *Config, token Token) (*HSM, error) {
+	if conf.Mechanism.ivSize() == 0 {
+		return nil, fmt.Errorf("unsupported mechanism %s", conf.Mechanism)
+	}
+
+	h := &HSM{
+		token:     token,
+		keyLabel:  conf.KeyLabel,
+		mechanism: conf.Mechanism,
+	}
+
+	keyIDs, err := h.findKeys(h.keyLabel)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyIDs) == 0 {
+		if !conf.GenerateKey {
+			return nil, fmt.Errorf("no key with label %q found on the HSM; set 'generate_key' to generate it", h.keyLabel)
+		}
+		id := keyID(1)
+		if err := token.GenerateKey(h.keyLabel, id); err != nil {
+			return nil, errwrap.Wrapf("error generating the key: {{err}}", err)
+		}
+		keyIDs = map[int][]byte{1: id}
+	}
+
+	h.setKeys(keyIDs)
+	return h, nil
+}
+
+// KeyLabel returns the label of the key
+func (h *HSM) KeyLabel() string {
+	return h.keyLabel
+}
+
+// KeyVersion returns the version of the key data is encrypted with
+func (h *HSM) KeyVersion() int {
+	h.l.RLock()
+	defer h.l.RUnlock()
+	return h.keyVersion
+}
+
+// Encrypt encrypts plaintext with the latest version of the key
+func (h *HSM) Encrypt(plaintext []byte) (*Ciphertext, error) {
+	h.l.RLock()
+	version := h.keyVersion
+	id := h.keyIDs[version]
+	h.l.RUnlock()
+
+	iv := make([]byte, h.mechanism.ivSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, errwrap.Wrapf("error generating the IV: {{err}}", err)
+	}
+
+	value, err := h.token.Encrypt(h.keyLabel, id, h.mechanism, iv, plaintext)
+	if err != nil {
+		return nil, errwrap.Wrapf("error encrypting with the HSM: {{err}}", err)
+	}
+
+	return &Ciphertext{
+		KeyLabel:   h.keyLabel,
+		KeyVersion: version,
+		Mechanism:  h.mechanism,
+		IV:         iv,
+		Value:      value,
+	}, nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt, with any version of the key or
+// with a key of another label
+func (h *HSM) Decrypt(ct *Ciphertext) ([]byte, error) {
+	if ct == nil {
+		return nil, errors.New("nil ciphertext")
+	}
+
+	id, err := h.resolveKeyID(ct.KeyLabel, ct.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := h.token.Decrypt(ct.KeyLabel, id, ct.Mechanism, ct.IV, ct.Value)
+	if err != nil {
+		return nil, errwrap.Wrapf("error decrypting with the HSM: {{err}}", err)
+	}
+	return plaintext, nil
+}
+
+// Current returns whether the ciphertext is encrypted with the latest version
+// of the key
+func (h *HSM) Current(ct *Ciphertext) bool {
+	h.l.RLock()
+	defer h.l.RUnlock()
+	return ct.KeyLabel == h.keyLabel && ct.KeyVersion == h.keyVersion && ct.Mechanism == h.mechanism
+}
+
+// RotateKey generates a new version of the key, which data is encrypted with
+// from then on, and returns it
+func (h *HSM) RotateKey() (int, error) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	// Another node may have rotated the key in the meantime
+	keyIDs, err := h.findKeys(h.keyLabel)
+	if err != nil {
+		return 0, err
+	}
+	h.setKeysLocked(keyIDs)
+
+	version := h.keyVersion + 1
+	id := keyID(version)
+	if err := h.token.GenerateKey(h.keyLabel, id); err != nil {
+		return 0, errwrap.Wrapf("error generating the key: {{err}}", err)
+	}
+	h.keyIDs[version] = id
+	h.keyVersion = version
+
+	return version, nil
+}
+
+// Check verifies that the HSM can encrypt and decrypt with the latest version
+// of the key
+func (h *HSM) Check() error {
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		return err
+	}
+
+	ct, err := h.Encrypt(value)
+	if err != nil {
+		return err
+	}
+	plaintext, err := h.Decrypt(ct)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(plaintext, value) {
+		return errors.New("the HSM returned a different value than the one encrypted")
+	}
+	return nil
+}
+
+// Close releases the token of the HSM
+func (h *HSM) Close() error {
+	return h.token.Close()
+}
+
+func (h *HSM) resolveKeyID(label string, version int) ([]byte, error) {
+	if label == h.keyLabel {
+		h.l.RLock()
+		id, ok := h.keyIDs[version]
+		h.l.RUnlock()
+		if ok {
+			return id, nil
+		}
+	}
+
+	// The key may be of another label, or a version created by another node
+	keyIDs, err := h.findKeys(label)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := keyIDs[version]
+	if !ok {
+		return nil, fmt.Errorf("version %d of the key with label %q not found on the HSM", version, label)
+	}
+	if label == h.keyLabel {
+		h.setKeys(keyIDs)
+	}
+	return id, nil
+}
+
+// findKeys returns the IDs of the versions of the keys with the label. Keys
+// whose ID is not a version, such as keys created outside of Vault, are
+// version 0.
+func (h *HSM) findKeys(label string) (map[int][]byte, error) {
+	ids, err := h.token.FindKeys(label)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error finding the keys with label %q: {{err}}", label), err)
+	}
+
+	keyIDs := make(map[int][]byte, len(ids))
+	for _, id := range ids {
+		version, err := strconv.Atoi(string(id))
+		if err != nil || version < 1 {
+			version = 0
+		}
+		if _, ok := keyIDs[version]; ok {
+			return nil, fmt.Errorf("found several keys with label %q and version %d", label, version)
+		}
+		keyIDs[version] = id
+	}
+	return keyIDs, nil
+}
+
+func (h *HSM) setKeys(keyIDs map[int][]byte) {
+	h.l.Lock()
+	defer h.l.Unlock()
+	h.setKeysLocked(keyIDs)
+}
+
+func (h *HSM) setKeysLocked(keyIDs map[int][]byte) {
+	h.keyIDs = keyIDs
+	for version := range keyIDs {
+		if version > h.keyVersion {
+			h.keyVersion = version
+		}
+	}
+}
+
+func keyID(version int) []byte {
+	return []byte(strconv.Itoa(version))
+}
@@ -0,0 +1,134 @@
+package pkcs11
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	conf, err := ParseConfig(map[string]string{
+		"lib":                   "/usr/lib/softhsm/libsofthsm2.so",
+		"slot":                  "0x2",
+		"pin":                   "1234",
+		"key_label":             "vault",
+		"mechanism":             "CKM_AES_CBC_PAD",
+		"generate_key":          "true",
+		"health_check_interval": "30s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Config{
+		Lib:                 "/usr/lib/softhsm/libsofthsm2.so",
+		Slot:                2,
+		PIN:                 "1234",
+		KeyLabel:            "vault",
+		Mechanism:           MechanismAESCBCPad,
+		GenerateKey:         true,
+		HealthCheckInterval: 30 * time.Second,
+	}
+	if *conf != expected {
+		t.Fatalf("expected %#v to be %#v", *conf, expected)
+	}
+
+	os.Setenv("VAULT_HSM_PIN", "5678")
+	defer os.Unsetenv("VAULT_HSM_PIN")
+	conf, err = ParseConfig(map[string]string{
+		"lib":       "/usr/lib/softhsm/libsofthsm2.so",
+		"slot":      "0",
+		"key_label": "vault",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.PIN != "5678" || conf.Mechanism != MechanismAESGCM || conf.HealthCheckInterval != DefaultHealthCheckInterval {
+		t.Fatalf("bad: %#v", conf)
+	}
+
+	for _, bad := range []map[string]string{
+		{"slot": "0", "pin": "1234", "key_label": "vault"},
+		{"lib": "lib.so", "pin": "1234", "key_label": "vault"},
+		{"lib": "lib.so", "slot": "zero", "pin": "1234", "key_label": "vault"},
+		{"lib": "lib.so", "slot": "0", "pin": "1234"},
+		{"lib": "lib.so", "slot": "0", "pin": "1234", "key_label": "vault", "mechanism": "CKM_RSA_PKCS"},
+	} {
+		if _, err := ParseConfig(bad); err == nil {
+			t.Fatalf("expected an error parsing %v", bad)
+		}
+	}
+}
+
+func TestHSM(t *testing.T) {
+	for _, mechanism := range []Mechanism{MechanismAESGCM, MechanismAESCBCPad} {
+		token := NewInmemToken()
+		conf := &Config{
+			KeyLabel:  "vault",
+			Mechanism: mechanism,
+		}
+
+		if _, err := New(conf, token); err == nil {
+			t.Fatal("expected an error without a key")
+		}
+
+		conf.GenerateKey = true
+		h, err := New(conf, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.KeyVersion() != 1 {
+			t.Fatalf("bad: %d", h.KeyVersion())
+		}
+		if err := h.Check(); err != nil {
+			t.Fatalf("%s: %v", mechanism, err)
+		}
+
+		ct, err := h.Encrypt([]byte("master key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A second node sharing the HSM rotates the key
+		other, err := New(conf, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		version, err := other.RotateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 2 || other.Current(ct) {
+			t.Fatalf("bad: %d", version)
+		}
+		rotated, err := other.Encrypt([]byte("master key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Both versions are usable from either node
+		for _, c := range []*Ciphertext{ct, rotated} {
+			for _, node := range []*HSM{h, other} {
+				plaintext, err := node.Decrypt(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(plaintext) != "master key" {
+					t.Fatalf("bad: %q", plaintext)
+				}
+			}
+		}
+		if !h.Current(rotated) || h.KeyVersion() != 2 {
+			t.Fatal("expected the first node to learn about the new version")
+		}
+
+		token.SetErr(errors.New("device removed"))
+		if err := h.Check(); err == nil {
+			t.Fatal("expected the check to fail")
+		}
+		token.SetErr(nil)
+		if err := h.Check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
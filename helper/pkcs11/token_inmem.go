@@ -0,0 +1,155 @@
+package pkcs11
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type inmemKey struct {
+	id  []byte
+	key []byte
+}
+
+// InmemToken is a Token that keeps its keys in memory. It encrypts like an
+// HSM would, and is used for tests and development.
+type InmemToken struct {
+	l    sync.Mutex
+	keys map[string][]*inmemKey
+	err  error
+}
+
+// NewInmemToken returns an empty InmemToken
+func NewInmemToken() *InmemToken {
+	return &InmemToken{
+		keys: make(map[string][]*inmemKey),
+	}
+}
+
+// SetErr sets the error every operation returns, to simulate an HSM that
+// cannot be reached; nil restores the token
+func (t *InmemToken) SetErr(err error) {
+	t.l.Lock()
+	defer t.l.Unlock()
+	t.err = err
+}
+
+// FindKeys implements Token
+func (t *InmemToken) FindKeys(label string) ([][]byte, error) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	ids := make([][]byte, 0, len(t.keys[label]))
+	for _, key := range t.keys[label] {
+		ids = append(ids, key.id)
+	}
+	return ids, nil
+}
+
+// GenerateKey implements Token
+func (t *InmemToken) GenerateKey(label string, id []byte) error {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	if t.err != nil {
+		return t.err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	t.keys[label] = append(t.keys[label], &inmemKey{
+		id:  append([]byte(nil), id...),
+		key: key,
+	})
+	return nil
+}
+
+// Encrypt implements Token
+func (t *InmemToken) Encrypt(label string, id []byte, mechanism Mechanism, iv, plaintext []byte) ([]byte, error) {
+	block, err := t.block(label, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mechanism {
+	case MechanismAESGCM:
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		return gcm.Seal(nil, iv, plaintext, nil), nil
+
+	case MechanismAESCBCPad:
+		padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+		padded := append(append([]byte(nil), plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		out := make([]byte, len(padded))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, padded)
+		return out, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported mechanism %s", mechanism)
+	}
+}
+
+// Decrypt implements Token
+func (t *InmemToken) Decrypt(label string, id []byte, mechanism Mechanism, iv, ciphertext []byte) ([]byte, error) {
+	block, err := t.block(label, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mechanism {
+	case MechanismAESGCM:
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		return gcm.Open(nil, iv, ciphertext, nil)
+
+	case MechanismAESCBCPad:
+		if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, errors.New("invalid ciphertext length")
+		}
+		out := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, ciphertext)
+		padding := int(out[len(out)-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, errors.New("invalid padding")
+		}
+		return out[:len(out)-padding], nil
+
+	default:
+		return nil, fmt.Errorf("unsupported mechanism %s", mechanism)
+	}
+}
+
+// Close implements Token
+func (t *InmemToken) Close() error {
+	return nil
+}
+
+func (t *InmemToken) block(label string, id []byte) (cipher.Block, error) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	for _, key := range t.keys[label] {
+		if bytes.Equal(key.id, id) {
+			return aes.NewCipher(key.key)
+		}
+	}
+	return nil, fmt.Errorf("no key with label %q and ID %q", label, id)
+}
@@ -0,0 +1,581 @@
+// +build cgo,!windows
+
+package pkcs11
+
+/*
+#cgo linux LDFLAGS: -ldl
+
+#include <dlfcn.h>
+#include <stdlib.h>
+#include <string.h>
+
+// The subset of the PKCS#11 header needed to use a key of a token
+
+typedef unsigned char CK_BYTE;
+typedef CK_BYTE CK_BBOOL;
+typedef unsigned long CK_ULONG;
+typedef CK_ULONG CK_RV;
+typedef CK_ULONG CK_FLAGS;
+typedef CK_ULONG CK_SLOT_ID;
+typedef CK_ULONG CK_SESSION_HANDLE;
+typedef CK_ULONG CK_OBJECT_HANDLE;
+typedef CK_ULONG CK_MECHANISM_TYPE;
+
+#define CKR_OK                              0x000
+#define CKR_CRYPTOKI_ALREADY_INITIALIZED    0x191
+#define CKR_USER_ALREADY_LOGGED_IN          0x100
+#define CKF_RW_SESSION                      0x002
+#define CKF_SERIAL_SESSION                  0x004
+#define CKF_OS_LOCKING_OK                   0x002
+#define CKU_USER                            1
+#define CKO_SECRET_KEY                      4
+#define CKK_AES                             0x01F
+#define CKA_CLASS                           0x000
+#define CKA_TOKEN                           0x001
+#define CKA_PRIVATE                         0x002
+#define CKA_LABEL                           0x003
+#define CKA_KEY_TYPE                        0x100
+#define CKA_ID                              0x102
+#define CKA_SENSITIVE                       0x103
+#define CKA_ENCRYPT                         0x104
+#define CKA_DECRYPT                         0x105
+#define CKA_VALUE_LEN                       0x161
+#define CKA_EXTRACTABLE                     0x162
+#define CKM_AES_KEY_GEN                     0x1080
+#define CKM_AES_CBC_PAD                     0x1085
+#define CKM_AES_GCM                         0x1087
+
+typedef struct {
+	CK_BYTE major;
+	CK_BYTE minor;
+} CK_VERSION;
+
+typedef struct {
+	CK_ULONG type;
+	void *pValue;
+	CK_ULONG ulValueLen;
+} CK_ATTRIBUTE;
+
+typedef struct {
+	CK_MECHANISM_TYPE mechanism;
+	void *pParameter;
+	CK_ULONG ulParameterLen;
+} CK_MECHANISM;
+
+typedef struct {
+	CK_BYTE *pIv;
+	CK_ULONG ulIvLen;
+	CK_ULONG ulIvBits;
+	CK_BYTE *pAAD;
+	CK_ULONG ulAADLen;
+	CK_ULONG ulTagBits;
+} CK_GCM_PARAMS;
+
+typedef struct {
+	void *CreateMutex;
+	void *DestroyMutex;
+	void *LockMutex;
+	void *UnlockMutex;
+	CK_FLAGS flags;
+	void *pReserved;
+} CK_C_INITIALIZE_ARGS;
+
+// The function list, in the order of the specification; the functions that
+// are not used are left untyped
+typedef struct {
+	CK_VERSION version;
+	CK_RV (*C_Initialize)(void *);
+	CK_RV (*C_Finalize)(void *);
+	void *C_GetInfo;
+	void *C_GetFunctionList;
+	void *C_GetSlotList;
+	void *C_GetSlotInfo;
+	void *C_GetTokenInfo;
+	void *C_GetMechanismList;
+	void *C_GetMechanismInfo;
+	void *C_InitToken;
+	void *C_InitPIN;
+	void *C_SetPIN;
+	CK_RV (*C_OpenSession)(CK_SLOT_ID, CK_FLAGS, void *, void *, CK_SESSION_HANDLE *);
+	CK_RV (*C_CloseSession)(CK_SESSION_HANDLE);
+	void *C_CloseAllSessions;
+	void *C_GetSessionInfo;
+	void *C_GetOperationState;
+	void *C_SetOperationState;
+	CK_RV (*C_Login)(CK_SESSION_HANDLE, CK_ULONG, CK_BYTE *, CK_ULONG);
+	CK_RV (*C_Logout)(CK_SESSION_HANDLE);
+	void *C_CreateObject;
+	void *C_CopyObject;
+	void *C_DestroyObject;
+	void *C_GetObjectSize;
+	CK_RV (*C_GetAttributeValue)(CK_SESSION_HANDLE, CK_OBJECT_HANDLE, CK_ATTRIBUTE *, CK_ULONG);
+	void *C_SetAttributeValue;
+	CK_RV (*C_FindObjectsInit)(CK_SESSION_HANDLE, CK_ATTRIBUTE *, CK_ULONG);
+	CK_RV (*C_FindObjects)(CK_SESSION_HANDLE, CK_OBJECT_HANDLE *, CK_ULONG, CK_ULONG *);
+	CK_RV (*C_FindObjectsFinal)(CK_SESSION_HANDLE);
+	CK_RV (*C_EncryptInit)(CK_SESSION_HANDLE, CK_MECHANISM *, CK_OBJECT_HANDLE);
+	CK_RV (*C_Encrypt)(CK_SESSION_HANDLE, CK_BYTE *, CK_ULONG, CK_BYTE *, CK_ULONG *);
+	void *C_EncryptUpdate;
+	void *C_EncryptFinal;
+	CK_RV (*C_DecryptInit)(CK_SESSION_HANDLE, CK_MECHANISM *, CK_OBJECT_HANDLE);
+	CK_RV (*C_Decrypt)(CK_SESSION_HANDLE, CK_BYTE *, CK_ULONG, CK_BYTE *, CK_ULONG *);
+	void *C_DecryptUpdate;
+	void *C_DecryptFinal;
+	void *C_DigestInit;
+	void *C_Digest;
+	void *C_DigestUpdate;
+	void *C_DigestKey;
+	void *C_DigestFinal;
+	void *C_SignInit;
+	void *C_Sign;
+	void *C_SignUpdate;
+	void *C_SignFinal;
+	void *C_SignRecoverInit;
+	void *C_SignRecover;
+	void *C_VerifyInit;
+	void *C_Verify;
+	void *C_VerifyUpdate;
+	void *C_VerifyFinal;
+	void *C_VerifyRecoverInit;
+	void *C_VerifyRecover;
+	void *C_DigestEncryptUpdate;
+	void *C_DecryptDigestUpdate;
+	void *C_SignEncryptUpdate;
+	void *C_DecryptVerifyUpdate;
+	CK_RV (*C_GenerateKey)(CK_SESSION_HANDLE, CK_MECHANISM *, CK_ATTRIBUTE *, CK_ULONG, CK_OBJECT_HANDLE *);
+	void *C_GenerateKeyPair;
+	void *C_WrapKey;
+	void *C_UnwrapKey;
+	void *C_DeriveKey;
+	void *C_SeedRandom;
+	void *C_GenerateRandom;
+	void *C_GetFunctionStatus;
+	void *C_CancelFunction;
+	void *C_WaitForSlotEvent;
+} CK_FUNCTION_LIST;
+
+typedef CK_RV (*CK_C_GetFunctionList)(CK_FUNCTION_LIST **);
+
+// pkcs11_open loads and initializes the library; on failure, either rv is set
+// or err is set to a string to free
+static void *pkcs11_open(const char *path, CK_FUNCTION_LIST **functions, CK_RV *rv, char **err) {
+	void *handle = dlopen(path, RTLD_NOW | RTLD_LOCAL);
+	if (handle == NULL) {
+		*err = strdup(dlerror());
+		return NULL;
+	}
+	CK_C_GetFunctionList getFunctionList = (CK_C_GetFunctionList)dlsym(handle, "C_GetFunctionList");
+	if (getFunctionList == NULL) {
+		*err = strdup("C_GetFunctionList not found");
+		dlclose(handle);
+		return NULL;
+	}
+	*rv = getFunctionList(functions);
+	if (*rv != CKR_OK) {
+		dlclose(handle);
+		return NULL;
+	}
+
+	CK_C_INITIALIZE_ARGS args;
+	memset(&args, 0, sizeof(args));
+	args.flags = CKF_OS_LOCKING_OK;
+	*rv = (*functions)->C_Initialize(&args);
+	if (*rv == CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		*rv = CKR_OK;
+	}
+	if (*rv != CKR_OK) {
+		dlclose(handle);
+		return NULL;
+	}
+	return handle;
+}
+
+static void pkcs11_close(void *handle, CK_FUNCTION_LIST *functions) {
+	functions->C_Finalize(NULL);
+	dlclose(handle);
+}
+
+static CK_RV pkcs11_login(CK_FUNCTION_LIST *functions, CK_SLOT_ID slot, CK_BYTE *pin, CK_ULONG pinLen, CK_SESSION_HANDLE *session) {
+	CK_RV rv = functions->C_OpenSession(slot, CKF_SERIAL_SESSION | CKF_RW_SESSION, NULL, NULL, session);
+	if (rv != CKR_OK) {
+		return rv;
+	}
+	rv = functions->C_Login(*session, CKU_USER, pin, pinLen);
+	if (rv == CKR_USER_ALREADY_LOGGED_IN) {
+		rv = CKR_OK;
+	}
+	if (rv != CKR_OK) {
+		functions->C_CloseSession(*session);
+	}
+	return rv;
+}
+
+static CK_RV pkcs11_logout(CK_FUNCTION_LIST *functions, CK_SESSION_HANDLE session) {
+	functions->C_Logout(session);
+	return functions->C_CloseSession(session);
+}
+
+// pkcs11_find_keys finds the secret keys with the label and, if given, the
+// ID; the handles of at most max keys are returned
+static CK_RV pkcs11_find_keys(CK_FUNCTION_LIST *functions, CK_SESSION_HANDLE session, CK_BYTE *label, CK_ULONG labelLen, CK_BYTE *id, CK_ULONG idLen, CK_OBJECT_HANDLE *handles, CK_ULONG max, CK_ULONG *count) {
+	CK_ULONG class = CKO_SECRET_KEY;
+	CK_ATTRIBUTE template[] = {
+		{CKA_CLASS, &class, sizeof(class)},
+		{CKA_LABEL, label, labelLen},
+		{CKA_ID, id, idLen},
+	};
+	CK_RV rv = functions->C_FindObjectsInit(session, template, id == NULL ? 2 : 3);
+	if (rv != CKR_OK) {
+		return rv;
+	}
+	rv = functions->C_FindObjects(session, handles, max, count);
+	CK_RV finalRV = functions->C_FindObjectsFinal(session);
+	return rv != CKR_OK ? rv : finalRV;
+}
+
+// pkcs11_get_id returns the ID of a key; when id is NULL, only its length
+static CK_RV pkcs11_get_id(CK_FUNCTION_LIST *functions, CK_SESSION_HANDLE session, CK_OBJECT_HANDLE handle, CK_BYTE *id, CK_ULONG *idLen) {
+	CK_ATTRIBUTE template[] = {
+		{CKA_ID, id, *idLen},
+	};
+	CK_RV rv = functions->C_GetAttributeValue(session, handle, template, 1);
+	*idLen = template[0].ulValueLen;
+	return rv;
+}
+
+static CK_RV pkcs11_generate_key(CK_FUNCTION_LIST *functions, CK_SESSION_HANDLE session, CK_BYTE *label, CK_ULONG labelLen, CK_BYTE *id, CK_ULONG idLen) {
+	CK_MECHANISM mechanism = {CKM_AES_KEY_GEN, NULL, 0};
+	CK_ULONG class = CKO_SECRET_KEY;
+	CK_ULONG keyType = CKK_AES;
+	CK_ULONG valueLen = 32;
+	CK_BBOOL yes = 1;
+	CK_BBOOL no = 0;
+	CK_ATTRIBUTE template[] = {
+		{CKA_CLASS, &class, sizeof(class)},
+		{CKA_KEY_TYPE, &keyType, sizeof(keyType)},
+		{CKA_VALUE_LEN, &valueLen, sizeof(valueLen)},
+		{CKA_LABEL, label, labelLen},
+		{CKA_ID, id, idLen},
+		{CKA_TOKEN, &yes, sizeof(yes)},
+		{CKA_PRIVATE, &yes, sizeof(yes)},
+		{CKA_SENSITIVE, &yes, sizeof(yes)},
+		{CKA_EXTRACTABLE, &no, sizeof(no)},
+		{CKA_ENCRYPT, &yes, sizeof(yes)},
+		{CKA_DECRYPT, &yes, sizeof(yes)},
+	};
+	CK_OBJECT_HANDLE handle;
+	return functions->C_GenerateKey(session, &mechanism, template, sizeof(template) / sizeof(template[0]), &handle);
+}
+
+// pkcs11_crypt encrypts or decrypts in with the key; out must be large
+// enough for the result
+static CK_RV pkcs11_crypt(CK_FUNCTION_LIST *functions, CK_SESSION_HANDLE session, int encrypt, CK_MECHANISM_TYPE type, CK_OBJECT_HANDLE key, CK_BYTE *iv, CK_ULONG ivLen, CK_BYTE *in, CK_ULONG inLen, CK_BYTE *out, CK_ULONG *outLen) {
+	CK_GCM_PARAMS gcm = {iv, ivLen, ivLen * 8, NULL, 0, 128};
+	CK_MECHANISM mechanism = {type, iv, ivLen};
+	if (type == CKM_AES_GCM) {
+		mechanism.pParameter = &gcm;
+		mechanism.ulParameterLen = sizeof(gcm);
+	}
+
+	CK_RV rv;
+	if (encrypt) {
+		rv = functions->C_EncryptInit(session, &mechanism, key);
+		if (rv == CKR_OK) {
+			rv = functions->C_Encrypt(session, in, inLen, out, outLen);
+		}
+	} else {
+		rv = functions->C_DecryptInit(session, &mechanism, key);
+		if (rv == CKR_OK) {
+			rv = functions->C_Decrypt(session, in, inLen, out, outLen);
+		}
+	}
+	return rv;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+const (
+	// maxKeys is the maximum number of versions of a key that are looked up
+	maxKeys = 1024
+
+	// unavailableInformation is CK_UNAVAILABLE_INFORMATION
+	unavailableInformation = ^C.CK_ULONG(0)
+)
+
+// Error is an error returned by a PKCS#11 library
+type Error uint
+
+var errorNames = map[Error]string{
+	0x005: "CKR_GENERAL_ERROR",
+	0x006: "CKR_FUNCTION_FAILED",
+	0x007: "CKR_ARGUMENTS_BAD",
+	0x003: "CKR_SLOT_ID_INVALID",
+	0x013: "CKR_ATTRIBUTE_VALUE_INVALID",
+	0x021: "CKR_DATA_LEN_RANGE",
+	0x030: "CKR_DEVICE_ERROR",
+	0x031: "CKR_DEVICE_MEMORY",
+	0x032: "CKR_DEVICE_REMOVED",
+	0x040: "CKR_ENCRYPTED_DATA_INVALID",
+	0x041: "CKR_ENCRYPTED_DATA_LEN_RANGE",
+	0x060: "CKR_KEY_HANDLE_INVALID",
+	0x070: "CKR_MECHANISM_INVALID",
+	0x071: "CKR_MECHANISM_PARAM_INVALID",
+	0x0A0: "CKR_PIN_INCORRECT",
+	0x0A4: "CKR_PIN_LOCKED",
+	0x0B0: "CKR_SESSION_CLOSED",
+	0x0B3: "CKR_SESSION_HANDLE_INVALID",
+	0x0D0: "CKR_TEMPLATE_INCOMPLETE",
+	0x0D1: "CKR_TEMPLATE_INCONSISTENT",
+	0x0E0: "CKR_TOKEN_NOT_PRESENT",
+	0x101: "CKR_USER_NOT_LOGGED_IN",
+	0x150: "CKR_BUFFER_TOO_SMALL",
+	0x190: "CKR_CRYPTOKI_NOT_INITIALIZED",
+}
+
+func (e Error) Error() string {
+	if name, ok := errorNames[e]; ok {
+		return fmt.Sprintf("pkcs11: %s (0x%X)", name, uint(e))
+	}
+	return fmt.Sprintf("pkcs11: error 0x%X", uint(e))
+}
+
+// sessionLost returns whether the error means the session has to be opened
+// again, such as after the HSM was restarted
+func (e Error) sessionLost() bool {
+	switch e {
+	case 0x030, 0x032, 0x0B0, 0x0B3, 0x0E0, 0x101:
+		return true
+	}
+	return false
+}
+
+func toError(rv C.CK_RV) error {
+	if rv == C.CKR_OK {
+		return nil
+	}
+	return Error(rv)
+}
+
+// ModuleToken is a Token of an HSM accessed through its PKCS#11 library. The
+// operations are made on a single session, which is opened again when the
+// HSM loses it.
+type ModuleToken struct {
+	l sync.Mutex
+
+	handle    unsafe.Pointer
+	functions *C.CK_FUNCTION_LIST
+	slot      uint
+	pin       string
+
+	session    C.CK_SESSION_HANDLE
+	hasSession bool
+}
+
+// OpenModuleToken loads the PKCS#11 library at the path and logs into the
+// token of the slot
+func OpenModuleToken(lib string, slot uint, pin string) (Token, error) {
+	cLib := C.CString(lib)
+	defer C.free(unsafe.Pointer(cLib))
+
+	t := &ModuleToken{
+		slot: slot,
+		pin:  pin,
+	}
+
+	var rv C.CK_RV
+	var cErr *C.char
+	t.handle = C.pkcs11_open(cLib, &t.functions, &rv, &cErr)
+	if t.handle == nil {
+		if cErr != nil {
+			defer C.free(unsafe.Pointer(cErr))
+			return nil, fmt.Errorf("error loading the PKCS#11 library %q: %s", lib, C.GoString(cErr))
+		}
+		return nil, fmt.Errorf("error initializing the PKCS#11 library %q: %v", lib, Error(rv))
+	}
+
+	t.l.Lock()
+	defer t.l.Unlock()
+	if err := t.login(); err != nil {
+		C.pkcs11_close(t.handle, t.functions)
+		return nil, err
+	}
+	return t, nil
+}
+
+// FindKeys implements Token
+func (t *ModuleToken) FindKeys(label string) ([][]byte, error) {
+	var ids [][]byte
+	err := t.do(func() error {
+		handles, err := t.findKeys(label, nil)
+		if err != nil {
+			return err
+		}
+
+		ids = make([][]byte, 0, len(handles))
+		for _, handle := range handles {
+			var idLen C.CK_ULONG
+			if err := toError(C.pkcs11_get_id(t.functions, t.session, handle, nil, &idLen)); err != nil {
+				return err
+			}
+			if idLen == unavailableInformation {
+				idLen = 0
+			}
+			id := make([]byte, int(idLen))
+			if idLen > 0 {
+				if err := toError(C.pkcs11_get_id(t.functions, t.session, handle, bytesPtr(id), &idLen)); err != nil {
+					return err
+				}
+			}
+			ids = append(ids, id[:int(idLen)])
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// GenerateKey implements Token
+func (t *ModuleToken) GenerateKey(label string, id []byte) error {
+	return t.do(func() error {
+		return toError(C.pkcs11_generate_key(t.functions, t.session,
+			bytesPtr([]byte(label)), C.CK_ULONG(len(label)),
+			bytesPtr(id), C.CK_ULONG(len(id))))
+	})
+}
+
+// Encrypt implements Token
+func (t *ModuleToken) Encrypt(label string, id []byte, mechanism Mechanism, iv, plaintext []byte) ([]byte, error) {
+	// Padding and authentication tags add at most two blocks
+	return t.crypt(true, label, id, mechanism, iv, plaintext, len(plaintext)+32)
+}
+
+// Decrypt implements Token
+func (t *ModuleToken) Decrypt(label string, id []byte, mechanism Mechanism, iv, ciphertext []byte) ([]byte, error) {
+	return t.crypt(false, label, id, mechanism, iv, ciphertext, len(ciphertext))
+}
+
+// Close implements Token
+func (t *ModuleToken) Close() error {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	if t.handle == nil {
+		return nil
+	}
+	if t.hasSession {
+		C.pkcs11_logout(t.functions, t.session)
+		t.hasSession = false
+	}
+	C.pkcs11_close(t.handle, t.functions)
+	t.handle = nil
+	return nil
+}
+
+func (t *ModuleToken) crypt(encrypt bool, label string, id []byte, mechanism Mechanism, iv, in []byte, outLen int) ([]byte, error) {
+	if mechanism.ivSize() == 0 {
+		return nil, fmt.Errorf("unsupported mechanism %s", mechanism)
+	}
+
+	var out []byte
+	err := t.do(func() error {
+		handles, err := t.findKeys(label, id)
+		if err != nil {
+			return err
+		}
+		if len(handles) == 0 {
+			return fmt.Errorf("no key with label %q and ID %q", label, id)
+		}
+
+		encryptFlag := C.int(0)
+		if encrypt {
+			encryptFlag = 1
+		}
+		buf := make([]byte, outLen+1)
+		n := C.CK_ULONG(len(buf))
+		if err := toError(C.pkcs11_crypt(t.functions, t.session, encryptFlag,
+			C.CK_MECHANISM_TYPE(mechanism), handles[0],
+			bytesPtr(iv), C.CK_ULONG(len(iv)),
+			bytesPtr(in), C.CK_ULONG(len(in)),
+			bytesPtr(buf), &n)); err != nil {
+			return err
+		}
+		out = buf[:int(n)]
+		return nil
+	})
+	return out, err
+}
+
+// findKeys returns the handles of the keys with the label and, if given, the
+// ID. It must be called with the lock held.
+func (t *ModuleToken) findKeys(label string, id []byte) ([]C.CK_OBJECT_HANDLE, error) {
+	var idPtr *C.CK_BYTE
+	switch {
+	case len(id) > 0:
+		idPtr = bytesPtr(id)
+	case id != nil:
+		// An empty ID still has to be matched, so it needs a pointer
+		idPtr = bytesPtr([]byte{0})
+	}
+
+	handles := make([]C.CK_OBJECT_HANDLE, maxKeys)
+	var count C.CK_ULONG
+	if err := toError(C.pkcs11_find_keys(t.functions, t.session,
+		bytesPtr([]byte(label)), C.CK_ULONG(len(label)),
+		idPtr, C.CK_ULONG(len(id)),
+		&handles[0], C.CK_ULONG(len(handles)), &count)); err != nil {
+		return nil, err
+	}
+	return handles[:int(count)], nil
+}
+
+// do runs the operation on the session, logging in again and retrying once
+// if the session was lost
+func (t *ModuleToken) do(op func() error) error {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	if t.handle == nil {
+		return errors.New("the PKCS#11 token is closed")
+	}
+	if !t.hasSession {
+		if err := t.login(); err != nil {
+			return err
+		}
+	}
+
+	err := op()
+	if perr, ok := err.(Error); ok && perr.sessionLost() {
+		C.pkcs11_logout(t.functions, t.session)
+		t.hasSession = false
+		if err := t.login(); err != nil {
+			return err
+		}
+		err = op()
+	}
+	return err
+}
+
+// login opens a session on the slot and logs into it. It must be called with
+// the lock held.
+func (t *ModuleToken) login() error {
+	pin := []byte(t.pin)
+	if err := toError(C.pkcs11_login(t.functions, C.CK_SLOT_ID(t.slot), bytesPtr(pin), C.CK_ULONG(len(pin)), &t.session)); err != nil {
+		return fmt.Errorf("error logging into the token of slot %d: %v", t.slot, err)
+	}
+	t.hasSession = true
+	return nil
+}
+
+// bytesPtr returns a pointer to the data of b, or nil if b is empty
+func bytesPtr(b []byte) *C.CK_BYTE {
+	if len(b) == 0 {
+		return nil
+	}
+	return (*C.CK_BYTE)(unsafe.Pointer(&b[0]))
+}
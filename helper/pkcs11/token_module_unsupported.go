@@ -0,0 +1,12 @@
+// +build !cgo windows
+
+package pkcs11
+
+import "errors"
+
+// OpenModuleToken loads the PKCS#11 library at the path and logs into the
+// token of the slot. Loading a PKCS#11 library requires cgo, so this build
+// cannot.
+func OpenModuleToken(lib string, slot uint, pin string) (Token, error) {
+	return nil, errors.New("PKCS#11 is not supported by this build of Vault: it requires cgo")
+}
@@ -0,0 +1,224 @@
+// Package random generates random strings, such as passwords, that follow a
+// password policy.
+package random
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/helper/hclutil"
+)
+
+const (
+	// MaxLength is the longest password a policy may generate
+	MaxLength = 1024
+)
+
+// Policy is a password policy. Passwords are Length characters long, and
+// made of the characters of all of the charsets of the rules.
+type Policy struct {
+	Length int     `hcl:"length"`
+	Rules  []*Rule `hcl:"-"`
+}
+
+// Rule requires a password to contain at least MinChars characters of the
+// charset.
+type Rule struct {
+	Charset  string `hcl:"charset"`
+	MinChars int    `hcl:"min_chars"`
+}
+
+// ParsePolicy parses and validates a password policy in HCL, such as:
+//
+//	length = 20
+//	rule "charset" {
+//	  charset = "abcdefghijklmnopqrstuvwxyz"
+//	  min_chars = 1
+//	}
+func ParsePolicy(raw string) (*Policy, error) {
+	root, err := hcl.Parse(raw)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
+	}
+
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse policy: does not contain a root object")
+	}
+
+	if err := hclutil.CheckHCLKeys(list, []string{"length", "rule"}); err != nil {
+		return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
+	}
+
+	var p Policy
+	if err := hcl.DecodeObject(&p, list); err != nil {
+		return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
+	}
+
+	for _, item := range list.Filter("rule").Items {
+		if len(item.Keys) != 1 {
+			return nil, fmt.Errorf("failed to parse policy: rule on line %d must have a type", item.Assign.Line)
+		}
+		ruleType := item.Keys[0].Token.Value().(string)
+		if ruleType != "charset" {
+			return nil, fmt.Errorf("failed to parse policy: unknown rule type %q", ruleType)
+		}
+		if err := hclutil.CheckHCLKeys(item.Val, []string{"charset", "min_chars"}); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to parse policy: rule %q: {{err}}", ruleType), err)
+		}
+
+		var rule Rule
+		if err := hcl.DecodeObject(&rule, item.Val); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to parse policy: rule %q: {{err}}", ruleType), err)
+		}
+		p.Rules = append(p.Rules, &rule)
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	switch {
+	case p.Length <= 0:
+		return fmt.Errorf("length must be positive")
+	case p.Length > MaxLength:
+		return fmt.Errorf("length must be at most %d", MaxLength)
+	case len(p.Rules) == 0:
+		return fmt.Errorf("at least one rule is required")
+	}
+
+	minChars := 0
+	for _, rule := range p.Rules {
+		if rule.Charset == "" {
+			return fmt.Errorf("the charset of a rule must not be empty")
+		}
+		if !utf8.ValidString(rule.Charset) {
+			return fmt.Errorf("the charset of a rule must be valid UTF-8")
+		}
+		if rule.MinChars < 0 {
+			return fmt.Errorf("min_chars must not be negative")
+		}
+		minChars += rule.MinChars
+	}
+	if minChars > p.Length {
+		return fmt.Errorf("the rules require %d characters, more than the length of %d", minChars, p.Length)
+	}
+
+	return nil
+}
+
+// charset returns the distinct characters of all of the rules
+func (p *Policy) charset() []rune {
+	seen := map[rune]struct{}{}
+	var charset []rune
+	for _, rule := range p.Rules {
+		for _, r := range rule.Charset {
+			if _, ok := seen[r]; ok {
+				continue
+			}
+			seen[r] = struct{}{}
+			charset = append(charset, r)
+		}
+	}
+	return charset
+}
+
+// Generate returns a random password following the policy, using the given
+// source of randomness, or crypto/rand if it is nil.
+func (p *Policy) Generate(rng io.Reader) (string, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+
+	// The minimum characters of each rule come first, the rest are picked
+	// from all of the charsets, then everything is shuffled
+	password := make([]rune, 0, p.Length)
+	for _, rule := range p.Rules {
+		charset := []rune(rule.Charset)
+		for i := 0; i < rule.MinChars; i++ {
+			r, err := pick(rng, charset)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, r)
+		}
+	}
+
+	charset := p.charset()
+	for len(password) < p.Length {
+		r, err := pick(rng, charset)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, r)
+	}
+
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomInt(rng, i+1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+
+	return string(password), nil
+}
+
+// Check returns an error if the password does not follow the policy: it must
+// be at least Length characters long, be made of the characters of the
+// charsets of the rules only, and contain at least MinChars characters of the
+// charset of each rule. The errors do not include the password.
+func (p *Policy) Check(password string) error {
+	if n := utf8.RuneCountInString(password); n < p.Length {
+		return fmt.Errorf("password must be at least %d characters long", p.Length)
+	}
+
+	allowed := map[rune]struct{}{}
+	for _, r := range p.charset() {
+		allowed[r] = struct{}{}
+	}
+	for _, r := range password {
+		if _, ok := allowed[r]; !ok {
+			return fmt.Errorf("password contains characters the password policy does not allow")
+		}
+	}
+
+	for _, rule := range p.Rules {
+		count := 0
+		for _, r := range password {
+			if strings.ContainsRune(rule.Charset, r) {
+				count++
+			}
+		}
+		if count < rule.MinChars {
+			return fmt.Errorf("password must contain at least %d characters of %q", rule.MinChars, rule.Charset)
+		}
+	}
+	return nil
+}
+
+func pick(rng io.Reader, charset []rune) (rune, error) {
+	i, err := randomInt(rng, len(charset))
+	if err != nil {
+		return 0, err
+	}
+	return charset[i], nil
+}
+
+func randomInt(rng io.Reader, max int) (int, error) {
+	n, err := rand.Int(rng, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, errwrap.Wrapf("failed to generate random number: {{err}}", err)
+	}
+	return int(n.Int64()), nil
+}
@@ -0,0 +1,129 @@
+package random
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParsePolicy(t *testing.T) {
+	raw := `
+length = 20
+rule "charset" {
+  charset = "abcdefghijklmnopqrstuvwxyz"
+  min_chars = 1
+}
+rule "charset" {
+  charset = "0123456789"
+  min_chars = 2
+}
+`
+	p, err := ParsePolicy(raw)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if p.Length != 20 {
+		t.Fatalf("bad length: %d", p.Length)
+	}
+	if len(p.Rules) != 2 {
+		t.Fatalf("bad rules: %#v", p.Rules)
+	}
+	if p.Rules[1].Charset != "0123456789" || p.Rules[1].MinChars != 2 {
+		t.Fatalf("bad rule: %#v", p.Rules[1])
+	}
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"no length":      `rule "charset" { charset = "abc" }`,
+		"too long":       `length = 2000 rule "charset" { charset = "abc" }`,
+		"no rules":       `length = 10`,
+		"empty charset":  `length = 10 rule "charset" { charset = "" }`,
+		"unknown rule":   `length = 10 rule "other" { charset = "abc" }`,
+		"unknown key":    `length = 10 foo = "bar" rule "charset" { charset = "abc" }`,
+		"too many chars": `length = 2 rule "charset" { charset = "abc" min_chars = 3 }`,
+		"invalid hcl":    `length = `,
+	}
+
+	for name, raw := range cases {
+		if _, err := ParsePolicy(raw); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPolicy_Generate(t *testing.T) {
+	p := &Policy{
+		Length: 12,
+		Rules: []*Rule{
+			{Charset: "abcdefghijklmnopqrstuvwxyz", MinChars: 4},
+			{Charset: "0123456789", MinChars: 4},
+			{Charset: "!@#$", MinChars: 4},
+		},
+	}
+	if err := p.validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		password, err := p.Generate(nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if n := utf8.RuneCountInString(password); n != p.Length {
+			t.Fatalf("bad length %d: %q", n, password)
+		}
+		for _, rule := range p.Rules {
+			count := 0
+			for _, r := range password {
+				if strings.ContainsRune(rule.Charset, r) {
+					count++
+				}
+			}
+			if count < rule.MinChars {
+				t.Fatalf("%q has %d characters of %q, expected at least %d", password, count, rule.Charset, rule.MinChars)
+			}
+		}
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	p := &Policy{
+		Length: 8,
+		Rules: []*Rule{
+			{Charset: "abcdefghijklmnopqrstuvwxyz", MinChars: 1},
+			{Charset: "0123456789", MinChars: 2},
+		},
+	}
+
+	for _, password := range []string{"abcdef12", "abcdefghijk123"} {
+		if err := p.Check(password); err != nil {
+			t.Fatalf("%q: err: %v", password, err)
+		}
+	}
+
+	cases := map[string]string{
+		"too short":         "abc12",
+		"disallowed chars":  "abcdef12!",
+		"missing min chars": "abcdefg1",
+	}
+	for name, password := range cases {
+		err := p.Check(password)
+		if err == nil {
+			t.Fatalf("%s: expected an error for %q", name, password)
+		}
+		if strings.Contains(err.Error(), password) {
+			t.Fatalf("%s: error contains the password: %v", name, err)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		password, err := p.Generate(nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := p.Check(password); err != nil {
+			t.Fatalf("generated password %q: err: %v", password, err)
+		}
+	}
+}
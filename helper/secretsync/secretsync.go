@@ -0,0 +1,622 @@
+// Package secretsync pushes secrets to external secret stores, for workloads
+// which cannot read them from Vault: Kubernetes secrets, AWS Secrets Manager
+// and GCP Secret Manager. The stores are reached through their REST APIs.
+package secretsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/awsutil"
+	"github.com/hashicorp/vault/helper/useragent"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// ManagedByLabel and ManagedByValue label the secrets created in the
+	// stores, so that they can be told apart from those managed otherwise
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByValue = "vault"
+
+	// maxResponseSize caps the size of the responses read from the stores
+	maxResponseSize = 4 << 20
+)
+
+// Destination is an external secret store secrets are pushed to. Secrets are
+// sets of key/value pairs.
+type Destination interface {
+	// Put creates or replaces the secret of the given name
+	Put(ctx context.Context, name string, data map[string]string) error
+
+	// Get returns the secret of the given name, or nil if there is none
+	Get(ctx context.Context, name string) (map[string]string, error)
+
+	// Delete removes the secret of the given name. Deleting a missing
+	// secret is not an error.
+	Delete(ctx context.Context, name string) error
+}
+
+// Equal returns whether two secrets have the same key/value pairs
+func Equal(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Name returns the name of a secret in the stores from a prefix and the
+// path of the secret in Vault. Letters are lowercased and runs of other
+// characters than letters and digits become a dash, which gives names valid
+// in all of the stores.
+func Name(prefix, path string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(prefix + path) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// apiError is an error status returned by the API of a store
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Message)
+}
+
+// isNotFound returns whether the error is a not found status of an API
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// doJSON sends a request with a JSON body, if any, and decodes the JSON
+// response into out, if not nil. Statuses other than 2xx are returned as an
+// *apiError, with the message decoded by errMessage if given.
+func doJSON(client *http.Client, req *http.Request, out interface{}, errMessage func([]byte) string) error {
+	req.Header.Set("User-Agent", useragent.String())
+	if req.Header.Get("Content-Type") == "" && req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &apiError{StatusCode: resp.StatusCode}
+		if errMessage != nil {
+			apiErr.Message = errMessage(body)
+		}
+		return apiErr
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return errwrap.Wrapf("failed to decode response: {{err}}", err)
+	}
+	return nil
+}
+
+// newJSONRequest returns a request of the given method to the URL, with in
+// encoded as its JSON body if not nil
+func newJSONRequest(ctx context.Context, method, u string, in interface{}) (*http.Request, []byte, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req.WithContext(ctx), body, nil
+}
+
+// KubernetesConfig is the configuration of a destination pushing secrets as
+// Kubernetes secrets of a namespace
+type KubernetesConfig struct {
+	// Host is the URL of the API server of the cluster
+	Host string
+
+	// Token is the bearer token of a service account allowed to get,
+	// create, update and delete the secrets of the namespace
+	Token string
+
+	// Namespace is the namespace of the secrets, "default" if empty
+	Namespace string
+
+	// HTTPClient is the client reaching the API server, trusting its CA
+	// certificate. A default client is used if nil.
+	HTTPClient *http.Client
+}
+
+// kubernetesDestination pushes secrets as Opaque secrets, with a key for each
+// of the key/value pairs
+type kubernetesDestination struct {
+	client    *http.Client
+	host      string
+	token     string
+	namespace string
+}
+
+// kubernetesSecret is the subset of a Kubernetes secret the destination
+// reads and writes
+type kubernetesSecret struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   kubernetesMetadata `json:"metadata"`
+	Type       string             `json:"type,omitempty"`
+	Data       map[string][]byte  `json:"data"`
+}
+
+type kubernetesMetadata struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// NewKubernetesDestination returns a destination pushing secrets to a
+// namespace of a Kubernetes cluster
+func NewKubernetesDestination(conf *KubernetesConfig) (Destination, error) {
+	if conf.Host == "" {
+		return nil, fmt.Errorf("kubernetes_host must be set")
+	}
+	if _, err := url.Parse(conf.Host); err != nil {
+		return nil, errwrap.Wrapf("invalid kubernetes_host: {{err}}", err)
+	}
+	if conf.Token == "" {
+		return nil, fmt.Errorf("kubernetes_token must be set")
+	}
+
+	d := &kubernetesDestination{
+		client:    conf.HTTPClient,
+		host:      strings.TrimSuffix(conf.Host, "/"),
+		token:     conf.Token,
+		namespace: conf.Namespace,
+	}
+	if d.client == nil {
+		d.client = cleanhttp.DefaultClient()
+	}
+	if d.namespace == "" {
+		d.namespace = "default"
+	}
+	return d, nil
+}
+
+func (d *kubernetesDestination) url(name string) string {
+	u := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets", d.host, url.PathEscape(d.namespace))
+	if name != "" {
+		u += "/" + url.PathEscape(name)
+	}
+	return u
+}
+
+func (d *kubernetesDestination) do(ctx context.Context, method, u string, in, out interface{}) error {
+	req, _, err := newJSONRequest(ctx, method, u, in)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.token)
+	return doJSON(d.client, req, out, func(body []byte) string {
+		var status struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &status)
+		return status.Message
+	})
+}
+
+// Put replaces the secret, or creates it if it does not exist yet
+func (d *kubernetesDestination) Put(ctx context.Context, name string, data map[string]string) error {
+	secret := &kubernetesSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: kubernetesMetadata{
+			Name:      name,
+			Namespace: d.namespace,
+			Labels: map[string]string{
+				ManagedByLabel: ManagedByValue,
+			},
+		},
+		Type: "Opaque",
+		Data: make(map[string][]byte, len(data)),
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+
+	err := d.do(ctx, http.MethodPut, d.url(name), secret, nil)
+	if isNotFound(err) {
+		err = d.do(ctx, http.MethodPost, d.url(""), secret, nil)
+	}
+	return err
+}
+
+func (d *kubernetesDestination) Get(ctx context.Context, name string) (map[string]string, error) {
+	var secret kubernetesSecret
+	err := d.do(ctx, http.MethodGet, d.url(name), nil, &secret)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	return data, nil
+}
+
+func (d *kubernetesDestination) Delete(ctx context.Context, name string) error {
+	err := d.do(ctx, http.MethodDelete, d.url(name), nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// AWSSecretsManagerConfig is the configuration of a destination pushing
+// secrets to AWS Secrets Manager
+type AWSSecretsManagerConfig struct {
+	Region    string
+	AccessKey string
+	SecretKey string
+
+	// Endpoint overrides the regional endpoint of the service
+	Endpoint string
+
+	// HTTPClient is the client reaching the service. A default client is
+	// used if nil.
+	HTTPClient *http.Client
+}
+
+// awsSecretsManagerDestination pushes secrets as JSON objects in the secret
+// string of AWS secrets
+type awsSecretsManagerDestination struct {
+	client   *http.Client
+	signer   *v4.Signer
+	region   string
+	endpoint string
+}
+
+// NewAWSSecretsManagerDestination returns a destination pushing secrets to
+// AWS Secrets Manager. Credentials not given in the configuration are looked
+// up in the environment.
+func NewAWSSecretsManagerDestination(conf *AWSSecretsManagerConfig) (Destination, error) {
+	region := conf.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	d := &awsSecretsManagerDestination{
+		client:   conf.HTTPClient,
+		region:   region,
+		endpoint: strings.TrimSuffix(conf.Endpoint, "/"),
+	}
+	if d.client == nil {
+		d.client = cleanhttp.DefaultClient()
+	}
+	if d.endpoint == "" {
+		d.endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:  conf.AccessKey,
+		SecretKey:  conf.SecretKey,
+		Region:     region,
+		HTTPClient: d.client,
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+	d.signer = v4.NewSigner(creds)
+
+	return d, nil
+}
+
+// awsError is the body of the errors of the service
+type awsError struct {
+	Type         string `json:"__type"`
+	Message      string `json:"message"`
+	MessageUpper string `json:"Message"`
+}
+
+// call invokes an action of the JSON API of the service
+func (d *awsSecretsManagerDestination) call(ctx context.Context, action string, in, out interface{}) error {
+	req, body, err := newJSONRequest(ctx, http.MethodPost, d.endpoint+"/", in)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager."+action)
+	if _, err := d.signer.Sign(req, bytes.NewReader(body), "secretsmanager", d.region, time.Now()); err != nil {
+		return errwrap.Wrapf("failed to sign request: {{err}}", err)
+	}
+
+	return doJSON(d.client, req, out, func(body []byte) string {
+		var awsErr awsError
+		json.Unmarshal(body, &awsErr)
+		message := awsErr.Message
+		if message == "" {
+			message = awsErr.MessageUpper
+		}
+		if i := strings.LastIndex(awsErr.Type, "#"); i >= 0 {
+			awsErr.Type = awsErr.Type[i+1:]
+		}
+		switch {
+		case awsErr.Type == "":
+			return message
+		case message == "":
+			return awsErr.Type
+		default:
+			return awsErr.Type + ": " + message
+		}
+	})
+}
+
+// isAWSNotFound returns whether the error is that the secret does not exist.
+// The service reports it with a 400 status.
+func isAWSNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && strings.HasPrefix(apiErr.Message, "ResourceNotFoundException")
+}
+
+// Put stores a new version of the secret, or creates it if it does not exist
+// yet
+func (d *awsSecretsManagerDestination) Put(ctx context.Context, name string, data map[string]string) error {
+	secretString, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	err = d.call(ctx, "PutSecretValue", map[string]interface{}{
+		"SecretId":     name,
+		"SecretString": string(secretString),
+	}, nil)
+	if isAWSNotFound(err) {
+		err = d.call(ctx, "CreateSecret", map[string]interface{}{
+			"Name":         name,
+			"SecretString": string(secretString),
+			"Tags": []map[string]string{
+				{"Key": ManagedByLabel, "Value": ManagedByValue},
+			},
+		}, nil)
+	}
+	return err
+}
+
+func (d *awsSecretsManagerDestination) Get(ctx context.Context, name string) (map[string]string, error) {
+	var out struct {
+		SecretString string `json:"SecretString"`
+	}
+	err := d.call(ctx, "GetSecretValue", map[string]interface{}{
+		"SecretId": name,
+	}, &out)
+	if isAWSNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A secret string which is not a JSON object of strings was not
+	// written by Vault, and differs from any secret it pushes
+	data := make(map[string]string)
+	if err := json.Unmarshal([]byte(out.SecretString), &data); err != nil {
+		return map[string]string{}, nil
+	}
+	return data, nil
+}
+
+// Delete deletes the secret without a recovery window, so that it can be
+// created again right away
+func (d *awsSecretsManagerDestination) Delete(ctx context.Context, name string) error {
+	err := d.call(ctx, "DeleteSecret", map[string]interface{}{
+		"SecretId":                   name,
+		"ForceDeleteWithoutRecovery": true,
+	}, nil)
+	if isAWSNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// GCPSecretManagerConfig is the configuration of a destination pushing
+// secrets to GCP Secret Manager
+type GCPSecretManagerConfig struct {
+	ProjectID string
+
+	// Credentials is the JSON key of a service account. The application
+	// default credentials are used if empty.
+	Credentials string
+
+	// Endpoint overrides the endpoint of the service
+	Endpoint string
+
+	// HTTPClient is the client reaching the service and the token
+	// endpoint. A default client is used if nil.
+	HTTPClient *http.Client
+}
+
+// gcpSecretManagerDestination pushes secrets as JSON objects in the payload
+// of the versions of GCP secrets
+type gcpSecretManagerDestination struct {
+	client    *http.Client
+	projectID string
+	endpoint  string
+}
+
+// NewGCPSecretManagerDestination returns a destination pushing secrets to
+// GCP Secret Manager
+func NewGCPSecretManagerDestination(conf *GCPSecretManagerConfig) (Destination, error) {
+	if conf.ProjectID == "" {
+		return nil, fmt.Errorf("gcp_project_id must be set")
+	}
+
+	baseClient := conf.HTTPClient
+	if baseClient == nil {
+		baseClient = cleanhttp.DefaultClient()
+	}
+	// The token source keeps the context to refresh the tokens
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, baseClient)
+
+	scope := "https://www.googleapis.com/auth/cloud-platform"
+	var tokenSource oauth2.TokenSource
+	if conf.Credentials != "" {
+		creds, err := google.CredentialsFromJSON(ctx, []byte(conf.Credentials), scope)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to parse credentials: {{err}}", err)
+		}
+		tokenSource = creds.TokenSource
+	} else {
+		var err error
+		tokenSource, err = google.DefaultTokenSource(ctx, scope)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to find default credentials: {{err}}", err)
+		}
+	}
+
+	d := &gcpSecretManagerDestination{
+		client:    oauth2.NewClient(ctx, tokenSource),
+		projectID: conf.ProjectID,
+		endpoint:  strings.TrimSuffix(conf.Endpoint, "/"),
+	}
+	if d.endpoint == "" {
+		d.endpoint = "https://secretmanager.googleapis.com"
+	}
+	return d, nil
+}
+
+// gcpPayload is the payload of a version of a secret
+type gcpPayload struct {
+	Data []byte `json:"data"`
+}
+
+func (d *gcpSecretManagerDestination) url(name, suffix string) string {
+	u := fmt.Sprintf("%s/v1/projects/%s/secrets", d.endpoint, url.PathEscape(d.projectID))
+	if name != "" {
+		u += "/" + url.PathEscape(name)
+	}
+	return u + suffix
+}
+
+func (d *gcpSecretManagerDestination) do(ctx context.Context, method, u string, in, out interface{}) error {
+	req, _, err := newJSONRequest(ctx, method, u, in)
+	if err != nil {
+		return err
+	}
+	return doJSON(d.client, req, out, func(body []byte) string {
+		var status struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(body, &status)
+		return status.Error.Message
+	})
+}
+
+// Put adds a new version to the secret, creating the secret first if it does
+// not exist yet
+func (d *gcpSecretManagerDestination) Put(ctx context.Context, name string, data map[string]string) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	version := map[string]interface{}{
+		"payload": &gcpPayload{Data: payload},
+	}
+
+	err = d.do(ctx, http.MethodPost, d.url(name, ":addVersion"), version, nil)
+	if !isNotFound(err) {
+		return err
+	}
+
+	err = d.do(ctx, http.MethodPost, d.url("", "?secretId="+url.QueryEscape(name)), map[string]interface{}{
+		"replication": map[string]interface{}{
+			"automatic": map[string]interface{}{},
+		},
+		"labels": map[string]string{
+			"managed-by": ManagedByValue,
+		},
+	}, nil)
+	if err != nil {
+		return errwrap.Wrapf("failed to create secret: {{err}}", err)
+	}
+	return d.do(ctx, http.MethodPost, d.url(name, ":addVersion"), version, nil)
+}
+
+func (d *gcpSecretManagerDestination) Get(ctx context.Context, name string) (map[string]string, error) {
+	var out struct {
+		Payload gcpPayload `json:"payload"`
+	}
+	err := d.do(ctx, http.MethodGet, d.url(name, "/versions/latest:access"), nil, &out)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// See the AWS Secrets Manager destination
+	data := make(map[string]string)
+	if err := json.Unmarshal(out.Payload.Data, &data); err != nil {
+		return map[string]string{}, nil
+	}
+	return data, nil
+}
+
+func (d *gcpSecretManagerDestination) Delete(ctx context.Context, name string) error {
+	err := d.do(ctx, http.MethodDelete, d.url(name, ""), nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
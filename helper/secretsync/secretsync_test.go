@@ -0,0 +1,134 @@
+package secretsync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	cases := map[string]string{
+		"secret/app/db":      "vault-secret-app-db",
+		"ns1/kv/App_DB//key": "vault-ns1-kv-app-db-key",
+		"secret/-leading":    "vault-secret-leading",
+	}
+	for path, expected := range cases {
+		if name := Name("vault-", path); name != expected {
+			t.Fatalf("bad name of %q: expected %q, got %q", path, expected, name)
+		}
+	}
+	if name := Name("", "/secret/app/"); name != "secret-app" {
+		t.Fatalf("bad name: %q", name)
+	}
+}
+
+func TestAWSSecretsManagerDestination(t *testing.T) {
+	secrets := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIDEXAMPLE/") || !strings.Contains(r.Header.Get("Authorization"), "/secretsmanager/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var in struct {
+			Name         string
+			SecretId     string
+			SecretString string
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		notFound := func() {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","Message":"Secrets Manager can't find the specified secret."}`))
+		}
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.GetSecretValue":
+			value, ok := secrets[in.SecretId]
+			if !ok {
+				notFound()
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"SecretString": value})
+		case "secretsmanager.PutSecretValue":
+			if _, ok := secrets[in.SecretId]; !ok {
+				notFound()
+				return
+			}
+			secrets[in.SecretId] = in.SecretString
+		case "secretsmanager.CreateSecret":
+			secrets[in.Name] = in.SecretString
+		case "secretsmanager.DeleteSecret":
+			if _, ok := secrets[in.SecretId]; !ok {
+				notFound()
+				return
+			}
+			delete(secrets, in.SecretId)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	d, err := NewAWSSecretsManagerDestination(&AWSSecretsManagerConfig{
+		Region:    "eu-west-1",
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "secret",
+		Endpoint:  server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	data, err := d.Get(ctx, "vault-secret-app")
+	if err != nil || data != nil {
+		t.Fatalf("bad: data: %#v, err: %v", data, err)
+	}
+
+	// The secret is created, then updated
+	for _, password := range []string{"hunter2", "correct horse"} {
+		if err := d.Put(ctx, "vault-secret-app", map[string]string{"password": password}); err != nil {
+			t.Fatal(err)
+		}
+		data, err = d.Get(ctx, "vault-secret-app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(data, map[string]string{"password": password}) {
+			t.Fatalf("bad: %#v", data)
+		}
+	}
+
+	// Secrets not written by Vault differ from any secret
+	secrets["vault-secret-app"] = "plain text"
+	data, err = d.Get(ctx, "vault-secret-app")
+	if err != nil || data == nil || len(data) != 0 {
+		t.Fatalf("bad: data: %#v, err: %v", data, err)
+	}
+
+	// Deleting is idempotent
+	for i := 0; i < 2; i++ {
+		if err := d.Delete(ctx, "vault-secret-app"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(secrets) != 0 {
+		t.Fatalf("bad: %#v", secrets)
+	}
+
+	// Error statuses are reported
+	d, err = NewAWSSecretsManagerDestination(&AWSSecretsManagerConfig{
+		AccessKey: "other",
+		SecretKey: "secret",
+		Endpoint:  server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Get(ctx, "vault-secret-app"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected a forbidden error, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+// Package snapshotstore stores the snapshots of the storage backend taken on
+// a schedule, in a local directory or in a cloud storage bucket.
+package snapshotstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/awsutil"
+	"github.com/hashicorp/vault/helper/useragent"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// Store is where snapshots are kept
+type Store interface {
+	// Put stores a snapshot under the given name
+	Put(ctx context.Context, name string, r io.Reader) error
+
+	// List returns the names of the stored snapshots, sorted
+	List(ctx context.Context) ([]string, error)
+
+	// Delete removes the snapshot with the given name
+	Delete(ctx context.Context, name string) error
+}
+
+// localStore keeps snapshots as files in a directory
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore returns a store keeping snapshots in the given directory of
+// the server, which is created if needed
+func NewLocalStore(dir string) (Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("local_path must be set")
+	}
+	return &localStore{dir: dir}, nil
+}
+
+// Put writes the snapshot to a temporary file first, so that a snapshot file
+// is always complete
+func (s *localStore) Put(ctx context.Context, name string, r io.Reader) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(s.dir, name+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(s.dir, name))
+}
+
+func (s *localStore) List(ctx context.Context) ([]string, error) {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, info := range infos {
+		if info.Mode().IsRegular() && !strings.Contains(info.Name(), ".tmp") {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *localStore) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(s.dir, name))
+}
+
+// S3Config is the configuration of a store in an AWS S3 bucket
+type S3Config struct {
+	Bucket    string
+	Prefix    string
+	Region    string
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+}
+
+// s3Store keeps snapshots as objects in an S3 bucket
+type s3Store struct {
+	client *s3.S3
+	bucket string
+	prefix string
+}
+
+// NewS3Store returns a store keeping snapshots in an S3 bucket. Credentials
+// not given in the configuration are looked up in the environment.
+func NewS3Store(conf *S3Config) (Store, error) {
+	if conf.Bucket == "" {
+		return nil, fmt.Errorf("aws_s3_bucket must be set")
+	}
+	region := conf.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey: conf.AccessKey,
+		SecretKey: conf.SecretKey,
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := &aws.Config{
+		Credentials: creds,
+		HTTPClient:  cleanhttp.DefaultClient(),
+		Region:      aws.String(region),
+	}
+	if conf.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(conf.Endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	return &s3Store{
+		client: s3.New(session.New(awsConfig)),
+		bucket: conf.Bucket,
+		prefix: conf.Prefix,
+	}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, name string, r io.Reader) error {
+	// PutObject needs a seekable body, so the snapshot is buffered in a
+	// temporary file rather than in memory
+	f, err := ioutil.TempFile("", name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err = s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + name),
+		Body:   f,
+	})
+	return err
+}
+
+func (s *s3Store) List(ctx context.Context) ([]string, error) {
+	var names []string
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			name := strings.TrimPrefix(aws.StringValue(object.Key), s.prefix)
+			if name != "" && !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, name string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + name),
+	})
+	return err
+}
+
+// GCSConfig is the configuration of a store in a Google Cloud Storage bucket
+type GCSConfig struct {
+	Bucket          string
+	Prefix          string
+	CredentialsFile string
+}
+
+// gcsStore keeps snapshots as objects in a GCS bucket
+type gcsStore struct {
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+// NewGCSStore returns a store keeping snapshots in a GCS bucket. Without a
+// credentials file, the application default credentials are used.
+func NewGCSStore(ctx context.Context, conf *GCSConfig) (Store, error) {
+	if conf.Bucket == "" {
+		return nil, fmt.Errorf("google_gcs_bucket must be set")
+	}
+
+	opts := []option.ClientOption{option.WithUserAgent(useragent.String())}
+	if conf.CredentialsFile != "" {
+		opts = append(opts, option.WithServiceAccountFile(conf.CredentialsFile))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to create storage client: {{err}}", err)
+	}
+
+	return &gcsStore{
+		client: client,
+		bucket: conf.Bucket,
+		prefix: conf.Prefix,
+	}, nil
+}
+
+func (s *gcsStore) Put(ctx context.Context, name string, r io.Reader) error {
+	w := s.client.Bucket(s.bucket).Object(s.prefix + name).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (s *gcsStore) List(ctx context.Context) ([]string, error) {
+	var names []string
+	it := s.client.Bucket(s.bucket).Objects(ctx, &storage.Query{
+		Prefix: s.prefix,
+	})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(attrs.Name, s.prefix)
+		if name != "" && !strings.Contains(name, "/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *gcsStore) Delete(ctx context.Context, name string) error {
+	return s.client.Bucket(s.bucket).Object(s.prefix + name).Delete(ctx)
+}
@@ -0,0 +1,162 @@
+// Package srvutil resolves the addresses written as DNS SRV names, such as
+// dnssrv+_consul._tcp.consul.example.com, to the targets of their records.
+// The targets are resolved again when none of them can be reached, so that
+// the servers behind a name can move without changing the configuration or
+// restarting.
+package srvutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// Prefix marks an address as a DNS SRV name
+const Prefix = "dnssrv+"
+
+// IsSRV returns whether the address is a DNS SRV name
+func IsSRV(addr string) bool {
+	return strings.HasPrefix(addr, Prefix)
+}
+
+// LookupFunc looks up the SRV records of a name, as net.Resolver.LookupSRV
+// does with an empty service and protocol
+type LookupFunc func(ctx context.Context, name string) ([]*net.SRV, error)
+
+func lookupSRV(ctx context.Context, name string) ([]*net.SRV, error) {
+	_, addrs, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	return addrs, err
+}
+
+// Resolver resolves a DNS SRV name and caches its targets until they fail
+type Resolver struct {
+	name   string
+	domain string
+	lookup LookupFunc
+
+	l       sync.Mutex
+	targets []string
+}
+
+// NewResolver returns a resolver of the address, which must be a DNS SRV
+// name of the form dnssrv+_service._proto.domain
+func NewResolver(addr string) (*Resolver, error) {
+	return NewResolverWithLookup(addr, lookupSRV)
+}
+
+// NewResolverWithLookup returns a resolver of the address looking up its
+// records with the given function
+func NewResolverWithLookup(addr string, lookup LookupFunc) (*Resolver, error) {
+	if !IsSRV(addr) {
+		return nil, fmt.Errorf("address %q is not a DNS SRV name starting with %q", addr, Prefix)
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(addr, Prefix), ".")
+
+	labels := strings.SplitN(name, ".", 3)
+	if len(labels) != 3 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") || labels[2] == "" {
+		return nil, fmt.Errorf("DNS SRV name %q must be of the form _service._proto.domain", name)
+	}
+
+	return &Resolver{
+		name:   name,
+		domain: labels[2],
+		lookup: lookup,
+	}, nil
+}
+
+// Name returns the DNS SRV name, without the prefix
+func (r *Resolver) Name() string {
+	return r.name
+}
+
+// Domain returns the domain of the name, without its service and protocol
+// labels. It is the name the servers are expected to present in their
+// certificates.
+func (r *Resolver) Domain() string {
+	return r.domain
+}
+
+// Targets returns the host:port targets of the records, ordered by priority
+// and, for equal priorities, by decreasing weight. They are looked up if they
+// are not cached.
+func (r *Resolver) Targets(ctx context.Context) ([]string, error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.targets != nil {
+		return r.targets, nil
+	}
+
+	records, err := r.lookup(ctx, r.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the SRV records of %q: %v", r.name, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no SRV records found for %q", r.name)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Priority != records[j].Priority {
+			return records[i].Priority < records[j].Priority
+		}
+		return records[i].Weight > records[j].Weight
+	})
+
+	targets := make([]string, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		targets = append(targets, net.JoinHostPort(host, strconv.Itoa(int(record.Port))))
+	}
+	r.targets = targets
+
+	return targets, nil
+}
+
+// Invalidate forgets the cached targets, so that the next call to Targets
+// looks them up again
+func (r *Resolver) Invalidate() {
+	r.l.Lock()
+	r.targets = nil
+	r.l.Unlock()
+}
+
+// DialContext returns a function dialing the targets in order with the
+// dialer, ignoring the address it is given. When none of the targets can be
+// reached, they are looked up again and dialed once more.
+func (r *Resolver) DialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, _ string) (net.Conn, error) {
+		conn, err := r.dialTargets(ctx, dialer, network)
+		if err == nil || ctx.Err() != nil {
+			return conn, err
+		}
+
+		r.Invalidate()
+		return r.dialTargets(ctx, dialer, network)
+	}
+}
+
+func (r *Resolver) dialTargets(ctx context.Context, dialer *net.Dialer, network string) (net.Conn, error) {
+	targets, err := r.Targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var retErr *multierror.Error
+	for _, target := range targets {
+		conn, err := dialer.DialContext(ctx, network, target)
+		if err == nil {
+			return conn, nil
+		}
+		retErr = multierror.Append(retErr, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, retErr.ErrorOrNil()
+}
@@ -0,0 +1,119 @@
+package srvutil
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewResolver(t *testing.T) {
+	r, err := NewResolver("dnssrv+_consul._tcp.consul.example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name() != "_consul._tcp.consul.example.com" || r.Domain() != "consul.example.com" {
+		t.Fatalf("bad: name %q domain %q", r.Name(), r.Domain())
+	}
+
+	for _, addr := range []string{
+		"consul.example.com:8500",
+		"dnssrv+consul.example.com",
+		"dnssrv+_consul.consul.example.com",
+		"dnssrv+_consul._tcp",
+	} {
+		if _, err := NewResolver(addr); err == nil {
+			t.Fatalf("expected %q to be refused", addr)
+		}
+	}
+}
+
+func TestResolver_Targets(t *testing.T) {
+	var lookups int32
+	r, err := NewResolverWithLookup("dnssrv+_etcd._tcp.example.com", func(ctx context.Context, name string) ([]*net.SRV, error) {
+		atomic.AddInt32(&lookups, 1)
+		return []*net.SRV{
+			{Target: "c.example.com.", Port: 2379, Priority: 20, Weight: 10},
+			{Target: "a.example.com.", Port: 2379, Priority: 10, Weight: 10},
+			{Target: "b.example.com.", Port: 2380, Priority: 10, Weight: 50},
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"b.example.com:2380", "a.example.com:2379", "c.example.com:2379"}
+	for i := 0; i < 2; i++ {
+		targets, err := r.Targets(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(targets, expected) {
+			t.Fatalf("bad: %v", targets)
+		}
+	}
+	if lookups != 1 {
+		t.Fatalf("expected the targets to be cached, got %d lookups", lookups)
+	}
+
+	r.Invalidate()
+	if _, err := r.Targets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if lookups != 2 {
+		t.Fatalf("expected the targets to be looked up again, got %d lookups", lookups)
+	}
+}
+
+func TestResolver_DialContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	// A closed port stands in for a server which moved
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	var lookups int32
+	r, err := NewResolverWithLookup("dnssrv+_vault._tcp.example.com", func(ctx context.Context, name string) ([]*net.SRV, error) {
+		if atomic.AddInt32(&lookups, 1) == 1 {
+			return []*net.SRV{{Target: "127.0.0.1.", Port: uint16(closedPort)}}, nil
+		}
+		return []*net.SRV{{Target: "127.0.0.1.", Port: uint16(port)}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dial := r.DialContext(&net.Dialer{})
+	conn, err := dial(context.Background(), "tcp", "ignored:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if conn.RemoteAddr().String() != net.JoinHostPort("127.0.0.1", strconv.Itoa(port)) {
+		t.Fatalf("bad: %v", conn.RemoteAddr())
+	}
+	if lookups != 2 {
+		t.Fatalf("expected the targets to be looked up again, got %d lookups", lookups)
+	}
+}
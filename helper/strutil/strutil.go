@@ -325,3 +325,18 @@ func AppendIfMissing(slice []string, i string) []string {
 	}
 	return append(slice, i)
 }
+
+// Page sorts the given strings in place and returns at most limit of those
+// sorting after the given one. A limit of zero or less returns all of them.
+func Page(items []string, after string, limit int) []string {
+	sort.Strings(items)
+	if after != "" {
+		items = items[sort.Search(len(items), func(i int) bool {
+			return items[i] > after
+		}):]
+	}
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
@@ -423,3 +423,29 @@ func TestStrUtil_RemoveDuplicates(t *testing.T) {
 		}
 	}
 }
+
+func TestStrUtil_Page(t *testing.T) {
+	type tCase struct {
+		input  []string
+		after  string
+		limit  int
+		expect []string
+	}
+
+	tCases := []tCase{
+		tCase{[]string{}, "", 0, []string{}},
+		tCase{[]string{"c", "a/", "b"}, "", 0, []string{"a/", "b", "c"}},
+		tCase{[]string{"c", "a/", "b"}, "", 2, []string{"a/", "b"}},
+		tCase{[]string{"c", "a/", "b"}, "a/", 0, []string{"b", "c"}},
+		tCase{[]string{"c", "a/", "b"}, "a", 1, []string{"a/"}},
+		tCase{[]string{"c", "a/", "b"}, "c", 1, []string{}},
+	}
+
+	for _, tc := range tCases {
+		actual := Page(tc.input, tc.after, tc.limit)
+
+		if !reflect.DeepEqual(actual, tc.expect) {
+			t.Fatalf("Bad testcase %#v, expected %v, got %v", tc, tc.expect, actual)
+		}
+	}
+}
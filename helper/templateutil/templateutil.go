@@ -0,0 +1,55 @@
+// Package templateutil renders the templates of fields computed from the
+// fields of a secret, such as a connection URL assembled from a host, a
+// username and a password.
+package templateutil
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// Parse parses the template of a field. The fields of the secret are
+// referenced as {{.name}}, or {{index . "name"}} for names which are not
+// identifiers, and the functions of text/template such as urlquery are
+// available. Referencing a field the secret does not have is an error.
+func Parse(name, tpl string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(tpl)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("invalid template for field %q: ", name)+"{{err}}", err)
+	}
+	return t, nil
+}
+
+// Render renders the template of a field with the fields of a secret
+func Render(name, tpl string, data map[string]interface{}) (string, error) {
+	t, err := Parse(name, tpl)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", errwrap.Wrapf(fmt.Sprintf("failed to render field %q: ", name)+"{{err}}", err)
+	}
+	return buf.String(), nil
+}
+
+// RenderAll renders the templates of the given fields with the fields of a
+// secret. Fields whose template fails to render are left out, and the errors
+// returned as warnings.
+func RenderAll(templates map[string]string, data map[string]interface{}) (map[string]interface{}, []string) {
+	result := make(map[string]interface{}, len(templates))
+	var warnings []string
+	for name, tpl := range templates {
+		value, err := Render(name, tpl, data)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		result[name] = value
+	}
+	return result, warnings
+}
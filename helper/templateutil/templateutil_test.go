@@ -0,0 +1,53 @@
+package templateutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"host": "db.example.com", "port": 5432, "username": "app", "password": "p@ss/word", "db-name": "orders"}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"{{.host}}:{{.port}}": "db.example.com:5432",
+		`jdbc:postgresql://{{.host}}:{{.port}}/{{index . "db-name"}}?user={{.username}}&password={{urlquery .password}}`: "jdbc:postgresql://db.example.com:5432/orders?user=app&password=p%40ss%2Fword",
+		"static": "static",
+	}
+	for tpl, expected := range cases {
+		actual, err := Render("url", tpl, data)
+		if err != nil {
+			t.Fatalf("%s: %v", tpl, err)
+		}
+		if actual != expected {
+			t.Fatalf("%s: expected %q, got %q", tpl, expected, actual)
+		}
+	}
+
+	if _, err := Render("url", "{{.host", data); err == nil || !strings.Contains(err.Error(), `invalid template for field "url"`) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	if _, err := Render("url", "{{.hostname}}", data); err == nil || !strings.Contains(err.Error(), `failed to render field "url"`) {
+		t.Fatalf("expected a missing field error, got %v", err)
+	}
+}
+
+func TestRenderAll(t *testing.T) {
+	data := map[string]interface{}{"host": "localhost", "port": "8200"}
+	templates := map[string]string{
+		"address": "https://{{.host}}:{{.port}}",
+		"invalid": "{{.missing}}",
+	}
+
+	result, warnings := RenderAll(templates, data)
+	if !reflect.DeepEqual(result, map[string]interface{}{"address": "https://localhost:8200"}) {
+		t.Fatalf("bad result: %#v", result)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"invalid"`) {
+		t.Fatalf("bad warnings: %#v", warnings)
+	}
+}
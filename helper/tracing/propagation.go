@@ -0,0 +1,180 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+)
+
+// The headers propagating traces
+const (
+	HeaderB3TraceID      = "X-B3-TraceId"
+	HeaderB3SpanID       = "X-B3-SpanId"
+	HeaderB3ParentSpanID = "X-B3-ParentSpanId"
+	HeaderB3Sampled      = "X-B3-Sampled"
+	HeaderB3Flags        = "X-B3-Flags"
+	HeaderB3             = "b3"
+	HeaderJaeger         = "uber-trace-id"
+)
+
+// SpanContext is the trace an inbound request belongs to, read from its
+// headers
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+
+	// Sampled is the sampling decision of the trace, or nil if the caller
+	// left it to Vault
+	Sampled *bool
+}
+
+// Extract reads the span context of the B3 headers of Zipkin, either the
+// multiple headers or the single one, or of the uber-trace-id header of
+// Jaeger. It returns an empty span context if the headers carry none or
+// are malformed.
+func Extract(h http.Header) SpanContext {
+	if value := h.Get(HeaderB3); value != "" {
+		return extractB3Single(value)
+	}
+	if value := h.Get(HeaderJaeger); value != "" {
+		return extractJaeger(value)
+	}
+
+	var sc SpanContext
+	switch strings.ToLower(h.Get(HeaderB3Sampled)) {
+	case "1", "true":
+		sc.Sampled = boolPtr(true)
+	case "0", "false":
+		sc.Sampled = boolPtr(false)
+	}
+	// The debug flag implies the trace is sampled
+	if h.Get(HeaderB3Flags) == "1" {
+		sc.Sampled = boolPtr(true)
+	}
+
+	traceID, spanID := h.Get(HeaderB3TraceID), h.Get(HeaderB3SpanID)
+	if validID(traceID, 16, 32) && validID(spanID, 16, 16) {
+		sc.TraceID = strings.ToLower(traceID)
+		sc.SpanID = strings.ToLower(spanID)
+	}
+	return sc
+}
+
+// extractB3Single reads the b3 header, either a sampling decision alone or
+// {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}, the last two being
+// optional
+func extractB3Single(value string) SpanContext {
+	var sc SpanContext
+	parts := strings.Split(value, "-")
+	if len(parts) == 1 {
+		sc.Sampled = parseB3SamplingState(parts[0])
+		return sc
+	}
+	if len(parts) > 4 || !validID(parts[0], 16, 32) || !validID(parts[1], 16, 16) {
+		return sc
+	}
+
+	sc.TraceID = strings.ToLower(parts[0])
+	sc.SpanID = strings.ToLower(parts[1])
+	if len(parts) > 2 {
+		sc.Sampled = parseB3SamplingState(parts[2])
+	}
+	return sc
+}
+
+func parseB3SamplingState(state string) *bool {
+	switch state {
+	case "1", "d":
+		return boolPtr(true)
+	case "0":
+		return boolPtr(false)
+	default:
+		return nil
+	}
+}
+
+// extractJaeger reads the uber-trace-id header,
+// {trace-id}:{span-id}:{parent-span-id}:{flags}, whose IDs may omit their
+// leading zeros
+func extractJaeger(value string) SpanContext {
+	var sc SpanContext
+	parts := strings.Split(value, ":")
+	if len(parts) != 4 {
+		return sc
+	}
+
+	traceID, spanID := padID(parts[0]), padID(parts[1])
+	if !validID(traceID, 16, 32) || !validID(spanID, 16, 16) {
+		return sc
+	}
+	sc.TraceID = strings.ToLower(traceID)
+	sc.SpanID = strings.ToLower(spanID)
+
+	// The sampled bit of the flags, which are hex encoded
+	flags := parts[3]
+	if flags != "" && isHex(flags) {
+		last := flags[len(flags)-1]
+		var bit byte
+		switch {
+		case last >= '0' && last <= '9':
+			bit = last - '0'
+		case last >= 'a' && last <= 'f':
+			bit = last - 'a' + 10
+		default:
+			bit = last - 'A' + 10
+		}
+		sc.Sampled = boolPtr(bit&1 == 1)
+	}
+	return sc
+}
+
+// Inject writes the B3 headers of the given span, so that a request made
+// on its behalf continues its trace
+func Inject(span *Span, h http.Header) {
+	if span == nil {
+		return
+	}
+	h.Set(HeaderB3TraceID, span.TraceID)
+	h.Set(HeaderB3SpanID, span.ID)
+	if span.ParentID != "" {
+		h.Set(HeaderB3ParentSpanID, span.ParentID)
+	} else {
+		h.Del(HeaderB3ParentSpanID)
+	}
+	h.Set(HeaderB3Sampled, "1")
+	h.Del(HeaderB3)
+	h.Del(HeaderJaeger)
+}
+
+// padID pads an ID to 16 or 32 hex characters with leading zeros
+func padID(id string) string {
+	switch {
+	case len(id) > 0 && len(id) < 16:
+		return strings.Repeat("0", 16-len(id)) + id
+	case len(id) > 16 && len(id) < 32:
+		return strings.Repeat("0", 32-len(id)) + id
+	default:
+		return id
+	}
+}
+
+func validID(id string, minLen, maxLen int) bool {
+	if len(id) != minLen && len(id) != maxLen {
+		return false
+	}
+	return isHex(id) && strings.Trim(id, "0") != ""
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'f', r >= 'A' && r <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
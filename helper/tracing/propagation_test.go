@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	cases := map[string]struct {
+		headers  map[string]string
+		expected SpanContext
+	}{
+		"none": {
+			nil,
+			SpanContext{},
+		},
+		"b3": {
+			map[string]string{
+				"X-B3-TraceId": "463ac35c9f6413ad48485a3953bb6124",
+				"X-B3-SpanId":  "A2FB4A1D1A96D312",
+				"X-B3-Sampled": "1",
+			},
+			SpanContext{
+				TraceID: "463ac35c9f6413ad48485a3953bb6124",
+				SpanID:  "a2fb4a1d1a96d312",
+				Sampled: boolPtr(true),
+			},
+		},
+		"b3 not sampled": {
+			map[string]string{
+				"X-B3-Sampled": "0",
+			},
+			SpanContext{
+				Sampled: boolPtr(false),
+			},
+		},
+		"b3 debug": {
+			map[string]string{
+				"X-B3-TraceId": "48485a3953bb6124",
+				"X-B3-SpanId":  "a2fb4a1d1a96d312",
+				"X-B3-Flags":   "1",
+			},
+			SpanContext{
+				TraceID: "48485a3953bb6124",
+				SpanID:  "a2fb4a1d1a96d312",
+				Sampled: boolPtr(true),
+			},
+		},
+		"b3 malformed": {
+			map[string]string{
+				"X-B3-TraceId": "not an id",
+				"X-B3-SpanId":  "a2fb4a1d1a96d312",
+			},
+			SpanContext{},
+		},
+		"b3 single": {
+			map[string]string{
+				"b3": "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90",
+			},
+			SpanContext{
+				TraceID: "80f198ee56343ba864fe8b2a57d3eff7",
+				SpanID:  "e457b5a2e4d86bd1",
+				Sampled: boolPtr(true),
+			},
+		},
+		"b3 single without sampling": {
+			map[string]string{
+				"b3": "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1",
+			},
+			SpanContext{
+				TraceID: "80f198ee56343ba864fe8b2a57d3eff7",
+				SpanID:  "e457b5a2e4d86bd1",
+			},
+		},
+		"b3 single sampling only": {
+			map[string]string{
+				"b3": "0",
+			},
+			SpanContext{
+				Sampled: boolPtr(false),
+			},
+		},
+		"jaeger": {
+			map[string]string{
+				"uber-trace-id": "7f3a2b1c:e457b5a2e4d86bd1:0:1",
+			},
+			SpanContext{
+				TraceID: "000000007f3a2b1c",
+				SpanID:  "e457b5a2e4d86bd1",
+				Sampled: boolPtr(true),
+			},
+		},
+		"jaeger not sampled": {
+			map[string]string{
+				"uber-trace-id": "80f198ee56343ba864fe8b2a57d3eff7:e457b5a2e4d86bd1:0:2",
+			},
+			SpanContext{
+				TraceID: "80f198ee56343ba864fe8b2a57d3eff7",
+				SpanID:  "e457b5a2e4d86bd1",
+				Sampled: boolPtr(false),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := make(http.Header)
+			for k, v := range tc.headers {
+				h.Set(k, v)
+			}
+			if actual := Extract(h); !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("bad: expected %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	span := &Span{
+		TraceID:  "80f198ee56343ba864fe8b2a57d3eff7",
+		ID:       "e457b5a2e4d86bd1",
+		ParentID: "05e3ac9a4f6e3b90",
+	}
+
+	h := make(http.Header)
+	h.Set("b3", "0")
+	Inject(span, h)
+
+	sc := Extract(h)
+	if sc.TraceID != span.TraceID || sc.SpanID != span.ID || sc.Sampled == nil || !*sc.Sampled {
+		t.Fatalf("bad span context: %#v", sc)
+	}
+	if h.Get(HeaderB3ParentSpanID) != span.ParentID {
+		t.Fatalf("bad parent span ID: %q", h.Get(HeaderB3ParentSpanID))
+	}
+
+	// A nil span leaves the headers alone
+	h = make(http.Header)
+	Inject(nil, h)
+	if len(h) != 0 {
+		t.Fatalf("expected no headers, got %#v", h)
+	}
+}
@@ -0,0 +1,213 @@
+// Package tracing records the spans of the requests Vault serves, such as the
+// dispatch of the router or the calls to the storage, and reports them to a
+// tracing system such as Zipkin. The traces of inbound requests are continued
+// from their Zipkin B3 or Jaeger headers.
+//
+// Spans are carried by the contexts of the requests. A request without a
+// span, because tracing is disabled or the trace is not sampled, starts no
+// spans, and the methods of a nil *Span do nothing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	mathrand "math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Span is a timed operation of a trace
+type Span struct {
+	TraceID  string
+	ID       string
+	ParentID string
+	Name     string
+	Kind     string
+	Start    time.Time
+	Duration time.Duration
+
+	tracer *Tracer
+
+	l        sync.Mutex
+	tags     map[string]string
+	finished bool
+}
+
+// Span kinds, in the terms of Zipkin
+const (
+	KindServer = "SERVER"
+	KindClient = "CLIENT"
+)
+
+// SetTag sets a tag of the span
+func (s *Span) SetTag(key, value string) {
+	if s == nil {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	if s.tags == nil {
+		s.tags = make(map[string]string)
+	}
+	s.tags[key] = value
+}
+
+// Tags returns a copy of the tags of the span
+func (s *Span) Tags() map[string]string {
+	if s == nil {
+		return nil
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	tags := make(map[string]string, len(s.tags))
+	for key, value := range s.tags {
+		tags[key] = value
+	}
+	return tags
+}
+
+// Finish records the duration of the span and reports it. Only the first
+// call has an effect.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.l.Lock()
+	if s.finished {
+		s.l.Unlock()
+		return
+	}
+	s.finished = true
+	s.Duration = time.Since(s.Start)
+	s.l.Unlock()
+
+	s.tracer.reporter.Report(s)
+}
+
+// Reporter sends the finished spans to a tracing system
+type Reporter interface {
+	Report(*Span)
+	Close() error
+}
+
+// Tracer starts the root spans of the requests
+type Tracer struct {
+	reporter   Reporter
+	sampleRate float64
+}
+
+// NewTracer returns a tracer reporting spans to the given reporter. The
+// sample rate, between 0 and 1, is the share of the requests which are traced
+// when they don't carry the sampling decision of a trace.
+func NewTracer(reporter Reporter, sampleRate float64) *Tracer {
+	return &Tracer{
+		reporter:   reporter,
+		sampleRate: sampleRate,
+	}
+}
+
+// StartRootSpan starts a span continuing the trace of the given parent, or a
+// new trace if parent is empty. It returns nil if the trace is not sampled.
+func (t *Tracer) StartRootSpan(ctx context.Context, name string, parent SpanContext) (*Span, context.Context) {
+	if t == nil {
+		return nil, ctx
+	}
+
+	sampled := parent.Sampled
+	if sampled == nil {
+		decision := t.sampleRate >= 1 || mathrand.Float64() < t.sampleRate
+		sampled = &decision
+	}
+	if !*sampled {
+		return nil, ctx
+	}
+
+	span := &Span{
+		TraceID:  parent.TraceID,
+		ID:       newID(8),
+		ParentID: parent.SpanID,
+		Name:     name,
+		Start:    time.Now(),
+		tracer:   t,
+	}
+	if span.TraceID == "" {
+		span.TraceID = newID(16)
+	}
+	return span, ContextWithSpan(ctx, span)
+}
+
+// Close closes the reporter of the tracer
+func (t *Tracer) Close() error {
+	if t == nil {
+		return nil
+	}
+	return t.reporter.Close()
+}
+
+// global holds the tracer of the server, set with SetTracer
+var global atomic.Value
+
+func init() {
+	global.Store((*Tracer)(nil))
+}
+
+// SetTracer sets the tracer starting the root spans of the requests the
+// server handles. A nil tracer disables tracing.
+func SetTracer(t *Tracer) {
+	global.Store(t)
+}
+
+// GetTracer returns the tracer set with SetTracer
+func GetTracer() *Tracer {
+	return global.Load().(*Tracer)
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns a context carrying the given span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span carried by the context, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// StartSpan starts a child of the span carried by the context, and returns
+// it along with a context carrying it. If the context carries no span, it
+// returns nil and the context.
+func StartSpan(ctx context.Context, name string) (*Span, context.Context) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return nil, ctx
+	}
+
+	span := &Span{
+		TraceID:  parent.TraceID,
+		ID:       newID(8),
+		ParentID: parent.ID,
+		Name:     name,
+		Start:    time.Now(),
+		tracer:   parent.tracer,
+	}
+	return span, ContextWithSpan(ctx, span)
+}
+
+// newID returns a random ID of the given number of bytes, hex encoded
+func newID(size int) string {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		mathrand.Read(b)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type testReporter struct {
+	l     sync.Mutex
+	spans []*Span
+}
+
+func (r *testReporter) Report(span *Span) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func (r *testReporter) Close() error {
+	return nil
+}
+
+func TestTracer_StartRootSpan(t *testing.T) {
+	reporter := &testReporter{}
+	tracer := NewTracer(reporter, 1)
+
+	// A new trace
+	span, ctx := tracer.StartRootSpan(context.Background(), "root", SpanContext{})
+	if span == nil {
+		t.Fatal("expected a span")
+	}
+	if len(span.TraceID) != 32 || len(span.ID) != 16 || span.ParentID != "" {
+		t.Fatalf("bad span: %#v", span)
+	}
+	if SpanFromContext(ctx) != span {
+		t.Fatal("expected the context to carry the span")
+	}
+
+	// Continuing a trace
+	parent := SpanContext{
+		TraceID: "463ac35c9f6413ad48485a3953bb6124",
+		SpanID:  "a2fb4a1d1a96d312",
+	}
+	span, _ = tracer.StartRootSpan(context.Background(), "root", parent)
+	if span.TraceID != parent.TraceID || span.ParentID != parent.SpanID {
+		t.Fatalf("bad span: %#v", span)
+	}
+
+	// The sampling decision of the caller wins over the rate
+	parent.Sampled = boolPtr(false)
+	if span, _ := tracer.StartRootSpan(context.Background(), "root", parent); span != nil {
+		t.Fatalf("expected no span, got %#v", span)
+	}
+	tracer = NewTracer(reporter, 0)
+	if span, _ := tracer.StartRootSpan(context.Background(), "root", SpanContext{}); span != nil {
+		t.Fatalf("expected no span, got %#v", span)
+	}
+	parent.Sampled = boolPtr(true)
+	if span, _ := tracer.StartRootSpan(context.Background(), "root", parent); span == nil {
+		t.Fatal("expected a span")
+	}
+
+	// A nil tracer traces nothing
+	tracer = nil
+	if span, _ := tracer.StartRootSpan(context.Background(), "root", SpanContext{}); span != nil {
+		t.Fatalf("expected no span, got %#v", span)
+	}
+}
+
+func TestStartSpan(t *testing.T) {
+	reporter := &testReporter{}
+	tracer := NewTracer(reporter, 1)
+
+	// No span without a traced request
+	span, _ := StartSpan(context.Background(), "child")
+	if span != nil {
+		t.Fatalf("expected no span, got %#v", span)
+	}
+	span.SetTag("key", "value")
+	span.Finish()
+
+	root, ctx := tracer.StartRootSpan(context.Background(), "root", SpanContext{})
+	child, _ := StartSpan(ctx, "child")
+	if child.TraceID != root.TraceID || child.ParentID != root.ID || child.ID == root.ID {
+		t.Fatalf("bad span: %#v", child)
+	}
+	child.SetTag("key", "value")
+	child.Finish()
+	child.Finish()
+	root.Finish()
+
+	if len(reporter.spans) != 2 {
+		t.Fatalf("expected two spans, got %d", len(reporter.spans))
+	}
+	if reporter.spans[0] != child || reporter.spans[1] != root {
+		t.Fatalf("bad spans: %#v", reporter.spans)
+	}
+	if tags := child.Tags(); len(tags) != 1 || tags["key"] != "value" {
+		t.Fatalf("bad tags: %#v", tags)
+	}
+}
@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/jsonutil"
+)
+
+const (
+	// zipkinBatchSize is the number of spans sent to Zipkin at once
+	zipkinBatchSize = 100
+
+	// zipkinQueueSize is the number of spans waiting to be sent to Zipkin,
+	// beyond which spans are dropped rather than slowing down the requests
+	zipkinQueueSize = 1000
+
+	// zipkinFlushInterval is how often the spans are sent to Zipkin
+	zipkinFlushInterval = time.Second
+)
+
+// ZipkinReporter sends the spans in batches to the v2 HTTP API of Zipkin, such
+// as http://zipkin:9411/api/v2/spans
+type ZipkinReporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+	logger      log.Logger
+
+	spans   chan *Span
+	closeCh chan struct{}
+	doneCh  chan struct{}
+	once    sync.Once
+}
+
+// NewZipkinReporter returns a reporter sending the spans of the given service
+// to the given URL
+func NewZipkinReporter(url, serviceName string, logger log.Logger) *ZipkinReporter {
+	r := &ZipkinReporter{
+		url:         url,
+		serviceName: serviceName,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logger:  logger,
+		spans:   make(chan *Span, zipkinQueueSize),
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Report queues the span to be sent, dropping it if the queue is full
+func (r *ZipkinReporter) Report(span *Span) {
+	select {
+	case r.spans <- span:
+	default:
+		r.logger.Trace("dropping span, the queue is full", "name", span.Name)
+	}
+}
+
+// Close sends the queued spans and stops the reporter
+func (r *ZipkinReporter) Close() error {
+	r.once.Do(func() {
+		close(r.closeCh)
+	})
+	<-r.doneCh
+	return nil
+}
+
+func (r *ZipkinReporter) run() {
+	defer close(r.doneCh)
+
+	ticker := time.NewTicker(zipkinFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, zipkinBatchSize)
+	for {
+		select {
+		case span := <-r.spans:
+			batch = append(batch, span)
+			if len(batch) < zipkinBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-r.closeCh:
+			for len(r.spans) > 0 {
+				batch = append(batch, <-r.spans)
+			}
+			r.send(batch)
+			return
+		}
+
+		r.send(batch)
+		batch = batch[:0]
+	}
+}
+
+// zipkinSpan is a span in the JSON of the v2 API of Zipkin, whose times are
+// in microseconds
+type zipkinSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind,omitempty"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint zipkinEndpoint    `json:"localEndpoint"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+func (r *ZipkinReporter) send(batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+
+	spans := make([]zipkinSpan, 0, len(batch))
+	for _, span := range batch {
+		duration := span.Duration.Nanoseconds() / int64(time.Microsecond)
+		if duration < 1 {
+			duration = 1
+		}
+		spans = append(spans, zipkinSpan{
+			TraceID:   span.TraceID,
+			ID:        span.ID,
+			ParentID:  span.ParentID,
+			Name:      span.Name,
+			Kind:      span.Kind,
+			Timestamp: span.Start.UnixNano() / int64(time.Microsecond),
+			Duration:  duration,
+			LocalEndpoint: zipkinEndpoint{
+				ServiceName: r.serviceName,
+			},
+			Tags: span.Tags(),
+		})
+	}
+
+	body, err := jsonutil.EncodeJSON(spans)
+	if err != nil {
+		r.logger.Error("error encoding spans", "error", err)
+		return
+	}
+	if err := r.post(body); err != nil {
+		r.logger.Warn("error sending spans to zipkin", "spans", len(spans), "error", err)
+	}
+}
+
+func (r *ZipkinReporter) post(body []byte) error {
+	req, err := http.NewRequest("POST", r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+)
+
+func TestZipkinReporter(t *testing.T) {
+	var l sync.Mutex
+	var received []zipkinSpan
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v2/spans" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var spans []zipkinSpan
+		if err := json.NewDecoder(r.Body).Decode(&spans); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		l.Lock()
+		received = append(received, spans...)
+		l.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	reporter := NewZipkinReporter(ts.URL+"/api/v2/spans", "vault", logging.NewVaultLogger(log.Trace))
+	tracer := NewTracer(reporter, 1)
+
+	root, ctx := tracer.StartRootSpan(context.Background(), "http.request", SpanContext{})
+	root.Kind = KindServer
+	child, _ := StartSpan(ctx, "router.route")
+	child.SetTag("mount", "secret/")
+	child.Finish()
+	root.Finish()
+
+	// Closing the reporter sends the queued spans
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("expected two spans, got %#v", received)
+	}
+	r, c := received[1], received[0]
+	if r.Name != "http.request" || r.Kind != KindServer || r.TraceID != root.TraceID || r.ID != root.ID || r.ParentID != "" {
+		t.Fatalf("bad root span: %#v", r)
+	}
+	if c.Name != "router.route" || c.TraceID != root.TraceID || c.ParentID != root.ID || c.Tags["mount"] != "secret/" {
+		t.Fatalf("bad child span: %#v", c)
+	}
+	if r.LocalEndpoint.ServiceName != "vault" || r.Timestamp == 0 || r.Duration == 0 {
+		t.Fatalf("bad root span: %#v", r)
+	}
+}
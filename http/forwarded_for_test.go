@@ -171,6 +171,82 @@ func TestHandler_XForwardedFor(t *testing.T) {
 		}
 	})
 
+	// Next: test rejecting values that are not IP addresses
+	t.Run("invalid_address", func(t *testing.T) {
+		t.Parallel()
+		testHandler := func(props *vault.HandlerProperties) http.Handler {
+			origHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(r.RemoteAddr))
+			})
+			return WrapForwardedForHandler(origHandler, []*sockaddr.SockAddrMarshaler{
+				&sockaddr.SockAddrMarshaler{
+					SockAddr: goodAddr,
+				},
+			}, true, true, 0)
+		}
+
+		cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{
+			HandlerFunc: testHandler,
+		})
+		cluster.Start()
+		defer cluster.Cleanup()
+		client := cluster.Cores[0].Client
+
+		req := client.NewRequest("GET", "/")
+		req.Headers = make(http.Header)
+		req.Headers.Set("x-forwarded-for", "2.3.4.5, unknown")
+		_, err := client.RawRequest(req)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), "expected an IP address") {
+			t.Fatalf("bad error message: %v", err)
+		}
+	})
+
+	t.Run("address_with_port", func(t *testing.T) {
+		t.Parallel()
+		testHandler := func(props *vault.HandlerProperties) http.Handler {
+			origHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(r.RemoteAddr))
+			})
+			return WrapForwardedForHandler(origHandler, []*sockaddr.SockAddrMarshaler{
+				&sockaddr.SockAddrMarshaler{
+					SockAddr: goodAddr,
+				},
+			}, true, true, 0)
+		}
+
+		cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{
+			HandlerFunc: testHandler,
+		})
+		cluster.Start()
+		defer cluster.Cleanup()
+		client := cluster.Cores[0].Client
+
+		for header, expected := range map[string]string{
+			"1.2.3.4:5678": "1.2.3.4:",
+			"[::1]:443":    "[::1]:",
+			"::1":          "[::1]:",
+		} {
+			req := client.NewRequest("GET", "/")
+			req.Headers = make(http.Header)
+			req.Headers.Set("x-forwarded-for", "2.3.4.5, "+header)
+			resp, err := client.RawRequest(req)
+			if err != nil {
+				t.Fatalf("%s: %v", header, err)
+			}
+			buf := bytes.NewBuffer(nil)
+			buf.ReadFrom(resp.Body)
+			resp.Body.Close()
+			if !strings.HasPrefix(buf.String(), expected) {
+				t.Fatalf("%s: bad body: %s", header, buf.String())
+			}
+		}
+	})
+
 	// Next: test picking correct value
 	t.Run("correct_hop_skipping", func(t *testing.T) {
 		t.Parallel()
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -18,6 +20,8 @@ import (
 
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/audit"
+	auditFile "github.com/hashicorp/vault/builtin/audit/file"
 	credCert "github.com/hashicorp/vault/builtin/credential/cert"
 	"github.com/hashicorp/vault/builtin/logical/transit"
 	"github.com/hashicorp/vault/helper/keysutil"
@@ -581,3 +585,222 @@ func TestHTTP_Forwarding_HelpOperation(t *testing.T) {
 	testHelp(cores[0].Client)
 	testHelp(cores[1].Client)
 }
+
+func TestHTTP_PerfStandby(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"transit": transit.Factory,
+		},
+		PerformanceStandby: true,
+	}
+
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	cores := cluster.Cores
+
+	// make it easy to get access to the active
+	core := cores[0].Core
+	vault.TestWaitActive(t, core)
+
+	if _, err := cores[0].Client.Logical().Write("secret/foo", map[string]interface{}{
+		"bar": "baz",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	standby := cores[1]
+	start := time.Now()
+	for !standby.Core.PerfStandby() {
+		if time.Now().Sub(start) > 10*time.Second {
+			t.Fatal("standby did not become a performance standby")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Don't follow redirects, so that we can tell whether the standby
+	// serviced the request
+	transport := &http.Transport{
+		TLSClientConfig: cores[0].TLSConfig,
+	}
+	if err := http2.ConfigureTransport(transport); err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	addr := fmt.Sprintf("https://127.0.0.1:%d", standby.Listeners[0].Address.Port)
+
+	doReq := func(method, path string, body io.Reader, noForward bool) *http.Response {
+		req, err := http.NewRequest(method, addr+path, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(AuthHeaderName, cluster.RootToken)
+		if noForward {
+			req.Header.Set(NoRequestForwardingHeaderName, "true")
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Reads are serviced locally
+	resp := doReq("GET", "/v1/secret/foo", nil, true)
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	if actual["data"].(map[string]interface{})["bar"] != "baz" {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// Writes need the active node
+	resp = doReq("PUT", "/v1/secret/foo", strings.NewReader(`{"bar":"qux"}`), true)
+	testResponseStatus(t, resp, 307)
+
+	// With forwarding allowed the write is sent to the active node
+	resp = doReq("PUT", "/v1/secret/foo", strings.NewReader(`{"bar":"qux"}`), false)
+	testResponseStatus(t, resp, 204)
+
+	resp = doReq("GET", "/v1/secret/foo", nil, true)
+	actual = nil
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	if actual["data"].(map[string]interface{})["bar"] != "qux" {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// System paths are always forwarded
+	resp = doReq("GET", "/v1/sys/mounts", nil, true)
+	testResponseStatus(t, resp, 307)
+	resp = doReq("GET", "/v1/sys/mounts", nil, false)
+	testResponseStatus(t, resp, 200)
+}
+
+func TestHTTP_PerfStandby_Audit(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"transit": transit.Factory,
+		},
+		AuditBackends: map[string]audit.Factory{
+			"file": auditFile.Factory,
+		},
+		PerformanceStandby: true,
+	}
+
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	cores := cluster.Cores
+
+	core := cores[0].Core
+	vault.TestWaitActive(t, core)
+
+	auditFilePath := filepath.Join(cluster.TempDir, "audit.log")
+	if err := cores[0].Client.Sys().EnableAuditWithOptions("file", &api.EnableAuditOptions{
+		Type: "file",
+		Options: map[string]string{
+			"file_path": auditFilePath,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cores[0].Client.Sys().Mount("transit", &api.MountInput{
+		Type: "transit",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cores[0].Client.Logical().Write("secret/foo", map[string]interface{}{
+		"bar": "baz",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The nodes share the audit device, so the entries of a request forwarded
+	// by the standby would be in the file twice if both audited it. An empty
+	// operation matches any.
+	countEntries := func(entryType, operation, path string) int {
+		t.Helper()
+		raw, err := ioutil.ReadFile(auditFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n int
+		for _, line := range strings.Split(string(raw), "\n") {
+			if line == "" {
+				continue
+			}
+			var entry struct {
+				Type    string `json:"type"`
+				Request struct {
+					Operation string `json:"operation"`
+					Path      string `json:"path"`
+				} `json:"request"`
+			}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatal(err)
+			}
+			if entry.Type == entryType && (operation == "" || entry.Request.Operation == operation) && entry.Request.Path == path {
+				n++
+			}
+		}
+		return n
+	}
+
+	standby := cores[1]
+	client, err := standby.Client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(cluster.RootToken)
+
+	// Wait for the standby to serve reads with the audit device set up
+	start := time.Now()
+	for {
+		if standby.Core.PerfStandby() {
+			if _, err := client.Logical().Read("secret/foo"); err != nil {
+				t.Fatal(err)
+			}
+			if countEntries("request", "read", "secret/foo") > 0 {
+				break
+			}
+		}
+		if time.Now().Sub(start) > 30*time.Second {
+			t.Fatal("standby did not audit as a performance standby")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Writes to the key/value store are forwarded without being attempted
+	if _, err := client.Logical().Write("secret/foo", map[string]interface{}{
+		"bar": "qux",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n := countEntries("request", "update", "secret/foo"); n != 1 {
+		t.Fatalf("expected the write to be audited once, got %d entries", n)
+	}
+
+	// Encrypting with a new key creates it, which fails on the standby and
+	// is forwarded
+	if _, err := client.Logical().Write("transit/encrypt/foo", map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString([]byte("the quick brown fox")),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n := countEntries("request", "", "transit/encrypt/foo"); n != 1 {
+		t.Fatalf("expected the encryption to be audited once, got %d request entries", n)
+	}
+	if n := countEntries("response", "", "transit/encrypt/foo"); n != 1 {
+		t.Fatalf("expected the encryption to be audited once, got %d response entries", n)
+	}
+}
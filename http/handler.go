@@ -1,16 +1,19 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/textproto"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +25,7 @@ import (
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/helper/tracing"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/vault"
 )
@@ -54,11 +58,34 @@ const (
 	// soft-mandatory Sentinel policies.
 	PolicyOverrideHeaderName = "X-Vault-Policy-Override"
 
+	// NamespaceHeaderName is the header set to make a request within a
+	// namespace; it is equivalent to prefixing the request path with the
+	// namespace path.
+	NamespaceHeaderName = "X-Vault-Namespace"
+
+	// RequestHeaderName is the header the Vault clients set on every request.
+	// Unlike a form submission or a redirect, a forged request cannot carry
+	// it, so listeners may require it to guard against SSRF.
+	RequestHeaderName = "X-Vault-Request"
+
+	// ReasonHeaderName is the header carrying the justification for a
+	// request, which is recorded in the audit log
+	ReasonHeaderName = "X-Vault-Reason"
+
+	// IdempotencyKeyHeaderName is the header carrying the nonce that makes
+	// retries of a request return the lease registered for the first one
+	IdempotencyKeyHeaderName = "X-Vault-Idempotency-Key"
+
 	// DefaultMaxRequestSize is the default maximum accepted request size. This
 	// is to prevent a denial of service attack where no Content-Length is
 	// provided and the server is fed ever more data until it exhausts memory.
 	// Can be overridden per listener.
 	DefaultMaxRequestSize = 32 * 1024 * 1024
+
+	// DefaultReservedConcurrentRequests is the default number of requests to
+	// the operator endpoints of sys/ served beyond the concurrent requests
+	// limit of a listener
+	DefaultReservedConcurrentRequests = 10
 )
 
 var (
@@ -72,16 +99,21 @@ var (
 // its own to mount the Vault API within another web server.
 func Handler(props *vault.HandlerProperties) http.Handler {
 	core := props.Core
+	if core.RecoveryMode() {
+		return recoveryHandler(props)
+	}
 
 	// Create the muxer to handle the actual endpoints
 	mux := http.NewServeMux()
 	mux.Handle("/v1/sys/init", handleSysInit(core))
-	mux.Handle("/v1/sys/seal-status", handleSysSealStatus(core))
+	mux.Handle("/v1/sys/seal-status", handleSysSealStatus(core, props.HideUnauthenticatedDetails))
 	mux.Handle("/v1/sys/seal", handleSysSeal(core))
 	mux.Handle("/v1/sys/step-down", handleRequestForwarding(core, handleSysStepDown(core)))
-	mux.Handle("/v1/sys/unseal", handleSysUnseal(core))
+	mux.Handle("/v1/sys/unseal", handleSysUnseal(core, props.HideUnauthenticatedDetails))
 	mux.Handle("/v1/sys/leader", handleSysLeader(core))
-	mux.Handle("/v1/sys/health", handleSysHealth(core))
+	mux.Handle("/v1/sys/monitor", handleSysMonitor(core))
+	mux.Handle("/v1/sys/events/subscribe/", handleSysEventsSubscribe(core))
+	mux.Handle("/v1/sys/health", handleSysHealth(core, props.HideUnauthenticatedDetails))
 	mux.Handle("/v1/sys/generate-root/attempt", handleRequestForwarding(core, handleSysGenerateRootAttempt(core, vault.GenerateStandardRootTokenStrategy)))
 	mux.Handle("/v1/sys/generate-root/update", handleRequestForwarding(core, handleSysGenerateRootUpdate(core, vault.GenerateStandardRootTokenStrategy)))
 	mux.Handle("/v1/sys/rekey/init", handleRequestForwarding(core, handleSysRekeyInit(core, false)))
@@ -97,8 +129,8 @@ func Handler(props *vault.HandlerProperties) http.Handler {
 		mux.Handle(path, handleRequestForwarding(core, handleLogical(core, true, nil)))
 	}
 	mux.Handle("/v1/sys/", handleRequestForwarding(core, handleLogical(core, false, nil)))
-	mux.Handle("/v1/", handleRequestForwarding(core, handleLogical(core, false, nil)))
-	if core.UIEnabled() == true {
+	mux.Handle("/v1/", handleLogicalRequestForwarding(core, handleLogical(core, false, nil)))
+	if core.UIEnabled() == true && !props.DisableUI {
 		if uiBuiltIn {
 			mux.Handle("/ui/", http.StripPrefix("/ui/", gziphandler.GzipHandler(handleUIHeaders(core, handleUI(http.FileServer(&UIAssetWrapper{FileSystem: assetFS()}))))))
 		} else {
@@ -107,8 +139,22 @@ func Handler(props *vault.HandlerProperties) http.Handler {
 		mux.Handle("/", handleRootRedirect())
 	}
 
+	var handler http.Handler = mux
+	if props.MaxConcurrentRequests > 0 {
+		handler = wrapRequestLimiter(handler, props.MaxConcurrentRequests, props.ReservedConcurrentRequests)
+	}
+	if props.RequestQueueTimeout > 0 {
+		handler = wrapRequestQueue(handler, core, props.RequestQueueTimeout)
+	}
+	if props.RequireAuthForSysInternal {
+		handler = wrapRequireAuthForSysInternal(handler)
+	}
+	if props.RequireRequestHeader {
+		handler = WrapRequireRequestHeader(handler)
+	}
+
 	// Wrap the handler in another handler to trigger all help paths.
-	helpWrappedHandler := wrapHelpHandler(mux, core)
+	helpWrappedHandler := wrapHelpHandler(handler, core)
 	corsWrappedHandler := wrapCORSHandler(helpWrappedHandler, core)
 
 	// Wrap the help wrapped handler with another layer with a generic
@@ -140,19 +186,57 @@ func wrapGenericHandler(h http.Handler, maxRequestSize int64, maxRequestDuration
 		// Start with the request context
 		ctx := r.Context()
 		var cancelFunc context.CancelFunc
-		// Add our timeout
-		ctx, cancelFunc = context.WithTimeout(ctx, maxRequestDuration)
+		var span *tracing.Span
+		// Add our timeout, except to the streams of sys/monitor and
+		// sys/events/subscribe which last until the client disconnects
+		if r.URL.Path == "/v1/sys/monitor" || strings.HasPrefix(r.URL.Path, "/v1/sys/events/subscribe/") {
+			ctx, cancelFunc = context.WithCancel(ctx)
+		} else {
+			ctx, cancelFunc = context.WithTimeout(ctx, maxRequestDuration)
+
+			// Trace the request, continuing the trace of the caller if it
+			// sent one
+			span, ctx = tracing.GetTracer().StartRootSpan(ctx, "http.request", tracing.Extract(r.Header))
+		}
 		// Add a size limiter if desired
 		if maxRequestSize > 0 {
 			ctx = context.WithValue(ctx, "max_request_size", maxRequestSize)
 		}
 		r = r.WithContext(ctx)
+		if span != nil {
+			span.Kind = tracing.KindServer
+			span.SetTag("http.method", r.Method)
+			span.SetTag("http.path", r.URL.Path)
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			w = recorder
+			defer func() {
+				span.SetTag("http.status_code", strconv.Itoa(recorder.status))
+				span.Finish()
+			}()
+		}
 		h.ServeHTTP(w, r)
 		cancelFunc()
 		return
 	})
 }
 
+// statusRecorder records the status code of a response for its span
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func WrapForwardedForHandler(h http.Handler, authorizedAddrs []*sockaddr.SockAddrMarshaler, rejectNotPresent, rejectNonAuthz bool, hopSkips int) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		headers, headersOK := r.Header[textproto.CanonicalMIMEHeaderKey("X-Forwarded-For")]
@@ -231,16 +315,228 @@ func WrapForwardedForHandler(h http.Handler, authorizedAddrs []*sockaddr.SockAdd
 			// authorized (or we've turned off explicit rejection) and we
 			// should assume that what comes in should be properly
 			// formatted.
-			respondError(w, http.StatusBadRequest, fmt.Errorf("malformed x-forwarded-for configuration or request, hops to skip (%d) would skip before earliest chain link (chain length %d)", hopSkips, len(headers)))
+			respondError(w, http.StatusBadRequest, fmt.Errorf("malformed x-forwarded-for configuration or request, hops to skip (%d) would skip before earliest chain link (chain length %d)", hopSkips, len(acc)))
 			return
 		}
 
-		r.RemoteAddr = net.JoinHostPort(acc[indexToUse], port)
+		// The chosen value ends up in audit logs and is checked against
+		// CIDR-bound tokens and roles, so it must be an actual IP address.
+		// Some proxies append the port of the client, which is dropped.
+		clientAddr := acc[indexToUse]
+		if host, _, err := net.SplitHostPort(clientAddr); err == nil {
+			clientAddr = host
+		}
+		if net.ParseIP(clientAddr) == nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("malformed x-forwarded-for value %q, expected an IP address", clientAddr))
+			return
+		}
+
+		r.RemoteAddr = net.JoinHostPort(clientAddr, port)
 		h.ServeHTTP(w, r)
 		return
 	})
 }
 
+// wrapRequireAuthForSysInternal rejects requests to sys/internal endpoints,
+// including those of namespaces, that do not carry a token. Whether a given
+// token is valid is left to the endpoints themselves.
+func wrapRequireAuthForSysInternal(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(AuthHeaderName) == "" && isSysInternalPath(r) {
+			respondError(w, http.StatusForbidden, logical.ErrPermissionDenied)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// WrapRequireRequestHeader rejects API requests that do not carry the
+// X-Vault-Request header with a 412. The pages of the UI are left out, as the
+// requests they make to the API set the header.
+func WrapRequireRequestHeader(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/") && r.Header.Get(RequestHeaderName) == "" {
+			respondError(w, http.StatusPreconditionFailed, fmt.Errorf("missing %s header", RequestHeaderName))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// requestQueueExemptPaths are served as soon as they arrive by
+// wrapRequestQueue, as they are used to bring the core out of the states
+// requests are held for, or to observe them
+var requestQueueExemptPaths = map[string]bool{
+	"/v1/sys/init":        true,
+	"/v1/sys/seal-status": true,
+	"/v1/sys/seal":        true,
+	"/v1/sys/unseal":      true,
+	"/v1/sys/leader":      true,
+	"/v1/sys/health":      true,
+	"/v1/sys/monitor":     true,
+}
+
+// requestQueueExemptPrefixes are the prefixes of the paths served as soon as
+// they arrive by wrapRequestQueue, along with requestQueueExemptPaths
+var requestQueueExemptPrefixes = []string{
+	"/v1/sys/events/subscribe/",
+}
+
+// requestQueueExempt returns whether the request to the given path is served
+// as soon as it arrives by wrapRequestQueue
+func requestQueueExempt(path string) bool {
+	if requestQueueExemptPaths[path] {
+		return true
+	}
+	for _, prefix := range requestQueueExemptPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestQueueInterval is how often wrapRequestQueue checks whether the core
+// can serve the requests it holds
+var requestQueueInterval = 100 * time.Millisecond
+
+// wrapRequestQueue holds API requests while the core is sealed or no active
+// node is known, such as while it is unsealed or during a step-down, for up
+// to timeout. The requests are then served as usual, so they fail as they
+// would have if the core still cannot serve them.
+func wrapRequestQueue(h http.Handler, core *vault.Core, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") || requestQueueExempt(r.URL.Path) || requestQueueReady(core) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		ticker := time.NewTicker(requestQueueInterval)
+		defer ticker.Stop()
+
+	QUEUE:
+		for {
+			select {
+			case <-ticker.C:
+				if requestQueueReady(core) {
+					break QUEUE
+				}
+			case <-timer.C:
+				break QUEUE
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// requestQueueReady returns whether the core is unsealed and either is the
+// active node or knows the one to forward requests to
+func requestQueueReady(core *vault.Core) bool {
+	if core.Sealed() {
+		return false
+	}
+	isLeader, leaderAddr, _, err := core.Leader()
+	switch {
+	case err == vault.ErrHANotEnabled:
+		return true
+	case err != nil:
+		return false
+	}
+	return isLeader || leaderAddr != ""
+}
+
+// requestLimiterPriorityPaths are the paths, or the prefixes of the paths
+// ending with a slash, that wrapRequestLimiter may serve with the reserved
+// capacity, as operators need them to observe and operate the cluster. The
+// streams of sys/monitor and sys/events/subscribe are not limited at all, as
+// they would hold their capacity until the client disconnects.
+var requestLimiterPriorityPaths = []string{
+	"/v1/sys/init",
+	"/v1/sys/seal-status",
+	"/v1/sys/seal",
+	"/v1/sys/unseal",
+	"/v1/sys/step-down",
+	"/v1/sys/leader",
+	"/v1/sys/ha-status",
+	"/v1/sys/health",
+	"/v1/sys/host-info",
+	"/v1/sys/metrics",
+	"/v1/sys/key-status",
+	"/v1/sys/generate-root/",
+	"/v1/sys/generate-recovery-token/",
+	"/v1/sys/rekey/",
+	"/v1/sys/rekey-recovery-key/",
+	"/v1/sys/replication/",
+	"/v1/sys/storage/",
+	"/v1/sys/pprof/",
+}
+
+// requestLimiterPriority returns whether the request may be served with the
+// reserved capacity of wrapRequestLimiter
+func requestLimiterPriority(r *http.Request) bool {
+	for _, path := range requestLimiterPriorityPaths {
+		if r.URL.Path == path || (strings.HasSuffix(path, "/") && strings.HasPrefix(r.URL.Path, path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapRequestLimiter serves at most maxRequests API requests at once, plus up
+// to reservedRequests requests to the priority paths once the others are in
+// use, so that the cluster can still be observed, sealed or stepped down
+// while application traffic saturates it. Requests wait for capacity until
+// their context is done and fail with a 503 then.
+func wrapRequestLimiter(h http.Handler, maxRequests, reservedRequests int) http.Handler {
+	slots := make(chan struct{}, maxRequests)
+	reserved := make(chan struct{}, reservedRequests)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") || r.URL.Path == "/v1/sys/monitor" || strings.HasPrefix(r.URL.Path, "/v1/sys/events/subscribe/") {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// A nil channel is never ready, so other requests only wait for the
+		// shared capacity
+		var reservedLane chan struct{}
+		if requestLimiterPriority(r) {
+			reservedLane = reserved
+		}
+
+		var release chan struct{}
+		select {
+		case slots <- struct{}{}:
+			release = slots
+		case reservedLane <- struct{}{}:
+			release = reservedLane
+		case <-r.Context().Done():
+			respondError(w, http.StatusServiceUnavailable, errors.New("too many concurrent requests"))
+			return
+		}
+		defer func() { <-release }()
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// isSysInternalPath returns whether the request is made to a sys/internal
+// endpoint, either directly or within a namespace
+func isSysInternalPath(r *http.Request) bool {
+	path, ok := stripPrefix("/v1/", r.URL.Path)
+	if !ok {
+		return false
+	}
+	if ns := strings.Trim(r.Header.Get(NamespaceHeaderName), "/"); ns != "" {
+		path = ns + "/" + path
+	}
+	return strings.HasPrefix(path, "sys/internal/") || strings.Contains(path, "/sys/internal/")
+}
+
 // A lookup on a token that is about to expire returns nil, which means by the
 // time we can validate a wrapping token lookup will return nil since it will
 // be revoked after the call. So we have to do the validation here.
@@ -371,6 +667,17 @@ func parseRequest(r *http.Request, w http.ResponseWriter, out interface{}) error
 // handleRequestForwarding determines whether to forward a request or not,
 // falling back on the older behavior of redirecting the client
 func handleRequestForwarding(core *vault.Core, handler http.Handler) http.Handler {
+	return handleRequestForwardingInternal(core, handler, false)
+}
+
+// handleLogicalRequestForwarding is like handleRequestForwarding, but allows
+// performance standbys to attempt to service the request locally. handler
+// must forward requests that fail with ErrPerfStandbyPleaseForward.
+func handleLogicalRequestForwarding(core *vault.Core, handler http.Handler) http.Handler {
+	return handleRequestForwardingInternal(core, handler, true)
+}
+
+func handleRequestForwardingInternal(core *vault.Core, handler http.Handler, perfStandbyOK bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get(vault.IntNoForwardingHeaderName) != "" {
 			handler.ServeHTTP(w, r)
@@ -408,32 +715,82 @@ func handleRequestForwarding(core *vault.Core, handler http.Handler) http.Handle
 			return
 		}
 
-		// Attempt forwarding the request. If we cannot forward -- perhaps it's
-		// been disabled on the active node -- this will return with an
-		// ErrCannotForward and we simply fall back
-		statusCode, header, retBytes, err := core.ForwardRequest(r)
-		if err != nil {
-			if err == vault.ErrCannotForward {
-				core.Logger().Debug("handleRequestForwarding: cannot forward (possibly disabled on active node), falling back")
-			} else {
-				core.Logger().Error("handleRequestForwarding: error forwarding request", "error", err)
+		// Performance standbys attempt to service the request locally first.
+		// Buffer the body so that it is still available if the request ends up
+		// needing to be forwarded.
+		if perfStandbyOK && core.PerfStandby() {
+			body, err := readRequestBody(w, r)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, err)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			r.GetBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(bytes.NewReader(body)), nil
 			}
-
-			// Fall back to redirection
 			handler.ServeHTTP(w, r)
 			return
 		}
 
-		if header != nil {
-			for k, v := range header {
-				w.Header()[k] = v
-			}
+		forwardRequest(core, w, r, handler)
+		return
+	})
+}
+
+// forwardRequest forwards the request to the active node, calling fallback if
+// forwarding is not possible
+func forwardRequest(core *vault.Core, w http.ResponseWriter, r *http.Request, fallback http.Handler) {
+	// If the body was buffered, rewind it
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		r.Body = body
+	}
+
+	// Attempt forwarding the request. If we cannot forward -- perhaps it's
+	// been disabled on the active node -- this will return with an
+	// ErrCannotForward and we simply fall back
+	statusCode, header, retBytes, err := core.ForwardRequest(r)
+	if err != nil {
+		if err == vault.ErrCannotForward {
+			core.Logger().Debug("handleRequestForwarding: cannot forward (possibly disabled on active node), falling back")
+		} else {
+			core.Logger().Error("handleRequestForwarding: error forwarding request", "error", err)
 		}
 
-		w.WriteHeader(statusCode)
-		w.Write(retBytes)
+		// Fall back to redirection
+		fallback.ServeHTTP(w, r)
 		return
-	})
+	}
+
+	if header != nil {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+	}
+
+	w.WriteHeader(statusCode)
+	w.Write(retBytes)
+}
+
+// readRequestBody reads the full request body, honoring the maximum request
+// size
+func readRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	reader := r.Body
+	maxRequestSize := r.Context().Value("max_request_size")
+	if maxRequestSize != nil {
+		max, ok := maxRequestSize.(int64)
+		if !ok {
+			return nil, errors.New("could not parse max_request_size from request context")
+		}
+		if max > 0 {
+			reader = http.MaxBytesReader(w, r.Body, max)
+		}
+	}
+	return ioutil.ReadAll(reader)
 }
 
 // request is a helper to perform a request and properly exit in the
@@ -444,6 +801,20 @@ func request(core *vault.Core, w http.ResponseWriter, rawReq *http.Request, r *l
 		respondStandby(core, w, rawReq.URL)
 		return resp, false
 	}
+	if errwrap.Contains(err, consts.ErrPerfStandbyPleaseForward.Error()) {
+		if rawReq.Header.Get(NoRequestForwardingHeaderName) != "" {
+			respondStandby(core, w, rawReq.URL)
+			return resp, false
+		}
+		forwardRequest(core, w, rawReq, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			respondStandby(core, w, r.URL)
+		}))
+		return resp, false
+	}
+	if err != nil && rawReq.Context().Err() == context.DeadlineExceeded {
+		respondError(w, http.StatusGatewayTimeout, errwrap.Wrapf("request exceeded the maximum request duration: {{err}}", err))
+		return resp, false
+	}
 	if respondErrorCommon(w, r, resp, err) {
 		return resp, false
 	}
@@ -553,12 +924,21 @@ func requestWrapInfo(r *http.Request, req *logical.Request) (*logical.Request, e
 }
 
 func respondError(w http.ResponseWriter, status int, err error) {
+	respondErrorWithCause(w, status, err, err)
+}
+
+// respondErrorWithCause responds with err, taking the error code from cause,
+// which may be the error err only carries the message of
+func respondErrorWithCause(w http.ResponseWriter, status int, err, cause error) {
 	logical.AdjustErrorStatusCode(&status, err)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
-	resp := &ErrorResponse{Errors: make([]string, 0, 1)}
+	resp := &ErrorResponse{
+		Errors:    make([]string, 0, 1),
+		ErrorCode: logical.ErrorCode(status, cause),
+	}
 	if err != nil {
 		resp.Errors = append(resp.Errors, err.Error())
 	}
@@ -573,7 +953,13 @@ func respondErrorCommon(w http.ResponseWriter, req *logical.Request, resp *logic
 		return false
 	}
 
-	respondError(w, statusCode, newErr)
+	// The returned error only has the message of an error response, so the
+	// code is taken from the error of the request where there is one
+	cause := err
+	if cause == nil {
+		cause = newErr
+	}
+	respondErrorWithCause(w, statusCode, newErr, cause)
 	return true
 }
 
@@ -590,7 +976,8 @@ func respondOk(w http.ResponseWriter, body interface{}) {
 }
 
 type ErrorResponse struct {
-	Errors []string `json:"errors"`
+	Errors    []string `json:"errors"`
+	ErrorCode string   `json:"error_code,omitempty"`
 }
 
 var injectDataIntoTopRoutes = []string{
@@ -8,10 +8,14 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/tracing"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/vault"
 )
@@ -97,6 +101,198 @@ func TestHandler_cors(t *testing.T) {
 	}
 }
 
+func TestHandler_DisableUI(t *testing.T) {
+	cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	core := cluster.Cores[0].Core
+	vault.TestWaitActive(t, core)
+
+	for _, disableUI := range []bool{false, true} {
+		handler := Handler(&vault.HandlerProperties{
+			Core:      core,
+			DisableUI: disableUI,
+		})
+		req := httptest.NewRequest("GET", "/ui/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		expected := http.StatusOK
+		if disableUI {
+			expected = http.StatusNotFound
+		}
+		if w.Code != expected {
+			t.Fatalf("disable_ui %t: expected %d, got %d", disableUI, expected, w.Code)
+		}
+	}
+}
+
+func TestHandler_RequireRequestHeader(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestListener(t)
+	defer ln.Close()
+	TestServerWithListenerAndProperties(t, ln, addr, core, &vault.HandlerProperties{
+		Core:                 core,
+		MaxRequestSize:       DefaultMaxRequestSize,
+		RequireRequestHeader: true,
+	})
+
+	resp := testHttpGet(t, token, addr+"/v1/sys/mounts")
+	testResponseStatus(t, resp, http.StatusPreconditionFailed)
+
+	req, err := http.NewRequest("GET", addr+"/v1/sys/mounts", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(AuthHeaderName, token)
+	req.Header.Set(RequestHeaderName, "true")
+	resp, err = cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testResponseStatus(t, resp, http.StatusOK)
+
+	// The API client always sets the header
+	config := api.DefaultConfig()
+	config.Address = addr
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+	if _, err := client.Sys().ListMounts(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler_RequestQueue(t *testing.T) {
+	core, keys, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestListener(t)
+	defer ln.Close()
+	TestServerWithListenerAndProperties(t, ln, addr, core, &vault.HandlerProperties{
+		Core:                core,
+		MaxRequestSize:      DefaultMaxRequestSize,
+		RequestQueueTimeout: 5 * time.Second,
+	})
+
+	if err := core.Seal(token); err != nil {
+		t.Fatal(err)
+	}
+
+	// The seal status is served right away while the core is sealed
+	resp := testHttpGet(t, "", addr+"/v1/sys/seal-status")
+	testResponseStatus(t, resp, http.StatusOK)
+
+	// So are event subscriptions, which fail as the core is sealed
+	start := time.Now()
+	resp = testHttpGet(t, token, addr+"/v1/sys/events/subscribe/kv")
+	testResponseStatus(t, resp, http.StatusServiceUnavailable)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected the subscription not to be held, took %s", elapsed)
+	}
+
+	// Unseal while the request is held
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		for _, key := range keys {
+			if _, err := vault.TestCoreUnseal(core, key); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	start = time.Now()
+	resp = testHttpGet(t, token, addr+"/v1/sys/mounts")
+	testResponseStatus(t, resp, http.StatusOK)
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Fatalf("expected the request to be held until the unseal, took %s", elapsed)
+	}
+}
+
+func TestHandler_RequestQueue_Timeout(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestListener(t)
+	defer ln.Close()
+	TestServerWithListenerAndProperties(t, ln, addr, core, &vault.HandlerProperties{
+		Core:                core,
+		MaxRequestSize:      DefaultMaxRequestSize,
+		RequestQueueTimeout: 500 * time.Millisecond,
+	})
+
+	if err := core.Seal(token); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp := testHttpGet(t, token, addr+"/v1/sys/mounts")
+	testResponseStatus(t, resp, http.StatusServiceUnavailable)
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Fatalf("expected the request to be held for the timeout, took %s", elapsed)
+	}
+}
+
+func TestHandler_RequestLimiter(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	handler := wrapRequestLimiter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}), 2, 1)
+
+	serve := func(path string, timeout time.Duration) chan int {
+		done := make(chan int, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			req := httptest.NewRequest("GET", path, nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			done <- w.Code
+		}()
+		return done
+	}
+	waitStarted := func() {
+		t.Helper()
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the request to be served")
+		}
+	}
+
+	// Application requests use up the shared capacity
+	app1 := serve("/v1/secret/foo", time.Minute)
+	app2 := serve("/v1/sys/leases/renew", time.Minute)
+	waitStarted()
+	waitStarted()
+
+	// Another application request waits for capacity until it times out
+	if code := <-serve("/v1/secret/bar", 200*time.Millisecond); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected a 503, got %d", code)
+	}
+
+	// An operator request is served with the reserved capacity, and the next
+	// one waits for it
+	status := serve("/v1/sys/seal-status", time.Minute)
+	waitStarted()
+	if code := <-serve("/v1/sys/rekey/init", 200*time.Millisecond); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected a 503, got %d", code)
+	}
+
+	// Streams are not limited
+	monitor := serve("/v1/sys/monitor", time.Minute)
+	waitStarted()
+
+	close(release)
+	for _, done := range []chan int{app1, app2, status, monitor} {
+		if code := <-done; code != http.StatusOK {
+			t.Fatalf("expected a 200, got %d", code)
+		}
+	}
+}
+
 func TestHandler_CacheControlNoStore(t *testing.T) {
 	core, _, token := vault.TestCoreUnsealed(t)
 	ln, addr := TestServer(t, core)
@@ -409,6 +605,35 @@ func TestHandler_error(t *testing.T) {
 	}
 }
 
+func TestHandler_errorCode(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	testErrorCode := func(resp *http.Response, status int, code string) {
+		t.Helper()
+		testResponseStatus(t, resp, status)
+		var actual ErrorResponse
+		testResponseBody(t, resp, &actual)
+		if actual.ErrorCode != code || len(actual.Errors) == 0 {
+			t.Fatalf("expected error code %q, got %#v", code, actual)
+		}
+	}
+
+	testErrorCode(testHttpGet(t, "bogus", addr+"/v1/sys/mounts"), 403, logical.ErrorCodePermissionDenied)
+	testErrorCode(testHttpGet(t, token, addr+"/v1/nonexistent/foo"), 404, logical.ErrorCodeUnsupportedPath)
+	testErrorCode(testHttpPost(t, token, addr+"/v1/sys/mounts/foo", map[string]interface{}{"type": "nonexistent"}), 400, logical.ErrorCodeInvalidRequest)
+
+	testResponseStatus(t, testHttpPut(t, token, addr+"/v1/sys/quotas/lease-count/secret", map[string]interface{}{"path": "secret", "max_leases": 1}), 204)
+	testResponseStatus(t, testHttpPut(t, token, addr+"/v1/secret/foo", map[string]interface{}{"data": "bar", "ttl": "1h"}), 204)
+	testResponseStatus(t, testHttpGet(t, token, addr+"/v1/secret/foo"), 200)
+	testErrorCode(testHttpGet(t, token, addr+"/v1/secret/foo"), 403, logical.ErrorCodeQuotaExceeded)
+
+	core.Seal(token)
+	testErrorCode(testHttpGet(t, token, addr+"/v1/secret/foo"), 503, logical.ErrorCodeSealed)
+}
+
 func TestHandler_nonPrintableChars(t *testing.T) {
 	testNonPrintable(t, false)
 	testNonPrintable(t, true)
@@ -443,3 +668,91 @@ func testNonPrintable(t *testing.T, disable bool) {
 		testResponseStatus(t, resp, 400)
 	}
 }
+
+type testSpanReporter struct {
+	l     sync.Mutex
+	spans []*tracing.Span
+}
+
+func (r *testSpanReporter) Report(span *tracing.Span) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func (r *testSpanReporter) Close() error {
+	return nil
+}
+
+func TestHandler_Tracing(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	reporter := &testSpanReporter{}
+	tracing.SetTracer(tracing.NewTracer(reporter, 1))
+	defer tracing.SetTracer(nil)
+
+	traceID := "463ac35c9f6413ad48485a3953bb6124"
+	req, err := http.NewRequest("PUT", addr+"/v1/secret/foo", strings.NewReader(`{"bar": "baz"}`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req.Header.Set(AuthHeaderName, token)
+	req.Header.Set(tracing.HeaderB3TraceID, traceID)
+	req.Header.Set(tracing.HeaderB3SpanID, "a2fb4a1d1a96d312")
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, resp, 204)
+
+	// The span of the request finishes once the response is written
+	spans := make(map[string]*tracing.Span)
+	for i := 0; i < 50 && spans["http.request"] == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+		reporter.l.Lock()
+		for _, span := range reporter.spans {
+			if span.TraceID != traceID {
+				continue
+			}
+			if _, ok := spans[span.Name]; !ok {
+				spans[span.Name] = span
+			}
+		}
+		reporter.l.Unlock()
+	}
+
+	root := spans["http.request"]
+	if root == nil {
+		t.Fatalf("missing the span of the request: %#v", spans)
+	}
+	if root.ParentID != "a2fb4a1d1a96d312" || root.Kind != tracing.KindServer {
+		t.Fatalf("bad span: %#v", root)
+	}
+	tags := root.Tags()
+	if tags["http.method"] != "PUT" || tags["http.path"] != "/v1/secret/foo" || tags["http.status_code"] != "204" {
+		t.Fatalf("bad tags: %#v", tags)
+	}
+
+	route := spans["router.route"]
+	if route == nil {
+		t.Fatalf("missing the span of the router: %#v", spans)
+	}
+	if tags := route.Tags(); tags["mount"] != "secret/" || tags["operation"] != "create" || tags["path"] != "foo" {
+		t.Fatalf("bad tags: %#v", tags)
+	}
+
+	if acl := spans["acl.check"]; acl == nil || acl.Tags()["allowed"] != "true" {
+		t.Fatalf("bad span of the ACL check: %#v", acl)
+	}
+
+	put := spans["storage.put"]
+	if put == nil {
+		t.Fatalf("missing the span of the storage: %#v", spans)
+	}
+	if put.ParentID != route.ID || put.Kind != tracing.KindClient || !strings.HasSuffix(put.Tags()["key"], "/foo") {
+		t.Fatalf("bad span: %#v", put)
+	}
+}
@@ -1,7 +1,9 @@
 package http
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"net"
@@ -27,13 +29,16 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 	if path == "" {
 		return nil, http.StatusNotFound, nil
 	}
+	if ns := strings.Trim(r.Header.Get(NamespaceHeaderName), "/"); ns != "" {
+		path = ns + "/" + path
+	}
 
 	// Determine the operation
 	var op logical.Operation
 	switch r.Method {
 	case "DELETE":
 		op = logical.DeleteOperation
-	case "GET":
+	case "GET", "HEAD":
 		op = logical.ReadOperation
 		// Need to call ParseForm to get query params loaded
 		queryVals := r.URL.Query()
@@ -75,13 +80,14 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	// If we are a read operation, try and parse any parameters
-	if op == logical.ReadOperation {
+	// If we are a read, delete or list operation, try and parse any
+	// parameters, such as the limit and continuation token of a list
+	if op == logical.ReadOperation || op == logical.DeleteOperation || op == logical.ListOperation {
 		getData := map[string]interface{}{}
 
 		for k, v := range r.URL.Query() {
-			// Skip the help key as this is a reserved parameter
-			if k == "help" {
+			// Skip the help and list keys as these are reserved parameters
+			if k == "help" || k == "list" {
 				continue
 			}
 
@@ -112,6 +118,9 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 		Data:       data,
 		Connection: getConnection(r),
 		Headers:    r.Header,
+		Reason:     r.Header.Get(ReasonHeaderName),
+
+		IdempotencyKey: r.Header.Get(IdempotencyKeyHeaderName),
 	})
 
 	req, err = requestWrapInfo(r, req)
@@ -191,6 +200,16 @@ func respondLogical(w http.ResponseWriter, r *http.Request, req *logical.Request
 
 		ret = httpResp
 
+		// Let pollers of KV secrets skip the payload of reads that have not
+		// changed since their last one
+		if etag := kvETag(req, resp, httpResp); etag != "" {
+			w.Header().Set("ETag", etag)
+			if etagMatches(r.Header.Get("If-None-Match"), etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
 		if injectDataIntoTopLevel {
 			injector := logical.HTTPSysInjector{
 				Response: httpResp,
@@ -204,6 +223,44 @@ func respondLogical(w http.ResponseWriter, r *http.Request, req *logical.Request
 	return
 }
 
+// kvETag returns the entity tag of a read of a KV secret, or an empty string
+// for any other response. The tag is a hash of the response body without its
+// request ID, so it changes with every new version or value of the secret, and
+// with every read of a mount that issues leases.
+func kvETag(req *logical.Request, resp *logical.Response, httpResp *logical.HTTPResponse) string {
+	if req.Operation != logical.ReadOperation || resp.WrapInfo != nil {
+		return ""
+	}
+	switch req.MountType {
+	case "kv", "generic":
+	default:
+		return ""
+	}
+
+	body := *httpResp
+	body.RequestID = ""
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches checks if the entity tag is in the If-None-Match header value
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // respondRaw is used when the response is using HTTPContentType and HTTPRawBody
 // to change the default response handling. This is only used for specific things like
 // returning the CRL information on the PKI backends.
@@ -245,6 +302,33 @@ func respondRaw(w http.ResponseWriter, r *http.Request, resp *logical.Response)
 		return
 	}
 
+	// Get the headers, which may have been decoded from JSON for plugins
+	headers := make(map[string][]string)
+	switch headersRaw := resp.Data[logical.HTTPRawHeaders].(type) {
+	case nil:
+	case map[string][]string:
+		headers = headersRaw
+	case map[string]interface{}:
+		for k, v := range headersRaw {
+			values, ok := v.([]interface{})
+			if !ok {
+				retErr(w, "cannot decode headers")
+				return
+			}
+			for _, value := range values {
+				value, ok := value.(string)
+				if !ok {
+					retErr(w, "cannot decode headers")
+					return
+				}
+				headers[k] = append(headers[k], value)
+			}
+		}
+	default:
+		retErr(w, "cannot decode headers")
+		return
+	}
+
 	nonEmpty := status != http.StatusNoContent
 
 	var contentType string
@@ -294,6 +378,11 @@ WRITE_RESPONSE:
 	if contentType != "" {
 		w.Header().Set("Content-Type", contentType)
 	}
+	for k, values := range headers {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
 
 	w.WriteHeader(status)
 	w.Write(body)
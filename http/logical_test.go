@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	log "github.com/hashicorp/go-hclog"
 
 	"github.com/hashicorp/vault/helper/logging"
@@ -266,6 +267,22 @@ func TestLogical_RequestSizeLimit(t *testing.T) {
 	testResponseStatus(t, resp, 413)
 }
 
+func TestLogical_RequestDurationLimit(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestListener(t)
+	defer ln.Close()
+	TestServerWithListenerAndProperties(t, ln, addr, core, &vault.HandlerProperties{
+		Core:               core,
+		MaxRequestSize:     DefaultMaxRequestSize,
+		MaxRequestDuration: time.Nanosecond,
+	})
+
+	resp := testHttpPut(t, token, addr+"/v1/secret/foo", map[string]interface{}{
+		"data": "bar",
+	})
+	testResponseStatus(t, resp, http.StatusGatewayTimeout)
+}
+
 func TestLogical_ListSuffix(t *testing.T) {
 	core, _, _ := vault.TestCoreUnsealed(t)
 	req, _ := http.NewRequest("GET", "http://127.0.0.1:8200/v1/secret/foo", nil)
@@ -305,6 +322,43 @@ func TestLogical_ListSuffix(t *testing.T) {
 	}
 }
 
+func TestLogical_ListPage(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	for _, key := range []string{"c", "a", "b"} {
+		resp := testHttpPut(t, token, addr+"/v1/secret/page/"+key, map[string]interface{}{
+			"data": "bar",
+		})
+		testResponseStatus(t, resp, 204)
+	}
+
+	resp := testHttpGet(t, token, addr+"/v1/secret/page?list=true&limit=2")
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	expected := map[string]interface{}{
+		"keys":         []interface{}{"a", "b"},
+		"continuation": "b",
+	}
+	if !reflect.DeepEqual(actual["data"], expected) {
+		t.Fatalf("bad:\nactual:\n%#v\nexpected:\n%#v", actual["data"], expected)
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/secret/page?list=true&limit=2&continuation=b")
+	actual = nil
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	expected = map[string]interface{}{
+		"keys": []interface{}{"c"},
+	}
+	if !reflect.DeepEqual(actual["data"], expected) {
+		t.Fatalf("bad:\nactual:\n%#v\nexpected:\n%#v", actual["data"], expected)
+	}
+}
+
 func TestLogical_RespondWithStatusCode(t *testing.T) {
 	resp := &logical.Response{
 		Data: map[string]interface{}{
@@ -335,3 +389,101 @@ func TestLogical_RespondWithStatusCode(t *testing.T) {
 		t.Fatalf("bad response: %s", string(bodyRaw[:]))
 	}
 }
+
+func TestLogical_NamespaceHeader(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, token, addr+"/v1/sys/namespaces/team1", nil)
+	testResponseStatus(t, resp, 200)
+	resp = testHttpPut(t, token, addr+"/v1/team1/sys/mounts/secret", map[string]interface{}{
+		"type": "kv",
+	})
+	testResponseStatus(t, resp, 204)
+	resp = testHttpPut(t, token, addr+"/v1/team1/secret/foo", map[string]interface{}{
+		"data": "bar",
+	})
+	testResponseStatus(t, resp, 204)
+
+	// Setting the header is equivalent to prefixing the path
+	req, err := http.NewRequest("GET", addr+"/v1/secret/foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(AuthHeaderName, token)
+	req.Header.Set(NamespaceHeaderName, "team1/")
+	resp, err = cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	if data, ok := actual["data"].(map[string]interface{}); !ok || data["data"] != "bar" {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
+func TestLogical_ETag(t *testing.T) {
+	read := func(req *logical.Request, data map[string]interface{}, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/v1/secret/foo", nil)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		respondLogical(w, r, req, false, &logical.Response{Data: data})
+		return w
+	}
+
+	req := &logical.Request{
+		ID:        "1",
+		Operation: logical.ReadOperation,
+		Path:      "foo",
+		MountType: "kv",
+	}
+	w := read(req, map[string]interface{}{"data": "bar"}, "")
+	etag := w.Header().Get("ETag")
+	if w.Code != 200 || etag == "" {
+		t.Fatalf("expected an ETag, got %d %q", w.Code, etag)
+	}
+
+	// An unchanged secret is not sent again, whatever the request ID
+	req.ID = "2"
+	for _, header := range []string{etag, `"other", W/` + etag, "*"} {
+		w = read(req, map[string]interface{}{"data": "bar"}, header)
+		if w.Code != 304 || w.Body.Len() != 0 {
+			t.Fatalf("expected a 304 for %q, got %d %q", header, w.Code, w.Body.String())
+		}
+	}
+
+	// A new value gets a new tag
+	w = read(req, map[string]interface{}{"data": "baz"}, etag)
+	if w.Code != 200 || w.Header().Get("ETag") == etag || w.Header().Get("ETag") == "" {
+		t.Fatalf("expected a new ETag, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	// Only KV reads are tagged
+	req.MountType = "transit"
+	if w = read(req, map[string]interface{}{"data": "bar"}, etag); w.Code != 200 || w.Header().Get("ETag") != "" {
+		t.Fatalf("unexpected ETag outside of KV: %d %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestLogical_ReasonHeader(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	req, _ := http.NewRequest("GET", "http://127.0.0.1:8200/v1/secret/foo", nil)
+	req.Header.Set(ReasonHeaderName, "CHG-1234")
+	lreq, status, err := buildLogicalRequest(core, nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != 0 {
+		t.Fatalf("got status %d", status)
+	}
+	if lreq.Reason != "CHG-1234" {
+		t.Fatalf("bad reason: %q", lreq.Reason)
+	}
+}
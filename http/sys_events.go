@@ -0,0 +1,73 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// handleSysEventsSubscribe streams the events under a topic to the client as
+// server-sent events until it disconnects. The request is handled by the
+// system backend first, which checks that it is allowed and audits it.
+func handleSysEventsSubscribe(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Events are published on the active node, which handles the
+		// requests that cause them, so standbys redirect clients to it
+		// rather than forwarding a stream
+		if standby, _ := core.Standby(); standby {
+			respondStandby(core, w, r.URL)
+			return
+		}
+
+		req, statusCode, err := buildLogicalRequest(core, w, r)
+		if err != nil || statusCode != 0 {
+			respondError(w, statusCode, err)
+			return
+		}
+
+		switch req.Operation {
+		case logical.ReadOperation:
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			respondError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+			return
+		}
+
+		if _, ok := request(core, w, r, req); !ok {
+			return
+		}
+
+		events, stop := core.SubscribeEvents(strings.TrimPrefix(r.URL.Path, "/v1/sys/events/subscribe/"))
+		defer stop()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event := <-events:
+				data, err := json.Marshal(event)
+				if err != nil {
+					return
+				}
+				if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
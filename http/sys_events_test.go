@@ -0,0 +1,53 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysEventsSubscribe(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpGet(t, token, addr+"/v1/sys/events/subscribe/kv/secret/app/")
+	defer resp.Body.Close()
+	testResponseStatus(t, resp, 200)
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("bad content type: %q", contentType)
+	}
+
+	// Only the write under the topic is streamed
+	testResponseStatus(t, testHttpPut(t, token, addr+"/v1/secret/other", map[string]interface{}{
+		"foo": "bar",
+	}), 204)
+	testResponseStatus(t, testHttpPut(t, token, addr+"/v1/secret/app/db", map[string]interface{}{
+		"foo": "bar",
+	}), 204)
+
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() && scanner.Text() != "" {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 3 || lines[1] != "event: kv" || !strings.HasPrefix(lines[2], "data: ") {
+		t.Fatalf("bad event: %v", lines)
+	}
+
+	var event vault.Event
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &event); err != nil {
+		t.Fatal(err)
+	}
+	if lines[0] != "id: "+event.ID || event.Path != "secret/app/db" {
+		t.Fatalf("bad event: %v", lines)
+	}
+
+	// Unknown event types are rejected
+	resp = testHttpGet(t, token, addr+"/v1/sys/events/subscribe/secrets/")
+	testResponseStatus(t, resp, 400)
+}
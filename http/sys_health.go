@@ -13,13 +13,13 @@ import (
 	"github.com/hashicorp/vault/version"
 )
 
-func handleSysHealth(core *vault.Core) http.Handler {
+func handleSysHealth(core *vault.Core, hideDetails bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "GET":
-			handleSysHealthGet(core, w, r)
+			handleSysHealthGet(core, w, r, hideDetails)
 		case "HEAD":
-			handleSysHealthHead(core, w, r)
+			handleSysHealthHead(core, w, r, hideDetails)
 		default:
 			respondError(w, http.StatusMethodNotAllowed, nil)
 		}
@@ -39,8 +39,8 @@ func fetchStatusCode(r *http.Request, field string) (int, bool, bool) {
 	return statusCode, false, true
 }
 
-func handleSysHealthGet(core *vault.Core, w http.ResponseWriter, r *http.Request) {
-	code, body, err := getSysHealth(core, r)
+func handleSysHealthGet(core *vault.Core, w http.ResponseWriter, r *http.Request, hideDetails bool) {
+	code, body, err := getSysHealth(core, r, hideDetails)
 	if err != nil {
 		core.Logger().Error("error checking health", "error", err)
 		respondError(w, http.StatusInternalServerError, nil)
@@ -60,8 +60,8 @@ func handleSysHealthGet(core *vault.Core, w http.ResponseWriter, r *http.Request
 	enc.Encode(body)
 }
 
-func handleSysHealthHead(core *vault.Core, w http.ResponseWriter, r *http.Request) {
-	code, body, err := getSysHealth(core, r)
+func handleSysHealthHead(core *vault.Core, w http.ResponseWriter, r *http.Request, hideDetails bool) {
+	code, body, err := getSysHealth(core, r, hideDetails)
 	if err != nil {
 		code = http.StatusInternalServerError
 	}
@@ -72,9 +72,10 @@ func handleSysHealthHead(core *vault.Core, w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(code)
 }
 
-func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, error) {
+func getSysHealth(core *vault.Core, r *http.Request, hideDetails bool) (int, *HealthResponse, error) {
 	// Check if being a standby is allowed for the purpose of a 200 OK
 	_, standbyOK := r.URL.Query()["standbyok"]
+	_, perfStandbyOK := r.URL.Query()["perfstandbyok"]
 
 	uninitCode := http.StatusNotImplemented
 	if code, found, ok := fetchStatusCode(r, "uninitcode"); !ok {
@@ -104,6 +105,13 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		activeCode = code
 	}
 
+	perfStandbyCode := 473 // unofficial 4xx status code
+	if code, found, ok := fetchStatusCode(r, "performancestandbycode"); !ok {
+		return http.StatusBadRequest, nil, nil
+	} else if found {
+		perfStandbyCode = code
+	}
+
 	drSecondaryCode := 472 // unofficial 4xx status code
 	if code, found, ok := fetchStatusCode(r, "drsecondarycode"); !ok {
 		return http.StatusBadRequest, nil, nil
@@ -116,6 +124,7 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 	// Check system status
 	sealed := core.Sealed()
 	standby, _ := core.Standby()
+	perfStandby := core.PerfStandby()
 	var replicationState consts.ReplicationState
 	if standby {
 		replicationState = core.ActiveNodeReplicationState()
@@ -137,6 +146,8 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		code = sealedCode
 	case replicationState.HasState(consts.ReplicationDRSecondary):
 		code = drSecondaryCode
+	case !perfStandbyOK && perfStandby:
+		code = perfStandbyCode
 	case !standbyOK && standby:
 		code = standbyCode
 	}
@@ -160,6 +171,7 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		Initialized:                init,
 		Sealed:                     sealed,
 		Standby:                    standby,
+		PerformanceStandby:         perfStandby,
 		ReplicationPerformanceMode: replicationState.GetPerformanceString(),
 		ReplicationDRMode:          replicationState.GetDRString(),
 		ServerTimeUTC:              time.Now().UTC().Unix(),
@@ -167,6 +179,14 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		ClusterName:                clusterName,
 		ClusterID:                  clusterID,
 	}
+	if hideDetails {
+		body.ReplicationPerformanceMode = ""
+		body.ReplicationDRMode = ""
+		body.ServerTimeUTC = 0
+		body.Version = ""
+		body.ClusterName = ""
+		body.ClusterID = ""
+	}
 	return code, body, nil
 }
 
@@ -174,10 +194,11 @@ type HealthResponse struct {
 	Initialized                bool   `json:"initialized"`
 	Sealed                     bool   `json:"sealed"`
 	Standby                    bool   `json:"standby"`
-	ReplicationPerformanceMode string `json:"replication_performance_mode"`
-	ReplicationDRMode          string `json:"replication_dr_mode"`
-	ServerTimeUTC              int64  `json:"server_time_utc"`
-	Version                    string `json:"version"`
+	PerformanceStandby         bool   `json:"performance_standby"`
+	ReplicationPerformanceMode string `json:"replication_performance_mode,omitempty"`
+	ReplicationDRMode          string `json:"replication_dr_mode,omitempty"`
+	ServerTimeUTC              int64  `json:"server_time_utc,omitempty"`
+	Version                    string `json:"version,omitempty"`
 	ClusterName                string `json:"cluster_name,omitempty"`
 	ClusterID                  string `json:"cluster_id,omitempty"`
 }
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/vault"
@@ -29,6 +31,7 @@ func TestSysHealth_get(t *testing.T) {
 		"initialized":                  false,
 		"sealed":                       true,
 		"standby":                      true,
+		"performance_standby":          false,
 	}
 	testResponseStatus(t, resp, 501)
 	testResponseBody(t, resp, &actual)
@@ -61,6 +64,7 @@ func TestSysHealth_get(t *testing.T) {
 		"initialized":                  true,
 		"sealed":                       true,
 		"standby":                      true,
+		"performance_standby":          false,
 	}
 	testResponseStatus(t, resp, 503)
 	testResponseBody(t, resp, &actual)
@@ -97,6 +101,7 @@ func TestSysHealth_get(t *testing.T) {
 		"initialized":                  true,
 		"sealed":                       false,
 		"standby":                      false,
+		"performance_standby":          false,
 	}
 	testResponseStatus(t, resp, 200)
 	testResponseBody(t, resp, &actual)
@@ -139,6 +144,7 @@ func TestSysHealth_customcodes(t *testing.T) {
 		"initialized":                  false,
 		"sealed":                       true,
 		"standby":                      true,
+		"performance_standby":          false,
 	}
 	testResponseStatus(t, resp, 581)
 	testResponseBody(t, resp, &actual)
@@ -172,6 +178,7 @@ func TestSysHealth_customcodes(t *testing.T) {
 		"initialized":                  true,
 		"sealed":                       true,
 		"standby":                      true,
+		"performance_standby":          false,
 	}
 	testResponseStatus(t, resp, 523)
 	testResponseBody(t, resp, &actual)
@@ -209,6 +216,7 @@ func TestSysHealth_customcodes(t *testing.T) {
 		"initialized":                  true,
 		"sealed":                       false,
 		"standby":                      false,
+		"performance_standby":          false,
 	}
 	testResponseStatus(t, resp, 202)
 	testResponseBody(t, resp, &actual)
@@ -266,3 +274,79 @@ func TestSysHealth_head(t *testing.T) {
 		}
 	}
 }
+
+func TestSysHealth_perfStandby(t *testing.T) {
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		PerformanceStandby: true,
+	}, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	vault.TestWaitActive(t, cluster.Cores[0].Core)
+
+	standby := cluster.Cores[1]
+	start := time.Now()
+	for !standby.Core.PerfStandby() {
+		if time.Now().Sub(start) > 10*time.Second {
+			t.Fatal("standby did not become a performance standby")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	testData := []struct {
+		uri  string
+		code int
+	}{
+		{"", 473},
+		{"?performancestandbycode=299", 299},
+		{"?perfstandbyok", 429},
+		{"?perfstandbyok&standbyok", 200},
+	}
+
+	for _, tt := range testData {
+		req := standby.Client.NewRequest("GET", "/v1/sys/health")
+		req.Params, _ = url.ParseQuery(strings.TrimPrefix(tt.uri, "?"))
+		resp, _ := standby.Client.RawRequest(req)
+		if resp == nil {
+			t.Fatalf("%q: no response", tt.uri)
+		}
+		if resp.StatusCode != tt.code {
+			t.Fatalf("%q: expected code %d, got %d", tt.uri, tt.code, resp.StatusCode)
+		}
+		var actual map[string]interface{}
+		testResponseBody(t, resp.Response, &actual)
+		if actual["performance_standby"] != true || actual["standby"] != true {
+			t.Fatalf("%q: bad: %#v", tt.uri, actual)
+		}
+	}
+}
+
+func TestSysHealth_hideDetails(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	ln, addr := TestListener(t)
+	defer ln.Close()
+	TestServerWithListenerAndProperties(t, ln, addr, core, &vault.HandlerProperties{
+		Core:                       core,
+		MaxRequestSize:             DefaultMaxRequestSize,
+		HideUnauthenticatedDetails: true,
+	})
+
+	resp, err := http.Get(addr + "/v1/sys/health")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var actual map[string]interface{}
+	expected := map[string]interface{}{
+		"initialized":         true,
+		"sealed":              false,
+		"standby":             false,
+		"performance_standby": false,
+	}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: expected:%#v\nactual:%#v", expected, actual)
+	}
+}
@@ -83,3 +83,65 @@ func TestSysInternal_UIMounts(t *testing.T) {
 		t.Fatalf("bad:\nExpected: %#v\nActual:%#v", expected, actual)
 	}
 }
+
+func TestSysInternal_RequireAuth(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestListener(t)
+	defer ln.Close()
+	TestServerWithListenerAndProperties(t, ln, addr, core, &vault.HandlerProperties{
+		Core:                      core,
+		MaxRequestSize:            DefaultMaxRequestSize,
+		RequireAuthForSysInternal: true,
+	})
+
+	resp := testHttpGet(t, "", addr+"/v1/sys/internal/ui/mounts")
+	testResponseStatus(t, resp, 403)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/internal/ui/mounts")
+	testResponseStatus(t, resp, 200)
+
+	// Other unauthenticated endpoints are unaffected
+	resp = testHttpGet(t, "", addr+"/v1/sys/seal-status")
+	testResponseStatus(t, resp, 200)
+}
+
+func TestSysInternal_UIMountsNamespace(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, token, addr+"/v1/sys/namespaces/team1", nil)
+	testResponseStatus(t, resp, 200)
+	resp = testHttpPut(t, token, addr+"/v1/team1/sys/mounts/kv", map[string]interface{}{
+		"type":    "kv",
+		"options": map[string]interface{}{"version": "2"},
+	})
+	testResponseStatus(t, resp, 204)
+
+	// Only the mounts of the namespace are listed, relative to it
+	resp = testHttpGet(t, token, addr+"/v1/team1/sys/internal/ui/mounts")
+	testResponseStatus(t, resp, 200)
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	secret := actual["data"].(map[string]interface{})["secret"].(map[string]interface{})
+	if _, ok := secret["kv/"]; !ok || len(secret) != 1 {
+		t.Fatalf("bad: %#v", secret)
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/team1/sys/internal/ui/mounts/kv/foo")
+	testResponseStatus(t, resp, 200)
+	actual = nil
+	testResponseBody(t, resp, &actual)
+	data := actual["data"].(map[string]interface{})
+	if data["path"] != "kv/" || data["type"] != "kv" {
+		t.Fatalf("bad: %#v", data)
+	}
+	if options := data["options"].(map[string]interface{}); options["version"] != "2" {
+		t.Fatalf("bad: %#v", data)
+	}
+
+	// Mounts of the root namespace are not found from within the namespace
+	resp = testHttpGet(t, token, addr+"/v1/team1/sys/internal/ui/mounts/secret/foo")
+	testResponseStatus(t, resp, 403)
+}
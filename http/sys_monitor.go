@@ -0,0 +1,79 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// handleSysMonitor streams the log lines of the server to the client until it
+// disconnects. The request is handled by the system backend first, which
+// checks that it is allowed and audits it.
+func handleSysMonitor(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, statusCode, err := buildLogicalRequest(core, w, r)
+		if err != nil || statusCode != 0 {
+			respondError(w, statusCode, err)
+			return
+		}
+
+		switch req.Operation {
+		case logical.ReadOperation:
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			respondError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+			return
+		}
+
+		if _, ok := request(core, w, r, req); !ok {
+			return
+		}
+
+		level := log.Info
+		if logLevel, ok := req.Data["log_level"].(string); ok {
+			level = log.LevelFromString(logLevel)
+		}
+
+		lines, stop := core.SubscribeLogs()
+		if lines == nil {
+			respondError(w, http.StatusInternalServerError, errors.New("log streaming is not available"))
+			return
+		}
+		defer stop()
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		// Lines without a level continue the line before, and are skipped
+		// along with it
+		var skip bool
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case line := <-lines:
+				if lineLevel := logging.LineLevel(line); lineLevel != log.NoLevel {
+					skip = lineLevel < level
+				}
+				if skip {
+					continue
+				}
+				if _, err := fmt.Fprintln(w, line); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/vault"
+)
+
+// recoveryHandler returns the handler of the API in recovery mode. Only
+// unsealing, generating the recovery token and the raw endpoint are
+// available, since none of the state behind the barrier is loaded.
+func recoveryHandler(props *vault.HandlerProperties) http.Handler {
+	core := props.Core
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/sys/seal-status", handleSysSealStatus(core, props.HideUnauthenticatedDetails))
+	mux.Handle("/v1/sys/unseal", handleSysUnseal(core, props.HideUnauthenticatedDetails))
+	mux.Handle("/v1/sys/generate-recovery-token/attempt", handleSysGenerateRootAttempt(core, vault.GenerateRecoveryTokenStrategy))
+	mux.Handle("/v1/sys/generate-recovery-token/update", handleSysGenerateRootUpdate(core, vault.GenerateRecoveryTokenStrategy))
+	mux.Handle("/v1/sys/raw", handleSysRecoveryRaw(core))
+	mux.Handle("/v1/sys/raw/", handleSysRecoveryRaw(core))
+	mux.Handle("/v1/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respondError(w, http.StatusServiceUnavailable, errors.New("vault is in recovery mode, only the sys/raw endpoint is available"))
+	}))
+
+	var handler http.Handler = mux
+	if props.RequireRequestHeader {
+		handler = WrapRequireRequestHeader(handler)
+	}
+
+	genericWrappedHandler := wrapGenericHandler(handler, props.MaxRequestSize, props.MaxRequestDuration)
+	if props.DisablePrintableCheck {
+		return genericWrappedHandler
+	}
+	return cleanhttp.PrintablePathCheckHandler(genericWrappedHandler, nil)
+}
+
+// handleSysRecoveryRaw serves the raw endpoint to the holder of the recovery
+// token
+func handleSysRecoveryRaw(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, statusCode, err := buildLogicalRequest(core, w, r)
+		if err != nil || statusCode != 0 {
+			respondError(w, statusCode, err)
+			return
+		}
+
+		resp, err := core.HandleRecoveryRequest(r.Context(), req)
+		if respondErrorCommon(w, req, resp, err) {
+			return
+		}
+
+		respondLogical(w, r, req, false, resp)
+	})
+}
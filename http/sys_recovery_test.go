@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/helper/xor"
+	"github.com/hashicorp/vault/physical/inmem"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysRecoveryMode(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Trace)
+	inm, err := inmem.NewInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, keys, root := vault.TestCoreUnsealedBackend(t, inm)
+	if err := core.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	core, err = vault.NewCore(&vault.CoreConfig{
+		Physical:     inm,
+		Seal:         vault.NewTestSeal(t, nil),
+		Logger:       logger,
+		DisableMlock: true,
+		RecoveryMode: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer core.Shutdown()
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	for _, key := range keys {
+		resp := testHttpPut(t, "", addr+"/v1/sys/unseal", map[string]interface{}{
+			"key": hex.EncodeToString(key),
+		})
+		testResponseStatus(t, resp, 200)
+	}
+	if core.Sealed() {
+		t.Fatal("should not be sealed")
+	}
+
+	// Only the raw endpoint is available, with the recovery token
+	resp := testHttpGet(t, root, addr+"/v1/sys/mounts")
+	testResponseStatus(t, resp, 503)
+	resp = testHttpGet(t, root, addr+"/v1/sys/raw/core/mounts")
+	testResponseStatus(t, resp, 403)
+
+	otpBytes, err := vault.GenerateRandBytes(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otp := base64.StdEncoding.EncodeToString(otpBytes)
+
+	resp = testHttpPut(t, "", addr+"/v1/sys/generate-recovery-token/attempt", map[string]interface{}{
+		"otp": otp,
+	})
+	var status map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &status)
+
+	var result map[string]interface{}
+	for _, key := range keys {
+		resp = testHttpPut(t, "", addr+"/v1/sys/generate-recovery-token/update", map[string]interface{}{
+			"nonce": status["nonce"].(string),
+			"key":   hex.EncodeToString(key),
+		})
+		result = map[string]interface{}{}
+		testResponseStatus(t, resp, 200)
+		testResponseBody(t, resp, &result)
+	}
+	if result["complete"] != true {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	decoded, err := xor.XORBase64(otp, result["encoded_token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := uuid.FormatUUID(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/raw/core/mounts")
+	testResponseStatus(t, resp, 200)
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/raw/test", map[string]interface{}{
+		"value": "repaired",
+	})
+	testResponseStatus(t, resp, 204)
+	resp = testHttpGet(t, token, addr+"/v1/sys/raw/test")
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	if actual["data"].(map[string]interface{})["value"] != "repaired" {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// The protected paths stay protected
+	resp = testHttpGet(t, token, addr+"/v1/sys/raw/core/keyring")
+	testResponseStatus(t, resp, 400)
+
+	// The recovery token doesn't survive a seal
+	if err := core.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	resp = testHttpGet(t, token, addr+"/v1/sys/raw/test")
+	testResponseStatus(t, resp, 503)
+}
@@ -67,11 +67,18 @@ func handleSysStepDown(core *vault.Core) http.Handler {
 			return
 		}
 
+		// Only respond once the node has handed off leadership, so that
+		// callers such as deploy tooling know it can be stopped
+		if err := core.WaitForStepDown(r.Context()); err != nil {
+			respondError(w, http.StatusGatewayTimeout, errwrap.Wrapf("step-down did not complete in time: {{err}}", err))
+			return
+		}
+
 		respondOk(w, nil)
 	})
 }
 
-func handleSysUnseal(core *vault.Core) http.Handler {
+func handleSysUnseal(core *vault.Core, hideDetails bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "PUT":
@@ -141,22 +148,22 @@ func handleSysUnseal(core *vault.Core) http.Handler {
 		}
 
 		// Return the seal status
-		handleSysSealStatusRaw(core, w, r)
+		handleSysSealStatusRaw(core, w, r, hideDetails)
 	})
 }
 
-func handleSysSealStatus(core *vault.Core) http.Handler {
+func handleSysSealStatus(core *vault.Core, hideDetails bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "GET" {
 			respondError(w, http.StatusMethodNotAllowed, nil)
 			return
 		}
 
-		handleSysSealStatusRaw(core, w, r)
+		handleSysSealStatusRaw(core, w, r, hideDetails)
 	})
 }
 
-func handleSysSealStatusRaw(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+func handleSysSealStatusRaw(core *vault.Core, w http.ResponseWriter, r *http.Request, hideDetails bool) {
 	ctx := context.Background()
 
 	sealed := core.Sealed()
@@ -196,7 +203,7 @@ func handleSysSealStatusRaw(core *vault.Core, w http.ResponseWriter, r *http.Req
 
 	progress, nonce := core.SecretProgress()
 
-	respondOk(w, &SealStatusResponse{
+	status := &SealStatusResponse{
 		Type:        sealConfig.Type,
 		Sealed:      sealed,
 		T:           sealConfig.SecretThreshold,
@@ -206,7 +213,14 @@ func handleSysSealStatusRaw(core *vault.Core, w http.ResponseWriter, r *http.Req
 		Version:     version.GetVersion().VersionNumber(),
 		ClusterName: clusterName,
 		ClusterID:   clusterID,
-	})
+	}
+	if hideDetails {
+		status.Version = ""
+		status.ClusterName = ""
+		status.ClusterID = ""
+	}
+
+	respondOk(w, status)
 }
 
 type SealStatusResponse struct {
@@ -216,7 +230,7 @@ type SealStatusResponse struct {
 	N           int    `json:"n"`
 	Progress    int    `json:"progress"`
 	Nonce       string `json:"nonce"`
-	Version     string `json:"version"`
+	Version     string `json:"version,omitempty"`
 	ClusterName string `json:"cluster_name,omitempty"`
 	ClusterID   string `json:"cluster_id,omitempty"`
 }
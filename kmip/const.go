@@ -0,0 +1,176 @@
+package kmip
+
+import "fmt"
+
+// Tag identifies a KMIP item
+type Tag uint32
+
+// The tags of the items of the operations this server supports
+const (
+	TagAttribute              Tag = 0x420008
+	TagAttributeIndex         Tag = 0x420009
+	TagAttributeName          Tag = 0x42000A
+	TagAttributeValue         Tag = 0x42000B
+	TagBatchCount             Tag = 0x42000D
+	TagBatchItem              Tag = 0x42000F
+	TagCryptographicAlgorithm Tag = 0x420028
+	TagCryptographicLength    Tag = 0x42002A
+	TagCryptographicUsageMask Tag = 0x42002C
+	TagKeyBlock               Tag = 0x420040
+	TagKeyFormatType          Tag = 0x420042
+	TagKeyMaterial            Tag = 0x420043
+	TagKeyValue               Tag = 0x420045
+	TagMaximumItems           Tag = 0x42004F
+	TagName                   Tag = 0x420053
+	TagNameType               Tag = 0x420054
+	TagNameValue              Tag = 0x420055
+	TagObjectType             Tag = 0x420057
+	TagOperation              Tag = 0x42005C
+	TagProtocolVersion        Tag = 0x420069
+	TagProtocolVersionMajor   Tag = 0x42006A
+	TagProtocolVersionMinor   Tag = 0x42006B
+	TagRequestHeader          Tag = 0x420077
+	TagRequestMessage         Tag = 0x420078
+	TagRequestPayload         Tag = 0x420079
+	TagResponseHeader         Tag = 0x42007A
+	TagResponseMessage        Tag = 0x42007B
+	TagResponsePayload        Tag = 0x42007C
+	TagResultMessage          Tag = 0x42007D
+	TagResultReason           Tag = 0x42007E
+	TagResultStatus           Tag = 0x42007F
+	TagState                  Tag = 0x42008D
+	TagSymmetricKey           Tag = 0x42008F
+	TagTemplateAttribute      Tag = 0x420091
+	TagTimeStamp              Tag = 0x420092
+	TagUniqueBatchItemID      Tag = 0x420093
+	TagUniqueIdentifier       Tag = 0x420094
+)
+
+var tagNames = map[Tag]string{
+	TagAttribute:              "Attribute",
+	TagAttributeIndex:         "Attribute Index",
+	TagAttributeName:          "Attribute Name",
+	TagAttributeValue:         "Attribute Value",
+	TagBatchCount:             "Batch Count",
+	TagBatchItem:              "Batch Item",
+	TagCryptographicAlgorithm: "Cryptographic Algorithm",
+	TagCryptographicLength:    "Cryptographic Length",
+	TagCryptographicUsageMask: "Cryptographic Usage Mask",
+	TagKeyBlock:               "Key Block",
+	TagKeyFormatType:          "Key Format Type",
+	TagKeyMaterial:            "Key Material",
+	TagKeyValue:               "Key Value",
+	TagMaximumItems:           "Maximum Items",
+	TagName:                   "Name",
+	TagNameType:               "Name Type",
+	TagNameValue:              "Name Value",
+	TagObjectType:             "Object Type",
+	TagOperation:              "Operation",
+	TagProtocolVersion:        "Protocol Version",
+	TagProtocolVersionMajor:   "Protocol Version Major",
+	TagProtocolVersionMinor:   "Protocol Version Minor",
+	TagRequestHeader:          "Request Header",
+	TagRequestMessage:         "Request Message",
+	TagRequestPayload:         "Request Payload",
+	TagResponseHeader:         "Response Header",
+	TagResponseMessage:        "Response Message",
+	TagResponsePayload:        "Response Payload",
+	TagResultMessage:          "Result Message",
+	TagResultReason:           "Result Reason",
+	TagResultStatus:           "Result Status",
+	TagState:                  "State",
+	TagSymmetricKey:           "Symmetric Key",
+	TagTemplateAttribute:      "Template-Attribute",
+	TagTimeStamp:              "Time Stamp",
+	TagUniqueBatchItemID:      "Unique Batch Item ID",
+	TagUniqueIdentifier:       "Unique Identifier",
+}
+
+func (t Tag) String() string {
+	if name, ok := tagNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("tag %#06x", uint32(t))
+}
+
+// Type is the type of the value of a KMIP item
+type Type byte
+
+const (
+	TypeStructure   Type = 0x01
+	TypeInteger     Type = 0x02
+	TypeLongInteger Type = 0x03
+	TypeBigInteger  Type = 0x04
+	TypeEnumeration Type = 0x05
+	TypeBoolean     Type = 0x06
+	TypeTextString  Type = 0x07
+	TypeByteString  Type = 0x08
+	TypeDateTime    Type = 0x09
+	TypeInterval    Type = 0x0A
+)
+
+// Operations
+const (
+	OperationCreate           uint32 = 0x01
+	OperationLocate           uint32 = 0x08
+	OperationGet              uint32 = 0x0A
+	OperationGetAttributes    uint32 = 0x0B
+	OperationActivate         uint32 = 0x12
+	OperationDestroy          uint32 = 0x14
+	OperationDiscoverVersions uint32 = 0x1E
+)
+
+// Object types
+const (
+	ObjectTypeSymmetricKey uint32 = 0x02
+)
+
+// Cryptographic algorithms
+const (
+	CryptographicAlgorithmAES uint32 = 0x03
+)
+
+// Key format types
+const (
+	KeyFormatTypeRaw uint32 = 0x01
+)
+
+// Name types
+const (
+	NameTypeUninterpretedTextString uint32 = 0x01
+)
+
+// States
+const (
+	StateActive uint32 = 0x02
+)
+
+// Result statuses
+const (
+	ResultStatusSuccess         uint32 = 0x00
+	ResultStatusOperationFailed uint32 = 0x01
+)
+
+// Result reasons
+const (
+	ResultReasonItemNotFound                uint32 = 0x01
+	ResultReasonAuthenticationNotSuccessful uint32 = 0x03
+	ResultReasonInvalidMessage              uint32 = 0x04
+	ResultReasonOperationNotSupported       uint32 = 0x05
+	ResultReasonMissingData                 uint32 = 0x06
+	ResultReasonInvalidField                uint32 = 0x07
+	ResultReasonPermissionDenied            uint32 = 0x0C
+	ResultReasonKeyFormatTypeNotSupported   uint32 = 0x10
+	ResultReasonGeneralFailure              uint32 = 0x100
+)
+
+// Attribute names
+const (
+	AttributeName                   = "Name"
+	AttributeObjectType             = "Object Type"
+	AttributeCryptographicAlgorithm = "Cryptographic Algorithm"
+	AttributeCryptographicLength    = "Cryptographic Length"
+	AttributeCryptographicUsageMask = "Cryptographic Usage Mask"
+	AttributeState                  = "State"
+	AttributeUniqueIdentifier       = "Unique Identifier"
+)
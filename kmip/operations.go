@@ -0,0 +1,311 @@
+package kmip
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+)
+
+// keyNameRegex matches the names transit accepts for keys
+var keyNameRegex = regexp.MustCompile(`^\w(([\w-.]+)?\w)?$`)
+
+// discoverVersions returns the supported protocol versions among the ones
+// the client offers, or all of them if it offers none
+func discoverVersions(payload *Item) *Item {
+	resp := Structure(TagResponsePayload)
+
+	offered := payload.ChildrenWithTag(TagProtocolVersion)
+	if len(offered) == 0 {
+		for _, v := range supportedVersions {
+			resp.Append(v.item())
+		}
+		return resp
+	}
+
+	for _, pv := range offered {
+		v := protocolVersion{
+			major: pv.Child(TagProtocolVersionMajor).IntegerValue(),
+			minor: pv.Child(TagProtocolVersionMinor).IntegerValue(),
+		}
+		if v.supported() {
+			resp.Append(v.item())
+		}
+	}
+	return resp
+}
+
+// attributes returns the attributes among the children of the item, by name
+func attributes(item *Item) map[string]*Item {
+	attrs := map[string]*Item{}
+	for _, attr := range item.ChildrenWithTag(TagAttribute) {
+		name := attr.Child(TagAttributeName).TextValue()
+		if value := attr.Child(TagAttributeValue); name != "" && value != nil {
+			attrs[name] = value
+		}
+	}
+	return attrs
+}
+
+func attribute(name string, value *Item) *Item {
+	value.Tag = TagAttributeValue
+	return Structure(TagAttribute,
+		Text(TagAttributeName, name),
+		value)
+}
+
+// uniqueIdentifier returns the unique identifier of the payload, falling back
+// to the ID placeholder
+func uniqueIdentifier(payload *Item, placeholder string) (string, error) {
+	id := payload.Child(TagUniqueIdentifier).TextValue()
+	if id == "" {
+		id = placeholder
+	}
+	if id == "" {
+		return "", newError(ResultReasonMissingData, "missing unique identifier")
+	}
+	if !keyNameRegex.MatchString(id) {
+		return "", newError(ResultReasonItemNotFound, "object %q not found", id)
+	}
+	return id, nil
+}
+
+// create creates an AES-256 key as an exportable transit key. The name of the
+// key, and so its unique identifier, is the Name attribute if there is one.
+func (c *connection) create(payload *Item, placeholder *string) (*Item, error) {
+	if objectType := payload.Child(TagObjectType).EnumValue(); objectType != ObjectTypeSymmetricKey {
+		return nil, newError(ResultReasonInvalidField, "only symmetric keys can be created")
+	}
+
+	attrs := attributes(payload.Child(TagTemplateAttribute))
+	if alg, ok := attrs[AttributeCryptographicAlgorithm]; ok && alg.EnumValue() != CryptographicAlgorithmAES {
+		return nil, newError(ResultReasonInvalidField, "only AES keys can be created")
+	}
+	if length, ok := attrs[AttributeCryptographicLength]; ok && length.IntegerValue() != 256 {
+		return nil, newError(ResultReasonInvalidField, "only 256-bit keys can be created")
+	}
+
+	var name string
+	if attr, ok := attrs[AttributeName]; ok {
+		name = attr.Child(TagNameValue).TextValue()
+		if !keyNameRegex.MatchString(name) {
+			return nil, newError(ResultReasonInvalidField, "invalid name %q", name)
+		}
+
+		// Creating an existing key would silently return it
+		existing, err := c.transitRequest(logical.ReadOperation, "keys/"+name, nil)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, newError(ResultReasonInvalidField, "an object named %q already exists", name)
+		}
+	} else {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		name = "kmip-" + id
+	}
+
+	if _, err := c.transitRequest(logical.UpdateOperation, "keys/"+name, map[string]interface{}{
+		"type":       "aes256-gcm96",
+		"exportable": true,
+	}); err != nil {
+		return nil, err
+	}
+
+	*placeholder = name
+	return Structure(TagResponsePayload,
+		Enum(TagObjectType, ObjectTypeSymmetricKey),
+		Text(TagUniqueIdentifier, name)), nil
+}
+
+// locate returns the unique identifiers of the keys matching the Name
+// attribute, or of all of the keys when no name is given
+func (c *connection) locate(payload *Item, placeholder *string) (*Item, error) {
+	attrs := attributes(payload)
+	if objectType, ok := attrs[AttributeObjectType]; ok && objectType.EnumValue() != ObjectTypeSymmetricKey {
+		return Structure(TagResponsePayload), nil
+	}
+
+	var ids []string
+	if attr, ok := attrs[AttributeName]; ok {
+		name := attr.Child(TagNameValue).TextValue()
+		if keyNameRegex.MatchString(name) {
+			resp, err := c.transitRequest(logical.ReadOperation, "keys/"+name, nil)
+			if err != nil {
+				return nil, err
+			}
+			if resp != nil && isAES256(resp) {
+				ids = append(ids, name)
+			}
+		}
+	} else {
+		resp, err := c.transitRequest(logical.ListOperation, "keys/", nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil {
+			keys, _ := resp.Data["keys"].([]string)
+			ids = append(ids, keys...)
+		}
+	}
+	sort.Strings(ids)
+
+	if max := payload.Child(TagMaximumItems).IntegerValue(); max > 0 && int(max) < len(ids) {
+		ids = ids[:max]
+	}
+
+	resp := Structure(TagResponsePayload)
+	for _, id := range ids {
+		resp.Append(Text(TagUniqueIdentifier, id))
+	}
+	if len(ids) > 0 {
+		*placeholder = ids[0]
+	}
+	return resp, nil
+}
+
+// get returns the material of the latest version of a key
+func (c *connection) get(payload *Item, placeholder string) (*Item, error) {
+	id, err := uniqueIdentifier(payload, placeholder)
+	if err != nil {
+		return nil, err
+	}
+	if format := payload.Child(TagKeyFormatType); format != nil && format.EnumValue() != KeyFormatTypeRaw {
+		return nil, newError(ResultReasonKeyFormatTypeNotSupported, "only the raw key format is supported")
+	}
+
+	resp, err := c.transitRequest(logical.ReadOperation, "export/encryption-key/"+id+"/latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, newError(ResultReasonItemNotFound, "object %q not found", id)
+	}
+	if keyType, _ := resp.Data["type"].(string); keyType != "aes256-gcm96" {
+		return nil, newError(ResultReasonInvalidField, "object %q is not an AES-256 key", id)
+	}
+
+	keys, _ := resp.Data["keys"].(map[string]string)
+	if len(keys) != 1 {
+		return nil, fmt.Errorf("unexpected export of %d versions", len(keys))
+	}
+	var material []byte
+	for _, encoded := range keys {
+		if material, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return nil, err
+		}
+	}
+
+	return Structure(TagResponsePayload,
+		Enum(TagObjectType, ObjectTypeSymmetricKey),
+		Text(TagUniqueIdentifier, id),
+		Structure(TagSymmetricKey,
+			Structure(TagKeyBlock,
+				Enum(TagKeyFormatType, KeyFormatTypeRaw),
+				Structure(TagKeyValue,
+					Bytes(TagKeyMaterial, material)),
+				Enum(TagCryptographicAlgorithm, CryptographicAlgorithmAES),
+				Integer(TagCryptographicLength, 256)))), nil
+}
+
+// getAttributes returns the requested attributes of a key, or all of them
+func (c *connection) getAttributes(payload *Item, placeholder string) (*Item, error) {
+	id, err := uniqueIdentifier(payload, placeholder)
+	if err != nil {
+		return nil, err
+	}
+	key, err := c.readKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	all := []*Item{
+		attribute(AttributeUniqueIdentifier, Text(0, id)),
+		attribute(AttributeObjectType, Enum(0, ObjectTypeSymmetricKey)),
+		attribute(AttributeCryptographicAlgorithm, Enum(0, CryptographicAlgorithmAES)),
+		attribute(AttributeCryptographicLength, Integer(0, 256)),
+		attribute(AttributeState, Enum(0, StateActive)),
+		attribute(AttributeName, Structure(0,
+			Text(TagNameValue, id),
+			Enum(TagNameType, NameTypeUninterpretedTextString))),
+	}
+	if version, ok := key.Data["latest_version"].(int); ok {
+		// Not a standard attribute, but useful to tell whether the material
+		// changed
+		all = append(all, attribute("x-transit-latest-version", Text(0, strconv.Itoa(version))))
+	}
+
+	requested := map[string]bool{}
+	for _, name := range payload.ChildrenWithTag(TagAttributeName) {
+		requested[name.TextValue()] = true
+	}
+
+	resp := Structure(TagResponsePayload, Text(TagUniqueIdentifier, id))
+	for _, attr := range all {
+		if len(requested) == 0 || requested[attr.Child(TagAttributeName).TextValue()] {
+			resp.Append(attr)
+		}
+	}
+	return resp, nil
+}
+
+// activate succeeds for existing keys, since transit keys can be used as soon
+// as they are created
+func (c *connection) activate(payload *Item, placeholder string) (*Item, error) {
+	id, err := uniqueIdentifier(payload, placeholder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.readKey(id); err != nil {
+		return nil, err
+	}
+	return Structure(TagResponsePayload, Text(TagUniqueIdentifier, id)), nil
+}
+
+// destroy deletes a key, allowing its deletion first
+func (c *connection) destroy(payload *Item, placeholder string) (*Item, error) {
+	id, err := uniqueIdentifier(payload, placeholder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.readKey(id); err != nil {
+		return nil, err
+	}
+
+	if _, err := c.transitRequest(logical.UpdateOperation, "keys/"+id+"/config", map[string]interface{}{
+		"deletion_allowed": true,
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := c.transitRequest(logical.DeleteOperation, "keys/"+id, nil); err != nil {
+		return nil, err
+	}
+	return Structure(TagResponsePayload, Text(TagUniqueIdentifier, id)), nil
+}
+
+// readKey reads an AES-256 transit key
+func (c *connection) readKey(id string) (*logical.Response, error) {
+	resp, err := c.transitRequest(logical.ReadOperation, "keys/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, newError(ResultReasonItemNotFound, "object %q not found", id)
+	}
+	if !isAES256(resp) {
+		return nil, newError(ResultReasonInvalidField, "object %q is not an AES-256 key", id)
+	}
+	return resp, nil
+}
+
+func isAES256(resp *logical.Response) bool {
+	keyType, _ := resp.Data["type"].(string)
+	return keyType == "aes256-gcm96"
+}
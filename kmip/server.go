@@ -0,0 +1,421 @@
+// Package kmip serves the keys of a transit secrets engine to clients that
+// speak the Key Management Interoperability Protocol, such as storage arrays
+// and databases with transparent data encryption.
+package kmip
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+const (
+	// DefaultMount is the transit mount keys are served from by default
+	DefaultMount = "transit"
+
+	// DefaultAuthMount is the cert auth mount clients log in with by default
+	DefaultAuthMount = "cert"
+
+	// maxMessageLength is the longest request message that is read
+	maxMessageLength = 1024 * 1024
+
+	// idleTimeout is how long a connection may go without a request
+	idleTimeout = 5 * time.Minute
+
+	// DefaultMaxConnections is the number of connections served at once by
+	// default
+	DefaultMaxConnections = 256
+)
+
+// supportedVersions are the protocol versions the server speaks, most
+// preferred first
+var supportedVersions = []protocolVersion{
+	{1, 4},
+	{1, 3},
+	{1, 2},
+	{1, 1},
+	{1, 0},
+}
+
+type protocolVersion struct {
+	major, minor int32
+}
+
+func (v protocolVersion) item() *Item {
+	return Structure(TagProtocolVersion,
+		Integer(TagProtocolVersionMajor, v.major),
+		Integer(TagProtocolVersionMinor, v.minor))
+}
+
+func (v protocolVersion) supported() bool {
+	for _, s := range supportedVersions {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Server serves KMIP requests. Clients authenticate with TLS client
+// certificates, which are logged in to a cert auth mount; every operation is
+// then performed against the transit mount with the policies of the resulting
+// token, so policies decide which keys each client may use.
+type Server struct {
+	Core *vault.Core
+
+	// Mount is the path of the transit mount the keys are served from
+	Mount string
+
+	// AuthMount is the path of the cert auth mount clients log in with
+	AuthMount string
+
+	// MaxConnections is the number of connections served at once. Further
+	// connections are not accepted until one of them closes.
+	MaxConnections int
+
+	Logger log.Logger
+}
+
+// Serve accepts connections on the listener, which must return TLS
+// connections, until it is closed.
+func (s *Server) Serve(ln net.Listener) error {
+	maxConnections := s.MaxConnections
+	if maxConnections <= 0 {
+		maxConnections = DefaultMaxConnections
+	}
+	slots := make(chan struct{}, maxConnections)
+
+	for {
+		slots <- struct{}{}
+		conn, err := ln.Accept()
+		if err != nil {
+			<-slots
+			return err
+		}
+		go func() {
+			defer func() { <-slots }()
+			s.serveConn(conn)
+		}()
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		s.Logger.Error("kmip connections must use tls", "remote_addr", conn.RemoteAddr().String())
+		return
+	}
+	conn.SetDeadline(time.Now().Add(idleTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		s.Logger.Debug("tls handshake failed", "remote_addr", conn.RemoteAddr().String(), "error", err)
+		return
+	}
+	state := tlsConn.ConnectionState()
+
+	c := &connection{
+		server: s,
+		logicalConn: &logical.Connection{
+			RemoteAddr: hostOnly(conn.RemoteAddr().String()),
+			ConnState:  &state,
+		},
+	}
+	// The token of the connection and its leases are of no use once it
+	// closes
+	defer func() {
+		c.tokenLock.Lock()
+		defer c.tokenLock.Unlock()
+		c.revokeToken()
+	}()
+
+	for {
+		conn.SetDeadline(time.Now().Add(idleTimeout))
+		msg, err := ReadItem(conn, maxMessageLength)
+		if err != nil {
+			if err != io.EOF {
+				s.Logger.Debug("failed to read request", "remote_addr", c.logicalConn.RemoteAddr, "error", err)
+			}
+			return
+		}
+
+		resp, err := c.handleMessage(msg).MarshalBinary()
+		if err != nil {
+			s.Logger.Error("failed to encode response", "error", err)
+			return
+		}
+		if _, err := conn.Write(resp); err != nil {
+			s.Logger.Debug("failed to write response", "remote_addr", c.logicalConn.RemoteAddr, "error", err)
+			return
+		}
+	}
+}
+
+func hostOnly(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// connection is the state of one client connection
+type connection struct {
+	server      *Server
+	logicalConn *logical.Connection
+
+	tokenLock   sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// kmipError is an operation failure, reported with a result reason
+type kmipError struct {
+	reason  uint32
+	message string
+}
+
+func (e *kmipError) Error() string {
+	return e.message
+}
+
+func newError(reason uint32, format string, args ...interface{}) *kmipError {
+	return &kmipError{reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// handleMessage performs the batch items of a request message and returns
+// the response message
+func (c *connection) handleMessage(msg *Item) *Item {
+	version := supportedVersions[0]
+	header := msg.Child(TagRequestHeader)
+	if pv := header.Child(TagProtocolVersion); pv != nil {
+		version = protocolVersion{
+			major: pv.Child(TagProtocolVersionMajor).IntegerValue(),
+			minor: pv.Child(TagProtocolVersionMinor).IntegerValue(),
+		}
+	}
+
+	var items []*Item
+	switch {
+	case msg.Tag != TagRequestMessage || header == nil:
+		items = []*Item{failedItem(nil, newError(ResultReasonInvalidMessage, "expected a request message with a header"))}
+	case !version.supported():
+		items = []*Item{failedItem(nil, newError(ResultReasonInvalidMessage, "protocol version %d.%d is not supported", version.major, version.minor))}
+		version = supportedVersions[0]
+	default:
+		// The ID placeholder lets batch items refer to the object the
+		// previous item created or located
+		var placeholder string
+		for _, batchItem := range msg.ChildrenWithTag(TagBatchItem) {
+			items = append(items, c.handleBatchItem(batchItem, &placeholder))
+		}
+	}
+
+	resp := Structure(TagResponseMessage,
+		Structure(TagResponseHeader,
+			version.item(),
+			DateTime(TagTimeStamp, time.Now().UTC()),
+			Integer(TagBatchCount, int32(len(items)))))
+	resp.Append(items...)
+	return resp
+}
+
+func (c *connection) handleBatchItem(batchItem *Item, placeholder *string) *Item {
+	operation := batchItem.Child(TagOperation)
+	if operation == nil {
+		return failedItem(batchItem, newError(ResultReasonInvalidMessage, "batch item has no operation"))
+	}
+	payload := batchItem.Child(TagRequestPayload)
+	if payload == nil {
+		payload = Structure(TagRequestPayload)
+	}
+
+	var respPayload *Item
+	var err error
+	switch operation.EnumValue() {
+	case OperationDiscoverVersions:
+		respPayload = discoverVersions(payload)
+	case OperationCreate:
+		respPayload, err = c.create(payload, placeholder)
+	case OperationLocate:
+		respPayload, err = c.locate(payload, placeholder)
+	case OperationGet:
+		respPayload, err = c.get(payload, *placeholder)
+	case OperationGetAttributes:
+		respPayload, err = c.getAttributes(payload, *placeholder)
+	case OperationActivate:
+		respPayload, err = c.activate(payload, *placeholder)
+	case OperationDestroy:
+		respPayload, err = c.destroy(payload, *placeholder)
+	default:
+		err = newError(ResultReasonOperationNotSupported, "operation %#x is not supported", operation.EnumValue())
+	}
+	if err != nil {
+		return failedItem(batchItem, err)
+	}
+
+	item := Structure(TagBatchItem, operation)
+	if id := batchItem.Child(TagUniqueBatchItemID); id != nil {
+		item.Append(id)
+	}
+	item.Append(Enum(TagResultStatus, ResultStatusSuccess), respPayload)
+	return item
+}
+
+// failedItem returns the response batch item of a failed operation
+func failedItem(batchItem *Item, err error) *Item {
+	kerr, ok := err.(*kmipError)
+	if !ok {
+		kerr = newError(ResultReasonGeneralFailure, "%s", err.Error())
+	}
+
+	item := Structure(TagBatchItem)
+	if operation := batchItem.Child(TagOperation); operation != nil {
+		item.Append(operation)
+	}
+	if id := batchItem.Child(TagUniqueBatchItemID); id != nil {
+		item.Append(id)
+	}
+	item.Append(
+		Enum(TagResultStatus, ResultStatusOperationFailed),
+		Enum(TagResultReason, kerr.reason),
+		Text(TagResultMessage, kerr.message))
+	return item
+}
+
+// clientToken returns a token of the client, logging in with its certificate
+// when there is no token yet or the previous one has expired
+func (c *connection) clientToken(ctx context.Context) (string, error) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+	c.revokeToken()
+
+	req := &logical.Request{
+		Operation:  logical.UpdateOperation,
+		Path:       "auth/" + strings.Trim(c.server.authMount(), "/") + "/login",
+		Connection: c.logicalConn,
+		Data:       map[string]interface{}{},
+	}
+	if err := setRequestID(req); err != nil {
+		return "", err
+	}
+	resp, err := c.server.Core.HandleRequest(ctx, req)
+	if err != nil || resp == nil || resp.Auth == nil || resp.Auth.ClientToken == "" {
+		message := "login failed"
+		if err != nil {
+			message = fmt.Sprintf("login failed: %s", err)
+		} else if resp != nil && resp.IsError() {
+			message = fmt.Sprintf("login failed: %s", resp.Error())
+		}
+		return "", newError(ResultReasonAuthenticationNotSuccessful, "%s", message)
+	}
+
+	c.token = resp.Auth.ClientToken
+	if ttl := resp.Auth.TTL; ttl > 0 {
+		// Log in again a little before the token expires
+		c.tokenExpiry = time.Now().Add(ttl - ttl/10)
+	} else {
+		// Tokens without a TTL don't expire
+		c.tokenExpiry = time.Now().Add(100 * 365 * 24 * time.Hour)
+	}
+	return c.token, nil
+}
+
+// revokeToken revokes the token of the client, along with its leases. The
+// token lock must be held.
+func (c *connection) revokeToken() {
+	if c.token == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), vault.DefaultMaxRequestDuration)
+	defer cancel()
+
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "auth/token/revoke-self",
+		ClientToken: c.token,
+		Connection:  c.logicalConn,
+	}
+	c.token = ""
+	if err := setRequestID(req); err != nil {
+		c.server.Logger.Error("failed to revoke token", "error", err)
+		return
+	}
+	resp, err := c.server.Core.HandleRequest(ctx, req)
+	if err == nil && resp != nil && resp.IsError() {
+		err = resp.Error()
+	}
+	if err != nil {
+		c.server.Logger.Debug("failed to revoke token", "remote_addr", c.logicalConn.RemoteAddr, "error", err)
+	}
+}
+
+// transitRequest performs a request against the transit mount with the token
+// of the client. A nil response means the path was not found.
+func (c *connection) transitRequest(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), vault.DefaultMaxRequestDuration)
+	defer cancel()
+	token, err := c.clientToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &logical.Request{
+		Operation:   op,
+		Path:        strings.Trim(c.server.mount(), "/") + "/" + path,
+		ClientToken: token,
+		Connection:  c.logicalConn,
+		Data:        data,
+	}
+	if err := setRequestID(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.server.Core.HandleRequest(ctx, req)
+	switch {
+	case err != nil && errwrap.Contains(err, logical.ErrPermissionDenied.Error()):
+		return nil, newError(ResultReasonPermissionDenied, "permission denied")
+	case resp != nil && resp.IsError():
+		return nil, newError(ResultReasonInvalidField, "%s", resp.Error())
+	case err != nil:
+		return nil, err
+	}
+	return resp, nil
+}
+
+func setRequestID(req *logical.Request) error {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	req.ID = id
+	return nil
+}
+
+func (s *Server) mount() string {
+	if s.Mount == "" {
+		return DefaultMount
+	}
+	return s.Mount
+}
+
+func (s *Server) authMount() string {
+	if s.AuthMount == "" {
+		return DefaultAuthMount
+	}
+	return s.AuthMount
+}
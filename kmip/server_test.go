@@ -0,0 +1,341 @@
+package kmip
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	credCert "github.com/hashicorp/vault/builtin/credential/cert"
+	"github.com/hashicorp/vault/builtin/logical/transit"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestServer(t *testing.T) {
+	vault.AddTestLogicalBackend("transit", transit.Factory)
+	vault.AddTestCredentialBackend("cert", credCert.Factory)
+	core, _, root := vault.TestCoreUnsealed(t)
+
+	clientCert, clientPEM := testCertificate(t, "kmip-client", x509.ExtKeyUsageClientAuth)
+	otherCert, otherPEM := testCertificate(t, "other-client", x509.ExtKeyUsageClientAuth)
+	serverCert, _ := testCertificate(t, "localhost", x509.ExtKeyUsageServerAuth)
+
+	for _, req := range []*logical.Request{
+		{Path: "sys/mounts/transit", Data: map[string]interface{}{"type": "transit"}},
+		{Path: "sys/auth/cert", Data: map[string]interface{}{"type": "cert"}},
+		{Path: "sys/policy/kmip", Data: map[string]interface{}{"policy": `path "transit/*" { capabilities = ["create", "read", "update", "delete", "list"] }`}},
+		{Path: "auth/cert/certs/kmip", Data: map[string]interface{}{"certificate": clientPEM, "policies": "kmip"}},
+		{Path: "auth/cert/certs/other", Data: map[string]interface{}{"certificate": otherPEM, "policies": "default"}},
+	} {
+		req.Operation = logical.UpdateOperation
+		req.ClientToken = root
+		if resp, err := core.HandleRequest(context.Background(), req); err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v resp: %#v", req.Path, err, resp)
+		}
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequestClientCert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	server := &Server{
+		Core:   core,
+		Logger: logging.NewVaultLogger(0),
+	}
+	go server.Serve(ln)
+
+	client := testClient(t, ln.Addr().String(), clientCert)
+	defer client.Close()
+
+	// Discover the versions
+	resp := client.request(t, OperationDiscoverVersions, Structure(TagRequestPayload,
+		protocolVersion{2, 0}.item(),
+		protocolVersion{1, 2}.item()))
+	versions := resp.ChildrenWithTag(TagProtocolVersion)
+	if len(versions) != 1 || versions[0].Child(TagProtocolVersionMinor).IntegerValue() != 2 {
+		t.Fatalf("bad versions: %#v", versions)
+	}
+
+	// Create a named key
+	resp = client.request(t, OperationCreate, Structure(TagRequestPayload,
+		Enum(TagObjectType, ObjectTypeSymmetricKey),
+		Structure(TagTemplateAttribute,
+			attribute(AttributeCryptographicAlgorithm, Enum(0, CryptographicAlgorithmAES)),
+			attribute(AttributeCryptographicLength, Integer(0, 256)),
+			attribute(AttributeName, Structure(0,
+				Text(TagNameValue, "array-1"),
+				Enum(TagNameType, NameTypeUninterpretedTextString))))))
+	if id := resp.Child(TagUniqueIdentifier).TextValue(); id != "array-1" {
+		t.Fatalf("bad unique identifier: %q", id)
+	}
+
+	// Creating it again fails
+	client.requestError(t, OperationCreate, ResultReasonInvalidField, Structure(TagRequestPayload,
+		Enum(TagObjectType, ObjectTypeSymmetricKey),
+		Structure(TagTemplateAttribute,
+			attribute(AttributeName, Structure(0,
+				Text(TagNameValue, "array-1"),
+				Enum(TagNameType, NameTypeUninterpretedTextString))))))
+
+	// Only AES-256 keys can be created
+	client.requestError(t, OperationCreate, ResultReasonInvalidField, Structure(TagRequestPayload,
+		Enum(TagObjectType, ObjectTypeSymmetricKey),
+		Structure(TagTemplateAttribute,
+			attribute(AttributeCryptographicLength, Integer(0, 128)))))
+
+	// The material matches what transit exports
+	resp = client.request(t, OperationGet, Structure(TagRequestPayload,
+		Text(TagUniqueIdentifier, "array-1")))
+	material, _ := resp.Child(TagSymmetricKey).Child(TagKeyBlock).Child(TagKeyValue).Child(TagKeyMaterial).Value.([]byte)
+	if len(material) != 32 {
+		t.Fatalf("bad key material of %d bytes", len(material))
+	}
+
+	// Locate finds the key by name
+	resp = client.request(t, OperationLocate, Structure(TagRequestPayload,
+		attribute(AttributeName, Structure(0,
+			Text(TagNameValue, "array-1"),
+			Enum(TagNameType, NameTypeUninterpretedTextString)))))
+	if ids := resp.ChildrenWithTag(TagUniqueIdentifier); len(ids) != 1 || ids[0].TextValue() != "array-1" {
+		t.Fatalf("bad located ids: %#v", ids)
+	}
+
+	// An unnamed key gets a generated name, which can be used through the ID
+	// placeholder in the same batch
+	msg := requestMessage(
+		batchItem(OperationCreate, Structure(TagRequestPayload,
+			Enum(TagObjectType, ObjectTypeSymmetricKey))),
+		batchItem(OperationGetAttributes, Structure(TagRequestPayload,
+			Text(TagAttributeName, AttributeState))))
+	items := client.send(t, msg).ChildrenWithTag(TagBatchItem)
+	if len(items) != 2 {
+		t.Fatalf("bad batch items: %#v", items)
+	}
+	for _, item := range items {
+		if item.Child(TagResultStatus).EnumValue() != ResultStatusSuccess {
+			t.Fatalf("bad result: %s", item.Child(TagResultMessage).TextValue())
+		}
+	}
+	generated := items[0].Child(TagResponsePayload).Child(TagUniqueIdentifier).TextValue()
+	attrs := attributes(items[1].Child(TagResponsePayload))
+	if len(attrs) != 1 || attrs[AttributeState].EnumValue() != StateActive {
+		t.Fatalf("bad attributes: %#v", attrs)
+	}
+
+	// Locate without a name lists all of the keys
+	resp = client.request(t, OperationLocate, Structure(TagRequestPayload))
+	if ids := resp.ChildrenWithTag(TagUniqueIdentifier); len(ids) != 2 {
+		t.Fatalf("bad located ids: %#v", ids)
+	}
+
+	// Clients without access to transit are denied
+	other := testClient(t, ln.Addr().String(), otherCert)
+	defer other.Close()
+	other.requestError(t, OperationGet, ResultReasonPermissionDenied, Structure(TagRequestPayload,
+		Text(TagUniqueIdentifier, "array-1")))
+
+	// Destroy deletes the key from transit
+	client.request(t, OperationDestroy, Structure(TagRequestPayload,
+		Text(TagUniqueIdentifier, generated)))
+	keyResp, err := core.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "transit/keys/" + generated,
+		ClientToken: root,
+	})
+	if err != nil || keyResp != nil {
+		t.Fatalf("expected key to be deleted: err: %v resp: %#v", err, keyResp)
+	}
+	client.requestError(t, OperationGet, ResultReasonItemNotFound, Structure(TagRequestPayload,
+		Text(TagUniqueIdentifier, generated)))
+
+	// Unsupported operations fail
+	client.requestError(t, 0x02, ResultReasonOperationNotSupported, Structure(TagRequestPayload))
+
+	// The tokens the connections logged in with are revoked once they close,
+	// leaving only the root token
+	client.Close()
+	other.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		listResp, err := core.HandleRequest(context.Background(), &logical.Request{
+			Operation:   logical.ListOperation,
+			Path:        "auth/token/accessors/",
+			ClientToken: root,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := listResp.Data["keys"].([]string)
+		if len(keys) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the tokens of the connections to be revoked: %v", keys)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestServer_MaxConnections(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	clientCert, _ := testCertificate(t, "kmip-client", x509.ExtKeyUsageClientAuth)
+	serverCert, _ := testCertificate(t, "localhost", x509.ExtKeyUsageServerAuth)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequestClientCert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	server := &Server{
+		Core:           core,
+		MaxConnections: 1,
+		Logger:         logging.NewVaultLogger(0),
+	}
+	go server.Serve(ln)
+
+	dial := func() (*testKMIPClient, error) {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 500 * time.Millisecond}, "tcp", ln.Addr().String(), &tls.Config{
+			Certificates:       []tls.Certificate{clientCert},
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &testKMIPClient{conn}, nil
+	}
+
+	first := testClient(t, ln.Addr().String(), clientCert)
+	first.request(t, OperationDiscoverVersions, Structure(TagRequestPayload))
+
+	// A second connection isn't served while the first is open
+	if second, err := dial(); err == nil {
+		second.Close()
+		t.Fatal("expected the second connection not to be served")
+	}
+
+	first.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		second, err := dial()
+		if err == nil {
+			second.request(t, OperationDiscoverVersions, Structure(TagRequestPayload))
+			second.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the second connection to be served: %v", err)
+		}
+	}
+}
+
+type testKMIPClient struct {
+	*tls.Conn
+}
+
+func testClient(t *testing.T, addr string, cert tls.Certificate) *testKMIPClient {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKMIPClient{conn}
+}
+
+func (c *testKMIPClient) send(t *testing.T, msg *Item) *Item {
+	b, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ReadItem(c, maxMessageLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Tag != TagResponseMessage {
+		t.Fatalf("bad response: %#v", resp)
+	}
+	return resp
+}
+
+// request performs one operation and returns the response payload
+func (c *testKMIPClient) request(t *testing.T, operation uint32, payload *Item) *Item {
+	item := c.send(t, requestMessage(batchItem(operation, payload))).Child(TagBatchItem)
+	if item.Child(TagResultStatus).EnumValue() != ResultStatusSuccess {
+		t.Fatalf("operation %#x failed: %s", operation, item.Child(TagResultMessage).TextValue())
+	}
+	return item.Child(TagResponsePayload)
+}
+
+// requestError performs one operation that is expected to fail
+func (c *testKMIPClient) requestError(t *testing.T, operation uint32, reason uint32, payload *Item) {
+	item := c.send(t, requestMessage(batchItem(operation, payload))).Child(TagBatchItem)
+	if item.Child(TagResultStatus).EnumValue() != ResultStatusOperationFailed {
+		t.Fatalf("expected operation %#x to fail", operation)
+	}
+	if got := item.Child(TagResultReason).EnumValue(); got != reason {
+		t.Fatalf("bad result reason %#x, expected %#x: %s", got, reason, item.Child(TagResultMessage).TextValue())
+	}
+}
+
+func requestMessage(items ...*Item) *Item {
+	msg := Structure(TagRequestMessage,
+		Structure(TagRequestHeader,
+			protocolVersion{1, 2}.item(),
+			Integer(TagBatchCount, int32(len(items)))))
+	msg.Append(items...)
+	return msg
+}
+
+func batchItem(operation uint32, payload *Item) *Item {
+	return Structure(TagBatchItem,
+		Enum(TagOperation, operation),
+		payload)
+}
+
+// testCertificate returns a self-signed certificate and its PEM encoding
+func testCertificate(t *testing.T, cn string, usage x509.ExtKeyUsage) (tls.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{usage},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
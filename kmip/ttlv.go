@@ -0,0 +1,329 @@
+package kmip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Item is a KMIP TTLV (tag, type, length, value) item. The Go type of the
+// value depends on the item type:
+//
+//	TypeStructure   []*Item
+//	TypeInteger     int32
+//	TypeLongInteger int64
+//	TypeBigInteger  []byte, two's complement big-endian
+//	TypeEnumeration uint32
+//	TypeBoolean     bool
+//	TypeTextString  string
+//	TypeByteString  []byte
+//	TypeDateTime    time.Time
+//	TypeInterval    uint32
+type Item struct {
+	Tag   Tag
+	Type  Type
+	Value interface{}
+}
+
+// Structure returns a structure item with the given children
+func Structure(tag Tag, children ...*Item) *Item {
+	return &Item{Tag: tag, Type: TypeStructure, Value: children}
+}
+
+// Integer returns an integer item
+func Integer(tag Tag, v int32) *Item {
+	return &Item{Tag: tag, Type: TypeInteger, Value: v}
+}
+
+// Enum returns an enumeration item
+func Enum(tag Tag, v uint32) *Item {
+	return &Item{Tag: tag, Type: TypeEnumeration, Value: v}
+}
+
+// Text returns a text string item
+func Text(tag Tag, v string) *Item {
+	return &Item{Tag: tag, Type: TypeTextString, Value: v}
+}
+
+// Bytes returns a byte string item
+func Bytes(tag Tag, v []byte) *Item {
+	return &Item{Tag: tag, Type: TypeByteString, Value: v}
+}
+
+// DateTime returns a date-time item
+func DateTime(tag Tag, v time.Time) *Item {
+	return &Item{Tag: tag, Type: TypeDateTime, Value: v}
+}
+
+// Children returns the children of a structure item, or nil for other items
+func (i *Item) Children() []*Item {
+	if i == nil {
+		return nil
+	}
+	children, _ := i.Value.([]*Item)
+	return children
+}
+
+// Child returns the first child of a structure item with the given tag, or
+// nil if there is none
+func (i *Item) Child(tag Tag) *Item {
+	for _, child := range i.Children() {
+		if child.Tag == tag {
+			return child
+		}
+	}
+	return nil
+}
+
+// ChildrenWithTag returns all of the children of a structure item with the
+// given tag
+func (i *Item) ChildrenWithTag(tag Tag) []*Item {
+	var ret []*Item
+	for _, child := range i.Children() {
+		if child.Tag == tag {
+			ret = append(ret, child)
+		}
+	}
+	return ret
+}
+
+// Append adds children to a structure item
+func (i *Item) Append(children ...*Item) {
+	i.Value = append(i.Children(), children...)
+}
+
+// TextValue returns the value of a text string item, or the empty string
+func (i *Item) TextValue() string {
+	if i == nil {
+		return ""
+	}
+	v, _ := i.Value.(string)
+	return v
+}
+
+// EnumValue returns the value of an enumeration item, or zero
+func (i *Item) EnumValue() uint32 {
+	if i == nil {
+		return 0
+	}
+	v, _ := i.Value.(uint32)
+	return v
+}
+
+// IntegerValue returns the value of an integer item, or zero
+func (i *Item) IntegerValue() int32 {
+	if i == nil {
+		return 0
+	}
+	v, _ := i.Value.(int32)
+	return v
+}
+
+// MarshalBinary encodes the item in TTLV
+func (i *Item) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := i.encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (i *Item) encode(buf *bytes.Buffer) error {
+	var value []byte
+	switch i.Type {
+	case TypeStructure:
+		var inner bytes.Buffer
+		for _, child := range i.Children() {
+			if err := child.encode(&inner); err != nil {
+				return err
+			}
+		}
+		value = inner.Bytes()
+	case TypeInteger:
+		v, ok := i.Value.(int32)
+		if !ok {
+			return i.typeError()
+		}
+		value = make([]byte, 4)
+		binary.BigEndian.PutUint32(value, uint32(v))
+	case TypeEnumeration, TypeInterval:
+		v, ok := i.Value.(uint32)
+		if !ok {
+			return i.typeError()
+		}
+		value = make([]byte, 4)
+		binary.BigEndian.PutUint32(value, v)
+	case TypeLongInteger:
+		v, ok := i.Value.(int64)
+		if !ok {
+			return i.typeError()
+		}
+		value = make([]byte, 8)
+		binary.BigEndian.PutUint64(value, uint64(v))
+	case TypeBoolean:
+		v, ok := i.Value.(bool)
+		if !ok {
+			return i.typeError()
+		}
+		value = make([]byte, 8)
+		if v {
+			value[7] = 1
+		}
+	case TypeDateTime:
+		v, ok := i.Value.(time.Time)
+		if !ok {
+			return i.typeError()
+		}
+		value = make([]byte, 8)
+		binary.BigEndian.PutUint64(value, uint64(v.Unix()))
+	case TypeTextString:
+		v, ok := i.Value.(string)
+		if !ok {
+			return i.typeError()
+		}
+		value = []byte(v)
+	case TypeByteString, TypeBigInteger:
+		v, ok := i.Value.([]byte)
+		if !ok {
+			return i.typeError()
+		}
+		if i.Type == TypeBigInteger && len(v)%8 != 0 {
+			return fmt.Errorf("big integer %s must be a multiple of 8 bytes long", i.Tag)
+		}
+		value = v
+	default:
+		return fmt.Errorf("unknown type %#x of %s", byte(i.Type), i.Tag)
+	}
+
+	var header [8]byte
+	header[0] = byte(i.Tag >> 16)
+	header[1] = byte(i.Tag >> 8)
+	header[2] = byte(i.Tag)
+	header[3] = byte(i.Type)
+	binary.BigEndian.PutUint32(header[4:], uint32(len(value)))
+	buf.Write(header[:])
+	buf.Write(value)
+	if pad := padding(len(value)); pad > 0 {
+		buf.Write(make([]byte, pad))
+	}
+	return nil
+}
+
+func (i *Item) typeError() error {
+	return fmt.Errorf("invalid value of type %T for %s", i.Value, i.Tag)
+}
+
+// ReadItem reads one TTLV item from the reader, refusing items longer than
+// maxLength bytes.
+func ReadItem(r io.Reader, maxLength int) (*Item, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[4:])
+	if int64(length) > int64(maxLength) {
+		return nil, fmt.Errorf("message of %d bytes is too long", length)
+	}
+
+	msg := make([]byte, 8+int(length)+padding(int(length)))
+	copy(msg, header[:])
+	if _, err := io.ReadFull(r, msg[8:]); err != nil {
+		return nil, err
+	}
+	return UnmarshalItem(msg)
+}
+
+// UnmarshalItem decodes one TTLV item, which must span the whole input
+func UnmarshalItem(b []byte) (*Item, error) {
+	item, n, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(b) {
+		return nil, fmt.Errorf("%d trailing bytes after %s", len(b)-n, item.Tag)
+	}
+	return item, nil
+}
+
+// decode decodes the item at the start of the input and returns how many
+// bytes it spans, including padding
+func decode(b []byte) (*Item, int, error) {
+	if len(b) < 8 {
+		return nil, 0, fmt.Errorf("truncated item header")
+	}
+	item := &Item{
+		Tag:  Tag(uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])),
+		Type: Type(b[3]),
+	}
+	length := int(binary.BigEndian.Uint32(b[4:8]))
+	if length < 0 || length > len(b)-8 {
+		return nil, 0, fmt.Errorf("truncated value of %s", item.Tag)
+	}
+	value := b[8 : 8+length]
+	size := 8 + length + padding(length)
+	if size > len(b) {
+		return nil, 0, fmt.Errorf("truncated padding of %s", item.Tag)
+	}
+
+	fixedLength := func(n int) error {
+		if length != n {
+			return fmt.Errorf("invalid length %d of %s", length, item.Tag)
+		}
+		return nil
+	}
+
+	switch item.Type {
+	case TypeStructure:
+		children := []*Item{}
+		for len(value) > 0 {
+			child, n, err := decode(value)
+			if err != nil {
+				return nil, 0, err
+			}
+			children = append(children, child)
+			value = value[n:]
+		}
+		item.Value = children
+	case TypeInteger:
+		if err := fixedLength(4); err != nil {
+			return nil, 0, err
+		}
+		item.Value = int32(binary.BigEndian.Uint32(value))
+	case TypeEnumeration, TypeInterval:
+		if err := fixedLength(4); err != nil {
+			return nil, 0, err
+		}
+		item.Value = binary.BigEndian.Uint32(value)
+	case TypeLongInteger:
+		if err := fixedLength(8); err != nil {
+			return nil, 0, err
+		}
+		item.Value = int64(binary.BigEndian.Uint64(value))
+	case TypeBoolean:
+		if err := fixedLength(8); err != nil {
+			return nil, 0, err
+		}
+		item.Value = binary.BigEndian.Uint64(value) != 0
+	case TypeDateTime:
+		if err := fixedLength(8); err != nil {
+			return nil, 0, err
+		}
+		item.Value = time.Unix(int64(binary.BigEndian.Uint64(value)), 0).UTC()
+	case TypeTextString:
+		item.Value = string(value)
+	case TypeByteString, TypeBigInteger:
+		item.Value = append([]byte(nil), value...)
+	default:
+		return nil, 0, fmt.Errorf("unknown type %#x of %s", byte(item.Type), item.Tag)
+	}
+
+	return item, size, nil
+}
+
+// padding returns the number of bytes that pad a value of the given length to
+// a multiple of eight bytes
+func padding(length int) int {
+	return (8 - length%8) % 8
+}
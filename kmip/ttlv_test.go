@@ -0,0 +1,84 @@
+package kmip
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestItem_MarshalBinary(t *testing.T) {
+	// Examples from section 9.1.2 of the KMIP 1.4 specification
+	cases := []struct {
+		item     *Item
+		expected string
+	}{
+		{Integer(0x420020, 8), "42002002000000040000000800000000"},
+		{&Item{Tag: 0x420020, Type: TypeLongInteger, Value: int64(123456789000000000)}, "420020030000000801B69B4BA5749200"},
+		{Enum(0x420020, 255), "4200200500000004000000FF00000000"},
+		{&Item{Tag: 0x420020, Type: TypeBoolean, Value: true}, "42002006000000080000000000000001"},
+		{Text(0x420020, "Hello World"), "420020070000000B48656C6C6F20576F726C640000000000"},
+		{Bytes(0x420020, []byte{1, 2, 3}), "42002008000000030102030000000000"},
+		{DateTime(0x420020, time.Date(2008, 3, 14, 11, 56, 40, 0, time.UTC)), "42002009000000080000000047DA67F8"},
+		{
+			Structure(0x420020, Enum(0x420004, 254), Integer(0x420005, 255)),
+			"42002001000000204200040500000004000000FE000000004200050200000004000000FF00000000",
+		},
+	}
+
+	for _, tc := range cases {
+		b, err := tc.item.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(b); got != hex.EncodeToString(mustDecodeHex(t, tc.expected)) {
+			t.Fatalf("bad encoding of %s: %s, expected %s", tc.item.Tag, got, tc.expected)
+		}
+
+		decoded, err := UnmarshalItem(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, tc.item) {
+			t.Fatalf("bad round trip: %#v, expected %#v", decoded, tc.item)
+		}
+
+		read, err := ReadItem(bytes.NewReader(b), len(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(read, tc.item) {
+			t.Fatalf("bad read: %#v, expected %#v", read, tc.item)
+		}
+	}
+}
+
+func TestUnmarshalItem_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"truncated header":  "420020020000",
+		"truncated value":   "420020020000000400000008",
+		"bad length":        "42002002000000080000000800000000",
+		"unknown type":      "420020FF000000040000000800000000",
+		"trailing bytes":    "420020020000000400000008000000000000",
+		"truncated padding": "420020070000000B48656C6C6F20576F726C64",
+	}
+	for name, input := range cases {
+		if _, err := UnmarshalItem(mustDecodeHex(t, input)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	// Messages longer than the limit are refused before they are read
+	if _, err := ReadItem(bytes.NewReader(mustDecodeHex(t, "420078010000100000")), 1024); err == nil {
+		t.Fatal("expected error reading a message that is too long")
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -20,6 +20,34 @@ var (
 	// ErrMultiAuthzPending is returned if the the request needs more
 	// authorizations
 	ErrMultiAuthzPending = errors.New("request needs further approval")
+
+	// ErrMountOverloaded is returned if the mount already handles as many
+	// requests as it is tuned to, and cannot queue the request
+	ErrMountOverloaded = errors.New("too many concurrent requests to the mount")
+
+	// ErrLoginLockedOut is returned if the auth method locked out the user
+	// or the client after too many failed logins
+	ErrLoginLockedOut = errors.New("too many failed login attempts")
+
+	// ErrQuotaExceeded is returned if the request would exceed a quota
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
+
+// The error codes sent alongside the error strings of an API error response.
+// Unlike the strings, these are stable, so clients can match on them.
+const (
+	ErrorCodeInvalidRequest       = "invalid_request"
+	ErrorCodePermissionDenied     = "permission_denied"
+	ErrorCodeNotFound             = "not_found"
+	ErrorCodeUnsupportedPath      = "unsupported_path"
+	ErrorCodeUnsupportedOperation = "unsupported_operation"
+	ErrorCodeRequestTooLarge      = "request_too_large"
+	ErrorCodeSealed               = "sealed"
+	ErrorCodeStandby              = "standby"
+	ErrorCodeUnavailable          = "unavailable"
+	ErrorCodeLockedOut            = "locked_out"
+	ErrorCodeQuotaExceeded        = "quota_exceeded"
+	ErrorCodeInternal             = "internal_error"
 )
 
 type HTTPCodedError interface {
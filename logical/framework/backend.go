@@ -2,8 +2,10 @@ package framework
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"regexp"
 	"sort"
@@ -15,6 +17,7 @@ import (
 	log "github.com/hashicorp/go-hclog"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/entropy"
 	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/parseutil"
@@ -264,6 +267,16 @@ func (b *Backend) System() logical.SystemView {
 	return b.system
 }
 
+// GetRandomReader returns the io.Reader to generate key material from. If
+// the system view gives access to an external entropy source, its entropy is
+// mixed into the one of crypto/rand; otherwise crypto/rand is used.
+func (b *Backend) GetRandomReader() io.Reader {
+	if sourcer, ok := b.System().(entropy.Sourcer); ok {
+		return entropy.NewReader(sourcer)
+	}
+	return rand.Reader
+}
+
 // Type returns the backend type
 func (b *Backend) Type() logical.BackendType {
 	return b.BackendType
@@ -360,7 +373,17 @@ func (b *Backend) handleRootHelp() (*logical.Response, error) {
 		return nil, err
 	}
 
-	return logical.HelpResponse(help, nil), nil
+	// Build the OpenAPI document of the paths
+	doc := NewOASDocument()
+	documentPaths(b, doc)
+	openapi, err := doc.toMap()
+	if err != nil {
+		return nil, errwrap.Wrapf("error building OpenAPI document: {{err}}", err)
+	}
+
+	resp := logical.HelpResponse(help, nil)
+	resp.Data["openapi"] = openapi
+	return resp, nil
 }
 
 func (b *Backend) handleRevokeRenew(ctx context.Context, req *logical.Request) (*logical.Response, error) {
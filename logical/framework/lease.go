@@ -104,3 +104,27 @@ func CalculateTTL(sysView logical.SystemView, increment, backendTTL, period, bac
 
 	return ttl, warnings, nil
 }
+
+// LeaseTTLs returns the TTL and the maximum TTL of a lease given the TTL and
+// maximum TTL configured by the backend (perhaps from a role), falling back
+// to and capped by the mount's values. The TTL is capped to the maximum TTL
+// with a warning.
+func LeaseTTLs(sysView logical.SystemView, backendTTL, backendMaxTTL time.Duration) (ttl, maxTTL time.Duration, warnings []string) {
+	ttl = backendTTL
+	if ttl <= 0 {
+		ttl = sysView.DefaultLeaseTTL()
+	}
+
+	maxTTL = sysView.MaxLeaseTTL()
+	if backendMaxTTL > 0 && backendMaxTTL < maxTTL {
+		maxTTL = backendMaxTTL
+	}
+
+	if ttl > maxTTL {
+		warnings = append(warnings,
+			fmt.Sprintf("TTL of %q exceeded the effective max_ttl of %q; TTL value is capped accordingly", ttl, maxTTL))
+		ttl = maxTTL
+	}
+
+	return ttl, maxTTL, warnings
+}
@@ -132,3 +132,63 @@ func TestCalculateTTL(t *testing.T) {
 		}
 	}
 }
+
+func TestLeaseTTLs(t *testing.T) {
+	testSysView := logical.StaticSystemView{
+		DefaultLeaseTTLVal: 5 * time.Hour,
+		MaxLeaseTTLVal:     30 * time.Hour,
+	}
+
+	cases := map[string]struct {
+		BackendTTL    time.Duration
+		BackendMaxTTL time.Duration
+		TTL           time.Duration
+		MaxTTL        time.Duration
+		Warnings      int
+	}{
+		"no backend values, uses sysview": {
+			TTL:    5 * time.Hour,
+			MaxTTL: 30 * time.Hour,
+		},
+
+		"good backend values": {
+			BackendTTL:    1 * time.Hour,
+			BackendMaxTTL: 2 * time.Hour,
+			TTL:           1 * time.Hour,
+			MaxTTL:        2 * time.Hour,
+		},
+
+		"backend max too large, capped by sysview": {
+			BackendTTL:    1 * time.Hour,
+			BackendMaxTTL: 40 * time.Hour,
+			TTL:           1 * time.Hour,
+			MaxTTL:        30 * time.Hour,
+		},
+
+		"backend ttl too large, capped by backend max": {
+			BackendTTL:    3 * time.Hour,
+			BackendMaxTTL: 2 * time.Hour,
+			TTL:           2 * time.Hour,
+			MaxTTL:        2 * time.Hour,
+			Warnings:      1,
+		},
+
+		"sysview default too large, capped by backend max": {
+			BackendMaxTTL: 2 * time.Hour,
+			TTL:           2 * time.Hour,
+			MaxTTL:        2 * time.Hour,
+			Warnings:      1,
+		},
+	}
+
+	for name, tc := range cases {
+		ttl, maxTTL, warnings := LeaseTTLs(testSysView, tc.BackendTTL, tc.BackendMaxTTL)
+		if ttl != tc.TTL || maxTTL != tc.MaxTTL {
+			t.Fatalf("bad: %s\nttl: %s\nmax ttl: %s", name, ttl, maxTTL)
+		}
+
+		if tc.Warnings != len(warnings) {
+			t.Fatalf("bad: %s\nwarning count mismatch, expect %d, got %d: %#v", name, tc.Warnings, len(warnings), warnings)
+		}
+	}
+}
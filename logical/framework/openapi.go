@@ -0,0 +1,410 @@
+package framework
+
+import (
+	"encoding/json"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// OASVersion is the version of the OpenAPI specification the documents
+// follow
+const OASVersion = "3.0.2"
+
+const (
+	// maxPatternExpansions bounds the number of paths a pattern is
+	// documented as; patterns expanding to more are not documented
+	maxPatternExpansions = 32
+
+	// maxCharClassExpansion is the largest character class expanded into
+	// literal paths
+	maxCharClassExpansion = 8
+)
+
+var oasPathParamRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// OASDocument is an OpenAPI document describing the paths of backends
+type OASDocument struct {
+	Version string                  `json:"openapi"`
+	Info    OASInfo                 `json:"info"`
+	Paths   map[string]*OASPathItem `json:"paths"`
+}
+
+// OASInfo is the metadata of an OpenAPI document
+type OASInfo struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Version     string     `json:"version"`
+	License     OASLicense `json:"license"`
+}
+
+// OASLicense is the license of the API described by an OpenAPI document
+type OASLicense struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// OASPathItem describes the operations of a path. Vault specific
+// properties are set as extensions.
+type OASPathItem struct {
+	Description     string         `json:"description,omitempty"`
+	Parameters      []OASParameter `json:"parameters,omitempty"`
+	Sudo            bool           `json:"x-vault-sudo,omitempty"`
+	Unauthenticated bool           `json:"x-vault-unauthenticated,omitempty"`
+
+	Get    *OASOperation `json:"get,omitempty"`
+	Post   *OASOperation `json:"post,omitempty"`
+	Delete *OASOperation `json:"delete,omitempty"`
+}
+
+// OASOperation describes an operation on a path
+type OASOperation struct {
+	Summary     string                  `json:"summary,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Parameters  []OASParameter          `json:"parameters,omitempty"`
+	RequestBody *OASRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OASResponse `json:"responses"`
+}
+
+// OASParameter describes a parameter of a path or of an operation
+type OASParameter struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	In          string     `json:"in"`
+	Required    bool       `json:"required,omitempty"`
+	Schema      *OASSchema `json:"schema,omitempty"`
+}
+
+// OASRequestBody describes the body of an operation
+type OASRequestBody struct {
+	Content map[string]*OASMediaType `json:"content"`
+}
+
+// OASMediaType describes the content of a body
+type OASMediaType struct {
+	Schema *OASSchema `json:"schema"`
+}
+
+// OASSchema describes the type of a parameter or a body
+type OASSchema struct {
+	Type        string                `json:"type,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Format      string                `json:"format,omitempty"`
+	Default     interface{}           `json:"default,omitempty"`
+	Items       *OASSchema            `json:"items,omitempty"`
+	Properties  map[string]*OASSchema `json:"properties,omitempty"`
+}
+
+// OASResponse describes a response of an operation
+type OASResponse struct {
+	Description string `json:"description"`
+}
+
+// NewOASDocument returns an empty OpenAPI document
+func NewOASDocument() *OASDocument {
+	return &OASDocument{
+		Version: OASVersion,
+		Info: OASInfo{
+			Title:       "HashiCorp Vault API",
+			Description: "HTTP API that gives you full access to Vault. All API routes are prefixed with `/v1/`.",
+			License: OASLicense{
+				Name: "Mozilla Public License 2.0",
+				URL:  "https://www.mozilla.org/en-US/MPL/2.0",
+			},
+		},
+		Paths: make(map[string]*OASPathItem),
+	}
+}
+
+// NewOASDocumentFromMap returns the OpenAPI document held in the data of a
+// response, as decoded from JSON
+func NewOASDocumentFromMap(raw map[string]interface{}) (*OASDocument, error) {
+	buf, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	doc := NewOASDocument()
+	if err := json.Unmarshal(buf, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// toMap returns the document as a map, so that it can be sent in the data of
+// a response by any plugin transport
+func (d *OASDocument) toMap() (map[string]interface{}, error) {
+	buf, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(buf, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// documentPaths adds the paths of the backend to the document
+func documentPaths(b *Backend, doc *OASDocument) {
+	for _, p := range b.Paths {
+		documentPath(p, b.PathsSpecial, doc)
+	}
+}
+
+// documentPath adds the paths matching the pattern of the path to the
+// document. Patterns which cannot be expressed as OpenAPI paths are skipped.
+func documentPath(p *Path, specialPaths *logical.Paths, doc *OASDocument) {
+	_, hasList := p.Callbacks[logical.ListOperation]
+
+	paths := expandPattern(p.Pattern)
+	listPaths := make(map[string]bool)
+	for _, path := range paths {
+		if strings.HasSuffix(path, "/") {
+			listPaths[strings.TrimSuffix(path, "/")] = true
+		}
+	}
+
+	for _, path := range paths {
+		// Optional trailing slashes are only kept for listing
+		path = strings.TrimSuffix(path, "/")
+		if hasList && listPaths[path] {
+			path += "/"
+		}
+		if _, ok := doc.Paths["/"+path]; ok {
+			continue
+		}
+
+		pi := &OASPathItem{
+			Description: strings.TrimSpace(p.HelpDescription),
+		}
+		if specialPaths != nil {
+			pi.Sudo = specialPathMatch(path, specialPaths.Root)
+			pi.Unauthenticated = specialPathMatch(path, specialPaths.Unauthenticated)
+		}
+
+		pathParams := make(map[string]bool)
+		for _, match := range oasPathParamRe.FindAllStringSubmatch(path, -1) {
+			name := match[1]
+			pathParams[name] = true
+			param := OASParameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &OASSchema{Type: "string"},
+			}
+			if field, ok := p.Fields[name]; ok {
+				param.Description = strings.TrimSpace(field.Description)
+				param.Schema = convertType(field.Type)
+			}
+			pi.Parameters = append(pi.Parameters, param)
+		}
+
+		newOperation := func() *OASOperation {
+			return &OASOperation{
+				Summary: strings.TrimSpace(p.HelpSynopsis),
+				Responses: map[string]*OASResponse{
+					"200": &OASResponse{Description: "OK"},
+				},
+			}
+		}
+
+		if _, ok := p.Callbacks[logical.ReadOperation]; ok || hasList {
+			op := newOperation()
+			if hasList {
+				op.Parameters = append(op.Parameters, OASParameter{
+					Name:        "list",
+					Description: "Return a list if `true`",
+					In:          "query",
+					Required:    !ok,
+					Schema:      &OASSchema{Type: "string"},
+				})
+			}
+			pi.Get = op
+		}
+
+		_, hasCreate := p.Callbacks[logical.CreateOperation]
+		if _, ok := p.Callbacks[logical.UpdateOperation]; ok || hasCreate {
+			op := newOperation()
+			body := &OASSchema{
+				Type:       "object",
+				Properties: make(map[string]*OASSchema),
+			}
+			for name, field := range p.Fields {
+				if pathParams[name] {
+					continue
+				}
+				prop := convertType(field.Type)
+				prop.Description = strings.TrimSpace(field.Description)
+				if field.Default != nil {
+					prop.Default = field.Default
+				}
+				body.Properties[name] = prop
+			}
+			if len(body.Properties) > 0 {
+				op.RequestBody = &OASRequestBody{
+					Content: map[string]*OASMediaType{
+						"application/json": &OASMediaType{Schema: body},
+					},
+				}
+			}
+			pi.Post = op
+		}
+
+		if _, ok := p.Callbacks[logical.DeleteOperation]; ok {
+			op := newOperation()
+			op.Responses = map[string]*OASResponse{
+				"204": &OASResponse{Description: "empty body"},
+			}
+			pi.Delete = op
+		}
+
+		// Paths only answering help requests are not documented
+		if pi.Get == nil && pi.Post == nil && pi.Delete == nil {
+			continue
+		}
+		doc.Paths["/"+path] = pi
+	}
+}
+
+// specialPathMatch returns whether the path matches one of the special paths,
+// which are either exact matches or prefixes ending with '*'
+func specialPathMatch(path string, specialPaths []string) bool {
+	for _, sp := range specialPaths {
+		if sp == path || (strings.HasSuffix(sp, "*") && strings.HasPrefix(path, strings.TrimSuffix(sp, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// convertType returns the OpenAPI schema of a field type
+func convertType(t FieldType) *OASSchema {
+	switch t {
+	case TypeString, TypeLowerCaseString, TypeNameString:
+		return &OASSchema{Type: "string"}
+	case TypeInt:
+		return &OASSchema{Type: "integer"}
+	case TypeBool:
+		return &OASSchema{Type: "boolean"}
+	case TypeMap, TypeKVPairs:
+		return &OASSchema{Type: "object"}
+	case TypeDurationSecond:
+		return &OASSchema{Type: "integer", Format: "seconds"}
+	case TypeCommaIntSlice:
+		return &OASSchema{Type: "array", Items: &OASSchema{Type: "integer"}}
+	case TypeStringSlice, TypeCommaStringSlice:
+		return &OASSchema{Type: "array", Items: &OASSchema{Type: "string"}}
+	case TypeSlice:
+		return &OASSchema{Type: "array", Items: &OASSchema{}}
+	default:
+		return &OASSchema{}
+	}
+}
+
+// expandPattern returns the OpenAPI paths matched by a path pattern, named
+// captures becoming path parameters. Alternations and optional groups are
+// expanded into several paths, and uncaptured wildcards become a "path"
+// parameter. It returns nil if the pattern cannot be expanded.
+func expandPattern(pattern string) []string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	paths, ok := expandRegexp(re)
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(paths))
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path = strings.TrimPrefix(path, "/")
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		result = append(result, path)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// expandRegexp returns the strings matched by the regular expression, with
+// its captures replaced by their name in braces
+func expandRegexp(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return []string{""}, true
+
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}, true
+
+	case syntax.OpCharClass:
+		var result []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				result = append(result, string(r))
+				if len(result) > maxCharClassExpansion {
+					return nil, false
+				}
+			}
+		}
+		return result, true
+
+	case syntax.OpCapture:
+		if re.Name != "" {
+			return []string{"{" + re.Name + "}"}, true
+		}
+		return expandRegexp(re.Sub[0])
+
+	case syntax.OpStar, syntax.OpPlus:
+		if op := re.Sub[0].Op; op == syntax.OpAnyChar || op == syntax.OpAnyCharNotNL {
+			return []string{"{path}"}, true
+		}
+		return nil, false
+
+	case syntax.OpQuest:
+		sub, ok := expandRegexp(re.Sub[0])
+		if !ok {
+			return nil, false
+		}
+		return append([]string{""}, sub...), true
+
+	case syntax.OpConcat:
+		result := []string{""}
+		for _, s := range re.Sub {
+			sub, ok := expandRegexp(s)
+			if !ok || len(result)*len(sub) > maxPatternExpansions {
+				return nil, false
+			}
+			product := make([]string, 0, len(result)*len(sub))
+			for _, prefix := range result {
+				for _, suffix := range sub {
+					product = append(product, prefix+suffix)
+				}
+			}
+			result = product
+		}
+		return result, true
+
+	case syntax.OpAlternate:
+		var result []string
+		for _, s := range re.Sub {
+			sub, ok := expandRegexp(s)
+			if !ok {
+				return nil, false
+			}
+			result = append(result, sub...)
+		}
+		if len(result) > maxPatternExpansions {
+			return nil, false
+		}
+		return result, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,140 @@
+package framework
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestExpandPattern(t *testing.T) {
+	cases := map[string][]string{
+		"config":                            {"config"},
+		"config/lease$":                     {"config/lease"},
+		"^roles/?$":                         {"roles", "roles/"},
+		"roles/" + GenericNameRegex("name"): {"roles/{name}"},
+		"tools/hash" + OptionalParamRegex("algo"): {"tools/hash", "tools/hash/{algo}"},
+		`(cert/)?ca_chain`:                        {"ca_chain", "cert/ca_chain"},
+		`crl(/pem)?`:                              {"crl", "crl/pem"},
+		"(creds|sts)/" + GenericNameRegex("name"): {"creds/{name}", "sts/{name}"},
+		`cert/(?P<serial>[0-9A-Fa-f-:]+)`:         {"cert/{serial}"},
+		`users/(?P<name>.+)/policies$`:            {"users/{name}/policies"},
+		"undelete/.*":                             {"undelete/{path}"},
+		".*":                                      {"{path}"},
+		"keys/[a-z]+":                             nil,
+		"invalid(":                                nil,
+		"(a|b|c|d|e|f)(a|b|c|d|e|f)(a|b|c|d|e|f)": nil,
+	}
+	for pattern, expected := range cases {
+		if actual := expandPattern(pattern); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("%s: expected %#v, got %#v", pattern, expected, actual)
+		}
+	}
+}
+
+func TestBackendHandleRequest_helpRootOpenAPI(t *testing.T) {
+	b := &Backend{
+		Help: "42",
+		Paths: []*Path{
+			&Path{
+				Pattern: "roles/?$",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ListOperation: nil,
+				},
+				HelpSynopsis: "List the roles.",
+			},
+			&Path{
+				Pattern: "roles/" + GenericNameRegex("name"),
+				Fields: map[string]*FieldSchema{
+					"name": &FieldSchema{Type: TypeString, Description: "Name of the role."},
+					"ttl":  &FieldSchema{Type: TypeDurationSecond, Description: "TTL of the role.", Default: 60},
+					"tags": &FieldSchema{Type: TypeCommaStringSlice},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation:   nil,
+					logical.UpdateOperation: nil,
+					logical.DeleteOperation: nil,
+				},
+				HelpSynopsis:    "Manage the roles.",
+				HelpDescription: "Roles describe the credentials.",
+			},
+			&Path{
+				Pattern: "login",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.UpdateOperation: nil,
+				},
+			},
+			&Path{
+				Pattern: "help-only",
+			},
+		},
+		PathsSpecial: &logical.Paths{
+			Root:            []string{"roles/*"},
+			Unauthenticated: []string{"login"},
+		},
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.HelpOperation,
+		Path:      "",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	raw, ok := resp.Data["openapi"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("bad: %#v", resp)
+	}
+	doc, err := NewOASDocumentFromMap(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != OASVersion || len(doc.Paths) != 3 {
+		t.Fatalf("bad document: %#v", doc)
+	}
+
+	list := doc.Paths["/roles/"]
+	if list == nil || list.Get == nil || list.Post != nil || list.Get.Summary != "List the roles." {
+		t.Fatalf("bad list path: %#v", list)
+	}
+	if len(list.Get.Parameters) != 1 || list.Get.Parameters[0].Name != "list" || !list.Get.Parameters[0].Required {
+		t.Fatalf("bad list parameters: %#v", list.Get.Parameters)
+	}
+
+	role := doc.Paths["/roles/{name}"]
+	if role == nil || role.Get == nil || role.Post == nil || role.Delete == nil || !role.Sudo || role.Unauthenticated {
+		t.Fatalf("bad role path: %#v", role)
+	}
+	if role.Description != "Roles describe the credentials." {
+		t.Fatalf("bad description: %q", role.Description)
+	}
+	expectedParams := []OASParameter{{
+		Name:        "name",
+		Description: "Name of the role.",
+		In:          "path",
+		Required:    true,
+		Schema:      &OASSchema{Type: "string"},
+	}}
+	if !reflect.DeepEqual(role.Parameters, expectedParams) {
+		t.Fatalf("bad parameters: %#v", role.Parameters)
+	}
+	body := role.Post.RequestBody.Content["application/json"].Schema
+	if len(body.Properties) != 2 {
+		t.Fatalf("bad body: %#v", body)
+	}
+	if ttl := body.Properties["ttl"]; ttl.Type != "integer" || ttl.Format != "seconds" || ttl.Default != float64(60) || ttl.Description != "TTL of the role." {
+		t.Fatalf("bad ttl property: %#v", ttl)
+	}
+	if tags := body.Properties["tags"]; tags.Type != "array" || tags.Items.Type != "string" {
+		t.Fatalf("bad tags property: %#v", tags)
+	}
+	if _, ok := role.Delete.Responses["204"]; !ok {
+		t.Fatalf("bad delete responses: %#v", role.Delete.Responses)
+	}
+
+	login := doc.Paths["/login"]
+	if login == nil || login.Post == nil || login.Post.RequestBody != nil || !login.Unauthenticated || login.Sudo {
+		t.Fatalf("bad login path: %#v", login)
+	}
+}
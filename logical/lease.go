@@ -51,3 +51,46 @@ func (l *LeaseOptions) ExpirationTime() time.Time {
 	}
 	return expireTime
 }
+
+// The decisions behind the TTL granted by a renewal
+const (
+	// RenewalDecisionRequested is a TTL of the requested increment, or of the
+	// TTL of the backend if none was requested
+	RenewalDecisionRequested = "requested"
+
+	// RenewalDecisionDefaultIncrement is a TTL of the default renewal
+	// increment of the mount, as no increment was requested
+	RenewalDecisionDefaultIncrement = "default_increment"
+
+	// RenewalDecisionMaxIncrement is a TTL capped to the max renewal
+	// increment of the mount
+	RenewalDecisionMaxIncrement = "max_increment"
+
+	// RenewalDecisionMaxTTL is a TTL capped to the time left before the max
+	// TTL of the lease
+	RenewalDecisionMaxTTL = "max_ttl"
+
+	// RenewalDecisionGracePeriod is a TTL extended to the max TTL of the
+	// lease, as the time left after it would have been within the renewal
+	// grace period of the mount
+	RenewalDecisionGracePeriod = "grace_period"
+)
+
+// RenewalInfo describes how the TTL granted by the renewal of a lease was
+// decided, so that clients renewing it can tell whether another renewal
+// would extend it
+type RenewalInfo struct {
+	// RequestedIncrement is the increment the client requested, zero if it
+	// requested none
+	RequestedIncrement time.Duration `json:"requested_increment"`
+
+	// TTL is the TTL granted
+	TTL time.Duration `json:"ttl"`
+
+	// Decision is the RenewalDecision* the TTL results from
+	Decision string `json:"decision"`
+
+	// Final is true if the lease now expires at its max TTL, so that
+	// renewing it again cannot extend it
+	Final bool `json:"final"`
+}
@@ -123,4 +123,9 @@ type Paths struct {
 	// should be seal wrapped with extra encryption. It is exact matching
 	// unless it ends with '/' in which case it will be treated as a prefix.
 	SealWrapStorage []string
+
+	// Idempotent are the paths where a request carrying an idempotency key
+	// is only handled once per client token and key; retries are given the
+	// lease the first request registered instead of a new secret.
+	Idempotent []string
 }
@@ -131,10 +131,28 @@ type Request struct {
 	// to make this request
 	EntityID string `json:"entity_id" structs:"entity_id" mapstructure:"entity_id" sentinel:""`
 
+	// EntityName and EntityMetadata are the name and metadata of the entity
+	// of the token used to make this request, so that backends can use them
+	// in the secrets they issue
+	EntityName     string            `json:"entity_name" structs:"entity_name" mapstructure:"entity_name" sentinel:""`
+	EntityMetadata map[string]string `json:"entity_metadata" structs:"entity_metadata" mapstructure:"entity_metadata" sentinel:""`
+
+	// TokenMetadata is the metadata of the token used to make this request,
+	// such as the user name an auth method attached at login
+	TokenMetadata map[string]string `json:"token_metadata" structs:"token_metadata" mapstructure:"token_metadata" sentinel:""`
+
 	// PolicyOverride indicates that the requestor wishes to override
 	// soft-mandatory Sentinel policies
 	PolicyOverride bool `json:"policy_override" structs:"policy_override" mapstructure:"policy_override"`
 
+	// Reason is the justification the client gave for making the request,
+	// which is recorded in the audit log
+	Reason string `json:"reason" structs:"reason" mapstructure:"reason" sentinel:""`
+
+	// IdempotencyKey is a nonce the client chose for the request, so that a
+	// retry of it on an idempotent path returns the lease already issued
+	IdempotencyKey string `json:"idempotency_key" structs:"idempotency_key" mapstructure:"idempotency_key" sentinel:""`
+
 	// Whether the request is unauthenticated, as in, had no client token
 	// attached. Useful in some situations where the client token is not made
 	// accessible.
@@ -1,9 +1,11 @@
 package logical
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
+	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/wrapping"
 )
 
@@ -25,6 +27,12 @@ const (
 	// avoided like the HTTPContentType. The value must be an integer.
 	HTTPStatusCode = "http_status_code"
 
+	// HTTPRawHeaders are the headers of the HTTP response that goes with the
+	// HTTPContentType, for specifications that require them. This can only be
+	// specified for non-secrets, and should be similarly avoided like the
+	// HTTPContentType. The value must be a map of header names to values.
+	HTTPRawHeaders = "http_raw_headers"
+
 	// For unwrapping we may need to know whether the value contained in the
 	// raw body is already JSON-unmarshaled. The presence of this key indicates
 	// that it has already been unmarshaled. That way we don't need to simply
@@ -60,6 +68,10 @@ type Response struct {
 
 	// Information for wrapping the response in a cubbyhole
 	WrapInfo *wrapping.ResponseWrapInfo `json:"wrap_info" structs:"wrap_info" mapstructure:"wrap_info"`
+
+	// Renewal, if not nil, describes how the TTL of a renewed lease was
+	// decided. It is set by the expiration manager.
+	Renewal *RenewalInfo `json:"renewal,omitempty" structs:"renewal" mapstructure:"renewal"`
 }
 
 // AddWarning adds a warning into the response's warning list
@@ -118,6 +130,60 @@ func ListResponse(keys []string) *Response {
 	return resp
 }
 
+// ListPageResponse is used to format a response to a paginated list
+// operation. The continuation token, if any, is the one to list the next page
+// with.
+func ListPageResponse(keys []string, continuation string) *Response {
+	resp := ListResponse(keys)
+	if continuation != "" {
+		resp.Data["continuation"] = continuation
+	}
+	return resp
+}
+
+// ListStorageResponse lists the keys under the given prefix of the storage of
+// a list request and formats the response. If the request gives a limit or a
+// continuation token, a page of the keys is listed instead, along with the
+// continuation token of the next page if there may be more.
+func ListStorageResponse(ctx context.Context, req *Request, prefix string) (*Response, error) {
+	var limit int64
+	if raw, ok := req.Data["limit"]; ok {
+		var err error
+		limit, err = parseutil.ParseInt(raw)
+		if err != nil || limit < 0 {
+			return ErrorResponse("limit must be a non-negative integer"), ErrInvalidRequest
+		}
+	}
+	continuation, ok := req.Data["continuation"].(string)
+	if req.Data["continuation"] != nil && !ok {
+		return ErrorResponse("continuation must be a string"), ErrInvalidRequest
+	}
+
+	if limit == 0 && continuation == "" {
+		keys, err := req.Storage.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		return ListResponse(keys), nil
+	}
+
+	// List one more key than the limit, telling whether the page is the last
+	// one. The continuation token is the last key of the page.
+	fetch := int(limit)
+	if fetch > 0 {
+		fetch++
+	}
+	keys, err := ListPage(ctx, req.Storage, prefix, continuation, fetch)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 || len(keys) <= int(limit) {
+		return ListPageResponse(keys, ""), nil
+	}
+	keys = keys[:limit]
+	return ListPageResponse(keys, keys[len(keys)-1]), nil
+}
+
 // ListResponseWithInfo is used to format a response to a list operation and
 // return the keys as well as a map with corresponding key info.
 func ListResponseWithInfo(keys []string, keyInfo map[string]interface{}) *Response {
@@ -105,6 +105,12 @@ func RespondErrorCommon(req *Request, resp *Response, err error) (int, error) {
 			statusCode = http.StatusNotFound
 		case errwrap.Contains(err, ErrInvalidRequest.Error()):
 			statusCode = http.StatusBadRequest
+		case errwrap.Contains(err, ErrMountOverloaded.Error()):
+			statusCode = http.StatusServiceUnavailable
+		case errwrap.Contains(err, ErrLoginLockedOut.Error()):
+			statusCode = http.StatusForbidden
+		case errwrap.Contains(err, ErrQuotaExceeded.Error()):
+			statusCode = http.StatusForbidden
 		}
 	}
 
@@ -115,6 +121,51 @@ func RespondErrorCommon(req *Request, resp *Response, err error) (int, error) {
 	return statusCode, err
 }
 
+// ErrorCode returns the error code of an error response with the given
+// status. The errors Vault defines are recognized within err; otherwise the
+// code follows from the status.
+func ErrorCode(status int, err error) string {
+	if err != nil {
+		switch {
+		case errwrap.Contains(err, consts.ErrSealed.Error()):
+			return ErrorCodeSealed
+		case errwrap.Contains(err, consts.ErrStandby.Error()):
+			return ErrorCodeStandby
+		case errwrap.Contains(err, ErrLoginLockedOut.Error()):
+			return ErrorCodeLockedOut
+		case errwrap.Contains(err, ErrQuotaExceeded.Error()):
+			return ErrorCodeQuotaExceeded
+		case errwrap.Contains(err, ErrPermissionDenied.Error()):
+			return ErrorCodePermissionDenied
+		case errwrap.Contains(err, ErrUnsupportedOperation.Error()):
+			return ErrorCodeUnsupportedOperation
+		case errwrap.Contains(err, ErrUnsupportedPath.Error()):
+			return ErrorCodeUnsupportedPath
+		case errwrap.Contains(err, ErrInvalidRequest.Error()):
+			return ErrorCodeInvalidRequest
+		case errwrap.Contains(err, "http: request body too large"):
+			return ErrorCodeRequestTooLarge
+		}
+	}
+
+	switch {
+	case status == http.StatusForbidden:
+		return ErrorCodePermissionDenied
+	case status == http.StatusNotFound:
+		return ErrorCodeNotFound
+	case status == http.StatusMethodNotAllowed:
+		return ErrorCodeUnsupportedOperation
+	case status == http.StatusRequestEntityTooLarge:
+		return ErrorCodeRequestTooLarge
+	case status == http.StatusServiceUnavailable:
+		return ErrorCodeUnavailable
+	case status >= 400 && status < 500:
+		return ErrorCodeInvalidRequest
+	default:
+		return ErrorCodeInternal
+	}
+}
+
 // AdjustErrorStatusCode adjusts the status that will be sent in error
 // conditions in a way that can be shared across http's respondError and other
 // locations.
@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/strutil"
 )
 
 // ErrReadOnly is returned when a backend does not support
@@ -27,6 +28,31 @@ type Storage interface {
 	Delete(context.Context, string) error
 }
 
+// Paginator is an optional interface that a Storage can implement. If it
+// does, the keys under a prefix can be listed a page at a time without every
+// one of them being loaded.
+type Paginator interface {
+	// ListPage lists, in lexical order, at most limit of the keys under a
+	// given prefix, up to the next prefix, that sort after the given key. A
+	// limit of zero or less lists all of them.
+	ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error)
+}
+
+// ListPage lists a page of the keys under a given prefix, through the
+// storage's own ListPage if it is a Paginator and otherwise by sorting all the
+// keys returned by List.
+func ListPage(ctx context.Context, s Storage, prefix, after string, limit int) ([]string, error) {
+	if p, ok := s.(Paginator); ok {
+		return p.ListPage(ctx, prefix, after, limit)
+	}
+
+	keys, err := s.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return strutil.Page(keys, after, limit), nil
+}
+
 // StorageEntry is the entry for an item in a Storage implementation.
 type StorageEntry struct {
 	Key      string
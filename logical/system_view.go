@@ -3,8 +3,10 @@ package logical
 import (
 	"context"
 	"errors"
+	"net/http"
 	"time"
 
+	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/pluginutil"
 	"github.com/hashicorp/vault/helper/wrapping"
@@ -63,6 +65,83 @@ type SystemView interface {
 	EntityInfo(entityID string) (*Entity, error)
 }
 
+// PasswordGenerator is implemented by system views that can generate
+// passwords from the password policies of Vault. It is not part of SystemView
+// so that existing implementations, like the one of plugins run over gRPC,
+// keep satisfying it; backends should check for it with a type assertion,
+// which GeneratePasswordFromPolicy does.
+type PasswordGenerator interface {
+	// GeneratePasswordFromPolicy returns a random password following the
+	// named password policy
+	GeneratePasswordFromPolicy(ctx context.Context, policyName string) (string, error)
+}
+
+// ErrPasswordPoliciesUnsupported is returned when the system view of a
+// backend cannot use the password policies of Vault.
+var ErrPasswordPoliciesUnsupported = errors.New("password policies are not supported by this Vault server")
+
+// GeneratePasswordFromPolicy returns a random password following the named
+// password policy, generated by the system view if it is a PasswordGenerator.
+// As it fails if the policy does not exist, it is also how backends validate
+// the name of a policy they are configured with.
+func GeneratePasswordFromPolicy(ctx context.Context, sys SystemView, policyName string) (string, error) {
+	generator, ok := sys.(PasswordGenerator)
+	if !ok {
+		return "", ErrPasswordPoliciesUnsupported
+	}
+	return generator.GeneratePasswordFromPolicy(ctx, policyName)
+}
+
+// PasswordChecker is implemented by system views that can check passwords,
+// such as those users choose, against the password policies of Vault. Like
+// PasswordGenerator, backends should check for it with a type assertion.
+type PasswordChecker interface {
+	// CheckPasswordAgainstPolicy returns an error if the password does not
+	// follow the named password policy
+	CheckPasswordAgainstPolicy(ctx context.Context, policyName, password string) error
+}
+
+// Auditor is implemented by system views that can log the operations a
+// backend performs on its own, such as scheduled rotations, to the audit
+// devices. Like PasswordGenerator, backends should check for it with a type
+// assertion.
+type Auditor interface {
+	// AuditRequest logs the given request, whose path is relative to the
+	// mount of the backend, as a request to the audit devices
+	AuditRequest(ctx context.Context, req *Request) error
+}
+
+// OutboundHTTPProvider is implemented by system views that know the egress
+// proxy and CA certificates the backend must use to reach external APIs.
+// Like PasswordGenerator, backends should check for it with a type
+// assertion, which OutboundTransport does.
+type OutboundHTTPProvider interface {
+	// OutboundTransport returns a new transport going through the egress
+	// proxy of the mount and trusting its CA certificates
+	OutboundTransport() (*http.Transport, error)
+}
+
+// OutboundTransport returns a new transport for the backend to reach external
+// APIs with: the one of the system view if it provides one, and otherwise
+// that of cleanhttp, which honors the proxy environment variables.
+func OutboundTransport(sys SystemView) (*http.Transport, error) {
+	if provider, ok := sys.(OutboundHTTPProvider); ok {
+		return provider.OutboundTransport()
+	}
+	return cleanhttp.DefaultTransport(), nil
+}
+
+// OutboundHTTPClient returns a new HTTP client using OutboundTransport
+func OutboundHTTPClient(sys SystemView) (*http.Client, error) {
+	transport, err := OutboundTransport(sys)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: transport,
+	}, nil
+}
+
 type StaticSystemView struct {
 	DefaultLeaseTTLVal  time.Duration
 	MaxLeaseTTLVal      time.Duration
@@ -1,11 +1,55 @@
 package logical
 
 import (
+	"fmt"
 	"time"
 
 	sockaddr "github.com/hashicorp/go-sockaddr"
 )
 
+// TokenType is the type of a token, which determines how it is stored and
+// what can be done with it
+type TokenType uint8
+
+const (
+	// TokenTypeDefault is the type of tokens issued by mounts that don't set
+	// a token type, which are service tokens
+	TokenTypeDefault TokenType = iota
+
+	// TokenTypeService tokens are persisted, and can be renewed, revoked and
+	// have child tokens
+	TokenTypeService
+
+	// TokenTypeBatch tokens are encrypted blobs that aren't persisted. They
+	// can't be renewed or revoked, and live until their TTL runs out.
+	TokenTypeBatch
+)
+
+func (t TokenType) String() string {
+	switch t {
+	case TokenTypeService:
+		return "service"
+	case TokenTypeBatch:
+		return "batch"
+	default:
+		return "default"
+	}
+}
+
+// ParseTokenType returns the token type of the given name
+func ParseTokenType(str string) (TokenType, error) {
+	switch str {
+	case "", "default":
+		return TokenTypeDefault, nil
+	case "service":
+		return TokenTypeService, nil
+	case "batch":
+		return TokenTypeBatch, nil
+	default:
+		return TokenTypeDefault, fmt.Errorf("unknown token type %q", str)
+	}
+}
+
 // TokenEntry is used to represent a given token
 type TokenEntry struct {
 	// ID of this entry, generally a random UUID
@@ -65,6 +109,21 @@ type TokenEntry struct {
 
 	// The set of CIDRs that this token can be used with
 	BoundCIDRs []*sockaddr.SockAddrMarshaler `json:"bound_cidrs"`
+
+	// The namespace the token belongs to; empty for the root namespace
+	NamespaceID string `json:"namespace_id" mapstructure:"namespace_id" structs:"namespace_id"`
+
+	// The type of the token
+	Type TokenType `json:"type" mapstructure:"type" structs:"type"`
+
+	// If set, the children of the token are orphaned rather than revoked
+	// when the token is revoked or expires
+	OrphanChildrenOnRevoke bool `json:"orphan_children_on_revoke" mapstructure:"orphan_children_on_revoke" structs:"orphan_children_on_revoke"`
+
+	// The rules of the inline policies restricting the token, inherited from
+	// its parent. The token is only allowed what both its policies and each
+	// of them allow.
+	InlinePolicies []string `json:"inline_policies,omitempty" mapstructure:"inline_policies" structs:"inline_policies"`
 }
 
 func (te *TokenEntry) SentinelGet(key string) (interface{}, error) {
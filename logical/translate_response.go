@@ -39,6 +39,15 @@ func LogicalResponseToHTTPResponse(input *Response) *HTTPResponse {
 		}
 	}
 
+	if input.Renewal != nil {
+		httpResp.Renewal = &HTTPRenewal{
+			RequestedIncrement: int(input.Renewal.RequestedIncrement.Seconds()),
+			TTL:                int(input.Renewal.TTL.Seconds()),
+			Decision:           input.Renewal.Decision,
+			Final:              input.Renewal.Final,
+		}
+	}
+
 	return httpResp
 }
 
@@ -70,6 +79,15 @@ func HTTPResponseToLogicalResponse(input *HTTPResponse) *Response {
 		logicalResp.Auth.TTL = time.Second * time.Duration(input.Auth.LeaseDuration)
 	}
 
+	if input.Renewal != nil {
+		logicalResp.Renewal = &RenewalInfo{
+			RequestedIncrement: time.Second * time.Duration(input.Renewal.RequestedIncrement),
+			TTL:                time.Second * time.Duration(input.Renewal.TTL),
+			Decision:           input.Renewal.Decision,
+			Final:              input.Renewal.Final,
+		}
+	}
+
 	return logicalResp
 }
 
@@ -82,6 +100,7 @@ type HTTPResponse struct {
 	WrapInfo      *HTTPWrapInfo          `json:"wrap_info"`
 	Warnings      []string               `json:"warnings"`
 	Auth          *HTTPAuth              `json:"auth"`
+	Renewal       *HTTPRenewal           `json:"renewal,omitempty"`
 }
 
 type HTTPAuth struct {
@@ -96,6 +115,13 @@ type HTTPAuth struct {
 	EntityID         string            `json:"entity_id"`
 }
 
+type HTTPRenewal struct {
+	RequestedIncrement int    `json:"requested_increment"`
+	TTL                int    `json:"ttl"`
+	Decision           string `json:"decision"`
+	Final              bool   `json:"final"`
+}
+
 type HTTPWrapInfo struct {
 	Token           string `json:"token"`
 	Accessor        string `json:"accessor"`
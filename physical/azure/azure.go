@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"sort"
 	"strconv"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	storage "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/errwrap"
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
@@ -25,8 +27,23 @@ const (
 	MaxBlobSize = 1024 * 1024 * 4
 	// MaxListResults is the current default value, setting explicitly
 	MaxListResults = 5000
+	// DefaultMaxRetries is the number of times a failed request is retried
+	DefaultMaxRetries = 4
+	// RetryInterval is the base of the exponential backoff between retries
+	RetryInterval = 5 * time.Second
 )
 
+// retryStatusCodes are the status codes of the responses to retry, which
+// include those of requests the storage account throttled
+var retryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
 // AzureBackend is a physical backend that stores data
 // within an Azure blob container.
 type AzureBackend struct {
@@ -66,12 +83,39 @@ func NewAzureBackend(conf map[string]string, logger log.Logger) (physical.Backen
 		}
 	}
 
-	client, err := storage.NewBasicClient(accountName, accountKey)
+	environmentName := os.Getenv("AZURE_ENVIRONMENT")
+	if environmentName == "" {
+		environmentName = conf["environment"]
+		if environmentName == "" {
+			environmentName = "AzurePublicCloud"
+		}
+	}
+	environment, err := azure.EnvironmentFromName(environmentName)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to look up Azure environment descriptor for name %q: {{err}}", environmentName), err)
+	}
+
+	client, err := storage.NewBasicClientOnSovereignCloud(accountName, accountKey, environment)
 	if err != nil {
 		return nil, errwrap.Wrapf("failed to create Azure client: {{err}}", err)
 	}
 	client.HTTPClient = cleanhttp.DefaultPooledClient()
 
+	maxRetries := DefaultMaxRetries
+	if maxRetriesStr, ok := conf["max_retries"]; ok {
+		maxRetries, err = strconv.Atoi(maxRetriesStr)
+		if err != nil || maxRetries < 0 {
+			return nil, fmt.Errorf("invalid max_retries: %q", maxRetriesStr)
+		}
+	}
+	// Requests throttled by the storage account are retried with an
+	// exponential backoff, along with those failing with server errors
+	client.Sender = &storage.DefaultSender{
+		RetryAttempts:    maxRetries + 1,
+		RetryDuration:    RetryInterval,
+		ValidStatusCodes: retryStatusCodes,
+	}
+
 	blobClient := client.GetBlobService()
 	container := blobClient.GetContainerReference(name)
 	_, err = container.CreateIfNotExists(&storage.CreateContainerOptions{
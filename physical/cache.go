@@ -182,6 +182,11 @@ func (c *Cache) List(ctx context.Context, prefix string) ([]string, error) {
 	return c.backend.List(ctx, prefix)
 }
 
+func (c *Cache) ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error) {
+	// Always pass-through, as for List
+	return ListPage(ctx, c.backend, prefix, after, limit)
+}
+
 func (c *TransactionalCache) Transaction(ctx context.Context, txns []*TxnEntry) error {
 	// Bypass the locking below
 	if atomic.LoadUint32(c.enabled) == 0 {
@@ -133,14 +133,20 @@ func getEtcdEndpoints(conf map[string]string) ([]string, error) {
 	}
 
 	if useSrv {
-		discoverer := client.NewSRVDiscover()
-		endpoints, err := discoverer.Discover(domain)
-		if err != nil {
-			return nil, errwrap.Wrapf("failed to discover etcd endpoints through SRV discovery: {{err}}", err)
-		}
-		return endpoints, nil
+		return discoverEtcdEndpoints(domain)
 	}
 
 	// Set a default endpoints list if no option was set
 	return []string{"http://127.0.0.1:2379"}, nil
 }
+
+// discoverEtcdEndpoints looks up the endpoints of the cluster through the
+// SRV records of the domain
+func discoverEtcdEndpoints(domain string) ([]string, error) {
+	discoverer := client.NewSRVDiscover()
+	endpoints, err := discoverer.Discover(domain)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to discover etcd endpoints through SRV discovery: {{err}}", err)
+	}
+	return endpoints, nil
+}
@@ -19,6 +19,8 @@ import (
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/physical"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // EtcdBackend is a physical backend that stores data at specific
@@ -32,6 +34,12 @@ type EtcdBackend struct {
 	permitPool *physical.PermitPool
 
 	etcd *clientv3.Client
+
+	// discoverySRV is the domain the endpoints were discovered through,
+	// if any, which they are discovered through again when requests fail
+	discoverySRV  string
+	discoveryLock sync.Mutex
+	lastDiscovery time.Time
 }
 
 const (
@@ -146,15 +154,47 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 		}
 	}
 
+	discoverySRV, _ := getEtcdOption(conf, "discovery_srv", "ETCD_DISCOVERY_SRV")
+
 	return &EtcdBackend{
-		path:       path,
-		etcd:       etcd,
-		permitPool: physical.NewPermitPool(physical.DefaultParallelOperations),
-		logger:     logger,
-		haEnabled:  haEnabledBool,
+		path:          path,
+		etcd:          etcd,
+		permitPool:    physical.NewPermitPool(physical.DefaultParallelOperations),
+		logger:        logger,
+		haEnabled:     haEnabledBool,
+		discoverySRV:  discoverySRV,
+		lastDiscovery: time.Now(),
 	}, nil
 }
 
+// rediscoverEndpoints discovers the endpoints again after a request failed
+// to reach the cluster, as its members may have moved. It happens at most
+// once per request timeout.
+func (c *EtcdBackend) rediscoverEndpoints(err error) {
+	if c.discoverySRV == "" {
+		return
+	}
+	if err != context.DeadlineExceeded && status.Code(err) != codes.Unavailable {
+		return
+	}
+
+	c.discoveryLock.Lock()
+	defer c.discoveryLock.Unlock()
+
+	if time.Since(c.lastDiscovery) < etcd3RequestTimeout {
+		return
+	}
+	c.lastDiscovery = time.Now()
+
+	endpoints, err := discoverEtcdEndpoints(c.discoverySRV)
+	if err != nil {
+		c.logger.Warn("failed to discover the endpoints again", "error", err)
+		return
+	}
+	c.logger.Info("discovered the endpoints again", "endpoints", endpoints)
+	c.etcd.SetEndpoints(endpoints...)
+}
+
 func (c *EtcdBackend) Put(ctx context.Context, entry *physical.Entry) error {
 	defer metrics.MeasureSince([]string{"etcd", "put"}, time.Now())
 
@@ -164,6 +204,9 @@ func (c *EtcdBackend) Put(ctx context.Context, entry *physical.Entry) error {
 	ctx, cancel := context.WithTimeout(context.Background(), etcd3RequestTimeout)
 	defer cancel()
 	_, err := c.etcd.Put(ctx, path.Join(c.path, entry.Key), string(entry.Value))
+	if err != nil {
+		c.rediscoverEndpoints(err)
+	}
 	return err
 }
 
@@ -177,6 +220,7 @@ func (c *EtcdBackend) Get(ctx context.Context, key string) (*physical.Entry, err
 	defer cancel()
 	resp, err := c.etcd.Get(ctx, path.Join(c.path, key))
 	if err != nil {
+		c.rediscoverEndpoints(err)
 		return nil, err
 	}
 
@@ -202,6 +246,7 @@ func (c *EtcdBackend) Delete(ctx context.Context, key string) error {
 	defer cancel()
 	_, err := c.etcd.Delete(ctx, path.Join(c.path, key))
 	if err != nil {
+		c.rediscoverEndpoints(err)
 		return err
 	}
 	return nil
@@ -218,6 +263,7 @@ func (c *EtcdBackend) List(ctx context.Context, prefix string) ([]string, error)
 	prefix = path.Join(c.path, prefix) + "/"
 	resp, err := c.etcd.Get(ctx, prefix, clientv3.WithPrefix())
 	if err != nil {
+		c.rediscoverEndpoints(err)
 		return nil, err
 	}
 
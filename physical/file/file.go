@@ -16,6 +16,7 @@ import (
 
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/physical"
 )
 
@@ -23,6 +24,8 @@ import (
 var _ physical.Backend = (*FileBackend)(nil)
 var _ physical.Transactional = (*TransactionalFileBackend)(nil)
 var _ physical.PseudoTransactional = (*FileBackend)(nil)
+var _ physical.Snapshotter = (*FileBackend)(nil)
+var _ physical.Paginator = (*FileBackend)(nil)
 
 // FileBackend is a physical backend that stores data on disk
 // at a given file path. It can be used for durable single server
@@ -342,6 +345,112 @@ func (b *FileBackend) ListInternal(ctx context.Context, prefix string) ([]string
 	return names, nil
 }
 
+// ListPage lists a page of the keys under the given prefix. Only the entries
+// of the page are looked up, the others being told apart by their name alone:
+// the files of the entries are prefixed with an underscore.
+func (b *FileBackend) ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error) {
+	b.permitPool.Acquire()
+	defer b.permitPool.Release()
+
+	b.RLock()
+	defer b.RUnlock()
+
+	if err := b.validatePath(prefix); err != nil {
+		return nil, err
+	}
+
+	path := b.path
+	if prefix != "" {
+		path = filepath.Join(path, prefix)
+	}
+
+	f, err := os.Open(path)
+	if f != nil {
+		defer f.Close()
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, name := range names {
+		if name[0] == '_' {
+			names[i] = name[1:]
+		} else {
+			names[i] = name + "/"
+		}
+	}
+
+	page := strutil.Page(names, after, limit)
+	for i, key := range page {
+		if !strings.HasSuffix(key, "/") {
+			continue
+		}
+		fi, err := os.Stat(filepath.Join(path, strings.TrimSuffix(key, "/")))
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			page[i] = strings.TrimSuffix(key, "/")
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return page, nil
+}
+
+// Snapshot writes all the entries of the backend to w, in the format of the
+// snapshots of the inmem backend
+func (b *FileBackend) Snapshot(w io.Writer) error {
+	b.permitPool.Acquire()
+	defer b.permitPool.Release()
+
+	b.RLock()
+	defer b.RUnlock()
+
+	enc := json.NewEncoder(w)
+	var walk func(prefix string) error
+	walk = func(prefix string) error {
+		keys, err := b.ListInternal(context.Background(), prefix)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if strings.HasSuffix(key, "/") {
+				if err := walk(prefix + key); err != nil {
+					return err
+				}
+				continue
+			}
+			entry, err := b.GetInternal(context.Background(), prefix+key)
+			if err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("failed to read %q: {{err}}", prefix+key), err)
+			}
+			if entry == nil {
+				continue
+			}
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk("")
+}
+
 func (b *FileBackend) expandPath(k string) (string, string) {
 	path := filepath.Join(b.path, k)
 	key := filepath.Base(path)
@@ -1,6 +1,7 @@
 package file
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io/ioutil"
@@ -236,4 +237,52 @@ func TestFileBackend(t *testing.T) {
 	}
 
 	physical.ExerciseBackend_ListPrefix(t, b)
+	physical.ExerciseBackend_ListPage(t, b)
+}
+
+func TestFileBackend_Snapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logger := logging.NewVaultLogger(log.Debug)
+
+	b, err := NewFileBackend(map[string]string{
+		"path": dir,
+	}, logger)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx := context.Background()
+	for _, key := range []string{"foo", "foo/bar", "baz/qux/quux"} {
+		if err := b.Put(ctx, &physical.Entry{Key: key, Value: []byte("value of " + key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := b.(physical.Snapshotter).Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	entries := map[string]string{}
+	for dec.More() {
+		var entry physical.Entry
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		entries[entry.Key] = string(entry.Value)
+	}
+	expected := map[string]string{
+		"foo":          "value of foo",
+		"foo/bar":      "value of foo/bar",
+		"baz/qux/quux": "value of baz/qux/quux",
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("bad: %#v", entries)
+	}
 }
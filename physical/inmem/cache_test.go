@@ -19,6 +19,7 @@ func TestCache(t *testing.T) {
 	cache := physical.NewCache(inm, 0, logger)
 	physical.ExerciseBackend(t, cache)
 	physical.ExerciseBackend_ListPrefix(t, cache)
+	physical.ExerciseBackend_ListPage(t, cache)
 }
 
 func TestCache_Purge(t *testing.T) {
@@ -267,3 +268,49 @@ func TestCache_Disable(t *testing.T) {
 	cache.SetEnabled(false)
 	disabledTests()
 }
+
+func TestCache_ExcludedPaths(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	inm, err := NewInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := physical.NewCache(inm, 0, logger)
+	cache.SetEnabled(true)
+
+	for _, key := range []string{"foo", "sys/expire/id/foo", "wal/logs/foo"} {
+		err = cache.Put(context.Background(), &physical.Entry{
+			Key:   key,
+			Value: []byte("bar"),
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Delete from under the cache
+		if err := inm.Delete(context.Background(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The cached key should still be readable
+	out, err := cache.Get(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("should have key")
+	}
+
+	// Excluded paths must always be read from the underlying backend
+	for _, key := range []string{"sys/expire/id/foo", "wal/logs/foo"} {
+		out, err := cache.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out != nil {
+			t.Fatalf("should not have key %q", key)
+		}
+	}
+}
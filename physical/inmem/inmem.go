@@ -15,6 +15,8 @@ import (
 
 // Verify interfaces are satisfied
 var _ physical.Backend = (*InmemBackend)(nil)
+var _ physical.Paginator = (*InmemBackend)(nil)
+var _ physical.Paginator = (*InmemHABackend)(nil)
 var _ physical.HABackend = (*InmemHABackend)(nil)
 var _ physical.HABackend = (*TransactionalInmemHABackend)(nil)
 var _ physical.Lock = (*InmemLock)(nil)
@@ -227,6 +229,44 @@ func (i *InmemBackend) ListInternal(ctx context.Context, prefix string) ([]strin
 	return out, nil
 }
 
+// ListPage is used to list, in lexical order, at most limit of the keys under
+// a given prefix, up to the next prefix, that sort after the given key.
+func (i *InmemBackend) ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error) {
+	i.permitPool.Acquire()
+	defer i.permitPool.Release()
+
+	i.RLock()
+	defer i.RUnlock()
+
+	if atomic.LoadUint32(i.failList) != 0 {
+		return nil, ListDisabledError
+	}
+
+	// The tree is walked in lexical order, in which the keys of a same
+	// sub-prefix are next to each other
+	var out []string
+	walkFn := func(s string, v interface{}) bool {
+		trimmed := strings.TrimPrefix(s, prefix)
+		if sep := strings.Index(trimmed, "/"); sep != -1 {
+			trimmed = trimmed[:sep+1]
+		}
+		if trimmed <= after || (len(out) > 0 && out[len(out)-1] == trimmed) {
+			return false
+		}
+		out = append(out, trimmed)
+		return limit > 0 && len(out) >= limit
+	}
+	i.root.WalkPrefix(prefix, walkFn)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return out, nil
+}
+
 func (i *InmemBackend) FailList(fail bool) {
 	var val uint32
 	if fail {
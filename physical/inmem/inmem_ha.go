@@ -1,6 +1,7 @@
 package inmem
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -58,6 +59,11 @@ func NewTransactionalInmemHA(_ map[string]string, logger log.Logger) (physical.B
 	return in, nil
 }
 
+// ListPage is used to list a page of the keys under a given prefix.
+func (i *InmemHABackend) ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error) {
+	return physical.ListPage(ctx, i.Backend, prefix, after, limit)
+}
+
 // LockWith is used for mutual exclusion based on the given key.
 func (i *InmemHABackend) LockWith(key, value string) (physical.Lock, error) {
 	l := &InmemLock{
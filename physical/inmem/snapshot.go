@@ -0,0 +1,90 @@
+package inmem
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/armon/go-radix"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/physical"
+)
+
+// Verify InmemBackend satisfies the correct interfaces
+var _ physical.Snapshotter = (*InmemBackend)(nil)
+
+// Snapshot writes all the entries of the backend to w, so that they can be
+// restored into a backend later with Restore
+func (i *InmemBackend) Snapshot(w io.Writer) error {
+	i.RLock()
+	defer i.RUnlock()
+
+	enc := json.NewEncoder(w)
+	var err error
+	i.root.Walk(func(key string, v interface{}) bool {
+		err = enc.Encode(&physical.Entry{
+			Key:   key,
+			Value: v.([]byte),
+		})
+		return err != nil
+	})
+	return err
+}
+
+// Restore replaces the entries of the backend with those of a snapshot
+// written by Snapshot. The entries are left untouched if the snapshot cannot
+// be read.
+func (i *InmemBackend) Restore(r io.Reader) error {
+	root := radix.New()
+	dec := json.NewDecoder(r)
+	for {
+		var entry physical.Entry
+		err := dec.Decode(&entry)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errwrap.Wrapf("failed to decode snapshot: {{err}}", err)
+		}
+		root.Insert(entry.Key, entry.Value)
+	}
+
+	i.Lock()
+	defer i.Unlock()
+	i.root = root
+	return nil
+}
+
+// SnapshotFile writes a snapshot of the backend to the file at path. The
+// snapshot is written to a temporary file first, so that an existing
+// snapshot is only replaced by a complete one.
+func (i *InmemBackend) SnapshotFile(path string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := i.Snapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// RestoreFile restores the snapshot in the file at path written by
+// SnapshotFile
+func (i *InmemBackend) RestoreFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return i.Restore(f)
+}
@@ -0,0 +1,93 @@
+package inmem
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/physical"
+)
+
+func TestInmem_Snapshot(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	b, err := NewInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inm := b.(*InmemBackend)
+
+	ctx := context.Background()
+	for _, key := range []string{"foo", "foo/bar", "baz"} {
+		if err := inm.Put(ctx, &physical.Entry{Key: key, Value: []byte("value of " + key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "vault-inmem-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot")
+
+	if err := inm.SnapshotFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes after the snapshot are undone by restoring it
+	if err := inm.Delete(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := inm.Put(ctx, &physical.Entry{Key: "qux", Value: []byte("new")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := inm.RestoreFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := inm.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"baz", "foo", "foo/"}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+	entry, err := inm.Get(ctx, "foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || string(entry.Value) != "value of foo/bar" {
+		t.Fatalf("bad: %#v", entry)
+	}
+
+	// A snapshot can be restored into another backend
+	other, err := NewInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.(*InmemBackend).RestoreFile(path); err != nil {
+		t.Fatal(err)
+	}
+	entry, err = other.Get(ctx, "baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || string(entry.Value) != "value of baz" {
+		t.Fatalf("bad: %#v", entry)
+	}
+
+	// An invalid snapshot leaves the entries untouched
+	if err := inm.Restore(strings.NewReader("not a snapshot")); err == nil {
+		t.Fatal("expected error")
+	}
+	if entry, err := inm.Get(ctx, "baz"); err != nil || entry == nil {
+		t.Fatalf("expected the entries to be kept, got %#v, err: %v", entry, err)
+	}
+}
@@ -2,10 +2,12 @@ package physical
 
 import (
 	"context"
+	"io"
 	"strings"
 	"sync"
 
 	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/strutil"
 )
 
 const DefaultParallelOperations = 128
@@ -72,6 +74,39 @@ type RedirectDetect interface {
 	DetectHostAddr() (string, error)
 }
 
+// Snapshotter is an optional interface that a Backend can implement. If it
+// does, snapshots of all its entries can be taken while it is in use, each
+// entry written as a JSON-encoded Entry.
+type Snapshotter interface {
+	// Snapshot writes all the entries of the backend to w
+	Snapshot(w io.Writer) error
+}
+
+// Paginator is an optional interface that a Backend can implement. If it
+// does, the keys under a prefix can be listed a page at a time without every
+// one of them being loaded.
+type Paginator interface {
+	// ListPage is used to list, in lexical order, at most limit of the keys
+	// under a given prefix, up to the next prefix, that sort after the given
+	// key. A limit of zero or less lists all of them.
+	ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error)
+}
+
+// ListPage lists a page of the keys under a given prefix, through the
+// backend's own ListPage if it is a Paginator and otherwise by sorting all the
+// keys returned by List.
+func ListPage(ctx context.Context, b Backend, prefix, after string, limit int) ([]string, error) {
+	if p, ok := b.(Paginator); ok {
+		return p.ListPage(ctx, prefix, after, limit)
+	}
+
+	keys, err := b.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return strutil.Page(keys, after, limit), nil
+}
+
 // Callback signatures for RunServiceDiscovery
 type ActiveFunction func() bool
 type SealedFunction func() bool
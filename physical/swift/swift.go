@@ -3,6 +3,7 @@ package swift
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"sort"
 	"strconv"
@@ -25,12 +26,21 @@ var _ physical.Backend = (*SwiftBackend)(nil)
 // SwiftBackend is a physical backend that stores data
 // within an OpenStack Swift container.
 type SwiftBackend struct {
-	container  string
-	client     *swift.Connection
-	logger     log.Logger
-	permitPool *physical.PermitPool
+	container     string
+	client        *swift.Connection
+	logger        log.Logger
+	permitPool    *physical.PermitPool
+	maxRetries    int
+	retryInterval time.Duration
 }
 
+const (
+	// DefaultMaxRetries is the number of times a throttled request is retried
+	DefaultMaxRetries = 3
+	// RetryInterval is the base of the exponential backoff between retries
+	RetryInterval = 500 * time.Millisecond
+)
+
 // NewSwiftBackend constructs a Swift backend using a pre-existing
 // container. Credentials can be provided to the backend, sourced
 // from the environment.
@@ -143,15 +153,56 @@ func NewSwiftBackend(conf map[string]string, logger log.Logger) (physical.Backen
 		}
 	}
 
+	maxRetries := DefaultMaxRetries
+	if maxRetriesStr, ok := conf["max_retries"]; ok {
+		maxRetries, err = strconv.Atoi(maxRetriesStr)
+		if err != nil || maxRetries < 0 {
+			return nil, fmt.Errorf("invalid max_retries: %q", maxRetriesStr)
+		}
+	}
+
 	s := &SwiftBackend{
-		client:     &c,
-		container:  container,
-		logger:     logger,
-		permitPool: physical.NewPermitPool(maxParInt),
+		client:        &c,
+		container:     container,
+		logger:        logger,
+		permitPool:    physical.NewPermitPool(maxParInt),
+		maxRetries:    maxRetries,
+		retryInterval: RetryInterval,
 	}
 	return s, nil
 }
 
+// retry calls f until it succeeds, fails other than by being throttled, or
+// the retries are exhausted, waiting with an exponential backoff in between
+func (s *SwiftBackend) retry(ctx context.Context, f func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if !throttled(err) || attempt >= s.maxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(s.retryInterval << uint(attempt)):
+		}
+	}
+}
+
+// throttled returns whether the error is the response to a request the
+// cluster rejected for exceeding its rate limits
+func throttled(err error) bool {
+	swiftErr, ok := err.(*swift.Error)
+	if !ok {
+		return false
+	}
+	switch swiftErr.StatusCode {
+	case swift.RateLimit.StatusCode, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+	return false
+}
+
 // Put is used to insert or update an entry
 func (s *SwiftBackend) Put(ctx context.Context, entry *physical.Entry) error {
 	defer metrics.MeasureSince([]string{"swift", "put"}, time.Now())
@@ -159,7 +210,9 @@ func (s *SwiftBackend) Put(ctx context.Context, entry *physical.Entry) error {
 	s.permitPool.Acquire()
 	defer s.permitPool.Release()
 
-	err := s.client.ObjectPutBytes(s.container, entry.Key, entry.Value, "")
+	err := s.retry(ctx, func() error {
+		return s.client.ObjectPutBytes(s.container, entry.Key, entry.Value, "")
+	})
 
 	if err != nil {
 		return err
@@ -178,14 +231,22 @@ func (s *SwiftBackend) Get(ctx context.Context, key string) (*physical.Entry, er
 	//Do a list of names with the key first since eventual consistency means
 	//it might be deleted, but a node might return a read of bytes which fails
 	//the physical test
-	list, err := s.client.ObjectNames(s.container, &swift.ObjectsOpts{Prefix: key})
+	var list []string
+	err := s.retry(ctx, func() (err error) {
+		list, err = s.client.ObjectNames(s.container, &swift.ObjectsOpts{Prefix: key})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	if 0 == len(list) {
 		return nil, nil
 	}
-	data, err := s.client.ObjectGetBytes(s.container, key)
+	var data []byte
+	err = s.retry(ctx, func() (err error) {
+		data, err = s.client.ObjectGetBytes(s.container, key)
+		return err
+	})
 	if err == swift.ObjectNotFound {
 		return nil, nil
 	}
@@ -207,7 +268,9 @@ func (s *SwiftBackend) Delete(ctx context.Context, key string) error {
 	s.permitPool.Acquire()
 	defer s.permitPool.Release()
 
-	err := s.client.ObjectDelete(s.container, key)
+	err := s.retry(ctx, func() error {
+		return s.client.ObjectDelete(s.container, key)
+	})
 
 	if err != nil && err != swift.ObjectNotFound {
 		return err
@@ -224,7 +287,11 @@ func (s *SwiftBackend) List(ctx context.Context, prefix string) ([]string, error
 	s.permitPool.Acquire()
 	defer s.permitPool.Release()
 
-	list, err := s.client.ObjectNamesAll(s.container, &swift.ObjectsOpts{Prefix: prefix})
+	var list []string
+	err := s.retry(ctx, func() (err error) {
+		list, err = s.client.ObjectNamesAll(s.container, &swift.ObjectsOpts{Prefix: prefix})
+		return err
+	})
 	if nil != err {
 		return nil, err
 	}
@@ -1,7 +1,9 @@
 package swift
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"testing"
 	"time"
@@ -89,3 +91,47 @@ func TestSwiftBackend(t *testing.T) {
 	physical.ExerciseBackend(t, b)
 	physical.ExerciseBackend_ListPrefix(t, b)
 }
+
+func TestSwiftBackend_Retry(t *testing.T) {
+	s := &SwiftBackend{
+		maxRetries:    2,
+		retryInterval: time.Millisecond,
+	}
+
+	cases := []struct {
+		err      error
+		attempts int
+	}{
+		{nil, 1},
+		{swift.ObjectNotFound, 1},
+		{swift.RateLimit, 3},
+		{&swift.Error{StatusCode: http.StatusTooManyRequests}, 3},
+	}
+
+	for _, tc := range cases {
+		var attempts int
+		err := s.retry(context.Background(), func() error {
+			attempts++
+			return tc.err
+		})
+		if err != tc.err {
+			t.Fatalf("%v: expected the error to be returned, got %v", tc.err, err)
+		}
+		if attempts != tc.attempts {
+			t.Fatalf("%v: expected %d attempts, got %d", tc.err, tc.attempts, attempts)
+		}
+	}
+
+	// Throttled requests succeeding when retried
+	var attempts int
+	err := s.retry(context.Background(), func() error {
+		attempts++
+		if attempts == 1 {
+			return swift.RateLimit
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("expected success on the second attempt, got %d attempts, err: %v", attempts, err)
+	}
+}
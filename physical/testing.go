@@ -288,6 +288,46 @@ func ExerciseBackend_ListPrefix(t testing.TB, b Backend) {
 	}
 }
 
+func ExerciseBackend_ListPage(t testing.TB, b Backend) {
+	t.Helper()
+
+	keys := []string{"page/c", "page/a", "page/b/1", "page/b/2", "page/d"}
+	defer func() {
+		for _, key := range keys {
+			b.Delete(context.Background(), key)
+		}
+	}()
+	for _, key := range keys {
+		if err := b.Put(context.Background(), &Entry{Key: key, Value: []byte("test")}); err != nil {
+			t.Fatalf("failed to put %q: %v", key, err)
+		}
+	}
+
+	cases := []struct {
+		after    string
+		limit    int
+		expected []string
+	}{
+		{"", 0, []string{"a", "b/", "c", "d"}},
+		{"", 2, []string{"a", "b/"}},
+		{"b/", 2, []string{"c", "d"}},
+		{"a", 0, []string{"b/", "c", "d"}},
+		{"d", 1, nil},
+	}
+	for _, tc := range cases {
+		page, err := ListPage(context.Background(), b, "page/", tc.after, tc.limit)
+		if err != nil {
+			t.Fatalf("list page after %q: %v", tc.after, err)
+		}
+		if len(page) == 0 && len(tc.expected) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(page, tc.expected) {
+			t.Errorf("page after %q with limit %d expected %v: %v", tc.after, tc.limit, tc.expected, page)
+		}
+	}
+}
+
 func ExerciseHABackend(t testing.TB, b HABackend, b2 HABackend) {
 	t.Helper()
 
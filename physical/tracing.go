@@ -0,0 +1,118 @@
+package physical
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/vault/helper/tracing"
+)
+
+// TracingBackend records a span for each request to the underlying physical
+// backend made on behalf of a traced request
+type TracingBackend struct {
+	backend Backend
+}
+
+// TransactionalTracingBackend is the transactional version of the tracing
+// backend
+type TransactionalTracingBackend struct {
+	*TracingBackend
+	Transactional
+}
+
+// Verify TracingBackend satisfies the correct interfaces
+var _ Backend = (*TracingBackend)(nil)
+var _ Transactional = (*TransactionalTracingBackend)(nil)
+
+// NewTracingBackend returns a wrapped physical backend recording spans
+func NewTracingBackend(b Backend) *TracingBackend {
+	return &TracingBackend{
+		backend: b,
+	}
+}
+
+// NewTransactionalTracingBackend creates a new transactional TracingBackend
+func NewTransactionalTracingBackend(b Backend) *TransactionalTracingBackend {
+	return &TransactionalTracingBackend{
+		TracingBackend: NewTracingBackend(b),
+		Transactional:  b.(Transactional),
+	}
+}
+
+func startStorageSpan(ctx context.Context, operation string) (*tracing.Span, context.Context) {
+	span, ctx := tracing.StartSpan(ctx, "storage."+operation)
+	if span != nil {
+		span.Kind = tracing.KindClient
+	}
+	return span, ctx
+}
+
+func finishStorageSpan(span *tracing.Span, err error) {
+	if err != nil {
+		span.SetTag("error", err.Error())
+	}
+	span.Finish()
+}
+
+// Put is a traced put request
+func (t *TracingBackend) Put(ctx context.Context, entry *Entry) (err error) {
+	span, ctx := startStorageSpan(ctx, "put")
+	if span != nil {
+		span.SetTag("key", entry.Key)
+		defer func() { finishStorageSpan(span, err) }()
+	}
+	return t.backend.Put(ctx, entry)
+}
+
+// Get is a traced get request
+func (t *TracingBackend) Get(ctx context.Context, key string) (entry *Entry, err error) {
+	span, ctx := startStorageSpan(ctx, "get")
+	if span != nil {
+		span.SetTag("key", key)
+		defer func() {
+			span.SetTag("found", strconv.FormatBool(entry != nil))
+			finishStorageSpan(span, err)
+		}()
+	}
+	return t.backend.Get(ctx, key)
+}
+
+// Delete is a traced delete request
+func (t *TracingBackend) Delete(ctx context.Context, key string) (err error) {
+	span, ctx := startStorageSpan(ctx, "delete")
+	if span != nil {
+		span.SetTag("key", key)
+		defer func() { finishStorageSpan(span, err) }()
+	}
+	return t.backend.Delete(ctx, key)
+}
+
+// List is a traced list request
+func (t *TracingBackend) List(ctx context.Context, prefix string) (keys []string, err error) {
+	span, ctx := startStorageSpan(ctx, "list")
+	if span != nil {
+		span.SetTag("prefix", prefix)
+		defer func() { finishStorageSpan(span, err) }()
+	}
+	return t.backend.List(ctx, prefix)
+}
+
+// ListPage is a traced paginated list request
+func (t *TracingBackend) ListPage(ctx context.Context, prefix, after string, limit int) (keys []string, err error) {
+	span, ctx := startStorageSpan(ctx, "list_page")
+	if span != nil {
+		span.SetTag("prefix", prefix)
+		defer func() { finishStorageSpan(span, err) }()
+	}
+	return ListPage(ctx, t.backend, prefix, after, limit)
+}
+
+// Transaction is a traced transaction request
+func (t *TransactionalTracingBackend) Transaction(ctx context.Context, txns []*TxnEntry) (err error) {
+	span, ctx := startStorageSpan(ctx, "transaction")
+	if span != nil {
+		span.SetTag("entries", strconv.Itoa(len(txns)))
+		defer func() { finishStorageSpan(span, err) }()
+	}
+	return t.Transactional.Transaction(ctx, txns)
+}
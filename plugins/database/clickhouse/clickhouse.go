@@ -0,0 +1,172 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/plugins"
+	"github.com/hashicorp/vault/plugins/helper/database/credsutil"
+	"github.com/hashicorp/vault/plugins/helper/database/dbutil"
+)
+
+const (
+	clickHouseTypeName = "clickhouse"
+
+	defaultClickHouseRevocationStmts = `DROP USER IF EXISTS '{{name}}'`
+)
+
+// ClickHouse is an implementation of Database interface
+type ClickHouse struct {
+	*clickHouseConnectionProducer
+	credsutil.CredentialsProducer
+}
+
+var _ dbplugin.Database = &ClickHouse{}
+
+// New returns a new ClickHouse instance
+func New() (interface{}, error) {
+	db := new()
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+	return dbType, nil
+}
+
+func new() *ClickHouse {
+	connProducer := &clickHouseConnectionProducer{}
+	connProducer.Type = clickHouseTypeName
+
+	credsProducer := &credsutil.SQLCredentialsProducer{
+		DisplayNameLen: 15,
+		RoleNameLen:    15,
+		UsernameLen:    100,
+		Separator:      "_",
+	}
+
+	return &ClickHouse{
+		clickHouseConnectionProducer: connProducer,
+		CredentialsProducer:          credsProducer,
+	}
+}
+
+// Run instantiates a ClickHouse object, and runs the RPC server for the plugin
+func Run(apiTLSConfig *api.TLSConfig) error {
+	dbType, err := New()
+	if err != nil {
+		return err
+	}
+
+	plugins.Serve(dbType.(dbplugin.Database), apiTLSConfig)
+
+	return nil
+}
+
+// Type returns the TypeName for this backend
+func (c *ClickHouse) Type() (string, error) {
+	return clickHouseTypeName, nil
+}
+
+// CreateUser runs the creation statements one at a time. Since ClickHouse
+// has no transactions, the user is dropped if a statement fails.
+func (c *ClickHouse) CreateUser(ctx context.Context, statements dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, expiration time.Time) (username string, password string, err error) {
+	// Grab the lock
+	c.Lock()
+	defer c.Unlock()
+
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	if len(statements.Creation) == 0 {
+		return "", "", dbutil.ErrEmptyCreationStatement
+	}
+
+	username, err = c.GenerateUsername(usernameConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err = c.GeneratePassword()
+	if err != nil {
+		return "", "", err
+	}
+
+	expirationStr, err := c.GenerateExpiration(expiration)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, stmt := range statements.Creation {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+			query = dbutil.QueryHelper(query, map[string]string{
+				"name":       username,
+				"password":   password,
+				"expiration": expirationStr,
+			})
+
+			if err := c.exec(ctx, query); err != nil {
+				// The error may quote the query, and with it the password
+				err = errors.New(strings.Replace(err.Error(), password, "[password]", -1))
+				if rmErr := c.revoke(ctx, statements.Revocation, username); rmErr != nil {
+					return "", "", multierror.Append(err, errwrap.Wrapf("failed to drop user: {{err}}", rmErr))
+				}
+				return "", "", err
+			}
+		}
+	}
+
+	return username, password, nil
+}
+
+// RenewUser is not supported on ClickHouse, so this is a no-op.
+func (c *ClickHouse) RenewUser(ctx context.Context, statements dbplugin.Statements, username string, expiration time.Time) error {
+	// NOOP
+	return nil
+}
+
+// RevokeUser runs the revocation statements, which default to dropping the
+// user if it exists.
+func (c *ClickHouse) RevokeUser(ctx context.Context, statements dbplugin.Statements, username string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	return c.revoke(ctx, statements.Revocation, username)
+}
+
+func (c *ClickHouse) revoke(ctx context.Context, revocationStmts []string, username string) error {
+	// Use a default statement for revocation if one cannot be fetched from the role
+	if len(revocationStmts) == 0 {
+		revocationStmts = []string{defaultClickHouseRevocationStmts}
+	}
+
+	for _, stmt := range revocationStmts {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+			query = dbutil.QueryHelper(query, map[string]string{
+				"name": username,
+			})
+			if err := c.exec(ctx, query); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RotateRootCredentials is not currently supported on ClickHouse
+func (c *ClickHouse) RotateRootCredentials(ctx context.Context, statements []string) (map[string]interface{}, error) {
+	return nil, errors.New("root credential rotation is not currently implemented in this database secrets engine")
+}
@@ -0,0 +1,155 @@
+package clickhouse
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/plugins/helper/database/connutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+// clickHouseConnectionProducer holds the configuration of the connection to
+// the HTTP interface of a ClickHouse server
+type clickHouseConnectionProducer struct {
+	ConnectionURL     string      `json:"connection_url" structs:"connection_url" mapstructure:"connection_url"`
+	Username          string      `json:"username" structs:"username" mapstructure:"username"`
+	Password          string      `json:"password" structs:"password" mapstructure:"password"`
+	InsecureTLS       bool        `json:"insecure_tls" structs:"insecure_tls" mapstructure:"insecure_tls"`
+	CACert            string      `json:"ca_cert" structs:"ca_cert" mapstructure:"ca_cert"`
+	ConnectTimeoutRaw interface{} `json:"connect_timeout" structs:"connect_timeout" mapstructure:"connect_timeout"`
+
+	rawConfig map[string]interface{}
+
+	Initialized bool
+	Type        string
+	client      *http.Client
+	sync.Mutex
+}
+
+func (c *clickHouseConnectionProducer) Initialize(ctx context.Context, conf map[string]interface{}, verifyConnection bool) error {
+	_, err := c.Init(ctx, conf, verifyConnection)
+	return err
+}
+
+func (c *clickHouseConnectionProducer) Init(ctx context.Context, conf map[string]interface{}, verifyConnection bool) (map[string]interface{}, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.rawConfig = conf
+
+	err := mapstructure.WeakDecode(conf, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ConnectTimeoutRaw == nil {
+		c.ConnectTimeoutRaw = "10s"
+	}
+	connectTimeout, err := parseutil.ParseDurationSecond(c.ConnectTimeoutRaw)
+	if err != nil {
+		return nil, errwrap.Wrapf("invalid connect_timeout: {{err}}", err)
+	}
+
+	if len(c.ConnectionURL) == 0 {
+		return nil, fmt.Errorf("connection_url cannot be empty")
+	}
+	u, err := url.Parse(c.ConnectionURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("connection_url must be the http or https URL of the ClickHouse HTTP interface")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("connection_url cannot contain credentials, use username and password")
+	}
+	c.ConnectionURL = strings.TrimSuffix(c.ConnectionURL, "/")
+
+	transport := cleanhttp.DefaultPooledTransport()
+	if u.Scheme == "https" {
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: c.InsecureTLS,
+		}
+		if c.CACert != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(c.CACert)) {
+				return nil, fmt.Errorf("could not parse ca_cert")
+			}
+			transport.TLSClientConfig.RootCAs = pool
+		}
+	}
+	c.client = &http.Client{
+		Transport: transport,
+		Timeout:   connectTimeout,
+	}
+
+	// Set initialized to true at this point since all fields are set,
+	// and the connection can be established at a later time.
+	c.Initialized = true
+
+	if verifyConnection {
+		if err := c.exec(ctx, "SELECT 1"); err != nil {
+			return nil, errwrap.Wrapf("error verifying connection: {{err}}", err)
+		}
+	}
+
+	return conf, nil
+}
+
+// Close releases the idle connections of the HTTP client
+func (c *clickHouseConnectionProducer) Close() error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.client != nil {
+		if transport, ok := c.client.Transport.(*http.Transport); ok {
+			transport.CloseIdleConnections()
+		}
+	}
+
+	return nil
+}
+
+// exec runs a single query through the HTTP interface. The interface does not
+// accept several statements in a request, nor transactions.
+func (c *clickHouseConnectionProducer) exec(ctx context.Context, query string) error {
+	if !c.Initialized {
+		return connutil.ErrNotInitialized
+	}
+
+	req, err := http.NewRequest("POST", c.ConnectionURL+"/", strings.NewReader(query))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-ClickHouse-User", c.Username)
+	req.Header.Set("X-ClickHouse-Key", c.Password)
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from ClickHouse: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (c *clickHouseConnectionProducer) secretValues() map[string]interface{} {
+	return map[string]interface{}{
+		c.Password: "[password]",
+	}
+}
@@ -0,0 +1,270 @@
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/plugins/helper/database/connutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	defaultInfluxDBPort = 8086
+
+	influxDBAPIv1 = "v1"
+	influxDBAPIv2 = "v2"
+)
+
+// influxDBConnectionProducer holds the configuration of the connection to an
+// InfluxDB server, through the InfluxQL API of InfluxDB 1.x or the API of
+// InfluxDB 2.x
+type influxDBConnectionProducer struct {
+	Host              string      `json:"host" structs:"host" mapstructure:"host"`
+	Port              int         `json:"port" structs:"port" mapstructure:"port"`
+	APIVersion        string      `json:"api_version" structs:"api_version" mapstructure:"api_version"`
+	Username          string      `json:"username" structs:"username" mapstructure:"username"`
+	Password          string      `json:"password" structs:"password" mapstructure:"password"`
+	Token             string      `json:"token" structs:"token" mapstructure:"token"`
+	Organization      string      `json:"organization" structs:"organization" mapstructure:"organization"`
+	TLS               bool        `json:"tls" structs:"tls" mapstructure:"tls"`
+	InsecureTLS       bool        `json:"insecure_tls" structs:"insecure_tls" mapstructure:"insecure_tls"`
+	CACert            string      `json:"ca_cert" structs:"ca_cert" mapstructure:"ca_cert"`
+	ConnectTimeoutRaw interface{} `json:"connect_timeout" structs:"connect_timeout" mapstructure:"connect_timeout"`
+
+	baseURL   string
+	rawConfig map[string]interface{}
+
+	Initialized bool
+	Type        string
+	client      *http.Client
+	sync.Mutex
+}
+
+func (i *influxDBConnectionProducer) Initialize(ctx context.Context, conf map[string]interface{}, verifyConnection bool) error {
+	_, err := i.Init(ctx, conf, verifyConnection)
+	return err
+}
+
+func (i *influxDBConnectionProducer) Init(ctx context.Context, conf map[string]interface{}, verifyConnection bool) (map[string]interface{}, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.rawConfig = conf
+
+	err := mapstructure.WeakDecode(conf, i)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.ConnectTimeoutRaw == nil {
+		i.ConnectTimeoutRaw = "5s"
+	}
+	connectTimeout, err := parseutil.ParseDurationSecond(i.ConnectTimeoutRaw)
+	if err != nil {
+		return nil, errwrap.Wrapf("invalid connect_timeout: {{err}}", err)
+	}
+
+	if i.Port == 0 {
+		i.Port = defaultInfluxDBPort
+	}
+	if i.APIVersion == "" {
+		i.APIVersion = influxDBAPIv1
+	}
+
+	switch {
+	case len(i.Host) == 0:
+		return nil, fmt.Errorf("host cannot be empty")
+	case i.APIVersion == influxDBAPIv1:
+		if len(i.Username) == 0 {
+			return nil, fmt.Errorf("username cannot be empty")
+		}
+		if len(i.Password) == 0 {
+			return nil, fmt.Errorf("password cannot be empty")
+		}
+	case i.APIVersion == influxDBAPIv2:
+		if len(i.Token) == 0 {
+			return nil, fmt.Errorf("token cannot be empty")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported api_version %q, must be %q or %q", i.APIVersion, influxDBAPIv1, influxDBAPIv2)
+	}
+
+	transport := cleanhttp.DefaultPooledTransport()
+	scheme := "http"
+	if i.TLS {
+		scheme = "https"
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: i.InsecureTLS,
+		}
+		if i.CACert != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(i.CACert)) {
+				return nil, fmt.Errorf("could not parse ca_cert")
+			}
+			transport.TLSClientConfig.RootCAs = pool
+		}
+	}
+	i.client = &http.Client{
+		Transport: transport,
+		Timeout:   connectTimeout,
+	}
+	i.baseURL = fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(i.Host, strconv.Itoa(i.Port)))
+
+	// Set initialized to true at this point since all fields are set,
+	// and the connection can be established at a later time.
+	i.Initialized = true
+
+	if verifyConnection {
+		var err error
+		switch i.APIVersion {
+		case influxDBAPIv1:
+			err = i.query(ctx, "SHOW USERS")
+		case influxDBAPIv2:
+			err = i.request(ctx, "GET", "/api/v2/authorizations?limit=1", nil, nil)
+		}
+		if err != nil {
+			return nil, errwrap.Wrapf("error verifying connection: {{err}}", err)
+		}
+	}
+
+	return conf, nil
+}
+
+// Close releases the idle connections of the HTTP client
+func (i *influxDBConnectionProducer) Close() error {
+	i.Lock()
+	defer i.Unlock()
+
+	if i.client != nil {
+		if transport, ok := i.client.Transport.(*http.Transport); ok {
+			transport.CloseIdleConnections()
+		}
+	}
+
+	return nil
+}
+
+// query runs InfluxQL statements through the query endpoint of InfluxDB 1.x
+func (i *influxDBConnectionProducer) query(ctx context.Context, q string) error {
+	if !i.Initialized {
+		return connutil.ErrNotInitialized
+	}
+
+	form := url.Values{}
+	form.Set("q", q)
+	req, err := http.NewRequest("POST", i.baseURL+"/query", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(i.Username, i.Password)
+
+	var result struct {
+		Error   string `json:"error"`
+		Results []struct {
+			Error string `json:"error"`
+		} `json:"results"`
+	}
+	if err := i.do(ctx, req, &result); err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return fmt.Errorf("error from InfluxDB: %s", result.Error)
+	}
+	for _, r := range result.Results {
+		if r.Error != "" {
+			return fmt.Errorf("error from InfluxDB: %s", r.Error)
+		}
+	}
+	return nil
+}
+
+// request calls the API of InfluxDB 2.x, encoding in as the JSON body of the
+// request and decoding the response into out if they are not nil
+func (i *influxDBConnectionProducer) request(ctx context.Context, method, path string, in, out interface{}) error {
+	if !i.Initialized {
+		return connutil.ErrNotInitialized
+	}
+
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequest(method, i.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Token "+i.Token)
+
+	return i.do(ctx, req, out)
+}
+
+// errNotFound is returned for requests answered with a 404
+var errNotFound = fmt.Errorf("not found")
+
+func (i *influxDBConnectionProducer) do(ctx context.Context, req *http.Request, out interface{}) error {
+	resp, err := i.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errNotFound
+	case resp.StatusCode >= http.StatusBadRequest:
+		var apiErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		msg := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &apiErr) == nil {
+			switch {
+			case apiErr.Message != "":
+				msg = apiErr.Message
+			case apiErr.Error != "":
+				msg = apiErr.Error
+			}
+		}
+		return fmt.Errorf("unexpected status %d from InfluxDB: %s", resp.StatusCode, msg)
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+func (i *influxDBConnectionProducer) secretValues() map[string]interface{} {
+	return map[string]interface{}{
+		i.Password: "[password]",
+		i.Token:    "[token]",
+	}
+}
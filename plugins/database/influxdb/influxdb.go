@@ -0,0 +1,255 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/plugins"
+	"github.com/hashicorp/vault/plugins/helper/database/credsutil"
+	"github.com/hashicorp/vault/plugins/helper/database/dbutil"
+)
+
+const (
+	influxDBTypeName = "influxdb"
+
+	defaultInfluxDBRevocationStmts = `DROP USER "{{name}}"`
+)
+
+// InfluxDB is an implementation of Database interface creating users on
+// InfluxDB 1.x and tokens on InfluxDB 2.x
+type InfluxDB struct {
+	*influxDBConnectionProducer
+	credsutil.CredentialsProducer
+}
+
+var _ dbplugin.Database = &InfluxDB{}
+
+// New returns a new InfluxDB instance
+func New() (interface{}, error) {
+	db := new()
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+	return dbType, nil
+}
+
+func new() *InfluxDB {
+	connProducer := &influxDBConnectionProducer{}
+	connProducer.Type = influxDBTypeName
+
+	credsProducer := &credsutil.SQLCredentialsProducer{
+		DisplayNameLen: 15,
+		RoleNameLen:    15,
+		UsernameLen:    100,
+		Separator:      "_",
+	}
+
+	return &InfluxDB{
+		influxDBConnectionProducer: connProducer,
+		CredentialsProducer:        credsProducer,
+	}
+}
+
+// Run instantiates an InfluxDB object, and runs the RPC server for the plugin
+func Run(apiTLSConfig *api.TLSConfig) error {
+	dbType, err := New()
+	if err != nil {
+		return err
+	}
+
+	plugins.Serve(dbType.(dbplugin.Database), apiTLSConfig)
+
+	return nil
+}
+
+// Type returns the TypeName for this backend
+func (i *InfluxDB) Type() (string, error) {
+	return influxDBTypeName, nil
+}
+
+// CreateUser creates a user with the InfluxQL creation statements on
+// InfluxDB 1.x, or a token with the permissions of the creation statement on
+// InfluxDB 2.x, in which case the ID of the token is returned as the
+// username and the token as the password.
+func (i *InfluxDB) CreateUser(ctx context.Context, statements dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, expiration time.Time) (username string, password string, err error) {
+	// Grab the lock
+	i.Lock()
+	defer i.Unlock()
+
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	if len(statements.Creation) == 0 {
+		return "", "", dbutil.ErrEmptyCreationStatement
+	}
+
+	username, err = i.GenerateUsername(usernameConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	if i.APIVersion == influxDBAPIv2 {
+		return i.createToken(ctx, statements.Creation, username)
+	}
+
+	password, err = i.GeneratePassword()
+	if err != nil {
+		return "", "", err
+	}
+
+	expirationStr, err := i.GenerateExpiration(expiration)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, stmt := range statements.Creation {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+			query = dbutil.QueryHelper(query, map[string]string{
+				"name":       username,
+				"password":   password,
+				"expiration": expirationStr,
+			})
+
+			if err := i.query(ctx, query); err != nil {
+				// The error may quote the query, and with it the password
+				err = errors.New(strings.Replace(err.Error(), password, "[password]", -1))
+
+				// InfluxQL has no transactions, so drop the user in case
+				// it was created by a previous statement
+				if rmErr := i.query(ctx, dbutil.QueryHelper(defaultInfluxDBRevocationStmts, map[string]string{"name": username})); rmErr != nil && !strings.Contains(rmErr.Error(), "user not found") {
+					return "", "", multierror.Append(err, errwrap.Wrapf("failed to drop user: {{err}}", rmErr))
+				}
+				return "", "", err
+			}
+		}
+	}
+
+	return username, password, nil
+}
+
+// influxDBTokenStatement is the creation statement of InfluxDB 2.x tokens
+type influxDBTokenStatement struct {
+	OrgID       string        `json:"org_id"`
+	Permissions []interface{} `json:"permissions"`
+}
+
+// createToken creates an InfluxDB 2.x token described by the name of the
+// user. The creation statement is a JSON object holding the permissions of
+// the token in the format of the authorizations API, such as
+// {"permissions": [{"action": "read", "resource": {"type": "buckets"}}]}, and
+// optionally the org_id of the token, which defaults to the ID of the
+// configured organization.
+func (i *InfluxDB) createToken(ctx context.Context, creation []string, name string) (string, string, error) {
+	if len(creation) != 1 {
+		return "", "", fmt.Errorf("expected 1 creation statement, got %d", len(creation))
+	}
+
+	var stmt influxDBTokenStatement
+	if err := json.Unmarshal([]byte(creation[0]), &stmt); err != nil {
+		return "", "", errwrap.Wrapf("error parsing the creation statement: {{err}}", err)
+	}
+	if len(stmt.Permissions) == 0 {
+		return "", "", fmt.Errorf("permissions array is required in creation statement")
+	}
+
+	if stmt.OrgID == "" {
+		if i.Organization == "" {
+			return "", "", fmt.Errorf("org_id is required in creation statement unless an organization is configured")
+		}
+		var orgs struct {
+			Orgs []struct {
+				ID string `json:"id"`
+			} `json:"orgs"`
+		}
+		if err := i.request(ctx, "GET", "/api/v2/orgs?org="+url.QueryEscape(i.Organization), nil, &orgs); err != nil && err != errNotFound {
+			return "", "", errwrap.Wrapf("error looking up the organization: {{err}}", err)
+		}
+		if len(orgs.Orgs) == 0 {
+			return "", "", fmt.Errorf("organization %q not found", i.Organization)
+		}
+		stmt.OrgID = orgs.Orgs[0].ID
+	}
+
+	var auth struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	err := i.request(ctx, "POST", "/api/v2/authorizations", map[string]interface{}{
+		"orgID":       stmt.OrgID,
+		"description": name,
+		"permissions": stmt.Permissions,
+	}, &auth)
+	if err != nil {
+		return "", "", err
+	}
+	if auth.ID == "" || auth.Token == "" {
+		return "", "", fmt.Errorf("InfluxDB did not return the created token")
+	}
+
+	return auth.ID, auth.Token, nil
+}
+
+// RenewUser is not supported on InfluxDB, so this is a no-op.
+func (i *InfluxDB) RenewUser(ctx context.Context, statements dbplugin.Statements, username string, expiration time.Time) error {
+	// NOOP
+	return nil
+}
+
+// RevokeUser drops the user with the revocation statements on InfluxDB 1.x,
+// defaulting to DROP USER, or deletes the token on InfluxDB 2.x.
+func (i *InfluxDB) RevokeUser(ctx context.Context, statements dbplugin.Statements, username string) error {
+	i.Lock()
+	defer i.Unlock()
+
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	if i.APIVersion == influxDBAPIv2 {
+		if len(statements.Revocation) != 0 {
+			return fmt.Errorf("revocation statements are not supported with InfluxDB 2.x tokens")
+		}
+		err := i.request(ctx, "DELETE", "/api/v2/authorizations/"+url.PathEscape(username), nil, nil)
+		if err != nil && err != errNotFound {
+			return err
+		}
+		return nil
+	}
+
+	revocationStmts := statements.Revocation
+	// Use a default statement for revocation if one cannot be fetched from the role
+	if len(revocationStmts) == 0 {
+		revocationStmts = []string{defaultInfluxDBRevocationStmts}
+	}
+
+	for _, stmt := range revocationStmts {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+			query = dbutil.QueryHelper(query, map[string]string{
+				"name": username,
+			})
+			if err := i.query(ctx, query); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RotateRootCredentials is not currently supported on InfluxDB
+func (i *InfluxDB) RotateRootCredentials(ctx context.Context, statements []string) (map[string]interface{}, error) {
+	return nil, errors.New("root credential rotation is not currently implemented in this database secrets engine")
+}
@@ -0,0 +1,307 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+)
+
+const testInfluxDBRole = `CREATE USER "{{name}}" WITH PASSWORD '{{password}}'; GRANT READ ON "telegraf" TO "{{name}}";`
+
+const testInfluxDBTokenRole = `{ "permissions": [{ "action": "read", "resource": { "type": "buckets", "id": "b1" } }] }`
+
+var testInfluxDBCreateUser = regexp.MustCompile(`^CREATE USER "([^"]+)" WITH PASSWORD '([^']+)'$`)
+
+// testInfluxDBServer is a fake InfluxDB server answering InfluxQL user
+// statements and the authorizations API
+type testInfluxDBServer struct {
+	*httptest.Server
+
+	sync.Mutex
+	users  map[string]string
+	grants map[string][]string
+	tokens map[string]map[string]interface{}
+}
+
+func newTestInfluxDBServer(t *testing.T) *testInfluxDBServer {
+	s := &testInfluxDBServer{
+		users:  make(map[string]string),
+		grants: make(map[string][]string),
+		tokens: make(map[string]map[string]interface{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *testInfluxDBServer) conf() map[string]interface{} {
+	host, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+	return map[string]interface{}{
+		"host": host,
+		"port": port,
+	}
+}
+
+func (s *testInfluxDBServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if r.URL.Path == "/query" {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"authorization failed"}`))
+			return
+		}
+		q := strings.TrimSpace(r.FormValue("q"))
+		var stmtErr string
+		switch {
+		case q == "SHOW USERS":
+		case testInfluxDBCreateUser.MatchString(q):
+			m := testInfluxDBCreateUser.FindStringSubmatch(q)
+			s.users[m[1]] = m[2]
+		case strings.HasPrefix(q, "GRANT "):
+			name := strings.Trim(q[strings.LastIndex(q, " ")+1:], `"`)
+			if _, ok := s.users[name]; !ok {
+				stmtErr = "user not found"
+				break
+			}
+			s.grants[name] = append(s.grants[name], q)
+		case strings.HasPrefix(q, "DROP USER "):
+			name := strings.Trim(strings.TrimPrefix(q, "DROP USER "), `"`)
+			if _, ok := s.users[name]; !ok {
+				stmtErr = "user not found"
+				break
+			}
+			delete(s.users, name)
+			delete(s.grants, name)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf(`{"error":"error parsing query: %s"}`, q)))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{{"statement_id": 0, "error": stmtErr}},
+		})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Token admin-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code":"unauthorized","message":"unauthorized access"}`))
+		return
+	}
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/api/v2/authorizations":
+		w.Write([]byte(`{"authorizations":[]}`))
+	case r.Method == "GET" && r.URL.Path == "/api/v2/orgs":
+		if r.URL.Query().Get("org") != "acme" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"not found","message":"organization not found"}`))
+			return
+		}
+		w.Write([]byte(`{"orgs":[{"id":"o1","name":"acme"}]}`))
+	case r.Method == "POST" && r.URL.Path == "/api/v2/authorizations":
+		var auth map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&auth); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		id := fmt.Sprintf("a%d", len(s.tokens)+1)
+		s.tokens[id] = auth
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":    id,
+			"token": "token-" + id,
+		})
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/api/v2/authorizations/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/v2/authorizations/")
+		if _, ok := s.tokens[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"not found","message":"authorization not found"}`))
+			return
+		}
+		delete(s.tokens, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testCreateUser(db *InfluxDB, creation string) (string, string, error) {
+	statements := dbplugin.Statements{
+		Creation: []string{creation},
+	}
+	usernameConfig := dbplugin.UsernameConfig{
+		DisplayName: "test",
+		RoleName:    "test",
+	}
+	return db.CreateUser(context.Background(), statements, usernameConfig, time.Now().Add(time.Minute))
+}
+
+func TestInfluxDB_Initialize(t *testing.T) {
+	server := newTestInfluxDBServer(t)
+	defer server.Close()
+
+	conf := server.conf()
+	conf["username"] = "admin"
+	conf["password"] = "secret"
+
+	db := new()
+	if _, err := db.Init(context.Background(), conf, true); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !db.Initialized {
+		t.Fatal("Database should be initialized")
+	}
+
+	conf["password"] = "wrong"
+	db = new()
+	if _, err := db.Init(context.Background(), conf, true); err == nil || !strings.Contains(err.Error(), "authorization failed") {
+		t.Fatalf("expected the connection verification to fail, got: %v", err)
+	}
+
+	for _, conf := range []map[string]interface{}{
+		{"username": "admin", "password": "secret"},
+		{"host": "localhost", "password": "secret"},
+		{"host": "localhost", "api_version": "v2"},
+		{"host": "localhost", "api_version": "v3", "token": "admin-token"},
+	} {
+		db := new()
+		if _, err := db.Init(context.Background(), conf, false); err == nil {
+			t.Fatalf("expected an error initializing with %v", conf)
+		}
+	}
+}
+
+func TestInfluxDB_CreateUser(t *testing.T) {
+	server := newTestInfluxDBServer(t)
+	defer server.Close()
+
+	conf := server.conf()
+	conf["username"] = "admin"
+	conf["password"] = "secret"
+
+	db := new()
+	if _, err := db.Init(context.Background(), conf, true); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, _, err := db.CreateUser(context.Background(), dbplugin.Statements{}, dbplugin.UsernameConfig{}, time.Now()); err == nil {
+		t.Fatal("Expected error when no creation statement is provided")
+	}
+
+	username, password, err := testCreateUser(db, testInfluxDBRole)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	server.Lock()
+	if server.users[username] != password {
+		t.Fatalf("expected user %q to be created with the password, got %v", username, server.users)
+	}
+	if len(server.grants[username]) != 1 {
+		t.Fatalf("expected user %q to be granted privileges, got %v", username, server.grants)
+	}
+	server.Unlock()
+
+	if err := db.RevokeUser(context.Background(), dbplugin.Statements{}, username); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	server.Lock()
+	if _, ok := server.users[username]; ok {
+		t.Fatalf("expected user %q to be dropped", username)
+	}
+	server.Unlock()
+
+	// A failing statement drops the user and does not leak the password
+	_, _, err = testCreateUser(db, `CREATE USER "{{name}}" WITH PASSWORD '{{password}}'; SET PASSWORD FOR "{{name}}" = '{{password}}' NOW`)
+	if err == nil {
+		t.Fatal("expected an error with an invalid statement")
+	}
+	if !strings.Contains(err.Error(), "[password]") {
+		t.Fatalf("expected the password to be redacted, got: %v", err)
+	}
+	server.Lock()
+	defer server.Unlock()
+	if len(server.users) != 0 {
+		t.Fatalf("expected the user to be dropped, got %v", server.users)
+	}
+}
+
+func TestInfluxDB_CreateToken(t *testing.T) {
+	server := newTestInfluxDBServer(t)
+	defer server.Close()
+
+	conf := server.conf()
+	conf["api_version"] = "v2"
+	conf["token"] = "admin-token"
+	conf["organization"] = "acme"
+
+	db := new()
+	if _, err := db.Init(context.Background(), conf, true); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	id, token, err := testCreateUser(db, testInfluxDBTokenRole)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if id != "a1" || token != "token-a1" {
+		t.Fatalf("bad: id %q, token %q", id, token)
+	}
+
+	server.Lock()
+	auth := server.tokens[id]
+	if auth["orgID"] != "o1" || !strings.HasPrefix(auth["description"].(string), "v_test_test_") {
+		t.Fatalf("bad authorization: %#v", auth)
+	}
+	server.Unlock()
+
+	// The organization of the statement is used as is
+	id, _, err = testCreateUser(db, `{ "org_id": "o2", "permissions": [{ "action": "write", "resource": { "type": "buckets" } }] }`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	server.Lock()
+	if server.tokens[id]["orgID"] != "o2" {
+		t.Fatalf("bad authorization: %#v", server.tokens[id])
+	}
+	server.Unlock()
+
+	if _, _, err := testCreateUser(db, `{ "permissions": [] }`); err == nil {
+		t.Fatal("expected an error without permissions")
+	}
+
+	for _, id := range []string{"a1", "a2"} {
+		if err := db.RevokeUser(context.Background(), dbplugin.Statements{}, id); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	server.Lock()
+	if len(server.tokens) != 0 {
+		t.Fatalf("expected the tokens to be deleted, got %v", server.tokens)
+	}
+	server.Unlock()
+
+	// Revoking a token that no longer exists succeeds
+	if err := db.RevokeUser(context.Background(), dbplugin.Statements{}, "a1"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	conf["organization"] = "unknown"
+	db = new()
+	if _, err := db.Init(context.Background(), conf, false); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, _, err := testCreateUser(db, testInfluxDBTokenRole); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected an error with an unknown organization, got: %v", err)
+	}
+}
@@ -31,6 +31,7 @@ ALTER ROLE "{{username}}" WITH PASSWORD '{{password}}';
 )
 
 var _ dbplugin.Database = &PostgreSQL{}
+var _ dbplugin.CredentialDatabase = &PostgreSQL{}
 
 // New implements builtinplugins.BuiltinFactory
 func New() (interface{}, error) {
@@ -90,10 +91,47 @@ func (p *PostgreSQL) getConnection(ctx context.Context) (*sql.DB, error) {
 }
 
 func (p *PostgreSQL) CreateUser(ctx context.Context, statements dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, expiration time.Time) (username string, password string, err error) {
+	password, err = p.GeneratePassword()
+	if err != nil {
+		return "", "", err
+	}
+
+	username, err = p.createUser(ctx, statements, usernameConfig, expiration, map[string]string{
+		"password": password,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return username, password, nil
+}
+
+// CreateUserWithCredential creates a user authenticating with a client
+// certificate, as configured in pg_hba.conf, or with a password generated by
+// Vault. For a certificate the creation statements must not set a password;
+// the certificate is issued for the returned username.
+func (p *PostgreSQL) CreateUserWithCredential(ctx context.Context, statements dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, expiration time.Time, credential dbplugin.Credential) (username string, err error) {
+	switch credential.Type {
+	case dbplugin.CredentialTypePassword:
+		return p.createUser(ctx, statements, usernameConfig, expiration, map[string]string{
+			"password": credential.Password,
+		})
+	case dbplugin.CredentialTypeClientCertificate:
+		return p.createUser(ctx, statements, usernameConfig, expiration, map[string]string{
+			"public_key": credential.PublicKey,
+		})
+	default:
+		return "", dbplugin.ErrCredentialTypeUnsupported
+	}
+}
+
+// createUser runs the creation statements for a new user, with the given
+// values to substitute along with its name and expiration.
+func (p *PostgreSQL) createUser(ctx context.Context, statements dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, expiration time.Time, values map[string]string) (username string, err error) {
 	statements = dbutil.StatementCompatibilityHelper(statements)
 
 	if len(statements.Creation) == 0 {
-		return "", "", dbutil.ErrEmptyCreationStatement
+		return "", dbutil.ErrEmptyCreationStatement
 	}
 
 	// Grab the lock
@@ -102,29 +140,24 @@ func (p *PostgreSQL) CreateUser(ctx context.Context, statements dbplugin.Stateme
 
 	username, err = p.GenerateUsername(usernameConfig)
 	if err != nil {
-		return "", "", err
-	}
-
-	password, err = p.GeneratePassword()
-	if err != nil {
-		return "", "", err
+		return "", err
 	}
 
 	expirationStr, err := p.GenerateExpiration(expiration)
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
 
 	// Get the connection
 	db, err := p.getConnection(ctx)
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
 
 	// Start a transaction
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return "", "", err
+		return "", err
 
 	}
 	defer func() {
@@ -142,21 +175,23 @@ func (p *PostgreSQL) CreateUser(ctx context.Context, statements dbplugin.Stateme
 
 			m := map[string]string{
 				"name":       username,
-				"password":   password,
 				"expiration": expirationStr,
 			}
+			for k, v := range values {
+				m[k] = v
+			}
 			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
-				return "", "", err
+				return "", err
 			}
 		}
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
-		return "", "", err
+		return "", err
 	}
 
-	return username, password, nil
+	return username, nil
 }
 
 func (p *PostgreSQL) RenewUser(ctx context.Context, statements dbplugin.Statements, username string, expiration time.Time) error {
@@ -148,6 +148,57 @@ func TestPostgreSQL_CreateUser(t *testing.T) {
 	}
 }
 
+func TestPostgreSQL_CreateUserWithCredential(t *testing.T) {
+	cleanup, connURL := preparePostgresTestContainer(t)
+	defer cleanup()
+
+	connectionDetails := map[string]interface{}{
+		"connection_url": connURL,
+	}
+
+	db := new()
+	_, err := db.Init(context.Background(), connectionDetails, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	usernameConfig := dbplugin.UsernameConfig{
+		DisplayName: "test",
+		RoleName:    "test",
+	}
+	statements := dbplugin.Statements{
+		Creation: []string{testPostgresCertificateRole},
+	}
+
+	// Postgres has no key pair authentication
+	_, err = db.CreateUserWithCredential(context.Background(), statements, usernameConfig, time.Now().Add(time.Minute), dbplugin.Credential{
+		Type: dbplugin.CredentialTypeRSAPrivateKey,
+	})
+	if err != dbplugin.ErrCredentialTypeUnsupported {
+		t.Fatalf("expected the credential type to be unsupported, got: %v", err)
+	}
+
+	username, err := db.CreateUserWithCredential(context.Background(), statements, usernameConfig, time.Now().Add(time.Minute), dbplugin.Credential{
+		Type:      dbplugin.CredentialTypeClientCertificate,
+		PublicKey: "public key",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	conn, err := db.getConnection(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var canLogin bool
+	if err := conn.QueryRow("SELECT rolcanlogin FROM pg_roles WHERE rolname = $1;", username).Scan(&canLogin); err != nil {
+		t.Fatalf("expected user %q to exist: %s", username, err)
+	}
+	if !canLogin {
+		t.Fatal("expected the user to be able to log in")
+	}
+}
+
 func TestPostgreSQL_RenewUser(t *testing.T) {
 	cleanup, connURL := preparePostgresTestContainer(t)
 	defer cleanup()
@@ -338,6 +389,13 @@ CREATE ROLE "{{name}}" WITH
 GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO "{{name}}";
 `
 
+const testPostgresCertificateRole = `
+CREATE ROLE "{{name}}" WITH
+  LOGIN
+  VALID UNTIL '{{expiration}}';
+GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO "{{name}}";
+`
+
 const testPostgresReadOnlyRole = `
 CREATE ROLE "{{name}}" WITH
   LOGIN
@@ -0,0 +1,266 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/plugins/helper/database/connutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+const defaultRedisPort = 6379
+
+// redisConnectionProducer holds the configuration of the connection to a
+// standalone Redis server, a Redis Cluster or the master of a Sentinel
+// deployment
+type redisConnectionProducer struct {
+	Hosts             string      `json:"hosts" structs:"hosts" mapstructure:"hosts"`
+	Port              int         `json:"port" structs:"port" mapstructure:"port"`
+	Username          string      `json:"username" structs:"username" mapstructure:"username"`
+	Password          string      `json:"password" structs:"password" mapstructure:"password"`
+	TLS               bool        `json:"tls" structs:"tls" mapstructure:"tls"`
+	InsecureTLS       bool        `json:"insecure_tls" structs:"insecure_tls" mapstructure:"insecure_tls"`
+	CACert            string      `json:"ca_cert" structs:"ca_cert" mapstructure:"ca_cert"`
+	ConnectTimeoutRaw interface{} `json:"connect_timeout" structs:"connect_timeout" mapstructure:"connect_timeout"`
+	Cluster           bool        `json:"cluster" structs:"cluster" mapstructure:"cluster"`
+	SentinelMaster    string      `json:"sentinel_master_name" structs:"sentinel_master_name" mapstructure:"sentinel_master_name"`
+	SentinelUsername  string      `json:"sentinel_username" structs:"sentinel_username" mapstructure:"sentinel_username"`
+	SentinelPassword  string      `json:"sentinel_password" structs:"sentinel_password" mapstructure:"sentinel_password"`
+
+	addrs          []string
+	tlsConfig      *tls.Config
+	connectTimeout time.Duration
+	rawConfig      map[string]interface{}
+
+	Initialized bool
+	Type        string
+	sync.Mutex
+}
+
+func (c *redisConnectionProducer) Initialize(ctx context.Context, conf map[string]interface{}, verifyConnection bool) error {
+	_, err := c.Init(ctx, conf, verifyConnection)
+	return err
+}
+
+func (c *redisConnectionProducer) Init(ctx context.Context, conf map[string]interface{}, verifyConnection bool) (map[string]interface{}, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.rawConfig = conf
+
+	err := mapstructure.WeakDecode(conf, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ConnectTimeoutRaw == nil {
+		c.ConnectTimeoutRaw = "10s"
+	}
+	c.connectTimeout, err = parseutil.ParseDurationSecond(c.ConnectTimeoutRaw)
+	if err != nil {
+		return nil, errwrap.Wrapf("invalid connect_timeout: {{err}}", err)
+	}
+
+	if c.Port == 0 {
+		c.Port = defaultRedisPort
+	}
+
+	c.addrs = nil
+	for _, host := range strings.Split(c.Hosts, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, strconv.Itoa(c.Port))
+		}
+		c.addrs = append(c.addrs, host)
+	}
+
+	switch {
+	case len(c.addrs) == 0:
+		return nil, fmt.Errorf("hosts cannot be empty")
+	case c.Cluster && c.SentinelMaster != "":
+		return nil, fmt.Errorf("cluster and sentinel_master_name are mutually exclusive")
+	case !c.Cluster && c.SentinelMaster == "" && len(c.addrs) > 1:
+		return nil, fmt.Errorf("multiple hosts require cluster or sentinel_master_name to be set")
+	}
+
+	c.tlsConfig = nil
+	if c.TLS {
+		c.tlsConfig = &tls.Config{
+			InsecureSkipVerify: c.InsecureTLS,
+		}
+		if c.CACert != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(c.CACert)) {
+				return nil, fmt.Errorf("could not parse ca_cert")
+			}
+			c.tlsConfig.RootCAs = pool
+		}
+	}
+
+	// Set initialized to true at this point since all fields are set,
+	// and the connection can be established at a later time.
+	c.Initialized = true
+
+	if verifyConnection {
+		if err := c.each(ctx, func(conn *redisConn) error {
+			_, err := conn.do("PING")
+			return err
+		}); err != nil {
+			return nil, errwrap.Wrapf("error verifying connection: {{err}}", err)
+		}
+	}
+
+	return conf, nil
+}
+
+// Close is a no-op, connections are only held for the duration of an
+// operation
+func (c *redisConnectionProducer) Close() error {
+	return nil
+}
+
+// each runs fn with a connection to every node the users are managed on:
+// the server, the current master of a Sentinel deployment, or every node of
+// a cluster, since ACL changes are not propagated between cluster nodes.
+func (c *redisConnectionProducer) each(ctx context.Context, fn func(*redisConn) error) error {
+	if !c.Initialized {
+		return connutil.ErrNotInitialized
+	}
+
+	nodes, err := c.nodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	var result error
+	for _, addr := range nodes {
+		conn, err := dialRedis(ctx, addr, c.tlsConfig, c.Username, c.Password, c.connectTimeout)
+		if err != nil {
+			result = multierror.Append(result, errwrap.Wrapf(fmt.Sprintf("error connecting to %s: {{err}}", addr), err))
+			continue
+		}
+		err = fn(conn)
+		conn.Close()
+		if err != nil {
+			result = multierror.Append(result, errwrap.Wrapf(fmt.Sprintf("error on %s: {{err}}", addr), err))
+		}
+	}
+	return result
+}
+
+// nodes returns the addresses of the nodes users are managed on
+func (c *redisConnectionProducer) nodes(ctx context.Context) ([]string, error) {
+	switch {
+	case c.SentinelMaster != "":
+		return c.sentinelMaster(ctx)
+	case c.Cluster:
+		return c.clusterNodes(ctx)
+	default:
+		return c.addrs, nil
+	}
+}
+
+// sentinelMaster asks the sentinels for the address of the current master
+func (c *redisConnectionProducer) sentinelMaster(ctx context.Context) ([]string, error) {
+	var result error
+	for _, addr := range c.addrs {
+		conn, err := dialRedis(ctx, addr, c.tlsConfig, c.SentinelUsername, c.SentinelPassword, c.connectTimeout)
+		if err != nil {
+			result = multierror.Append(result, errwrap.Wrapf(fmt.Sprintf("error connecting to sentinel %s: {{err}}", addr), err))
+			continue
+		}
+		reply, err := conn.do("SENTINEL", "get-master-addr-by-name", c.SentinelMaster)
+		conn.Close()
+		if err != nil {
+			result = multierror.Append(result, errwrap.Wrapf(fmt.Sprintf("error querying sentinel %s: {{err}}", addr), err))
+			continue
+		}
+		master, ok := reply.([]interface{})
+		if !ok || len(master) != 2 {
+			result = multierror.Append(result, fmt.Errorf("sentinel %s does not know master %q", addr, c.SentinelMaster))
+			continue
+		}
+		host, _ := master[0].(string)
+		port, _ := master[1].(string)
+		return []string{net.JoinHostPort(host, port)}, nil
+	}
+	return nil, result
+}
+
+// clusterNodes returns the addresses of the healthy nodes of the cluster,
+// as reported by the first host that answers
+func (c *redisConnectionProducer) clusterNodes(ctx context.Context) ([]string, error) {
+	var result error
+	for _, addr := range c.addrs {
+		conn, err := dialRedis(ctx, addr, c.tlsConfig, c.Username, c.Password, c.connectTimeout)
+		if err != nil {
+			result = multierror.Append(result, errwrap.Wrapf(fmt.Sprintf("error connecting to %s: {{err}}", addr), err))
+			continue
+		}
+		reply, err := conn.do("CLUSTER", "NODES")
+		conn.Close()
+		if err != nil {
+			result = multierror.Append(result, errwrap.Wrapf(fmt.Sprintf("error listing the cluster nodes from %s: {{err}}", addr), err))
+			continue
+		}
+		nodes, ok := reply.(string)
+		if !ok {
+			result = multierror.Append(result, fmt.Errorf("unexpected reply listing the cluster nodes from %s", addr))
+			continue
+		}
+		return parseClusterNodes(nodes), nil
+	}
+	return nil, result
+}
+
+// parseClusterNodes parses the reply of CLUSTER NODES, skipping nodes that
+// are failing or have no address. Each line is of the form
+// "<id> <ip:port@cport[,hostname]> <flags> ..."
+func parseClusterNodes(nodes string) []string {
+	var addrs []string
+	for _, line := range strings.Split(nodes, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		skip := false
+		for _, flag := range strings.Split(fields[2], ",") {
+			switch flag {
+			case "fail", "fail?", "handshake", "noaddr":
+				skip = true
+			}
+		}
+		if skip {
+			continue
+		}
+		addr := fields[1]
+		if i := strings.IndexAny(addr, "@,"); i >= 0 {
+			addr = addr[:i]
+		}
+		if strings.HasPrefix(addr, ":") {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+func (c *redisConnectionProducer) secretValues() map[string]interface{} {
+	return map[string]interface{}{
+		c.Password:         "[password]",
+		c.SentinelPassword: "[sentinel_password]",
+	}
+}
@@ -0,0 +1,183 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/plugins"
+	"github.com/hashicorp/vault/plugins/helper/database/credsutil"
+	"github.com/hashicorp/vault/plugins/helper/database/dbutil"
+)
+
+const redisTypeName = "redis"
+
+// Redis is an implementation of Database interface managing Redis ACL users
+type Redis struct {
+	*redisConnectionProducer
+	credsutil.CredentialsProducer
+}
+
+var _ dbplugin.Database = &Redis{}
+
+// New returns a new Redis instance
+func New() (interface{}, error) {
+	db := new()
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+	return dbType, nil
+}
+
+func new() *Redis {
+	connProducer := &redisConnectionProducer{}
+	connProducer.Type = redisTypeName
+
+	credsProducer := &credsutil.SQLCredentialsProducer{
+		DisplayNameLen: 15,
+		RoleNameLen:    15,
+		UsernameLen:    100,
+		Separator:      "-",
+	}
+
+	return &Redis{
+		redisConnectionProducer: connProducer,
+		CredentialsProducer:     credsProducer,
+	}
+}
+
+// Run instantiates a Redis object, and runs the RPC server for the plugin
+func Run(apiTLSConfig *api.TLSConfig) error {
+	dbType, err := New()
+	if err != nil {
+		return err
+	}
+
+	plugins.Serve(dbType.(dbplugin.Database), apiTLSConfig)
+
+	return nil
+}
+
+// Type returns the TypeName for this backend
+func (r *Redis) Type() (string, error) {
+	return redisTypeName, nil
+}
+
+// CreateUser creates an ACL user with the rules of the creation statements.
+// Each creation statement is a JSON array of ACL rules granting commands and
+// key patterns as accepted by ACL SETUSER, such as
+// ["~cache:*", "+@read", "+@write", "-flushdb"]. On a cluster the user is
+// created on every node.
+func (r *Redis) CreateUser(ctx context.Context, statements dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, expiration time.Time) (username string, password string, err error) {
+	// Grab the lock
+	r.Lock()
+	defer r.Unlock()
+
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	if len(statements.Creation) == 0 {
+		return "", "", dbutil.ErrEmptyCreationStatement
+	}
+
+	rules, err := parseRules(statements.Creation)
+	if err != nil {
+		return "", "", err
+	}
+
+	username, err = r.GenerateUsername(usernameConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err = r.GeneratePassword()
+	if err != nil {
+		return "", "", err
+	}
+
+	args := append([]string{"ACL", "SETUSER", username, "on", ">" + password}, rules...)
+	err = r.each(ctx, func(conn *redisConn) error {
+		_, err := conn.do(args...)
+		return err
+	})
+	if err != nil {
+		// Remove the user from the nodes it was created on
+		if rmErr := r.deleteUser(ctx, username); rmErr != nil {
+			return "", "", multierror.Append(err, errwrap.Wrapf("failed to delete user: {{err}}", rmErr))
+		}
+		return "", "", err
+	}
+
+	return username, password, nil
+}
+
+// RenewUser is not supported on Redis, so this is a no-op.
+func (r *Redis) RenewUser(ctx context.Context, statements dbplugin.Statements, username string, expiration time.Time) error {
+	// NOOP
+	return nil
+}
+
+// RevokeUser deletes the ACL user. Revocation statements are not supported.
+func (r *Redis) RevokeUser(ctx context.Context, statements dbplugin.Statements, username string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	if len(statements.Revocation) != 0 {
+		return fmt.Errorf("revocation statements are not supported by the redis plugin")
+	}
+
+	return r.deleteUser(ctx, username)
+}
+
+// deleteUser deletes the user from every node. Deleting a user that does not
+// exist is not an error.
+func (r *Redis) deleteUser(ctx context.Context, username string) error {
+	return r.each(ctx, func(conn *redisConn) error {
+		_, err := conn.do("ACL", "DELUSER", username)
+		return err
+	})
+}
+
+// RotateRootCredentials is not currently supported on Redis
+func (r *Redis) RotateRootCredentials(ctx context.Context, statements []string) (map[string]interface{}, error) {
+	return nil, errors.New("root credential rotation is not currently implemented in this database secrets engine")
+}
+
+// parseRules returns the ACL rules of the creation statements. Rules
+// changing the state or the passwords of the user are rejected, since the
+// user is always enabled with the generated password.
+func parseRules(creation []string) ([]string, error) {
+	var rules []string
+	for _, stmt := range creation {
+		var stmtRules []string
+		if err := json.Unmarshal([]byte(stmt), &stmtRules); err != nil {
+			return nil, errwrap.Wrapf("creation statements must be JSON arrays of ACL rules: {{err}}", err)
+		}
+		for _, rule := range stmtRules {
+			rule = strings.TrimSpace(rule)
+			switch {
+			case rule == "":
+				continue
+			case strings.ContainsAny(rule, " \t\r\n"):
+				return nil, fmt.Errorf("invalid ACL rule %q", rule)
+			case strings.IndexAny(rule[:1], "><#!") == 0:
+				return nil, fmt.Errorf("ACL rule %q sets a password, which is generated", rule)
+			}
+			switch strings.ToLower(rule) {
+			case "on", "off", "nopass", "resetpass", "reset":
+				return nil, fmt.Errorf("ACL rule %q is not allowed in creation statements", rule)
+			}
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("creation statements do not contain any ACL rules")
+	}
+	return rules, nil
+}
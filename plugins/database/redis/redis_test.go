@@ -0,0 +1,399 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+)
+
+const testRedisRole = `["~cache:*", "+@read", "+@write"]`
+
+// testRedisServer is a fake Redis server answering the commands used by the
+// plugin
+type testRedisServer struct {
+	listener net.Listener
+	password string
+
+	// nodes is the reply to CLUSTER NODES, master the reply to SENTINEL
+	// get-master-addr-by-name and rejectSetUser makes ACL SETUSER fail
+	nodes         string
+	master        []string
+	rejectSetUser bool
+
+	sync.Mutex
+	users map[string][]string
+}
+
+func newTestRedisServer(t *testing.T) *testRedisServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testRedisServer{
+		listener: ln,
+		password: "secret",
+		users:    make(map[string][]string),
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *testRedisServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *testRedisServer) Close() {
+	s.listener.Close()
+}
+
+func (s *testRedisServer) user(name string) ([]string, bool) {
+	s.Lock()
+	defer s.Unlock()
+	rules, ok := s.users[name]
+	return rules, ok
+}
+
+func (s *testRedisServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := false
+	for {
+		args, err := readTestCommand(reader)
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(args[0])
+		if len(args) > 1 {
+			cmd += " " + strings.ToUpper(args[1])
+		}
+
+		s.Lock()
+		var reply string
+		switch {
+		case strings.HasPrefix(cmd, "AUTH"):
+			if args[len(args)-1] != s.password {
+				reply = "-WRONGPASS invalid username-password pair\r\n"
+				break
+			}
+			authenticated = true
+			reply = "+OK\r\n"
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case cmd == "PING":
+			reply = "+PONG\r\n"
+		case cmd == "ACL SETUSER":
+			if s.rejectSetUser {
+				reply = "-ERR Error in ACL SETUSER modifier\r\n"
+				break
+			}
+			s.users[args[2]] = args[3:]
+			reply = "+OK\r\n"
+		case cmd == "ACL DELUSER":
+			_, ok := s.users[args[2]]
+			delete(s.users, args[2])
+			if ok {
+				reply = ":1\r\n"
+			} else {
+				reply = ":0\r\n"
+			}
+		case cmd == "CLUSTER NODES":
+			reply = fmt.Sprintf("$%d\r\n%s\r\n", len(s.nodes), s.nodes)
+		case cmd == "SENTINEL GET-MASTER-ADDR-BY-NAME":
+			if s.master == nil {
+				reply = "*-1\r\n"
+				break
+			}
+			reply = fmt.Sprintf("*2\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(s.master[0]), s.master[0], len(s.master[1]), s.master[1])
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		s.Unlock()
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readTestCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func testCreateUser(t *testing.T, db *Redis, creation string) (string, string, error) {
+	statements := dbplugin.Statements{
+		Creation: []string{creation},
+	}
+	usernameConfig := dbplugin.UsernameConfig{
+		DisplayName: "test",
+		RoleName:    "test",
+	}
+	return db.CreateUser(context.Background(), statements, usernameConfig, time.Now().Add(time.Minute))
+}
+
+func TestRedis_Initialize(t *testing.T) {
+	server := newTestRedisServer(t)
+	defer server.Close()
+
+	db := new()
+	_, err := db.Init(context.Background(), map[string]interface{}{
+		"hosts":    server.Addr(),
+		"password": server.password,
+	}, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !db.Initialized {
+		t.Fatal("Database should be initialized")
+	}
+
+	for _, conf := range []map[string]interface{}{
+		{"hosts": ""},
+		{"hosts": "127.0.0.1:1,127.0.0.1:2"},
+		{"hosts": server.Addr(), "cluster": true, "sentinel_master_name": "master"},
+		{"hosts": server.Addr(), "tls": true, "ca_cert": "not a certificate"},
+	} {
+		db := new()
+		if _, err := db.Init(context.Background(), conf, false); err == nil {
+			t.Fatalf("expected an error initializing with %v", conf)
+		}
+	}
+
+	db = new()
+	_, err = db.Init(context.Background(), map[string]interface{}{
+		"hosts":    server.Addr(),
+		"password": "wrong",
+	}, true)
+	if err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Fatalf("expected the connection verification to fail, got: %v", err)
+	}
+}
+
+func TestRedis_CreateUser(t *testing.T) {
+	server := newTestRedisServer(t)
+	defer server.Close()
+
+	db := new()
+	_, err := db.Init(context.Background(), map[string]interface{}{
+		"hosts":    server.Addr(),
+		"password": server.password,
+	}, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	_, _, err = db.CreateUser(context.Background(), dbplugin.Statements{}, dbplugin.UsernameConfig{}, time.Now())
+	if err == nil {
+		t.Fatal("Expected error when no creation statement is provided")
+	}
+
+	username, password, err := testCreateUser(t, db, testRedisRole)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rules, ok := server.user(username)
+	if !ok {
+		t.Fatalf("expected user %q to be created", username)
+	}
+	expected := []string{"on", ">" + password, "~cache:*", "+@read", "+@write"}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("expected rules %v, got %v", expected, rules)
+	}
+
+	if err := db.RevokeUser(context.Background(), dbplugin.Statements{}, username); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, ok := server.user(username); ok {
+		t.Fatalf("expected user %q to be deleted", username)
+	}
+
+	// Revoking a user that does not exist succeeds
+	if err := db.RevokeUser(context.Background(), dbplugin.Statements{}, username); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestRedis_CreateUser_rules(t *testing.T) {
+	server := newTestRedisServer(t)
+	defer server.Close()
+
+	db := new()
+	_, err := db.Init(context.Background(), map[string]interface{}{
+		"hosts":    server.Addr(),
+		"password": server.password,
+	}, false)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, creation := range []string{
+		`+@read`,
+		`[]`,
+		`["~cache:* +@all"]`,
+		`[">password", "+@all"]`,
+		`["nopass", "+@all"]`,
+		`["~*", "OFF"]`,
+	} {
+		if _, _, err := testCreateUser(t, db, creation); err == nil {
+			t.Fatalf("expected an error creating a user with %s", creation)
+		}
+	}
+
+	server.Lock()
+	defer server.Unlock()
+	if len(server.users) != 0 {
+		t.Fatalf("expected no user to be created, got %v", server.users)
+	}
+}
+
+func TestRedis_Cluster(t *testing.T) {
+	node1 := newTestRedisServer(t)
+	defer node1.Close()
+	node2 := newTestRedisServer(t)
+	defer node2.Close()
+	failed := newTestRedisServer(t)
+	defer failed.Close()
+
+	nodes := fmt.Sprintf("07c37dfeb235213a872192d90877d0cd55635b91 %s@16379 myself,master - 0 0 1 connected 0-8191\n"+
+		"67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 %s@16380,node2.example.com slave 07c37dfeb235213a872192d90877d0cd55635b91 0 0 1 connected\n"+
+		"e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca %s@16381 master,fail - 0 0 2 connected 8192-16383\n",
+		node1.Addr(), node2.Addr(), failed.Addr())
+	node1.nodes = nodes
+	node2.nodes = nodes
+
+	if addrs := parseClusterNodes(nodes); !reflect.DeepEqual(addrs, []string{node1.Addr(), node2.Addr()}) {
+		t.Fatalf("bad cluster nodes: %v", addrs)
+	}
+
+	db := new()
+	_, err := db.Init(context.Background(), map[string]interface{}{
+		"hosts":    "127.0.0.1:1," + node2.Addr(),
+		"password": node1.password,
+		"cluster":  true,
+	}, false)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	username, _, err := testCreateUser(t, db, testRedisRole)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, node := range []*testRedisServer{node1, node2} {
+		if _, ok := node.user(username); !ok {
+			t.Fatalf("expected user %q to be created on %s", username, node.Addr())
+		}
+	}
+	if _, ok := failed.user(username); ok {
+		t.Fatalf("expected user %q not to be created on the failed node", username)
+	}
+
+	if err := db.RevokeUser(context.Background(), dbplugin.Statements{}, username); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, node := range []*testRedisServer{node1, node2} {
+		if _, ok := node.user(username); ok {
+			t.Fatalf("expected user %q to be deleted from %s", username, node.Addr())
+		}
+	}
+
+	// A node rejecting the user fails the creation and the user is deleted
+	// from the other nodes
+	node2.Lock()
+	node2.rejectSetUser = true
+	node2.Unlock()
+	if _, _, err := testCreateUser(t, db, testRedisRole); err == nil {
+		t.Fatal("expected an error creating a user rejected by a node")
+	}
+	node1.Lock()
+	defer node1.Unlock()
+	if len(node1.users) != 0 {
+		t.Fatalf("expected the user to be deleted, got %v", node1.users)
+	}
+}
+
+func TestRedis_Sentinel(t *testing.T) {
+	master := newTestRedisServer(t)
+	defer master.Close()
+	sentinel := newTestRedisServer(t)
+	defer sentinel.Close()
+
+	host, port, err := net.SplitHostPort(master.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sentinel.password = "sentinel"
+	sentinel.master = []string{host, port}
+
+	db := new()
+	_, err = db.Init(context.Background(), map[string]interface{}{
+		"hosts":                sentinel.Addr(),
+		"password":             master.password,
+		"sentinel_master_name": "mymaster",
+		"sentinel_password":    sentinel.password,
+	}, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	username, _, err := testCreateUser(t, db, testRedisRole)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, ok := master.user(username); !ok {
+		t.Fatalf("expected user %q to be created on the master", username)
+	}
+	if _, ok := sentinel.user(username); ok {
+		t.Fatalf("expected user %q not to be created on the sentinel", username)
+	}
+
+	sentinel.Lock()
+	sentinel.master = nil
+	sentinel.Unlock()
+	if _, _, err := testCreateUser(t, db, testRedisRole); err == nil || !strings.Contains(err.Error(), "does not know master") {
+		t.Fatalf("expected an error without a known master, got: %v", err)
+	}
+}
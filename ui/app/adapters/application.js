@@ -27,6 +27,9 @@ export default DS.RESTAdapter.extend({
 
   _preRequest(url, options) {
     const token = options.clientToken || this.get('auth.currentToken');
+    options.headers = assign(options.headers || {}, {
+      'X-Vault-Request': 'true',
+    });
     if (token && !options.unauthenticated) {
       options.headers = assign(options.headers || {}, {
         'X-Vault-Token': token,
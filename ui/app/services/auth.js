@@ -67,6 +67,7 @@ export default Ember.Service.extend({
       dataType: 'json',
       headers: {
         'X-Vault-Token': this.get('currentToken'),
+        'X-Vault-Request': 'true',
       },
     };
     return Ember.$.ajax(Ember.assign(defaults, options));
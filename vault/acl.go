@@ -26,6 +26,10 @@ type ACL struct {
 
 	// root is enabled if the "root" named policy is present.
 	root bool
+
+	// restrictions are ACLs which must allow the operations as well, such as
+	// those of the inline policies of a token
+	restrictions []*ACL
 }
 
 type PolicyCheckOpts struct {
@@ -198,7 +202,43 @@ func NewACL(policies []*Policy) (*ACL, error) {
 	return a, nil
 }
 
-func (a *ACL) Capabilities(path string) (pathCapabilities []string) {
+// restrict limits the ACL to what each of the given ACLs allows as well
+func (a *ACL) restrict(restrictions ...*ACL) {
+	a.restrictions = append(a.restrictions, restrictions...)
+}
+
+// Capabilities returns the capabilities the ACL grants on the given path
+func (a *ACL) Capabilities(path string) []string {
+	pathCapabilities := a.capabilities(path)
+	for _, r := range a.restrictions {
+		pathCapabilities = intersectCapabilities(pathCapabilities, r.capabilities(path))
+	}
+	return pathCapabilities
+}
+
+// intersectCapabilities returns the capabilities in both lists, root standing
+// for all of them
+func intersectCapabilities(a, b []string) []string {
+	switch {
+	case strutil.StrListContains(a, RootCapability):
+		return b
+	case strutil.StrListContains(b, RootCapability):
+		return a
+	}
+
+	var ret []string
+	for _, capability := range a {
+		if capability != DenyCapability && strutil.StrListContains(b, capability) {
+			ret = append(ret, capability)
+		}
+	}
+	if len(ret) == 0 {
+		return []string{DenyCapability}
+	}
+	return ret
+}
+
+func (a *ACL) capabilities(path string) (pathCapabilities []string) {
 	// Fast-path root
 	if a.root {
 		return []string{RootCapability}
@@ -251,8 +291,33 @@ CHECK:
 	return
 }
 
+// matchingRule returns the prefix of the rule the given path is governed by,
+// and whether it is a glob. An exact rule is preferred over the longest
+// matching glob, as when checking operations.
+func (a *ACL) matchingRule(path string) (prefix string, glob bool, ok bool) {
+	if _, ok := a.exactRules.Get(path); ok {
+		return path, false, true
+	}
+	prefix, _, ok = a.globRules.LongestPrefix(path)
+	return prefix, true, ok
+}
+
 // AllowOperation is used to check if the given operation is permitted.
-func (a *ACL) AllowOperation(req *logical.Request) (ret *ACLResults) {
+func (a *ACL) AllowOperation(req *logical.Request) *ACLResults {
+	ret := a.allowOperation(req)
+	for _, r := range a.restrictions {
+		if !ret.Allowed {
+			break
+		}
+		restricted := r.allowOperation(req)
+		ret.Allowed = restricted.Allowed
+		ret.RootPrivs = ret.RootPrivs && restricted.RootPrivs
+		ret.IsRoot = ret.IsRoot && restricted.IsRoot
+	}
+	return ret
+}
+
+func (a *ACL) allowOperation(req *logical.Request) (ret *ACLResults) {
 	ret = new(ACLResults)
 
 	// Fast-path root
@@ -0,0 +1,456 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// activitySubPath is the sub-path of the system view the monthly
+	// segments of the activity log are stored under
+	activitySubPath = "activity/"
+
+	// activityMonthFormat is the format of the month of the segments, which
+	// is also the first part of their storage key
+	activityMonthFormat = "2006-01"
+
+	// activityRetentionMonths is how many months of segments are kept
+	activityRetentionMonths = 24
+
+	// activityDefaultMonths is how many months are reported when no start
+	// time is given
+	activityDefaultMonths = 12
+
+	// activityRootNamespaceKey is the storage key of the segments of the root
+	// namespace, whose ID is empty
+	activityRootNamespaceKey = "root"
+)
+
+var (
+	// activityFlushInterval is how often the active node persists the
+	// clients seen since the last flush
+	activityFlushInterval = 10 * time.Minute
+)
+
+// activityClients are the distinct clients of a namespace in a month. Only
+// HMACs of the entity IDs and of the IDs of tokens without an entity are
+// kept, so the activity log can't be used to recover them.
+type activityClients struct {
+	Entities        map[string]struct{}
+	NonEntityTokens map[string]struct{}
+}
+
+func newActivityClients() *activityClients {
+	return &activityClients{
+		Entities:        map[string]struct{}{},
+		NonEntityTokens: map[string]struct{}{},
+	}
+}
+
+// merge adds the clients of other
+func (a *activityClients) merge(other *activityClients) {
+	for id := range other.Entities {
+		a.Entities[id] = struct{}{}
+	}
+	for id := range other.NonEntityTokens {
+		a.NonEntityTokens[id] = struct{}{}
+	}
+}
+
+// counts returns the number of distinct clients as response data
+func (a *activityClients) counts() map[string]int {
+	return map[string]int{
+		"distinct_clients":   len(a.Entities) + len(a.NonEntityTokens),
+		"entity_clients":     len(a.Entities),
+		"non_entity_clients": len(a.NonEntityTokens),
+	}
+}
+
+// activitySegment is the stored form of the clients of a namespace in a month
+type activitySegment struct {
+	Month           string   `json:"month"`
+	NamespaceID     string   `json:"namespace_id"`
+	Entities        []string `json:"entities"`
+	NonEntityTokens []string `json:"non_entity_tokens"`
+}
+
+func (s *activitySegment) clients() *activityClients {
+	clients := newActivityClients()
+	for _, id := range s.Entities {
+		clients.Entities[id] = struct{}{}
+	}
+	for _, id := range s.NonEntityTokens {
+		clients.NonEntityTokens[id] = struct{}{}
+	}
+	return clients
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// activityKey returns the storage key of the segment of the namespace in the
+// month
+func activityKey(month, namespaceID string) string {
+	if namespaceID == "" {
+		namespaceID = activityRootNamespaceKey
+	}
+	return month + "/" + namespaceID
+}
+
+// parseActivityKey returns the month and the namespace ID of a storage key
+func parseActivityKey(key string) (string, string) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return key, ""
+	}
+	if parts[1] == activityRootNamespaceKey {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// recordActivity adds the client of the token to the clients of the current
+// month. Clients are only recorded on the active node, and are persisted by
+// the next flush.
+func (c *Core) recordActivity(ctx context.Context, te *logical.TokenEntry) {
+	if te == nil || c.activityCh == nil {
+		return
+	}
+
+	s, err := c.tokenStore.Salt(ctx)
+	if err != nil {
+		c.logger.Error("failed to record client activity", "error", err)
+		return
+	}
+
+	key := activityKey(time.Now().UTC().Format(activityMonthFormat), te.NamespaceID)
+
+	c.activityLock.Lock()
+	defer c.activityLock.Unlock()
+
+	clients, ok := c.activityPending[key]
+	if !ok {
+		clients = newActivityClients()
+		c.activityPending[key] = clients
+	}
+	if te.EntityID != "" {
+		clients.Entities[s.GetHMAC(te.EntityID)] = struct{}{}
+	} else {
+		clients.NonEntityTokens[s.GetHMAC(te.ID)] = struct{}{}
+	}
+}
+
+// pendingActivity returns a copy of the clients recorded since the last flush
+func (c *Core) pendingActivity() map[string]*activityClients {
+	c.activityLock.Lock()
+	defer c.activityLock.Unlock()
+
+	pending := make(map[string]*activityClients, len(c.activityPending))
+	for key, clients := range c.activityPending {
+		copied := newActivityClients()
+		copied.merge(clients)
+		pending[key] = copied
+	}
+	return pending
+}
+
+// flushActivity merges the clients recorded since the last flush into the
+// stored segments, and removes the segments past the retention period. The
+// clients that couldn't be persisted are kept for the next flush.
+func (c *Core) flushActivity(ctx context.Context) error {
+	view := c.systemBarrierView.SubView(activitySubPath)
+
+	c.activityLock.Lock()
+	pending := c.activityPending
+	c.activityPending = map[string]*activityClients{}
+	c.activityLock.Unlock()
+
+	keys := make([]string, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		if err := c.persistActivitySegment(ctx, view, key, pending[key]); err != nil {
+			c.activityLock.Lock()
+			for _, k := range keys[i:] {
+				if clients, ok := c.activityPending[k]; ok {
+					clients.merge(pending[k])
+				} else {
+					c.activityPending[k] = pending[k]
+				}
+			}
+			c.activityLock.Unlock()
+			return err
+		}
+	}
+
+	months, err := view.List(ctx, "")
+	if err != nil {
+		return errwrap.Wrapf("failed to list activity log: {{err}}", err)
+	}
+	oldest := time.Now().UTC().AddDate(0, -activityRetentionMonths, 0).Format(activityMonthFormat)
+	for _, month := range months {
+		month = strings.TrimSuffix(month, "/")
+		if month >= oldest {
+			continue
+		}
+		if err := logical.ClearView(ctx, view.SubView(month+"/")); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to delete activity log of %s: {{err}}", month), err)
+		}
+	}
+
+	return nil
+}
+
+// persistActivitySegment merges the clients into the stored segment with the
+// given key
+func (c *Core) persistActivitySegment(ctx context.Context, view *BarrierView, key string, clients *activityClients) error {
+	month, namespaceID := parseActivityKey(key)
+	segment, err := readActivitySegment(ctx, view, key)
+	if err != nil {
+		return err
+	}
+	merged := clients
+	if segment != nil {
+		merged = segment.clients()
+		merged.merge(clients)
+	}
+
+	entry, err := logical.StorageEntryJSON(key, &activitySegment{
+		Month:           month,
+		NamespaceID:     namespaceID,
+		Entities:        sortedSet(merged.Entities),
+		NonEntityTokens: sortedSet(merged.NonEntityTokens),
+	})
+	if err != nil {
+		return errwrap.Wrapf("failed to encode activity log: {{err}}", err)
+	}
+	if err := view.Put(ctx, entry); err != nil {
+		return errwrap.Wrapf("failed to persist activity log: {{err}}", err)
+	}
+	return nil
+}
+
+func readActivitySegment(ctx context.Context, view *BarrierView, key string) (*activitySegment, error) {
+	raw, err := view.Get(ctx, key)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read activity log: {{err}}", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	var segment activitySegment
+	if err := raw.DecodeJSON(&segment); err != nil {
+		return nil, errwrap.Wrapf("failed to decode activity log: {{err}}", err)
+	}
+	return &segment, nil
+}
+
+// activity returns the clients of each namespace in each month between start
+// and end, both included, keyed by month then by namespace ID. The stored
+// segments are combined with the clients not yet flushed.
+func (c *Core) activity(ctx context.Context, start, end string) (map[string]map[string]*activityClients, error) {
+	view := c.systemBarrierView.SubView(activitySubPath)
+
+	result := map[string]map[string]*activityClients{}
+	add := func(key string, clients *activityClients) {
+		month, namespaceID := parseActivityKey(key)
+		if month < start || month > end {
+			return
+		}
+		if _, ok := result[month]; !ok {
+			result[month] = map[string]*activityClients{}
+		}
+		if existing, ok := result[month][namespaceID]; ok {
+			existing.merge(clients)
+		} else {
+			result[month][namespaceID] = clients
+		}
+	}
+
+	months, err := view.List(ctx, "")
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list activity log: {{err}}", err)
+	}
+	for _, month := range months {
+		month = strings.TrimSuffix(month, "/")
+		if month < start || month > end {
+			continue
+		}
+		namespaceIDs, err := view.List(ctx, month+"/")
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to list activity log: {{err}}", err)
+		}
+		for _, namespaceID := range namespaceIDs {
+			key := month + "/" + namespaceID
+			segment, err := readActivitySegment(ctx, view, key)
+			if err != nil {
+				return nil, err
+			}
+			if segment != nil {
+				add(key, segment.clients())
+			}
+		}
+	}
+
+	for key, clients := range c.pendingActivity() {
+		add(key, clients)
+	}
+
+	return result, nil
+}
+
+// runActivityLog periodically flushes the activity log, until the stop
+// channel is closed
+func (c *Core) runActivityLog(stopCh chan struct{}) {
+	ticker := time.NewTicker(activityFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.stateLock.RLock()
+			select {
+			case <-stopCh:
+				c.stateLock.RUnlock()
+				return
+			default:
+			}
+			if err := c.flushActivity(c.activeContext); err != nil {
+				c.logger.Error("failed to flush the activity log", "error", err)
+			}
+			c.stateLock.RUnlock()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// parseActivityMonth returns the month of a time given as a month, such as
+// "2018-09", or in RFC 3339 format
+func parseActivityMonth(raw string) (string, error) {
+	if t, err := time.Parse(activityMonthFormat, raw); err == nil {
+		return t.Format(activityMonthFormat), nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid time %q, must be a month such as \"2018-09\" or in RFC 3339 format", raw)
+	}
+	return t.UTC().Format(activityMonthFormat), nil
+}
+
+// handleActivityRead returns the number of distinct clients between the
+// start and end times, in total, by namespace and by month
+func (b *SystemBackend) handleActivityRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	end := time.Now().UTC().Format(activityMonthFormat)
+	if raw := data.Get("end_time").(string); raw != "" {
+		var err error
+		if end, err = parseActivityMonth(raw); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+	endMonth, err := time.Parse(activityMonthFormat, end)
+	if err != nil {
+		return nil, err
+	}
+	start := endMonth.AddDate(0, 1-activityDefaultMonths, 0).Format(activityMonthFormat)
+	if raw := data.Get("start_time").(string); raw != "" {
+		if start, err = parseActivityMonth(raw); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+	if start > end {
+		return logical.ErrorResponse("start_time must not be after end_time"), logical.ErrInvalidRequest
+	}
+
+	// The namespace filter includes the namespaces within it
+	var filter *Namespace
+	if path := data.Get("namespace").(string); path != "" {
+		path = strings.Trim(path, "/") + "/"
+		filter = b.Core.namespaceByPath(path)
+		if filter.Path != path {
+			return logical.ErrorResponse(fmt.Sprintf("namespace %q does not exist", path)), logical.ErrInvalidRequest
+		}
+	}
+
+	activity, err := b.Core.activity(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	total := newActivityClients()
+	byNamespace := map[string]*activityClients{}
+	monthsData := []map[string]interface{}{}
+
+	months := make([]string, 0, len(activity))
+	for month := range activity {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	for _, month := range months {
+		monthTotal := newActivityClients()
+		monthByNamespace := map[string]map[string]int{}
+		for namespaceID, clients := range activity[month] {
+			// Clients of deleted namespaces are reported under their ID
+			nsKey := namespaceID
+			ns := b.Core.namespaceByID(namespaceID)
+			if ns != nil {
+				nsKey = namespaceCounterKey(ns)
+			}
+			if filter != nil && (ns == nil || !strings.HasPrefix(ns.Path, filter.Path)) {
+				continue
+			}
+
+			monthTotal.merge(clients)
+			monthByNamespace[nsKey] = clients.counts()
+			if _, ok := byNamespace[nsKey]; !ok {
+				byNamespace[nsKey] = newActivityClients()
+			}
+			byNamespace[nsKey].merge(clients)
+		}
+		total.merge(monthTotal)
+
+		monthData := map[string]interface{}{
+			"month":        month,
+			"by_namespace": monthByNamespace,
+		}
+		for k, v := range monthTotal.counts() {
+			monthData[k] = v
+		}
+		monthsData = append(monthsData, monthData)
+	}
+
+	byNamespaceData := make(map[string]map[string]int, len(byNamespace))
+	for nsKey, clients := range byNamespace {
+		byNamespaceData[nsKey] = clients.counts()
+	}
+
+	respData := map[string]interface{}{
+		"start_time":   start,
+		"end_time":     end,
+		"by_namespace": byNamespaceData,
+		"months":       monthsData,
+	}
+	for k, v := range total.counts() {
+		respData[k] = v
+	}
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
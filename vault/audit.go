@@ -102,6 +102,9 @@ func (c *Core) enableAudit(ctx context.Context, entry *MountEntry) error {
 	newTable := c.audit.shallowClone()
 	newTable.Entries = append(newTable.Entries, entry)
 	if err := c.persistAudit(ctx, newTable, entry.Local); err != nil {
+		if f, ok := backend.(*auditFallback); ok {
+			f.stop()
+		}
 		return errors.New("failed to update audit table")
 	}
 
@@ -346,6 +349,7 @@ func (c *Core) setupAudits(ctx context.Context) error {
 	}
 
 	if len(c.audit.Entries) > 0 && successCount == 0 {
+		broker.stopFallbacks()
 		return errLoadAuditFailed
 	}
 
@@ -365,6 +369,9 @@ func (c *Core) teardownAudits() error {
 		}
 	}
 
+	if c.auditBroker != nil {
+		c.auditBroker.stopFallbacks()
+	}
 	c.audit = nil
 	c.auditBroker = nil
 	return nil
@@ -449,7 +456,7 @@ func (c *Core) newAuditBackend(ctx context.Context, entry *MountEntry, view logi
 		}
 	}
 
-	return be, err
+	return newAuditFallback(entry.Path, be, conf, auditLogger)
 }
 
 // defaultAuditTable creates a default audit table
@@ -48,9 +48,24 @@ func (a *AuditBroker) Register(name string, b audit.Backend, v *BarrierView) {
 func (a *AuditBroker) Deregister(name string) {
 	a.Lock()
 	defer a.Unlock()
+	if f, ok := a.backends[name].backend.(*auditFallback); ok {
+		f.stop()
+	}
 	delete(a.backends, name)
 }
 
+// stopFallbacks stops the replay of the entries buffered by the backends and
+// closes their buffers
+func (a *AuditBroker) stopFallbacks() {
+	a.Lock()
+	defer a.Unlock()
+	for _, be := range a.backends {
+		if f, ok := be.backend.(*auditFallback); ok {
+			f.stop()
+		}
+	}
+}
+
 // IsRegistered is used to check if a given audit backend is registered
 func (a *AuditBroker) IsRegistered(name string) bool {
 	a.RLock()
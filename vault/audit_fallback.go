@@ -0,0 +1,397 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/parseutil"
+)
+
+const (
+	// The fallbacks of an audit backend failing to log, set with its
+	// fallback option
+	auditFallbackBlock    = "block"
+	auditFallbackBuffer   = "buffer"
+	auditFallbackFailOpen = "fail_open"
+
+	// auditBufferDefaultMaxSize is the default size of the buffer of an
+	// audit backend, in bytes
+	auditBufferDefaultMaxSize = 100 * 1024 * 1024
+
+	// auditBufferHeaderSize is the size of the length preceding each entry
+	// of a buffer
+	auditBufferHeaderSize = 4
+)
+
+var (
+	// auditBufferReplayInterval is how often the entries of a buffer are
+	// written again to their backend
+	auditBufferReplayInterval = 5 * time.Second
+
+	// auditBufferReplayBatchSize is how many buffered entries are read at
+	// once to be written again to their backend
+	auditBufferReplayBatchSize = 1024
+
+	errAuditBufferFull = errors.New("audit buffer is full")
+)
+
+// auditFallback wraps an audit backend to change what happens when it fails
+// to log. With the buffer fallback, the entries are written to a bounded
+// local buffer, and written to the backend in order once it recovers; a
+// buffered entry counts as logged. With the fail_open fallback, the failures
+// are reported but count as logged, so that requests are served even if no
+// backend logs them. With the block fallback, the default, the backend is
+// not wrapped and requests fail if no backend logs them.
+type auditFallback struct {
+	audit.Backend
+
+	path   string
+	logger log.Logger
+
+	// buffer is nil with the fail_open fallback
+	buffer *auditBuffer
+
+	// replayL serializes the replays of the buffer, the only ones removing
+	// its entries
+	replayL sync.Mutex
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// newAuditFallback wraps the backend of the audit entry at the given path as
+// per its fallback options
+func newAuditFallback(path string, be audit.Backend, conf map[string]string, logger log.Logger) (audit.Backend, error) {
+	switch conf["fallback"] {
+	case "", auditFallbackBlock:
+		return be, nil
+
+	case auditFallbackFailOpen:
+		return &auditFallback{
+			Backend: be,
+			path:    path,
+			logger:  logger,
+		}, nil
+
+	case auditFallbackBuffer:
+		if _, ok := be.(audit.EntryBackend); !ok {
+			return nil, errors.New("this audit backend cannot buffer its entries")
+		}
+		bufferPath := conf["buffer_path"]
+		if bufferPath == "" {
+			return nil, errors.New("buffer_path must be set with the buffer fallback")
+		}
+		maxSize := int64(auditBufferDefaultMaxSize)
+		if raw, ok := conf["buffer_max_size"]; ok {
+			size, err := parseutil.ParseInt(raw)
+			if err != nil || size <= auditBufferHeaderSize {
+				return nil, fmt.Errorf("invalid buffer_max_size %q", raw)
+			}
+			maxSize = size
+		}
+
+		buffer, err := openAuditBuffer(bufferPath, maxSize)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to open the audit buffer %q: {{err}}", bufferPath), err)
+		}
+		f := &auditFallback{
+			Backend: be,
+			path:    path,
+			logger:  logger,
+			buffer:  buffer,
+			stopCh:  make(chan struct{}),
+		}
+		go f.runReplay()
+		return f, nil
+
+	default:
+		return nil, fmt.Errorf("invalid fallback %q, must be one of %q, %q or %q", conf["fallback"], auditFallbackBlock, auditFallbackBuffer, auditFallbackFailOpen)
+	}
+}
+
+func (f *auditFallback) LogRequest(ctx context.Context, in *audit.LogInput) error {
+	if f.buffer == nil {
+		return f.failOpen(f.Backend.LogRequest(ctx, in))
+	}
+
+	var buf bytes.Buffer
+	if err := f.Backend.(audit.EntryBackend).FormatRequest(ctx, &buf, in); err != nil {
+		return err
+	}
+	return f.write(ctx, buf.Bytes())
+}
+
+func (f *auditFallback) LogResponse(ctx context.Context, in *audit.LogInput) error {
+	if f.buffer == nil {
+		return f.failOpen(f.Backend.LogResponse(ctx, in))
+	}
+
+	var buf bytes.Buffer
+	if err := f.Backend.(audit.EntryBackend).FormatResponse(ctx, &buf, in); err != nil {
+		return err
+	}
+	return f.write(ctx, buf.Bytes())
+}
+
+// failOpen reports the failure of the backend to log, without returning it
+func (f *auditFallback) failOpen(err error) error {
+	if err != nil {
+		f.logger.Error("backend failed to log, failing open", "backend", f.path, "error", err)
+		metrics.IncrCounter([]string{"audit", f.path, "fail_open"}, 1)
+	}
+	return nil
+}
+
+// write writes an entry to the backend, or to the buffer if the backend
+// fails. The entries are buffered while the buffer is not empty, so that
+// they are written in order. The lock of the buffer is not held while
+// writing to the backend, which may be slow to fail.
+func (f *auditFallback) write(ctx context.Context, entry []byte) error {
+	f.buffer.l.Lock()
+	empty := f.buffer.size == 0
+	f.buffer.l.Unlock()
+
+	if empty {
+		err := f.Backend.(audit.EntryBackend).WriteEntry(ctx, entry)
+		if err == nil {
+			return nil
+		}
+		f.logger.Error("backend failed to log, buffering entries", "backend", f.path, "buffer_path", f.buffer.path, "error", err)
+	}
+
+	f.buffer.l.Lock()
+	defer f.buffer.l.Unlock()
+	if err := f.buffer.append(entry); err != nil {
+		return errwrap.Wrapf("failed to buffer entry: {{err}}", err)
+	}
+	metrics.IncrCounter([]string{"audit", f.path, "buffered"}, 1)
+	metrics.SetGauge([]string{"audit", f.path, "buffer_size"}, float32(f.buffer.size))
+	return nil
+}
+
+// runReplay periodically writes the buffered entries to the backend, until
+// the fallback is stopped
+func (f *auditFallback) runReplay() {
+	ticker := time.NewTicker(auditBufferReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		f.replay(context.Background())
+
+		select {
+		case <-f.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replay writes the buffered entries to the backend in order, in batches,
+// stopping at the first failure, and removes those written from the buffer.
+// The lock of the buffer is only held to read and remove the entries, so
+// that entries keep being buffered while they are written.
+func (f *auditFallback) replay(ctx context.Context) {
+	f.replayL.Lock()
+	defer f.replayL.Unlock()
+
+	var replayed int
+	for {
+		f.buffer.l.Lock()
+		if f.buffer.f == nil || f.buffer.size == 0 {
+			f.buffer.l.Unlock()
+			return
+		}
+		entries, err := f.buffer.read(auditBufferReplayBatchSize)
+		f.buffer.l.Unlock()
+		if err != nil {
+			f.logger.Error("failed to read the buffered entries", "backend", f.path, "buffer_path", f.buffer.path, "error", err)
+			return
+		}
+
+		var written int
+		var writtenSize int64
+		for _, entry := range entries {
+			if err := f.Backend.(audit.EntryBackend).WriteEntry(ctx, entry); err != nil {
+				break
+			}
+			written++
+			writtenSize += int64(auditBufferHeaderSize + len(entry))
+		}
+		if written == 0 {
+			return
+		}
+		replayed += written
+
+		f.buffer.l.Lock()
+		err = f.buffer.trim(writtenSize)
+		size := f.buffer.size
+		f.buffer.l.Unlock()
+		if err != nil {
+			f.logger.Error("failed to remove the replayed entries from the buffer", "backend", f.path, "buffer_path", f.buffer.path, "error", err)
+			return
+		}
+		metrics.SetGauge([]string{"audit", f.path, "buffer_size"}, float32(size))
+		if size == 0 {
+			f.logger.Info("backend recovered, replayed the buffered entries", "backend", f.path, "entries", replayed)
+			return
+		}
+		if written < len(entries) {
+			return
+		}
+	}
+}
+
+// stop stops the replay of the buffered entries and closes the buffer
+func (f *auditFallback) stop() {
+	if f.buffer == nil {
+		return
+	}
+	f.stopOnce.Do(func() {
+		close(f.stopCh)
+
+		f.buffer.l.Lock()
+		defer f.buffer.l.Unlock()
+		if err := f.buffer.close(); err != nil {
+			f.logger.Error("failed to close the audit buffer", "backend", f.path, "buffer_path", f.buffer.path, "error", err)
+		}
+	})
+}
+
+// auditBuffer is a file holding the entries an audit backend failed to
+// write, each preceded by its length. Its lock must be held to use it.
+type auditBuffer struct {
+	l       sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+}
+
+// openAuditBuffer opens the buffer at the given path, keeping the entries it
+// holds
+func openAuditBuffer(path string, maxSize int64) (*auditBuffer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &auditBuffer{
+		path:    path,
+		maxSize: maxSize,
+		f:       f,
+		size:    info.Size(),
+	}, nil
+}
+
+// append adds an entry to the buffer, unless it would exceed its maximum
+// size
+func (b *auditBuffer) append(entry []byte) error {
+	if b.f == nil {
+		return errors.New("audit buffer is closed")
+	}
+	record := auditBufferRecord(entry)
+	if b.size+int64(len(record)) > b.maxSize {
+		return errAuditBufferFull
+	}
+
+	if _, err := b.f.Write(record); err != nil {
+		// Drop what may have been written of the entry
+		b.f.Truncate(b.size)
+		return err
+	}
+	b.size += int64(len(record))
+	return nil
+}
+
+// read returns the first entries of the buffer, at most limit of them. An
+// entry only partially written, such as on a crash, is ignored.
+func (b *auditBuffer) read(limit int) ([][]byte, error) {
+	var entries [][]byte
+	header := make([]byte, auditBufferHeaderSize)
+	var offset int64
+	for len(entries) < limit && offset+auditBufferHeaderSize <= b.size {
+		if _, err := b.f.ReadAt(header, offset); err != nil {
+			return nil, err
+		}
+		length := int64(binary.BigEndian.Uint32(header))
+		if offset+auditBufferHeaderSize+length > b.size {
+			break
+		}
+		entry := make([]byte, length)
+		if _, err := b.f.ReadAt(entry, offset+auditBufferHeaderSize); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+		offset += auditBufferHeaderSize + length
+	}
+	return entries, nil
+}
+
+// trim removes the given number of bytes of entries from the start of the
+// buffer, atomically, keeping the entries appended since they were read
+func (b *auditBuffer) trim(size int64) error {
+	if b.f == nil {
+		return errors.New("audit buffer is closed")
+	}
+	tmpPath := b.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, io.NewSectionReader(b.f, size, b.size-size)); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, b.path); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	b.f.Close()
+	b.f = tmp
+	b.size -= size
+	return nil
+}
+
+func (b *auditBuffer) close() error {
+	if b.f == nil {
+		return nil
+	}
+	err := b.f.Close()
+	b.f = nil
+	return err
+}
+
+// auditBufferRecord returns an entry preceded by its length
+func auditBufferRecord(entry []byte) []byte {
+	record := make([]byte, auditBufferHeaderSize+len(entry))
+	binary.BigEndian.PutUint32(record, uint32(len(entry)))
+	copy(record[auditBufferHeaderSize:], entry)
+	return record
+}
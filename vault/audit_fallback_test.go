@@ -0,0 +1,310 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/logical"
+)
+
+// testEntryAudit is an audit backend writing its entries in memory, failing
+// while its err is set
+type testEntryAudit struct {
+	NoopAudit
+
+	l       sync.Mutex
+	err     error
+	entries []string
+}
+
+var _ audit.EntryBackend = (*testEntryAudit)(nil)
+
+func (a *testEntryAudit) LogRequest(ctx context.Context, in *audit.LogInput) error {
+	a.l.Lock()
+	defer a.l.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.entries = append(a.entries, "request "+in.Request.Path)
+	return nil
+}
+
+func (a *testEntryAudit) FormatRequest(_ context.Context, w io.Writer, in *audit.LogInput) error {
+	_, err := fmt.Fprintf(w, "request %s", in.Request.Path)
+	return err
+}
+
+func (a *testEntryAudit) FormatResponse(_ context.Context, w io.Writer, in *audit.LogInput) error {
+	_, err := fmt.Fprintf(w, "response %s", in.Request.Path)
+	return err
+}
+
+func (a *testEntryAudit) WriteEntry(_ context.Context, entry []byte) error {
+	a.l.Lock()
+	defer a.l.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.entries = append(a.entries, string(entry))
+	return nil
+}
+
+func (a *testEntryAudit) setErr(err error) {
+	a.l.Lock()
+	defer a.l.Unlock()
+	a.err = err
+}
+
+func (a *testEntryAudit) written() []string {
+	a.l.Lock()
+	defer a.l.Unlock()
+	return append([]string(nil), a.entries...)
+}
+
+func TestAuditFallback_Options(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Trace)
+	be := &testEntryAudit{}
+
+	for _, fallback := range []string{"", "block"} {
+		wrapped, err := newAuditFallback("test/", be, map[string]string{"fallback": fallback}, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wrapped != be {
+			t.Fatalf("expected the backend not to be wrapped with fallback %q", fallback)
+		}
+	}
+
+	cases := map[string]map[string]string{
+		"invalid fallback":    {"fallback": "retry"},
+		"missing buffer_path": {"fallback": "buffer"},
+		"invalid size":        {"fallback": "buffer", "buffer_path": "/tmp/buffer", "buffer_max_size": "-1"},
+	}
+	for name, conf := range cases {
+		if _, err := newAuditFallback("test/", be, conf, logger); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+	if _, err := newAuditFallback("test/", &NoopAudit{}, map[string]string{"fallback": "buffer", "buffer_path": "/tmp/buffer"}, logger); err == nil {
+		t.Fatal("expected an error for a backend which cannot buffer")
+	}
+}
+
+func TestAuditFallback_FailOpen(t *testing.T) {
+	be := &testEntryAudit{err: errors.New("disk full")}
+	wrapped, err := newAuditFallback("test/", be, map[string]string{"fallback": "fail_open"}, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failing backend counts as logged
+	broker := NewAuditBroker(logging.NewVaultLogger(log.Trace))
+	broker.Register("test/", wrapped, nil)
+	in := &audit.LogInput{Request: &logical.Request{Path: "sys/mounts"}}
+	if err := broker.LogRequest(context.Background(), in, &AuditedHeadersConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without fallback, it does not
+	broker.Register("test/", be, nil)
+	if err := broker.LogRequest(context.Background(), in, &AuditedHeadersConfig{}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestAuditFallback_Buffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-audit-buffer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bufferPath := filepath.Join(dir, "audit.buffer")
+	conf := map[string]string{
+		"fallback":        "buffer",
+		"buffer_path":     bufferPath,
+		"buffer_max_size": "64",
+	}
+	ctx := context.Background()
+	logRequest := func(b audit.Backend, path string) error {
+		return b.LogRequest(ctx, &audit.LogInput{Request: &logical.Request{Path: path}})
+	}
+
+	be := &testEntryAudit{}
+	wrapped, err := newAuditFallback("test/", be, conf, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := wrapped.(*auditFallback)
+
+	if err := logRequest(f, "one"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entries are buffered while the backend fails, up to the size of
+	// the buffer
+	be.setErr(errors.New("disk full"))
+	for _, path := range []string{"two", "three", "four"} {
+		if err := logRequest(f, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := logRequest(f, "too many entries"); err == nil || !strings.Contains(err.Error(), errAuditBufferFull.Error()) {
+		t.Fatalf("expected a full buffer, got %v", err)
+	}
+	f.replay(ctx)
+
+	// The entries are still buffered while the buffer is not empty, and
+	// replayed in order
+	be.setErr(nil)
+	if err := logRequest(f, "five"); err != nil {
+		t.Fatal(err)
+	}
+	if written := be.written(); len(written) != 1 {
+		t.Fatalf("bad entries: %#v", written)
+	}
+	f.replay(ctx)
+	expected := []string{"request one", "request two", "request three", "request four", "request five"}
+	if written := be.written(); strings.Join(written, ",") != strings.Join(expected, ",") {
+		t.Fatalf("bad entries: %#v", written)
+	}
+	if err := logRequest(f, "six"); err != nil {
+		t.Fatal(err)
+	}
+	if written := be.written(); len(written) != 6 || written[5] != "request six" {
+		t.Fatalf("bad entries: %#v", written)
+	}
+	if info, err := os.Stat(bufferPath); err != nil || info.Size() != 0 {
+		t.Fatalf("expected an empty buffer, got %v, err: %v", info, err)
+	}
+
+	// The buffer is kept across restarts
+	be.setErr(errors.New("disk full"))
+	if err := logRequest(f, "seven"); err != nil {
+		t.Fatal(err)
+	}
+	f.stop()
+
+	be = &testEntryAudit{}
+	wrapped, err = newAuditFallback("test/", be, conf, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = wrapped.(*auditFallback)
+	defer f.stop()
+	f.replay(ctx)
+	if written := be.written(); len(written) != 1 || written[0] != "request seven" {
+		t.Fatalf("bad entries: %#v", written)
+	}
+}
+
+// blockingEntryAudit is an audit backend whose writes wait for release to be
+// closed, reporting on entered when one starts waiting
+type blockingEntryAudit struct {
+	*testEntryAudit
+
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (a *blockingEntryAudit) WriteEntry(ctx context.Context, entry []byte) error {
+	select {
+	case a.entered <- struct{}{}:
+	default:
+	}
+	<-a.release
+	return a.testEntryAudit.WriteEntry(ctx, entry)
+}
+
+func TestAuditFallback_BufferReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-audit-buffer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf := map[string]string{
+		"fallback":    "buffer",
+		"buffer_path": filepath.Join(dir, "audit.buffer"),
+	}
+	ctx := context.Background()
+	logRequest := func(b audit.Backend, path string) error {
+		return b.LogRequest(ctx, &audit.LogInput{Request: &logical.Request{Path: path}})
+	}
+
+	batchSize := auditBufferReplayBatchSize
+	auditBufferReplayBatchSize = 2
+	defer func() { auditBufferReplayBatchSize = batchSize }()
+
+	be := &testEntryAudit{err: errors.New("disk full")}
+	wrapped, err := newAuditFallback("test/", be, conf, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"one", "two", "three", "four", "five"} {
+		if err := logRequest(wrapped, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wrapped.(*auditFallback).stop()
+
+	// The buffer is replayed on start, by a backend slow to write
+	blocking := &blockingEntryAudit{
+		testEntryAudit: &testEntryAudit{},
+		entered:        make(chan struct{}, 1),
+		release:        make(chan struct{}),
+	}
+	wrapped, err = newAuditFallback("test/", blocking, conf, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := wrapped.(*auditFallback)
+	defer f.stop()
+	select {
+	case <-blocking.entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the buffer was not replayed")
+	}
+
+	// Entries are still buffered while the replay writes
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- logRequest(f, "six")
+	}()
+	select {
+	case err := <-doneCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the entry was not buffered during the replay")
+	}
+
+	// All of them are replayed in order, in batches
+	close(blocking.release)
+	expected := []string{"request one", "request two", "request three", "request four", "request five", "request six"}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		written := blocking.written()
+		f.buffer.l.Lock()
+		size := f.buffer.size
+		f.buffer.l.Unlock()
+		if strings.Join(written, ",") == strings.Join(expected, ",") && size == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("bad entries: %#v, buffer size: %d", written, size)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -703,3 +703,41 @@ func TestAuditBroker_AuditHeaders(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 }
+
+func TestDynamicSystemView_AuditRequest(t *testing.T) {
+	noop := &NoopAudit{}
+	c, _, root := TestCoreUnsealed(t)
+	c.auditBackends["noop"] = func(ctx context.Context, config *audit.BackendConfig) (audit.Backend, error) {
+		noop = &NoopAudit{
+			Config: config,
+		}
+		return noop, nil
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/audit/noop")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(context.Background(), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	sysView := dynamicSystemView{
+		core:       c,
+		mountEntry: c.router.MatchingMountEntry("secret/"),
+	}
+	err := sysView.AuditRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "foo/rotate",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(noop.Req) != 1 {
+		t.Fatalf("bad: %#v", noop.Req)
+	}
+	audited := noop.Req[0]
+	if audited.Path != "secret/foo/rotate" || audited.MountPoint != "secret/" || audited.MountType != "kv" || audited.ID == "" {
+		t.Fatalf("bad: %#v", audited)
+	}
+}
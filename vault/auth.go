@@ -56,6 +56,11 @@ func (c *Core) enableCredential(ctx context.Context, entry *MountEntry) error {
 		return fmt.Errorf("backend path must be specified")
 	}
 
+	// Ensure the path is not within a namespace other than the entry's
+	if _, err := c.checkNamespaceMountPath(entry); err != nil {
+		return err
+	}
+
 	c.authLock.Lock()
 	defer c.authLock.Unlock()
 
@@ -149,6 +154,10 @@ func (c *Core) enableCredential(ctx context.Context, entry *MountEntry) error {
 	if c.logger.IsInfo() {
 		c.logger.Info("enabled credential backend", "path", entry.Path, "type", entry.Type)
 	}
+
+	c.publishMountEvent(credentialRoutePrefix+entry.Path, "enable", map[string]string{
+		"type": entry.Type,
+	})
 	return nil
 }
 
@@ -161,7 +170,7 @@ func (c *Core) disableCredential(ctx context.Context, path string) error {
 	}
 
 	// Ensure the token backend is not affected
-	if path == "token/" {
+	if strings.TrimPrefix(path, c.namespaceByPath(path).Path) == "token/" {
 		return fmt.Errorf("token credential backend cannot be disabled")
 	}
 
@@ -219,6 +228,8 @@ func (c *Core) disableCredential(ctx context.Context, path string) error {
 	if c.logger.IsInfo() {
 		c.logger.Info("disabled credential backend", "path", path)
 	}
+
+	c.publishMountEvent(credentialRoutePrefix+path, "disable", nil)
 	return nil
 }
 
@@ -150,6 +150,11 @@ type BarrierStorage interface {
 	// List is used ot list all the keys under a given
 	// prefix, up to the next prefix.
 	List(ctx context.Context, prefix string) ([]string, error)
+
+	// ListPage is used to list, in lexical order, at most limit of the keys
+	// under a given prefix, up to the next prefix, that sort after the given
+	// key.
+	ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error)
 }
 
 // BarrierEncryptor is the in memory only interface that does not actually
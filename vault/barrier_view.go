@@ -64,6 +64,14 @@ func (v *BarrierView) List(ctx context.Context, prefix string) ([]string, error)
 	return v.barrier.List(ctx, v.expandKey(prefix))
 }
 
+// logical.Paginator impl.
+func (v *BarrierView) ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error) {
+	if err := v.sanityCheck(prefix); err != nil {
+		return nil, err
+	}
+	return v.barrier.ListPage(ctx, v.expandKey(prefix), after, limit)
+}
+
 // logical.Storage impl.
 func (v *BarrierView) Get(ctx context.Context, key string) (*logical.StorageEntry, error) {
 	if err := v.sanityCheck(key); err != nil {
@@ -99,9 +107,12 @@ func (v *BarrierView) Put(ctx context.Context, entry *logical.StorageEntry) erro
 
 	expandedKey := v.expandKey(entry.Key)
 
-	roErr := v.getReadOnlyErr()
-	if roErr != nil {
-		return roErr
+	// The read-only error is held for the whole write, so that making the
+	// view read-only waits for the writes in progress
+	v.readOnlyErrLock.RLock()
+	defer v.readOnlyErrLock.RUnlock()
+	if v.readOnlyErr != nil {
+		return v.readOnlyErr
 	}
 
 	nested := &Entry{
@@ -120,9 +131,10 @@ func (v *BarrierView) Delete(ctx context.Context, key string) error {
 
 	expandedKey := v.expandKey(key)
 
-	roErr := v.getReadOnlyErr()
-	if roErr != nil {
-		return roErr
+	v.readOnlyErrLock.RLock()
+	defer v.readOnlyErrLock.RUnlock()
+	if v.readOnlyErr != nil {
+		return v.readOnlyErr
 	}
 
 	return v.barrier.Delete(ctx, expandedKey)
@@ -17,59 +17,63 @@ func (c *Core) Capabilities(ctx context.Context, token, path string) ([]string,
 		return nil, &logical.StatusBadRequest{Err: "missing token"}
 	}
 
-	te, err := c.tokenStore.Lookup(ctx, token)
+	policies, restrictions, err := c.tokenPolicies(ctx, token)
 	if err != nil {
 		return nil, err
 	}
-	if te == nil {
-		return nil, &logical.StatusBadRequest{Err: "invalid token"}
-	}
 
-	if te.Policies == nil {
+	if len(policies) == 0 {
 		return []string{DenyCapability}, nil
 	}
 
-	var policies []*Policy
-	for _, tePolicy := range te.Policies {
-		policy, err := c.policyStore.GetPolicy(ctx, tePolicy, PolicyTypeToken)
-		if err != nil {
-			return nil, err
-		}
-		policies = append(policies, policy)
+	acl, err := NewACL(policies)
+	if err != nil {
+		return nil, err
+	}
+	acl.restrict(restrictions...)
+
+	capabilities := acl.Capabilities(path)
+	sort.Strings(capabilities)
+	return capabilities, nil
+}
+
+// tokenPolicies returns the policies of the given token, including those it
+// is entitled to through its entity, and the ACLs of the inline policies
+// restricting it
+func (c *Core) tokenPolicies(ctx context.Context, token string) ([]*Policy, []*ACL, error) {
+	te, err := c.tokenStore.Lookup(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if te == nil {
+		return nil, nil, &logical.StatusBadRequest{Err: "invalid token"}
+	}
+
+	if te.Policies == nil {
+		return nil, nil, nil
 	}
 
 	entity, derivedPolicies, err := c.fetchEntityAndDerivedPolicies(te.EntityID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if entity != nil && entity.Disabled {
 		c.logger.Warn("permission denied as the entity on the token is disabled")
-		return nil, logical.ErrPermissionDenied
+		return nil, nil, logical.ErrPermissionDenied
 	}
 	if te != nil && te.EntityID != "" && entity == nil {
 		c.logger.Warn("permission denied as the entity on the token is invalid")
-		return nil, logical.ErrPermissionDenied
+		return nil, nil, logical.ErrPermissionDenied
 	}
 
-	for _, item := range derivedPolicies {
-		policy, err := c.policyStore.GetPolicy(ctx, item, PolicyTypeToken)
-		if err != nil {
-			return nil, err
-		}
-		policies = append(policies, policy)
-	}
-
-	if len(policies) == 0 {
-		return []string{DenyCapability}, nil
+	policies, err := c.policiesForToken(ctx, te, derivedPolicies)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	acl, err := NewACL(policies)
+	restrictions, err := c.restrictionsForToken(te)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	capabilities := acl.Capabilities(path)
-	sort.Strings(capabilities)
-	return capabilities, nil
+	return policies, restrictions, nil
 }
@@ -19,6 +19,7 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/fips"
 	"github.com/hashicorp/vault/helper/jsonutil"
 )
 
@@ -405,6 +406,9 @@ func (c *Core) ClusterTLSConfig(ctx context.Context, repClusters *ReplicatedClus
 			NextProtos:           clientHello.SupportedProtos,
 			CipherSuites:         c.clusterCipherSuites,
 		}
+		if err := fips.ConfigureTLS(ret); err != nil {
+			return nil, err
+		}
 
 		switch {
 		default:
@@ -428,6 +432,9 @@ func (c *Core) ClusterTLSConfig(ctx context.Context, repClusters *ReplicatedClus
 		MinVersion:           tls.VersionTLS12,
 		CipherSuites:         c.clusterCipherSuites,
 	}
+	if err := fips.ConfigureTLS(tlsConfig); err != nil {
+		return nil, err
+	}
 
 	parsedCert := c.localClusterParsedCert.Load().(*x509.Certificate)
 	currCert := c.localClusterCert.Load().([]byte)
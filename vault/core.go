@@ -25,6 +25,8 @@ import (
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/entropy"
+	"github.com/hashicorp/vault/helper/fips"
 	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/mlock"
 	"github.com/hashicorp/vault/helper/reload"
@@ -75,6 +77,10 @@ const (
 	// clusters that they need to perform a rekey operation synchronously; this
 	// isn't keyring-canary to avoid ignoring it when ignoring core/keyring
 	coreKeyringCanaryPath = "core/canary-keyring"
+
+	// entropyOperationBarrier is the operation class covering the generation
+	// of barrier keys
+	entropyOperationBarrier = "barrier"
 )
 
 var (
@@ -99,6 +105,10 @@ var (
 	// It's var not const so that tests can manipulate it.
 	manualStepDownSleepPeriod = 10 * time.Second
 
+	// defaultEntropyOperations are the operation classes an entropy source is
+	// used for when none are configured
+	defaultEntropyOperations = []string{entropyOperationBarrier, "transit", "pki"}
+
 	// Functions only in the Enterprise version
 	enterprisePostUnseal = enterprisePostUnsealImpl
 	enterprisePreSeal    = enterprisePreSealImpl
@@ -165,6 +175,10 @@ type Core struct {
 	// physical backend is the un-trusted backend with durable data
 	physical physical.Backend
 
+	// underlyingPhysical is the storage backend as configured, before the
+	// cache and the other wrappers are layered on top of it
+	underlyingPhysical physical.Backend
+
 	// Our Seal, for seal configuration information
 	seal Seal
 
@@ -194,6 +208,21 @@ type Core struct {
 	keepHALockOnStepDown *uint32
 	heldHALock           physical.Lock
 
+	// stepDownDoneCh is closed once the step-down queued by StepDown has
+	// completed, for WaitForStepDown
+	stepDownDoneCh   chan struct{}
+	stepDownDoneLock sync.Mutex
+
+	// perfStandbyStorage is set when performance standby mode is enabled and
+	// guards storage against writes while a standby services requests
+	perfStandbyStorage *perfStandbyStorage
+	// perfStandbyActive is true while the standby has the state necessary to
+	// service requests locally
+	perfStandbyActive bool
+	// perfStandbyDigest is the hash of the tables the current performance
+	// standby state was built from
+	perfStandbyDigest []byte
+
 	// unlockInfo has the keys provided to Unseal until the threshold number of parts is available, as well as the operation nonce
 	unlockInfo *unlockInformation
 
@@ -226,6 +255,10 @@ type Core struct {
 	// change underneath a calling function
 	authLock sync.RWMutex
 
+	// namespaceStore tracks the namespaces and is loaded after unseal since
+	// it is a protected configuration
+	namespaceStore *NamespaceStore
+
 	// audit is loaded after unseal since it is a protected
 	// configuration
 	audit *MountTable
@@ -261,6 +294,15 @@ type Core struct {
 	// token store is used to manage authentication tokens
 	tokenStore *TokenStore
 
+	// storageCleanupLock is set while a storage cleanup is running
+	storageCleanupLock uint32
+
+	// mountExportLock serializes the exports of mounts, and
+	// mountImportWrappingKeyLock the generation of the wrapping key of the
+	// imports
+	mountExportLock            sync.Mutex
+	mountImportWrappingKeyLock sync.Mutex
+
 	// identityStore is used to manage client entities
 	identityStore *IdentityStore
 
@@ -274,6 +316,59 @@ type Core struct {
 	defaultLeaseTTL time.Duration
 	maxLeaseTTL     time.Duration
 
+	// rootTokenMaxTTL, if set, caps the lifetime of every root token
+	rootTokenMaxTTL time.Duration
+	// logRootTokens causes the creation and use of root tokens to be logged
+	logRootTokens bool
+
+	// mountDeletionGracePeriod, if set, delays the deletion of disabled
+	// secrets engines so that they can be restored in the meantime
+	mountDeletionGracePeriod time.Duration
+	// mountDeletionCh is used to stop deleting the secrets engines whose
+	// grace period has elapsed
+	mountDeletionCh chan struct{}
+	// countersCh is used to stop taking the daily snapshots of the usage
+	// counters
+	countersCh chan struct{}
+	// snapshotsCh is used to stop taking the scheduled snapshots of the
+	// storage backend
+	snapshotsCh chan struct{}
+	// secretsSyncCh is used to stop pushing secrets to the sync
+	// destinations
+	secretsSyncCh chan struct{}
+	// activityCh is used to stop flushing the activity log. The clients seen
+	// since the last flush are kept in activityPending, keyed by month and
+	// namespace.
+	activityCh      chan struct{}
+	activityLock    sync.Mutex
+	activityPending map[string]*activityClients
+
+	// leaseRevocationWorkers is the number of workers revoking expired leases
+	leaseRevocationWorkers int
+
+	// renewalDefaults are the renewal settings of the mounts which don't
+	// override them
+	renewalDefaults renewalSettings
+
+	// leaseCountQuotas are the lease count quotas, loaded on unseal
+	leaseCountQuotas *leaseCountQuotas
+
+	// secretsSync holds the destinations secrets are pushed to, loaded on
+	// unseal
+	secretsSync *secretsSyncer
+
+	// outbound is the egress configuration of the backends
+	outbound *outboundConfig
+
+	// metricsSink and logLines, if set, are returned by the debugging
+	// endpoints of the system backend
+	metricsSink *metrics.InmemSink
+	logLines    *logging.LineBuffer
+
+	// events delivers the events published on the node to the streams of
+	// sys/events/subscribe
+	events *eventBus
+
 	logger log.Logger
 
 	// cachingDisabled indicates whether caches are disabled
@@ -299,6 +394,11 @@ type Core struct {
 	clusterName string
 	// Specific cipher suites to use for clustering, if any
 	clusterCipherSuites []uint16
+
+	// entropySource, if set, is mixed into the randomness used to generate
+	// keys for the operation classes in entropyOperations
+	entropySource     entropy.Sourcer
+	entropyOperations map[string]bool
 	// Used to modify cluster parameters
 	clusterParamsLock sync.RWMutex
 	// The private key stored in the barrier used for establishing
@@ -358,12 +458,33 @@ type Core struct {
 	replicationState           *uint32
 	activeNodeReplicationState *uint32
 
+	// drLog records storage updates for DR secondaries while this cluster
+	// is a DR primary
+	drLog *drLog
+	// drLock protects the DR replication configuration and sync routine
+	drLock            sync.RWMutex
+	drPrimaryConfig   *drPrimaryConfig
+	drSecondaryConfig *drSecondaryConfig
+	drSyncStopCh      chan struct{}
+	drSyncDoneCh      chan struct{}
+
 	// uiConfig contains UI configuration
 	uiConfig *UIConfig
 
 	// rawEnabled indicates whether the Raw endpoint is enabled
 	rawEnabled bool
 
+	// pprofDisabled indicates whether the sys/pprof endpoint is disabled
+	pprofDisabled bool
+
+	// recoveryMode only unseals the barrier, serving the raw endpoint to the
+	// holder of the recovery token so that storage can be repaired.
+	// recoveryToken holds the token once generated, and recoveryBackend
+	// serves the raw endpoint.
+	recoveryMode    bool
+	recoveryToken   *atomic.Value
+	recoveryBackend logical.Backend
+
 	// pluginDirectory is the location vault will look for plugin binaries
 	pluginDirectory string
 
@@ -431,8 +552,56 @@ type CoreConfig struct {
 	// Enable the raw endpoint
 	EnableRaw bool `json:"enable_raw" structs:"enable_raw" mapstructure:"enable_raw"`
 
+	// Disable the pprof endpoint
+	DisablePprof bool `json:"disable_pprof" structs:"disable_pprof" mapstructure:"disable_pprof"`
+
+	// Start in recovery mode, where only the raw endpoint is available
+	RecoveryMode bool `json:"recovery_mode" structs:"recovery_mode" mapstructure:"recovery_mode"`
+
 	PluginDirectory string `json:"plugin_directory" structs:"plugin_directory" mapstructure:"plugin_directory"`
 
+	// Allow standby nodes to service requests that do not modify storage
+	PerformanceStandby bool `json:"performance_standby" structs:"performance_standby" mapstructure:"performance_standby"`
+
+	// The maximum TTL of root tokens; zero means root tokens may not expire
+	RootTokenMaxTTL time.Duration `json:"root_token_max_ttl" structs:"root_token_max_ttl" mapstructure:"root_token_max_ttl"`
+
+	// Log a warning whenever a root token is created or used
+	LogRootTokens bool `json:"log_root_tokens" structs:"log_root_tokens" mapstructure:"log_root_tokens"`
+
+	// How long disabled secrets engines are kept before being deleted; zero
+	// means they are deleted right away
+	MountDeletionGracePeriod time.Duration `json:"mount_deletion_grace_period" structs:"mount_deletion_grace_period" mapstructure:"mount_deletion_grace_period"`
+
+	// The number of workers revoking expired leases
+	LeaseRevocationWorkers int `json:"lease_revocation_workers" structs:"lease_revocation_workers" mapstructure:"lease_revocation_workers"`
+
+	// The increment of renewals requesting none, the most a renewal can
+	// extend a lease by, and how close to its max TTL a renewal extends a
+	// lease all the way to it; zero leaves each unset
+	DefaultRenewalIncrement time.Duration `json:"default_renewal_increment" structs:"default_renewal_increment" mapstructure:"default_renewal_increment"`
+	MaxRenewalIncrement     time.Duration `json:"max_renewal_increment" structs:"max_renewal_increment" mapstructure:"max_renewal_increment"`
+	RenewalGracePeriod      time.Duration `json:"renewal_grace_period" structs:"renewal_grace_period" mapstructure:"renewal_grace_period"`
+
+	// The proxy the backends reach external APIs through, the hosts they
+	// reach directly, and the PEM-encoded CA certificates they trust in
+	// addition to those of the system
+	OutboundProxyURL string `json:"outbound_proxy_url" structs:"outbound_proxy_url" mapstructure:"outbound_proxy_url"`
+	OutboundNoProxy  string `json:"outbound_no_proxy" structs:"outbound_no_proxy" mapstructure:"outbound_no_proxy"`
+	OutboundCACert   string `json:"-" structs:"-" mapstructure:"-"`
+
+	// The in-memory metrics and recent log lines returned by sys/metrics and
+	// sys/logs
+	MetricsSink *metrics.InmemSink  `json:"-" structs:"-" mapstructure:"-"`
+	LogLines    *logging.LineBuffer `json:"-" structs:"-" mapstructure:"-"`
+
+	// An external source of entropy, such as an HSM or a hardware RNG, and
+	// the operation classes it is used for: "barrier" or a secrets engine
+	// type. When no operations are given, it is used for the barrier, transit
+	// and pki.
+	EntropySource     entropy.Sourcer `json:"-" structs:"-" mapstructure:"-"`
+	EntropyOperations []string        `json:"entropy_operations" structs:"entropy_operations" mapstructure:"entropy_operations"`
+
 	ReloadFuncs     *map[string][]reload.ReloadFunc
 	ReloadFuncsLock *sync.RWMutex
 }
@@ -454,6 +623,27 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	if conf.DefaultLeaseTTL > conf.MaxLeaseTTL {
 		return nil, fmt.Errorf("cannot have DefaultLeaseTTL larger than MaxLeaseTTL")
 	}
+	if conf.RootTokenMaxTTL < 0 {
+		return nil, fmt.Errorf("cannot have a negative RootTokenMaxTTL")
+	}
+	if conf.MountDeletionGracePeriod < 0 {
+		return nil, fmt.Errorf("cannot have a negative MountDeletionGracePeriod")
+	}
+	if conf.LeaseRevocationWorkers < 0 {
+		return nil, fmt.Errorf("cannot have a negative LeaseRevocationWorkers")
+	}
+	renewalDefaults := renewalSettings{
+		defaultIncrement: conf.DefaultRenewalIncrement,
+		maxIncrement:     conf.MaxRenewalIncrement,
+		gracePeriod:      conf.RenewalGracePeriod,
+	}
+	if err := renewalDefaults.validate(); err != nil {
+		return nil, err
+	}
+	outbound, outboundErr := newOutboundConfig(conf.OutboundProxyURL, conf.OutboundNoProxy, conf.OutboundCACert)
+	if outboundErr != nil {
+		return nil, outboundErr
+	}
 
 	// Validate the advertise addr if its given to us
 	if conf.RedirectAddr != "" {
@@ -476,6 +666,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	c := &Core{
 		devToken:                         conf.DevToken,
 		physical:                         conf.Physical,
+		underlyingPhysical:               conf.Physical,
 		redirectAddr:                     conf.RedirectAddr,
 		clusterAddr:                      conf.ClusterAddr,
 		seal:                             conf.Seal,
@@ -485,6 +676,16 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		logger:                           conf.Logger.Named("core"),
 		defaultLeaseTTL:                  conf.DefaultLeaseTTL,
 		maxLeaseTTL:                      conf.MaxLeaseTTL,
+		rootTokenMaxTTL:                  conf.RootTokenMaxTTL,
+		mountDeletionGracePeriod:         conf.MountDeletionGracePeriod,
+		logRootTokens:                    conf.LogRootTokens,
+		leaseRevocationWorkers:           conf.LeaseRevocationWorkers,
+		renewalDefaults:                  renewalDefaults,
+		outbound:                         outbound,
+		metricsSink:                      conf.MetricsSink,
+		logLines:                         conf.LogLines,
+		events:                           newEventBus(),
+		activityPending:                  map[string]*activityClients{},
 		cachingDisabled:                  conf.DisableCache,
 		clusterName:                      conf.ClusterName,
 		clusterListenerShutdownCh:        make(chan struct{}),
@@ -492,6 +693,9 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		clusterPeerClusterAddrsCache:     cache.New(3*HeartbeatInterval, time.Second),
 		enableMlock:                      !conf.DisableMlock,
 		rawEnabled:                       conf.EnableRaw,
+		pprofDisabled:                    conf.DisablePprof,
+		recoveryMode:                     conf.RecoveryMode,
+		recoveryToken:                    new(atomic.Value),
 		replicationState:                 new(uint32),
 		rpcServerActive:                  new(uint32),
 		atomicPrimaryClusterAddrs:        new(atomic.Value),
@@ -517,6 +721,25 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		}
 		c.clusterCipherSuites = suites
 	}
+	if fips.Enabled() {
+		if len(c.clusterCipherSuites) == 0 {
+			c.clusterCipherSuites = fips.CipherSuites
+		} else if err := fips.CheckCipherSuites(c.clusterCipherSuites); err != nil {
+			return nil, errwrap.Wrapf("invalid cluster cipher suites for FIPS mode: {{err}}", err)
+		}
+	}
+
+	if conf.EntropySource != nil {
+		c.entropySource = conf.EntropySource
+		c.entropyOperations = make(map[string]bool)
+		operations := conf.EntropyOperations
+		if len(operations) == 0 {
+			operations = defaultEntropyOperations
+		}
+		for _, op := range operations {
+			c.entropyOperations[op] = true
+		}
+	}
 
 	// Load CORS config and provide a value for the core field.
 	c.corsConfig = &CORSConfig{
@@ -524,8 +747,15 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		Enabled: new(uint32),
 	}
 
-	phys := conf.Physical
+	// Record the latency of the physical backend in the traces of the
+	// requests
+	var phys physical.Backend
 	_, txnOK := conf.Physical.(physical.Transactional)
+	if txnOK {
+		phys = physical.NewTransactionalTracingBackend(conf.Physical)
+	} else {
+		phys = physical.NewTracingBackend(conf.Physical)
+	}
 	if c.seal == nil {
 		c.seal = NewDefaultSeal()
 	}
@@ -543,6 +773,10 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	}
 	c.physicalCache = c.physical.(physical.ToggleablePurgemonster)
 
+	// Record storage updates so that they can be sent to DR secondaries
+	c.drLog = newDRLog()
+	c.physical = newDRLogStorage(c.physical, c.drLog)
+
 	if !conf.DisableMlock {
 		// Ensure our memory usage is locked into physical RAM
 		if err := mlock.LockMemory(); err != nil {
@@ -567,15 +801,26 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		}
 	}
 
-	// Construct a new AES-GCM barrier
-	c.barrier, err = NewAESGCMBarrier(c.physical)
+	// Recovery mode runs without HA, so that a single node comes up
+	if conf.HAPhysical != nil && conf.HAPhysical.HAEnabled() && !conf.RecoveryMode {
+		c.ha = conf.HAPhysical
+	}
+
+	// Construct a new AES-GCM barrier. Performance standbys need to be able
+	// to stop everything above the physical layer from writing.
+	barrierBackend := c.physical
+	if conf.PerformanceStandby && c.ha != nil {
+		c.perfStandbyStorage = newPerfStandbyStorage(c.physical)
+		barrierBackend = c.perfStandbyStorage
+	}
+	barrier, err := NewAESGCMBarrier(barrierBackend)
 	if err != nil {
 		return nil, errwrap.Wrapf("barrier setup failed: {{err}}", err)
 	}
-
-	if conf.HAPhysical != nil && conf.HAPhysical.HAEnabled() {
-		c.ha = conf.HAPhysical
+	if c.entropyAllowed(entropyOperationBarrier) {
+		barrier.rand = entropy.NewReader(c.entropySource)
 	}
+	c.barrier = barrier
 
 	// We create the funcs here, then populate the given config with it so that
 	// the caller can share state
@@ -627,6 +872,10 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	uiStoragePrefix := systemBarrierPrefix + "ui"
 	c.uiConfig = NewUIConfig(conf.EnableUI, physical.NewView(c.physical, uiStoragePrefix), NewBarrierView(c.barrier, uiStoragePrefix))
 
+	if c.recoveryMode {
+		c.recoveryBackend = newRecoveryBackend(c)
+	}
+
 	return c, nil
 }
 
@@ -656,6 +905,12 @@ func (c *Core) Sealed() bool {
 	return atomic.LoadUint32(c.sealed) == 1
 }
 
+// entropyAllowed returns whether the configured entropy source, if any, is
+// used to generate keys for the given operation class
+func (c *Core) entropyAllowed(op string) bool {
+	return c.entropySource != nil && c.entropyOperations[op]
+}
+
 // SecretProgress returns the number of keys provided so far
 func (c *Core) SecretProgress() (int, string) {
 	c.stateLock.RLock()
@@ -875,6 +1130,16 @@ func (c *Core) unsealInternal(ctx context.Context, masterKey []byte) (bool, erro
 		c.logger.Info("vault is unsealed")
 	}
 
+	// Recovery mode stops here, so that none of the state stored behind the
+	// barrier is loaded
+	if c.recoveryMode {
+		c.logger.Warn("vault is in recovery mode, only the raw endpoint is available")
+		c.activeContext, c.activeContextCancelFunc = context.WithCancel(context.Background())
+		c.standby = false
+		atomic.StoreUint32(c.sealed, 0)
+		return true, nil
+	}
+
 	// Do post-unseal setup if HA is not enabled
 	if c.ha == nil {
 		// We still need to set up cluster info even if it's not part of a
@@ -1143,7 +1408,10 @@ func (c *Core) sealInternalWithOptions(grabStateLock, keepHALock bool) error {
 			c.activeContextCancelFunc()
 		}
 
-		if err := c.preSeal(); err != nil {
+		// Nothing but the barrier is set up in recovery mode
+		if c.recoveryMode {
+			c.recoveryToken.Store("")
+		} else if err := c.preSeal(); err != nil {
 			c.logger.Error("pre-seal teardown failed", "error", err)
 			return fmt.Errorf("internal error")
 		}
@@ -1170,6 +1438,10 @@ func (c *Core) sealInternalWithOptions(grabStateLock, keepHALock bool) error {
 		<-c.standbyDoneCh
 		atomic.StoreUint32(c.keepHALockOnStepDown, 0)
 		c.logger.Debug("runStandby done")
+
+		if c.perfStandbyActive {
+			c.perfStandbyTeardown()
+		}
 	}
 
 	c.logger.Debug("sealing barrier")
@@ -1242,6 +1514,9 @@ func (c *Core) postUnseal() (retErr error) {
 	if err := enterprisePostUnseal(c); err != nil {
 		return err
 	}
+	if err := startReplication(c); err != nil {
+		return err
+	}
 	if err := c.ensureWrappingKey(c.activeContext); err != nil {
 		return err
 	}
@@ -1266,12 +1541,32 @@ func (c *Core) postUnseal() (retErr error) {
 	if err := c.setupCredentials(c.activeContext); err != nil {
 		return err
 	}
-	if err := c.startRollback(); err != nil {
+	c.warnDeprecatedMounts()
+	if err := c.setupNamespaces(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.loadLeaseCountQuotas(c.activeContext); err != nil {
 		return err
 	}
-	if err := c.setupExpiration(); err != nil {
+	if err := c.loadSecretsSync(c.activeContext); err != nil {
 		return err
 	}
+	// A DR secondary only services replication requests, so it must not
+	// revoke leases or run rollbacks against the primary's data
+	drSecondary := c.IsDRSecondary()
+	if drSecondary {
+		c.setupNonRestoringExpiration()
+	} else {
+		if err := c.startRollback(); err != nil {
+			return err
+		}
+		if err := c.setupExpiration(); err != nil {
+			return err
+		}
+		if err := c.storeVersion(c.activeContext); err != nil {
+			return err
+		}
+	}
 	if err := c.loadAudits(c.activeContext); err != nil {
 		return err
 	}
@@ -1292,12 +1587,27 @@ func (c *Core) postUnseal() (retErr error) {
 	}
 	c.metricsCh = make(chan struct{})
 	go c.emitMetrics(c.metricsCh)
+	if !drSecondary {
+		c.mountDeletionCh = make(chan struct{})
+		go c.runMountDeletion(c.mountDeletionCh)
+		c.countersCh = make(chan struct{})
+		go c.runCounters(c.countersCh)
+		c.snapshotsCh = make(chan struct{})
+		go c.runSnapshots(c.snapshotsCh)
+		c.secretsSyncCh = make(chan struct{})
+		go c.runSecretsSync(c.secretsSyncCh)
+		c.activityCh = make(chan struct{})
+		go c.runActivityLog(c.activityCh)
+	}
 
 	// This is intentionally the last block in this function. We want to allow
 	// writes just before allowing client requests, to ensure everything has
-	// been set up properly before any writes can have happened.
-	for _, v := range c.postUnsealFuncs {
-		v()
+	// been set up properly before any writes can have happened. Storage on a
+	// DR secondary is only written by replication.
+	if !drSecondary {
+		for _, v := range c.postUnsealFuncs {
+			v()
+		}
 	}
 
 	c.logger.Info("post-unseal setup complete")
@@ -1321,6 +1631,29 @@ func (c *Core) preSeal() error {
 		close(c.metricsCh)
 		c.metricsCh = nil
 	}
+	if c.mountDeletionCh != nil {
+		close(c.mountDeletionCh)
+		c.mountDeletionCh = nil
+	}
+	if c.countersCh != nil {
+		close(c.countersCh)
+		c.countersCh = nil
+	}
+	if c.snapshotsCh != nil {
+		close(c.snapshotsCh)
+		c.snapshotsCh = nil
+	}
+	if c.secretsSyncCh != nil {
+		close(c.secretsSyncCh)
+		c.secretsSyncCh = nil
+	}
+	if c.activityCh != nil {
+		close(c.activityCh)
+		c.activityCh = nil
+		if err := c.flushActivity(context.Background()); err != nil {
+			c.logger.Error("failed to flush the activity log", "error", err)
+		}
+	}
 	var result error
 
 	c.stopClusterListener()
@@ -1331,6 +1664,9 @@ func (c *Core) preSeal() error {
 	if err := c.stopExpiration(); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error stopping expiration: {{err}}", err))
 	}
+	if err := c.teardownNamespaces(c.activeContext); err != nil {
+		result = multierror.Append(result, errwrap.Wrapf("error tearing down namespaces: {{err}}", err))
+	}
 	if err := c.teardownCredentials(c.activeContext); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error tearing down credentials: {{err}}", err))
 	}
@@ -1343,6 +1679,9 @@ func (c *Core) preSeal() error {
 	if err := c.unloadMounts(c.activeContext); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error unloading mounts: {{err}}", err))
 	}
+	if err := stopReplication(c); err != nil {
+		result = multierror.Append(result, errwrap.Wrapf("error stopping replication: {{err}}", err))
+	}
 	if err := enterprisePreSeal(c); err != nil {
 		result = multierror.Append(result, err)
 	}
@@ -1371,11 +1710,11 @@ func enterprisePreSealImpl(c *Core) error {
 }
 
 func startReplicationImpl(c *Core) error {
-	return nil
+	return c.setupDRReplication(c.activeContext)
 }
 
 func stopReplicationImpl(c *Core) error {
-	return nil
+	return c.teardownDRReplication()
 }
 
 // emitMetrics is used to periodically expose metrics while running
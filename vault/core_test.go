@@ -2,7 +2,10 @@ package vault
 
 import (
 	"context"
+	"crypto/rand"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +14,7 @@ import (
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/entropy"
 	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/physical"
@@ -1395,6 +1399,135 @@ func TestCore_StepDown(t *testing.T) {
 	}
 }
 
+func TestCore_WaitForStepDown(t *testing.T) {
+	logger = logging.NewVaultLogger(log.Trace)
+
+	inm, err := inmem.NewInmemHA(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inmha, err := inmem.NewInmemHA(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	core, err := NewCore(&CoreConfig{
+		Physical:     inm,
+		HAPhysical:   inmha.(physical.HABackend),
+		RedirectAddr: "http://127.0.0.1:8200",
+		DisableMlock: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer core.Shutdown()
+	keys, root := TestCoreInit(t, core)
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(core, TestKeyCopy(key)); err != nil {
+			t.Fatalf("unseal err: %s", err)
+		}
+	}
+	TestWaitActive(t, core)
+
+	// Nothing to wait for before a step-down
+	if err := core.WaitForStepDown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the state lock like a request in flight
+	core.stateLock.RLock()
+
+	if err := core.StepDown(context.Background(), &logical.Request{
+		ClientToken: root,
+		Path:        "sys/step-down",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The step-down waits for the request to drain
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := core.WaitForStepDown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected the wait to time out, got %v", err)
+	}
+
+	core.stateLock.RUnlock()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := core.WaitForStepDown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	standby, err := core.Standby()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !standby {
+		t.Fatal("should be standby")
+	}
+
+	// The HA lock has been released
+	lock, err := inmha.(physical.HABackend).LockWith(coreLockPath, "read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	held, _, err := lock.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if held {
+		t.Fatal("the HA lock should have been released")
+	}
+}
+
+func TestCore_WaitForStepDown_Seal(t *testing.T) {
+	logger = logging.NewVaultLogger(log.Trace)
+
+	inm, err := inmem.NewInmemHA(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inmha, err := inmem.NewInmemHA(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	core, err := NewCore(&CoreConfig{
+		Physical:     inm,
+		HAPhysical:   inmha.(physical.HABackend),
+		RedirectAddr: "http://127.0.0.1:8200",
+		DisableMlock: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer core.Shutdown()
+	keys, root := TestCoreInit(t, core)
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(core, TestKeyCopy(key)); err != nil {
+			t.Fatalf("unseal err: %s", err)
+		}
+	}
+	TestWaitActive(t, core)
+
+	// A step-down queued when the node is sealed never happens, which
+	// unblocks its waiters
+	core.stepDownDoneLock.Lock()
+	core.stepDownDoneCh = make(chan struct{})
+	core.stepDownDoneLock.Unlock()
+
+	if err := core.Seal(root); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := core.WaitForStepDown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestCore_CleanLeaderPrefix(t *testing.T) {
 	// Create the first core and initialize it
 	logger = logging.NewVaultLogger(log.Trace)
@@ -2138,6 +2271,87 @@ func TestCore_HandleRequest_MountPointType(t *testing.T) {
 	}
 }
 
+func TestCore_HandleRequest_IdempotentLease(t *testing.T) {
+	noop := &NoopBackend{
+		Idempotent: []string{"creds/*"},
+		Response: &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: time.Hour,
+				},
+			},
+			Data: map[string]interface{}{
+				"username": "foo",
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/foo")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(context.Background(), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	request := func(path, key string) *logical.Response {
+		req := &logical.Request{
+			Operation:      logical.ReadOperation,
+			Path:           path,
+			ClientToken:    root,
+			IdempotencyKey: key,
+			Connection:     &logical.Connection{},
+		}
+		resp, err := c.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+			t.Fatalf("bad: %#v", resp)
+		}
+		return resp
+	}
+
+	// A retry with the same key gets the same lease without the backend
+	// being asked again. The backend returns the same secret every time, so
+	// only its lease ID is kept.
+	first := request("foo/creds/test", "abcd").Secret.LeaseID
+	retry := request("foo/creds/test", "abcd")
+	if retry.Secret.LeaseID != first || retry.Data["username"] != "foo" {
+		t.Fatalf("bad: %#v", retry)
+	}
+	if retry.Secret.TTL <= 0 || retry.Secret.TTL > time.Hour {
+		t.Fatalf("bad: %#v", retry.Secret)
+	}
+	if len(noop.Requests) != 1 {
+		t.Fatalf("expected 1 backend request, got %d", len(noop.Requests))
+	}
+
+	// A new key, or a path that isn't idempotent, issues a new lease
+	if other := request("foo/creds/test", "efgh"); other.Secret.LeaseID == first {
+		t.Fatalf("bad: %#v", other)
+	}
+	if other := request("foo/other", "abcd"); other.Secret.LeaseID == first {
+		t.Fatalf("bad: %#v", other)
+	}
+	request("foo/other", "abcd")
+	if len(noop.Requests) != 4 {
+		t.Fatalf("expected 4 backend requests, got %d", len(noop.Requests))
+	}
+
+	// Once the lease is revoked, the key issues a new one
+	if err := c.expiration.Revoke(context.Background(), first); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	request("foo/creds/test", "abcd")
+	if len(noop.Requests) != 6 {
+		t.Fatalf("expected 6 backend requests, got %d", len(noop.Requests))
+	}
+}
+
 func TestCore_Standby_Rotate(t *testing.T) {
 	// Create the first core and initialize it
 	logger = logging.NewVaultLogger(log.Trace)
@@ -2298,3 +2512,133 @@ func TestCore_HandleRequest_Headers(t *testing.T) {
 		t.Fatalf("did not expect 'Should-Not-Passthrough' to be in the headers map")
 	}
 }
+
+type testEntropySource struct{}
+
+func (testEntropySource) GetRandom(bytes int) ([]byte, error) {
+	return make([]byte, bytes), nil
+}
+
+func TestCore_EntropySource(t *testing.T) {
+	for _, tc := range []struct {
+		operations []string
+		barrier    bool
+		mounts     map[string]bool
+	}{
+		{nil, true, map[string]bool{"transit": true, "pki": true, "kv": false}},
+		{[]string{"transit"}, false, map[string]bool{"transit": true, "pki": false, "kv": false}},
+	} {
+		inm, err := inmem.NewInmem(nil, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core, err := NewCore(&CoreConfig{
+			Physical:          inm,
+			DisableMlock:      true,
+			EntropySource:     testEntropySource{},
+			EntropyOperations: tc.operations,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if barrierAugmented := core.barrier.(*AESGCMBarrier).rand != rand.Reader; barrierAugmented != tc.barrier {
+			t.Fatalf("operations %v: expected barrier augmentation to be %t", tc.operations, tc.barrier)
+		}
+		for mountType, expected := range tc.mounts {
+			_, ok := core.mountEntrySysView(&MountEntry{Type: mountType}).(entropy.Sourcer)
+			if ok != expected {
+				t.Fatalf("operations %v: expected %s augmentation to be %t", tc.operations, mountType, expected)
+			}
+		}
+	}
+}
+
+// pagingBackend records the prefixes listed through a physical backend, a
+// page at a time or all at once
+type pagingBackend struct {
+	physical.Backend
+
+	l     sync.Mutex
+	paged map[string]int
+	full  map[string]int
+}
+
+func (p *pagingBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	p.l.Lock()
+	p.full[prefix]++
+	p.l.Unlock()
+	return p.Backend.List(ctx, prefix)
+}
+
+func (p *pagingBackend) ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error) {
+	p.l.Lock()
+	p.paged[prefix]++
+	p.l.Unlock()
+	return physical.ListPage(ctx, p.Backend, prefix, after, limit)
+}
+
+func TestCore_ListPage_Paginator(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Trace)
+	inm, err := inmem.NewInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &pagingBackend{
+		Backend: inm,
+		paged:   make(map[string]int),
+		full:    make(map[string]int),
+	}
+	c, _, root := TestCoreUnsealedBackend(t, b)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, "secret/foo/"+key)
+		req.ClientToken = root
+		req.Data["raw"] = "test"
+		if _, err := c.HandleRequest(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := logical.TestRequest(t, logical.ListOperation, "secret/foo/")
+	req.ClientToken = root
+	req.Data["limit"] = "2"
+	resp, err := c.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := resp.Data["keys"].([]string); !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The page is listed by the backend itself, through every layer between
+	// it and the barrier, rather than by sorting a full listing
+	var paged, full int
+	b.l.Lock()
+	for prefix, n := range b.paged {
+		if strings.HasSuffix(prefix, "/foo/") {
+			paged += n
+		}
+	}
+	for prefix, n := range b.full {
+		if strings.HasSuffix(prefix, "/foo/") {
+			full += n
+		}
+	}
+	b.l.Unlock()
+	if paged != 1 || full != 0 {
+		t.Fatalf("expected one paged listing and no full one, got %d and %d", paged, full)
+	}
+
+	// A performance standby's storage passes pages through as well
+	ps := newPerfStandbyStorage(b)
+	ps.setReadOnly(true)
+	if _, err := physical.ListPage(context.Background(), ps, "other/", "", 1); err != nil {
+		t.Fatal(err)
+	}
+	b.l.Lock()
+	defer b.l.Unlock()
+	if b.paged["other/"] != 1 || b.full["other/"] != 0 {
+		t.Fatalf("bad: %v %v", b.paged, b.full)
+	}
+}
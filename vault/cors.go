@@ -21,11 +21,14 @@ var StdAllowedHeaders = []string{
 	"X-Requested-With",
 	"X-Vault-AWS-IAM-Server-ID",
 	"X-Vault-MFA",
+	"X-Vault-Namespace",
 	"X-Vault-No-Request-Forwarding",
 	"X-Vault-Token",
 	"X-Vault-Wrap-Format",
 	"X-Vault-Wrap-TTL",
 	"X-Vault-Policy-Override",
+	"X-Vault-Reason",
+	"X-Vault-Request",
 }
 
 // CORSConfig stores the state of the CORS configuration.
@@ -0,0 +1,300 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// countersSubPath is the sub-path of the system view the daily snapshots
+	// of the usage counters are stored under
+	countersSubPath = "counters/"
+
+	// countersDateFormat is the format of the date of the snapshots, which is
+	// also their storage key
+	countersDateFormat = "2006-01-02"
+
+	// countersRetention is how long the daily snapshots are kept
+	countersRetention = 90 * 24 * time.Hour
+)
+
+var (
+	// countersInterval is how often the active node checks whether the
+	// snapshot of the current day has been taken
+	countersInterval = time.Hour
+)
+
+// usageCounters are the number of tokens, entities and leases at a point in
+// time. The tokens and leases are broken down by namespace, and the leases
+// also by mount.
+type usageCounters struct {
+	Date              string         `json:"date"`
+	Tokens            int            `json:"tokens"`
+	TokensByNamespace map[string]int `json:"tokens_by_namespace"`
+	Entities          int            `json:"entities"`
+	Leases            int            `json:"leases"`
+	LeasesByNamespace map[string]int `json:"leases_by_namespace"`
+	LeasesByMount     map[string]int `json:"leases_by_mount"`
+}
+
+// namespaceCounterKey is the key the counters of the namespace are reported
+// under
+func namespaceCounterKey(ns *Namespace) string {
+	if ns.Path == "" {
+		return "root"
+	}
+	return ns.Path
+}
+
+// countTokens returns the number of tokens, in total and by namespace
+func (c *Core) countTokens(ctx context.Context) (int, map[string]int, error) {
+	keys, err := c.tokenStore.view.List(ctx, lookupPrefix)
+	if err != nil {
+		return 0, nil, errwrap.Wrapf("failed to list tokens: {{err}}", err)
+	}
+
+	byNamespace := map[string]int{}
+	for _, key := range keys {
+		raw, err := c.tokenStore.view.Get(ctx, lookupPrefix+key)
+		if err != nil {
+			return 0, nil, errwrap.Wrapf("failed to read token: {{err}}", err)
+		}
+		if raw == nil {
+			continue
+		}
+		var entry logical.TokenEntry
+		if err := jsonutil.DecodeJSON(raw.Value, &entry); err != nil {
+			return 0, nil, errwrap.Wrapf("failed to decode token: {{err}}", err)
+		}
+
+		nsKey := entry.NamespaceID
+		if ns := c.namespaceByID(entry.NamespaceID); ns != nil {
+			nsKey = namespaceCounterKey(ns)
+		}
+		byNamespace[nsKey]++
+	}
+
+	return len(keys), byNamespace, nil
+}
+
+// countEntities returns the number of identity entities
+func (c *Core) countEntities() (int, error) {
+	if c.identityStore == nil {
+		return 0, nil
+	}
+
+	txn := c.identityStore.db.Txn(false)
+	iter, err := txn.Get(entitiesTable, "id")
+	if err != nil {
+		return 0, errwrap.Wrapf("failed to fetch iterator for entities in memdb: {{err}}", err)
+	}
+
+	return countIterator(iter), nil
+}
+
+func countIterator(iter memdb.ResultIterator) int {
+	count := 0
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		count++
+	}
+	return count
+}
+
+// countLeases returns the number of leases, in total, by namespace and by
+// mount. Leases of mounts that no longer exist only count towards the total.
+func (c *Core) countLeases(ctx context.Context) (int, map[string]int, map[string]int, error) {
+	leaseIDs, err := logical.CollectKeys(ctx, c.expiration.idView)
+	if err != nil {
+		return 0, nil, nil, errwrap.Wrapf("failed to scan for leases: {{err}}", err)
+	}
+
+	byNamespace := map[string]int{}
+	byMount := map[string]int{}
+	for _, leaseID := range leaseIDs {
+		entry := c.router.MatchingMountEntry(leaseID)
+		if entry == nil {
+			continue
+		}
+		ns := c.namespaceByID(entry.NamespaceID)
+		if ns == nil {
+			continue
+		}
+		byNamespace[namespaceCounterKey(ns)]++
+		byMount[c.router.MatchingMount(leaseID)]++
+	}
+
+	return len(leaseIDs), byNamespace, byMount, nil
+}
+
+// currentCounters counts the tokens, entities and leases right now
+func (c *Core) currentCounters(ctx context.Context) (*usageCounters, error) {
+	counters := &usageCounters{
+		Date: time.Now().UTC().Format(countersDateFormat),
+	}
+
+	var err error
+	if counters.Tokens, counters.TokensByNamespace, err = c.countTokens(ctx); err != nil {
+		return nil, err
+	}
+	if counters.Entities, err = c.countEntities(); err != nil {
+		return nil, err
+	}
+	if counters.Leases, counters.LeasesByNamespace, counters.LeasesByMount, err = c.countLeases(ctx); err != nil {
+		return nil, err
+	}
+
+	return counters, nil
+}
+
+// countersHistory returns the stored daily snapshots of the counters, oldest
+// first
+func (c *Core) countersHistory(ctx context.Context) ([]*usageCounters, error) {
+	view := c.systemBarrierView.SubView(countersSubPath)
+	dates, err := view.List(ctx, "")
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list counters: {{err}}", err)
+	}
+	sort.Strings(dates)
+
+	history := make([]*usageCounters, 0, len(dates))
+	for _, date := range dates {
+		raw, err := view.Get(ctx, date)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to read counters: {{err}}", err)
+		}
+		if raw == nil {
+			continue
+		}
+		var counters usageCounters
+		if err := raw.DecodeJSON(&counters); err != nil {
+			return nil, errwrap.Wrapf("failed to decode counters: {{err}}", err)
+		}
+		history = append(history, &counters)
+	}
+
+	return history, nil
+}
+
+// snapshotCounters stores the counters of the current day, unless they have
+// been stored already, and removes the snapshots past the retention period
+func (c *Core) snapshotCounters(ctx context.Context) error {
+	view := c.systemBarrierView.SubView(countersSubPath)
+
+	now := time.Now().UTC()
+	date := now.Format(countersDateFormat)
+	existing, err := view.Get(ctx, date)
+	if err != nil {
+		return errwrap.Wrapf("failed to read counters: {{err}}", err)
+	}
+	if existing == nil {
+		counters, err := c.currentCounters(ctx)
+		if err != nil {
+			return err
+		}
+		entry, err := logical.StorageEntryJSON(date, counters)
+		if err != nil {
+			return errwrap.Wrapf("failed to encode counters: {{err}}", err)
+		}
+		if err := view.Put(ctx, entry); err != nil {
+			return errwrap.Wrapf("failed to persist counters: {{err}}", err)
+		}
+	}
+
+	dates, err := view.List(ctx, "")
+	if err != nil {
+		return errwrap.Wrapf("failed to list counters: {{err}}", err)
+	}
+	oldest := now.Add(-countersRetention).Format(countersDateFormat)
+	for _, d := range dates {
+		if d < oldest {
+			if err := view.Delete(ctx, d); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("failed to delete counters of %s: {{err}}", d), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// runCounters periodically takes the daily snapshot of the counters, until
+// the stop channel is closed
+func (c *Core) runCounters(stopCh chan struct{}) {
+	ticker := time.NewTicker(countersInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.stateLock.RLock()
+			select {
+			case <-stopCh:
+				c.stateLock.RUnlock()
+				return
+			default:
+			}
+			if err := c.snapshotCounters(c.activeContext); err != nil {
+				c.logger.Error("failed to take snapshot of the usage counters", "error", err)
+			}
+			c.stateLock.RUnlock()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// handleCountersRead returns the current value of one kind of counters along
+// with its daily history
+func (b *SystemBackend) handleCountersRead(kind string) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		current, err := b.Core.currentCounters(ctx)
+		if err != nil {
+			return nil, err
+		}
+		history, err := b.Core.countersHistory(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// The snapshot of the current day is replaced by the current value
+		historyData := make([]map[string]interface{}, 0, len(history))
+		for _, counters := range history {
+			if counters.Date == current.Date {
+				continue
+			}
+			historyData = append(historyData, countersData(kind, counters))
+		}
+
+		respData := countersData(kind, current)
+		respData["history"] = historyData
+		return &logical.Response{
+			Data: respData,
+		}, nil
+	}
+}
+
+// countersData returns one kind of counters as response data
+func countersData(kind string, counters *usageCounters) map[string]interface{} {
+	data := map[string]interface{}{
+		"date": counters.Date,
+	}
+	switch kind {
+	case "tokens":
+		data["total"] = counters.Tokens
+		data["by_namespace"] = counters.TokensByNamespace
+	case "entities":
+		data["total"] = counters.Entities
+	case "leases":
+		data["total"] = counters.Leases
+		data["by_namespace"] = counters.LeasesByNamespace
+		data["by_mount"] = counters.LeasesByMount
+	}
+	return data
+}
@@ -0,0 +1,122 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// deprecationStatusDeprecated is the deprecation status of the builtin
+	// backends still shipped but superseded by another one
+	deprecationStatusDeprecated = "deprecated"
+)
+
+// deprecatedBuiltins are the deprecated builtin backends, by mount table,
+// with the backend superseding them
+var deprecatedBuiltins = map[string]map[string]string{
+	credentialTableType: {
+		"app-id": "approle",
+	},
+	mountTableType: {
+		"cassandra":  "database",
+		"mongodb":    "database",
+		"mssql":      "database",
+		"mysql":      "database",
+		"postgresql": "database",
+	},
+}
+
+// builtinDeprecation returns the backend superseding the builtin backend the
+// mount entry uses, if it is deprecated
+func builtinDeprecation(entry *MountEntry) (replacement string, deprecated bool) {
+	replacement, deprecated = deprecatedBuiltins[entry.Table][entry.Type]
+	return replacement, deprecated
+}
+
+// deprecationWarning returns the warning about the mount entry using a
+// deprecated builtin backend, or the empty string if it does not
+func deprecationWarning(entry *MountEntry) string {
+	replacement, deprecated := builtinDeprecation(entry)
+	if !deprecated {
+		return ""
+	}
+	kind := "secrets engine"
+	if entry.Table == credentialTableType {
+		kind = "auth method"
+	}
+	return fmt.Sprintf("The %q %s mounted at %q is deprecated and will be removed in a future release; use the %q %s instead.", entry.Type, kind, entry.Path, replacement, kind)
+}
+
+// warnDeprecatedMounts logs the mounts using deprecated builtin backends
+func (c *Core) warnDeprecatedMounts() {
+	c.mountsLock.RLock()
+	for _, entry := range c.mounts.Entries {
+		if replacement, deprecated := builtinDeprecation(entry); deprecated {
+			c.logger.Warn("secrets engine is deprecated", "path", entry.Path, "type", entry.Type, "replacement", replacement)
+		}
+	}
+	c.mountsLock.RUnlock()
+
+	c.authLock.RLock()
+	for _, entry := range c.auth.Entries {
+		if replacement, deprecated := builtinDeprecation(entry); deprecated {
+			c.logger.Warn("auth method is deprecated", "path", entry.Path, "type", entry.Type, "replacement", replacement)
+		}
+	}
+	c.authLock.RUnlock()
+}
+
+// handleDeprecationsRead lists the deprecated builtin backends, and the
+// mounts of the namespace of the request using them
+func (b *SystemBackend) handleDeprecationsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.requestNamespace(req)
+
+	builtins := map[string]interface{}{}
+	for table, key := range map[string]string{credentialTableType: "auth", mountTableType: "secrets"} {
+		backends := map[string]interface{}{}
+		for backendType, replacement := range deprecatedBuiltins[table] {
+			backends[backendType] = map[string]interface{}{
+				"deprecation_status": deprecationStatusDeprecated,
+				"replacement":        replacement,
+			}
+		}
+		builtins[key] = backends
+	}
+
+	mounts := map[string]interface{}{}
+	addMounts := func(entries []*MountEntry, prefix string) {
+		for _, entry := range entries {
+			if entry.NamespaceID != ns.ID {
+				continue
+			}
+			replacement, deprecated := builtinDeprecation(entry)
+			if !deprecated {
+				continue
+			}
+			mounts[prefix+strings.TrimPrefix(entry.Path, ns.Path)] = map[string]interface{}{
+				"type":               entry.Type,
+				"deprecation_status": deprecationStatusDeprecated,
+				"replacement":        replacement,
+			}
+		}
+	}
+
+	b.Core.mountsLock.RLock()
+	addMounts(b.Core.mounts.Entries, "")
+	b.Core.mountsLock.RUnlock()
+
+	b.Core.authLock.RLock()
+	addMounts(b.Core.auth.Entries, credentialRoutePrefix)
+	b.Core.authLock.RUnlock()
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"builtins": builtins,
+			"mounts":   mounts,
+		},
+	}, nil
+}
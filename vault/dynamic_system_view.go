@@ -3,10 +3,14 @@ package vault
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/entropy"
 	"github.com/hashicorp/vault/helper/pluginutil"
 	"github.com/hashicorp/vault/helper/wrapping"
 	"github.com/hashicorp/vault/logical"
@@ -17,6 +21,13 @@ type dynamicSystemView struct {
 	mountEntry *MountEntry
 }
 
+// entropySystemView is a dynamicSystemView that also gives the backend access
+// to the configured entropy source
+type entropySystemView struct {
+	dynamicSystemView
+	entropy.Sourcer
+}
+
 func (d dynamicSystemView) DefaultLeaseTTL() time.Duration {
 	def, _ := d.fetchTTLs()
 	return def
@@ -42,11 +53,22 @@ func (d dynamicSystemView) SudoPrivilege(ctx context.Context, path string, token
 	}
 
 	// Construct the corresponding ACL object
-	acl, err := d.core.policyStore.ACL(ctx, te.Policies...)
+	policies, err := d.core.policiesForToken(ctx, te, nil)
+	if err != nil {
+		d.core.logger.Error("failed to retrieve token's policies", "token_policies", te.Policies, "error", err)
+		return false
+	}
+	restrictions, err := d.core.restrictionsForToken(te)
+	if err != nil {
+		d.core.logger.Error("failed to retrieve token's inline policies", "error", err)
+		return false
+	}
+	acl, err := NewACL(policies)
 	if err != nil {
 		d.core.logger.Error("failed to retrieve ACL for token's policies", "token_policies", te.Policies, "error", err)
 		return false
 	}
+	acl.restrict(restrictions...)
 
 	// The operation type isn't important here as this is run from a path the
 	// user has already been given access to; we only care about whether they
@@ -81,7 +103,7 @@ func (d dynamicSystemView) Tainted() bool {
 
 // CachingDisabled indicates whether to use caching behavior
 func (d dynamicSystemView) CachingDisabled() bool {
-	return d.core.cachingDisabled || (d.mountEntry != nil && d.mountEntry.Config.ForceNoCache)
+	return d.core.cachingDisabled || d.core.perfStandbyCachingDisabled() || (d.mountEntry != nil && d.mountEntry.Config.ForceNoCache)
 }
 
 func (d dynamicSystemView) LocalMount() bool {
@@ -206,3 +228,68 @@ func (d dynamicSystemView) EntityInfo(entityID string) (*logical.Entity, error)
 
 	return ret, nil
 }
+
+func (d dynamicSystemView) GeneratePasswordFromPolicy(ctx context.Context, policyName string) (string, error) {
+	policy, err := d.core.getPasswordPolicy(ctx, policyName)
+	if err != nil {
+		return "", err
+	}
+	if policy == nil {
+		return "", fmt.Errorf("password policy %q not found", policyName)
+	}
+	return policy.Generate(nil)
+}
+
+func (d dynamicSystemView) CheckPasswordAgainstPolicy(ctx context.Context, policyName, password string) error {
+	policy, err := d.core.getPasswordPolicy(ctx, policyName)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return fmt.Errorf("password policy %q not found", policyName)
+	}
+	return policy.Check(password)
+}
+
+// OutboundTransport returns a new transport going through the egress proxy
+// of the mount, or else of the server, and trusting the CA certificates of
+// both
+func (d dynamicSystemView) OutboundTransport() (*http.Transport, error) {
+	var proxyURL, caCert string
+	if d.mountEntry != nil {
+		proxyURL = d.mountEntry.Config.OutboundProxyURL
+		caCert = d.mountEntry.Config.OutboundCACert
+	}
+	return d.core.outbound.transport(proxyURL, caCert)
+}
+
+// AuditRequest logs an operation the backend performs on its own as a request
+// to the path of the mount
+func (d dynamicSystemView) AuditRequest(ctx context.Context, req *logical.Request) error {
+	if d.mountEntry == nil {
+		return fmt.Errorf("no mount to audit the request of")
+	}
+	ns := d.core.namespaceByID(d.mountEntry.NamespaceID)
+	if ns == nil {
+		return fmt.Errorf("failed to find the namespace of the mount")
+	}
+
+	if req.ID == "" {
+		requestID, err := uuid.GenerateUUID()
+		if err != nil {
+			return errwrap.Wrapf("failed to generate request identifier: {{err}}", err)
+		}
+		req.ID = requestID
+	}
+
+	mountPoint := d.mountEntry.Path
+	if d.mountEntry.Table == credentialTableType {
+		mountPoint = credentialRoutePrefix + mountPoint
+	}
+	req.MountPoint = mountPoint
+	req.MountType = d.mountEntry.Type
+	req.MountAccessor = d.mountEntry.Accessor
+	req.Path = ns.Path + mountPoint + req.Path
+
+	return d.core.auditBroker.LogRequest(ctx, &audit.LogInput{Request: req}, d.core.auditedHeaders)
+}
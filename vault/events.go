@@ -0,0 +1,178 @@
+package vault
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// eventBufferSize is the number of events buffered for each stream of
+	// sys/events/subscribe, beyond which events are dropped for slow clients
+	eventBufferSize = 256
+
+	// EventTypeKV events are published on writes and deletes in kv mounts
+	EventTypeKV = "kv"
+
+	// EventTypeLease events are published when leases are revoked
+	EventTypeLease = "lease"
+
+	// EventTypeMount events are published when secrets engines and auth
+	// methods are enabled, disabled or moved
+	EventTypeMount = "mount"
+)
+
+// Event is a notification of a change in Vault. Its topic is its type and
+// path, so that subscribers can receive the events of a type under a prefix.
+type Event struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Path      string            `json:"path"`
+	Operation string            `json:"operation"`
+	Timestamp time.Time         `json:"timestamp"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Topic returns the topic of the event
+func (e *Event) Topic() string {
+	return e.Type + "/" + e.Path
+}
+
+// eventBus delivers the events published on a node to the subscribers of
+// their topics
+type eventBus struct {
+	l    sync.Mutex
+	subs map[chan *Event]string
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		subs: make(map[chan *Event]string),
+	}
+}
+
+// Publish delivers an event to the subscribers of a prefix of its topic
+func (b *eventBus) Publish(event *Event) {
+	topic := event.Topic()
+
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	for ch, prefix := range b.subs {
+		if !strings.HasPrefix(topic, prefix) {
+			continue
+		}
+		// Events are dropped for subscribers which do not keep up, rather
+		// than blocking the request that published them
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events published from now on
+// whose topics start with the given prefix, which buffers up to size events,
+// and a function to stop receiving them, which closes the channel
+func (b *eventBus) Subscribe(prefix string, size int) (<-chan *Event, func()) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	ch := make(chan *Event, size)
+	b.subs[ch] = prefix
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.l.Lock()
+			defer b.l.Unlock()
+			delete(b.subs, ch)
+			close(ch)
+		})
+	}
+}
+
+// publish publishes a new event of the given type. Publishing on a nil bus
+// does nothing.
+func (b *eventBus) publish(eventType, path, operation string, metadata map[string]string) {
+	if b == nil {
+		return
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return
+	}
+
+	b.Publish(&Event{
+		ID:        id,
+		Type:      eventType,
+		Path:      path,
+		Operation: operation,
+		Timestamp: time.Now().UTC(),
+		Metadata:  metadata,
+	})
+}
+
+// publishKVEvent publishes an event for a successful write or delete in a kv
+// mount
+func (c *Core) publishKVEvent(entry *MountEntry, req *logical.Request) {
+	if entry == nil {
+		return
+	}
+	switch entry.Type {
+	case "kv", "generic":
+	default:
+		return
+	}
+
+	switch req.Operation {
+	case logical.CreateOperation, logical.UpdateOperation, logical.DeleteOperation:
+	default:
+		return
+	}
+
+	c.events.publish(EventTypeKV, req.Path, string(req.Operation), map[string]string{
+		"mount": entry.Path,
+	})
+}
+
+// publishMountEvent publishes an event for a mount or auth method that was
+// enabled, disabled or moved
+func (c *Core) publishMountEvent(path, operation string, metadata map[string]string) {
+	c.events.publish(EventTypeMount, path, operation, metadata)
+}
+
+// publishRevocation publishes an event for a revoked lease
+func (m *ExpirationManager) publishRevocation(le *leaseEntry) {
+	m.events.publish(EventTypeLease, le.LeaseID, "revoke", map[string]string{
+		"path": le.Path,
+	})
+}
+
+// SubscribeEvents returns a channel receiving the events published on the
+// node from now on whose topics start with the given prefix, and a function
+// to stop receiving them
+func (c *Core) SubscribeEvents(topic string) (<-chan *Event, func()) {
+	return c.events.Subscribe(topic, eventBufferSize)
+}
+
+// handleEventsSubscribe checks a request to stream events. The events are
+// streamed by the HTTP handler of sys/events/subscribe once the request is
+// allowed, as responses of backends are not streamed.
+func (b *SystemBackend) handleEventsSubscribe(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	topic := data.Get("topic").(string)
+	switch strings.SplitN(topic, "/", 2)[0] {
+	case "", EventTypeKV, EventTypeLease, EventTypeMount:
+	default:
+		return logical.ErrorResponse("unknown event type; must be one of \"kv\", \"lease\" or \"mount\""), nil
+	}
+
+	// An empty response, as reads without one are not found
+	return &logical.Response{}, nil
+}
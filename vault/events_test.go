@@ -0,0 +1,109 @@
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestEventBus(t *testing.T) {
+	b := newEventBus()
+
+	kv, stopKV := b.Subscribe("kv/secret/app/", 1)
+	defer stopKV()
+	all, stopAll := b.Subscribe("", 10)
+
+	b.publish(EventTypeKV, "secret/app/db", "update", nil)
+	b.publish(EventTypeKV, "secret/other", "update", nil)
+	b.publish(EventTypeKV, "secret/app/api", "update", nil)
+
+	// The second matching event is dropped, as the buffer is full
+	if len(kv) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(kv))
+	}
+	if event := <-kv; event.Topic() != "kv/secret/app/db" || event.ID == "" {
+		t.Fatalf("bad event: %#v", event)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(all))
+	}
+
+	// Stopping closes the channel once and stops the delivery
+	stopAll()
+	stopAll()
+	b.publish(EventTypeLease, "secret/app/db/1234", "revoke", nil)
+	for range all {
+	}
+
+	// Publishing without a bus does nothing
+	var nilBus *eventBus
+	nilBus.publish(EventTypeKV, "secret/app/db", "update", nil)
+}
+
+func TestCore_Events(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	events, stop := c.SubscribeEvents("")
+	defer stop()
+
+	next := func() *Event {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+		}
+		return nil
+	}
+
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "secret/test",
+		Data: map[string]interface{}{
+			"foo":   "bar",
+			"lease": "1h",
+		},
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if event := next(); event.Type != EventTypeKV || event.Path != "secret/test" || event.Operation != "create" || event.Metadata["mount"] != "secret/" {
+		t.Fatalf("bad event: %#v", event)
+	}
+
+	// Reads are not published, but revoking the lease they return is
+	req.Operation = logical.ReadOperation
+	req.Data = nil
+	resp, err := c.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.expiration.Revoke(context.Background(), resp.Secret.LeaseID); err != nil {
+		t.Fatal(err)
+	}
+	if event := next(); event.Type != EventTypeLease || event.Path != resp.Secret.LeaseID || event.Metadata["path"] != "secret/test" {
+		t.Fatalf("bad event: %#v", event)
+	}
+
+	me := &MountEntry{
+		Table: mountTableType,
+		Path:  "events/",
+		Type:  "kv",
+	}
+	if err := c.mount(context.Background(), me); err != nil {
+		t.Fatal(err)
+	}
+	if event := next(); event.Type != EventTypeMount || event.Path != "events/" || event.Operation != "enable" || event.Metadata["type"] != "kv" {
+		t.Fatalf("bad event: %#v", event)
+	}
+	if err := c.unmount(context.Background(), "events"); err != nil {
+		t.Fatal(err)
+	}
+	if event := next(); event.Topic() != "mount/events/" || event.Operation != "disable" {
+		t.Fatalf("bad event: %#v", event)
+	}
+}
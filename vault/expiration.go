@@ -22,7 +22,6 @@ import (
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/locksutil"
 	"github.com/hashicorp/vault/logical"
-	"github.com/hashicorp/vault/logical/framework"
 )
 
 const (
@@ -52,6 +51,10 @@ const (
 	maxLeaseThreshold = 256000
 )
 
+// errIdempotentLeaseExpired is returned for a retry of an idempotent request
+// whose lease has expired
+var errIdempotentLeaseExpired = errors.New("the lease issued for this idempotency key has expired; use a new key")
+
 type pendingInfo struct {
 	exportLeaseTimes *leaseEntry
 	timer            *time.Timer
@@ -78,13 +81,35 @@ type ExpirationManager struct {
 	restoreRequestLock sync.RWMutex
 	restoreLocks       []*locksutil.LockEntry
 	restoreLoaded      sync.Map
+	idempotencyLocks   []*locksutil.LockEntry
 	quitCh             chan struct{}
 
+	// restoreRemaining is the number of leases the background restore has
+	// yet to load
+	restoreRemaining *int64
+
 	coreStateLock     *sync.RWMutex
 	quitContext       context.Context
 	leaseCheckCounter *uint32
 
 	logLeaseExpirations bool
+
+	// renewalDefaults are the renewal settings of the mounts which don't
+	// override them
+	renewalDefaults renewalSettings
+
+	// revocations holds expired leases until a worker revokes them
+	revocations *revocationQueue
+
+	// revocationMetricMounts are the mounts a pending revocation gauge was
+	// last emitted for, so it can be reset once their queue drains
+	revocationMetricMounts map[string]struct{}
+
+	// events is where revoked leases are published
+	events *eventBus
+
+	// quotas are the lease count quotas new leases are checked against
+	quotas *leaseCountQuotas
 }
 
 // NewExpirationManager creates a new ExpirationManager that is backed
@@ -101,18 +126,32 @@ func NewExpirationManager(c *Core, view *BarrierView, logger log.Logger) *Expira
 
 		// new instances of the expiration manager will go immediately into
 		// restore mode
-		restoreMode:  new(int32),
-		restoreLocks: locksutil.CreateLocks(),
-		quitCh:       make(chan struct{}),
+		restoreMode:      new(int32),
+		restoreLocks:     locksutil.CreateLocks(),
+		idempotencyLocks: locksutil.CreateLocks(),
+		restoreRemaining: new(int64),
+		quitCh:           make(chan struct{}),
 
 		coreStateLock:     &c.stateLock,
 		quitContext:       c.activeContext,
 		leaseCheckCounter: new(uint32),
 
 		logLeaseExpirations: os.Getenv("VAULT_SKIP_LOGGING_LEASE_EXPIRATIONS") == "",
+
+		renewalDefaults: c.renewalDefaults,
+
+		events: c.events,
+		quotas: c.leaseCountQuotas,
 	}
 	*exp.restoreMode = 1
 
+	workers := c.leaseRevocationWorkers
+	if workers <= 0 {
+		workers = defaultLeaseRevocationWorkers
+	}
+	exp.revocations = newRevocationQueue(workers)
+	exp.startRevocationWorkers(workers)
+
 	if exp.logger == nil {
 		opts := log.LoggerOptions{Name: "expiration_manager"}
 		exp.logger = log.New(&opts)
@@ -149,6 +188,20 @@ func (c *Core) setupExpiration() error {
 	return nil
 }
 
+// setupNonRestoringExpiration creates an expiration manager that is only used
+// to look up leases; it does not restore leases or run revocation timers.
+func (c *Core) setupNonRestoringExpiration() {
+	c.metricsMutex.Lock()
+	defer c.metricsMutex.Unlock()
+	view := c.systemBarrierView.SubView(expirationSubPath)
+
+	mgr := NewExpirationManager(c, view, c.logger.ResetNamed("expiration"))
+	atomic.StoreInt32(mgr.restoreMode, 0)
+	c.expiration = mgr
+
+	c.tokenStore.SetExpirationManager(mgr)
+}
+
 // stopExpiration is used to stop the expiration manager before
 // sealing the Vault.
 func (c *Core) stopExpiration() error {
@@ -324,12 +377,14 @@ func (m *ExpirationManager) Restore(errorFunc func()) (retErr error) {
 	}()
 
 	// Accumulate existing leases
+	start := time.Now()
 	m.logger.Debug("collecting leases")
 	existing, err := logical.CollectKeys(m.quitContext, m.idView)
 	if err != nil {
 		return errwrap.Wrapf("failed to scan for leases: {{err}}", err)
 	}
 	m.logger.Debug("leases collected", "num_existing", len(existing))
+	atomic.StoreInt64(m.restoreRemaining, int64(len(existing)))
 
 	// Make the channels used for the worker pool
 	broker := make(chan string)
@@ -413,6 +468,7 @@ func (m *ExpirationManager) Restore(errorFunc func()) (retErr error) {
 			return nil
 
 		case <-result:
+			atomic.AddInt64(m.restoreRemaining, -1)
 		}
 	}
 
@@ -425,7 +481,8 @@ func (m *ExpirationManager) Restore(errorFunc func()) (retErr error) {
 	atomic.StoreInt32(m.restoreMode, 0)
 	m.restoreModeLock.Unlock()
 
-	m.logger.Info("lease restore complete")
+	metrics.MeasureSince([]string{"expire", "restore"}, start)
+	m.logger.Info("lease restore complete", "num_leases", len(existing), "duration", time.Since(start))
 	return nil
 }
 
@@ -466,12 +523,14 @@ func (m *ExpirationManager) Stop() error {
 	// Do this before stopping pending timers to avoid potential races with
 	// expiring timers
 	close(m.quitCh)
+	m.revocations.close()
 
 	m.pendingLock.Lock()
 	for _, pending := range m.pending {
 		pending.timer.Stop()
 	}
 	m.pending = make(map[string]pendingInfo)
+	m.quotas.resetCounts()
 	m.pendingLock.Unlock()
 
 	if m.inRestoreMode() {
@@ -570,7 +629,7 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 	m.pendingLock.Lock()
 	if pending, ok := m.pending[leaseID]; ok {
 		pending.timer.Stop()
-		delete(m.pending, leaseID)
+		m.deletePendingInternal(leaseID)
 	}
 	m.pendingLock.Unlock()
 
@@ -578,6 +637,8 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 		m.logger.Info("revoked lease", "lease_id", leaseID)
 	}
 
+	m.publishRevocation(le)
+
 	return nil
 }
 
@@ -706,6 +767,85 @@ func (m *ExpirationManager) revokePrefixCommon(prefix string, force, sync bool)
 	return nil
 }
 
+// MovePrefix moves the leases under the source prefix to the destination
+// prefix, keeping their expiration and their index by token. It is used when
+// a mount is moved, so that its leases can still be renewed and revoked. The
+// number of moved leases is returned.
+func (m *ExpirationManager) MovePrefix(src, dst string) (int, error) {
+	defer metrics.MeasureSince([]string{"expire", "move-prefix"}, time.Now())
+
+	if m.inRestoreMode() {
+		m.restoreRequestLock.Lock()
+		defer m.restoreRequestLock.Unlock()
+	}
+
+	if !strings.HasSuffix(src, "/") {
+		src += "/"
+	}
+	if !strings.HasSuffix(dst, "/") {
+		dst += "/"
+	}
+
+	// Accumulate existing leases
+	sub := m.idView.SubView(src)
+	existing, err := logical.CollectKeys(m.quitContext, sub)
+	if err != nil {
+		return 0, errwrap.Wrapf("failed to scan for leases: {{err}}", err)
+	}
+
+	moved := 0
+	for idx, suffix := range existing {
+		leaseID := src + suffix
+		le, err := m.loadEntry(leaseID)
+		if err != nil {
+			return moved, errwrap.Wrapf(fmt.Sprintf("failed to load %q (%d / %d): {{err}}", leaseID, idx+1, len(existing)), err)
+		}
+		if le == nil {
+			continue
+		}
+
+		newLe := *le
+		newLe.LeaseID = dst + suffix
+		newLe.Path = dst + strings.TrimPrefix(le.Path, src)
+		if le.Secret != nil && le.Secret.LeaseID != "" {
+			secret := *le.Secret
+			secret.LeaseID = newLe.LeaseID
+			newLe.Secret = &secret
+		}
+
+		// Write the new lease before removing the old one, so that a failure
+		// leaves a lease that can still be revoked
+		if err := m.persistEntry(&newLe); err != nil {
+			return moved, err
+		}
+		if newLe.ClientToken != "" {
+			if err := m.createIndexByToken(newLe.ClientToken, newLe.LeaseID); err != nil {
+				return moved, err
+			}
+			if err := m.removeIndexByToken(le.ClientToken, le.LeaseID); err != nil {
+				return moved, err
+			}
+		}
+		if err := m.deleteEntry(le.LeaseID); err != nil {
+			return moved, err
+		}
+
+		// Move the revocation timer
+		m.pendingLock.Lock()
+		if pending, ok := m.pending[le.LeaseID]; ok {
+			pending.timer.Stop()
+			m.deletePendingInternal(le.LeaseID)
+		}
+		m.updatePendingInternal(&newLe, newLe.ExpireTime.Sub(time.Now()))
+		m.pendingLock.Unlock()
+		m.restoreLoaded.Store(newLe.LeaseID, struct{}{})
+
+		moved++
+	}
+
+	return moved, nil
+}
+
 // Renew is used to renew a secret using the given leaseID
 // and a renew interval. The increment may be ignored.
 func (m *ExpirationManager) Renew(leaseID string, increment time.Duration) (*logical.Response, error) {
@@ -735,7 +875,8 @@ func (m *ExpirationManager) Renew(leaseID string, increment time.Duration) (*log
 	}
 
 	// Attempt to renew the entry
-	resp, err := m.renewEntry(le, increment)
+	settings := m.renewalSettings(le.Path)
+	resp, err := m.renewEntry(le, settings.increment(increment, 0))
 	if err != nil {
 		return nil, err
 	}
@@ -751,7 +892,7 @@ func (m *ExpirationManager) Renew(leaseID string, increment time.Duration) (*log
 		return nil, nil
 	}
 
-	ttl, warnings, err := framework.CalculateTTL(sysView, increment, resp.Secret.TTL, 0, resp.Secret.MaxTTL, 0, le.IssueTime)
+	ttl, renewal, warnings, err := calculateRenewalTTL(settings, sysView, increment, resp.Secret.TTL, 0, resp.Secret.MaxTTL, 0, le.IssueTime)
 	if err != nil {
 		return nil, err
 	}
@@ -759,6 +900,21 @@ func (m *ExpirationManager) Renew(leaseID string, increment time.Duration) (*log
 		resp.AddWarning(warning)
 	}
 	resp.Secret.TTL = ttl
+	resp.Renewal = renewal
+
+	// Renewal can't extend a lease of a batch token past the token
+	if strings.HasPrefix(le.ClientToken, batchTokenPrefix) {
+		te, err := m.tokenStore.Lookup(m.quitContext, le.ClientToken)
+		if err != nil {
+			return nil, err
+		}
+		if te == nil {
+			return logical.ErrorResponse("the token of the lease is no longer valid"), logical.ErrInvalidRequest
+		}
+		if remaining := batchTokenRemainingTTL(te); resp.Secret.TTL > remaining {
+			resp.Secret.TTL = remaining
+		}
+	}
 
 	// Attach the LeaseID
 	resp.Secret.LeaseID = leaseID
@@ -811,7 +967,8 @@ func (m *ExpirationManager) RenewToken(req *logical.Request, source string, toke
 	}
 
 	// Attempt to renew the auth entry
-	resp, err := m.renewAuthEntry(req, le, increment)
+	settings := m.renewalSettings(le.Path)
+	resp, err := m.renewAuthEntry(req, le, settings.increment(increment, le.Auth.Period))
 	if err != nil {
 		return nil, err
 	}
@@ -832,11 +989,13 @@ func (m *ExpirationManager) RenewToken(req *logical.Request, source string, toke
 		return nil, fmt.Errorf("unable to retrieve system view from router")
 	}
 
-	ttl, warnings, err := framework.CalculateTTL(sysView, increment, resp.Auth.TTL, resp.Auth.Period, resp.Auth.MaxTTL, resp.Auth.ExplicitMaxTTL, le.IssueTime)
+	ttl, renewal, warnings, err := calculateRenewalTTL(settings, sysView, increment, resp.Auth.TTL, resp.Auth.Period, resp.Auth.MaxTTL, resp.Auth.ExplicitMaxTTL, le.IssueTime)
 	if err != nil {
 		return nil, err
 	}
-	retResp := &logical.Response{}
+	retResp := &logical.Response{
+		Renewal: renewal,
+	}
 	for _, warning := range warnings {
 		retResp.AddWarning(warning)
 	}
@@ -886,14 +1045,23 @@ func (m *ExpirationManager) Register(req *logical.Request, resp *logical.Respons
 		return "", err
 	}
 
-	// Create a lease entry
-	leaseUUID, err := uuid.GenerateUUID()
-	if err != nil {
-		return "", err
+	// Create a lease entry. A request carrying an idempotency key gets the
+	// lease ID its retries will look up.
+	var leaseID string
+	if req.IdempotencyKey != "" && m.router.IdempotentPath(req.Path) {
+		var err error
+		leaseID, err = m.idempotentLeaseID(req)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		leaseUUID, err := uuid.GenerateUUID()
+		if err != nil {
+			return "", err
+		}
+		leaseID = path.Join(req.Path, leaseUUID)
 	}
 
-	leaseID := path.Join(req.Path, leaseUUID)
-
 	defer func() {
 		// If there is an error we want to rollback as much as possible (note
 		// that errors here are ignored to do as much cleanup as we can). We
@@ -917,6 +1085,10 @@ func (m *ExpirationManager) Register(req *logical.Request, resp *logical.Respons
 		}
 	}()
 
+	if err := m.checkLeaseCountQuotas(req.Path); err != nil {
+		return "", err
+	}
+
 	le := leaseEntry{
 		LeaseID:     leaseID,
 		ClientToken: req.ClientToken,
@@ -944,6 +1116,57 @@ func (m *ExpirationManager) Register(req *logical.Request, resp *logical.Respons
 	return le.LeaseID, nil
 }
 
+// idempotentLeaseID returns the lease ID of the secret issued for the client
+// token and idempotency key of a request
+func (m *ExpirationManager) idempotentLeaseID(req *logical.Request) (string, error) {
+	saltedID, err := m.tokenStore.SaltID(m.quitContext, req.ClientToken+"/"+req.IdempotencyKey)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(req.Path, saltedID), nil
+}
+
+// lockIdempotent takes out the lock held while an idempotent request is
+// handled, so concurrent retries of it wait for the first to register its
+// lease. The returned function releases the lock.
+func (m *ExpirationManager) lockIdempotent(req *logical.Request) (string, func(), error) {
+	leaseID, err := m.idempotentLeaseID(req)
+	if err != nil {
+		return "", nil, err
+	}
+	lock := locksutil.LockForKey(m.idempotencyLocks, leaseID)
+	lock.Lock()
+	return leaseID, lock.Unlock, nil
+}
+
+// FetchIdempotent returns the response of the secret already registered under
+// the given idempotent lease ID, or nil if there is none. A lease that has
+// expired but not yet been revoked is an error, since its ID cannot be reused
+// until the revocation completes.
+func (m *ExpirationManager) FetchIdempotent(leaseID string, req *logical.Request) (*logical.Response, error) {
+	le, err := m.loadEntry(leaseID)
+	if err != nil {
+		return nil, err
+	}
+	if le == nil || le.Secret == nil || le.ClientToken != req.ClientToken {
+		return nil, nil
+	}
+
+	secret := *le.Secret
+	secret.LeaseID = le.LeaseID
+	if !le.ExpireTime.IsZero() {
+		ttl := le.ExpireTime.Sub(time.Now())
+		if ttl <= 0 {
+			return nil, errIdempotentLeaseExpired
+		}
+		secret.TTL = ttl.Round(time.Second)
+	}
+	return &logical.Response{
+		Secret: &secret,
+		Data:   le.Data,
+	}, nil
+}
+
 // RegisterAuth is used to take an Auth response with an associated lease.
 // The token does not get a LeaseID, but the lease management is handled by
 // the expiration manager.
@@ -986,9 +1209,14 @@ func (m *ExpirationManager) RegisterAuth(source string, auth *logical.Auth) erro
 
 // FetchLeaseTimesByToken is a helper function to use token values to compute
 // the leaseID, rather than pushing that logic back into the token store.
+// Batch tokens have no lease, so none is looked up for them.
 func (m *ExpirationManager) FetchLeaseTimesByToken(source, token string) (*leaseEntry, error) {
 	defer metrics.MeasureSince([]string{"expire", "fetch-lease-times-by-token"}, time.Now())
 
+	if strings.HasPrefix(token, batchTokenPrefix) {
+		return nil, nil
+	}
+
 	// Compute the Lease ID
 	saltedID, err := m.tokenStore.SaltID(m.quitContext, token)
 	if err != nil {
@@ -1065,7 +1293,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry, leaseTotal tim
 		// pending timers.
 		if ok {
 			pending.timer.Stop()
-			delete(m.pending, le.LeaseID)
+			m.deletePendingInternal(le.LeaseID)
 		}
 		return
 	}
@@ -1085,49 +1313,34 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry, leaseTotal tim
 	// Extend the timer by the lease total
 	pending.exportLeaseTimes = m.leaseTimesForExport(le)
 
+	if !ok {
+		m.quotas.countLease(le.LeaseID, 1)
+	}
 	m.pending[le.LeaseID] = pending
 }
 
-// expireID is invoked when a given ID is expired
+// deletePendingInternal removes a lease from the pending leases, which no
+// longer counts against the lease count quotas; do not call this without a
+// write lock on m.pending
+func (m *ExpirationManager) deletePendingInternal(leaseID string) {
+	if _, ok := m.pending[leaseID]; !ok {
+		return
+	}
+	delete(m.pending, leaseID)
+	m.quotas.countLease(leaseID, -1)
+}
+
+// expireID is invoked when a given ID is expired, and queues its revocation
 func (m *ExpirationManager) expireID(leaseID string) {
 	// Clear from the pending expiration
 	m.pendingLock.Lock()
-	delete(m.pending, leaseID)
+	m.deletePendingInternal(leaseID)
 	m.pendingLock.Unlock()
 
-	for attempt := uint(0); attempt < maxRevokeAttempts; attempt++ {
-		ctx, cancel := context.WithTimeout(m.quitContext, DefaultMaxRequestDuration)
-
-		go func() {
-			select {
-			case <-ctx.Done():
-			case <-m.quitCh:
-				cancel()
-			}
-		}()
-
-		select {
-		case <-m.quitCh:
-			m.logger.Error("shutting down, not attempting further revocation of lease", "lease_id", leaseID)
-			return
-		case <-m.quitContext.Done():
-			m.logger.Error("core context canceled, not attempting further revocation of lease", "lease_id", leaseID)
-			return
-		default:
-		}
-
-		m.coreStateLock.RLock()
-		err := m.Revoke(ctx, leaseID)
-		m.coreStateLock.RUnlock()
-		cancel()
-		if err == nil {
-			return
-		}
-
-		m.logger.Error("failed to revoke lease", "lease_id", leaseID, "error", err)
-		time.Sleep((1 << attempt) * revokeRetryBase)
-	}
-	m.logger.Error("maximum revoke attempts reached", "lease_id", leaseID)
+	m.revocations.push(&revocationJob{
+		leaseID: leaseID,
+		mount:   m.router.MatchingMount(leaseID),
+	})
 }
 
 // revokeEntry is used to attempt revocation of an internal entry
@@ -1413,6 +1626,27 @@ func (m *ExpirationManager) emitMetrics() {
 	num := len(m.pending)
 	m.pendingLock.RUnlock()
 	metrics.SetGauge([]string{"expire", "num_leases"}, float32(num))
+	if m.inRestoreMode() {
+		metrics.SetGauge([]string{"expire", "restore", "remaining"}, float32(atomic.LoadInt64(m.restoreRemaining)))
+	}
+
+	waiting, inFlight := m.revocations.stats()
+	var numWaiting int
+	for mount, n := range waiting {
+		numWaiting += n
+		metrics.SetGaugeWithLabels([]string{"expire", "revocation", "pending_by_mount"}, float32(n), []metrics.Label{{Name: "mount", Value: mount}})
+	}
+	for mount := range m.revocationMetricMounts {
+		if _, ok := waiting[mount]; !ok {
+			metrics.SetGaugeWithLabels([]string{"expire", "revocation", "pending_by_mount"}, 0, []metrics.Label{{Name: "mount", Value: mount}})
+		}
+	}
+	m.revocationMetricMounts = make(map[string]struct{}, len(waiting))
+	for mount := range waiting {
+		m.revocationMetricMounts[mount] = struct{}{}
+	}
+	metrics.SetGauge([]string{"expire", "revocation", "pending"}, float32(numWaiting))
+	metrics.SetGauge([]string{"expire", "revocation", "in_flight"}, float32(inFlight))
 	// Check if lease count is greater than the threshold
 	if num > maxLeaseThreshold {
 		if atomic.LoadUint32(m.leaseCheckCounter) > 59 {
@@ -0,0 +1,210 @@
+package vault
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLeaseRevocationWorkers is the number of workers revoking expired
+	// leases if not otherwise configured
+	defaultLeaseRevocationWorkers = 64
+)
+
+// revocationJob is an expired lease waiting to be revoked
+type revocationJob struct {
+	leaseID string
+	mount   string
+	attempt uint
+}
+
+// revocationQueue holds expired leases until one of a fixed pool of workers
+// can revoke them. Leases are queued per mount and the mounts are served in
+// turn, and no mount may occupy more than half of the workers at once, so
+// that a backend whose revocations are slow cannot hold up the others.
+type revocationQueue struct {
+	l    sync.Mutex
+	cond *sync.Cond
+
+	// queues holds the waiting jobs of each mount, and order the mounts with
+	// waiting jobs in the order they are served
+	queues map[string][]*revocationJob
+	order  []string
+
+	// inFlight is the number of jobs of each mount currently being revoked
+	inFlight map[string]int
+
+	maxPerMount int
+	closed      bool
+}
+
+func newRevocationQueue(workers int) *revocationQueue {
+	maxPerMount := workers / 2
+	if maxPerMount < 1 {
+		maxPerMount = 1
+	}
+
+	q := &revocationQueue{
+		queues:      make(map[string][]*revocationJob),
+		inFlight:    make(map[string]int),
+		maxPerMount: maxPerMount,
+	}
+	q.cond = sync.NewCond(&q.l)
+	return q
+}
+
+// push queues a job. Jobs pushed after the queue is closed are dropped.
+func (q *revocationQueue) push(job *revocationJob) {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	if q.closed {
+		return
+	}
+	if _, ok := q.queues[job.mount]; !ok {
+		q.order = append(q.order, job.mount)
+	}
+	q.queues[job.mount] = append(q.queues[job.mount], job)
+	q.cond.Signal()
+}
+
+// pop blocks until a job can be started, returning false once the queue is
+// closed. The caller must call done with the job once it has been handled.
+func (q *revocationQueue) pop() (*revocationJob, bool) {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	for {
+		if q.closed {
+			return nil, false
+		}
+		if job := q.next(); job != nil {
+			return job, true
+		}
+		q.cond.Wait()
+	}
+}
+
+// next takes the first job of the next mount in turn that is below its
+// limit of in flight jobs. The lock must be held.
+func (q *revocationQueue) next() *revocationJob {
+	for i, mount := range q.order {
+		if q.inFlight[mount] >= q.maxPerMount {
+			continue
+		}
+
+		jobs := q.queues[mount]
+		job := jobs[0]
+		q.order = append(q.order[:i:i], q.order[i+1:]...)
+		if len(jobs) == 1 {
+			delete(q.queues, mount)
+		} else {
+			q.queues[mount] = jobs[1:]
+			q.order = append(q.order, mount)
+		}
+		q.inFlight[mount]++
+		return job
+	}
+	return nil
+}
+
+// done marks a job returned by pop as handled
+func (q *revocationQueue) done(job *revocationJob) {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	q.inFlight[job.mount]--
+	if q.inFlight[job.mount] <= 0 {
+		delete(q.inFlight, job.mount)
+	}
+	q.cond.Signal()
+}
+
+// close drops all waiting jobs and stops the workers
+func (q *revocationQueue) close() {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	q.closed = true
+	q.queues = make(map[string][]*revocationJob)
+	q.order = nil
+	q.cond.Broadcast()
+}
+
+// stats returns the number of jobs waiting for each mount, and the total
+// number of jobs being revoked
+func (q *revocationQueue) stats() (map[string]int, int) {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	waiting := make(map[string]int, len(q.queues))
+	for mount, jobs := range q.queues {
+		waiting[mount] = len(jobs)
+	}
+	var inFlight int
+	for _, n := range q.inFlight {
+		inFlight += n
+	}
+	return waiting, inFlight
+}
+
+// startRevocationWorkers starts the workers revoking expired leases
+func (m *ExpirationManager) startRevocationWorkers(workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				job, ok := m.revocations.pop()
+				if !ok {
+					return
+				}
+				m.processRevocation(job)
+				m.revocations.done(job)
+			}
+		}()
+	}
+}
+
+// processRevocation makes one attempt at revoking an expired lease,
+// scheduling another attempt with backoff if it fails
+func (m *ExpirationManager) processRevocation(job *revocationJob) {
+	ctx, cancel := context.WithTimeout(m.quitContext, DefaultMaxRequestDuration)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-m.quitCh:
+			cancel()
+		}
+	}()
+
+	select {
+	case <-m.quitCh:
+		m.logger.Error("shutting down, not attempting further revocation of lease", "lease_id", job.leaseID)
+		return
+	case <-m.quitContext.Done():
+		m.logger.Error("core context canceled, not attempting further revocation of lease", "lease_id", job.leaseID)
+		return
+	default:
+	}
+
+	m.coreStateLock.RLock()
+	err := m.Revoke(ctx, job.leaseID)
+	m.coreStateLock.RUnlock()
+	if err == nil {
+		return
+	}
+
+	m.logger.Error("failed to revoke lease", "lease_id", job.leaseID, "error", err)
+	job.attempt++
+	if job.attempt >= maxRevokeAttempts {
+		m.logger.Error("maximum revoke attempts reached", "lease_id", job.leaseID)
+		return
+	}
+
+	// Requeue rather than sleep so the worker can serve other leases
+	time.AfterFunc((1<<(job.attempt-1))*revokeRetryBase, func() {
+		m.revocations.push(job)
+	})
+}
@@ -0,0 +1,97 @@
+package vault
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRevocationQueue_Fairness(t *testing.T) {
+	q := newRevocationQueue(8)
+	for i := 0; i < 3; i++ {
+		q.push(&revocationJob{leaseID: fmt.Sprintf("database/creds/%d", i), mount: "database/"})
+	}
+	q.push(&revocationJob{leaseID: "pki/issue/0", mount: "pki/"})
+
+	waiting, inFlight := q.stats()
+	if !reflect.DeepEqual(waiting, map[string]int{"database/": 3, "pki/": 1}) || inFlight != 0 {
+		t.Fatalf("bad: %#v %d", waiting, inFlight)
+	}
+
+	// Mounts are served in turn rather than in the order leases expired
+	var order []string
+	for i := 0; i < 4; i++ {
+		job, ok := q.pop()
+		if !ok {
+			t.Fatal("expected a job")
+		}
+		order = append(order, job.leaseID)
+		q.done(job)
+	}
+	expected := []string{"database/creds/0", "pki/issue/0", "database/creds/1", "database/creds/2"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("bad: %v", order)
+	}
+}
+
+func TestRevocationQueue_MaxPerMount(t *testing.T) {
+	q := newRevocationQueue(2)
+	q.push(&revocationJob{leaseID: "database/creds/0", mount: "database/"})
+	q.push(&revocationJob{leaseID: "database/creds/1", mount: "database/"})
+
+	// A slow mount may only take half of the workers
+	slow, _ := q.pop()
+	popped := make(chan *revocationJob)
+	go func() {
+		job, _ := q.pop()
+		popped <- job
+	}()
+	select {
+	case job := <-popped:
+		t.Fatalf("expected the second job of the mount to wait, got %q", job.leaseID)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Other mounts are still served
+	q.push(&revocationJob{leaseID: "pki/issue/0", mount: "pki/"})
+	select {
+	case job := <-popped:
+		if job.leaseID != "pki/issue/0" {
+			t.Fatalf("bad: %q", job.leaseID)
+		}
+		q.done(job)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for job")
+	}
+
+	// Once the slow revocation finishes the mount's next job is started
+	go func() {
+		job, _ := q.pop()
+		popped <- job
+	}()
+	q.done(slow)
+	select {
+	case job := <-popped:
+		if job.leaseID != "database/creds/1" {
+			t.Fatalf("bad: %q", job.leaseID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for job")
+	}
+
+	// Closing releases the workers and drops anything queued afterwards
+	done := make(chan bool)
+	go func() {
+		_, ok := q.pop()
+		done <- ok
+	}()
+	q.close()
+	if ok := <-done; ok {
+		t.Fatal("expected pop to fail once closed")
+	}
+	q.push(&revocationJob{leaseID: "pki/issue/1", mount: "pki/"})
+	if waiting, _ := q.stats(); len(waiting) != 0 {
+		t.Fatalf("bad: %#v", waiting)
+	}
+}
@@ -0,0 +1,152 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// renewalSettings control the TTLs granted by the renewals of leases, which
+// the mounts can override from the settings of the server
+type renewalSettings struct {
+	// defaultIncrement is the increment of the renewals requesting none, in
+	// place of the TTL of the backend
+	defaultIncrement time.Duration
+
+	// maxIncrement is the most a renewal can extend a lease by
+	maxIncrement time.Duration
+
+	// gracePeriod is how close to its max TTL a renewal extends a lease all
+	// the way to it, rather than leaving a remainder too short to be worth
+	// renewing
+	gracePeriod time.Duration
+}
+
+func (s renewalSettings) validate() error {
+	switch {
+	case s.defaultIncrement < 0:
+		return fmt.Errorf("default_renewal_increment cannot be negative")
+	case s.maxIncrement < 0:
+		return fmt.Errorf("max_renewal_increment cannot be negative")
+	case s.gracePeriod < 0:
+		return fmt.Errorf("renewal_grace_period cannot be negative")
+	case s.maxIncrement > 0 && s.defaultIncrement > s.maxIncrement:
+		return fmt.Errorf("default_renewal_increment cannot be larger than max_renewal_increment")
+	}
+	return nil
+}
+
+// renewalSettings returns the renewal settings of the mount of the given path
+func (m *ExpirationManager) renewalSettings(path string) renewalSettings {
+	settings := m.renewalDefaults
+
+	entry := m.router.MatchingMountEntry(path)
+	if entry == nil {
+		return settings
+	}
+	if entry.Config.DefaultRenewalIncrement > 0 {
+		settings.defaultIncrement = entry.Config.DefaultRenewalIncrement
+	}
+	if entry.Config.MaxRenewalIncrement > 0 {
+		settings.maxIncrement = entry.Config.MaxRenewalIncrement
+	}
+	if entry.Config.RenewalGracePeriod > 0 {
+		settings.gracePeriod = entry.Config.RenewalGracePeriod
+	}
+	return settings
+}
+
+// increment returns the increment a renewal requesting the given one is
+// handed to the backend with. As in calculateRenewalTTL, the default
+// increment doesn't apply to periodic tokens.
+func (s renewalSettings) increment(requested, period time.Duration) time.Duration {
+	if requested == 0 && period == 0 {
+		return s.defaultIncrement
+	}
+	return requested
+}
+
+// calculateRenewalTTL calculates the TTL granted by the renewal of a lease,
+// as framework.CalculateTTL does, before applying the renewal settings. The
+// increments don't apply to the renewals of periodic tokens, whose TTL is
+// their period.
+func calculateRenewalTTL(settings renewalSettings, sysView logical.SystemView, requested, backendTTL, period, backendMaxTTL, explicitMaxTTL time.Duration, issueTime time.Time) (time.Duration, *logical.RenewalInfo, []string, error) {
+	info := &logical.RenewalInfo{
+		RequestedIncrement: requested,
+		Decision:           logical.RenewalDecisionRequested,
+	}
+
+	increment := requested
+	if period == 0 && requested == 0 && settings.defaultIncrement > 0 {
+		increment = settings.defaultIncrement
+		info.Decision = logical.RenewalDecisionDefaultIncrement
+	}
+
+	ttl, warnings, err := framework.CalculateTTL(sysView, increment, backendTTL, period, backendMaxTTL, explicitMaxTTL, issueTime)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// The TTL a lease issued now would get, which the TTL falls short of if
+	// it was capped to the max TTL of the lease
+	uncapped, _, err := framework.CalculateTTL(sysView, increment, backendTTL, period, backendMaxTTL, explicitMaxTTL, time.Time{})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	remaining, bounded := remainingMaxTTL(sysView, period, backendMaxTTL, explicitMaxTTL, issueTime)
+
+	switch {
+	case period == 0 && settings.maxIncrement > 0 && ttl > settings.maxIncrement:
+		warnings = append(warnings,
+			fmt.Sprintf("TTL of %q exceeded the max_renewal_increment of %q; TTL value is capped accordingly", ttl, settings.maxIncrement))
+		ttl = settings.maxIncrement
+		info.Decision = logical.RenewalDecisionMaxIncrement
+	case ttl < uncapped:
+		info.Decision = logical.RenewalDecisionMaxTTL
+	}
+
+	if bounded && ttl >= remaining {
+		info.Final = true
+	}
+
+	// Rather than leaving a remainder within the grace period, which would
+	// take another renewal to reach, extend the lease to its max TTL, as long
+	// as that is within the max renewal increment
+	if bounded && !info.Final && settings.gracePeriod > 0 && remaining-ttl < settings.gracePeriod &&
+		(period > 0 || settings.maxIncrement == 0 || remaining <= settings.maxIncrement) {
+		ttl = remaining
+		info.Decision = logical.RenewalDecisionGracePeriod
+		info.Final = true
+	}
+
+	info.TTL = ttl
+	return ttl, info, warnings, nil
+}
+
+// remainingMaxTTL returns the time left before a lease issued at the given
+// time reaches its max TTL, the way framework.CalculateTTL caps the TTLs of
+// renewals, or false if the lease has no max TTL, as a periodic token without
+// an explicit max TTL
+func remainingMaxTTL(sysView logical.SystemView, period, backendMaxTTL, explicitMaxTTL time.Duration, issueTime time.Time) (time.Duration, bool) {
+	now := time.Now().Truncate(time.Second)
+	startTime := issueTime.Truncate(time.Second)
+
+	if period > 0 {
+		if explicitMaxTTL <= 0 {
+			return 0, false
+		}
+		return startTime.Add(explicitMaxTTL).Sub(now), true
+	}
+
+	maxTTL := sysView.MaxLeaseTTL()
+	if backendMaxTTL > 0 && backendMaxTTL < maxTTL {
+		maxTTL = backendMaxTTL
+	}
+	if explicitMaxTTL > 0 && explicitMaxTTL < maxTTL {
+		maxTTL = explicitMaxTTL
+	}
+	return startTime.Add(maxTTL).Sub(now), true
+}
@@ -0,0 +1,170 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCalculateRenewalTTL(t *testing.T) {
+	sysView := logical.StaticSystemView{
+		DefaultLeaseTTLVal: 5 * time.Hour,
+		MaxLeaseTTLVal:     30 * time.Hour,
+	}
+
+	cases := map[string]struct {
+		settings  renewalSettings
+		requested time.Duration
+		backend   time.Duration
+		period    time.Duration
+		explicit  time.Duration
+		age       time.Duration
+		ttl       time.Duration
+		decision  string
+		final     bool
+	}{
+		"requested increment": {
+			requested: time.Hour,
+			ttl:       time.Hour,
+			decision:  logical.RenewalDecisionRequested,
+		},
+		"backend TTL without an increment": {
+			backend:  2 * time.Hour,
+			ttl:      2 * time.Hour,
+			decision: logical.RenewalDecisionRequested,
+		},
+		"default increment": {
+			settings: renewalSettings{defaultIncrement: 3 * time.Hour},
+			backend:  2 * time.Hour,
+			ttl:      3 * time.Hour,
+			decision: logical.RenewalDecisionDefaultIncrement,
+		},
+		"requested increment wins over the default": {
+			settings:  renewalSettings{defaultIncrement: 3 * time.Hour},
+			requested: time.Hour,
+			ttl:       time.Hour,
+			decision:  logical.RenewalDecisionRequested,
+		},
+		"max increment": {
+			settings:  renewalSettings{maxIncrement: 2 * time.Hour},
+			requested: 10 * time.Hour,
+			ttl:       2 * time.Hour,
+			decision:  logical.RenewalDecisionMaxIncrement,
+		},
+		"max increment caps the backend TTL": {
+			settings: renewalSettings{maxIncrement: 2 * time.Hour},
+			backend:  10 * time.Hour,
+			ttl:      2 * time.Hour,
+			decision: logical.RenewalDecisionMaxIncrement,
+		},
+		"max TTL": {
+			requested: 10 * time.Hour,
+			age:       25 * time.Hour,
+			ttl:       5 * time.Hour,
+			decision:  logical.RenewalDecisionMaxTTL,
+			final:     true,
+		},
+		"max TTL within the max increment": {
+			settings:  renewalSettings{maxIncrement: 8 * time.Hour},
+			requested: 10 * time.Hour,
+			age:       25 * time.Hour,
+			ttl:       5 * time.Hour,
+			decision:  logical.RenewalDecisionMaxTTL,
+			final:     true,
+		},
+		"remainder outside the grace period": {
+			settings:  renewalSettings{gracePeriod: time.Hour},
+			requested: 2 * time.Hour,
+			age:       20 * time.Hour,
+			ttl:       2 * time.Hour,
+			decision:  logical.RenewalDecisionRequested,
+		},
+		"remainder within the grace period": {
+			settings:  renewalSettings{gracePeriod: time.Hour},
+			requested: 2 * time.Hour,
+			age:       27*time.Hour + 30*time.Minute,
+			ttl:       2*time.Hour + 30*time.Minute,
+			decision:  logical.RenewalDecisionGracePeriod,
+			final:     true,
+		},
+		"grace period within the max increment only": {
+			settings:  renewalSettings{gracePeriod: time.Hour, maxIncrement: 2 * time.Hour},
+			requested: 2 * time.Hour,
+			age:       27*time.Hour + 30*time.Minute,
+			ttl:       2 * time.Hour,
+			decision:  logical.RenewalDecisionRequested,
+		},
+		"periodic token ignores the increments": {
+			settings:  renewalSettings{defaultIncrement: 3 * time.Hour, maxIncrement: 3 * time.Hour},
+			requested: 10 * time.Hour,
+			period:    4 * time.Hour,
+			ttl:       4 * time.Hour,
+			decision:  logical.RenewalDecisionRequested,
+		},
+		"periodic token with an explicit max TTL": {
+			settings: renewalSettings{gracePeriod: time.Hour},
+			period:   4 * time.Hour,
+			explicit: 10 * time.Hour,
+			age:      5*time.Hour + 30*time.Minute,
+			ttl:      4*time.Hour + 30*time.Minute,
+			decision: logical.RenewalDecisionGracePeriod,
+			final:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			issueTime := time.Now().Add(-tc.age)
+			ttl, info, _, err := calculateRenewalTTL(tc.settings, sysView, tc.requested, tc.backend, tc.period, 0, tc.explicit, issueTime)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The TTLs capped to the max TTL depend on the second the lease was
+			// issued at
+			if ttl < tc.ttl-time.Second || ttl > tc.ttl {
+				t.Fatalf("expected a TTL of %s, got %s", tc.ttl, ttl)
+			}
+			if info.TTL != ttl || info.RequestedIncrement != tc.requested {
+				t.Fatalf("bad: %#v", info)
+			}
+			if info.Decision != tc.decision || info.Final != tc.final {
+				t.Fatalf("expected decision %q and final %t, got %#v", tc.decision, tc.final, info)
+			}
+		})
+	}
+}
+
+func TestRenewalSettings_validate(t *testing.T) {
+	for _, settings := range []renewalSettings{
+		{defaultIncrement: -1},
+		{maxIncrement: -1},
+		{gracePeriod: -1},
+		{defaultIncrement: 2 * time.Hour, maxIncrement: time.Hour},
+	} {
+		if err := settings.validate(); err == nil {
+			t.Fatalf("expected an error validating %#v", settings)
+		}
+	}
+
+	if err := (renewalSettings{defaultIncrement: time.Hour, maxIncrement: time.Hour, gracePeriod: time.Minute}).validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenewalSettings_increment(t *testing.T) {
+	settings := renewalSettings{defaultIncrement: time.Hour}
+
+	if increment := settings.increment(0, 0); increment != time.Hour {
+		t.Fatalf("expected the default increment, got %s", increment)
+	}
+	if increment := settings.increment(time.Minute, 0); increment != time.Minute {
+		t.Fatalf("expected the requested increment, got %s", increment)
+	}
+
+	// Periodic tokens are renewed for their period
+	if increment := settings.increment(0, 10*time.Minute); increment != 0 {
+		t.Fatalf("expected no increment, got %s", increment)
+	}
+}
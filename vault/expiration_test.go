@@ -442,6 +442,65 @@ func TestExpiration_Restore(t *testing.T) {
 	}
 }
 
+func TestExpiration_RestoreLazy(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	var leaseIDs []string
+	for _, path := range []string{"secret/foo", "secret/bar", "secret/baz"} {
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        path,
+			ClientToken: "foobar",
+		}
+		resp := &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: time.Hour,
+				},
+			},
+		}
+		leaseID, err := c.expiration.Register(req, resp)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		leaseIDs = append(leaseIDs, leaseID)
+	}
+
+	exp := NewExpirationManager(c, c.systemBarrierView.SubView(expirationSubPath), c.logger)
+	defer exp.Stop()
+	if !exp.inRestoreMode() {
+		t.Fatal("expected new expiration manager to be in restore mode")
+	}
+
+	// Leases are loaded on demand before the restore has reached them
+	le, err := exp.loadEntry(leaseIDs[0])
+	if err != nil || le == nil {
+		t.Fatalf("le: %#v, err: %v", le, err)
+	}
+	exp.pendingLock.RLock()
+	_, ok := exp.pending[leaseIDs[0]]
+	exp.pendingLock.RUnlock()
+	if !ok {
+		t.Fatal("expected lease loaded on demand to be pending")
+	}
+
+	if err := exp.Restore(nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if exp.inRestoreMode() {
+		t.Fatal("expected restore mode to be finished")
+	}
+	if remaining := atomic.LoadInt64(exp.restoreRemaining); remaining != 0 {
+		t.Fatalf("expected all leases to be restored, %d remaining", remaining)
+	}
+	exp.pendingLock.RLock()
+	numPending := len(exp.pending)
+	exp.pendingLock.RUnlock()
+	if numPending != len(leaseIDs) {
+		t.Fatalf("expected %d pending leases, got %d", len(leaseIDs), numPending)
+	}
+}
+
 func TestExpiration_Register(t *testing.T) {
 	exp := mockExpiration(t)
 	req := &logical.Request{
@@ -116,6 +116,9 @@ func (c *Core) GenerateRootConfiguration() (*GenerateRootConfig, error) {
 func (c *Core) GenerateRootInit(otp, pgpKey string, strategy GenerateRootStrategy) error {
 	var fingerprint string
 	switch {
+	case len(otp) > 0 && len(pgpKey) > 0:
+		return fmt.Errorf("only one of an OTP or a PGP key may be specified")
+
 	case len(otp) > 0:
 		otpBytes, err := base64.StdEncoding.DecodeString(otp)
 		if err != nil {
@@ -136,7 +139,7 @@ func (c *Core) GenerateRootInit(otp, pgpKey string, strategy GenerateRootStrateg
 		fingerprint = fingerprints[0]
 
 	default:
-		return fmt.Errorf("unreachable condition")
+		return fmt.Errorf("either an OTP or a PGP key must be specified")
 	}
 
 	c.stateLock.RLock()
@@ -94,6 +94,16 @@ func testCore_GenerateRoot_Init_Common(t *testing.T, c *Core) {
 		t.Fatal(err)
 	}
 
+	// Exactly one of an OTP and a PGP key is required
+	err = c.GenerateRootInit("", "", GenerateStandardRootTokenStrategy)
+	if err == nil {
+		t.Fatalf("should fail")
+	}
+	err = c.GenerateRootInit(base64.StdEncoding.EncodeToString(otpBytes), pgpkeys.TestPubKey1, GenerateStandardRootTokenStrategy)
+	if err == nil {
+		t.Fatalf("should fail")
+	}
+
 	err = c.GenerateRootInit(base64.StdEncoding.EncodeToString(otpBytes), "", GenerateStandardRootTokenStrategy)
 	if err != nil {
 		t.Fatalf("err: %v", err)
@@ -257,6 +257,11 @@ func (c *Core) StepDown(httpCtx context.Context, req *logical.Request) (retErr e
 
 	select {
 	case c.manualStepDownCh <- struct{}{}:
+		// The step-down cannot complete before this returns, as it needs the
+		// state lock
+		c.stepDownDoneLock.Lock()
+		c.stepDownDoneCh = make(chan struct{})
+		c.stepDownDoneLock.Unlock()
 	default:
 		c.logger.Warn("manual step-down operation already queued")
 	}
@@ -264,6 +269,25 @@ func (c *Core) StepDown(httpCtx context.Context, req *logical.Request) (retErr e
 	return retErr
 }
 
+// WaitForStepDown blocks until a step-down queued by StepDown has completed,
+// that is until the requests that were in flight on the active node have
+// drained and the HA lock has been released, or until ctx is done.
+func (c *Core) WaitForStepDown(ctx context.Context) error {
+	c.stepDownDoneLock.Lock()
+	doneCh := c.stepDownDoneCh
+	c.stepDownDoneLock.Unlock()
+	if doneCh == nil {
+		return nil
+	}
+
+	select {
+	case <-doneCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // runStandby is a long running process that manages a number of the HA
 // subsystems.
 func (c *Core) runStandby(doneCh, manualStepDownCh, stopCh chan struct{}) {
@@ -322,6 +346,20 @@ func (c *Core) runStandby(doneCh, manualStepDownCh, stopCh chan struct{}) {
 			<-leaderDoneCh
 		})
 	}
+	if c.perfStandbyStorage != nil {
+		// Keep the state needed to service requests locally up to date
+		perfStandbyDoneCh := make(chan struct{})
+		perfStandbyStopCh := make(chan struct{})
+
+		g.Add(func() error {
+			c.runPerfStandby(perfStandbyDoneCh, perfStandbyStopCh)
+			return nil
+		}, func(error) {
+			close(perfStandbyStopCh)
+			c.logger.Debug("shutting down performance standby")
+			<-perfStandbyDoneCh
+		})
+	}
 
 	// Start all the actors
 	g.Run()
@@ -333,6 +371,9 @@ func (c *Core) runStandby(doneCh, manualStepDownCh, stopCh chan struct{}) {
 func (c *Core) waitForLeadership(doneCh, manualStepDownCh, stopCh chan struct{}) {
 	defer close(doneCh)
 
+	// A step-down cannot be waited for once the node is no longer active
+	defer c.finishStepDown()
+
 	c.logger.Info("entering standby mode")
 
 	var manualStepDown bool
@@ -412,6 +453,12 @@ func (c *Core) waitForLeadership(doneCh, manualStepDownCh, stopCh chan struct{})
 			return
 		}
 
+		// Drop any state used to service requests as a performance standby;
+		// everything is set up again below
+		if c.perfStandbyActive {
+			c.perfStandbyTeardown()
+		}
+
 		// Store the lock so that we can manually clear it later if needed
 		c.heldHALock = lock
 
@@ -531,11 +578,34 @@ func (c *Core) waitForLeadership(doneCh, manualStepDownCh, stopCh chan struct{})
 			manualStepDown = true
 			c.logger.Warn("stepping down from active operation to standby")
 
+			// Taking the state lock waits for the requests in flight, while
+			// new requests wait for the step-down and are then redirected or
+			// forwarded to the next active node
+			c.logger.Info("draining in-flight requests before stepping down")
+			drainTime := time.Now()
 			c.stateLock.Lock()
+			metrics.MeasureSince([]string{"core", "step_down_drain"}, drainTime)
 			runSealing()
 			releaseHALock()
 			c.stateLock.Unlock()
+			c.logger.Info("stepped down, released the HA lock")
 		}
+
+		// However the node stopped being active, a step-down queued meanwhile
+		// is done with
+		c.finishStepDown()
+	}
+}
+
+// finishStepDown unblocks WaitForStepDown once a queued step-down has
+// completed, or can no longer happen
+func (c *Core) finishStepDown() {
+	c.stepDownDoneLock.Lock()
+	defer c.stepDownDoneLock.Unlock()
+
+	if c.stepDownDoneCh != nil {
+		close(c.stepDownDoneCh)
+		c.stepDownDoneCh = nil
 	}
 }
 
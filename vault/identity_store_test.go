@@ -82,6 +82,68 @@ func TestIdentityStore_EntityIDPassthrough(t *testing.T) {
 	}
 }
 
+func TestIdentityStore_EntityInfoPassthrough(t *testing.T) {
+	is, ghAccessor, core := testIdentityStoreWithGithubAuth(t)
+	entity, err := is.CreateOrFetchEntity(&logical.Alias{
+		MountType:     "github",
+		MountAccessor: ghAccessor,
+		Name:          "githubuser",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity.Metadata = map[string]string{"team": "storage"}
+	if err := is.upsertEntity(entity, nil, true); err != nil {
+		t.Fatal(err)
+	}
+
+	ent := &logical.TokenEntry{
+		ID:           "testtokenid",
+		Path:         "test",
+		Policies:     []string{"root"},
+		CreationTime: time.Now().Unix(),
+		EntityID:     entity.ID,
+		Meta:         map[string]string{"username": "githubuser"},
+	}
+	if err := core.tokenStore.create(context.Background(), ent); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var received *logical.Request
+	noop := &NoopBackend{
+		RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+			received = req
+			return nil, nil
+		},
+	}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.router.Mount(noop, "test/backend/", &MountEntry{Path: "test/backend/", Type: "noop", UUID: meUUID, Accessor: "noop-accessor"}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = core.HandleRequest(context.Background(), &logical.Request{
+		ClientToken: "testtokenid",
+		Operation:   logical.ReadOperation,
+		Path:        "test/backend/foo",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if received.EntityName != entity.Name || received.EntityMetadata["team"] != "storage" {
+		t.Fatalf("expected the entity to be passed through to the backend: %#v", received)
+	}
+	if received.TokenMetadata["username"] != "githubuser" {
+		t.Fatalf("expected the token metadata to be passed through to the backend: %#v", received.TokenMetadata)
+	}
+}
+
 func TestIdentityStore_CreateOrFetchEntity(t *testing.T) {
 	is, ghAccessor, _ := testIdentityStoreWithGithubAuth(t)
 	alias := &logical.Alias{
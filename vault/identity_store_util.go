@@ -1661,3 +1661,35 @@ func diffGroups(old, new []*identity.Group) *groupDiff {
 
 	return diff
 }
+
+// policyReferences returns the names of the entities and groups that are
+// directly assigned the policy, keyed by their IDs
+func (i *IdentityStore) policyReferences(policy string) (map[string]interface{}, map[string]interface{}, error) {
+	txn := i.db.Txn(false)
+
+	entities := map[string]interface{}{}
+	iter, err := txn.Get(entitiesTable, "id")
+	if err != nil {
+		return nil, nil, errwrap.Wrapf("failed to fetch iterator for entities in memdb: {{err}}", err)
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		entity := raw.(*identity.Entity)
+		if strutil.StrListContains(entity.Policies, policy) {
+			entities[entity.ID] = entity.Name
+		}
+	}
+
+	groups := map[string]interface{}{}
+	iter, err = txn.Get(groupsTable, "id")
+	if err != nil {
+		return nil, nil, errwrap.Wrapf("failed to fetch iterator for groups in memdb: {{err}}", err)
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		group := raw.(*identity.Group)
+		if strutil.StrListContains(group.Policies, policy) {
+			groups[group.ID] = group.Name
+		}
+	}
+
+	return entities, groups, nil
+}
@@ -0,0 +1,264 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// leaseCountQuotaSubPath is the sub-path of the system view the lease
+	// count quotas are stored under, keyed by name
+	leaseCountQuotaSubPath = "quotas/lease-count/"
+)
+
+// leaseCountQuota caps the number of live leases under a namespace or a
+// mount, or of the whole cluster if it has no path
+type leaseCountQuota struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	MaxLeases int    `json:"max_leases"`
+
+	// prefixes are the prefixes of the lease IDs the quota counts. The
+	// leases of a namespace include those of its auth methods, which are
+	// routed under the global auth prefix.
+	prefixes []string
+
+	// leases is the number of live leases counting against the quota, kept
+	// along with the pending leases of the expiration manager and protected
+	// by its pendingLock
+	leases int
+}
+
+// matches returns whether the lease counts against the quota
+func (q *leaseCountQuota) matches(leaseID string) bool {
+	if q.Path == "" {
+		return true
+	}
+	for _, prefix := range q.prefixes {
+		if strings.HasPrefix(leaseID, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// leaseCountQuotas holds the lease count quotas, by name
+type leaseCountQuotas struct {
+	l      sync.RWMutex
+	quotas map[string]*leaseCountQuota
+}
+
+// matching returns the quotas the lease counts against
+func (q *leaseCountQuotas) matching(leaseID string) []*leaseCountQuota {
+	if q == nil {
+		return nil
+	}
+
+	q.l.RLock()
+	defer q.l.RUnlock()
+
+	var ret []*leaseCountQuota
+	for _, quota := range q.quotas {
+		if quota.matches(leaseID) {
+			ret = append(ret, quota)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// countLease adds delta to the number of leases of the quotas the lease
+// counts against. The caller must hold the write lock on the pending leases
+// of the expiration manager.
+func (q *leaseCountQuotas) countLease(leaseID string, delta int) {
+	if q == nil {
+		return
+	}
+
+	q.l.RLock()
+	defer q.l.RUnlock()
+	for _, quota := range q.quotas {
+		if quota.matches(leaseID) {
+			quota.leases += delta
+		}
+	}
+}
+
+// resetCounts forgets the leases counting against the quotas. The caller
+// must hold the write lock on the pending leases of the expiration manager.
+func (q *leaseCountQuotas) resetCounts() {
+	if q == nil {
+		return
+	}
+
+	q.l.RLock()
+	defer q.l.RUnlock()
+	for _, quota := range q.quotas {
+		quota.leases = 0
+	}
+}
+
+func (q *leaseCountQuotas) get(name string) *leaseCountQuota {
+	q.l.RLock()
+	defer q.l.RUnlock()
+	return q.quotas[name]
+}
+
+func (q *leaseCountQuotas) set(quota *leaseCountQuota) {
+	q.l.Lock()
+	defer q.l.Unlock()
+	q.quotas[quota.Name] = quota
+}
+
+func (q *leaseCountQuotas) remove(name string) {
+	q.l.Lock()
+	defer q.l.Unlock()
+	delete(q.quotas, name)
+}
+
+// leaseCountQuotaPrefixes returns the prefixes of the lease IDs counting
+// against a quota on the path
+func (c *Core) leaseCountQuotaPrefixes(path string) []string {
+	if path == "" {
+		return nil
+	}
+	if ns := c.namespaceByPath(path); ns.Path == path {
+		return []string{path, credentialRoutePrefix + path}
+	}
+	return []string{path}
+}
+
+// validateLeaseCountQuotaPath checks that the path of a quota is that of a
+// namespace or a mount, and returns it with a trailing slash
+func (c *Core) validateLeaseCountQuotaPath(path string) (string, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "/")
+	if path == "" {
+		return "", nil
+	}
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	if ns := c.namespaceByPath(path); ns.Path == path {
+		return path, nil
+	}
+	if c.router.MatchingMount(path) == path {
+		return path, nil
+	}
+	return "", fmt.Errorf("no namespace or mount at %q", path)
+}
+
+// loadLeaseCountQuotas reads the lease count quotas from storage
+func (c *Core) loadLeaseCountQuotas(ctx context.Context) error {
+	view := c.systemBarrierView.SubView(leaseCountQuotaSubPath)
+	keys, err := view.List(ctx, "")
+	if err != nil {
+		return errwrap.Wrapf("failed to list lease count quotas: {{err}}", err)
+	}
+
+	quotas := &leaseCountQuotas{
+		quotas: make(map[string]*leaseCountQuota, len(keys)),
+	}
+	for _, key := range keys {
+		raw, err := view.Get(ctx, key)
+		if err != nil {
+			return errwrap.Wrapf("failed to read lease count quota: {{err}}", err)
+		}
+		if raw == nil {
+			continue
+		}
+		quota := new(leaseCountQuota)
+		if err := raw.DecodeJSON(quota); err != nil {
+			return errwrap.Wrapf("failed to decode lease count quota: {{err}}", err)
+		}
+		quota.prefixes = c.leaseCountQuotaPrefixes(quota.Path)
+		quotas.quotas[quota.Name] = quota
+	}
+
+	c.leaseCountQuotas = quotas
+	return nil
+}
+
+// setLeaseCountQuota sets a lease count quota. A quota on a new path counts
+// the live leases against it once, while no lease is registered or revoked;
+// the count is then kept as leases come and go.
+func (m *ExpirationManager) setLeaseCountQuota(quota *leaseCountQuota) {
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+
+	if existing := m.quotas.get(quota.Name); existing != nil && existing.Path == quota.Path {
+		quota.leases = existing.leases
+	} else {
+		quota.leases = 0
+		for leaseID := range m.pending {
+			if quota.matches(leaseID) {
+				quota.leases++
+			}
+		}
+	}
+	m.quotas.set(quota)
+}
+
+// leaseCount returns the number of live leases counting against the quota.
+// Leases the expiration manager has yet to restore after an unseal are not
+// counted.
+func (m *ExpirationManager) leaseCount(quota *leaseCountQuota) int {
+	m.pendingLock.RLock()
+	defer m.pendingLock.RUnlock()
+	return quota.leases
+}
+
+// checkLeaseCountQuotas returns an error if a quota a lease registered under
+// the path would count against is reached. The check and the registration of
+// the lease are not atomic, so concurrent requests may exceed a quota by the
+// number of leases they register at once.
+func (m *ExpirationManager) checkLeaseCountQuotas(path string) error {
+	quotas := m.quotas.matching(path)
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	m.pendingLock.RLock()
+	defer m.pendingLock.RUnlock()
+	for _, quota := range quotas {
+		if quota.leases < quota.MaxLeases {
+			continue
+		}
+		m.logger.Warn("lease count quota reached", "quota", quota.Name, "max_leases", quota.MaxLeases, "request_path", path)
+		return errwrap.Wrapf(fmt.Sprintf("lease count quota %q of %d leases reached: {{err}}", quota.Name, quota.MaxLeases), logical.ErrQuotaExceeded)
+	}
+	return nil
+}
+
+// checkRequestLeaseCountQuotas returns an error if the request could issue a
+// lease counting against a quota which is reached, so that the backend is not
+// asked for a secret which would then fail to be registered. Only the
+// operations which can issue leases are checked, leaving out the system
+// backend and the endpoints of the token store other than those creating
+// tokens, so that leases and tokens can still be revoked to make room.
+func (c *Core) checkRequestLeaseCountQuotas(entry *MountEntry, req *logical.Request) error {
+	switch req.Operation {
+	case logical.ReadOperation, logical.CreateOperation, logical.UpdateOperation:
+	default:
+		return nil
+	}
+	if entry == nil {
+		return nil
+	}
+	switch entry.Type {
+	case "system", "identity", "cubbyhole":
+		return nil
+	case "token":
+		if !strings.HasPrefix(c.namespaceTokenStorePath(req.Path), "auth/token/create") {
+			return nil
+		}
+	}
+	return c.expiration.checkLeaseCountQuotas(req.Path)
+}
@@ -0,0 +1,270 @@
+package vault
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestLeaseCountQuota(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := context.Background()
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			Path:        path,
+			Data:        data,
+			ClientToken: root,
+		})
+	}
+
+	// Quotas apply to namespaces or mounts only, and need a maximum
+	for _, data := range []map[string]interface{}{
+		{"path": "nope", "max_leases": 2},
+		{"path": "secret/test", "max_leases": 2},
+		{"path": "secret"},
+	} {
+		if resp, err := request(logical.UpdateOperation, "sys/quotas/lease-count/secret", data); err == nil || !resp.IsError() {
+			t.Fatalf("expected an error for %v, got %#v", data, resp)
+		}
+	}
+
+	if resp, err := request(logical.UpdateOperation, "sys/quotas/lease-count/secret", map[string]interface{}{
+		"path":       "secret",
+		"max_leases": 2,
+	}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+
+	if resp, err := request(logical.UpdateOperation, "secret/test", map[string]interface{}{
+		"foo":   "bar",
+		"lease": "1h",
+	}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+
+	var leaseIDs []string
+	for i := 0; i < 2; i++ {
+		resp, err := request(logical.ReadOperation, "secret/test", nil)
+		if err != nil || resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+			t.Fatalf("bad: %#v, %v", resp, err)
+		}
+		leaseIDs = append(leaseIDs, resp.Secret.LeaseID)
+	}
+
+	resp, err := request(logical.ReadOperation, "secret/test", nil)
+	if !errwrap.Contains(err, logical.ErrQuotaExceeded.Error()) || !resp.IsError() {
+		t.Fatalf("expected the quota to be exceeded, got %#v, %v", resp, err)
+	}
+
+	resp, err = request(logical.ReadOperation, "sys/quotas/lease-count/secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"name":       "secret",
+		"path":       "secret/",
+		"max_leases": 2,
+		"leases":     2,
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: expected %#v, got %#v", expected, resp.Data)
+	}
+
+	// Revoking a lease makes room for another
+	if err := c.expiration.Revoke(ctx, leaseIDs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := request(logical.ReadOperation, "secret/test", nil); err != nil || resp == nil || resp.Secret == nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+
+	// Raising the maximum keeps the path
+	if resp, err := request(logical.UpdateOperation, "sys/quotas/lease-count/secret", map[string]interface{}{
+		"max_leases": 3,
+	}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	if resp, err := request(logical.ReadOperation, "secret/test", nil); err != nil || resp == nil || resp.Secret == nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+
+	// Tokens count against the quotas of the token store
+	if resp, err := request(logical.UpdateOperation, "sys/quotas/lease-count/tokens", map[string]interface{}{
+		"path":       "auth/token",
+		"max_leases": 1,
+	}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	if resp, err := request(logical.UpdateOperation, "auth/token/create", map[string]interface{}{"ttl": "1h"}); err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	resp, err = request(logical.UpdateOperation, "auth/token/create", map[string]interface{}{"ttl": "1h"})
+	if !errwrap.Contains(err, logical.ErrQuotaExceeded.Error()) || !resp.IsError() {
+		t.Fatalf("expected the quota to be exceeded, got %#v, %v", resp, err)
+	}
+
+	// The leases within a namespace count against its quotas
+	if resp, err := request(logical.UpdateOperation, "sys/namespaces/team1", nil); err != nil || resp.IsError() {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	if resp, err := request(logical.UpdateOperation, "team1/sys/mounts/secret", map[string]interface{}{"type": "kv"}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	if resp, err := request(logical.UpdateOperation, "sys/quotas/lease-count/team1", map[string]interface{}{
+		"path":       "team1",
+		"max_leases": 1,
+	}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	if resp, err := request(logical.UpdateOperation, "team1/secret/test", map[string]interface{}{
+		"foo":   "bar",
+		"lease": "1h",
+	}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	if resp, err := request(logical.ReadOperation, "team1/secret/test", nil); err != nil || resp == nil || resp.Secret == nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	resp, err = request(logical.ReadOperation, "team1/secret/test", nil)
+	if !errwrap.Contains(err, logical.ErrQuotaExceeded.Error()) || !resp.IsError() {
+		t.Fatalf("expected the quota to be exceeded, got %#v, %v", resp, err)
+	}
+
+	resp, err = request(logical.ListOperation, "sys/quotas/lease-count", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := resp.Data["keys"].([]string); !reflect.DeepEqual(keys, []string{"secret", "team1", "tokens"}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+
+	// The quotas are loaded on unseal
+	if err := c.loadLeaseCountQuotas(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if quota := c.leaseCountQuotas.get("tokens"); quota == nil || quota.Path != "auth/token/" || quota.MaxLeases != 1 {
+		t.Fatalf("bad: %#v", quota)
+	}
+
+	if _, err := request(logical.DeleteOperation, "sys/quotas/lease-count/secret", nil); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := request(logical.ReadOperation, "sys/quotas/lease-count/secret", nil); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+}
+
+func TestLeaseCountQuota_CheckedBeforeRouting(t *testing.T) {
+	noop := &NoopBackend{
+		RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+			if req.Operation != logical.ReadOperation {
+				return nil, nil
+			}
+			return &logical.Response{
+				Secret: &logical.Secret{
+					LeaseOptions: logical.LeaseOptions{
+						TTL: time.Hour,
+					},
+				},
+				Data: map[string]interface{}{
+					"username": "foo",
+				},
+			}, nil
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+	ctx := context.Background()
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			Path:        path,
+			Data:        data,
+			ClientToken: root,
+		})
+	}
+	reads := func() int {
+		noop.Lock()
+		defer noop.Unlock()
+		var n int
+		for _, req := range noop.Requests {
+			if req.Operation == logical.ReadOperation {
+				n++
+			}
+		}
+		return n
+	}
+	leases := func() interface{} {
+		t.Helper()
+		resp, err := request(logical.ReadOperation, "sys/quotas/lease-count/foo", nil)
+		if err != nil || resp == nil {
+			t.Fatalf("bad: %#v, %v", resp, err)
+		}
+		return resp.Data["leases"]
+	}
+
+	if resp, err := request(logical.UpdateOperation, "sys/mounts/foo", map[string]interface{}{"type": "noop"}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	resp, err := request(logical.ReadOperation, "foo/creds/test", nil)
+	if err != nil || resp == nil || resp.Secret == nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	leaseID := resp.Secret.LeaseID
+
+	// A quota counts the leases which are already live
+	if resp, err := request(logical.UpdateOperation, "sys/quotas/lease-count/foo", map[string]interface{}{
+		"path":       "foo",
+		"max_leases": 1,
+	}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	if n := leases(); n != 1 {
+		t.Fatalf("expected 1 lease, got %v", n)
+	}
+
+	// The backend is not asked for a secret once the quota is reached
+	resp, err = request(logical.ReadOperation, "foo/creds/test", nil)
+	if !errwrap.Contains(err, logical.ErrQuotaExceeded.Error()) || !resp.IsError() {
+		t.Fatalf("expected the quota to be exceeded, got %#v, %v", resp, err)
+	}
+	if n := reads(); n != 1 {
+		t.Fatalf("expected the backend to be read once, got %d", n)
+	}
+
+	// The operations which cannot issue leases still reach the backend
+	if _, err := request(logical.DeleteOperation, "foo/creds/test", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Updating the maximum keeps the count, which follows the revocations
+	if resp, err := request(logical.UpdateOperation, "sys/quotas/lease-count/foo", map[string]interface{}{
+		"max_leases": 2,
+	}); err != nil || resp != nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	if n := leases(); n != 1 {
+		t.Fatalf("expected 1 lease, got %v", n)
+	}
+	if err := c.expiration.Revoke(ctx, leaseID); err != nil {
+		t.Fatal(err)
+	}
+	if n := leases(); n != 0 {
+		t.Fatalf("expected no lease, got %v", n)
+	}
+	if resp, err := request(logical.ReadOperation, "foo/creds/test", nil); err != nil || resp == nil || resp.Secret == nil {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	if n := reads(); n != 2 {
+		t.Fatalf("expected the backend to be read twice, got %d", n)
+	}
+}
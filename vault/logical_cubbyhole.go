@@ -82,6 +82,9 @@ func (b *CubbyholeBackend) handleRead(ctx context.Context, req *logical.Request,
 	if req.ClientToken == "" {
 		return nil, fmt.Errorf("client token empty")
 	}
+	if req.Path == "" {
+		return nil, fmt.Errorf("missing path")
+	}
 
 	// Read the path
 	out, err := req.Storage.Get(ctx, req.ClientToken+"/"+req.Path)
@@ -116,6 +119,10 @@ func (b *CubbyholeBackend) handleWrite(ctx context.Context, req *logical.Request
 	if len(req.Data) == 0 {
 		return nil, fmt.Errorf("missing data fields")
 	}
+	// A value at the root of the cubbyhole would be listed as an empty key
+	if req.Path == "" {
+		return nil, fmt.Errorf("missing path")
+	}
 
 	// JSON encode the data
 	buf, err := json.Marshal(req.Data)
@@ -142,6 +149,9 @@ func (b *CubbyholeBackend) handleDelete(ctx context.Context, req *logical.Reques
 	if req.ClientToken == "" {
 		return nil, fmt.Errorf("client token empty")
 	}
+	if req.Path == "" {
+		return nil, fmt.Errorf("missing path")
+	}
 	// Delete the key at the request path
 	if err := req.Storage.Delete(ctx, req.ClientToken+"/"+req.Path); err != nil {
 		return nil, err
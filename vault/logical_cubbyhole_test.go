@@ -153,6 +153,23 @@ func TestCubbyholeBackend_List(t *testing.T) {
 	}
 }
 
+func TestCubbyholeBackend_MissingPath(t *testing.T) {
+	b := testCubbyholeBackend()
+	clientToken, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, op := range []logical.Operation{logical.UpdateOperation, logical.ReadOperation, logical.DeleteOperation} {
+		req := logical.TestRequest(t, op, "")
+		req.ClientToken = clientToken
+		req.Data["raw"] = "test"
+		if _, err := b.HandleRequest(context.Background(), req); err == nil {
+			t.Fatalf("%s: expected an error without a path", op)
+		}
+	}
+}
+
 func TestCubbyholeIsolation(t *testing.T) {
 	b := testCubbyholeBackend()
 
@@ -208,14 +208,9 @@ func (b *PassthroughBackend) handleList(ctx context.Context, req *logical.Reques
 		path = path + "/"
 	}
 
-	// List the keys at the prefix given by the request
-	keys, err := req.Storage.List(ctx, path)
-	if err != nil {
-		return nil, err
-	}
-
-	// Generate the response
-	return logical.ListResponse(keys), nil
+	// List the keys at the prefix given by the request, a page at a time if
+	// requested
+	return logical.ListStorageResponse(ctx, req, path)
 }
 
 const passthroughHelp = `
@@ -196,6 +196,68 @@ func TestPassthroughBackend_List(t *testing.T) {
 	test(b)
 }
 
+func TestPassthroughBackend_ListPage(t *testing.T) {
+	test := func(b logical.Backend) {
+		storage := &logical.InmemStorage{}
+		for _, key := range []string{"foo/c", "foo/a", "foo/d/e", "foo/b"} {
+			req := logical.TestRequest(t, logical.UpdateOperation, key)
+			req.Data["raw"] = "test"
+			req.Storage = storage
+			if _, err := b.HandleRequest(context.Background(), req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		}
+
+		req := logical.TestRequest(t, logical.ListOperation, "foo/")
+		req.Storage = storage
+		req.Data["limit"] = "2"
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		expected := &logical.Response{
+			Data: map[string]interface{}{
+				"keys":         []string{"a", "b"},
+				"continuation": "b",
+			},
+		}
+		if !reflect.DeepEqual(resp, expected) {
+			t.Fatalf("bad response.\n\nexpected: %#v\n\nGot: %#v", expected, resp)
+		}
+
+		req = logical.TestRequest(t, logical.ListOperation, "foo/")
+		req.Storage = storage
+		req.Data["limit"] = "2"
+		req.Data["continuation"] = "b"
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		expected = &logical.Response{
+			Data: map[string]interface{}{
+				"keys": []string{"c", "d/"},
+			},
+		}
+		if !reflect.DeepEqual(resp, expected) {
+			t.Fatalf("bad response.\n\nexpected: %#v\n\nGot: %#v", expected, resp)
+		}
+
+		req = logical.TestRequest(t, logical.ListOperation, "foo/")
+		req.Storage = storage
+		req.Data["limit"] = "-1"
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != logical.ErrInvalidRequest {
+			t.Fatalf("expected invalid request, got: %v %#v", err, resp)
+		}
+	}
+	b := testPassthroughBackend()
+	test(b)
+	b = testPassthroughLeasedBackend()
+	test(b)
+}
+
 func TestPassthroughBackend_Revoke(t *testing.T) {
 	test := func(b logical.Backend) {
 		req := logical.TestRequest(t, logical.RevokeOperation, "kv")
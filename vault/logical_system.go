@@ -11,7 +11,9 @@ import (
 	"fmt"
 	"hash"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,6 +31,7 @@ import (
 	"github.com/hashicorp/vault/helper/wrapping"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/version"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -45,15 +48,138 @@ var (
 			&framework.Path{
 				Pattern: "replication/status",
 				Callbacks: map[logical.Operation]framework.OperationFunc{
-					logical.ReadOperation: func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-						resp := &logical.Response{
-							Data: map[string]interface{}{
-								"mode": "disabled",
-							},
-						}
-						return resp, nil
+					logical.ReadOperation: b.handleReplicationStatus,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-status"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-status"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/status",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleReplicationStatus,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-status"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-status"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/primary/enable",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleReplicationDRPrimaryEnable,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-primary-enable"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-primary-enable"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/primary/secondary-token",
+				Fields: map[string]*framework.FieldSchema{
+					"id": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "An identifier for the secondary.",
+					},
+					"ttl": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Default:     1800,
+						Description: "The TTL of the secondary activation token.",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleReplicationDRSecondaryToken,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-secondary-token"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-secondary-token"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/primary/revoke-secondary",
+				Fields: map[string]*framework.FieldSchema{
+					"id": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The identifier of the secondary to revoke.",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleReplicationDRRevokeSecondary,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-revoke-secondary"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-revoke-secondary"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/primary/fetch",
+				Fields: map[string]*framework.FieldSchema{
+					"id": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The identifier of the secondary.",
+					},
+					"secret": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The secret of the secondary.",
+					},
+					"epoch": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The epoch of the log the secondary is tailing.",
+					},
+					"index": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: "The index of the last update applied by the secondary.",
+					},
+					"keys": &framework.FieldSchema{
+						Type:        framework.TypeStringSlice,
+						Description: "Keys to fetch during a full sync.",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleReplicationDRFetch,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-fetch"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-fetch"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/secondary/enable",
+				Fields: map[string]*framework.FieldSchema{
+					"token": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The secondary activation token generated by the primary.",
+					},
+					"primary_api_addr": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The API address of the primary. Overrides the address in the activation token.",
+					},
+					"ca_file": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "Path to a PEM-encoded CA certificate file used to verify the primary's TLS certificate.",
 					},
+					"ca_path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "Path to a directory of PEM-encoded CA certificate files used to verify the primary's TLS certificate.",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleReplicationDRSecondaryEnable,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-secondary-enable"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-secondary-enable"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/secondary/promote",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleReplicationDRSecondaryPromote,
 				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-secondary-promote"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-secondary-promote"][1]),
 			},
 		}
 	}
@@ -78,7 +204,13 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				"raw/*",
 				"replication/primary/secondary-token",
 				"replication/reindex",
+				"replication/dr/primary/enable",
+				"replication/dr/primary/secondary-token",
+				"replication/dr/primary/revoke-secondary",
+				"replication/dr/secondary/enable",
+				"replication/dr/secondary/promote",
 				"rotate",
+				"rotate/seal",
 				"config/cors",
 				"config/auditing/*",
 				"config/ui/headers/*",
@@ -88,14 +220,33 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				"leases/revoke-prefix/*",
 				"leases/revoke-force/*",
 				"leases/lookup/*",
+				"root-tokens",
+				"root-tokens/",
+				"metrics",
+				"logs",
+				"monitor",
+				"host-info",
+				"internal/counters/*",
+				"storage/snapshot-schedule",
+				"storage/snapshot-schedule/*",
+				"storage/cleanup",
+				"storage/mount-export",
+				"storage/mount-import",
+				"storage/mount-import/*",
+				"pprof/*",
+				"quotas/*",
+				"sync/*",
 			},
 
 			Unauthenticated: []string{
 				"wrapping/lookup",
 				"wrapping/pubkey",
 				"replication/status",
+				"replication/dr/status",
+				"replication/dr/primary/fetch",
 				"internal/ui/mounts",
 				"internal/ui/mounts/*",
+				"internal/specs/openapi",
 			},
 		},
 
@@ -241,6 +392,63 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["capabilities_self"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "policies/usage/(?P<name>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"name": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "Name of the policy.",
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handlePoliciesUsage,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["policies_usage"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["policies_usage"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "policies/simulate$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"token": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "Token whose policies are evaluated. Defaults to the client token if no policies are given.",
+					},
+					"policies": &framework.FieldSchema{
+						Type:        framework.TypeCommaStringSlice,
+						Description: "Names of the stored ACL policies to evaluate in place of those of a token.",
+					},
+					"policy": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "Text of an ACL policy, which need not be stored, to evaluate along with the named policies.",
+					},
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "Path of the simulated request.",
+					},
+					"operation": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Default:     "update",
+						Description: "Operation of the simulated request: create, read, update, delete or list.",
+					},
+					"parameters": &framework.FieldSchema{
+						Type:        framework.TypeMap,
+						Description: "Parameters of the simulated request, checked against the allowed, denied and required parameters of the policies.",
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handlePoliciesSimulate,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["policies_simulate"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["policies_simulate"][1]),
+			},
+
 			&framework.Path{
 				Pattern:         "generate-root(/attempt)?$",
 				HelpSynopsis:    strings.TrimSpace(sysHelp["generate-root"][0]),
@@ -320,6 +528,50 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 						Type:        framework.TypeCommaStringSlice,
 						Description: strings.TrimSpace(sysHelp["passthrough_request_headers"][0]),
 					},
+					"token_type": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["token_type"][0]),
+					},
+					"max_in_flight_requests": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["max_in_flight_requests"][0]),
+					},
+					"max_queued_requests": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["max_queued_requests"][0]),
+					},
+					"default_renewal_increment": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Description: strings.TrimSpace(sysHelp["default_renewal_increment"][0]),
+					},
+					"max_renewal_increment": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Description: strings.TrimSpace(sysHelp["max_renewal_increment"][0]),
+					},
+					"renewal_grace_period": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Description: strings.TrimSpace(sysHelp["renewal_grace_period"][0]),
+					},
+					"outbound_proxy_url": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["outbound_proxy_url"][0]),
+					},
+					"outbound_ca_cert": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["outbound_ca_cert"][0]),
+					},
+					"lockout_threshold": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["lockout_threshold"][0]),
+					},
+					"lockout_ip_threshold": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["lockout_ip_threshold"][0]),
+					},
+					"lockout_duration": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Description: strings.TrimSpace(sysHelp["lockout_duration"][0]),
+					},
 				},
 				Callbacks: map[logical.Operation]framework.OperationFunc{
 					logical.ReadOperation:   b.handleAuthTuneRead,
@@ -329,6 +581,50 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["auth_tune"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "auth/(?P<path>.+?)/lockouts$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_tune"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleAuthLockoutsRead,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["auth_lockouts"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["auth_lockouts"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "auth/(?P<path>.+?)/unlock$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_tune"][0]),
+					},
+					"user": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_unlock_user"][0]),
+					},
+					"remote_address": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_unlock_remote_address"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleAuthUnlock,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["auth_unlock"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["auth_unlock"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "mounts/(?P<path>.+?)/tune$",
 
@@ -369,6 +665,50 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 						Type:        framework.TypeCommaStringSlice,
 						Description: strings.TrimSpace(sysHelp["passthrough_request_headers"][0]),
 					},
+					"token_type": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["token_type"][0]),
+					},
+					"max_in_flight_requests": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["max_in_flight_requests"][0]),
+					},
+					"max_queued_requests": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["max_queued_requests"][0]),
+					},
+					"default_renewal_increment": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Description: strings.TrimSpace(sysHelp["default_renewal_increment"][0]),
+					},
+					"max_renewal_increment": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Description: strings.TrimSpace(sysHelp["max_renewal_increment"][0]),
+					},
+					"renewal_grace_period": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Description: strings.TrimSpace(sysHelp["renewal_grace_period"][0]),
+					},
+					"outbound_proxy_url": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["outbound_proxy_url"][0]),
+					},
+					"outbound_ca_cert": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["outbound_ca_cert"][0]),
+					},
+					"lockout_threshold": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["lockout_threshold"][0]),
+					},
+					"lockout_ip_threshold": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["lockout_ip_threshold"][0]),
+					},
+					"lockout_duration": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Description: strings.TrimSpace(sysHelp["lockout_duration"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -380,6 +720,24 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["mount_tune"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "mounts/(?P<path>.+?)/restore$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["mount_path"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleMountRestore,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["mount_restore"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["mount_restore"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "mounts/(?P<path>.+?)",
 
@@ -418,6 +776,11 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 						Type:        framework.TypeKVPairs,
 						Description: strings.TrimSpace(sysHelp["mount_options"][0]),
 					},
+					"force": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Default:     false,
+						Description: strings.TrimSpace(sysHelp["mount_force"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -462,6 +825,17 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["remount"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "root-tokens/?$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handleRootTokensList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["root-tokens"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["root-tokens"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "leases/lookup/(?P<prefix>.+?)?",
 
@@ -604,39 +978,112 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 			},
 
 			&framework.Path{
-				Pattern: "auth$",
+				Pattern: "storage/cleanup$",
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
-					logical.ReadOperation: b.handleAuthTable,
+					logical.ReadOperation:   b.handleStorageCleanup,
+					logical.UpdateOperation: b.handleStorageCleanup,
 				},
 
-				HelpSynopsis:    strings.TrimSpace(sysHelp["auth-table"][0]),
-				HelpDescription: strings.TrimSpace(sysHelp["auth-table"][1]),
+				HelpSynopsis:    strings.TrimSpace(sysHelp["storage_cleanup"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["storage_cleanup"][1]),
 			},
 
 			&framework.Path{
-				Pattern: "auth/(?P<path>.+)",
+				Pattern: "version-history/?$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handleVersionHistoryList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["version_history"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["version_history"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "deprecations$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleDeprecationsRead,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["deprecations"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["deprecations"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "quotas/lease-count/?$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handleLeaseCountQuotasList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["lease-count-quota-list"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["lease-count-quota-list"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "quotas/lease-count/(?P<name>.+)",
 
 				Fields: map[string]*framework.FieldSchema{
-					"path": &framework.FieldSchema{
-						Type:        framework.TypeString,
-						Description: strings.TrimSpace(sysHelp["auth_path"][0]),
-					},
-					"type": &framework.FieldSchema{
+					"name": &framework.FieldSchema{
 						Type:        framework.TypeString,
-						Description: strings.TrimSpace(sysHelp["auth_type"][0]),
+						Description: strings.TrimSpace(sysHelp["lease-count-quota-name"][0]),
 					},
-					"description": &framework.FieldSchema{
+					"path": &framework.FieldSchema{
 						Type:        framework.TypeString,
-						Description: strings.TrimSpace(sysHelp["auth_desc"][0]),
+						Description: strings.TrimSpace(sysHelp["lease-count-quota-path"][0]),
 					},
-					"config": &framework.FieldSchema{
-						Type:        framework.TypeMap,
-						Description: strings.TrimSpace(sysHelp["auth_config"][0]),
+					"max_leases": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["lease-count-quota-max-leases"][0]),
 					},
-					"local": &framework.FieldSchema{
-						Type:        framework.TypeBool,
-						Default:     false,
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.handleLeaseCountQuotasRead,
+					logical.UpdateOperation: b.handleLeaseCountQuotasSet,
+					logical.DeleteOperation: b.handleLeaseCountQuotasDelete,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["lease-count-quota"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["lease-count-quota"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "auth$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleAuthTable,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["auth-table"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["auth-table"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "auth/(?P<path>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_path"][0]),
+					},
+					"type": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_type"][0]),
+					},
+					"description": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_desc"][0]),
+					},
+					"config": &framework.FieldSchema{
+						Type:        framework.TypeMap,
+						Description: strings.TrimSpace(sysHelp["auth_config"][0]),
+					},
+					"local": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Default:     false,
 						Description: strings.TrimSpace(sysHelp["mount_local"][0]),
 					},
 					"seal_wrap": &framework.FieldSchema{
@@ -738,6 +1185,90 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["policy"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "policies/password/?$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handlePasswordPoliciesList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["password-policy-list"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["password-policy-list"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "policies/password/(?P<name>.+)/generate$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"name": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["password-policy-name"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handlePasswordPoliciesGenerate,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["password-policy-generate"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["password-policy-generate"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "policies/password/(?P<name>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"name": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["password-policy-name"][0]),
+					},
+					"policy": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["password-policy-rules"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.handlePasswordPoliciesRead,
+					logical.UpdateOperation: b.handlePasswordPoliciesSet,
+					logical.DeleteOperation: b.handlePasswordPoliciesDelete,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["password-policy"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["password-policy"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "namespaces/?$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handleNamespacesList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["namespaces"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["namespaces"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "namespaces/(?P<path>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["namespace-path"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.handleNamespacesRead,
+					logical.UpdateOperation: b.handleNamespacesCreate,
+					logical.DeleteOperation: b.handleNamespacesDelete,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["namespace"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["namespace"][1]),
+			},
+
 			&framework.Path{
 				Pattern:         "seal-status$",
 				HelpSynopsis:    strings.TrimSpace(sysHelp["seal-status"][0]),
@@ -847,6 +1378,17 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["rotate"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "rotate/seal$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleRotateSeal,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["rotate-seal"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["rotate-seal"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "wrapping/wrap$",
 
@@ -1103,6 +1645,134 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-ui-mounts"][0]),
 				HelpDescription: strings.TrimSpace(sysHelp["internal-ui-mounts"][1]),
 			},
+			&framework.Path{
+				Pattern: "internal/counters/tokens$",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleCountersRead("tokens"),
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-counters-tokens"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["internal-counters-tokens"][1]),
+			},
+			&framework.Path{
+				Pattern: "internal/counters/entities$",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleCountersRead("entities"),
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-counters-entities"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["internal-counters-entities"][1]),
+			},
+			&framework.Path{
+				Pattern: "internal/counters/leases$",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleCountersRead("leases"),
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-counters-leases"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["internal-counters-leases"][1]),
+			},
+			&framework.Path{
+				Pattern: "internal/counters/activity$",
+				Fields: map[string]*framework.FieldSchema{
+					"start_time": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["internal-counters-activity-start-time"][0]),
+					},
+					"end_time": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["internal-counters-activity-end-time"][0]),
+					},
+					"namespace": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["internal-counters-activity-namespace"][0]),
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleActivityRead,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-counters-activity"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["internal-counters-activity"][1]),
+			},
+			&framework.Path{
+				Pattern: "storage/snapshot-schedule$",
+				Fields: map[string]*framework.FieldSchema{
+					"interval": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Description: strings.TrimSpace(sysHelp["snapshot-schedule-interval"][0]),
+					},
+					"retain": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Default:     1,
+						Description: strings.TrimSpace(sysHelp["snapshot-schedule-retain"][0]),
+					},
+					"file_prefix": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Default:     "vault-snapshot",
+						Description: strings.TrimSpace(sysHelp["snapshot-schedule-file-prefix"][0]),
+					},
+					"storage_type": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["snapshot-schedule-storage-type"][0]),
+					},
+					"local_path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["snapshot-schedule-local-path"][0]),
+					},
+					"path_prefix": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["snapshot-schedule-path-prefix"][0]),
+					},
+					"aws_s3_bucket": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The S3 bucket of the aws-s3 storage type.",
+					},
+					"aws_s3_region": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: `The region of the S3 bucket. Defaults to "us-east-1".`,
+					},
+					"aws_s3_endpoint": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The endpoint of an S3-compatible service.",
+					},
+					"aws_access_key_id": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The AWS access key ID. Defaults to the credentials of the environment.",
+					},
+					"aws_secret_access_key": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The AWS secret access key. Defaults to the credentials of the environment.",
+					},
+					"google_gcs_bucket": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The GCS bucket of the google-gcs storage type.",
+					},
+					"google_credentials_file": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The path on the server of a service account key file. Defaults to the application default credentials.",
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.handleSnapshotScheduleRead,
+					logical.UpdateOperation: b.handleSnapshotScheduleUpdate,
+					logical.DeleteOperation: b.handleSnapshotScheduleDelete,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["snapshot-schedule"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["snapshot-schedule"][1]),
+			},
+			&framework.Path{
+				Pattern: "storage/snapshot-schedule/status$",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleSnapshotScheduleStatus,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["snapshot-schedule-status"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["snapshot-schedule-status"][1]),
+			},
+			&framework.Path{
+				Pattern: "internal/specs/openapi",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.pathInternalOpenAPI,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-specs-openapi"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["internal-specs-openapi"][1]),
+			},
 			&framework.Path{
 				Pattern: "internal/ui/resultant-acl",
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -1111,33 +1781,80 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-ui-resultant-acl"][0]),
 				HelpDescription: strings.TrimSpace(sysHelp["internal-ui-resultant-acl"][1]),
 			},
+			&framework.Path{
+				Pattern: "metrics$",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleMetrics,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["metrics"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["metrics"][1]),
+			},
+			&framework.Path{
+				Pattern: "logs$",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleLogs,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["logs"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["logs"][1]),
+			},
+			&framework.Path{
+				Pattern: "monitor$",
+				Fields: map[string]*framework.FieldSchema{
+					"log_level": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Default:     "info",
+						Description: strings.TrimSpace(sysHelp["monitor-log-level"][0]),
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleMonitor,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["monitor"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["monitor"][1]),
+			},
+			&framework.Path{
+				Pattern: "events/subscribe/(?P<topic>.*)",
+				Fields: map[string]*framework.FieldSchema{
+					"topic": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["events-subscribe-topic"][0]),
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleEventsSubscribe,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["events-subscribe"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["events-subscribe"][1]),
+			},
+			&framework.Path{
+				Pattern: "host-info$",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleHostInfo,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["host-info"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["host-info"][1]),
+			},
+			&framework.Path{
+				Pattern: "ha-status$",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleHAStatus,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["ha-status"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["ha-status"][1]),
+			},
 		},
 	}
 
 	b.Backend.Paths = append(b.Backend.Paths, replicationPaths(b)...)
+	b.Backend.Paths = append(b.Backend.Paths, b.secretsSyncPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.mountTransferPaths()...)
 
 	if core.rawEnabled {
-		b.Backend.Paths = append(b.Backend.Paths, &framework.Path{
-			Pattern: "(raw/?$|raw/(?P<path>.+))",
-
-			Fields: map[string]*framework.FieldSchema{
-				"path": &framework.FieldSchema{
-					Type: framework.TypeString,
-				},
-				"value": &framework.FieldSchema{
-					Type: framework.TypeString,
-				},
-			},
+		b.Backend.Paths = append(b.Backend.Paths, b.rawPaths()...)
+	}
 
-			Callbacks: map[logical.Operation]framework.OperationFunc{
-				logical.ReadOperation:   b.handleRawRead,
-				logical.UpdateOperation: b.handleRawWrite,
-				logical.DeleteOperation: b.handleRawDelete,
-				logical.ListOperation:   b.handleRawList,
-			},
-			HelpSynopsis:    strings.TrimSpace(sysHelp["raw"][0]),
-			HelpDescription: strings.TrimSpace(sysHelp["raw"][1]),
-		})
+	if !core.pprofDisabled {
+		b.Backend.Paths = append(b.Backend.Paths, b.pprofPaths()...)
 	}
 
 	b.Backend.Invalidate = b.invalidate
@@ -1242,10 +1959,10 @@ func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, req *logi
 		return logical.ErrorResponse("missing plugin name"), nil
 	}
 
-	sha256 := d.Get("sha256").(string)
-	if sha256 == "" {
-		sha256 = d.Get("sha_256").(string)
-		if sha256 == "" {
+	sha256Str := d.Get("sha256").(string)
+	if sha256Str == "" {
+		sha256Str = d.Get("sha_256").(string)
+		if sha256Str == "" {
 			return logical.ErrorResponse("missing SHA-256 value"), nil
 		}
 	}
@@ -1267,10 +1984,13 @@ func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, req *logi
 		args = parts[1:]
 	}
 
-	sha256Bytes, err := hex.DecodeString(sha256)
+	sha256Bytes, err := hex.DecodeString(sha256Str)
 	if err != nil {
 		return logical.ErrorResponse("Could not decode SHA-256 value from Hex"), err
 	}
+	if len(sha256Bytes) != sha256.Size {
+		return logical.ErrorResponse(fmt.Sprintf("SHA-256 value must be %d bytes, got %d", sha256.Size, len(sha256Bytes))), logical.ErrInvalidRequest
+	}
 
 	err = b.Core.pluginCatalog.Set(ctx, pluginName, parts[0], args, sha256Bytes)
 	if err != nil {
@@ -1463,8 +2183,9 @@ func (b *SystemBackend) handleCapabilities(ctx context.Context, req *logical.Req
 		return logical.ErrorResponse("paths must be supplied"), nil
 	}
 
+	ns := b.requestNamespace(req)
 	for _, path := range paths {
-		pathCap, err := b.Core.Capabilities(ctx, token, path)
+		pathCap, err := b.Core.Capabilities(ctx, token, namespaceAPIPath(ns, path))
 		if err != nil {
 			if !strings.HasSuffix(req.Path, "capabilities-self") && errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
 				return nil, &logical.StatusBadRequest{Err: "invalid token"}
@@ -1565,6 +2286,9 @@ func mountInfo(entry *MountEntry) map[string]interface{} {
 		"seal_wrap":   entry.SealWrap,
 		"options":     entry.Options,
 	}
+	if _, deprecated := builtinDeprecation(entry); deprecated {
+		info["deprecation_status"] = deprecationStatusDeprecated
+	}
 	entryConfig := map[string]interface{}{
 		"default_lease_ttl": int64(entry.Config.DefaultLeaseTTL.Seconds()),
 		"max_lease_ttl":     int64(entry.Config.MaxLeaseTTL.Seconds()),
@@ -1585,9 +2309,19 @@ func mountInfo(entry *MountEntry) map[string]interface{} {
 	if rawVal, ok := entry.synthesizedConfigCache.Load("passthrough_request_headers"); ok {
 		entryConfig["passthrough_request_headers"] = rawVal.([]string)
 	}
+	if entry.Config.MaxInFlightRequests > 0 {
+		entryConfig["max_in_flight_requests"] = entry.Config.MaxInFlightRequests
+		entryConfig["max_queued_requests"] = entry.Config.MaxQueuedRequests
+	}
+	addRenewalSettings(entryConfig, entry.Config)
+	addOutboundSettings(entryConfig, entry.Config)
 
 	info["config"] = entryConfig
 
+	if entry.DeletionTime != 0 {
+		info["deletion_time"] = time.Unix(entry.DeletionTime, 0).Format(time.RFC3339)
+	}
+
 	return info
 }
 
@@ -1600,10 +2334,15 @@ func (b *SystemBackend) handleMountTable(ctx context.Context, req *logical.Reque
 		Data: make(map[string]interface{}),
 	}
 
+	ns := b.requestNamespace(req)
 	for _, entry := range b.Core.mounts.Entries {
+		if entry.NamespaceID != ns.ID {
+			continue
+		}
+
 		// Populate mount info
 		info := mountInfo(entry)
-		resp.Data[entry.Path] = info
+		resp.Data[strings.TrimPrefix(entry.Path, ns.Path)] = info
 	}
 
 	return resp, nil
@@ -1619,8 +2358,9 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 	}
 
 	// Get all the options
+	ns := b.requestNamespace(req)
 	path := data.Get("path").(string)
-	path = sanitizeMountPath(path)
+	path = ns.Path + sanitizeMountPath(path)
 
 	logicalType := data.Get("type").(string)
 	description := data.Get("description").(string)
@@ -1746,6 +2486,26 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 		config.PassthroughRequestHeaders = apiConfig.PassthroughRequestHeaders
 	}
 
+	if apiConfig.TokenType != "" {
+		return logical.ErrorResponse("token_type can only be set on auth methods"), logical.ErrInvalidRequest
+	}
+	if apiConfig.LockoutThreshold != 0 || apiConfig.LockoutIPThreshold != 0 || apiConfig.LockoutDuration != "" {
+		return logical.ErrorResponse("lockout settings can only be set on auth methods"), logical.ErrInvalidRequest
+	}
+
+	if err := checkRequestLimits(apiConfig.MaxInFlightRequests, apiConfig.MaxQueuedRequests); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	config.MaxInFlightRequests = apiConfig.MaxInFlightRequests
+	config.MaxQueuedRequests = apiConfig.MaxQueuedRequests
+
+	if err := parseRenewalSettings(apiConfig, &config); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if err := parseOutboundSettings(apiConfig, &config); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	// Create the mount entry
 	me := &MountEntry{
 		Table:       mountTableType,
@@ -1756,6 +2516,7 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 		Local:       local,
 		SealWrap:    sealWrap,
 		Options:     options,
+		NamespaceID: ns.ID,
 	}
 
 	// Attempt mount
@@ -1764,6 +2525,11 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 		return handleError(err)
 	}
 
+	if warning := deprecationWarning(me); warning != "" {
+		resp := &logical.Response{}
+		resp.AddWarning(warning)
+		return resp, nil
+	}
 	return nil, nil
 }
 
@@ -1798,8 +2564,9 @@ func handleErrorNoReadOnlyForward(
 
 // handleUnmount is used to unmount a path
 func (b *SystemBackend) handleUnmount(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.requestNamespace(req)
 	path := data.Get("path").(string)
-	path = sanitizeMountPath(path)
+	path = ns.Path + sanitizeMountPath(path)
 
 	repState := b.Core.ReplicationState()
 	entry := b.Core.router.MatchingMountEntry(path)
@@ -1810,10 +2577,25 @@ func (b *SystemBackend) handleUnmount(ctx context.Context, req *logical.Request,
 	// We return success when the mount does not exists to not expose if the
 	// mount existed or not
 	match := b.Core.router.MatchingMount(path)
-	if match == "" || path != match {
+	if match == "" || path != match || entry.NamespaceID != ns.ID {
 		return nil, nil
 	}
 
+	// Keep the mount around for the grace period unless deleting it right away
+	// is forced
+	if b.Core.mountDeletionGracePeriod > 0 && !data.Get("force").(bool) {
+		deletionTime, err := b.Core.scheduleUnmount(ctx, path)
+		if err != nil {
+			b.Backend.Logger().Error("unmount failed", "path", path, "error", err)
+			return handleError(err)
+		}
+
+		resp := &logical.Response{}
+		resp.AddWarning(fmt.Sprintf("The mount is disabled and will be deleted after %s. Until then it can be restored at sys/mounts/%s/restore.",
+			deletionTime.Format(time.RFC3339), strings.TrimSuffix(strings.TrimPrefix(path, ns.Path), "/")))
+		return resp, nil
+	}
+
 	// Attempt unmount
 	if err := b.Core.unmount(ctx, path); err != nil {
 		b.Backend.Logger().Error("unmount failed", "path", path, "error", err)
@@ -1823,12 +2605,31 @@ func (b *SystemBackend) handleUnmount(ctx context.Context, req *logical.Request,
 	return nil, nil
 }
 
-// handleRemount is used to remount a path
-func (b *SystemBackend) handleRemount(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	repState := b.Core.ReplicationState()
+// handleMountRestore is used to cancel the pending deletion of a mount
+func (b *SystemBackend) handleMountRestore(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.requestNamespace(req)
+	path := data.Get("path").(string)
+	path = ns.Path + sanitizeMountPath(path)
 
-	// Get the paths
-	fromPath := data.Get("from").(string)
+	entry := b.Core.router.MatchingMountEntry(path)
+	if entry == nil || entry.NamespaceID != ns.ID {
+		return logical.ErrorResponse(fmt.Sprintf("no mount at %q", strings.TrimPrefix(path, ns.Path))), logical.ErrInvalidRequest
+	}
+
+	if err := b.Core.restoreMount(ctx, path); err != nil {
+		b.Backend.Logger().Error("restore failed", "path", path, "error", err)
+		return handleError(err)
+	}
+
+	return nil, nil
+}
+
+// handleRemount is used to remount a path
+func (b *SystemBackend) handleRemount(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	repState := b.Core.ReplicationState()
+
+	// Get the paths
+	fromPath := data.Get("from").(string)
 	toPath := data.Get("to").(string)
 	if fromPath == "" || toPath == "" {
 		return logical.ErrorResponse(
@@ -1850,7 +2651,27 @@ func (b *SystemBackend) handleRemount(ctx context.Context, req *logical.Request,
 		return handleError(err)
 	}
 
-	return nil, nil
+	// Policies are not rewritten, so report the ones that still grant access
+	// through the old path
+	policies, err := b.Core.policyStore.policiesReferencingPrefix(ctx, fromPath)
+	if err != nil {
+		b.Backend.Logger().Warn("failed to look up policies referencing the old path", "from_path", fromPath, "error", err)
+		resp := &logical.Response{}
+		resp.AddWarning(fmt.Sprintf("Failed to look up the policies referencing %q: %v", fromPath, err))
+		return resp, nil
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"referencing_policies": policies,
+		},
+	}
+	resp.AddWarning(fmt.Sprintf("The following policies have rules for %q and may need to be updated to use %q: %s",
+		fromPath, toPath, strings.Join(policies, ", ")))
+	return resp, nil
 }
 
 // handleAuthTuneRead is used to get config settings on a auth path
@@ -1861,7 +2682,7 @@ func (b *SystemBackend) handleAuthTuneRead(ctx context.Context, req *logical.Req
 				"path must be specified as a string"),
 			logical.ErrInvalidRequest
 	}
-	return b.handleTuneReadCommon("auth/" + path)
+	return b.handleTuneReadCommon(b.requestNamespace(req), "auth/"+path)
 }
 
 // handleMountTuneRead is used to get config settings on a backend
@@ -1876,12 +2697,13 @@ func (b *SystemBackend) handleMountTuneRead(ctx context.Context, req *logical.Re
 	// This call will read both logical backend's configuration as well as auth methods'.
 	// Retaining this behavior for backward compatibility. If this behavior is not desired,
 	// an error can be returned if path has a prefix of "auth/".
-	return b.handleTuneReadCommon(path)
+	return b.handleTuneReadCommon(b.requestNamespace(req), path)
 }
 
-// handleTuneReadCommon returns the config settings of a path
-func (b *SystemBackend) handleTuneReadCommon(path string) (*logical.Response, error) {
-	path = sanitizeMountPath(path)
+// handleTuneReadCommon returns the config settings of a path within a
+// namespace
+func (b *SystemBackend) handleTuneReadCommon(ns *Namespace, path string) (*logical.Response, error) {
+	path = namespaceAPIPath(ns, sanitizeMountPath(path))
 
 	sysView := b.Core.router.MatchingSystemView(path)
 	if sysView == nil {
@@ -1890,7 +2712,7 @@ func (b *SystemBackend) handleTuneReadCommon(path string) (*logical.Response, er
 	}
 
 	mountEntry := b.Core.router.MatchingMountEntry(path)
-	if mountEntry == nil {
+	if mountEntry == nil || mountEntry.NamespaceID != ns.ID {
 		b.Backend.Logger().Error("cannot fetch mount entry", "path", path)
 		return handleError(fmt.Errorf("sys: cannot fetch mount entry for path %q", path))
 	}
@@ -1919,6 +2741,21 @@ func (b *SystemBackend) handleTuneReadCommon(path string) (*logical.Response, er
 		resp.Data["passthrough_request_headers"] = rawVal.([]string)
 	}
 
+	if mountEntry.Table == credentialTableType && mountEntry.Config.TokenType != logical.TokenTypeDefault {
+		resp.Data["token_type"] = mountEntry.Config.TokenType.String()
+	}
+
+	if mountEntry.Config.MaxInFlightRequests > 0 {
+		resp.Data["max_in_flight_requests"] = mountEntry.Config.MaxInFlightRequests
+		resp.Data["max_queued_requests"] = mountEntry.Config.MaxQueuedRequests
+	}
+
+	addRenewalSettings(resp.Data, mountEntry.Config)
+	addOutboundSettings(resp.Data, mountEntry.Config)
+	if mountEntry.Table == credentialTableType {
+		addLockoutSettings(resp.Data, mountEntry.Config)
+	}
+
 	if len(mountEntry.Options) > 0 {
 		resp.Data["options"] = mountEntry.Options
 	}
@@ -1933,7 +2770,7 @@ func (b *SystemBackend) handleAuthTuneWrite(ctx context.Context, req *logical.Re
 		return logical.ErrorResponse("path must be specified as a string"),
 			logical.ErrInvalidRequest
 	}
-	return b.handleTuneWriteCommon(ctx, "auth/"+path, data)
+	return b.handleTuneWriteCommon(ctx, b.requestNamespace(req), "auth/"+path, data)
 }
 
 // handleMountTuneWrite is used to set config settings on a backend
@@ -1946,25 +2783,28 @@ func (b *SystemBackend) handleMountTuneWrite(ctx context.Context, req *logical.R
 	// This call will write both logical backend's configuration as well as auth methods'.
 	// Retaining this behavior for backward compatibility. If this behavior is not desired,
 	// an error can be returned if path has a prefix of "auth/".
-	return b.handleTuneWriteCommon(ctx, path, data)
+	return b.handleTuneWriteCommon(ctx, b.requestNamespace(req), path, data)
 }
 
-// handleTuneWriteCommon is used to set config settings on a path
-func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string, data *framework.FieldData) (*logical.Response, error) {
+// handleTuneWriteCommon is used to set config settings on a path within a
+// namespace
+func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, ns *Namespace, path string, data *framework.FieldData) (*logical.Response, error) {
 	repState := b.Core.ReplicationState()
 
 	path = sanitizeMountPath(path)
 
-	// Prevent protected paths from being changed
+	// Prevent protected paths from being changed. The token store is shared
+	// by all namespaces, so it can only be tuned from the root namespace.
 	for _, p := range untunableMounts {
-		if strings.HasPrefix(path, p) {
+		if strings.HasPrefix(path, p) || (ns.ID != "" && path == "auth/token/") {
 			b.Backend.Logger().Error("cannot tune this mount", "path", path)
 			return handleError(fmt.Errorf("cannot tune %q", path))
 		}
 	}
+	path = namespaceAPIPath(ns, path)
 
 	mountEntry := b.Core.router.MatchingMountEntry(path)
-	if mountEntry == nil {
+	if mountEntry == nil || mountEntry.NamespaceID != ns.ID {
 		b.Backend.Logger().Error("tune failed: no mount entry found", "path", path)
 		return handleError(fmt.Errorf("tune of path %q failed: no mount entry found", path))
 	}
@@ -2164,6 +3004,195 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 		}
 	}
 
+	if rawVal, ok := data.GetOk("token_type"); ok {
+		if !strings.HasPrefix(path, credentialRoutePrefix) || mountEntry.Type == "token" {
+			return logical.ErrorResponse("token_type can only be set on auth methods other than the token store"), logical.ErrInvalidRequest
+		}
+		tokenType, err := logical.ParseTokenType(rawVal.(string))
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid token_type: %v", err)), logical.ErrInvalidRequest
+		}
+
+		oldVal := mountEntry.Config.TokenType
+		mountEntry.Config.TokenType = tokenType
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+			mountEntry.Config.TokenType = oldVal
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of token_type successful", "path", path, "token_type", tokenType.String())
+		}
+	}
+
+	rawInFlight, inFlightOk := data.GetOk("max_in_flight_requests")
+	rawQueued, queuedOk := data.GetOk("max_queued_requests")
+	if inFlightOk || queuedOk {
+		maxInFlight := mountEntry.Config.MaxInFlightRequests
+		if inFlightOk {
+			maxInFlight = rawInFlight.(int)
+		}
+		maxQueued := mountEntry.Config.MaxQueuedRequests
+		if queuedOk {
+			maxQueued = rawQueued.(int)
+		}
+		if err := checkRequestLimits(maxInFlight, maxQueued); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		oldInFlight, oldQueued := mountEntry.Config.MaxInFlightRequests, mountEntry.Config.MaxQueuedRequests
+		mountEntry.Config.MaxInFlightRequests = maxInFlight
+		mountEntry.Config.MaxQueuedRequests = maxQueued
+
+		// Update the mount table
+		var err error
+		switch {
+		case strings.HasPrefix(path, credentialRoutePrefix):
+			err = b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local)
+		default:
+			err = b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local)
+		}
+		if err != nil {
+			mountEntry.Config.MaxInFlightRequests = oldInFlight
+			mountEntry.Config.MaxQueuedRequests = oldQueued
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of request limits successful", "path", path, "max_in_flight_requests", maxInFlight, "max_queued_requests", maxQueued)
+		}
+	}
+
+	rawDefaultIncrement, defaultIncrementOk := data.GetOk("default_renewal_increment")
+	rawMaxIncrement, maxIncrementOk := data.GetOk("max_renewal_increment")
+	rawGracePeriod, gracePeriodOk := data.GetOk("renewal_grace_period")
+	if defaultIncrementOk || maxIncrementOk || gracePeriodOk {
+		settings := renewalSettings{
+			defaultIncrement: mountEntry.Config.DefaultRenewalIncrement,
+			maxIncrement:     mountEntry.Config.MaxRenewalIncrement,
+			gracePeriod:      mountEntry.Config.RenewalGracePeriod,
+		}
+		if defaultIncrementOk {
+			settings.defaultIncrement = time.Duration(rawDefaultIncrement.(int)) * time.Second
+		}
+		if maxIncrementOk {
+			settings.maxIncrement = time.Duration(rawMaxIncrement.(int)) * time.Second
+		}
+		if gracePeriodOk {
+			settings.gracePeriod = time.Duration(rawGracePeriod.(int)) * time.Second
+		}
+		if err := settings.validate(); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		oldDefaultIncrement, oldMaxIncrement, oldGracePeriod := mountEntry.Config.DefaultRenewalIncrement, mountEntry.Config.MaxRenewalIncrement, mountEntry.Config.RenewalGracePeriod
+		mountEntry.Config.DefaultRenewalIncrement = settings.defaultIncrement
+		mountEntry.Config.MaxRenewalIncrement = settings.maxIncrement
+		mountEntry.Config.RenewalGracePeriod = settings.gracePeriod
+
+		// Update the mount table
+		var err error
+		switch {
+		case strings.HasPrefix(path, credentialRoutePrefix):
+			err = b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local)
+		default:
+			err = b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local)
+		}
+		if err != nil {
+			mountEntry.Config.DefaultRenewalIncrement = oldDefaultIncrement
+			mountEntry.Config.MaxRenewalIncrement = oldMaxIncrement
+			mountEntry.Config.RenewalGracePeriod = oldGracePeriod
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of renewal settings successful", "path", path, "default_renewal_increment", settings.defaultIncrement, "max_renewal_increment", settings.maxIncrement, "renewal_grace_period", settings.gracePeriod)
+		}
+	}
+
+	rawProxyURL, proxyURLOk := data.GetOk("outbound_proxy_url")
+	rawCACert, caCertOk := data.GetOk("outbound_ca_cert")
+	if proxyURLOk || caCertOk {
+		proxyURL, caCert := mountEntry.Config.OutboundProxyURL, mountEntry.Config.OutboundCACert
+		if proxyURLOk {
+			proxyURL = rawProxyURL.(string)
+		}
+		if caCertOk {
+			caCert = rawCACert.(string)
+		}
+		if err := checkOutboundSettings(proxyURL, caCert); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		oldProxyURL, oldCACert := mountEntry.Config.OutboundProxyURL, mountEntry.Config.OutboundCACert
+		mountEntry.Config.OutboundProxyURL = proxyURL
+		mountEntry.Config.OutboundCACert = caCert
+
+		// Update the mount table
+		var err error
+		switch {
+		case strings.HasPrefix(path, credentialRoutePrefix):
+			err = b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local)
+		default:
+			err = b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local)
+		}
+		if err != nil {
+			mountEntry.Config.OutboundProxyURL = oldProxyURL
+			mountEntry.Config.OutboundCACert = oldCACert
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of outbound settings successful", "path", path)
+		}
+	}
+
+	rawThreshold, thresholdOk := data.GetOk("lockout_threshold")
+	rawIPThreshold, ipThresholdOk := data.GetOk("lockout_ip_threshold")
+	rawDuration, durationOk := data.GetOk("lockout_duration")
+	if thresholdOk || ipThresholdOk || durationOk {
+		if !strings.HasPrefix(path, credentialRoutePrefix) || mountEntry.Type == "token" {
+			return logical.ErrorResponse("lockout settings can only be set on auth methods other than the token store"), logical.ErrInvalidRequest
+		}
+
+		settings := lockoutSettings{
+			threshold:   mountEntry.Config.LockoutThreshold,
+			ipThreshold: mountEntry.Config.LockoutIPThreshold,
+			duration:    mountEntry.Config.LockoutDuration,
+		}
+		if thresholdOk {
+			settings.threshold = rawThreshold.(int)
+		}
+		if ipThresholdOk {
+			settings.ipThreshold = rawIPThreshold.(int)
+		}
+		if durationOk {
+			settings.duration = time.Duration(rawDuration.(int)) * time.Second
+		}
+		if err := settings.validate(); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		oldThreshold, oldIPThreshold, oldDuration := mountEntry.Config.LockoutThreshold, mountEntry.Config.LockoutIPThreshold, mountEntry.Config.LockoutDuration
+		mountEntry.Config.LockoutThreshold = settings.threshold
+		mountEntry.Config.LockoutIPThreshold = settings.ipThreshold
+		mountEntry.Config.LockoutDuration = settings.duration
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+			mountEntry.Config.LockoutThreshold = oldThreshold
+			mountEntry.Config.LockoutIPThreshold = oldIPThreshold
+			mountEntry.Config.LockoutDuration = oldDuration
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of lockout settings successful", "path", path, "lockout_threshold", settings.threshold, "lockout_ip_threshold", settings.ipThreshold, "lockout_duration", settings.duration)
+		}
+	}
+
 	var err error
 	var resp *logical.Response
 	var options map[string]string
@@ -2266,6 +3295,15 @@ func (b *SystemBackend) handleLeaseLookup(ctx context.Context, req *logical.Requ
 	return resp, nil
 }
 
+// handleRootTokensList lists the accessors of all live root tokens
+func (b *SystemBackend) handleRootTokensList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	keys, keyInfo, err := b.Core.tokenStore.rootTokenAccessors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
 func (b *SystemBackend) handleLeaseLookupList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	prefix := data.Get("prefix").(string)
 	if prefix != "" && !strings.HasSuffix(prefix, "/") {
@@ -2378,7 +3416,12 @@ func (b *SystemBackend) handleAuthTable(ctx context.Context, req *logical.Reques
 	resp := &logical.Response{
 		Data: make(map[string]interface{}),
 	}
+	ns := b.requestNamespace(req)
 	for _, entry := range b.Core.auth.Entries {
+		if entry.NamespaceID != ns.ID {
+			continue
+		}
+
 		info := map[string]interface{}{
 			"type":        entry.Type,
 			"description": entry.Description,
@@ -2387,6 +3430,9 @@ func (b *SystemBackend) handleAuthTable(ctx context.Context, req *logical.Reques
 			"seal_wrap":   entry.SealWrap,
 			"options":     entry.Options,
 		}
+		if _, deprecated := builtinDeprecation(entry); deprecated {
+			info["deprecation_status"] = deprecationStatusDeprecated
+		}
 		entryConfig := map[string]interface{}{
 			"default_lease_ttl": int64(entry.Config.DefaultLeaseTTL.Seconds()),
 			"max_lease_ttl":     int64(entry.Config.MaxLeaseTTL.Seconds()),
@@ -2406,9 +3452,19 @@ func (b *SystemBackend) handleAuthTable(ctx context.Context, req *logical.Reques
 		if rawVal, ok := entry.synthesizedConfigCache.Load("passthrough_request_headers"); ok {
 			entryConfig["passthrough_request_headers"] = rawVal.([]string)
 		}
+		if entry.Config.TokenType != logical.TokenTypeDefault {
+			entryConfig["token_type"] = entry.Config.TokenType.String()
+		}
+		if entry.Config.MaxInFlightRequests > 0 {
+			entryConfig["max_in_flight_requests"] = entry.Config.MaxInFlightRequests
+			entryConfig["max_queued_requests"] = entry.Config.MaxQueuedRequests
+		}
+		addRenewalSettings(entryConfig, entry.Config)
+		addOutboundSettings(entryConfig, entry.Config)
+		addLockoutSettings(entryConfig, entry.Config)
 
 		info["config"] = entryConfig
-		resp.Data[entry.Path] = info
+		resp.Data[strings.TrimPrefix(entry.Path, ns.Path)] = info
 	}
 	return resp, nil
 }
@@ -2422,8 +3478,9 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 	}
 
 	// Get all the options
+	ns := b.requestNamespace(req)
 	path := data.Get("path").(string)
-	path = sanitizeMountPath(path)
+	path = ns.Path + sanitizeMountPath(path)
 	logicalType := data.Get("type").(string)
 	description := data.Get("description").(string)
 	pluginName := data.Get("plugin_name").(string)
@@ -2523,6 +3580,28 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 		config.PassthroughRequestHeaders = apiConfig.PassthroughRequestHeaders
 	}
 
+	tokenType, err := logical.ParseTokenType(apiConfig.TokenType)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid token_type: %v", err)), logical.ErrInvalidRequest
+	}
+	config.TokenType = tokenType
+
+	if err := checkRequestLimits(apiConfig.MaxInFlightRequests, apiConfig.MaxQueuedRequests); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	config.MaxInFlightRequests = apiConfig.MaxInFlightRequests
+	config.MaxQueuedRequests = apiConfig.MaxQueuedRequests
+
+	if err := parseRenewalSettings(apiConfig, &config); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if err := parseOutboundSettings(apiConfig, &config); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if err := parseLockoutSettings(apiConfig, &config); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	// Create the mount entry
 	me := &MountEntry{
 		Table:       credentialTableType,
@@ -2533,6 +3612,7 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 		Local:       local,
 		SealWrap:    sealWrap,
 		Options:     options,
+		NamespaceID: ns.ID,
 	}
 
 	// Attempt enabling
@@ -2540,13 +3620,20 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 		b.Backend.Logger().Error("enable auth mount failed", "path", me.Path, "error", err)
 		return handleError(err)
 	}
+
+	if warning := deprecationWarning(me); warning != "" {
+		resp := &logical.Response{}
+		resp.AddWarning(warning)
+		return resp, nil
+	}
 	return nil, nil
 }
 
 // handleDisableAuth is used to disable a credential backend
 func (b *SystemBackend) handleDisableAuth(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.requestNamespace(req)
 	path := data.Get("path").(string)
-	path = sanitizeMountPath(path)
+	path = ns.Path + sanitizeMountPath(path)
 
 	fullPath := credentialRoutePrefix + path
 
@@ -2559,7 +3646,7 @@ func (b *SystemBackend) handleDisableAuth(ctx context.Context, req *logical.Requ
 	// We return success when the mount does not exists to not expose if the
 	// mount existed or not
 	match := b.Core.router.MatchingMount(fullPath)
-	if match == "" || fullPath != match {
+	if match == "" || fullPath != match || entry.NamespaceID != ns.ID {
 		return nil, nil
 	}
 
@@ -2574,15 +3661,19 @@ func (b *SystemBackend) handleDisableAuth(ctx context.Context, req *logical.Requ
 // handlePoliciesList handles /sys/policy/ and /sys/policies/<type> endpoints to provide the enabled policies
 func (b *SystemBackend) handlePoliciesList(policyType PolicyType) framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-		policies, err := b.Core.policyStore.ListPolicies(ctx, policyType)
+		ns := b.requestNamespace(req)
+		policies, err := b.Core.namespacePolicyStore(ns).ListPolicies(ctx, policyType)
 		if err != nil {
 			return nil, err
 		}
 
 		switch policyType {
 		case PolicyTypeACL:
-			// Add the special "root" policy if not egp
-			policies = append(policies, "root")
+			// Add the special "root" policy if not egp; namespaces have no
+			// root policy
+			if ns.ID == "" {
+				policies = append(policies, "root")
+			}
 			resp := logical.ListResponse(policies)
 
 			// If the request is from sys/policy/ we handle backwards compatibility
@@ -2602,7 +3693,7 @@ func (b *SystemBackend) handlePoliciesRead(policyType PolicyType) framework.Oper
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 		name := data.Get("name").(string)
 
-		policy, err := b.Core.policyStore.GetPolicy(ctx, name, policyType)
+		policy, err := b.Core.namespacePolicyStore(b.requestNamespace(req)).GetPolicy(ctx, name, policyType)
 		if err != nil {
 			return handleError(err)
 		}
@@ -2672,7 +3763,7 @@ func (b *SystemBackend) handlePoliciesSet(policyType PolicyType) framework.Opera
 		}
 
 		// Update the policy
-		if err := b.Core.policyStore.SetPolicy(ctx, policy); err != nil {
+		if err := b.Core.namespacePolicyStore(b.requestNamespace(req)).SetPolicy(ctx, policy); err != nil {
 			return handleError(err)
 		}
 		return resp, nil
@@ -2683,7 +3774,7 @@ func (b *SystemBackend) handlePoliciesDelete(policyType PolicyType) framework.Op
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 		name := data.Get("name").(string)
 
-		if err := b.Core.policyStore.DeletePolicy(ctx, name, policyType); err != nil {
+		if err := b.Core.namespacePolicyStore(b.requestNamespace(req)).DeletePolicy(ctx, name, policyType); err != nil {
 			return handleError(err)
 		}
 		return nil, nil
@@ -2849,6 +3940,34 @@ func (b *SystemBackend) handleConfigUIHeadersDelete(ctx context.Context, req *lo
 	return nil, nil
 }
 
+// rawPaths returns the paths reading and writing the barrier directly, which
+// are also served in recovery mode
+func (b *SystemBackend) rawPaths() []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "(raw/?$|raw/(?P<path>.+))",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type: framework.TypeString,
+				},
+				"value": &framework.FieldSchema{
+					Type: framework.TypeString,
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.handleRawRead,
+				logical.UpdateOperation: b.handleRawWrite,
+				logical.DeleteOperation: b.handleRawDelete,
+				logical.ListOperation:   b.handleRawList,
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["raw"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["raw"][1]),
+		},
+	}
+}
+
 // handleRawRead is used to read directly from the barrier
 func (b *SystemBackend) handleRawRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	path := data.Get("path").(string)
@@ -3014,6 +4133,21 @@ func (b *SystemBackend) handleRotate(ctx context.Context, req *logical.Request,
 	return nil, nil
 }
 
+// handleRotateSeal is used to rotate the key the seal protects the master
+// key with
+func (b *SystemBackend) handleRotateSeal(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if _, ok := b.Core.seal.(sealKeyRotator); !ok {
+		return logical.ErrorResponse(fmt.Sprintf("seals of type %q do not support key rotation", b.Core.seal.BarrierType())), logical.ErrInvalidRequest
+	}
+
+	if err := b.Core.RotateSealKey(ctx); err != nil {
+		b.Backend.Logger().Error("failed to rotate the seal key", "error", err)
+		return handleError(err)
+	}
+
+	return nil, nil
+}
+
 func (b *SystemBackend) handleWrappingPubkey(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	x, _ := b.Core.wrappingJWTKey.X.MarshalText()
 	y, _ := b.Core.wrappingJWTKey.Y.MarshalText()
@@ -3533,14 +4667,22 @@ func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logic
 		return false
 	}
 
+	// Only the mounts of the namespace of the request are listed, relative
+	// to it
+	ns := b.requestNamespace(req)
+
 	b.Core.mountsLock.RLock()
 	for _, entry := range b.Core.mounts.Entries {
+		if entry.NamespaceID != ns.ID {
+			continue
+		}
+		path := strings.TrimPrefix(entry.Path, ns.Path)
 		if hasAccess(entry) {
 			if isAuthed {
 				// If this is an authed request return all the mount info
-				secretMounts[entry.Path] = mountInfo(entry)
+				secretMounts[path] = mountInfo(entry)
 			} else {
-				secretMounts[entry.Path] = map[string]interface{}{
+				secretMounts[path] = map[string]interface{}{
 					"type":        entry.Type,
 					"description": entry.Description,
 					"options":     entry.Options,
@@ -3552,12 +4694,16 @@ func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logic
 
 	b.Core.authLock.RLock()
 	for _, entry := range b.Core.auth.Entries {
+		if entry.NamespaceID != ns.ID {
+			continue
+		}
+		path := strings.TrimPrefix(entry.Path, ns.Path)
 		if hasAccess(entry) {
 			if isAuthed {
 				// If this is an authed request return all the mount info
-				authMounts[entry.Path] = mountInfo(entry)
+				authMounts[path] = mountInfo(entry)
 			} else {
-				authMounts[entry.Path] = map[string]interface{}{
+				authMounts[path] = map[string]interface{}{
 					"type":        entry.Type,
 					"description": entry.Description,
 					"options":     entry.Options,
@@ -3570,6 +4716,101 @@ func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logic
 	return resp, nil
 }
 
+// pathInternalOpenAPI returns the OpenAPI document of the paths of the
+// mounts the token has access to, as listed by internal/ui/mounts
+func (b *SystemBackend) pathInternalOpenAPI(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	mountsResp, err := b.pathInternalUIMountsRead(ctx, req, d)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := framework.NewOASDocument()
+	doc.Info.Version = version.GetVersion().VersionNumber()
+
+	ns := b.requestNamespace(req)
+	for _, group := range []struct {
+		name   string
+		prefix string
+	}{
+		{"secret", ""},
+		{"auth", credentialRoutePrefix},
+	} {
+		mounts := mountsResp.Data[group.name].(map[string]interface{})
+		paths := make([]string, 0, len(mounts))
+		for path := range mounts {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, mount := range paths {
+			apiPath := group.prefix + mount
+			backend := b.Core.router.MatchingBackend(namespaceAPIPath(ns, apiPath))
+			if backend == nil {
+				continue
+			}
+
+			helpResp, err := backend.HandleRequest(ctx, &logical.Request{
+				Operation: logical.HelpOperation,
+				Storage:   req.Storage,
+			})
+			if err != nil || helpResp == nil {
+				// Backends not built with the framework cannot be documented
+				b.logger.Debug("failed to document mount", "path", apiPath, "error", err)
+				continue
+			}
+			raw, ok := helpResp.Data["openapi"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			backendDoc, err := framework.NewOASDocumentFromMap(raw)
+			if err != nil {
+				b.logger.Debug("failed to decode OpenAPI document of mount", "path", apiPath, "error", err)
+				continue
+			}
+
+			tag := openAPITag(group.name, mounts[mount])
+			for path, item := range backendDoc.Paths {
+				for _, op := range []*framework.OASOperation{item.Get, item.Post, item.Delete} {
+					if op != nil {
+						op.Tags = []string{tag}
+					}
+				}
+				doc.Paths["/"+apiPath+strings.TrimPrefix(path, "/")] = item
+			}
+		}
+	}
+
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPContentType: "application/json",
+			logical.HTTPRawBody:     buf,
+		},
+	}, nil
+}
+
+// openAPITag returns the tag grouping the operations of a mount in OpenAPI
+// documents
+func openAPITag(group string, info interface{}) string {
+	if group == "auth" {
+		return "auth"
+	}
+	var mountType string
+	if info, ok := info.(map[string]interface{}); ok {
+		mountType, _ = info["type"].(string)
+	}
+	switch mountType {
+	case "system", "identity":
+		return mountType
+	}
+	return "secrets"
+}
+
 func (b *SystemBackend) pathInternalUIMountRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	path := d.Get("path").(string)
 	if path == "" {
@@ -3579,8 +4820,11 @@ func (b *SystemBackend) pathInternalUIMountRead(ctx context.Context, req *logica
 
 	errResp := logical.ErrorResponse(fmt.Sprintf("Preflight capability check returned 403, please ensure client's policies grant access to path \"%s\"", path))
 
-	me := b.Core.router.MatchingMountEntry(path)
-	if me == nil {
+	// The path is relative to the namespace of the request, which the mount
+	// has to belong to
+	ns := b.requestNamespace(req)
+	me := b.Core.router.MatchingMountEntry(namespaceAPIPath(ns, path))
+	if me == nil || me.NamespaceID != ns.ID {
 		// Return a permission denied error here so this path cannot be used to
 		// brute force a list of mounts.
 		return errResp, logical.ErrPermissionDenied
@@ -3589,7 +4833,7 @@ func (b *SystemBackend) pathInternalUIMountRead(ctx context.Context, req *logica
 	resp := &logical.Response{
 		Data: mountInfo(me),
 	}
-	resp.Data["path"] = me.Path
+	resp.Data["path"] = strings.TrimPrefix(me.Path, ns.Path)
 
 	// Load the ACL policies so we can walk the prefix for this mount
 	acl, te, entity, _, err := b.Core.fetchACLTokenEntryAndEntity(req)
@@ -3745,27 +4989,153 @@ func checkListingVisibility(visibility ListingVisibilityType) error {
 	return nil
 }
 
-const sysHelpRoot = `
-The system backend is built-in to Vault and cannot be remounted or
-unmounted. It contains the paths that are used to configure Vault itself
-as well as perform core operations.
-`
+// checkRequestLimits verifies the limits on the requests a mount handles
+// concurrently
+func checkRequestLimits(maxInFlight, maxQueued int) error {
+	switch {
+	case maxInFlight < 0:
+		return fmt.Errorf("max_in_flight_requests cannot be negative")
+	case maxQueued < 0:
+		return fmt.Errorf("max_queued_requests cannot be negative")
+	}
+	return nil
+}
 
-// sysHelp is all the help text for the sys backend.
-var sysHelp = map[string][2]string{
-	"config/cors": {
-		"Configures or returns the current configuration of CORS settings.",
-		`
-This path responds to the following HTTP methods.
+// parseRenewalSettings parses the renewal settings of the configuration of a
+// new mount
+func parseRenewalSettings(apiConfig APIMountConfig, config *MountConfig) error {
+	for _, setting := range []struct {
+		name string
+		raw  string
+		dst  *time.Duration
+	}{
+		{"default_renewal_increment", apiConfig.DefaultRenewalIncrement, &config.DefaultRenewalIncrement},
+		{"max_renewal_increment", apiConfig.MaxRenewalIncrement, &config.MaxRenewalIncrement},
+		{"renewal_grace_period", apiConfig.RenewalGracePeriod, &config.RenewalGracePeriod},
+	} {
+		if setting.raw == "" {
+			continue
+		}
+		d, err := parseutil.ParseDurationSecond(setting.raw)
+		if err != nil {
+			return fmt.Errorf("unable to parse %s of %s: %s", setting.name, setting.raw, err)
+		}
+		*setting.dst = d
+	}
 
-    GET /
-        Returns the configuration of the CORS setting.
+	return renewalSettings{
+		defaultIncrement: config.DefaultRenewalIncrement,
+		maxIncrement:     config.MaxRenewalIncrement,
+		gracePeriod:      config.RenewalGracePeriod,
+	}.validate()
+}
 
-    POST /
-        Sets the comma-separated list of origins that can make cross-origin requests.
+// addRenewalSettings adds the renewal settings the mount overrides to the
+// given mount configuration output
+func addRenewalSettings(data map[string]interface{}, config MountConfig) {
+	if config.DefaultRenewalIncrement > 0 {
+		data["default_renewal_increment"] = int64(config.DefaultRenewalIncrement.Seconds())
+	}
+	if config.MaxRenewalIncrement > 0 {
+		data["max_renewal_increment"] = int64(config.MaxRenewalIncrement.Seconds())
+	}
+	if config.RenewalGracePeriod > 0 {
+		data["renewal_grace_period"] = int64(config.RenewalGracePeriod.Seconds())
+	}
+}
 
-    DELETE /
-        Clears the CORS configuration and disables acceptance of CORS requests.
+// checkOutboundSettings checks the egress proxy and CA certificates of a
+// mount, either of which may be empty
+func checkOutboundSettings(proxyURL, caCert string) error {
+	if proxyURL != "" {
+		if _, err := parseOutboundProxyURL(proxyURL); err != nil {
+			return err
+		}
+	}
+	if caCert != "" {
+		return checkOutboundCACert(caCert)
+	}
+	return nil
+}
+
+// parseOutboundSettings parses the egress settings of the configuration of a
+// new mount
+func parseOutboundSettings(apiConfig APIMountConfig, config *MountConfig) error {
+	if err := checkOutboundSettings(apiConfig.OutboundProxyURL, apiConfig.OutboundCACert); err != nil {
+		return err
+	}
+	config.OutboundProxyURL = apiConfig.OutboundProxyURL
+	config.OutboundCACert = apiConfig.OutboundCACert
+	return nil
+}
+
+// addOutboundSettings adds the egress settings the mount overrides to the
+// given mount configuration output. Credentials in the proxy URL are
+// redacted.
+func addOutboundSettings(data map[string]interface{}, config MountConfig) {
+	if config.OutboundProxyURL != "" {
+		if u, err := url.Parse(config.OutboundProxyURL); err == nil {
+			data["outbound_proxy_url"] = u.Redacted()
+		}
+	}
+	if config.OutboundCACert != "" {
+		data["outbound_ca_cert"] = config.OutboundCACert
+	}
+}
+
+// parseLockoutSettings parses the lockout settings of the configuration of a
+// new auth method
+func parseLockoutSettings(apiConfig APIMountConfig, config *MountConfig) error {
+	config.LockoutThreshold = apiConfig.LockoutThreshold
+	config.LockoutIPThreshold = apiConfig.LockoutIPThreshold
+	if apiConfig.LockoutDuration != "" {
+		d, err := parseutil.ParseDurationSecond(apiConfig.LockoutDuration)
+		if err != nil {
+			return fmt.Errorf("unable to parse lockout_duration of %s: %s", apiConfig.LockoutDuration, err)
+		}
+		config.LockoutDuration = d
+	}
+
+	return lockoutSettings{
+		threshold:   config.LockoutThreshold,
+		ipThreshold: config.LockoutIPThreshold,
+		duration:    config.LockoutDuration,
+	}.validate()
+}
+
+// addLockoutSettings adds the lockout settings of an auth method to the
+// given mount configuration output, if it locks out failed logins
+func addLockoutSettings(data map[string]interface{}, config MountConfig) {
+	settings := mountLockoutSettings(config)
+	if !settings.enabled() {
+		return
+	}
+	data["lockout_threshold"] = settings.threshold
+	data["lockout_ip_threshold"] = settings.ipThreshold
+	data["lockout_duration"] = int64(settings.duration.Seconds())
+}
+
+const sysHelpRoot = `
+The system backend is built-in to Vault and cannot be remounted or
+unmounted. It contains the paths that are used to configure Vault itself
+as well as perform core operations.
+`
+
+// sysHelp is all the help text for the sys backend.
+var sysHelp = map[string][2]string{
+	"config/cors": {
+		"Configures or returns the current configuration of CORS settings.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the configuration of the CORS setting.
+
+    POST /
+        Sets the comma-separated list of origins that can make cross-origin requests.
+
+    DELETE /
+        Clears the CORS configuration and disables acceptance of CORS requests.
 		`,
 	},
 	"config/ui/headers": {
@@ -3910,6 +5280,20 @@ in the plugin catalog.`,
 		`The options to pass into the backend. Should be a json object with string keys and values.`,
 	},
 
+	"mount_force": {
+		`Delete the mount right away, even if a mount deletion grace period is configured.`,
+		"",
+	},
+
+	"mount_restore": {
+		"Restore a mount that is pending deletion.",
+		`
+When the server has a mount deletion grace period, disabling a mount only
+stops it from serving requests, and its leases and data are deleted once the
+grace period has elapsed. Until then, the mount is restored with this endpoint.
+		`,
+	},
+
 	"seal_wrap": {
 		`Whether to turn on seal wrapping for the mount.`,
 	},
@@ -3950,6 +5334,43 @@ This path responds to the following HTTP methods.
 the auth path.`,
 	},
 
+	"version_history": {
+		"List the versions of Vault the cluster has run.",
+		`List the versions of Vault the active nodes of the cluster have run, in
+the order they were first run, with the time they were first run and the
+version they succeeded.`,
+	},
+
+	"deprecations": {
+		"Report the deprecated builtin backends and the mounts using them.",
+		`Read the builtin secrets engines and auth methods which are deprecated,
+with the backend superseding each, and the mounts of the namespace using them.`,
+	},
+
+	"auth_lockouts": {
+		"List the users and client addresses locked out of an auth path.",
+		`Read the users and client addresses the auth path locked out after too
+many failed logins, with the time their lockout ends. The failed logins are
+counted by each node, so the lockouts are those of the node handling the
+request.`,
+	},
+
+	"auth_unlock": {
+		"Unlock a user or client address locked out of an auth path.",
+		`Clear the lockout and failed logins of a user or client address of the
+auth path, on the node handling the request.`,
+	},
+
+	"auth_unlock_user": {
+		"The name of the user to unlock, as the auth method names its alias.",
+		"",
+	},
+
+	"auth_unlock_remote_address": {
+		"The client address to unlock.",
+		"",
+	},
+
 	"mount_tune": {
 		"Tune backend configuration parameters for this mount.",
 		`Read and write the 'default-lease-ttl' and 'max-lease-ttl' values of
@@ -4123,6 +5544,266 @@ or delete a policy.
 		"",
 	},
 
+	"lease-count-quota-list": {
+		`List the configured lease count quotas.`,
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        List the names of the configured lease count quotas.
+
+    GET /<name>
+        Retrieve the named lease count quota and its number of leases.
+
+    PUT /<name>
+        Add or update a lease count quota.
+
+    DELETE /<name>
+        Delete the lease count quota with the given name.
+		`,
+	},
+
+	"lease-count-quota": {
+		`Read, Modify, or Delete a lease count quota.`,
+		`
+Lease count quotas cap the number of live leases of a namespace, a mount, or
+the whole cluster. Requests that would create a lease beyond the quota are
+rejected, and the secrets they generated are revoked.
+		`,
+	},
+
+	"lease-count-quota-name": {
+		`The name of the lease count quota.`,
+		"",
+	},
+
+	"lease-count-quota-path": {
+		`The namespace or mount the quota applies to, such as "ns1/" or "pki/". The quota applies to the whole cluster if empty.`,
+		"",
+	},
+
+	"lease-count-quota-max-leases": {
+		`The maximum number of live leases under the path.`,
+		"",
+	},
+
+	"sync-destinations": {
+		`List the destinations secrets are pushed to.`,
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        List the types and names of the sync destinations.
+
+    GET /<type>/<name>
+        Retrieve a sync destination, without its credentials.
+
+    PUT /<type>/<name>
+        Add or update a sync destination.
+
+    DELETE /<type>/<name>
+        Delete a sync destination which has no associations left.
+		`,
+	},
+
+	"sync-destination": {
+		`Read, Modify, or Delete a sync destination.`,
+		`
+Sync destinations are external secret stores KV secrets are pushed to, for
+workloads which cannot read them from Vault: Kubernetes secrets ("kubernetes"),
+AWS Secrets Manager ("aws-sm") and GCP Secret Manager ("gcp-sm"). The secrets
+already pushed are not moved when the location of a destination is changed.
+		`,
+	},
+
+	"sync-destination-type": {
+		`The type of the destination: "kubernetes", "aws-sm" or "gcp-sm".`,
+		"",
+	},
+
+	"sync-destination-name": {
+		`The name of the destination.`,
+		"",
+	},
+
+	"sync-secret-name-prefix": {
+		`The prefix of the names of the secrets in the destination, followed by the path of the secret in Vault.`,
+		"",
+	},
+
+	"sync-kubernetes-host": {
+		`The URL of the API server of the Kubernetes cluster.`,
+		"",
+	},
+
+	"sync-kubernetes-ca-cert": {
+		`The PEM-encoded CA certificate of the API server, if not trusted by the system.`,
+		"",
+	},
+
+	"sync-kubernetes-token": {
+		`The token of a service account allowed to manage the secrets of the namespace.`,
+		"",
+	},
+
+	"sync-kubernetes-namespace": {
+		`The namespace the secrets are pushed to. Defaults to "default".`,
+		"",
+	},
+
+	"sync-aws-region": {
+		`The AWS region of the secrets. Defaults to "us-east-1".`,
+		"",
+	},
+
+	"sync-aws-access-key-id": {
+		`The AWS access key ID. Taken from the environment if empty.`,
+		"",
+	},
+
+	"sync-aws-secret-access-key": {
+		`The AWS secret access key. Taken from the environment if empty.`,
+		"",
+	},
+
+	"sync-aws-endpoint": {
+		`Overrides the endpoint of AWS Secrets Manager.`,
+		"",
+	},
+
+	"sync-gcp-project-id": {
+		`The GCP project of the secrets.`,
+		"",
+	},
+
+	"sync-gcp-credentials": {
+		`The JSON key of a GCP service account. The application default credentials are used if empty.`,
+		"",
+	},
+
+	"sync-gcp-endpoint": {
+		`Overrides the endpoint of GCP Secret Manager.`,
+		"",
+	},
+
+	"sync-associations": {
+		`Read the secrets pushed to a destination and their status.`,
+		`
+Returns the secrets associated with the destination, keyed by their path, with
+the name of the secret in the destination and the status of its
+synchronization. Secrets are pushed when written or deleted, and checked for
+drift periodically; a secret changed in the destination is pushed again.
+		`,
+	},
+
+	"sync-associations-set": {
+		`Associate a KV secret with a destination.`,
+		`
+Associates a KV secret with the destination and pushes it right away. The
+response holds the status of the synchronization.
+		`,
+	},
+
+	"sync-associations-remove": {
+		`Remove the association of a KV secret with a destination.`,
+		`
+Stops pushing the KV secret to the destination and deletes it there.
+		`,
+	},
+
+	"sync-association-mount": {
+		`The path of the kv mount of the secret, such as "secret/".`,
+		"",
+	},
+
+	"sync-association-secret-name": {
+		`The path of the secret within the mount, such as "app/db".`,
+		"",
+	},
+
+	"password-policy-list": {
+		`List the configured password policies.`,
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        List the names of the configured password policies.
+
+    GET /<name>
+        Retrieve the named password policy.
+
+    PUT /<name>
+        Add or update a password policy.
+
+    DELETE /<name>
+        Delete the password policy with the given name.
+
+    GET /<name>/generate
+        Generate a password following the named password policy.
+		`,
+	},
+
+	"password-policy": {
+		`Read, Modify, or Delete a password policy.`,
+		`
+Password policies set the length of generated passwords and the character sets
+they are made of, along with the minimum number of characters of each set.
+		`,
+	},
+
+	"password-policy-name": {
+		`The name of the password policy. Example: "alphanumeric"`,
+		"",
+	},
+
+	"password-policy-rules": {
+		`The password policy, in HCL or JSON format.`,
+		"",
+	},
+
+	"password-policy-generate": {
+		`Generate a password following a password policy.`,
+		`
+Returns a random password following the named password policy, generated with
+a cryptographically secure source of randomness.
+		`,
+	},
+
+	"namespaces": {
+		`List the namespaces.`,
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        List the namespaces directly within the current namespace.
+
+    GET /<path>
+        Retrieve the ID and full path of a namespace.
+
+    PUT /<path>
+        Create a namespace within the current namespace.
+
+    DELETE /<path>
+        Delete an empty namespace.
+		`,
+	},
+
+	"namespace": {
+		`Read, Create, or Delete a namespace.`,
+		`
+Namespaces are administrative boundaries with their own secrets engines, auth
+methods, policies and tokens. Requests are made within a namespace either by
+prefixing the request path with the namespace path or by setting the
+X-Vault-Namespace header. A namespace can only be deleted once all of its
+mounts and nested namespaces have been removed.
+		`,
+	},
+
+	"namespace-path": {
+		`The name of the namespace, relative to the current namespace. Example: "team1"`,
+		"",
+	},
+
 	"audit-hash": {
 		"The hash of the given string via the given audit backend",
 		"",
@@ -4187,6 +5868,16 @@ Enable a new audit backend or disable an existing backend.
 		`,
 	},
 
+	"rotate-seal": {
+		"Rotates the key of the seal.",
+		`
+		Rotate generates a new version of the key the seal protects the
+		master key with, such as the key of an HSM, and encrypts the master
+		key and the recovery key with it. The previous versions are kept so
+		that other nodes can still unseal.
+		`,
+	},
+
 	"rekey_backup": {
 		"Allows fetching or deleting the backup of the rotated unseal keys.",
 		"",
@@ -4204,6 +5895,21 @@ Enable a new audit backend or disable an existing backend.
 		The path will be searched for a path match in all the policies associated with the client token.`,
 	},
 
+	"policies_simulate": {
+		"Evaluates whether policies would allow a request, without making it.",
+		`Checks the path, operation and parameters of a request against the policies of a token,
+		or against a set of stored policies and a policy that has yet to be written. The rule
+		governing the path and the policies it was merged from are returned along with the result.`,
+	},
+
+	"policies_usage": {
+		"Reports the tokens, entities, groups and token roles referring to a policy.",
+		`Lists the accessors of the live tokens of the namespace that carry the policy, the
+		entities and groups it is assigned to and the token roles allowing or disallowing it,
+		so that it can be checked before deleting the policy. The roles of other auth methods
+		are not reported.`,
+	},
+
 	"capabilities_accessor": {
 		"Fetches the capabilities of the token associated with the given token, on the given path.",
 		`When there is no access to the token, token accessor can be used to fetch the token's capabilities
@@ -4219,6 +5925,58 @@ necessary, and is only required if upgrade notes or support personnel suggest
 it.`,
 	},
 
+	"storage_cleanup": {
+		"Reports or deletes the lease, token and cubbyhole entries left behind in storage.",
+		`Reading this endpoint scans the storage for the leases whose token no
+longer exists, the leases and tokens which expired over an hour ago but were
+never revoked, and the cubbyholes, including those of response-wrapping tokens,
+whose token no longer exists. Writing to it also deletes them: the tokens are
+revoked as on expiration, and the expired leases are revoked with force,
+ignoring the errors of their backend.`,
+	},
+
+	"mount-export": {
+		"Exports the storage of a mount.",
+		`Writing to this endpoint exports all of the storage of the secrets engine
+or auth method at the given path, along with the configuration of its mount,
+so that it can be imported into another cluster. The mount is read-only during
+the export. The export is encrypted with the given AES-256 key, or for the
+given RSA public key, such as the wrapping key of the target cluster.`,
+	},
+
+	"mount-import": {
+		"Imports the storage of a mount exported from another cluster.",
+		`Writing to this endpoint creates a secrets engine or auth method at the
+given path, with the configuration and the storage of the export. Auth
+methods are imported under auth/.`,
+	},
+
+	"mount-import-wrapping-key": {
+		"Returns the public key exports can be encrypted for.",
+		`Returns the RSA public key of this cluster which the exports of mounts
+can be encrypted for, so that they can only be imported into this cluster.`,
+	},
+
+	"mount-transfer-path": {
+		"The path of the mount, prefixed with auth/ for auth methods.",
+		"",
+	},
+
+	"mount-transfer-key": {
+		"The base64-encoded AES-256 key the export is encrypted with.",
+		"",
+	},
+
+	"mount-export-public-key": {
+		"The PEM-encoded RSA public key to encrypt the export for.",
+		"",
+	},
+
+	"mount-import-export": {
+		"The export, as returned by sys/storage/mount-export.",
+		"",
+	},
+
 	"wrap": {
 		"Response-wraps an arbitrary JSON object.",
 		`Round trips the given input data into a response-wrapped token.`,
@@ -4301,6 +6059,114 @@ plugin directory.`,
 		`The args passed to plugin command.`,
 		"",
 	},
+	"root-tokens": {
+		`List the accessors of live root tokens.`,
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        Lists the accessors of all root tokens that have not expired or
+        been revoked, along with their creation and expiration times.
+		`,
+	},
+
+	"metrics": {
+		`Read the in-memory metrics of the server.`,
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the gauges, counters and samples of the most recent
+        complete interval of the in-memory metrics.
+		`,
+	},
+	"logs": {
+		`Read the recent log lines of the server.`,
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the lines most recently logged by the server, oldest first.
+		`,
+	},
+	"monitor": {
+		`Stream the log lines of the server.`,
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Streams the lines logged by the server from now on, at or above
+        the given level, until the client disconnects. Only levels the
+        server logs at can be streamed.
+		`,
+	},
+	"monitor-log-level": {
+		`The lowest level of the lines to stream, one of "trace", "debug", "info", "warn" or "error". Defaults to "info".`,
+		"",
+	},
+	"events-subscribe": {
+		`Stream the events of the node under a topic.`,
+		`
+This path responds to the following HTTP methods.
+
+    GET /<topic>
+        Streams the events published on the node from now on whose topics
+        start with the given topic, as server-sent events, until the client
+        disconnects. The topic of an event is its type, "kv", "lease" or
+        "mount", followed by its path.
+		`,
+	},
+	"events-subscribe-topic": {
+		`The prefix of the topics of the events to stream, such as "kv/secret/app/" or "lease/". Empty streams all the events.`,
+		"",
+	},
+	"host-info": {
+		`Read information about the host and process of the server.`,
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the hostname, platform, CPU count, Go version and memory
+        usage of the server process.
+		`,
+	},
+	"ha-status": {
+		`Read the nodes of the HA cluster.`,
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the nodes of the cluster, the active node and the standbys
+        that sent a heartbeat to it recently, with their hostname, addresses,
+        Vault version, whether they are performance standbys and the time of
+        their last heartbeat.
+		`,
+	},
+	"pprof": {
+		`Capture a runtime profile of the server.`,
+		`
+This path responds to the following HTTP methods.
+
+    GET /<name>
+        Returns the named runtime profile, such as "goroutine", "heap",
+        "allocs", "threadcreate", "block" or "mutex", in the format read by
+        "go tool pprof". The "profile" name captures a CPU profile and
+        "trace" an execution trace, over the given number of seconds.
+		`,
+	},
+	"pprof-name": {
+		`The name of the profile.`,
+		"",
+	},
+	"pprof-seconds": {
+		`The number of seconds to capture a CPU profile or execution trace for. Defaults to 30.`,
+		"",
+	},
+	"pprof-debug": {
+		`If greater than zero, the profile is returned as text rather than in the binary format.`,
+		"",
+	},
+
 	"leases": {
 		`View or list lease metadata.`,
 		`
@@ -4349,6 +6215,53 @@ This path responds to the following HTTP methods.
 		"A list of headers to whitelist and pass from the request to the backend.",
 		"",
 	},
+	"token_type": {
+		"The type of tokens logins to the auth method issue, 'service' or 'batch'. Defaults to 'default', which issues service tokens.",
+		"",
+	},
+	"default_renewal_increment": {
+		"The increment of the renewals of the leases of the mount requesting none, in place of the TTL of the backend. Defaults to the default_renewal_increment of the server, if any.",
+		"",
+	},
+	"max_renewal_increment": {
+		"The most a renewal can extend a lease of the mount by; larger increments are capped. Defaults to the max_renewal_increment of the server, if any.",
+		"",
+	},
+	"renewal_grace_period": {
+		"How close to its max TTL a renewal extends a lease of the mount all the way to it, rather than leaving a shorter remainder. Defaults to the renewal_grace_period of the server, if any.",
+		"",
+	},
+	"outbound_proxy_url": {
+		"The URL of the proxy the backend of the mount reaches external APIs through, in place of the outbound_proxy_url of the server, if any.",
+		"",
+	},
+	"outbound_ca_cert": {
+		"PEM-encoded CA certificates the backend of the mount trusts when reaching external APIs, in addition to those of the system and the outbound_ca_cert_file of the server.",
+		"",
+	},
+
+	"lockout_threshold": {
+		"The number of consecutive failed logins after which a user is locked out of the auth method. Defaults to 0, which never locks users out.",
+		"",
+	},
+
+	"lockout_ip_threshold": {
+		"The number of consecutive failed logins from a client address, for any user, after which the address is locked out of the auth method. Defaults to 0, which never locks addresses out.",
+		"",
+	},
+
+	"lockout_duration": {
+		"How long a user or client address stays locked out, and how long its failed logins are counted. Defaults to 15 minutes.",
+		"",
+	},
+	"max_in_flight_requests": {
+		"The maximum number of requests the mount handles concurrently. Defaults to 0, which leaves the mount unlimited.",
+		"",
+	},
+	"max_queued_requests": {
+		"The maximum number of requests that wait for the mount once it handles max_in_flight_requests. Requests over the queue are rejected. Defaults to 0.",
+		"",
+	},
 	"raw": {
 		"Write, Read, and Delete data directly in the Storage backend.",
 		"",
@@ -4357,8 +6270,150 @@ This path responds to the following HTTP methods.
 		"Information about mounts returned according to their tuned visibility. Internal API; its location, inputs, and outputs may change.",
 		"",
 	},
+	"internal-specs-openapi": {
+		"Generate an OpenAPI 3 document of all mounted paths.",
+		`
+This endpoint returns an OpenAPI 3 document describing the paths of the
+mounts the token has access to, which can be used to generate API clients.
+Paths whose pattern cannot be expressed as an OpenAPI path are left out.
+Internal API; its location, inputs, and outputs may change.
+		`,
+	},
 	"internal-ui-resultant-acl": {
 		"Information about a token's resultant ACL. Internal API; its location, inputs, and outputs may change.",
 		"",
 	},
+	"internal-counters-tokens": {
+		"Number of tokens, in total and by namespace, with their daily history. Internal API; its location, inputs, and outputs may change.",
+		"",
+	},
+	"internal-counters-entities": {
+		"Number of identity entities, with their daily history. Internal API; its location, inputs, and outputs may change.",
+		"",
+	},
+	"internal-counters-leases": {
+		"Number of leases, in total, by namespace and by mount, with their daily history. Internal API; its location, inputs, and outputs may change.",
+		"",
+	},
+	"internal-counters-activity": {
+		"Number of distinct clients by month, in total and by namespace. Internal API; its location, inputs, and outputs may change.",
+		`
+The clients are the entities, and the tokens without an entity, used to make
+requests. Clients are counted once over the requested time range, even if they
+were active in several months.
+		`,
+	},
+	"internal-counters-activity-start-time": {
+		`Start of the time range, as a month such as "2018-09" or in RFC 3339 format. Defaults to 11 months before the end time.`,
+		"",
+	},
+	"internal-counters-activity-end-time": {
+		`End of the time range, as a month such as "2018-09" or in RFC 3339 format. Defaults to the current month.`,
+		"",
+	},
+	"internal-counters-activity-namespace": {
+		"Path of the namespace to report the clients of, along with those of the namespaces within it. Defaults to all the namespaces.",
+		"",
+	},
+	"snapshot-schedule": {
+		"Configure the snapshots of the storage backend taken by the active node.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the schedule of the snapshots, without its secrets.
+
+    POST /
+        Sets the schedule of the snapshots. The active node takes a snapshot
+        of the storage backend every interval, stores it and deletes the
+        oldest snapshots beyond the number retained. Only storage backends
+        that support snapshots, such as "file", can be snapshotted.
+
+    DELETE /
+        Stops taking snapshots. The snapshots already taken are kept.
+		`,
+	},
+	"snapshot-schedule-interval": {
+		"How often to take a snapshot, as a number of seconds or a duration string.",
+		"",
+	},
+	"snapshot-schedule-retain": {
+		"The number of snapshots to keep. Defaults to 1.",
+		"",
+	},
+	"snapshot-schedule-file-prefix": {
+		`The prefix of the names of the snapshots, followed by the time they are taken. Defaults to "vault-snapshot".`,
+		"",
+	},
+	"snapshot-schedule-storage-type": {
+		`Where to store the snapshots: "local", "aws-s3" or "google-gcs".`,
+		"",
+	},
+	"snapshot-schedule-local-path": {
+		"The directory of the server the snapshots of the local storage type are stored in.",
+		"",
+	},
+	"snapshot-schedule-path-prefix": {
+		"The prefix of the object names of the snapshots in a cloud storage bucket.",
+		"",
+	},
+	"snapshot-schedule-status": {
+		"Read the outcome of the scheduled snapshots.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the time of the last attempt and of the last successful
+        snapshot, the name of the last snapshot, the error of the last
+        attempt and the number of consecutive failures.
+		`,
+	},
+	"replication-status": {
+		"Returns the replication status of the cluster.",
+		"",
+	},
+	"replication-dr-primary-enable": {
+		"Enables DR replication with this cluster as the primary.",
+		`
+Once enabled, every update made to storage on the active node is recorded so
+that it can be sent to DR secondaries. Secondaries are added by generating a
+secondary activation token.
+		`,
+	},
+	"replication-dr-secondary-token": {
+		"Generates a secondary activation token.",
+		`
+The token is a response-wrapping token in JWT format that contains the
+primary's API address and the credentials the secondary uses to sync from the
+primary. It is used with the "replication/dr/secondary/enable" endpoint on
+the secondary cluster.
+		`,
+	},
+	"replication-dr-revoke-secondary": {
+		"Revokes the ability of a secondary to sync from this primary.",
+		"",
+	},
+	"replication-dr-fetch": {
+		"Returns storage updates to a DR secondary. Internal API; its location, inputs, and outputs may change.",
+		"",
+	},
+	"replication-dr-secondary-enable": {
+		"Enables DR replication with this cluster as a secondary.",
+		`
+All existing data on this cluster is replaced with the data of the primary.
+Once the initial sync completes this node seals, and from then on it must be
+unsealed using the primary's unseal keys. Storage updates made on the primary
+are then continuously applied to this cluster. A DR secondary only services
+requests to the "sys/replication/" endpoints.
+		`,
+	},
+	"replication-dr-secondary-promote": {
+		"Promotes a DR secondary to a DR primary.",
+		`
+The secondary stops syncing from the primary and rebuilds its state from the
+replicated data, after which it services requests as a primary. The old
+primary should be shut down or disabled first to avoid both clusters
+accepting writes.
+		`,
+	},
 }
@@ -0,0 +1,276 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/version"
+)
+
+// maxProfileDuration bounds how long a CPU profile or execution trace may be
+// captured for in a single request
+const maxProfileDuration = 5 * time.Minute
+
+// monitorBufferSize is the number of log lines buffered for each stream of
+// sys/monitor, beyond which lines are dropped for slow clients
+const monitorBufferSize = 512
+
+// handleMetrics returns the most recent interval of the in-memory metrics
+func (b *SystemBackend) handleMetrics(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if b.Core.metricsSink == nil {
+		return logical.ErrorResponse("in-memory metrics are not available"), nil
+	}
+
+	summary, err := b.Core.metricsSink.DisplayMetrics(nil, nil)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// The summary is returned as it is encoded rather than as its Go fields
+	encoded, err := jsonutil.EncodeJSON(summary)
+	if err != nil {
+		return nil, err
+	}
+	var respData map[string]interface{}
+	if err := jsonutil.DecodeJSON(encoded, &respData); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+// handleLogs returns the recent lines logged by the server
+func (b *SystemBackend) handleLogs(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if b.Core.logLines == nil {
+		return logical.ErrorResponse("recent log lines are not available"), nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"lines": b.Core.logLines.Lines(),
+		},
+	}, nil
+}
+
+// handleMonitor checks a request to stream the log lines of the server. The
+// lines are streamed by the HTTP handler of sys/monitor once the request is
+// allowed, as responses of backends are not streamed.
+func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if b.Core.logLines == nil {
+		return logical.ErrorResponse("log streaming is not available"), nil
+	}
+
+	logLevel := data.Get("log_level").(string)
+	if log.LevelFromString(logLevel) == log.NoLevel {
+		return logical.ErrorResponse(fmt.Sprintf("unknown log level %q", logLevel)), nil
+	}
+
+	// An empty response, as reads without one are not found
+	return &logical.Response{}, nil
+}
+
+// SubscribeLogs returns a channel receiving the lines logged by the server
+// from now on and a function to stop receiving them, or nil if the lines of
+// the server are not kept
+func (c *Core) SubscribeLogs() (<-chan string, func()) {
+	if c.logLines == nil {
+		return nil, nil
+	}
+	return c.logLines.Subscribe(monitorBufferSize)
+}
+
+// handleHostInfo returns information about the host and process of the
+// server
+func (b *SystemBackend) handleHostInfo(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"hostname":   hostname,
+			"os":         runtime.GOOS,
+			"arch":       runtime.GOARCH,
+			"cpus":       runtime.NumCPU(),
+			"go_version": runtime.Version(),
+			"pid":        os.Getpid(),
+			"goroutines": runtime.NumGoroutine(),
+			"memory": map[string]interface{}{
+				"alloc":       mem.Alloc,
+				"total_alloc": mem.TotalAlloc,
+				"sys":         mem.Sys,
+				"heap_alloc":  mem.HeapAlloc,
+				"heap_inuse":  mem.HeapInuse,
+				"heap_idle":   mem.HeapIdle,
+				"num_gc":      mem.NumGC,
+			},
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// handleHAStatus returns the nodes of the HA cluster: this node, which is the
+// active one as standbys forward the request, and the standbys that sent a
+// heartbeat recently
+func (b *SystemBackend) handleHAStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if b.Core.ha == nil {
+		return logical.ErrorResponse("high availability is not enabled"), nil
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	b.Core.stateLock.RLock()
+	apiAddr := b.Core.redirectAddr
+	clusterAddr := b.Core.clusterAddr
+	b.Core.stateLock.RUnlock()
+
+	nodes := []map[string]interface{}{
+		{
+			"hostname":            hostname,
+			"api_address":         apiAddr,
+			"cluster_address":     clusterAddr,
+			"version":             version.GetVersion().VersionNumber(),
+			"active_node":         true,
+			"performance_standby": false,
+			"last_echo":           nil,
+		},
+	}
+	for _, peer := range b.Core.getHAPeerNodesCached() {
+		nodes = append(nodes, map[string]interface{}{
+			"hostname":            peer.Hostname,
+			"api_address":         peer.APIAddress,
+			"cluster_address":     peer.ClusterAddress,
+			"version":             peer.Version,
+			"active_node":         false,
+			"performance_standby": peer.PerfStandby,
+			"last_echo":           peer.LastEcho.UTC().Format(time.RFC3339Nano),
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"nodes": nodes,
+		},
+	}, nil
+}
+
+// pprofPaths returns the paths capturing the runtime profiles of the server,
+// unless disabled in its configuration
+func (b *SystemBackend) pprofPaths() []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "pprof/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["pprof-name"][0]),
+				},
+				"seconds": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Default:     30,
+					Description: strings.TrimSpace(sysHelp["pprof-seconds"][0]),
+				},
+				"debug": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["pprof-debug"][0]),
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handlePprof,
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["pprof"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["pprof"][1]),
+		},
+	}
+}
+
+// handlePprof returns a profile in the format of the runtime/pprof package,
+// or an execution trace
+func (b *SystemBackend) handlePprof(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	duration := time.Duration(data.Get("seconds").(int)) * time.Second
+	if duration <= 0 || duration > maxProfileDuration {
+		return logical.ErrorResponse(fmt.Sprintf("seconds must be between 1 and %d", int(maxProfileDuration.Seconds()))), nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && (name == "profile" || name == "trace") && time.Now().Add(duration).After(deadline) {
+		return logical.ErrorResponse("seconds must be shorter than the max_request_duration of the listener"), nil
+	}
+
+	var buf bytes.Buffer
+	switch name {
+	case "profile":
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("could not start CPU profile: %s", err)), nil
+		}
+		err := sleepCtx(ctx, duration)
+		pprof.StopCPUProfile()
+		if err != nil {
+			return nil, err
+		}
+
+	case "trace":
+		if err := trace.Start(&buf); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("could not start trace: %s", err)), nil
+		}
+		err := sleepCtx(ctx, duration)
+		trace.Stop()
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			return logical.ErrorResponse(fmt.Sprintf("unknown profile %q", name)), nil
+		}
+		if err := profile.WriteTo(&buf, data.Get("debug").(int)); err != nil {
+			return nil, err
+		}
+	}
+
+	contentType := "application/octet-stream"
+	if data.Get("debug").(int) > 0 && name != "profile" && name != "trace" {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: contentType,
+			logical.HTTPRawBody:     buf.Bytes(),
+			logical.HTTPStatusCode:  http.StatusOK,
+		},
+	}, nil
+}
+
+// sleepCtx waits for the duration, or returns early if the context is
+// canceled
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return errors.New("request canceled")
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -441,6 +442,14 @@ func testSystemBackend_PluginReload(t *testing.T, reqData map[string]interface{}
 			t.Fatal("did not expect backend internal value to be 'baz'")
 		}
 	}
+
+	// Mounts that are not backed by a plugin cannot be reloaded
+	_, err = client.Logical().Write("sys/plugins/reload/backend", map[string]interface{}{
+		"mounts": "mock-0/,secret/",
+	})
+	if err == nil || !strings.Contains(err.Error(), "mount is not a plugin") {
+		t.Fatalf("expected error reloading a non-plugin mount, got: %v", err)
+	}
 }
 
 // testSystemBackendMock returns a systemBackend with the desired number
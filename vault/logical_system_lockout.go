@@ -0,0 +1,72 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// authLockout returns the login lockout of the auth method at the path
+// within the namespace of the request
+func (b *SystemBackend) authLockout(req *logical.Request, path string) (*loginLockout, error) {
+	ns := b.requestNamespace(req)
+	path = namespaceAPIPath(ns, sanitizeMountPath(credentialRoutePrefix+path))
+
+	mountEntry := b.Core.router.MatchingMountEntry(path)
+	if mountEntry == nil || mountEntry.NamespaceID != ns.ID || mountEntry.Table != credentialTableType || mountEntry.Type == "token" {
+		return nil, fmt.Errorf("no auth method at %q", path)
+	}
+	lockout := b.Core.router.MatchingLoginLockout(path)
+	if lockout == nil {
+		return nil, fmt.Errorf("no auth method at %q", path)
+	}
+	return lockout, nil
+}
+
+// handleAuthLockoutsRead lists the users and client addresses an auth
+// method locked out
+func (b *SystemBackend) handleAuthLockoutsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := data.Get("path").(string)
+	if path == "" {
+		return logical.ErrorResponse("path must be specified as a string"), logical.ErrInvalidRequest
+	}
+	lockout, err := b.authLockout(req, path)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	users, clients := lockout.lockedOut(time.Now())
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"users":            lockoutsData(users),
+			"remote_addresses": lockoutsData(clients),
+		},
+	}, nil
+}
+
+// handleAuthUnlock clears the lockout of a user or client address of an
+// auth method
+func (b *SystemBackend) handleAuthUnlock(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := data.Get("path").(string)
+	if path == "" {
+		return logical.ErrorResponse("path must be specified as a string"), logical.ErrInvalidRequest
+	}
+	user := data.Get("user").(string)
+	client := data.Get("remote_address").(string)
+	if user == "" && client == "" {
+		return logical.ErrorResponse("user or remote_address must be specified"), logical.ErrInvalidRequest
+	}
+	lockout, err := b.authLockout(req, path)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	lockout.unlock(user, client)
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("unlocked login", "path", credentialRoutePrefix+path, "user", user, "remote_address", client)
+	}
+	return nil, nil
+}
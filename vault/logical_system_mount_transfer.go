@@ -0,0 +1,164 @@
+package vault
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// mountTransferPaths returns the paths exporting the storage of a mount, and
+// importing it into a new mount of another cluster
+func (b *SystemBackend) mountTransferPaths() []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "storage/mount-export$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mount-transfer-path"][0]),
+				},
+				"key": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mount-transfer-key"][0]),
+				},
+				"public_key": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mount-export-public-key"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleMountExport,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mount-export"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mount-export"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "storage/mount-import$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mount-transfer-path"][0]),
+				},
+				"export": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mount-import-export"][0]),
+				},
+				"key": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mount-transfer-key"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleMountImport,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mount-import"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mount-import"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "storage/mount-import/wrapping-key$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handleMountImportWrappingKey,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mount-import-wrapping-key"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mount-import-wrapping-key"][1]),
+		},
+	}
+}
+
+// handleMountExport exports the storage of a mount, encrypted with the given
+// key or for the given public key
+func (b *SystemBackend) handleMountExport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	path := d.Get("path").(string)
+	if strings.Trim(path, "/") == "" {
+		return logical.ErrorResponse("path must be specified"), logical.ErrInvalidRequest
+	}
+	key, err := base64.StdEncoding.DecodeString(d.Get("key").(string))
+	if err != nil {
+		return logical.ErrorResponse("key must be base64-encoded"), logical.ErrInvalidRequest
+	}
+	wrapKey, err := mountExportKeyWrapper(key, d.Get("public_key").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	export, entry, entries, err := b.Core.exportMount(ctx, b.requestNamespace(req), path, wrapKey)
+	if err != nil {
+		return handleError(err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"export":  base64.StdEncoding.EncodeToString(export),
+			"type":    entry.Type,
+			"entries": entries,
+		},
+	}, nil
+}
+
+// handleMountImport creates a mount with the storage of an export
+func (b *SystemBackend) handleMountImport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	path := d.Get("path").(string)
+	if strings.Trim(strings.TrimPrefix(strings.Trim(path, "/"), "auth"), "/") == "" {
+		return logical.ErrorResponse("path must be specified"), logical.ErrInvalidRequest
+	}
+	export, err := base64.StdEncoding.DecodeString(d.Get("export").(string))
+	if err != nil || len(export) == 0 {
+		return logical.ErrorResponse("export must be given base64-encoded"), logical.ErrInvalidRequest
+	}
+	key, err := base64.StdEncoding.DecodeString(d.Get("key").(string))
+	if err != nil {
+		return logical.ErrorResponse("key must be base64-encoded"), logical.ErrInvalidRequest
+	}
+
+	entry, entries, err := b.Core.importMount(ctx, b.requestNamespace(req), path, export, b.Core.mountImportKeyUnwrapper(ctx, key))
+	if err != nil {
+		return handleError(err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"type":     entry.Type,
+			"accessor": entry.Accessor,
+			"entries":  entries,
+		},
+	}, nil
+}
+
+// handleMountImportWrappingKey returns the public key the exports imported
+// into this cluster can be encrypted for
+func (b *SystemBackend) handleMountImportWrappingKey(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key, err := b.Core.mountImportWrappingKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	derBytes, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, errwrap.Wrapf("error marshaling public key: {{err}}", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": string(pem.EncodeToMemory(&pem.Block{
+				Type:  "PUBLIC KEY",
+				Bytes: derBytes,
+			})),
+		},
+	}, nil
+}
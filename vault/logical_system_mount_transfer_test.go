@@ -0,0 +1,212 @@
+package vault_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/builtin/logical/kv"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSystemBackend_MountTransfer(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": kv.Factory,
+		},
+		CredentialBackends: map[string]logical.Factory{
+			"userpass": credUserpass.Factory,
+		},
+	}
+	source := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+		NumCores:    1,
+	})
+	source.Start()
+	defer source.Cleanup()
+	target := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+		NumCores:    1,
+	})
+	target.Start()
+	defer target.Cleanup()
+
+	client := source.Cores[0].Client
+	targetClient := target.Cores[0].Client
+
+	// Write two versions of a secret of a kv v2 mount
+	if err := client.Sys().Mount("kv", &api.MountInput{
+		Type:        "kv",
+		Description: "migrated",
+		Options:     map[string]string{"version": "2"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, password := range []string{"hunter2", "correct horse"} {
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			_, err := client.Logical().Write("kv/data/app", map[string]interface{}{
+				"data": map[string]interface{}{"password": password},
+			})
+			if err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal(err)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	if _, err := client.Logical().Write("kv/metadata/app", map[string]interface{}{
+		"max_versions": 5,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Export it for the target cluster
+	publicKey, err := targetClient.Sys().MountImportWrappingKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(publicKey, "-----BEGIN PUBLIC KEY-----") {
+		t.Fatalf("bad public key: %q", publicKey)
+	}
+	export, err := client.Sys().ExportMount("kv/", &api.MountExportInput{
+		PublicKey: publicKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Type != "kv" || export.Entries == 0 {
+		t.Fatalf("bad export: %#v", export)
+	}
+
+	// The mount is writable again after the export
+	if _, err := client.Logical().Write("kv/data/other", map[string]interface{}{
+		"data": map[string]interface{}{"foo": "bar"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the target cluster can import it
+	if _, err := client.Sys().ImportMount("migrated/", &api.MountImportInput{
+		Export: export.Export,
+	}); err == nil || !strings.Contains(err.Error(), "wrapping key") {
+		t.Fatalf("expected an error decrypting the export, got %v", err)
+	}
+	imported, err := targetClient.Sys().ImportMount("migrated/", &api.MountImportInput{
+		Export: export.Export,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Type != "kv" || imported.Entries != export.Entries {
+		t.Fatalf("bad import: %#v", imported)
+	}
+
+	// The versions, metadata and mount configuration are preserved
+	mounts, err := targetClient.Sys().ListMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mount := mounts["migrated/"]
+	if mount == nil || mount.Description != "migrated" || mount.Options["version"] != "2" || mount.Accessor != imported.Accessor {
+		t.Fatalf("bad mount: %#v", mount)
+	}
+
+	r := targetClient.NewRequest("GET", "/v1/migrated/data/app")
+	r.Params.Set("version", "1")
+	resp, err := targetClient.RawRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := api.ParseSecret(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := secret.Data["data"].(map[string]interface{}); data["password"] != "hunter2" {
+		t.Fatalf("bad version 1: %#v", secret.Data)
+	}
+
+	secret, err = targetClient.Logical().Read("migrated/metadata/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["current_version"] != json.Number("2") || secret.Data["max_versions"] != json.Number("5") {
+		t.Fatalf("bad metadata: %#v", secret.Data)
+	}
+	secret, err = targetClient.Logical().Read("migrated/data/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := secret.Data["data"].(map[string]interface{}); data["password"] != "correct horse" {
+		t.Fatalf("bad version 2: %#v", secret.Data)
+	}
+
+	// Paths in use are rejected
+	if _, err := targetClient.Sys().ImportMount("migrated/", &api.MountImportInput{
+		Export: export.Export,
+	}); err == nil || !strings.Contains(err.Error(), "existing mount") {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+
+	// Auth methods are exported with a key, and imported under auth/
+	if err := client.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{
+		Type: "userpass",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("auth/userpass/users/alice", map[string]interface{}{
+		"password": "secret",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	export, err = client.Sys().ExportMount("auth/userpass", &api.MountExportInput{
+		Key: key,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := targetClient.Sys().ImportMount("userpass", &api.MountImportInput{
+		Export: export.Export,
+		Key:    key,
+	}); err == nil || !strings.Contains(err.Error(), "under auth/") {
+		t.Fatalf("expected an error importing outside of auth/, got %v", err)
+	}
+	if _, err := targetClient.Sys().ImportMount("auth/users", &api.MountImportInput{
+		Export: export.Export,
+		Key:    base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210")),
+	}); err == nil || !strings.Contains(err.Error(), "given key") {
+		t.Fatalf("expected an error decrypting with the wrong key, got %v", err)
+	}
+	if _, err := targetClient.Sys().ImportMount("auth/users", &api.MountImportInput{
+		Export: export.Export,
+		Key:    key,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	secret, err = targetClient.Logical().Write("auth/users/login/alice", map[string]interface{}{
+		"password": "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Auth == nil || secret.Auth.ClientToken == "" {
+		t.Fatalf("bad login: %#v", secret)
+	}
+
+	// The mounts tied to the cluster cannot be exported
+	if _, err := client.Sys().ExportMount("cubbyhole", &api.MountExportInput{
+		Key: key,
+	}); err == nil || !strings.Contains(err.Error(), "cannot be exported") {
+		t.Fatalf("expected an error exporting the cubbyhole, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package vault
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// requestNamespace returns the namespace a request to the system backend was
+// made within
+func (b *SystemBackend) requestNamespace(req *logical.Request) *Namespace {
+	return b.Core.namespaceByMountPoint(req.MountPoint)
+}
+
+// childNamespace returns the namespace named in the request, relative to the
+// namespace the request was made within
+func (b *SystemBackend) childNamespace(req *logical.Request, data *framework.FieldData) *Namespace {
+	parent := b.requestNamespace(req)
+	path := parent.Path + strings.Trim(data.Get("path").(string), "/") + "/"
+	if ns := b.Core.namespaceByPath(path); ns.Path == path {
+		return ns
+	}
+	return nil
+}
+
+// handleNamespacesList lists the namespaces within the current namespace
+func (b *SystemBackend) handleNamespacesList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	parent := b.requestNamespace(req)
+
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	for _, ns := range b.Core.listNamespaces(parent) {
+		key := strings.TrimPrefix(ns.Path, parent.Path)
+		keys = append(keys, key)
+		keyInfo[key] = map[string]interface{}{
+			"id":   ns.ID,
+			"path": ns.Path,
+		}
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+// handleNamespacesRead returns a namespace
+func (b *SystemBackend) handleNamespacesRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.childNamespace(req, data)
+	if ns == nil {
+		return nil, nil
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   ns.ID,
+			"path": ns.Path,
+		},
+	}, nil
+}
+
+// handleNamespacesCreate creates a namespace within the current namespace
+func (b *SystemBackend) handleNamespacesCreate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := b.Core.createNamespace(ctx, b.requestNamespace(req), data.Get("path").(string))
+	if err != nil {
+		return handleError(err)
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   ns.ID,
+			"path": ns.Path,
+		},
+	}, nil
+}
+
+// handleNamespacesDelete deletes an empty namespace
+func (b *SystemBackend) handleNamespacesDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.childNamespace(req, data)
+	if ns == nil {
+		return nil, nil
+	}
+	if err := b.Core.deleteNamespace(ctx, ns); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
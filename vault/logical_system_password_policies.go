@@ -0,0 +1,157 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/random"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// passwordPolicySubPath is the sub-path of the system view the password
+	// policies are stored under
+	passwordPolicySubPath = "password_policy/"
+)
+
+// passwordPolicyEntry is the stored form of a password policy, which keeps
+// the policy as it was written
+type passwordPolicyEntry struct {
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+// getPasswordPolicyEntry returns the stored password policy, or nil if there
+// is no policy of that name
+func (c *Core) getPasswordPolicyEntry(ctx context.Context, name string) (*passwordPolicyEntry, error) {
+	view := c.systemBarrierView.SubView(passwordPolicySubPath)
+	raw, err := view.Get(ctx, name)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read password policy: {{err}}", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var entry passwordPolicyEntry
+	if err := raw.DecodeJSON(&entry); err != nil {
+		return nil, errwrap.Wrapf("failed to decode password policy: {{err}}", err)
+	}
+	return &entry, nil
+}
+
+// getPasswordPolicy returns the parsed password policy, or nil if there is no
+// policy of that name
+func (c *Core) getPasswordPolicy(ctx context.Context, name string) (*random.Policy, error) {
+	entry, err := c.getPasswordPolicyEntry(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	policy, err := random.ParsePolicy(entry.Policy)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to parse password policy %q: {{err}}", name), err)
+	}
+	return policy, nil
+}
+
+// handlePasswordPoliciesList lists the names of the password policies
+func (b *SystemBackend) handlePasswordPoliciesList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	keys, err := b.Core.systemBarrierView.SubView(passwordPolicySubPath).List(ctx, "")
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list password policies: {{err}}", err)
+	}
+	return logical.ListResponse(keys), nil
+}
+
+// handlePasswordPoliciesRead returns a password policy as it was written
+func (b *SystemBackend) handlePasswordPoliciesRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := strings.TrimSpace(data.Get("name").(string))
+
+	entry, err := b.Core.getPasswordPolicyEntry(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":   entry.Name,
+			"policy": entry.Policy,
+		},
+	}, nil
+}
+
+// handlePasswordPoliciesSet validates and stores a password policy
+func (b *SystemBackend) handlePasswordPoliciesSet(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := strings.TrimSpace(data.Get("name").(string))
+	if name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+	if strings.Contains(name, "/") {
+		return logical.ErrorResponse("name must not contain a slash"), nil
+	}
+
+	rawPolicy := data.Get("policy").(string)
+	if rawPolicy == "" {
+		return logical.ErrorResponse("missing policy"), nil
+	}
+	if _, err := random.ParsePolicy(rawPolicy); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(name, &passwordPolicyEntry{
+		Name:   name,
+		Policy: rawPolicy,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to encode password policy: {{err}}", err)
+	}
+	if err := b.Core.systemBarrierView.SubView(passwordPolicySubPath).Put(ctx, entry); err != nil {
+		return nil, errwrap.Wrapf("failed to persist password policy: {{err}}", err)
+	}
+	return nil, nil
+}
+
+// handlePasswordPoliciesDelete deletes a password policy
+func (b *SystemBackend) handlePasswordPoliciesDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := strings.TrimSpace(data.Get("name").(string))
+
+	if err := b.Core.systemBarrierView.SubView(passwordPolicySubPath).Delete(ctx, name); err != nil {
+		return nil, errwrap.Wrapf("failed to delete password policy: {{err}}", err)
+	}
+	return nil, nil
+}
+
+// handlePasswordPoliciesGenerate returns a password following the password
+// policy
+func (b *SystemBackend) handlePasswordPoliciesGenerate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := strings.TrimSpace(data.Get("name").(string))
+
+	policy, err := b.Core.getPasswordPolicy(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return logical.ErrorResponse(fmt.Sprintf("password policy %q not found", name)), logical.ErrInvalidRequest
+	}
+
+	password, err := policy.Generate(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"password": password,
+		},
+	}, nil
+}
@@ -0,0 +1,57 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// handlePoliciesUsage reports the tokens, entities, groups and token roles
+// referring to a policy, so that it can be checked before the policy is
+// deleted. The policy need not exist: references to a deleted policy are
+// reported as well.
+func (b *SystemBackend) handlePoliciesUsage(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns := b.requestNamespace(req)
+	name := b.Core.namespacePolicyStore(ns).sanitizeName(d.Get("name").(string))
+	if name == "" {
+		return logical.ErrorResponse("missing policy name"), nil
+	}
+
+	accessors, err := b.Core.tokenStore.policyTokenAccessors(ctx, name, ns.ID)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to look up tokens: {{err}}", err)
+	}
+
+	// Entities, groups and token roles live in the root namespace and refer
+	// to its policies
+	entities := map[string]interface{}{}
+	groups := map[string]interface{}{}
+	roles := []string{}
+	if ns.ID == rootNamespace.ID {
+		if b.Core.identityStore != nil {
+			entities, groups, err = b.Core.identityStore.policyReferences(name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		roles, err = b.Core.tokenStore.policyTokenRoles(ctx, name)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to look up token roles: {{err}}", err)
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name": name,
+			"tokens": map[string]interface{}{
+				"count":     len(accessors),
+				"accessors": accessors,
+			},
+			"entities":    entities,
+			"groups":      groups,
+			"token_roles": roles,
+		},
+	}, nil
+}
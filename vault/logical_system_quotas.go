@@ -0,0 +1,106 @@
+package vault
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// handleLeaseCountQuotasList lists the names of the lease count quotas
+func (b *SystemBackend) handleLeaseCountQuotasList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	keys, err := b.Core.systemBarrierView.SubView(leaseCountQuotaSubPath).List(ctx, "")
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list lease count quotas: {{err}}", err)
+	}
+	return logical.ListResponse(keys), nil
+}
+
+// handleLeaseCountQuotasRead returns a lease count quota, along with the
+// number of live leases counting against it
+func (b *SystemBackend) handleLeaseCountQuotasRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := strings.TrimSpace(data.Get("name").(string))
+
+	quota := b.Core.leaseCountQuotas.get(name)
+	if quota == nil {
+		return nil, nil
+	}
+
+	respData := map[string]interface{}{
+		"name":       quota.Name,
+		"path":       quota.Path,
+		"max_leases": quota.MaxLeases,
+	}
+	if b.Core.expiration != nil {
+		respData["leases"] = b.Core.expiration.leaseCount(quota)
+	}
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+// handleLeaseCountQuotasSet validates and stores a lease count quota. The
+// fields not given keep their value when updating a quota.
+func (b *SystemBackend) handleLeaseCountQuotasSet(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := strings.TrimSpace(data.Get("name").(string))
+	if name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+	if strings.Contains(name, "/") {
+		return logical.ErrorResponse("name must not contain a slash"), nil
+	}
+
+	quota := &leaseCountQuota{
+		Name: name,
+	}
+	if existing := b.Core.leaseCountQuotas.get(name); existing != nil {
+		quota.Path = existing.Path
+		quota.MaxLeases = existing.MaxLeases
+	}
+
+	if pathRaw, ok := data.GetOk("path"); ok {
+		path, err := b.Core.validateLeaseCountQuotaPath(pathRaw.(string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		quota.Path = path
+	}
+	if maxLeasesRaw, ok := data.GetOk("max_leases"); ok {
+		quota.MaxLeases = maxLeasesRaw.(int)
+	}
+	if quota.MaxLeases <= 0 {
+		return logical.ErrorResponse("max_leases must be greater than zero"), logical.ErrInvalidRequest
+	}
+	quota.prefixes = b.Core.leaseCountQuotaPrefixes(quota.Path)
+
+	entry, err := logical.StorageEntryJSON(name, quota)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to encode lease count quota: {{err}}", err)
+	}
+	if err := b.Core.systemBarrierView.SubView(leaseCountQuotaSubPath).Put(ctx, entry); err != nil {
+		return nil, errwrap.Wrapf("failed to persist lease count quota: {{err}}", err)
+	}
+	if b.Core.expiration != nil {
+		b.Core.expiration.setLeaseCountQuota(quota)
+	} else {
+		b.Core.leaseCountQuotas.set(quota)
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("lease count quota set", "name", name, "path", quota.Path, "max_leases", quota.MaxLeases)
+	}
+	return nil, nil
+}
+
+// handleLeaseCountQuotasDelete deletes a lease count quota
+func (b *SystemBackend) handleLeaseCountQuotasDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := strings.TrimSpace(data.Get("name").(string))
+
+	if err := b.Core.systemBarrierView.SubView(leaseCountQuotaSubPath).Delete(ctx, name); err != nil {
+		return nil, errwrap.Wrapf("failed to delete lease count quota: {{err}}", err)
+	}
+	b.Core.leaseCountQuotas.remove(name)
+	return nil, nil
+}
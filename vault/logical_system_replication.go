@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/wrapping"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// handleReplicationStatus returns the replication status of the cluster
+func (b *SystemBackend) handleReplicationStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	repState := b.Core.ReplicationState()
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"mode": repState.GetPerformanceString(),
+			"dr":   b.Core.drReplicationStatus(),
+		},
+	}, nil
+}
+
+// handleReplicationDRPrimaryEnable turns this cluster into a DR primary
+func (b *SystemBackend) handleReplicationDRPrimaryEnable(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.drPrimaryEnable(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	return nil, nil
+}
+
+// handleReplicationDRSecondaryToken registers a new secondary and returns its
+// activation token
+func (b *SystemBackend) handleReplicationDRSecondaryToken(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	id := data.Get("id").(string)
+	if id == "" {
+		return logical.ErrorResponse("missing secondary id"), nil
+	}
+	ttl := time.Duration(data.Get("ttl").(int)) * time.Second
+	if ttl <= 0 {
+		return logical.ErrorResponse("ttl must be positive"), nil
+	}
+
+	if !b.Core.ReplicationState().HasState(consts.ReplicationDRPrimary) {
+		return logical.ErrorResponse(ErrDRNotPrimary.Error()), logical.ErrInvalidRequest
+	}
+	secret, err := b.Core.drPrimaryAddSecondary(ctx, id)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":     id,
+			"secret": secret,
+		},
+		WrapInfo: &wrapping.ResponseWrapInfo{
+			TTL:    ttl,
+			Format: "jwt",
+		},
+	}, nil
+}
+
+// handleReplicationDRRevokeSecondary removes a secondary from the primary
+func (b *SystemBackend) handleReplicationDRRevokeSecondary(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	id := data.Get("id").(string)
+	if id == "" {
+		return logical.ErrorResponse("missing secondary id"), nil
+	}
+
+	if err := b.Core.drPrimaryRevokeSecondary(ctx, id); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	return nil, nil
+}
+
+// handleReplicationDRFetch returns storage updates to an authenticated
+// secondary
+func (b *SystemBackend) handleReplicationDRFetch(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if !b.Core.ReplicationState().HasState(consts.ReplicationDRPrimary) {
+		return logical.ErrorResponse(ErrDRNotPrimary.Error()), logical.ErrInvalidRequest
+	}
+	if !b.Core.drAuthenticateSecondary(data.Get("id").(string), data.Get("secret").(string)) {
+		return nil, logical.ErrPermissionDenied
+	}
+
+	index := data.Get("index").(int)
+	if index < 0 {
+		return logical.ErrorResponse("index cannot be negative"), nil
+	}
+
+	respData, err := b.Core.drPrimaryFetch(ctx, data.Get("epoch").(string), uint64(index), data.Get("keys").([]string))
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+// handleReplicationDRSecondaryEnable connects this cluster to a DR primary
+func (b *SystemBackend) handleReplicationDRSecondaryEnable(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	token := data.Get("token").(string)
+	if token == "" {
+		return logical.ErrorResponse("missing secondary activation token"), nil
+	}
+
+	if err := b.Core.drSecondaryEnable(ctx, token, data.Get("primary_api_addr").(string), data.Get("ca_file").(string), data.Get("ca_path").(string)); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	resp := &logical.Response{}
+	resp.AddWarning("This node will seal once the initial sync completes. It must then be unsealed using the primary's unseal keys.")
+	return resp, nil
+}
+
+// handleReplicationDRSecondaryPromote promotes this DR secondary to a primary
+func (b *SystemBackend) handleReplicationDRSecondaryPromote(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.drSecondaryPromote(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	return nil, nil
+}
@@ -0,0 +1,451 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// secretsSyncPaths returns the paths managing the destinations KV secrets are
+// pushed to, and the secrets pushed to them
+func (b *SystemBackend) secretsSyncPaths() []*framework.Path {
+	destinationFields := map[string]*framework.FieldSchema{
+		"type": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["sync-destination-type"][0]),
+		},
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["sync-destination-name"][0]),
+		},
+	}
+
+	configFields := map[string]*framework.FieldSchema{
+		"secret_name_prefix": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Default:     "vault-",
+			Description: strings.TrimSpace(sysHelp["sync-secret-name-prefix"][0]),
+		},
+	}
+	for _, name := range []string{
+		"kubernetes_host", "kubernetes_ca_cert", "kubernetes_token", "kubernetes_namespace",
+		"aws_region", "aws_access_key_id", "aws_secret_access_key", "aws_endpoint",
+		"gcp_project_id", "gcp_credentials", "gcp_endpoint",
+	} {
+		configFields[name] = &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["sync-"+strings.Replace(name, "_", "-", -1)][0]),
+		}
+	}
+	for name, field := range destinationFields {
+		configFields[name] = field
+	}
+
+	associationFields := map[string]*framework.FieldSchema{
+		"mount": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["sync-association-mount"][0]),
+		},
+		"secret_name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["sync-association-secret-name"][0]),
+		},
+	}
+	for name, field := range destinationFields {
+		associationFields[name] = field
+	}
+
+	destinationPattern := "sync/destinations/" + framework.GenericNameRegex("type") + "/" + framework.GenericNameRegex("name")
+
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "sync/destinations/?$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.handleSyncDestinationsList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["sync-destinations"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["sync-destinations"][1]),
+		},
+
+		&framework.Path{
+			Pattern: destinationPattern + "$",
+
+			Fields: configFields,
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.handleSyncDestinationRead,
+				logical.UpdateOperation: b.handleSyncDestinationSet,
+				logical.DeleteOperation: b.handleSyncDestinationDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["sync-destination"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["sync-destination"][1]),
+		},
+
+		&framework.Path{
+			Pattern: destinationPattern + "/associations$",
+
+			Fields: destinationFields,
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handleSyncAssociationsRead,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["sync-associations"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["sync-associations"][1]),
+		},
+
+		&framework.Path{
+			Pattern: destinationPattern + "/associations/set$",
+
+			Fields: associationFields,
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleSyncAssociationSet,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["sync-associations-set"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["sync-associations-set"][1]),
+		},
+
+		&framework.Path{
+			Pattern: destinationPattern + "/associations/remove$",
+
+			Fields: associationFields,
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleSyncAssociationRemove,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["sync-associations-remove"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["sync-associations-remove"][1]),
+		},
+	}
+}
+
+// syncDestinationKey returns the key of the destination of the request,
+// checking its type
+func syncDestinationKey(data *framework.FieldData) (string, error) {
+	destType := data.Get("type").(string)
+	if !strutil.StrListContains(secretsSyncTypes, destType) {
+		return "", fmt.Errorf("unsupported destination type %q; must be one of %q", destType, secretsSyncTypes)
+	}
+	return destType + "/" + data.Get("name").(string), nil
+}
+
+// persistSyncDestination stores a destination
+func (b *SystemBackend) persistSyncDestination(ctx context.Context, d *syncDestination) error {
+	entry, err := logical.StorageEntryJSON(d.key(), d)
+	if err != nil {
+		return errwrap.Wrapf("failed to encode sync destination: {{err}}", err)
+	}
+	if err := b.Core.systemBarrierView.SubView(secretsSyncSubPath).Put(ctx, entry); err != nil {
+		return errwrap.Wrapf("failed to persist sync destination: {{err}}", err)
+	}
+	return nil
+}
+
+// handleSyncDestinationsList lists the keys of the sync destinations, their
+// types and names
+func (b *SystemBackend) handleSyncDestinationsList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var keys []string
+	for _, d := range b.Core.secretsSync.list() {
+		keys = append(keys, d.key())
+	}
+	return logical.ListResponse(keys), nil
+}
+
+// handleSyncDestinationRead returns a sync destination, without its secrets
+func (b *SystemBackend) handleSyncDestinationRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	key, err := syncDestinationKey(data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	d := b.Core.secretsSync.get(key)
+	if d == nil {
+		return nil, nil
+	}
+
+	respData := map[string]interface{}{
+		"type":               d.Type,
+		"name":               d.Name,
+		"secret_name_prefix": d.SecretNamePrefix,
+		"associations":       len(d.Associations),
+	}
+	switch d.Type {
+	case "kubernetes":
+		respData["kubernetes_host"] = d.KubernetesHost
+		respData["kubernetes_ca_cert"] = d.KubernetesCACert
+		respData["kubernetes_namespace"] = d.KubernetesNamespace
+	case "aws-sm":
+		respData["aws_region"] = d.AWSRegion
+		respData["aws_access_key_id"] = d.AWSAccessKeyID
+		respData["aws_endpoint"] = d.AWSEndpoint
+	case "gcp-sm":
+		respData["gcp_project_id"] = d.GCPProjectID
+		respData["gcp_endpoint"] = d.GCPEndpoint
+	}
+
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+// handleSyncDestinationSet validates and stores a sync destination. The
+// fields not given keep their value when updating a destination. The secrets
+// already pushed are not moved when its location changes.
+func (b *SystemBackend) handleSyncDestinationSet(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	key, err := syncDestinationKey(data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	b.Core.secretsSync.writeLock.Lock()
+	defer b.Core.secretsSync.writeLock.Unlock()
+
+	d := &syncDestination{
+		Type:             data.Get("type").(string),
+		Name:             data.Get("name").(string),
+		SecretNamePrefix: data.Get("secret_name_prefix").(string),
+	}
+	existing := b.Core.secretsSync.get(key)
+	if existing != nil {
+		*d = *existing
+	}
+
+	for field, value := range map[string]*string{
+		"kubernetes_host":       &d.KubernetesHost,
+		"kubernetes_ca_cert":    &d.KubernetesCACert,
+		"kubernetes_token":      &d.KubernetesToken,
+		"kubernetes_namespace":  &d.KubernetesNamespace,
+		"aws_region":            &d.AWSRegion,
+		"aws_access_key_id":     &d.AWSAccessKeyID,
+		"aws_secret_access_key": &d.AWSSecretAccessKey,
+		"aws_endpoint":          &d.AWSEndpoint,
+		"gcp_project_id":        &d.GCPProjectID,
+		"gcp_credentials":       &d.GCPCredentials,
+		"gcp_endpoint":          &d.GCPEndpoint,
+	} {
+		if raw, ok := data.GetOk(field); ok {
+			*value = raw.(string)
+		}
+	}
+	if raw, ok := data.GetOk("secret_name_prefix"); ok && raw.(string) != d.SecretNamePrefix {
+		if len(d.Associations) > 0 {
+			return logical.ErrorResponse("secret_name_prefix cannot be changed while secrets are associated with the destination"), logical.ErrInvalidRequest
+		}
+		d.SecretNamePrefix = raw.(string)
+	}
+
+	client, err := b.Core.syncDestinationClient(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	if err := b.persistSyncDestination(ctx, d); err != nil {
+		return nil, err
+	}
+	b.Core.secretsSync.set(d, client)
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("sync destination set", "destination", key)
+	}
+	return nil, nil
+}
+
+// handleSyncDestinationDelete deletes a sync destination, which must have no
+// associations left
+func (b *SystemBackend) handleSyncDestinationDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	key, err := syncDestinationKey(data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	b.Core.secretsSync.writeLock.Lock()
+	defer b.Core.secretsSync.writeLock.Unlock()
+
+	d := b.Core.secretsSync.get(key)
+	if d == nil {
+		return nil, nil
+	}
+	if len(d.Associations) > 0 {
+		return logical.ErrorResponse("secrets are still associated with the destination; remove the associations first"), logical.ErrInvalidRequest
+	}
+
+	if err := b.Core.systemBarrierView.SubView(secretsSyncSubPath).Delete(ctx, key); err != nil {
+		return nil, errwrap.Wrapf("failed to delete sync destination: {{err}}", err)
+	}
+	b.Core.secretsSync.remove(key)
+	return nil, nil
+}
+
+// syncStatusData returns the status of an association as the data of a
+// response
+func syncStatusData(d *syncDestination, a *syncAssociation, status syncStatus) map[string]interface{} {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"mount":               a.Mount,
+		"secret_name":         a.SecretName,
+		"remote_name":         a.remoteName(d),
+		"sync_status":         status.Status,
+		"last_synced":         formatTime(status.LastSynced),
+		"last_checked":        formatTime(status.LastChecked),
+		"last_drift_detected": formatTime(status.LastDrift),
+		"error":               status.Error,
+	}
+}
+
+// handleSyncAssociationsRead returns the secrets pushed to a destination and
+// the status of their synchronization, keyed by their path
+func (b *SystemBackend) handleSyncAssociationsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	key, err := syncDestinationKey(data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	d := b.Core.secretsSync.get(key)
+	if d == nil {
+		return nil, nil
+	}
+
+	associations := make(map[string]interface{}, len(d.Associations))
+	for _, a := range d.Associations {
+		associations[a.path()] = syncStatusData(d, a, b.Core.secretsSync.status(d, a))
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"associations": associations,
+		},
+	}, nil
+}
+
+// syncAssociationRequest returns the destination and the association of the
+// request, checking that the mount is a kv mount
+func (b *SystemBackend) syncAssociationRequest(data *framework.FieldData) (*syncDestination, *syncAssociation, error) {
+	key, err := syncDestinationKey(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := b.Core.secretsSync.get(key)
+	if d == nil {
+		return nil, nil, fmt.Errorf("sync destination %q not found", key)
+	}
+
+	mount := strings.TrimPrefix(data.Get("mount").(string), "/")
+	if mount == "" {
+		return nil, nil, fmt.Errorf("missing mount")
+	}
+	if !strings.HasSuffix(mount, "/") {
+		mount += "/"
+	}
+	secretName := strings.Trim(data.Get("secret_name").(string), "/")
+	if secretName == "" {
+		return nil, nil, fmt.Errorf("missing secret_name")
+	}
+
+	return d, &syncAssociation{
+		Mount:      mount,
+		SecretName: secretName,
+	}, nil
+}
+
+// handleSyncAssociationSet associates a KV secret with a destination and
+// pushes it right away, returning the outcome
+func (b *SystemBackend) handleSyncAssociationSet(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.Core.secretsSync.writeLock.Lock()
+	defer b.Core.secretsSync.writeLock.Unlock()
+
+	d, a, err := b.syncAssociationRequest(data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	entry := b.Core.router.MatchingMountEntry(a.Mount)
+	if entry == nil || entry.Path != a.Mount {
+		return logical.ErrorResponse(fmt.Sprintf("no mount found at %q", a.Mount)), logical.ErrInvalidRequest
+	}
+	if entry.Type != "kv" && entry.Type != "generic" {
+		return logical.ErrorResponse(fmt.Sprintf("mount %q is not a kv mount", a.Mount)), logical.ErrInvalidRequest
+	}
+
+	if existing := d.association(a.Mount, a.SecretName); existing != nil {
+		a = existing
+	} else {
+		updated := *d
+		updated.Associations = append(append([]*syncAssociation{}, d.Associations...), a)
+		if err := b.persistSyncDestination(ctx, &updated); err != nil {
+			return nil, err
+		}
+		b.Core.secretsSync.set(&updated, b.Core.secretsSync.client(d.key()))
+		d = &updated
+	}
+
+	status := b.Core.syncSecret(ctx, d, a, false)
+	resp := &logical.Response{
+		Data: syncStatusData(d, a, status),
+	}
+	if status.Status == syncStatusFailed {
+		resp.AddWarning("the secret was associated with the destination but could not be pushed to it: " + status.Error)
+	}
+	return resp, nil
+}
+
+// handleSyncAssociationRemove stops pushing a KV secret to a destination,
+// and deletes it there
+func (b *SystemBackend) handleSyncAssociationRemove(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.Core.secretsSync.writeLock.Lock()
+	defer b.Core.secretsSync.writeLock.Unlock()
+
+	d, a, err := b.syncAssociationRequest(data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if d.association(a.Mount, a.SecretName) == nil {
+		return nil, nil
+	}
+
+	updated := *d
+	updated.Associations = nil
+	for _, existing := range d.Associations {
+		if existing.Mount != a.Mount || existing.SecretName != a.SecretName {
+			updated.Associations = append(updated.Associations, existing)
+		}
+	}
+	if err := b.persistSyncDestination(ctx, &updated); err != nil {
+		return nil, err
+	}
+	client := b.Core.secretsSync.client(d.key())
+	b.Core.secretsSync.set(&updated, client)
+
+	var warnings []string
+	if client == nil {
+		warnings = append(warnings, "the destination is misconfigured; the secret was not deleted from it")
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, secretsSyncTimeout)
+		defer cancel()
+		if err := client.Delete(ctx, a.remoteName(d)); err != nil {
+			warnings = append(warnings, "the secret could not be deleted from the destination: "+err.Error())
+		}
+	}
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+
+	resp := &logical.Response{}
+	for _, warning := range warnings {
+		resp.AddWarning(warning)
+	}
+	return resp, nil
+}
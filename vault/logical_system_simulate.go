@@ -0,0 +1,129 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// handlePoliciesSimulate evaluates whether a request would be allowed by the
+// policies of a token, or by a given set of policies, without making it
+func (b *SystemBackend) handlePoliciesSimulate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	path := strings.TrimPrefix(d.Get("path").(string), "/")
+	if path == "" {
+		return logical.ErrorResponse("missing path"), nil
+	}
+
+	op := logical.Operation(strings.ToLower(d.Get("operation").(string)))
+	switch op {
+	case logical.CreateOperation, logical.ReadOperation, logical.UpdateOperation, logical.DeleteOperation, logical.ListOperation:
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unsupported operation %q", op)), nil
+	}
+
+	ns := b.requestNamespace(req)
+	names := d.Get("policies").([]string)
+	rules := d.Get("policy").(string)
+	token := d.Get("token").(string)
+
+	var policies []*Policy
+	var restrictions []*ACL
+	switch {
+	case token != "" && (len(names) > 0 || rules != ""):
+		return logical.ErrorResponse("token cannot be given along with policies or policy"), nil
+
+	case len(names) > 0 || rules != "":
+		ps := b.Core.namespacePolicyStore(ns)
+		for _, name := range names {
+			policy, err := ps.GetPolicy(ctx, strings.ToLower(name), PolicyTypeACL)
+			if err != nil {
+				return handleError(err)
+			}
+			if policy == nil {
+				return logical.ErrorResponse(fmt.Sprintf("policy %q does not exist", name)), nil
+			}
+			policies = append(policies, policy)
+		}
+
+		// The policy being tested is evaluated as if it were stored
+		if rules != "" {
+			policy, err := ParseACLPolicy(rules)
+			if err != nil {
+				return logical.ErrorResponse(errwrap.Wrapf("failed to parse policy: {{err}}", err).Error()), nil
+			}
+			policy.Name = "(policy)"
+			if ns != nil && ns.ID != "" {
+				policy.Paths = namespacePolicyPaths(ns, policy.Paths)
+			}
+			policies = append(policies, policy)
+		}
+
+	default:
+		if token == "" {
+			token = req.ClientToken
+		}
+		var err error
+		policies, restrictions, err = b.Core.tokenPolicies(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	acl, err := NewACL(policies)
+	if err != nil {
+		return nil, err
+	}
+	acl.restrict(restrictions...)
+
+	simReq := &logical.Request{
+		Operation: op,
+		Path:      namespaceAPIPath(ns, path),
+		Data:      d.Get("parameters").(map[string]interface{}),
+	}
+	results := acl.AllowOperation(simReq)
+
+	capabilities := acl.Capabilities(simReq.Path)
+	sort.Strings(capabilities)
+
+	respData := map[string]interface{}{
+		"allowed":      results.Allowed,
+		"root_privs":   results.RootPrivs,
+		"capabilities": capabilities,
+	}
+
+	// Report the rule the path is governed by and the policies it was
+	// merged from
+	if results.IsRoot {
+		respData["matching_policies"] = []string{"root"}
+	} else if prefix, glob, ok := acl.matchingRule(simReq.Path); ok {
+		rulePath := prefix
+		if glob {
+			rulePath += "*"
+		}
+		respData["matching_rule"] = rulePath
+
+		matching := []string{}
+		for _, policy := range policies {
+			if policy == nil {
+				continue
+			}
+			for _, pr := range policy.Paths {
+				if pr.Prefix == prefix && pr.Glob == glob {
+					matching = append(matching, policy.Name)
+					break
+				}
+			}
+		}
+		sort.Strings(matching)
+		respData["matching_policies"] = matching
+	}
+
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
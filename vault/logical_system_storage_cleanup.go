@@ -0,0 +1,46 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// handleStorageCleanup reports the lease, token and cubbyhole entries left
+// behind in storage, and deletes them on update
+func (b *SystemBackend) handleStorageCleanup(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if b.requestNamespace(req).ID != rootNamespace.ID {
+		return logical.ErrorResponse("storage can only be cleaned up from the root namespace"), logical.ErrInvalidRequest
+	}
+
+	result, err := b.Core.cleanupStorage(ctx, req.Operation == logical.UpdateOperation)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"orphaned_leases": map[string]interface{}{
+				"count":     len(result.OrphanedLeases),
+				"lease_ids": result.OrphanedLeases,
+			},
+			"expired_leases": map[string]interface{}{
+				"count":     len(result.ExpiredLeases),
+				"lease_ids": result.ExpiredLeases,
+			},
+			"unreaped_tokens": map[string]interface{}{
+				"count":     len(result.UnreapedTokens),
+				"accessors": result.UnreapedTokens,
+			},
+			"orphaned_cubbyholes": map[string]interface{}{
+				"count": result.OrphanedCubbyholes,
+			},
+			"deleted": result.Deleted,
+		},
+	}
+	for _, err := range result.Errors {
+		resp.AddWarning(err)
+	}
+	return resp, nil
+}
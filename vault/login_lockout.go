@@ -0,0 +1,260 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// defaultLockoutDuration is how long a user or client stays locked out
+	// of an auth method, and how long its failed logins are remembered, if
+	// the mount sets no lockout_duration
+	defaultLockoutDuration = 15 * time.Minute
+)
+
+// loginLockout tracks the consecutive failed logins to an auth method, by
+// user and by client address, to lock out those reaching the thresholds the
+// mount is tuned to. The thresholds are passed on each call, so that tuning
+// the mount applies to the following logins. The failures are kept in the
+// memory of the node handling the logins.
+type loginLockout struct {
+	l         sync.Mutex
+	users     map[string]*lockoutEntry
+	clients   map[string]*lockoutEntry
+	lastPrune time.Time
+}
+
+// lockoutEntry tracks the failed logins of a user or client
+type lockoutEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// lockoutSettings are the lockout settings of an auth method
+type lockoutSettings struct {
+	threshold   int
+	ipThreshold int
+	duration    time.Duration
+}
+
+func (s lockoutSettings) enabled() bool {
+	return s.threshold > 0 || s.ipThreshold > 0
+}
+
+// validate checks the lockout settings of an auth method
+func (s lockoutSettings) validate() error {
+	switch {
+	case s.threshold < 0:
+		return fmt.Errorf("lockout_threshold cannot be negative")
+	case s.ipThreshold < 0:
+		return fmt.Errorf("lockout_ip_threshold cannot be negative")
+	case s.duration < 0:
+		return fmt.Errorf("lockout_duration cannot be negative")
+	}
+	return nil
+}
+
+func mountLockoutSettings(config MountConfig) lockoutSettings {
+	settings := lockoutSettings{
+		threshold:   config.LockoutThreshold,
+		ipThreshold: config.LockoutIPThreshold,
+		duration:    config.LockoutDuration,
+	}
+	if settings.duration == 0 {
+		settings.duration = defaultLockoutDuration
+	}
+	return settings
+}
+
+// locked returns whether the user or the client is locked out. Either may
+// be empty if unknown.
+func (l *loginLockout) locked(user, client string, now time.Time) bool {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	if e, ok := l.users[user]; ok && user != "" && now.Before(e.lockedUntil) {
+		return true
+	}
+	if e, ok := l.clients[client]; ok && client != "" && now.Before(e.lockedUntil) {
+		return true
+	}
+	return false
+}
+
+// failed records a failed login of the user from the client, locking out
+// either if it reaches its threshold
+func (l *loginLockout) failed(user, client string, settings lockoutSettings, now time.Time) {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	l.pruneLocked(settings.duration, now)
+
+	if user != "" && settings.threshold > 0 {
+		if l.users == nil {
+			l.users = make(map[string]*lockoutEntry)
+		}
+		l.users[user] = recordFailure(l.users[user], settings.threshold, settings.duration, now)
+	}
+	if client != "" && settings.ipThreshold > 0 {
+		if l.clients == nil {
+			l.clients = make(map[string]*lockoutEntry)
+		}
+		l.clients[client] = recordFailure(l.clients[client], settings.ipThreshold, settings.duration, now)
+	}
+}
+
+// recordFailure counts a failure on the entry, forgetting the failures older
+// than the lockout duration
+func recordFailure(e *lockoutEntry, threshold int, duration time.Duration, now time.Time) *lockoutEntry {
+	if e == nil || now.Sub(e.lastFailure) > duration {
+		e = new(lockoutEntry)
+	}
+	e.failures++
+	e.lastFailure = now
+	if e.failures >= threshold {
+		e.lockedUntil = now.Add(duration)
+		e.failures = 0
+	}
+	return e
+}
+
+// unlock clears the lockout and failed logins of the user and the client,
+// either of which may be empty
+func (l *loginLockout) unlock(user, client string) {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	if user != "" {
+		delete(l.users, user)
+	}
+	if client != "" {
+		delete(l.clients, client)
+	}
+}
+
+// lockedOut returns the users and clients currently locked out, with the
+// time their lockout ends
+func (l *loginLockout) lockedOut(now time.Time) (users, clients map[string]time.Time) {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	collect := func(entries map[string]*lockoutEntry) map[string]time.Time {
+		locked := make(map[string]time.Time)
+		for key, e := range entries {
+			if now.Before(e.lockedUntil) {
+				locked[key] = e.lockedUntil
+			}
+		}
+		return locked
+	}
+	return collect(l.users), collect(l.clients)
+}
+
+// pruneLocked drops the entries which are neither locked out nor have
+// recent failures, at most once per lockout duration
+func (l *loginLockout) pruneLocked(duration time.Duration, now time.Time) {
+	if now.Sub(l.lastPrune) < duration {
+		return
+	}
+	l.lastPrune = now
+
+	for _, entries := range []map[string]*lockoutEntry{l.users, l.clients} {
+		for key, e := range entries {
+			if now.After(e.lockedUntil) && now.Sub(e.lastFailure) > duration {
+				delete(entries, key)
+			}
+		}
+	}
+}
+
+// checkLoginLockout refuses the login request if the auth method locked out
+// its user or client. Otherwise, it returns a function recording the outcome
+// of the login, or nil if the auth method has no lockout.
+func (c *Core) checkLoginLockout(ctx context.Context, req *logical.Request) (func(*logical.Response, error), *logical.Response, error) {
+	mountEntry := c.router.MatchingMountEntry(req.Path)
+	if mountEntry == nil {
+		return nil, nil, nil
+	}
+	settings := mountLockoutSettings(mountEntry.Config)
+	if !settings.enabled() {
+		return nil, nil, nil
+	}
+	lockout := c.router.MatchingLoginLockout(req.Path)
+	if lockout == nil {
+		return nil, nil, nil
+	}
+
+	var client string
+	if req.Connection != nil {
+		client = req.Connection.RemoteAddr
+	}
+	user := c.loginAliasName(ctx, req)
+
+	if lockout.locked(user, client, time.Now()) {
+		c.logger.Warn("login refused due to lockout", "path", req.Path, "remote_address", client)
+		return nil, logical.ErrorResponse(logical.ErrLoginLockedOut.Error()), logical.ErrLoginLockedOut
+	}
+
+	record := func(resp *logical.Response, err error) {
+		switch {
+		case resp != nil && resp.Auth != nil && err == nil:
+			// Only the user's failures are cleared. The client keeps
+			// counting towards its threshold, or one valid account would
+			// be enough to try the passwords of any number of others.
+			lockout.unlock(user, "")
+		case loginRejected(req, resp, err):
+			lockout.failed(user, client, settings, time.Now())
+		}
+	}
+	return record, nil, nil
+}
+
+// loginAliasName returns the name of the alias the login request is for,
+// looked ahead from the auth method, or the empty string if the auth method
+// does not tell it
+func (c *Core) loginAliasName(ctx context.Context, req *logical.Request) string {
+	lookaheadReq := &logical.Request{
+		Operation:       logical.AliasLookaheadOperation,
+		Path:            req.Path,
+		Data:            req.Data,
+		Connection:      req.Connection,
+		Headers:         req.Headers,
+		Unauthenticated: true,
+	}
+	resp, err := c.router.Route(ctx, lookaheadReq)
+	if err != nil || resp == nil || resp.Auth == nil || resp.Auth.Alias == nil {
+		return ""
+	}
+	return resp.Auth.Alias.Name
+}
+
+// loginRejected returns whether the outcome of a login is a rejection of its
+// credentials, rather than a failure of Vault or of the auth method
+func loginRejected(req *logical.Request, resp *logical.Response, err error) bool {
+	if err == nil && (resp == nil || !resp.IsError()) {
+		return false
+	}
+	status, _ := logical.RespondErrorCommon(req, resp, err)
+	switch status {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	}
+	return false
+}
+
+// lockoutsData returns the output of the locked out users or clients
+func lockoutsData(locked map[string]time.Time) map[string]interface{} {
+	data := make(map[string]interface{}, len(locked))
+	for key, lockedUntil := range locked {
+		data[key] = map[string]interface{}{
+			"locked_until": lockedUntil.Format(time.RFC3339),
+		}
+	}
+	return data
+}
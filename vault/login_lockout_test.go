@@ -0,0 +1,84 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginLockout(t *testing.T) {
+	var l loginLockout
+	settings := lockoutSettings{
+		threshold:   3,
+		ipThreshold: 4,
+		duration:    time.Minute,
+	}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		l.failed("alice", "10.0.0.1", settings, now)
+	}
+	if l.locked("alice", "10.0.0.1", now) {
+		t.Fatal("locked out before the threshold")
+	}
+
+	// A success clears the failures
+	l.unlock("alice", "10.0.0.1")
+	for i := 0; i < 2; i++ {
+		l.failed("alice", "10.0.0.1", settings, now)
+	}
+	if l.locked("alice", "", now) {
+		t.Fatal("locked out before the threshold")
+	}
+
+	// Failures older than the duration are forgotten
+	l.failed("alice", "10.0.0.1", settings, now.Add(2*time.Minute))
+	if l.locked("alice", "", now.Add(2*time.Minute)) {
+		t.Fatal("locked out on forgotten failures")
+	}
+
+	now = now.Add(2 * time.Minute)
+	l.failed("alice", "10.0.0.1", settings, now)
+	l.failed("alice", "10.0.0.1", settings, now)
+	if !l.locked("alice", "", now) {
+		t.Fatal("expected the user to be locked out")
+	}
+	if l.locked("bob", "10.0.0.2", now) {
+		t.Fatal("expected other users and clients not to be locked out")
+	}
+
+	// The client reaches its threshold across users
+	l.failed("bob", "10.0.0.1", settings, now)
+	if !l.locked("carol", "10.0.0.1", now) {
+		t.Fatal("expected the client to be locked out")
+	}
+
+	users, clients := l.lockedOut(now)
+	if len(users) != 1 || !users["alice"].Equal(now.Add(time.Minute)) {
+		t.Fatalf("bad: %#v", users)
+	}
+	if len(clients) != 1 || !clients["10.0.0.1"].Equal(now.Add(time.Minute)) {
+		t.Fatalf("bad: %#v", clients)
+	}
+
+	// The lockout ends after the duration
+	if l.locked("alice", "10.0.0.1", now.Add(time.Minute)) {
+		t.Fatal("expected the lockout to end")
+	}
+
+	l.unlock("alice", "")
+	if l.locked("alice", "", now) {
+		t.Fatal("expected the user to be unlocked")
+	}
+	if !l.locked("", "10.0.0.1", now) {
+		t.Fatal("expected the client to stay locked out")
+	}
+
+	// Stale entries are pruned
+	l.failed("dave", "", settings, now.Add(time.Hour))
+	if _, ok := l.users["alice"]; ok {
+		t.Fatal("expected stale entries to be pruned")
+	}
+	if _, ok := l.clients["10.0.0.1"]; ok {
+		t.Fatal("expected stale entries to be pruned")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/strutil"
@@ -41,6 +42,12 @@ const (
 	mountTableType = "mounts"
 )
 
+var (
+	// mountDeletionInterval is how often the mounts pending deletion are
+	// checked for an elapsed grace period
+	mountDeletionInterval = time.Minute
+)
+
 // ListingVisibilityType represents the types for listing visibility
 type ListingVisibilityType string
 
@@ -174,18 +181,20 @@ func (t *MountTable) sortEntriesByPath() *MountTable {
 
 // MountEntry is used to represent a mount table entry
 type MountEntry struct {
-	Table            string            `json:"table"`              // The table it belongs to
-	Path             string            `json:"path"`               // Mount Path
-	Type             string            `json:"type"`               // Logical backend Type
-	Description      string            `json:"description"`        // User-provided description
-	UUID             string            `json:"uuid"`               // Barrier view UUID
-	BackendAwareUUID string            `json:"backend_aware_uuid"` // UUID that can be used by the backend as a helper when a consistent value is needed outside of storage.
-	Accessor         string            `json:"accessor"`           // Unique but more human-friendly ID. Does not change, not used for any sensitive things (like as a salt, which the UUID sometimes is).
-	Config           MountConfig       `json:"config"`             // Configuration related to this mount (but not backend-derived)
-	Options          map[string]string `json:"options"`            // Backend options
-	Local            bool              `json:"local"`              // Local mounts are not replicated or affected by replication
-	SealWrap         bool              `json:"seal_wrap"`          // Whether to wrap CSPs
-	Tainted          bool              `json:"tainted,omitempty"`  // Set as a Write-Ahead flag for unmount/remount
+	Table            string            `json:"table"`                   // The table it belongs to
+	Path             string            `json:"path"`                    // Mount Path
+	Type             string            `json:"type"`                    // Logical backend Type
+	Description      string            `json:"description"`             // User-provided description
+	UUID             string            `json:"uuid"`                    // Barrier view UUID
+	BackendAwareUUID string            `json:"backend_aware_uuid"`      // UUID that can be used by the backend as a helper when a consistent value is needed outside of storage.
+	Accessor         string            `json:"accessor"`                // Unique but more human-friendly ID. Does not change, not used for any sensitive things (like as a salt, which the UUID sometimes is).
+	Config           MountConfig       `json:"config"`                  // Configuration related to this mount (but not backend-derived)
+	Options          map[string]string `json:"options"`                 // Backend options
+	Local            bool              `json:"local"`                   // Local mounts are not replicated or affected by replication
+	SealWrap         bool              `json:"seal_wrap"`               // Whether to wrap CSPs
+	Tainted          bool              `json:"tainted,omitempty"`       // Set as a Write-Ahead flag for unmount/remount
+	NamespaceID      string            `json:"namespace_id,omitempty"`  // The namespace the mount belongs to; empty for the root namespace
+	DeletionTime     int64             `json:"deletion_time,omitempty"` // Unix time after which a disabled mount is deleted; zero unless pending deletion
 
 	// synthesizedConfigCache is used to cache configuration values. These
 	// particular values are cached since we want to get them at a point-in-time
@@ -204,6 +213,17 @@ type MountConfig struct {
 	AuditNonHMACResponseKeys  []string              `json:"audit_non_hmac_response_keys,omitempty" structs:"audit_non_hmac_response_keys" mapstructure:"audit_non_hmac_response_keys"`
 	ListingVisibility         ListingVisibilityType `json:"listing_visibility,omitempty" structs:"listing_visibility" mapstructure:"listing_visibility"`
 	PassthroughRequestHeaders []string              `json:"passthrough_request_headers,omitempty" structs:"passthrough_request_headers" mapstructure:"passthrough_request_headers"`
+	TokenType                 logical.TokenType     `json:"token_type,omitempty" structs:"token_type" mapstructure:"token_type"` // Only used by auth methods
+	MaxInFlightRequests       int                   `json:"max_in_flight_requests,omitempty" structs:"max_in_flight_requests" mapstructure:"max_in_flight_requests"`
+	MaxQueuedRequests         int                   `json:"max_queued_requests,omitempty" structs:"max_queued_requests" mapstructure:"max_queued_requests"`
+	DefaultRenewalIncrement   time.Duration         `json:"default_renewal_increment,omitempty" structs:"default_renewal_increment" mapstructure:"default_renewal_increment"` // Override for global default
+	MaxRenewalIncrement       time.Duration         `json:"max_renewal_increment,omitempty" structs:"max_renewal_increment" mapstructure:"max_renewal_increment"`             // Override for global default
+	RenewalGracePeriod        time.Duration         `json:"renewal_grace_period,omitempty" structs:"renewal_grace_period" mapstructure:"renewal_grace_period"`                // Override for global default
+	OutboundProxyURL          string                `json:"outbound_proxy_url,omitempty" structs:"outbound_proxy_url" mapstructure:"outbound_proxy_url"`                      // Override for global default
+	OutboundCACert            string                `json:"outbound_ca_cert,omitempty" structs:"outbound_ca_cert" mapstructure:"outbound_ca_cert"`                            // Added to the global CA certificates
+	LockoutThreshold          int                   `json:"lockout_threshold,omitempty" structs:"lockout_threshold" mapstructure:"lockout_threshold"`                         // Only used by auth methods
+	LockoutIPThreshold        int                   `json:"lockout_ip_threshold,omitempty" structs:"lockout_ip_threshold" mapstructure:"lockout_ip_threshold"`                // Only used by auth methods
+	LockoutDuration           time.Duration         `json:"lockout_duration,omitempty" structs:"lockout_duration" mapstructure:"lockout_duration"`                            // Only used by auth methods
 }
 
 // APIMountConfig is an embedded struct of api.MountConfigInput
@@ -216,6 +236,17 @@ type APIMountConfig struct {
 	AuditNonHMACResponseKeys  []string              `json:"audit_non_hmac_response_keys,omitempty" structs:"audit_non_hmac_response_keys" mapstructure:"audit_non_hmac_response_keys"`
 	ListingVisibility         ListingVisibilityType `json:"listing_visibility,omitempty" structs:"listing_visibility" mapstructure:"listing_visibility"`
 	PassthroughRequestHeaders []string              `json:"passthrough_request_headers,omitempty" structs:"passthrough_request_headers" mapstructure:"passthrough_request_headers"`
+	TokenType                 string                `json:"token_type,omitempty" structs:"token_type" mapstructure:"token_type"`
+	MaxInFlightRequests       int                   `json:"max_in_flight_requests,omitempty" structs:"max_in_flight_requests" mapstructure:"max_in_flight_requests"`
+	MaxQueuedRequests         int                   `json:"max_queued_requests,omitempty" structs:"max_queued_requests" mapstructure:"max_queued_requests"`
+	DefaultRenewalIncrement   string                `json:"default_renewal_increment,omitempty" structs:"default_renewal_increment" mapstructure:"default_renewal_increment"`
+	MaxRenewalIncrement       string                `json:"max_renewal_increment,omitempty" structs:"max_renewal_increment" mapstructure:"max_renewal_increment"`
+	RenewalGracePeriod        string                `json:"renewal_grace_period,omitempty" structs:"renewal_grace_period" mapstructure:"renewal_grace_period"`
+	OutboundProxyURL          string                `json:"outbound_proxy_url,omitempty" structs:"outbound_proxy_url" mapstructure:"outbound_proxy_url"`
+	OutboundCACert            string                `json:"outbound_ca_cert,omitempty" structs:"outbound_ca_cert" mapstructure:"outbound_ca_cert"`
+	LockoutThreshold          int                   `json:"lockout_threshold,omitempty" structs:"lockout_threshold" mapstructure:"lockout_threshold"`
+	LockoutIPThreshold        int                   `json:"lockout_ip_threshold,omitempty" structs:"lockout_ip_threshold" mapstructure:"lockout_ip_threshold"`
+	LockoutDuration           string                `json:"lockout_duration,omitempty" structs:"lockout_duration" mapstructure:"lockout_duration"`
 }
 
 // Clone returns a deep copy of the mount entry
@@ -257,9 +288,14 @@ func (c *Core) mount(ctx context.Context, entry *MountEntry) error {
 		entry.Path += "/"
 	}
 
-	// Prevent protected paths from being mounted
+	// Prevent protected paths from being mounted, both at the root and
+	// within a namespace
+	relPath, err := c.checkNamespaceMountPath(entry)
+	if err != nil {
+		return err
+	}
 	for _, p := range protectedMounts {
-		if strings.HasPrefix(entry.Path, p) {
+		if strings.HasPrefix(relPath, p) {
 			return logical.CodedError(403, fmt.Sprintf("cannot mount '%s'", entry.Path))
 		}
 	}
@@ -270,7 +306,14 @@ func (c *Core) mount(ctx context.Context, entry *MountEntry) error {
 			return logical.CodedError(403, fmt.Sprintf("Cannot mount more than one instance of '%s'", entry.Type))
 		}
 	}
-	return c.mountInternal(ctx, entry)
+	if err := c.mountInternal(ctx, entry); err != nil {
+		return err
+	}
+
+	c.publishMountEvent(entry.Path, "enable", map[string]string{
+		"type": entry.Type,
+	})
+	return nil
 }
 
 func (c *Core) mountInternal(ctx context.Context, entry *MountEntry) error {
@@ -366,12 +409,18 @@ func (c *Core) unmount(ctx context.Context, path string) error {
 	}
 
 	// Prevent protected paths from being unmounted
+	ns := c.namespaceByPath(path)
 	for _, p := range protectedMounts {
-		if strings.HasPrefix(path, p) {
+		if strings.HasPrefix(strings.TrimPrefix(path, ns.Path), p) {
 			return fmt.Errorf("cannot unmount %q", path)
 		}
 	}
-	return c.unmountInternal(ctx, path)
+	if err := c.unmountInternal(ctx, path); err != nil {
+		return err
+	}
+
+	c.publishMountEvent(path, "disable", nil)
+	return nil
 }
 
 func (c *Core) unmountInternal(ctx context.Context, path string) error {
@@ -493,6 +542,192 @@ func (c *Core) taintMountEntry(ctx context.Context, path string) error {
 	return nil
 }
 
+// scheduleUnmount disables the mount at the path right away, but only deletes
+// it, along with its leases and data, once the mount deletion grace period has
+// elapsed. It returns the time after which the mount is deleted.
+func (c *Core) scheduleUnmount(ctx context.Context, path string) (time.Time, error) {
+	// Ensure we end the path in a slash
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	// Prevent protected paths from being unmounted
+	ns := c.namespaceByPath(path)
+	for _, p := range protectedMounts {
+		if strings.HasPrefix(strings.TrimPrefix(path, ns.Path), p) {
+			return time.Time{}, fmt.Errorf("cannot unmount %q", path)
+		}
+	}
+
+	// Verify exact match of the route
+	match := c.router.MatchingMount(path)
+	if match == "" || path != match {
+		return time.Time{}, fmt.Errorf("no matching mount")
+	}
+
+	c.mountsLock.Lock()
+	defer c.mountsLock.Unlock()
+
+	var entry *MountEntry
+	for _, e := range c.mounts.Entries {
+		if e.Path == path {
+			entry = e
+			break
+		}
+	}
+	if entry == nil {
+		c.logger.Error("failed to find entry in mounts table", "path", path)
+		return time.Time{}, logical.CodedError(500, "failed to find entry in mounts table")
+	}
+
+	// Disabling a mount again keeps the original deletion time
+	if entry.DeletionTime != 0 {
+		return time.Unix(entry.DeletionTime, 0), nil
+	}
+
+	deletionTime := time.Now().Add(c.mountDeletionGracePeriod)
+	entry.DeletionTime = deletionTime.Unix()
+
+	// Update the mount table
+	if err := c.persistMounts(ctx, c.mounts, &entry.Local); err != nil {
+		entry.DeletionTime = 0
+		c.logger.Error("failed to update mounts table", "error", err)
+		return time.Time{}, logical.CodedError(500, "failed to update mounts table")
+	}
+
+	// Taint the router path to prevent routing while the deletion is pending
+	if err := c.router.Taint(path); err != nil {
+		return time.Time{}, err
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("mount scheduled for deletion", "path", path, "deletion_time", deletionTime.Format(time.RFC3339))
+	}
+	return deletionTime, nil
+}
+
+// restoreMount cancels the pending deletion of the mount at the path and
+// makes it serve requests again.
+func (c *Core) restoreMount(ctx context.Context, path string) error {
+	// Ensure we end the path in a slash
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	// Verify exact match of the route
+	match := c.router.MatchingMount(path)
+	if match == "" || path != match {
+		return fmt.Errorf("no matching mount")
+	}
+
+	c.mountsLock.Lock()
+	defer c.mountsLock.Unlock()
+
+	var entry *MountEntry
+	for _, e := range c.mounts.Entries {
+		if e.Path == path {
+			entry = e
+			break
+		}
+	}
+	if entry == nil || entry.Tainted {
+		return fmt.Errorf("no matching mount")
+	}
+	if entry.DeletionTime == 0 {
+		return fmt.Errorf("mount at %q is not pending deletion", path)
+	}
+
+	deletionTime := entry.DeletionTime
+	entry.DeletionTime = 0
+
+	// Update the mount table
+	if err := c.persistMounts(ctx, c.mounts, &entry.Local); err != nil {
+		entry.DeletionTime = deletionTime
+		c.logger.Error("failed to update mounts table", "error", err)
+		return logical.CodedError(500, "failed to update mounts table")
+	}
+
+	// Un-taint the path
+	if err := c.router.Untaint(path); err != nil {
+		return err
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("successfully restored mount", "path", path)
+	}
+	return nil
+}
+
+// runMountDeletion periodically deletes the mounts whose deletion grace
+// period has elapsed, until the stop channel is closed
+func (c *Core) runMountDeletion(stopCh chan struct{}) {
+	ticker := time.NewTicker(mountDeletionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.stateLock.RLock()
+			select {
+			case <-stopCh:
+				c.stateLock.RUnlock()
+				return
+			default:
+			}
+			c.deleteExpiredMounts(c.activeContext)
+			c.stateLock.RUnlock()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// deleteExpiredMounts unmounts every mount whose deletion grace period has
+// elapsed. Each deletion is audited as a request from the system itself,
+// since the client that disabled the mount is no longer around.
+func (c *Core) deleteExpiredMounts(ctx context.Context) {
+	now := time.Now().Unix()
+
+	var expired []*MountEntry
+	c.mountsLock.RLock()
+	for _, entry := range c.mounts.Entries {
+		if entry.DeletionTime != 0 && entry.DeletionTime <= now {
+			expired = append(expired, entry)
+		}
+	}
+	c.mountsLock.RUnlock()
+
+	for _, entry := range expired {
+		ns := c.namespaceByID(entry.NamespaceID)
+		if ns == nil {
+			c.logger.Error("failed to find the namespace of mount pending deletion", "path", entry.Path)
+			continue
+		}
+
+		requestID, err := uuid.GenerateUUID()
+		if err != nil {
+			c.logger.Error("failed to generate identifier for the deletion of mount", "path", entry.Path, "error", err)
+			continue
+		}
+		req := &logical.Request{
+			ID:        requestID,
+			Operation: logical.DeleteOperation,
+			Path:      ns.Path + "sys/mounts/" + strings.TrimPrefix(entry.Path, ns.Path),
+			Data: map[string]interface{}{
+				"grace_period_elapsed": true,
+			},
+		}
+		if err := c.auditBroker.LogRequest(ctx, &audit.LogInput{Request: req}, c.auditedHeaders); err != nil {
+			c.logger.Error("failed to audit the deletion of mount", "path", entry.Path, "error", err)
+			continue
+		}
+
+		if err := c.unmountInternal(ctx, entry.Path); err != nil {
+			c.logger.Error("failed to delete mount after its grace period", "path", entry.Path, "error", err)
+		}
+	}
+}
+
 // remountForce takes a copy of the mount entry for the path and fully unmounts
 // and remounts the backend to pick up any changes, such as filtered paths
 func (c *Core) remountForce(ctx context.Context, path string) error {
@@ -522,12 +757,17 @@ func (c *Core) remount(ctx context.Context, src, dst string) error {
 		dst += "/"
 	}
 
-	// Prevent protected paths from being remounted
+	// Prevent protected paths from being remounted, and mounts from being
+	// moved between namespaces
+	ns := c.namespaceByPath(src)
 	for _, p := range protectedMounts {
-		if strings.HasPrefix(src, p) {
+		if strings.HasPrefix(strings.TrimPrefix(src, ns.Path), p) {
 			return fmt.Errorf("cannot remount %q", src)
 		}
 	}
+	if dstNS := c.namespaceByPath(dst); dstNS.ID != ns.ID {
+		return fmt.Errorf("cannot remount %q to a different namespace", src)
+	}
 
 	// Verify exact match of the route
 	match := c.router.MatchingMount(src)
@@ -539,6 +779,10 @@ func (c *Core) remount(ctx context.Context, src, dst string) error {
 		return fmt.Errorf("existing mount at %q", match)
 	}
 
+	if entry := c.router.MatchingMountEntry(src); entry != nil && entry.DeletionTime != 0 {
+		return fmt.Errorf("cannot remount %q while it is pending deletion", src)
+	}
+
 	// Mark the entry as tainted
 	if err := c.taintMountEntry(ctx, src); err != nil {
 		return err
@@ -554,11 +798,6 @@ func (c *Core) remount(ctx context.Context, src, dst string) error {
 		return err
 	}
 
-	// Revoke all the dynamic keys
-	if err := c.expiration.RevokePrefix(src, true); err != nil {
-		return err
-	}
-
 	c.mountsLock.Lock()
 	var entry *MountEntry
 	for _, entry = range c.mounts.Entries {
@@ -590,14 +829,25 @@ func (c *Core) remount(ctx context.Context, src, dst string) error {
 		return err
 	}
 
+	// Move the dynamic keys along with the mount, so that they are still
+	// renewed and revoked through it
+	moved, err := c.expiration.MovePrefix(src, dst)
+	if err != nil {
+		return err
+	}
+
 	// Un-taint the path
 	if err := c.router.Untaint(dst); err != nil {
 		return err
 	}
 
 	if c.logger.IsInfo() {
-		c.logger.Info("successful remount", "old_path", src, "new_path", dst)
+		c.logger.Info("successful remount", "old_path", src, "new_path", dst, "moved_leases", moved)
 	}
+
+	c.publishMountEvent(src, "remount", map[string]string{
+		"to": dst,
+	})
 	return nil
 }
 
@@ -873,8 +1123,9 @@ func (c *Core) setupMounts(ctx context.Context) error {
 			c.logger.Info("successfully mounted backend", "type", entry.Type, "path", entry.Path)
 		}
 
-		// Ensure the path is tainted if set in the mount table
-		if entry.Tainted {
+		// Ensure the path is tainted if set in the mount table, or if the
+		// mount is pending deletion
+		if entry.Tainted || entry.DeletionTime != 0 {
 			c.router.Taint(entry.Path)
 		}
 	}
@@ -945,10 +1196,17 @@ func (c *Core) newLogicalBackend(ctx context.Context, entry *MountEntry, sysView
 // mount-specific entries; because this should be called when setting
 // up a mountEntry, it doesn't check to ensure that me is not nil
 func (c *Core) mountEntrySysView(entry *MountEntry) logical.SystemView {
-	return dynamicSystemView{
+	sysView := dynamicSystemView{
 		core:       c,
 		mountEntry: entry,
 	}
+	if c.entropyAllowed(entry.Type) {
+		return entropySystemView{
+			dynamicSystemView: sysView,
+			Sourcer:           c.entropySource,
+		}
+	}
+	return sysView
 }
 
 // defaultMountTable creates a default mount table
@@ -326,6 +326,101 @@ func TestCore_Unmount_Cleanup(t *testing.T) {
 	}
 }
 
+func TestCore_Unmount_GracePeriod(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.mountDeletionGracePeriod = time.Hour
+
+	noop := &NoopAudit{}
+	c.auditBackends["noop"] = func(ctx context.Context, config *audit.BackendConfig) (audit.Backend, error) {
+		noop.Config = config
+		return noop, nil
+	}
+	if err := c.enableAudit(context.Background(), &MountEntry{
+		Table: auditTableType,
+		Path:  "noop/",
+		Type:  "noop",
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/foo")
+	req.Data["foo"] = "bar"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(context.Background(), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Disabling the mount stops it from serving requests, but keeps it around
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/mounts/secret")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || len(resp.Warnings) != 1 {
+		t.Fatalf("expected a warning, got: %#v", resp)
+	}
+	if match := c.router.MatchingMount("secret/foo"); match != "secret/" {
+		t.Fatalf("expected the mount to be kept, got %q", match)
+	}
+
+	read := logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	read.ClientToken = root
+	if _, err := c.HandleRequest(context.Background(), read); err == nil {
+		t.Fatal("expected an error reading from a disabled mount")
+	}
+	if err := c.remount(context.Background(), "secret", "foo"); err == nil {
+		t.Fatal("expected an error remounting a disabled mount")
+	}
+
+	// Restoring the mount makes its data available again
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/secret/restore")
+	req.ClientToken = root
+	if _, err := c.HandleRequest(context.Background(), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	read = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	read.ClientToken = root
+	resp, err = c.HandleRequest(context.Background(), read)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["foo"] != "bar" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The mount is deleted once the grace period has elapsed
+	deletionTime, err := c.scheduleUnmount(context.Background(), "secret")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if deletionTime.Before(time.Now().Add(59 * time.Minute)) {
+		t.Fatalf("bad deletion time: %v", deletionTime)
+	}
+	c.deleteExpiredMounts(context.Background())
+	if match := c.router.MatchingMount("secret/foo"); match != "secret/" {
+		t.Fatalf("expected the mount to be kept, got %q", match)
+	}
+
+	c.mountsLock.Lock()
+	for _, entry := range c.mounts.Entries {
+		if entry.Path == "secret/" {
+			entry.DeletionTime = time.Now().Add(-time.Minute).Unix()
+		}
+	}
+	c.mountsLock.Unlock()
+	c.deleteExpiredMounts(context.Background())
+	if match := c.router.MatchingMount("secret/foo"); match != "" {
+		t.Fatalf("expected the mount to be deleted, got %q", match)
+	}
+
+	// The deletion is audited
+	last := noop.Req[len(noop.Req)-1]
+	if last.Operation != logical.DeleteOperation || last.Path != "sys/mounts/secret/" {
+		t.Fatalf("bad audited request: %#v", last)
+	}
+}
+
 func TestCore_Remount(t *testing.T) {
 	c, keys, _ := TestCoreUnsealed(t)
 	err := c.remount(context.Background(), "secret", "foo")
@@ -418,6 +513,8 @@ func TestCore_Remount_Cleanup(t *testing.T) {
 		t.Fatalf("bad: %#v", resp)
 	}
 
+	leaseID := resp.Secret.LeaseID
+
 	// Remount, this should cleanup
 	if err := c.remount(context.Background(), "test/", "new/"); err != nil {
 		t.Fatalf("err: %v", err)
@@ -428,7 +525,33 @@ func TestCore_Remount_Cleanup(t *testing.T) {
 		t.Fatalf("bad: %#v", noop.Requests)
 	}
 
-	// Revoke should be invoked
+	// The lease should be moved rather than revoked
+	if len(noop.Requests) != 2 {
+		t.Fatalf("bad: %#v", noop.Requests)
+	}
+	le, err := c.expiration.loadEntry(leaseID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if le != nil {
+		t.Fatalf("expected the old lease to be removed, got: %#v", le)
+	}
+	movedID := "new/" + strings.TrimPrefix(leaseID, "test/")
+	le, err = c.expiration.loadEntry(movedID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if le == nil || le.Path != "new/foo" {
+		t.Fatalf("bad: %#v", le)
+	}
+	if _, ok := c.expiration.pending[movedID]; !ok {
+		t.Fatal("expected the moved lease to be pending expiration")
+	}
+
+	// The moved lease is revoked through the new mount
+	if err := c.expiration.Revoke(context.Background(), movedID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 	if noop.Requests[2].Operation != logical.RevokeOperation {
 		t.Fatalf("bad: %#v", noop.Requests)
 	}
@@ -0,0 +1,471 @@
+package vault
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// mountTransferSubPath is the sub-path of the system view holding the
+	// wrapping key of the imports
+	mountTransferSubPath = "mount-transfer/"
+
+	// mountImportWrappingKeyPath is the storage key of the wrapping key
+	mountImportWrappingKeyPath = "wrapping-key"
+
+	// mountImportWrappingKeyBits is the size of the wrapping key
+	mountImportWrappingKeyBits = 4096
+
+	// mountExportVersion is the version of the format of the exports
+	mountExportVersion = 1
+
+	// The ways the key encrypting an export is itself encrypted: with a key
+	// given by the operator, or with the wrapping key of the target cluster
+	mountExportKeyWrappingAES = "aes-gcm"
+	mountExportKeyWrappingRSA = "rsa-oaep-sha256"
+)
+
+var (
+	errMountExporting = errors.New("cannot write to storage while the mount is exported")
+
+	// mountExportUnsupported are the types of the mounts which cannot be
+	// exported, their storage being tied to the rest of the cluster
+	mountExportUnsupported = []string{
+		"cubbyhole",
+		"identity",
+		"system",
+		"token",
+	}
+)
+
+// mountExportEnvelope is the encrypted form of an export. The payload is
+// encrypted with AES-GCM under a random key, itself encrypted as per
+// KeyWrapping.
+type mountExportEnvelope struct {
+	Version      int    `json:"version"`
+	KeyWrapping  string `json:"key_wrapping"`
+	EncryptedKey []byte `json:"encrypted_key"`
+	Ciphertext   []byte `json:"ciphertext"`
+}
+
+// mountExportPayload holds the mount entry and the storage of an exported
+// mount
+type mountExportPayload struct {
+	Table       string              `json:"table"`
+	Path        string              `json:"path"`
+	Type        string              `json:"type"`
+	Description string              `json:"description"`
+	Config      MountConfig         `json:"config"`
+	Options     map[string]string   `json:"options"`
+	Local       bool                `json:"local"`
+	SealWrap    bool                `json:"seal_wrap"`
+	ExportedAt  time.Time           `json:"exported_at"`
+	Entries     []*mountExportEntry `json:"entries"`
+
+	// BackendAwareUUID is kept, some backends such as kv v2 prefixing their
+	// storage with it
+	BackendAwareUUID string `json:"backend_aware_uuid"`
+}
+
+type mountExportEntry struct {
+	Key      string `json:"key"`
+	Value    []byte `json:"value"`
+	SealWrap bool   `json:"seal_wrap"`
+}
+
+// mountTransferPath resolves the path of a mount given relative to a
+// namespace, with the auth/ prefix for auth methods, to the path of its
+// entry and the table of the entry
+func mountTransferPath(ns *Namespace, path string) (string, string) {
+	path = sanitizeMountPath(path)
+	if strings.HasPrefix(path, credentialRoutePrefix) {
+		return ns.Path + strings.TrimPrefix(path, credentialRoutePrefix), credentialTableType
+	}
+	return ns.Path + path, mountTableType
+}
+
+// exportMount exports the storage of the mount at the given path along with
+// its entry. The view of the mount is read-only while it is read, so that
+// the export is consistent: the writes in progress are completed first, and
+// the writes made during the export fail. The key encrypting the export is
+// passed to wrapKey, which returns how and what it was encrypted to.
+func (c *Core) exportMount(ctx context.Context, ns *Namespace, path string, wrapKey func([]byte) (string, []byte, error)) ([]byte, *MountEntry, int, error) {
+	entryPath, table := mountTransferPath(ns, path)
+	routePath := entryPath
+	if table == credentialTableType {
+		routePath = credentialRoutePrefix + entryPath
+		c.authLock.RLock()
+		defer c.authLock.RUnlock()
+	} else {
+		c.mountsLock.RLock()
+		defer c.mountsLock.RUnlock()
+	}
+
+	c.mountExportLock.Lock()
+	defer c.mountExportLock.Unlock()
+
+	entry := c.router.MatchingMountEntry(routePath)
+	if entry == nil || entry.Path != entryPath || entry.Table != table {
+		return nil, nil, 0, logical.CodedError(400, fmt.Sprintf("no mount at %q", path))
+	}
+	for _, t := range mountExportUnsupported {
+		if entry.Type == t {
+			return nil, nil, 0, logical.CodedError(400, fmt.Sprintf("mounts of type %q cannot be exported", entry.Type))
+		}
+	}
+	view, ok := c.router.MatchingStorageByAPIPath(routePath).(*BarrierView)
+	if !ok {
+		return nil, nil, 0, fmt.Errorf("no storage view for mount %q", path)
+	}
+
+	// A view already read-only, such as during the setup of its mount, is
+	// left as is
+	if view.getReadOnlyErr() == nil {
+		view.setReadOnlyErr(errMountExporting)
+		defer view.setReadOnlyErr(nil)
+	}
+
+	keys, err := logical.CollectKeys(ctx, view)
+	if err != nil {
+		return nil, nil, 0, errwrap.Wrapf("failed to list the storage of the mount: {{err}}", err)
+	}
+	payload := &mountExportPayload{
+		Table:       entry.Table,
+		Path:        strings.TrimPrefix(entry.Path, ns.Path),
+		Type:        entry.Type,
+		Description: entry.Description,
+		Config:      entry.Config,
+		Options:     entry.Options,
+		Local:       entry.Local,
+		SealWrap:    entry.SealWrap,
+		ExportedAt:  time.Now().UTC(),
+		Entries:     make([]*mountExportEntry, 0, len(keys)),
+
+		BackendAwareUUID: entry.BackendAwareUUID,
+	}
+	for _, key := range keys {
+		se, err := view.Get(ctx, key)
+		if err != nil {
+			return nil, nil, 0, errwrap.Wrapf(fmt.Sprintf("failed to read %q: {{err}}", key), err)
+		}
+		if se == nil {
+			continue
+		}
+		payload.Entries = append(payload.Entries, &mountExportEntry{
+			Key:      se.Key,
+			Value:    se.Value,
+			SealWrap: se.SealWrap,
+		})
+	}
+
+	export, err := sealMountExport(payload, wrapKey)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return export, entry, len(payload.Entries), nil
+}
+
+// importMount mounts the secrets engine or auth method of an export at the
+// given path, with the exported storage. The key encrypting the export is
+// decrypted with unwrapKey, given how it was encrypted.
+func (c *Core) importMount(ctx context.Context, ns *Namespace, path string, export []byte, unwrapKey func(string, []byte) ([]byte, error)) (*MountEntry, int, error) {
+	payload, err := openMountExport(export, unwrapKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entryPath, table := mountTransferPath(ns, path)
+	if table != payload.Table {
+		if payload.Table == credentialTableType {
+			return nil, 0, logical.CodedError(400, "auth methods must be imported under auth/")
+		}
+		return nil, 0, logical.CodedError(400, "secrets engines cannot be imported under auth/")
+	}
+	if !payload.Local && c.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return nil, 0, logical.CodedError(400, "cannot import a non-local mount to a replication secondary")
+	}
+
+	entryUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, 0, err
+	}
+	entry := &MountEntry{
+		Table:       payload.Table,
+		Path:        entryPath,
+		Type:        payload.Type,
+		Description: payload.Description,
+		UUID:        entryUUID,
+		Config:      payload.Config,
+		Options:     payload.Options,
+		Local:       payload.Local,
+		SealWrap:    payload.SealWrap,
+		NamespaceID: ns.ID,
+
+		BackendAwareUUID: payload.BackendAwareUUID,
+	}
+
+	// The storage is written before the mount is created so that the
+	// backend finds it when set up, and cleared if the mount fails
+	routePath, viewPath := entryPath, backendBarrierPrefix+entryUUID+"/"
+	if table == credentialTableType {
+		routePath, viewPath = credentialRoutePrefix+entryPath, credentialBarrierPrefix+entryUUID+"/"
+	}
+	if conflict := c.router.MountConflict(routePath); conflict != "" {
+		return nil, 0, logical.CodedError(409, fmt.Sprintf("existing mount at %s", conflict))
+	}
+	view := NewBarrierView(c.barrier, viewPath)
+	for _, e := range payload.Entries {
+		if err := view.Put(ctx, &logical.StorageEntry{
+			Key:      e.Key,
+			Value:    e.Value,
+			SealWrap: e.SealWrap,
+		}); err != nil {
+			logical.ClearView(ctx, view)
+			return nil, 0, errwrap.Wrapf(fmt.Sprintf("failed to write %q: {{err}}", e.Key), err)
+		}
+	}
+
+	if table == credentialTableType {
+		err = c.enableCredential(ctx, entry)
+	} else {
+		err = c.mount(ctx, entry)
+	}
+	if err != nil {
+		if clearErr := logical.ClearView(ctx, view); clearErr != nil {
+			c.logger.Error("failed to clear the storage of a failed import", "path", entryPath, "error", clearErr)
+		}
+		return nil, 0, err
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("imported mount", "path", entryPath, "type", entry.Type, "entries", len(payload.Entries), "exported_at", payload.ExportedAt)
+	}
+	return entry, len(payload.Entries), nil
+}
+
+// sealMountExport encodes and encrypts the payload of an export
+func sealMountExport(payload *mountExportPayload, wrapKey func([]byte) (string, []byte, error)) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(payload); err != nil {
+		return nil, errwrap.Wrapf("failed to encode the export: {{err}}", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errwrap.Wrapf("failed to compress the export: {{err}}", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	ciphertext, err := mountExportEncrypt(key, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	keyWrapping, encryptedKey, err := wrapKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&mountExportEnvelope{
+		Version:      mountExportVersion,
+		KeyWrapping:  keyWrapping,
+		EncryptedKey: encryptedKey,
+		Ciphertext:   ciphertext,
+	})
+}
+
+// openMountExport decrypts and decodes the payload of an export
+func openMountExport(export []byte, unwrapKey func(string, []byte) ([]byte, error)) (*mountExportPayload, error) {
+	var envelope mountExportEnvelope
+	if err := json.Unmarshal(export, &envelope); err != nil {
+		return nil, logical.CodedError(400, "export is not valid")
+	}
+	if envelope.Version != mountExportVersion {
+		return nil, logical.CodedError(400, fmt.Sprintf("unsupported export version %d", envelope.Version))
+	}
+
+	key, err := unwrapKey(envelope.KeyWrapping, envelope.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := mountExportDecrypt(key, envelope.Ciphertext)
+	if err != nil {
+		return nil, logical.CodedError(400, "failed to decrypt the export with the given key")
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(plaintext))
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to decompress the export: {{err}}", err)
+	}
+	raw, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to decompress the export: {{err}}", err)
+	}
+	var payload mountExportPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, errwrap.Wrapf("failed to decode the export: {{err}}", err)
+	}
+	return &payload, nil
+}
+
+// mountExportEncrypt encrypts with AES-GCM, prepending the nonce
+func mountExportEncrypt(key, plaintext []byte) ([]byte, error) {
+	aead, err := mountExportAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func mountExportDecrypt(key, ciphertext []byte) ([]byte, error) {
+	aead, err := mountExportAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], nil)
+}
+
+func mountExportAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// mountExportKeyWrapper returns the function encrypting the key of an export
+// with either an AES-256 key or an RSA public key, given in PEM
+func mountExportKeyWrapper(aesKey []byte, publicKeyPEM string) (func([]byte) (string, []byte, error), error) {
+	switch {
+	case len(aesKey) > 0 && publicKeyPEM != "":
+		return nil, errors.New("only one of key and public_key can be given")
+
+	case len(aesKey) > 0:
+		if len(aesKey) != 32 {
+			return nil, errors.New("key must be a base64-encoded 256-bit key")
+		}
+		return func(key []byte) (string, []byte, error) {
+			encrypted, err := mountExportEncrypt(aesKey, key)
+			return mountExportKeyWrappingAES, encrypted, err
+		}, nil
+
+	case publicKeyPEM != "":
+		block, _ := pem.Decode([]byte(publicKeyPEM))
+		if block == nil {
+			return nil, errors.New("public_key is not a PEM-encoded key")
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to parse public_key: {{err}}", err)
+		}
+		publicKey, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("public_key must be an RSA key")
+		}
+		return func(key []byte) (string, []byte, error) {
+			encrypted, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+			return mountExportKeyWrappingRSA, encrypted, err
+		}, nil
+
+	default:
+		return nil, errors.New("either key or public_key must be given")
+	}
+}
+
+// mountImportKeyUnwrapper returns the function decrypting the key of an
+// export, with the given AES-256 key or the wrapping key of the cluster
+func (c *Core) mountImportKeyUnwrapper(ctx context.Context, aesKey []byte) func(string, []byte) ([]byte, error) {
+	return func(keyWrapping string, encrypted []byte) ([]byte, error) {
+		switch keyWrapping {
+		case mountExportKeyWrappingAES:
+			if len(aesKey) == 0 {
+				return nil, logical.CodedError(400, "the export is encrypted with a key which must be given")
+			}
+			key, err := mountExportDecrypt(aesKey, encrypted)
+			if err != nil {
+				return nil, logical.CodedError(400, "failed to decrypt the export with the given key")
+			}
+			return key, nil
+
+		case mountExportKeyWrappingRSA:
+			wrappingKey, err := c.mountImportWrappingKey(ctx)
+			if err != nil {
+				return nil, err
+			}
+			key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, wrappingKey, encrypted, nil)
+			if err != nil {
+				return nil, logical.CodedError(400, "failed to decrypt the export with the wrapping key of this cluster")
+			}
+			return key, nil
+
+		default:
+			return nil, logical.CodedError(400, fmt.Sprintf("unsupported key wrapping %q", keyWrapping))
+		}
+	}
+}
+
+// mountImportWrappingKey returns the key the exports imported into this
+// cluster can be encrypted for, generating it if it doesn't exist yet
+func (c *Core) mountImportWrappingKey(ctx context.Context) (*rsa.PrivateKey, error) {
+	c.mountImportWrappingKeyLock.Lock()
+	defer c.mountImportWrappingKeyLock.Unlock()
+
+	view := c.systemBarrierView.SubView(mountTransferSubPath)
+	entry, err := view.Get(ctx, mountImportWrappingKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		var key rsa.PrivateKey
+		if err := entry.DecodeJSON(&key); err != nil {
+			return nil, errwrap.Wrapf("failed to decode wrapping key: {{err}}", err)
+		}
+		key.Precompute()
+		return &key, nil
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, mountImportWrappingKeyBits)
+	if err != nil {
+		return nil, err
+	}
+	entry, err = logical.StorageEntryJSON(mountImportWrappingKeyPath, key)
+	if err != nil {
+		return nil, err
+	}
+	entry.SealWrap = true
+	if err := view.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
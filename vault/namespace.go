@@ -0,0 +1,685 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	radix "github.com/armon/go-radix"
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// coreNamespaceConfigPath is used to store the namespace table
+	coreNamespaceConfigPath = "core/namespaces"
+
+	// namespaceSubPath is the sub-path under the system view where data
+	// belonging to a namespace, such as its policies, is stored
+	namespaceSubPath = "namespaces/"
+)
+
+var (
+	// validNamespaceName is the set of names a single namespace segment may
+	// have
+	validNamespaceName = regexp.MustCompile(`^[\w.-]+$`)
+
+	// reservedNamespaceNames cannot be used as namespace names since requests
+	// to these paths are handled specially within a namespace
+	reservedNamespaceNames = []string{
+		"audit",
+		"auth",
+		"cubbyhole",
+		"identity",
+		"sys",
+	}
+
+	// namespaceSystemPaths are the paths of the system backend that are
+	// available within a namespace
+	namespaceSystemPaths = []string{
+		"auth",
+		"capabilities",
+		"capabilities-self",
+		"internal/ui/mounts",
+		"mounts",
+		"namespaces",
+		"policies/acl",
+		"policy",
+	}
+
+	// namespaceTokenPaths are the paths of the token store that are
+	// available within a namespace
+	namespaceTokenPaths = []string{
+		"create",
+		"create-orphan",
+		"create-restricted",
+		"lookup",
+		"lookup-self",
+		"renew",
+		"renew-self",
+		"revoke",
+		"revoke-self",
+	}
+
+	// ErrNamespaceNotEmpty is returned when deleting a namespace that still
+	// contains mounts or other namespaces
+	ErrNamespaceNotEmpty = fmt.Errorf("namespace is not empty")
+)
+
+// Namespace is an administrative boundary within Vault. Each namespace has
+// its own mounts, auth methods, policies and tokens, and may contain further
+// namespaces.
+type Namespace struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
+// rootNamespace is the implicit namespace containing everything that has not
+// been placed in a namespace. Its ID is empty so that objects created before
+// namespaces existed belong to it.
+var rootNamespace = &Namespace{}
+
+// namespaceTable is the persisted list of namespaces
+type namespaceTable struct {
+	Entries []*Namespace `json:"entries"`
+}
+
+// namespaceEntry holds the state required to service requests within a
+// namespace
+type namespaceEntry struct {
+	namespace   *Namespace
+	policyStore *PolicyStore
+	sysPath     string
+	tokenPath   string
+}
+
+// NamespaceStore tracks the namespaces of a cluster
+type NamespaceStore struct {
+	l      sync.RWMutex
+	byID   map[string]*namespaceEntry
+	byPath *radix.Tree
+}
+
+// namespaceAPIPath maps a path relative to the given namespace onto the path
+// used to route it. Auth methods of a namespace live under the global auth
+// prefix, so "auth/foo" within namespace "ns1/" becomes "auth/ns1/foo".
+func namespaceAPIPath(ns *Namespace, path string) string {
+	if ns == nil || ns.ID == "" {
+		return path
+	}
+	if strings.HasPrefix(path, credentialRoutePrefix) {
+		return credentialRoutePrefix + ns.Path + strings.TrimPrefix(path, credentialRoutePrefix)
+	}
+	return ns.Path + path
+}
+
+// namespacePolicyPaths rewrites the rules of a policy belonging to the given
+// namespace so that they apply to the namespace's paths only
+func namespacePolicyPaths(ns *Namespace, paths []*PathRules) []*PathRules {
+	ret := make([]*PathRules, 0, len(paths))
+	for _, pr := range paths {
+		rule := *pr
+		rule.Prefix = namespaceAPIPath(ns, pr.Prefix)
+		ret = append(ret, &rule)
+
+		// A glob that would match the auth prefix needs a matching rule for
+		// the namespace's auth methods, which do not share its prefix
+		if pr.Glob && pr.Prefix != credentialRoutePrefix && strings.HasPrefix(credentialRoutePrefix, pr.Prefix) {
+			authRule := *pr
+			authRule.Prefix = credentialRoutePrefix + ns.Path
+			ret = append(ret, &authRule)
+		}
+	}
+	return ret
+}
+
+// namespaceBackend exposes a subset of the paths of a builtin backend, such
+// as the system backend or the token store, within a namespace
+type namespaceBackend struct {
+	logical.Backend
+
+	paths   []string
+	special *logical.Paths
+	checkFn func(context.Context, *logical.Request) error
+}
+
+func (b *namespaceBackend) allowed(path string) bool {
+	for _, p := range b.paths {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *namespaceBackend) check(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	if !b.allowed(req.Path) {
+		return logical.ErrorResponse(fmt.Sprintf("path %q is not available within a namespace", req.Path)), logical.ErrUnsupportedPath
+	}
+	if b.checkFn != nil {
+		if err := b.checkFn(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (b *namespaceBackend) HandleRequest(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	if resp, err := b.check(ctx, req); resp != nil || err != nil {
+		return resp, err
+	}
+	return b.Backend.HandleRequest(ctx, req)
+}
+
+func (b *namespaceBackend) HandleExistenceCheck(ctx context.Context, req *logical.Request) (bool, bool, error) {
+	if !b.allowed(req.Path) {
+		return false, false, nil
+	}
+	return b.Backend.HandleExistenceCheck(ctx, req)
+}
+
+func (b *namespaceBackend) SpecialPaths() *logical.Paths {
+	return b.special
+}
+
+// Cleanup is a no-op; the underlying backend is shared with the root
+// namespace and is cleaned up when it is unmounted there
+func (b *namespaceBackend) Cleanup(context.Context) {}
+
+// setupNamespaces loads the namespace table and prepares each namespace for
+// servicing requests
+func (c *Core) setupNamespaces(ctx context.Context) error {
+	raw, err := c.barrier.Get(ctx, coreNamespaceConfigPath)
+	if err != nil {
+		c.logger.Error("failed to read namespace table", "error", err)
+		return errLoadMountsFailed
+	}
+
+	table := &namespaceTable{}
+	if raw != nil {
+		if err := jsonutil.DecodeJSON(raw.Value, table); err != nil {
+			c.logger.Error("failed to decode namespace table", "error", err)
+			return errLoadMountsFailed
+		}
+	}
+
+	c.namespaceStore = &NamespaceStore{
+		byID:   make(map[string]*namespaceEntry),
+		byPath: radix.New(),
+	}
+
+	c.namespaceStore.l.Lock()
+	defer c.namespaceStore.l.Unlock()
+	for _, ns := range table.Entries {
+		if err := c.activateNamespace(ctx, ns); err != nil {
+			c.logger.Error("failed to set up namespace", "path", ns.Path, "error", err)
+			return errLoadMountsFailed
+		}
+	}
+	if len(table.Entries) > 0 {
+		c.logger.Info("namespaces loaded", "count", len(table.Entries))
+	}
+
+	return nil
+}
+
+// teardownNamespaces reverses setupNamespaces
+func (c *Core) teardownNamespaces(ctx context.Context) error {
+	if c.namespaceStore == nil {
+		return nil
+	}
+
+	c.namespaceStore.l.Lock()
+	defer c.namespaceStore.l.Unlock()
+	for _, entry := range c.namespaceStore.byID {
+		c.deactivateNamespace(ctx, entry)
+	}
+	c.namespaceStore = nil
+	return nil
+}
+
+// activateNamespace creates the policy store of a namespace and mounts the
+// parts of the system backend and token store it exposes. The namespace
+// store lock must be held.
+func (c *Core) activateNamespace(ctx context.Context, ns *Namespace) error {
+	sysView := &dynamicSystemView{core: c}
+	ps := NewPolicyStore(ctx, c, c.systemBarrierView.SubView(namespaceSubPath+ns.ID+"/"), sysView, c.logger.ResetNamed("policy"))
+	if ps == nil {
+		return fmt.Errorf("failed to create policy store")
+	}
+	ps.setNamespace(ns)
+	if !c.perfStandbyCachingDisabled() {
+		if err := ps.loadACLPolicy(ctx, defaultPolicyName, defaultPolicy); err != nil {
+			return err
+		}
+	}
+
+	entry := &namespaceEntry{
+		namespace:   ns,
+		policyStore: ps,
+		sysPath:     ns.Path + "sys/",
+		tokenPath:   credentialRoutePrefix + ns.Path + "token/",
+	}
+
+	sysBackend := &namespaceBackend{
+		Backend: c.systemBackend,
+		paths:   namespaceSystemPaths,
+		special: &logical.Paths{
+			Root: c.systemBackend.SpecialPaths().Root,
+			Unauthenticated: []string{
+				"internal/ui/mounts",
+				"internal/ui/mounts/*",
+			},
+		},
+	}
+	if err := c.mountNamespaceBackend(ctx, ns, sysBackend, entry.sysPath, "system"); err != nil {
+		return err
+	}
+
+	tokenBackend := &namespaceBackend{
+		Backend: c.tokenStore,
+		paths:   namespaceTokenPaths,
+		special: &logical.Paths{},
+		checkFn: c.namespaceTokenCheck(ns),
+	}
+	if err := c.mountNamespaceBackend(ctx, ns, tokenBackend, entry.tokenPath, "token"); err != nil {
+		c.router.Unmount(ctx, entry.sysPath)
+		return err
+	}
+
+	c.namespaceStore.byID[ns.ID] = entry
+	c.namespaceStore.byPath.Insert(ns.Path, entry)
+	return nil
+}
+
+// deactivateNamespace removes the mounts created by activateNamespace. The
+// namespace store lock must be held.
+func (c *Core) deactivateNamespace(ctx context.Context, entry *namespaceEntry) {
+	if err := c.router.Unmount(ctx, entry.sysPath); err != nil {
+		c.logger.Error("failed to unmount namespace system backend", "path", entry.sysPath, "error", err)
+	}
+	if err := c.router.Unmount(ctx, entry.tokenPath); err != nil {
+		c.logger.Error("failed to unmount namespace token store", "path", entry.tokenPath, "error", err)
+	}
+	delete(c.namespaceStore.byID, entry.namespace.ID)
+	c.namespaceStore.byPath.Delete(entry.namespace.Path)
+}
+
+// mountNamespaceBackend mounts a builtin backend at a path within a
+// namespace. The entry is never persisted; it gets its own identifiers and
+// storage prefix so that it does not shadow the root namespace's mount in
+// the router.
+func (c *Core) mountNamespaceBackend(ctx context.Context, ns *Namespace, backend logical.Backend, path, logicalType string) error {
+	mountUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	accessor, err := c.generateMountAccessor("ns_" + logicalType)
+	if err != nil {
+		return err
+	}
+
+	me := &MountEntry{
+		Table:       mountTableType,
+		Path:        path,
+		Type:        logicalType,
+		Description: fmt.Sprintf("%s backend of namespace %s", logicalType, ns.Path),
+		UUID:        mountUUID,
+		Accessor:    accessor,
+		NamespaceID: ns.ID,
+	}
+	view := c.systemBarrierView.SubView(namespaceSubPath + ns.ID + "/" + logicalType + "/")
+	return c.router.Mount(backend, path, me, view)
+}
+
+// namespaceTokenCheck ensures that tokens looked up, renewed or revoked
+// through the token store of a namespace belong to that namespace
+func (c *Core) namespaceTokenCheck(ns *Namespace) func(context.Context, *logical.Request) error {
+	return func(ctx context.Context, req *logical.Request) error {
+		switch req.Path {
+		case "lookup", "renew", "revoke":
+		default:
+			return nil
+		}
+
+		token, _ := req.Data["token"].(string)
+		if token == "" {
+			return nil
+		}
+		te, err := c.tokenStore.Lookup(ctx, token)
+		if err != nil {
+			return err
+		}
+		if te != nil && te.NamespaceID != ns.ID {
+			return logical.ErrPermissionDenied
+		}
+		return nil
+	}
+}
+
+// namespaceEntryByPath returns the innermost namespace containing the given
+// path, or nil if it is not within a namespace
+func (c *Core) namespaceEntryByPath(path string) *namespaceEntry {
+	if c.namespaceStore == nil {
+		return nil
+	}
+
+	c.namespaceStore.l.RLock()
+	defer c.namespaceStore.l.RUnlock()
+	_, raw, ok := c.namespaceStore.byPath.LongestPrefix(path)
+	if !ok {
+		return nil
+	}
+	return raw.(*namespaceEntry)
+}
+
+// namespaceByPath returns the innermost namespace containing the given path
+func (c *Core) namespaceByPath(path string) *Namespace {
+	if entry := c.namespaceEntryByPath(path); entry != nil {
+		return entry.namespace
+	}
+	return rootNamespace
+}
+
+// namespaceByID returns the namespace with the given ID, or nil if it does
+// not exist
+func (c *Core) namespaceByID(id string) *Namespace {
+	if id == "" {
+		return rootNamespace
+	}
+	if c.namespaceStore == nil {
+		return nil
+	}
+
+	c.namespaceStore.l.RLock()
+	defer c.namespaceStore.l.RUnlock()
+	if entry, ok := c.namespaceStore.byID[id]; ok {
+		return entry.namespace
+	}
+	return nil
+}
+
+// namespaceByMountPoint returns the namespace a mount point belongs to
+func (c *Core) namespaceByMountPoint(mountPoint string) *Namespace {
+	return c.namespaceByPath(strings.TrimPrefix(mountPoint, credentialRoutePrefix))
+}
+
+// namespacePolicyStore returns the policy store of the given namespace, or
+// nil if the namespace does not exist
+func (c *Core) namespacePolicyStore(ns *Namespace) *PolicyStore {
+	if ns == nil {
+		return nil
+	}
+	if ns.ID == "" {
+		return c.policyStore
+	}
+	if c.namespaceStore == nil {
+		return nil
+	}
+
+	c.namespaceStore.l.RLock()
+	defer c.namespaceStore.l.RUnlock()
+	if entry, ok := c.namespaceStore.byID[ns.ID]; ok {
+		return entry.policyStore
+	}
+	return nil
+}
+
+// namespaceRequestPath rewrites requests addressed to the auth methods of a
+// namespace, such as "ns1/auth/userpass/login", to the path they are routed
+// on, "auth/ns1/userpass/login"
+func (c *Core) namespaceRequestPath(path string) string {
+	entry := c.namespaceEntryByPath(path)
+	if entry == nil {
+		return path
+	}
+	rest := strings.TrimPrefix(path, entry.namespace.Path)
+	if !strings.HasPrefix(rest, credentialRoutePrefix) {
+		return path
+	}
+	return namespaceAPIPath(entry.namespace, rest)
+}
+
+// namespaceTokenStorePath maps a path of the token store of a namespace onto
+// the equivalent path of the root namespace's token store, so that token
+// store requests can be recognized regardless of where they were made. Other
+// paths are returned unchanged.
+func (c *Core) namespaceTokenStorePath(path string) string {
+	if !strings.HasPrefix(path, credentialRoutePrefix) {
+		return path
+	}
+	entry := c.namespaceEntryByPath(strings.TrimPrefix(path, credentialRoutePrefix))
+	if entry == nil || !strings.HasPrefix(path, entry.tokenPath) {
+		return path
+	}
+	return credentialRoutePrefix + "token/" + strings.TrimPrefix(path, entry.tokenPath)
+}
+
+// checkNamespaceMountPath ensures that a mount path is within the namespace
+// of its entry and does not overlap with a namespace nested in it. It
+// returns the path relative to the namespace.
+func (c *Core) checkNamespaceMountPath(entry *MountEntry) (string, error) {
+	ns := c.namespaceByPath(entry.Path)
+	if ns.ID != entry.NamespaceID {
+		return "", logical.CodedError(400, fmt.Sprintf("path %q is within namespace %q", entry.Path, ns.Path))
+	}
+	relPath := strings.TrimPrefix(entry.Path, ns.Path)
+	if ns.ID != "" && strings.Trim(relPath, "/") == "" {
+		return "", logical.CodedError(400, "mount path must be specified")
+	}
+	return relPath, nil
+}
+
+// listNamespaces returns the namespaces directly contained in the given one
+func (c *Core) listNamespaces(parent *Namespace) []*Namespace {
+	if c.namespaceStore == nil {
+		return nil
+	}
+
+	c.namespaceStore.l.RLock()
+	defer c.namespaceStore.l.RUnlock()
+	var ret []*Namespace
+	c.namespaceStore.byPath.WalkPrefix(parent.Path, func(path string, raw interface{}) bool {
+		rest := strings.TrimPrefix(path, parent.Path)
+		if rest != "" && strings.Count(rest, "/") == 1 {
+			ret = append(ret, raw.(*namespaceEntry).namespace)
+		}
+		return false
+	})
+	return ret
+}
+
+// persistNamespaces writes the namespace table. The namespace store lock
+// must be held.
+func (c *Core) persistNamespaces(ctx context.Context) error {
+	table := &namespaceTable{}
+	for _, entry := range c.namespaceStore.byID {
+		table.Entries = append(table.Entries, entry.namespace)
+	}
+	sort.Slice(table.Entries, func(i, j int) bool {
+		return table.Entries[i].Path < table.Entries[j].Path
+	})
+
+	buf, err := jsonutil.EncodeJSON(table)
+	if err != nil {
+		return errwrap.Wrapf("failed to encode namespace table: {{err}}", err)
+	}
+	if err := c.barrier.Put(ctx, &Entry{
+		Key:   coreNamespaceConfigPath,
+		Value: buf,
+	}); err != nil {
+		return errwrap.Wrapf("failed to persist namespace table: {{err}}", err)
+	}
+	return nil
+}
+
+// createNamespace creates a namespace with the given name within parent
+func (c *Core) createNamespace(ctx context.Context, parent *Namespace, name string) (*Namespace, error) {
+	name = strings.Trim(name, "/")
+	switch {
+	case name == "":
+		return nil, fmt.Errorf("missing namespace name")
+	case !validNamespaceName.MatchString(name), name == ".", name == "..":
+		return nil, fmt.Errorf("invalid namespace name %q", name)
+	}
+	for _, reserved := range reservedNamespaceNames {
+		if name == reserved {
+			return nil, fmt.Errorf("namespace name %q is reserved", name)
+		}
+	}
+
+	path := parent.Path + name + "/"
+	if match := c.router.MountConflict(path); match != "" {
+		return nil, fmt.Errorf("namespace path %q conflicts with existing mount %q", path, match)
+	}
+	if match := c.router.MountConflict(credentialRoutePrefix + path); match != "" {
+		return nil, fmt.Errorf("namespace path %q conflicts with existing mount %q", path, match)
+	}
+
+	c.namespaceStore.l.Lock()
+	defer c.namespaceStore.l.Unlock()
+	if parent.ID != "" {
+		if _, ok := c.namespaceStore.byID[parent.ID]; !ok {
+			return nil, fmt.Errorf("parent namespace %q no longer exists", parent.Path)
+		}
+	}
+	if _, ok := c.namespaceStore.byPath.Get(path); ok {
+		return nil, fmt.Errorf("namespace %q already exists", path)
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	ns := &Namespace{
+		ID:   id,
+		Path: path,
+	}
+	if err := c.activateNamespace(ctx, ns); err != nil {
+		return nil, err
+	}
+	if err := c.persistNamespaces(ctx); err != nil {
+		c.deactivateNamespace(ctx, c.namespaceStore.byID[ns.ID])
+		return nil, err
+	}
+
+	c.logger.Info("created namespace", "path", ns.Path)
+	return ns, nil
+}
+
+// deleteNamespace removes an empty namespace along with its policies
+func (c *Core) deleteNamespace(ctx context.Context, ns *Namespace) error {
+	// Mounts are looked up before grabbing the namespace lock to preserve the
+	// lock ordering of the mount handlers
+	if c.namespaceHasMounts(ns) {
+		return ErrNamespaceNotEmpty
+	}
+
+	c.namespaceStore.l.Lock()
+	defer c.namespaceStore.l.Unlock()
+	entry, ok := c.namespaceStore.byID[ns.ID]
+	if !ok {
+		return nil
+	}
+	var hasChildren bool
+	c.namespaceStore.byPath.WalkPrefix(ns.Path, func(path string, _ interface{}) bool {
+		hasChildren = path != ns.Path
+		return hasChildren
+	})
+	if hasChildren {
+		return ErrNamespaceNotEmpty
+	}
+
+	c.deactivateNamespace(ctx, entry)
+	if err := c.persistNamespaces(ctx); err != nil {
+		return err
+	}
+	if err := logical.ClearView(ctx, c.systemBarrierView.SubView(namespaceSubPath+ns.ID+"/")); err != nil {
+		c.logger.Error("failed to clear namespace data", "path", ns.Path, "error", err)
+	}
+
+	c.logger.Info("deleted namespace", "path", ns.Path)
+	return nil
+}
+
+// namespaceHasMounts returns true if any secrets engine or auth method is
+// mounted within the given namespace
+func (c *Core) namespaceHasMounts(ns *Namespace) bool {
+	c.mountsLock.RLock()
+	for _, entry := range c.mounts.Entries {
+		if entry.NamespaceID == ns.ID {
+			c.mountsLock.RUnlock()
+			return true
+		}
+	}
+	c.mountsLock.RUnlock()
+
+	c.authLock.RLock()
+	defer c.authLock.RUnlock()
+	for _, entry := range c.auth.Entries {
+		if entry.NamespaceID == ns.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// policiesForToken resolves the token's policies using the policy store of
+// its namespace. Identity is shared by all namespaces and its policies belong
+// to the root namespace, so identity policies only apply to tokens of the
+// root namespace. A token whose namespace no longer exists resolves to no
+// policies.
+func (c *Core) policiesForToken(ctx context.Context, te *logical.TokenEntry, identityPolicies []string) ([]*Policy, error) {
+	ps := c.namespacePolicyStore(c.namespaceByID(te.NamespaceID))
+	if ps == nil {
+		return nil, nil
+	}
+	if te.NamespaceID != "" {
+		identityPolicies = nil
+	}
+
+	var policies []*Policy
+	for _, name := range te.Policies {
+		p, err := ps.GetPolicy(ctx, name, PolicyTypeToken)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to get policy: {{err}}", err)
+		}
+		policies = append(policies, p)
+	}
+	for _, name := range identityPolicies {
+		p, err := c.policyStore.GetPolicy(ctx, name, PolicyTypeToken)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to get policy: {{err}}", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+// restrictionsForToken returns the ACLs of the inline policies restricting
+// the token
+func (c *Core) restrictionsForToken(te *logical.TokenEntry) ([]*ACL, error) {
+	if len(te.InlinePolicies) == 0 {
+		return nil, nil
+	}
+
+	ps := c.namespacePolicyStore(c.namespaceByID(te.NamespaceID))
+	if ps == nil {
+		return nil, fmt.Errorf("namespace of the token not found")
+	}
+
+	restrictions := make([]*ACL, 0, len(te.InlinePolicies))
+	for _, rules := range te.InlinePolicies {
+		acl, err := ps.InlineACL(rules)
+		if err != nil {
+			return nil, err
+		}
+		restrictions = append(restrictions, acl)
+	}
+	return restrictions, nil
+}
@@ -0,0 +1,269 @@
+package vault
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestNamespace_PolicyPaths(t *testing.T) {
+	ns := &Namespace{ID: "abc", Path: "team1/"}
+	paths := namespacePolicyPaths(ns, []*PathRules{
+		{Prefix: "secret/foo", Policy: "read"},
+		{Prefix: "auth/token/create", Policy: "write"},
+		{Prefix: "", Policy: "read", Glob: true},
+		{Prefix: "auth/", Policy: "read", Glob: true},
+	})
+
+	var prefixes []string
+	for _, pr := range paths {
+		prefixes = append(prefixes, pr.Prefix)
+	}
+	expected := []string{
+		"team1/secret/foo",
+		"auth/team1/token/create",
+		"team1/",
+		"auth/team1/",
+		"auth/team1/",
+	}
+	if !reflect.DeepEqual(prefixes, expected) {
+		t.Fatalf("bad: %v", prefixes)
+	}
+}
+
+func TestNamespace_CreateDelete(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies: []string{"reader"},
+			},
+		},
+	}
+	c.credentialBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	handle := func(op logical.Operation, path, token string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = token
+		req.Data = data
+		return c.HandleRequest(context.Background(), req)
+	}
+	mustHandle := func(op logical.Operation, path, token string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := handle(op, path, token, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s %s: resp: %#v, err: %v", op, path, resp, err)
+		}
+		return resp
+	}
+
+	resp := mustHandle(logical.UpdateOperation, "sys/namespaces/team1", root, nil)
+	if resp.Data["path"] != "team1/" || resp.Data["id"] == "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if resp, err := handle(logical.UpdateOperation, "sys/namespaces/sys", root, nil); err == nil {
+		t.Fatalf("expected reserved name to be rejected: %#v", resp)
+	}
+	mustHandle(logical.UpdateOperation, "team1/sys/namespaces/sub", root, nil)
+
+	resp = mustHandle(logical.ListOperation, "sys/namespaces/", root, nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"team1/"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Mounts and auth methods of the namespace are only visible within it
+	mustHandle(logical.UpdateOperation, "team1/sys/mounts/secret", root, map[string]interface{}{"type": "kv"})
+	mustHandle(logical.UpdateOperation, "team1/sys/auth/noop", root, map[string]interface{}{"type": "noop"})
+	resp = mustHandle(logical.ReadOperation, "team1/sys/mounts", root, nil)
+	if _, ok := resp.Data["secret/"]; !ok || len(resp.Data) != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = mustHandle(logical.ReadOperation, "sys/mounts", root, nil)
+	if _, ok := resp.Data["team1/secret/"]; ok {
+		t.Fatalf("namespace mount listed in root namespace: %#v", resp.Data)
+	}
+	resp = mustHandle(logical.ReadOperation, "team1/sys/auth", root, nil)
+	if _, ok := resp.Data["noop/"]; !ok || len(resp.Data) != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if resp, err := handle(logical.UpdateOperation, "sys/mounts/team1/other", root, map[string]interface{}{"type": "kv"}); err == nil {
+		t.Fatalf("expected mount within namespace from root to fail: %#v", resp)
+	}
+
+	// Policies are scoped to the namespace
+	mustHandle(logical.UpdateOperation, "team1/sys/policy/reader", root, map[string]interface{}{
+		"policy": `
+path "secret/*" { capabilities = ["read", "create", "update"] }
+path "auth/token/create" { capabilities = ["update"] }
+`,
+	})
+	resp = mustHandle(logical.ListOperation, "team1/sys/policy/", root, nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"default", "reader"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if p, _ := c.policyStore.GetPolicy(context.Background(), "reader", PolicyTypeACL); p != nil {
+		t.Fatalf("namespace policy visible in root namespace")
+	}
+
+	// Logins through the namespace's auth method get tokens within it
+	resp = mustHandle(logical.UpdateOperation, "team1/auth/noop/login", "", nil)
+	nsToken := resp.Auth.ClientToken
+	te, err := c.tokenStore.Lookup(context.Background(), nsToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te.NamespaceID == "" || te.NamespaceID != c.namespaceByPath("team1/").ID {
+		t.Fatalf("bad: %#v", te)
+	}
+
+	mustHandle(logical.UpdateOperation, "team1/secret/foo", nsToken, map[string]interface{}{"value": "bar"})
+	resp = mustHandle(logical.ReadOperation, "team1/secret/foo", nsToken, nil)
+	if resp.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if _, err := handle(logical.ReadOperation, "secret/foo", nsToken, nil); !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	if _, err := handle(logical.ReadOperation, "team1/sys/mounts", nsToken, nil); !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	resp = mustHandle(logical.UpdateOperation, "team1/sys/capabilities-self", nsToken, map[string]interface{}{"paths": []string{"secret/foo"}})
+	if !reflect.DeepEqual(resp.Data["capabilities"], []string{"create", "read", "update"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Child tokens stay within the namespace
+	resp = mustHandle(logical.UpdateOperation, "team1/auth/token/create", nsToken, map[string]interface{}{"policies": []string{"reader"}})
+	child, err := c.tokenStore.Lookup(context.Background(), resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.NamespaceID != te.NamespaceID {
+		t.Fatalf("bad: %#v", child)
+	}
+	mustHandle(logical.ReadOperation, "team1/auth/token/lookup-self", nsToken, nil)
+	if resp, err := handle(logical.UpdateOperation, "team1/auth/token/create", root, map[string]interface{}{"policies": []string{"root"}}); err == nil {
+		t.Fatalf("expected root token creation in namespace to fail: %#v", resp)
+	}
+
+	// Non-empty namespaces cannot be deleted
+	if _, err := handle(logical.DeleteOperation, "sys/namespaces/team1", root, nil); err == nil {
+		t.Fatalf("expected deletion of non-empty namespace to fail")
+	}
+	mustHandle(logical.DeleteOperation, "team1/sys/namespaces/sub", root, nil)
+	mustHandle(logical.DeleteOperation, "team1/sys/mounts/secret", root, nil)
+	mustHandle(logical.DeleteOperation, "team1/sys/auth/noop", root, nil)
+	mustHandle(logical.DeleteOperation, "sys/namespaces/team1", root, nil)
+
+	resp = mustHandle(logical.ReadOperation, "sys/namespaces/team1", root, nil)
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if _, err := handle(logical.ReadOperation, "team1/auth/token/lookup-self", nsToken, nil); err == nil {
+		t.Fatalf("expected token of deleted namespace to be rejected")
+	}
+}
+
+// Identity policies belong to the root namespace, so a namespace token of an
+// entity in a group must not be granted their root paths
+func TestNamespace_IdentityPolicies(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Alias: &logical.Alias{
+					Name: "alice",
+				},
+			},
+		},
+	}
+	c.credentialBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	handle := func(op logical.Operation, path, token string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = token
+		req.Data = data
+		return c.HandleRequest(context.Background(), req)
+	}
+	mustHandle := func(op logical.Operation, path, token string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := handle(op, path, token, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s %s: resp: %#v, err: %v", op, path, resp, err)
+		}
+		return resp
+	}
+
+	mustHandle(logical.UpdateOperation, "sys/policy/root-reader", root, map[string]interface{}{
+		"policy": `path "secret/*" { capabilities = ["read"] }`,
+	})
+	mustHandle(logical.UpdateOperation, "secret/foo", root, map[string]interface{}{"value": "bar"})
+	mustHandle(logical.UpdateOperation, "sys/namespaces/team1", root, nil)
+	mustHandle(logical.UpdateOperation, "team1/sys/auth/noop", root, map[string]interface{}{"type": "noop"})
+
+	resp := mustHandle(logical.UpdateOperation, "team1/auth/noop/login", "", nil)
+	nsToken := resp.Auth.ClientToken
+	if resp.Auth.EntityID == "" {
+		t.Fatalf("expected login to be assigned an entity")
+	}
+	mustHandle(logical.UpdateOperation, "identity/group", root, map[string]interface{}{
+		"name":              "readers",
+		"policies":          []string{"root-reader"},
+		"member_entity_ids": []string{resp.Auth.EntityID},
+	})
+
+	if _, err := handle(logical.ReadOperation, "secret/foo", nsToken, nil); !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	resp = mustHandle(logical.UpdateOperation, "sys/capabilities", root, map[string]interface{}{
+		"token": nsToken,
+		"paths": []string{"secret/foo"},
+	})
+	if !reflect.DeepEqual(resp.Data["capabilities"], []string{"deny"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = mustHandle(logical.ReadOperation, "team1/auth/token/lookup-self", nsToken, nil)
+	if _, ok := resp.Data["identity_policies"]; ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestNamespace_Persisted(t *testing.T) {
+	c, keys, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/namespaces/team1")
+	req.ClientToken = root
+	if _, err := c.HandleRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Seal(root); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(c, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "team1/sys/mounts")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || len(resp.Data) != 0 {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
@@ -0,0 +1,140 @@
+package vault
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/strutil"
+)
+
+// outboundConfig is the egress configuration of the server: the proxy the
+// backends reach external APIs through, the hosts they reach directly, and
+// the CA certificates they trust in addition to those of the system, such as
+// that of a proxy intercepting TLS. Mounts can override the proxy and add CA
+// certificates of their own.
+type outboundConfig struct {
+	proxyURL *url.URL
+	noProxy  []string
+	caCert   string
+}
+
+func newOutboundConfig(proxyURL, noProxy, caCert string) (*outboundConfig, error) {
+	config := &outboundConfig{
+		noProxy: strutil.ParseDedupLowercaseAndSortStrings(noProxy, ","),
+		caCert:  caCert,
+	}
+
+	if proxyURL != "" {
+		u, err := parseOutboundProxyURL(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		config.proxyURL = u
+	}
+
+	if caCert != "" {
+		if err := checkOutboundCACert(caCert); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// parseOutboundProxyURL parses the URL of an egress proxy
+func parseOutboundProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outbound proxy URL: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("outbound proxy URL %q must use the http, https or socks5 scheme", u.Redacted())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("outbound proxy URL %q has no host", u.Redacted())
+	}
+	return u, nil
+}
+
+// checkOutboundCACert checks that the CA bundle contains certificates
+func checkOutboundCACert(pemCerts string) error {
+	if !x509.NewCertPool().AppendCertsFromPEM([]byte(pemCerts)) {
+		return errors.New("outbound CA certificate: no PEM-encoded certificate found")
+	}
+	return nil
+}
+
+// bypassProxy returns whether the host is reached directly rather than
+// through the proxy. Entries of the list are host names, which also match
+// their subdomains, IP addresses, CIDR blocks, or "*" for all hosts.
+func (c *outboundConfig) bypassProxy(host string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range c.noProxy {
+		if entry == "*" || entry == host {
+			return true
+		}
+		if ip != nil {
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(entry, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// transport returns a new transport for a mount. The proxy of the mount
+// takes precedence over that of the server, and its CA certificates are
+// trusted along with those of the server. Without a proxy configured, the
+// proxy environment variables are honored as by cleanhttp.
+func (c *outboundConfig) transport(mountProxyURL, mountCACert string) (*http.Transport, error) {
+	transport := cleanhttp.DefaultTransport()
+
+	proxyURL := c.proxyURL
+	if mountProxyURL != "" {
+		u, err := parseOutboundProxyURL(mountProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		proxyURL = u
+	}
+	if proxyURL != nil {
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if c.bypassProxy(req.URL.Hostname()) {
+				return nil, nil
+			}
+			return proxyURL, nil
+		}
+	}
+
+	if c.caCert != "" || mountCACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, pemCerts := range []string{c.caCert, mountCACert} {
+			if pemCerts != "" && !pool.AppendCertsFromPEM([]byte(pemCerts)) {
+				return nil, errors.New("outbound CA certificate: no PEM-encoded certificate found")
+			}
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs: pool,
+		}
+	}
+
+	return transport, nil
+}
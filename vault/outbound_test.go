@@ -0,0 +1,115 @@
+package vault
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOutboundConfig_transport(t *testing.T) {
+	config, err := newOutboundConfig("http://proxy.example.com:3128", "localhost, .Internal,10.0.0.0/8", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyOf := func(transport *http.Transport, rawURL string) string {
+		t.Helper()
+		req, err := http.NewRequest("GET", rawURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := transport.Proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u == nil {
+			return ""
+		}
+		return u.String()
+	}
+
+	transport, err := config.transport("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rawURL, expected := range map[string]string{
+		"https://sts.amazonaws.com/":       "http://proxy.example.com:3128",
+		"https://localhost:8200/":          "",
+		"https://api.internal/":            "",
+		"https://vault.api.internal/":      "",
+		"https://notinternal/":             "http://proxy.example.com:3128",
+		"https://10.1.2.3/":                "",
+		"https://192.168.1.1/":             "http://proxy.example.com:3128",
+		"https://internal.example.com/foo": "http://proxy.example.com:3128",
+	} {
+		if proxy := proxyOf(transport, rawURL); proxy != expected {
+			t.Fatalf("expected %q to go through %q, got %q", rawURL, expected, proxy)
+		}
+	}
+
+	// The proxy of a mount takes precedence
+	transport, err = config.transport("http://mount-proxy.example.com:8080", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxy := proxyOf(transport, "https://sts.amazonaws.com/"); proxy != "http://mount-proxy.example.com:8080" {
+		t.Fatalf("bad: %q", proxy)
+	}
+	if proxy := proxyOf(transport, "https://api.internal/"); proxy != "" {
+		t.Fatalf("bad: %q", proxy)
+	}
+
+	for _, proxyURL := range []string{"proxy.example.com:3128", "ftp://proxy.example.com", "http://"} {
+		if _, err := newOutboundConfig(proxyURL, "", ""); err == nil {
+			t.Fatalf("expected %q to be rejected", proxyURL)
+		}
+	}
+	if _, err := newOutboundConfig("", "", "not a certificate"); err == nil {
+		t.Fatal("expected an invalid CA certificate to be rejected")
+	}
+}
+
+func TestOutboundConfig_caCert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	caCert := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}))
+
+	get := func(config *outboundConfig, mountCACert string) error {
+		t.Helper()
+		transport, err := config.transport("", mountCACert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	config, err := newOutboundConfig("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := get(config, ""); err == nil {
+		t.Fatal("expected the certificate of the server not to be trusted")
+	}
+	if err := get(config, caCert); err != nil {
+		t.Fatalf("expected the CA certificate of the mount to be trusted: %v", err)
+	}
+
+	config, err = newOutboundConfig("", "", caCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := get(config, ""); err != nil {
+		t.Fatalf("expected the CA certificate of the server to be trusted: %v", err)
+	}
+}
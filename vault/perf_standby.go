@@ -0,0 +1,370 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
+)
+
+var (
+	// perfStandbyRefreshInterval is how often a performance standby checks
+	// whether the mount tables have changed and refreshes its identity data.
+	// Making this a package var allows tests to modify
+	perfStandbyRefreshInterval = 10 * time.Second
+
+	// perfStandbyTablePaths are the storage entries that, when changed, cause
+	// a performance standby to rebuild its local state
+	perfStandbyTablePaths = []string{
+		coreMountConfigPath,
+		coreLocalMountConfigPath,
+		coreAuthConfigPath,
+		coreLocalAuthConfigPath,
+		coreAuditConfigPath,
+		coreLocalAuditConfigPath,
+		coreNamespaceConfigPath,
+	}
+)
+
+// perfStandbyStorage sits between the barrier and the physical backend. While
+// a performance standby is servicing requests locally it rejects all writes,
+// so that nothing on the standby can modify storage out from under the active
+// node.
+type perfStandbyStorage struct {
+	physical.Backend
+	readOnly *uint32
+}
+
+func newPerfStandbyStorage(b physical.Backend) *perfStandbyStorage {
+	return &perfStandbyStorage{
+		Backend:  b,
+		readOnly: new(uint32),
+	}
+}
+
+func (p *perfStandbyStorage) setReadOnly(readOnly bool) {
+	if readOnly {
+		atomic.StoreUint32(p.readOnly, 1)
+		return
+	}
+	atomic.StoreUint32(p.readOnly, 0)
+}
+
+func (p *perfStandbyStorage) Put(ctx context.Context, entry *physical.Entry) error {
+	if atomic.LoadUint32(p.readOnly) == 1 {
+		return logical.ErrReadOnly
+	}
+	return p.Backend.Put(ctx, entry)
+}
+
+func (p *perfStandbyStorage) Delete(ctx context.Context, key string) error {
+	if atomic.LoadUint32(p.readOnly) == 1 {
+		return logical.ErrReadOnly
+	}
+	return p.Backend.Delete(ctx, key)
+}
+
+// ListPage is a read, so it passes through even while writes are rejected
+func (p *perfStandbyStorage) ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error) {
+	return physical.ListPage(ctx, p.Backend, prefix, after, limit)
+}
+
+// PerfStandby returns true if this node is a standby that is currently able
+// to service requests locally.
+func (c *Core) PerfStandby() bool {
+	c.stateLock.RLock()
+	perfStandby := c.standby && c.perfStandbyActive
+	c.stateLock.RUnlock()
+	return perfStandby
+}
+
+// perfStandbyCachingDisabled reports whether backends are being set up for a
+// performance standby. Since a standby is never told about writes made on the
+// active node, nothing it reads may be cached.
+func (c *Core) perfStandbyCachingDisabled() bool {
+	return c.perfStandbyStorage != nil && atomic.LoadUint32(c.perfStandbyStorage.readOnly) == 1
+}
+
+// perfStandbyCanServe determines whether a request is a candidate for being
+// serviced locally on a performance standby. Only mounts whose backends do not
+// manage external state are allowed, and only the operations of theirs which
+// may not write; any attempt to write to storage while processing the request
+// causes it to be forwarded to the active node.
+func (c *Core) perfStandbyCanServe(req *logical.Request) bool {
+	// Wrapping requires creating a token
+	if req.WrapInfo != nil && req.WrapInfo.TTL != 0 {
+		return false
+	}
+
+	switch req.Operation {
+	case logical.ReadOperation, logical.ListOperation, logical.UpdateOperation:
+	default:
+		return false
+	}
+
+	if c.router.LoginPath(req.Path) {
+		return false
+	}
+
+	entry := c.router.MatchingMountEntry(req.Path)
+	if entry == nil {
+		return false
+	}
+	switch entry.Type {
+	case "transit":
+		// Encrypting, decrypting, signing and the like are updates which
+		// don't write
+		return true
+	case "kv", "generic", "cubbyhole":
+	case "plugin":
+		if entry.Config.PluginName != "kv" {
+			return false
+		}
+	default:
+		return false
+	}
+
+	// Updates of the key/value stores always write
+	return req.Operation != logical.UpdateOperation
+}
+
+// deferredRequestAudit holds the audit entry of a request on a performance
+// standby until the standby knows whether it serves the request or forwards
+// it to the active node, which audits it in turn
+type deferredRequestAudit struct {
+	input *audit.LogInput
+}
+
+type deferredRequestAuditKey struct{}
+
+// auditRequest creates an audit trail of the request, unless it is deferred
+// by a performance standby
+func (c *Core) auditRequest(ctx context.Context, input *audit.LogInput) error {
+	if deferred, ok := ctx.Value(deferredRequestAuditKey{}).(*deferredRequestAudit); ok {
+		deferred.input = input
+		return nil
+	}
+	return c.auditBroker.LogRequest(ctx, input, c.auditedHeaders)
+}
+
+// perfStandbyReadOnlyErr returns true if the given response or error
+// indicates that the backend attempted to modify storage.
+func perfStandbyReadOnlyErr(resp *logical.Response, err error) bool {
+	isReadOnly := func(msg string) bool {
+		return strings.Contains(msg, logical.ErrReadOnly.Error()) ||
+			strings.Contains(msg, logical.ErrSetupReadOnly.Error())
+	}
+
+	if err != nil && isReadOnly(err.Error()) {
+		return true
+	}
+	if resp != nil && resp.IsError() {
+		if respErr := resp.Error(); respErr != nil && isReadOnly(respErr.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// perfStandbyTableDigest hashes the mount, auth and audit tables so that a
+// performance standby can tell when they have been modified on the active
+// node.
+func (c *Core) perfStandbyTableDigest(ctx context.Context) ([]byte, error) {
+	hash := sha256.New()
+	for _, path := range perfStandbyTablePaths {
+		entry, err := c.barrier.Get(ctx, path)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to read table: {{err}}", err)
+		}
+		hash.Write([]byte(path))
+		if entry != nil {
+			hash.Write(entry.Value)
+		}
+	}
+	return hash.Sum(nil), nil
+}
+
+// perfStandbySetup builds the read-only state required to service requests
+// on a standby. It mirrors postUnseal, skipping anything that performs
+// writes or background work that only the active node should do. The state
+// lock must be held.
+func (c *Core) perfStandbySetup() (retErr error) {
+	c.postUnsealFuncs = nil
+	c.activeContext, c.activeContextCancelFunc = context.WithCancel(context.Background())
+
+	defer func() {
+		if retErr != nil {
+			c.perfStandbyTeardown()
+		}
+	}()
+
+	c.logger.Info("performance standby setup starting")
+	c.perfStandbyStorage.setReadOnly(true)
+
+	digest, err := c.perfStandbyTableDigest(c.activeContext)
+	if err != nil {
+		return err
+	}
+	if err := c.setupPluginCatalog(); err != nil {
+		return err
+	}
+	if err := c.loadMounts(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.setupMounts(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.setupPolicyStore(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.loadCORSConfig(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.loadCredentials(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.setupCredentials(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.setupNamespaces(c.activeContext); err != nil {
+		return err
+	}
+
+	c.setupNonRestoringExpiration()
+
+	if err := c.loadAudits(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.setupAudits(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.loadIdentityStoreArtifacts(c.activeContext); err != nil {
+		return err
+	}
+	if err := c.setupAuditedHeadersConfig(c.activeContext); err != nil {
+		return err
+	}
+
+	// Backend views stay read-only, so these are never run
+	c.postUnsealFuncs = nil
+
+	c.perfStandbyDigest = digest
+	c.perfStandbyActive = true
+	c.logger.Info("performance standby setup complete")
+	return nil
+}
+
+// perfStandbyTeardown reverses perfStandbySetup. The state lock must be held.
+func (c *Core) perfStandbyTeardown() {
+	if c.perfStandbyStorage == nil {
+		return
+	}
+
+	c.perfStandbyActive = false
+	c.perfStandbyDigest = nil
+
+	if c.activeContextCancelFunc != nil {
+		c.activeContextCancelFunc()
+	}
+
+	if err := c.teardownAudits(); err != nil {
+		c.logger.Error("error tearing down audits", "error", err)
+	}
+	if err := c.stopExpiration(); err != nil {
+		c.logger.Error("error stopping expiration", "error", err)
+	}
+	if err := c.teardownNamespaces(c.activeContext); err != nil {
+		c.logger.Error("error tearing down namespaces", "error", err)
+	}
+	if err := c.teardownCredentials(c.activeContext); err != nil {
+		c.logger.Error("error tearing down credentials", "error", err)
+	}
+	if err := c.teardownPolicyStore(); err != nil {
+		c.logger.Error("error tearing down policy store", "error", err)
+	}
+	if err := c.unloadMounts(c.activeContext); err != nil {
+		c.logger.Error("error unloading mounts", "error", err)
+	}
+
+	c.postUnsealFuncs = nil
+	c.perfStandbyStorage.setReadOnly(false)
+}
+
+// perfStandbyRefresh rebuilds the local state of a performance standby if the
+// tables have changed, otherwise it reloads identity data so that group and
+// entity policy changes are seen. The state lock must be held.
+func (c *Core) perfStandbyRefresh() error {
+	if !c.perfStandbyActive {
+		return c.perfStandbySetup()
+	}
+
+	digest, err := c.perfStandbyTableDigest(c.activeContext)
+	if err != nil {
+		return err
+	}
+	if string(digest) != string(c.perfStandbyDigest) {
+		c.logger.Info("mount tables changed on active node, rebuilding performance standby state")
+		c.perfStandbyTeardown()
+		return c.perfStandbySetup()
+	}
+
+	if c.identityStore == nil {
+		return nil
+	}
+	db, err := memdb.NewMemDB(identityStoreSchema())
+	if err != nil {
+		return err
+	}
+	c.identityStore.db = db
+	return c.loadIdentityStoreArtifacts(c.activeContext)
+}
+
+// runPerfStandby is a long running routine that keeps the local state of a
+// performance standby up to date.
+func (c *Core) runPerfStandby(doneCh, stopCh chan struct{}) {
+	defer close(doneCh)
+
+	// Set up immediately, then refresh periodically
+	var wait time.Duration
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(wait):
+		}
+		wait = perfStandbyRefreshInterval
+
+		// Grab the lock in a way that can be interrupted; sealing holds the
+		// state lock while waiting for the standby routines to exit
+		lockGrabbedCh := make(chan struct{})
+		go func() {
+			c.stateLock.Lock()
+			select {
+			case <-stopCh:
+				c.stateLock.Unlock()
+			default:
+				close(lockGrabbedCh)
+			}
+		}()
+
+		select {
+		case <-stopCh:
+			return
+		case <-lockGrabbedCh:
+		}
+
+		if c.standby && !c.Sealed() {
+			if err := c.perfStandbyRefresh(); err != nil {
+				c.logger.Error("performance standby refresh failed", "error", err)
+			}
+		}
+		c.stateLock.Unlock()
+	}
+}
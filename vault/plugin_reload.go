@@ -12,7 +12,7 @@ import (
 )
 
 // reloadPluginMounts reloads provided mounts, regardless of
-// plugin name, as long as the backend type is plugin.
+// plugin name. All of the mounts must be of backend type plugin.
 func (c *Core) reloadMatchingPluginMounts(ctx context.Context, mounts []string) error {
 	c.mountsLock.Lock()
 	defer c.mountsLock.Unlock()
@@ -31,14 +31,17 @@ func (c *Core) reloadMatchingPluginMounts(ctx context.Context, mounts []string)
 			isAuth = true
 		}
 
-		if entry.Type == "plugin" {
-			err := c.reloadBackendCommon(ctx, entry, isAuth)
-			if err != nil {
-				errors = multierror.Append(errors, errwrap.Wrapf(fmt.Sprintf("cannot reload plugin on %q: {{err}}", mount), err))
-				continue
-			}
-			c.logger.Info("successfully reloaded plugin", "plugin", entry.Config.PluginName, "path", entry.Path)
+		if entry.Type != "plugin" {
+			errors = multierror.Append(errors, fmt.Errorf("cannot reload %q: mount is not a plugin", mount))
+			continue
+		}
+
+		err := c.reloadBackendCommon(ctx, entry, isAuth)
+		if err != nil {
+			errors = multierror.Append(errors, errwrap.Wrapf(fmt.Sprintf("cannot reload plugin on %q: {{err}}", mount), err))
+			continue
 		}
+		c.logger.Info("successfully reloaded plugin", "plugin", entry.Config.PluginName, "path", entry.Path)
 	}
 	return errors
 }
@@ -137,6 +140,7 @@ func (c *Core) reloadBackendCommon(ctx context.Context, entry *MountEntry, isAut
 	if paths != nil {
 		re.rootPaths.Store(pathsToRadix(paths.Root))
 		re.loginPaths.Store(pathsToRadix(paths.Unauthenticated))
+		re.idemPaths.Store(pathsToRadix(paths.Idempotent))
 	}
 
 	return nil
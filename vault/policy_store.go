@@ -153,6 +153,8 @@ type PolicyStore struct {
 	core             *Core
 	aclView          *BarrierView
 	tokenPoliciesLRU *lru.TwoQueueCache
+	// inlineACLsLRU caches the ACLs of inline policies by their rules
+	inlineACLsLRU *lru.TwoQueueCache
 	// This is used to ensure that writes to the store (acl/rgp) or to the egp
 	// path tree don't happen concurrently. We are okay reading stale data so
 	// long as there aren't concurrent writes.
@@ -161,6 +163,9 @@ type PolicyStore struct {
 	policyTypeMap sync.Map
 	// logger is the server logger copied over from core
 	logger log.Logger
+	// namespace is the namespace the policies belong to; nil for the root
+	// namespace
+	namespace *Namespace
 }
 
 // PolicyEntry is used to store a policy by name
@@ -182,6 +187,8 @@ func NewPolicyStore(ctx context.Context, core *Core, baseView *BarrierView, syst
 	if !system.CachingDisabled() {
 		cache, _ := lru.New2Q(policyCacheSize)
 		ps.tokenPoliciesLRU = cache
+		inlineCache, _ := lru.New2Q(policyCacheSize)
+		ps.inlineACLsLRU = inlineCache
 	}
 
 	keys, err := logical.CollectKeys(ctx, ps.aclView)
@@ -197,6 +204,14 @@ func NewPolicyStore(ctx context.Context, core *Core, baseView *BarrierView, syst
 	return ps
 }
 
+// setNamespace scopes the store to the given namespace. The rules of its
+// policies are rewritten to apply to the namespace's paths, and there is no
+// root policy.
+func (ps *PolicyStore) setNamespace(ns *Namespace) {
+	ps.namespace = ns
+	ps.policyTypeMap.Delete("root")
+}
+
 // setupPolicyStore is used to initialize the policy store
 // when the vault is being unsealed.
 func (c *Core) setupPolicyStore(ctx context.Context) error {
@@ -290,8 +305,13 @@ func (ps *PolicyStore) setPolicyInternal(ctx context.Context, p *Policy) error {
 		ps.policyTypeMap.Store(p.Name, PolicyTypeACL)
 
 		if ps.tokenPoliciesLRU != nil {
-			// Update the LRU cache
-			ps.tokenPoliciesLRU.Add(p.Name, p)
+			// Update the LRU cache. Policies of a namespace are evicted
+			// instead so that their rules are rewritten when next loaded.
+			if ps.namespace != nil {
+				ps.tokenPoliciesLRU.Remove(p.Name)
+			} else {
+				ps.tokenPoliciesLRU.Add(p.Name, p)
+			}
 		}
 
 	default:
@@ -338,7 +358,7 @@ func (ps *PolicyStore) GetPolicy(ctx context.Context, name string, policyType Po
 	}
 
 	// Special case the root policy
-	if policyType == PolicyTypeACL && name == "root" {
+	if policyType == PolicyTypeACL && name == "root" && ps.namespace == nil {
 		p := &Policy{Name: "root"}
 		if cache != nil {
 			cache.Add(p.Name, p)
@@ -385,6 +405,9 @@ func (ps *PolicyStore) GetPolicy(ctx context.Context, name string, policyType Po
 			return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
 		}
 		policy.Paths = p.Paths
+		if ps.namespace != nil {
+			policy.Paths = namespacePolicyPaths(ps.namespace, p.Paths)
+		}
 		// Reset this in case they set the name in the policy itself
 		policy.Name = name
 
@@ -492,6 +515,43 @@ func (ps *PolicyStore) ACL(ctx context.Context, names ...string) (*ACL, error) {
 	return acl, nil
 }
 
+// InlineACL constructs the ACL of an inline policy of a token of the
+// namespace of the store from its rules
+func (ps *PolicyStore) InlineACL(rules string) (*ACL, error) {
+	if ps.inlineACLsLRU != nil {
+		if raw, ok := ps.inlineACLsLRU.Get(rules); ok {
+			return raw.(*ACL), nil
+		}
+	}
+
+	policy, err := parseInlinePolicy(rules)
+	if err != nil {
+		return nil, err
+	}
+	if ps.namespace != nil && ps.namespace.ID != "" {
+		policy.Paths = namespacePolicyPaths(ps.namespace, policy.Paths)
+	}
+
+	acl, err := NewACL([]*Policy{policy})
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to construct ACL: {{err}}", err)
+	}
+	if ps.inlineACLsLRU != nil {
+		ps.inlineACLsLRU.Add(rules, acl)
+	}
+	return acl, nil
+}
+
+// parseInlinePolicy parses the rules of an inline policy of a token
+func parseInlinePolicy(rules string) (*Policy, error) {
+	policy, err := ParseACLPolicy(rules)
+	if err != nil {
+		return nil, err
+	}
+	policy.Name = "(inline)"
+	return policy, nil
+}
+
 func (ps *PolicyStore) loadACLPolicy(ctx context.Context, policyName, policyText string) error {
 	// Check if the policy already exists
 	policy, err := ps.GetPolicy(ctx, policyName, PolicyTypeACL)
@@ -520,6 +580,34 @@ func (ps *PolicyStore) loadACLPolicy(ctx context.Context, policyName, policyText
 	return ps.setPolicyInternal(ctx, policy)
 }
 
+// policiesReferencingPrefix returns the names of the ACL policies that have
+// rules for the prefix or paths under it
+func (ps *PolicyStore) policiesReferencingPrefix(ctx context.Context, prefix string) ([]string, error) {
+	names, err := ps.ListPolicies(ctx, PolicyTypeACL)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list policies: {{err}}", err)
+	}
+
+	var referencing []string
+	for _, name := range names {
+		p, err := ps.GetPolicy(ctx, name, PolicyTypeACL)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to read policy %q: {{err}}", name), err)
+		}
+		if p == nil {
+			continue
+		}
+		for _, pr := range p.Paths {
+			if strings.HasPrefix(pr.Prefix, prefix) || pr.Prefix == strings.TrimSuffix(prefix, "/") {
+				referencing = append(referencing, name)
+				break
+			}
+		}
+	}
+
+	return referencing, nil
+}
+
 func (ps *PolicyStore) sanitizeName(name string) string {
 	return strings.ToLower(strings.TrimSpace(name))
 }
@@ -0,0 +1,91 @@
+package vault
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+var (
+	// GenerateRecoveryTokenStrategy is the strategy used to generate the
+	// token of recovery mode
+	GenerateRecoveryTokenStrategy GenerateRootStrategy = generateRecoveryToken{}
+)
+
+// generateRecoveryToken implements the GenerateRootStrategy and is in charge
+// of creating the token of recovery mode. The token store isn't available in
+// recovery mode, so the token is only kept in memory, until the next seal.
+type generateRecoveryToken struct{}
+
+func (g generateRecoveryToken) generate(ctx context.Context, c *Core) (string, func(), error) {
+	if !c.recoveryMode {
+		return "", nil, fmt.Errorf("recovery tokens can only be generated in recovery mode")
+	}
+
+	token, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", nil, err
+	}
+	c.recoveryToken.Store(token)
+
+	cleanupFunc := func() {
+		c.recoveryToken.Store("")
+	}
+
+	return token, cleanupFunc, nil
+}
+
+// RecoveryMode returns whether the core was started in recovery mode
+func (c *Core) RecoveryMode() bool {
+	return c.recoveryMode
+}
+
+// newRecoveryBackend returns the backend serving the raw endpoint in
+// recovery mode
+func newRecoveryBackend(c *Core) logical.Backend {
+	b := &SystemBackend{
+		Core:   c,
+		logger: c.logger.Named("recovery"),
+	}
+	b.Backend = &framework.Backend{
+		BackendType: logical.TypeLogical,
+	}
+	b.Backend.Paths = b.rawPaths()
+	return b
+}
+
+// HandleRecoveryRequest serves a request in recovery mode. Only the sys/raw
+// paths are available, to the holder of the recovery token.
+func (c *Core) HandleRecoveryRequest(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	if !c.recoveryMode {
+		return nil, fmt.Errorf("vault is not in recovery mode")
+	}
+
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.Sealed() {
+		return nil, consts.ErrSealed
+	}
+
+	token, _ := c.recoveryToken.Load().(string)
+	if token == "" || subtle.ConstantTimeCompare([]byte(req.ClientToken), []byte(token)) != 1 {
+		return nil, logical.ErrPermissionDenied
+	}
+
+	if req.Path != "sys/raw" && !strings.HasPrefix(req.Path, "sys/raw/") {
+		return nil, logical.ErrUnsupportedPath
+	}
+	req.Path = strings.TrimPrefix(req.Path, "sys/")
+
+	if c.logger.IsInfo() {
+		c.logger.Info("recovery mode request", "operation", req.Operation, "path", req.Path)
+	}
+
+	return c.recoveryBackend.HandleRequest(ctx, req)
+}
@@ -0,0 +1,1048 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SermoDigital/jose/jws"
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/physical"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// drReplicationPrefix is the storage prefix holding replication
+	// configuration. Nothing under it is ever replicated.
+	drReplicationPrefix = "core/replication/"
+
+	// coreDRPrimaryConfigPath stores the secondaries that have been
+	// registered with a DR primary. It is stored in the barrier.
+	coreDRPrimaryConfigPath = drReplicationPrefix + "dr/primary"
+
+	// coreDRSecondaryConfigPath stores the connection information of a DR
+	// secondary. It is written directly to physical storage since the
+	// secondary's barrier keys are replaced by those of the primary during
+	// the initial sync.
+	coreDRSecondaryConfigPath = drReplicationPrefix + "dr/secondary"
+)
+
+var (
+	// drLogSize is the number of storage updates a DR primary keeps in memory
+	// for secondaries to tail. A secondary that falls further behind than
+	// this performs a full sync. Making this a package var allows tests to
+	// modify
+	drLogSize = 4096
+
+	// drFetchBatchSize is the maximum number of entries returned by a single
+	// fetch from the primary
+	drFetchBatchSize = 256
+
+	// drSyncInterval is how often a DR secondary polls the primary once it
+	// has caught up
+	drSyncInterval = time.Second
+
+	// drLocalPaths are node or cluster specific and are never sent to
+	// secondaries
+	drLocalPaths = []string{
+		drReplicationPrefix,
+		coreLockPath,
+		coreLeaderPrefix,
+		coreLocalClusterInfoPath,
+	}
+
+	// drKeepPaths are left in place on a secondary when it replaces its
+	// storage with the contents of the primary
+	drKeepPaths = []string{
+		drReplicationPrefix,
+		coreLockPath,
+	}
+
+	ErrDRNotPrimary     = errors.New("cluster is not a DR primary")
+	ErrDRNotSecondary   = errors.New("cluster is not a DR secondary")
+	ErrDRSecondaryState = errors.New("operation not supported on a DR secondary")
+)
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// drLogEntry is a single storage update recorded by a DR primary. Values are
+// exactly as stored in the physical backend, so they remain encrypted by the
+// barrier.
+type drLogEntry struct {
+	Index   uint64
+	Key     string
+	Value   []byte
+	Deleted bool
+}
+
+// drLog holds the most recent storage updates of a DR primary. The epoch
+// changes whenever the log is reset, which tells secondaries that they can no
+// longer tail it and must perform a full sync.
+type drLog struct {
+	l       sync.RWMutex
+	enabled uint32
+	epoch   string
+	index   uint64
+	entries []*drLogEntry
+
+	// locks serialize updates to a key with their insertion into the log so
+	// that the log order matches the storage order
+	locks []*locksutil.LockEntry
+}
+
+func newDRLog() *drLog {
+	return &drLog{
+		locks: locksutil.CreateLocks(),
+	}
+}
+
+func (d *drLog) isEnabled() bool {
+	return atomic.LoadUint32(&d.enabled) == 1
+}
+
+// reset clears the log and starts a new epoch
+func (d *drLog) reset(enabled bool) error {
+	epoch, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+
+	d.l.Lock()
+	defer d.l.Unlock()
+	d.epoch = epoch
+	d.index = 0
+	d.entries = nil
+	if enabled {
+		atomic.StoreUint32(&d.enabled, 1)
+	} else {
+		atomic.StoreUint32(&d.enabled, 0)
+	}
+	return nil
+}
+
+func (d *drLog) append(key string, value []byte, deleted bool) {
+	if hasAnyPrefix(key, drLocalPaths) {
+		return
+	}
+
+	entry := &drLogEntry{
+		Key:     key,
+		Deleted: deleted,
+	}
+	if !deleted {
+		entry.Value = make([]byte, len(value))
+		copy(entry.Value, value)
+	}
+
+	d.l.Lock()
+	defer d.l.Unlock()
+	d.index++
+	entry.Index = d.index
+	d.entries = append(d.entries, entry)
+	if len(d.entries) > drLogSize {
+		d.entries = d.entries[len(d.entries)-drLogSize:]
+	}
+}
+
+// current returns the epoch and the index of the last update
+func (d *drLog) current() (string, uint64) {
+	d.l.RLock()
+	defer d.l.RUnlock()
+	return d.epoch, d.index
+}
+
+// since returns up to max entries after index. If the updates after index
+// are no longer available ok is false.
+func (d *drLog) since(epoch string, index uint64, max int) (entries []*drLogEntry, ok bool) {
+	d.l.RLock()
+	defer d.l.RUnlock()
+
+	if epoch != d.epoch || index > d.index {
+		return nil, false
+	}
+	if index == d.index {
+		return nil, true
+	}
+	if len(d.entries) == 0 || d.entries[0].Index > index+1 {
+		return nil, false
+	}
+
+	start := int(index + 1 - d.entries[0].Index)
+	end := start + max
+	if end > len(d.entries) {
+		end = len(d.entries)
+	}
+	entries = make([]*drLogEntry, end-start)
+	copy(entries, d.entries[start:end])
+	return entries, true
+}
+
+// drLogStorage records every update made to the physical backend in the DR
+// log while the cluster is a DR primary.
+type drLogStorage struct {
+	physical.Backend
+	log *drLog
+}
+
+type drLogTransactionalStorage struct {
+	*drLogStorage
+	physical.Transactional
+}
+
+var _ physical.Backend = (*drLogStorage)(nil)
+var _ physical.Paginator = (*drLogStorage)(nil)
+var _ physical.Transactional = (*drLogTransactionalStorage)(nil)
+
+func newDRLogStorage(b physical.Backend, log *drLog) physical.Backend {
+	s := &drLogStorage{
+		Backend: b,
+		log:     log,
+	}
+	if txn, ok := b.(physical.Transactional); ok {
+		return &drLogTransactionalStorage{
+			drLogStorage:  s,
+			Transactional: txn,
+		}
+	}
+	return s
+}
+
+func (s *drLogStorage) Put(ctx context.Context, entry *physical.Entry) error {
+	if !s.log.isEnabled() {
+		return s.Backend.Put(ctx, entry)
+	}
+
+	lock := locksutil.LockForKey(s.log.locks, entry.Key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := s.Backend.Put(ctx, entry); err != nil {
+		return err
+	}
+	s.log.append(entry.Key, entry.Value, false)
+	return nil
+}
+
+// ListPage passes through to the backend, which records nothing in the DR log
+func (s *drLogStorage) ListPage(ctx context.Context, prefix, after string, limit int) ([]string, error) {
+	return physical.ListPage(ctx, s.Backend, prefix, after, limit)
+}
+
+func (s *drLogStorage) Delete(ctx context.Context, key string) error {
+	if !s.log.isEnabled() {
+		return s.Backend.Delete(ctx, key)
+	}
+
+	lock := locksutil.LockForKey(s.log.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := s.Backend.Delete(ctx, key); err != nil {
+		return err
+	}
+	s.log.append(key, nil, true)
+	return nil
+}
+
+func (s *drLogTransactionalStorage) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	if !s.log.isEnabled() {
+		return s.Transactional.Transaction(ctx, txns)
+	}
+
+	keys := make([]string, 0, len(txns))
+	for _, txn := range txns {
+		keys = append(keys, txn.Entry.Key)
+	}
+	for _, lock := range locksutil.LocksForKeys(s.log.locks, keys) {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	if err := s.Transactional.Transaction(ctx, txns); err != nil {
+		return err
+	}
+	for _, txn := range txns {
+		switch txn.Operation {
+		case physical.PutOperation:
+			s.log.append(txn.Entry.Key, txn.Entry.Value, false)
+		case physical.DeleteOperation:
+			s.log.append(txn.Entry.Key, nil, true)
+		}
+	}
+	return nil
+}
+
+// listPhysicalKeys recursively lists all keys under prefix
+func listPhysicalKeys(ctx context.Context, b physical.Backend, prefix string) ([]string, error) {
+	keys, err := b.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, key := range keys {
+		if strings.HasSuffix(key, "/") {
+			sub, err := listPhysicalKeys(ctx, b, prefix+key)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, sub...)
+			continue
+		}
+		out = append(out, prefix+key)
+	}
+	return out, nil
+}
+
+// drPrimaryConfig is the persisted state of a DR primary
+type drPrimaryConfig struct {
+	Secondaries map[string]*drSecondaryEntry `json:"secondaries"`
+}
+
+// drSecondaryEntry is a secondary known to a DR primary
+type drSecondaryEntry struct {
+	SecretHash string    `json:"secret_hash"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// drSecondaryConfig is the persisted state of a DR secondary
+type drSecondaryConfig struct {
+	PrimaryAPIAddr string `json:"primary_api_addr"`
+	ID             string `json:"id"`
+	Secret         string `json:"secret"`
+	CACert         string `json:"ca_file"`
+	CAPath         string `json:"ca_path"`
+	Epoch          string `json:"epoch"`
+	Index          uint64 `json:"index"`
+}
+
+// drFetchResponse is the data returned by the primary's fetch endpoint
+type drFetchResponse struct {
+	Epoch    string          `mapstructure:"epoch"`
+	Index    uint64          `mapstructure:"index"`
+	FullSync bool            `mapstructure:"full_sync"`
+	Keys     []string        `mapstructure:"keys"`
+	Entries  []*drFetchEntry `mapstructure:"entries"`
+}
+
+type drFetchEntry struct {
+	Key     string `mapstructure:"key"`
+	Value   string `mapstructure:"value"`
+	Deleted bool   `mapstructure:"deleted"`
+}
+
+func hashDRSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// setDRReplicationState replaces the DR portion of the replication state
+func (c *Core) setDRReplicationState(state consts.ReplicationState) {
+	for {
+		old := atomic.LoadUint32(c.replicationState)
+		newState := consts.ReplicationState(old)
+		newState.ClearState(consts.ReplicationDRPrimary | consts.ReplicationDRSecondary |
+			consts.ReplicationDRBootstrapping | consts.ReplicationDRDisabled)
+		newState.AddState(state)
+		if atomic.CompareAndSwapUint32(c.replicationState, old, uint32(newState)) {
+			return
+		}
+	}
+}
+
+// setupDRReplication loads the DR replication configuration and starts
+// recording updates or syncing from the primary as appropriate. This is
+// called during post-unseal on the active node.
+func (c *Core) setupDRReplication(ctx context.Context) error {
+	entry, err := c.physical.Get(ctx, coreDRSecondaryConfigPath)
+	if err != nil {
+		return errwrap.Wrapf("failed to read DR secondary configuration: {{err}}", err)
+	}
+	if entry != nil {
+		config := new(drSecondaryConfig)
+		if err := jsonutil.DecodeJSON(entry.Value, config); err != nil {
+			return errwrap.Wrapf("failed to decode DR secondary configuration: {{err}}", err)
+		}
+		c.setDRReplicationState(consts.ReplicationDRSecondary)
+		if err := c.drLog.reset(false); err != nil {
+			return err
+		}
+		c.startDRSecondarySync(config)
+		return nil
+	}
+
+	out, err := c.barrier.Get(ctx, coreDRPrimaryConfigPath)
+	if err != nil {
+		return errwrap.Wrapf("failed to read DR primary configuration: {{err}}", err)
+	}
+	if out == nil {
+		c.drLock.Lock()
+		c.drPrimaryConfig = nil
+		c.drLock.Unlock()
+		c.setDRReplicationState(consts.ReplicationDRDisabled)
+		return c.drLog.reset(false)
+	}
+
+	config := new(drPrimaryConfig)
+	if err := jsonutil.DecodeJSON(out.Value, config); err != nil {
+		return errwrap.Wrapf("failed to decode DR primary configuration: {{err}}", err)
+	}
+	if config.Secondaries == nil {
+		config.Secondaries = make(map[string]*drSecondaryEntry)
+	}
+
+	c.drLock.Lock()
+	c.drPrimaryConfig = config
+	c.drLock.Unlock()
+	c.setDRReplicationState(consts.ReplicationDRPrimary)
+	return c.drLog.reset(true)
+}
+
+// teardownDRReplication stops any DR replication activity on this node
+func (c *Core) teardownDRReplication() error {
+	c.stopDRSecondarySync()
+	return c.drLog.reset(false)
+}
+
+func (c *Core) persistDRPrimaryConfig(ctx context.Context, config *drPrimaryConfig) error {
+	value, err := jsonutil.EncodeJSON(config)
+	if err != nil {
+		return err
+	}
+	return c.barrier.Put(ctx, &Entry{
+		Key:   coreDRPrimaryConfigPath,
+		Value: value,
+	})
+}
+
+func (c *Core) persistDRSecondaryConfig(ctx context.Context, config *drSecondaryConfig) error {
+	value, err := jsonutil.EncodeJSON(config)
+	if err != nil {
+		return err
+	}
+	return c.physical.Put(ctx, &physical.Entry{
+		Key:   coreDRSecondaryConfigPath,
+		Value: value,
+	})
+}
+
+// drPrimaryEnable turns this cluster into a DR primary
+func (c *Core) drPrimaryEnable(ctx context.Context) error {
+	state := c.ReplicationState()
+	switch {
+	case state.HasState(consts.ReplicationDRPrimary):
+		return errors.New("cluster is already a DR primary")
+	case state.HasState(consts.ReplicationDRSecondary | consts.ReplicationDRBootstrapping):
+		return ErrDRSecondaryState
+	}
+
+	config := &drPrimaryConfig{
+		Secondaries: make(map[string]*drSecondaryEntry),
+	}
+	if err := c.persistDRPrimaryConfig(ctx, config); err != nil {
+		return errwrap.Wrapf("failed to persist DR primary configuration: {{err}}", err)
+	}
+
+	c.drLock.Lock()
+	c.drPrimaryConfig = config
+	c.drLock.Unlock()
+	if err := c.drLog.reset(true); err != nil {
+		return err
+	}
+	c.setDRReplicationState(consts.ReplicationDRPrimary)
+	c.logger.Info("enabled DR replication as primary")
+	return nil
+}
+
+// drPrimaryAddSecondary registers a secondary and returns the secret it uses
+// to authenticate to this primary
+func (c *Core) drPrimaryAddSecondary(ctx context.Context, id string) (string, error) {
+	c.drLock.Lock()
+	defer c.drLock.Unlock()
+
+	if c.drPrimaryConfig == nil {
+		return "", ErrDRNotPrimary
+	}
+	if _, ok := c.drPrimaryConfig.Secondaries[id]; ok {
+		return "", fmt.Errorf("secondary with id %q already exists", id)
+	}
+
+	secret, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+
+	config := &drPrimaryConfig{
+		Secondaries: make(map[string]*drSecondaryEntry, len(c.drPrimaryConfig.Secondaries)+1),
+	}
+	for k, v := range c.drPrimaryConfig.Secondaries {
+		config.Secondaries[k] = v
+	}
+	config.Secondaries[id] = &drSecondaryEntry{
+		SecretHash: hashDRSecret(secret),
+		CreatedAt:  time.Now(),
+	}
+	if err := c.persistDRPrimaryConfig(ctx, config); err != nil {
+		return "", errwrap.Wrapf("failed to persist DR primary configuration: {{err}}", err)
+	}
+	c.drPrimaryConfig = config
+	return secret, nil
+}
+
+// drPrimaryRevokeSecondary removes a secondary's ability to sync from this
+// primary
+func (c *Core) drPrimaryRevokeSecondary(ctx context.Context, id string) error {
+	c.drLock.Lock()
+	defer c.drLock.Unlock()
+
+	if c.drPrimaryConfig == nil {
+		return ErrDRNotPrimary
+	}
+	if _, ok := c.drPrimaryConfig.Secondaries[id]; !ok {
+		return nil
+	}
+
+	config := &drPrimaryConfig{
+		Secondaries: make(map[string]*drSecondaryEntry, len(c.drPrimaryConfig.Secondaries)),
+	}
+	for k, v := range c.drPrimaryConfig.Secondaries {
+		if k != id {
+			config.Secondaries[k] = v
+		}
+	}
+	if err := c.persistDRPrimaryConfig(ctx, config); err != nil {
+		return errwrap.Wrapf("failed to persist DR primary configuration: {{err}}", err)
+	}
+	c.drPrimaryConfig = config
+	return nil
+}
+
+// drPrimarySecondaries returns the IDs of the registered secondaries
+func (c *Core) drPrimarySecondaries() []string {
+	c.drLock.RLock()
+	defer c.drLock.RUnlock()
+
+	if c.drPrimaryConfig == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(c.drPrimaryConfig.Secondaries))
+	for id := range c.drPrimaryConfig.Secondaries {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// drAuthenticateSecondary checks the credentials presented by a secondary
+func (c *Core) drAuthenticateSecondary(id, secret string) bool {
+	c.drLock.RLock()
+	defer c.drLock.RUnlock()
+
+	if c.drPrimaryConfig == nil || id == "" || secret == "" {
+		return false
+	}
+	entry, ok := c.drPrimaryConfig.Secondaries[id]
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(entry.SecretHash), []byte(hashDRSecret(secret))) == 1
+}
+
+// drPrimaryFetch returns the data requested by a secondary. If keys are
+// given their current values are returned. Otherwise the updates after the
+// given position in the log are returned, or if those are unavailable the
+// list of keys the secondary must fetch to perform a full sync.
+func (c *Core) drPrimaryFetch(ctx context.Context, epoch string, index uint64, keys []string) (map[string]interface{}, error) {
+	if len(keys) > 0 {
+		entries := make([]map[string]interface{}, 0, len(keys))
+		for _, key := range keys {
+			if hasAnyPrefix(key, drLocalPaths) {
+				continue
+			}
+			entry, err := c.physical.Get(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			if entry == nil {
+				entries = append(entries, map[string]interface{}{
+					"key":     key,
+					"deleted": true,
+				})
+				continue
+			}
+			entries = append(entries, map[string]interface{}{
+				"key":   key,
+				"value": base64.StdEncoding.EncodeToString(entry.Value),
+			})
+		}
+		return map[string]interface{}{
+			"entries": entries,
+		}, nil
+	}
+
+	if logEntries, ok := c.drLog.since(epoch, index, drFetchBatchSize); ok {
+		entries := make([]map[string]interface{}, 0, len(logEntries))
+		for _, entry := range logEntries {
+			e := map[string]interface{}{
+				"key":     entry.Key,
+				"deleted": entry.Deleted,
+			}
+			if !entry.Deleted {
+				e["value"] = base64.StdEncoding.EncodeToString(entry.Value)
+			}
+			entries = append(entries, e)
+			index = entry.Index
+		}
+		return map[string]interface{}{
+			"epoch":   epoch,
+			"index":   index,
+			"entries": entries,
+		}, nil
+	}
+
+	// Capture the position before listing so that anything modified while
+	// the secondary syncs is replayed when it starts tailing
+	epoch, index = c.drLog.current()
+	allKeys, err := listPhysicalKeys(ctx, c.physical, "")
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list keys: {{err}}", err)
+	}
+	syncKeys := make([]string, 0, len(allKeys))
+	for _, key := range allKeys {
+		if !hasAnyPrefix(key, drLocalPaths) {
+			syncKeys = append(syncKeys, key)
+		}
+	}
+	return map[string]interface{}{
+		"epoch":     epoch,
+		"index":     index,
+		"full_sync": true,
+		"keys":      syncKeys,
+	}, nil
+}
+
+// drClient returns an API client that talks to the primary
+func drClient(config *drSecondaryConfig) (*api.Client, error) {
+	clientConfig := api.DefaultConfig()
+	if clientConfig.Error != nil {
+		return nil, clientConfig.Error
+	}
+	clientConfig.Address = config.PrimaryAPIAddr
+	if config.CACert != "" || config.CAPath != "" {
+		if err := clientConfig.ConfigureTLS(&api.TLSConfig{
+			CACert: config.CACert,
+			CAPath: config.CAPath,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := api.NewClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	client.ClearToken()
+	return client, nil
+}
+
+func drFetch(client *api.Client, config *drSecondaryConfig, keys []string) (*drFetchResponse, error) {
+	data := map[string]interface{}{
+		"id":     config.ID,
+		"secret": config.Secret,
+		"epoch":  config.Epoch,
+		"index":  config.Index,
+	}
+	if len(keys) > 0 {
+		data["keys"] = keys
+	}
+
+	secret, err := client.Logical().Write("sys/replication/dr/primary/fetch", data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("empty response from primary")
+	}
+
+	resp := new(drFetchResponse)
+	if err := mapstructure.WeakDecode(secret.Data, resp); err != nil {
+		return nil, errwrap.Wrapf("failed to decode response from primary: {{err}}", err)
+	}
+	return resp, nil
+}
+
+// drApplyEntries writes entries received from the primary to storage
+func (c *Core) drApplyEntries(ctx context.Context, entries []*drFetchEntry) error {
+	for _, entry := range entries {
+		if hasAnyPrefix(entry.Key, drKeepPaths) {
+			continue
+		}
+		if entry.Deleted {
+			if err := c.physical.Delete(ctx, entry.Key); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(entry.Value)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to decode value of %q: {{err}}", entry.Key), err)
+		}
+		if err := c.physical.Put(ctx, &physical.Entry{
+			Key:   entry.Key,
+			Value: value,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drFullSync replaces the contents of storage with those of the primary. On
+// success the configuration is updated with the position in the primary's
+// log to tail from.
+func (c *Core) drFullSync(ctx context.Context, client *api.Client, config *drSecondaryConfig, resp *drFetchResponse) error {
+	c.logger.Info("starting DR full sync", "keys", len(resp.Keys))
+
+	wanted := make(map[string]struct{}, len(resp.Keys))
+	for _, key := range resp.Keys {
+		wanted[key] = struct{}{}
+	}
+	existing, err := listPhysicalKeys(ctx, c.physical, "")
+	if err != nil {
+		return errwrap.Wrapf("failed to list local keys: {{err}}", err)
+	}
+	for _, key := range existing {
+		if _, ok := wanted[key]; ok || hasAnyPrefix(key, drKeepPaths) {
+			continue
+		}
+		if err := c.physical.Delete(ctx, key); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to delete local key %q: {{err}}", key), err)
+		}
+	}
+
+	for start := 0; start < len(resp.Keys); start += drFetchBatchSize {
+		end := start + drFetchBatchSize
+		if end > len(resp.Keys) {
+			end = len(resp.Keys)
+		}
+		batch, err := drFetch(client, config, resp.Keys[start:end])
+		if err != nil {
+			return errwrap.Wrapf("failed to fetch keys from primary: {{err}}", err)
+		}
+		if err := c.drApplyEntries(ctx, batch.Entries); err != nil {
+			return errwrap.Wrapf("failed to write keys from primary: {{err}}", err)
+		}
+	}
+
+	if err := c.drSetSecondaryPosition(ctx, config, resp.Epoch, resp.Index); err != nil {
+		return err
+	}
+
+	c.logger.Info("DR full sync complete", "index", resp.Index)
+	return nil
+}
+
+// drSetSecondaryPosition records the position in the primary's log that the
+// secondary has applied. The configuration is only ever updated by the sync
+// routine, but it is read by status requests.
+func (c *Core) drSetSecondaryPosition(ctx context.Context, config *drSecondaryConfig, epoch string, index uint64) error {
+	c.drLock.Lock()
+	config.Epoch = epoch
+	config.Index = index
+	c.drLock.Unlock()
+
+	if err := c.persistDRSecondaryConfig(ctx, config); err != nil {
+		return errwrap.Wrapf("failed to persist DR secondary configuration: {{err}}", err)
+	}
+	return nil
+}
+
+// drSyncOnce fetches and applies one batch of updates from the primary. It
+// returns true if more updates are immediately available.
+func (c *Core) drSyncOnce(ctx context.Context, client *api.Client, config *drSecondaryConfig) (bool, error) {
+	resp, err := drFetch(client, config, nil)
+	if err != nil {
+		return false, err
+	}
+	if resp.FullSync {
+		return true, c.drFullSync(ctx, client, config, resp)
+	}
+	if len(resp.Entries) == 0 {
+		return false, nil
+	}
+
+	if err := c.drApplyEntries(ctx, resp.Entries); err != nil {
+		return false, err
+	}
+	if err := c.drSetSecondaryPosition(ctx, config, resp.Epoch, resp.Index); err != nil {
+		return false, err
+	}
+	return len(resp.Entries) == drFetchBatchSize, nil
+}
+
+func (c *Core) startDRSecondarySync(config *drSecondaryConfig) {
+	c.stopDRSecondarySync()
+
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	c.drLock.Lock()
+	c.drSecondaryConfig = config
+	c.drSyncStopCh = stopCh
+	c.drSyncDoneCh = doneCh
+	c.drLock.Unlock()
+
+	go c.runDRSecondarySync(config, stopCh, doneCh)
+}
+
+func (c *Core) stopDRSecondarySync() {
+	c.drLock.Lock()
+	stopCh, doneCh := c.drSyncStopCh, c.drSyncDoneCh
+	c.drSyncStopCh, c.drSyncDoneCh = nil, nil
+	c.drLock.Unlock()
+
+	if stopCh != nil {
+		close(stopCh)
+		<-doneCh
+	}
+}
+
+// runDRSecondarySync is a long running routine that tails the primary's log
+// and applies the updates to local storage.
+func (c *Core) runDRSecondarySync(config *drSecondaryConfig, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-doneCh:
+		}
+	}()
+
+	var wait time.Duration
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(wait):
+		}
+		wait = drSyncInterval
+
+		client, err := drClient(config)
+		if err != nil {
+			c.logger.Error("failed to create DR replication client", "error", err)
+			continue
+		}
+
+		for {
+			more, err := c.drSyncOnce(ctx, client, config)
+			if err != nil {
+				c.logger.Error("failed to sync from DR primary", "error", err)
+				break
+			}
+			if !more {
+				break
+			}
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// drSecondaryEnable connects this cluster to a DR primary using a secondary
+// activation token. Storage is replaced with the contents of the primary in
+// the background, after which this node seals; it must then be unsealed with
+// the primary's unseal keys.
+func (c *Core) drSecondaryEnable(ctx context.Context, token, primaryAPIAddr, caFile, caPath string) error {
+	if c.ReplicationState().HasState(consts.ReplicationDRPrimary | consts.ReplicationDRSecondary | consts.ReplicationDRBootstrapping) {
+		return errors.New("DR replication is already enabled")
+	}
+
+	if primaryAPIAddr == "" {
+		wt, err := jws.ParseJWT([]byte(token))
+		if err != nil {
+			return errwrap.Wrapf("error parsing secondary activation token: {{err}}", err)
+		}
+		primaryAPIAddr, _ = wt.Claims().Get("addr").(string)
+		if primaryAPIAddr == "" {
+			return errors.New("secondary activation token does not contain the primary's address; set primary_api_addr")
+		}
+	}
+
+	config := &drSecondaryConfig{
+		PrimaryAPIAddr: primaryAPIAddr,
+		CACert:         caFile,
+		CAPath:         caPath,
+	}
+	client, err := drClient(config)
+	if err != nil {
+		return errwrap.Wrapf("error creating client for primary: {{err}}", err)
+	}
+	secret, err := client.Logical().Unwrap(token)
+	if err != nil {
+		return errwrap.Wrapf("error unwrapping secondary activation token: {{err}}", err)
+	}
+	if secret == nil || secret.Data == nil {
+		return errors.New("secondary activation token did not contain any data")
+	}
+	config.ID, _ = secret.Data["id"].(string)
+	config.Secret, _ = secret.Data["secret"].(string)
+	if config.ID == "" || config.Secret == "" {
+		return errors.New("secondary activation token is invalid")
+	}
+
+	// Make sure the primary will talk to us before anything is replaced
+	resp, err := drFetch(client, config, nil)
+	if err != nil {
+		return errwrap.Wrapf("error contacting primary: {{err}}", err)
+	}
+
+	if err := c.persistDRSecondaryConfig(ctx, config); err != nil {
+		return errwrap.Wrapf("failed to persist DR secondary configuration: {{err}}", err)
+	}
+	c.drLock.Lock()
+	c.drSecondaryConfig = config
+	c.drLock.Unlock()
+	c.setDRReplicationState(consts.ReplicationDRBootstrapping)
+
+	go c.drSecondaryBootstrap(client, config, resp)
+	return nil
+}
+
+// drSecondaryBootstrap performs the initial sync of a new secondary and then
+// seals it, since its barrier keys are now the primary's.
+func (c *Core) drSecondaryBootstrap(client *api.Client, config *drSecondaryConfig, resp *drFetchResponse) {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	if c.Sealed() || c.standby {
+		c.logger.Warn("node is no longer active, DR secondary bootstrap will resume once unsealed")
+		return
+	}
+
+	ctx := context.Background()
+	err := c.drFullSync(ctx, client, config, resp)
+	if err != nil {
+		c.logger.Error("DR secondary bootstrap failed, remaining a DR secondary and retrying after unseal", "error", err)
+	} else {
+		c.logger.Info("DR secondary bootstrap complete, sealing; unseal using the primary's unseal keys")
+	}
+	c.setDRReplicationState(consts.ReplicationDRSecondary)
+
+	if err := c.sealInternalWithOptions(false, false); err != nil {
+		c.logger.Error("error sealing after DR secondary bootstrap", "error", err)
+	}
+}
+
+// drSecondaryPromote turns a DR secondary into a DR primary. Local state is
+// rebuilt from the replicated data in the background.
+func (c *Core) drSecondaryPromote(ctx context.Context) error {
+	if !c.IsDRSecondary() {
+		return ErrDRNotSecondary
+	}
+
+	c.stopDRSecondarySync()
+	if err := c.physical.Delete(ctx, coreDRSecondaryConfigPath); err != nil {
+		return errwrap.Wrapf("failed to remove DR secondary configuration: {{err}}", err)
+	}
+	c.drLock.Lock()
+	c.drSecondaryConfig = nil
+	c.drLock.Unlock()
+
+	// Requests stay blocked until the reload has set up the primary
+	c.setDRReplicationState(consts.ReplicationDRBootstrapping)
+
+	go c.drPromoteReload()
+	return nil
+}
+
+// drPromoteReload picks up the replicated keyring and rebuilds all state held
+// in memory after a promotion. If the keyring cannot be loaded the node is
+// sealed and must be unsealed with the primary's unseal keys.
+func (c *Core) drPromoteReload() {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	if c.Sealed() || c.standby {
+		return
+	}
+
+	ctx := context.Background()
+	seal := func(msg string, err error) {
+		c.logger.Error(msg, "error", err)
+		if err := c.sealInternalWithOptions(false, false); err != nil {
+			c.logger.Error("error sealing after DR promotion", "error", err)
+		}
+	}
+
+	if err := c.performKeyUpgrades(ctx); err != nil {
+		seal("failed to load replicated keyring after DR promotion, sealing", err)
+		return
+	}
+	if err := c.persistDRPrimaryConfig(ctx, &drPrimaryConfig{
+		Secondaries: make(map[string]*drSecondaryEntry),
+	}); err != nil {
+		seal("failed to persist DR primary configuration, sealing", err)
+		return
+	}
+	if err := c.preSeal(); err != nil {
+		seal("failed to tear down DR secondary state, sealing", err)
+		return
+	}
+	if err := c.postUnseal(); err != nil {
+		seal("failed to set up state after DR promotion, sealing", err)
+		return
+	}
+	c.logger.Info("promoted to DR primary")
+}
+
+// drReplicationStatus returns the DR replication status of this node
+func (c *Core) drReplicationStatus() map[string]interface{} {
+	state := c.ReplicationState()
+	status := map[string]interface{}{
+		"mode": state.GetDRString(),
+	}
+
+	switch {
+	case state.HasState(consts.ReplicationDRPrimary):
+		epoch, index := c.drLog.current()
+		status["epoch"] = epoch
+		status["last_index"] = index
+		status["known_secondaries"] = c.drPrimarySecondaries()
+	case state.HasState(consts.ReplicationDRSecondary | consts.ReplicationDRBootstrapping):
+		c.drLock.RLock()
+		if config := c.drSecondaryConfig; config != nil {
+			status["primary_api_addr"] = config.PrimaryAPIAddr
+			status["secondary_id"] = config.ID
+			status["epoch"] = config.Epoch
+			status["last_index"] = config.Index
+		}
+		c.drLock.RUnlock()
+	}
+
+	return status
+}
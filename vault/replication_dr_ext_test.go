@@ -0,0 +1,156 @@
+package vault_test
+
+import (
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestDRReplication_SecondaryPromote(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		DisableMlock: true,
+		DisableCache: true,
+		Logger:       log.NewNullLogger(),
+	}
+
+	primary := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+		NumCores:    1,
+	})
+	primary.Start()
+	defer primary.Cleanup()
+	vault.TestWaitActive(t, primary.Cores[0].Core)
+	primaryClient := primary.Cores[0].Client
+
+	secondary := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+		NumCores:    1,
+	})
+	secondary.Start()
+	defer secondary.Cleanup()
+	vault.TestWaitActive(t, secondary.Cores[0].Core)
+	secondaryCore := secondary.Cores[0]
+	secondaryClient := secondaryCore.Client
+
+	// Secondary tokens can only be generated by a primary
+	_, err := primaryClient.Logical().Write("sys/replication/dr/primary/secondary-token", map[string]interface{}{
+		"id": "secondary",
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if _, err := primaryClient.Logical().Write("sys/replication/dr/primary/enable", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := primaryClient.Sys().PutPolicy("before", `path "secret/*" { capabilities = ["read"] }`); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := primaryClient.Logical().Write("sys/replication/dr/primary/secondary-token", map[string]interface{}{
+		"id": "secondary",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == nil || secret.WrapInfo == nil || secret.WrapInfo.Token == "" {
+		t.Fatalf("expected a wrapped response: %#v", secret)
+	}
+
+	_, err = secondaryClient.Logical().Write("sys/replication/dr/secondary/enable", map[string]interface{}{
+		"token":   secret.WrapInfo.Token,
+		"ca_file": primary.CACertPEMFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The secondary seals itself once the initial sync is done; it now
+	// uses the primary's unseal keys
+	waitFor(t, func() bool { return secondaryCore.Sealed() })
+	secondary.BarrierKeys = primary.BarrierKeys
+	secondary.UnsealCores(t)
+	vault.TestWaitActive(t, secondaryCore.Core)
+	if !secondaryCore.IsDRSecondary() {
+		t.Fatal("expected a DR secondary")
+	}
+
+	// Requests outside of the replication endpoints are rejected
+	secondaryClient.SetToken(primary.RootToken)
+	if _, err := secondaryClient.Sys().GetPolicy("before"); err == nil {
+		t.Fatal("expected an error")
+	}
+	status, err := secondaryClient.Logical().Read("sys/replication/dr/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := status.Data["dr"].(map[string]interface{})["mode"]; mode != "secondary" {
+		t.Fatalf("bad: %#v", status.Data)
+	}
+
+	// Updates made after the initial sync are streamed to the secondary
+	if err := primaryClient.Sys().PutPolicy("after", `path "secret/*" { capabilities = ["list"] }`); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		status, err := secondaryClient.Logical().Read("sys/replication/dr/status")
+		if err != nil {
+			return false
+		}
+		primaryStatus, err := primaryClient.Logical().Read("sys/replication/dr/status")
+		if err != nil {
+			return false
+		}
+		return status.Data["dr"].(map[string]interface{})["last_index"] == primaryStatus.Data["dr"].(map[string]interface{})["last_index"]
+	})
+
+	// Revoked secondaries can no longer sync
+	if _, err := primaryClient.Logical().Write("sys/replication/dr/primary/revoke-secondary", map[string]interface{}{
+		"id": "secondary",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := secondaryClient.Logical().Write("sys/replication/dr/secondary/promote", nil); err != nil {
+		t.Fatal(err)
+	}
+	var policy string
+	waitFor(t, func() bool {
+		policy, err = secondaryClient.Sys().GetPolicy("after")
+		return err == nil
+	})
+	if policy == "" {
+		t.Fatal("expected replicated policy")
+	}
+	if policy, err := secondaryClient.Sys().GetPolicy("before"); err != nil || policy == "" {
+		t.Fatalf("expected replicated policy: %v", err)
+	}
+
+	status, err = secondaryClient.Logical().Read("sys/replication/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := status.Data["dr"].(map[string]interface{})["mode"]; mode != "primary" {
+		t.Fatalf("bad: %#v", status.Data)
+	}
+
+	// The promoted cluster accepts writes
+	if err := secondaryClient.Sys().PutPolicy("promoted", `path "secret/*" { capabilities = ["read"] }`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitFor(t *testing.T, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		if f() {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for condition")
+}
@@ -0,0 +1,150 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/physical/inmem"
+)
+
+func TestDRLog_Since(t *testing.T) {
+	oldSize := drLogSize
+	drLogSize = 4
+	defer func() { drLogSize = oldSize }()
+
+	l := newDRLog()
+	if err := l.reset(true); err != nil {
+		t.Fatal(err)
+	}
+	epoch, index := l.current()
+	if index != 0 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	// Nothing has been written yet
+	entries, ok := l.since(epoch, 0, 10)
+	if !ok || len(entries) != 0 {
+		t.Fatalf("bad: %v %#v", ok, entries)
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		l.append(key, []byte(key), false)
+	}
+	l.append("b", nil, true)
+
+	entries, ok = l.since(epoch, 1, 2)
+	if !ok || len(entries) != 2 {
+		t.Fatalf("bad: %v %#v", ok, entries)
+	}
+	if entries[0].Key != "b" || entries[0].Index != 2 || entries[1].Key != "c" || entries[1].Index != 3 {
+		t.Fatalf("bad: %#v %#v", entries[0], entries[1])
+	}
+
+	entries, ok = l.since(epoch, 3, 10)
+	if !ok || len(entries) != 1 || entries[0].Key != "b" || !entries[0].Deleted {
+		t.Fatalf("bad: %v %#v", ok, entries)
+	}
+
+	// Push the first entry out of the log
+	l.append("d", []byte("d"), false)
+	if _, ok := l.since(epoch, 0, 10); ok {
+		t.Fatal("expected a full sync to be required")
+	}
+	if entries, ok := l.since(epoch, 1, 10); !ok || len(entries) != 4 {
+		t.Fatalf("bad: %v %#v", ok, entries)
+	}
+
+	// Local paths are never recorded
+	l.append(coreLockPath, []byte("lock"), false)
+	l.append(coreDRPrimaryConfigPath, []byte("config"), false)
+	if _, index := l.current(); index != 5 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	// Unknown positions or epochs require a full sync
+	if _, ok := l.since(epoch, 6, 10); ok {
+		t.Fatal("expected a full sync to be required")
+	}
+	if _, ok := l.since("foo", 5, 10); ok {
+		t.Fatal("expected a full sync to be required")
+	}
+
+	if err := l.reset(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l.since(epoch, 5, 10); ok {
+		t.Fatal("expected a full sync to be required")
+	}
+}
+
+func TestDRLogStorage(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Trace)
+	inm, err := inmem.NewTransactionalInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := newDRLog()
+	b := newDRLogStorage(inm, l)
+	txn, ok := b.(physical.Transactional)
+	if !ok {
+		t.Fatal("expected storage to be transactional")
+	}
+
+	ctx := context.Background()
+	if err := b.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("bar")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, index := l.current(); index != 0 {
+		t.Fatalf("recorded while disabled: %d", index)
+	}
+
+	if err := l.reset(true); err != nil {
+		t.Fatal(err)
+	}
+	epoch, _ := l.current()
+
+	if err := b.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("baz")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Delete(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Transaction(ctx, []*physical.TxnEntry{
+		&physical.TxnEntry{
+			Operation: physical.PutOperation,
+			Entry:     &physical.Entry{Key: "zip", Value: []byte("zap")},
+		},
+		&physical.TxnEntry{
+			Operation: physical.PutOperation,
+			Entry:     &physical.Entry{Key: coreLocalClusterInfoPath, Value: []byte("local")},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, ok := l.since(epoch, 0, 10)
+	if !ok || len(entries) != 3 {
+		t.Fatalf("bad: %v %#v", ok, entries)
+	}
+	if entries[0].Key != "foo" || string(entries[0].Value) != "baz" {
+		t.Fatalf("bad: %#v", entries[0])
+	}
+	if entries[1].Key != "foo" || !entries[1].Deleted {
+		t.Fatalf("bad: %#v", entries[1])
+	}
+	if entries[2].Key != "zip" || string(entries[2].Value) != "zap" {
+		t.Fatalf("bad: %#v", entries[2])
+	}
+
+	keys, err := listPhysicalKeys(ctx, b, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != coreLocalClusterInfoPath || keys[1] != "zip" {
+		t.Fatalf("bad: %#v", keys)
+	}
+}
@@ -9,13 +9,18 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"runtime"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	metrics "github.com/armon/go-metrics"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/forwarding"
+	"github.com/hashicorp/vault/helper/srvutil"
+	"github.com/hashicorp/vault/version"
 	"golang.org/x/net/http2"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
@@ -337,6 +342,8 @@ func (c *Core) clearForwardingClients() {
 // ForwardRequest forwards a given request to the active node and returns the
 // response.
 func (c *Core) ForwardRequest(req *http.Request) (int, http.Header, []byte, error) {
+	defer metrics.MeasureSince([]string{"ha", "rpc", "client", "forward"}, time.Now())
+
 	c.requestForwardingConnectionLock.RLock()
 	defer c.requestForwardingConnectionLock.RUnlock()
 
@@ -355,6 +362,7 @@ func (c *Core) ForwardRequest(req *http.Request) (int, http.Header, []byte, erro
 	}
 	resp, err := c.rpcForwardingClient.ForwardRequest(c.rpcClientConnContext, freq)
 	if err != nil {
+		metrics.IncrCounter([]string{"ha", "rpc", "client", "forward", "errors"}, 1)
 		c.logger.Error("error during forwarded RPC request", "error", err)
 		return 0, nil, nil, fmt.Errorf("error during forwarding RPC request")
 	}
@@ -395,10 +403,49 @@ func (c *Core) getGRPCDialer(ctx context.Context, alpnProto, serverName string,
 		dialer := &net.Dialer{
 			Timeout: timeout,
 		}
+
+		// A cluster address given as a DNS SRV name is resolved on each
+		// dial, so that reconnecting after a failure reaches the node
+		// wherever it moved
+		if srvutil.IsSRV(addr) {
+			return dialSRV(ctx, dialer, addr, tlsConfig)
+		}
+
 		return tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
 	}
 }
 
+// dialSRV dials the targets of the DNS SRV name and performs the TLS
+// handshake, as tls.DialWithDialer does for an address
+func dialSRV(ctx context.Context, dialer *net.Dialer, addr string, tlsConfig *tls.Config) (net.Conn, error) {
+	resolver, err := srvutil.NewResolver(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if dialer.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dialer.Timeout)
+		defer cancel()
+	}
+
+	rawConn, err := resolver.DialContext(dialer)(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := tls.Client(rawConn, tlsConfig)
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := conn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
 type forwardedRequestRPCServer struct {
 	core    *Core
 	handler http.Handler
@@ -451,7 +498,14 @@ func (s *forwardedRequestRPCServer) ForwardRequest(ctx context.Context, freq *fo
 
 func (s *forwardedRequestRPCServer) Echo(ctx context.Context, in *EchoRequest) (*EchoReply, error) {
 	if in.ClusterAddr != "" {
-		s.core.clusterPeerClusterAddrsCache.Set(in.ClusterAddr, nil, 0)
+		s.core.clusterPeerClusterAddrsCache.Set(in.ClusterAddr, &peerNode{
+			Hostname:       in.Hostname,
+			APIAddress:     in.ApiAddr,
+			ClusterAddress: in.ClusterAddr,
+			Version:        in.Version,
+			PerfStandby:    in.PerfStandby,
+			LastEcho:       time.Now(),
+		}, 0)
 	}
 	return &EchoReply{
 		Message:          "pong",
@@ -459,6 +513,33 @@ func (s *forwardedRequestRPCServer) Echo(ctx context.Context, in *EchoRequest) (
 	}, nil
 }
 
+// peerNode is a standby node as described by its last heartbeat to the
+// active node
+type peerNode struct {
+	Hostname       string
+	APIAddress     string
+	ClusterAddress string
+	Version        string
+	PerfStandby    bool
+	LastEcho       time.Time
+}
+
+// getHAPeerNodesCached returns the standby nodes that sent a heartbeat to this
+// node recently, sorted by cluster address
+func (c *Core) getHAPeerNodesCached() []*peerNode {
+	items := c.clusterPeerClusterAddrsCache.Items()
+	nodes := make([]*peerNode, 0, len(items))
+	for _, item := range items {
+		if node, ok := item.Object.(*peerNode); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ClusterAddress < nodes[j].ClusterAddress
+	})
+	return nodes
+}
+
 type forwardingClient struct {
 	RequestForwardingClient
 
@@ -475,12 +556,21 @@ func (c *forwardingClient) startHeartbeat() {
 		tick := func() {
 			c.core.stateLock.RLock()
 			clusterAddr := c.core.clusterAddr
+			apiAddr := c.core.redirectAddr
+			perfStandby := c.core.perfStandbyActive
 			c.core.stateLock.RUnlock()
 
+			// The hostname is only informational, and left empty if unknown
+			hostname, _ := os.Hostname()
+
 			ctx, cancel := context.WithTimeout(c.echoContext, 2*time.Second)
 			resp, err := c.RequestForwardingClient.Echo(ctx, &EchoRequest{
 				Message:     "ping",
 				ClusterAddr: clusterAddr,
+				Hostname:    hostname,
+				ApiAddr:     apiAddr,
+				Version:     version.GetVersion().VersionNumber(),
+				PerfStandby: perfStandby,
 			})
 			cancel()
 			if err != nil {
@@ -31,7 +31,13 @@ type EchoRequest struct {
 	ClusterAddr string `protobuf:"bytes,2,opt,name=cluster_addr,json=clusterAddr,proto3" json:"cluster_addr,omitempty"`
 	// ClusterAddrs is used to send up a list of cluster addresses to a dr
 	// primary from a dr secondary
-	ClusterAddrs         []string `protobuf:"bytes,3,rep,name=cluster_addrs,json=clusterAddrs,proto3" json:"cluster_addrs,omitempty"`
+	ClusterAddrs []string `protobuf:"bytes,3,rep,name=cluster_addrs,json=clusterAddrs,proto3" json:"cluster_addrs,omitempty"`
+	// Hostname, APIAddr, Version and PerfStandby describe a standby node to
+	// the active node upon heartbeat, for sys/ha-status
+	Hostname             string   `protobuf:"bytes,4,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	ApiAddr              string   `protobuf:"bytes,5,opt,name=api_addr,json=apiAddr,proto3" json:"api_addr,omitempty"`
+	Version              string   `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`
+	PerfStandby          bool     `protobuf:"varint,7,opt,name=perf_standby,json=perfStandby,proto3" json:"perf_standby,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -41,7 +47,7 @@ func (m *EchoRequest) Reset()         { *m = EchoRequest{} }
 func (m *EchoRequest) String() string { return proto.CompactTextString(m) }
 func (*EchoRequest) ProtoMessage()    {}
 func (*EchoRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_request_forwarding_service_9602fb43b1d0ebdf, []int{0}
+	return fileDescriptor_request_forwarding_service_d00e57dbe55ccd0b, []int{0}
 }
 func (m *EchoRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_EchoRequest.Unmarshal(m, b)
@@ -82,6 +88,34 @@ func (m *EchoRequest) GetClusterAddrs() []string {
 	return nil
 }
 
+func (m *EchoRequest) GetHostname() string {
+	if m != nil {
+		return m.Hostname
+	}
+	return ""
+}
+
+func (m *EchoRequest) GetApiAddr() string {
+	if m != nil {
+		return m.ApiAddr
+	}
+	return ""
+}
+
+func (m *EchoRequest) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *EchoRequest) GetPerfStandby() bool {
+	if m != nil {
+		return m.PerfStandby
+	}
+	return false
+}
+
 type EchoReply struct {
 	Message              string   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	ClusterAddrs         []string `protobuf:"bytes,2,rep,name=cluster_addrs,json=clusterAddrs,proto3" json:"cluster_addrs,omitempty"`
@@ -95,7 +129,7 @@ func (m *EchoReply) Reset()         { *m = EchoReply{} }
 func (m *EchoReply) String() string { return proto.CompactTextString(m) }
 func (*EchoReply) ProtoMessage()    {}
 func (*EchoReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_request_forwarding_service_9602fb43b1d0ebdf, []int{1}
+	return fileDescriptor_request_forwarding_service_d00e57dbe55ccd0b, []int{1}
 }
 func (m *EchoReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_EchoReply.Unmarshal(m, b)
@@ -247,28 +281,32 @@ var _RequestForwarding_serviceDesc = grpc.ServiceDesc{
 }
 
 func init() {
-	proto.RegisterFile("vault/request_forwarding_service.proto", fileDescriptor_request_forwarding_service_9602fb43b1d0ebdf)
-}
-
-var fileDescriptor_request_forwarding_service_9602fb43b1d0ebdf = []byte{
-	// 297 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x91, 0x41, 0x4b, 0xfb, 0x40,
-	0x10, 0xc5, 0x9b, 0xf6, 0xff, 0x57, 0xba, 0x6d, 0xa5, 0x5d, 0x3d, 0x84, 0x82, 0x10, 0x23, 0x48,
-	0x40, 0xd8, 0x80, 0x9e, 0x3d, 0x28, 0xe8, 0x07, 0x88, 0x37, 0x2f, 0x61, 0xbb, 0x19, 0x93, 0x85,
-	0x6d, 0x76, 0xdd, 0xd9, 0xb4, 0xe4, 0xea, 0x27, 0x97, 0x26, 0xa9, 0x4d, 0x29, 0x78, 0x19, 0x98,
-	0x37, 0x8f, 0x37, 0xcc, 0x6f, 0xc8, 0xdd, 0x86, 0x57, 0xca, 0xc5, 0x16, 0xbe, 0x2a, 0x40, 0x97,
-	0x7e, 0x6a, 0xbb, 0xe5, 0x36, 0x93, 0x65, 0x9e, 0x22, 0xd8, 0x8d, 0x14, 0xc0, 0x8c, 0xd5, 0x4e,
-	0xd3, 0xff, 0x8d, 0x6f, 0x79, 0x5d, 0x80, 0x32, 0x60, 0xe3, 0x83, 0x2f, 0x76, 0xb5, 0x01, 0x6c,
-	0x5d, 0xa1, 0x26, 0x93, 0x57, 0x51, 0xe8, 0xa4, 0x4d, 0xa3, 0x3e, 0x39, 0x5f, 0x03, 0x22, 0xcf,
-	0xc1, 0xf7, 0x02, 0x2f, 0x1a, 0x27, 0xfb, 0x96, 0xde, 0x90, 0xa9, 0x50, 0x15, 0x3a, 0xb0, 0x29,
-	0xcf, 0x32, 0xeb, 0x0f, 0x9b, 0xf1, 0xa4, 0xd3, 0x9e, 0xb3, 0xcc, 0xd2, 0x5b, 0x32, 0xeb, 0x5b,
-	0xd0, 0x1f, 0x05, 0xa3, 0x68, 0x9c, 0x4c, 0x7b, 0x1e, 0x0c, 0xb7, 0x64, 0xdc, 0x2e, 0x34, 0xaa,
-	0xfe, 0x63, 0xdd, 0x49, 0xd6, 0xf0, 0x34, 0x8b, 0xde, 0x93, 0x85, 0x05, 0xa3, 0xa4, 0xe0, 0x4e,
-	0xea, 0x32, 0x45, 0xc7, 0x1d, 0xf8, 0xa3, 0xc0, 0x8b, 0x66, 0xc9, 0xbc, 0x37, 0x78, 0xdf, 0xe9,
-	0x0f, 0xdf, 0x1e, 0x59, 0x74, 0x67, 0xbe, 0xfd, 0xb2, 0xa0, 0x4f, 0xe4, 0xa2, 0xeb, 0xf6, 0x08,
-	0x2e, 0xd9, 0x01, 0x15, 0xeb, 0xc4, 0xe5, 0xd5, 0xb1, 0x88, 0x46, 0x97, 0x08, 0xe1, 0x80, 0x32,
-	0xf2, 0x6f, 0x77, 0x0d, 0xa5, 0xac, 0xa1, 0xcd, 0x7a, 0x2c, 0x97, 0xf3, 0x23, 0xcd, 0xa8, 0x3a,
-	0x1c, 0xbc, 0x84, 0x1f, 0x41, 0x2e, 0x5d, 0x51, 0xad, 0x98, 0xd0, 0xeb, 0xb8, 0xe0, 0x58, 0x48,
-	0xa1, 0xad, 0x89, 0xdb, 0x9f, 0x36, 0x75, 0x75, 0xd6, 0x7c, 0xe6, 0xf1, 0x27, 0x00, 0x00, 0xff,
-	0xff, 0xfe, 0x9f, 0x88, 0xc6, 0xe9, 0x01, 0x00, 0x00,
+	proto.RegisterFile("vault/request_forwarding_service.proto", fileDescriptor_request_forwarding_service_d00e57dbe55ccd0b)
+}
+
+var fileDescriptor_request_forwarding_service_d00e57dbe55ccd0b = []byte{
+	// 357 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x52, 0x4d, 0x6b, 0xeb, 0x30,
+	0x10, 0x8c, 0xf3, 0x1d, 0x25, 0x79, 0x24, 0x7a, 0xef, 0xa0, 0x67, 0x28, 0xb8, 0x2e, 0x14, 0x43,
+	0xc1, 0x86, 0xf6, 0xdc, 0x43, 0x0b, 0xed, 0x0f, 0x70, 0x6e, 0xbd, 0x18, 0xc5, 0xde, 0xc4, 0x02,
+	0xc7, 0x52, 0x25, 0x39, 0xc1, 0xd7, 0xfe, 0xd5, 0xfe, 0x91, 0x62, 0xcb, 0x49, 0x1c, 0x5a, 0x7a,
+	0x11, 0xcc, 0xec, 0x30, 0xda, 0xdd, 0x59, 0x74, 0xbb, 0xa7, 0x45, 0xa6, 0x03, 0x09, 0xef, 0x05,
+	0x28, 0x1d, 0x6d, 0xb8, 0x3c, 0x50, 0x99, 0xb0, 0x7c, 0x1b, 0x29, 0x90, 0x7b, 0x16, 0x83, 0x2f,
+	0x24, 0xd7, 0x1c, 0x0f, 0x6a, 0x9d, 0x7d, 0x95, 0x42, 0x26, 0x40, 0x06, 0x67, 0x5d, 0xa0, 0x4b,
+	0x01, 0xca, 0xa8, 0xdc, 0x4f, 0x0b, 0x4d, 0x5f, 0xe2, 0x94, 0x87, 0xc6, 0x0e, 0x13, 0x34, 0xda,
+	0x81, 0x52, 0x74, 0x0b, 0xc4, 0x72, 0x2c, 0x6f, 0x12, 0x1e, 0x21, 0xbe, 0x46, 0xb3, 0x38, 0x2b,
+	0x94, 0x06, 0x19, 0xd1, 0x24, 0x91, 0xa4, 0x5b, 0x97, 0xa7, 0x0d, 0xf7, 0x94, 0x24, 0x12, 0xdf,
+	0xa0, 0x79, 0x5b, 0xa2, 0x48, 0xcf, 0xe9, 0x79, 0x93, 0x70, 0xd6, 0xd2, 0x28, 0x6c, 0xa3, 0x71,
+	0xca, 0x95, 0xce, 0xe9, 0x0e, 0x48, 0xbf, 0xf6, 0x38, 0x61, 0xfc, 0x1f, 0x8d, 0xa9, 0x60, 0xc6,
+	0x7f, 0x60, 0xbe, 0xa7, 0x82, 0xd5, 0xde, 0x04, 0x8d, 0xf6, 0x20, 0x15, 0xe3, 0x39, 0x19, 0x9a,
+	0x4a, 0x03, 0xab, 0xc6, 0x04, 0xc8, 0x4d, 0xa4, 0x34, 0xcd, 0x93, 0x75, 0x49, 0x46, 0x8e, 0xe5,
+	0x8d, 0xc3, 0x69, 0xc5, 0xad, 0x0c, 0xe5, 0x1e, 0xd0, 0xc4, 0x0c, 0x29, 0xb2, 0xf2, 0x97, 0x11,
+	0xbf, 0xf5, 0xdf, 0xfd, 0xa1, 0xff, 0x3b, 0xb4, 0x94, 0x20, 0x32, 0x16, 0x53, 0xcd, 0x78, 0x5e,
+	0xfd, 0xaa, 0x81, 0xf4, 0x1c, 0xcb, 0x9b, 0x87, 0x8b, 0x56, 0x61, 0x55, 0xf1, 0xf7, 0x1f, 0x16,
+	0x5a, 0x36, 0xab, 0x7d, 0x3d, 0x05, 0x80, 0x1f, 0xd1, 0x9f, 0x06, 0x1d, 0xd7, 0xfe, 0xd7, 0x3f,
+	0xe7, 0xe3, 0x37, 0xa4, 0xfd, 0xef, 0x92, 0x54, 0x82, 0xe7, 0x0a, 0xdc, 0x0e, 0xf6, 0x51, 0xbf,
+	0x9a, 0x06, 0x63, 0xbf, 0x8e, 0xd8, 0x6f, 0xe5, 0x67, 0x2f, 0x2e, 0x38, 0x91, 0x95, 0x6e, 0xe7,
+	0xd9, 0x7d, 0x73, 0xb6, 0x4c, 0xa7, 0xc5, 0xda, 0x8f, 0xf9, 0x2e, 0x48, 0xa9, 0x4a, 0x59, 0xcc,
+	0xa5, 0x08, 0xcc, 0x21, 0xd5, 0xef, 0x7a, 0x58, 0x9f, 0xc3, 0xc3, 0xd7, 0x00, 0x7e, 0x5a, 0x0f,
+	0x5f, 0x5e, 0x02, 0x00, 0x00,
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-multierror"
 	sockaddr "github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/vault/audit"
@@ -17,6 +19,7 @@ import (
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/helper/tracing"
 	"github.com/hashicorp/vault/helper/wrapping"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -41,6 +44,29 @@ type HandlerProperties struct {
 	MaxRequestSize        int64
 	MaxRequestDuration    time.Duration
 	DisablePrintableCheck bool
+	DisableUI             bool
+
+	// HideUnauthenticatedDetails removes the version, cluster and
+	// replication details from the unauthenticated seal status and health
+	// endpoints
+	HideUnauthenticatedDetails bool
+
+	// RequireAuthForSysInternal rejects requests to sys/internal endpoints
+	// that are made without a token
+	RequireAuthForSysInternal bool
+
+	// RequireRequestHeader rejects API requests that do not carry the
+	// X-Vault-Request header
+	RequireRequestHeader bool
+
+	// RequestQueueTimeout is how long API requests are held while the core
+	// is sealed or no active node is known, rather than failing right away
+	RequestQueueTimeout time.Duration
+
+	// MaxConcurrentRequests limits the API requests served at once, with
+	// ReservedConcurrentRequests more kept for the operator endpoints of sys/
+	MaxConcurrentRequests      int
+	ReservedConcurrentRequests int
 }
 
 // fetchEntityAndDerivedPolicies returns the entity object for the given entity
@@ -152,14 +178,25 @@ func (c *Core) fetchACLTokenEntryAndEntity(req *logical.Request) (*ACL, *logical
 		return nil, nil, nil, nil, ErrInternalError
 	}
 
-	allPolicies := append(te.Policies, identityPolicies...)
+	policies, err := c.policiesForToken(c.activeContext, te, identityPolicies)
+	if err != nil {
+		c.logger.Error("failed to fetch policies", "error", err)
+		return nil, nil, nil, nil, ErrInternalError
+	}
+
+	restrictions, err := c.restrictionsForToken(te)
+	if err != nil {
+		c.logger.Error("failed to fetch inline policies", "error", err)
+		return nil, nil, nil, nil, ErrInternalError
+	}
 
 	// Construct the corresponding ACL object
-	acl, err := c.policyStore.ACL(c.activeContext, allPolicies...)
+	acl, err := NewACL(policies)
 	if err != nil {
 		c.logger.Error("failed to construct ACL", "error", err)
 		return nil, nil, nil, nil, ErrInternalError
 	}
+	acl.restrict(restrictions...)
 
 	return acl, te, entity, identityPolicies, nil
 }
@@ -167,6 +204,9 @@ func (c *Core) fetchACLTokenEntryAndEntity(req *logical.Request) (*ACL, *logical
 func (c *Core) checkToken(ctx context.Context, req *logical.Request, unauth bool) (*logical.Auth, *logical.TokenEntry, error) {
 	defer metrics.MeasureSince([]string{"core", "check_token"}, time.Now())
 
+	span, ctx := tracing.StartSpan(ctx, "core.check_token")
+	defer span.Finish()
+
 	var acl *ACL
 	var te *logical.TokenEntry
 	var entity *identity.Entity
@@ -195,6 +235,14 @@ func (c *Core) checkToken(ctx context.Context, req *logical.Request, unauth bool
 		return nil, te, logical.ErrPermissionDenied
 	}
 
+	// Batch tokens are never revoked, so nothing would destroy a cubbyhole
+	// tied to one
+	if te != nil && te.Type == logical.TokenTypeBatch {
+		if entry := c.router.MatchingMountEntry(req.Path); entry != nil && entry.Type == "cubbyhole" {
+			return nil, te, errors.New("cubbyhole is not available to batch tokens")
+		}
+	}
+
 	// Check if this is a root protected path
 	rootPath := c.router.RootPath(req.Path)
 
@@ -254,14 +302,37 @@ func (c *Core) checkToken(ctx context.Context, req *logical.Request, unauth bool
 		auth.EntityID = te.EntityID
 		// Store the entity ID in the request object
 		req.EntityID = te.EntityID
+		req.TokenMetadata = te.Meta
+
+		if strutil.StrListContains(te.Policies, "root") {
+			metrics.IncrCounter([]string{"token", "root", "use"}, 1)
+			if c.logRootTokens {
+				c.logger.Warn("root token used", "accessor", te.Accessor, "operation", req.Operation, "path", req.Path)
+			}
+		}
+	}
+
+	// Pass the name and metadata of the entity on to the backend, copying
+	// the metadata since the entity is shared with MemDB
+	if entity != nil {
+		req.EntityName = entity.Name
+		if entity.Metadata != nil {
+			req.EntityMetadata = make(map[string]string, len(entity.Metadata))
+			for k, v := range entity.Metadata {
+				req.EntityMetadata[k] = v
+			}
+		}
 	}
 
 	// Check the standard non-root ACLs. Return the token entry if it's not
 	// allowed so we can decrement the use count.
+	aclSpan, _ := tracing.StartSpan(ctx, "acl.check")
 	authResults := c.performPolicyChecks(ctx, acl, te, req, entity, &PolicyCheckOpts{
 		Unauth:            unauth,
 		RootPrivsRequired: rootPath,
 	})
+	aclSpan.SetTag("allowed", strconv.FormatBool(authResults.Allowed))
+	aclSpan.Finish()
 	if authResults.Error.ErrorOrNil() != nil {
 		return auth, te, authResults.Error
 	}
@@ -280,13 +351,37 @@ func (c *Core) HandleRequest(httpCtx context.Context, req *logical.Request) (res
 	if c.Sealed() {
 		return nil, consts.ErrSealed
 	}
+	// Requests to the auth methods of a namespace are routed under the global
+	// auth prefix
+	req.Path = c.namespaceRequestPath(req.Path)
+
 	if c.standby {
-		return nil, consts.ErrStandby
+		if !c.perfStandbyActive {
+			return nil, consts.ErrStandby
+		}
+		if !c.perfStandbyCanServe(req) {
+			return nil, consts.ErrPerfStandbyPleaseForward
+		}
+	}
+	if c.ReplicationState().HasState(consts.ReplicationDRSecondary|consts.ReplicationDRBootstrapping) &&
+		!strings.HasPrefix(req.Path, "sys/replication/") {
+		return logical.ErrorResponse(ErrDRSecondaryState.Error()), logical.ErrInvalidRequest
+	}
+
+	// Don't start processing a request that has already run out of time
+	if err := httpCtx.Err(); err != nil {
+		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(c.activeContext)
 	defer cancel()
 
+	// Continue the trace of the request, as its context derives from the
+	// active context rather than the HTTP one
+	if span := tracing.SpanFromContext(httpCtx); span != nil {
+		ctx = tracing.ContextWithSpan(ctx, span)
+	}
+
 	go func() {
 		select {
 		case <-ctx.Done():
@@ -307,6 +402,14 @@ func (c *Core) HandleRequest(httpCtx context.Context, req *logical.Request) (res
 		return logical.ErrorResponse("cannot write to a path ending in '/'"), nil
 	}
 
+	// A performance standby only audits the requests it serves once it knows
+	// it doesn't forward them, so that they are audited once
+	var deferredAudit *deferredRequestAudit
+	if c.standby {
+		deferredAudit = &deferredRequestAudit{}
+		ctx = context.WithValue(ctx, deferredRequestAuditKey{}, deferredAudit)
+	}
+
 	var auth *logical.Auth
 	if c.router.LoginPath(req.Path) {
 		resp, auth, err = c.handleLoginRequest(ctx, req)
@@ -314,6 +417,18 @@ func (c *Core) HandleRequest(httpCtx context.Context, req *logical.Request) (res
 		resp, auth, err = c.handleRequest(ctx, req)
 	}
 
+	// A performance standby can't complete requests that need to write, so
+	// hand these off to the active node
+	if c.standby && (perfStandbyReadOnlyErr(resp, err) || errwrap.Contains(err, consts.ErrPerfStandbyPleaseForward.Error())) {
+		return nil, consts.ErrPerfStandbyPleaseForward
+	}
+	if deferredAudit != nil && deferredAudit.input != nil {
+		if auditErr := c.auditBroker.LogRequest(ctx, deferredAudit.input, c.auditedHeaders); auditErr != nil {
+			c.logger.Error("failed to audit request", "path", req.Path, "error", auditErr)
+			return nil, ErrInternalError
+		}
+	}
+
 	// Ensure we don't leak internal data
 	if resp != nil {
 		if resp.Secret != nil {
@@ -411,8 +526,36 @@ func (c *Core) HandleRequest(httpCtx context.Context, req *logical.Request) (res
 	return
 }
 
+// mountMetricLabels returns the labels of the per-mount request metrics: the
+// mount the request is routed to, relative to its namespace, the namespace
+// and the operation.
+func (c *Core) mountMetricLabels(req *logical.Request) []metrics.Label {
+	entry := c.router.MatchingMountEntry(req.Path)
+	if entry == nil {
+		return nil
+	}
+	ns := c.namespaceByID(entry.NamespaceID)
+	if ns == nil {
+		return nil
+	}
+
+	nsLabel := ns.Path
+	if nsLabel == "" {
+		nsLabel = "root"
+	}
+
+	return []metrics.Label{
+		{Name: "mount", Value: strings.TrimPrefix(c.router.MatchingMount(req.Path), ns.Path)},
+		{Name: "namespace", Value: nsLabel},
+		{Name: "operation", Value: string(req.Operation)},
+	}
+}
+
 func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
 	defer metrics.MeasureSince([]string{"core", "handle_request"}, time.Now())
+	if labels := c.mountMetricLabels(req); labels != nil {
+		defer metrics.MeasureSinceWithLabels([]string{"core", "handle_request_by_mount"}, time.Now(), labels)
+	}
 
 	var nonHMACReqDataKeys []string
 	entry := c.router.MatchingMountEntry(req.Path)
@@ -425,6 +568,11 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 
 	// Validate the token
 	auth, te, ctErr := c.checkToken(ctx, req, false)
+	// Decrementing the use count requires a write, which a performance
+	// standby can't do
+	if c.standby && te != nil && te.NumUses != 0 {
+		return nil, nil, consts.ErrPerfStandbyPleaseForward
+	}
 	// We run this logic first because we want to decrement the use count even in the case of an error
 	if te != nil {
 		// Attempt to use the token (decrement NumUses)
@@ -492,6 +640,9 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		return logical.ErrorResponse(ctErr.Error()), auth, retErr
 	}
 
+	// Count the client towards the activity log
+	c.recordActivity(ctx, te)
+
 	// Attach the display name
 	req.DisplayName = auth.DisplayName
 
@@ -501,14 +652,56 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		Request:            req,
 		NonHMACReqDataKeys: nonHMACReqDataKeys,
 	}
-	if err := c.auditBroker.LogRequest(ctx, logInput, c.auditedHeaders); err != nil {
+	if err := c.auditRequest(ctx, logInput); err != nil {
 		c.logger.Error("failed to audit request", "path", req.Path, "error", err)
 		retErr = multierror.Append(retErr, ErrInternalError)
 		return nil, auth, retErr
 	}
 
+	// A retry of a request carrying an idempotency key is given the lease
+	// registered for the first one instead of a new secret from the backend
+	var idempotentResp *logical.Response
+	if req.IdempotencyKey != "" && c.router.IdempotentPath(req.Path) {
+		leaseID, unlock, err := c.expiration.lockIdempotent(req)
+		if err != nil {
+			c.logger.Error("failed to lock idempotent request", "request_path", req.Path, "error", err)
+			retErr = multierror.Append(retErr, ErrInternalError)
+			return nil, auth, retErr
+		}
+		defer unlock()
+
+		idempotentResp, err = c.expiration.FetchIdempotent(leaseID, req)
+		switch {
+		case err == errIdempotentLeaseExpired:
+			retErr = multierror.Append(retErr, logical.ErrInvalidRequest)
+			return logical.ErrorResponse(err.Error()), auth, retErr
+		case err != nil:
+			c.logger.Error("failed to fetch idempotent lease", "request_path", req.Path, "error", err)
+			retErr = multierror.Append(retErr, ErrInternalError)
+			return nil, auth, retErr
+		}
+	}
+
+	// Refuse the requests which could issue a lease counting against a
+	// reached quota before they reach the backend
+	if idempotentResp == nil {
+		if err := c.checkRequestLeaseCountQuotas(entry, req); err != nil {
+			retErr = multierror.Append(retErr, err)
+			return logical.ErrorResponse(err.Error()), auth, retErr
+		}
+	}
+
 	// Route the request
-	resp, routeErr := c.router.Route(ctx, req)
+	var resp *logical.Response
+	var routeErr error
+	if idempotentResp != nil {
+		resp = idempotentResp
+	} else {
+		resp, routeErr = c.router.Route(ctx, req)
+		if routeErr == nil && (resp == nil || !resp.IsError()) {
+			c.publishKVEvent(entry, req)
+		}
+	}
 	if resp != nil {
 		// If wrapping is used, use the shortest between the request and response
 		var wrapTTL time.Duration
@@ -554,7 +747,8 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 
 	// If there is a secret, we must register it with the expiration manager.
 	// We exclude renewal of a lease, since it does not need to be re-registered
-	if resp != nil && resp.Secret != nil && !strings.HasPrefix(req.Path, "sys/renew") &&
+	if resp != nil && resp.Secret != nil && idempotentResp == nil &&
+		!strings.HasPrefix(req.Path, "sys/renew") &&
 		!strings.HasPrefix(req.Path, "sys/leases/renew") {
 		// KV mounts should return the TTL but not register
 		// for a lease as this provides a massive slowdown
@@ -598,6 +792,11 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		}
 
 		if registerLease {
+			// Performance standbys can't register leases
+			if c.standby {
+				return nil, auth, consts.ErrPerfStandbyPleaseForward
+			}
+
 			sysView := c.router.MatchingSystemView(req.Path)
 			if sysView == nil {
 				c.logger.Error("unable to look up sys view for login path", "request_path", req.Path)
@@ -613,7 +812,20 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 			}
 			resp.Secret.TTL = ttl
 
+			if te != nil && te.Type == logical.TokenTypeBatch {
+				remaining := batchTokenRemainingTTL(te)
+				if resp.Secret.TTL > remaining {
+					resp.Secret.TTL = remaining
+				}
+				if resp.Secret.MaxTTL == 0 || resp.Secret.MaxTTL > remaining {
+					resp.Secret.MaxTTL = remaining
+				}
+			}
+
 			leaseID, err := c.expiration.Register(req, resp)
+			if err != nil && errwrap.Contains(err, logical.ErrQuotaExceeded.Error()) {
+				return logical.ErrorResponse(err.Error()), auth, err
+			}
 			if err != nil {
 				c.logger.Error("failed to register lease", "request_path", req.Path, "error", err)
 				retErr = multierror.Append(retErr, ErrInternalError)
@@ -625,7 +837,8 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 
 	// If the request was to renew a token, and if there are group aliases set
 	// in the auth object, then the group memberships should be refreshed
-	if strings.HasPrefix(req.Path, "auth/token/renew") &&
+	tokenStorePath := c.namespaceTokenStorePath(req.Path)
+	if strings.HasPrefix(tokenStorePath, "auth/token/renew") &&
 		resp != nil &&
 		resp.Auth != nil &&
 		resp.Auth.EntityID != "" &&
@@ -642,8 +855,8 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 	// Only the token store is allowed to return an auth block, for any
 	// other request this is an internal error. We exclude renewal of a token,
 	// since it does not need to be re-registered
-	if resp != nil && resp.Auth != nil && !strings.HasPrefix(req.Path, "auth/token/renew") {
-		if !strings.HasPrefix(req.Path, "auth/token/") {
+	if resp != nil && resp.Auth != nil && !strings.HasPrefix(tokenStorePath, "auth/token/renew") {
+		if !strings.HasPrefix(tokenStorePath, "auth/token/") {
 			c.logger.Error("unexpected Auth response for non-token backend", "request_path", req.Path)
 			retErr = multierror.Append(retErr, ErrInternalError)
 			return nil, auth, retErr
@@ -655,6 +868,13 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 			return nil, nil, ErrInternalError
 		}
 
+		if !strings.HasPrefix(resp.Auth.ClientToken, batchTokenPrefix) {
+			if err := c.expiration.checkLeaseCountQuotas(resp.Auth.CreationPath); err != nil {
+				c.tokenStore.revokeOrphan(ctx, resp.Auth.ClientToken)
+				return logical.ErrorResponse(err.Error()), nil, err
+			}
+		}
+
 		resp.Auth.TokenPolicies = policyutil.SanitizePolicies(resp.Auth.Policies, policyutil.DoNotAddDefaultPolicy)
 		if err := c.expiration.RegisterAuth(resp.Auth.CreationPath, resp.Auth); err != nil {
 			c.tokenStore.revokeOrphan(ctx, te.ID)
@@ -690,6 +910,9 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 // unauthenticated request to the backend.
 func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
 	defer metrics.MeasureSince([]string{"core", "handle_login_request"}, time.Now())
+	if labels := c.mountMetricLabels(req); labels != nil {
+		defer metrics.MeasureSinceWithLabels([]string{"core", "handle_login_request_by_mount"}, time.Now(), labels)
+	}
 
 	req.Unauthenticated = true
 
@@ -708,13 +931,28 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 
 	// The token store uses authentication even when creating a new token,
 	// so it's handled in handleRequest. It should not be reached here.
-	if strings.HasPrefix(req.Path, "auth/token/") {
+	if strings.HasPrefix(c.namespaceTokenStorePath(req.Path), "auth/token/") {
 		c.logger.Error("unexpected login request for token backend", "request_path", req.Path)
 		return nil, nil, ErrInternalError
 	}
 
+	// Refuse the login if the auth method locked out its user or client
+	recordLogin, lockedResp, err := c.checkLoginLockout(ctx, req)
+	if err != nil {
+		return lockedResp, nil, err
+	}
+
+	// Refuse the login before it reaches the auth method if the lease of its
+	// token would count against a reached quota
+	if err := c.checkRequestLeaseCountQuotas(c.router.MatchingMountEntry(req.Path), req); err != nil {
+		return logical.ErrorResponse(err.Error()), nil, err
+	}
+
 	// Route the request
 	resp, routeErr := c.router.Route(ctx, req)
+	if recordLogin != nil {
+		recordLogin(resp, routeErr)
+	}
 	if resp != nil {
 		// If wrapping is used, use the shortest between the request and response
 		var wrapTTL time.Duration
@@ -756,6 +994,15 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		}
 	}
 
+	// Warn the client if the auth method is deprecated
+	if resp != nil {
+		if entry := c.router.MatchingMountEntry(req.Path); entry != nil {
+			if warning := deprecationWarning(entry); warning != "" {
+				resp.AddWarning(warning)
+			}
+		}
+	}
+
 	// A login request should never return a secret!
 	if resp != nil && resp.Secret != nil {
 		c.logger.Error("unexpected Secret response for login path", "request_path", req.Path)
@@ -848,6 +1095,15 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			EntityID:     auth.EntityID,
 			BoundCIDRs:   auth.BoundCIDRs,
 		}
+		if mEntry != nil {
+			te.NamespaceID = mEntry.NamespaceID
+			if mEntry.Config.TokenType == logical.TokenTypeBatch {
+				te.Type = logical.TokenTypeBatch
+			}
+		}
+		if te.Type == logical.TokenTypeBatch && (auth.Period != 0 || auth.NumUses != 0) {
+			return logical.ErrorResponse("the mount issues batch tokens, which cannot be periodic or have a use limit"), nil, logical.ErrInvalidRequest
+		}
 
 		te.Policies = policyutil.SanitizePolicies(auth.Policies, policyutil.AddDefaultPolicy)
 
@@ -871,6 +1127,12 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			}
 		}
 
+		if te.Type != logical.TokenTypeBatch {
+			if err := c.expiration.checkLeaseCountQuotas(te.Path); err != nil {
+				return logical.ErrorResponse(err.Error()), nil, err
+			}
+		}
+
 		if err := c.tokenStore.create(ctx, &te); err != nil {
 			c.logger.Error("failed to create token", "error", err)
 			return nil, auth, ErrInternalError
@@ -881,11 +1143,14 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		auth.Accessor = te.Accessor
 		auth.TTL = te.TTL
 
-		// Register with the expiration manager
-		if err := c.expiration.RegisterAuth(te.Path, auth); err != nil {
-			c.tokenStore.revokeOrphan(ctx, te.ID)
-			c.logger.Error("failed to register token lease", "request_path", req.Path, "error", err)
-			return nil, auth, ErrInternalError
+		// Register with the expiration manager. Batch tokens have no lease;
+		// they can't be renewed and expire on their own.
+		if te.Type != logical.TokenTypeBatch {
+			if err := c.expiration.RegisterAuth(te.Path, auth); err != nil {
+				c.tokenStore.revokeOrphan(ctx, te.ID)
+				c.logger.Error("failed to register token lease", "request_path", req.Path, "error", err)
+				return nil, auth, ErrInternalError
+			}
 		}
 
 		auth.IdentityPolicies = policyutil.SanitizePolicies(identityPolicies, policyutil.DoNotAddDefaultPolicy)
@@ -2,9 +2,13 @@ package vault
 
 import (
 	"context"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-uuid"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 	"github.com/hashicorp/vault/logical"
@@ -144,3 +148,365 @@ func TestRequestHandling_LoginWrapping(t *testing.T) {
 		t.Fatalf("bad: %#v", resp)
 	}
 }
+
+func TestRequestHandling_MountMetricLabels(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/namespaces/team1")
+	req.ClientToken = root
+	if _, err := core.HandleRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	req = logical.TestRequest(t, logical.UpdateOperation, "team1/sys/mounts/secret")
+	req.ClientToken = root
+	req.Data["type"] = "kv"
+	if _, err := core.HandleRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]metrics.Label{
+		"secret/foo": {
+			{Name: "mount", Value: "secret/"},
+			{Name: "namespace", Value: "root"},
+			{Name: "operation", Value: "read"},
+		},
+		"team1/secret/foo": {
+			{Name: "mount", Value: "secret/"},
+			{Name: "namespace", Value: "team1/"},
+			{Name: "operation", Value: "read"},
+		},
+		"auth/token/lookup-self": {
+			{Name: "mount", Value: "auth/token/"},
+			{Name: "namespace", Value: "root"},
+			{Name: "operation", Value: "read"},
+		},
+		"nonexistent/foo": nil,
+	}
+	for path, expected := range cases {
+		labels := core.mountMetricLabels(logical.TestRequest(t, logical.ReadOperation, path))
+		if !reflect.DeepEqual(labels, expected) {
+			t.Fatalf("%s: expected %#v, got %#v", path, expected, labels)
+		}
+	}
+}
+
+func TestRequestHandling_LoginBatchToken(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	req := &logical.Request{
+		Path:        "sys/auth/userpass",
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "userpass",
+			"config": map[string]interface{}{
+				"token_type": "batch",
+			},
+		},
+		Connection: &logical.Connection{},
+	}
+	resp, err := core.HandleRequest(context.Background(), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+
+	req.Path = "auth/userpass/users/test"
+	req.Data = map[string]interface{}{
+		"password": "foo",
+		"policies": "default",
+	}
+	resp, err = core.HandleRequest(context.Background(), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+
+	login := func() *logical.Auth {
+		resp, err := core.HandleRequest(context.Background(), &logical.Request{
+			Path:      "auth/userpass/login/test",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"password": "foo",
+			},
+			Connection: &logical.Connection{},
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp == nil || resp.Auth == nil {
+			t.Fatalf("bad: %#v", resp)
+		}
+		return resp.Auth
+	}
+
+	auth := login()
+	if !strings.HasPrefix(auth.ClientToken, batchTokenPrefix) || auth.Accessor != "" {
+		t.Fatalf("bad: %#v", auth)
+	}
+
+	// The batch token can be used, but not renewed or tied to a cubbyhole
+	resp, err = core.HandleRequest(context.Background(), &logical.Request{
+		Path:        "auth/token/lookup-self",
+		ClientToken: auth.ClientToken,
+		Operation:   logical.ReadOperation,
+		Connection:  &logical.Connection{},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["type"] != "batch" || resp.Data["renewable"] != false || resp.Data["display_name"] != "userpass-test" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = core.HandleRequest(context.Background(), &logical.Request{
+		Path:        "auth/token/renew-self",
+		ClientToken: auth.ClientToken,
+		Operation:   logical.UpdateOperation,
+		Connection:  &logical.Connection{},
+	})
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error renewing a batch token: %#v", resp)
+	}
+
+	resp, err = core.HandleRequest(context.Background(), &logical.Request{
+		Path:        "cubbyhole/foo",
+		ClientToken: auth.ClientToken,
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"foo": "bar",
+		},
+		Connection: &logical.Connection{},
+	})
+	if err == nil {
+		t.Fatalf("expected an error writing to the cubbyhole of a batch token: %#v", resp)
+	}
+
+	// Tuning the mount back to the default issues service tokens
+	resp, err = core.HandleRequest(context.Background(), &logical.Request{
+		Path:        "sys/auth/userpass/tune",
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"token_type": "default",
+		},
+		Connection: &logical.Connection{},
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+
+	auth = login()
+	if strings.HasPrefix(auth.ClientToken, batchTokenPrefix) || auth.Accessor == "" {
+		t.Fatalf("bad: %#v", auth)
+	}
+	if _, err := core.HandleRequest(context.Background(), &logical.Request{
+		Path:        "sys/auth/userpass/tune",
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"token_type": "batch",
+		},
+		Connection: &logical.Connection{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	auth = login()
+
+	// A batch token stops working along with the auth method which created
+	// it
+	resp, err = core.HandleRequest(context.Background(), &logical.Request{
+		Path:        "sys/auth/userpass",
+		ClientToken: root,
+		Operation:   logical.DeleteOperation,
+		Connection:  &logical.Connection{},
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+	resp, err = core.HandleRequest(context.Background(), &logical.Request{
+		Path:        "auth/token/lookup-self",
+		ClientToken: auth.ClientToken,
+		Operation:   logical.ReadOperation,
+		Connection:  &logical.Connection{},
+	})
+	if !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected the batch token to be invalid, got %v %#v", err, resp)
+	}
+}
+
+func TestRequestHandling_BatchTokenLeaseTTL(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	request := func(token string, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := core.HandleRequest(context.Background(), &logical.Request{
+			Path:        path,
+			ClientToken: token,
+			Operation:   op,
+			Data:        data,
+			Connection:  &logical.Connection{},
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp
+	}
+
+	request(root, logical.UpdateOperation, "sys/auth/userpass", map[string]interface{}{
+		"type": "userpass",
+		"config": map[string]interface{}{
+			"token_type": "batch",
+		},
+	})
+	request(root, logical.UpdateOperation, "sys/policy/reader", map[string]interface{}{
+		"policy": `path "secret/*" { capabilities = ["read"] }`,
+	})
+	request(root, logical.UpdateOperation, "auth/userpass/users/test", map[string]interface{}{
+		"password": "foo",
+		"policies": "reader",
+		"ttl":      "10m",
+	})
+	request(root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"foo":   "bar",
+		"lease": "1h",
+	})
+
+	resp := request("", logical.UpdateOperation, "auth/userpass/login/test", map[string]interface{}{
+		"password": "foo",
+	})
+	if resp == nil || resp.Auth == nil || !strings.HasPrefix(resp.Auth.ClientToken, batchTokenPrefix) {
+		t.Fatalf("bad: %#v", resp)
+	}
+	token := resp.Auth.ClientToken
+	te, err := core.tokenStore.Lookup(context.Background(), token)
+	if err != nil || te == nil {
+		t.Fatalf("bad: %v %#v", err, te)
+	}
+	tokenExpiry := time.Unix(te.CreationTime, 0).Add(te.TTL)
+
+	// The lease is capped at the expiry of the batch token rather than the
+	// hour the secret asks for
+	resp = request(token, logical.ReadOperation, "secret/foo", nil)
+	if resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Secret.TTL > 10*time.Minute || resp.Secret.MaxTTL > 10*time.Minute {
+		t.Fatalf("expected the lease to be capped at the token TTL: %#v", resp.Secret)
+	}
+	le, err := core.expiration.loadEntry(resp.Secret.LeaseID)
+	if err != nil || le == nil {
+		t.Fatalf("bad: %v %#v", err, le)
+	}
+	if le.ExpireTime.After(tokenExpiry.Add(time.Second)) {
+		t.Fatalf("lease expires at %v, after its batch token at %v", le.ExpireTime, tokenExpiry)
+	}
+}
+
+func TestRequestHandling_LoginLockout(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/auth/userpass")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"type": "userpass",
+		"config": map[string]interface{}{
+			"lockout_threshold":    2,
+			"lockout_ip_threshold": 3,
+			"lockout_duration":     "1h",
+		},
+	}
+	resp, err := core.HandleRequest(context.Background(), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+
+	for _, user := range []string{"alice", "bob"} {
+		req = logical.TestRequest(t, logical.UpdateOperation, "auth/userpass/users/"+user)
+		req.ClientToken = root
+		req.Data["password"] = "foo"
+		resp, err = core.HandleRequest(context.Background(), req)
+		if err != nil || resp != nil {
+			t.Fatalf("bad: %v %#v", err, resp)
+		}
+	}
+
+	login := func(user, password, remoteAddr string) (*logical.Response, error) {
+		return core.HandleRequest(context.Background(), &logical.Request{
+			Path:      "auth/userpass/login/" + user,
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"password": password,
+			},
+			Connection: &logical.Connection{RemoteAddr: remoteAddr},
+		})
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp, err := login("alice", "bar", "10.0.0.1"); err == logical.ErrLoginLockedOut || !resp.IsError() {
+			t.Fatalf("expected the login to fail: %v %#v", err, resp)
+		}
+	}
+	if _, err := login("alice", "foo", "10.0.0.2"); err != logical.ErrLoginLockedOut {
+		t.Fatalf("expected the user to be locked out: %v", err)
+	}
+
+	// The client reaches its threshold with another user
+	if resp, err := login("bob", "bar", "10.0.0.1"); err == logical.ErrLoginLockedOut || !resp.IsError() {
+		t.Fatalf("expected the login to fail: %v %#v", err, resp)
+	}
+	if _, err := login("bob", "foo", "10.0.0.1"); err != logical.ErrLoginLockedOut {
+		t.Fatalf("expected the client to be locked out: %v", err)
+	}
+	resp, err = login("bob", "foo", "10.0.0.2")
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/auth/userpass/lockouts")
+	req.ClientToken = root
+	resp, err = core.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	users := resp.Data["users"].(map[string]interface{})
+	clients := resp.Data["remote_addresses"].(map[string]interface{})
+	if _, ok := users["alice"]; !ok || len(users) != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if _, ok := clients["10.0.0.1"]; !ok || len(clients) != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/auth/userpass/unlock")
+	req.ClientToken = root
+	req.Data["user"] = "alice"
+	resp, err = core.HandleRequest(context.Background(), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+	resp, err = login("alice", "foo", "10.0.0.2")
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+
+	// Logging in to a valid account in between does not clear the failures
+	// of the client, so that it can't spray other users unnoticed
+	for _, user := range []string{"dave", "erin"} {
+		if resp, err := login(user, "bar", "10.0.0.3"); err == logical.ErrLoginLockedOut || !resp.IsError() {
+			t.Fatalf("expected the login to fail: %v %#v", err, resp)
+		}
+	}
+	resp, err = login("bob", "foo", "10.0.0.3")
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+	if resp, err := login("frank", "bar", "10.0.0.3"); err == logical.ErrLoginLockedOut || !resp.IsError() {
+		t.Fatalf("expected the login to fail: %v %#v", err, resp)
+	}
+	if _, err := login("bob", "foo", "10.0.0.3"); err != logical.ErrLoginLockedOut {
+		t.Fatalf("expected the client to be locked out: %v", err)
+	}
+}
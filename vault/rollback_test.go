@@ -40,7 +40,8 @@ func mockRollback(t *testing.T) (*RollbackManager, *NoopBackend) {
 
 	logger := logging.NewVaultLogger(log.Trace)
 
-	rb := NewRollbackManager(logger, mountsFunc, router, context.Background())
+	c, _, _ := TestCoreUnsealed(t)
+	rb := NewRollbackManager(context.Background(), logger, mountsFunc, router, c)
 	rb.period = 10 * time.Millisecond
 	return rb, backend
 }
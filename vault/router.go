@@ -11,6 +11,7 @@ import (
 	"github.com/armon/go-metrics"
 	"github.com/armon/go-radix"
 	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/helper/tracing"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -47,6 +48,9 @@ type routeEntry struct {
 	storagePrefix string
 	rootPaths     atomic.Value
 	loginPaths    atomic.Value
+	idemPaths     atomic.Value
+	limiter       mountRequestLimiter
+	lockout       loginLockout
 	l             sync.RWMutex
 }
 
@@ -117,6 +121,7 @@ func (r *Router) Mount(backend logical.Backend, prefix string, mountEntry *Mount
 	}
 	re.rootPaths.Store(pathsToRadix(paths.Root))
 	re.loginPaths.Store(pathsToRadix(paths.Unauthenticated))
+	re.idemPaths.Store(pathsToRadix(paths.Idempotent))
 
 	switch {
 	case prefix == "":
@@ -316,6 +321,18 @@ func (r *Router) MatchingMountEntry(path string) *MountEntry {
 	return raw.(*routeEntry).mountEntry
 }
 
+// MatchingLoginLockout returns the login lockout of the auth method used
+// for a path
+func (r *Router) MatchingLoginLockout(path string) *loginLockout {
+	r.l.RLock()
+	_, raw, ok := r.root.LongestPrefix(path)
+	r.l.RUnlock()
+	if !ok {
+		return nil
+	}
+	return &raw.(*routeEntry).lockout
+}
+
 // MatchingBackend returns the backend used for a path
 func (r *Router) MatchingBackend(path string) logical.Backend {
 	r.l.RLock()
@@ -445,6 +462,9 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 	switch {
 	case strings.HasPrefix(originalPath, "auth/token/"):
 	case strings.HasPrefix(originalPath, "sys/"):
+	case re.mountEntry.NamespaceID != "" && (re.mountEntry.Type == "token" || re.mountEntry.Type == "system"):
+		// The token store and system backend are also mounted within each
+		// namespace
 	case strings.HasPrefix(originalPath, "cubbyhole/"):
 		// In order for the token store to revoke later, we need to have the same
 		// salted ID, so we double-salt what's going to the cubbyhole backend
@@ -515,11 +535,41 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		req.SetTokenEntry(reqTokenEntry)
 	}()
 
+	// Record the dispatch to the backend in the trace of the request
+	spanName := "router.route"
+	if existenceCheck {
+		spanName = "router.existence_check"
+	}
+	span, ctx := tracing.StartSpan(ctx, spanName)
+	if span != nil {
+		span.SetTag("mount", mount)
+		span.SetTag("mount_type", re.mountEntry.Type)
+		span.SetTag("operation", string(req.Operation))
+		span.SetTag("path", req.Path)
+		defer span.Finish()
+	}
+
 	// Invoke the backend
 	if existenceCheck {
 		ok, exists, err := re.backend.HandleExistenceCheck(ctx, req)
 		return nil, ok, exists, err
 	} else {
+		// Rollback and revoke operations are run by Vault itself and are not
+		// limited, the same way they are allowed on tainted mounts
+		switch req.Operation {
+		case logical.RevokeOperation, logical.RollbackOperation:
+		default:
+			if maxInFlight := re.mountEntry.Config.MaxInFlightRequests; maxInFlight > 0 {
+				if err := re.limiter.acquire(ctx, maxInFlight, re.mountEntry.Config.MaxQueuedRequests); err != nil {
+					if err == logical.ErrMountOverloaded {
+						return logical.ErrorResponse(fmt.Sprintf("too many concurrent requests to %q", mount)), false, false, err
+					}
+					return nil, false, false, err
+				}
+				defer re.limiter.release()
+			}
+		}
+
 		resp, err := re.backend.HandleRequest(ctx, req)
 		// When a token gets renewed, the request hits this path and reaches
 		// token store. Token store delegates the renewal to the expiration
@@ -606,6 +656,36 @@ func (r *Router) LoginPath(path string) bool {
 	return match == remain
 }
 
+// IdempotentPath checks if the given path honors idempotency keys
+func (r *Router) IdempotentPath(path string) bool {
+	r.l.RLock()
+	mount, raw, ok := r.root.LongestPrefix(path)
+	r.l.RUnlock()
+	if !ok {
+		return false
+	}
+	re := raw.(*routeEntry)
+
+	// Trim to get remaining path
+	remain := strings.TrimPrefix(path, mount)
+
+	// Check the idempotent paths of this backend
+	idemPaths := re.idemPaths.Load().(*radix.Tree)
+	match, raw, ok := idemPaths.LongestPrefix(remain)
+	if !ok {
+		return false
+	}
+	prefixMatch := raw.(bool)
+
+	// Handle the prefix match case
+	if prefixMatch {
+		return strings.HasPrefix(remain, match)
+	}
+
+	// Handle the exact match case
+	return match == remain
+}
+
 // pathsToRadix converts a the mapping of special paths to a mapping
 // of special paths to radix trees.
 func pathsToRadix(paths []string) *radix.Tree {
@@ -0,0 +1,75 @@
+package vault
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// mountRequestLimiter bounds the requests a mount handles concurrently, so
+// that a slow backend holds up its own requests rather than the whole API.
+// Requests over the limit wait in a queue, in the order they arrived, and
+// requests over the queue are shed. The limits are passed on each acquire, so
+// that tuning the mount applies to the following requests.
+type mountRequestLimiter struct {
+	l        sync.Mutex
+	inFlight int
+	waiters  []chan struct{}
+}
+
+// acquire takes a slot for a request, waiting in the queue if the mount is
+// at maxInFlight. A maxInFlight of 0 leaves the mount unlimited. It returns
+// logical.ErrMountOverloaded if maxQueued requests are already waiting, or
+// the error of the context if it is done before a slot frees up.
+func (m *mountRequestLimiter) acquire(ctx context.Context, maxInFlight, maxQueued int) error {
+	m.l.Lock()
+	if maxInFlight <= 0 || m.inFlight < maxInFlight {
+		m.inFlight++
+		m.l.Unlock()
+		return nil
+	}
+	if len(m.waiters) >= maxQueued {
+		m.l.Unlock()
+		return logical.ErrMountOverloaded
+	}
+	ready := make(chan struct{})
+	m.waiters = append(m.waiters, ready)
+	m.l.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	m.l.Lock()
+	defer m.l.Unlock()
+	for i, waiter := range m.waiters {
+		if waiter == ready {
+			m.waiters = append(m.waiters[:i], m.waiters[i+1:]...)
+			return ctx.Err()
+		}
+	}
+
+	// The slot was handed over while the context was done, so pass it on
+	m.releaseLocked()
+	return ctx.Err()
+}
+
+// release frees the slot of a request, handing it over to the first request
+// in the queue if there is one.
+func (m *mountRequestLimiter) release() {
+	m.l.Lock()
+	m.releaseLocked()
+	m.l.Unlock()
+}
+
+func (m *mountRequestLimiter) releaseLocked() {
+	if len(m.waiters) == 0 {
+		m.inFlight--
+		return
+	}
+	close(m.waiters[0])
+	m.waiters = m.waiters[1:]
+}
@@ -21,6 +21,7 @@ type NoopBackend struct {
 
 	Root            []string
 	Login           []string
+	Idempotent      []string
 	Paths           []string
 	Requests        []*logical.Request
 	Response        *logical.Response
@@ -62,6 +63,7 @@ func (n *NoopBackend) SpecialPaths() *logical.Paths {
 	return &logical.Paths{
 		Root:            n.Root,
 		Unauthenticated: n.Login,
+		Idempotent:      n.Idempotent,
 	}
 }
 
@@ -543,6 +545,112 @@ func TestRouter_Untaint(t *testing.T) {
 	}
 }
 
+func TestRouter_RequestLimits(t *testing.T) {
+	r := NewRouter()
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{}, 10)
+	unblock := make(chan struct{})
+	n := &NoopBackend{
+		RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+			if req.Operation == logical.ReadOperation {
+				started <- struct{}{}
+				<-unblock
+			}
+			return nil, nil
+		},
+	}
+	mountEntry := &MountEntry{
+		UUID:     meUUID,
+		Accessor: "awsaccessor",
+		Config: MountConfig{
+			MaxInFlightRequests: 1,
+			MaxQueuedRequests:   1,
+		},
+	}
+	if err := r.Mount(n, "prod/aws/", mountEntry, view); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	raw, _ := r.root.Get("prod/aws/")
+	limiter := &raw.(*routeEntry).limiter
+	queued := func() int {
+		limiter.l.Lock()
+		defer limiter.l.Unlock()
+		return len(limiter.waiters)
+	}
+
+	route := func(ctx context.Context, op logical.Operation) error {
+		_, err := r.Route(ctx, &logical.Request{
+			Operation: op,
+			Path:      "prod/aws/foo",
+		})
+		return err
+	}
+
+	errCh := make(chan error, 2)
+	go func() { errCh <- route(context.Background(), logical.ReadOperation) }()
+	<-started
+	go func() { errCh <- route(context.Background(), logical.ReadOperation) }()
+	for queued() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The mount handles one request and queues another, so the next one is
+	// shed
+	if err := route(context.Background(), logical.ReadOperation); err != logical.ErrMountOverloaded {
+		t.Fatalf("expected request to be shed, got: %v", err)
+	}
+
+	// Rollbacks are not limited
+	if err := route(context.Background(), logical.RollbackOperation); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The queued request is handled once the first one is done
+	unblock <- struct{}{}
+	<-started
+	unblock <- struct{}{}
+	for i := 0; i < 2; i++ {
+		if err := <-errCh; err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// A queued request gives up when its context is done
+	go func() { errCh <- route(context.Background(), logical.ReadOperation) }()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := route(ctx, logical.ReadOperation); err != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline to be exceeded, got: %v", err)
+	}
+	if queued() != 0 {
+		t.Fatal("expected the request to leave the queue")
+	}
+	unblock <- struct{}{}
+	if err := <-errCh; err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lifting the limit lets the requests through
+	mountEntry.Config.MaxInFlightRequests = 0
+	close(unblock)
+	for i := 0; i < 3; i++ {
+		if err := route(context.Background(), logical.ReadOperation); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if limiter.inFlight != 0 {
+		t.Fatalf("expected no request in flight, got %d", limiter.inFlight)
+	}
+}
+
 func TestPathsToRadix(t *testing.T) {
 	// Provide real paths
 	paths := []string{
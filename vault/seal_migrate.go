@@ -0,0 +1,408 @@
+package vault
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/shamir"
+)
+
+// SealMigrationResult describes a completed seal migration
+type SealMigrationResult struct {
+	// OldType and NewType are the barrier types of the seals
+	OldType string
+	NewType string
+
+	// The shares the operators hold once migrated. They are the shares they
+	// provided, which changed meaning: when migrating to an auto seal the
+	// unseal keys become the recovery keys, and when migrating to the Shamir
+	// seal the recovery keys become the unseal keys.
+	SecretShares    int
+	SecretThreshold int
+	RecoveryKeys    bool
+}
+
+// isAutoSeal returns whether the seal stores the master key and uses
+// recovery keys, the way the seals unsealing Vault by themselves do
+func isAutoSeal(seal Seal) bool {
+	return seal.StoredKeysSupported() && seal.RecoveryKeySupported()
+}
+
+// MigrateSeal moves the protection of the master key from the seal of the
+// core to newSeal. The shares are the unseal keys of a Shamir seal or the
+// recovery keys of an auto seal, up to the threshold.
+//
+// The old seal decrypts the stored keys, and they are checked to open the
+// barrier. The writes of the new seal are then staged in memory and read back
+// through the new seal before any of them reaches the storage. The core must
+// be sealed, and no other node may be running against the storage.
+func (c *Core) MigrateSeal(ctx context.Context, newSeal Seal, shares [][]byte) (*SealMigrationResult, error) {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	if !c.Sealed() {
+		return nil, errors.New("vault must be sealed to migrate its seal")
+	}
+	init, err := c.Initialized(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !init {
+		return nil, ErrNotInit
+	}
+
+	oldSeal := c.seal
+	oldAuto, newAuto := isAutoSeal(oldSeal), isAutoSeal(newSeal)
+	switch {
+	case !oldAuto && !newAuto:
+		return nil, errors.New("both seals are Shamir seals, there is nothing to migrate")
+	case newSeal.StoredKeysSupported() != newSeal.RecoveryKeySupported():
+		return nil, fmt.Errorf("seals of type %q cannot be migrated to", newSeal.BarrierType())
+	}
+
+	barrierConfig, err := oldSeal.BarrierConfig(ctx)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read the seal configuration: {{err}}", err)
+	}
+
+	// Recover the master key with the old seal, and the recovery key that
+	// authorizes the migration of an auto seal
+	var masterKey, recoveryKey []byte
+	var recoveryConfig *SealConfig
+	if oldAuto {
+		recoveryConfig, err = oldSeal.RecoveryConfig(ctx)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to read the recovery configuration: {{err}}", err)
+		}
+		recoveryKey, err = combineSealShares(recoveryConfig, shares)
+		if err != nil {
+			return nil, err
+		}
+		defer memzero(recoveryKey)
+		if err := oldSeal.VerifyRecoveryKey(ctx, recoveryKey); err != nil {
+			return nil, errwrap.Wrapf("failed to verify the recovery key: {{err}}", err)
+		}
+		storedKeys, err := oldSeal.GetStoredKeys(ctx)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to decrypt the stored keys with the old seal: {{err}}", err)
+		}
+		masterKey, err = combineSealShares(barrierConfig, storedKeys)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to combine the stored keys: {{err}}", err)
+		}
+	} else {
+		masterKey, err = combineSealShares(barrierConfig, shares)
+		if err != nil {
+			return nil, err
+		}
+		recoveryKey = masterKey
+	}
+	defer memzero(masterKey)
+
+	if err := c.barrier.Unseal(ctx, masterKey); err != nil {
+		return nil, errwrap.Wrapf("the keys do not open the barrier: {{err}}", err)
+	}
+	defer func() {
+		if err := c.barrier.Seal(); err != nil {
+			c.logger.Error("failed to seal barrier", "error", err)
+		}
+	}()
+
+	// Write the state of the new seal to the staged storage
+	staged := newStagedBackend(c.physical)
+	newSeal.SetCore(&Core{physical: staged, logger: c.logger})
+	if err := newSeal.Init(ctx); err != nil {
+		return nil, errwrap.Wrapf("error initializing the new seal: {{err}}", err)
+	}
+
+	result := &SealMigrationResult{
+		OldType: oldSeal.BarrierType(),
+		NewType: newSeal.BarrierType(),
+	}
+
+	var newBarrierConfig, newRecoveryConfig *SealConfig
+	var newStoredKeys [][]byte
+	switch {
+	case newAuto && oldAuto:
+		newBarrierConfig = barrierConfig.Clone()
+		newRecoveryConfig = recoveryConfig.Clone()
+		newStoredKeys, err = oldSeal.GetStoredKeys(ctx)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to decrypt the stored keys with the old seal: {{err}}", err)
+		}
+
+	case newAuto:
+		// The unseal keys become the recovery keys
+		newBarrierConfig = &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		}
+		newRecoveryConfig = &SealConfig{
+			SecretShares:    barrierConfig.SecretShares,
+			SecretThreshold: barrierConfig.SecretThreshold,
+		}
+		newStoredKeys = [][]byte{masterKey}
+
+	default:
+		// The recovery keys become the unseal keys, the barrier is rekeyed
+		// with the recovery key on commit
+		min, max := c.barrier.KeyLength()
+		if len(recoveryKey) < min || len(recoveryKey) > max {
+			return nil, fmt.Errorf("the recovery key of %d bytes cannot be used as a master key", len(recoveryKey))
+		}
+		newBarrierConfig = &SealConfig{
+			SecretShares:    recoveryConfig.SecretShares,
+			SecretThreshold: recoveryConfig.SecretThreshold,
+		}
+	}
+
+	if err := newSeal.SetBarrierConfig(ctx, newBarrierConfig); err != nil {
+		return nil, err
+	}
+	if newAuto {
+		if err := newSeal.SetStoredKeys(ctx, newStoredKeys); err != nil {
+			return nil, errwrap.Wrapf("failed to encrypt the stored keys with the new seal: {{err}}", err)
+		}
+		if err := newSeal.SetRecoveryConfig(ctx, newRecoveryConfig); err != nil {
+			return nil, err
+		}
+		if err := newSeal.SetRecoveryKey(ctx, recoveryKey); err != nil {
+			return nil, errwrap.Wrapf("failed to encrypt the recovery key with the new seal: {{err}}", err)
+		}
+	}
+
+	// Verify the staged state through the new seal, bypassing its caches
+	newSeal.SetBarrierConfig(ctx, nil)
+	stagedConfig, err := newSeal.BarrierConfig(ctx)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to verify the seal configuration: {{err}}", err)
+	}
+	if stagedConfig == nil || stagedConfig.SecretThreshold != newBarrierConfig.SecretThreshold {
+		return nil, errors.New("failed to verify the seal configuration: it was not read back")
+	}
+	if newAuto {
+		storedKeys, err := newSeal.GetStoredKeys(ctx)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to verify the stored keys: {{err}}", err)
+		}
+		stagedMasterKey, err := combineSealShares(stagedConfig, storedKeys)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to verify the stored keys: {{err}}", err)
+		}
+		match := subtle.ConstantTimeCompare(stagedMasterKey, masterKey) == 1
+		memzero(stagedMasterKey)
+		if !match {
+			return nil, errors.New("failed to verify the stored keys: they do not match the master key")
+		}
+
+		newSeal.SetRecoveryConfig(ctx, nil)
+		if _, err := newSeal.RecoveryConfig(ctx); err != nil {
+			return nil, errwrap.Wrapf("failed to verify the recovery configuration: {{err}}", err)
+		}
+		if err := newSeal.VerifyRecoveryKey(ctx, recoveryKey); err != nil {
+			return nil, errwrap.Wrapf("failed to verify the recovery key: {{err}}", err)
+		}
+	}
+
+	// Commit
+	if !newAuto {
+		if err := c.barrier.Rekey(ctx, recoveryKey); err != nil {
+			return nil, errwrap.Wrapf("failed to rekey the barrier with the recovery key: {{err}}", err)
+		}
+		if err := c.barrier.VerifyMaster(recoveryKey); err != nil {
+			return nil, errwrap.Wrapf("failed to verify the rekeyed barrier: {{err}}", err)
+		}
+	}
+	if err := staged.commit(ctx, barrierSealConfigPath); err != nil {
+		// The old seal still holds the old master key
+		if !newAuto {
+			if rekeyErr := c.barrier.Rekey(ctx, masterKey); rekeyErr != nil {
+				err = multierror.Append(err, errwrap.Wrapf("failed to rekey the barrier back with the master key: {{err}}", rekeyErr))
+			}
+		}
+		return nil, errwrap.Wrapf("failed to write the state of the new seal: {{err}}", err)
+	}
+	if oldAuto && !newAuto {
+		for _, path := range []string{storedBarrierKeysPath, recoveryKeyPath, recoverySealConfigPlaintextPath} {
+			if err := c.physical.Delete(ctx, path); err != nil {
+				c.logger.Warn("failed to delete the state of the old seal", "path", path, "error", err)
+			}
+		}
+	}
+
+	c.seal = newSeal
+	newSeal.SetCore(c)
+	newSeal.SetBarrierConfig(ctx, nil)
+	if newAuto {
+		newSeal.SetRecoveryConfig(ctx, nil)
+	}
+
+	result.SecretShares = barrierConfig.SecretShares
+	result.SecretThreshold = barrierConfig.SecretThreshold
+	if oldAuto {
+		result.SecretShares = recoveryConfig.SecretShares
+		result.SecretThreshold = recoveryConfig.SecretThreshold
+	}
+	result.RecoveryKeys = newAuto
+
+	c.logger.Info("migrated the seal", "old_seal_type", result.OldType, "new_seal_type", result.NewType)
+	return result, nil
+}
+
+// combineSealShares combines the shares up to the threshold of the
+// configuration
+func combineSealShares(config *SealConfig, shares [][]byte) ([]byte, error) {
+	if config == nil {
+		return nil, errors.New("the seal configuration is missing")
+	}
+	if len(shares) < config.SecretThreshold {
+		return nil, fmt.Errorf("%d of the %d key shares required were provided", len(shares), config.SecretThreshold)
+	}
+	if config.SecretThreshold == 1 {
+		key := make([]byte, len(shares[0]))
+		copy(key, shares[0])
+		return key, nil
+	}
+
+	key, err := shamir.Combine(shares)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to combine the key shares: {{err}}", err)
+	}
+	return key, nil
+}
+
+// stagedBackend keeps the writes to a backend in memory until they are
+// committed, reading them back over the entries of the backend
+type stagedBackend struct {
+	physical.Backend
+
+	l       sync.RWMutex
+	entries map[string]*physical.Entry
+	deleted map[string]bool
+}
+
+func newStagedBackend(b physical.Backend) *stagedBackend {
+	return &stagedBackend{
+		Backend: b,
+		entries: make(map[string]*physical.Entry),
+		deleted: make(map[string]bool),
+	}
+}
+
+func (s *stagedBackend) Put(ctx context.Context, entry *physical.Entry) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	value := make([]byte, len(entry.Value))
+	copy(value, entry.Value)
+	s.entries[entry.Key] = &physical.Entry{
+		Key:      entry.Key,
+		Value:    value,
+		SealWrap: entry.SealWrap,
+	}
+	delete(s.deleted, entry.Key)
+	return nil
+}
+
+func (s *stagedBackend) Get(ctx context.Context, key string) (*physical.Entry, error) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if s.deleted[key] {
+		return nil, nil
+	}
+	if entry, ok := s.entries[key]; ok {
+		value := make([]byte, len(entry.Value))
+		copy(value, entry.Value)
+		return &physical.Entry{
+			Key:      entry.Key,
+			Value:    value,
+			SealWrap: entry.SealWrap,
+		}, nil
+	}
+	return s.Backend.Get(ctx, key)
+}
+
+func (s *stagedBackend) Delete(ctx context.Context, key string) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	delete(s.entries, key)
+	s.deleted[key] = true
+	return nil
+}
+
+func (s *stagedBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := s.Backend.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	seen := make(map[string]bool)
+	var result []string
+	add := func(k string) {
+		if !seen[k] {
+			seen[k] = true
+			result = append(result, k)
+		}
+	}
+	for _, k := range keys {
+		if !s.deleted[prefix+k] {
+			add(k)
+		}
+	}
+	for key := range s.entries {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		k := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(k, "/"); i != -1 {
+			k = k[:i+1]
+		}
+		add(k)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// commit writes the staged entries to the backend. The entry at last is
+// written after the others, so that it can switch what they are used for.
+func (s *stagedBackend) commit(ctx context.Context, last string) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		if key != last {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if _, ok := s.entries[last]; ok {
+		keys = append(keys, last)
+	}
+
+	for key := range s.deleted {
+		if err := s.Backend.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if err := s.Backend.Put(ctx, s.entries[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
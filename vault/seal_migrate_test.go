@@ -0,0 +1,177 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/helper/pkcs11"
+	"github.com/hashicorp/vault/physical"
+)
+
+func testPKCS11SealToken(t *testing.T, token *pkcs11.InmemToken) *PKCS11Seal {
+	t.Helper()
+	hsm, err := pkcs11.New(&pkcs11.Config{
+		KeyLabel:    "vault",
+		Mechanism:   pkcs11.MechanismAESGCM,
+		GenerateKey: true,
+	}, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPKCS11Seal(hsm, time.Minute)
+}
+
+func testSealMigrationSnapshot(t *testing.T, b physical.Backend) map[string][]byte {
+	t.Helper()
+	ctx := context.Background()
+	snapshot := make(map[string][]byte)
+	prefixes := []string{""}
+	for len(prefixes) > 0 {
+		prefix := prefixes[0]
+		prefixes = prefixes[1:]
+		keys, err := b.List(ctx, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range keys {
+			if strings.HasSuffix(key, "/") {
+				prefixes = append(prefixes, prefix+key)
+				continue
+			}
+			entry, err := b.Get(ctx, prefix+key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			snapshot[prefix+key] = entry.Value
+		}
+	}
+	return snapshot
+}
+
+func testSealMigrationUnchanged(t *testing.T, b physical.Backend, snapshot map[string][]byte) {
+	t.Helper()
+	current := testSealMigrationSnapshot(t, b)
+	if len(current) != len(snapshot) {
+		t.Fatalf("expected %d entries in the storage, got %d", len(snapshot), len(current))
+	}
+	for key, value := range snapshot {
+		if !bytes.Equal(current[key], value) {
+			t.Fatalf("entry %q changed", key)
+		}
+	}
+}
+
+func TestCore_MigrateSeal(t *testing.T) {
+	ctx := context.Background()
+
+	// Shamir to pkcs11
+	core, keys, root := TestCoreUnsealed(t)
+	if _, err := core.MigrateSeal(ctx, testPKCS11SealToken(t, pkcs11.NewInmemToken()), keys); err == nil {
+		t.Fatal("expected an error migrating an unsealed core")
+	}
+	if err := core.Seal(root); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := testSealMigrationSnapshot(t, core.physical)
+	badKeys := [][]byte{keys[0], keys[1], keys[0]}
+	for _, shares := range [][][]byte{keys[:2], badKeys} {
+		if _, err := core.MigrateSeal(ctx, testPKCS11SealToken(t, pkcs11.NewInmemToken()), shares); err == nil {
+			t.Fatal("expected an error migrating with bad keys")
+		}
+		testSealMigrationUnchanged(t, core.physical, snapshot)
+	}
+
+	token := pkcs11.NewInmemToken()
+	seal := testPKCS11SealToken(t, token)
+	result, err := core.MigrateSeal(ctx, seal, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OldType != SealTypeShamir || result.NewType != SealTypePKCS11 || !result.RecoveryKeys || result.SecretShares != 3 || result.SecretThreshold != 3 {
+		t.Fatalf("bad: %#v", result)
+	}
+	if !core.Sealed() {
+		t.Fatal("expected the core to stay sealed")
+	}
+
+	// A core restarted with the new seal unseals itself, and the unseal keys
+	// are its recovery keys
+	core = testSealMigrationRestart(t, core.physical, testPKCS11SealToken(t, token))
+	if err := core.UnsealWithStoredKeys(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if core.Sealed() {
+		t.Fatal("should be unsealed with the stored keys")
+	}
+	if err := core.Seal(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// pkcs11 to pkcs11 with another token
+	newToken := pkcs11.NewInmemToken()
+	if _, err := core.MigrateSeal(ctx, testPKCS11SealToken(t, newToken), keys[:1]); err == nil {
+		t.Fatal("expected an error migrating with too few recovery keys")
+	}
+	if _, err := core.MigrateSeal(ctx, testPKCS11SealToken(t, newToken), keys); err != nil {
+		t.Fatal(err)
+	}
+	core = testSealMigrationRestart(t, core.physical, testPKCS11SealToken(t, newToken))
+	if err := core.UnsealWithStoredKeys(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if core.Sealed() {
+		t.Fatal("should be unsealed with the stored keys")
+	}
+	if err := core.Seal(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// pkcs11 to Shamir, the recovery keys become the unseal keys
+	core = testSealMigrationRestart(t, core.physical, testPKCS11SealToken(t, newToken))
+	result, err = core.MigrateSeal(ctx, NewDefaultSeal(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OldType != SealTypePKCS11 || result.NewType != SealTypeShamir || result.RecoveryKeys {
+		t.Fatalf("bad: %#v", result)
+	}
+	for _, path := range []string{storedBarrierKeysPath, recoveryKeyPath, recoverySealConfigPlaintextPath} {
+		if entry, err := core.physical.Get(ctx, path); err != nil || entry != nil {
+			t.Fatalf("expected %q to be deleted: %v", path, err)
+		}
+	}
+
+	core = testSealMigrationRestart(t, core.physical, NewDefaultSeal())
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(core, TestKeyCopy(key)); err != nil {
+			t.Fatalf("unseal err: %s", err)
+		}
+	}
+	if core.Sealed() {
+		t.Fatal("should be unsealed with the former recovery keys")
+	}
+	if err := core.Seal(root); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := core.MigrateSeal(ctx, NewDefaultSeal(), keys); err == nil {
+		t.Fatal("expected an error migrating between Shamir seals")
+	}
+}
+
+func testSealMigrationRestart(t *testing.T, b physical.Backend, seal Seal) *Core {
+	t.Helper()
+	conf := testCoreConfig(t, b, logging.NewVaultLogger(log.Trace))
+	conf.Seal = seal
+	core, err := NewCore(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return core
+}
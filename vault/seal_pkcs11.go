@@ -0,0 +1,460 @@
+package vault
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/pkcs11"
+	"github.com/hashicorp/vault/physical"
+)
+
+// PKCS11Seal is a seal that protects the master key with a key of an HSM,
+// accessed through PKCS#11, so that Vault unseals itself. Recovery keys
+// replace the unseal keys for the operations that require a quorum of
+// operators.
+type PKCS11Seal struct {
+	hsm                 *pkcs11.HSM
+	healthCheckInterval time.Duration
+
+	core           *Core
+	config         atomic.Value
+	recoveryConfig atomic.Value
+
+	// l serializes the writes of the keys encrypted by the HSM
+	l sync.Mutex
+
+	healthy    *uint32
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+	healthOnce sync.Once
+}
+
+// NewPKCS11Seal returns a seal using the HSM, which is checked every
+// healthCheckInterval
+func NewPKCS11Seal(hsm *pkcs11.HSM, healthCheckInterval time.Duration) *PKCS11Seal {
+	if healthCheckInterval <= 0 {
+		healthCheckInterval = pkcs11.DefaultHealthCheckInterval
+	}
+
+	s := &PKCS11Seal{
+		hsm:                 hsm,
+		healthCheckInterval: healthCheckInterval,
+		healthy:             new(uint32),
+		stopCh:              make(chan struct{}),
+	}
+	atomic.StoreUint32(s.healthy, 1)
+	s.config.Store((*SealConfig)(nil))
+	s.recoveryConfig.Store((*SealConfig)(nil))
+	return s
+}
+
+func (s *PKCS11Seal) checkCore() error {
+	if s.core == nil {
+		return fmt.Errorf("seal does not have a core set")
+	}
+	return nil
+}
+
+// SetCore sets the core of the seal and starts checking the HSM
+func (s *PKCS11Seal) SetCore(core *Core) {
+	s.core = core
+	s.healthOnce.Do(func() {
+		go s.runHealthChecks()
+	})
+}
+
+func (s *PKCS11Seal) Init(ctx context.Context) error {
+	if err := s.hsm.Check(); err != nil {
+		return errwrap.Wrapf("the HSM failed its health check: {{err}}", err)
+	}
+	return nil
+}
+
+// Finalize stops checking the HSM and releases it
+func (s *PKCS11Seal) Finalize(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	return s.hsm.Close()
+}
+
+func (s *PKCS11Seal) BarrierType() string {
+	return SealTypePKCS11
+}
+
+func (s *PKCS11Seal) StoredKeysSupported() bool {
+	return true
+}
+
+func (s *PKCS11Seal) RecoveryKeySupported() bool {
+	return true
+}
+
+func (s *PKCS11Seal) RecoveryType() string {
+	return RecoveryTypeShamir
+}
+
+// Healthy returns whether the last health check of the HSM succeeded
+func (s *PKCS11Seal) Healthy() bool {
+	return atomic.LoadUint32(s.healthy) == 1
+}
+
+// SetStoredKeys encrypts the keys with the HSM and stores them
+func (s *PKCS11Seal) SetStoredKeys(ctx context.Context, keys [][]byte) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(keys)
+	if err != nil {
+		return errwrap.Wrapf("failed to encode keys for storage: {{err}}", err)
+	}
+	defer memzero(buf)
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.writeEncrypted(ctx, storedBarrierKeysPath, buf)
+}
+
+// GetStoredKeys returns the stored keys, decrypted by the HSM. Keys that are
+// not encrypted with the latest version of the key of the HSM are encrypted
+// again with it.
+func (s *PKCS11Seal) GetStoredKeys(ctx context.Context) ([][]byte, error) {
+	if err := s.checkCore(); err != nil {
+		return nil, err
+	}
+
+	buf, current, err := s.readEncrypted(ctx, storedBarrierKeysPath)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read stored keys: {{err}}", err)
+	}
+	if buf == nil {
+		return nil, nil
+	}
+	defer memzero(buf)
+
+	var keys [][]byte
+	if err := json.Unmarshal(buf, &keys); err != nil {
+		return nil, errwrap.Wrapf("failed to decode stored keys: {{err}}", err)
+	}
+
+	if !current {
+		s.l.Lock()
+		err := s.writeEncrypted(ctx, storedBarrierKeysPath, buf)
+		s.l.Unlock()
+		if err != nil {
+			s.core.logger.Warn("failed to encrypt the stored keys with the current HSM key", "error", err)
+		} else {
+			s.core.logger.Info("encrypted the stored keys with the current HSM key", "key_label", s.hsm.KeyLabel(), "key_version", s.hsm.KeyVersion())
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *PKCS11Seal) BarrierConfig(ctx context.Context) (*SealConfig, error) {
+	if conf := s.config.Load().(*SealConfig); conf != nil {
+		return conf.Clone(), nil
+	}
+
+	conf, err := s.readConfig(ctx, barrierSealConfigPath, "seal")
+	if err != nil || conf == nil {
+		return nil, err
+	}
+
+	switch conf.Type {
+	case s.BarrierType():
+	default:
+		s.core.logger.Error("barrier seal type does not match loaded type", "barrier_seal_type", conf.Type, "loaded_seal_type", s.BarrierType())
+		return nil, fmt.Errorf("barrier seal type of %q does not match loaded type of %q", conf.Type, s.BarrierType())
+	}
+
+	s.config.Store(conf)
+	return conf.Clone(), nil
+}
+
+func (s *PKCS11Seal) SetBarrierConfig(ctx context.Context, config *SealConfig) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+
+	// Provide a way to wipe out the cached value (also prevents actually
+	// saving a nil config)
+	if config == nil {
+		s.config.Store((*SealConfig)(nil))
+		return nil
+	}
+
+	config.Type = s.BarrierType()
+	if err := s.writeConfig(ctx, barrierSealConfigPath, "seal", config); err != nil {
+		return err
+	}
+
+	s.config.Store(config.Clone())
+	return nil
+}
+
+func (s *PKCS11Seal) RecoveryConfig(ctx context.Context) (*SealConfig, error) {
+	if conf := s.recoveryConfig.Load().(*SealConfig); conf != nil {
+		return conf.Clone(), nil
+	}
+
+	conf, err := s.readConfig(ctx, recoverySealConfigPlaintextPath, "recovery")
+	if err != nil || conf == nil {
+		return nil, err
+	}
+
+	if conf.Type != s.RecoveryType() {
+		s.core.logger.Error("recovery seal type does not match loaded type", "recovery_seal_type", conf.Type, "loaded_seal_type", s.RecoveryType())
+		return nil, fmt.Errorf("recovery seal type of %q does not match loaded type of %q", conf.Type, s.RecoveryType())
+	}
+
+	s.recoveryConfig.Store(conf)
+	return conf.Clone(), nil
+}
+
+func (s *PKCS11Seal) SetRecoveryConfig(ctx context.Context, config *SealConfig) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+
+	// Provide a way to wipe out the cached value (also prevents actually
+	// saving a nil config)
+	if config == nil {
+		s.recoveryConfig.Store((*SealConfig)(nil))
+		return nil
+	}
+
+	config.Type = s.RecoveryType()
+	if err := s.writeConfig(ctx, recoverySealConfigPlaintextPath, "recovery", config); err != nil {
+		return err
+	}
+
+	s.recoveryConfig.Store(config.Clone())
+	return nil
+}
+
+// SetRecoveryKey encrypts the recovery key with the HSM and stores it
+func (s *PKCS11Seal) SetRecoveryKey(ctx context.Context, key []byte) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("recovery key to store is empty")
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.writeEncrypted(ctx, recoveryKeyPath, key)
+}
+
+func (s *PKCS11Seal) VerifyRecoveryKey(ctx context.Context, key []byte) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("recovery key to verify is empty")
+	}
+
+	stored, _, err := s.readEncrypted(ctx, recoveryKeyPath)
+	if err != nil {
+		return errwrap.Wrapf("failed to read the recovery key: {{err}}", err)
+	}
+	if stored == nil {
+		return fmt.Errorf("no recovery key found")
+	}
+	defer memzero(stored)
+
+	if subtle.ConstantTimeCompare(key, stored) != 1 {
+		return fmt.Errorf("recovery key does not match submitted values")
+	}
+	return nil
+}
+
+// RotateKey generates a new version of the key of the HSM and encrypts the
+// stored keys and the recovery key with it. The previous versions are kept
+// on the HSM, so that other nodes and backups can still be unsealed.
+func (s *PKCS11Seal) RotateKey(ctx context.Context) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	keys, _, err := s.readEncrypted(ctx, storedBarrierKeysPath)
+	if err != nil {
+		return errwrap.Wrapf("failed to read stored keys: {{err}}", err)
+	}
+	defer memzero(keys)
+	recoveryKey, _, err := s.readEncrypted(ctx, recoveryKeyPath)
+	if err != nil {
+		return errwrap.Wrapf("failed to read the recovery key: {{err}}", err)
+	}
+	defer memzero(recoveryKey)
+
+	version, err := s.hsm.RotateKey()
+	if err != nil {
+		return errwrap.Wrapf("failed to rotate the HSM key: {{err}}", err)
+	}
+
+	if keys != nil {
+		if err := s.writeEncrypted(ctx, storedBarrierKeysPath, keys); err != nil {
+			return err
+		}
+	}
+	if recoveryKey != nil {
+		if err := s.writeEncrypted(ctx, recoveryKeyPath, recoveryKey); err != nil {
+			return err
+		}
+	}
+
+	s.core.logger.Info("rotated the HSM key", "key_label", s.hsm.KeyLabel(), "key_version", version)
+	return nil
+}
+
+// runHealthChecks checks the HSM until the seal is finalized, logging when
+// its health changes
+func (s *PKCS11Seal) runHealthChecks() {
+	ticker := time.NewTicker(s.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		err := s.hsm.Check()
+		switch {
+		case err != nil:
+			metrics.SetGauge([]string{"seal", "pkcs11", "healthy"}, 0)
+			if atomic.SwapUint32(s.healthy, 0) == 1 {
+				s.core.logger.Error("the HSM failed its health check", "error", err)
+			}
+		default:
+			metrics.SetGauge([]string{"seal", "pkcs11", "healthy"}, 1)
+			if atomic.SwapUint32(s.healthy, 1) == 0 {
+				s.core.logger.Info("the HSM passed its health check again")
+			}
+		}
+	}
+}
+
+// readEncrypted returns the value at the path, decrypted by the HSM, or nil if
+// there is none, and whether it is encrypted with the latest version of the
+// key
+func (s *PKCS11Seal) readEncrypted(ctx context.Context, path string) ([]byte, bool, error) {
+	pe, err := s.core.physical.Get(ctx, path)
+	if err != nil {
+		return nil, false, err
+	}
+	if pe == nil {
+		return nil, true, nil
+	}
+
+	var ct pkcs11.Ciphertext
+	if err := jsonutil.DecodeJSON(pe.Value, &ct); err != nil {
+		return nil, false, errwrap.Wrapf("failed to decode the encrypted value: {{err}}", err)
+	}
+
+	plaintext, err := s.hsm.Decrypt(&ct)
+	if err != nil {
+		return nil, false, err
+	}
+	return plaintext, s.hsm.Current(&ct), nil
+}
+
+// writeEncrypted stores the value at the path, encrypted by the HSM. It must
+// be called with the lock held.
+func (s *PKCS11Seal) writeEncrypted(ctx context.Context, path string, value []byte) error {
+	ct, err := s.hsm.Encrypt(value)
+	if err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(ct)
+	if err != nil {
+		return errwrap.Wrapf("failed to encode the encrypted value: {{err}}", err)
+	}
+
+	if err := s.core.physical.Put(ctx, &physical.Entry{
+		Key:   path,
+		Value: buf,
+	}); err != nil {
+		s.core.logger.Error("failed to write an encrypted value", "path", path, "error", err)
+		return errwrap.Wrapf("failed to write the encrypted value: {{err}}", err)
+	}
+	return nil
+}
+
+func (s *PKCS11Seal) readConfig(ctx context.Context, path, name string) (*SealConfig, error) {
+	if err := s.checkCore(); err != nil {
+		return nil, err
+	}
+
+	pe, err := s.core.physical.Get(ctx, path)
+	if err != nil {
+		s.core.logger.Error(fmt.Sprintf("failed to read %s configuration", name), "error", err)
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to check %s configuration: {{err}}", name), err)
+	}
+
+	// If the configuration is missing, we are not initialized
+	if pe == nil {
+		s.core.logger.Info(fmt.Sprintf("%s configuration missing, not initialized", name))
+		return nil, nil
+	}
+
+	var conf SealConfig
+	if err := jsonutil.DecodeJSON(pe.Value, &conf); err != nil {
+		s.core.logger.Error(fmt.Sprintf("failed to decode %s configuration", name), "error", err)
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to decode %s configuration: {{err}}", name), err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		s.core.logger.Error(fmt.Sprintf("invalid %s configuration", name), "error", err)
+		return nil, errwrap.Wrapf(fmt.Sprintf("%s validation failed: {{err}}", name), err)
+	}
+
+	return &conf, nil
+}
+
+func (s *PKCS11Seal) writeConfig(ctx context.Context, path, name string, config *SealConfig) error {
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("failed to encode %s configuration: {{err}}", name), err)
+	}
+
+	if err := s.core.physical.Put(ctx, &physical.Entry{
+		Key:   path,
+		Value: buf,
+	}); err != nil {
+		s.core.logger.Error(fmt.Sprintf("failed to write %s configuration", name), "error", err)
+		return errwrap.Wrapf(fmt.Sprintf("failed to write %s configuration: {{err}}", name), err)
+	}
+	return nil
+}
+
+// sealKeyRotator is implemented by seals whose key can be rotated
+type sealKeyRotator interface {
+	RotateKey(context.Context) error
+}
+
+// RotateSealKey rotates the key the seal protects the master key with
+func (c *Core) RotateSealKey(ctx context.Context) error {
+	rotator, ok := c.seal.(sealKeyRotator)
+	if !ok {
+		return errors.New("the seal does not support key rotation")
+	}
+	return rotator.RotateKey(ctx)
+}
@@ -0,0 +1,108 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/pkcs11"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestPKCS11Seal(t *testing.T) {
+	token := pkcs11.NewInmemToken()
+	hsm, err := pkcs11.New(&pkcs11.Config{
+		KeyLabel:    "vault",
+		Mechanism:   pkcs11.MechanismAESGCM,
+		GenerateKey: true,
+	}, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := NewPKCS11Seal(hsm, 10*time.Millisecond)
+	core := TestCoreWithSeal(t, seal, false)
+	defer seal.Finalize(context.Background())
+
+	ctx := context.Background()
+	result, err := core.Initialize(ctx, &InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		},
+		RecoveryConfig: &SealConfig{
+			SecretShares:    5,
+			SecretThreshold: 3,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.SecretShares) != 0 || len(result.RecoveryShares) != 5 {
+		t.Fatalf("bad: %d unseal keys, %d recovery keys", len(result.SecretShares), len(result.RecoveryShares))
+	}
+
+	if err := core.UnsealWithStoredKeys(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if core.Sealed() {
+		t.Fatal("should be unsealed with the stored keys")
+	}
+
+	recoveryConfig, err := seal.RecoveryConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recoveryConfig.Type != RecoveryTypeShamir || recoveryConfig.SecretThreshold != 3 {
+		t.Fatalf("bad: %#v", recoveryConfig)
+	}
+
+	// Rotate the key through the API; the master key is then encrypted with
+	// the new version
+	resp, err := core.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/rotate/seal",
+		ClientToken: result.RootToken,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+	if hsm.KeyVersion() != 2 {
+		t.Fatalf("bad: %d", hsm.KeyVersion())
+	}
+	if _, current, err := seal.readEncrypted(ctx, storedBarrierKeysPath); err != nil || !current {
+		t.Fatalf("expected the stored keys to be encrypted with the new key version: %v", err)
+	}
+	if _, current, err := seal.readEncrypted(ctx, recoveryKeyPath); err != nil || !current {
+		t.Fatalf("expected the recovery key to be encrypted with the new key version: %v", err)
+	}
+
+	// The core unseals itself again after a seal
+	if err := core.Seal(result.RootToken); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.UnsealWithStoredKeys(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if core.Sealed() {
+		t.Fatal("should be unsealed with the stored keys")
+	}
+
+	// Health checks follow the HSM
+	token.SetErr(errors.New("device removed"))
+	waitForHealth(t, seal, false)
+	token.SetErr(nil)
+	waitForHealth(t, seal, true)
+}
+
+func waitForHealth(t *testing.T, seal *PKCS11Seal, healthy bool) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if seal.Healthy() == healthy {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected the seal health to be %t", healthy)
+}
@@ -0,0 +1,500 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/secretsync"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// secretsSyncSubPath is the sub-path of the system view the sync
+	// destinations are stored under, keyed by type and name
+	secretsSyncSubPath = "sync/destinations/"
+
+	// secretsSyncTimeout bounds the synchronization of a secret with a
+	// destination
+	secretsSyncTimeout = 30 * time.Second
+
+	// The statuses of the synchronization of the associations
+	syncStatusPending = "pending"
+	syncStatusSynced  = "synced"
+	syncStatusFailed  = "failed"
+)
+
+var (
+	// secretsSyncInterval is how often the active node checks the secrets
+	// in the destinations for drift
+	secretsSyncInterval = 10 * time.Minute
+
+	// secretsSyncTypes are the types of destinations
+	secretsSyncTypes = []string{"aws-sm", "gcp-sm", "kubernetes"}
+)
+
+// syncDestination is an external secret store selected KV secrets are pushed
+// to, for workloads which cannot read them from Vault
+type syncDestination struct {
+	Type             string `json:"type"`
+	Name             string `json:"name"`
+	SecretNamePrefix string `json:"secret_name_prefix"`
+
+	KubernetesHost      string `json:"kubernetes_host,omitempty"`
+	KubernetesCACert    string `json:"kubernetes_ca_cert,omitempty"`
+	KubernetesToken     string `json:"kubernetes_token,omitempty"`
+	KubernetesNamespace string `json:"kubernetes_namespace,omitempty"`
+
+	AWSRegion          string `json:"aws_region,omitempty"`
+	AWSAccessKeyID     string `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey string `json:"aws_secret_access_key,omitempty"`
+	AWSEndpoint        string `json:"aws_endpoint,omitempty"`
+
+	GCPProjectID   string `json:"gcp_project_id,omitempty"`
+	GCPCredentials string `json:"gcp_credentials,omitempty"`
+	GCPEndpoint    string `json:"gcp_endpoint,omitempty"`
+
+	// Associations are the secrets pushed to the destination
+	Associations []*syncAssociation `json:"associations,omitempty"`
+}
+
+// key returns the key of the destination, its type and name
+func (d *syncDestination) key() string {
+	return d.Type + "/" + d.Name
+}
+
+// association returns the association of the secret, or nil if it is not
+// pushed to the destination
+func (d *syncDestination) association(mount, secretName string) *syncAssociation {
+	for _, assoc := range d.Associations {
+		if assoc.Mount == mount && assoc.SecretName == secretName {
+			return assoc
+		}
+	}
+	return nil
+}
+
+// syncDestinationClient returns a new client of the destination, reaching it
+// through the egress proxy of the server
+func (c *Core) syncDestinationClient(d *syncDestination) (secretsync.Destination, error) {
+	caCert := ""
+	if d.Type == "kubernetes" {
+		caCert = d.KubernetesCACert
+	}
+	transport, err := c.outbound.transport("", caCert)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport}
+
+	switch d.Type {
+	case "kubernetes":
+		return secretsync.NewKubernetesDestination(&secretsync.KubernetesConfig{
+			Host:       d.KubernetesHost,
+			Token:      d.KubernetesToken,
+			Namespace:  d.KubernetesNamespace,
+			HTTPClient: client,
+		})
+	case "aws-sm":
+		return secretsync.NewAWSSecretsManagerDestination(&secretsync.AWSSecretsManagerConfig{
+			Region:     d.AWSRegion,
+			AccessKey:  d.AWSAccessKeyID,
+			SecretKey:  d.AWSSecretAccessKey,
+			Endpoint:   d.AWSEndpoint,
+			HTTPClient: client,
+		})
+	case "gcp-sm":
+		return secretsync.NewGCPSecretManagerDestination(&secretsync.GCPSecretManagerConfig{
+			ProjectID:   d.GCPProjectID,
+			Credentials: d.GCPCredentials,
+			Endpoint:    d.GCPEndpoint,
+			HTTPClient:  client,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported destination type %q", d.Type)
+	}
+}
+
+// syncAssociation is a KV secret pushed to a destination
+type syncAssociation struct {
+	// Mount is the path of the kv mount, including its namespace
+	Mount      string `json:"mount"`
+	SecretName string `json:"secret_name"`
+}
+
+// path returns the path of the secret in Vault
+func (a *syncAssociation) path() string {
+	return a.Mount + a.SecretName
+}
+
+// remoteName returns the name of the secret in the destination
+func (a *syncAssociation) remoteName(d *syncDestination) string {
+	return secretsync.Name(d.SecretNamePrefix, a.path())
+}
+
+// matches returns whether the path, relative to the mount, is that of the
+// secret. The paths of the secrets of version 2 of the kv engine are
+// prefixed by the operation, such as "data/" or "metadata/".
+func (a *syncAssociation) matches(mount, path string) bool {
+	if mount != a.Mount {
+		return false
+	}
+	if path == a.SecretName {
+		return true
+	}
+	parts := strings.SplitN(path, "/", 2)
+	return len(parts) == 2 && parts[1] == a.SecretName
+}
+
+// syncStatus is the outcome of the synchronization of an association. The
+// statuses are kept in memory by the active node, which checks all of the
+// associations when it becomes active.
+type syncStatus struct {
+	Status      string
+	LastSynced  time.Time
+	LastChecked time.Time
+	LastDrift   time.Time
+	Error       string
+}
+
+// secretsSyncer holds the sync destinations, by key, along with their
+// clients and the statuses of their associations
+type secretsSyncer struct {
+	// writeLock serializes the updates of the destinations and of their
+	// associations, which are read, changed and stored back
+	writeLock sync.Mutex
+
+	l            sync.RWMutex
+	destinations map[string]*syncDestination
+	clients      map[string]secretsync.Destination
+	statuses     map[string]*syncStatus
+}
+
+func newSecretsSyncer() *secretsSyncer {
+	return &secretsSyncer{
+		destinations: make(map[string]*syncDestination),
+		clients:      make(map[string]secretsync.Destination),
+		statuses:     make(map[string]*syncStatus),
+	}
+}
+
+// statusKey returns the key of the status of an association
+func statusKey(d *syncDestination, a *syncAssociation) string {
+	return d.key() + "\x00" + a.path()
+}
+
+func (s *secretsSyncer) get(key string) *syncDestination {
+	s.l.RLock()
+	defer s.l.RUnlock()
+	return s.destinations[key]
+}
+
+// list returns the destinations, sorted by key
+func (s *secretsSyncer) list() []*syncDestination {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	ret := make([]*syncDestination, 0, len(s.destinations))
+	for _, d := range s.destinations {
+		ret = append(ret, d)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].key() < ret[j].key()
+	})
+	return ret
+}
+
+// set replaces a destination and its client, keeping the statuses of the
+// associations it still has
+func (s *secretsSyncer) set(d *syncDestination, client secretsync.Destination) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	key := d.key()
+	if old := s.destinations[key]; old != nil {
+		for _, assoc := range old.Associations {
+			if d.association(assoc.Mount, assoc.SecretName) == nil {
+				delete(s.statuses, statusKey(old, assoc))
+			}
+		}
+	}
+	s.destinations[key] = d
+	s.clients[key] = client
+}
+
+func (s *secretsSyncer) remove(key string) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if d := s.destinations[key]; d != nil {
+		for _, assoc := range d.Associations {
+			delete(s.statuses, statusKey(d, assoc))
+		}
+	}
+	delete(s.destinations, key)
+	delete(s.clients, key)
+}
+
+func (s *secretsSyncer) client(key string) secretsync.Destination {
+	s.l.RLock()
+	defer s.l.RUnlock()
+	return s.clients[key]
+}
+
+// status returns a copy of the status of an association
+func (s *secretsSyncer) status(d *syncDestination, a *syncAssociation) syncStatus {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if status := s.statuses[statusKey(d, a)]; status != nil {
+		return *status
+	}
+	return syncStatus{Status: syncStatusPending}
+}
+
+// setStatus sets the status of an association, unless it was removed in the
+// meantime
+func (s *secretsSyncer) setStatus(d *syncDestination, a *syncAssociation, status syncStatus) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	current := s.destinations[d.key()]
+	if current == nil || current.association(a.Mount, a.SecretName) == nil {
+		return
+	}
+	s.statuses[statusKey(d, a)] = &status
+}
+
+// loadSecretsSync loads the sync destinations and creates their clients. A
+// destination whose client cannot be created is kept; its associations fail
+// to sync until it is fixed.
+func (c *Core) loadSecretsSync(ctx context.Context) error {
+	view := c.systemBarrierView.SubView(secretsSyncSubPath)
+	syncer := newSecretsSyncer()
+
+	for _, destType := range secretsSyncTypes {
+		names, err := view.List(ctx, destType+"/")
+		if err != nil {
+			return errwrap.Wrapf("failed to list sync destinations: {{err}}", err)
+		}
+		for _, name := range names {
+			raw, err := view.Get(ctx, destType+"/"+name)
+			if err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("failed to read sync destination %q: {{err}}", destType+"/"+name), err)
+			}
+			if raw == nil {
+				continue
+			}
+
+			var d syncDestination
+			if err := raw.DecodeJSON(&d); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("failed to decode sync destination %q: {{err}}", destType+"/"+name), err)
+			}
+			client, err := c.syncDestinationClient(&d)
+			if err != nil {
+				c.logger.Error("failed to create sync destination client", "destination", d.key(), "error", err)
+			}
+			syncer.set(&d, client)
+		}
+	}
+
+	c.secretsSync = syncer
+	return nil
+}
+
+// syncedSecret returns the key/value pairs of the secret of the association,
+// or nil if it does not exist. Values which are not strings are encoded as
+// JSON.
+func (c *Core) syncedSecret(ctx context.Context, a *syncAssociation) (map[string]string, error) {
+	entry := c.router.MatchingMountEntry(a.Mount)
+	if entry == nil || entry.Path != a.Mount {
+		return nil, fmt.Errorf("no mount found at %q", a.Mount)
+	}
+	version2 := entry.Options["version"] == "2"
+
+	path := a.path()
+	if version2 {
+		path = a.Mount + "data/" + a.SecretName
+	}
+	resp, err := c.router.Route(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      path,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read secret: {{err}}", err)
+	}
+	if resp == nil {
+		return nil, nil
+	}
+	if resp.IsError() {
+		return nil, errwrap.Wrapf("failed to read secret: {{err}}", resp.Error())
+	}
+
+	raw := resp.Data
+	if version2 {
+		// Deleted and destroyed versions have no data
+		raw, _ = resp.Data["data"].(map[string]interface{})
+		if raw == nil {
+			return nil, nil
+		}
+	}
+
+	data := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			data[k] = s
+			continue
+		}
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to encode the value of %q: {{err}}", k), err)
+		}
+		data[k] = string(encoded)
+	}
+	return data, nil
+}
+
+// syncSecret pushes the secret of an association to its destination, or
+// deletes it there if it no longer exists in Vault, and records the outcome.
+// When checking for drift, the secret in the destination is read first and
+// only pushed if it differs.
+func (c *Core) syncSecret(ctx context.Context, d *syncDestination, a *syncAssociation, checkDrift bool) syncStatus {
+	ctx, cancel := context.WithTimeout(ctx, secretsSyncTimeout)
+	defer cancel()
+
+	status := c.secretsSync.status(d, a)
+	fail := func(err error) syncStatus {
+		c.logger.Error("failed to sync secret", "destination", d.key(), "path", a.path(), "error", err)
+		status.Status = syncStatusFailed
+		status.Error = err.Error()
+		c.secretsSync.setStatus(d, a, status)
+		return status
+	}
+
+	client := c.secretsSync.client(d.key())
+	if client == nil {
+		return fail(fmt.Errorf("destination %q is misconfigured", d.key()))
+	}
+	data, err := c.syncedSecret(ctx, a)
+	if err != nil {
+		return fail(err)
+	}
+	remoteName := a.remoteName(d)
+
+	if checkDrift {
+		remote, err := client.Get(ctx, remoteName)
+		if err != nil {
+			return fail(errwrap.Wrapf("failed to read secret from destination: {{err}}", err))
+		}
+		status.LastChecked = time.Now().UTC()
+		if (data == nil) == (remote == nil) && secretsync.Equal(data, remote) {
+			if status.Status != syncStatusSynced {
+				status.LastSynced = status.LastChecked
+			}
+			status.Status = syncStatusSynced
+			status.Error = ""
+			c.secretsSync.setStatus(d, a, status)
+			return status
+		}
+		// A secret which was in sync was changed or deleted in the
+		// destination, rather than pushed late
+		if status.Status == syncStatusSynced {
+			c.logger.Warn("secret drifted in sync destination", "destination", d.key(), "path", a.path(), "name", remoteName)
+			status.LastDrift = status.LastChecked
+		}
+	}
+
+	if data == nil {
+		err = client.Delete(ctx, remoteName)
+	} else {
+		err = client.Put(ctx, remoteName, data)
+	}
+	if err != nil {
+		return fail(errwrap.Wrapf("failed to write secret to destination: {{err}}", err))
+	}
+
+	status.Status = syncStatusSynced
+	status.LastSynced = time.Now().UTC()
+	status.Error = ""
+	c.secretsSync.setStatus(d, a, status)
+	return status
+}
+
+// runSecretsSync pushes the associated secrets on the kv events published on
+// the node, and checks all of them for drift on start and periodically, until
+// the stop channel is closed. Checking also catches up on the events dropped
+// while the loop was busy.
+func (c *Core) runSecretsSync(stopCh chan struct{}) {
+	events, stopEvents := c.SubscribeEvents(EventTypeKV + "/")
+	defer stopEvents()
+
+	ticker := time.NewTicker(secretsSyncInterval)
+	defer ticker.Stop()
+
+	// The destinations are read and synced under the state lock, as long
+	// as the node was not sealed
+	destinations := func() ([]*syncDestination, bool) {
+		c.stateLock.RLock()
+		defer c.stateLock.RUnlock()
+		select {
+		case <-stopCh:
+			return nil, false
+		default:
+		}
+		return c.secretsSync.list(), true
+	}
+	syncOne := func(d *syncDestination, a *syncAssociation, checkDrift bool) bool {
+		c.stateLock.RLock()
+		defer c.stateLock.RUnlock()
+		select {
+		case <-stopCh:
+			return false
+		default:
+		}
+		c.syncSecret(c.activeContext, d, a, checkDrift)
+		return true
+	}
+	syncMatching := func(match func(*syncAssociation) bool, checkDrift bool) bool {
+		list, ok := destinations()
+		if !ok {
+			return false
+		}
+		for _, d := range list {
+			for _, a := range d.Associations {
+				if match(a) && !syncOne(d, a, checkDrift) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	checkAll := func() bool {
+		return syncMatching(func(*syncAssociation) bool { return true }, true)
+	}
+
+	if !checkAll() {
+		return
+	}
+	for {
+		select {
+		case event := <-events:
+			mount := event.Metadata["mount"]
+			path := strings.TrimPrefix(event.Path, mount)
+			if !syncMatching(func(a *syncAssociation) bool { return a.matches(mount, path) }, false) {
+				return
+			}
+		case <-ticker.C:
+			if !checkAll() {
+				return
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
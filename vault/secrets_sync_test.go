@@ -0,0 +1,282 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// testKubernetesAPI is a fake Kubernetes API server holding the secrets of a
+// namespace
+type testKubernetesAPI struct {
+	l       sync.Mutex
+	secrets map[string]map[string][]byte
+}
+
+func (k *testKubernetesAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	k.l.Lock()
+	defer k.l.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	const prefix = "/api/v1/namespaces/apps/secrets"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	var secret struct {
+		Metadata struct {
+			Name   string            `json:"name"`
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Data map[string][]byte `json:"data"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&secret)
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := k.secrets[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		secret.Metadata.Name = name
+		secret.Data = data
+		json.NewEncoder(w).Encode(secret)
+	case http.MethodPut:
+		if _, ok := k.secrets[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		k.secrets[name] = secret.Data
+	case http.MethodPost:
+		if secret.Metadata.Labels["app.kubernetes.io/managed-by"] != "vault" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		k.secrets[secret.Metadata.Name] = secret.Data
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if _, ok := k.secrets[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(k.secrets, name)
+	}
+}
+
+func (k *testKubernetesAPI) get(name string) map[string][]byte {
+	k.l.Lock()
+	defer k.l.Unlock()
+	return k.secrets[name]
+}
+
+func (k *testKubernetesAPI) set(name string, data map[string][]byte) {
+	k.l.Lock()
+	defer k.l.Unlock()
+	k.secrets[name] = data
+}
+
+func TestSecretsSync(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	api := &testKubernetesAPI{secrets: make(map[string]map[string][]byte)}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		return c.HandleRequest(context.Background(), &logical.Request{
+			Operation:   op,
+			Path:        path,
+			Data:        data,
+			ClientToken: root,
+		})
+	}
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the secret to be synced")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Destinations are validated
+	resp, err := request(logical.UpdateOperation, "sys/sync/destinations/ftp/test", nil)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error for an unsupported type, got %#v", resp)
+	}
+	resp, err = request(logical.UpdateOperation, "sys/sync/destinations/kubernetes/test", nil)
+	if err == nil || !resp.IsError() || !strings.Contains(resp.Data["error"].(string), "kubernetes_host") {
+		t.Fatalf("expected an error for a missing host, got %#v", resp)
+	}
+
+	resp, err = request(logical.UpdateOperation, "sys/sync/destinations/kubernetes/test", map[string]interface{}{
+		"kubernetes_host":      server.URL,
+		"kubernetes_token":     "test-token",
+		"kubernetes_namespace": "apps",
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	resp, err = request(logical.ReadOperation, "sys/sync/destinations/kubernetes/test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["kubernetes_host"] != server.URL || resp.Data["secret_name_prefix"] != "vault-" || resp.Data["kubernetes_token"] != nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp, err = request(logical.ListOperation, "sys/sync/destinations", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "kubernetes/test" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Associating a secret pushes it right away
+	if _, err := request(logical.UpdateOperation, "secret/app/db", map[string]interface{}{
+		"password": "hunter2",
+		"port":     5432,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = request(logical.UpdateOperation, "sys/sync/destinations/kubernetes/test/associations/set", map[string]interface{}{
+		"mount":       "secret",
+		"secret_name": "app/db",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["sync_status"] != syncStatusSynced || resp.Data["remote_name"] != "vault-secret-app-db" || len(resp.Warnings) != 0 {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if data := api.get("vault-secret-app-db"); string(data["password"]) != "hunter2" || string(data["port"]) != "5432" {
+		t.Fatalf("bad secret: %#v", data)
+	}
+
+	// Only kv mounts can be associated
+	resp, err = request(logical.UpdateOperation, "sys/sync/destinations/kubernetes/test/associations/set", map[string]interface{}{
+		"mount":       "cubbyhole",
+		"secret_name": "app/db",
+	})
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error for a cubbyhole mount, got %#v", resp)
+	}
+
+	// Writes are pushed
+	if _, err := request(logical.UpdateOperation, "secret/app/db", map[string]interface{}{
+		"password": "correct horse",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool {
+		return string(api.get("vault-secret-app-db")["password"]) == "correct horse"
+	})
+
+	// Drift is detected and the secret pushed again
+	d := c.secretsSync.get("kubernetes/test")
+	api.set("vault-secret-app-db", map[string][]byte{"password": []byte("tampered")})
+	status := c.syncSecret(context.Background(), d, d.Associations[0], true)
+	if status.Status != syncStatusSynced || status.LastDrift.IsZero() {
+		t.Fatalf("bad status: %#v", status)
+	}
+	if string(api.get("vault-secret-app-db")["password"]) != "correct horse" {
+		t.Fatalf("bad secret: %#v", api.get("vault-secret-app-db"))
+	}
+
+	resp, err = request(logical.ReadOperation, "sys/sync/destinations/kubernetes/test/associations", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assoc := resp.Data["associations"].(map[string]interface{})["secret/app/db"].(map[string]interface{})
+	if assoc["sync_status"] != syncStatusSynced || assoc["last_drift_detected"] == "" || assoc["last_checked"] == "" || assoc["error"] != "" {
+		t.Fatalf("bad: %#v", assoc)
+	}
+
+	// Failures are reported
+	server.Close()
+	status = c.syncSecret(context.Background(), d, d.Associations[0], true)
+	if status.Status != syncStatusFailed || status.Error == "" {
+		t.Fatalf("bad status: %#v", status)
+	}
+	server = httptest.NewServer(api)
+	defer server.Close()
+	if _, err := request(logical.UpdateOperation, "sys/sync/destinations/kubernetes/test", map[string]interface{}{
+		"kubernetes_host": server.URL,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deletes are pushed
+	if _, err := request(logical.DeleteOperation, "secret/app/db", nil); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool {
+		return api.get("vault-secret-app-db") == nil
+	})
+
+	// The destinations are persisted with their associations
+	raw, err := c.systemBarrierView.SubView(secretsSyncSubPath).Get(context.Background(), "kubernetes/test")
+	if err != nil || raw == nil {
+		t.Fatalf("bad: entry: %#v, err: %v", raw, err)
+	}
+	var stored syncDestination
+	if err := raw.DecodeJSON(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.KubernetesHost != server.URL || stored.KubernetesToken != "test-token" || len(stored.Associations) != 1 {
+		t.Fatalf("bad destination: %#v", stored)
+	}
+
+	// Destinations with associations cannot be deleted
+	resp, err = request(logical.DeleteOperation, "sys/sync/destinations/kubernetes/test", nil)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+
+	// Removing the association deletes the secret in the destination
+	if _, err := request(logical.UpdateOperation, "secret/app/db", map[string]interface{}{
+		"password": "hunter2",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool {
+		return api.get("vault-secret-app-db") != nil
+	})
+	resp, err = request(logical.UpdateOperation, "sys/sync/destinations/kubernetes/test/associations/remove", map[string]interface{}{
+		"mount":       "secret/",
+		"secret_name": "app/db",
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if api.get("vault-secret-app-db") != nil {
+		t.Fatal("expected the secret to be deleted")
+	}
+
+	resp, err = request(logical.DeleteOperation, "sys/sync/destinations/kubernetes/test", nil)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if c.secretsSync.get("kubernetes/test") != nil {
+		t.Fatal("expected the destination to be deleted")
+	}
+}
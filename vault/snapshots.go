@@ -0,0 +1,386 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/helper/snapshotstore"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	// snapshotsSubPath is the sub-path of the system view the schedule of
+	// the snapshots and its status are stored under
+	snapshotsSubPath = "snapshot-schedule/"
+
+	// snapshotScheduleKey and snapshotStatusKey are the storage keys of the
+	// schedule and its status
+	snapshotScheduleKey = "config"
+	snapshotStatusKey   = "status"
+
+	// snapshotTimeFormat is the format of the time in the names of the
+	// snapshots, which sorts them by time
+	snapshotTimeFormat = "20060102T150405Z"
+
+	// snapshotSuffix ends the names of the snapshots
+	snapshotSuffix = ".snap"
+)
+
+var (
+	// snapshotsInterval is how often the active node checks whether a
+	// snapshot is due
+	snapshotsInterval = time.Minute
+)
+
+// snapshotSchedule is how often snapshots of the storage backend are taken,
+// how many are kept and where
+type snapshotSchedule struct {
+	Interval    time.Duration `json:"interval"`
+	Retain      int           `json:"retain"`
+	FilePrefix  string        `json:"file_prefix"`
+	StorageType string        `json:"storage_type"`
+
+	// LocalPath is the directory of the "local" storage type
+	LocalPath string `json:"local_path,omitempty"`
+
+	// PathPrefix is the prefix of the object names of the cloud storage
+	// types
+	PathPrefix string `json:"path_prefix,omitempty"`
+
+	AWSS3Bucket        string `json:"aws_s3_bucket,omitempty"`
+	AWSS3Region        string `json:"aws_s3_region,omitempty"`
+	AWSS3Endpoint      string `json:"aws_s3_endpoint,omitempty"`
+	AWSAccessKeyID     string `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey string `json:"aws_secret_access_key,omitempty"`
+
+	GoogleGCSBucket       string `json:"google_gcs_bucket,omitempty"`
+	GoogleCredentialsFile string `json:"google_credentials_file,omitempty"`
+}
+
+// store returns the store the snapshots of the schedule are kept in
+func (s *snapshotSchedule) store(ctx context.Context) (snapshotstore.Store, error) {
+	switch s.StorageType {
+	case "local":
+		return snapshotstore.NewLocalStore(s.LocalPath)
+	case "aws-s3":
+		return snapshotstore.NewS3Store(&snapshotstore.S3Config{
+			Bucket:    s.AWSS3Bucket,
+			Prefix:    s.PathPrefix,
+			Region:    s.AWSS3Region,
+			Endpoint:  s.AWSS3Endpoint,
+			AccessKey: s.AWSAccessKeyID,
+			SecretKey: s.AWSSecretAccessKey,
+		})
+	case "google-gcs":
+		return snapshotstore.NewGCSStore(ctx, &snapshotstore.GCSConfig{
+			Bucket:          s.GoogleGCSBucket,
+			Prefix:          s.PathPrefix,
+			CredentialsFile: s.GoogleCredentialsFile,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage_type %q", s.StorageType)
+	}
+}
+
+// snapshotStatus is the outcome of the snapshots taken on the schedule
+type snapshotStatus struct {
+	LastAttempt         time.Time `json:"last_attempt"`
+	LastSuccess         time.Time `json:"last_success"`
+	LastSnapshot        string    `json:"last_snapshot"`
+	LastError           string    `json:"last_error"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+}
+
+// snapshotSchedule returns the schedule of the snapshots, or nil if there is
+// none
+func (c *Core) snapshotSchedule(ctx context.Context) (*snapshotSchedule, error) {
+	raw, err := c.systemBarrierView.SubView(snapshotsSubPath).Get(ctx, snapshotScheduleKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read snapshot schedule: {{err}}", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var schedule snapshotSchedule
+	if err := raw.DecodeJSON(&schedule); err != nil {
+		return nil, errwrap.Wrapf("failed to decode snapshot schedule: {{err}}", err)
+	}
+	return &schedule, nil
+}
+
+// snapshotStatus returns the status of the snapshots
+func (c *Core) snapshotStatus(ctx context.Context) (*snapshotStatus, error) {
+	raw, err := c.systemBarrierView.SubView(snapshotsSubPath).Get(ctx, snapshotStatusKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read snapshot status: {{err}}", err)
+	}
+
+	var status snapshotStatus
+	if raw != nil {
+		if err := raw.DecodeJSON(&status); err != nil {
+			return nil, errwrap.Wrapf("failed to decode snapshot status: {{err}}", err)
+		}
+	}
+	return &status, nil
+}
+
+// takeScheduledSnapshot takes a snapshot if one is scheduled and due, and
+// records its outcome in the status
+func (c *Core) takeScheduledSnapshot(ctx context.Context) error {
+	schedule, err := c.snapshotSchedule(ctx)
+	if err != nil {
+		return err
+	}
+	if schedule == nil {
+		return nil
+	}
+	status, err := c.snapshotStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if now.Sub(status.LastAttempt) < schedule.Interval {
+		return nil
+	}
+
+	status.LastAttempt = now
+	name, snapshotErr := c.takeSnapshot(ctx, schedule, now)
+	if snapshotErr != nil {
+		status.LastError = snapshotErr.Error()
+		status.ConsecutiveFailures++
+		c.logger.Error("failed to take scheduled snapshot", "error", snapshotErr)
+	} else {
+		status.LastSuccess = now
+		status.LastSnapshot = name
+		status.LastError = ""
+		status.ConsecutiveFailures = 0
+		c.logger.Info("took scheduled snapshot", "name", name)
+	}
+
+	entry, err := logical.StorageEntryJSON(snapshotStatusKey, status)
+	if err != nil {
+		return errwrap.Wrapf("failed to encode snapshot status: {{err}}", err)
+	}
+	if err := c.systemBarrierView.SubView(snapshotsSubPath).Put(ctx, entry); err != nil {
+		return errwrap.Wrapf("failed to persist snapshot status: {{err}}", err)
+	}
+	return nil
+}
+
+// takeSnapshot writes a snapshot of the storage backend to the store of the
+// schedule, then deletes the snapshots beyond the number retained. It
+// returns the name of the snapshot.
+func (c *Core) takeSnapshot(ctx context.Context, schedule *snapshotSchedule, now time.Time) (string, error) {
+	snapshotter, ok := c.underlyingPhysical.(physical.Snapshotter)
+	if !ok {
+		return "", fmt.Errorf("storage backend does not support snapshots")
+	}
+	store, err := schedule.store(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-%s%s", schedule.FilePrefix, now.Format(snapshotTimeFormat), snapshotSuffix)
+
+	// The snapshot is streamed to the store rather than held in memory
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(snapshotter.Snapshot(pw))
+	}()
+	err = store.Put(ctx, name, pr)
+	pr.CloseWithError(err)
+	if err != nil {
+		return "", errwrap.Wrapf("failed to store snapshot: {{err}}", err)
+	}
+
+	names, err := store.List(ctx)
+	if err != nil {
+		return name, errwrap.Wrapf("failed to list snapshots: {{err}}", err)
+	}
+	var snapshots []string
+	for _, n := range names {
+		if strings.HasPrefix(n, schedule.FilePrefix+"-") && strings.HasSuffix(n, snapshotSuffix) {
+			snapshots = append(snapshots, n)
+		}
+	}
+	sort.Strings(snapshots)
+	for len(snapshots) > schedule.Retain {
+		if err := store.Delete(ctx, snapshots[0]); err != nil {
+			return name, errwrap.Wrapf(fmt.Sprintf("failed to delete snapshot %q: {{err}}", snapshots[0]), err)
+		}
+		snapshots = snapshots[1:]
+	}
+
+	return name, nil
+}
+
+// runSnapshots periodically takes the scheduled snapshots, until the stop
+// channel is closed
+func (c *Core) runSnapshots(stopCh chan struct{}) {
+	ticker := time.NewTicker(snapshotsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.stateLock.RLock()
+			select {
+			case <-stopCh:
+				c.stateLock.RUnlock()
+				return
+			default:
+			}
+			if err := c.takeScheduledSnapshot(c.activeContext); err != nil {
+				c.logger.Error("failed to run snapshot schedule", "error", err)
+			}
+			c.stateLock.RUnlock()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// handleSnapshotScheduleRead returns the schedule of the snapshots, without
+// its secrets
+func (b *SystemBackend) handleSnapshotScheduleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	schedule, err := b.Core.snapshotSchedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, nil
+	}
+
+	respData := map[string]interface{}{
+		"interval":     int64(schedule.Interval.Seconds()),
+		"retain":       schedule.Retain,
+		"file_prefix":  schedule.FilePrefix,
+		"storage_type": schedule.StorageType,
+	}
+	switch schedule.StorageType {
+	case "local":
+		respData["local_path"] = schedule.LocalPath
+	case "aws-s3":
+		respData["path_prefix"] = schedule.PathPrefix
+		respData["aws_s3_bucket"] = schedule.AWSS3Bucket
+		respData["aws_s3_region"] = schedule.AWSS3Region
+		respData["aws_s3_endpoint"] = schedule.AWSS3Endpoint
+		respData["aws_access_key_id"] = schedule.AWSAccessKeyID
+	case "google-gcs":
+		respData["path_prefix"] = schedule.PathPrefix
+		respData["google_gcs_bucket"] = schedule.GoogleGCSBucket
+		respData["google_credentials_file"] = schedule.GoogleCredentialsFile
+	}
+
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+// handleSnapshotScheduleUpdate sets the schedule of the snapshots
+func (b *SystemBackend) handleSnapshotScheduleUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if _, ok := b.Core.underlyingPhysical.(physical.Snapshotter); !ok {
+		return logical.ErrorResponse("the storage backend does not support snapshots"), logical.ErrInvalidRequest
+	}
+
+	interval, err := parseutil.ParseDurationSecond(data.Get("interval"))
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid interval: %v", err)), logical.ErrInvalidRequest
+	}
+	if interval < snapshotsInterval {
+		return logical.ErrorResponse(fmt.Sprintf("interval must be at least %s", snapshotsInterval)), logical.ErrInvalidRequest
+	}
+
+	schedule := &snapshotSchedule{
+		Interval:    interval,
+		Retain:      data.Get("retain").(int),
+		FilePrefix:  data.Get("file_prefix").(string),
+		StorageType: data.Get("storage_type").(string),
+
+		LocalPath:  data.Get("local_path").(string),
+		PathPrefix: data.Get("path_prefix").(string),
+
+		AWSS3Bucket:        data.Get("aws_s3_bucket").(string),
+		AWSS3Region:        data.Get("aws_s3_region").(string),
+		AWSS3Endpoint:      data.Get("aws_s3_endpoint").(string),
+		AWSAccessKeyID:     data.Get("aws_access_key_id").(string),
+		AWSSecretAccessKey: data.Get("aws_secret_access_key").(string),
+
+		GoogleGCSBucket:       data.Get("google_gcs_bucket").(string),
+		GoogleCredentialsFile: data.Get("google_credentials_file").(string),
+	}
+	if schedule.Retain < 1 {
+		return logical.ErrorResponse("retain must be at least 1"), logical.ErrInvalidRequest
+	}
+	if schedule.FilePrefix == "" || strings.ContainsAny(schedule.FilePrefix, `/\`) {
+		return logical.ErrorResponse("file_prefix must be set and can't contain slashes"), logical.ErrInvalidRequest
+	}
+	if _, err := schedule.store(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(snapshotScheduleKey, schedule)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Core.systemBarrierView.SubView(snapshotsSubPath).Put(ctx, entry); err != nil {
+		return nil, errwrap.Wrapf("failed to persist snapshot schedule: {{err}}", err)
+	}
+
+	return nil, nil
+}
+
+// handleSnapshotScheduleDelete stops taking snapshots. The snapshots already
+// taken are kept.
+func (b *SystemBackend) handleSnapshotScheduleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.systemBarrierView.SubView(snapshotsSubPath).Delete(ctx, snapshotScheduleKey); err != nil {
+		return nil, errwrap.Wrapf("failed to delete snapshot schedule: {{err}}", err)
+	}
+	return nil, nil
+}
+
+// handleSnapshotScheduleStatus returns the outcome of the scheduled snapshots
+func (b *SystemBackend) handleSnapshotScheduleStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	schedule, err := b.Core.snapshotSchedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status, err := b.Core.snapshotStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	respData := map[string]interface{}{
+		"scheduled":            schedule != nil,
+		"last_snapshot":        status.LastSnapshot,
+		"last_error":           status.LastError,
+		"consecutive_failures": status.ConsecutiveFailures,
+	}
+	if !status.LastAttempt.IsZero() {
+		respData["last_attempt"] = status.LastAttempt.Format(time.RFC3339)
+	}
+	if !status.LastSuccess.IsZero() {
+		respData["last_success"] = status.LastSuccess.Format(time.RFC3339)
+	}
+	if schedule != nil {
+		next := status.LastAttempt.Add(schedule.Interval)
+		if next.Before(time.Now()) {
+			next = time.Now()
+		}
+		respData["next_attempt"] = next.UTC().Format(time.RFC3339)
+	}
+
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
+)
+
+func TestSystemBackend_snapshotSchedule(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+
+	dir, err := ioutil.TempDir("", "vault-snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "storage/snapshot-schedule")
+	req.Data["interval"] = "1h"
+	req.Data["retain"] = 2
+	req.Data["storage_type"] = "local"
+	req.Data["local_path"] = dir
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), logical.TestRequest(t, logical.ReadOperation, "storage/snapshot-schedule"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"interval":     int64(3600),
+		"retain":       2,
+		"file_prefix":  "vault-snapshot",
+		"storage_type": "local",
+		"local_path":   dir,
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The first snapshot is due right away, the next one after the interval
+	if err := c.takeScheduledSnapshot(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.takeScheduledSnapshot(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "vault-snapshot-*.snap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Fatalf("expected 1 snapshot, got %v", names)
+	}
+
+	// The snapshot has the entries of the storage backend
+	raw, err := ioutil.ReadFile(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry physical.Entry
+	if err := json.NewDecoder(bytes.NewReader(raw)).Decode(&entry); err != nil || entry.Key == "" {
+		t.Fatalf("bad snapshot: entry: %#v, err: %v", entry, err)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), logical.TestRequest(t, logical.ReadOperation, "storage/snapshot-schedule/status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["last_snapshot"] != filepath.Base(names[0]) || resp.Data["last_error"] != "" || resp.Data["scheduled"] != true {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The oldest snapshots beyond the number retained are deleted
+	schedule, err := c.snapshotSchedule(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for i := 1; i <= 2; i++ {
+		if _, err := c.takeSnapshot(context.Background(), schedule, now.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remaining, err := filepath.Glob(filepath.Join(dir, "vault-snapshot-*.snap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 2 || remaining[0] == names[0] {
+		t.Fatalf("expected the 2 newest snapshots, got %v", remaining)
+	}
+
+	// Invalid schedules are rejected
+	req = logical.TestRequest(t, logical.UpdateOperation, "storage/snapshot-schedule")
+	req.Data["interval"] = "1h"
+	req.Data["storage_type"] = "ftp"
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error for an unsupported storage type, got %#v", resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), logical.TestRequest(t, logical.DeleteOperation, "storage/snapshot-schedule"))
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp, err = b.HandleRequest(context.Background(), logical.TestRequest(t, logical.ReadOperation, "storage/snapshot-schedule/status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["scheduled"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
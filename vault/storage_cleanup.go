@@ -0,0 +1,285 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// storageCleanupGrace is how long a lease or token must have been
+	// expired before it is considered never reaped, beyond the retries of
+	// its revocation
+	storageCleanupGrace = time.Hour
+)
+
+// storageCleanupResult lists the entries found left behind in storage: the
+// leases whose token no longer exists, the leases and tokens expired long
+// ago that the expiration manager is no longer revoking, and the cubbyholes,
+// response-wrapping ones included, whose token no longer exists
+type storageCleanupResult struct {
+	OrphanedLeases     []string
+	ExpiredLeases      []string
+	UnreapedTokens     []string
+	OrphanedCubbyholes int
+
+	// Deleted is whether the entries were deleted rather than only reported
+	Deleted bool
+
+	// Errors are the failures to delete individual entries
+	Errors []string
+}
+
+// cleanupStorage scans the storage for the entries left behind by leases
+// and tokens, deleting them if requested. The cubbyholes are listed before
+// the tokens, so that the cubbyhole of a token created during the scan is
+// never taken for an orphaned one.
+func (c *Core) cleanupStorage(ctx context.Context, delete bool) (*storageCleanupResult, error) {
+	if !atomic.CompareAndSwapUint32(&c.storageCleanupLock, 0, 1) {
+		return nil, errors.New("storage cleanup already in progress")
+	}
+	defer atomic.StoreUint32(&c.storageCleanupLock, 0)
+
+	if c.expiration.inRestoreMode() {
+		return nil, errors.New("cannot clean up storage while restoring leases")
+	}
+
+	ts := c.tokenStore
+	result := &storageCleanupResult{
+		OrphanedLeases: []string{},
+		ExpiredLeases:  []string{},
+		UnreapedTokens: []string{},
+		Deleted:        delete,
+	}
+
+	var cubbyholes []string
+	if ts.cubbyholeBackend != nil {
+		keys, err := ts.cubbyholeBackend.storageView.List(ctx, "")
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to list cubbyholes: {{err}}", err)
+		}
+		cubbyholes = keys
+	}
+
+	saltedIDs, err := ts.view.List(ctx, lookupPrefix)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list tokens: {{err}}", err)
+	}
+
+	var cleanupErrors *multierror.Error
+
+	// Tokens go first, as revoking them also revokes their leases and
+	// destroys their cubbyholes
+	liveCubbyholes := make(map[string]struct{}, len(saltedIDs))
+	for _, saltedID := range saltedIDs {
+		te, err := ts.loadSaltedEntry(ctx, saltedID)
+		if err != nil {
+			return nil, err
+		}
+		if te == nil {
+			continue
+		}
+		if ts.cubbyholeBackend != nil {
+			liveCubbyholes[salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash)+"/"] = struct{}{}
+		}
+
+		unreaped, err := c.expiration.tokenUnreaped(te)
+		if err != nil {
+			return nil, err
+		}
+		if !unreaped {
+			continue
+		}
+
+		result.UnreapedTokens = append(result.UnreapedTokens, te.Accessor)
+		if delete {
+			// Revoke the token through its lease, as on expiration, creating
+			// one if it was lost
+			leaseID, err := c.expiration.CreateOrFetchRevocationLeaseByToken(te)
+			if err == nil {
+				err = c.expiration.Revoke(ctx, leaseID)
+			}
+			if err != nil {
+				cleanupErrors = multierror.Append(cleanupErrors, errwrap.Wrapf(fmt.Sprintf("failed to revoke the token with accessor %q: {{err}}", te.Accessor), err))
+			}
+		}
+	}
+
+	orphaned, expired, err := c.expiration.unreapedLeases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.OrphanedLeases = append(result.OrphanedLeases, orphaned...)
+	result.ExpiredLeases = append(result.ExpiredLeases, expired...)
+	if delete {
+		for _, leaseID := range orphaned {
+			// The token no longer exists, so skip the token store
+			if err := c.expiration.revokeCommon(ctx, leaseID, true, true); err != nil {
+				cleanupErrors = multierror.Append(cleanupErrors, errwrap.Wrapf(fmt.Sprintf("failed to revoke the lease %q: {{err}}", leaseID), err))
+			}
+		}
+		for _, leaseID := range expired {
+			if err := c.expiration.revokeCommon(ctx, leaseID, true, false); err != nil {
+				cleanupErrors = multierror.Append(cleanupErrors, errwrap.Wrapf(fmt.Sprintf("failed to revoke the lease %q: {{err}}", leaseID), err))
+			}
+		}
+	}
+
+	for _, key := range cubbyholes {
+		if !strings.HasSuffix(key, "/") {
+			continue
+		}
+		if _, ok := liveCubbyholes[key]; ok {
+			continue
+		}
+
+		result.OrphanedCubbyholes++
+		if delete {
+			if err := ts.cubbyholeBackend.revoke(ctx, strings.TrimSuffix(key, "/")); err != nil {
+				cleanupErrors = multierror.Append(cleanupErrors, errwrap.Wrapf("failed to destroy a cubbyhole: {{err}}", err))
+			}
+		}
+	}
+
+	if cleanupErrors != nil {
+		for _, err := range cleanupErrors.Errors {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+
+	sort.Strings(result.OrphanedLeases)
+	sort.Strings(result.ExpiredLeases)
+	sort.Strings(result.UnreapedTokens)
+
+	return result, nil
+}
+
+// loadSaltedEntry reads the entry of a salted token as stored, without the
+// revocation lookupSalted performs on expired tokens
+func (ts *TokenStore) loadSaltedEntry(ctx context.Context, saltedID string) (*logical.TokenEntry, error) {
+	raw, err := ts.view.Get(ctx, lookupPrefix+saltedID)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read entry: {{err}}", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	entry := new(logical.TokenEntry)
+	if err := jsonutil.DecodeJSON(raw.Value, entry); err != nil {
+		return nil, errwrap.Wrapf("failed to decode entry: {{err}}", err)
+	}
+	return entry, nil
+}
+
+// leaseUnreaped returns whether the lease expired long enough ago that its
+// revocation is no longer pending nor being retried
+func (m *ExpirationManager) leaseUnreaped(le *leaseEntry) bool {
+	if le.ExpireTime.IsZero() || !le.ExpireTime.Before(time.Now().Add(-storageCleanupGrace)) {
+		return false
+	}
+
+	m.pendingLock.RLock()
+	_, pending := m.pending[le.LeaseID]
+	m.pendingLock.RUnlock()
+
+	return !pending
+}
+
+// tokenUnreaped returns whether the token is left in storage: it is marked
+// as revoked or is expiring yet has no lease to revoke it, or its lease was
+// never reaped. Tokens created recently may not have their lease yet.
+func (m *ExpirationManager) tokenUnreaped(te *logical.TokenEntry) (bool, error) {
+	if time.Unix(te.CreationTime, 0).After(time.Now().Add(-storageCleanupGrace)) {
+		return false, nil
+	}
+
+	saltedID, err := m.tokenStore.SaltID(m.quitContext, te.ID)
+	if err != nil {
+		return false, err
+	}
+	le, err := m.loadEntry(path.Join(te.Path, saltedID))
+	if err != nil {
+		return false, errwrap.Wrapf("failed to load the lease of a token: {{err}}", err)
+	}
+
+	if le == nil {
+		// Root tokens without a TTL need no lease
+		isRoot := len(te.Policies) == 1 && te.Policies[0] == "root" && te.TTL == 0
+		return !isRoot || te.NumUses == tokenRevocationPending, nil
+	}
+	return m.leaseUnreaped(le), nil
+}
+
+// unreapedLeases returns the IDs of the leases whose token no longer exists,
+// and of those expired that were never reaped
+func (m *ExpirationManager) unreapedLeases(ctx context.Context) (orphaned, expired []string, err error) {
+	tokenExists := make(map[string]bool)
+	var scanErr error
+
+	scanFunc := func(leaseID string) {
+		if scanErr != nil {
+			return
+		}
+
+		le, err := m.loadEntry(leaseID)
+		if err != nil {
+			scanErr = errwrap.Wrapf(fmt.Sprintf("failed to load the lease ID %q: {{err}}", leaseID), err)
+			return
+		}
+		if le == nil {
+			return
+		}
+
+		if le.ClientToken == "" {
+			orphaned = append(orphaned, leaseID)
+			return
+		}
+
+		// Batch tokens have no entry to look for
+		if !strings.HasPrefix(le.ClientToken, batchTokenPrefix) {
+			exists, ok := tokenExists[le.ClientToken]
+			if !ok {
+				saltedID, err := m.tokenStore.SaltID(ctx, le.ClientToken)
+				if err != nil {
+					scanErr = err
+					return
+				}
+				raw, err := m.tokenStore.view.Get(ctx, lookupPrefix+saltedID)
+				if err != nil {
+					scanErr = errwrap.Wrapf("failed to look up token: {{err}}", err)
+					return
+				}
+				exists = raw != nil
+				tokenExists[le.ClientToken] = exists
+			}
+			if !exists {
+				orphaned = append(orphaned, leaseID)
+				return
+			}
+		}
+
+		if m.leaseUnreaped(le) {
+			expired = append(expired, leaseID)
+		}
+	}
+
+	if err := logical.ScanView(ctx, m.idView, scanFunc); err != nil {
+		return nil, nil, err
+	}
+	if scanErr != nil {
+		return nil, nil, scanErr
+	}
+	return orphaned, expired, nil
+}
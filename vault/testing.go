@@ -1145,6 +1145,9 @@ func NewTestCluster(t testing.T, base *CoreConfig, opts *TestClusterOptions) *Te
 		coreConfig.Seal = base.Seal
 		coreConfig.DevToken = base.DevToken
 		coreConfig.EnableRaw = base.EnableRaw
+		coreConfig.PerformanceStandby = base.PerformanceStandby
+		coreConfig.MetricsSink = base.MetricsSink
+		coreConfig.LogLines = base.LogLines
 
 		if !coreConfig.DisableMlock {
 			base.DisableMlock = false
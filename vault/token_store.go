@@ -2,10 +2,12 @@ package vault
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"sync"
 	"sync/atomic"
 
@@ -38,6 +40,10 @@ const (
 	// primary ID based index
 	lookupPrefix = "id/"
 
+	// batchTokenPrefix prefixes the IDs of batch tokens, which are their
+	// encrypted entries rather than references to stored ones
+	batchTokenPrefix = "b."
+
 	// accessorPrefix is the prefix used to store the index from
 	// Accessor to Token ID
 	accessorPrefix = "accessor/"
@@ -116,7 +122,9 @@ type TokenStore struct {
 
 	cubbyholeBackend *CubbyholeBackend
 
-	policyLookupFunc func(string) (*Policy, error)
+	policyLookupFunc func(*Namespace, string) (*Policy, error)
+
+	namespaceLookupFunc func(string) *Namespace
 
 	tokenLocks []*locksutil.LockEntry
 
@@ -136,6 +144,15 @@ type TokenStore struct {
 	tidyLock *uint32
 
 	identityPoliciesDeriverFunc func(string) (*identity.Entity, []string, error)
+
+	// rootTokenMaxTTL, if set, is the longest any root token may live
+	rootTokenMaxTTL time.Duration
+
+	// logRootTokens causes root token creation to be logged
+	logRootTokens bool
+
+	// batchTokenEncryptor encrypts the entries of batch tokens into their IDs
+	batchTokenEncryptor BarrierEncryptor
 }
 
 // NewTokenStore is used to construct a token store that is
@@ -154,13 +171,21 @@ func NewTokenStore(ctx context.Context, logger log.Logger, c *Core, config *logi
 		saltLock:                    sync.RWMutex{},
 		identityPoliciesDeriverFunc: c.fetchEntityAndDerivedPolicies,
 		tidyLock:                    new(uint32),
+		rootTokenMaxTTL:             c.rootTokenMaxTTL,
+		logRootTokens:               c.logRootTokens,
+		batchTokenEncryptor:         c.barrier,
 	}
 
 	if c.policyStore != nil {
-		t.policyLookupFunc = func(name string) (*Policy, error) {
-			return c.policyStore.GetPolicy(ctx, name, PolicyTypeToken)
+		t.policyLookupFunc = func(ns *Namespace, name string) (*Policy, error) {
+			ps := c.namespacePolicyStore(ns)
+			if ps == nil {
+				return nil, nil
+			}
+			return ps.GetPolicy(ctx, name, PolicyTypeToken)
 		}
 	}
+	t.namespaceLookupFunc = c.namespaceByMountPoint
 
 	// Setup the framework endpoints
 	t.Backend = &framework.Backend{
@@ -229,6 +254,12 @@ func NewTokenStore(ctx context.Context, logger log.Logger, c *Core, config *logi
 						Description: tokenOrphanHelp,
 					},
 
+					"orphan_children_on_revoke": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Default:     false,
+						Description: tokenOrphanChildrenOnRevokeHelp,
+					},
+
 					"period": &framework.FieldSchema{
 						Type:        framework.TypeDurationSecond,
 						Default:     0,
@@ -283,6 +314,17 @@ func NewTokenStore(ctx context.Context, logger log.Logger, c *Core, config *logi
 				HelpDescription: strings.TrimSpace(tokenCreateOrphanHelp),
 			},
 
+			&framework.Path{
+				Pattern: "create-restricted$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: t.handleCreateRestricted,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(tokenCreateRestrictedHelp),
+				HelpDescription: strings.TrimSpace(tokenCreateRestrictedHelpDesc),
+			},
+
 			&framework.Path{
 				Pattern: "create/" + framework.GenericNameRegex("role_name"),
 
@@ -570,6 +612,10 @@ type tsRoleEntry struct {
 	// If true, tokens created using this role will be orphans
 	Orphan bool `json:"orphan" mapstructure:"orphan" structs:"orphan"`
 
+	// If true, the children of tokens created using this role will be
+	// orphaned rather than revoked when the tokens are revoked
+	OrphanChildrenOnRevoke bool `json:"orphan_children_on_revoke" mapstructure:"orphan_children_on_revoke" structs:"orphan_children_on_revoke"`
+
 	// If non-zero, tokens created using this role will be able to be renewed
 	// forever, but will have a fixed renewal period of this value
 	Period time.Duration `json:"period" mapstructure:"period" structs:"period"`
@@ -614,17 +660,149 @@ func (ts *TokenStore) SaltID(ctx context.Context, id string) (string, error) {
 // RootToken is used to generate a new token with root privileges and no parent
 func (ts *TokenStore) rootToken(ctx context.Context) (*logical.TokenEntry, error) {
 	te := &logical.TokenEntry{
-		Policies:     []string{"root"},
-		Path:         "auth/token/root",
-		DisplayName:  "root",
-		CreationTime: time.Now().Unix(),
+		Policies:       []string{"root"},
+		Path:           "auth/token/root",
+		DisplayName:    "root",
+		CreationTime:   time.Now().Unix(),
+		TTL:            ts.rootTokenMaxTTL,
+		ExplicitMaxTTL: ts.rootTokenMaxTTL,
 	}
 	if err := ts.create(ctx, te); err != nil {
 		return nil, err
 	}
+
+	// An expiring root token must be tracked so that it is revoked
+	if te.TTL > 0 {
+		auth := &logical.Auth{
+			ClientToken:    te.ID,
+			Accessor:       te.Accessor,
+			DisplayName:    te.DisplayName,
+			Policies:       te.Policies,
+			TokenPolicies:  te.Policies,
+			ExplicitMaxTTL: te.ExplicitMaxTTL,
+			CreationPath:   te.Path,
+			LeaseOptions: logical.LeaseOptions{
+				TTL:       te.TTL,
+				Renewable: true,
+			},
+		}
+		if err := ts.expiration.RegisterAuth(te.Path, auth); err != nil {
+			ts.revokeOrphan(ctx, te.ID)
+			return nil, errwrap.Wrapf("failed to register root token lease: {{err}}", err)
+		}
+	}
+
+	ts.rootTokenCreated(te)
 	return te, nil
 }
 
+// rootTokenCreated records the creation of a root token
+func (ts *TokenStore) rootTokenCreated(te *logical.TokenEntry) {
+	metrics.IncrCounter([]string{"token", "root", "create"}, 1)
+	if ts.logRootTokens {
+		ts.logger.Warn("root token created", "accessor", te.Accessor, "path", te.Path, "ttl", te.TTL)
+	}
+}
+
+// rootTokenAccessors returns the accessors of all live root tokens, along
+// with information about each of them keyed by accessor
+func (ts *TokenStore) rootTokenAccessors(ctx context.Context) ([]string, map[string]interface{}, error) {
+	entries, err := ts.view.List(ctx, accessorPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0)
+	keyInfo := make(map[string]interface{})
+	for _, entry := range entries {
+		aEntry, err := ts.lookupBySaltedAccessor(ctx, entry, false)
+		if err != nil || aEntry.TokenID == "" {
+			continue
+		}
+		te, err := ts.Lookup(ctx, aEntry.TokenID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if te == nil || !strutil.StrListContains(te.Policies, "root") {
+			continue
+		}
+
+		info := map[string]interface{}{
+			"creation_time": te.CreationTime,
+			"display_name":  te.DisplayName,
+			"path":          te.Path,
+			"expire_time":   nil,
+		}
+		leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(te.Path, te.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if leaseTimes != nil && !leaseTimes.ExpireTime.IsZero() {
+			info["expire_time"] = leaseTimes.ExpireTime
+		}
+
+		keys = append(keys, te.Accessor)
+		keyInfo[te.Accessor] = info
+	}
+	sort.Strings(keys)
+
+	return keys, keyInfo, nil
+}
+
+// policyTokenAccessors returns the accessors of all live tokens of the
+// namespace that carry the policy
+func (ts *TokenStore) policyTokenAccessors(ctx context.Context, policy, namespaceID string) ([]string, error) {
+	entries, err := ts.view.List(ctx, accessorPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	accessors := make([]string, 0)
+	for _, entry := range entries {
+		aEntry, err := ts.lookupBySaltedAccessor(ctx, entry, false)
+		if err != nil || aEntry.TokenID == "" {
+			continue
+		}
+		te, err := ts.Lookup(ctx, aEntry.TokenID)
+		if err != nil {
+			return nil, err
+		}
+		if te == nil || te.NamespaceID != namespaceID || !strutil.StrListContains(te.Policies, policy) {
+			continue
+		}
+		accessors = append(accessors, te.Accessor)
+	}
+	sort.Strings(accessors)
+
+	return accessors, nil
+}
+
+// policyTokenRoles returns the names of the token roles that allow or
+// disallow the policy
+func (ts *TokenStore) policyTokenRoles(ctx context.Context, policy string) ([]string, error) {
+	entries, err := ts.view.List(ctx, rolesPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]string, 0)
+	for _, entry := range entries {
+		role, err := ts.tokenStoreRole(ctx, strings.TrimPrefix(entry, rolesPrefix))
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			continue
+		}
+		if strutil.StrListContains(role.AllowedPolicies, policy) || strutil.StrListContains(role.DisallowedPolicies, policy) {
+			roles = append(roles, role.Name)
+		}
+	}
+	sort.Strings(roles)
+
+	return roles, nil
+}
+
 func (ts *TokenStore) tokenStoreAccessorList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	entries, err := ts.view.List(ctx, accessorPrefix)
 	if err != nil {
@@ -692,6 +870,10 @@ func (ts *TokenStore) createAccessor(ctx context.Context, entry *logical.TokenEn
 // a newly generated ID if not provided.
 func (ts *TokenStore) create(ctx context.Context, entry *logical.TokenEntry) error {
 	defer metrics.MeasureSince([]string{"token", "create"}, time.Now())
+	if entry.Type == logical.TokenTypeBatch {
+		return ts.createBatchToken(ctx, entry)
+	}
+
 	// Generate an ID if necessary
 	if entry.ID == "" {
 		entryUUID, err := uuid.GenerateUUID()
@@ -720,6 +902,93 @@ func (ts *TokenStore) create(ctx context.Context, entry *logical.TokenEntry) err
 	return ts.storeCommon(ctx, entry, true)
 }
 
+// createBatchToken sets the ID of a batch token to its encrypted entry. Batch
+// tokens have no accessor and nothing is stored for them.
+func (ts *TokenStore) createBatchToken(ctx context.Context, entry *logical.TokenEntry) error {
+	switch {
+	case entry.ID != "":
+		return fmt.Errorf("batch tokens cannot have a custom ID")
+	case entry.TTL == 0:
+		return fmt.Errorf("batch tokens must have a TTL")
+	case entry.NumUses != 0:
+		return fmt.Errorf("batch tokens cannot have a use limit")
+	case entry.Period != 0:
+		return fmt.Errorf("batch tokens cannot be periodic")
+	}
+	if ts.batchTokenEncryptor == nil {
+		return fmt.Errorf("batch tokens are not supported")
+	}
+
+	entry.Policies = policyutil.SanitizePolicies(entry.Policies, policyutil.DoNotAddDefaultPolicy)
+	entry.Accessor = ""
+
+	plaintext, err := jsonutil.EncodeJSON(entry)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := ts.batchTokenEncryptor.Encrypt(ctx, batchTokenPrefix, plaintext)
+	if err != nil {
+		return errwrap.Wrapf("failed to encrypt batch token: {{err}}", err)
+	}
+	entry.ID = batchTokenPrefix + base64.RawURLEncoding.EncodeToString(ciphertext)
+	return nil
+}
+
+// lookupBatchToken decrypts the entry of a batch token. Tokens that can't be
+// decrypted or have expired are not found.
+func (ts *TokenStore) lookupBatchToken(ctx context.Context, id string) (*logical.TokenEntry, error) {
+	if ts.batchTokenEncryptor == nil {
+		return nil, nil
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(id, batchTokenPrefix))
+	if err != nil {
+		return nil, nil
+	}
+	plaintext, err := ts.batchTokenEncryptor.Decrypt(ctx, batchTokenPrefix, ciphertext)
+	switch {
+	case err == ErrBarrierSealed:
+		return nil, err
+	case err != nil:
+		return nil, nil
+	}
+
+	entry := new(logical.TokenEntry)
+	if err := jsonutil.DecodeJSON(plaintext, entry); err != nil {
+		return nil, errwrap.Wrapf("failed to decode batch token: {{err}}", err)
+	}
+	if time.Now().After(time.Unix(entry.CreationTime, 0).Add(entry.TTL)) {
+		return nil, nil
+	}
+
+	// Nothing revokes batch tokens, so they are invalid once what would have
+	// revoked them is gone: their parent, or the auth method which created
+	// them
+	if entry.Parent != "" {
+		parent, err := ts.Lookup(ctx, entry.Parent)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to look up the parent of batch token: {{err}}", err)
+		}
+		if parent == nil {
+			return nil, nil
+		}
+	}
+	if ts.expiration == nil {
+		return nil, errors.New("expiration manager is nil on tokenstore")
+	}
+	if mountEntry := ts.expiration.router.MatchingMountEntry(entry.Path); mountEntry == nil || mountEntry.Tainted {
+		return nil, nil
+	}
+
+	entry.ID = id
+	return entry, nil
+}
+
+// batchTokenRemainingTTL returns how much longer a batch token is valid. The
+// leases of a batch token are capped at it since nothing revokes them.
+func batchTokenRemainingTTL(te *logical.TokenEntry) time.Duration {
+	return time.Unix(te.CreationTime, 0).Add(te.TTL).Sub(time.Now())
+}
+
 // Store is used to store an updated token entry without writing the
 // secondary index.
 func (ts *TokenStore) store(ctx context.Context, entry *logical.TokenEntry) error {
@@ -862,6 +1131,9 @@ func (ts *TokenStore) Lookup(ctx context.Context, id string) (*logical.TokenEntr
 	if id == "" {
 		return nil, fmt.Errorf("cannot lookup blank token")
 	}
+	if strings.HasPrefix(id, batchTokenPrefix) {
+		return ts.lookupBatchToken(ctx, id)
+	}
 
 	lock := locksutil.LockForKey(ts.tokenLocks, id)
 	lock.RLock()
@@ -881,6 +1153,9 @@ func (ts *TokenStore) lookupTainted(ctx context.Context, id string) (*logical.To
 	if id == "" {
 		return nil, fmt.Errorf("cannot lookup blank token")
 	}
+	if strings.HasPrefix(id, batchTokenPrefix) {
+		return ts.lookupBatchToken(ctx, id)
+	}
 
 	lock := locksutil.LockForKey(ts.tokenLocks, id)
 	lock.RLock()
@@ -1021,6 +1296,9 @@ func (ts *TokenStore) revokeOrphan(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("cannot revoke blank token")
 	}
+	if strings.HasPrefix(id, batchTokenPrefix) {
+		return fmt.Errorf("batch tokens cannot be revoked")
+	}
 
 	saltedID, err := ts.SaltID(ctx, id)
 	if err != nil {
@@ -1178,6 +1456,9 @@ func (ts *TokenStore) revokeTree(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("cannot tree-revoke blank token")
 	}
+	if strings.HasPrefix(id, batchTokenPrefix) {
+		return fmt.Errorf("batch tokens cannot be revoked")
+	}
 
 	// Get the salted ID
 	saltedID, err := ts.SaltID(ctx, id)
@@ -1204,6 +1485,20 @@ func (ts *TokenStore) revokeTreeSalted(ctx context.Context, saltedID string) err
 		if err != nil {
 			return errwrap.Wrapf("failed to scan for children: {{err}}", err)
 		}
+		// The children of a token orphaning them on revocation are left out
+		// of the tree, and orphaned when the token is revoked
+		orphanChildren := false
+		if len(children) > 0 {
+			entry, err := ts.lookupSalted(ctx, id, true)
+			if err != nil {
+				return errwrap.Wrapf("failed to look up entry: {{err}}", err)
+			}
+			if entry != nil && entry.OrphanChildrenOnRevoke {
+				children = nil
+				orphanChildren = true
+			}
+		}
+
 		// If the length of the children array is zero,
 		// then we are at a leaf node.
 		if len(children) == 0 {
@@ -1212,7 +1507,7 @@ func (ts *TokenStore) revokeTreeSalted(ctx context.Context, saltedID string) err
 			// take care of expiring them. If Vault is restarted, any revoked tokens
 			// would have been deleted, and any pending leases for deletion will be restored
 			// by the expiration manager.
-			if err := ts.revokeSalted(ctx, id, true); err != nil {
+			if err := ts.revokeSalted(ctx, id, !orphanChildren); err != nil {
 
 				return errwrap.Wrapf("failed to revoke entry: {{err}}", err)
 			}
@@ -1242,7 +1537,7 @@ func (ts *TokenStore) handleCreateAgainstRole(ctx context.Context, req *logical.
 		return logical.ErrorResponse(fmt.Sprintf("unknown role %s", name)), nil
 	}
 
-	return ts.handleCreateCommon(ctx, req, d, false, roleEntry)
+	return ts.handleCreateCommon(ctx, req, d, false, false, roleEntry)
 }
 
 func (ts *TokenStore) lookupByAccessor(ctx context.Context, accessor string, tainted bool) (accessorEntry, error) {
@@ -1616,17 +1911,24 @@ func (ts *TokenStore) handleUpdateRevokeAccessor(ctx context.Context, req *logic
 // handleCreate handles the auth/token/create path for creation of new orphan
 // tokens
 func (ts *TokenStore) handleCreateOrphan(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	return ts.handleCreateCommon(ctx, req, d, true, nil)
+	return ts.handleCreateCommon(ctx, req, d, true, false, nil)
 }
 
 // handleCreate handles the auth/token/create path for creation of new non-orphan
 // tokens
 func (ts *TokenStore) handleCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	return ts.handleCreateCommon(ctx, req, d, false, nil)
+	return ts.handleCreateCommon(ctx, req, d, false, false, nil)
+}
+
+// handleCreateRestricted handles the auth/token/create-restricted path for
+// creation of tokens restricted to a subset of the policies of their parent
+// and to an inline policy
+func (ts *TokenStore) handleCreateRestricted(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return ts.handleCreateCommon(ctx, req, d, false, true, nil)
 }
 
 // handleCreateCommon handles the auth/token/create path for creation of new tokens
-func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Request, d *framework.FieldData, orphan bool, role *tsRoleEntry) (*logical.Response, error) {
+func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Request, d *framework.FieldData, orphan, restricted bool, role *tsRoleEntry) (*logical.Response, error) {
 	// Read the parent policy
 	parent, err := ts.Lookup(ctx, req.ClientToken)
 	if err != nil {
@@ -1636,6 +1938,11 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		return logical.ErrorResponse("parent token lookup failed: no parent found"), logical.ErrInvalidRequest
 	}
 
+	// Batch tokens can't be revoked, so they can't have child tokens
+	if parent.Type == logical.TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot create more tokens"), logical.ErrInvalidRequest
+	}
+
 	// A token with a restricted number of uses cannot create a new token
 	// otherwise it could escape the restriction count.
 	if parent.NumUses > 0 {
@@ -1643,28 +1950,44 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 			logical.ErrInvalidRequest
 	}
 
+	// Tokens are created in the namespace of the token store they are
+	// requested from. Only root tokens may create tokens in a namespace other
+	// than their own.
+	ns := rootNamespace
+	if ts.namespaceLookupFunc != nil {
+		ns = ts.namespaceLookupFunc(req.MountPoint)
+	}
+	if parent.NamespaceID != ns.ID && !strutil.StrListContains(parent.Policies, "root") {
+		return logical.ErrorResponse("tokens may only be created within the namespace of the parent token"), logical.ErrInvalidRequest
+	}
+
 	// Check if the client token has sudo/root privileges for the requested path
 	isSudo := ts.System().SudoPrivilege(ctx, req.MountPoint+req.Path, req.ClientToken)
 
 	// Read and parse the fields
 	var data struct {
-		ID              string
-		Policies        []string
-		Metadata        map[string]string `mapstructure:"meta"`
-		NoParent        bool              `mapstructure:"no_parent"`
-		NoDefaultPolicy bool              `mapstructure:"no_default_policy"`
-		Lease           string
-		TTL             string
-		Renewable       *bool
-		ExplicitMaxTTL  string `mapstructure:"explicit_max_ttl"`
-		DisplayName     string `mapstructure:"display_name"`
-		NumUses         int    `mapstructure:"num_uses"`
-		Period          string
+		ID                     string
+		Policies               []string
+		Metadata               map[string]string `mapstructure:"meta"`
+		NoParent               bool              `mapstructure:"no_parent"`
+		NoDefaultPolicy        bool              `mapstructure:"no_default_policy"`
+		OrphanChildrenOnRevoke bool              `mapstructure:"orphan_children_on_revoke"`
+		Lease                  string
+		TTL                    string
+		Renewable              *bool
+		ExplicitMaxTTL         string `mapstructure:"explicit_max_ttl"`
+		DisplayName            string `mapstructure:"display_name"`
+		NumUses                int    `mapstructure:"num_uses"`
+		Period                 string
+		InlinePolicy           string `mapstructure:"inline_policy"`
 	}
 	if err := mapstructure.WeakDecode(req.Data, &data); err != nil {
 		return logical.ErrorResponse(fmt.Sprintf(
 			"Error decoding request: %s", err)), logical.ErrInvalidRequest
 	}
+	if data.InlinePolicy != "" && !restricted {
+		return logical.ErrorResponse("inline policies can only be given to tokens created with create-restricted"), logical.ErrInvalidRequest
+	}
 
 	// Verify the number of uses is positive
 	if data.NumUses < 0 {
@@ -1685,6 +2008,7 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		DisplayName:  "token",
 		NumUses:      data.NumUses,
 		CreationTime: time.Now().Unix(),
+		NamespaceID:  ns.ID,
 	}
 
 	renewable := true
@@ -1805,9 +2129,10 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 
 	// When a role is not in use or does not specify allowed/disallowed, only
 	// permit policies to be a subset unless the client has root or sudo
-	// privileges. Default is added in this case if the parent has it, unless
-	// the client specified for it not to be added.
-	case !isSudo:
+	// privileges. Sudo privileges are ignored for restricted tokens, only a
+	// root client being allowed any policies. Default is added in this case if
+	// the parent has it, unless the client specified for it not to be added.
+	case !isSudo || (restricted && !strutil.StrListContains(parent.Policies, "root")):
 		// Sanitize passed-in and parent policies before comparison
 		sanitizedInputPolicies := policyutil.SanitizePolicies(data.Policies, policyutil.DoNotAddDefaultPolicy)
 		sanitizedParentPolicies := policyutil.SanitizePolicies(parent.Policies, policyutil.DoNotAddDefaultPolicy)
@@ -1849,6 +2174,29 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		return logical.ErrorResponse("root tokens may not be created without parent token being root"), logical.ErrInvalidRequest
 	}
 
+	// There is no root policy within a namespace
+	if ns.ID != "" && strutil.StrListContains(te.Policies, "root") {
+		return logical.ErrorResponse("root tokens may not be created within a namespace"), logical.ErrInvalidRequest
+	}
+
+	// The inline policies of the parent are inherited whichever way the token
+	// is created, so that restricted tokens cannot create less restricted
+	// ones
+	if len(parent.InlinePolicies) > 0 {
+		te.InlinePolicies = append([]string{}, parent.InlinePolicies...)
+	}
+	if restricted {
+		if strutil.StrListContains(te.Policies, "root") {
+			return logical.ErrorResponse("restricted tokens may not have the root policy"), logical.ErrInvalidRequest
+		}
+		if data.InlinePolicy != "" {
+			if _, err := parseInlinePolicy(data.InlinePolicy); err != nil {
+				return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+			}
+			te.InlinePolicies = append(te.InlinePolicies, data.InlinePolicy)
+		}
+	}
+
 	//
 	// NOTE: Do not modify policies below this line. We need the checks above
 	// to be the last checks as they must look at the final policy set.
@@ -1880,6 +2228,21 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		}
 	}
 
+	// Children outliving the token is as much of an escape from the
+	// revocation tree as an orphan token, so it takes the same privileges,
+	// unless a role grants it
+	switch {
+	case role != nil && role.OrphanChildrenOnRevoke:
+		te.OrphanChildrenOnRevoke = true
+
+	case data.OrphanChildrenOnRevoke:
+		if !isSudo {
+			return logical.ErrorResponse("root or sudo privileges required to create a token orphaning its children on revocation"),
+				logical.ErrInvalidRequest
+		}
+		te.OrphanChildrenOnRevoke = true
+	}
+
 	// At this point, it is clear whether the token is going to be an orphan or
 	// not. If the token is not going to be an orphan, inherit the parent's
 	// entity identifier into the child token.
@@ -1988,6 +2351,17 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		te.TTL = explicitMaxTTLToUse
 	}
 
+	// Root tokens may never outlive the configured root token max TTL
+	if ts.rootTokenMaxTTL > 0 && strutil.StrListContains(te.Policies, "root") {
+		if te.TTL == 0 || te.TTL > ts.rootTokenMaxTTL {
+			te.TTL = ts.rootTokenMaxTTL
+		}
+		if explicitMaxTTLToUse == 0 || explicitMaxTTLToUse > ts.rootTokenMaxTTL {
+			explicitMaxTTLToUse = ts.rootTokenMaxTTL
+		}
+		te.ExplicitMaxTTL = explicitMaxTTLToUse
+	}
+
 	// Don't advertise non-expiring root tokens as renewable, as attempts to
 	// renew them are denied. Don't CIDR-restrict these either.
 	if te.TTL == 0 {
@@ -2002,6 +2376,9 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 	if err := ts.create(ctx, &te); err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
 	}
+	if strutil.StrListContains(te.Policies, "root") {
+		ts.rootTokenCreated(&te)
+	}
 
 	// Generate the response
 	resp.Auth = &logical.Auth{
@@ -2023,7 +2400,7 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 
 	if ts.policyLookupFunc != nil {
 		for _, p := range te.Policies {
-			policy, err := ts.policyLookupFunc(p)
+			policy, err := ts.policyLookupFunc(ns, p)
 			if err != nil {
 				return logical.ErrorResponse(fmt.Sprintf("could not look up policy %s", p)), nil
 			}
@@ -2048,6 +2425,9 @@ func (ts *TokenStore) handleRevokeSelf(ctx context.Context, req *logical.Request
 	if te == nil {
 		return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
 	}
+	if te.Type == logical.TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot be revoked"), logical.ErrInvalidRequest
+	}
 
 	leaseID, err := ts.expiration.CreateOrFetchRevocationLeaseByToken(te)
 	if err != nil {
@@ -2084,6 +2464,9 @@ func (ts *TokenStore) handleRevokeTree(ctx context.Context, req *logical.Request
 	if te == nil {
 		return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
 	}
+	if te.Type == logical.TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot be revoked"), logical.ErrInvalidRequest
+	}
 
 	leaseID, err := ts.expiration.CreateOrFetchRevocationLeaseByToken(te)
 	if err != nil {
@@ -2172,18 +2555,27 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		return logical.ErrorResponse("missing token ID"), logical.ErrInvalidRequest
 	}
 
-	lock := locksutil.LockForKey(ts.tokenLocks, id)
-	lock.RLock()
-	defer lock.RUnlock()
-
 	// Lookup the token
-	saltedID, err := ts.SaltID(ctx, id)
-	if err != nil {
-		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
-	}
-	out, err := ts.lookupSalted(ctx, saltedID, true)
-	if err != nil {
-		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	var out *logical.TokenEntry
+	if strings.HasPrefix(id, batchTokenPrefix) {
+		var err error
+		out, err = ts.lookupBatchToken(ctx, id)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	} else {
+		lock := locksutil.LockForKey(ts.tokenLocks, id)
+		lock.RLock()
+		defer lock.RUnlock()
+
+		saltedID, err := ts.SaltID(ctx, id)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		out, err = ts.lookupSalted(ctx, saltedID, true)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
 	}
 
 	if out == nil {
@@ -2215,6 +2607,10 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		resp.Data["orphan"] = true
 	}
 
+	if out.OrphanChildrenOnRevoke {
+		resp.Data["orphan_children_on_revoke"] = true
+	}
+
 	if out.Role != "" {
 		resp.Data["role"] = out.Role
 	}
@@ -2227,6 +2623,20 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		resp.Data["bound_cidrs"] = out.BoundCIDRs
 	}
 
+	if len(out.InlinePolicies) > 0 {
+		resp.Data["inline_policies"] = out.InlinePolicies
+	}
+
+	// Batch tokens have no lease; they expire at the end of their TTL
+	if out.Type == logical.TokenTypeBatch {
+		expireTime := time.Unix(out.CreationTime, 0).Add(out.TTL)
+		resp.Data["type"] = out.Type.String()
+		resp.Data["expire_time"] = expireTime
+		resp.Data["ttl"] = int64(time.Until(expireTime).Seconds())
+		resp.Data["renewable"] = false
+		resp.Data["issue_time"] = time.Unix(out.CreationTime, 0)
+	}
+
 	// Fetch the last renewal time
 	leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(out.Path, out.ID)
 	if err != nil {
@@ -2246,7 +2656,8 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		resp.Data["issue_time"] = leaseTimes.IssueTime
 	}
 
-	if out.EntityID != "" {
+	// Identity policies do not apply to tokens of other namespaces
+	if out.EntityID != "" && out.NamespaceID == "" {
 		_, identityPolicies, err := ts.identityPoliciesDeriverFunc(out.EntityID)
 		if err != nil {
 			return nil, err
@@ -2295,6 +2706,9 @@ func (ts *TokenStore) handleRenew(ctx context.Context, req *logical.Request, dat
 	if te == nil {
 		return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
 	}
+	if te.Type == logical.TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot be renewed"), logical.ErrInvalidRequest
+	}
 
 	// Renew the token and its children
 	resp, err := ts.expiration.RenewToken(req, te.Path, te.ID, increment)
@@ -2343,6 +2757,12 @@ func (ts *TokenStore) authRenew(ctx context.Context, req *logical.Request, d *fr
 
 	req.Auth.Period = role.Period
 	req.Auth.ExplicitMaxTTL = role.ExplicitMaxTTL
+
+	// Root tokens keep the cap they were given at creation regardless of role
+	if strutil.StrListContains(te.Policies, "root") && te.ExplicitMaxTTL > 0 &&
+		(req.Auth.ExplicitMaxTTL == 0 || te.ExplicitMaxTTL < req.Auth.ExplicitMaxTTL) {
+		req.Auth.ExplicitMaxTTL = te.ExplicitMaxTTL
+	}
 	return &logical.Response{Auth: req.Auth}, nil
 }
 
@@ -2412,6 +2832,10 @@ func (ts *TokenStore) tokenStoreRoleRead(ctx context.Context, req *logical.Reque
 		resp.Data["bound_cidrs"] = role.BoundCIDRs
 	}
 
+	if role.OrphanChildrenOnRevoke {
+		resp.Data["orphan_children_on_revoke"] = true
+	}
+
 	return resp, nil
 }
 
@@ -2457,6 +2881,13 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 		entry.Orphan = data.Get("orphan").(bool)
 	}
 
+	orphanChildrenInt, ok := data.GetOk("orphan_children_on_revoke")
+	if ok {
+		entry.OrphanChildrenOnRevoke = orphanChildrenInt.(bool)
+	} else if req.Operation == logical.CreateOperation {
+		entry.OrphanChildrenOnRevoke = data.Get("orphan_children_on_revoke").(bool)
+	}
+
 	periodInt, ok := data.GetOk("period")
 	if ok {
 		entry.Period = time.Second * time.Duration(periodInt.(int))
@@ -2590,6 +3021,9 @@ policy names.`
 no policies in the given list are requested. The parameter is a comma-delimited string of policy names.`
 	tokenOrphanHelp = `If true, tokens created via this role
 will be orphan tokens (have no parent)`
+	tokenOrphanChildrenOnRevokeHelp = `If true, the children of tokens
+created via this role are orphaned rather than revoked
+when the tokens are revoked or expire`
 	tokenPeriodHelp = `If set, tokens created via this role
 will have no max lifetime; instead, their
 renewal period will be fixed to this value.
@@ -2616,4 +3050,13 @@ or revoke them. Because this can be used to
 cause a denial of service, this endpoint
 requires 'sudo' capability in addition to
 'list'.`
+	tokenCreateRestrictedHelp     = `This token create path is used to create new tokens restricted to an inline policy.`
+	tokenCreateRestrictedHelpDesc = `
+This token create path is used to create new tokens whose
+policies must be a subset of the calling token's policies,
+even when it has root or sudo privileges. The rules given
+in "inline_policy" further restrict the token: it is only
+allowed what both its policies and the inline policy allow.
+The inline policies are inherited by the children of the
+token, however they are created.`
 )
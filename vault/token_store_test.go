@@ -599,6 +599,51 @@ func TestTokenStore_RootToken(t *testing.T) {
 	}
 }
 
+func TestTokenStore_RootTokenMaxTTL(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ts.rootTokenMaxTTL = time.Hour
+
+	te, err := ts.rootToken(context.Background())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if te.TTL != time.Hour || te.ExplicitMaxTTL != time.Hour {
+		t.Fatalf("bad: %#v", te)
+	}
+	leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(te.Path, te.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaseTimes == nil || leaseTimes.ExpireTime.IsZero() {
+		t.Fatalf("expected root token to be tracked for expiration: %#v", leaseTimes)
+	}
+
+	// Tokens created through the store are capped regardless of the TTL asked for
+	for _, ttl := range []string{"", "2h"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, "create")
+		req.ClientToken = root
+		req.Data["policies"] = []string{"root"}
+		if ttl != "" {
+			req.Data["ttl"] = ttl
+		}
+		resp := testMakeTokenViaRequest(t, ts, req)
+		if resp.Auth.TTL != time.Hour || resp.Auth.ExplicitMaxTTL != time.Hour {
+			t.Fatalf("ttl %q: bad: %#v", ttl, resp.Auth)
+		}
+	}
+
+	// Shorter TTLs are left alone
+	req := logical.TestRequest(t, logical.UpdateOperation, "create")
+	req.ClientToken = root
+	req.Data["policies"] = []string{"root"}
+	req.Data["ttl"] = "30m"
+	resp := testMakeTokenViaRequest(t, ts, req)
+	if resp.Auth.TTL != 30*time.Minute || resp.Auth.ExplicitMaxTTL != time.Hour {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+}
+
 func TestTokenStore_CreateLookup(t *testing.T) {
 	c, _, _ := TestCoreUnsealed(t)
 	ts := c.tokenStore
@@ -638,6 +683,138 @@ func TestTokenStore_CreateLookup(t *testing.T) {
 	}
 }
 
+func TestTokenStore_BatchToken(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	ent := &logical.TokenEntry{
+		Path:         "auth/token/create",
+		Policies:     []string{"dev", "ops"},
+		CreationTime: time.Now().Unix(),
+		TTL:          time.Hour,
+		Type:         logical.TokenTypeBatch,
+	}
+	if err := ts.create(context.Background(), ent); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(ent.ID, batchTokenPrefix) || ent.Accessor != "" {
+		t.Fatalf("bad: %#v", ent)
+	}
+
+	out, err := ts.Lookup(context.Background(), ent.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(out, ent) {
+		t.Fatalf("bad: expected:%#v\nactual:%#v", ent, out)
+	}
+
+	// Nothing is stored for batch tokens
+	keys, err := ts.view.List(context.Background(), lookupPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("expected only the root token to be stored, got %v", keys)
+	}
+
+	// A tampered token is not found
+	middle := len(ent.ID) / 2
+	replacement := "A"
+	if ent.ID[middle] == 'A' {
+		replacement = "B"
+	}
+	tampered := ent.ID[:middle] + replacement + ent.ID[middle+1:]
+	out, err = ts.Lookup(context.Background(), tampered)
+	if err != nil || out != nil {
+		t.Fatalf("bad: %v %#v", err, out)
+	}
+
+	// Neither is an expired one
+	expired := &logical.TokenEntry{
+		Path:         "test",
+		Policies:     []string{"dev"},
+		CreationTime: time.Now().Add(-2 * time.Hour).Unix(),
+		TTL:          time.Hour,
+		Type:         logical.TokenTypeBatch,
+	}
+	if err := ts.create(context.Background(), expired); err != nil {
+		t.Fatal(err)
+	}
+	out, err = ts.Lookup(context.Background(), expired.ID)
+	if err != nil || out != nil {
+		t.Fatalf("bad: %v %#v", err, out)
+	}
+
+	// Nor is one whose parent is revoked
+	parent := &logical.TokenEntry{
+		Path:     "auth/token/create",
+		Policies: []string{"dev"},
+		TTL:      time.Hour,
+	}
+	testMakeTokenDirectly(t, ts, parent)
+	child := &logical.TokenEntry{
+		Parent:       parent.ID,
+		Path:         "auth/token/create",
+		Policies:     []string{"dev"},
+		CreationTime: time.Now().Unix(),
+		TTL:          time.Hour,
+		Type:         logical.TokenTypeBatch,
+	}
+	if err := ts.create(context.Background(), child); err != nil {
+		t.Fatal(err)
+	}
+	out, err = ts.Lookup(context.Background(), child.ID)
+	if err != nil || out == nil {
+		t.Fatalf("bad: %v %#v", err, out)
+	}
+	if err := ts.revokeOrphan(context.Background(), parent.ID); err != nil {
+		t.Fatal(err)
+	}
+	out, err = ts.Lookup(context.Background(), child.ID)
+	if err != nil || out != nil {
+		t.Fatalf("bad: %v %#v", err, out)
+	}
+
+	// Neither is one whose auth method is disabled
+	unmounted := &logical.TokenEntry{
+		Path:         "auth/nope/login/test",
+		Policies:     []string{"dev"},
+		CreationTime: time.Now().Unix(),
+		TTL:          time.Hour,
+		Type:         logical.TokenTypeBatch,
+	}
+	if err := ts.create(context.Background(), unmounted); err != nil {
+		t.Fatal(err)
+	}
+	out, err = ts.Lookup(context.Background(), unmounted.ID)
+	if err != nil || out != nil {
+		t.Fatalf("bad: %v %#v", err, out)
+	}
+
+	// Batch tokens can't be revoked or create child tokens
+	if err := ts.revokeOrphan(context.Background(), ent.ID); err == nil {
+		t.Fatal("expected an error revoking a batch token")
+	}
+	req := logical.TestRequest(t, logical.UpdateOperation, "create")
+	req.ClientToken = ent.ID
+	resp, err := ts.HandleRequest(context.Background(), req)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected an error creating a child token: %v %#v", err, resp)
+	}
+
+	for _, bad := range []*logical.TokenEntry{
+		{Type: logical.TokenTypeBatch},
+		{Type: logical.TokenTypeBatch, TTL: time.Hour, NumUses: 1},
+		{Type: logical.TokenTypeBatch, TTL: time.Hour, Period: time.Hour},
+		{Type: logical.TokenTypeBatch, TTL: time.Hour, ID: "foo"},
+	} {
+		if err := ts.create(context.Background(), bad); err == nil {
+			t.Fatalf("expected an error creating %#v", bad)
+		}
+	}
+}
+
 func TestTokenStore_CreateLookup_ProvidedID(t *testing.T) {
 	c, _, _ := TestCoreUnsealed(t)
 	ts := c.tokenStore
@@ -991,6 +1168,114 @@ func testTokenStore_RevokeTree_NonRecursive(t testing.TB, depth uint64) {
 	}
 }
 
+func TestTokenStore_RevokeTree_OrphanChildrenOnRevoke(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	testMakeTokenViaBackend(t, ts, root, "parent", "", []string{"foo"})
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "create")
+	req.ClientToken = "parent"
+	req.Data["orphan_children_on_revoke"] = true
+	resp, err := ts.HandleRequest(context.Background(), req)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected a non-sudo token to be refused: %v %#v", err, resp)
+	}
+
+	// The CI token orphans its children, whether it is revoked itself or
+	// along with its parent
+	req = logical.TestRequest(t, logical.UpdateOperation, "create")
+	req.ClientToken = root
+	req.Data["id"] = "ci"
+	req.Data["policies"] = []string{"foo"}
+	req.Data["orphan_children_on_revoke"] = true
+	testMakeTokenViaRequest(t, ts, req)
+	testMakeTokenDirectly(t, ts, &logical.TokenEntry{
+		ID:                     "nested-ci",
+		Parent:                 "parent",
+		TTL:                    time.Hour,
+		OrphanChildrenOnRevoke: true,
+	})
+	// Parents are created before their children
+	for _, token := range []struct {
+		id, parent string
+	}{
+		{"workload", "ci"},
+		{"job", "workload"},
+		{"nested-workload", "nested-ci"},
+		{"sibling", "parent"},
+	} {
+		testMakeTokenDirectly(t, ts, &logical.TokenEntry{
+			ID:     token.id,
+			Parent: token.parent,
+			TTL:    time.Hour,
+		})
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "lookup")
+	req.ClientToken = root
+	req.Data["token"] = "ci"
+	resp, err = ts.HandleRequest(context.Background(), req)
+	if err != nil || resp == nil || resp.Data["orphan_children_on_revoke"] != true {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+
+	for _, id := range []string{"ci", "parent"} {
+		if err := ts.revokeTree(context.Background(), id); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	for _, id := range []string{"ci", "parent", "nested-ci", "sibling"} {
+		out, err := ts.Lookup(context.Background(), id)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out != nil {
+			t.Fatalf("expected %q to be revoked: %#v", id, out)
+		}
+	}
+
+	for _, id := range []string{"workload", "nested-workload"} {
+		out, err := ts.Lookup(context.Background(), id)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out == nil || out.Parent != "" {
+			t.Fatalf("expected %q to be orphaned: %#v", id, out)
+		}
+	}
+
+	// The grandchildren keep their parent
+	out, err := ts.Lookup(context.Background(), "job")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Parent != "workload" {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// A role grants the flag to the tokens created through it
+	req = logical.TestRequest(t, logical.UpdateOperation, "roles/ci")
+	req.ClientToken = root
+	req.Data["orphan_children_on_revoke"] = true
+	resp, err = ts.HandleRequest(context.Background(), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "create/ci")
+	req.ClientToken = "workload"
+	resp = testMakeTokenViaRequest(t, ts, req)
+	out, err = ts.Lookup(context.Background(), resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || !out.OrphanChildrenOnRevoke {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 // A benchmark function that tests testTokenStore_RevokeTree_NonRecursive
 // for a variety of different depths.
 func BenchmarkTokenStore_RevokeTree(b *testing.B) {
@@ -1390,6 +1675,122 @@ func TestTokenStore_HandleRequest_CreateToken_NonRoot_RootChild(t *testing.T) {
 	}
 }
 
+func TestTokenStore_HandleRequest_CreateRestricted(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	policy, _ := ParseACLPolicy(`
+path "secret/*" {
+	capabilities = ["create", "read", "update", "delete", "list", "sudo"]
+}`)
+	policy.Name = "apps"
+	if err := c.policyStore.SetPolicy(context.Background(), policy); err != nil {
+		t.Fatal(err)
+	}
+	testMakeTokenViaBackend(t, ts, root, "client", "", []string{"apps", "other"})
+
+	createReq := func(path, client string, data map[string]interface{}) *logical.Request {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = client
+		req.MountPoint = "auth/token/"
+		req.Data = data
+		return req
+	}
+	create := func(path, client string, data map[string]interface{}) string {
+		t.Helper()
+		return testMakeTokenViaRequest(t, ts, createReq(path, client, data)).Auth.ClientToken
+	}
+	capabilities := func(token, path string) []string {
+		t.Helper()
+		capabilities, err := c.Capabilities(context.Background(), token, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return capabilities
+	}
+	restrictedPolicy := `
+path "secret/app1/*" {
+	capabilities = ["read", "list", "sudo"]
+}`
+
+	errCases := map[string]struct {
+		path   string
+		client string
+		data   map[string]interface{}
+	}{
+		"inline policy on create":   {"create", "client", map[string]interface{}{"inline_policy": restrictedPolicy}},
+		"policies beyond parent's":  {"create-restricted", "client", map[string]interface{}{"policies": "apps,foo"}},
+		"invalid inline policy":     {"create-restricted", "client", map[string]interface{}{"inline_policy": "path {"}},
+		"root policy":               {"create-restricted", root, map[string]interface{}{"inline_policy": restrictedPolicy}},
+		"root policy of root token": {"create-restricted", root, map[string]interface{}{"policies": "root"}},
+	}
+	for name, tc := range errCases {
+		resp, err := ts.HandleRequest(context.Background(), createReq(tc.path, tc.client, tc.data))
+		if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+			t.Fatalf("%s: expected an error, got err: %v resp: %#v", name, err, resp)
+		}
+	}
+
+	child := create("create-restricted", "client", map[string]interface{}{
+		"policies":      "apps",
+		"inline_policy": restrictedPolicy,
+	})
+
+	// The token is only allowed what both its policies and its inline policy
+	// allow
+	if actual := capabilities(child, "secret/app1/foo"); !reflect.DeepEqual(actual, []string{"list", "read", "sudo"}) {
+		t.Fatalf("bad capabilities: %#v", actual)
+	}
+	if actual := capabilities(child, "secret/app2/foo"); !reflect.DeepEqual(actual, []string{"deny"}) {
+		t.Fatalf("bad capabilities: %#v", actual)
+	}
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/app1/foo")
+	req.ClientToken = child
+	acl, _, _, _, err := c.fetchACLTokenEntryAndEntity(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acl.AllowOperation(req).Allowed {
+		t.Fatal("expected the update to be denied")
+	}
+	req.Operation = logical.ReadOperation
+	if results := acl.AllowOperation(req); !results.Allowed || !results.RootPrivs {
+		t.Fatalf("bad results: %#v", results)
+	}
+	if !ts.System().SudoPrivilege(context.Background(), "secret/app1/foo", child) || ts.System().SudoPrivilege(context.Background(), "secret/app2/foo", child) {
+		t.Fatal("bad sudo privileges")
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "lookup")
+	req.Data["token"] = child
+	resp, err := ts.HandleRequest(context.Background(), req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Data["inline_policies"], []string{restrictedPolicy}) {
+		t.Fatalf("bad inline policies: %#v", resp.Data["inline_policies"])
+	}
+
+	// The children of the token inherit its inline policy, whichever way they
+	// are created, and further inline policies restrict them further
+	if actual := capabilities(create("create", child, map[string]interface{}{}), "secret/app2/foo"); !reflect.DeepEqual(actual, []string{"deny"}) {
+		t.Fatalf("bad capabilities: %#v", actual)
+	}
+
+	grandchild, err := ts.Lookup(context.Background(), create("create-restricted", child, map[string]interface{}{
+		"inline_policy": `path "secret/*" { capabilities = ["read", "update"] }`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grandchild.InlinePolicies) != 2 {
+		t.Fatalf("bad inline policies: %#v", grandchild.InlinePolicies)
+	}
+	if actual := capabilities(grandchild.ID, "secret/app1/foo"); !reflect.DeepEqual(actual, []string{"read"}) {
+		t.Fatalf("bad capabilities: %#v", actual)
+	}
+}
+
 func TestTokenStore_HandleRequest_CreateToken_Root_RootChild_NoExpiry_Expiry(t *testing.T) {
 	c, _, root := TestCoreUnsealed(t)
 	ts := c.tokenStore
@@ -0,0 +1,116 @@
+package vault
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/version"
+)
+
+const (
+	// versionHistorySubPath is the sub-path of the system view the versions
+	// the cluster has run are stored under, keyed by version
+	versionHistorySubPath = "versions/"
+)
+
+// versionEntry records when the cluster first ran a version of Vault
+type versionEntry struct {
+	Version            string    `json:"version"`
+	Revision           string    `json:"revision,omitempty"`
+	PreviousVersion    string    `json:"previous_version,omitempty"`
+	TimestampInstalled time.Time `json:"timestamp_installed"`
+}
+
+// versionHistory returns the versions the cluster has run, in the order they
+// were first run
+func (c *Core) versionHistory(ctx context.Context) ([]*versionEntry, error) {
+	view := c.systemBarrierView.SubView(versionHistorySubPath)
+	keys, err := view.List(ctx, "")
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list versions: {{err}}", err)
+	}
+
+	history := make([]*versionEntry, 0, len(keys))
+	for _, key := range keys {
+		raw, err := view.Get(ctx, key)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to read version: {{err}}", err)
+		}
+		if raw == nil {
+			continue
+		}
+		var entry versionEntry
+		if err := raw.DecodeJSON(&entry); err != nil {
+			return nil, errwrap.Wrapf("failed to decode version: {{err}}", err)
+		}
+		history = append(history, &entry)
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].TimestampInstalled.Before(history[j].TimestampInstalled)
+	})
+	return history, nil
+}
+
+// storeVersion records the version of Vault the active node runs, unless the
+// cluster has run it before
+func (c *Core) storeVersion(ctx context.Context) error {
+	info := version.GetVersion()
+	current := info.VersionNumber()
+
+	history, err := c.versionHistory(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range history {
+		if entry.Version == current {
+			return nil
+		}
+	}
+
+	entry := &versionEntry{
+		Version:            current,
+		Revision:           info.Revision,
+		TimestampInstalled: time.Now().UTC(),
+	}
+	if len(history) > 0 {
+		previous := history[len(history)-1]
+		entry.PreviousVersion = previous.Version
+		c.logger.Info("running a new version of Vault", "version", current, "previous_version", previous.Version)
+	}
+
+	storageEntry, err := logical.StorageEntryJSON(current, entry)
+	if err != nil {
+		return errwrap.Wrapf("failed to encode version: {{err}}", err)
+	}
+	view := c.systemBarrierView.SubView(versionHistorySubPath)
+	if err := view.Put(ctx, storageEntry); err != nil {
+		return errwrap.Wrapf("failed to persist version: {{err}}", err)
+	}
+	return nil
+}
+
+// handleVersionHistoryList lists the versions of Vault the cluster has run,
+// in the order they were first run
+func (b *SystemBackend) handleVersionHistoryList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	history, err := b.Core.versionHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(history))
+	keyInfo := make(map[string]interface{}, len(history))
+	for _, entry := range history {
+		keys = append(keys, entry.Version)
+		keyInfo[entry.Version] = map[string]interface{}{
+			"revision":            entry.Revision,
+			"previous_version":    entry.PreviousVersion,
+			"timestamp_installed": entry.TimestampInstalled.Format(time.RFC3339),
+		}
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/version"
+)
+
+func TestVersionHistory(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	view := c.systemBarrierView.SubView(versionHistorySubPath)
+	current := version.GetVersion().VersionNumber()
+
+	// Unsealing recorded the version, and records it once
+	if err := c.storeVersion(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	history, err := c.versionHistory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Version != current || history[0].PreviousVersion != "" {
+		t.Fatalf("bad: %#v", history)
+	}
+
+	// Pretend the cluster ran an older version until now
+	if err := view.Delete(context.Background(), current); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := logical.StorageEntryJSON("0.1.0", &versionEntry{
+		Version:            "0.1.0",
+		TimestampInstalled: time.Now().Add(-24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := view.Put(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.storeVersion(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := logical.TestRequest(t, logical.ListOperation, "sys/version-history")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	keys := resp.Data["keys"].([]string)
+	if len(keys) != 2 || keys[0] != "0.1.0" || keys[1] != current {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	info := resp.Data["key_info"].(map[string]interface{})[current].(map[string]interface{})
+	if info["previous_version"] != "0.1.0" || info["timestamp_installed"] == "" {
+		t.Fatalf("bad: %#v", info)
+	}
+}